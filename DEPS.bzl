@@ -277,6 +277,19 @@ def go_deps():
             "https://storage.googleapis.com/pingcapmirror/gomod/github.com/aliyun/alibaba-cloud-sdk-go/com_github_aliyun_alibaba_cloud_sdk_go-v1.61.1581.zip",
         ],
     )
+    go_repository(
+        name = "com_github_aliyun_aliyun_oss_go_sdk",
+        build_file_proto_mode = "disable_global",
+        importpath = "github.com/aliyun/aliyun-oss-go-sdk",
+        sha256 = "d9fdda3fde5885381761bb164c7004e4e1d40de5a6da603cc597f029bd7e868c",
+        strip_prefix = "github.com/aliyun/aliyun-oss-go-sdk@v3.0.2+incompatible",
+        urls = [
+            "http://bazel-cache.pingcap.net:8080/gomod/github.com/aliyun/aliyun-oss-go-sdk/com_github_aliyun_aliyun_oss_go_sdk-v3.0.2+incompatible.zip",
+            "http://ats.apps.svc/gomod/github.com/aliyun/aliyun-oss-go-sdk/com_github_aliyun_aliyun_oss_go_sdk-v3.0.2+incompatible.zip",
+            "https://cache.hawkingrei.com/gomod/github.com/aliyun/aliyun-oss-go-sdk/com_github_aliyun_aliyun_oss_go_sdk-v3.0.2+incompatible.zip",
+            "https://storage.googleapis.com/pingcapmirror/gomod/github.com/aliyun/aliyun-oss-go-sdk/com_github_aliyun_aliyun_oss_go_sdk-v3.0.2+incompatible.zip",
+        ],
+    )
     go_repository(
         name = "com_github_andybalholm_brotli",
         build_file_proto_mode = "disable_global",
@@ -1031,6 +1044,19 @@ def go_deps():
             "https://storage.googleapis.com/pingcapmirror/gomod/github.com/ckaznocha/intrange/com_github_ckaznocha_intrange-v0.3.0.zip",
         ],
     )
+    go_repository(
+        name = "com_github_clbanning_mxj",
+        build_file_proto_mode = "disable_global",
+        importpath = "github.com/clbanning/mxj",
+        sha256 = "8947cf617bdd9efc62817c8ddb17bafe497f35abdf10a3c60f295e387f633f70",
+        strip_prefix = "github.com/clbanning/mxj@v1.8.4",
+        urls = [
+            "http://bazel-cache.pingcap.net:8080/gomod/github.com/clbanning/mxj/com_github_clbanning_mxj-v1.8.4.zip",
+            "http://ats.apps.svc/gomod/github.com/clbanning/mxj/com_github_clbanning_mxj-v1.8.4.zip",
+            "https://cache.hawkingrei.com/gomod/github.com/clbanning/mxj/com_github_clbanning_mxj-v1.8.4.zip",
+            "https://storage.googleapis.com/pingcapmirror/gomod/github.com/clbanning/mxj/com_github_clbanning_mxj-v1.8.4.zip",
+        ],
+    )
     go_repository(
         name = "com_github_client9_misspell",
         build_file_proto_mode = "disable_global",
@@ -5304,6 +5330,19 @@ def go_deps():
             "https://storage.googleapis.com/pingcapmirror/gomod/github.com/morikuni/aec/com_github_morikuni_aec-v1.0.0.zip",
         ],
     )
+    go_repository(
+        name = "com_github_mozillazg_go_httpheader",
+        build_file_proto_mode = "disable_global",
+        importpath = "github.com/mozillazg/go-httpheader",
+        sha256 = "50b7a36360fc1ec1a85fd40fe45f8db02fc734fc2af0514a60a068f0a2708122",
+        strip_prefix = "github.com/mozillazg/go-httpheader@v0.2.1",
+        urls = [
+            "http://bazel-cache.pingcap.net:8080/gomod/github.com/mozillazg/go-httpheader/com_github_mozillazg_go_httpheader-v0.2.1.zip",
+            "http://ats.apps.svc/gomod/github.com/mozillazg/go-httpheader/com_github_mozillazg_go_httpheader-v0.2.1.zip",
+            "https://cache.hawkingrei.com/gomod/github.com/mozillazg/go-httpheader/com_github_mozillazg_go_httpheader-v0.2.1.zip",
+            "https://storage.googleapis.com/pingcapmirror/gomod/github.com/mozillazg/go-httpheader/com_github_mozillazg_go_httpheader-v0.2.1.zip",
+        ],
+    )
     go_repository(
         name = "com_github_munnerz_goautoneg",
         build_file_proto_mode = "disable_global",
@@ -6968,6 +7007,19 @@ def go_deps():
             "https://storage.googleapis.com/pingcapmirror/gomod/github.com/tdewolff/parse/v2/com_github_tdewolff_parse_v2-v2.6.4.zip",
         ],
     )
+    go_repository(
+        name = "com_github_tencentyun_cos_go_sdk_v5",
+        build_file_proto_mode = "disable_global",
+        importpath = "github.com/tencentyun/cos-go-sdk-v5",
+        sha256 = "bb069333c0938675da53547cfb8c44d3c4a0c8dc38d7624500ae59b3da0e1cbf",
+        strip_prefix = "github.com/tencentyun/cos-go-sdk-v5@v0.7.45",
+        urls = [
+            "http://bazel-cache.pingcap.net:8080/gomod/github.com/tencentyun/cos-go-sdk-v5/com_github_tencentyun_cos_go_sdk_v5-v0.7.45.zip",
+            "http://ats.apps.svc/gomod/github.com/tencentyun/cos-go-sdk-v5/com_github_tencentyun_cos_go_sdk_v5-v0.7.45.zip",
+            "https://cache.hawkingrei.com/gomod/github.com/tencentyun/cos-go-sdk-v5/com_github_tencentyun_cos_go_sdk_v5-v0.7.45.zip",
+            "https://storage.googleapis.com/pingcapmirror/gomod/github.com/tencentyun/cos-go-sdk-v5/com_github_tencentyun_cos_go_sdk_v5-v0.7.45.zip",
+        ],
+    )
     go_repository(
         name = "com_github_tenntenn_modver",
         build_file_proto_mode = "disable_global",
@@ -106,6 +106,92 @@ func runBackupTxnCommand(command *cobra.Command, cmdName string) error {
 	return nil
 }
 
+func runAddMasterKeyCommand(command *cobra.Command, cmdName string) error {
+	cfg := task.AddMasterKeyConfig{Config: task.Config{LogProgress: HasLogFile()}}
+	if err := cfg.ParseFromFlags(command.Flags()); err != nil {
+		command.SilenceUsage = false
+		return errors.Trace(err)
+	}
+
+	ctx := GetDefaultContext()
+	if err := task.RunAddMasterKey(ctx, tidbGlue, cmdName, &cfg); err != nil {
+		log.Error("failed to add master key", zap.Error(err))
+		return errors.Trace(err)
+	}
+	return nil
+}
+
+func runReplicateCommand(command *cobra.Command, cmdName string) error {
+	cfg := task.ReplicateConfig{Config: task.Config{LogProgress: HasLogFile()}}
+	if err := cfg.ParseFromFlags(command.Flags()); err != nil {
+		command.SilenceUsage = false
+		return errors.Trace(err)
+	}
+
+	ctx := GetDefaultContext()
+	if err := task.RunReplicate(ctx, tidbGlue, cmdName, &cfg); err != nil {
+		log.Error("failed to replicate backup", zap.Error(err))
+		return errors.Trace(err)
+	}
+	return nil
+}
+
+func runBackupPresignCommand(command *cobra.Command, cmdName string) error {
+	cfg := task.PresignConfig{Config: task.Config{LogProgress: HasLogFile()}}
+	if err := cfg.ParseFromFlags(command.Flags()); err != nil {
+		command.SilenceUsage = false
+		return errors.Trace(err)
+	}
+
+	ctx := GetDefaultContext()
+	if err := task.RunBackupPresign(ctx, tidbGlue, cmdName, &cfg); err != nil {
+		log.Error("failed to presign backup files", zap.Error(err))
+		return errors.Trace(err)
+	}
+	return nil
+}
+
+func runBackupEstimateCommand(command *cobra.Command, cmdName string) error {
+	cfg := task.BackupConfig{Config: task.Config{LogProgress: HasLogFile()}}
+	if err := cfg.ParseFromFlags(command.Flags(), false); err != nil {
+		command.SilenceUsage = false
+		return errors.Trace(err)
+	}
+
+	ctx := GetDefaultContext()
+	if err := task.RunBackupEstimate(ctx, tidbGlue, cmdName, &cfg); err != nil {
+		log.Error("failed to estimate backup", zap.Error(err))
+		return errors.Trace(err)
+	}
+	return nil
+}
+
+func runBackupScheduleCommand(command *cobra.Command, cmdName string) error {
+	cfg := task.BackupScheduleConfig{BackupConfig: task.BackupConfig{Config: task.Config{LogProgress: HasLogFile()}}}
+	if err := cfg.ParseFromFlags(command.Flags()); err != nil {
+		command.SilenceUsage = false
+		return errors.Trace(err)
+	}
+	overrideDefaultBackupConfigIfNeeded(&cfg.BackupConfig, command)
+
+	if err := metricsutil.RegisterMetricsForBR(cfg.PD, cfg.KeyspaceName); err != nil {
+		return errors.Trace(err)
+	}
+	config.UpdateGlobal(func(conf *config.Config) {
+		conf.AdvertiseAddress = config.UnavailableIP
+		conf.TiKVClient.CoprCache.CapacityMB = 0
+	})
+	gctuner.GlobalMemoryLimitTuner.DisableAdjustMemoryLimit()
+	defer gctuner.GlobalMemoryLimitTuner.EnableAdjustMemoryLimit()
+
+	ctx := GetDefaultContext()
+	if err := task.RunBackupSchedule(ctx, tidbGlue, cmdName, &cfg); err != nil {
+		log.Error("failed to run the backup schedule", zap.Error(err))
+		return errors.Trace(err)
+	}
+	return nil
+}
+
 // NewBackupCommand return a full backup subcommand.
 func NewBackupCommand() *cobra.Command {
 	command := &cobra.Command{
@@ -135,6 +221,12 @@ func NewBackupCommand() *cobra.Command {
 		newTableBackupCommand(),
 		newRawBackupCommand(),
 		newTxnBackupCommand(),
+		newAddMasterKeyCommand(),
+		newReplicateCommand(),
+		newBackupPresignCommand(),
+		newBackupEstimateCommand(),
+		newBackupScheduleCommand(),
+		newBackupListCommand(),
 	)
 
 	task.DefineBackupFlags(command.PersistentFlags())
@@ -218,6 +310,112 @@ func newTxnBackupCommand() *cobra.Command {
 	return command
 }
 
+// newAddMasterKeyCommand return a subcommand that wraps the data keys with more master keys.
+func newAddMasterKeyCommand() *cobra.Command {
+	command := &cobra.Command{
+		Use:   "add-master-key",
+		Short: "wrap the data keys of an encrypted log backup with additional master keys",
+		Args:  cobra.NoArgs,
+		RunE: func(command *cobra.Command, _ []string) error {
+			return runAddMasterKeyCommand(command, task.AddMasterKeyCmd)
+		},
+	}
+
+	task.DefineAddMasterKeyFlags(command)
+	return command
+}
+
+// newReplicateCommand return a subcommand that copies a backup to another storage.
+func newReplicateCommand() *cobra.Command {
+	command := &cobra.Command{
+		Use:   "replicate",
+		Short: "copy a backup to another storage, e.g. in another region",
+		Args:  cobra.NoArgs,
+		RunE: func(command *cobra.Command, _ []string) error {
+			return runReplicateCommand(command, task.ReplicateCmd)
+		},
+	}
+
+	task.DefineReplicateFlags(command)
+	return command
+}
+
+// newBackupPresignCommand return a subcommand that shares the files of tables by pre-signed URLs.
+func newBackupPresignCommand() *cobra.Command {
+	command := &cobra.Command{
+		Use:   "presign",
+		Short: "print the pre-signed URLs of the files needed to restore the given tables",
+		Args:  cobra.NoArgs,
+		RunE: func(command *cobra.Command, _ []string) error {
+			return runBackupPresignCommand(command, task.PresignCmd)
+		},
+	}
+
+	task.DefineBackupPresignFlags(command)
+	return command
+}
+
+// newBackupEstimateCommand return a subcommand that estimates the cost of a backup.
+func newBackupEstimateCommand() *cobra.Command {
+	command := &cobra.Command{
+		Use:   "estimate",
+		Short: "estimate the size, file count and duration of backing up the tables",
+		Args:  cobra.NoArgs,
+		RunE: func(command *cobra.Command, _ []string) error {
+			return runBackupEstimateCommand(command, task.EstimateCmd)
+		},
+	}
+
+	task.DefineFilterFlags(command, acceptAllTables, false)
+	return command
+}
+
+// newBackupScheduleCommand return a subcommand that takes the backups periodically.
+func newBackupScheduleCommand() *cobra.Command {
+	command := &cobra.Command{
+		Use:   "schedule",
+		Short: "take the full and differential backups periodically and delete the expired ones",
+		Long: "take a backup into a new directory under --storage every --interval, which is a full backup every " +
+			"--full-every backups and a differential backup since the last full backup otherwise. The backups older " +
+			"than the last --retention full backups are deleted, and the logs of --log-storage before the oldest " +
+			"kept full backup are truncated",
+		Args: cobra.NoArgs,
+		RunE: func(command *cobra.Command, _ []string) error {
+			return runBackupScheduleCommand(command, task.BackupScheduleCmd)
+		},
+	}
+
+	task.DefineFilterFlags(command, acceptAllTables, false)
+	task.DefineBackupScheduleFlags(command)
+	return command
+}
+
+// newBackupListCommand return a subcommand that lists the backups under a storage.
+func newBackupListCommand() *cobra.Command {
+	command := &cobra.Command{
+		Use:   "list",
+		Short: "list the snapshot backups and the log backups under a storage",
+		Long: "find the snapshot backups and the log backups under the storage root given by --storage, " +
+			"only the ones having all of the labels given by --label are listed",
+		Args: cobra.NoArgs,
+		RunE: func(command *cobra.Command, _ []string) error {
+			cfg := task.BackupListConfig{Config: task.Config{LogProgress: HasLogFile()}}
+			if err := cfg.ParseFromFlags(command.Flags()); err != nil {
+				command.SilenceUsage = false
+				return errors.Trace(err)
+			}
+			if err := task.RunBackupList(GetDefaultContext(), tidbGlue, task.BackupListCmd, &cfg); err != nil {
+				log.Error("failed to list the backups", zap.Error(err))
+				return errors.Trace(err)
+			}
+			return nil
+		},
+	}
+
+	task.DefineBackupListFlags(command)
+	return command
+}
+
 func overrideDefaultBackupConfigIfNeeded(config *task.BackupConfig, cmd *cobra.Command) {
 	// override only if flag not set by user
 	if !cmd.Flags().Changed(task.FlagChecksum) {
@@ -9,6 +9,7 @@ import (
 	"os"
 	"path/filepath"
 	"runtime/debug"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -26,6 +27,7 @@ import (
 	"github.com/pingcap/tidb/pkg/util/memory"
 	"github.com/pingcap/tidb/pkg/util/redact"
 	"github.com/pingcap/tidb/pkg/util/size"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/spf13/cobra"
 	"go.uber.org/zap"
 )
@@ -35,6 +37,7 @@ var (
 	defaultContext  context.Context
 	hasLogFile      uint64
 	tidbGlue        = gluetidb.New()
+	metricsPusher   *utils.MetricsPusher
 	envLogToTermKey = "BR_LOG_TO_TERM"
 
 	filterOutSysAndMemTables = []string{
@@ -70,6 +73,10 @@ const (
 	FlagLogFormat = "log-format"
 	// FlagStatusAddr is the name of status-addr flag.
 	FlagStatusAddr = "status-addr"
+	// FlagMetricsPushAddr is the name of metrics-push-addr flag.
+	FlagMetricsPushAddr = "metrics-push-addr"
+	// FlagMetricsPushInterval is the name of metrics-push-interval flag.
+	FlagMetricsPushInterval = "metrics-push-interval"
 	// FlagSlowLogFile is the name of slow-log-file flag.
 	FlagSlowLogFile = "slow-log-file"
 	// FlagRedactLog is whether to redact sensitive information in log, already deprecated by FlagRedactInfoLog
@@ -104,6 +111,12 @@ func DefineCommonFlags(cmd *cobra.Command) {
 	cmd.PersistentFlags().String(FlagStatusAddr, "",
 		"Set the HTTP listening address for the status report service. Set to empty string to disable")
 
+	cmd.PersistentFlags().String(FlagMetricsPushAddr, "",
+		"Set the address of the Prometheus push gateway to push the metrics to, the metrics are pushed periodically "+
+			"and when BR exits. Set to empty string to disable")
+	cmd.PersistentFlags().Duration(FlagMetricsPushInterval, 15*time.Second,
+		"Set the interval of pushing the metrics, set to 0 to push the metrics only when BR exits")
+
 	// defines BR task common flags, this is shared by cmd and sql(brie)
 	task.DefineCommonFlags(cmd.PersistentFlags())
 
@@ -227,7 +240,10 @@ func Init(cmd *cobra.Command) (err error) {
 			return
 		}
 		redact.InitRedact(redactLog || redactInfoLog)
-		err = startPProf(cmd)
+		if err = startPProf(cmd); err != nil {
+			return
+		}
+		err = startMetricsPusher(cmd)
 	})
 	return errors.Trace(err)
 }
@@ -255,6 +271,41 @@ func startPProf(cmd *cobra.Command) error {
 	return nil
 }
 
+func startMetricsPusher(cmd *cobra.Command) error {
+	addr, err := cmd.Flags().GetString(FlagMetricsPushAddr)
+	if err != nil || addr == "" {
+		return errors.Trace(err)
+	}
+	interval, err := cmd.Flags().GetDuration(FlagMetricsPushInterval)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+	// the labels identify the run, so the pushes of the concurrent runs don't overwrite each other. They
+	// are stable across the runs, otherwise every run leaves a new group in the push gateway.
+	labels := map[string]string{
+		// e.g. "restore-full" for `br restore full`.
+		"command":  strings.Join(strings.Fields(cmd.CommandPath())[1:], "-"),
+		"instance": hostname,
+	}
+	// the name of the log backup task.
+	if f := cmd.Flags().Lookup("task-name"); f != nil && f.Value.String() != "" {
+		labels["task"] = f.Value.String()
+	}
+	metricsPusher = utils.StartMetricsPusher(addr, interval, prometheus.DefaultGatherer, labels)
+	return nil
+}
+
+// stopMetricsPusher pushes the final metrics if the metrics are pushed.
+func stopMetricsPusher() {
+	if metricsPusher != nil {
+		metricsPusher.Stop()
+	}
+}
+
 // HasLogFile returns whether we set a log file.
 func HasLogFile() bool {
 	return atomic.LoadUint64(&hasLogFile) != uint64(0)
@@ -57,6 +57,8 @@ func NewDebugCommand() *cobra.Command {
 	meta.AddCommand(encodeBackupMetaCommand())
 	meta.AddCommand(setPDConfigCommand())
 	meta.AddCommand(searchStreamBackupCommand())
+	meta.AddCommand(searchSchemaCommand())
+	meta.AddCommand(schemaDiffCommand())
 	meta.Hidden = true
 
 	return meta
@@ -493,3 +495,49 @@ func searchStreamBackupCommand() *cobra.Command {
 
 	return searchBackupCMD
 }
+
+func searchSchemaCommand() *cobra.Command {
+	searchSchemaCMD := &cobra.Command{
+		Use:   "search-schema",
+		Short: "search the snapshot and log backup for a table or column name",
+		Long: "search the snapshot backup given by --full-backup-storage and the log backup given by --storage " +
+			"for a table or column name, and report when the table existed, its IDs over time and the data files containing it",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			cfg := task.SearchSchemaConfig{Config: task.Config{LogProgress: HasLogFile()}}
+			if err := cfg.ParseFromFlags(cmd.Flags()); err != nil {
+				return errors.Trace(err)
+			}
+			if err := task.RunSearchSchema(GetDefaultContext(), tidbGlue, task.SearchSchemaCmd, &cfg); err != nil {
+				log.Error("failed to search schema", zap.Error(err))
+				return errors.Trace(err)
+			}
+			return nil
+		},
+	}
+	task.DefineSearchSchemaFlags(searchSchemaCMD)
+	return searchSchemaCMD
+}
+
+func schemaDiffCommand() *cobra.Command {
+	schemaDiffCMD := &cobra.Command{
+		Use:   "schema-diff",
+		Short: "show the schema changes in the log backup between two ts",
+		Long: "replay the meta kv entries of the log backup given by --storage in memory, and show the tables " +
+			"created, dropped and altered between --from-ts and --to-ts, to help picking the ts to restore to",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			cfg := task.SchemaDiffConfig{Config: task.Config{LogProgress: HasLogFile()}}
+			if err := cfg.ParseFromFlags(cmd.Flags()); err != nil {
+				return errors.Trace(err)
+			}
+			if err := task.RunSchemaDiff(GetDefaultContext(), tidbGlue, task.SchemaDiffCmd, &cfg); err != nil {
+				log.Error("failed to diff schema", zap.Error(err))
+				return errors.Trace(err)
+			}
+			return nil
+		},
+	}
+	task.DefineSchemaDiffFlags(schemaDiffCMD)
+	return schemaDiffCMD
+}
@@ -0,0 +1,48 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package main
+
+import (
+	"github.com/pingcap/errors"
+	"github.com/pingcap/log"
+	"github.com/pingcap/tidb/br/pkg/task"
+	"github.com/pingcap/tidb/br/pkg/version/build"
+	"github.com/pingcap/tidb/pkg/util/logutil"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+// NewExportCommand returns an export subcommand.
+func NewExportCommand() *cobra.Command {
+	command := &cobra.Command{
+		Use:   "export",
+		Short: "export the tables as of a ts from the backup to csv or parquet files",
+		Long: "reconstruct the tables as of --as-of-ts from the snapshot backup given by --full-backup-storage " +
+			"and the log backup given by --storage, and write them to --output, no cluster is needed. " +
+			"--layout dataset writes the tables as the partitioned datasets read by Spark and Trino directly",
+		Args: cobra.NoArgs,
+		PersistentPreRunE: func(c *cobra.Command, args []string) error {
+			if err := Init(c); err != nil {
+				return errors.Trace(err)
+			}
+			build.LogInfo(build.BR)
+			logutil.LogEnvVariables()
+			task.LogArguments(c)
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			cfg := task.ExportConfig{Config: task.Config{LogProgress: HasLogFile()}}
+			if err := cfg.ParseFromFlags(cmd.Flags()); err != nil {
+				return errors.Trace(err)
+			}
+			if err := task.RunExport(GetDefaultContext(), tidbGlue, task.ExportCmd, &cfg); err != nil {
+				log.Error("failed to export", zap.Error(err))
+				return errors.Trace(err)
+			}
+			return nil
+		},
+	}
+	task.DefineExportFlags(command)
+	task.DefineFilterFlags(command, filterOutSysAndMemTables, false)
+	return command
+}
@@ -27,13 +27,17 @@ func main() {
 		NewBackupCommand(),
 		NewRestoreCommand(),
 		NewStreamCommand(),
+		NewExportCommand(),
+		NewPITRCommand(),
 		newOperatorCommand(),
 	)
 	// Outputs cmd.Print to stdout.
 	rootCmd.SetOut(os.Stdout)
 
 	rootCmd.SetArgs(os.Args[1:])
-	if err := rootCmd.Execute(); err != nil {
+	err := rootCmd.Execute()
+	stopMetricsPusher()
+	if err != nil {
 		cancel()
 		log.Error("br failed", zap.Error(err))
 		os.Exit(1) // nolint:gocritic
@@ -0,0 +1,58 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package main
+
+import (
+	"github.com/pingcap/errors"
+	"github.com/pingcap/log"
+	"github.com/pingcap/tidb/br/pkg/task"
+	"github.com/pingcap/tidb/br/pkg/version/build"
+	"github.com/pingcap/tidb/pkg/util/logutil"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+// NewPITRCommand returns a pitr subcommand.
+func NewPITRCommand() *cobra.Command {
+	command := &cobra.Command{
+		Use:          "pitr",
+		Short:        "inspect the point-in-time recovery of the backups",
+		SilenceUsage: true,
+		PersistentPreRunE: func(c *cobra.Command, args []string) error {
+			if err := Init(c); err != nil {
+				return errors.Trace(err)
+			}
+			build.LogInfo(build.BR)
+			logutil.LogEnvVariables()
+			task.LogArguments(c)
+			return nil
+		},
+	}
+	command.AddCommand(newPITRTimelineCommand())
+	return command
+}
+
+func newPITRTimelineCommand() *cobra.Command {
+	command := &cobra.Command{
+		Use:   "timeline",
+		Short: "show the ts each table can be restored to from the backups under a storage",
+		Long: "find the snapshot backups and the log backups under the storage root given by --storage, and show " +
+			"the ranges of ts each table in the snapshot backups can be restored to, and the gaps between them. " +
+			"The tables created in the log backups aren't shown",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			cfg := task.PITRTimelineConfig{Config: task.Config{LogProgress: HasLogFile()}}
+			if err := cfg.ParseFromFlags(cmd.Flags()); err != nil {
+				return errors.Trace(err)
+			}
+			if err := task.RunPITRTimeline(GetDefaultContext(), tidbGlue, task.PITRTimelineCmd, &cfg); err != nil {
+				log.Error("failed to show the pitr timeline", zap.Error(err))
+				return errors.Trace(err)
+			}
+			return nil
+		},
+	}
+	task.DefinePITRTimelineFlags(command)
+	task.DefineFilterFlags(command, filterOutSysAndMemTables, false)
+	return command
+}
@@ -30,6 +30,15 @@ func runRestoreCommand(command *cobra.Command, cmdName string) error {
 		return errors.Trace(err)
 	}
 
+	if cfg.SQLEndpoint != "" {
+		// only the SQL port of the target cluster is accessible, let the TiDB restore the backup.
+		if err := task.RunRestoreViaSQL(GetDefaultContext(), cmdName, &cfg); err != nil {
+			log.Error("failed to restore via the SQL endpoint", zap.Error(err))
+			return errors.Trace(err)
+		}
+		return nil
+	}
+
 	if err := metricsutil.RegisterMetricsForBR(cfg.PD, cfg.KeyspaceName); err != nil {
 		return errors.Trace(err)
 	}
@@ -103,6 +112,90 @@ func printWorkaroundOnFullRestoreError(err error) {
 	fmt.Println("#######################################################################")
 }
 
+func runRestoreVerifyCommand(command *cobra.Command, cmdName string) error {
+	cfg := task.RestoreConfig{Config: task.Config{LogProgress: HasLogFile()}}
+	if err := cfg.ParseFromFlags(command.Flags(), false); err != nil {
+		command.SilenceUsage = false
+		return errors.Trace(err)
+	}
+
+	if err := task.RunRestoreVerify(GetDefaultContext(), tidbGlue, cmdName, &cfg); err != nil {
+		log.Error("failed to verify restore", zap.Error(err))
+		return errors.Trace(err)
+	}
+	return nil
+}
+
+func runRestoreRollbackCommand(command *cobra.Command, cmdName string) error {
+	cfg := task.RestoreConfig{Config: task.Config{LogProgress: HasLogFile()}}
+	if err := cfg.ParseFromFlags(command.Flags(), false); err != nil {
+		command.SilenceUsage = false
+		return errors.Trace(err)
+	}
+
+	if err := task.RunRestoreRollback(GetDefaultContext(), tidbGlue, cmdName, &cfg); err != nil {
+		log.Error("failed to roll back restore", zap.Error(err))
+		return errors.Trace(err)
+	}
+	return nil
+}
+
+func runRestoreDroppedTableCommand(command *cobra.Command, cmdName string) error {
+	cfg := task.RestoreDroppedTableConfig{RestoreConfig: task.RestoreConfig{Config: task.Config{LogProgress: HasLogFile()}}}
+	if err := cfg.ParseFromFlags(command.Flags()); err != nil {
+		command.SilenceUsage = false
+		return errors.Trace(err)
+	}
+
+	if err := task.RunRestoreDroppedTable(GetDefaultContext(), tidbGlue, cmdName, &cfg); err != nil {
+		log.Error("failed to restore the dropped table", zap.Error(err))
+		return errors.Trace(err)
+	}
+	return nil
+}
+
+func runRepairIndexesCommand(command *cobra.Command, cmdName string) error {
+	cfg := task.RepairIndexesConfig{RestoreConfig: task.RestoreConfig{Config: task.Config{LogProgress: HasLogFile()}}}
+	if err := cfg.ParseFromFlags(command.Flags()); err != nil {
+		command.SilenceUsage = false
+		return errors.Trace(err)
+	}
+
+	if err := task.RunRepairIndexes(GetDefaultContext(), tidbGlue, cmdName, &cfg); err != nil {
+		log.Error("failed to repair the indexes", zap.Error(err))
+		return errors.Trace(err)
+	}
+	return nil
+}
+
+func runRestoreTiFlashReplicasCommand(command *cobra.Command, cmdName string) error {
+	cfg := task.RestoreTiFlashReplicasConfig{RestoreConfig: task.RestoreConfig{Config: task.Config{LogProgress: HasLogFile()}}}
+	if err := cfg.ParseFromFlags(command.Flags()); err != nil {
+		command.SilenceUsage = false
+		return errors.Trace(err)
+	}
+
+	if err := task.RunRestoreTiFlashReplicas(GetDefaultContext(), tidbGlue, cmdName, &cfg); err != nil {
+		log.Error("failed to restore the TiFlash replicas", zap.Error(err))
+		return errors.Trace(err)
+	}
+	return nil
+}
+
+func runRestoreClusterConfigCommand(command *cobra.Command, cmdName string) error {
+	cfg := task.RestoreClusterConfigConfig{RestoreConfig: task.RestoreConfig{Config: task.Config{LogProgress: HasLogFile()}}}
+	if err := cfg.ParseFromFlags(command.Flags()); err != nil {
+		command.SilenceUsage = false
+		return errors.Trace(err)
+	}
+
+	if err := task.RunRestoreClusterConfig(GetDefaultContext(), tidbGlue, cmdName, &cfg); err != nil {
+		log.Error("failed to restore the cluster config", zap.Error(err))
+		return errors.Trace(err)
+	}
+	return nil
+}
+
 func runRestoreRawCommand(command *cobra.Command, cmdName string) error {
 	cfg := task.RestoreRawConfig{
 		RawKvConfig: task.RawKvConfig{Config: task.Config{LogProgress: HasLogFile()}},
@@ -171,6 +264,12 @@ func NewRestoreCommand() *cobra.Command {
 		newRawRestoreCommand(),
 		newTxnRestoreCommand(),
 		newStreamRestoreCommand(),
+		newVerifyRestoreCommand(),
+		newRollbackRestoreCommand(),
+		newRestoreDroppedTableCommand(),
+		newRepairIndexesCommand(),
+		newRestoreTiFlashReplicasCommand(),
+		newRestoreClusterConfigCommand(),
 	)
 	task.DefineRestoreFlags(command.PersistentFlags())
 
@@ -258,3 +357,87 @@ func newStreamRestoreCommand() *cobra.Command {
 	task.DefineStreamRestoreFlags(command)
 	return command
 }
+
+// newVerifyRestoreCommand returns a subcommand that verifies the restored tables against the backup.
+func newVerifyRestoreCommand() *cobra.Command {
+	command := &cobra.Command{
+		Use:   "verify",
+		Short: "verify the checksums, row counts, indexes and auto ids of the restored tables against the backup",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return runRestoreVerifyCommand(cmd, task.VerifyRestoreCmd)
+		},
+	}
+	task.DefineFilterFlags(command, filterOutSysAndMemTables, false)
+	return command
+}
+
+// newRollbackRestoreCommand returns a subcommand that rolls back the partially completed restore by its
+// checkpoint.
+func newRollbackRestoreCommand() *cobra.Command {
+	command := &cobra.Command{
+		Use:   "rollback",
+		Short: "drop the databases, tables and placement policies created by the partially completed restore by its checkpoint",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return runRestoreRollbackCommand(cmd, task.RestoreRollbackCmd)
+		},
+	}
+	return command
+}
+
+// newRestoreDroppedTableCommand returns a subcommand that restores a single dropped table from the log backup.
+func newRestoreDroppedTableCommand() *cobra.Command {
+	command := &cobra.Command{
+		Use:   "dropped-table",
+		Short: "restore a dropped table from the log backup into the current cluster without the full PiTR",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return runRestoreDroppedTableCommand(cmd, task.RestoreDroppedTableCmd)
+		},
+	}
+	task.DefineRestoreDroppedTableFlags(command)
+	return command
+}
+
+// newRepairIndexesCommand returns a subcommand that re-drives the ingest index repairs of the log restore.
+func newRepairIndexesCommand() *cobra.Command {
+	command := &cobra.Command{
+		Use:   "repair-indexes",
+		Short: "re-attempt the repairs of the ingest indexes failed in the previous log restore",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return runRepairIndexesCommand(cmd, task.RepairIndexesCmd)
+		},
+	}
+	task.DefineRepairIndexesFlags(command)
+	return command
+}
+
+// newRestoreTiFlashReplicasCommand returns a subcommand that sets the TiFlash replicas deferred by the log restore.
+func newRestoreTiFlashReplicasCommand() *cobra.Command {
+	command := &cobra.Command{
+		Use:   "tiflash-replicas",
+		Short: "set the TiFlash replicas of the tables restored by the log restore with --defer-tiflash-replicas",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return runRestoreTiFlashReplicasCommand(cmd, task.RestoreTiFlashReplicasCmd)
+		},
+	}
+	task.DefineRestoreTiFlashReplicasFlags(command)
+	return command
+}
+
+// newRestoreClusterConfigCommand returns a subcommand that compares or applies the cluster config saved alongside the backup.
+func newRestoreClusterConfigCommand() *cobra.Command {
+	command := &cobra.Command{
+		Use:   "config",
+		Short: "compare the cluster config saved alongside the backup with the target cluster, or apply it with --apply",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return runRestoreClusterConfigCommand(cmd, task.RestoreClusterConfigCmd)
+		},
+	}
+	task.DefineRestoreClusterConfigFlags(command)
+	return command
+}
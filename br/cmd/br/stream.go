@@ -47,9 +47,12 @@ func NewStreamCommand() *cobra.Command {
 		newStreamStopCommand(),
 		newStreamPauseCommand(),
 		newStreamResumeCommand(),
+		newStreamUpdateCommand(),
 		newStreamStatusCommand(),
 		newStreamTruncateCommand(),
 		newStreamCheckCommand(),
+		newStreamIndexCommand(),
+		newStreamDoctorCommand(),
 		newStreamAdvancerCommand(),
 	)
 	command.SetHelpFunc(func(command *cobra.Command, strings []string) {
@@ -120,6 +123,21 @@ func newStreamResumeCommand() *cobra.Command {
 	return command
 }
 
+func newStreamUpdateCommand() *cobra.Command {
+	command := &cobra.Command{
+		Use:   "update",
+		Short: "update the table filter of a log backup task without restarting it",
+		Args:  cobra.NoArgs,
+		RunE: func(command *cobra.Command, _ []string) error {
+			return streamCommand(command, task.StreamUpdate)
+		},
+	}
+
+	task.DefineFilterFlags(command, acceptAllTables, true)
+	task.DefineStreamUpdateFlags(command.Flags())
+	return command
+}
+
 func newStreamStatusCommand() *cobra.Command {
 	command := &cobra.Command{
 		Use:   "status",
@@ -159,6 +177,34 @@ func newStreamCheckCommand() *cobra.Command {
 	return command
 }
 
+func newStreamIndexCommand() *cobra.Command {
+	command := &cobra.Command{
+		Use: "index",
+		Short: "build the table ID indexes of the log backup, " +
+			"so the point in time restore can skip the files of the tables not to restore. " +
+			"The indexes of the deleted metadata files are deleted.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return streamCommand(cmd, task.StreamIndex)
+		},
+	}
+	return command
+}
+
+func newStreamDoctorCommand() *cobra.Command {
+	command := &cobra.Command{
+		Use: "doctor",
+		Short: "check the log backup tasks for the common failures, " +
+			"and print the probable causes and the remediation steps.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return streamCommand(cmd, task.StreamDoctor)
+		},
+	}
+	task.DefineStreamDoctorFlags(command.Flags())
+	return command
+}
+
 func newStreamAdvancerCommand() *cobra.Command {
 	command := &cobra.Command{
 		Use: "advancer",
@@ -190,7 +236,7 @@ func streamCommand(command *cobra.Command, cmdName string) error {
 	}
 
 	switch cmdName {
-	case task.StreamMetadata:
+	case task.StreamMetadata, task.StreamIndex:
 		{
 			// do nothing.
 		}
@@ -202,6 +248,10 @@ func streamCommand(command *cobra.Command, cmdName string) error {
 		if err = cfg.ParseStreamStatusFromFlags(command.Flags()); err != nil {
 			return errors.Trace(err)
 		}
+	case task.StreamDoctor:
+		if err = cfg.ParseStreamDoctorFromFlags(command.Flags()); err != nil {
+			return errors.Trace(err)
+		}
 	case task.StreamStart:
 		if err = cfg.ParseStreamStartFromFlags(command.Flags()); err != nil {
 			return errors.Trace(err)
@@ -210,6 +260,10 @@ func streamCommand(command *cobra.Command, cmdName string) error {
 		if err = cfg.ParseStreamPauseFromFlags(command.Flags()); err != nil {
 			return errors.Trace(err)
 		}
+	case task.StreamUpdate:
+		if err = cfg.ParseStreamUpdateFromFlags(command.Flags()); err != nil {
+			return errors.Trace(err)
+		}
 	case task.StreamCtl:
 		if err = cfg.ParseStreamCommonFromFlags(command.Flags()); err != nil {
 			return errors.Trace(err)
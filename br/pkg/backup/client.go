@@ -95,7 +95,10 @@ type MainBackupLoop struct {
 	GetBackupClientCallBack func(ctx context.Context, storeID uint64, reset bool) (backuppb.BackupClient, error)
 }
 
-type MainBackupSender struct{}
+type MainBackupSender struct {
+	// Controller adjusts the concurrency of every store, the concurrency is fixed if it's nil.
+	Controller *ConcurrencyController
+}
 
 func (s *MainBackupSender) SendAsync(
 	ctx context.Context,
@@ -112,7 +115,16 @@ func (s *MainBackupSender) SendAsync(
 			logutil.CL(ctx).Info("store backup goroutine exits", zap.Uint64("store", storeID))
 			close(respCh)
 		}()
-		err := startBackup(ctx, storeID, request, cli, concurrency, respCh)
+		var limiter *ConcurrencyLimiter
+		requestCount := concurrency
+		if s.Controller != nil {
+			// the concurrency can be raised up to the ceiling, and the ranges are split into more requests
+			// than it, so a lowered limit takes effect once the short running requests finish.
+			limiter = s.Controller.Limiter(storeID)
+			concurrency = s.Controller.Ceiling()
+			requestCount = s.Controller.RequestCount()
+		}
+		err := startBackup(ctx, storeID, request, cli, concurrency, requestCount, limiter, respCh)
 		if err != nil {
 			// only 2 kinds of errors will occur here.
 			// 1. grpc connection error(already retry inside)
@@ -391,6 +403,11 @@ type Client struct {
 	checkpointRunner *checkpoint.CheckpointRunner[checkpoint.BackupKeyType, checkpoint.BackupValueType]
 
 	gcTTL int64
+
+	// tableLayout is set when the data files should be organized by table.
+	tableLayout *TableLayout
+	// concurrencyController is set when the concurrency should be adjusted by the pressure of TiKV.
+	concurrencyController *ConcurrencyController
 }
 
 // NewBackupClient returns a new backup client.
@@ -496,6 +513,17 @@ func (bc *Client) GetGCTTL() int64 {
 	return bc.gcTTL
 }
 
+// SetTableLayout makes the client move the data files into their table prefixes
+// before they are recorded in the backup meta.
+func (bc *Client) SetTableLayout(layout *TableLayout) {
+	bc.tableLayout = layout
+}
+
+// SetConcurrencyController sets the controller adjusting the backup concurrency of every store.
+func (bc *Client) SetConcurrencyController(controller *ConcurrencyController) {
+	bc.concurrencyController = controller
+}
+
 // GetStorageBackend gets storage backupend field in client.
 func (bc *Client) GetStorageBackend() *backuppb.StorageBackend {
 	return bc.backend
@@ -1122,8 +1150,15 @@ func (bc *Client) BackupRanges(
 	stateNotifier := make(chan BackupRetryPolicy)
 	ObserveStoreChangesAsync(ctx, stateNotifier, bc.mgr.GetPDClient())
 
+	sender := &MainBackupSender{}
+	if bc.concurrencyController != nil {
+		controllerCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		go bc.concurrencyController.Run(controllerCtx)
+		sender.Controller = bc.concurrencyController
+	}
 	mainBackupLoop := &MainBackupLoop{
-		BackupSender:       &MainBackupSender{},
+		BackupSender:       sender,
 		BackupReq:          request,
 		Concurrency:        concurrency,
 		GlobalProgressTree: &globalProgressTree,
@@ -1144,6 +1179,11 @@ func (bc *Client) BackupRanges(
 	if err != nil {
 		return errors.Trace(err)
 	}
+	if bc.tableLayout != nil {
+		if err := relayoutRangeFiles(ctx, bc.storage, bc.tableLayout, &globalProgressTree); err != nil {
+			return errors.Trace(err)
+		}
+	}
 	return collectRangeFiles(&globalProgressTree, metaWriter)
 }
 
@@ -1236,6 +1276,28 @@ func (bc *Client) OnBackupResponse(
 	return nil, nil
 }
 
+func relayoutRangeFiles(
+	ctx context.Context,
+	s storage.ExternalStorage,
+	layout *TableLayout,
+	progressRangeTree *rtree.ProgressRangeTree,
+) error {
+	files := make([]*backuppb.File, 0)
+	progressRangeTree.Ascend(func(progressRange *rtree.ProgressRange) bool {
+		progressRange.Res.Ascend(func(i btree.Item) bool {
+			files = append(files, i.(*rtree.Range).Files...)
+			return true
+		})
+		return true
+	})
+	start := time.Now()
+	if err := layout.Relayout(ctx, s, files); err != nil {
+		return errors.Trace(err)
+	}
+	log.Info("moved data files into table prefixes", zap.Int("files", len(files)), zap.Duration("take", time.Since(start)))
+	return nil
+}
+
 func collectRangeFiles(progressRangeTree *rtree.ProgressRangeTree, metaWriter *metautil.MetaWriter) error {
 	var progressRangeAscendErr error
 	progressRangeTree.Ascend(func(progressRange *rtree.ProgressRange) bool {
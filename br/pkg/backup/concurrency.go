@@ -0,0 +1,274 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package backup
+
+import (
+	"context"
+	"crypto/tls"
+	"math"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/log"
+	"github.com/pingcap/tidb/br/pkg/httputil"
+	"github.com/pingcap/tidb/br/pkg/pdutil"
+	pd "github.com/tikv/pd/client"
+	"go.uber.org/zap"
+)
+
+const (
+	// the PromQLs of the pressure of TiKV, grouped by the status address of TiKV.
+	backupPendingTasksQuery = `sum(tikv_futurepool_pending_task_total{name=~"backup.*"}) by (instance)`
+	foregroundLatencyQuery  = `histogram_quantile(0.99, sum(rate(` +
+		`tikv_grpc_msg_duration_seconds_bucket{type=~"kv_get|kv_batch_get|kv_prewrite|kv_commit|coprocessor"}[1m]` +
+		`)) by (le, instance))`
+
+	defaultAdjustConcurrencyInterval = 15 * time.Second
+	// adaptiveRequestsPerSlot is the number of the requests the ranges of a store are split into per slot of
+	// the ceiling, the smaller requests finish sooner, so a lowered limit takes effect sooner.
+	adaptiveRequestsPerSlot = 4
+)
+
+// ConcurrencyLimiter limits the in-flight backup requests to a store, the limit can be changed at runtime.
+// It's checked before a request is sent, the running requests aren't throttled by it.
+type ConcurrencyLimiter struct {
+	mu      sync.Mutex
+	limit   uint
+	running uint
+	// changed is closed and replaced when a slot may be available.
+	changed chan struct{}
+}
+
+// NewConcurrencyLimiter creates a ConcurrencyLimiter.
+func NewConcurrencyLimiter(limit uint) *ConcurrencyLimiter {
+	return &ConcurrencyLimiter{limit: max(limit, 1), changed: make(chan struct{})}
+}
+
+// Acquire blocks until the in-flight requests are fewer than the limit.
+func (l *ConcurrencyLimiter) Acquire(ctx context.Context) error {
+	for {
+		l.mu.Lock()
+		if l.running < l.limit {
+			l.running += 1
+			l.mu.Unlock()
+			return nil
+		}
+		changed := l.changed
+		l.mu.Unlock()
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-changed:
+		}
+	}
+}
+
+// Release releases a slot acquired by Acquire.
+func (l *ConcurrencyLimiter) Release() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.running -= 1
+	l.notifyLocked()
+}
+
+// Limit returns the current limit.
+func (l *ConcurrencyLimiter) Limit() uint {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.limit
+}
+
+// SetLimit changes the limit, the in-flight requests beyond the new limit are not interrupted.
+func (l *ConcurrencyLimiter) SetLimit(limit uint) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.limit = max(limit, 1)
+	l.notifyLocked()
+}
+
+func (l *ConcurrencyLimiter) notifyLocked() {
+	close(l.changed)
+	l.changed = make(chan struct{})
+}
+
+// StorePressure is the pressure of a TiKV store.
+type StorePressure struct {
+	// PendingTasks is the length of the queue of the backup workers.
+	PendingTasks float64
+	// ForegroundLatency is the 99th percentile latency of the foreground requests.
+	ForegroundLatency time.Duration
+}
+
+// PressureSource reports the pressure of the stores.
+type PressureSource interface {
+	StorePressure(ctx context.Context) (map[uint64]StorePressure, error)
+}
+
+// AdaptiveConcurrencyConfig is the config of the ConcurrencyController.
+type AdaptiveConcurrencyConfig struct {
+	// Floor and Ceiling are the bounds of the concurrency per store.
+	Floor   uint
+	Ceiling uint
+	// the store is overloaded if any of the thresholds is exceeded.
+	MaxPendingTasks      float64
+	MaxForegroundLatency time.Duration
+	Interval             time.Duration
+}
+
+// ConcurrencyController adjusts the backup concurrency of every store by its pressure.
+// It raises the concurrency by one while the store is idle and halves it once the store is overloaded.
+// The concurrency is the number of the in-flight backup requests of the sub ranges to the store, a new
+// limit takes effect when the running requests finish. It can't throttle a backup of a single range, which
+// is sent as one request.
+type ConcurrencyController struct {
+	cfg     AdaptiveConcurrencyConfig
+	initial uint
+	source  PressureSource
+
+	mu       sync.Mutex
+	limiters map[uint64]*ConcurrencyLimiter
+}
+
+// NewConcurrencyController creates a ConcurrencyController, every store starts with the initial concurrency.
+func NewConcurrencyController(initial uint, cfg AdaptiveConcurrencyConfig, source PressureSource) *ConcurrencyController {
+	cfg.Floor = max(cfg.Floor, 1)
+	cfg.Ceiling = max(cfg.Ceiling, cfg.Floor)
+	if cfg.Interval <= 0 {
+		cfg.Interval = defaultAdjustConcurrencyInterval
+	}
+	return &ConcurrencyController{
+		cfg:      cfg,
+		initial:  min(max(initial, cfg.Floor), cfg.Ceiling),
+		source:   source,
+		limiters: make(map[uint64]*ConcurrencyLimiter),
+	}
+}
+
+// Ceiling returns the max concurrency of a store.
+func (c *ConcurrencyController) Ceiling() uint {
+	return c.cfg.Ceiling
+}
+
+// RequestCount returns the number of the requests the ranges of a store are split into.
+func (c *ConcurrencyController) RequestCount() uint {
+	return c.cfg.Ceiling * adaptiveRequestsPerSlot
+}
+
+// Limiter returns the limiter of the store.
+func (c *ConcurrencyController) Limiter(storeID uint64) *ConcurrencyLimiter {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	l, ok := c.limiters[storeID]
+	if !ok {
+		l = NewConcurrencyLimiter(c.initial)
+		c.limiters[storeID] = l
+	}
+	return l
+}
+
+// Run adjusts the concurrency periodically until the context is done.
+func (c *ConcurrencyController) Run(ctx context.Context) {
+	ticker := time.NewTicker(c.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := c.Adjust(ctx); err != nil {
+				log.Warn("failed to get the pressure of stores, keep the backup concurrency", zap.Error(err))
+			}
+		}
+	}
+}
+
+// Adjust adjusts the concurrency of the stores once. The stores without pressure reported are unchanged.
+func (c *ConcurrencyController) Adjust(ctx context.Context) error {
+	pressures, err := c.source.StorePressure(ctx)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for storeID, l := range c.limiters {
+		p, ok := pressures[storeID]
+		if !ok {
+			continue
+		}
+		limit := l.Limit()
+		newLimit := min(limit+1, c.cfg.Ceiling)
+		if c.overloaded(p) {
+			newLimit = max(limit/2, c.cfg.Floor)
+		}
+		if newLimit != limit {
+			log.Info("adjust backup concurrency", zap.Uint64("store", storeID),
+				zap.Uint("from", limit), zap.Uint("to", newLimit),
+				zap.Float64("pending-tasks", p.PendingTasks), zap.Duration("foreground-latency", p.ForegroundLatency))
+			l.SetLimit(newLimit)
+		}
+	}
+	return nil
+}
+
+func (c *ConcurrencyController) overloaded(p StorePressure) bool {
+	if c.cfg.MaxPendingTasks > 0 && p.PendingTasks > c.cfg.MaxPendingTasks {
+		return true
+	}
+	return c.cfg.MaxForegroundLatency > 0 && p.ForegroundLatency > c.cfg.MaxForegroundLatency
+}
+
+// pdMetricsPressure reads the pressure of the stores from the metrics proxy of PD.
+type pdMetricsPressure struct {
+	pdClient pd.Client
+	cli      *http.Client
+	tls      bool
+}
+
+// NewPDMetricsPressureSource creates a PressureSource reading the metrics by PD.
+func NewPDMetricsPressureSource(pdClient pd.Client, tlsConf *tls.Config) PressureSource {
+	return &pdMetricsPressure{pdClient: pdClient, cli: httputil.NewClient(tlsConf), tls: tlsConf != nil}
+}
+
+// StorePressure implements PressureSource.
+func (p *pdMetricsPressure) StorePressure(ctx context.Context) (map[uint64]StorePressure, error) {
+	stores, err := p.pdClient.GetAllStores(ctx)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	storeIDs := make(map[string]uint64, len(stores))
+	for _, s := range stores {
+		storeIDs[s.GetStatusAddress()] = s.GetId()
+	}
+
+	pdURL := p.pdClient.GetServiceDiscovery().GetServingURL()
+	// the pd client dialing by the TLS dialer discovers the URLs in the plain HTTP scheme.
+	if p.tls && strings.HasPrefix(pdURL, "http://") {
+		pdURL = "https://" + strings.TrimPrefix(pdURL, "http://")
+	}
+	pressures := make(map[uint64]StorePressure, len(stores))
+	for _, query := range []string{backupPendingTasksQuery, foregroundLatencyQuery} {
+		samples, err := pdutil.QueryMetric(ctx, p.cli, pdURL, query)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		for _, sample := range samples {
+			storeID, ok := storeIDs[sample.Labels["instance"]]
+			// the quantile is NaN if there is no request.
+			if !ok || math.IsNaN(sample.Value) {
+				continue
+			}
+			pressure := pressures[storeID]
+			switch query {
+			case backupPendingTasksQuery:
+				pressure.PendingTasks = sample.Value
+			case foregroundLatencyQuery:
+				pressure.ForegroundLatency = time.Duration(sample.Value * float64(time.Second))
+			}
+			pressures[storeID] = pressure
+		}
+	}
+	return pressures, nil
+}
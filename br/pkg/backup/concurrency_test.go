@@ -0,0 +1,92 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package backup
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConcurrencyLimiter(t *testing.T) {
+	ctx := context.Background()
+	l := NewConcurrencyLimiter(2)
+	require.NoError(t, l.Acquire(ctx))
+	require.NoError(t, l.Acquire(ctx))
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	require.ErrorIs(t, l.Acquire(timeoutCtx), context.DeadlineExceeded)
+
+	acquired := make(chan struct{})
+	go func() {
+		require.NoError(t, l.Acquire(ctx))
+		close(acquired)
+	}()
+	select {
+	case <-acquired:
+		require.FailNow(t, "acquired beyond the limit")
+	case <-time.After(50 * time.Millisecond):
+	}
+	// raising the limit wakes up the waiter.
+	l.SetLimit(3)
+	<-acquired
+
+	// lowering the limit blocks the new requests until the running ones are released.
+	l.SetLimit(1)
+	require.Equal(t, uint(1), l.Limit())
+	l.Release()
+	l.Release()
+	timeoutCtx, cancel = context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	require.ErrorIs(t, l.Acquire(timeoutCtx), context.DeadlineExceeded)
+	l.Release()
+	require.NoError(t, l.Acquire(ctx))
+}
+
+type fakePressureSource struct {
+	pressures map[uint64]StorePressure
+}
+
+func (f *fakePressureSource) StorePressure(context.Context) (map[uint64]StorePressure, error) {
+	return f.pressures, nil
+}
+
+func TestConcurrencyController(t *testing.T) {
+	ctx := context.Background()
+	source := &fakePressureSource{}
+	c := NewConcurrencyController(4, AdaptiveConcurrencyConfig{
+		Floor:                2,
+		Ceiling:              6,
+		MaxPendingTasks:      10,
+		MaxForegroundLatency: 100 * time.Millisecond,
+	}, source)
+	require.Equal(t, uint(6), c.Ceiling())
+	require.Equal(t, uint(6*adaptiveRequestsPerSlot), c.RequestCount())
+	l1, l2, l3 := c.Limiter(1), c.Limiter(2), c.Limiter(3)
+	require.Same(t, l1, c.Limiter(1))
+	require.Equal(t, uint(4), l1.Limit())
+
+	source.pressures = map[uint64]StorePressure{
+		1: {PendingTasks: 1, ForegroundLatency: time.Millisecond},
+		2: {PendingTasks: 20},
+	}
+	require.NoError(t, c.Adjust(ctx))
+	require.Equal(t, uint(5), l1.Limit())
+	require.Equal(t, uint(2), l2.Limit())
+	// no pressure is reported.
+	require.Equal(t, uint(4), l3.Limit())
+
+	source.pressures = map[uint64]StorePressure{
+		1: {},
+		2: {ForegroundLatency: time.Second},
+		3: {ForegroundLatency: time.Second},
+	}
+	require.NoError(t, c.Adjust(ctx))
+	require.NoError(t, c.Adjust(ctx))
+	require.Equal(t, uint(6), l1.Limit())
+	require.Equal(t, uint(2), l2.Limit())
+	require.Equal(t, uint(2), l3.Limit())
+}
@@ -0,0 +1,109 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package backup
+
+import (
+	"context"
+	"time"
+
+	"github.com/docker/go-units"
+	"github.com/pingcap/errors"
+	"github.com/pingcap/tidb/pkg/kv"
+	"github.com/pingcap/tidb/pkg/meta/model"
+	"github.com/pingcap/tidb/pkg/tablecodec"
+	pdhttp "github.com/tikv/pd/client/http"
+)
+
+// RegionStatsGetter returns the approximate statistics of the regions in the key range.
+type RegionStatsGetter func(ctx context.Context, startKey, endKey []byte) (*pdhttp.RegionStats, error)
+
+// RowCounter returns the row count of the physical table, false is returned if it's unknown.
+type RowCounter func(tableInfo *model.TableInfo, physicalID int64) (int64, bool)
+
+// Estimation is the estimated cost of a backup.
+type Estimation struct {
+	Tables  int
+	Regions int
+	// StorageSize is the approximate size in TiKV, including the MVCC versions.
+	StorageSize uint64
+	// BackupSize is the expected size of the backup files, only the latest version is backed up.
+	BackupSize uint64
+	// Files is the expected count of the backup files, every region produces a file for each column family.
+	Files int
+	// Stores is the count of the stores leading the regions, they back up the regions in parallel.
+	Stores int
+	// Duration is the expected duration under the rate limit, zero if there is no rate limit.
+	Duration time.Duration
+}
+
+// MVCCAmplification returns how many times the storage size is larger than the backup size.
+func (e *Estimation) MVCCAmplification() float64 {
+	if e.BackupSize == 0 {
+		return 1
+	}
+	return float64(e.StorageSize) / float64(e.BackupSize)
+}
+
+// mvccAmplification estimates the MVCC amplification of a physical table by comparing the
+// approximate keys with the keys of the latest version, i.e. a row key and an index key per index.
+func mvccAmplification(tableInfo *model.TableInfo, rows int64, storageKeys int64) float64 {
+	latestKeys := rows * int64(1+len(tableInfo.Indices))
+	if latestKeys <= 0 || storageKeys <= latestKeys {
+		return 1
+	}
+	return float64(storageKeys) / float64(latestKeys)
+}
+
+// Estimate estimates the cost of backing up the schemas. rateLimit is in bytes per second of every store.
+func (ss *Schemas) Estimate(
+	ctx context.Context,
+	store kv.Storage,
+	getStats RegionStatsGetter,
+	countRows RowCounter,
+	rateLimit uint64,
+) (*Estimation, error) {
+	tables := make([]*model.TableInfo, 0, ss.Len())
+	if err := ss.iterFunc(store, func(_ *model.DBInfo, tableInfo *model.TableInfo) {
+		if tableInfo != nil && !tableInfo.IsView() && !tableInfo.IsSequence() {
+			tables = append(tables, tableInfo)
+		}
+	}); err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	e := &Estimation{Tables: len(tables)}
+	stores := make(map[uint64]struct{})
+	for _, tableInfo := range tables {
+		physicalIDs := []int64{tableInfo.ID}
+		if pi := tableInfo.GetPartitionInfo(); pi != nil {
+			physicalIDs = physicalIDs[:0]
+			for _, def := range pi.Definitions {
+				physicalIDs = append(physicalIDs, def.ID)
+			}
+		}
+		for _, id := range physicalIDs {
+			stats, err := getStats(ctx, tablecodec.EncodeTablePrefix(id), tablecodec.EncodeTablePrefix(id+1))
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			// the storage size reported by PD is in MiB.
+			storageSize := uint64(max(stats.StorageSize, 0)) * units.MiB
+			amplification := 1.0
+			if rows, ok := countRows(tableInfo, id); ok {
+				amplification = mvccAmplification(tableInfo, rows, stats.StorageKeys)
+			}
+			e.Regions += stats.Count - stats.EmptyCount
+			e.StorageSize += storageSize
+			e.BackupSize += uint64(float64(storageSize) / amplification)
+			for storeID := range stats.StoreLeaderCount {
+				stores[storeID] = struct{}{}
+			}
+		}
+	}
+	e.Files = e.Regions * 2
+	e.Stores = len(stores)
+	if rateLimit > 0 && e.Stores > 0 {
+		e.Duration = time.Duration(float64(e.BackupSize) / float64(rateLimit*uint64(e.Stores)) * float64(time.Second))
+	}
+	return e, nil
+}
@@ -0,0 +1,68 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package backup_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/docker/go-units"
+	"github.com/pingcap/tidb/br/pkg/backup"
+	"github.com/pingcap/tidb/pkg/kv"
+	"github.com/pingcap/tidb/pkg/meta/model"
+	"github.com/pingcap/tidb/pkg/parser/ast"
+	"github.com/pingcap/tidb/pkg/tablecodec"
+	"github.com/stretchr/testify/require"
+	pdhttp "github.com/tikv/pd/client/http"
+)
+
+func TestEstimate(t *testing.T) {
+	dbInfo := &model.DBInfo{Name: ast.NewCIStr("test")}
+	tables := []*model.TableInfo{
+		// 100 rows with an index, 400 keys in storage.
+		{ID: 100, Name: ast.NewCIStr("t1"), Indices: []*model.IndexInfo{{ID: 1}}},
+		// no statistics.
+		{ID: 200, Name: ast.NewCIStr("t2"), Partition: &model.PartitionInfo{
+			Enable:      true,
+			Definitions: []model.PartitionDefinition{{ID: 201}, {ID: 202}},
+		}},
+	}
+	schemas := backup.NewBackupSchemas(func(_ kv.Storage, fn func(*model.DBInfo, *model.TableInfo)) error {
+		fn(dbInfo, nil)
+		for _, tbl := range tables {
+			fn(dbInfo, tbl)
+		}
+		return nil
+	}, len(tables))
+
+	getStats := func(_ context.Context, startKey, _ []byte) (*pdhttp.RegionStats, error) {
+		switch tablecodec.DecodeTableID(startKey) {
+		case 100:
+			return &pdhttp.RegionStats{Count: 2, StorageSize: 64, StorageKeys: 400,
+				StoreLeaderCount: map[uint64]int{1: 1, 2: 1}}, nil
+		case 201, 202:
+			return &pdhttp.RegionStats{Count: 2, EmptyCount: 1, StorageSize: 16, StorageKeys: 10,
+				StoreLeaderCount: map[uint64]int{2: 1}}, nil
+		}
+		t.Fatalf("unexpected key %x", startKey)
+		return nil, nil
+	}
+	countRows := func(tbl *model.TableInfo, physicalID int64) (int64, bool) {
+		if physicalID == 100 {
+			return 100, true
+		}
+		return 0, false
+	}
+
+	e, err := schemas.Estimate(context.Background(), nil, getStats, countRows, 8*units.MiB)
+	require.NoError(t, err)
+	require.Equal(t, 2, e.Tables)
+	require.Equal(t, 4, e.Regions)
+	require.Equal(t, 8, e.Files)
+	require.Equal(t, 2, e.Stores)
+	require.EqualValues(t, 96*units.MiB, e.StorageSize)
+	require.EqualValues(t, 64*units.MiB, e.BackupSize)
+	require.InDelta(t, 1.5, e.MVCCAmplification(), 0.001)
+	require.Equal(t, 4*time.Second, e.Duration)
+}
@@ -0,0 +1,140 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package backup
+
+import (
+	"context"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/pingcap/errors"
+	backuppb "github.com/pingcap/kvproto/pkg/brpb"
+	"github.com/pingcap/log"
+	berrors "github.com/pingcap/tidb/br/pkg/errors"
+	"github.com/pingcap/tidb/br/pkg/storage"
+	"github.com/pingcap/tidb/pkg/kv"
+	"github.com/pingcap/tidb/pkg/meta/model"
+	"github.com/pingcap/tidb/pkg/tablecodec"
+	tidbutil "github.com/pingcap/tidb/pkg/util"
+	"go.uber.org/zap"
+	"golang.org/x/sync/errgroup"
+)
+
+// StorageLayout is the way how the data files are organized in the external storage.
+type StorageLayout string
+
+const (
+	// StorageLayoutFlat puts all the data files in the root of the external storage.
+	StorageLayoutFlat StorageLayout = "flat"
+	// StorageLayoutPerTable puts the data files of a table under `tables/<db>/<table>/`,
+	// so a table can be copied or expired by the object storage tools alone.
+	StorageLayoutPerTable StorageLayout = "per-table"
+
+	// TableLayoutPrefix is the prefix of the data files when using the per-table layout.
+	TableLayoutPrefix = "tables"
+
+	relayoutConcurrency = 16
+)
+
+// ParseStorageLayout parses the storage layout from string.
+func ParseStorageLayout(s string) (StorageLayout, error) {
+	switch layout := StorageLayout(strings.ToLower(s)); layout {
+	case "", StorageLayoutFlat:
+		return StorageLayoutFlat, nil
+	case StorageLayoutPerTable:
+		return layout, nil
+	default:
+		return "", errors.Annotatef(berrors.ErrInvalidArgument, "unknown storage layout %s", s)
+	}
+}
+
+// TableLayout maps the physical table IDs to the prefix of their data files.
+type TableLayout struct {
+	prefixes map[int64]string
+}
+
+// NewTableLayout creates an empty TableLayout.
+func NewTableLayout() *TableLayout {
+	return &TableLayout{prefixes: make(map[int64]string)}
+}
+
+// TablePrefix returns the storage prefix of the data files of the table.
+func TablePrefix(dbName, tableName string) string {
+	return path.Join(TableLayoutPrefix, url.PathEscape(dbName), url.PathEscape(tableName))
+}
+
+// AddTable registers the table and its partitions to the layout.
+func (l *TableLayout) AddTable(dbInfo *model.DBInfo, tableInfo *model.TableInfo) {
+	prefix := TablePrefix(dbInfo.Name.O, tableInfo.Name.O)
+	l.prefixes[tableInfo.ID] = prefix
+	if pi := tableInfo.GetPartitionInfo(); pi != nil {
+		for _, def := range pi.Definitions {
+			l.prefixes[def.ID] = prefix
+		}
+	}
+}
+
+// PrefixOf returns the storage prefix of the data file. False is returned
+// if the file doesn't belong to any registered table, the file stays in the root then.
+func (l *TableLayout) PrefixOf(file *backuppb.File) (string, bool) {
+	tableID := tablecodec.DecodeTableID(file.StartKey)
+	if tableID == 0 {
+		return "", false
+	}
+	prefix, ok := l.prefixes[tableID]
+	return prefix, ok
+}
+
+// Relayout moves the data files into their table prefixes and updates the file names in place.
+// It is idempotent: a file that has been moved already (e.g. by a previous run resumed from
+// the checkpoint) only gets its name updated, the moved files are listed once instead of being
+// checked one by one.
+func (l *TableLayout) Relayout(ctx context.Context, s storage.ExternalStorage, files []*backuppb.File) error {
+	moved := make(map[string]struct{})
+	err := s.WalkDir(ctx, &storage.WalkOption{SubDir: TableLayoutPrefix}, func(name string, _ int64) error {
+		moved[name] = struct{}{}
+		return nil
+	})
+	if err != nil {
+		return errors.Annotate(err, "failed to list the moved data files")
+	}
+	pool := tidbutil.NewWorkerPool(relayoutConcurrency, "relayout")
+	eg, ectx := errgroup.WithContext(ctx)
+	for _, f := range files {
+		prefix, ok := l.PrefixOf(f)
+		if !ok || strings.HasPrefix(f.Name, TableLayoutPrefix+"/") {
+			continue
+		}
+		newName := path.Join(prefix, f.Name)
+		if _, ok := moved[newName]; ok {
+			f.Name = newName
+			continue
+		}
+		file := f
+		pool.ApplyOnErrorGroup(eg, func() error {
+			if err := s.Rename(ectx, file.Name, newName); err != nil {
+				return errors.Annotatef(err, "failed to move %s to %s", file.Name, newName)
+			}
+			file.Name = newName
+			return nil
+		})
+	}
+	return eg.Wait()
+}
+
+// BuildTableLayout builds the table layout of all tables to be backed up.
+func (ss *Schemas) BuildTableLayout(store kv.Storage) (*TableLayout, error) {
+	layout := NewTableLayout()
+	err := ss.iterFunc(store, func(dbInfo *model.DBInfo, tableInfo *model.TableInfo) {
+		if tableInfo == nil {
+			return
+		}
+		layout.AddTable(dbInfo, tableInfo)
+	})
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	log.Info("build per-table storage layout", zap.Int("physical-tables", len(layout.prefixes)))
+	return layout, nil
+}
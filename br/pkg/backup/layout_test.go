@@ -0,0 +1,94 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package backup_test
+
+import (
+	"context"
+	"path"
+	"testing"
+
+	backuppb "github.com/pingcap/kvproto/pkg/brpb"
+	"github.com/pingcap/tidb/br/pkg/backup"
+	"github.com/pingcap/tidb/br/pkg/storage"
+	"github.com/pingcap/tidb/pkg/kv"
+	"github.com/pingcap/tidb/pkg/meta/model"
+	"github.com/pingcap/tidb/pkg/parser/ast"
+	"github.com/pingcap/tidb/pkg/tablecodec"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseStorageLayout(t *testing.T) {
+	layout, err := backup.ParseStorageLayout("")
+	require.NoError(t, err)
+	require.Equal(t, backup.StorageLayoutFlat, layout)
+	layout, err = backup.ParseStorageLayout("Per-Table")
+	require.NoError(t, err)
+	require.Equal(t, backup.StorageLayoutPerTable, layout)
+	_, err = backup.ParseStorageLayout("nested")
+	require.Error(t, err)
+}
+
+// renameCountingStorage counts the renames of the files.
+type renameCountingStorage struct {
+	storage.ExternalStorage
+	renames int
+}
+
+func (s *renameCountingStorage) Rename(ctx context.Context, oldName, newName string) error {
+	s.renames++
+	return s.ExternalStorage.Rename(ctx, oldName, newName)
+}
+
+func TestTableLayoutRelayout(t *testing.T) {
+	ctx := context.Background()
+	local, err := storage.NewLocalStorage(t.TempDir())
+	require.NoError(t, err)
+	s := &renameCountingStorage{ExternalStorage: local}
+
+	layout := backup.NewTableLayout()
+	dbInfo := &model.DBInfo{Name: ast.NewCIStr("test")}
+	layout.AddTable(dbInfo, &model.TableInfo{ID: 100, Name: ast.NewCIStr("t1")})
+	layout.AddTable(dbInfo, &model.TableInfo{
+		ID:   200,
+		Name: ast.NewCIStr("t/2"),
+		Partition: &model.PartitionInfo{
+			Enable:      true,
+			Definitions: []model.PartitionDefinition{{ID: 201}, {ID: 202}},
+		},
+	})
+
+	files := []*backuppb.File{
+		{Name: "1_default.sst", StartKey: tablecodec.EncodeTablePrefix(100)},
+		{Name: "2_write.sst", StartKey: tablecodec.EncodeRowKeyWithHandle(202, kv.IntHandle(1))},
+		// unknown table stays in the root.
+		{Name: "3_default.sst", StartKey: tablecodec.EncodeTablePrefix(300)},
+	}
+	for _, f := range files {
+		require.NoError(t, s.WriteFile(ctx, f.Name, []byte(f.Name)))
+	}
+	// the file has been moved by the previous run.
+	moved := &backuppb.File{Name: "4_default.sst", StartKey: tablecodec.EncodeTablePrefix(100)}
+	require.NoError(t, s.WriteFile(ctx, path.Join(backup.TablePrefix("test", "t1"), moved.Name), []byte(moved.Name)))
+	files = append(files, moved)
+
+	require.NoError(t, layout.Relayout(ctx, s, files))
+	// the file moved by the previous run isn't renamed again.
+	require.Equal(t, 2, s.renames)
+	require.Equal(t, "tables/test/t1/1_default.sst", files[0].Name)
+	require.Equal(t, "tables/test/t%2F2/2_write.sst", files[1].Name)
+	require.Equal(t, "3_default.sst", files[2].Name)
+	require.Equal(t, "tables/test/t1/4_default.sst", files[3].Name)
+	for _, f := range files {
+		exists, err := s.FileExists(ctx, f.Name)
+		require.NoError(t, err)
+		require.True(t, exists, f.Name)
+	}
+
+	// relayout again is a no-op.
+	require.NoError(t, layout.Relayout(ctx, s, files))
+	require.Equal(t, 2, s.renames)
+	require.Equal(t, "tables/test/t1/1_default.sst", files[0].Name)
+
+	lost := []*backuppb.File{{Name: "5_default.sst", StartKey: tablecodec.EncodeTablePrefix(100)}}
+	require.Error(t, layout.Relayout(ctx, s, lost))
+}
@@ -92,6 +92,71 @@ func (ss *Schemas) BackupSchemas(
 	startAll := time.Now()
 	op := metautil.AppendSchema
 	metaWriter.StartWriteMetasAsync(ctx, op)
+	backupSchema := func(ectx context.Context, schema *schemaInfo) error {
+		var checksum *checkpoint.ChecksumItem
+		var exists = false
+		if ss.checkpointChecksum != nil && schema.tableInfo != nil {
+			checksum, exists = ss.checkpointChecksum[schema.tableInfo.ID]
+		}
+		if schema.tableInfo != nil {
+			logger := log.L().With(
+				zap.String("db", schema.dbInfo.Name.O),
+				zap.String("table", schema.tableInfo.Name.O),
+			)
+
+			if !skipChecksum {
+				logger.Info("Calculate table checksum start")
+				if exists && checksum != nil {
+					schema.crc64xor = checksum.Crc64xor
+					schema.totalKvs = checksum.TotalKvs
+					schema.totalBytes = checksum.TotalBytes
+					logger.Info("Calculate table checksum completed (from checkpoint)",
+						zap.Uint64("Crc64Xor", schema.crc64xor),
+						zap.Uint64("TotalKvs", schema.totalKvs),
+						zap.Uint64("TotalBytes", schema.totalBytes))
+				} else {
+					start := time.Now()
+					err := schema.calculateChecksum(ectx, store.GetClient(), backupTS, copConcurrency)
+					if err != nil {
+						return errors.Trace(err)
+					}
+					calculateCost := time.Since(start)
+					if checkpointRunner != nil {
+						// if checkpoint runner is running and the checksum is not from checkpoint
+						// then flush the checksum by the checkpoint runner
+						if err = checkpointRunner.FlushChecksum(ctx, schema.tableInfo.ID, schema.crc64xor, schema.totalKvs, schema.totalBytes); err != nil {
+							return errors.Trace(err)
+						}
+					}
+					logger.Info("Calculate table checksum completed",
+						zap.Uint64("Crc64Xor", schema.crc64xor),
+						zap.Uint64("TotalKvs", schema.totalKvs),
+						zap.Uint64("TotalBytes", schema.totalBytes),
+						zap.Duration("TimeTaken", calculateCost))
+				}
+			}
+			if statsHandle != nil {
+				statsWriter := metaWriter.NewStatsWriter()
+				if err := schema.dumpStatsToJSON(ctx, statsWriter, statsHandle, backupTS); err != nil {
+					logger.Error("dump table stats failed", logutil.ShortError(err))
+					return errors.Trace(err)
+				}
+			}
+		}
+		// Send schema to metawriter
+		s, err := schema.encodeToSchema()
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if err := metaWriter.Send(s, op); err != nil {
+			return errors.Trace(err)
+		}
+		if updateCh != nil {
+			updateCh.Inc()
+		}
+		return nil
+	}
+	views := make([]*metautil.Table, 0)
 	err := ss.iterFunc(store, func(dbInfo *model.DBInfo, tableInfo *model.TableInfo) {
 		// because the field of `dbInfo` would be modified, which affects the later iteration.
 		// so copy the `dbInfo` for each to `newDBInfo`
@@ -105,69 +170,13 @@ func (ss *Schemas) BackupSchemas(
 			schema.dbInfo.Name = utils.TemporaryDBName(schema.dbInfo.Name.O)
 		}
 
-		var checksum *checkpoint.ChecksumItem
-		var exists = false
-		if ss.checkpointChecksum != nil && schema.tableInfo != nil {
-			checksum, exists = ss.checkpointChecksum[schema.tableInfo.ID]
+		if tableInfo != nil && tableInfo.IsView() {
+			// the views are written after the tables they select from.
+			views = append(views, &metautil.Table{DB: schema.dbInfo, Info: tableInfo})
+			return
 		}
 		workerPool.ApplyOnErrorGroup(errg, func() error {
-			if schema.tableInfo != nil {
-				logger := log.L().With(
-					zap.String("db", schema.dbInfo.Name.O),
-					zap.String("table", schema.tableInfo.Name.O),
-				)
-
-				if !skipChecksum {
-					logger.Info("Calculate table checksum start")
-					if exists && checksum != nil {
-						schema.crc64xor = checksum.Crc64xor
-						schema.totalKvs = checksum.TotalKvs
-						schema.totalBytes = checksum.TotalBytes
-						logger.Info("Calculate table checksum completed (from checkpoint)",
-							zap.Uint64("Crc64Xor", schema.crc64xor),
-							zap.Uint64("TotalKvs", schema.totalKvs),
-							zap.Uint64("TotalBytes", schema.totalBytes))
-					} else {
-						start := time.Now()
-						err := schema.calculateChecksum(ectx, store.GetClient(), backupTS, copConcurrency)
-						if err != nil {
-							return errors.Trace(err)
-						}
-						calculateCost := time.Since(start)
-						if checkpointRunner != nil {
-							// if checkpoint runner is running and the checksum is not from checkpoint
-							// then flush the checksum by the checkpoint runner
-							if err = checkpointRunner.FlushChecksum(ctx, schema.tableInfo.ID, schema.crc64xor, schema.totalKvs, schema.totalBytes); err != nil {
-								return errors.Trace(err)
-							}
-						}
-						logger.Info("Calculate table checksum completed",
-							zap.Uint64("Crc64Xor", schema.crc64xor),
-							zap.Uint64("TotalKvs", schema.totalKvs),
-							zap.Uint64("TotalBytes", schema.totalBytes),
-							zap.Duration("TimeTaken", calculateCost))
-					}
-				}
-				if statsHandle != nil {
-					statsWriter := metaWriter.NewStatsWriter()
-					if err := schema.dumpStatsToJSON(ctx, statsWriter, statsHandle, backupTS); err != nil {
-						logger.Error("dump table stats failed", logutil.ShortError(err))
-						return errors.Trace(err)
-					}
-				}
-			}
-			// Send schema to metawriter
-			s, err := schema.encodeToSchema()
-			if err != nil {
-				return errors.Trace(err)
-			}
-			if err := metaWriter.Send(s, op); err != nil {
-				return errors.Trace(err)
-			}
-			if updateCh != nil {
-				updateCh.Inc()
-			}
-			return nil
+			return backupSchema(ectx, schema)
 		})
 	})
 	if err != nil {
@@ -176,6 +185,17 @@ func (ss *Schemas) BackupSchemas(
 	if err := errg.Wait(); err != nil {
 		return errors.Trace(err)
 	}
+	for _, stage := range metautil.SortTablesByDependency(views) {
+		errg, ectx := errgroup.WithContext(ctx)
+		for _, view := range stage {
+			workerPool.ApplyOnErrorGroup(errg, func() error {
+				return backupSchema(ectx, &schemaInfo{tableInfo: view.Info, dbInfo: view.DB})
+			})
+		}
+		if err := errg.Wait(); err != nil {
+			return errors.Trace(err)
+		}
+	}
 	log.Info("Backup calculated table checksum into metas", zap.Duration("take", time.Since(startAll)))
 	summary.CollectDuration("backup checksum", time.Since(startAll))
 	return metaWriter.FinishWriteMetas(ctx, op)
@@ -4,6 +4,7 @@ package backup_test
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"math"
 	"strings"
@@ -18,6 +19,7 @@ import (
 	"github.com/pingcap/tidb/br/pkg/mock"
 	"github.com/pingcap/tidb/br/pkg/storage"
 	"github.com/pingcap/tidb/br/pkg/utils"
+	"github.com/pingcap/tidb/pkg/meta/model"
 	"github.com/pingcap/tidb/pkg/sessionctx/variable"
 	"github.com/pingcap/tidb/pkg/testkit"
 	filter "github.com/pingcap/tidb/pkg/util/table-filter"
@@ -198,6 +200,54 @@ func TestBuildBackupRangeAndSchema(t *testing.T) {
 	require.NotZerof(t, schemas[1].TotalBytes, "%v", schemas[1])
 }
 
+func TestBackupSchemasWithViews(t *testing.T) {
+	m := createMockCluster(t)
+
+	tk := testkit.NewTestKit(t, m.Storage)
+	tk.MustExec("use test")
+	tk.MustExec("create table t1 (a int);")
+	tk.MustExec("create view v1 as select a from t1;")
+	tk.MustExec("create view v2 as select a from v1;")
+	tk.MustExec("create view v3 as select v2.a from v2 join t1 on v2.a = t1.a;")
+	tk.MustExec("create table t2 (a int);")
+
+	testFilter, err := filter.Parse([]string{"test.*"})
+	require.NoError(t, err)
+	_, backupSchemas, _, err := backup.BuildBackupRangeAndInitSchema(
+		m.Storage, testFilter, math.MaxUint64, false, true)
+	require.NoError(t, err)
+	require.Equal(t, 5, backupSchemas.Len())
+
+	es := GetRandomStorage(t)
+	cipher := backuppb.CipherInfo{
+		CipherType: encryptionpb.EncryptionMethod_PLAINTEXT,
+	}
+	metaWriter := metautil.NewMetaWriter(es, metautil.MetaFileSize, false, "", &cipher)
+	ctx := context.Background()
+	updateCh := new(simpleProgress)
+	err = backupSchemas.BackupSchemas(
+		ctx, metaWriter, nil, m.Storage, nil, math.MaxUint64, 4, variable.DefChecksumTableConcurrency, true, updateCh)
+	require.NoError(t, err)
+	require.Equal(t, int64(5), updateCh.get())
+	require.NoError(t, metaWriter.FlushBackupMeta(ctx))
+
+	// read the backupmeta directly since the meta reader parses the schemas concurrently.
+	metaBytes, err := es.ReadFile(ctx, metautil.MetaFile)
+	require.NoError(t, err)
+	meta := &backuppb.BackupMeta{}
+	require.NoError(t, proto.Unmarshal(metaBytes, meta))
+	require.Len(t, meta.Schemas, 5)
+	names := make([]string, 0, len(meta.Schemas))
+	for _, s := range meta.Schemas {
+		tableInfo := &model.TableInfo{}
+		require.NoError(t, json.Unmarshal(s.Table, tableInfo))
+		names = append(names, tableInfo.Name.O)
+	}
+	// the views follow the tables they select from.
+	require.ElementsMatch(t, []string{"t1", "t2"}, names[:2])
+	require.Equal(t, []string{"v1", "v2", "v3"}, names[2:])
+}
+
 func TestBuildBackupRangeAndSchemaWithBrokenStats(t *testing.T) {
 	m := createMockCluster(t)
 
@@ -196,6 +196,8 @@ func startBackup(
 	backupReq backuppb.BackupRequest,
 	backupCli backuppb.BackupClient,
 	concurrency uint,
+	requestCount uint,
+	limiter *ConcurrencyLimiter,
 	respCh chan *ResponseAndStore,
 ) error {
 	// this goroutine handle the response from a single store
@@ -206,7 +208,7 @@ func startBackup(
 		// Send backup request to the store.
 		// handle the backup response or internal error here.
 		// handle the store error(reboot or network partition) outside.
-		reqs := SplitBackupReqRanges(backupReq, concurrency)
+		reqs := SplitBackupReqRanges(backupReq, requestCount)
 		logutil.CL(pctx).Info("starting backup to the corresponding store", zap.Uint64("storeID", storeID),
 			zap.Int("requestCount", len(reqs)), zap.Uint("concurrency", concurrency))
 
@@ -221,6 +223,12 @@ func startBackup(
 			bkReq := req
 			reqIndex := i
 			pool.ApplyOnErrorGroup(eg, func() error {
+				if limiter != nil {
+					if err := limiter.Acquire(ectx); err != nil {
+						return errors.Trace(err)
+					}
+					defer limiter.Release()
+				}
 				retry := -1
 				return utils.WithRetry(ectx, func() error {
 					retry += 1
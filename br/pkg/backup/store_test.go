@@ -63,7 +63,7 @@ func TestTimeoutRecv(t *testing.T) {
 				require.Error(t, ctx.Err())
 				return nil, ctx.Err()
 			},
-		}, 1, nil)
+		}, 1, 1, nil, nil)
 		require.Error(t, err)
 	}
 
@@ -82,7 +82,7 @@ func TestTimeoutRecv(t *testing.T) {
 				time.Sleep(time.Millisecond * 80)
 				return &backuppb.BackupResponse{}, nil
 			},
-		}, 1, make(chan *ResponseAndStore, 15))
+		}, 1, 1, nil, make(chan *ResponseAndStore, 15))
 		require.Error(t, err)
 		require.Equal(t, count, 15)
 	}
@@ -0,0 +1,112 @@
+// Copyright 2026 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package checkpoint
+
+import (
+	"context"
+	"strings"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/tidb/br/pkg/glue"
+	"github.com/pingcap/tidb/pkg/kv"
+	"github.com/pingcap/tidb/pkg/util/sqlexec"
+)
+
+// The applied files are the SST files ingested into a region of the target cluster. They're recorded
+// by the sha256 of the file, the region id and the version of the region epoch, so that the resumed
+// restore doesn't download the files into the region again, as long as the region isn't split or merged.
+const (
+	checkpointAppliedFileTableName string = "cpt_applied_file"
+
+	createAppliedFileTable string = `
+		CREATE TABLE IF NOT EXISTS %n.%n (
+			sha VARCHAR(64) NOT NULL,
+			region_id BIGINT UNSIGNED NOT NULL,
+			epoch_version BIGINT UNSIGNED NOT NULL,
+			PRIMARY KEY(sha, region_id, epoch_version));`
+
+	insertAppliedFileSQLPrefix string = `INSERT IGNORE INTO %n.%n (sha, region_id, epoch_version) VALUES `
+
+	selectAppliedFileSQLTemplate string = `SELECT sha, region_id, epoch_version FROM %n.%n;`
+
+	// appliedFileInsertBatch is the max count of the applied files inserted by a statement.
+	appliedFileInsertBatch = 256
+)
+
+// AppliedFileKey identifies an SST file ingested into a region.
+type AppliedFileKey struct {
+	// SHA256 is the hex encoded sha256 of the file.
+	SHA256       string
+	RegionID     uint64
+	EpochVersion uint64
+}
+
+// InitAppliedFileTable creates the table of the applied files if not exists.
+func InitAppliedFileTable(ctx context.Context, se glue.Session, dbName string) error {
+	if err := se.ExecuteInternal(ctx, "CREATE DATABASE IF NOT EXISTS %n;", dbName); err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(se.ExecuteInternal(ctx, createAppliedFileTable, dbName, checkpointAppliedFileTableName))
+}
+
+// LoadAppliedFiles loads the applied files, the table should be created by InitAppliedFileTable before,
+// since it doesn't exist in the checkpoint created by the older BR.
+func LoadAppliedFiles(
+	ctx context.Context,
+	execCtx sqlexec.RestrictedSQLExecutor,
+	dbName string,
+	fn func(AppliedFileKey),
+) error {
+	rows, _, errSQL := execCtx.ExecRestrictedSQL(
+		kv.WithInternalSourceType(ctx, kv.InternalTxnBR),
+		nil,
+		selectAppliedFileSQLTemplate,
+		dbName, checkpointAppliedFileTableName,
+	)
+	if errSQL != nil {
+		return errors.Annotatef(errSQL, "failed to get the applied files from table %s.%s",
+			dbName, checkpointAppliedFileTableName)
+	}
+	for _, row := range rows {
+		fn(AppliedFileKey{SHA256: row.GetString(0), RegionID: row.GetUint64(1), EpochVersion: row.GetUint64(2)})
+	}
+	return nil
+}
+
+// SaveAppliedFiles records the applied files, the recorded ones are ignored.
+func SaveAppliedFiles(ctx context.Context, se glue.Session, dbName string, keys []AppliedFileKey) error {
+	for len(keys) > 0 {
+		batch := keys[:min(len(keys), appliedFileInsertBatch)]
+		keys = keys[len(batch):]
+
+		var sql strings.Builder
+		sql.WriteString(insertAppliedFileSQLPrefix)
+		args := make([]any, 0, 2+3*len(batch))
+		args = append(args, dbName, checkpointAppliedFileTableName)
+		for i, key := range batch {
+			if i > 0 {
+				sql.WriteString(",")
+			}
+			sql.WriteString("(%?,%?,%?)")
+			args = append(args, key.SHA256, key.RegionID, key.EpochVersion)
+		}
+		sql.WriteString(";")
+		if err := se.ExecuteInternal(ctx, sql.String(), args...); err != nil {
+			return errors.Annotatef(err, "failed to save the applied files into table %s.%s",
+				dbName, checkpointAppliedFileTableName)
+		}
+	}
+	return nil
+}
@@ -145,6 +145,27 @@ func TestCheckpointMetaForRestore(t *testing.T) {
 	require.Equal(t, repairSQLs.SQLs[0].IndexName, "4")
 	require.Equal(t, repairSQLs.SQLs[0].AddSQL, "5")
 	require.Equal(t, repairSQLs.SQLs[0].AddArgs, []any{"6", "7", "8"})
+
+	// the savepoint of the meta kv apply is replaced by the later one.
+	exists = checkpoint.ExistsCheckpointMetaKVSavepoint(ctx, dom)
+	require.False(t, exists)
+	for i := 1; i <= 2; i++ {
+		err = checkpoint.SaveCheckpointMetaKVSavepoint(ctx, se, &checkpoint.CheckpointMetaKVSavepoint{
+			FilesDigest:    "digest",
+			AppliedBatches: i,
+			LastFilterTS:   uint64(100 * i),
+			LastCF:         "write",
+		})
+		require.NoError(t, err)
+	}
+	savepoint, err := checkpoint.LoadCheckpointMetaKVSavepoint(ctx, se.GetSessionCtx().GetRestrictedSQLExecutor())
+	require.NoError(t, err)
+	require.Equal(t, &checkpoint.CheckpointMetaKVSavepoint{
+		FilesDigest:    "digest",
+		AppliedBatches: 2,
+		LastFilterTS:   200,
+		LastCF:         "write",
+	}, savepoint)
 }
 
 type mockTimer struct {
@@ -643,3 +664,103 @@ func TestCheckpointCompactedRestoreRunner(t *testing.T) {
 	exists = s.Mock.Domain.InfoSchema().SchemaExists(ast.NewCIStr(checkpoint.CustomSSTRestoreCheckpointDatabaseName))
 	require.False(t, exists)
 }
+
+func TestRestoreToken(t *testing.T) {
+	ctx := context.Background()
+	s := utiltest.CreateRestoreSchemaSuite(t)
+	g := gluetidb.New()
+	se, err := g.CreateSession(s.Mock.Storage)
+	require.NoError(t, err)
+	execCtx := se.GetSessionCtx().GetRestrictedSQLExecutor()
+
+	require.NoError(t, checkpoint.InitRestoreTokenTable(ctx, se))
+	// it's fine to init the table again.
+	require.NoError(t, checkpoint.InitRestoreTokenTable(ctx, se))
+	token, err := checkpoint.LoadRestoreToken(ctx, execCtx, "job-1")
+	require.NoError(t, err)
+	require.Nil(t, token)
+
+	running := &checkpoint.RestoreToken{
+		Token:       "job-1",
+		Fingerprint: "abc",
+		Status:      checkpoint.RestoreTokenRunning,
+		Owner:       "run-1",
+	}
+	require.NoError(t, checkpoint.InsertRestoreToken(ctx, se, running))
+	// the recorded token is kept.
+	require.NoError(t, checkpoint.InsertRestoreToken(ctx, se, &checkpoint.RestoreToken{
+		Token:       "job-1",
+		Fingerprint: "abc",
+		Status:      checkpoint.RestoreTokenRunning,
+		Owner:       "run-2",
+	}))
+	token, err = checkpoint.LoadRestoreToken(ctx, execCtx, "job-1")
+	require.NoError(t, err)
+	require.Equal(t, running, token)
+
+	// the token is taken over only from its owner.
+	require.NoError(t, checkpoint.TakeOverRestoreToken(ctx, se, "job-1", "run-0", "run-3"))
+	require.NoError(t, checkpoint.TakeOverRestoreToken(ctx, se, "job-1", "run-1", "run-2"))
+	token, err = checkpoint.LoadRestoreToken(ctx, execCtx, "job-1")
+	require.NoError(t, err)
+	require.Equal(t, "run-2", token.Owner)
+
+	// the token is finished only by its owner.
+	require.NoError(t, checkpoint.FinishRestoreToken(ctx, se, "job-1", "run-1"))
+	token, err = checkpoint.LoadRestoreToken(ctx, execCtx, "job-1")
+	require.NoError(t, err)
+	require.Equal(t, checkpoint.RestoreTokenRunning, token.Status)
+	require.NoError(t, checkpoint.FinishRestoreToken(ctx, se, "job-1", "run-2"))
+	token, err = checkpoint.LoadRestoreToken(ctx, execCtx, "job-1")
+	require.NoError(t, err)
+	require.Equal(t, checkpoint.RestoreTokenFinished, token.Status)
+	// the finished token can't be taken over.
+	require.NoError(t, checkpoint.TakeOverRestoreToken(ctx, se, "job-1", "run-2", "run-3"))
+	token, err = checkpoint.LoadRestoreToken(ctx, execCtx, "job-1")
+	require.NoError(t, err)
+	require.Equal(t, "run-2", token.Owner)
+
+	// the finished token is deleted after the retention.
+	require.NoError(t, checkpoint.DeleteExpiredRestoreTokens(ctx, se, checkpoint.RestoreTokenRetention))
+	token, err = checkpoint.LoadRestoreToken(ctx, execCtx, "job-1")
+	require.NoError(t, err)
+	require.NotNil(t, token)
+	require.NoError(t, se.ExecuteInternal(ctx, "UPDATE %n.tokens SET update_time = DATE_SUB(NOW(), INTERVAL 8 DAY);",
+		checkpoint.RestoreTokenDatabaseName))
+	require.NoError(t, checkpoint.DeleteExpiredRestoreTokens(ctx, se, checkpoint.RestoreTokenRetention))
+	token, err = checkpoint.LoadRestoreToken(ctx, execCtx, "job-1")
+	require.NoError(t, err)
+	require.Nil(t, token)
+	require.True(t, checkpoint.IsCheckpointDB(ast.NewCIStr(checkpoint.RestoreTokenDatabaseName)))
+}
+
+func TestAppliedFiles(t *testing.T) {
+	ctx := context.Background()
+	s := utiltest.CreateRestoreSchemaSuite(t)
+	g := gluetidb.New()
+	se, err := g.CreateSession(s.Mock.Storage)
+	require.NoError(t, err)
+	execCtx := se.GetSessionCtx().GetRestrictedSQLExecutor()
+	dbName := checkpoint.SnapshotRestoreCheckpointDatabaseName
+
+	require.NoError(t, checkpoint.InitAppliedFileTable(ctx, se, dbName))
+	// it's fine to init the table again.
+	require.NoError(t, checkpoint.InitAppliedFileTable(ctx, se, dbName))
+
+	keys := make([]checkpoint.AppliedFileKey, 0, 300)
+	for i := range 300 {
+		keys = append(keys, checkpoint.AppliedFileKey{SHA256: fmt.Sprintf("%064x", i), RegionID: uint64(i % 7), EpochVersion: 3})
+	}
+	require.NoError(t, checkpoint.SaveAppliedFiles(ctx, se, dbName, keys))
+	// the recorded ones are ignored.
+	require.NoError(t, checkpoint.SaveAppliedFiles(ctx, se, dbName, keys[:10]))
+
+	loaded := make(map[checkpoint.AppliedFileKey]struct{})
+	require.NoError(t, checkpoint.LoadAppliedFiles(ctx, execCtx, dbName, func(key checkpoint.AppliedFileKey) {
+		loaded[key] = struct{}{}
+	}))
+	require.Len(t, loaded, len(keys))
+	for _, key := range keys {
+		require.Contains(t, loaded, key)
+	}
+}
@@ -0,0 +1,149 @@
+// Copyright 2026 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package checkpoint
+
+import (
+	"context"
+	"time"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/tidb/br/pkg/glue"
+	"github.com/pingcap/tidb/pkg/kv"
+	"github.com/pingcap/tidb/pkg/util/sqlexec"
+)
+
+// Unlike the checkpoint databases, the database of the restore tokens isn't dropped after the restore
+// finished, so the retried restore with a finished token can be skipped. The finished tokens are deleted
+// after RestoreTokenRetention.
+const (
+	RestoreTokenDatabaseName string = "__TiDB_BR_Restore_Tokens"
+
+	restoreTokenTableName string = "tokens"
+
+	createRestoreTokenTable string = `
+		CREATE TABLE IF NOT EXISTS %n.%n (
+			token VARCHAR(256) NOT NULL,
+			fingerprint VARCHAR(64) NOT NULL,
+			status VARCHAR(16) NOT NULL,
+			owner VARCHAR(64) NOT NULL,
+			update_time TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+			PRIMARY KEY(token));`
+
+	insertRestoreTokenSQLTemplate string = `
+		INSERT IGNORE INTO %n.%n (token, fingerprint, status, owner) VALUES (%?, %?, %?, %?);`
+
+	takeOverRestoreTokenSQLTemplate string = `
+		UPDATE %n.%n SET owner = %? WHERE token = %? AND owner = %? AND status = %?;`
+
+	finishRestoreTokenSQLTemplate string = `
+		UPDATE %n.%n SET status = %? WHERE token = %? AND owner = %?;`
+
+	deleteExpiredRestoreTokensSQLTemplate string = `
+		DELETE FROM %n.%n WHERE status = %? AND update_time < DATE_SUB(NOW(), INTERVAL %? SECOND);`
+
+	selectRestoreTokenSQLTemplate string = `
+		SELECT fingerprint, status, owner FROM %n.%n WHERE token = %?;`
+
+	// RestoreTokenRetention is how long a finished token is kept, the restore retried after it isn't skipped.
+	RestoreTokenRetention = 7 * 24 * time.Hour
+)
+
+// RestoreTokenStatus is the status of the restore recorded by the token.
+type RestoreTokenStatus string
+
+const (
+	// RestoreTokenRunning means the restore is started but not finished, the data files may be partially
+	// ingested, which are skipped by the checkpoint when the restore is retried.
+	RestoreTokenRunning RestoreTokenStatus = "running"
+	// RestoreTokenFinished means the restore is finished, the retried restore is skipped.
+	RestoreTokenFinished RestoreTokenStatus = "finished"
+)
+
+// RestoreToken is the idempotency token of a restore, which is given by the orchestrator retrying
+// the restore.
+type RestoreToken struct {
+	Token string
+	// Fingerprint identifies the restore using the token, the token can't be reused by another restore.
+	Fingerprint string
+	Status      RestoreTokenStatus
+	// Owner identifies the run of the restore holding the token, the token is only finished by its owner.
+	Owner string
+}
+
+// InitRestoreTokenTable creates the table of the restore tokens if not exists.
+func InitRestoreTokenTable(ctx context.Context, se glue.Session) error {
+	if err := se.ExecuteInternal(ctx, "CREATE DATABASE IF NOT EXISTS %n;", RestoreTokenDatabaseName); err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(se.ExecuteInternal(ctx, createRestoreTokenTable, RestoreTokenDatabaseName, restoreTokenTableName))
+}
+
+// LoadRestoreToken loads the restore token, it returns nil if the token isn't recorded.
+func LoadRestoreToken(
+	ctx context.Context,
+	execCtx sqlexec.RestrictedSQLExecutor,
+	token string,
+) (*RestoreToken, error) {
+	rows, _, errSQL := execCtx.ExecRestrictedSQL(
+		kv.WithInternalSourceType(ctx, kv.InternalTxnBR),
+		nil,
+		selectRestoreTokenSQLTemplate,
+		RestoreTokenDatabaseName, restoreTokenTableName, token,
+	)
+	if errSQL != nil {
+		return nil, errors.Annotatef(errSQL, "failed to get the restore token from table %s.%s",
+			RestoreTokenDatabaseName, restoreTokenTableName)
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	return &RestoreToken{
+		Token:       token,
+		Fingerprint: rows[0].GetString(0),
+		Status:      RestoreTokenStatus(rows[0].GetString(1)),
+		Owner:       rows[0].GetString(2),
+	}, nil
+}
+
+// InsertRestoreToken records the token if it isn't recorded, the recorded one is kept. The token should
+// be loaded again to tell whether it's inserted by the owner.
+func InsertRestoreToken(ctx context.Context, se glue.Session, token *RestoreToken) error {
+	err := se.ExecuteInternal(ctx, insertRestoreTokenSQLTemplate, RestoreTokenDatabaseName, restoreTokenTableName,
+		token.Token, token.Fingerprint, string(token.Status), token.Owner)
+	return errors.Annotatef(err, "failed to insert the restore token %s", token.Token)
+}
+
+// TakeOverRestoreToken changes the owner of the running token if it's still owned by `from`. The token
+// should be loaded again to tell whether it's taken over.
+func TakeOverRestoreToken(ctx context.Context, se glue.Session, token, from, to string) error {
+	err := se.ExecuteInternal(ctx, takeOverRestoreTokenSQLTemplate, RestoreTokenDatabaseName, restoreTokenTableName,
+		to, token, from, string(RestoreTokenRunning))
+	return errors.Annotatef(err, "failed to take over the restore token %s", token)
+}
+
+// FinishRestoreToken finishes the token if it's owned by the owner. The token should be loaded again to
+// tell whether it's finished.
+func FinishRestoreToken(ctx context.Context, se glue.Session, token, owner string) error {
+	err := se.ExecuteInternal(ctx, finishRestoreTokenSQLTemplate, RestoreTokenDatabaseName, restoreTokenTableName,
+		string(RestoreTokenFinished), token, owner)
+	return errors.Annotatef(err, "failed to finish the restore token %s", token)
+}
+
+// DeleteExpiredRestoreTokens deletes the tokens finished before the retention.
+func DeleteExpiredRestoreTokens(ctx context.Context, se glue.Session, retention time.Duration) error {
+	err := se.ExecuteInternal(ctx, deleteExpiredRestoreTokensSQLTemplate, RestoreTokenDatabaseName,
+		restoreTokenTableName, string(RestoreTokenFinished), int64(retention/time.Second))
+	return errors.Annotate(err, "failed to delete the expired restore tokens")
+}
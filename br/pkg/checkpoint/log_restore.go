@@ -298,6 +298,9 @@ func TryToGetCheckpointTaskInfo(
 }
 
 type CheckpointIngestIndexRepairSQL struct {
+	// TableID and JobID are the ones in the IngestRecorder, they're zero if saved by an older BR.
+	TableID    int64     `json:"table-id,omitempty"`
+	JobID      int64     `json:"job-id,omitempty"`
 	IndexID    int64     `json:"index-id"`
 	SchemaName ast.CIStr `json:"schema-name"`
 	TableName  ast.CIStr `json:"table-name"`
@@ -332,7 +335,45 @@ func SaveCheckpointIngestIndexRepairSQLs(
 	return insertCheckpointMeta(ctx, se, LogRestoreCheckpointDatabaseName, checkpointIngestTableName, meta)
 }
 
+// CheckpointMetaKVSavepoint is the savepoint of the meta kv apply. The meta kv files are applied in
+// batches, and the entries of a batch are all put into the cluster before the savepoint moves forward,
+// so the batches before the savepoint are durable and the others must be re-applied on resume.
+type CheckpointMetaKVSavepoint struct {
+	// FilesDigest is the digest of the meta kv files in the order they're applied, the savepoint
+	// is valid only if the files are split into batches the same way.
+	FilesDigest string `json:"files-digest"`
+	// AppliedBatches is the number of the leading batches applied durably.
+	AppliedBatches int `json:"applied-batches"`
+	// LastFilterTS and LastCF identify the last applied batch, they're for diagnosis only.
+	LastFilterTS uint64 `json:"last-filter-ts"`
+	LastCF       string `json:"last-cf"`
+}
+
+func LoadCheckpointMetaKVSavepoint(
+	ctx context.Context,
+	execCtx sqlexec.RestrictedSQLExecutor,
+) (*CheckpointMetaKVSavepoint, error) {
+	m := &CheckpointMetaKVSavepoint{}
+	err := selectCheckpointMeta(ctx, execCtx, LogRestoreCheckpointDatabaseName, checkpointMetaKVTableName, m)
+	return m, errors.Trace(err)
+}
+
+func ExistsCheckpointMetaKVSavepoint(ctx context.Context, dom *domain.Domain) bool {
+	return dom.InfoSchema().
+		TableExists(ast.NewCIStr(LogRestoreCheckpointDatabaseName), ast.NewCIStr(checkpointMetaKVTableName))
+}
+
+// SaveCheckpointMetaKVSavepoint saves the savepoint, it replaces the previous one.
+func SaveCheckpointMetaKVSavepoint(
+	ctx context.Context,
+	se glue.Session,
+	meta *CheckpointMetaKVSavepoint,
+) error {
+	return upsertCheckpointMeta(ctx, se, LogRestoreCheckpointDatabaseName, checkpointMetaKVTableName, meta)
+}
+
 func RemoveCheckpointDataForLogRestore(ctx context.Context, dom *domain.Domain, se glue.Session) error {
 	return dropCheckpointTables(ctx, dom, se, LogRestoreCheckpointDatabaseName,
-		[]string{checkpointDataTableName, checkpointMetaTableName, checkpointProgressTableName, checkpointIngestTableName})
+		[]string{checkpointDataTableName, checkpointMetaTableName, checkpointProgressTableName, checkpointIngestTableName,
+			checkpointMetaKVTableName})
 }
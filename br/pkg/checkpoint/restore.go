@@ -137,6 +137,39 @@ type CheckpointMetadataForSnapshotRestore struct {
 	UpstreamClusterID uint64                `json:"upstream-cluster-id"`
 	RestoredTS        uint64                `json:"restored-ts"`
 	SchedulersConfig  *pdutil.ClusterConfig `json:"schedulers-config"`
+	// Rollback is what the restore creates in the target cluster, it's nil in the checkpoint created by
+	// the older BR, which can't be rolled back.
+	Rollback *RestoreRollbackInfo `json:"rollback,omitempty"`
+}
+
+// The kinds of the tables created by the restore, they are dropped by the different statements.
+const (
+	RollbackKindTable    = "table"
+	RollbackKindView     = "view"
+	RollbackKindSequence = "sequence"
+)
+
+// RollbackTable is a table created by the restore in an existing database.
+type RollbackTable struct {
+	DB   string `json:"db"`
+	Name string `json:"name"`
+	Kind string `json:"kind"`
+}
+
+// RestoreRollbackInfo is what the snapshot restore creates in the target cluster, recorded at the first
+// run before anything is created, so that the partially completed restore can be rolled back by
+// `br restore rollback`.
+type RestoreRollbackInfo struct {
+	// StartTS is the ts before the restore creates anything, the schemas at it are the ones the rollback
+	// returns to.
+	StartTS       uint64 `json:"start-ts"`
+	SchemaVersion int64  `json:"schema-version"`
+	// Online is whether the restore sets the placement rules of the tables to restore.
+	Online bool `json:"online"`
+	// Databases are the databases created by the restore, the tables in them are dropped with them.
+	Databases []string        `json:"databases"`
+	Tables    []RollbackTable `json:"tables"`
+	Policies  []string        `json:"policies"`
 }
 
 func LoadCheckpointMetadataForSnapshotRestore(
@@ -178,5 +211,5 @@ func ExistsSstRestoreCheckpoint(
 
 func RemoveCheckpointDataForSstRestore(ctx context.Context, dom *domain.Domain, se glue.Session, dbName string) error {
 	return dropCheckpointTables(ctx, dom, se, dbName,
-		[]string{checkpointDataTableName, checkpointChecksumTableName, checkpointMetaTableName})
+		[]string{checkpointDataTableName, checkpointChecksumTableName, checkpointMetaTableName, checkpointAppliedFileTableName})
 }
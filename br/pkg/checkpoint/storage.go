@@ -58,6 +58,7 @@ const (
 	checkpointMetaTableName     string = "cpt_metadata"
 	checkpointProgressTableName string = "cpt_progress"
 	checkpointIngestTableName   string = "cpt_ingest"
+	checkpointMetaKVTableName   string = "cpt_meta_kv"
 
 	// the primary key (uuid: uuid, segment_id:0) records the number of segment
 	createCheckpointTable string = `
@@ -82,6 +83,13 @@ const (
 			update_time TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
 			PRIMARY KEY(segment_id));`
 
+	createCheckpointMetaTableIfNotExists string = `
+		CREATE TABLE IF NOT EXISTS %n.%n (
+			segment_id BIGINT NOT NULL,
+			data BLOB(524288) NOT NULL,
+			update_time TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY(segment_id));`
+
 	insertCheckpointMetaSQLTemplate string = `
 		REPLACE INTO %n.%n (segment_id, data) VALUES (%?, %?);`
 
@@ -92,7 +100,8 @@ const (
 func IsCheckpointDB(dbname ast.CIStr) bool {
 	return dbname.O == LogRestoreCheckpointDatabaseName ||
 		dbname.O == SnapshotRestoreCheckpointDatabaseName ||
-		dbname.O == CustomSSTRestoreCheckpointDatabaseName
+		dbname.O == CustomSSTRestoreCheckpointDatabaseName ||
+		dbname.O == RestoreTokenDatabaseName
 }
 
 const CheckpointIdMapBlockSize int = 524288
@@ -288,6 +297,25 @@ func insertCheckpointMeta[T any](ctx context.Context, se glue.Session, dbName st
 	return errors.Trace(err)
 }
 
+// upsertCheckpointMeta is like insertCheckpointMeta but the meta can be saved repeatedly, the meta
+// should be small enough to be in a single segment so that it's replaced atomically.
+func upsertCheckpointMeta[T any](ctx context.Context, se glue.Session, dbName string, tableName string, meta *T) error {
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if len(data) > CheckpointIdMapBlockSize {
+		return errors.Errorf("the checkpoint meta is too large to be saved into table %s.%s", dbName, tableName)
+	}
+	if err := se.ExecuteInternal(ctx, "CREATE DATABASE IF NOT EXISTS %n;", dbName); err != nil {
+		return errors.Trace(err)
+	}
+	if err := se.ExecuteInternal(ctx, createCheckpointMetaTableIfNotExists, dbName, tableName); err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(se.ExecuteInternal(ctx, insertCheckpointMetaSQLTemplate, dbName, tableName, 0, data))
+}
+
 func selectCheckpointMeta(
 	ctx context.Context,
 	execCtx sqlexec.RestrictedSQLExecutor,
@@ -3,6 +3,7 @@ package config
 
 import (
 	"encoding/json"
+	"strings"
 
 	"github.com/docker/go-units"
 )
@@ -72,3 +73,72 @@ func ParseLogBackupEnableFromConfig(resp []byte) (bool, error) {
 	}
 	return c.LogBackup.Enable, nil
 }
+
+// EncryptionAtRest is the encryption at rest config of a TiKV store.
+type EncryptionAtRest struct {
+	// DataEncryptionMethod is the method encrypting the data files, "plaintext" means disabled.
+	DataEncryptionMethod string
+	// MasterKeyType is the type of the master key encrypting the data keys, e.g. "file" or "kms".
+	MasterKeyType string
+}
+
+// Enabled returns whether the data files are encrypted.
+func (e EncryptionAtRest) Enabled() bool {
+	return e.DataEncryptionMethod != "" && !strings.EqualFold(e.DataEncryptionMethod, "plaintext")
+}
+
+// DataKeysProtected returns whether the data keys are encrypted by a master key.
+func (e EncryptionAtRest) DataKeysProtected() bool {
+	return e.MasterKeyType != "" && !strings.EqualFold(e.MasterKeyType, "plaintext")
+}
+
+func ParseEncryptionAtRestFromConfig(resp []byte) (EncryptionAtRest, error) {
+	type masterKey struct {
+		Type string `json:"type"`
+	}
+	type encryption struct {
+		DataEncryptionMethod string    `json:"data-encryption-method"`
+		MasterKey            masterKey `json:"master-key"`
+	}
+	type security struct {
+		Encryption encryption `json:"encryption"`
+	}
+	type config struct {
+		Security security `json:"security"`
+	}
+	var c config
+	e := json.Unmarshal(resp, &c)
+	if e != nil {
+		return EncryptionAtRest{}, e
+	}
+	return EncryptionAtRest{
+		DataEncryptionMethod: c.Security.Encryption.DataEncryptionMethod,
+		MasterKeyType:        c.Security.Encryption.MasterKey.Type,
+	}, nil
+}
+
+// ParseConfigItemsFromConfig parses the config items from the config, the items are the dotted paths like
+// "coprocessor.region-split-size". The items not found in the config are skipped.
+func ParseConfigItemsFromConfig(resp []byte, items []string) (map[string]any, error) {
+	var c map[string]any
+	e := json.Unmarshal(resp, &c)
+	if e != nil {
+		return nil, e
+	}
+	values := make(map[string]any, len(items))
+	for _, item := range items {
+		var value any = c
+		for _, key := range strings.Split(item, ".") {
+			section, ok := value.(map[string]any)
+			if !ok {
+				value = nil
+				break
+			}
+			value = section[key]
+		}
+		if value != nil {
+			values[item] = value
+		}
+	}
+	return values, nil
+}
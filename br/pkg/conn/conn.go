@@ -3,8 +3,10 @@
 package conn
 
 import (
+	"bytes"
 	"context"
 	"crypto/tls"
+	"encoding/json"
 	"fmt"
 	"io"
 	"net"
@@ -32,6 +34,7 @@ import (
 	"github.com/pingcap/tidb/pkg/ddl"
 	"github.com/pingcap/tidb/pkg/domain"
 	"github.com/pingcap/tidb/pkg/kv"
+	tikvconfig "github.com/tikv/client-go/v2/config"
 	"github.com/tikv/client-go/v2/oracle"
 	"github.com/tikv/client-go/v2/tikv"
 	"github.com/tikv/client-go/v2/txnkv/txnlock"
@@ -75,6 +78,7 @@ type Mgr struct {
 	storage     kv.Storage   // Used to access SQL related interfaces.
 	tikvStore   tikv.Storage // Used to access TiKV specific interfaces.
 	ownsStorage bool
+	pdTLSConf   *tls.Config
 
 	*utils.StoreManager
 }
@@ -151,8 +155,10 @@ func NewMgr(
 	ctx context.Context,
 	g glue.Glue,
 	pdAddrs []string,
-	tlsConf *tls.Config,
+	pdTLSConf *tls.Config,
 	securityOption pd.SecurityOption,
+	tikvTLSConf *tls.Config,
+	tikvSecurity tikvconfig.Security,
 	keepalive keepalive.ClientParameters,
 	storeBehavior util.StoreBehavior,
 	checkRequirements bool,
@@ -167,7 +173,8 @@ func NewMgr(
 
 	log.Info("new mgr", zap.Strings("pdAddrs", pdAddrs))
 
-	controller, err := pdutil.NewPdController(ctx, pdAddrs, tlsConf, securityOption)
+	// the pd client dials by the TLS config reloading the rotated certificates, like the other clients.
+	controller, err := pdutil.NewPdController(ctx, pdAddrs, pdTLSConf, securityOption, pdutil.WithTLSDialer())
 	if err != nil {
 		log.Error("failed to create pd controller", zap.Error(err))
 		return nil, errors.Trace(err)
@@ -203,7 +210,7 @@ func NewMgr(
 		"tikv://%s?disableGC=true&keyspaceName=%s",
 		strings.Join(pdAddrs, ","), config.GetGlobalKeyspaceName(),
 	)
-	storage, err := g.Open(path, securityOption)
+	storage, err := g.Open(path, securityOption, tikvSecurity)
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
@@ -235,7 +242,8 @@ func NewMgr(
 		tikvStore:    tikvStorage,
 		dom:          dom,
 		ownsStorage:  g.OwnsStorage(),
-		StoreManager: utils.NewStoreManager(controller.GetPDClient(), keepalive, tlsConf),
+		pdTLSConf:    pdTLSConf,
+		StoreManager: utils.NewStoreManager(controller.GetPDClient(), keepalive, tikvTLSConf),
 	}
 	return mgr, nil
 }
@@ -266,11 +274,16 @@ func (mgr *Mgr) GetStorage() kv.Storage {
 	return mgr.storage
 }
 
-// GetTLSConfig returns the tls config.
+// GetTLSConfig returns the tls config of the connections to TiKV.
 func (mgr *Mgr) GetTLSConfig() *tls.Config {
 	return mgr.StoreManager.TLSConfig()
 }
 
+// GetPDTLSConfig returns the tls config of the connections to PD.
+func (mgr *Mgr) GetPDTLSConfig() *tls.Config {
+	return mgr.pdTLSConf
+}
+
 // GetStore gets the tikvStore.
 func (mgr *Mgr) GetStore() tikv.Storage {
 	return mgr.tikvStore
@@ -385,8 +398,125 @@ func (mgr *Mgr) IsLogBackupEnabled(ctx context.Context, client *http.Client) (bo
 	return logbackupEnable, errors.Trace(err)
 }
 
+// GetEncryptionAtRestOfTiKV gets the encryption at rest config of all alive tikv stores, keyed by the store IDs.
+func (mgr *Mgr) GetEncryptionAtRestOfTiKV(ctx context.Context, client *http.Client) (map[uint64]kvconfig.EncryptionAtRest, error) {
+	encryptions := make(map[uint64]kvconfig.EncryptionAtRest)
+	err := mgr.GetConfigFromTiKVStores(ctx, client, func(store *metapb.Store, resp *http.Response) error {
+		respBytes, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return err
+		}
+		encryption, err := kvconfig.ParseEncryptionAtRestFromConfig(respBytes)
+		if err != nil {
+			log.Warn("Failed to parse encryption at rest from config", zap.Uint64("store-id", store.GetId()),
+				logutil.ShortError(err))
+			return err
+		}
+		encryptions[store.GetId()] = encryption
+		return nil
+	})
+	return encryptions, errors.Trace(err)
+}
+
+// GetConfigItemsOfTiKV gets the config items of all alive tikv stores keyed by the store IDs, the items are
+// the dotted paths like "coprocessor.region-split-size".
+func (mgr *Mgr) GetConfigItemsOfTiKV(
+	ctx context.Context,
+	client *http.Client,
+	items []string,
+) (map[uint64]map[string]any, error) {
+	configs := make(map[uint64]map[string]any)
+	err := mgr.GetConfigFromTiKVStores(ctx, client, func(store *metapb.Store, resp *http.Response) error {
+		respBytes, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return err
+		}
+		values, err := kvconfig.ParseConfigItemsFromConfig(respBytes, items)
+		if err != nil {
+			log.Warn("Failed to parse config items from config", zap.Uint64("store-id", store.GetId()),
+				logutil.ShortError(err))
+			return err
+		}
+		configs[store.GetId()] = values
+		return nil
+	})
+	return configs, errors.Trace(err)
+}
+
+// SetConfigOfTiKV changes the config items of all alive tikv stores online, the items are the dotted paths
+// like "coprocessor.region-split-size".
+func (mgr *Mgr) SetConfigOfTiKV(ctx context.Context, cli *http.Client, items map[string]any) error {
+	body, err := json.Marshal(items)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	allStores, err := GetAllTiKVStoresWithRetry(ctx, mgr.GetPDClient(), util.SkipTiFlash)
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	httpPrefix := "http://"
+	if mgr.GetTLSConfig() != nil {
+		httpPrefix = "https://"
+	}
+
+	for _, store := range allStores {
+		if store.State != metapb.StoreState_Up {
+			continue
+		}
+		addr, err := handleTiKVAddress(store, httpPrefix)
+		if err != nil {
+			return err
+		}
+		configAddr := fmt.Sprintf("%s/config", addr.String())
+
+		var (
+			status string
+			msg    []byte
+		)
+		err = utils.WithRetry(ctx, func() error {
+			req, e := http.NewRequestWithContext(ctx, http.MethodPost, configAddr, bytes.NewReader(body))
+			if e != nil {
+				return e
+			}
+			resp, e := cli.Do(req)
+			if e != nil {
+				return e
+			}
+			defer resp.Body.Close()
+			status = ""
+			if resp.StatusCode != http.StatusOK {
+				status = resp.Status
+				msg, _ = io.ReadAll(resp.Body)
+			}
+			return nil
+		}, utils.NewAggressivePDBackoffStrategy())
+		if err != nil {
+			return errors.Trace(err)
+		}
+		// the items rejected by the store are not retried, e.g. the items can't be changed online.
+		if status != "" {
+			return errors.Annotatef(berrors.ErrKVUnknown, "failed to change the config of the store %d: %s %s",
+				store.GetId(), status, msg)
+		}
+	}
+	return nil
+}
+
 // GetConfigFromTiKV get configs from all alive tikv stores.
 func (mgr *Mgr) GetConfigFromTiKV(ctx context.Context, cli *http.Client, fn func(*http.Response) error) error {
+	return mgr.GetConfigFromTiKVStores(ctx, cli, func(_ *metapb.Store, resp *http.Response) error {
+		return fn(resp)
+	})
+}
+
+// GetConfigFromTiKVStores gets configs from all alive tikv stores like GetConfigFromTiKV, fn is called with
+// the store of the config.
+func (mgr *Mgr) GetConfigFromTiKVStores(
+	ctx context.Context,
+	cli *http.Client,
+	fn func(*metapb.Store, *http.Response) error,
+) error {
 	allStores, err := GetAllTiKVStoresWithRetry(ctx, mgr.GetPDClient(), util.SkipTiFlash)
 	if err != nil {
 		return errors.Trace(err)
@@ -415,7 +545,7 @@ func (mgr *Mgr) GetConfigFromTiKV(ctx context.Context, cli *http.Client, fn func
 				return e
 			}
 			defer resp.Body.Close()
-			err = fn(resp)
+			err = fn(store, resp)
 			if err != nil {
 				return err
 			}
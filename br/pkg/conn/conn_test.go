@@ -4,6 +4,7 @@ package conn_test
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -17,6 +18,7 @@ import (
 	kvconfig "github.com/pingcap/tidb/br/pkg/config"
 	"github.com/pingcap/tidb/br/pkg/conn"
 	"github.com/pingcap/tidb/br/pkg/conn/util"
+	berrors "github.com/pingcap/tidb/br/pkg/errors"
 	"github.com/pingcap/tidb/br/pkg/pdutil"
 	"github.com/pingcap/tidb/br/pkg/restore/split"
 	"github.com/stretchr/testify/require"
@@ -621,6 +623,89 @@ func TestIsLogBackupEnabled(t *testing.T) {
 	}
 }
 
+func TestGetEncryptionAtRestOfTiKV(t *testing.T) {
+	stores := []*metapb.Store{
+		{Id: 1, State: metapb.StoreState_Up},
+		{Id: 2, State: metapb.StoreState_Up},
+		{Id: 3, State: metapb.StoreState_Offline},
+	}
+	contents := []string{
+		`{"security": {"encryption": {"data-encryption-method": "aes256-ctr", "master-key": {"type": "kms"}}}}`,
+		`{"security": {"encryption": {"data-encryption-method": "plaintext", "master-key": {"type": "plaintext"}}}}`,
+	}
+	count := 0
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, contents[count])
+		count++
+	}))
+	defer mockServer.Close()
+	for _, s := range stores {
+		s.Address = mockServer.URL
+		s.StatusAddress = mockServer.URL
+	}
+
+	mgr := &conn.Mgr{PdController: &pdutil.PdController{}}
+	mgr.PdController.SetPDClient(split.NewFakePDClient(stores, false, nil))
+	encryptions, err := mgr.GetEncryptionAtRestOfTiKV(context.Background(), mockServer.Client())
+	require.NoError(t, err)
+	require.Len(t, encryptions, 2)
+	require.True(t, encryptions[1].Enabled())
+	require.True(t, encryptions[1].DataKeysProtected())
+	require.Equal(t, "aes256-ctr", encryptions[1].DataEncryptionMethod)
+	require.False(t, encryptions[2].Enabled())
+	require.False(t, encryptions[2].DataKeysProtected())
+}
+
+func TestGetAndSetConfigOfTiKV(t *testing.T) {
+	stores := []*metapb.Store{
+		{Id: 1, State: metapb.StoreState_Up},
+		{Id: 2, State: metapb.StoreState_Up},
+		{Id: 3, State: metapb.StoreState_Offline},
+	}
+	config := map[string]any{"coprocessor": map[string]any{"region-split-size": "96MiB"}}
+	var posted []map[string]any
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			_ = json.NewEncoder(w).Encode(config)
+			return
+		}
+		items := make(map[string]any)
+		if err := json.NewDecoder(r.Body).Decode(&items); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if _, ok := items["storage.api-version"]; ok {
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = fmt.Fprint(w, "config storage.api-version can not be changed")
+			return
+		}
+		posted = append(posted, items)
+	}))
+	defer mockServer.Close()
+	for _, s := range stores {
+		s.Address = mockServer.URL
+		s.StatusAddress = mockServer.URL
+	}
+
+	ctx := context.Background()
+	mgr := &conn.Mgr{PdController: &pdutil.PdController{}}
+	mgr.PdController.SetPDClient(split.NewFakePDClient(stores, false, nil))
+	configs, err := mgr.GetConfigItemsOfTiKV(ctx, mockServer.Client(),
+		[]string{"coprocessor.region-split-size", "coprocessor.region-split-keys", "gc.ratio-threshold"})
+	require.NoError(t, err)
+	require.Equal(t, map[uint64]map[string]any{
+		1: {"coprocessor.region-split-size": "96MiB"},
+		2: {"coprocessor.region-split-size": "96MiB"},
+	}, configs)
+
+	items := map[string]any{"coprocessor.region-split-size": "144MiB"}
+	require.NoError(t, mgr.SetConfigOfTiKV(ctx, mockServer.Client(), items))
+	require.Equal(t, []map[string]any{items, items}, posted)
+	err = mgr.SetConfigOfTiKV(ctx, mockServer.Client(), map[string]any{"storage.api-version": 2})
+	require.ErrorIs(t, err, berrors.ErrKVUnknown)
+	require.ErrorContains(t, err, "can not be changed")
+}
+
 func TestHandleTiKVAddress(t *testing.T) {
 	cases := []struct {
 		store      *metapb.Store
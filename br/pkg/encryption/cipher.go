@@ -0,0 +1,124 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package encryption
+
+import (
+	"bytes"
+	"encoding/hex"
+	"os"
+
+	"github.com/pingcap/errors"
+	backuppb "github.com/pingcap/kvproto/pkg/brpb"
+	"github.com/pingcap/kvproto/pkg/encryptionpb"
+	berrors "github.com/pingcap/tidb/br/pkg/errors"
+)
+
+const (
+	crypterAES128KeyLen = 16
+	crypterAES192KeyLen = 24
+	crypterAES256KeyLen = 32
+
+	cipherKeyNonHexErrorMsg = "cipher key must be a valid hexadecimal string"
+)
+
+// ParseCipherType parses the crypter method given by the flags.
+func ParseCipherType(t string) (encryptionpb.EncryptionMethod, error) {
+	ct := encryptionpb.EncryptionMethod_UNKNOWN
+	switch t {
+	case "plaintext", "PLAINTEXT":
+		ct = encryptionpb.EncryptionMethod_PLAINTEXT
+	case "aes128-ctr", "AES128-CTR":
+		ct = encryptionpb.EncryptionMethod_AES128_CTR
+	case "aes192-ctr", "AES192-CTR":
+		ct = encryptionpb.EncryptionMethod_AES192_CTR
+	case "aes256-ctr", "AES256-CTR":
+		ct = encryptionpb.EncryptionMethod_AES256_CTR
+	default:
+		return ct, errors.Annotatef(berrors.ErrInvalidArgument, "invalid crypter method '%s'", t)
+	}
+
+	return ct, nil
+}
+
+func checkCipherKey(cipherKey, cipherKeyFile string) error {
+	if (len(cipherKey) == 0) == (len(cipherKeyFile) == 0) {
+		return errors.Annotate(berrors.ErrInvalidArgument,
+			"exactly one of cipher key or keyfile path should be provided")
+	}
+	return nil
+}
+
+// GetCipherKeyContent decodes the hex cipher key given directly or by the key file.
+func GetCipherKeyContent(cipherKey, cipherKeyFile string) ([]byte, error) {
+	if err := checkCipherKey(cipherKey, cipherKeyFile); err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	var hexString string
+
+	// Check if cipher-key is provided directly
+	if len(cipherKey) > 0 {
+		hexString = cipherKey
+	} else {
+		// Read content from cipher-file
+		content, err := os.ReadFile(cipherKeyFile)
+		if err != nil {
+			return nil, errors.Annotate(err, "failed to read cipher file")
+		}
+		hexString = string(bytes.TrimSuffix(content, []byte("\n")))
+	}
+
+	// Attempt to decode the hex string
+	decodedKey, err := hex.DecodeString(hexString)
+	if err != nil {
+		return nil, errors.Annotate(berrors.ErrInvalidArgument, cipherKeyNonHexErrorMsg)
+	}
+
+	return decodedKey, nil
+}
+
+// CheckCipherKeyMatch checks the length of the cipher key matches the crypter method.
+func CheckCipherKeyMatch(cipher *backuppb.CipherInfo) error {
+	switch cipher.CipherType {
+	case encryptionpb.EncryptionMethod_PLAINTEXT:
+		return nil
+	case encryptionpb.EncryptionMethod_AES128_CTR:
+		if len(cipher.CipherKey) != crypterAES128KeyLen {
+			return errors.Annotatef(berrors.ErrInvalidArgument, "AES-128 key length mismatch: expected %d, got %d",
+				crypterAES128KeyLen, len(cipher.CipherKey))
+		}
+	case encryptionpb.EncryptionMethod_AES192_CTR:
+		if len(cipher.CipherKey) != crypterAES192KeyLen {
+			return errors.Annotatef(berrors.ErrInvalidArgument, "AES-192 key length mismatch: expected %d, got %d",
+				crypterAES192KeyLen, len(cipher.CipherKey))
+		}
+	case encryptionpb.EncryptionMethod_AES256_CTR:
+		if len(cipher.CipherKey) != crypterAES256KeyLen {
+			return errors.Annotatef(berrors.ErrInvalidArgument, "AES-256 key length mismatch: expected %d, got %d",
+				crypterAES256KeyLen, len(cipher.CipherKey))
+		}
+	default:
+		return errors.Errorf("Unknown encryption method: %v", cipher.CipherType)
+	}
+	return nil
+}
+
+// ParseCipherInfo parses the crypter method and the key given directly or by the key file, the key isn't
+// read if the method is plaintext.
+func ParseCipherInfo(method, cipherKey, cipherKeyFile string) (*backuppb.CipherInfo, error) {
+	cipherType, err := ParseCipherType(method)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	cipher := &backuppb.CipherInfo{CipherType: cipherType}
+	if cipherType == encryptionpb.EncryptionMethod_PLAINTEXT {
+		return cipher, nil
+	}
+	if cipher.CipherKey, err = GetCipherKeyContent(cipherKey, cipherKeyFile); err != nil {
+		return nil, errors.Trace(err)
+	}
+	if err := CheckCipherKeyMatch(cipher); err != nil {
+		return nil, errors.Trace(err)
+	}
+	return cipher, nil
+}
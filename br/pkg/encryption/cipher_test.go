@@ -0,0 +1,163 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package encryption
+
+import (
+	"fmt"
+	"testing"
+
+	backuppb "github.com/pingcap/kvproto/pkg/brpb"
+	"github.com/pingcap/kvproto/pkg/encryptionpb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckCipherKeyMatch(t *testing.T) {
+	cases := []struct {
+		name       string
+		cipherInfo *backuppb.CipherInfo
+		expectErr  bool
+		errMsg     string
+	}{
+		{
+			name: "PLAINTEXT",
+			cipherInfo: &backuppb.CipherInfo{
+				CipherType: encryptionpb.EncryptionMethod_PLAINTEXT,
+			},
+			expectErr: false,
+		},
+		{
+			name: "UNKNOWN",
+			cipherInfo: &backuppb.CipherInfo{
+				CipherType: encryptionpb.EncryptionMethod_UNKNOWN,
+			},
+			expectErr: true,
+			errMsg:    "Unknown encryption method: UNKNOWN",
+		},
+		{
+			name: "AES128_CTR valid",
+			cipherInfo: &backuppb.CipherInfo{
+				CipherType: encryptionpb.EncryptionMethod_AES128_CTR,
+				CipherKey:  make([]byte, crypterAES128KeyLen),
+			},
+			expectErr: false,
+		},
+		{
+			name: "AES128_CTR invalid length",
+			cipherInfo: &backuppb.CipherInfo{
+				CipherType: encryptionpb.EncryptionMethod_AES128_CTR,
+				CipherKey:  make([]byte, crypterAES128KeyLen-1),
+			},
+			expectErr: true,
+			errMsg:    fmt.Sprintf("AES-128 key length mismatch: expected %d, got %d", crypterAES128KeyLen, crypterAES128KeyLen-1),
+		},
+		{
+			name: "AES192_CTR valid",
+			cipherInfo: &backuppb.CipherInfo{
+				CipherType: encryptionpb.EncryptionMethod_AES192_CTR,
+				CipherKey:  make([]byte, crypterAES192KeyLen),
+			},
+			expectErr: false,
+		},
+		{
+			name: "AES192_CTR invalid length",
+			cipherInfo: &backuppb.CipherInfo{
+				CipherType: encryptionpb.EncryptionMethod_AES192_CTR,
+				CipherKey:  make([]byte, crypterAES192KeyLen+1),
+			},
+			expectErr: true,
+			errMsg:    fmt.Sprintf("AES-192 key length mismatch: expected %d, got %d", crypterAES192KeyLen, crypterAES192KeyLen+1),
+		},
+		{
+			name: "AES256_CTR valid",
+			cipherInfo: &backuppb.CipherInfo{
+				CipherType: encryptionpb.EncryptionMethod_AES256_CTR,
+				CipherKey:  make([]byte, crypterAES256KeyLen),
+			},
+			expectErr: false,
+		},
+		{
+			name: "AES256_CTR invalid length",
+			cipherInfo: &backuppb.CipherInfo{
+				CipherType: encryptionpb.EncryptionMethod_AES256_CTR,
+				CipherKey:  make([]byte, 0),
+			},
+			expectErr: true,
+			errMsg:    fmt.Sprintf("AES-256 key length mismatch: expected %d, got %d", crypterAES256KeyLen, 0),
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := CheckCipherKeyMatch(c.cipherInfo)
+			if c.expectErr {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), c.errMsg)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestCheckCipherKey(t *testing.T) {
+	cases := []struct {
+		cipherKey string
+		keyFile   string
+		ok        bool
+	}{
+		{
+			cipherKey: "0123456789abcdef0123456789abcdef",
+			keyFile:   "",
+			ok:        true,
+		},
+		{
+			cipherKey: "0123456789abcdef0123456789abcdef",
+			keyFile:   "/tmp/abc",
+			ok:        false,
+		},
+		{
+			cipherKey: "",
+			keyFile:   "/tmp/abc",
+			ok:        true,
+		},
+		{
+			cipherKey: "",
+			keyFile:   "",
+			ok:        false,
+		},
+	}
+
+	for _, c := range cases {
+		err := checkCipherKey(c.cipherKey, c.keyFile)
+		if c.ok {
+			require.NoError(t, err)
+		} else {
+			require.Error(t, err)
+		}
+	}
+}
+
+func TestGetCipherKey(t *testing.T) {
+	nonHexKey := "this is not a hex string"
+	_, err := GetCipherKeyContent(nonHexKey, "")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), cipherKeyNonHexErrorMsg)
+}
+
+func TestParseCipherInfo(t *testing.T) {
+	cipher, err := ParseCipherInfo("plaintext", "", "")
+	require.NoError(t, err)
+	require.Equal(t, encryptionpb.EncryptionMethod_PLAINTEXT, cipher.CipherType)
+
+	cipher, err = ParseCipherInfo("aes128-ctr", "0123456789abcdef0123456789abcdef", "")
+	require.NoError(t, err)
+	require.Equal(t, encryptionpb.EncryptionMethod_AES128_CTR, cipher.CipherType)
+	require.Len(t, cipher.CipherKey, crypterAES128KeyLen)
+
+	_, err = ParseCipherInfo("aes256-ctr", "0123456789abcdef0123456789abcdef", "")
+	require.ErrorContains(t, err, "AES-256 key length mismatch")
+	_, err = ParseCipherInfo("aes128-ctr", "", "")
+	require.ErrorContains(t, err, "exactly one of cipher key or keyfile path should be provided")
+	_, err = ParseCipherInfo("sm4-ctr", "", "")
+	require.ErrorContains(t, err, "invalid crypter method")
+}
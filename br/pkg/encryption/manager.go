@@ -62,9 +62,8 @@ func (m *Manager) Decrypt(ctx context.Context, content []byte, fileEncryptionInf
 		if len(encryptedContents) == 0 {
 			return nil, errors.New("should contain at least one encrypted data key")
 		}
-		// pick first one, the list is for future expansion of multiple encrypted data keys by different master key backend
-		encryptedContent := encryptedContents[0]
-		decryptedDataKey, err := m.masterKeyBackends.Decrypt(ctx, encryptedContent)
+		// the data key may be wrapped by several master keys, any one of them is enough.
+		decryptedDataKey, err := m.masterKeyBackends.DecryptAny(ctx, encryptedContents)
 		if err != nil {
 			return nil, errors.Annotate(err, "failed to decrypt data key using master key")
 		}
@@ -83,6 +82,38 @@ func (m *Manager) Decrypt(ctx context.Context, content []byte, fileEncryptionInf
 	}
 }
 
+// AddMasterKey unwraps the data key of the file with the master keys held by the manager,
+// then wraps it again with the new master keys and appends the results to the envelope.
+// The new master keys already in the envelope are skipped, so adding them again is a no-op.
+// It returns false if the file isn't encrypted by master key based encryption, or nothing is added.
+func (m *Manager) AddMasterKey(
+	ctx context.Context,
+	fileEncryptionInfo *encryptionpb.FileEncryptionInfo,
+	newMasterKeys *encryption.MultiMasterKeyBackend,
+) (bool, error) {
+	masterKeyBased := fileEncryptionInfo.GetMasterKeyBased()
+	if masterKeyBased == nil {
+		return false, nil
+	}
+	if m.masterKeyBackends == nil {
+		return false, errors.New("master key is required to unwrap the data key but not set")
+	}
+
+	dataKey, err := m.masterKeyBackends.DecryptAny(ctx, masterKeyBased.DataKeyEncryptedContent)
+	if err != nil {
+		return false, errors.Annotate(err, "failed to decrypt data key using master key")
+	}
+	wrapped, err := newMasterKeys.EncryptByMissing(ctx, dataKey, masterKeyBased.DataKeyEncryptedContent)
+	if err != nil {
+		return false, errors.Annotate(err, "failed to encrypt data key using new master key")
+	}
+	if len(wrapped) == 0 {
+		return false, nil
+	}
+	masterKeyBased.DataKeyEncryptedContent = append(masterKeyBased.DataKeyEncryptedContent, wrapped...)
+	return true, nil
+}
+
 func (m *Manager) Close() {
 	if m == nil {
 		return
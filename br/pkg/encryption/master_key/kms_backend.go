@@ -4,6 +4,7 @@ package encryption
 
 import (
 	"context"
+	"crypto/rand"
 	"sync"
 	"time"
 
@@ -83,6 +84,40 @@ func (k *KmsBackend) Decrypt(ctx context.Context, content *encryptionpb.Encrypte
 	return k.state.cached.encryptionBackend.DecryptContent(ctx, content)
 }
 
+// Encrypt generates a fresh data key, wraps it by the KMS and encrypts the plaintext with it.
+// The wrapped data key is stored in the metadata so Decrypt can unwrap it later.
+func (k *KmsBackend) Encrypt(ctx context.Context, plaintext []byte) (*encryptionpb.EncryptedContent, error) {
+	dataKey := make([]byte, AesGcmKeyLen)
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, errors.Annotate(err, "failed to generate data key")
+	}
+
+	ciphertextKey, err :=
+		utils.WithRetryV2(ctx, utils.NewBackoffRetryAllErrorStrategy(10, 500*time.Millisecond, 5*time.Second),
+			func(ctx context.Context) ([]byte, error) {
+				return k.kmsProvider.EncryptDataKey(ctx, dataKey)
+			})
+	if err != nil {
+		return nil, errors.Annotate(err, "encrypt data key failed")
+	}
+
+	backend, err := NewMemAesGcmBackend(dataKey)
+	if err != nil {
+		return nil, errors.Annotate(err, "failed to create MemAesGcmBackend")
+	}
+	iv, err := NewIVGcm()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	content, err := backend.EncryptContent(ctx, plaintext, iv)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	content.Metadata[MetadataKeyKmsVendor] = []byte(k.kmsProvider.Name())
+	content.Metadata[MetadataKeyKmsCiphertextKey] = ciphertextKey
+	return content, nil
+}
+
 func (k *KmsBackend) Close() {
 	k.kmsProvider.Close()
 }
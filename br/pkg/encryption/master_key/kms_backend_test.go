@@ -14,6 +14,7 @@ import (
 type mockKmsProvider struct {
 	name           string
 	decryptCounter int
+	encryptCounter int
 }
 
 func (m *mockKmsProvider) Name() string {
@@ -30,6 +31,11 @@ func (m *mockKmsProvider) DecryptDataKey(_ctx context.Context, _encryptedKey []b
 	return key, nil
 }
 
+func (m *mockKmsProvider) EncryptDataKey(_ctx context.Context, dataKey []byte) ([]byte, error) {
+	m.encryptCounter++
+	return append([]byte("wrapped:"), dataKey...), nil
+}
+
 func (m *mockKmsProvider) Close() {
 	// do nothing
 }
@@ -111,3 +117,18 @@ func TestKmsBackendDecryptErrors(t *testing.T) {
 		})
 	}
 }
+
+func TestKmsBackendEncrypt(t *testing.T) {
+	ctx := context.Background()
+	mockProvider := &mockKmsProvider{name: "mock_kms"}
+	backend, err := NewKmsBackend(mockProvider)
+	require.NoError(t, err)
+
+	content, err := backend.Encrypt(ctx, []byte("data key"))
+	require.NoError(t, err)
+	require.Equal(t, 1, mockProvider.encryptCounter)
+	require.Equal(t, []byte("mock_kms"), content.Metadata[MetadataKeyKmsVendor])
+	require.Contains(t, string(content.Metadata[MetadataKeyKmsCiphertextKey]), "wrapped:")
+	require.Equal(t, []byte(MetadataMethodAes256Gcm), content.Metadata[MetadataKeyMethod])
+	require.NotEqual(t, []byte("data key"), content.Content)
+}
@@ -22,6 +22,8 @@ const (
 type Backend interface {
 	// Decrypt takes an EncryptedContent and returns the decrypted plaintext as a byte slice or an error.
 	Decrypt(ctx context.Context, ciphertext *encryptionpb.EncryptedContent) ([]byte, error)
+	// Encrypt wraps the plaintext with the master key and returns the EncryptedContent.
+	Encrypt(ctx context.Context, plaintext []byte) (*encryptionpb.EncryptedContent, error)
 	Close()
 }
 
@@ -3,6 +3,7 @@
 package encryption
 
 import (
+	"bytes"
 	"context"
 
 	"github.com/pingcap/errors"
@@ -16,8 +17,9 @@ const (
 
 // MultiMasterKeyBackend can contain multiple master shard backends.
 // If any one of those backends successfully decrypts the data, the data will be returned.
-// The main purpose of this backend is to provide a high availability for master key in the future.
-// Right now only one master key backend is used to encrypt/decrypt data.
+// The main purpose of this backend is to provide a high availability for master key:
+// a data key wrapped by every backend (see EncryptByAll) can be recovered as long as
+// any one of the master keys is reachable.
 type MultiMasterKeyBackend struct {
 	backends []Backend
 }
@@ -57,6 +59,86 @@ func (m *MultiMasterKeyBackend) Decrypt(ctx context.Context, encryptedContent *e
 	return nil, errors.Wrap(err, "failed to decrypt in multi master key backend")
 }
 
+// DecryptAny tries to decrypt each of the encrypted contents in order,
+// returns the first plaintext that any backend manages to decrypt.
+func (m *MultiMasterKeyBackend) DecryptAny(ctx context.Context, encryptedContents []*encryptionpb.EncryptedContent) (
+	[]byte, error) {
+	if len(encryptedContents) == 0 {
+		return nil, errors.New("should contain at least one encrypted content")
+	}
+
+	var err error
+	for _, encryptedContent := range encryptedContents {
+		res, decryptErr := m.Decrypt(ctx, encryptedContent)
+		if decryptErr == nil {
+			return res, nil
+		}
+		err = multierr.Append(err, decryptErr)
+	}
+	return nil, errors.Wrap(err, "none of the encrypted contents can be decrypted")
+}
+
+// EncryptByAll wraps the plaintext with every master key backend,
+// the result is in the same order as the backends.
+func (m *MultiMasterKeyBackend) EncryptByAll(ctx context.Context, plaintext []byte) (
+	[]*encryptionpb.EncryptedContent, error) {
+	if len(m.backends) == 0 {
+		return nil, errors.New("internal error: should always contain at least one backend")
+	}
+
+	contents := make([]*encryptionpb.EncryptedContent, 0, len(m.backends))
+	for i, masterKeyBackend := range m.backends {
+		content, err := masterKeyBackend.Encrypt(ctx, plaintext)
+		if err != nil {
+			return nil, errors.Annotatef(err, "failed to encrypt by the %d-th master key", i)
+		}
+		contents = append(contents, content)
+	}
+	return contents, nil
+}
+
+// EncryptByMissing wraps the plaintext like EncryptByAll, but skips the backends which can already unwrap
+// any of the existing encrypted contents into the same plaintext, so wrapping the plaintext with the same
+// master keys again doesn't add anything.
+func (m *MultiMasterKeyBackend) EncryptByMissing(
+	ctx context.Context,
+	plaintext []byte,
+	existing []*encryptionpb.EncryptedContent,
+) ([]*encryptionpb.EncryptedContent, error) {
+	if len(m.backends) == 0 {
+		return nil, errors.New("internal error: should always contain at least one backend")
+	}
+
+	contents := make([]*encryptionpb.EncryptedContent, 0, len(m.backends))
+	for i, masterKeyBackend := range m.backends {
+		if canUnwrapAny(ctx, masterKeyBackend, plaintext, existing) {
+			continue
+		}
+		content, err := masterKeyBackend.Encrypt(ctx, plaintext)
+		if err != nil {
+			return nil, errors.Annotatef(err, "failed to encrypt by the %d-th master key", i)
+		}
+		contents = append(contents, content)
+	}
+	return contents, nil
+}
+
+// canUnwrapAny checks whether the backend can decrypt any of the encrypted contents into the plaintext.
+func canUnwrapAny(
+	ctx context.Context,
+	backend Backend,
+	plaintext []byte,
+	encryptedContents []*encryptionpb.EncryptedContent,
+) bool {
+	for _, encryptedContent := range encryptedContents {
+		res, err := backend.Decrypt(ctx, encryptedContent)
+		if err == nil && bytes.Equal(res, plaintext) {
+			return true
+		}
+	}
+	return false
+}
+
 func (m *MultiMasterKeyBackend) Close() {
 	for _, backend := range m.backends {
 		backend.Close()
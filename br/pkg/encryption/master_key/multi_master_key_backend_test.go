@@ -26,6 +26,14 @@ func (m *MockBackend) Decrypt(ctx context.Context, encryptedContent *encryptionp
 	return nil, args.Error(1)
 }
 
+func (m *MockBackend) Encrypt(ctx context.Context, plaintext []byte) (*encryptionpb.EncryptedContent, error) {
+	args := m.Called(ctx, plaintext)
+	if ret := args.Get(0); ret != nil {
+		return ret.(*encryptionpb.EncryptedContent), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
 func (m *MockBackend) Close() {
 	// do nothing
 }
@@ -103,3 +111,86 @@ func TestMultiMasterKeyBackendDecrypt(t *testing.T) {
 		require.Contains(t, err.Error(), "internal error")
 	})
 }
+
+func TestMultiMasterKeyBackendEnvelope(t *testing.T) {
+	ctx := context.Background()
+	key1, err := NewMemAesGcmBackend([]byte("0123456789abcdef0123456789abcdef"))
+	require.NoError(t, err)
+	key2, err := NewMemAesGcmBackend([]byte("fedcba9876543210fedcba9876543210"))
+	require.NoError(t, err)
+
+	backend := &MultiMasterKeyBackend{
+		backends: []Backend{&FileBackend{memCache: key1}, &FileBackend{memCache: key2}},
+	}
+	dataKey := []byte("the-data-key")
+	contents, err := backend.EncryptByAll(ctx, dataKey)
+	require.NoError(t, err)
+	require.Len(t, contents, 2)
+
+	// only the second master key is reachable.
+	onlySecond := &MultiMasterKeyBackend{backends: []Backend{&FileBackend{memCache: key2}}}
+	plaintext, err := onlySecond.DecryptAny(ctx, contents)
+	require.NoError(t, err)
+	require.Equal(t, dataKey, plaintext)
+
+	// only the first master key is reachable.
+	onlyFirst := &MultiMasterKeyBackend{backends: []Backend{&FileBackend{memCache: key1}}}
+	plaintext, err = onlyFirst.DecryptAny(ctx, contents)
+	require.NoError(t, err)
+	require.Equal(t, dataKey, plaintext)
+
+	// none of the master keys can unwrap it.
+	key3, err := NewMemAesGcmBackend([]byte("00000000000000000000000000000000"))
+	require.NoError(t, err)
+	other := &MultiMasterKeyBackend{backends: []Backend{&FileBackend{memCache: key3}}}
+	_, err = other.DecryptAny(ctx, contents)
+	require.Error(t, err)
+
+	_, err = onlyFirst.DecryptAny(ctx, nil)
+	require.ErrorContains(t, err, "at least one encrypted content")
+}
+
+func TestMultiMasterKeyBackendEncryptByAllFailed(t *testing.T) {
+	ctx := context.Background()
+	plaintext := []byte("data key")
+
+	mock1 := new(MockBackend)
+	mock1.On("Encrypt", ctx, plaintext).Return(&encryptionpb.EncryptedContent{Content: []byte("c1")}, nil)
+	mock2 := new(MockBackend)
+	mock2.On("Encrypt", ctx, plaintext).Return(nil, errors.New("kms unavailable"))
+
+	backend := &MultiMasterKeyBackend{
+		backends: []Backend{mock1, mock2},
+	}
+	_, err := backend.EncryptByAll(ctx, plaintext)
+	require.ErrorContains(t, err, "kms unavailable")
+	mock1.AssertExpectations(t)
+	mock2.AssertExpectations(t)
+}
+
+func TestMultiMasterKeyBackendEncryptByMissing(t *testing.T) {
+	ctx := context.Background()
+	key1, err := NewMemAesGcmBackend([]byte("0123456789abcdef0123456789abcdef"))
+	require.NoError(t, err)
+	key2, err := NewMemAesGcmBackend([]byte("fedcba9876543210fedcba9876543210"))
+	require.NoError(t, err)
+	dataKey := []byte("the-data-key")
+	existing, err := (&MultiMasterKeyBackend{backends: []Backend{&FileBackend{memCache: key1}}}).EncryptByAll(ctx, dataKey)
+	require.NoError(t, err)
+
+	// only the master key missing in the envelope wraps the data key.
+	backend := &MultiMasterKeyBackend{
+		backends: []Backend{&FileBackend{memCache: key1}, &FileBackend{memCache: key2}},
+	}
+	contents, err := backend.EncryptByMissing(ctx, dataKey, existing)
+	require.NoError(t, err)
+	require.Len(t, contents, 1)
+	plaintext, err := (&MultiMasterKeyBackend{backends: []Backend{&FileBackend{memCache: key2}}}).Decrypt(ctx, contents[0])
+	require.NoError(t, err)
+	require.Equal(t, dataKey, plaintext)
+
+	// nothing is wrapped again once all the master keys are in the envelope.
+	contents, err = backend.EncryptByMissing(ctx, dataKey, append(existing, contents...))
+	require.NoError(t, err)
+	require.Empty(t, contents)
+}
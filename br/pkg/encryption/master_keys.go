@@ -1,18 +1,23 @@
 // Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
 
-package task
+package encryption
 
 import (
 	"fmt"
 	"net/url"
 	"path"
 	"regexp"
+	"strings"
 
 	"github.com/pingcap/errors"
+	backuppb "github.com/pingcap/kvproto/pkg/brpb"
 	"github.com/pingcap/kvproto/pkg/encryptionpb"
+	"github.com/pingcap/tidb/br/pkg/utils"
 )
 
 const (
+	masterKeysDelimiter = ","
+
 	SchemeLocal = "local"
 	SchemeAWS   = "aws-kms"
 	SchemeAzure = "azure-kms"
@@ -40,6 +45,41 @@ var (
 	gcpRegex   = regexp.MustCompile(`^/projects/([^/]+)/locations/([^/]+)/keyRings/([^/]+)/cryptoKeys/([^/]+)/?$`)
 )
 
+// ParseMasterKeyConfig parses the encryption method and the comma separated list of master key URLs.
+func ParseMasterKeyConfig(method, masterKeyString string) (*backuppb.MasterKeyConfig, error) {
+	encryptionMethod, err := ParseCipherType(method)
+	if err != nil {
+		return nil, errors.Errorf("failed to parse encryption method: %v", err)
+	}
+
+	if !utils.IsEffectiveEncryptionMethod(encryptionMethod) {
+		return nil, errors.Errorf("invalid encryption method: %s", method)
+	}
+
+	masterKeys, err := ParseMasterKeys(masterKeyString)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return &backuppb.MasterKeyConfig{
+		EncryptionType: encryptionMethod,
+		MasterKeys:     masterKeys,
+	}, nil
+}
+
+// ParseMasterKeys parses a comma separated list of master key URLs.
+func ParseMasterKeys(masterKeyString string) ([]*encryptionpb.MasterKey, error) {
+	masterKeyStrings := strings.Split(masterKeyString, masterKeysDelimiter)
+	masterKeys := make([]*encryptionpb.MasterKey, 0, len(masterKeyStrings))
+	for _, keyString := range masterKeyStrings {
+		masterKey, err := validateAndParseMasterKeyString(strings.TrimSpace(keyString))
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid master key configuration: %s", keyString)
+		}
+		masterKeys = append(masterKeys, &masterKey)
+	}
+	return masterKeys, nil
+}
+
 func validateAndParseMasterKeyString(keyString string) (encryptionpb.MasterKey, error) {
 	u, err := url.Parse(keyString)
 	if err != nil {
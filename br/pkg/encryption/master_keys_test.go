@@ -1,6 +1,6 @@
 // Copyright 2024 PingCAP, Inc. Licensed under Apache-2.0.
 
-package task
+package encryption
 
 import (
 	"net/url"
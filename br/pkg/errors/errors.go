@@ -73,7 +73,26 @@ var (
 	ErrDatabasesAlreadyExisted = errors.Normalize("databases already existed in restored cluster", errors.RFCCodeText("BR:Restore:ErrDatabasesAlreadyExisted"))
 	ErrTablesAlreadyExisted    = errors.Normalize("tables already existed in restored cluster", errors.RFCCodeText("BR:Restore:ErrTablesAlreadyExisted"))
 
-	// ErrStreamLogTaskExist is the error when stream log task already exists, because of supporting single task currently.
+	// ErrRestoreIncompatibleTable is the error when the data can't be restored into the existing table.
+	ErrRestoreIncompatibleTable = errors.Normalize("incompatible existing table", errors.RFCCodeText("BR:Restore:ErrRestoreIncompatibleTable"))
+	// ErrRestoreVerifyFailed is the error when the restored tables don't match the backup.
+	ErrRestoreVerifyFailed = errors.Normalize("restore verification failed", errors.RFCCodeText("BR:Restore:ErrRestoreVerifyFailed"))
+	// ErrRestoreRollbackFailed is the error when the schemas aren't the same as the ones before the restore after the rollback.
+	ErrRestoreRollbackFailed = errors.Normalize("restore rollback failed", errors.RFCCodeText("BR:Restore:ErrRestoreRollbackFailed"))
+	// ErrRestorePhaseStalled is the error when a phase of restore makes no progress in its timeout.
+	ErrRestorePhaseStalled = errors.Normalize("restore phase stalled", errors.RFCCodeText("BR:Restore:ErrRestorePhaseStalled"))
+	// ErrRestoreLossyMeta is the error when the meta kv entry changes after a JSON round trip.
+	ErrRestoreLossyMeta = errors.Normalize("lossy meta round trip", errors.RFCCodeText("BR:Restore:ErrRestoreLossyMeta"))
+	// ErrRestoreDDLGateBusy is the error when the DDL gate of the target cluster is held by another restore.
+	ErrRestoreDDLGateBusy = errors.Normalize("DDL gate is busy", errors.RFCCodeText("BR:Restore:ErrRestoreDDLGateBusy"))
+	// ErrRestoreTSRegression is the error when the restore would write the kvs older than the existing data.
+	ErrRestoreTSRegression = errors.Normalize("restore ts regression", errors.RFCCodeText("BR:Restore:ErrRestoreTSRegression"))
+	// ErrRestoreClusterMismatch is the error when the backup comes from a cluster other than the allowed one.
+	ErrRestoreClusterMismatch = errors.Normalize("restore cluster mismatch", errors.RFCCodeText("BR:Restore:ErrRestoreClusterMismatch"))
+	// ErrRestoreEncryptionAtRest is the error when the encryption at rest of the target stores doesn't meet the requirement.
+	ErrRestoreEncryptionAtRest = errors.Normalize("encryption at rest unsatisfied", errors.RFCCodeText("BR:Restore:ErrRestoreEncryptionAtRest"))
+
+	// ErrStreamLogTaskExist is the error when stream log task already exists, or it observes the tables of other tasks.
 	ErrStreamLogTaskExist = errors.Normalize("stream task already exists", errors.RFCCodeText("BR:Stream:ErrStreamLogTaskExist"))
 
 	// TODO maybe it belongs to PiTR.
@@ -83,10 +102,14 @@ var (
 	ErrPiTRTaskNotFound        = errors.Normalize("task not found", errors.RFCCodeText("BR:PiTR:ErrTaskNotFound"))
 	ErrPiTRInvalidTaskInfo     = errors.Normalize("task info is invalid", errors.RFCCodeText("BR:PiTR:ErrInvalidTaskInfo"))
 	ErrPiTRMalformedMetadata   = errors.Normalize("malformed metadata", errors.RFCCodeText("BR:PiTR:ErrMalformedMetadata"))
-
-	ErrStorageUnknown           = errors.Normalize("unknown external storage error", errors.RFCCodeText("BR:ExternalStorage:ErrStorageUnknown"))
-	ErrStorageInvalidConfig     = errors.Normalize("invalid external storage config", errors.RFCCodeText("BR:ExternalStorage:ErrStorageInvalidConfig"))
-	ErrStorageInvalidPermission = errors.Normalize("external storage permission", errors.RFCCodeText("BR:ExternalStorage:ErrStorageInvalidPermission"))
+	// ErrPiTRArchiveGap is the error when the data files recorded by the metadata are missing in the log backup archive.
+	ErrPiTRArchiveGap = errors.Normalize("the log backup archive has gaps", errors.RFCCodeText("BR:PiTR:ErrArchiveGap"))
+
+	ErrStorageUnknown              = errors.Normalize("unknown external storage error", errors.RFCCodeText("BR:ExternalStorage:ErrStorageUnknown"))
+	ErrStorageInvalidConfig        = errors.Normalize("invalid external storage config", errors.RFCCodeText("BR:ExternalStorage:ErrStorageInvalidConfig"))
+	ErrStorageInvalidPermission    = errors.Normalize("external storage permission", errors.RFCCodeText("BR:ExternalStorage:ErrStorageInvalidPermission"))
+	ErrStorageConditionNotMet      = errors.Normalize("the file is changed since it's read", errors.RFCCodeText("BR:ExternalStorage:ErrStorageConditionNotMet"))
+	ErrStorageRetryBudgetExhausted = errors.Normalize("the retry budget of the external storage is exhausted", errors.RFCCodeText("BR:ExternalStorage:ErrStorageRetryBudgetExhausted"))
 
 	// Snapshot restore
 	ErrRestoreTotalKVMismatch   = errors.Normalize("restore total tikvs mismatch", errors.RFCCodeText("BR:EBS:ErrRestoreTotalKVMismatch"))
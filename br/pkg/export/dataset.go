@@ -0,0 +1,150 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package export
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path"
+	"slices"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/tidb/br/pkg/storage"
+	"github.com/pingcap/tidb/pkg/meta/model"
+	"github.com/pingcap/tidb/pkg/types"
+	"golang.org/x/exp/maps"
+)
+
+// PartitionDirPrefix is the prefix of the directories of the partitions in a dataset, the directories
+// are named as `_tidb_partition=<name>` so that they are discovered as a column by Spark and Trino.
+const PartitionDirPrefix = "_tidb_partition="
+
+// rowSink writes the rows of a table into one or more files.
+type rowSink interface {
+	writeRow(ctx context.Context, physicalID int64, row []types.Datum) error
+	// close finishes the files and returns the number of them.
+	close(ctx context.Context) (int, error)
+}
+
+// fileSink writes all the rows of a table into one file.
+type fileSink struct {
+	w rowWriter
+}
+
+func (s *fileSink) writeRow(_ context.Context, _ int64, row []types.Datum) error {
+	return s.w.writeRow(row)
+}
+
+func (s *fileSink) close(ctx context.Context) (int, error) {
+	return 1, errors.Trace(s.w.close(ctx))
+}
+
+// datasetPart is the file being written of a directory of the dataset.
+type datasetPart struct {
+	dir  string
+	w    rowWriter
+	rows uint64
+	seq  int
+}
+
+// datasetSink writes the rows of a table into a directory, the rows of each partition are written into
+// its own sub directory, and a new file is started every rowsPerFile rows.
+type datasetSink struct {
+	storage     storage.ExternalStorage
+	format      Format
+	cols        []*model.ColumnInfo
+	rowsPerFile uint64
+	dir         string
+	// partitionDirs are the sub directories of the partitions, keyed by the physical table ID.
+	partitionDirs map[int64]string
+	parts         map[int64]*datasetPart
+	files         int
+}
+
+// datasetDir returns the directory of the table in the dataset.
+func datasetDir(db string, info *model.TableInfo) string {
+	return path.Join(url.PathEscape(db), url.PathEscape(info.Name.O))
+}
+
+func newDatasetSink(
+	s storage.ExternalStorage,
+	format Format,
+	db string,
+	info *model.TableInfo,
+	cols []*model.ColumnInfo,
+	rowsPerFile uint64,
+) *datasetSink {
+	sink := &datasetSink{
+		storage:       s,
+		format:        format,
+		cols:          cols,
+		rowsPerFile:   rowsPerFile,
+		dir:           datasetDir(db, info),
+		partitionDirs: make(map[int64]string),
+		parts:         make(map[int64]*datasetPart),
+	}
+	if partitions := info.GetPartitionInfo(); partitions != nil {
+		for _, def := range partitions.Definitions {
+			sink.partitionDirs[def.ID] = path.Join(sink.dir, PartitionDirPrefix+url.PathEscape(def.Name.O))
+		}
+	}
+	return sink
+}
+
+func (s *datasetSink) fileName(dir string, seq int) string {
+	return path.Join(dir, fmt.Sprintf("part-%05d.%s", seq, s.format))
+}
+
+func (s *datasetSink) writeRow(ctx context.Context, physicalID int64, row []types.Datum) error {
+	part, ok := s.parts[physicalID]
+	if !ok {
+		dir, ok := s.partitionDirs[physicalID]
+		if !ok {
+			dir = s.dir
+		}
+		part = &datasetPart{dir: dir}
+		s.parts[physicalID] = part
+	}
+	if part.w != nil && s.rowsPerFile > 0 && part.rows >= s.rowsPerFile {
+		if err := part.w.close(ctx); err != nil {
+			return errors.Annotatef(err, "failed to write %s", s.fileName(part.dir, part.seq-1))
+		}
+		part.w = nil
+	}
+	if part.w == nil {
+		w, err := newRowWriter(ctx, s.storage, s.fileName(part.dir, part.seq), s.format, s.cols)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		part.w, part.rows = w, 0
+		part.seq++
+		s.files++
+	}
+	part.rows++
+	return part.w.writeRow(row)
+}
+
+func (s *datasetSink) close(ctx context.Context) (int, error) {
+	var firstErr error
+	ids := maps.Keys(s.parts)
+	slices.Sort(ids)
+	for _, id := range ids {
+		part := s.parts[id]
+		if part.w == nil {
+			continue
+		}
+		if err := part.w.close(ctx); err != nil && firstErr == nil {
+			firstErr = errors.Annotatef(err, "failed to write %s", s.fileName(part.dir, part.seq-1))
+		}
+	}
+	if firstErr != nil || s.files > 0 {
+		return s.files, errors.Trace(firstErr)
+	}
+	// an empty file is written for the empty table, so the schema is still known by the readers.
+	w, err := newRowWriter(ctx, s.storage, s.fileName(s.dir, 0), s.format, s.cols)
+	if err != nil {
+		return 0, errors.Trace(err)
+	}
+	return 1, errors.Trace(w.close(ctx))
+}
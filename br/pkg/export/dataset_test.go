@@ -0,0 +1,74 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package export
+
+import (
+	"context"
+	"testing"
+
+	backuppb "github.com/pingcap/kvproto/pkg/brpb"
+	"github.com/pingcap/tidb/br/pkg/storage"
+	"github.com/pingcap/tidb/br/pkg/stream"
+	"github.com/pingcap/tidb/pkg/meta/model"
+	"github.com/pingcap/tidb/pkg/parser/ast"
+	"github.com/stretchr/testify/require"
+)
+
+func listTestFiles(t *testing.T, s storage.ExternalStorage) map[string]string {
+	ctx := context.Background()
+	files := make(map[string]string)
+	err := s.WalkDir(ctx, &storage.WalkOption{}, func(name string, _ int64) error {
+		content, err := s.ReadFile(ctx, name)
+		files[name] = string(content)
+		return err
+	})
+	require.NoError(t, err)
+	return files
+}
+
+func TestExportDataset(t *testing.T) {
+	ctx := context.Background()
+	snapshot, output := newTestStorage(t), newTestStorage(t)
+	info := testTableInfo(t)
+	info.Name = ast.NewCIStr("t/1")
+	info.Partition = &model.PartitionInfo{
+		Enable: true,
+		Definitions: []model.PartitionDefinition{
+			{ID: 101, Name: ast.NewCIStr("p0")},
+			{ID: 102, Name: ast.NewCIStr("p1")},
+		},
+	}
+	writeSST(t, snapshot, "1_write.sst",
+		testKV{withTS(encodeTestRecordKey(101, 1), testTSBase+95), encodeWriteValue(stream.WriteTypePut, testTSBase+90, encodeTestRow(t, "a"))},
+		testKV{withTS(encodeTestRecordKey(101, 2), testTSBase+95), encodeWriteValue(stream.WriteTypePut, testTSBase+90, encodeTestRow(t, "b"))},
+		testKV{withTS(encodeTestRecordKey(101, 3), testTSBase+95), encodeWriteValue(stream.WriteTypePut, testTSBase+90, encodeTestRow(t, "c"))},
+		testKV{withTS(encodeTestRecordKey(102, 4), testTSBase+95), encodeWriteValue(stream.WriteTypePut, testTSBase+90, encodeTestRow(t, "d", 1))},
+	)
+	exporter := NewExporter(output, FormatCSV, testSnapshotTS)
+	exporter.SetSnapshot(snapshot, nil, testSnapshotTS)
+	exporter.SetDataset(2)
+	table := &Table{DB: "test", Info: info, SnapshotFiles: []*backuppb.File{{Name: "1_write.sst", Cf: stream.WriteCF}}}
+	require.Equal(t, "test/t%2F1/", exporter.FileName(table))
+	rows, files, err := exporter.ExportTable(ctx, table)
+	require.NoError(t, err)
+	require.EqualValues(t, 4, rows)
+	require.Equal(t, 3, files)
+
+	header := "id,name,score\n"
+	require.Equal(t, map[string]string{
+		"test/t%2F1/_tidb_partition=p0/part-00000.csv": header + "1,a,7\n2,b,7\n",
+		"test/t%2F1/_tidb_partition=p0/part-00001.csv": header + "3,c,7\n",
+		"test/t%2F1/_tidb_partition=p1/part-00000.csv": header + "4,d,1\n",
+	}, listTestFiles(t, output))
+
+	// an empty file is written for the empty table.
+	output = newTestStorage(t)
+	exporter = NewExporter(output, FormatCSV, testSnapshotTS)
+	exporter.SetSnapshot(snapshot, nil, testSnapshotTS)
+	exporter.SetDataset(0)
+	rows, files, err = exporter.ExportTable(ctx, &Table{DB: "test", Info: testTableInfo(t)})
+	require.NoError(t, err)
+	require.EqualValues(t, 0, rows)
+	require.Equal(t, 1, files)
+	require.Equal(t, map[string]string{"test/t/part-00000.csv": header}, listTestFiles(t, output))
+}
@@ -0,0 +1,473 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package export
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"slices"
+
+	"github.com/cockroachdb/pebble/objstorage"
+	"github.com/cockroachdb/pebble/sstable"
+	"github.com/pingcap/errors"
+	backuppb "github.com/pingcap/kvproto/pkg/brpb"
+	"github.com/pingcap/log"
+	berrors "github.com/pingcap/tidb/br/pkg/errors"
+	"github.com/pingcap/tidb/br/pkg/storage"
+	"github.com/pingcap/tidb/br/pkg/stream"
+	"github.com/pingcap/tidb/br/pkg/utils"
+	"github.com/pingcap/tidb/pkg/meta/model"
+	"github.com/pingcap/tidb/pkg/types"
+	"go.uber.org/zap"
+)
+
+// Table is a table to export.
+type Table struct {
+	DB string
+	// Info is the table info as of the exported ts.
+	Info *model.TableInfo
+	// SnapshotFiles are the files of the table in the snapshot backup, it's empty if the table is
+	// created after the snapshot backup.
+	SnapshotFiles []*backuppb.File
+	// LogFiles are the data files of the physical tables in the log backup.
+	LogFiles []*backuppb.DataFileInfo
+}
+
+// Exporter reconstructs the tables as of a ts from the snapshot backup and the log backup after it,
+// and writes them to the output storage without a cluster.
+type Exporter struct {
+	output storage.ExternalStorage
+	format Format
+	asOfTS uint64
+
+	snapshotStorage storage.ExternalStorage
+	cipher          *backuppb.CipherInfo
+	snapshotTS      uint64
+
+	logStorage storage.ExternalStorage
+	logHelper  *stream.MetadataHelper
+
+	// dataset is whether the tables are written as datasets, see SetDataset.
+	dataset     bool
+	rowsPerFile uint64
+}
+
+// NewExporter creates an exporter writing the tables as of asOfTS to the output storage.
+func NewExporter(output storage.ExternalStorage, format Format, asOfTS uint64) *Exporter {
+	return &Exporter{output: output, format: format, asOfTS: asOfTS}
+}
+
+// SetSnapshot sets the snapshot backup, the files are decrypted by the cipher.
+func (e *Exporter) SetSnapshot(s storage.ExternalStorage, cipher *backuppb.CipherInfo, snapshotTS uint64) {
+	e.snapshotStorage, e.cipher, e.snapshotTS = s, cipher, snapshotTS
+}
+
+// SetLog sets the log backup, the writes committed after the snapshot backup and at or before the
+// exported ts are applied to the rows of the snapshot backup.
+func (e *Exporter) SetLog(s storage.ExternalStorage, helper *stream.MetadataHelper) {
+	e.logStorage, e.logHelper = s, helper
+}
+
+// SetDataset makes the tables written as datasets readable by Spark and Trino. The files of a table are
+// written into the directory `<db>/<table>`, the rows of a partition are written into the sub directory
+// of it, and a new file is started every rowsPerFile rows, zero means unlimited.
+func (e *Exporter) SetDataset(rowsPerFile uint64) {
+	e.dataset, e.rowsPerFile = true, rowsPerFile
+}
+
+// FileName returns the name of the exported file of the table, or the directory of it if it's written
+// as a dataset.
+func (e *Exporter) FileName(table *Table) string {
+	if e.dataset {
+		return datasetDir(table.DB, table.Info) + "/"
+	}
+	return fmt.Sprintf("%s.%s.%s", table.DB, table.Info.Name.O, e.format)
+}
+
+func (e *Exporter) newSink(ctx context.Context, table *Table, cols []*model.ColumnInfo) (rowSink, error) {
+	if e.dataset {
+		return newDatasetSink(e.output, e.format, table.DB, table.Info, cols, e.rowsPerFile), nil
+	}
+	w, err := newRowWriter(ctx, e.output, e.FileName(table), e.format, cols)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return &fileSink{w: w}, nil
+}
+
+// logWrite is the last change of a row in the log backup.
+type logWrite struct {
+	commitTS uint64
+	startTS  uint64
+	put      bool
+	value    []byte
+	hasValue bool
+}
+
+// ExportTable writes the rows of the table as of the exported ts, and returns the number of the rows and
+// the files.
+func (e *Exporter) ExportTable(ctx context.Context, table *Table) (rows uint64, files int, err error) {
+	name := e.FileName(table)
+	sink, err := e.newSink(ctx, table, ExportedColumns(table.Info))
+	if err != nil {
+		return 0, 0, errors.Trace(err)
+	}
+	rows, err = e.ReadTable(ctx, table, func(physicalID int64, row []types.Datum) error {
+		return errors.Trace(sink.writeRow(ctx, physicalID, row))
+	})
+	if err != nil {
+		_, _ = sink.close(ctx)
+		return 0, 0, errors.Trace(err)
+	}
+	if files, err = sink.close(ctx); err != nil {
+		return 0, 0, errors.Annotatef(err, "failed to write %s", name)
+	}
+	log.Info("exported the table", zap.String("db", table.DB), zap.String("table", table.Info.Name.O),
+		zap.String("file", name), zap.Uint64("rows", rows), zap.Int("files", files))
+	return rows, files, nil
+}
+
+// ReadTable calls fn with the physical table ID and the datums of the exported columns of every row of
+// the table as of the exported ts, and returns the number of the rows. The datums are only valid in fn.
+// The rows of the snapshot backup are read first, and then the rows written in the log backup.
+func (e *Exporter) ReadTable(
+	ctx context.Context,
+	table *Table,
+	fn func(physicalID int64, row []types.Datum) error,
+) (rows uint64, err error) {
+	decoder, err := NewRowDecoder(table.Info)
+	if err != nil {
+		return 0, errors.Trace(err)
+	}
+	changes, err := e.readLogChanges(ctx, table.LogFiles, decoder)
+	if err != nil {
+		return 0, errors.Annotatef(err, "failed to read the log backup of %s", table.Info.Name.O)
+	}
+	read := func(key, value []byte) error {
+		physicalID, row, err := decoder.decode(key, value)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		rows++
+		return errors.Trace(fn(physicalID, row))
+	}
+	err = ReadSnapshotRows(ctx, e.snapshotStorage, e.cipher, table.SnapshotFiles, func(key, value []byte) error {
+		if !decoder.isRecordKey(key) {
+			return nil
+		}
+		if _, changed := changes[string(key)]; changed {
+			return nil
+		}
+		return read(key, value)
+	})
+	if err != nil {
+		return 0, errors.Annotatef(err, "failed to read the snapshot backup of %s", table.Info.Name.O)
+	}
+	keys := make([]string, 0, len(changes))
+	for key, change := range changes {
+		if change.put {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+	for _, key := range keys {
+		if err := read([]byte(key), changes[key].value); err != nil {
+			return 0, errors.Trace(err)
+		}
+	}
+	log.Info("read the table", zap.String("db", table.DB), zap.String("table", table.Info.Name.O),
+		zap.Uint64("rows", rows), zap.Int("changes-in-log", len(changes)))
+	return rows, nil
+}
+
+// splitTS splits the key into the encoded user key and the ts.
+func splitTS(key []byte) ([]byte, uint64, error) {
+	if len(key) < 8 {
+		return nil, 0, errors.Annotatef(berrors.ErrInvalidArgument, "the key %x is too short to have a ts", key)
+	}
+	return key[:len(key)-8], ^binary.BigEndian.Uint64(key[len(key)-8:]), nil
+}
+
+// readLogChanges returns the last puts and deletes of the rows committed after the snapshot backup and at
+// or before the exported ts, keyed by the encoded user key.
+func (e *Exporter) readLogChanges(
+	ctx context.Context,
+	files []*backuppb.DataFileInfo,
+	decoder *RowDecoder,
+) (map[string]*logWrite, error) {
+	changes := make(map[string]*logWrite)
+	if e.logStorage == nil || e.asOfTS <= e.snapshotTS {
+		return changes, nil
+	}
+	writeFiles := make([]*backuppb.DataFileInfo, 0, len(files))
+	// the values of a transaction committed after the snapshot backup may be written before it.
+	minBeginTS := e.snapshotTS
+	for _, file := range files {
+		if file.Cf != stream.WriteCF || file.MaxTs <= e.snapshotTS || file.MinTs > e.asOfTS {
+			continue
+		}
+		writeFiles = append(writeFiles, file)
+		if file.MinBeginTsInDefaultCf > 0 {
+			minBeginTS = min(minBeginTS, file.MinBeginTsInDefaultCf)
+		}
+	}
+	defaultFiles := make([]*backuppb.DataFileInfo, 0, len(files))
+	for _, file := range files {
+		if file.Cf == stream.DefaultCF && file.MaxTs >= minBeginTS && file.MinTs <= e.asOfTS {
+			defaultFiles = append(defaultFiles, file)
+		}
+	}
+	e.initLogCache(writeFiles, defaultFiles)
+
+	for _, file := range writeFiles {
+		err := e.iterateLogFile(ctx, file, func(key, value []byte) error {
+			userKey, commitTS, err := splitTS(key)
+			if err != nil {
+				return errors.Trace(err)
+			}
+			if commitTS <= e.snapshotTS || commitTS > e.asOfTS || !decoder.isRecordKey(userKey) {
+				return nil
+			}
+			write := new(stream.RawWriteCFValue)
+			if err := write.ParseFrom(value); err != nil {
+				return errors.Trace(err)
+			}
+			put := write.GetWriteType() == stream.WriteTypePut
+			if !put && write.GetWriteType() != stream.WriteTypeDelete {
+				// the locks and the rollbacks don't change the row.
+				return nil
+			}
+			if last, ok := changes[string(userKey)]; ok && last.commitTS >= commitTS {
+				return nil
+			}
+			change := &logWrite{commitTS: commitTS, startTS: write.GetStartTs(), put: put}
+			if write.HasShortValue() {
+				change.value, change.hasValue = slices.Clone(write.GetShortValue()), true
+			}
+			changes[string(userKey)] = change
+			return nil
+		})
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+
+	// the long values are in the default cf, keyed by the start ts.
+	pending := make(map[string]*logWrite)
+	for key, change := range changes {
+		if change.put && !change.hasValue {
+			defaultKey := binary.BigEndian.AppendUint64([]byte(key), ^change.startTS)
+			pending[string(defaultKey)] = change
+		}
+	}
+	for _, file := range defaultFiles {
+		if len(pending) == 0 {
+			break
+		}
+		err := e.iterateLogFile(ctx, file, func(key, value []byte) error {
+			if change, ok := pending[string(key)]; ok {
+				change.value, change.hasValue = slices.Clone(value), true
+				delete(pending, string(key))
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	if len(pending) > 0 {
+		return nil, errors.Annotatef(berrors.ErrRestoreInvalidBackup,
+			"the values of %d rows aren't found in the default cf of the log backup", len(pending))
+	}
+	return changes, nil
+}
+
+// initLogCache initializes the cache of the group files of the metadata v2, which are read by range.
+func (e *Exporter) initLogCache(fileSets ...[]*backuppb.DataFileInfo) {
+	refs := make(map[string]int)
+	for _, files := range fileSets {
+		for _, file := range files {
+			if file.RangeLength > 0 {
+				refs[file.Path]++
+			}
+		}
+	}
+	for path, ref := range refs {
+		e.logHelper.InitCacheEntry(path, ref)
+	}
+}
+
+// iterateLogFile calls fn with the keys and the values of the data file of the log backup.
+func (e *Exporter) iterateLogFile(ctx context.Context, file *backuppb.DataFileInfo, fn func(key, value []byte) error) error {
+	buff, err := e.logHelper.ReadFile(ctx, file.Path, file.RangeOffset, file.RangeLength, file.CompressionType,
+		e.logStorage, file.FileEncryptionInfo)
+	if err != nil {
+		return errors.Annotatef(err, "failed to read the log file %s", file.Path)
+	}
+	if checksum := sha256.Sum256(buff); !bytes.Equal(checksum[:], file.GetSha256()) {
+		return berrors.ErrInvalidMetaFile.GenWithStackByArgs(fmt.Sprintf(
+			"checksum mismatch of the log file %s, expect %x, got %x", file.Path, file.GetSha256(), checksum[:]))
+	}
+	iter := stream.NewEventIterator(buff)
+	for iter.Valid() {
+		iter.Next()
+		if err := iter.GetError(); err != nil {
+			return errors.Trace(err)
+		}
+		if err := fn(iter.Key(), iter.Value()); err != nil {
+			return errors.Annotatef(err, "failed to read the log file %s", file.Path)
+		}
+	}
+	return nil
+}
+
+// ReadSnapshotRows calls fn with the encoded user keys and the values of the puts in the sst files of the
+// snapshot backup, the key and the value are only valid in fn. The files of a range are read together,
+// since the long values in the write cf file are in the default cf file.
+func ReadSnapshotRows(
+	ctx context.Context,
+	s storage.ExternalStorage,
+	cipher *backuppb.CipherInfo,
+	files []*backuppb.File,
+	fn func(key, value []byte) error,
+) error {
+	type rangeFiles struct {
+		defaults []*backuppb.File
+		writes   []*backuppb.File
+	}
+	ranges := make(map[string]*rangeFiles)
+	order := make([]string, 0, len(files))
+	for _, file := range files {
+		id := string(file.StartKey) + "\x00" + string(file.EndKey)
+		rf, ok := ranges[id]
+		if !ok {
+			rf = &rangeFiles{}
+			ranges[id] = rf
+			order = append(order, id)
+		}
+		switch file.Cf {
+		case stream.DefaultCF:
+			rf.defaults = append(rf.defaults, file)
+		case stream.WriteCF:
+			rf.writes = append(rf.writes, file)
+		}
+	}
+
+	for _, id := range order {
+		rf := ranges[id]
+		values := make(map[string][]byte)
+		for _, file := range rf.defaults {
+			err := iterateSST(ctx, s, cipher, file, func(key, value []byte) error {
+				values[string(key)] = slices.Clone(value)
+				return nil
+			})
+			if err != nil {
+				return errors.Trace(err)
+			}
+		}
+		for _, file := range rf.writes {
+			err := iterateSST(ctx, s, cipher, file, func(key, value []byte) error {
+				userKey, _, err := splitTS(key)
+				if err != nil {
+					return errors.Trace(err)
+				}
+				write := new(stream.RawWriteCFValue)
+				if err := write.ParseFrom(value); err != nil {
+					return errors.Trace(err)
+				}
+				if write.GetWriteType() != stream.WriteTypePut {
+					return nil
+				}
+				if write.HasShortValue() {
+					return fn(userKey, write.GetShortValue())
+				}
+				defaultKey := binary.BigEndian.AppendUint64(slices.Clone(userKey), ^write.GetStartTs())
+				rowValue, ok := values[string(defaultKey)]
+				if !ok {
+					return errors.Annotatef(berrors.ErrRestoreInvalidBackup,
+						"the value of key %x isn't found in the default cf", userKey)
+				}
+				return fn(userKey, rowValue)
+			})
+			if err != nil {
+				return errors.Trace(err)
+			}
+		}
+	}
+	return nil
+}
+
+// iterateSST calls fn with the keys without the data prefix and the values of the sst file.
+func iterateSST(
+	ctx context.Context,
+	s storage.ExternalStorage,
+	cipher *backuppb.CipherInfo,
+	file *backuppb.File,
+	fn func(key, value []byte) error,
+) error {
+	content, err := s.ReadFile(ctx, file.Name)
+	if err != nil {
+		return errors.Annotatef(err, "failed to read the sst file %s", file.Name)
+	}
+	if content, err = utils.Decrypt(content, cipher, file.CipherIv); err != nil {
+		return errors.Annotatef(err, "failed to decrypt the sst file %s", file.Name)
+	}
+	reader, err := sstable.NewReader(&memReadable{data: content}, sstable.ReaderOptions{})
+	if err != nil {
+		return errors.Annotatef(err, "failed to open the sst file %s", file.Name)
+	}
+	defer reader.Close()
+	iter, err := reader.NewIter(nil, nil)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer iter.Close()
+	for key, lazyValue := iter.First(); key != nil; key, lazyValue = iter.Next() {
+		value, _, err := lazyValue.Value(nil)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		// the keys in TiKV have the data prefix 'z'.
+		userKey := bytes.TrimPrefix(key.UserKey, []byte{'z'})
+		if err := fn(userKey, value); err != nil {
+			return errors.Annotatef(err, "failed to read the sst file %s", file.Name)
+		}
+	}
+	return errors.Trace(iter.Error())
+}
+
+// memReadable reads the sst file in memory.
+type memReadable struct {
+	data []byte
+}
+
+var _ objstorage.Readable = (*memReadable)(nil)
+
+// ReadAt implements objstorage.Readable.
+func (r *memReadable) ReadAt(_ context.Context, p []byte, off int64) error {
+	if off < 0 || off+int64(len(p)) > int64(len(r.data)) {
+		return io.ErrUnexpectedEOF
+	}
+	copy(p, r.data[off:])
+	return nil
+}
+
+// Close implements objstorage.Readable.
+func (*memReadable) Close() error {
+	return nil
+}
+
+// Size implements objstorage.Readable.
+func (r *memReadable) Size() int64 {
+	return int64(len(r.data))
+}
+
+// NewReadHandle implements objstorage.Readable.
+func (r *memReadable) NewReadHandle(context.Context) objstorage.ReadHandle {
+	h := objstorage.MakeNoopReadHandle(r)
+	return &h
+}
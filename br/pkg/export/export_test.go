@@ -0,0 +1,230 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package export
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/pebble/sstable"
+	backuppb "github.com/pingcap/kvproto/pkg/brpb"
+	"github.com/pingcap/tidb/br/pkg/storage"
+	"github.com/pingcap/tidb/br/pkg/stream"
+	"github.com/pingcap/tidb/pkg/kv"
+	"github.com/pingcap/tidb/pkg/meta/model"
+	"github.com/pingcap/tidb/pkg/parser/ast"
+	"github.com/pingcap/tidb/pkg/parser/mysql"
+	"github.com/pingcap/tidb/pkg/tablecodec"
+	"github.com/pingcap/tidb/pkg/types"
+	"github.com/pingcap/tidb/pkg/util/codec"
+	"github.com/pingcap/tidb/pkg/util/rowcodec"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testTableID = 100
+	// the ts are like the TSO, the start ts in the write cf value has at least 9 bytes.
+	testTSBase     = uint64(1) << 60
+	testSnapshotTS = testTSBase + 100
+	testAsOfTS     = testTSBase + 200
+)
+
+// testTableInfo returns `t(id bigint primary key, name varchar(255), score int default 7)`, the score
+// column is added after the snapshot backup.
+func testTableInfo(t *testing.T) *model.TableInfo {
+	newCol := func(id int64, name string, tp byte) *model.ColumnInfo {
+		col := &model.ColumnInfo{ID: id, Name: ast.NewCIStr(name), Offset: int(id - 1), State: model.StatePublic}
+		col.FieldType = *types.NewFieldType(tp)
+		return col
+	}
+	id := newCol(1, "id", mysql.TypeLonglong)
+	id.AddFlag(mysql.PriKeyFlag | mysql.NotNullFlag)
+	name := newCol(2, "name", mysql.TypeVarchar)
+	name.SetCharset("utf8mb4")
+	name.SetCollate("utf8mb4_bin")
+	score := newCol(3, "score", mysql.TypeLong)
+	require.NoError(t, score.SetOriginDefaultValue("7"))
+	return &model.TableInfo{
+		ID:         testTableID,
+		Name:       ast.NewCIStr("t"),
+		Columns:    []*model.ColumnInfo{id, name, score},
+		PKIsHandle: true,
+		State:      model.StatePublic,
+	}
+}
+
+func encodeTestRow(t *testing.T, name string, score ...int64) []byte {
+	row := []types.Datum{types.NewStringDatum(name)}
+	colIDs := []int64{2}
+	if len(score) > 0 {
+		row = append(row, types.NewIntDatum(score[0]))
+		colIDs = append(colIDs, 3)
+	}
+	value, err := tablecodec.EncodeRow(time.UTC, row, colIDs, nil, nil, nil, &rowcodec.Encoder{})
+	require.NoError(t, err)
+	return value
+}
+
+func encodeTestKey(handle int64) []byte {
+	return encodeTestRecordKey(testTableID, handle)
+}
+
+func encodeTestRecordKey(physicalID, handle int64) []byte {
+	return codec.EncodeBytes(nil, tablecodec.EncodeRowKeyWithHandle(physicalID, kv.IntHandle(handle)))
+}
+
+func withTS(key []byte, ts uint64) []byte {
+	return binary.BigEndian.AppendUint64(bytes.Clone(key), ^ts)
+}
+
+// encodeWriteValue encodes the value of the write cf, the value is stored in the default cf if it's nil.
+func encodeWriteValue(tp stream.WriteType, startTS uint64, shortValue []byte) []byte {
+	data := binary.AppendUvarint([]byte{tp}, startTS)
+	if len(shortValue) > 0 {
+		data = append(data, 'v', byte(len(shortValue)))
+		data = append(data, shortValue...)
+	}
+	return data
+}
+
+func newTestStorage(t *testing.T) storage.ExternalStorage {
+	s, err := storage.NewLocalStorage(t.TempDir())
+	require.NoError(t, err)
+	return s
+}
+
+type memWritable struct {
+	buf bytes.Buffer
+}
+
+func (w *memWritable) Write(p []byte) error {
+	_, err := w.buf.Write(p)
+	return err
+}
+
+func (*memWritable) Finish() error { return nil }
+
+func (*memWritable) Abort() {}
+
+type testKV struct {
+	key, value []byte
+}
+
+// writeSST writes the kvs sorted by key into a sst file with the data prefix like TiKV.
+func writeSST(t *testing.T, s storage.ExternalStorage, name string, kvs ...testKV) {
+	writable := &memWritable{}
+	w := sstable.NewWriter(writable, sstable.WriterOptions{})
+	for _, kv := range kvs {
+		require.NoError(t, w.Set(append([]byte{'z'}, kv.key...), kv.value))
+	}
+	require.NoError(t, w.Close())
+	require.NoError(t, s.WriteFile(context.Background(), name, writable.buf.Bytes()))
+}
+
+// writeLogFile writes the kvs into a data file of the log backup.
+func writeLogFile(t *testing.T, s storage.ExternalStorage, path, cf string, kvs ...testKV) *backuppb.DataFileInfo {
+	var (
+		data         []byte
+		minTS, maxTS uint64 = ^uint64(0), 0
+	)
+	for _, kv := range kvs {
+		data = append(data, stream.EncodeKVEntry(kv.key, kv.value)...)
+		ts := ^binary.BigEndian.Uint64(kv.key[len(kv.key)-8:])
+		minTS, maxTS = min(minTS, ts), max(maxTS, ts)
+	}
+	require.NoError(t, s.WriteFile(context.Background(), path, data))
+	checksum := sha256.Sum256(data)
+	return &backuppb.DataFileInfo{
+		Path:   path,
+		Cf:     cf,
+		MinTs:  minTS,
+		MaxTs:  maxTS,
+		Sha256: checksum[:],
+		Length: uint64(len(data)),
+	}
+}
+
+func TestExportTable(t *testing.T) {
+	ctx := context.Background()
+	snapshot, logStorage, output := newTestStorage(t), newTestStorage(t), newTestStorage(t)
+
+	// the row 3 has a long value in the default cf.
+	longName := string(bytes.Repeat([]byte{'c'}, 300))
+	writeSST(t, snapshot, "1_default.sst",
+		testKV{withTS(encodeTestKey(3), testTSBase+90), encodeTestRow(t, longName)},
+	)
+	writeSST(t, snapshot, "1_write.sst",
+		testKV{withTS(encodeTestKey(1), testTSBase+95), encodeWriteValue(stream.WriteTypePut, testTSBase+90, encodeTestRow(t, "a"))},
+		testKV{withTS(encodeTestKey(2), testTSBase+95), encodeWriteValue(stream.WriteTypePut, testTSBase+90, encodeTestRow(t, "b"))},
+		testKV{withTS(encodeTestKey(3), testTSBase+95), encodeWriteValue(stream.WriteTypePut, testTSBase+90, nil)},
+	)
+	snapshotFiles := []*backuppb.File{
+		{Name: "1_default.sst", Cf: stream.DefaultCF},
+		{Name: "1_write.sst", Cf: stream.WriteCF},
+	}
+
+	longName4 := string(bytes.Repeat([]byte{'d'}, 300))
+	logFiles := []*backuppb.DataFileInfo{
+		writeLogFile(t, logStorage, "log/1_write.log", stream.WriteCF,
+			// committed before the snapshot backup, it's ignored.
+			testKV{withTS(encodeTestKey(1), testTSBase+95), encodeWriteValue(stream.WriteTypePut, testTSBase+90, encodeTestRow(t, "x"))},
+			testKV{withTS(encodeTestKey(2), testTSBase+150), encodeWriteValue(stream.WriteTypePut, testTSBase+140, encodeTestRow(t, "b2", 9))},
+			testKV{withTS(encodeTestKey(2), testTSBase+155), encodeWriteValue(stream.WriteTypeLock, testTSBase+152, nil)},
+			testKV{withTS(encodeTestKey(3), testTSBase+160), encodeWriteValue(stream.WriteTypeDelete, testTSBase+158, nil)},
+			testKV{withTS(encodeTestKey(4), testTSBase+170), encodeWriteValue(stream.WriteTypePut, testTSBase+165, nil)},
+			// committed after the exported ts, it's ignored.
+			testKV{withTS(encodeTestKey(1), testTSBase+250), encodeWriteValue(stream.WriteTypeDelete, testTSBase+240, nil)},
+		),
+		writeLogFile(t, logStorage, "log/1_default.log", stream.DefaultCF,
+			testKV{withTS(encodeTestKey(4), testTSBase+165), encodeTestRow(t, longName4, 10)},
+		),
+	}
+
+	exporter := NewExporter(output, FormatCSV, testAsOfTS)
+	exporter.SetSnapshot(snapshot, nil, testSnapshotTS)
+	exporter.SetLog(logStorage, stream.NewMetadataHelper())
+	table := &Table{DB: "test", Info: testTableInfo(t), SnapshotFiles: snapshotFiles, LogFiles: logFiles}
+	rows, files, err := exporter.ExportTable(ctx, table)
+	require.NoError(t, err)
+	require.EqualValues(t, 3, rows)
+	require.Equal(t, 1, files)
+	require.Equal(t, "test.t.csv", exporter.FileName(table))
+
+	content, err := output.ReadFile(ctx, "test.t.csv")
+	require.NoError(t, err)
+	require.Equal(t, fmt.Sprintf("id,name,score\n1,a,7\n2,b2,9\n4,%s,10\n", longName4), string(content))
+
+	// without the log backup, the rows of the snapshot backup are exported.
+	exporter = NewExporter(output, FormatCSV, testSnapshotTS)
+	exporter.SetSnapshot(snapshot, nil, testSnapshotTS)
+	rows, _, err = exporter.ExportTable(ctx, table)
+	require.NoError(t, err)
+	require.EqualValues(t, 3, rows)
+	content, err = output.ReadFile(ctx, "test.t.csv")
+	require.NoError(t, err)
+	require.Equal(t, fmt.Sprintf("id,name,score\n1,a,7\n2,b,7\n3,%s,7\n", longName), string(content))
+}
+
+func TestExportTableMissingValue(t *testing.T) {
+	ctx := context.Background()
+	logStorage := newTestStorage(t)
+	logFiles := []*backuppb.DataFileInfo{
+		writeLogFile(t, logStorage, "log/1_write.log", stream.WriteCF,
+			testKV{withTS(encodeTestKey(1), testTSBase+150), encodeWriteValue(stream.WriteTypePut, testTSBase+140, nil)},
+		),
+	}
+	exporter := NewExporter(newTestStorage(t), FormatCSV, testAsOfTS)
+	exporter.SetSnapshot(newTestStorage(t), nil, testSnapshotTS)
+	exporter.SetLog(logStorage, stream.NewMetadataHelper())
+	_, _, err := exporter.ExportTable(ctx, &Table{DB: "test", Info: testTableInfo(t), LogFiles: logFiles})
+	require.ErrorContains(t, err, "the values of 1 rows aren't found in the default cf")
+
+	logFiles[0].Sha256 = []byte("broken")
+	_, _, err = exporter.ExportTable(ctx, &Table{DB: "test", Info: testTableInfo(t), LogFiles: logFiles})
+	require.ErrorContains(t, err, "checksum mismatch of the log file log/1_write.log")
+}
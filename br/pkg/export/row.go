@@ -0,0 +1,126 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package export
+
+import (
+	"time"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/tidb/pkg/kv"
+	"github.com/pingcap/tidb/pkg/meta/model"
+	"github.com/pingcap/tidb/pkg/tablecodec"
+	"github.com/pingcap/tidb/pkg/types"
+	"github.com/pingcap/tidb/pkg/util/codec"
+)
+
+// RowDecoder decodes the record kvs of a table into the datums of the exported columns. The rows are
+// decoded by the table info as of the exported ts, the columns added after a row is written are filled
+// by their original default values.
+type RowDecoder struct {
+	cols         []*model.ColumnInfo
+	fieldTypes   map[int64]*types.FieldType
+	handleColIDs []int64
+	defaults     []types.Datum
+	physicalIDs  map[int64]struct{}
+}
+
+// ExportedColumns returns the columns stored in the rows, the hidden and the virtual generated columns
+// aren't exported.
+func ExportedColumns(info *model.TableInfo) []*model.ColumnInfo {
+	cols := make([]*model.ColumnInfo, 0, len(info.Columns))
+	for _, col := range info.Cols() {
+		if col.Hidden || col.IsVirtualGenerated() {
+			continue
+		}
+		cols = append(cols, col)
+	}
+	return cols
+}
+
+// NewRowDecoder creates a decoder of the rows of the table.
+func NewRowDecoder(info *model.TableInfo) (*RowDecoder, error) {
+	d := &RowDecoder{
+		cols:        ExportedColumns(info),
+		fieldTypes:  make(map[int64]*types.FieldType),
+		physicalIDs: map[int64]struct{}{info.ID: {}},
+	}
+	if partitions := info.GetPartitionInfo(); partitions != nil {
+		for _, def := range partitions.Definitions {
+			d.physicalIDs[def.ID] = struct{}{}
+		}
+	}
+	d.defaults = make([]types.Datum, len(d.cols))
+	for i, col := range d.cols {
+		d.fieldTypes[col.ID] = &col.FieldType
+		if value := col.GetOriginDefaultValue(); value != nil {
+			datum := types.NewDatum(value)
+			converted, err := datum.ConvertTo(types.DefaultStmtNoWarningContext, &col.FieldType)
+			if err != nil {
+				return nil, errors.Annotatef(err, "failed to convert the default value of column %s", col.Name.O)
+			}
+			d.defaults[i] = converted
+		}
+	}
+	switch {
+	case info.PKIsHandle:
+		if col := info.GetPkColInfo(); col != nil {
+			d.handleColIDs = []int64{col.ID}
+		}
+	case info.IsCommonHandle:
+		for _, idxCol := range info.GetPrimaryKey().Columns {
+			d.handleColIDs = append(d.handleColIDs, info.Columns[idxCol.Offset].ID)
+		}
+	}
+	return d, nil
+}
+
+// isRecordKey returns whether the encoded key is a row of the table.
+func (d *RowDecoder) isRecordKey(key []byte) bool {
+	_, rawKey, err := codec.DecodeBytes(key, nil)
+	if err != nil || !tablecodec.IsRecordKey(rawKey) {
+		return false
+	}
+	_, ok := d.physicalIDs[tablecodec.DecodeTableID(rawKey)]
+	return ok
+}
+
+// Columns returns the columns of the decoded datums.
+func (d *RowDecoder) Columns() []*model.ColumnInfo {
+	return d.cols
+}
+
+// decode decodes the row by the encoded key and the value, and returns the physical table ID of the row
+// and the datums.
+func (d *RowDecoder) decode(key, value []byte) (int64, []types.Datum, error) {
+	_, rawKey, err := codec.DecodeBytes(key, nil)
+	if err != nil {
+		return 0, nil, errors.Trace(err)
+	}
+	physicalID, _, datums, err := d.DecodeRecord(rawKey, value)
+	return physicalID, datums, errors.Trace(err)
+}
+
+// DecodeRecord decodes the row by the raw record key and the value, and returns the physical table ID,
+// the handle and the datums of the row.
+func (d *RowDecoder) DecodeRecord(rawKey, value []byte) (int64, kv.Handle, []types.Datum, error) {
+	physicalID, handle, err := tablecodec.DecodeRecordKey(rawKey)
+	if err != nil {
+		return 0, nil, nil, errors.Trace(err)
+	}
+	row, err := tablecodec.DecodeRowToDatumMap(value, d.fieldTypes, time.UTC)
+	if err != nil {
+		return 0, nil, nil, errors.Annotatef(err, "failed to decode the row of handle %s", handle)
+	}
+	if row, err = tablecodec.DecodeHandleToDatumMap(handle, d.handleColIDs, d.fieldTypes, time.UTC, row); err != nil {
+		return 0, nil, nil, errors.Annotatef(err, "failed to decode the handle %s", handle)
+	}
+	datums := make([]types.Datum, len(d.cols))
+	for i, col := range d.cols {
+		if datum, ok := row[col.ID]; ok {
+			datums[i] = datum
+		} else {
+			datums[i] = d.defaults[i]
+		}
+	}
+	return physicalID, handle, datums, nil
+}
@@ -0,0 +1,259 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package export
+
+import (
+	"context"
+	"sort"
+
+	"github.com/pingcap/errors"
+	backuppb "github.com/pingcap/kvproto/pkg/brpb"
+	"github.com/pingcap/tidb/br/pkg/encryption"
+	berrors "github.com/pingcap/tidb/br/pkg/errors"
+	"github.com/pingcap/tidb/br/pkg/metautil"
+	"github.com/pingcap/tidb/br/pkg/storage"
+	"github.com/pingcap/tidb/br/pkg/stream"
+	"github.com/pingcap/tidb/pkg/meta/model"
+	filter "github.com/pingcap/tidb/pkg/util/table-filter"
+	"github.com/tikv/client-go/v2/oracle"
+)
+
+// SourceConfig is the backups the tables are read from.
+type SourceConfig struct {
+	SnapshotStorage storage.ExternalStorage
+	// BackupMeta is the decrypted backup meta of the snapshot backup, it's read from SnapshotStorage if
+	// it's nil.
+	BackupMeta *backuppb.BackupMeta
+	Cipher     *backuppb.CipherInfo
+	// LogStorage is the log backup after the snapshot backup, it can be nil if the tables are read as of
+	// the snapshot backup.
+	LogStorage      storage.ExternalStorage
+	LogCipher       *backuppb.CipherInfo
+	MasterKeyConfig *backuppb.MasterKeyConfig
+	// AsOfTS is the ts of the tables, zero means the ts of the snapshot backup.
+	AsOfTS      uint64
+	TableFilter filter.Filter
+}
+
+// Source is the tables as of a ts reconstructed from the snapshot backup and the log backup after it.
+type Source struct {
+	SnapshotTS uint64
+	AsOfTS     uint64
+	// Tables are the tables matched by the filter as of AsOfTS, sorted by the names.
+	Tables []*Table
+	// DBInfos are the databases of the snapshot backup by the names, the ones created in the log backup
+	// aren't included.
+	DBInfos map[string]*model.DBInfo
+
+	snapshotStorage   storage.ExternalStorage
+	cipher            *backuppb.CipherInfo
+	logStorage        storage.ExternalStorage
+	logHelper         *stream.MetadataHelper
+	encryptionManager *encryption.Manager
+}
+
+// NewExporter creates an exporter of the source writing the tables to the output storage.
+func (s *Source) NewExporter(output storage.ExternalStorage, format Format) *Exporter {
+	exporter := NewExporter(output, format, s.AsOfTS)
+	exporter.SetSnapshot(s.snapshotStorage, s.cipher, s.SnapshotTS)
+	if s.logStorage != nil {
+		exporter.SetLog(s.logStorage, s.logHelper)
+	}
+	return exporter
+}
+
+// Close releases the resources of the source.
+func (s *Source) Close() {
+	if s.encryptionManager != nil {
+		s.encryptionManager.Close()
+	}
+}
+
+// OpenSource reads the snapshot backup and the schema changes in the log backup after it, and resolves the
+// tables matched by the filter as of `cfg.AsOfTS`.
+func OpenSource(ctx context.Context, cfg *SourceConfig) (*Source, error) {
+	backupMeta := cfg.BackupMeta
+	if backupMeta == nil {
+		var err error
+		if backupMeta, err = readBackupMeta(ctx, cfg.SnapshotStorage, cfg.Cipher); err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	if backupMeta.IsRawKv || backupMeta.IsTxnKv {
+		return nil, errors.Annotate(berrors.ErrInvalidArgument, "the raw kv and the txn kv backup can't be exported")
+	}
+	snapshotTS := backupMeta.GetEndVersion()
+	asOfTS := cfg.AsOfTS
+	if asOfTS == 0 {
+		asOfTS = snapshotTS
+	}
+	if asOfTS < snapshotTS {
+		return nil, errors.Annotatef(berrors.ErrInvalidArgument,
+			"the exported ts(%d) is less than the ts of the snapshot backup(%d)", asOfTS, snapshotTS)
+	}
+
+	reader := metautil.NewMetaReader(backupMeta, cfg.SnapshotStorage, cfg.Cipher)
+	dbs, err := metautil.LoadBackupTables(ctx, reader, false)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	dbNames := make(map[int64]string, len(dbs))
+	snapshotTables := make(map[int64]*tableState)
+	knownIDs := make(map[int64]struct{})
+	dbInfos := make(map[string]*model.DBInfo, len(dbs))
+	for _, db := range dbs {
+		dbNames[db.Info.ID] = db.Info.Name.O
+		dbInfos[db.Info.Name.O] = db.Info
+		for _, table := range db.Tables {
+			if table.Info == nil {
+				continue
+			}
+			snapshotTables[table.Info.ID] = &tableState{dbID: db.Info.ID, info: table.Info,
+				snapshotFiles: table.Files}
+			knownIDs[table.Info.ID] = struct{}{}
+			if partitions := table.Info.GetPartitionInfo(); partitions != nil {
+				for _, def := range partitions.Definitions {
+					knownIDs[def.ID] = struct{}{}
+				}
+			}
+		}
+	}
+
+	source := &Source{
+		SnapshotTS:      snapshotTS,
+		AsOfTS:          asOfTS,
+		DBInfos:         dbInfos,
+		snapshotStorage: cfg.SnapshotStorage,
+		cipher:          cfg.Cipher,
+	}
+	var (
+		events   []stream.SchemaEvent
+		logFiles map[int64][]*backuppb.DataFileInfo
+	)
+	if cfg.LogStorage != nil {
+		logRange, err := stream.GetLogRange(ctx, cfg.LogStorage)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if logRange.MinTS > snapshotTS || asOfTS > logRange.MaxTS {
+			return nil, errors.Annotatef(berrors.ErrInvalidArgument,
+				"export from %d(%s) to %d(%s), but the current existed log from %d(%s) to %d(%s)",
+				snapshotTS, oracle.GetTimeFromTS(snapshotTS), asOfTS, oracle.GetTimeFromTS(asOfTS),
+				logRange.MinTS, oracle.GetTimeFromTS(logRange.MinTS),
+				logRange.MaxTS, oracle.GetTimeFromTS(logRange.MaxTS))
+		}
+		encryptionManager, err := encryption.NewManager(cfg.LogCipher, cfg.MasterKeyConfig)
+		if err != nil {
+			return nil, errors.Annotate(err, "failed to create encryption manager for log backup")
+		}
+		helper := stream.NewMetadataHelper(stream.WithEncryptionManager(encryptionManager))
+		// the values of the transactions committed after the snapshot backup may be written before it.
+		search := stream.NewSchemaSearch(cfg.LogStorage, helper, "")
+		search.SetStartTS(stream.ShiftTS(snapshotTS))
+		search.SetEndTs(asOfTS)
+		result, err := search.Search(ctx, knownIDs)
+		if err != nil {
+			encryptionManager.Close()
+			return nil, errors.Trace(err)
+		}
+		for id, name := range result.DBNames {
+			dbNames[id] = name
+		}
+		events, logFiles = result.Events, result.Files
+		source.logStorage, source.logHelper, source.encryptionManager = cfg.LogStorage, helper, encryptionManager
+	}
+	source.Tables = resolveTables(snapshotTables, events, logFiles, dbNames, snapshotTS, asOfTS, cfg.TableFilter)
+	return source, nil
+}
+
+func readBackupMeta(
+	ctx context.Context,
+	s storage.ExternalStorage,
+	cipher *backuppb.CipherInfo,
+) (*backuppb.BackupMeta, error) {
+	metaData, err := s.ReadFile(ctx, metautil.MetaFile)
+	if err != nil {
+		return nil, errors.Annotate(err, "load backupmeta failed")
+	}
+	decrypted, err := metautil.DecryptFullBackupMetaIfNeeded(metaData, cipher)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	backupMeta := &backuppb.BackupMeta{}
+	if err := backupMeta.Unmarshal(decrypted); err != nil {
+		return nil, errors.Annotate(err, "parse backupmeta failed because of wrong aes cipher")
+	}
+	return backupMeta, nil
+}
+
+// tableState is a table of the snapshot backup or created in the log backup.
+type tableState struct {
+	dbID int64
+	info *model.TableInfo
+	// snapshotFiles are empty if the table ID doesn't exist in the snapshot backup.
+	snapshotFiles []*backuppb.File
+}
+
+// resolveTables applies the schema changes of the log backup committed after the snapshot backup and at
+// or before asOfTS to the tables of the snapshot backup, and returns the tables matched by the filter as
+// of asOfTS, sorted by the names. The events are sorted by the ts.
+func resolveTables(
+	snapshotTables map[int64]*tableState,
+	events []stream.SchemaEvent,
+	logFiles map[int64][]*backuppb.DataFileInfo,
+	dbNames map[int64]string,
+	snapshotTS, asOfTS uint64,
+	tableFilter filter.Filter,
+) []*Table {
+	tables := make(map[int64]*tableState, len(snapshotTables))
+	for id, table := range snapshotTables {
+		tables[id] = table
+	}
+	for _, event := range events {
+		if event.TS <= snapshotTS {
+			continue
+		}
+		if event.TS > asOfTS {
+			break
+		}
+		if event.Info == nil {
+			delete(tables, event.TableID)
+			continue
+		}
+		table := &tableState{dbID: event.DBID, info: event.Info}
+		if old, ok := tables[event.TableID]; ok {
+			table.snapshotFiles = old.snapshotFiles
+		} else if old, ok := snapshotTables[event.TableID]; ok {
+			// the table is recovered after it's dropped.
+			table.snapshotFiles = old.snapshotFiles
+		}
+		tables[event.TableID] = table
+	}
+
+	exported := make([]*Table, 0, len(tables))
+	for id, table := range tables {
+		db, ok := dbNames[table.dbID]
+		if !ok || table.info.IsView() || table.info.IsSequence() || !tableFilter.MatchTable(db, table.info.Name.O) {
+			continue
+		}
+		files := append([]*backuppb.DataFileInfo{}, logFiles[id]...)
+		if partitions := table.info.GetPartitionInfo(); partitions != nil {
+			for _, def := range partitions.Definitions {
+				files = append(files, logFiles[def.ID]...)
+			}
+		}
+		exported = append(exported, &Table{
+			DB:            db,
+			Info:          table.info,
+			SnapshotFiles: table.snapshotFiles,
+			LogFiles:      files,
+		})
+	}
+	sort.Slice(exported, func(i, j int) bool {
+		if exported[i].DB != exported[j].DB {
+			return exported[i].DB < exported[j].DB
+		}
+		return exported[i].Info.Name.O < exported[j].Info.Name.O
+	})
+	return exported
+}
@@ -0,0 +1,81 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package export
+
+import (
+	"testing"
+
+	backuppb "github.com/pingcap/kvproto/pkg/brpb"
+	"github.com/pingcap/tidb/br/pkg/stream"
+	"github.com/pingcap/tidb/pkg/meta/model"
+	"github.com/pingcap/tidb/pkg/parser/ast"
+	filter "github.com/pingcap/tidb/pkg/util/table-filter"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveTables(t *testing.T) {
+	newInfo := func(id int64, name string) *model.TableInfo {
+		return &model.TableInfo{ID: id, Name: ast.NewCIStr(name)}
+	}
+	partitioned := newInfo(5, "p")
+	partitioned.Partition = &model.PartitionInfo{Enable: true, Definitions: []model.PartitionDefinition{{ID: 51}, {ID: 52}}}
+	view := newInfo(6, "v")
+	view.View = &model.ViewInfo{}
+	snapshotFiles := func(name string) []*backuppb.File {
+		return []*backuppb.File{{Name: name}}
+	}
+	snapshotTables := map[int64]*tableState{
+		1: {dbID: 100, info: newInfo(1, "t1"), snapshotFiles: snapshotFiles("1.sst")},
+		// truncated after the snapshot backup.
+		2: {dbID: 100, info: newInfo(2, "t2"), snapshotFiles: snapshotFiles("2.sst")},
+		3: {dbID: 100, info: newInfo(3, "dropped"), snapshotFiles: snapshotFiles("3.sst")},
+		5: {dbID: 101, info: partitioned, snapshotFiles: snapshotFiles("5.sst")},
+		6: {dbID: 100, info: view},
+	}
+	events := []stream.SchemaEvent{
+		// written before the snapshot backup.
+		{TS: 90, DBID: 100, TableID: 1, Info: newInfo(1, "old")},
+		// renamed into another database.
+		{TS: 110, DBID: 100, TableID: 1},
+		{TS: 110, DBID: 101, TableID: 1, Info: newInfo(1, "t1_renamed")},
+		{TS: 120, DBID: 100, TableID: 2},
+		{TS: 120, DBID: 100, TableID: 7, Info: newInfo(7, "t2")},
+		{TS: 130, DBID: 100, TableID: 3},
+		// after the exported ts.
+		{TS: 300, DBID: 100, TableID: 7},
+		{TS: 300, DBID: 100, TableID: 8, Info: newInfo(8, "later")},
+	}
+	logFiles := map[int64][]*backuppb.DataFileInfo{
+		1:  {{Path: "1.log"}},
+		2:  {{Path: "2.log"}},
+		7:  {{Path: "7.log"}},
+		51: {{Path: "51.log"}},
+		52: {{Path: "52.log"}},
+	}
+	dbNames := map[int64]string{100: "db", 101: "other"}
+	allTables, err := filter.Parse([]string{"*.*"})
+	require.NoError(t, err)
+
+	tables := resolveTables(snapshotTables, events, logFiles, dbNames, 100, 200, allTables)
+	require.Equal(t, []*Table{
+		{DB: "db", Info: newInfo(7, "t2"), LogFiles: []*backuppb.DataFileInfo{{Path: "7.log"}}},
+		{DB: "other", Info: partitioned, SnapshotFiles: snapshotFiles("5.sst"),
+			LogFiles: []*backuppb.DataFileInfo{{Path: "51.log"}, {Path: "52.log"}}},
+		{DB: "other", Info: newInfo(1, "t1_renamed"), SnapshotFiles: snapshotFiles("1.sst"),
+			LogFiles: []*backuppb.DataFileInfo{{Path: "1.log"}}},
+	}, tables)
+
+	// the tables as of the snapshot backup.
+	tables = resolveTables(snapshotTables, events, logFiles, dbNames, 100, 100, allTables)
+	names := make([]string, 0, len(tables))
+	for _, table := range tables {
+		names = append(names, table.DB+"."+table.Info.Name.O)
+	}
+	require.Equal(t, []string{"db.dropped", "db.t1", "db.t2", "other.p"}, names)
+
+	onlyOther, err := filter.Parse([]string{"other.t*"})
+	require.NoError(t, err)
+	tables = resolveTables(snapshotTables, events, logFiles, dbNames, 100, 200, onlyOther)
+	require.Len(t, tables, 1)
+	require.Equal(t, "t1_renamed", tables[0].Info.Name.O)
+}
@@ -0,0 +1,281 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package export
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pingcap/errors"
+	berrors "github.com/pingcap/tidb/br/pkg/errors"
+	"github.com/pingcap/tidb/br/pkg/storage"
+	"github.com/pingcap/tidb/pkg/meta/model"
+	"github.com/pingcap/tidb/pkg/parser/charset"
+	"github.com/pingcap/tidb/pkg/parser/mysql"
+	"github.com/pingcap/tidb/pkg/types"
+	"github.com/xitongsys/parquet-go/writer"
+)
+
+// Format is the format of the exported files.
+type Format string
+
+const (
+	// FormatCSV writes a header line with the column names, NULL is written as `\N`.
+	FormatCSV Format = "csv"
+	// FormatParquet writes the integers, the floats, the dates and the datetimes as the parquet types,
+	// and the others as strings.
+	FormatParquet Format = "parquet"
+)
+
+// ParseFormat parses the format of the exported files.
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(strings.ToLower(s)); f {
+	case FormatCSV, FormatParquet:
+		return f, nil
+	}
+	return "", errors.Annotatef(berrors.ErrInvalidArgument, "unsupported export format %q, should be %s or %s",
+		s, FormatCSV, FormatParquet)
+}
+
+const (
+	csvNull          = `\N`
+	writeBufferSize  = 1 << 20
+	parquetWriterNum = 4
+)
+
+// rowWriter writes the rows of a table into a file.
+type rowWriter interface {
+	writeRow(row []types.Datum) error
+	close(ctx context.Context) error
+}
+
+// storageWriter adapts the external file writer to io.Writer.
+type storageWriter struct {
+	ctx context.Context
+	w   storage.ExternalFileWriter
+}
+
+func (w *storageWriter) Write(p []byte) (int, error) {
+	return w.w.Write(w.ctx, p)
+}
+
+func newRowWriter(
+	ctx context.Context,
+	s storage.ExternalStorage,
+	name string,
+	format Format,
+	cols []*model.ColumnInfo,
+) (rowWriter, error) {
+	fileWriter, err := s.Create(ctx, name, nil)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	buf := bufio.NewWriterSize(&storageWriter{ctx: ctx, w: fileWriter}, writeBufferSize)
+	switch format {
+	case FormatParquet:
+		w, err := newParquetRowWriter(buf, fileWriter, cols)
+		if err != nil {
+			_ = fileWriter.Close(ctx)
+			return nil, errors.Trace(err)
+		}
+		return w, nil
+	default:
+		w := &csvRowWriter{buf: buf, file: fileWriter, w: csv.NewWriter(buf), cols: cols}
+		header := make([]string, 0, len(cols))
+		for _, col := range cols {
+			header = append(header, col.Name.O)
+		}
+		if err := w.w.Write(header); err != nil {
+			_ = fileWriter.Close(ctx)
+			return nil, errors.Trace(err)
+		}
+		return w, nil
+	}
+}
+
+// csvRowWriter writes the rows as csv.
+type csvRowWriter struct {
+	buf    *bufio.Writer
+	file   storage.ExternalFileWriter
+	w      *csv.Writer
+	cols   []*model.ColumnInfo
+	record []string
+}
+
+func (w *csvRowWriter) writeRow(row []types.Datum) error {
+	w.record = w.record[:0]
+	for i := range row {
+		s, ok, err := formatDatum(&row[i], &w.cols[i].FieldType)
+		if err != nil {
+			return errors.Annotatef(err, "failed to format column %s", w.cols[i].Name.O)
+		}
+		if !ok {
+			s = csvNull
+		}
+		w.record = append(w.record, s)
+	}
+	return errors.Trace(w.w.Write(w.record))
+}
+
+func (w *csvRowWriter) close(ctx context.Context) error {
+	w.w.Flush()
+	if err := w.w.Error(); err != nil {
+		_ = w.file.Close(ctx)
+		return errors.Trace(err)
+	}
+	if err := w.buf.Flush(); err != nil {
+		_ = w.file.Close(ctx)
+		return errors.Trace(err)
+	}
+	return errors.Trace(w.file.Close(ctx))
+}
+
+// formatDatum formats the datum as a string, false is returned if it's NULL.
+func formatDatum(d *types.Datum, ft *types.FieldType) (string, bool, error) {
+	switch d.Kind() {
+	case types.KindNull:
+		return "", false, nil
+	case types.KindMysqlBit, types.KindBinaryLiteral:
+		if ft.GetType() == mysql.TypeBit {
+			v, err := d.GetBinaryLiteral().ToInt(types.DefaultStmtNoWarningContext)
+			return strconv.FormatUint(v, 10), true, errors.Trace(err)
+		}
+	}
+	s, err := d.ToString()
+	return s, true, errors.Trace(err)
+}
+
+// parquetRowWriter writes the rows as parquet.
+type parquetRowWriter struct {
+	buf  *bufio.Writer
+	file storage.ExternalFileWriter
+	w    *writer.CSVWriter
+	cols []*model.ColumnInfo
+}
+
+// parquetType returns the physical type and the converted type of the column in parquet.
+func parquetType(ft *types.FieldType) (physical, converted string) {
+	switch ft.GetType() {
+	case mysql.TypeTiny, mysql.TypeShort, mysql.TypeInt24, mysql.TypeLong, mysql.TypeYear, mysql.TypeBit:
+		return "INT64", ""
+	case mysql.TypeLonglong:
+		if mysql.HasUnsignedFlag(ft.GetFlag()) {
+			return "INT64", "UINT_64"
+		}
+		return "INT64", ""
+	case mysql.TypeFloat:
+		return "FLOAT", ""
+	case mysql.TypeDouble:
+		return "DOUBLE", ""
+	case mysql.TypeDate:
+		return "INT32", "DATE"
+	case mysql.TypeDatetime, mysql.TypeTimestamp:
+		return "INT64", "TIMESTAMP_MICROS"
+	case mysql.TypeVarchar, mysql.TypeVarString, mysql.TypeString, mysql.TypeBlob, mysql.TypeTinyBlob,
+		mysql.TypeMediumBlob, mysql.TypeLongBlob:
+		if ft.GetCharset() == charset.CharsetBin {
+			return "BYTE_ARRAY", ""
+		}
+	}
+	return "BYTE_ARRAY", "UTF8"
+}
+
+func newParquetRowWriter(
+	buf *bufio.Writer,
+	file storage.ExternalFileWriter,
+	cols []*model.ColumnInfo,
+) (*parquetRowWriter, error) {
+	// the tags of the schema are separated by ',' and '='.
+	nameReplacer := strings.NewReplacer(",", "_", "=", "_")
+	md := make([]string, 0, len(cols))
+	for _, col := range cols {
+		physical, converted := parquetType(&col.FieldType)
+		tag := fmt.Sprintf("name=%s, type=%s, repetitiontype=OPTIONAL", nameReplacer.Replace(col.Name.O), physical)
+		if converted != "" {
+			tag += ", convertedtype=" + converted
+		}
+		md = append(md, tag)
+	}
+	w, err := writer.NewCSVWriterFromWriter(md, buf, parquetWriterNum)
+	if err != nil {
+		return nil, errors.Annotate(err, "failed to create the parquet writer")
+	}
+	return &parquetRowWriter{buf: buf, file: file, w: w, cols: cols}, nil
+}
+
+func (w *parquetRowWriter) writeRow(row []types.Datum) error {
+	// the record is buffered by the writer until the row group is flushed, so it can't be reused.
+	record := make([]any, len(row))
+	for i := range row {
+		v, err := parquetValue(&row[i], &w.cols[i].FieldType)
+		if err != nil {
+			return errors.Annotatef(err, "failed to convert column %s", w.cols[i].Name.O)
+		}
+		record[i] = v
+	}
+	return errors.Trace(w.w.Write(record))
+}
+
+// parquetValue converts the datum to the value of the parquet type of the column, nil is NULL.
+func parquetValue(d *types.Datum, ft *types.FieldType) (any, error) {
+	if d.IsNull() {
+		return nil, nil
+	}
+	physical, converted := parquetType(ft)
+	switch physical {
+	case "INT64":
+		switch d.Kind() {
+		case types.KindInt64:
+			return d.GetInt64(), nil
+		case types.KindUint64:
+			return int64(d.GetUint64()), nil
+		case types.KindMysqlBit, types.KindBinaryLiteral:
+			v, err := d.GetBinaryLiteral().ToInt(types.DefaultStmtNoWarningContext)
+			return int64(v), errors.Trace(err)
+		case types.KindMysqlTime:
+			t, err := d.GetMysqlTime().GoTime(time.UTC)
+			if err != nil {
+				// the zero datetime can't be represented.
+				return nil, nil
+			}
+			return t.UnixMicro(), nil
+		}
+	case "INT32":
+		t, err := d.GetMysqlTime().GoTime(time.UTC)
+		if err != nil {
+			return nil, nil
+		}
+		days := t.Unix() / 86400
+		if t.Unix() < 0 && t.Unix()%86400 != 0 {
+			days--
+		}
+		return int32(days), nil
+	case "FLOAT":
+		return d.GetFloat32(), nil
+	case "DOUBLE":
+		return d.GetFloat64(), nil
+	case "BYTE_ARRAY":
+		if converted == "" {
+			return string(d.GetBytes()), nil
+		}
+	}
+	s, _, err := formatDatum(d, ft)
+	return s, errors.Trace(err)
+}
+
+func (w *parquetRowWriter) close(ctx context.Context) error {
+	if err := w.w.WriteStop(); err != nil {
+		_ = w.file.Close(ctx)
+		return errors.Annotate(err, "failed to finish the parquet file")
+	}
+	if err := w.buf.Flush(); err != nil {
+		_ = w.file.Close(ctx)
+		return errors.Trace(err)
+	}
+	return errors.Trace(w.file.Close(ctx))
+}
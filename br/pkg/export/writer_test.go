@@ -0,0 +1,121 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package export
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pingcap/tidb/pkg/meta/model"
+	"github.com/pingcap/tidb/pkg/parser/ast"
+	"github.com/pingcap/tidb/pkg/parser/charset"
+	"github.com/pingcap/tidb/pkg/parser/mysql"
+	"github.com/pingcap/tidb/pkg/types"
+	"github.com/stretchr/testify/require"
+	"github.com/xitongsys/parquet-go-source/buffer"
+	"github.com/xitongsys/parquet-go/reader"
+)
+
+func TestParseFormat(t *testing.T) {
+	f, err := ParseFormat("CSV")
+	require.NoError(t, err)
+	require.Equal(t, FormatCSV, f)
+	f, err = ParseFormat("parquet")
+	require.NoError(t, err)
+	require.Equal(t, FormatParquet, f)
+	_, err = ParseFormat("sql")
+	require.ErrorContains(t, err, `unsupported export format "sql"`)
+}
+
+func writerTestColumns() []*model.ColumnInfo {
+	newCol := func(name string, tp byte) *model.ColumnInfo {
+		col := &model.ColumnInfo{Name: ast.NewCIStr(name)}
+		col.FieldType = *types.NewFieldType(tp)
+		return col
+	}
+	id := newCol("id", mysql.TypeLonglong)
+	id.AddFlag(mysql.UnsignedFlag)
+	name := newCol("name,with=comma", mysql.TypeVarchar)
+	name.SetCharset("utf8mb4")
+	raw := newCol("raw", mysql.TypeBlob)
+	raw.SetCharset(charset.CharsetBin)
+	bit := newCol("flags", mysql.TypeBit)
+	bit.SetFlen(8)
+	return []*model.ColumnInfo{
+		id, name, raw, bit,
+		newCol("price", mysql.TypeDouble),
+		newCol("day", mysql.TypeDate),
+		newCol("at", mysql.TypeDatetime),
+		newCol("amount", mysql.TypeNewDecimal),
+	}
+}
+
+func writerTestRows(t *testing.T) [][]types.Datum {
+	day, err := types.ParseDate(types.DefaultStmtNoWarningContext, "2024-01-02")
+	require.NoError(t, err)
+	at, err := types.ParseDatetime(types.DefaultStmtNoWarningContext, "2024-01-02 03:04:05.5")
+	require.NoError(t, err)
+	return [][]types.Datum{
+		{
+			types.NewUintDatum(1),
+			types.NewStringDatum("a,\"b\""),
+			types.NewBytesDatum([]byte{0, 1}),
+			types.NewMysqlBitDatum(types.NewBinaryLiteralFromUint(5, 1)),
+			types.NewFloat64Datum(1.5),
+			types.NewTimeDatum(day),
+			types.NewTimeDatum(at),
+			types.NewDecimalDatum(types.NewDecFromStringForTest("12.34")),
+		},
+		{
+			types.NewUintDatum(2),
+			{}, {}, {}, {}, {}, {}, {},
+		},
+	}
+}
+
+func writeTestRows(t *testing.T, format Format) []byte {
+	ctx := context.Background()
+	s := newTestStorage(t)
+	w, err := newRowWriter(ctx, s, "t."+string(format), format, writerTestColumns())
+	require.NoError(t, err)
+	for _, row := range writerTestRows(t) {
+		require.NoError(t, w.writeRow(row))
+	}
+	require.NoError(t, w.close(ctx))
+	content, err := s.ReadFile(ctx, "t."+string(format))
+	require.NoError(t, err)
+	return content
+}
+
+func TestCSVRowWriter(t *testing.T) {
+	content := writeTestRows(t, FormatCSV)
+	require.Equal(t, "id,\"name,with=comma\",raw,flags,price,day,at,amount\n"+
+		"1,\"a,\"\"b\"\"\",\x00\x01,5,1.5,2024-01-02,2024-01-02 03:04:05.5,12.34\n"+
+		"2,\\N,\\N,\\N,\\N,\\N,\\N,\\N\n", string(content))
+}
+
+func TestParquetRowWriter(t *testing.T) {
+	content := writeTestRows(t, FormatParquet)
+	file, err := buffer.NewBufferFile(content)
+	require.NoError(t, err)
+	r, err := reader.NewParquetReader(file, nil, 1)
+	require.NoError(t, err)
+	defer r.ReadStop()
+	require.EqualValues(t, 2, r.GetNumRows())
+
+	expected := [][]any{
+		{int64(1), int64(2)},
+		{"a,\"b\"", nil},
+		{"\x00\x01", nil},
+		{int64(5), nil},
+		{1.5, nil},
+		{int32(19724), nil},
+		{int64(1704164645500000), nil},
+		{"12.34", nil},
+	}
+	for i, values := range expected {
+		actual, _, _, err := r.ReadColumnByIndex(int64(i), 2)
+		require.NoError(t, err)
+		require.Equal(t, values, actual, "column %d", i)
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/pingcap/tidb/pkg/meta/model"
 	"github.com/pingcap/tidb/pkg/parser/ast"
 	"github.com/pingcap/tidb/pkg/sessionctx"
+	"github.com/tikv/client-go/v2/config"
 	pd "github.com/tikv/pd/client"
 )
 
@@ -25,7 +26,8 @@ const (
 type Glue interface {
 	GetDomain(store kv.Storage) (*domain.Domain, error)
 	CreateSession(store kv.Storage) (Session, error)
-	Open(path string, option pd.SecurityOption) (kv.Storage, error)
+	// Open opens the storage, which connects to PD by option and to TiKV by tikvSecurity.
+	Open(path string, option pd.SecurityOption, tikvSecurity config.Security) (kv.Storage, error)
 
 	// OwnsStorage returns whether the storage returned by Open() is owned
 	// If this method returns false, the connection manager will never close the storage.
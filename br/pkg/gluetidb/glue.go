@@ -22,6 +22,7 @@ import (
 	"github.com/pingcap/tidb/pkg/session"
 	sessiontypes "github.com/pingcap/tidb/pkg/session/types"
 	"github.com/pingcap/tidb/pkg/sessionctx"
+	tikvconfig "github.com/tikv/client-go/v2/config"
 	pd "github.com/tikv/pd/client"
 	"go.uber.org/zap"
 )
@@ -125,8 +126,8 @@ func (g Glue) createTypesSession(store kv.Storage) (sessiontypes.Session, error)
 }
 
 // Open implements glue.Glue.
-func (g Glue) Open(path string, option pd.SecurityOption) (kv.Storage, error) {
-	return g.tikvGlue.Open(path, option)
+func (g Glue) Open(path string, option pd.SecurityOption, tikvSecurity tikvconfig.Security) (kv.Storage, error) {
+	return g.tikvGlue.Open(path, option, tikvSecurity)
 }
 
 // OwnsStorage implements glue.Glue.
@@ -26,6 +26,7 @@ import (
 	"github.com/pingcap/tidb/pkg/parser/ast"
 	sessiontypes "github.com/pingcap/tidb/pkg/session/types"
 	"github.com/pingcap/tidb/pkg/sessionctx"
+	"github.com/tikv/client-go/v2/config"
 	pd "github.com/tikv/pd/client"
 )
 
@@ -130,7 +131,7 @@ func (m *MockGlue) CreateSession(store kv.Storage) (glue.Session, error) {
 }
 
 // Open implements glue.Glue.
-func (*MockGlue) Open(path string, option pd.SecurityOption) (kv.Storage, error) {
+func (*MockGlue) Open(path string, option pd.SecurityOption, tikvSecurity config.Security) (kv.Storage, error) {
 	return nil, nil
 }
 
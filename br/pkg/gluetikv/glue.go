@@ -13,6 +13,7 @@ import (
 	"github.com/pingcap/tidb/pkg/domain"
 	"github.com/pingcap/tidb/pkg/kv"
 	"github.com/pingcap/tidb/pkg/store/driver"
+	tikvconfig "github.com/tikv/client-go/v2/config"
 	pd "github.com/tikv/pd/client"
 )
 
@@ -38,7 +39,7 @@ func (Glue) CreateSession(store kv.Storage) (glue.Session, error) {
 }
 
 // Open implements glue.Glue.
-func (Glue) Open(path string, option pd.SecurityOption) (kv.Storage, error) {
+func (Glue) Open(path string, option pd.SecurityOption, tikvSecurity tikvconfig.Security) (kv.Storage, error) {
 	if option.CAPath != "" {
 		conf := config.GetGlobalConfig()
 		conf.Security.ClusterSSLCA = option.CAPath
@@ -46,6 +47,9 @@ func (Glue) Open(path string, option pd.SecurityOption) (kv.Storage, error) {
 		conf.Security.ClusterSSLKey = option.KeyPath
 		config.StoreGlobalConfig(conf)
 	}
+	if tikvSecurity.ClusterSSLCA != "" {
+		return driver.TiKVDriver{}.OpenWithOptions(path, driver.WithTiKVSecurity(tikvSecurity))
+	}
 	return driver.TiKVDriver{}.Open(path)
 }
 
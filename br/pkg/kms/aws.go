@@ -73,6 +73,20 @@ func (a *AwsKms) DecryptDataKey(ctx context.Context, dataKey []byte) ([]byte, er
 	return result.Plaintext, nil
 }
 
+func (a *AwsKms) EncryptDataKey(ctx context.Context, dataKey []byte) ([]byte, error) {
+	input := &kms.EncryptInput{
+		Plaintext: dataKey,
+		KeyId:     aws.String(a.currentKeyID),
+	}
+
+	result, err := a.client.EncryptWithContext(ctx, input)
+	if err != nil {
+		return nil, classifyDecryptError(err)
+	}
+
+	return result.CiphertextBlob, nil
+}
+
 func (a *AwsKms) Close() {
 	// don't need to do manual close
 }
@@ -83,6 +83,28 @@ func (g *GcpKms) DecryptDataKey(ctx context.Context, dataKey []byte) ([]byte, er
 	return resp.Plaintext, nil
 }
 
+func (g *GcpKms) EncryptDataKey(ctx context.Context, dataKey []byte) ([]byte, error) {
+	req := &kmspb.EncryptRequest{
+		Name:            g.config.KeyId,
+		Plaintext:       dataKey,
+		PlaintextCrc32C: wrapperspb.Int64(int64(g.calculateCRC32C(dataKey))),
+	}
+
+	resp, err := g.client.Encrypt(ctx, req)
+	if err != nil {
+		return nil, errors.Annotate(err, "gcp kms encrypt request failed")
+	}
+
+	if !resp.VerifiedPlaintextCrc32C {
+		return nil, errors.New("request corrupted in-transit")
+	}
+	if err := g.checkCRC32(resp.Ciphertext, resp.CiphertextCrc32C.Value); err != nil {
+		return nil, errors.Annotate(err, "response corrupted in-transit")
+	}
+
+	return resp.Ciphertext, nil
+}
+
 func (g *GcpKms) checkCRC32(data []byte, expected int64) error {
 	crc := int64(g.calculateCRC32C(data))
 	if crc != expected {
@@ -5,9 +5,10 @@ package kms
 import "context"
 
 // Provider is an interface for key management service providers
-// implement encrypt data key in future if needed
 type Provider interface {
 	DecryptDataKey(ctx context.Context, dataKey []byte) ([]byte, error)
+	// EncryptDataKey wraps a plaintext data key with the master key held by the KMS.
+	EncryptDataKey(ctx context.Context, dataKey []byte) ([]byte, error)
 	Name() string
 	Close()
 }
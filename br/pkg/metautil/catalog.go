@@ -0,0 +1,120 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package metautil
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"slices"
+	"time"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/log"
+	berrors "github.com/pingcap/tidb/br/pkg/errors"
+	"github.com/pingcap/tidb/br/pkg/storage"
+	"go.uber.org/zap"
+)
+
+// CatalogFile is the catalog at the storage root, which records the backup and restore operations of the
+// backups under the root. Every line of it is a JSON encoded CatalogRecord, and the lines are only
+// appended, so the backups can be listed without opening their backupmeta files.
+const CatalogFile = "backup.catalog"
+
+const (
+	// CatalogBackup is the operation writing a backup.
+	CatalogBackup = "backup"
+	// CatalogRestore is the operation restoring a backup.
+	CatalogRestore = "restore"
+
+	// CatalogKindSnapshot is the snapshot backup.
+	CatalogKindSnapshot = "snapshot"
+	// CatalogKindLog is the log backup.
+	CatalogKindLog = "log"
+
+	// CatalogSuccess is the status of the operation finished.
+	CatalogSuccess = "success"
+	// CatalogFailed is the status of the operation failed.
+	CatalogFailed = "failed"
+
+	catalogAppendRetry = 16
+)
+
+// CatalogRecord is the key facts of a backup or restore operation in the catalog.
+type CatalogRecord struct {
+	Operation string `json:"operation"`
+	Kind      string `json:"kind"`
+	// Path is the storage of the backup, relative to the storage root if it's under the root.
+	Path string `json:"path"`
+	// StartTS and EndTS are the range of the backup, for the restore the EndTS is the restored ts.
+	StartTS   uint64            `json:"start_ts"`
+	EndTS     uint64            `json:"end_ts"`
+	ClusterID uint64            `json:"cluster_id"`
+	Size      uint64            `json:"size"`
+	Status    string            `json:"status"`
+	Error     string            `json:"error,omitempty"`
+	Labels    map[string]string `json:"labels,omitempty"`
+	Time      time.Time         `json:"time"`
+}
+
+// AppendCatalogRecord appends the record to the catalog at the storage root. The catalog is rewritten only
+// if it isn't changed since it's read, so the records appended by the concurrent operations aren't lost.
+func AppendCatalogRecord(ctx context.Context, s storage.ExternalStorage, record *CatalogRecord) error {
+	w, ok := s.(storage.ConditionalWriter)
+	if !ok {
+		return errors.Annotatef(berrors.ErrStorageInvalidConfig,
+			"the storage %s doesn't support the conditional write, the catalog can't be updated atomically", s.URI())
+	}
+	line, err := json.Marshal(record)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	line = append(line, '\n')
+	for i := 0; ; i++ {
+		data, version, err := w.ReadFileWithVersion(ctx, CatalogFile)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		_, err = w.WriteFileIfMatch(ctx, CatalogFile, append(slices.Clip(data), line...), version)
+		if err == nil {
+			return nil
+		}
+		if !berrors.Is(err, berrors.ErrStorageConditionNotMet) || i+1 >= catalogAppendRetry {
+			return errors.Trace(err)
+		}
+		log.Info("the catalog is changed by others, retry appending", zap.Int("retry", i+1))
+		select {
+		case <-ctx.Done():
+			return errors.Trace(ctx.Err())
+		case <-time.After(time.Duration(rand.Int63n(int64(100*time.Millisecond))) + 10*time.Millisecond):
+		}
+	}
+}
+
+// ReadCatalog reads the records in the catalog at the storage root in the order of appending. It returns
+// false if there is no catalog.
+func ReadCatalog(ctx context.Context, s storage.ExternalStorage) ([]*CatalogRecord, bool, error) {
+	exists, err := s.FileExists(ctx, CatalogFile)
+	if err != nil || !exists {
+		return nil, false, errors.Trace(err)
+	}
+	data, err := s.ReadFile(ctx, CatalogFile)
+	if err != nil {
+		return nil, false, errors.Trace(err)
+	}
+	records := make([]*CatalogRecord, 0)
+	for i, line := range bytes.Split(data, []byte{'\n'}) {
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		record := &CatalogRecord{}
+		if err := json.Unmarshal(line, record); err != nil {
+			return nil, false, berrors.ErrInvalidMetaFile.GenWithStackByArgs(fmt.Sprintf(
+				"failed to decode the line %d of the catalog: %v", i+1, err))
+		}
+		records = append(records, record)
+	}
+	return records, true, nil
+}
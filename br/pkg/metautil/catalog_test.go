@@ -0,0 +1,62 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package metautil
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	berrors "github.com/pingcap/tidb/br/pkg/errors"
+	"github.com/pingcap/tidb/br/pkg/storage"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sync/errgroup"
+)
+
+func TestCatalog(t *testing.T) {
+	ctx := context.Background()
+	s, err := storage.NewLocalStorage(t.TempDir())
+	require.NoError(t, err)
+	records, exists, err := ReadCatalog(ctx, s)
+	require.NoError(t, err)
+	require.False(t, exists)
+	require.Empty(t, records)
+
+	// the records appended concurrently are all kept.
+	var eg errgroup.Group
+	for i := range 8 {
+		eg.Go(func() error {
+			return AppendCatalogRecord(ctx, s, &CatalogRecord{
+				Operation: CatalogBackup,
+				Kind:      CatalogKindSnapshot,
+				Path:      fmt.Sprintf("full%d", i),
+				EndTS:     uint64(i),
+				Status:    CatalogSuccess,
+				Labels:    map[string]string{"env": "prod"},
+			})
+		})
+	}
+	require.NoError(t, eg.Wait())
+	records, exists, err = ReadCatalog(ctx, s)
+	require.NoError(t, err)
+	require.True(t, exists)
+	require.Len(t, records, 8)
+	paths := make(map[string]struct{})
+	for _, r := range records {
+		require.Equal(t, CatalogSuccess, r.Status)
+		require.Equal(t, map[string]string{"env": "prod"}, r.Labels)
+		paths[r.Path] = struct{}{}
+	}
+	require.Len(t, paths, 8)
+
+	require.NoError(t, AppendCatalogRecord(ctx, s, &CatalogRecord{
+		Operation: CatalogRestore, Kind: CatalogKindLog, Path: "log", EndTS: 42, Status: CatalogFailed, Error: "oops"}))
+	records, _, err = ReadCatalog(ctx, s)
+	require.NoError(t, err)
+	require.Len(t, records, 9)
+	require.Equal(t, "oops", records[8].Error)
+
+	require.NoError(t, s.WriteFile(ctx, CatalogFile, []byte("{}\nnot json\n")))
+	_, _, err = ReadCatalog(ctx, s)
+	require.ErrorIs(t, err, berrors.ErrInvalidMetaFile)
+}
@@ -0,0 +1,83 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package metautil
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/pingcap/errors"
+	backuppb "github.com/pingcap/kvproto/pkg/brpb"
+	"github.com/pingcap/tidb/br/pkg/storage"
+	pdhttp "github.com/tikv/pd/client/http"
+)
+
+// ClusterConfigFile is the snapshot of the cluster config saved alongside the backup data, it's encrypted
+// like the backupmeta.
+const ClusterConfigFile = "cluster_config.json"
+
+// ClusterConfigSnapshot is the config of the backed up cluster, which is compared with or applied to the
+// cluster restored into by `br restore config`, so the rebuilt cluster behaves like the original one.
+type ClusterConfigSnapshot struct {
+	ClusterID uint64 `json:"cluster_id"`
+	BackupTS  uint64 `json:"backup_ts"`
+	// PDSchedule is the schedule config of PD, without the items bound to the store IDs.
+	PDSchedule map[string]any `json:"pd_schedule"`
+	// PlacementRules are the placement rule groups not bound to the table IDs.
+	PlacementRules []*pdhttp.GroupBundle `json:"placement_rules"`
+	Stores         []*ClusterConfigStore `json:"stores"`
+}
+
+// ClusterConfigStore is the labels and the important config items of a TiKV store.
+type ClusterConfigStore struct {
+	ID      uint64            `json:"id"`
+	Address string            `json:"address"`
+	Labels  map[string]string `json:"labels,omitempty"`
+	// TiKV is the config items keyed by the dotted paths like "coprocessor.region-split-size".
+	TiKV map[string]any `json:"tikv,omitempty"`
+}
+
+// WriteClusterConfig writes the snapshot of the cluster config into the backup storage.
+func WriteClusterConfig(
+	ctx context.Context,
+	s storage.ExternalStorage,
+	snapshot *ClusterConfigSnapshot,
+	cipher *backuppb.CipherInfo,
+) error {
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	encrypted, iv, err := Encrypt(data, cipher)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Annotatef(s.WriteFile(ctx, ClusterConfigFile, append(iv, encrypted...)),
+		"failed to save the cluster config to %s", ClusterConfigFile)
+}
+
+// ReadClusterConfig reads the snapshot of the cluster config written by WriteClusterConfig. It returns
+// false if the backup has no snapshot, e.g. it's taken by an old BR.
+func ReadClusterConfig(
+	ctx context.Context,
+	s storage.ExternalStorage,
+	cipher *backuppb.CipherInfo,
+) (*ClusterConfigSnapshot, bool, error) {
+	exists, err := s.FileExists(ctx, ClusterConfigFile)
+	if err != nil || !exists {
+		return nil, false, errors.Trace(err)
+	}
+	data, err := s.ReadFile(ctx, ClusterConfigFile)
+	if err != nil {
+		return nil, false, errors.Trace(err)
+	}
+	data, err = DecryptFullBackupMetaIfNeeded(data, cipher)
+	if err != nil {
+		return nil, false, errors.Trace(err)
+	}
+	snapshot := &ClusterConfigSnapshot{}
+	if err := json.Unmarshal(data, snapshot); err != nil {
+		return nil, false, errors.Annotatef(err, "failed to parse the cluster config in %s", ClusterConfigFile)
+	}
+	return snapshot, true, nil
+}
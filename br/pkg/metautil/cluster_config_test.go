@@ -0,0 +1,62 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package metautil
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	backuppb "github.com/pingcap/kvproto/pkg/brpb"
+	"github.com/pingcap/kvproto/pkg/encryptionpb"
+	"github.com/pingcap/tidb/br/pkg/storage"
+	"github.com/stretchr/testify/require"
+	pdhttp "github.com/tikv/pd/client/http"
+)
+
+func TestClusterConfig(t *testing.T) {
+	ctx := context.Background()
+	s, err := storage.NewLocalStorage(t.TempDir())
+	require.NoError(t, err)
+	cipher := &backuppb.CipherInfo{
+		CipherType: encryptionpb.EncryptionMethod_AES128_CTR,
+		CipherKey:  bytes.Repeat([]byte{1}, 16),
+	}
+	_, exists, err := ReadClusterConfig(ctx, s, cipher)
+	require.NoError(t, err)
+	require.False(t, exists)
+
+	snapshot := &ClusterConfigSnapshot{
+		ClusterID:  1,
+		BackupTS:   100,
+		PDSchedule: map[string]any{"max-merge-region-size": float64(54)},
+		PlacementRules: []*pdhttp.GroupBundle{{
+			ID: "pd",
+			Rules: []*pdhttp.Rule{{
+				GroupID:  "pd",
+				ID:       "default",
+				StartKey: []byte{},
+				EndKey:   []byte{},
+				Role:     pdhttp.Voter,
+				Count:    3,
+			}},
+		}},
+		Stores: []*ClusterConfigStore{{
+			ID:      1,
+			Address: "tikv-0:20160",
+			Labels:  map[string]string{"zone": "a"},
+			TiKV:    map[string]any{"coprocessor.region-split-size": "96MiB"},
+		}},
+	}
+	require.NoError(t, WriteClusterConfig(ctx, s, snapshot, cipher))
+	data, err := s.ReadFile(ctx, ClusterConfigFile)
+	require.NoError(t, err)
+	require.NotContains(t, string(data), "region-split-size")
+
+	read, exists, err := ReadClusterConfig(ctx, s, cipher)
+	require.NoError(t, err)
+	require.True(t, exists)
+	require.Equal(t, snapshot, read)
+	_, _, err = ReadClusterConfig(ctx, s, &backuppb.CipherInfo{CipherType: encryptionpb.EncryptionMethod_PLAINTEXT})
+	require.Error(t, err)
+}
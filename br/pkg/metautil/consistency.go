@@ -0,0 +1,81 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package metautil
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/pingcap/errors"
+	backuppb "github.com/pingcap/kvproto/pkg/brpb"
+	"github.com/pingcap/log"
+	berrors "github.com/pingcap/tidb/br/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// ConsistencyPoint is the logical barrier of the application held while the backup ts is taken, e.g. a
+// drained queue. The data at the backup ts is consistent from the view of the application, so the
+// application can resume from the marker after the backup is restored.
+type ConsistencyPoint struct {
+	// Marker is the opaque marker of the barrier returned by the application.
+	Marker   string `json:"marker"`
+	BackupTS uint64 `json:"backup-ts"`
+}
+
+// backupResult is the JSON stored in the backup_result field of the backupmeta.
+type backupResult struct {
+	ConsistencyPoint *ConsistencyPoint   `json:"consistency-point,omitempty"`
+	SequenceMode     SequenceRestoreMode `json:"sequence-restore-mode,omitempty"`
+	Labels           map[string]string   `json:"labels,omitempty"`
+}
+
+func decodeBackupResult(meta *backuppb.BackupMeta) (backupResult, error) {
+	var result backupResult
+	if meta.BackupResult == "" {
+		return result, nil
+	}
+	if err := json.Unmarshal([]byte(meta.BackupResult), &result); err != nil {
+		return result, berrors.ErrInvalidMetaFile.GenWithStackByArgs(fmt.Sprintf(
+			"failed to decode the backup result %q: %v", meta.BackupResult, err))
+	}
+	return result, nil
+}
+
+// readBackupResult returns the decoded backup_result field, the field not in JSON, e.g. written by other
+// tools, is treated as empty, so the backups carrying it can still be read.
+func readBackupResult(meta *backuppb.BackupMeta) backupResult {
+	result, err := decodeBackupResult(meta)
+	if err != nil {
+		log.Warn("ignore the backup result not written by BR", zap.Error(err))
+		return backupResult{}
+	}
+	return result
+}
+
+// updateBackupResult updates the JSON in the backup_result field, keeping the other fields in it. It fails
+// if the field isn't in JSON rather than overwriting it.
+func updateBackupResult(meta *backuppb.BackupMeta, update func(*backupResult)) error {
+	result, err := decodeBackupResult(meta)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	update(&result)
+	data, err := json.Marshal(result)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	meta.BackupResult = string(data)
+	return nil
+}
+
+// SetConsistencyPoint embeds the consistency point into the backupmeta.
+func SetConsistencyPoint(meta *backuppb.BackupMeta, cp *ConsistencyPoint) error {
+	return updateBackupResult(meta, func(result *backupResult) {
+		result.ConsistencyPoint = cp
+	})
+}
+
+// GetConsistencyPoint returns the consistency point embedded in the backupmeta, nil if there is none.
+func GetConsistencyPoint(meta *backuppb.BackupMeta) *ConsistencyPoint {
+	return readBackupResult(meta).ConsistencyPoint
+}
@@ -0,0 +1,51 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package metautil
+
+import (
+	"testing"
+
+	"github.com/gogo/protobuf/proto"
+	backuppb "github.com/pingcap/kvproto/pkg/brpb"
+	berrors "github.com/pingcap/tidb/br/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConsistencyPoint(t *testing.T) {
+	meta := &backuppb.BackupMeta{EndVersion: 42, IsTxnKv: true}
+	require.Nil(t, GetConsistencyPoint(meta))
+
+	require.NoError(t, SetConsistencyPoint(meta, &ConsistencyPoint{Marker: "queue-drained-7", BackupTS: 42}))
+	// the consistency point is kept after the backupmeta is encoded.
+	data, err := proto.Marshal(meta)
+	require.NoError(t, err)
+	decoded := &backuppb.BackupMeta{}
+	require.NoError(t, proto.Unmarshal(data, decoded))
+	require.Equal(t, &ConsistencyPoint{Marker: "queue-drained-7", BackupTS: 42}, GetConsistencyPoint(decoded))
+
+	// the backup result not in JSON is treated as empty, but isn't overwritten.
+	decoded.BackupResult = "not json"
+	require.Nil(t, GetConsistencyPoint(decoded))
+	require.Empty(t, GetSequenceRestoreMode(decoded))
+	require.Nil(t, GetBackupLabels(decoded))
+	err = SetConsistencyPoint(decoded, &ConsistencyPoint{Marker: "m", BackupTS: 42})
+	require.ErrorIs(t, err, berrors.ErrInvalidMetaFile)
+	require.Equal(t, "not json", decoded.BackupResult)
+}
+
+func TestSequenceRestoreMode(t *testing.T) {
+	meta := &backuppb.BackupMeta{}
+	require.Empty(t, GetSequenceRestoreMode(meta))
+
+	require.NoError(t, SetConsistencyPoint(meta, &ConsistencyPoint{Marker: "m", BackupTS: 1}))
+	require.NoError(t, SetSequenceRestoreMode(meta, SequenceRestoreSkipCache))
+	// the fields in the backup result are kept by each other.
+	require.Equal(t, SequenceRestoreSkipCache, GetSequenceRestoreMode(meta))
+	require.Equal(t, &ConsistencyPoint{Marker: "m", BackupTS: 1}, GetConsistencyPoint(meta))
+
+	mode, err := ParseSequenceRestoreMode("Reset")
+	require.NoError(t, err)
+	require.Equal(t, SequenceRestoreReset, mode)
+	_, err = ParseSequenceRestoreMode("jump")
+	require.ErrorIs(t, err, berrors.ErrInvalidArgument)
+}
@@ -0,0 +1,128 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package metautil
+
+import (
+	"github.com/pingcap/errors"
+	"github.com/pingcap/log"
+	"github.com/pingcap/tidb/pkg/meta/model"
+	"github.com/pingcap/tidb/pkg/parser"
+	"github.com/pingcap/tidb/pkg/parser/ast"
+	// the parser driver is required to parse the literals in the view definitions.
+	_ "github.com/pingcap/tidb/pkg/types/parser_driver"
+	"go.uber.org/zap"
+)
+
+type tableName struct {
+	db    string
+	table string
+}
+
+func nameOf(db, table ast.CIStr) tableName {
+	return tableName{db: db.L, table: table.L}
+}
+
+type tableNameCollector struct {
+	db    ast.CIStr
+	names []tableName
+}
+
+func (c *tableNameCollector) Enter(n ast.Node) (ast.Node, bool) {
+	if t, ok := n.(*ast.TableName); ok {
+		db := t.Schema
+		if db.L == "" {
+			db = c.db
+		}
+		c.names = append(c.names, nameOf(db, t.Name))
+	}
+	return n, false
+}
+
+func (*tableNameCollector) Leave(n ast.Node) (ast.Node, bool) {
+	return n, true
+}
+
+// viewDependencies returns the names of the tables and views selected by the view,
+// the unqualified names are in the database of the view. The names of CTEs are returned too.
+func viewDependencies(db ast.CIStr, view *model.TableInfo) ([]tableName, error) {
+	stmt, err := parser.New().ParseOneStmt(view.View.SelectStmt, view.Charset, view.Collate)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	c := &tableNameCollector{db: db}
+	stmt.Accept(c)
+	return c.names, nil
+}
+
+// SortTablesByDependency splits the tables into stages, a table only depends on the tables of the former stages.
+// The sequences come first since the default values of the columns may use them, then the base tables, and
+// then the views ordered by the tables and views they select from.
+func SortTablesByDependency(tables []*Table) [][]*Table {
+	var sequences, baseTables, views []*Table
+	viewNames := make(map[tableName]*Table)
+	for _, t := range tables {
+		switch {
+		case t.Info.IsSequence():
+			sequences = append(sequences, t)
+		case t.Info.IsView():
+			views = append(views, t)
+			viewNames[nameOf(t.DB.Name, t.Info.Name)] = t
+		default:
+			baseTables = append(baseTables, t)
+		}
+	}
+	stages := make([][]*Table, 0, 3)
+	for _, stage := range [][]*Table{sequences, baseTables} {
+		if len(stage) > 0 {
+			stages = append(stages, stage)
+		}
+	}
+	if len(views) == 0 {
+		return stages
+	}
+
+	// depth is the length of the longest chain of the views the view depends on.
+	const visiting = -1
+	depths := make(map[tableName]int, len(views))
+	var depthOf func(name tableName, view *Table) int
+	depthOf = func(name tableName, view *Table) int {
+		if depth, ok := depths[name]; ok {
+			if depth == visiting {
+				// unreachable unless the backup is broken, break the cycle here.
+				log.Warn("circular view dependency", zap.Stringer("db", view.DB.Name), zap.Stringer("view", view.Info.Name))
+				return 0
+			}
+			return depth
+		}
+		depths[name] = visiting
+		deps, err := viewDependencies(view.DB.Name, view.Info)
+		if err != nil {
+			log.Warn("failed to parse the view definition, create it after the tables",
+				zap.Stringer("db", view.DB.Name), zap.Stringer("view", view.Info.Name), zap.Error(err))
+		}
+		depth := 0
+		for _, dep := range deps {
+			// the dependencies out of the tables are either base tables or existing views.
+			if depView, ok := viewNames[dep]; ok && dep != name {
+				depth = max(depth, depthOf(dep, depView)+1)
+			}
+		}
+		depths[name] = depth
+		return depth
+	}
+	viewStages := make([][]*Table, 0)
+	for _, view := range views {
+		depth := depthOf(nameOf(view.DB.Name, view.Info.Name), view)
+		for len(viewStages) <= depth {
+			viewStages = append(viewStages, nil)
+		}
+		viewStages[depth] = append(viewStages[depth], view)
+	}
+	for _, stage := range viewStages {
+		// a stage may be empty if there is a cycle.
+		if len(stage) > 0 {
+			stages = append(stages, stage)
+		}
+	}
+	return stages
+}
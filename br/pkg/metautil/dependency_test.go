@@ -0,0 +1,41 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package metautil
+
+import (
+	"testing"
+
+	"github.com/pingcap/tidb/pkg/meta/model"
+	"github.com/pingcap/tidb/pkg/parser/ast"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSortTablesByDependency(t *testing.T) {
+	db1 := &model.DBInfo{Name: ast.NewCIStr("db1")}
+	db2 := &model.DBInfo{Name: ast.NewCIStr("db2")}
+	table := func(db *model.DBInfo, name string) *Table {
+		return &Table{DB: db, Info: &model.TableInfo{Name: ast.NewCIStr(name)}}
+	}
+	view := func(db *model.DBInfo, name string, selectStmt string) *Table {
+		tbl := table(db, name)
+		tbl.Info.View = &model.ViewInfo{SelectStmt: selectStmt}
+		return tbl
+	}
+	seq := table(db1, "seq")
+	seq.Info.Sequence = &model.SequenceInfo{}
+	t1 := table(db1, "t1")
+	t2 := table(db2, "t2")
+	// v3 -> v2 -> (v1, t2), v1 -> t1
+	v3 := view(db2, "v3", "SELECT `a` FROM `db2`.`v2` WHERE `a` > 1")
+	v1 := view(db1, "v1", "SELECT `a` FROM `t1`")
+	v2 := view(db2, "V2", "SELECT `v`.`a` FROM `db1`.`V1` AS `v` JOIN `t2` ON `v`.`a` = `t2`.`a`")
+	// v4 selects from a CTE shadowing v1 and a table out of the backup, it is conservatively created after v1.
+	v4 := view(db1, "v4", "WITH `v1` AS (SELECT 1 AS `a`) SELECT `a` FROM `v1` UNION SELECT `a` FROM `db3`.`t`")
+	broken := view(db1, "broken", "SELECT FROM")
+
+	stages := SortTablesByDependency([]*Table{v3, t1, v1, v2, seq, t2, v4, broken})
+	require.Equal(t, [][]*Table{{seq}, {t1, t2}, {v1, broken}, {v2, v4}, {v3}}, stages)
+
+	require.Empty(t, SortTablesByDependency(nil))
+	require.Equal(t, [][]*Table{{t1}}, SortTablesByDependency([]*Table{t1}))
+}
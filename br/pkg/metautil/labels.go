@@ -0,0 +1,66 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package metautil
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/pingcap/errors"
+	backuppb "github.com/pingcap/kvproto/pkg/brpb"
+	berrors "github.com/pingcap/tidb/br/pkg/errors"
+)
+
+// ParseLabels parses the labels in the form of `k1=v1,k2=v2`. The keys must be unique and non-empty,
+// the empty string gives no labels.
+func ParseLabels(s string) (map[string]string, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+	labels := make(map[string]string)
+	for _, item := range strings.Split(s, ",") {
+		key, value, ok := strings.Cut(item, "=")
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		if !ok || key == "" {
+			return nil, errors.Annotatef(berrors.ErrInvalidArgument, "invalid label %q, should be in the form of key=value", item)
+		}
+		if _, dup := labels[key]; dup {
+			return nil, errors.Annotatef(berrors.ErrInvalidArgument, "duplicated label key %q", key)
+		}
+		labels[key] = value
+	}
+	return labels, nil
+}
+
+// FormatLabels formats the labels as `k1=v1,k2=v2` sorted by the keys.
+func FormatLabels(labels map[string]string) string {
+	items := make([]string, 0, len(labels))
+	for _, key := range slices.Sorted(maps.Keys(labels)) {
+		items = append(items, fmt.Sprintf("%s=%s", key, labels[key]))
+	}
+	return strings.Join(items, ",")
+}
+
+// MatchLabels returns whether the labels contain all of the selector.
+func MatchLabels(labels, selector map[string]string) bool {
+	for key, value := range selector {
+		if v, ok := labels[key]; !ok || v != value {
+			return false
+		}
+	}
+	return true
+}
+
+// SetBackupLabels records the user-defined labels into the backupmeta.
+func SetBackupLabels(meta *backuppb.BackupMeta, labels map[string]string) error {
+	return updateBackupResult(meta, func(result *backupResult) {
+		result.Labels = labels
+	})
+}
+
+// GetBackupLabels returns the user-defined labels recorded in the backupmeta, nil if there are none.
+func GetBackupLabels(meta *backuppb.BackupMeta) map[string]string {
+	return readBackupResult(meta).Labels
+}
@@ -0,0 +1,37 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package metautil
+
+import (
+	"testing"
+
+	backuppb "github.com/pingcap/kvproto/pkg/brpb"
+	berrors "github.com/pingcap/tidb/br/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackupLabels(t *testing.T) {
+	labels, err := ParseLabels("")
+	require.NoError(t, err)
+	require.Nil(t, labels)
+	labels, err = ParseLabels("env=prod, owner = team-x,empty=")
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"env": "prod", "owner": "team-x", "empty": ""}, labels)
+	require.Equal(t, "empty=,env=prod,owner=team-x", FormatLabels(labels))
+	for _, bad := range []string{"env", "=prod", "env=prod,env=dev", "env=prod,"} {
+		_, err = ParseLabels(bad)
+		require.ErrorIs(t, err, berrors.ErrInvalidArgument, bad)
+	}
+
+	require.True(t, MatchLabels(labels, nil))
+	require.True(t, MatchLabels(labels, map[string]string{"env": "prod"}))
+	require.True(t, MatchLabels(labels, map[string]string{"empty": ""}))
+	require.False(t, MatchLabels(labels, map[string]string{"env": "dev"}))
+	require.False(t, MatchLabels(labels, map[string]string{"region": ""}))
+
+	meta := &backuppb.BackupMeta{}
+	require.NoError(t, SetSequenceRestoreMode(meta, SequenceRestoreReset))
+	require.NoError(t, SetBackupLabels(meta, labels))
+	require.Equal(t, labels, GetBackupLabels(meta))
+	require.Equal(t, SequenceRestoreReset, GetSequenceRestoreMode(meta))
+}
@@ -476,9 +476,15 @@ func parseSchemaFile(s *backuppb.Schema) (*Table, error) {
 	var tableInfo *model.TableInfo
 	if s.Table != nil {
 		tableInfo = &model.TableInfo{}
-		if err := json.Unmarshal(s.Table, tableInfo); err != nil {
+		unknownFields, err := utils.UnmarshalWithUnknownFields(s.Table, tableInfo)
+		if err != nil {
 			return nil, errors.Trace(err)
 		}
+		// the table is created by the known fields, the features of a newer TiDB are lost.
+		if paths := unknownFields.Paths(); len(paths) > 0 {
+			log.Warn("the table info has fields unknown to this version, they will be ignored",
+				zap.Stringer("db", dbInfo.Name), zap.Stringer("table", tableInfo.Name), zap.Strings("fields", paths))
+		}
 	}
 	var stats *util.JSONTable
 	if s.Stats != nil {
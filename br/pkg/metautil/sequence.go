@@ -0,0 +1,50 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package metautil
+
+import (
+	"strings"
+
+	"github.com/pingcap/errors"
+	backuppb "github.com/pingcap/kvproto/pkg/brpb"
+	berrors "github.com/pingcap/tidb/br/pkg/errors"
+)
+
+// SequenceRestoreMode is how the restored sequences resume. The values of a sequence are allocated in
+// batches of its cache size by every TiDB, so the value in the backup is the end of the last batch, and
+// the values cached but not handed out yet are unknown to BR.
+type SequenceRestoreMode string
+
+const (
+	// SequenceRestoreExact resumes the sequences right after the value in the backup.
+	SequenceRestoreExact SequenceRestoreMode = "exact"
+	// SequenceRestoreSkipCache jumps the sequences by a cache size further from the value in the backup,
+	// so the values handed out after the backup ts by the upstream are less likely to be reused.
+	SequenceRestoreSkipCache SequenceRestoreMode = "skip-cache"
+	// SequenceRestoreReset restarts the sequences from their start values.
+	SequenceRestoreReset SequenceRestoreMode = "reset"
+)
+
+// ParseSequenceRestoreMode parses the sequence restore mode, the empty string is returned as is.
+func ParseSequenceRestoreMode(s string) (SequenceRestoreMode, error) {
+	switch mode := SequenceRestoreMode(strings.ToLower(s)); mode {
+	case "", SequenceRestoreExact, SequenceRestoreSkipCache, SequenceRestoreReset:
+		return mode, nil
+	default:
+		return "", errors.Annotatef(berrors.ErrInvalidArgument, "unknown sequence restore mode %s", s)
+	}
+}
+
+// SetSequenceRestoreMode records the sequence restore mode into the backupmeta, it's used by the restore
+// if the mode isn't specified.
+func SetSequenceRestoreMode(meta *backuppb.BackupMeta, mode SequenceRestoreMode) error {
+	return updateBackupResult(meta, func(result *backupResult) {
+		result.SequenceMode = mode
+	})
+}
+
+// GetSequenceRestoreMode returns the sequence restore mode recorded in the backupmeta, empty if there is
+// none.
+func GetSequenceRestoreMode(meta *backuppb.BackupMeta) SequenceRestoreMode {
+	return readBackupResult(meta).SequenceMode
+}
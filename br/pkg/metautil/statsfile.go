@@ -193,30 +193,8 @@ func downloadStats(
 		}
 		statsFile := statsFileIndex
 		downloadWorkerpool.ApplyOnErrorGroup(eg, func() error {
-			var statsContent []byte
-			if len(statsFile.InlineData) > 0 {
-				statsContent = statsFile.InlineData
-			} else {
-				content, err := storage.ReadFile(ectx, statsFile.Name)
-				if err != nil {
-					return errors.Trace(err)
-				}
-
-				decryptContent, err := utils.Decrypt(content, cipher, statsFile.CipherIv)
-				if err != nil {
-					return errors.Trace(err)
-				}
-
-				checksum := sha256.Sum256(decryptContent)
-				if !bytes.Equal(statsFile.Sha256, checksum[:]) {
-					return berrors.ErrInvalidMetaFile.GenWithStackByArgs(fmt.Sprintf(
-						"checksum mismatch expect %x, got %x", statsFile.Sha256, checksum[:]))
-				}
-				statsContent = decryptContent
-			}
-
-			statsFileBlocks := &backuppb.StatsFile{}
-			if err := proto.Unmarshal(statsContent, statsFileBlocks); err != nil {
+			statsFileBlocks, err := readStatsFile(ectx, storage, cipher, statsFile)
+			if err != nil {
 				return errors.Trace(err)
 			}
 
@@ -249,3 +227,83 @@ func downloadStats(
 
 	return eg.Wait()
 }
+
+func readStatsFile(
+	ctx context.Context,
+	storage storage.ExternalStorage,
+	cipher *backuppb.CipherInfo,
+	statsFile *backuppb.StatsFileIndex,
+) (*backuppb.StatsFile, error) {
+	var statsContent []byte
+	if len(statsFile.InlineData) > 0 {
+		statsContent = statsFile.InlineData
+	} else {
+		content, err := storage.ReadFile(ctx, statsFile.Name)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+
+		decryptContent, err := utils.Decrypt(content, cipher, statsFile.CipherIv)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+
+		checksum := sha256.Sum256(decryptContent)
+		if !bytes.Equal(statsFile.Sha256, checksum[:]) {
+			return nil, berrors.ErrInvalidMetaFile.GenWithStackByArgs(fmt.Sprintf(
+				"checksum mismatch expect %x, got %x", statsFile.Sha256, checksum[:]))
+		}
+		statsContent = decryptContent
+	}
+
+	statsFileBlocks := &backuppb.StatsFile{}
+	if err := proto.Unmarshal(statsContent, statsFileBlocks); err != nil {
+		return nil, errors.Trace(err)
+	}
+	return statsFileBlocks, nil
+}
+
+// ReadStatsRowCount reads the row count of the table from the statistics in the backup, the count of a
+// partitioned table is the sum of its partitions. It returns false if the backup has no statistics of
+// the table.
+func ReadStatsRowCount(
+	ctx context.Context,
+	storage storage.ExternalStorage,
+	cipher *backuppb.CipherInfo,
+	table *Table,
+) (int64, bool, error) {
+	if table.Stats != nil {
+		return table.Stats.Count, true, nil
+	}
+	if len(table.StatsFileIndexes) == 0 {
+		return 0, false, nil
+	}
+	physicalIDs := map[int64]struct{}{table.Info.ID: {}}
+	if pi := table.Info.GetPartitionInfo(); pi != nil {
+		// the statistics of the table itself are the global statistics of the partitions.
+		physicalIDs = make(map[int64]struct{}, len(pi.Definitions))
+		for _, def := range pi.Definitions {
+			physicalIDs[def.ID] = struct{}{}
+		}
+	}
+	var count int64
+	found := false
+	for _, statsFileIndex := range table.StatsFileIndexes {
+		statsFileBlocks, err := readStatsFile(ctx, storage, cipher, statsFileIndex)
+		if err != nil {
+			return 0, false, errors.Trace(err)
+		}
+		for _, block := range statsFileBlocks.Blocks {
+			if _, ok := physicalIDs[block.PhysicalId]; !ok {
+				continue
+			}
+			jsonTable := &statsutil.JSONTable{}
+			if err := json.Unmarshal(block.JsonTable, jsonTable); err != nil {
+				return 0, false, errors.Trace(err)
+			}
+			count += jsonTable.Count
+			found = true
+		}
+	}
+	return count, found, nil
+}
@@ -22,6 +22,7 @@ import (
 	backuppb "github.com/pingcap/kvproto/pkg/brpb"
 	"github.com/pingcap/kvproto/pkg/encryptionpb"
 	"github.com/pingcap/tidb/br/pkg/storage"
+	"github.com/pingcap/tidb/pkg/meta/model"
 	"github.com/pingcap/tidb/pkg/statistics/handle/types"
 	"github.com/pingcap/tidb/pkg/statistics/util"
 	tidbutil "github.com/pingcap/tidb/pkg/util"
@@ -153,3 +154,43 @@ func TestStatsWriter(t *testing.T) {
 		require.NoError(t, err)
 	}
 }
+
+func TestReadStatsRowCount(t *testing.T) {
+	ctx := context.Background()
+	stg, err := storage.NewLocalStorage(t.TempDir())
+	require.NoError(t, err)
+	cipher := backuppb.CipherInfo{CipherType: encryptionpb.EncryptionMethod_PLAINTEXT}
+	statsWriter := newStatsWriter(stg, &cipher)
+	require.NoError(t, statsWriter.BackupStats(ctx, &util.JSONTable{Count: 10}, 1))
+	require.NoError(t, statsWriter.BackupStats(ctx, &util.JSONTable{Count: 30}, 2))
+	require.NoError(t, statsWriter.BackupStats(ctx, &util.JSONTable{Count: 12}, 3))
+	require.NoError(t, statsWriter.BackupStats(ctx, &util.JSONTable{Count: 100}, 4))
+	statsFileIndexes, err := statsWriter.BackupStatsDone(ctx)
+	require.NoError(t, err)
+
+	table := &Table{Info: &model.TableInfo{ID: 1}, StatsFileIndexes: statsFileIndexes}
+	count, ok, err := ReadStatsRowCount(ctx, stg, &cipher, table)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, int64(10), count)
+
+	// the partitioned table 2 has the partitions 3 and 4.
+	table = &Table{Info: &model.TableInfo{ID: 2, Partition: &model.PartitionInfo{Enable: true,
+		Definitions: []model.PartitionDefinition{{ID: 3}, {ID: 4}},
+	}}, StatsFileIndexes: statsFileIndexes}
+	count, ok, err = ReadStatsRowCount(ctx, stg, &cipher, table)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, int64(112), count)
+
+	table = &Table{Info: &model.TableInfo{ID: 5}, StatsFileIndexes: statsFileIndexes}
+	_, ok, err = ReadStatsRowCount(ctx, stg, &cipher, table)
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	table = &Table{Info: &model.TableInfo{ID: 5}, Stats: &util.JSONTable{Count: 7}}
+	count, ok, err = ReadStatsRowCount(ctx, stg, &cipher, table)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, int64(7), count)
+}
@@ -0,0 +1,86 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package pdutil
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/pingcap/errors"
+	berrors "github.com/pingcap/tidb/br/pkg/errors"
+)
+
+// metricQueryPrefix is the API of PD proxying the queries to the prometheus.
+const metricQueryPrefix = "/pd/api/v1/metric/query"
+
+// MetricSample is a sample of an instant vector.
+type MetricSample struct {
+	Labels map[string]string
+	Value  float64
+}
+
+type metricQueryResp struct {
+	Status string `json:"status"`
+	Error  string `json:"error"`
+	Data   struct {
+		ResultType string `json:"resultType"`
+		Result     []struct {
+			Metric map[string]string `json:"metric"`
+			// Value is [timestamp, "value"].
+			Value []any `json:"value"`
+		} `json:"result"`
+	} `json:"data"`
+}
+
+// QueryMetric queries the instant vector of the PromQL by the metrics proxy of PD.
+// It fails if the metric storage of PD isn't configured.
+func QueryMetric(ctx context.Context, cli *http.Client, pdURL string, query string) ([]MetricSample, error) {
+	reqURL := strings.TrimSuffix(pdURL, "/") + metricQueryPrefix + "?" + url.Values{"query": {query}}.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	resp, err := cli.Do(req)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Annotatef(berrors.ErrPDInvalidResponse,
+			"query metric failed: resp=%s, code=%d", body, resp.StatusCode)
+	}
+
+	result := &metricQueryResp{}
+	if err := json.Unmarshal(body, result); err != nil {
+		return nil, errors.Annotatef(berrors.ErrPDInvalidResponse, "query metric failed: %s", err)
+	}
+	if result.Status != "success" || result.Data.ResultType != "vector" {
+		return nil, errors.Annotatef(berrors.ErrPDInvalidResponse,
+			"query metric failed: status=%s, type=%s, err=%s", result.Status, result.Data.ResultType, result.Error)
+	}
+	samples := make([]MetricSample, 0, len(result.Data.Result))
+	for _, r := range result.Data.Result {
+		if len(r.Value) != 2 {
+			return nil, errors.Annotatef(berrors.ErrPDInvalidResponse, "unexpected sample %v", r.Value)
+		}
+		s, ok := r.Value[1].(string)
+		if !ok {
+			return nil, errors.Annotatef(berrors.ErrPDInvalidResponse, "unexpected sample %v", r.Value)
+		}
+		v, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return nil, errors.Annotatef(berrors.ErrPDInvalidResponse, "unexpected sample %v", r.Value)
+		}
+		samples = append(samples, MetricSample{Labels: r.Metric, Value: v})
+	}
+	return samples, nil
+}
@@ -0,0 +1,39 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package pdutil
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryMetric(t *testing.T) {
+	resp := `{"status":"success","data":{"resultType":"vector","result":[
+		{"metric":{"instance":"tikv-0:20180"},"value":[1700000000.1,"3"]},
+		{"metric":{"instance":"tikv-1:20180"},"value":[1700000000.1,"0.25"]}]}}`
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, metricQueryPrefix, r.URL.Path)
+		if r.URL.Query().Get("query") == "bad" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		require.Equal(t, "sum(up) by (instance)", r.URL.Query().Get("query"))
+		_, _ = w.Write([]byte(resp))
+	}))
+	defer ts.Close()
+
+	ctx := context.Background()
+	samples, err := QueryMetric(ctx, ts.Client(), ts.URL+"/", "sum(up) by (instance)")
+	require.NoError(t, err)
+	require.Equal(t, []MetricSample{
+		{Labels: map[string]string{"instance": "tikv-0:20180"}, Value: 3},
+		{Labels: map[string]string{"instance": "tikv-1:20180"}, Value: 0.25},
+	}, samples)
+
+	_, err = QueryMetric(ctx, ts.Client(), ts.URL, "bad")
+	require.Error(t, err)
+}
@@ -9,7 +9,9 @@ import (
 	"fmt"
 	"math"
 	"net/http"
+	"slices"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/coreos/go-semver/semver"
@@ -20,6 +22,7 @@ import (
 	"github.com/pingcap/failpoint"
 	"github.com/pingcap/log"
 	berrors "github.com/pingcap/tidb/br/pkg/errors"
+	"github.com/pingcap/tidb/br/pkg/utils"
 	"github.com/pingcap/tidb/pkg/util/codec"
 	pd "github.com/tikv/pd/client"
 	pdhttp "github.com/tikv/pd/client/http"
@@ -144,20 +147,47 @@ type PdController struct {
 	SchedulerPauseTTL time.Duration
 }
 
+type pdControllerOptions struct {
+	dialTLS bool
+}
+
+// PdControllerOption is the option of NewPdController.
+type PdControllerOption func(*pdControllerOptions)
+
+// WithTLSDialer makes the PD client dial the PD servers by the TLS config of the controller rather than
+// the one built from the security option. The PD client builds its TLS config from the files only once,
+// so it's required by the TLS config reloading the rotated certificates.
+func WithTLSDialer() PdControllerOption {
+	return func(o *pdControllerOptions) {
+		o.dialTLS = true
+	}
+}
+
 // NewPdController creates a new PdController.
 func NewPdController(
 	ctx context.Context,
 	pdAddrs []string,
 	tlsConf *tls.Config,
 	securityOption pd.SecurityOption,
+	opts ...PdControllerOption,
 ) (*PdController, error) {
-	maxCallMsgSize := []grpc.DialOption{
+	var options pdControllerOptions
+	for _, o := range opts {
+		o(&options)
+	}
+	dialOptions := []grpc.DialOption{
 		grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(maxMsgSize)),
 		grpc.WithDefaultCallOptions(grpc.MaxCallSendMsgSize(maxMsgSize)),
 	}
+	dialTLS := options.dialTLS && tlsConf != nil
+	if dialTLS {
+		// the connections are secured by the dialer, so the PD client treats them as the plain ones.
+		securityOption = pd.SecurityOption{}
+		dialOptions = append(dialOptions, grpc.WithContextDialer(utils.TLSDialer(tlsConf)))
+	}
 	pdClient, err := pd.NewClientWithContext(
 		ctx, caller.Component("br-pd-controller"), pdAddrs, securityOption,
-		opt.WithGRPCDialOptions(maxCallMsgSize...),
+		opt.WithGRPCDialOptions(dialOptions...),
 		// If the time too short, we may scatter a region many times, because
 		// the interface `ScatterRegions` may time out.
 		opt.WithCustomTimeoutOption(60*time.Second),
@@ -171,11 +201,23 @@ func NewPdController(
 	if tlsConf != nil {
 		pdHTTPCliConfig = append(pdHTTPCliConfig, pdhttp.WithTLSConfig(tlsConf))
 	}
-	pdHTTPCli := pdhttp.NewClientWithServiceDiscovery(
-		"br/lightning PD controller",
-		pdClient.GetServiceDiscovery(),
-		pdHTTPCliConfig...,
-	).WithBackoffer(retry.InitialBackoffer(time.Second, time.Second, PDRequestRetryTime*time.Second))
+	var pdHTTPCli pdhttp.Client
+	if dialTLS {
+		// the URLs discovered by the PD client are in the plain HTTP scheme then, so discover the ones in
+		// HTTPS by the HTTP client itself.
+		pdHTTPCli = pdhttp.NewClient("br/lightning PD controller", pdAddrs, pdHTTPCliConfig...)
+		if pdHTTPCli == nil {
+			pdClient.Close()
+			return nil, errors.Annotatef(berrors.ErrPDLeaderNotFound, "failed to create the pd http client of %v", pdAddrs)
+		}
+	} else {
+		pdHTTPCli = pdhttp.NewClientWithServiceDiscovery(
+			"br/lightning PD controller",
+			pdClient.GetServiceDiscovery(),
+			pdHTTPCliConfig...,
+		)
+	}
+	pdHTTPCli = pdHTTPCli.WithBackoffer(retry.InitialBackoffer(time.Second, time.Second, PDRequestRetryTime*time.Second))
 	versionStr, err := pdHTTPCli.GetPDVersion(ctx)
 	if err != nil {
 		pdHTTPCli.Close()
@@ -263,6 +305,17 @@ func (p *PdController) GetRegionCount(ctx context.Context, startKey, endKey []by
 	return status.Count, nil
 }
 
+// GetRegionStats returns the approximate statistics of the regions in the specified range.
+func (p *PdController) GetRegionStats(ctx context.Context, startKey, endKey []byte) (*pdhttp.RegionStats, error) {
+	var start, end []byte
+	start = codec.EncodeBytes(nil, startKey)
+	if len(endKey) != 0 { // Empty end key means the max.
+		end = codec.EncodeBytes(nil, endKey)
+	}
+	status, err := p.pdHTTPCli.GetRegionStatusByKeyRange(ctx, pdhttp.NewKeyRange(start, end), false)
+	return status, errors.Trace(err)
+}
+
 // GetStoreInfo returns the info of store with the specified id.
 func (p *PdController) GetStoreInfo(ctx context.Context, storeID uint64) (*pdhttp.StoreInfo, error) {
 	info, err := p.pdHTTPCli.GetStore(ctx, storeID)
@@ -748,6 +801,169 @@ func pauseSchedulerByKeyRangeWithTTL(
 	return done, nil
 }
 
+// KeyRangeSchedulerPauser pauses the schedulers for the regions in key ranges, the pausing of each key
+// range is resumed individually, e.g. once the table in the key range is restored. All the key ranges are
+// paused by a single label rule kept by a single goroutine, so pausing thousands of tables doesn't flood PD.
+type KeyRangeSchedulerPauser struct {
+	pdHTTPCli pdhttp.Client
+	ruleID    string
+	ttl       time.Duration
+
+	// updateMu serializes the updates of the rule, so a stale rule never overwrites a newer one.
+	updateMu sync.Mutex
+	mu       sync.Mutex
+	pausing  map[string]KeyRangeRule
+	// notify wakes up the keeper to update the rule after the key ranges are resumed.
+	notify chan struct{}
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// PausedKeyRange is a key range paused by the KeyRangeSchedulerPauser, identified by the ID.
+type PausedKeyRange struct {
+	ID       string
+	StartKey []byte
+	EndKey   []byte
+}
+
+// NewKeyRangeSchedulerPauser creates a KeyRangeSchedulerPauser.
+func NewKeyRangeSchedulerPauser(pdHTTPCli pdhttp.Client) *KeyRangeSchedulerPauser {
+	return &KeyRangeSchedulerPauser{
+		pdHTTPCli: pdHTTPCli,
+		ruleID:    uuid.New().String(),
+		ttl:       pauseTimeout,
+		pausing:   make(map[string]KeyRangeRule),
+		notify:    make(chan struct{}, 1),
+	}
+}
+
+// Pause pauses the schedulers for the regions in the key ranges, until the key ranges are resumed or the
+// context of the first Pause is done. Unlike PauseSchedulersByKeyRange, it doesn't wait for the rule to
+// take effect, the caller should pause the key ranges before splitting and scattering the regions.
+func (p *KeyRangeSchedulerPauser) Pause(ctx context.Context, ranges ...PausedKeyRange) error {
+	p.mu.Lock()
+	for _, rg := range ranges {
+		p.pausing[rg.ID] = KeyRangeRule{StartKeyHex: hex.EncodeToString(rg.StartKey), EndKeyHex: hex.EncodeToString(rg.EndKey)}
+	}
+	p.mu.Unlock()
+	if err := p.updateRule(ctx); err != nil {
+		return errors.Trace(err)
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.done == nil {
+		keepCtx, cancel := context.WithCancel(ctx)
+		p.cancel, p.done = cancel, make(chan struct{})
+		go p.keep(keepCtx, p.done)
+	}
+	return nil
+}
+
+// updateRule sets the label rule of the paused key ranges, or deletes it if there is none. The adjacent
+// key ranges are merged, so the consecutive tables are paused by a single key range of the rule.
+func (p *KeyRangeSchedulerPauser) updateRule(ctx context.Context) error {
+	p.updateMu.Lock()
+	defer p.updateMu.Unlock()
+	p.mu.Lock()
+	ranges := make([]KeyRangeRule, 0, len(p.pausing))
+	for _, rg := range p.pausing {
+		ranges = append(ranges, rg)
+	}
+	p.mu.Unlock()
+	if len(ranges) == 0 {
+		return errors.Trace(p.pdHTTPCli.PatchRegionLabelRules(ctx, &pdhttp.LabelRulePatch{DeleteRules: []string{p.ruleID}}))
+	}
+	// the hex encoding keeps the order of the keys.
+	slices.SortFunc(ranges, func(a, b KeyRangeRule) int {
+		return strings.Compare(a.StartKeyHex, b.StartKeyHex)
+	})
+	merged := make([]KeyRangeRule, 0, len(ranges))
+	for _, rg := range ranges {
+		if n := len(merged); n > 0 && merged[n-1].EndKeyHex >= rg.StartKeyHex {
+			merged[n-1].EndKeyHex = max(merged[n-1].EndKeyHex, rg.EndKeyHex)
+			continue
+		}
+		merged = append(merged, rg)
+	}
+	rule := &pdhttp.LabelRule{
+		ID: p.ruleID,
+		Labels: []pdhttp.RegionLabel{{
+			Key:   "schedule",
+			Value: "deny",
+			TTL:   p.ttl.String(),
+		}},
+		RuleType: "key-range",
+		Data:     merged,
+	}
+	return errors.Trace(p.pdHTTPCli.SetRegionLabelRule(ctx, rule))
+}
+
+// keep renews the label rule, updates it once the key ranges are resumed, and deletes it at last.
+func (p *KeyRangeSchedulerPauser) keep(ctx context.Context, done chan struct{}) {
+	defer close(done)
+	ticker := time.NewTicker(p.ttl / 3)
+	defer ticker.Stop()
+loop:
+	for {
+		select {
+		case <-ticker.C:
+		case <-p.notify:
+		case <-ctx.Done():
+			break loop
+		}
+		if err := p.updateRule(ctx); err != nil && !berrors.IsContextCanceled(err) {
+			log.Warn("update the region label rule pausing the key ranges failed, ignore it and wait next time",
+				zap.String("rule-id", p.ruleID), zap.Error(err))
+		}
+	}
+	// Use a new context to avoid the context is canceled by the caller.
+	recoverCtx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+	p.mu.Lock()
+	clear(p.pausing)
+	p.mu.Unlock()
+	if err := p.updateRule(recoverCtx); err != nil {
+		log.Warn("failed to delete region label rule, the rule will be removed after ttl expires",
+			zap.String("rule-id", p.ruleID), zap.Duration("ttl", p.ttl), zap.Error(err))
+	}
+}
+
+// Resume resumes the schedulers for the regions in the key ranges identified by the ids. The rule is
+// updated in the background, so the key ranges of the tables restored around the same time are removed
+// from the rule at once.
+func (p *KeyRangeSchedulerPauser) Resume(ids ...string) {
+	p.mu.Lock()
+	for _, id := range ids {
+		delete(p.pausing, id)
+	}
+	p.mu.Unlock()
+	select {
+	case p.notify <- struct{}{}:
+	default:
+	}
+}
+
+// ResumeAll resumes the schedulers for the regions in all the paused key ranges, and waits for the rule
+// removed.
+func (p *KeyRangeSchedulerPauser) ResumeAll() {
+	p.mu.Lock()
+	cancel, done := p.cancel, p.done
+	p.cancel, p.done = nil, nil
+	p.mu.Unlock()
+	if done == nil {
+		return
+	}
+	cancel()
+	<-done
+}
+
+// Paused returns the count of the paused key ranges.
+func (p *KeyRangeSchedulerPauser) Paused() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.pausing)
+}
+
 // CanPauseSchedulerByKeyRange returns whether the scheduler can be paused by key range.
 func (p *PdController) CanPauseSchedulerByKeyRange() bool {
 	// We need ttl feature to ensure scheduler can recover from pause automatically.
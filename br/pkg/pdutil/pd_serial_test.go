@@ -8,6 +8,7 @@ import (
 	"errors"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
 	"sync"
 	"testing"
 	"time"
@@ -164,3 +165,79 @@ func TestPauseSchedulersByKeyRange(t *testing.T) {
 	<-done
 	require.Len(t, labelExpires, 0)
 }
+
+func TestKeyRangeSchedulerPauser(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		rules    = make(map[string][]KeyRangeRule)
+		requests int
+	)
+	httpSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		requests++
+		switch r.Method {
+		case http.MethodPatch:
+			var patch pdhttp.LabelRulePatch
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&patch))
+			for _, id := range patch.DeleteRules {
+				delete(rules, id)
+			}
+		case http.MethodPost:
+			var labelRule struct {
+				ID   string         `json:"id"`
+				Data []KeyRangeRule `json:"data"`
+			}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&labelRule))
+			rules[labelRule.ID] = labelRule.Data
+		}
+	}))
+	defer httpSrv.Close()
+	activeRanges := func() [][]KeyRangeRule {
+		mu.Lock()
+		defer mu.Unlock()
+		ranges := make([][]KeyRangeRule, 0, len(rules))
+		for _, data := range rules {
+			ranges = append(ranges, data)
+		}
+		return ranges
+	}
+
+	pdHTTPCli := pdhttp.NewClientWithServiceDiscovery("test", unistore.NewMockPDServiceDiscovery([]string{httpSrv.URL}))
+	defer pdHTTPCli.Close()
+	ctx := context.Background()
+	pauser := NewKeyRangeSchedulerPauser(pdHTTPCli)
+	// the key ranges are paused by a single rule, and the adjacent ones are merged.
+	require.NoError(t, pauser.Pause(ctx,
+		PausedKeyRange{ID: "t1", StartKey: []byte{1}, EndKey: []byte{2}},
+		PausedKeyRange{ID: "t2", StartKey: []byte{2}, EndKey: []byte{3}},
+		PausedKeyRange{ID: "t4", StartKey: []byte{4}, EndKey: []byte{5}},
+	))
+	require.Equal(t, 3, pauser.Paused())
+	require.Equal(t, [][]KeyRangeRule{{{StartKeyHex: "01", EndKeyHex: "03"}, {StartKeyHex: "04", EndKeyHex: "05"}}},
+		activeRanges())
+	mu.Lock()
+	require.Equal(t, 1, requests)
+	mu.Unlock()
+
+	pauser.Resume("t1")
+	require.Equal(t, 2, pauser.Paused())
+	require.Eventually(t, func() bool {
+		return reflect.DeepEqual([][]KeyRangeRule{{{StartKeyHex: "02", EndKeyHex: "03"}, {StartKeyHex: "04", EndKeyHex: "05"}}},
+			activeRanges())
+	}, 5*time.Second, 10*time.Millisecond)
+	// resuming the resumed key range does nothing.
+	pauser.Resume("t1")
+
+	pauser.Resume("t2", "t4")
+	require.Equal(t, 0, pauser.Paused())
+	require.Eventually(t, func() bool {
+		return len(activeRanges()) == 0
+	}, 5*time.Second, 10*time.Millisecond)
+
+	require.NoError(t, pauser.Pause(ctx, PausedKeyRange{ID: "t5", StartKey: []byte{5}, EndKey: []byte{6}}))
+	require.Len(t, activeRanges(), 1)
+	pauser.ResumeAll()
+	require.Equal(t, 0, pauser.Paused())
+	require.Empty(t, activeRanges())
+}
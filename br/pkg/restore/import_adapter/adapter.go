@@ -0,0 +1,131 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package importadapter
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/log"
+	berrors "github.com/pingcap/tidb/br/pkg/errors"
+	"github.com/pingcap/tidb/br/pkg/storage"
+	"go.uber.org/zap"
+)
+
+const (
+	// FormatAuto detects the format of the data by the registered adapters.
+	FormatAuto = "auto"
+	// FormatDumpling is the SQL, CSV or Parquet files with the schema files exported by Dumpling or
+	// MyDumper.
+	FormatDumpling = "dumpling"
+	// FormatParquet is the Parquet files named as `{db}.{table}.*.parquet`, the schemas of the tables
+	// without the schema files are inferred from the Parquet files.
+	FormatParquet = "parquet"
+)
+
+// Adapter converts the data exported by another tool into a synthetic backup, so the data is restored
+// by the snapshot restore of BR.
+type Adapter interface {
+	// Name returns the format of the adapter, used by `--import-format`.
+	Name() string
+	// Detect checks whether the data in the storage is of the format.
+	Detect(ctx context.Context, s storage.ExternalStorage) (bool, error)
+	// Load scans the data in the storage and builds the synthetic backup.
+	Load(ctx context.Context, s storage.ExternalStorage) (*Backup, error)
+}
+
+var (
+	adaptersMu sync.Mutex
+	// adapters are detected in the order of registration.
+	adapters = []Adapter{dumplingAdapter{}, parquetAdapter{}}
+)
+
+// Register registers the adapter, which replaces the adapter of the same format.
+func Register(a Adapter) {
+	adaptersMu.Lock()
+	defer adaptersMu.Unlock()
+	for i, registered := range adapters {
+		if registered.Name() == a.Name() {
+			adapters[i] = a
+			return
+		}
+	}
+	adapters = append(adapters, a)
+}
+
+// Formats returns the formats of the registered adapters.
+func Formats() []string {
+	adaptersMu.Lock()
+	defer adaptersMu.Unlock()
+	formats := make([]string, 0, len(adapters))
+	for _, a := range adapters {
+		formats = append(formats, a.Name())
+	}
+	return formats
+}
+
+// Get returns the adapter of the format.
+func Get(format string) (Adapter, error) {
+	adaptersMu.Lock()
+	defer adaptersMu.Unlock()
+	for _, a := range adapters {
+		if a.Name() == format {
+			return a, nil
+		}
+	}
+	return nil, errors.Annotatef(berrors.ErrInvalidArgument, "unknown import format %q", format)
+}
+
+// CheckFormat checks whether the format is auto or the format of a registered adapter.
+func CheckFormat(format string) error {
+	if format == FormatAuto {
+		return nil
+	}
+	if _, err := Get(format); err != nil {
+		return errors.Annotatef(berrors.ErrInvalidArgument, "the import format should be one of %s, got %q",
+			strings.Join(append([]string{FormatAuto}, Formats()...), ", "), format)
+	}
+	return nil
+}
+
+// Detect returns the first registered adapter whose format matches the data in the storage.
+func Detect(ctx context.Context, s storage.ExternalStorage) (Adapter, error) {
+	adaptersMu.Lock()
+	candidates := append([]Adapter{}, adapters...)
+	adaptersMu.Unlock()
+	for _, a := range candidates {
+		ok, err := a.Detect(ctx, s)
+		if err != nil {
+			return nil, errors.Annotatef(err, "failed to detect the %s format", a.Name())
+		}
+		if ok {
+			log.Info("detected the import format", zap.String("format", a.Name()))
+			return a, nil
+		}
+	}
+	return nil, errors.Annotatef(berrors.ErrInvalidArgument,
+		"the format of the data isn't detected, it should be one of %s", strings.Join(Formats(), ", "))
+}
+
+// Load loads the data in the storage by the adapter of the format, the format is detected if it's auto.
+func Load(ctx context.Context, s storage.ExternalStorage, format string) (*Backup, error) {
+	var (
+		a   Adapter
+		err error
+	)
+	if format == FormatAuto {
+		a, err = Detect(ctx, s)
+	} else {
+		a, err = Get(format)
+	}
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	backup, err := a.Load(ctx, s)
+	if err != nil {
+		return nil, errors.Annotatef(err, "failed to load the data of the %s format", a.Name())
+	}
+	return backup, nil
+}
@@ -0,0 +1,192 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package importadapter_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	backuppb "github.com/pingcap/kvproto/pkg/brpb"
+	berrors "github.com/pingcap/tidb/br/pkg/errors"
+	importadapter "github.com/pingcap/tidb/br/pkg/restore/import_adapter"
+	"github.com/pingcap/tidb/br/pkg/storage"
+	"github.com/pingcap/tidb/pkg/meta/model"
+	"github.com/pingcap/tidb/pkg/tablecodec"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFiles(t *testing.T, files map[string]string) storage.ExternalStorage {
+	s, err := storage.NewLocalStorage(t.TempDir())
+	require.NoError(t, err)
+	for name, content := range files {
+		require.NoError(t, s.WriteFile(context.Background(), name, []byte(content)))
+	}
+	return s
+}
+
+type schema struct {
+	db    *model.DBInfo
+	table *model.TableInfo
+}
+
+func decodeSchemas(t *testing.T, meta *backuppb.BackupMeta) []schema {
+	schemas := make([]schema, 0, len(meta.Schemas))
+	for _, s := range meta.Schemas {
+		var sc schema
+		require.NoError(t, json.Unmarshal(s.Db, &sc.db))
+		if len(s.Table) > 0 {
+			require.NoError(t, json.Unmarshal(s.Table, &sc.table))
+		}
+		schemas = append(schemas, sc)
+	}
+	return schemas
+}
+
+// readRecords returns the handles of the record kvs and the number of the index kvs.
+func readRecords(t *testing.T, backup *importadapter.Backup, files ...*backuppb.File) ([]int64, int) {
+	var (
+		handles []int64
+		indexes int
+	)
+	err := backup.ReadKVs(context.Background(), files, func(key, _ []byte) error {
+		if tablecodec.IsIndexKey(key) {
+			indexes++
+			return nil
+		}
+		_, handle, err := tablecodec.DecodeRecordKey(key)
+		require.NoError(t, err)
+		handles = append(handles, handle.IntValue())
+		return nil
+	})
+	require.NoError(t, err)
+	return handles, indexes
+}
+
+func TestCheckFormat(t *testing.T) {
+	require.NoError(t, importadapter.CheckFormat(importadapter.FormatAuto))
+	require.NoError(t, importadapter.CheckFormat(importadapter.FormatDumpling))
+	require.NoError(t, importadapter.CheckFormat(importadapter.FormatParquet))
+	err := importadapter.CheckFormat("mydumper2")
+	require.ErrorIs(t, err, berrors.ErrInvalidArgument)
+	require.ErrorContains(t, err, "auto, dumpling, parquet")
+}
+
+func TestLoadDumpling(t *testing.T) {
+	ctx := context.Background()
+	s := writeFiles(t, map[string]string{
+		"metadata":               "Started dump at: 2026-01-01 00:00:00\n",
+		"db1-schema-create.sql":  "CREATE DATABASE `db1` DEFAULT CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci;\n",
+		"db1.t1-schema.sql":      "CREATE TABLE `t1` (`id` int PRIMARY KEY AUTO_INCREMENT, `v` varchar(10), KEY `idx_v` (`v`));\n",
+		"db1.t1.000000000.sql":   "INSERT INTO `t1` VALUES (1,'a'),(5,'b');\n",
+		"db1.t2-schema.sql":      "CREATE TABLE `t2` (`a` int, `b` varchar(10));\n",
+		"db1.t2.000000000.csv":   "b,a\nx,1\ny,2\n",
+		"db2-schema-create.sql":  "CREATE DATABASE `db2`;\n",
+		"db1.v1-schema-view.sql": "CREATE VIEW `v1` AS SELECT 1;\n",
+		"db1.v1-schema.sql":      "CREATE TABLE `v1` (`1` tinyint);\n",
+		"db1.t3-schema.sql":      "CREATE TABLE `t3` (`a` int) PARTITION BY HASH(`a`) PARTITIONS 2;\n",
+		"db3.t4-schema.sql":      "CREATE TABLE `t4` (`a` int);\n",
+		"db3-schema-create.sql":  "CREATE DATABASE `db3`;\n",
+	})
+
+	a, err := importadapter.Detect(ctx, s)
+	require.NoError(t, err)
+	require.Equal(t, importadapter.FormatDumpling, a.Name())
+	// the partitioned table isn't supported.
+	_, err = importadapter.Load(ctx, s, importadapter.FormatAuto)
+	require.ErrorIs(t, err, berrors.ErrInvalidArgument)
+	require.ErrorContains(t, err, "partitioned table `db1`.`t3`")
+	require.NoError(t, s.DeleteFile(ctx, "db1.t3-schema.sql"))
+
+	backup, err := importadapter.Load(ctx, s, importadapter.FormatAuto)
+	require.NoError(t, err)
+	schemas := decodeSchemas(t, backup.Meta)
+	tables := make(map[string]*model.TableInfo)
+	var emptyDBs []string
+	for _, sc := range schemas {
+		if sc.table == nil {
+			emptyDBs = append(emptyDBs, sc.db.Name.O)
+			continue
+		}
+		tables[sc.db.Name.O+"."+sc.table.Name.O] = sc.table
+		if sc.db.Name.O == "db1" {
+			require.Equal(t, "utf8mb4_general_ci", sc.db.Collate)
+		}
+	}
+	require.Equal(t, []string{"db2"}, emptyDBs)
+	require.Len(t, tables, 3)
+	require.Contains(t, tables, "db1.t1")
+	require.Contains(t, tables, "db1.t2")
+	require.Contains(t, tables, "db3.t4")
+	// the view is skipped.
+	require.NotContains(t, tables, "db1.v1")
+
+	files := make(map[string]*backuppb.File)
+	for _, f := range backup.Meta.Files {
+		files[f.Name] = f
+	}
+	require.Len(t, files, 2)
+	t1, t2 := tables["db1.t1"], tables["db1.t2"]
+	t1File, t2File := files["db1.t1.000000000.sql"], files["db1.t2.000000000.csv"]
+	require.Equal(t, []byte(tablecodec.EncodeTablePrefix(t1.ID)), t1File.StartKey)
+	require.Equal(t, t1.ID, tablecodec.DecodeTableID(t1File.EndKey))
+	require.Equal(t, "write", t1File.Cf)
+
+	handles, indexes := readRecords(t, backup, t1File)
+	require.Equal(t, []int64{1, 5}, handles)
+	require.Equal(t, 2, indexes)
+	// the rows without the primary key take the row ids allocated for the file.
+	handles, indexes = readRecords(t, backup, t2File)
+	require.Equal(t, []int64{1, 2}, handles)
+	require.Zero(t, indexes)
+	// the kvs are the same if the file is read again.
+	handles, _ = readRecords(t, backup, t2File)
+	require.Equal(t, []int64{1, 2}, handles)
+
+	require.Equal(t, int64(5), maxBase(backup.AutoIDBases(t1.ID)))
+	require.Equal(t, int64(2), maxBase(backup.AutoIDBases(t2.ID)))
+	require.Nil(t, backup.AutoIDBases(tables["db3.t4"].ID))
+
+	err = backup.ReadKVs(ctx, []*backuppb.File{{Name: "1_write.sst"}}, func(_, _ []byte) error { return nil })
+	require.ErrorIs(t, err, berrors.ErrRestoreInvalidBackup)
+}
+
+func TestLoadDumplingWithoutSchema(t *testing.T) {
+	ctx := context.Background()
+	s := writeFiles(t, map[string]string{
+		"db1.t1-schema.sql":    "CREATE TABLE `t1` (`a` int);\n",
+		"db1.t2.000000000.sql": "INSERT INTO `t2` VALUES (1);\n",
+	})
+	_, err := importadapter.Load(ctx, s, importadapter.FormatDumpling)
+	require.ErrorIs(t, err, berrors.ErrRestoreInvalidBackup)
+	require.ErrorContains(t, err, "the schema file of the table `db1`.`t2` is missing")
+
+	// the schema of the SQL file can't be inferred either.
+	_, err = importadapter.Load(ctx, s, importadapter.FormatParquet)
+	require.ErrorIs(t, err, berrors.ErrRestoreInvalidBackup)
+
+	_, err = importadapter.Load(ctx, writeFiles(t, map[string]string{"a.txt": ""}), importadapter.FormatAuto)
+	require.ErrorIs(t, err, berrors.ErrInvalidArgument)
+	require.ErrorContains(t, err, "isn't detected")
+}
+
+func TestReadKVsUnknownColumn(t *testing.T) {
+	ctx := context.Background()
+	s := writeFiles(t, map[string]string{
+		"db1.t1-schema.sql":    "CREATE TABLE `t1` (`a` int);\n",
+		"db1.t1.000000000.csv": "a,c\n1,2\n",
+	})
+	backup, err := importadapter.Load(ctx, s, importadapter.FormatDumpling)
+	require.NoError(t, err)
+	err = backup.ReadKVs(ctx, backup.Meta.Files, func(_, _ []byte) error { return nil })
+	require.ErrorIs(t, err, berrors.ErrInvalidArgument)
+	require.ErrorContains(t, err, "unknown columns c of the table t1")
+}
+
+func maxBase[K comparable](bases map[K]int64) int64 {
+	m := int64(0)
+	for _, base := range bases {
+		m = max(m, base)
+	}
+	return m
+}
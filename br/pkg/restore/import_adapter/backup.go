@@ -0,0 +1,228 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package importadapter
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"time"
+
+	"github.com/pingcap/errors"
+	backuppb "github.com/pingcap/kvproto/pkg/brpb"
+	berrors "github.com/pingcap/tidb/br/pkg/errors"
+	"github.com/pingcap/tidb/br/pkg/storage"
+	"github.com/pingcap/tidb/pkg/ddl"
+	lightningcfg "github.com/pingcap/tidb/pkg/lightning/config"
+	"github.com/pingcap/tidb/pkg/lightning/mydump"
+	"github.com/pingcap/tidb/pkg/lightning/worker"
+	"github.com/pingcap/tidb/pkg/meta/metabuild"
+	"github.com/pingcap/tidb/pkg/meta/model"
+	"github.com/pingcap/tidb/pkg/parser"
+	"github.com/pingcap/tidb/pkg/parser/ast"
+	"github.com/pingcap/tidb/pkg/parser/mysql"
+	"github.com/pingcap/tidb/pkg/tablecodec"
+)
+
+// dataFile is a data file of the synthetic backup.
+type dataFile struct {
+	meta    mydump.SourceFileMeta
+	tableID int64
+	// the row ids of the rows in the file are in (rowIDBase, rowIDMax], they are allocated by the upper
+	// bound of the rows like Lightning, so the retried import of the file writes the same keys.
+	rowIDBase int64
+	rowIDMax  int64
+}
+
+// Backup is the synthetic backup of the data exported by another tool. The backupmeta contains the schemas
+// of the tables and a backup file for each data file, whose key range is the whole table. The kvs of the
+// backup files are encoded from the rows of the data files by ReadKVs.
+type Backup struct {
+	Meta *backuppb.BackupMeta
+
+	store  storage.ExternalStorage
+	files  map[string]*dataFile
+	tables map[int64]*encodingTable
+	// timestamp is the timestamp of the sessions encoding the rows, so the default values are the same if
+	// the rows are encoded again by the retries.
+	timestamp int64
+	sqlMode   mysql.SQLMode
+	csv       lightningcfg.CSVConfig
+	ioWorkers *worker.Pool
+}
+
+// backupBuilder builds the synthetic backup, the ids of the databases and the tables are allocated from 1,
+// they are rewritten by the restore.
+type backupBuilder struct {
+	backup *Backup
+	nextID int64
+	// emptyDBs are the databases without tables yet.
+	emptyDBs []*model.DBInfo
+}
+
+func newBackupBuilder(s storage.ExternalStorage) *backupBuilder {
+	cfg := lightningcfg.NewConfig()
+	return &backupBuilder{
+		backup: &Backup{
+			Meta:      &backuppb.BackupMeta{},
+			store:     s,
+			files:     make(map[string]*dataFile),
+			tables:    make(map[int64]*encodingTable),
+			timestamp: time.Now().Unix(),
+			// the default SQL mode of Lightning.
+			sqlMode:   mysql.ModeOnlyFullGroupBy | mysql.ModeNoAutoCreateUser,
+			csv:       cfg.Mydumper.CSV,
+			ioWorkers: worker.NewPool(context.Background(), ioConcurrency, "import adapter io"),
+		},
+	}
+}
+
+func (b *backupBuilder) allocID() int64 {
+	b.nextID++
+	return b.nextID
+}
+
+// parseStatement returns the first statement of the type in the SQL.
+func parseStatement[T ast.StmtNode](sql string, sqlMode mysql.SQLMode) (T, error) {
+	var stmt T
+	p := parser.New()
+	p.SetSQLMode(sqlMode)
+	stmts, _, err := p.ParseSQL(sql)
+	if err != nil {
+		return stmt, errors.Trace(err)
+	}
+	for _, s := range stmts {
+		if stmt, ok := s.(T); ok {
+			return stmt, nil
+		}
+	}
+	return stmt, errors.Annotatef(berrors.ErrInvalidArgument, "the expected statement isn't found in %q", sql)
+}
+
+// addDatabase adds the database created by the SQL.
+func (b *backupBuilder) addDatabase(name, createSQL string) (*model.DBInfo, error) {
+	stmt, err := parseStatement[*ast.CreateDatabaseStmt](createSQL, b.backup.sqlMode)
+	if err != nil {
+		return nil, errors.Annotatef(err, "failed to parse the schema of the database `%s`", name)
+	}
+	dbInfo := &model.DBInfo{
+		ID:      b.allocID(),
+		Name:    ast.NewCIStr(name),
+		Charset: mysql.DefaultCharset,
+		Collate: mysql.DefaultCollationName,
+		State:   model.StatePublic,
+	}
+	for _, opt := range stmt.Options {
+		switch opt.Tp {
+		case ast.DatabaseOptionCharset:
+			dbInfo.Charset = opt.Value
+		case ast.DatabaseOptionCollate:
+			dbInfo.Collate = opt.Value
+		}
+	}
+	b.emptyDBs = append(b.emptyDBs, dbInfo)
+	return dbInfo, nil
+}
+
+// addTable adds the table created by the SQL and its data files.
+func (b *backupBuilder) addTable(
+	ctx context.Context,
+	dbInfo *model.DBInfo,
+	name string,
+	createSQL string,
+	files []mydump.FileInfo,
+) error {
+	stmt, err := parseStatement[*ast.CreateTableStmt](createSQL, b.backup.sqlMode)
+	if err != nil {
+		return errors.Annotatef(err, "failed to parse the schema of the table `%s`.`%s`", dbInfo.Name, name)
+	}
+	tableInfo, err := ddl.BuildTableInfoWithStmt(metabuild.NewNonStrictContext(), stmt, dbInfo.Charset, dbInfo.Collate, nil)
+	if err != nil {
+		return errors.Annotatef(err, "failed to build the table `%s`.`%s`", dbInfo.Name, name)
+	}
+	if tableInfo.Partition != nil {
+		return errors.Annotatef(berrors.ErrInvalidArgument,
+			"the partitioned table `%s`.`%s` isn't supported by the import adapter", dbInfo.Name, name)
+	}
+	tableInfo.ID = b.allocID()
+	tableInfo.Name = ast.NewCIStr(name)
+	tableInfo.State = model.StatePublic
+	table, err := newEncodingTable(tableInfo)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	b.backup.tables[tableInfo.ID] = table
+
+	dbJSON, err := json.Marshal(dbInfo)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	tableJSON, err := json.Marshal(tableInfo)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	b.backup.Meta.Schemas = append(b.backup.Meta.Schemas, &backuppb.Schema{Db: dbJSON, Table: tableJSON})
+	b.emptyDBs = slices.DeleteFunc(b.emptyDBs, func(db *model.DBInfo) bool { return db.ID == dbInfo.ID })
+
+	startKey := tablecodec.EncodeTablePrefix(tableInfo.ID)
+	// the end key is still of the table, so the rewrite rule of the table matches both keys.
+	endKey := append(tablecodec.EncodeTablePrefix(tableInfo.ID), 0xff)
+	rowIDBase := int64(0)
+	for _, f := range files {
+		rows, err := estimateRows(ctx, b.backup.store, f, len(tableInfo.Columns))
+		if err != nil {
+			return errors.Trace(err)
+		}
+		b.backup.files[f.FileMeta.Path] = &dataFile{
+			meta:      f.FileMeta,
+			tableID:   tableInfo.ID,
+			rowIDBase: rowIDBase,
+			rowIDMax:  rowIDBase + rows,
+		}
+		rowIDBase += rows
+		b.backup.Meta.Files = append(b.backup.Meta.Files, &backuppb.File{
+			Name:       f.FileMeta.Path,
+			StartKey:   startKey,
+			EndKey:     endKey,
+			Cf:         "write",
+			Size_:      uint64(f.FileMeta.FileSize),
+			TotalBytes: uint64(f.FileMeta.RealSize),
+		})
+	}
+	return nil
+}
+
+// build returns the synthetic backup.
+func (b *backupBuilder) build() (*Backup, error) {
+	for _, dbInfo := range b.emptyDBs {
+		dbJSON, err := json.Marshal(dbInfo)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		// the schema without the table is the empty database.
+		b.backup.Meta.Schemas = append(b.backup.Meta.Schemas, &backuppb.Schema{Db: dbJSON})
+	}
+	b.emptyDBs = nil
+	return b.backup, nil
+}
+
+// estimateRows returns the upper bound of the rows in the file like Lightning, i.e. the exact rows of
+// the Parquet file, or the rows of the text file if each field takes one byte.
+func estimateRows(ctx context.Context, s storage.ExternalStorage, f mydump.FileInfo, columns int) (int64, error) {
+	if f.FileMeta.Type == mydump.SourceTypeParquet {
+		if f.FileMeta.Rows > 0 {
+			return f.FileMeta.Rows, nil
+		}
+		rows, err := mydump.ReadParquetFileRowCountByFile(ctx, s, f.FileMeta)
+		return rows, errors.Annotatef(err, "failed to read the rows of %s", f.FileMeta.Path)
+	}
+	divisor := int64(columns)
+	if f.FileMeta.Type != mydump.SourceTypeCSV {
+		divisor += 2
+	}
+	size := f.FileMeta.FileSize
+	if f.FileMeta.Compression != mydump.CompressionNone {
+		size = f.FileMeta.RealSize * mydump.CompressSizeFactor
+	}
+	return size/divisor + 1, nil
+}
@@ -0,0 +1,108 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package importadapter
+
+import (
+	"context"
+	"path"
+	"strings"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/log"
+	berrors "github.com/pingcap/tidb/br/pkg/errors"
+	"github.com/pingcap/tidb/br/pkg/storage"
+	lightningcfg "github.com/pingcap/tidb/pkg/lightning/config"
+	"github.com/pingcap/tidb/pkg/lightning/mydump"
+	"go.uber.org/zap"
+)
+
+// errFound stops walking the storage once the expected file is found.
+var errFound = errors.New("found")
+
+// findFile checks whether there is a file in the storage whose path matches.
+func findFile(ctx context.Context, s storage.ExternalStorage, match func(path string) bool) (bool, error) {
+	err := s.WalkDir(ctx, &storage.WalkOption{}, func(path string, _ int64) error {
+		if match(path) {
+			return errFound
+		}
+		return nil
+	})
+	if errors.Cause(err) == errFound {
+		return true, nil
+	}
+	return false, errors.Trace(err)
+}
+
+// dumplingAdapter restores the directory exported by Dumpling or MyDumper, the files are routed by the
+// default file rules of Lightning.
+type dumplingAdapter struct{}
+
+func (dumplingAdapter) Name() string {
+	return FormatDumpling
+}
+
+// Detect checks whether there is the metadata file or a schema file in the storage.
+func (dumplingAdapter) Detect(ctx context.Context, s storage.ExternalStorage) (bool, error) {
+	return findFile(ctx, s, func(p string) bool {
+		name := path.Base(p)
+		return name == "metadata" || strings.Contains(name, "-schema.sql") || strings.Contains(name, "-schema-create.sql")
+	})
+}
+
+// Load requires the schema files of all the tables.
+func (dumplingAdapter) Load(ctx context.Context, s storage.ExternalStorage) (*Backup, error) {
+	return loadDumpDir(ctx, s, nil)
+}
+
+// loadDumpDir builds the synthetic backup of the directory scanned by the loader of Lightning. The schema
+// of the table without the schema file is inferred by inferSchema, or it's an error if inferSchema is nil.
+func loadDumpDir(
+	ctx context.Context,
+	s storage.ExternalStorage,
+	inferSchema func(ctx context.Context, tbl *mydump.MDTableMeta) (string, error),
+) (*Backup, error) {
+	loader, err := mydump.NewLoaderWithStore(ctx, mydump.LoaderConfig{
+		CharacterSet:     "auto",
+		Filter:           lightningcfg.GetDefaultFilter(),
+		DefaultFileRules: true,
+	}, s)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	builder := newBackupBuilder(s)
+	for _, dbMeta := range loader.GetDatabases() {
+		dbInfo, err := builder.addDatabase(dbMeta.Name, dbMeta.GetSchema(ctx, s))
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		// Dumpling exports the placeholder table of the view too, which isn't restored with the view.
+		views := make(map[string]struct{}, len(dbMeta.Views))
+		for _, view := range dbMeta.Views {
+			log.Warn("the view isn't restored by the import adapter",
+				zap.String("db", view.DB), zap.String("view", view.Name))
+			views[view.Name] = struct{}{}
+		}
+		for _, tblMeta := range dbMeta.Tables {
+			if _, ok := views[tblMeta.Name]; ok {
+				continue
+			}
+			var createSQL string
+			switch {
+			case len(tblMeta.SchemaFile.FileMeta.Path) > 0:
+				createSQL, err = tblMeta.GetSchema(ctx, s)
+			case inferSchema != nil:
+				createSQL, err = inferSchema(ctx, tblMeta)
+			default:
+				err = errors.Annotatef(berrors.ErrRestoreInvalidBackup,
+					"the schema file of the table `%s`.`%s` is missing", tblMeta.DB, tblMeta.Name)
+			}
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			if err := builder.addTable(ctx, dbInfo, tblMeta.Name, createSQL, tblMeta.DataFiles); err != nil {
+				return nil, errors.Trace(err)
+			}
+		}
+	}
+	return builder.build()
+}
@@ -0,0 +1,207 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package importadapter
+
+import (
+	"context"
+	"io"
+	"strings"
+
+	"github.com/pingcap/errors"
+	backuppb "github.com/pingcap/kvproto/pkg/brpb"
+	berrors "github.com/pingcap/tidb/br/pkg/errors"
+	"github.com/pingcap/tidb/br/pkg/storage"
+	"github.com/pingcap/tidb/pkg/lightning/backend/encode"
+	"github.com/pingcap/tidb/pkg/lightning/backend/kv"
+	lightningcfg "github.com/pingcap/tidb/pkg/lightning/config"
+	"github.com/pingcap/tidb/pkg/lightning/log"
+	"github.com/pingcap/tidb/pkg/lightning/mydump"
+	"github.com/pingcap/tidb/pkg/meta/autoid"
+	"github.com/pingcap/tidb/pkg/meta/model"
+	"github.com/pingcap/tidb/pkg/table"
+	"github.com/pingcap/tidb/pkg/table/tables"
+)
+
+// ioConcurrency is the number of the blocks of the text files read at the same time.
+const ioConcurrency = 16
+
+// encodingTable is the table encoding the rows of its data files. The allocators record the max auto ids
+// used by the rows, which are rebased after the table is restored.
+type encodingTable struct {
+	info   *model.TableInfo
+	allocs autoid.Allocators
+	table  table.Table
+}
+
+func newEncodingTable(info *model.TableInfo) (*encodingTable, error) {
+	allocs := kv.NewPanickingAllocators(info.SepAutoInc())
+	tbl, err := tables.TableFromMeta(allocs, info)
+	if err != nil {
+		return nil, errors.Annotatef(err, "failed to build the table %s", info.Name)
+	}
+	return &encodingTable{info: info, allocs: allocs, table: tbl}, nil
+}
+
+// ReadKVs implements snapclient.KVReader, the kvs are encoded from the rows of the data files.
+func (b *Backup) ReadKVs(ctx context.Context, files []*backuppb.File, fn func(key, value []byte) error) error {
+	for _, file := range files {
+		f, ok := b.files[file.Name]
+		if !ok {
+			return errors.Annotatef(berrors.ErrRestoreInvalidBackup, "%s isn't a data file of the import adapter", file.Name)
+		}
+		if err := b.readFileKVs(ctx, f, fn); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return nil
+}
+
+// AutoIDBases returns the max auto ids used by the rows of the table, nil if no id is used.
+func (b *Backup) AutoIDBases(tableID int64) map[autoid.AllocatorType]int64 {
+	tbl, ok := b.tables[tableID]
+	if !ok {
+		return nil
+	}
+	var bases map[autoid.AllocatorType]int64
+	for _, tp := range []autoid.AllocatorType{autoid.RowIDAllocType, autoid.AutoIncrementType, autoid.AutoRandomType} {
+		alloc := tbl.allocs.Get(tp)
+		if alloc == nil || alloc.Base() <= 0 {
+			continue
+		}
+		if bases == nil {
+			bases = make(map[autoid.AllocatorType]int64)
+		}
+		bases[tp] = alloc.Base()
+	}
+	return bases
+}
+
+func (b *Backup) openParser(ctx context.Context, f *dataFile) (mydump.Parser, error) {
+	reader, err := mydump.OpenReader(ctx, &f.meta, b.store, storage.DecompressConfig{ZStdDecodeConcurrency: 1})
+	if err != nil {
+		return nil, errors.Annotatef(err, "failed to open %s", f.meta.Path)
+	}
+	var parser mydump.Parser
+	blockBufSize := int64(lightningcfg.ReadBlockSize)
+	switch f.meta.Type {
+	case mydump.SourceTypeSQL:
+		parser = mydump.NewChunkParser(ctx, b.sqlMode, reader, blockBufSize, b.ioWorkers)
+	case mydump.SourceTypeCSV:
+		parser, err = mydump.NewCSVParser(ctx, &b.csv, reader, blockBufSize, b.ioWorkers, b.csv.Header, nil)
+	case mydump.SourceTypeParquet:
+		parser, err = mydump.NewParquetParser(ctx, b.store, reader, f.meta.Path)
+	default:
+		err = errors.Annotatef(berrors.ErrInvalidArgument, "the %s file %s isn't supported", f.meta.Type, f.meta.Path)
+	}
+	if err != nil {
+		_ = reader.Close()
+		return nil, errors.Trace(err)
+	}
+	parser.SetRowID(f.rowIDBase)
+	return parser, nil
+}
+
+// readFileKVs encodes the rows of the file into kvs by the encoder of Lightning.
+func (b *Backup) readFileKVs(ctx context.Context, f *dataFile, fn func(key, value []byte) error) error {
+	tbl := b.tables[f.tableID]
+	parser, err := b.openParser(ctx, f)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer parser.Close()
+	encoder, err := kv.NewTableKVEncoder(&encode.EncodingConfig{
+		SessionOptions: encode.SessionOptions{
+			SQLMode:   b.sqlMode,
+			Timestamp: b.timestamp,
+			// the shard bits of the auto random ids are the same if the rows are encoded again.
+			AutoRandomSeed: f.rowIDBase,
+		},
+		Path:   f.meta.Path,
+		Table:  tbl.table,
+		Logger: log.FromContext(ctx),
+	}, nil)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer encoder.Close()
+
+	var permutation []int
+	for {
+		err := parser.ReadRow()
+		if errors.Cause(err) == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return errors.Annotatef(err, "failed to parse %s", f.meta.Path)
+		}
+		row := parser.LastRow()
+		if permutation == nil {
+			if permutation, err = columnPermutation(tbl.info, parser.Columns()); err != nil {
+				return errors.Annotatef(err, "failed to match the columns of %s", f.meta.Path)
+			}
+		}
+		if row.RowID > f.rowIDMax {
+			return errors.Annotatef(berrors.ErrRestoreInvalidBackup,
+				"%s has more rows than %d, the rows of the file are estimated by its size",
+				f.meta.Path, f.rowIDMax-f.rowIDBase)
+		}
+		encoded, err := encoder.Encode(row.Row, row.RowID, permutation, 0)
+		if err != nil {
+			return errors.Annotatef(err, "failed to encode the row %d of %s", row.RowID-f.rowIDBase, f.meta.Path)
+		}
+		for _, pair := range kv.Row2KvPairs(encoded) {
+			if err := fn(pair.Key, pair.Val); err != nil {
+				return errors.Trace(err)
+			}
+		}
+		parser.RecycleRow(row)
+	}
+}
+
+// columnPermutation maps the columns of the table and the _tidb_rowid to the fields of the rows, -1 means
+// the column isn't in the rows. The fields are the non-generated columns in order if the names are unknown,
+// e.g. the INSERT statements without the column list.
+func columnPermutation(info *model.TableInfo, fields []string) ([]int, error) {
+	permutation := make([]int, len(info.Columns)+1)
+	rowIDOffset := len(info.Columns)
+	if len(fields) == 0 {
+		field := 0
+		for i, col := range info.Columns {
+			permutation[i] = -1
+			if !col.IsGenerated() {
+				permutation[i] = field
+				field++
+			}
+		}
+		permutation[rowIDOffset] = -1
+		return permutation, nil
+	}
+
+	offsets := make(map[string]int, len(fields))
+	for i, field := range fields {
+		offsets[strings.ToLower(field)] = i
+	}
+	for i, col := range info.Columns {
+		permutation[i] = -1
+		if offset, ok := offsets[col.Name.L]; ok {
+			permutation[i] = offset
+			delete(offsets, col.Name.L)
+		}
+	}
+	permutation[rowIDOffset] = -1
+	if offset, ok := offsets[model.ExtraHandleName.L]; ok {
+		permutation[rowIDOffset] = offset
+		delete(offsets, model.ExtraHandleName.L)
+	}
+	if len(offsets) > 0 {
+		unknown := make([]string, 0, len(offsets))
+		for _, field := range fields {
+			if _, ok := offsets[strings.ToLower(field)]; ok {
+				unknown = append(unknown, field)
+			}
+		}
+		return nil, errors.Annotatef(berrors.ErrInvalidArgument, "unknown columns %s of the table %s",
+			strings.Join(unknown, ", "), info.Name)
+	}
+	return permutation, nil
+}
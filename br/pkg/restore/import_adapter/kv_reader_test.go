@@ -0,0 +1,39 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package importadapter
+
+import (
+	"testing"
+
+	berrors "github.com/pingcap/tidb/br/pkg/errors"
+	"github.com/pingcap/tidb/pkg/meta/model"
+	"github.com/pingcap/tidb/pkg/parser/ast"
+	"github.com/stretchr/testify/require"
+)
+
+func TestColumnPermutation(t *testing.T) {
+	info := &model.TableInfo{
+		Name: ast.NewCIStr("t"),
+		Columns: []*model.ColumnInfo{
+			{Name: ast.NewCIStr("a")},
+			{Name: ast.NewCIStr("g"), GeneratedExprString: "a + 1"},
+			{Name: ast.NewCIStr("b")},
+		},
+	}
+	// the fields are the non-generated columns in order without the names.
+	permutation, err := columnPermutation(info, nil)
+	require.NoError(t, err)
+	require.Equal(t, []int{0, -1, 1, -1}, permutation)
+
+	permutation, err = columnPermutation(info, []string{"B", "_tidb_rowid", "a"})
+	require.NoError(t, err)
+	require.Equal(t, []int{2, -1, 0, 1}, permutation)
+
+	permutation, err = columnPermutation(info, []string{"b"})
+	require.NoError(t, err)
+	require.Equal(t, []int{-1, -1, 0, -1}, permutation)
+
+	_, err = columnPermutation(info, []string{"a", "c", "d"})
+	require.ErrorIs(t, err, berrors.ErrInvalidArgument)
+	require.ErrorContains(t, err, "unknown columns c, d of the table t")
+}
@@ -0,0 +1,157 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package importadapter
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/pingcap/errors"
+	berrors "github.com/pingcap/tidb/br/pkg/errors"
+	"github.com/pingcap/tidb/br/pkg/storage"
+	"github.com/pingcap/tidb/pkg/lightning/mydump"
+	"github.com/xitongsys/parquet-go/parquet"
+)
+
+// parquetAdapter restores the directory of the Parquet files, the schemas of the tables without the schema
+// files are inferred from the first Parquet file of the tables.
+type parquetAdapter struct{}
+
+func (parquetAdapter) Name() string {
+	return FormatParquet
+}
+
+// Detect checks whether there is a Parquet file in the storage.
+func (parquetAdapter) Detect(ctx context.Context, s storage.ExternalStorage) (bool, error) {
+	return findFile(ctx, s, func(p string) bool {
+		return strings.HasSuffix(strings.ToLower(p), ".parquet")
+	})
+}
+
+func (parquetAdapter) Load(ctx context.Context, s storage.ExternalStorage) (*Backup, error) {
+	return loadDumpDir(ctx, s, func(ctx context.Context, tbl *mydump.MDTableMeta) (string, error) {
+		return inferParquetSchema(ctx, s, tbl)
+	})
+}
+
+// inferParquetSchema generates the CREATE TABLE statement by the columns of the first Parquet file.
+func inferParquetSchema(ctx context.Context, s storage.ExternalStorage, tbl *mydump.MDTableMeta) (string, error) {
+	var file *mydump.FileInfo
+	for i := range tbl.DataFiles {
+		if tbl.DataFiles[i].FileMeta.Type == mydump.SourceTypeParquet {
+			file = &tbl.DataFiles[i]
+			break
+		}
+	}
+	if file == nil {
+		return "", errors.Annotatef(berrors.ErrRestoreInvalidBackup,
+			"the schema of the table `%s`.`%s` can't be inferred without the schema file or a Parquet file",
+			tbl.DB, tbl.Name)
+	}
+	reader, err := mydump.OpenReader(ctx, &file.FileMeta, s, storage.DecompressConfig{ZStdDecodeConcurrency: 1})
+	if err != nil {
+		return "", errors.Annotatef(err, "failed to open %s", file.FileMeta.Path)
+	}
+	parser, err := mydump.NewParquetParser(ctx, s, reader, file.FileMeta.Path)
+	if err != nil {
+		_ = reader.Close()
+		return "", errors.Annotatef(err, "failed to read the schema of %s", file.FileMeta.Path)
+	}
+	defer parser.Close()
+
+	columns := parser.Columns()
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "CREATE TABLE %s (", quoteName(tbl.Name))
+	i := 0
+	for _, se := range parser.Reader.SchemaHandler.SchemaElements {
+		if se.GetNumChildren() != 0 {
+			continue
+		}
+		tp, err := parquetColumnType(se)
+		if err != nil {
+			return "", errors.Annotatef(err, "failed to infer the type of the column %s of %s",
+				columns[i], file.FileMeta.Path)
+		}
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		fmt.Fprintf(&sb, "%s %s", quoteName(columns[i]), tp)
+		if se.GetRepetitionType() == parquet.FieldRepetitionType_REQUIRED {
+			sb.WriteString(" NOT NULL")
+		}
+		i++
+	}
+	sb.WriteString(")")
+	return sb.String(), nil
+}
+
+func quoteName(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
+
+// parquetColumnType maps the type of the Parquet column to the type of TiDB, which holds the values
+// converted by the Parquet parser of Lightning.
+func parquetColumnType(se *parquet.SchemaElement) (string, error) {
+	if lt := se.LogicalType; lt != nil {
+		switch {
+		case lt.DECIMAL != nil:
+			return fmt.Sprintf("decimal(%d,%d)", lt.DECIMAL.Precision, lt.DECIMAL.Scale), nil
+		case lt.DATE != nil:
+			return "date", nil
+		case lt.TIMESTAMP != nil:
+			return "datetime(6)", nil
+		case lt.TIME != nil:
+			return "time(6)", nil
+		case lt.INTEGER != nil:
+			unsigned := ""
+			if !lt.INTEGER.IsSigned {
+				unsigned = " unsigned"
+			}
+			if lt.INTEGER.BitWidth > 32 {
+				return "bigint" + unsigned, nil
+			}
+			return "int" + unsigned, nil
+		case lt.STRING != nil, lt.ENUM != nil, lt.JSON != nil:
+			return "longtext", nil
+		}
+	}
+	if ct := se.ConvertedType; ct != nil {
+		switch *ct {
+		case parquet.ConvertedType_DECIMAL:
+			return fmt.Sprintf("decimal(%d,%d)", se.GetPrecision(), se.GetScale()), nil
+		case parquet.ConvertedType_DATE:
+			return "date", nil
+		case parquet.ConvertedType_TIMESTAMP_MILLIS, parquet.ConvertedType_TIMESTAMP_MICROS:
+			return "datetime(6)", nil
+		case parquet.ConvertedType_TIME_MILLIS, parquet.ConvertedType_TIME_MICROS:
+			return "time(6)", nil
+		case parquet.ConvertedType_UINT_8, parquet.ConvertedType_UINT_16, parquet.ConvertedType_UINT_32:
+			return "int unsigned", nil
+		case parquet.ConvertedType_UINT_64:
+			return "bigint unsigned", nil
+		case parquet.ConvertedType_UTF8, parquet.ConvertedType_ENUM, parquet.ConvertedType_JSON:
+			return "longtext", nil
+		}
+	}
+	if se.Type == nil {
+		return "", errors.Annotatef(berrors.ErrInvalidArgument, "the column %s has no type", se.GetName())
+	}
+	switch *se.Type {
+	case parquet.Type_BOOLEAN:
+		return "tinyint(1)", nil
+	case parquet.Type_INT32:
+		return "int", nil
+	case parquet.Type_INT64:
+		return "bigint", nil
+	case parquet.Type_INT96:
+		return "datetime(6)", nil
+	case parquet.Type_FLOAT:
+		return "float", nil
+	case parquet.Type_DOUBLE:
+		return "double", nil
+	case parquet.Type_BYTE_ARRAY, parquet.Type_FIXED_LEN_BYTE_ARRAY:
+		return "longblob", nil
+	}
+	return "", errors.Annotatef(berrors.ErrInvalidArgument, "the Parquet type %s isn't supported", se.Type)
+}
@@ -0,0 +1,78 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package importadapter_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	importadapter "github.com/pingcap/tidb/br/pkg/restore/import_adapter"
+	"github.com/pingcap/tidb/br/pkg/storage"
+	"github.com/pingcap/tidb/pkg/parser/mysql"
+	"github.com/stretchr/testify/require"
+	"github.com/xitongsys/parquet-go-source/local"
+	"github.com/xitongsys/parquet-go/writer"
+)
+
+func TestLoadParquet(t *testing.T) {
+	type row struct {
+		ID     int64   `parquet:"name=id, type=INT64"`
+		Name   *string `parquet:"name=name, type=BYTE_ARRAY, convertedtype=UTF8"`
+		Price  int32   `parquet:"name=price, type=INT32, convertedtype=DECIMAL, scale=2, precision=9"`
+		Date   int32   `parquet:"name=date, type=INT32, convertedtype=DATE"`
+		Amount float64 `parquet:"name=amount, type=DOUBLE"`
+	}
+	ctx := context.Background()
+	dir := t.TempDir()
+	pf, err := local.NewLocalFileWriter(filepath.Join(dir, "db1.t1.0.parquet"))
+	require.NoError(t, err)
+	w, err := writer.NewParquetWriter(pf, new(row), 1)
+	require.NoError(t, err)
+	name := "a"
+	for i := range 3 {
+		r := &row{ID: int64(i + 10), Price: 1234, Date: 20000, Amount: 1.5}
+		if i != 1 {
+			r.Name = &name
+		}
+		require.NoError(t, w.Write(r))
+	}
+	require.NoError(t, w.WriteStop())
+	require.NoError(t, pf.Close())
+	s, err := storage.NewLocalStorage(dir)
+	require.NoError(t, err)
+
+	a, err := importadapter.Detect(ctx, s)
+	require.NoError(t, err)
+	require.Equal(t, importadapter.FormatParquet, a.Name())
+	backup, err := importadapter.Load(ctx, s, importadapter.FormatAuto)
+	require.NoError(t, err)
+
+	schemas := decodeSchemas(t, backup.Meta)
+	require.Len(t, schemas, 1)
+	require.Equal(t, "db1", schemas[0].db.Name.O)
+	tbl := schemas[0].table
+	require.Equal(t, "t1", tbl.Name.O)
+	require.Len(t, tbl.Columns, 5)
+	types := make(map[string]byte, len(tbl.Columns))
+	for _, col := range tbl.Columns {
+		types[col.Name.O] = col.GetType()
+	}
+	require.Equal(t, map[string]byte{
+		"id":     mysql.TypeLonglong,
+		"name":   mysql.TypeLongBlob,
+		"price":  mysql.TypeNewDecimal,
+		"date":   mysql.TypeDate,
+		"amount": mysql.TypeDouble,
+	}, types)
+	require.True(t, mysql.HasNotNullFlag(tbl.Columns[0].GetFlag()))
+	require.False(t, mysql.HasNotNullFlag(tbl.Columns[1].GetFlag()))
+	require.Equal(t, 2, tbl.Columns[2].GetDecimal())
+
+	// the rows of the Parquet file are exact, so the row ids are from 1 to 3.
+	require.Len(t, backup.Meta.Files, 1)
+	handles, indexes := readRecords(t, backup, backup.Meta.Files[0])
+	require.Equal(t, []int64{1, 2, 3}, handles)
+	require.Zero(t, indexes)
+	require.Equal(t, int64(3), maxBase(backup.AutoIDBases(tbl.ID)))
+}
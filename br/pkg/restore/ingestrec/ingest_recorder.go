@@ -16,6 +16,7 @@ package ingestrec
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 	"time"
 
@@ -37,6 +38,39 @@ type IngestIndexInfo struct {
 	IsPrimary  bool
 	IndexInfo  *model.IndexInfo
 	Updated    bool
+	// JobID is the ID of the last job adding the index.
+	JobID int64
+	// State is the state of the repair, empty if not repaired yet.
+	State IngestIndexState
+	Error string
+}
+
+// IngestIndexState is the state of the repair of an ingest index.
+type IngestIndexState string
+
+const (
+	// IngestIndexUnresolved means the table or the index isn't found in the newest schemas, e.g. dropped
+	// later, so it isn't repaired.
+	IngestIndexUnresolved IngestIndexState = "unresolved"
+	// IngestIndexPending means the index is waiting for the repair.
+	IngestIndexPending IngestIndexState = "pending"
+	// IngestIndexRepaired means the index is dropped and re-added.
+	IngestIndexRepaired IngestIndexState = "repaired"
+	// IngestIndexFailed means the repair of the index failed, it can be re-driven from the checkpoint.
+	IngestIndexFailed IngestIndexState = "failed"
+)
+
+// IngestIndexRecord is the record of an ingest index in the IngestRecorder.
+type IngestIndexRecord struct {
+	TableID    int64            `json:"table-id"`
+	IndexID    int64            `json:"index-id"`
+	JobID      int64            `json:"job-id"`
+	SchemaName string           `json:"schema-name"`
+	TableName  string           `json:"table-name"`
+	IndexName  string           `json:"index-name"`
+	State      IngestIndexState `json:"state"`
+	// Error is the error of the failed repair.
+	Error string `json:"error,omitempty"`
 }
 
 // IngestRecorder records the indexes information that use ingest mode to construct kvs.
@@ -111,6 +145,7 @@ func (i *IngestRecorder) TryAddJob(job *model.Job, isSubJob bool) error {
 		tableindexes[a.IndexID] = &IngestIndexInfo{
 			IsPrimary: job.Type == model.ActionAddPrimaryKey,
 			Updated:   false,
+			JobID:     job.ID,
 		}
 	}
 
@@ -210,3 +245,51 @@ func (i *IngestRecorder) Iterate(f func(tableID int64, indexID int64, info *Inge
 	}
 	return nil
 }
+
+// SetState sets the state of the repair of the ingest index, the error is kept for the failed one.
+func (i *IngestRecorder) SetState(tableID, indexID int64, state IngestIndexState, err error) {
+	info, ok := i.items[tableID][indexID]
+	if !ok {
+		return
+	}
+	info.State = state
+	info.Error = ""
+	if err != nil {
+		info.Error = err.Error()
+	}
+}
+
+// Records returns the records of all the ingest indexes, sorted by the table ID and the index ID.
+func (i *IngestRecorder) Records() []IngestIndexRecord {
+	records := make([]IngestIndexRecord, 0, len(i.items))
+	for tableID, is := range i.items {
+		for indexID, info := range is {
+			record := IngestIndexRecord{
+				TableID: tableID,
+				IndexID: indexID,
+				JobID:   info.JobID,
+				State:   info.State,
+				Error:   info.Error,
+			}
+			switch {
+			case !info.Updated:
+				record.State = IngestIndexUnresolved
+			case record.State == "":
+				record.State = IngestIndexPending
+			}
+			if info.Updated {
+				record.SchemaName = info.SchemaName.O
+				record.TableName = info.TableName.O
+				record.IndexName = info.IndexInfo.Name.O
+			}
+			records = append(records, record)
+		}
+	}
+	sort.Slice(records, func(a, b int) bool {
+		if records[a].TableID != records[b].TableID {
+			return records[a].TableID < records[b].TableID
+		}
+		return records[a].IndexID < records[b].IndexID
+	})
+	return records
+}
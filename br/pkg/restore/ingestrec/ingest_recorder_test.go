@@ -17,6 +17,7 @@ package ingestrec_test
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"testing"
 
 	"github.com/pingcap/errors"
@@ -495,3 +496,60 @@ func TestRewriteTableID(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, 2, count)
 }
+
+func TestRecords(t *testing.T) {
+	store, err := mockstore.NewMockStore(mockstore.WithStoreType(mockstore.EmbedUnistore))
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, store.Close())
+	}()
+
+	createMeta(t, store, func(m *meta.Mutator) {
+		dbInfo := &model.DBInfo{
+			ID:    1,
+			Name:  ast.NewCIStr(SchemaName),
+			State: model.StatePublic,
+		}
+		require.NoError(t, m.CreateDatabase(dbInfo))
+		tblInfo := &model.TableInfo{
+			ID:      TableID,
+			Name:    ast.NewCIStr(TableName),
+			Columns: []*model.ColumnInfo{{Name: ast.NewCIStr("x"), State: model.StatePublic}},
+			Indices: []*model.IndexInfo{{
+				ID:      1,
+				Name:    ast.NewCIStr("x"),
+				Table:   ast.NewCIStr(TableName),
+				Columns: []*model.IndexColumn{{Name: ast.NewCIStr("x"), Offset: 0, Length: -1}},
+				State:   model.StatePublic,
+			}},
+			State: model.StatePublic,
+		}
+		require.NoError(t, m.CreateTableOrView(1, tblInfo))
+	})
+	dom, err := session.GetDomain(store)
+	require.NoError(t, err)
+
+	recorder := ingestrec.New()
+	for _, indexID := range []int64{2, 1} {
+		job := fakeJob(model.ReorgTypeLitMerge, model.ActionAddIndex, model.JobStateSynced, 1000,
+			[]*model.IndexInfo{getIndex(indexID, []string{"x"})}, json.RawMessage(fmt.Sprintf(`[%d, false, [], false]`, indexID)))
+		job.ID = 100 + indexID
+		require.NoError(t, recorder.TryAddJob(job, false))
+	}
+	require.NoError(t, recorder.UpdateIndexInfo(dom.InfoSchema()))
+	// the index 2 is dropped later.
+	require.Equal(t, []ingestrec.IngestIndexRecord{
+		{TableID: TableID, IndexID: 1, JobID: 101, SchemaName: SchemaName, TableName: TableName, IndexName: "x",
+			State: ingestrec.IngestIndexPending},
+		{TableID: TableID, IndexID: 2, JobID: 102, State: ingestrec.IngestIndexUnresolved},
+	}, recorder.Records())
+
+	recorder.SetState(TableID, 1, ingestrec.IngestIndexFailed, errors.New("timeout"))
+	records := recorder.Records()
+	require.Equal(t, ingestrec.IngestIndexFailed, records[0].State)
+	require.Equal(t, "timeout", records[0].Error)
+	recorder.SetState(TableID, 1, ingestrec.IngestIndexRepaired, nil)
+	records = recorder.Records()
+	require.Equal(t, ingestrec.IngestIndexRepaired, records[0].State)
+	require.Empty(t, records[0].Error)
+}
@@ -27,6 +27,7 @@ import (
 type DB struct {
 	se            glue.Session
 	preallocedIDs *prealloctableid.PreallocIDs
+	sequenceMode  metautil.SequenceRestoreMode
 }
 
 // NewDB returns a new DB.
@@ -69,6 +70,11 @@ func (db *DB) Session() glue.Session {
 	return db.se
 }
 
+// SetSequenceRestoreMode sets how the restored sequences resume.
+func (db *DB) SetSequenceRestoreMode(mode metautil.SequenceRestoreMode) {
+	db.sequenceMode = mode
+}
+
 func (db *DB) RegisterPreallocatedIDs(ids *prealloctableid.PreallocIDs) {
 	db.preallocedIDs = ids
 }
@@ -180,9 +186,37 @@ func (db *DB) CreateDatabase(ctx context.Context, schema *model.DBInfo, supportP
 	return errors.Trace(err)
 }
 
+// sequenceRestoreValue returns the value set to the restored sequence by the mode.
+func sequenceRestoreValue(info *model.TableInfo, mode metautil.SequenceRestoreMode) int64 {
+	value := info.AutoIncID
+	if mode != metautil.SequenceRestoreSkipCache {
+		return value
+	}
+	seq := info.Sequence
+	cache := int64(1)
+	if seq.Cache && seq.CacheValue > 0 {
+		cache = seq.CacheValue
+	}
+	if seq.Increment > 0 {
+		if cache > (seq.MaxValue-value)/seq.Increment {
+			return seq.MaxValue
+		}
+	} else if cache > (value-seq.MinValue)/-seq.Increment {
+		return seq.MinValue
+	}
+	return value + cache*seq.Increment
+}
+
 func (db *DB) restoreSequence(ctx context.Context, table *metautil.Table) error {
 	var restoreMetaSQL string
 	var err error
+	if db.sequenceMode == metautil.SequenceRestoreReset {
+		log.Info("reset the restored sequence to its start value",
+			zap.Stringer("db", table.DB.Name),
+			zap.Stringer("table", table.Info.Name),
+			zap.Int64("start", table.Info.Sequence.Start))
+		return nil
+	}
 	if table.Info.IsSequence() {
 		setValFormat := fmt.Sprintf("do setval(%s.%s, %%d);",
 			utils.EncloseName(table.DB.Name.O),
@@ -223,7 +257,7 @@ func (db *DB) restoreSequence(ctx context.Context, table *metautil.Table) error
 				return errors.Trace(err)
 			}
 		}
-		restoreMetaSQL = fmt.Sprintf(setValFormat, table.Info.AutoIncID)
+		restoreMetaSQL = fmt.Sprintf(setValFormat, sequenceRestoreValue(table.Info, db.sequenceMode))
 		err = db.se.Execute(ctx, restoreMetaSQL)
 	}
 	if err != nil {
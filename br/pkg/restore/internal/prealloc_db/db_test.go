@@ -648,3 +648,31 @@ func TestCreateTableConsistent(t *testing.T) {
 	require.Equal(t, r11, r21)
 	require.Equal(t, r12, r22)
 }
+
+func TestSequenceRestoreValue(t *testing.T) {
+	info := &model.TableInfo{
+		AutoIncID: 1001,
+		Sequence: &model.SequenceInfo{
+			Cache: true, CacheValue: 1000, Increment: 2, MinValue: 1, MaxValue: 5000,
+		},
+	}
+	require.EqualValues(t, 1001, preallocdb.SequenceRestoreValue(info, metautil.SequenceRestoreExact))
+	require.EqualValues(t, 3001, preallocdb.SequenceRestoreValue(info, metautil.SequenceRestoreSkipCache))
+	// the jump stops at the bound.
+	info.Sequence.Increment = 5
+	require.EqualValues(t, 5000, preallocdb.SequenceRestoreValue(info, metautil.SequenceRestoreSkipCache))
+
+	// the sequence without cache jumps by an increment.
+	info.Sequence.Cache = false
+	require.EqualValues(t, 1006, preallocdb.SequenceRestoreValue(info, metautil.SequenceRestoreSkipCache))
+
+	info = &model.TableInfo{
+		AutoIncID: -100,
+		Sequence: &model.SequenceInfo{
+			Cache: true, CacheValue: 10, Increment: -3, MinValue: -120, MaxValue: -1,
+		},
+	}
+	require.EqualValues(t, -120, preallocdb.SequenceRestoreValue(info, metautil.SequenceRestoreSkipCache))
+	info.Sequence.CacheValue = 5
+	require.EqualValues(t, -115, preallocdb.SequenceRestoreValue(info, metautil.SequenceRestoreSkipCache))
+}
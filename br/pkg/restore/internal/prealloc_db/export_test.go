@@ -0,0 +1,5 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package preallocdb
+
+var SequenceRestoreValue = sequenceRestoreValue
@@ -4,14 +4,17 @@ package rawkv
 
 import (
 	"context"
+	"crypto/tls"
 	"time"
 
 	"github.com/pingcap/errors"
-	"github.com/pingcap/tidb/br/pkg/restore/utils"
+	restoreutils "github.com/pingcap/tidb/br/pkg/restore/utils"
+	"github.com/pingcap/tidb/br/pkg/utils"
 	"github.com/pingcap/tidb/pkg/util/hack"
-	"github.com/tikv/client-go/v2/config"
 	"github.com/tikv/client-go/v2/rawkv"
 	"github.com/tikv/pd/client/opt"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 )
 
 // RawkvClient is the interface for rawkv.client
@@ -23,13 +26,21 @@ type RawkvClient interface {
 	Close() error
 }
 
-// NewRawkvClient create a rawkv client.
-func NewRawkvClient(ctx context.Context, pdAddrs []string, security config.Security) (RawkvClient, error) {
-	return rawkv.NewClient(
-		ctx,
-		pdAddrs,
-		security,
-		opt.WithCustomTimeoutOption(10*time.Second))
+// NewRawkvClient create a rawkv client, which connects to PD by pdTLS and to TiKV by tikvTLS. The TLS
+// configs are used on each handshake, so the ones reloading the rotated certificates keep working. Nil
+// means the plain connections.
+func NewRawkvClient(ctx context.Context, pdAddrs []string, pdTLS, tikvTLS *tls.Config) (RawkvClient, error) {
+	pdOptions := []opt.ClientOption{opt.WithCustomTimeoutOption(10 * time.Second)}
+	if pdTLS != nil {
+		// the PD client builds its TLS config only once, so the connections are secured by the dialer.
+		pdOptions = append(pdOptions, opt.WithGRPCDialOptions(grpc.WithContextDialer(utils.TLSDialer(pdTLS))))
+	}
+	clientOptions := []rawkv.ClientOpt{rawkv.WithPDOptions(pdOptions...)}
+	if tikvTLS != nil {
+		clientOptions = append(clientOptions,
+			rawkv.WithGRPCDialOptions(grpc.WithTransportCredentials(credentials.NewTLS(tikvTLS))))
+	}
+	return rawkv.NewClientWithOpts(ctx, pdAddrs, clientOptions...)
 }
 
 type KVPair struct {
@@ -74,7 +85,7 @@ func (c *RawKVBatchClient) SetColumnFamily(columnFamily string) {
 
 // Put puts (key, value) into buffer justly, wait for batch write if the buffer is full.
 func (c *RawKVBatchClient) Put(ctx context.Context, key, value []byte, originTs uint64) error {
-	k := utils.TruncateTS(key)
+	k := restoreutils.TruncateTS(key)
 	sk := hack.String(k)
 	if v, ok := c.kvs[sk]; ok {
 		if v.ts < originTs {
@@ -40,6 +40,7 @@ import (
 	"github.com/pingcap/tidb/br/pkg/conn"
 	"github.com/pingcap/tidb/br/pkg/conn/util"
 	"github.com/pingcap/tidb/br/pkg/encryption"
+	berrors "github.com/pingcap/tidb/br/pkg/errors"
 	"github.com/pingcap/tidb/br/pkg/glue"
 	"github.com/pingcap/tidb/br/pkg/logutil"
 	"github.com/pingcap/tidb/br/pkg/metautil"
@@ -47,6 +48,7 @@ import (
 	"github.com/pingcap/tidb/br/pkg/restore/ingestrec"
 	importclient "github.com/pingcap/tidb/br/pkg/restore/internal/import_client"
 	"github.com/pingcap/tidb/br/pkg/restore/internal/rawkv"
+	"github.com/pingcap/tidb/br/pkg/restore/membudget"
 	snapclient "github.com/pingcap/tidb/br/pkg/restore/snap_client"
 	"github.com/pingcap/tidb/br/pkg/restore/split"
 	"github.com/pingcap/tidb/br/pkg/restore/tiflashrec"
@@ -65,7 +67,6 @@ import (
 	tidbutil "github.com/pingcap/tidb/pkg/util"
 	"github.com/pingcap/tidb/pkg/util/sqlexec"
 	filter "github.com/pingcap/tidb/pkg/util/table-filter"
-	"github.com/tikv/client-go/v2/config"
 	kvutil "github.com/tikv/client-go/v2/util"
 	pd "github.com/tikv/pd/client"
 	pdhttp "github.com/tikv/pd/client/http"
@@ -81,6 +82,10 @@ const maxSplitKeysOnce = 10240
 // rawKVBatchCount specifies the count of entries that the rawkv client puts into TiKV.
 const rawKVBatchCount = 64
 
+// idReservationBatchSize is the number of the global ids claimed at least every time for the downstream
+// ids of the tables.
+const idReservationBatchSize = 256
+
 // LogRestoreManager is a comprehensive wrapper that encapsulates all logic related to log restoration,
 // including concurrency management, checkpoint handling, and file importing for efficient log processing.
 type LogRestoreManager struct {
@@ -147,7 +152,7 @@ func (s *SstRestoreManager) Close(ctx context.Context) {
 
 func NewSstRestoreManager(
 	ctx context.Context,
-	snapFileImporter *snapclient.SnapFileImporter,
+	snapFileImporter restore.BalancedFileImporter,
 	concurrencyPerStore uint,
 	storeCount uint,
 	createCheckpointSessionFn func() (glue.Session, error),
@@ -191,6 +196,8 @@ type LogClient struct {
 
 	rawKVClient *rawkv.RawKVBatchClient
 	storage     storage.ExternalStorage
+	// metaKVExporter is set if the meta kv entries are exported instead of being applied.
+	metaKVExporter *metaKVExporter
 
 	// unsafeSession is not thread-safe.
 	// Currently, it is only utilized in some initialization and post-handle functions.
@@ -203,14 +210,40 @@ type LogClient struct {
 	upstreamClusterID uint64
 
 	// the query to insert rows into table `gc_delete_range`, lack of ts.
-	deleteRangeQuery          []*stream.PreDelRangeQuery
+	deleteRangeQuery          *membudget.SpillableList[*stream.PreDelRangeQuery]
 	deleteRangeQueryCh        chan *stream.PreDelRangeQuery
 	deleteRangeQueryWaitGroup sync.WaitGroup
+	// delRangeBatchLimit limits the statements inserting the delete ranges.
+	delRangeBatchLimit stream.DelRangeBatchLimit
+	// delRangePace paces the statements inserting the delete ranges.
+	delRangePace stream.DelRangePace
+	// sampleVerifier samples the kv files restored to verify, it's nil if the verification is disabled.
+	sampleVerifier *sampleVerifier
+	// rewriteTSAllocator allocates the commit ts of the meta kv entries in batches, it's nil if all of them
+	// are rewritten to currentTS.
+	rewriteTSAllocator *rewriteTSAllocator
+
+	// memBudget limits the memory of the major structures of the restore, it's nil if unlimited.
+	memBudget       *membudget.Budget
+	idMapTracker    *membudget.Tracker
+	metaKVTracker   *membudget.Tracker
+	fileMetaTracker *membudget.Tracker
+
+	// idReservation hands out the downstream IDs of the tables from the ranges claimed from the global ID.
+	idReservation *stream.IDReservation
+	// tableStats collects the restore statistics of the tables by the downstream IDs.
+	tableStats *snapclient.TableStatsCollector
 
 	// checkpoint information for log restore
 	useCheckpoint bool
 }
 
+// TableStats returns the restore statistics of the tables by the downstream IDs, including the compacted
+// SST files and the kv files.
+func (rc *LogClient) TableStats() []snapclient.TableStats {
+	return rc.tableStats.Stats()
+}
+
 // NewRestoreClient returns a new RestoreClient.
 func NewRestoreClient(
 	pdClient pd.Client,
@@ -218,14 +251,48 @@ func NewRestoreClient(
 	tlsConf *tls.Config,
 	keepaliveConf keepalive.ClientParameters,
 ) *LogClient {
-	return &LogClient{
+	rc := &LogClient{
 		pdClient:           pdClient,
 		pdHTTPClient:       pdHTTPCli,
 		tlsConf:            tlsConf,
 		keepaliveConf:      keepaliveConf,
-		deleteRangeQuery:   make([]*stream.PreDelRangeQuery, 0),
 		deleteRangeQueryCh: make(chan *stream.PreDelRangeQuery, 10),
+		delRangeBatchLimit: stream.DefaultDelRangeBatchLimit(),
+		tableStats:         snapclient.NewTableStatsCollector(),
+	}
+	rc.idReservation = stream.NewIDReservation(rc.ClaimGlobalIDs, idReservationBatchSize)
+	rc.SetMemoryBudget(nil)
+	return rc
+}
+
+// SetMemoryBudget sets the memory budget of the restore, nil means unlimited. The delete-range
+// queue is spilled to disk if the budget is exceeded, the meta kv entries are bounded by restoring
+// smaller batches, the ID maps and the file metadata are only tracked. It must be called before
+// RunGCRowsLoader.
+func (rc *LogClient) SetMemoryBudget(b *membudget.Budget) {
+	rc.memBudget = b
+	rc.deleteRangeQuery = membudget.NewSpillableList(b, "delete range queue", sizeOfDelRangeQuery)
+	rc.idMapTracker = b.NewTracker("id map", nil)
+	rc.metaKVTracker = b.NewTracker("meta kv entries", nil)
+	rc.fileMetaTracker = b.NewTracker("file metadata", nil)
+}
+
+func sizeOfDelRangeQuery(query *stream.PreDelRangeQuery) int64 {
+	// the size of the struct and the headers of its fields.
+	size := int64(64 + len(query.Sql))
+	for _, params := range query.ParamsList {
+		size += int64(48 + len(params.StartKey) + len(params.EndKey))
+	}
+	return size
+}
+
+func sizeOfKvEntries(entries []*KvEntryWithTS) int64 {
+	var size int64
+	for _, e := range entries {
+		// the size of KvEntryWithTS and the headers of the key and value.
+		size += int64(64 + len(e.E.Key) + len(e.E.Value))
 	}
+	return size
 }
 
 // Close a client.
@@ -247,6 +314,12 @@ func (rc *LogClient) Close(ctx context.Context) {
 	if rc.rawKVClient != nil {
 		rc.rawKVClient.Close()
 	}
+	if rc.metaKVExporter != nil {
+		rc.metaKVExporter.close()
+	}
+	if err := rc.deleteRangeQuery.Close(); err != nil {
+		log.Warn("failed to close the delete range queue", zap.Error(err))
+	}
 	log.Info("Restore client closed")
 }
 
@@ -317,9 +390,9 @@ func (rc *LogClient) RestoreCompactedSstFiles(
 func (rc *LogClient) SetRawKVBatchClient(
 	ctx context.Context,
 	pdAddrs []string,
-	security config.Security,
+	pdTLS, tikvTLS *tls.Config,
 ) error {
-	rawkvClient, err := rawkv.NewRawkvClient(ctx, pdAddrs, security)
+	rawkvClient, err := rawkv.NewRawkvClient(ctx, pdAddrs, pdTLS, tikvTLS)
 	if err != nil {
 		return errors.Trace(err)
 	}
@@ -441,7 +514,7 @@ func (rc *LogClient) InitClients(
 	}
 	rc.sstRestoreManager, err = NewSstRestoreManager(
 		ctx,
-		snapFileImporter,
+		snapclient.NewTableStatsImporter(snapFileImporter, rc.tableStats),
 		concurrencyPerStore,
 		uint(len(stores)),
 		createSessionFn,
@@ -525,6 +598,15 @@ func (rc *LogClient) GetMigrations(ctx context.Context) ([]*backuppb.Migration,
 	return migs.ListAll(), nil
 }
 
+// LoadTableFilterHistory loads the history of the table filter of the log backup task.
+func (rc *LogClient) LoadTableFilterHistory(ctx context.Context) (*stream.TableFilterHistory, error) {
+	changes, err := stream.LoadTableFilterChanges(ctx, rc.storage)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return stream.NewTableFilterHistory(changes)
+}
+
 func (rc *LogClient) InstallLogFileManager(ctx context.Context, startTS, restoreTS uint64, metadataDownloadBatchSize uint,
 	encryptionManager *encryption.Manager) error {
 	init := LogFileManagerInit{
@@ -538,6 +620,7 @@ func (rc *LogClient) InstallLogFileManager(ctx context.Context, startTS, restore
 		},
 		MetadataDownloadBatchSize: metadataDownloadBatchSize,
 		EncryptionManager:         encryptionManager,
+		MemTracker:                rc.fileMetaTracker,
 	}
 	var err error
 	rc.LogFileManager, err = CreateLogFileManager(ctx, init)
@@ -766,6 +849,15 @@ func (rc *LogClient) RestoreKVFiles(
 					summary.CollectInt("File", len(files))
 
 					if err == nil {
+						if rc.sampleVerifier != nil {
+							rc.sampleVerifier.sample(files, rule)
+						}
+						stats := snapclient.TableStats{PhysicalID: rule.NewTableID, Files: len(files), KVs: uint64(kvCount),
+							Bytes: size, Duration: time.Since(fileStart)}
+						if rule.NewTableID != files[0].TableId {
+							stats.RewrittenKVs = stats.KVs
+						}
+						rc.tableStats.Add(stats)
 						filenames := make([]string, 0, len(files))
 						for _, f := range files {
 							filenames = append(filenames, f.Path+", ")
@@ -919,6 +1011,8 @@ type BuildTableMappingManagerConfig struct {
 	FullBackupStorage *FullBackupStorageConfig
 	CipherInfo        *backuppb.CipherInfo
 	Files             []*backuppb.DataFileInfo
+	// MergePartitions matches the tables whose partitions are merged by the snapshot restore.
+	MergePartitions filter.Filter
 }
 
 const UnsafePITRLogRestoreStartBeforeAnyUpstreamUserDDL = "UNSAFE_PITR_LOG_RESTORE_START_BEFORE_ANY_UPSTREAM_USER_DDL"
@@ -968,10 +1062,15 @@ func (rc *LogClient) generateDBReplacesFromFullBackupStorage(
 			continue
 		}
 
+		partitionMap := restoreutils.GetPartitionIDMap(newTableInfo, t.Info)
+		if cfg.MergePartitions != nil && newTableInfo.Partition == nil &&
+			cfg.MergePartitions.MatchTable(t.DB.Name.O, t.Info.Name.O) {
+			partitionMap = restoreutils.GetMergedPartitionIDMap(newTableInfo, t.Info)
+		}
 		dbReplace.TableMap[t.Info.ID] = &stream.TableReplace{
 			Name:         newTableInfo.Name.O,
 			TableID:      newTableInfo.ID,
-			PartitionMap: restoreutils.GetPartitionIDMap(newTableInfo, t.Info),
+			PartitionMap: partitionMap,
 			IndexMap:     restoreutils.GetIndexIDMap(newTableInfo, t.Info),
 		}
 	}
@@ -1052,14 +1151,18 @@ func (rc *LogClient) BuildTableMappingManager(
 		}()...)
 	}
 
-	tableMappingManager := stream.NewTableMappingManager(dbReplaces, rc.GenGlobalID)
+	tableMappingManager := stream.NewTableMappingManager(dbReplaces, rc.idReservation.GenGlobalID)
 
 	// not loaded from previously saved, need to iter meta kv and build and save the map
 	if needConstructIdMap {
 		if err = rc.IterMetaKVToBuildAndSaveIdMap(ctx, tableMappingManager, cfg.Files); err != nil {
 			return nil, errors.Trace(err)
 		}
+		log.Info("built the id maps by the reserved ids", zap.Any("ranges", rc.idReservation.Ranges()),
+			zap.Int64("unused", rc.idReservation.Unused()))
 	}
+	rc.idMapTracker.Release(rc.idMapTracker.Used())
+	rc.idMapTracker.Consume(tableMappingManager.MemoryUsage())
 
 	return tableMappingManager, nil
 }
@@ -1072,7 +1175,14 @@ func SortMetaKVFiles(files []*backuppb.DataFileInfo) []*backuppb.DataFileInfo {
 		if c := cmp.Compare(i.GetMaxTs(), j.GetMaxTs()); c != 0 {
 			return c
 		}
-		return cmp.Compare(i.GetResolvedTs(), j.GetResolvedTs())
+		if c := cmp.Compare(i.GetResolvedTs(), j.GetResolvedTs()); c != 0 {
+			return c
+		}
+		// make the order deterministic, so the batches of the meta kv apply are the same on resume.
+		if c := cmp.Compare(i.GetPath(), j.GetPath()); c != 0 {
+			return c
+		}
+		return cmp.Compare(i.GetRangeOffset(), j.GetRangeOffset())
 	})
 	return files
 }
@@ -1100,6 +1210,7 @@ func (rc *LogClient) RestoreAndRewriteMetaKVFiles(
 			// this should happen abnormally.
 			// only do some preventive checks here.
 			log.Warn("detected delete file of meta key, skip it", zap.Any("file", f))
+			rc.fileMetaTracker.Release(int64(f.Size()))
 			continue
 		}
 		if f.Cf == stream.DefaultCF {
@@ -1114,15 +1225,51 @@ func (rc *LogClient) RestoreAndRewriteMetaKVFiles(
 		zap.Int("default files", len(filesInDefaultCF)),
 		zap.Int("write files", len(filesInWriteCF)))
 
+	// download the files ahead, the files of each cf are read in order of ts.
+	prefetchers := map[string]*logFilePrefetcher{
+		stream.DefaultCF: rc.prefetchLogFiles(ctx, filesInDefaultCF),
+		stream.WriteCF:   rc.prefetchLogFiles(ctx, filesInWriteCF),
+	}
+	defer func() {
+		for _, p := range prefetchers {
+			p.close()
+		}
+	}()
+	savepoint, err := rc.newMetaKVSavepoint(ctx, filesInDefaultCF, filesInWriteCF)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	restoreBatch := func(
+		ctx context.Context,
+		files []*backuppb.DataFileInfo,
+		schemasReplace *stream.SchemasReplace,
+		kvEntries []*KvEntryWithTS,
+		filterTS uint64,
+		updateStats func(kvCount uint64, size uint64),
+		progressInc func(),
+		cf string,
+	) ([]*KvEntryWithTS, error) {
+		durable := savepoint.nextBatch()
+		nextKvEntries, err := rc.restoreBatchMetaKVFiles(ctx, prefetchers[cf].read, files, schemasReplace, kvEntries,
+			filterTS, updateStats, progressInc, cf, durable)
+		if err != nil || (len(files) == 0 && len(kvEntries) == 0) {
+			return nextKvEntries, errors.Trace(err)
+		}
+		// the metadata of the files is tracked since the DDL files are loaded, it's no longer needed.
+		rc.fileMetaTracker.Release(sizeOfFileMetas(files))
+		return nextKvEntries, errors.Trace(savepoint.advance(ctx, cf, filterTS))
+	}
+
 	// run the rewrite and restore meta-kv into TiKV cluster.
-	if err := RestoreMetaKVFilesWithBatchMethod(
+	if err := restoreMetaKVFilesWithBatchMethod(
 		ctx,
+		rc.metaKVBatchSize(),
 		filesInDefaultCF,
 		filesInWriteCF,
 		schemasReplace,
 		updateStats,
 		progressInc,
-		rc.RestoreBatchMetaKVFiles,
+		restoreBatch,
 	); err != nil {
 		return errors.Trace(err)
 	}
@@ -1213,8 +1360,14 @@ func (rc *LogClient) iterAndBuildIDMap(
 	fs []*backuppb.DataFileInfo,
 	tableMappingManager *stream.TableMappingManager,
 ) error {
+	prefetcher := rc.prefetchLogFiles(ctx, fs)
+	defer prefetcher.close()
 	for _, f := range fs {
-		entries, _, err := rc.ReadAllEntries(ctx, f, math.MaxUint64)
+		buff, err := prefetcher.read(ctx, f)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		entries, _, err := rc.parseAllEntries(f, buff, math.MaxUint64)
 		if err != nil {
 			return errors.Trace(err)
 		}
@@ -1228,6 +1381,23 @@ func (rc *LogClient) iterAndBuildIDMap(
 	return nil
 }
 
+// metaKVBatchSize returns the max size of the meta kv files restored in a batch. The entries of a batch
+// are held in memory, so the batch is bounded by a quarter of the memory budget if it's limited.
+func (rc *LogClient) metaKVBatchSize() uint64 {
+	if limit := rc.memBudget.Limit() / 4; limit > 0 && uint64(limit) < MetaKVBatchSize {
+		return uint64(limit)
+	}
+	return MetaKVBatchSize
+}
+
+func sizeOfFileMetas(files []*backuppb.DataFileInfo) int64 {
+	var size int64
+	for _, f := range files {
+		size += int64(f.Size())
+	}
+	return size
+}
+
 func RestoreMetaKVFilesWithBatchMethod(
 	ctx context.Context,
 	defaultFiles []*backuppb.DataFileInfo,
@@ -1245,6 +1415,31 @@ func RestoreMetaKVFilesWithBatchMethod(
 		progressInc func(),
 		cf string,
 	) ([]*KvEntryWithTS, error),
+) error {
+	return restoreMetaKVFilesWithBatchMethod(ctx, MetaKVBatchSize, defaultFiles, writeFiles, schemasReplace,
+		updateStats, progressInc, restoreBatch)
+}
+
+// restoreMetaKVFilesWithBatchMethod is like RestoreMetaKVFilesWithBatchMethod, the files of a batch are
+// at most batchLimit bytes unless a single file is larger.
+func restoreMetaKVFilesWithBatchMethod(
+	ctx context.Context,
+	batchLimit uint64,
+	defaultFiles []*backuppb.DataFileInfo,
+	writeFiles []*backuppb.DataFileInfo,
+	schemasReplace *stream.SchemasReplace,
+	updateStats func(kvCount uint64, size uint64),
+	progressInc func(),
+	restoreBatch func(
+		ctx context.Context,
+		files []*backuppb.DataFileInfo,
+		schemasReplace *stream.SchemasReplace,
+		kvEntries []*KvEntryWithTS,
+		filterTS uint64,
+		updateStats func(kvCount uint64, size uint64),
+		progressInc func(),
+		cf string,
+	) ([]*KvEntryWithTS, error),
 ) error {
 	// the average size of each KV is 2560 Bytes
 	// kvEntries is kvs left by the previous batch
@@ -1268,7 +1463,7 @@ func RestoreMetaKVFilesWithBatchMethod(
 			rangeMin = f.MinTs
 			batchSize = f.Length
 		} else {
-			if f.MinTs <= rangeMax && batchSize+f.Length <= MetaKVBatchSize {
+			if f.MinTs <= rangeMax && batchSize+f.Length <= batchLimit {
 				rangeMin = min(rangeMin, f.MinTs)
 				rangeMax = max(rangeMax, f.MaxTs)
 				batchSize += f.Length
@@ -1325,6 +1520,24 @@ func (rc *LogClient) RestoreBatchMetaKVFiles(
 	updateStats func(kvCount uint64, size uint64),
 	progressInc func(),
 	cf string,
+) ([]*KvEntryWithTS, error) {
+	return rc.restoreBatchMetaKVFiles(ctx, rc.readLogFile, files, schemasReplace, kvEntries, filterTS,
+		updateStats, progressInc, cf, false)
+}
+
+// restoreBatchMetaKVFiles restores the batch of the meta kv files like RestoreBatchMetaKVFiles, the content
+// of the files is read by readFile. If the batch is durable, the entries are only rewritten but not put.
+func (rc *LogClient) restoreBatchMetaKVFiles(
+	ctx context.Context,
+	readFile func(ctx context.Context, file Log) ([]byte, error),
+	files []*backuppb.DataFileInfo,
+	schemasReplace *stream.SchemasReplace,
+	kvEntries []*KvEntryWithTS,
+	filterTS uint64,
+	updateStats func(kvCount uint64, size uint64),
+	progressInc func(),
+	cf string,
+	durable bool,
 ) ([]*KvEntryWithTS, error) {
 	nextKvEntries := make([]*KvEntryWithTS, 0)
 	curKvEntries := make([]*KvEntryWithTS, 0)
@@ -1343,13 +1556,19 @@ func (rc *LogClient) RestoreBatchMetaKVFiles(
 
 	// read all of entries from files.
 	for _, f := range files {
-		es, nextEs, err := rc.ReadAllEntries(ctx, f, filterTS)
+		buff, err := readFile(ctx, f)
+		if err != nil {
+			return nextKvEntries, errors.Trace(err)
+		}
+		es, nextEs, err := rc.parseAllEntries(f, buff, filterTS)
 		if err != nil {
 			return nextKvEntries, errors.Trace(err)
 		}
 
 		curKvEntries = append(curKvEntries, es...)
 		nextKvEntries = append(nextKvEntries, nextEs...)
+		// the entries left to the next batch keep tracked until they are restored.
+		rc.metaKVTracker.Consume(sizeOfKvEntries(es) + sizeOfKvEntries(nextEs))
 	}
 
 	// sort these entries.
@@ -1358,7 +1577,8 @@ func (rc *LogClient) RestoreBatchMetaKVFiles(
 	})
 
 	// restore these entries with rawPut() method.
-	kvCount, size, err := rc.restoreMetaKvEntries(ctx, schemasReplace, curKvEntries, cf)
+	kvCount, size, err := rc.restoreMetaKvEntries(ctx, schemasReplace, curKvEntries, cf, durable)
+	rc.metaKVTracker.Release(sizeOfKvEntries(curKvEntries))
 	if err != nil {
 		return nextKvEntries, errors.Trace(err)
 	}
@@ -1375,6 +1595,7 @@ func (rc *LogClient) restoreMetaKvEntries(
 	sr *stream.SchemasReplace,
 	entries []*KvEntryWithTS,
 	columnFamily string,
+	durable bool,
 ) (uint64, uint64, error) {
 	var (
 		kvCount uint64
@@ -1382,12 +1603,27 @@ func (rc *LogClient) restoreMetaKvEntries(
 	)
 
 	rc.rawKVClient.SetColumnFamily(columnFamily)
+	// the ts is only allocated for the entries put into the cluster.
+	allocateTS := rc.rewriteTSAllocator != nil && columnFamily == stream.WriteCF && !durable && rc.metaKVExporter == nil
+	if allocateTS {
+		defer func(rewriteTS uint64) { sr.RewriteTS = rewriteTS }(sr.RewriteTS)
+	}
+	// the rewritten entries are referenced by the raw kv client until they're put, so the arena is
+	// released only after all of them are put successfully.
+	arena := stream.AcquireKvEntryArena()
 
 	for _, entry := range entries {
 		log.Debug("before rewrte entry", zap.Uint64("key-ts", entry.Ts), zap.Int("key-len", len(entry.E.Key)),
 			zap.Int("value-len", len(entry.E.Value)), zap.ByteString("key", entry.E.Key))
 
-		newEntry, err := sr.RewriteKvEntry(&entry.E, columnFamily)
+		if allocateTS {
+			ts, err := rc.rewriteTSAllocator.next(ctx, rc.currentTS)
+			if err != nil {
+				return 0, 0, errors.Trace(err)
+			}
+			sr.RewriteTS = ts
+		}
+		newEntry, err := sr.RewriteKvEntryTo(arena, &entry.E, columnFamily)
 		if err != nil {
 			log.Error("rewrite txn entry failed", zap.Int("klen", len(entry.E.Key)),
 				logutil.Key("txn-key", entry.E.Key))
@@ -1401,6 +1637,18 @@ func (rc *LogClient) restoreMetaKvEntries(
 		failpoint.Inject("failed-to-restore-metakv", func(_ failpoint.Value) {
 			failpoint.Return(0, 0, errors.Errorf("failpoint: failed to restore metakv"))
 		})
+		if rc.metaKVExporter != nil {
+			if err := rc.metaKVExporter.export(&entry.E, newEntry, columnFamily, entry.Ts); err != nil {
+				return 0, 0, errors.Trace(err)
+			}
+			kvCount++
+			size += uint64(len(newEntry.Key) + len(newEntry.Value))
+			continue
+		}
+		if durable {
+			// the entry has been put by the previous run.
+			continue
+		}
 		if err := rc.rawKVClient.Put(ctx, newEntry.Key, newEntry.Value, entry.Ts); err != nil {
 			return 0, 0, errors.Trace(err)
 		}
@@ -1414,7 +1662,13 @@ func (rc *LogClient) restoreMetaKvEntries(
 		size += uint64(len(newEntry.Key) + len(newEntry.Value))
 	}
 
-	return kvCount, size, rc.rawKVClient.PutRest(ctx)
+	if rc.metaKVExporter == nil {
+		if err := rc.rawKVClient.PutRest(ctx); err != nil {
+			return 0, 0, errors.Trace(err)
+		}
+	}
+	arena.Release()
+	return kvCount, size, nil
 }
 
 // GenGlobalID generates a global id by transaction way.
@@ -1437,6 +1691,29 @@ func (rc *LogClient) GenGlobalID(ctx context.Context) (int64, error) {
 	return id, err
 }
 
+// ClaimGlobalIDs claims n contiguous global ids by transaction way, and returns the first one.
+func (rc *LogClient) ClaimGlobalIDs(ctx context.Context, n int) (int64, error) {
+	var origID int64
+	ctx = kv.WithInternalSourceType(ctx, kv.InternalTxnBR)
+	err := kv.RunInNewTxn(
+		ctx,
+		rc.GetDomain().Store(),
+		true,
+		func(ctx context.Context, txn kv.Transaction) error {
+			var e error
+			origID, e = meta.NewMutator(txn).AdvanceGlobalIDs(n)
+			return e
+		})
+	return origID + 1, errors.Trace(err)
+}
+
+// IDReservation returns the reservation handing out the downstream ids of the tables, the ids of the
+// databases, tables and partitions created by the restore are allocated from it, so that they never
+// collide with the tables created concurrently when restoring into a live cluster.
+func (rc *LogClient) IDReservation() *stream.IDReservation {
+	return rc.idReservation
+}
+
 // GenGlobalIDs generates several global ids by transaction way.
 func (rc *LogClient) GenGlobalIDs(ctx context.Context, n int) ([]int64, error) {
 	ids := make([]int64, 0)
@@ -1599,7 +1876,7 @@ func (rc *LogClient) generateRepairIngestIndexSQLs(
 	if err := ingestRecorder.UpdateIndexInfo(rc.dom.InfoSchema()); err != nil {
 		return sqls, false, errors.Trace(err)
 	}
-	if err := ingestRecorder.Iterate(func(_, indexID int64, info *ingestrec.IngestIndexInfo) error {
+	if err := ingestRecorder.Iterate(func(tableID, indexID int64, info *ingestrec.IngestIndexInfo) error {
 		var (
 			addSQL  strings.Builder
 			addArgs []any = make([]any, 0, 5+len(info.ColumnArgs))
@@ -1637,6 +1914,8 @@ func (rc *LogClient) generateRepairIngestIndexSQLs(
 		}
 
 		sqls = append(sqls, checkpoint.CheckpointIngestIndexRepairSQL{
+			TableID:    tableID,
+			JobID:      info.JobID,
 			IndexID:    indexID,
 			SchemaName: info.SchemaName,
 			TableName:  info.TableName,
@@ -1660,45 +1939,97 @@ func (rc *LogClient) generateRepairIngestIndexSQLs(
 	return sqls, false, nil
 }
 
-// RepairIngestIndex drops the indexes from IngestRecorder and re-add them.
+// RepairIngestIndex drops the indexes from IngestRecorder and re-add them. The states of the repairs are
+// set into the IngestRecorder, an index failed to repair doesn't stop the others.
 func (rc *LogClient) RepairIngestIndex(ctx context.Context, ingestRecorder *ingestrec.IngestRecorder, g glue.Glue) error {
 	sqls, fromCheckpoint, err := rc.generateRepairIngestIndexSQLs(ctx, ingestRecorder)
 	if err != nil {
 		return errors.Trace(err)
 	}
+	return errors.Trace(rc.repairIngestIndexes(ctx, sqls, fromCheckpoint, g,
+		func(sql checkpoint.CheckpointIngestIndexRepairSQL, state ingestrec.IngestIndexState, err error) {
+			ingestRecorder.SetState(sql.TableID, sql.IndexID, state, err)
+		}))
+}
+
+// RepairIngestIndexFromCheckpoint re-drives the repairs of the ingest indexes saved in the checkpoint by
+// a previous log restore, only the ones not repaired yet are re-attempted.
+func (rc *LogClient) RepairIngestIndexFromCheckpoint(ctx context.Context, g glue.Glue) ([]ingestrec.IngestIndexRecord, error) {
+	if !checkpoint.ExistsCheckpointIngestIndexRepairSQLs(ctx, rc.dom) {
+		return nil, errors.Annotate(berrors.ErrInvalidArgument,
+			"no checkpoint of the ingest index repairs, the log restore may not reach the repairs or not use the checkpoint")
+	}
+	checkpointSQLs, err := checkpoint.LoadCheckpointIngestIndexRepairSQLs(ctx, rc.unsafeSession.GetSessionCtx().GetRestrictedSQLExecutor())
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	records := make([]ingestrec.IngestIndexRecord, 0, len(checkpointSQLs.SQLs))
+	err = rc.repairIngestIndexes(ctx, checkpointSQLs.SQLs, true, g,
+		func(sql checkpoint.CheckpointIngestIndexRepairSQL, state ingestrec.IngestIndexState, err error) {
+			record := ingestrec.IngestIndexRecord{
+				TableID:    sql.TableID,
+				IndexID:    sql.IndexID,
+				JobID:      sql.JobID,
+				SchemaName: sql.SchemaName.O,
+				TableName:  sql.TableName.O,
+				IndexName:  sql.IndexName,
+				State:      state,
+			}
+			if err != nil {
+				record.Error = err.Error()
+			}
+			records = append(records, record)
+		})
+	return records, errors.Trace(err)
+}
 
+// repairIngestIndexes executes the SQLs repairing the ingest indexes, onRepaired is called with the state
+// of each repair. The indexes failed to repair don't stop the others, the first error is returned at last.
+func (rc *LogClient) repairIngestIndexes(
+	ctx context.Context,
+	sqls []checkpoint.CheckpointIngestIndexRepairSQL,
+	fromCheckpoint bool,
+	g glue.Glue,
+	onRepaired func(sql checkpoint.CheckpointIngestIndexRepairSQL, state ingestrec.IngestIndexState, err error),
+) error {
 	info := rc.dom.InfoSchema()
 	console := glue.GetConsole(g)
-NEXTSQL:
+	var (
+		firstErr error
+		failed   int
+	)
 	for _, sql := range sqls {
+		if ctx.Err() != nil {
+			return errors.Trace(ctx.Err())
+		}
 		progressTitle := fmt.Sprintf("repair ingest index %s for table %s.%s", sql.IndexName, sql.SchemaName, sql.TableName)
 
-		tableInfo, err := info.TableByName(ctx, sql.SchemaName, sql.TableName)
-		if err != nil {
-			return errors.Trace(err)
-		}
-		oldIndexIDFound := false
-		if fromCheckpoint {
-			for _, idx := range tableInfo.Indices() {
-				indexInfo := idx.Meta()
-				if indexInfo.ID == sql.IndexID {
-					// the original index id is not dropped
-					oldIndexIDFound = true
-					break
-				}
-				// what if index's state is not public?
-				if indexInfo.Name.O == sql.IndexName {
-					// find the same name index, but not the same index id,
-					// which means the repaired index id is created
-					if _, err := fmt.Fprintf(console.Out(), "%s ... %s\n", progressTitle, color.HiGreenString("SKIPPED DUE TO CHECKPOINT MODE")); err != nil {
-						return errors.Trace(err)
+		if err := func(sql checkpoint.CheckpointIngestIndexRepairSQL) error {
+			tableInfo, err := info.TableByName(ctx, sql.SchemaName, sql.TableName)
+			if err != nil {
+				return errors.Trace(err)
+			}
+			oldIndexIDFound := false
+			if fromCheckpoint {
+				for _, idx := range tableInfo.Indices() {
+					indexInfo := idx.Meta()
+					if indexInfo.ID == sql.IndexID {
+						// the original index id is not dropped
+						oldIndexIDFound = true
+						break
+					}
+					// what if index's state is not public?
+					if indexInfo.Name.O == sql.IndexName {
+						// find the same name index, but not the same index id,
+						// which means the repaired index id is created
+						if _, err := fmt.Fprintf(console.Out(), "%s ... %s\n", progressTitle, color.HiGreenString("SKIPPED DUE TO CHECKPOINT MODE")); err != nil {
+							return errors.Trace(err)
+						}
+						return nil
 					}
-					continue NEXTSQL
 				}
 			}
-		}
 
-		if err := func(sql checkpoint.CheckpointIngestIndexRepairSQL) error {
 			w := console.StartProgressBar(progressTitle, glue.OnlyOneTask)
 			defer w.Close()
 
@@ -1732,13 +2063,35 @@ NEXTSQL:
 			}
 			return nil
 		}(sql); err != nil {
-			return errors.Trace(err)
+			log.Warn("failed to repair the ingest index", zap.String("category", "ingest"),
+				zap.Stringer("db", sql.SchemaName), zap.Stringer("table", sql.TableName),
+				zap.String("index", sql.IndexName), zap.Error(err))
+			onRepaired(sql, ingestrec.IngestIndexFailed, err)
+			failed++
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
 		}
+		onRepaired(sql, ingestrec.IngestIndexRepaired, nil)
 	}
 
+	if firstErr != nil {
+		return errors.Annotatef(firstErr, "failed to repair %d of %d ingest indexes", failed, len(sqls))
+	}
 	return nil
 }
 
+// SetDeleteRangeBatchLimit sets the limit of the statements inserting the delete ranges.
+func (rc *LogClient) SetDeleteRangeBatchLimit(limit stream.DelRangeBatchLimit) {
+	rc.delRangeBatchLimit = limit
+}
+
+// SetDeleteRangePace sets the pace of the statements inserting the delete ranges.
+func (rc *LogClient) SetDeleteRangePace(pace stream.DelRangePace) {
+	rc.delRangePace = pace
+}
+
 func (rc *LogClient) RecordDeleteRange(sql *stream.PreDelRangeQuery) {
 	rc.deleteRangeQueryCh <- sql
 }
@@ -1757,62 +2110,102 @@ func (rc *LogClient) RunGCRowsLoader(ctx context.Context) {
 				if !ok {
 					return
 				}
-				rc.deleteRangeQuery = append(rc.deleteRangeQuery, query)
+				rc.deleteRangeQuery.Append(query)
 			}
 		}
 	}()
 }
 
-// InsertGCRows insert the querys into table `gc_delete_range`
-func (rc *LogClient) InsertGCRows(ctx context.Context) error {
+// InsertGCRows insert the querys into table `gc_delete_range`, the statements are paced by the
+// delRangePace so the GC workers aren't overloaded.
+func (rc *LogClient) InsertGCRows(ctx context.Context, g glue.Glue) error {
 	close(rc.deleteRangeQueryCh)
 	rc.deleteRangeQueryWaitGroup.Wait()
 	ts, err := restore.GetTSWithRetry(ctx, rc.pdClient)
 	if err != nil {
 		return errors.Trace(err)
 	}
+	statements := 0
+	if err := rc.deleteRangeQuery.Iterate(func(query *stream.PreDelRangeQuery) error {
+		statements += len(query.Batches(rc.delRangeBatchLimit))
+		return nil
+	}); err != nil {
+		return errors.Trace(err)
+	}
+	sleep := rc.delRangePace.SleepBetween(statements)
+	log.Info("insert the delete ranges", zap.Int("statements", statements), zap.Duration("sleep", sleep))
+	w := glue.GetConsole(g).StartProgressBar("Insert Delete Ranges", statements)
+	defer w.Close()
+	executed := 0
 	jobIDMap := make(map[int64]int64)
-	for _, query := range rc.deleteRangeQuery {
-		paramsList := make([]any, 0, len(query.ParamsList)*5)
-		for _, params := range query.ParamsList {
-			newJobID, exists := jobIDMap[params.JobID]
-			if !exists {
-				newJobID, err = rc.GenGlobalID(ctx)
-				if err != nil {
-					return errors.Trace(err)
+	err = rc.deleteRangeQuery.Iterate(func(query *stream.PreDelRangeQuery) error {
+		// the statement of a job dropping thousands of partitions is split into the batches, the job IDs are
+		// allocated across the batches so the delete ranges of a job still share the job ID.
+		batches := query.Batches(rc.delRangeBatchLimit)
+		if len(batches) > 1 {
+			log.Info("split the delete ranges into batches",
+				zap.Int("ranges", len(query.ParamsList)), zap.Int("batches", len(batches)))
+		}
+		for _, batch := range batches {
+			if executed > 0 && sleep > 0 {
+				select {
+				case <-ctx.Done():
+					return errors.Trace(ctx.Err())
+				case <-time.After(sleep):
+				}
+			}
+			paramsList := make([]any, 0, len(batch.ParamsList)*5)
+			for _, params := range batch.ParamsList {
+				newJobID, exists := jobIDMap[params.JobID]
+				if !exists {
+					newJobID, err = rc.GenGlobalID(ctx)
+					if err != nil {
+						return errors.Trace(err)
+					}
+					jobIDMap[params.JobID] = newJobID
 				}
-				jobIDMap[params.JobID] = newJobID
+				log.Info("insert into the delete range",
+					zap.Int64("jobID", newJobID),
+					zap.Int64("elemID", params.ElemID),
+					zap.String("startKey", params.StartKey),
+					zap.String("endKey", params.EndKey),
+					zap.Uint64("ts", ts))
+				// (job_id, elem_id, start_key, end_key, ts)
+				paramsList = append(paramsList, newJobID, params.ElemID, params.StartKey, params.EndKey, ts)
 			}
-			log.Info("insert into the delete range",
-				zap.Int64("jobID", newJobID),
-				zap.Int64("elemID", params.ElemID),
-				zap.String("startKey", params.StartKey),
-				zap.String("endKey", params.EndKey),
-				zap.Uint64("ts", ts))
-			// (job_id, elem_id, start_key, end_key, ts)
-			paramsList = append(paramsList, newJobID, params.ElemID, params.StartKey, params.EndKey, ts)
-		}
-		if len(paramsList) > 0 {
-			// trim the ',' behind the query.Sql if exists
-			// that's when the rewrite rule of the last table id is not exist
-			sql := strings.TrimSuffix(query.Sql, ",")
-			if err := rc.unsafeSession.ExecuteInternal(ctx, sql, paramsList...); err != nil {
+			if err := rc.unsafeSession.ExecuteInternal(ctx, batch.Sql, paramsList...); err != nil {
 				return errors.Trace(err)
 			}
+			executed++
+			w.Inc()
 		}
+		return nil
+	})
+	if err != nil {
+		return errors.Trace(err)
 	}
-	return nil
+	return errors.Trace(w.Wait(ctx))
 }
 
 // only for unit test
 func (rc *LogClient) GetGCRows() []*stream.PreDelRangeQuery {
 	close(rc.deleteRangeQueryCh)
 	rc.deleteRangeQueryWaitGroup.Wait()
-	return rc.deleteRangeQuery
+	queries := make([]*stream.PreDelRangeQuery, 0, rc.deleteRangeQuery.Len())
+	_ = rc.deleteRangeQuery.Iterate(func(query *stream.PreDelRangeQuery) error {
+		queries = append(queries, query)
+		return nil
+	})
+	return queries
 }
 
 const PITRIdMapBlockSize int = 524288
 
+// SaveIDMap saves the id mapping information, e.g. after the ids of the missing partitions are allocated.
+func (rc *LogClient) SaveIDMap(ctx context.Context, manager *stream.TableMappingManager) error {
+	return rc.saveIDMap(ctx, manager)
+}
+
 // saveIDMap saves the id mapping information.
 func (rc *LogClient) saveIDMap(
 	ctx context.Context,
@@ -1872,24 +2265,17 @@ func (rc *LogClient) FailpointDoChecksumForLogRestore(
 		TTL:      utils.DefaultBRGCSafePointTTL,
 		ID:       utils.MakeSafePointID(),
 	}
-	cctx, gcSafePointKeeperCancel := context.WithCancel(ctx)
+	gcSafePointLease, err := utils.GlobalSafePointManager().Register(ctx, pdClient, nil, "log restore checksum", sp)
+	if err != nil {
+		return errors.Trace(err)
+	}
 	defer func() {
-		log.Info("start to remove gc-safepoint keeper")
-		// close the gc safe point keeper at first
-		gcSafePointKeeperCancel()
-		// set the ttl to 0 to remove the gc-safe-point
-		sp.TTL = 0
-		if err := utils.UpdateServiceSafePoint(ctx, pdClient, sp); err != nil {
+		if err := gcSafePointLease.Release(ctx, false); err != nil {
 			log.Warn("failed to update service safe point, backup may fail if gc triggered",
 				zap.Error(err),
 			)
 		}
-		log.Info("finish removing gc-safepoint keeper")
 	}()
-	err = utils.StartServiceSafePointKeeper(cctx, pdClient, sp)
-	if err != nil {
-		return errors.Trace(err)
-	}
 
 	eg, ectx := errgroup.WithContext(ctx)
 	pool := tidbutil.NewWorkerPool(4, "checksum for log restore")
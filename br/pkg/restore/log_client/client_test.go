@@ -18,6 +18,8 @@ import (
 	"context"
 	"fmt"
 	"math"
+	"os"
+	"path/filepath"
 	"sync"
 	"testing"
 	"time"
@@ -32,6 +34,7 @@ import (
 	"github.com/pingcap/tidb/br/pkg/mock"
 	"github.com/pingcap/tidb/br/pkg/restore"
 	logclient "github.com/pingcap/tidb/br/pkg/restore/log_client"
+	"github.com/pingcap/tidb/br/pkg/restore/membudget"
 	"github.com/pingcap/tidb/br/pkg/restore/split"
 	"github.com/pingcap/tidb/br/pkg/restore/utils"
 	"github.com/pingcap/tidb/br/pkg/stream"
@@ -106,7 +109,45 @@ func TestDeleteRangeQueryExec(t *testing.T) {
 		client.RecordDeleteRange(query)
 	}
 
-	require.NoError(t, client.InsertGCRows(ctx))
+	require.NoError(t, client.InsertGCRows(ctx, g))
+}
+
+func TestDeleteRangeQueryExecInBatches(t *testing.T) {
+	ctx := context.Background()
+	m := mc
+	g := gluetidb.New()
+	client := logclient.NewRestoreClient(
+		split.NewFakePDClient(nil, false, nil), nil, nil, keepalive.ClientParameters{})
+	err := client.Init(ctx, g, m.Storage)
+	require.NoError(t, err)
+	client.SetDeleteRangeBatchLimit(stream.DelRangeBatchLimit{MaxRows: 1})
+	client.SetDeleteRangePace(stream.DelRangePace{Duration: 100 * time.Millisecond})
+
+	client.RunGCRowsLoader(ctx)
+	query := &stream.PreDelRangeQuery{Sql: "INSERT IGNORE INTO mysql.gc_delete_range VALUES "}
+	for i := range 5 {
+		query.ParamsList = append(query.ParamsList, stream.DelRangeParams{
+			JobID: 100, ElemID: int64(i + 1), StartKey: fmt.Sprintf("batch-%d", i), EndKey: fmt.Sprintf("batch-%d", i+1),
+		})
+	}
+	client.RecordDeleteRange(query)
+	start := time.Now()
+	require.NoError(t, client.InsertGCRows(ctx, g))
+	// the statements are spread over the duration.
+	require.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond)
+
+	// the delete ranges of the job are inserted by several statements, but still share the job ID.
+	se, err := g.CreateSession(m.Storage)
+	require.NoError(t, err)
+	defer se.Close()
+	exec := se.GetSessionCtx().GetRestrictedSQLExecutor()
+	rows, _, err := exec.ExecRestrictedSQL(kv.WithInternalSourceType(ctx, kv.InternalTxnBR),
+		[]sqlexec.OptionFuncAlias{sqlexec.ExecOptionUseCurSession},
+		"select count(*), count(distinct job_id) from mysql.gc_delete_range where start_key like 'batch-%'")
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	require.EqualValues(t, 5, rows[0].GetInt64(0))
+	require.EqualValues(t, 1, rows[0].GetInt64(1))
 }
 
 func TestDeleteRangeQuery(t *testing.T) {
@@ -136,6 +177,36 @@ func TestDeleteRangeQuery(t *testing.T) {
 	}
 }
 
+func TestDeleteRangeQuerySpilled(t *testing.T) {
+	ctx := context.Background()
+	t.Setenv("TMPDIR", t.TempDir())
+
+	client := logclient.NewRestoreClient(
+		split.NewFakePDClient(nil, false, nil), nil, nil, keepalive.ClientParameters{})
+	// the budget is too small to hold any query, so all of them are spilled to disk.
+	client.SetMemoryBudget(membudget.New(1))
+	defer client.Close(ctx)
+	client.RunGCRowsLoader(ctx)
+
+	for _, query := range deleteRangeQueryList {
+		client.RecordDeleteRange(query)
+	}
+	querys := client.GetGCRows()
+	require.Equal(t, deleteRangeQueryList, querys)
+	files, err := filepath.Glob(filepath.Join(os.TempDir(), "br-restore-spill-*"))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+}
+
+func TestMetaKVBatchSizeBoundedByBudget(t *testing.T) {
+	client := logclient.NewRestoreClient(nil, nil, nil, keepalive.ClientParameters{})
+	require.Equal(t, uint64(logclient.MetaKVBatchSize), client.TEST_MetaKVBatchSize())
+	client.SetMemoryBudget(membudget.New(4 * logclient.MetaKVBatchSize))
+	require.Equal(t, uint64(logclient.MetaKVBatchSize), client.TEST_MetaKVBatchSize())
+	client.SetMemoryBudget(membudget.New(4096))
+	require.Equal(t, uint64(1024), client.TEST_MetaKVBatchSize())
+}
+
 func MockEmptySchemasReplace() *stream.SchemasReplace {
 	dbMap := make(map[stream.UpstreamID]*stream.DBReplace)
 	return stream.NewSchemasReplace(
@@ -0,0 +1,229 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package logclient
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+
+	"github.com/pingcap/errors"
+	backuppb "github.com/pingcap/kvproto/pkg/brpb"
+	"github.com/pingcap/log"
+	"github.com/pingcap/tidb/br/pkg/storage"
+	"github.com/pingcap/tidb/br/pkg/stream"
+	"github.com/pingcap/tidb/br/pkg/utils/iter"
+	"go.uber.org/zap"
+)
+
+const compactionIndexReadConcurrency = 32
+
+// CompactionIndex indexes the tables and the ts ranges of the subcompaction meta files in the artifacts
+// of a compaction, so the log restore only reads the meta files having the tables to restore in the ts
+// window, instead of all of the artifacts.
+//
+// The artifacts are never changed after the compaction finishes, so the index keeps covering them as
+// long as the hash of the artifacts matches. The index is removed by the truncation with the artifacts.
+type CompactionIndex struct {
+	ArtifactsHash uint64                 `json:"artifacts-hash"`
+	Files         []*CompactionFileIndex `json:"files"`
+}
+
+// CompactionFileIndex indexes a subcompaction meta file.
+type CompactionFileIndex struct {
+	// Path is the path of the meta file.
+	Path   string                  `json:"path"`
+	Tables []*CompactionTableRange `json:"tables"`
+}
+
+// CompactionTableRange is the range of the input ts of the subcompactions of a table in a meta file.
+type CompactionTableRange struct {
+	TableID int64  `json:"table-id"`
+	MinTS   uint64 `json:"min-ts"`
+	MaxTS   uint64 `json:"max-ts"`
+}
+
+// CompactionFilter selects the subcompactions to restore.
+type CompactionFilter struct {
+	// TableIDs are the upstream IDs of the tables to restore.
+	TableIDs map[int64]struct{}
+	// StartTS and RestoreTS are the ts window to restore, the StartTS should be the shift start ts.
+	StartTS   uint64
+	RestoreTS uint64
+}
+
+// shouldSkip returns whether the subcompactions of the table in [minTS, maxTS] should be skipped.
+func (f *CompactionFilter) shouldSkip(tableID int64, minTS, maxTS uint64) bool {
+	if _, ok := f.TableIDs[tableID]; !ok {
+		return true
+	}
+	return maxTS < f.StartTS || minTS > f.RestoreTS
+}
+
+// ShouldSkip returns whether the subcompaction should be skipped.
+func (f *CompactionFilter) ShouldSkip(subc *backuppb.LogFileSubcompaction) bool {
+	return f.shouldSkip(subc.Meta.TableId, subc.Meta.InputMinTs, subc.Meta.InputMaxTs)
+}
+
+// shouldRead returns whether the meta file may have the subcompactions not skipped.
+func (f *CompactionFilter) shouldRead(file *CompactionFileIndex) bool {
+	for _, t := range file.Tables {
+		if !f.shouldSkip(t.TableID, t.MinTS, t.MaxTS) {
+			return true
+		}
+	}
+	return false
+}
+
+// buildCompactionFileIndex builds the index of the subcompaction meta file.
+func buildCompactionFileIndex(path string, subcs *backuppb.LogFileSubcompactions) *CompactionFileIndex {
+	file := &CompactionFileIndex{Path: path}
+	byTable := make(map[int64]*CompactionTableRange)
+	for _, subc := range subcs.Subcompactions {
+		t, ok := byTable[subc.Meta.TableId]
+		if !ok {
+			t = &CompactionTableRange{TableID: subc.Meta.TableId, MinTS: subc.Meta.InputMinTs, MaxTS: subc.Meta.InputMaxTs}
+			byTable[subc.Meta.TableId] = t
+			file.Tables = append(file.Tables, t)
+			continue
+		}
+		t.MinTS = min(t.MinTS, subc.Meta.InputMinTs)
+		t.MaxTS = max(t.MaxTS, subc.Meta.InputMaxTs)
+	}
+	return file
+}
+
+// ReadCompactionIndex reads the index of the artifacts of the compaction, nil is returned if there is no
+// index or the index is built on other artifacts.
+func ReadCompactionIndex(
+	ctx context.Context,
+	s storage.ExternalStorage,
+	compaction *backuppb.LogFileCompaction,
+) (*CompactionIndex, error) {
+	path := stream.CompactionIndexPath(compaction.Artifacts)
+	exists, err := s.FileExists(ctx, path)
+	if err != nil || !exists {
+		return nil, errors.Trace(err)
+	}
+	data, err := s.ReadFile(ctx, path)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	index := &CompactionIndex{}
+	if err := json.Unmarshal(data, index); err != nil {
+		return nil, errors.Annotatef(err, "failed to parse the compaction index %s", path)
+	}
+	if index.ArtifactsHash != compaction.ArtifactsHash {
+		log.Warn("the compaction index doesn't match the artifacts, ignore it", zap.String("index", path),
+			zap.Uint64("index-hash", index.ArtifactsHash), zap.Uint64("artifacts-hash", compaction.ArtifactsHash))
+		return nil, nil
+	}
+	return index, nil
+}
+
+// WriteCompactionIndex saves the index of the artifacts of the compaction.
+func WriteCompactionIndex(
+	ctx context.Context,
+	s storage.ExternalStorage,
+	compaction *backuppb.LogFileCompaction,
+	index *CompactionIndex,
+) error {
+	data, err := json.Marshal(index)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	path := stream.CompactionIndexPath(compaction.Artifacts)
+	return errors.Annotatef(s.WriteFile(ctx, path, data), "failed to write the compaction index %s", path)
+}
+
+// SubcompactionsOfTables iterates the subcompactions of the compaction selected by the filter. With the
+// index of the artifacts, only the meta files having the selected subcompactions are read. Otherwise all
+// of the meta files are read, and the index is built by the way and saved for the next restore.
+func SubcompactionsOfTables(
+	ctx context.Context,
+	compaction *backuppb.LogFileCompaction,
+	s storage.ExternalStorage,
+	filter *CompactionFilter,
+) SubCompactionIter {
+	var subcs iter.TryNextor[*backuppb.LogFileSubcompactions]
+	return iter.FilterOut(iter.FlatMap(iter.Func(func(ictx context.Context) iter.IterResult[*backuppb.LogFileSubcompactions] {
+		if subcs == nil {
+			index, err := ReadCompactionIndex(ictx, s, compaction)
+			if err != nil {
+				return iter.Throw[*backuppb.LogFileSubcompactions](err)
+			}
+			if index != nil {
+				subcs = readIndexedSubcompactions(index, s, filter)
+			} else {
+				subcs = scanAndIndexSubcompactions(ctx, compaction, s)
+			}
+		}
+		return subcs.TryNext(ictx)
+	}), func(subcs *backuppb.LogFileSubcompactions) iter.TryNextor[*backuppb.LogFileSubcompaction] {
+		return iter.FromSlice(subcs.Subcompactions)
+	}), filter.ShouldSkip)
+}
+
+func readIndexedSubcompactions(
+	index *CompactionIndex,
+	s storage.ExternalStorage,
+	filter *CompactionFilter,
+) iter.TryNextor[*backuppb.LogFileSubcompactions] {
+	paths := make([]string, 0, len(index.Files))
+	for _, file := range index.Files {
+		if filter.shouldRead(file) {
+			paths = append(paths, file.Path)
+		}
+	}
+	log.Info("read the subcompactions by the compaction index",
+		zap.Int("files", len(index.Files)), zap.Int("read-files", len(paths)))
+	return iter.Transform(iter.FromSlice(paths), func(ctx context.Context, path string) (*backuppb.LogFileSubcompactions, error) {
+		b, err := s.ReadFile(ctx, path)
+		if err != nil {
+			return nil, errors.Annotatef(err, "during reading meta file %s from storage", path)
+		}
+		subcs := &backuppb.LogFileSubcompactions{}
+		if err := subcs.Unmarshal(b); err != nil {
+			return nil, errors.Annotatef(err, "failed to unmarshal file %s", path)
+		}
+		return subcs, nil
+	}, iter.WithChunkSize(compactionIndexReadConcurrency), iter.WithConcurrency(compactionIndexReadConcurrency))
+}
+
+func scanAndIndexSubcompactions(
+	ctx context.Context,
+	compaction *backuppb.LogFileCompaction,
+	s storage.ExternalStorage,
+) iter.TryNextor[*backuppb.LogFileSubcompactions] {
+	var mu sync.Mutex
+	index := &CompactionIndex{ArtifactsHash: compaction.ArtifactsHash}
+	scanned := storage.UnmarshalDir(
+		ctx,
+		&storage.WalkOption{SubDir: compaction.Artifacts},
+		s,
+		func(t *backuppb.LogFileSubcompactions, name string, b []byte) error {
+			if err := t.Unmarshal(b); err != nil {
+				return err
+			}
+			file := buildCompactionFileIndex(name, t)
+			mu.Lock()
+			index.Files = append(index.Files, file)
+			mu.Unlock()
+			return nil
+		},
+	)
+	return iter.Func(func(ctx context.Context) iter.IterResult[*backuppb.LogFileSubcompactions] {
+		r := scanned.TryNext(ctx)
+		if r.Finished && index != nil {
+			mu.Lock()
+			defer mu.Unlock()
+			// The index is only an optimization, e.g. the storage may be read only for the restore.
+			if err := WriteCompactionIndex(ctx, s, compaction, index); err != nil {
+				log.Warn("failed to save the compaction index", zap.String("artifacts", compaction.Artifacts),
+					zap.Error(err))
+			}
+			index = nil
+		}
+		return r
+	})
+}
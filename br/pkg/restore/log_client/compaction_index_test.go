@@ -0,0 +1,73 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package logclient_test
+
+import (
+	"context"
+	"sort"
+	"testing"
+
+	backuppb "github.com/pingcap/kvproto/pkg/brpb"
+	logclient "github.com/pingcap/tidb/br/pkg/restore/log_client"
+	"github.com/pingcap/tidb/br/pkg/storage"
+	"github.com/pingcap/tidb/br/pkg/utils/iter"
+	"github.com/stretchr/testify/require"
+)
+
+func fakeSubcompactionInTS(tableID int64, minTS, maxTS uint64) *backuppb.LogFileSubcompaction {
+	return &backuppb.LogFileSubcompaction{
+		Meta: &backuppb.LogFileSubcompactionMeta{TableId: tableID, InputMinTs: minTS, InputMaxTs: maxTS},
+	}
+}
+
+func TestSubcompactionsOfTables(t *testing.T) {
+	ctx := context.Background()
+	s, err := storage.NewLocalStorage(t.TempDir())
+	require.NoError(t, err)
+
+	metas := map[string][]*backuppb.LogFileSubcompaction{
+		"compaction/metas/1.cmeta": {fakeSubcompactionInTS(1, 10, 20), fakeSubcompactionInTS(2, 10, 20)},
+		"compaction/metas/2.cmeta": {fakeSubcompactionInTS(3, 10, 20), fakeSubcompactionInTS(1, 50, 60)},
+		"compaction/metas/3.cmeta": {fakeSubcompactionInTS(3, 30, 40)},
+	}
+	for path, subcs := range metas {
+		b, err := (&backuppb.LogFileSubcompactions{Subcompactions: subcs}).Marshal()
+		require.NoError(t, err)
+		require.NoError(t, s.WriteFile(ctx, path, b))
+	}
+	compaction := &backuppb.LogFileCompaction{Artifacts: "compaction/metas", ArtifactsHash: 42}
+	filter := &logclient.CompactionFilter{
+		TableIDs:  map[int64]struct{}{1: {}, 2: {}},
+		StartTS:   15,
+		RestoreTS: 45,
+	}
+	collect := func() []int64 {
+		r := iter.CollectAll(ctx, logclient.SubcompactionsOfTables(ctx, compaction, s, filter))
+		require.NoError(t, r.Err)
+		tableIDs := make([]int64, 0, len(r.Item))
+		for _, subc := range r.Item {
+			tableIDs = append(tableIDs, subc.Meta.TableId)
+		}
+		sort.Slice(tableIDs, func(i, j int) bool { return tableIDs[i] < tableIDs[j] })
+		return tableIDs
+	}
+
+	// there is no index, all of the meta files are scanned and the index is built.
+	require.Equal(t, []int64{1, 2}, collect())
+	index, err := logclient.ReadCompactionIndex(ctx, s, compaction)
+	require.NoError(t, err)
+	require.NotNil(t, index)
+	require.Len(t, index.Files, 3)
+
+	// the meta files without the subcompactions to restore aren't read by the index.
+	require.NoError(t, s.DeleteFile(ctx, "compaction/metas/2.cmeta"))
+	require.NoError(t, s.DeleteFile(ctx, "compaction/metas/3.cmeta"))
+	require.Equal(t, []int64{1, 2}, collect())
+
+	// the index built on other artifacts is ignored.
+	compaction.ArtifactsHash = 43
+	index, err = logclient.ReadCompactionIndex(ctx, s, compaction)
+	require.NoError(t, err)
+	require.Nil(t, index)
+	require.Equal(t, []int64{1, 2}, collect())
+}
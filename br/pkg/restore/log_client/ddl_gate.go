@@ -0,0 +1,367 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package logclient
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/log"
+	berrors "github.com/pingcap/tidb/br/pkg/errors"
+	"github.com/pingcap/tidb/pkg/ddl"
+	"github.com/pingcap/tidb/pkg/kv"
+	"github.com/pingcap/tidb/pkg/meta"
+	"github.com/pingcap/tidb/pkg/meta/model"
+	"github.com/pingcap/tidb/pkg/sessionctx"
+	filter "github.com/pingcap/tidb/pkg/util/table-filter"
+	"github.com/tikv/client-go/v2/oracle"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultDDLGateTTL = time.Minute
+	// ddlGateResumeRetry is the max times to retry resuming the jobs still pausing, they're paused by the DDL
+	// owner soon and can't be resumed before that.
+	ddlGateResumeRetry    = 20
+	ddlGateResumeInterval = 500 * time.Millisecond
+)
+
+// DDLJobPauser pauses and resumes the DDL jobs of the target cluster.
+type DDLJobPauser interface {
+	// PauseJobs pauses the pausable jobs matched by match by restore, and returns the ids of the paused jobs.
+	PauseJobs(ctx context.Context, match func(*model.Job) bool) ([]int64, error)
+	// ResumeJobs resumes the jobs paused by restore and matched by match, and returns the ids of the resumed
+	// jobs and the jobs still pausing, which can't be resumed yet.
+	ResumeJobs(ctx context.Context, match func(*model.Job) bool) (resumed, pausing []int64, err error)
+}
+
+type sessionDDLJobPauser struct {
+	se sessionctx.Context
+}
+
+// NewSessionDDLJobPauser creates a DDLJobPauser pausing the jobs by restore through the session.
+func NewSessionDDLJobPauser(se sessionctx.Context) DDLJobPauser {
+	return &sessionDDLJobPauser{se: se}
+}
+
+func (p *sessionDDLJobPauser) PauseJobs(ctx context.Context, match func(*model.Job) bool) ([]int64, error) {
+	jobs, err := ddl.GetAllDDLJobs(ctx, p.se)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	ids := make([]int64, 0, len(jobs))
+	for _, job := range jobs {
+		if job.IsPausable() && match(job) {
+			ids = append(ids, job.ID)
+		}
+	}
+	return p.process(ids, ddl.PauseJobsByRestore)
+}
+
+func (p *sessionDDLJobPauser) ResumeJobs(ctx context.Context, match func(*model.Job) bool) (resumed, pausing []int64, err error) {
+	jobs, err := ddl.GetAllDDLJobs(ctx, p.se)
+	if err != nil {
+		return nil, nil, errors.Trace(err)
+	}
+	ids := make([]int64, 0, len(jobs))
+	for _, job := range jobs {
+		if job.AdminOperator != model.AdminCommandByRestore || !match(job) {
+			continue
+		}
+		if job.IsPausing() {
+			pausing = append(pausing, job.ID)
+		} else if job.IsPaused() {
+			ids = append(ids, job.ID)
+		}
+	}
+	resumed, err = p.process(ids, ddl.ResumeJobsByRestore)
+	return resumed, pausing, errors.Trace(err)
+}
+
+func (p *sessionDDLJobPauser) process(ids []int64, process func(sessionctx.Context, []int64) ([]error, error)) ([]int64, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	errs, err := process(p.se, ids)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	done := ids[:0]
+	for i, id := range ids {
+		// the job may be finished or canceled in the meantime.
+		if errs[i] != nil {
+			log.Info("skip the DDL job", zap.Int64("job-id", id), zap.Error(errs[i]))
+			continue
+		}
+		done = append(done, id)
+	}
+	return done, nil
+}
+
+// DDLGate keeps the DDL jobs involving the restored tables from interleaving with the meta kv entries applied
+// by the log restore. The gate is saved in the meta of the target cluster, and the DDL jobs covered by it are
+// paused by restore when they're submitted, the running ones are paused when the gate is acquired. The
+// job submitters cache the gates for a while, so the restore waits for the caches before pausing or
+// resuming the jobs. The restores of different tasks can hold their gates at the same time.
+// The gate expires if it isn't renewed in time, e.g. the restore crashes. The jobs paused by the expired
+// gates are resumed by the next restore acquiring a gate.
+type DDLGate struct {
+	store  kv.Storage
+	pauser DDLJobPauser
+	gate   *meta.RestoreDDLGate
+	filter filter.Filter
+	ttl    time.Duration
+	// cacheTTL is how long the gates are cached by the DDL job submitters.
+	cacheTTL time.Duration
+
+	acquired bool
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+}
+
+// NewDDLGate creates a DDL gate of the task, covering the tables matched by the table filter rules.
+func NewDDLGate(store kv.Storage, pauser DDLJobPauser, task string, filters []string) (*DDLGate, error) {
+	return NewDDLGateWithTTL(store, pauser, task, filters, defaultDDLGateTTL)
+}
+
+// NewDDLGateWithTTL creates a DDL gate of the task, which expires if it isn't renewed in ttl.
+func NewDDLGateWithTTL(
+	store kv.Storage, pauser DDLJobPauser, task string, filters []string, ttl time.Duration,
+) (*DDLGate, error) {
+	gate := &meta.RestoreDDLGate{Task: task, Filters: filters}
+	f, err := ddl.ParseRestoreDDLGateFilter(gate)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return &DDLGate{
+		store:    store,
+		pauser:   pauser,
+		gate:     gate,
+		filter:   f,
+		ttl:      ttl,
+		cacheTTL: ddl.RestoreDDLGateCacheTTL,
+	}, nil
+}
+
+// Acquire saves the gate and pauses the covered DDL jobs of the target cluster until Release is called.
+func (g *DDLGate) Acquire(ctx context.Context) error {
+	var expired []*meta.RestoreDDLGate
+	err := g.runInTxn(ctx, func(m *meta.Mutator, now time.Time) error {
+		gates, err := m.ListRestoreDDLGates()
+		if err != nil {
+			return errors.Trace(err)
+		}
+		expired = expired[:0]
+		for _, gate := range gates {
+			if now.After(gate.ExpireTime) {
+				expired = append(expired, gate)
+			} else if gate.Task == g.gate.Task {
+				return errors.Annotatef(berrors.ErrRestoreDDLGateBusy,
+					"the DDL gate of %s is held by another restore, it expires at %s if the restore crashed",
+					gate.Task, gate.ExpireTime)
+			}
+		}
+		g.gate.ExpireTime = now.Add(g.ttl)
+		return errors.Trace(m.SetRestoreDDLGate(g.gate))
+	})
+	if err != nil {
+		return errors.Annotate(err, "failed to acquire the DDL gate")
+	}
+	g.acquired = true
+	if err := g.syncGateCaches(ctx); err != nil {
+		g.Release(ctx)
+		return errors.Annotate(err, "failed to acquire the DDL gate")
+	}
+
+	for _, gate := range expired {
+		log.Warn("resume the DDL jobs paused by the expired DDL gate", zap.String("task", gate.Task))
+		if err := g.resumeAndDelete(ctx, gate); err != nil {
+			g.Release(ctx)
+			return errors.Trace(err)
+		}
+	}
+	paused, err := g.pauser.PauseJobs(ctx, g.covers)
+	if err != nil {
+		g.Release(ctx)
+		return errors.Annotate(err, "failed to pause the DDL jobs")
+	}
+	log.Info("the DDL gate is acquired", zap.String("task", g.gate.Task),
+		zap.Strings("filters", g.gate.Filters), zap.Int64s("paused-jobs", paused))
+
+	// the gate is renewed until Release, even if ctx is canceled before it.
+	bgCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	g.cancel = cancel
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		g.renewLoop(bgCtx)
+	}()
+	return nil
+}
+
+// Release removes the gate and resumes the DDL jobs paused by it. It's fine to call it if Acquire fails.
+func (g *DDLGate) Release(ctx context.Context) {
+	if g.cancel != nil {
+		g.cancel()
+		g.wg.Wait()
+		g.cancel = nil
+	}
+	if !g.acquired {
+		return
+	}
+	g.acquired = false
+	// the DDL jobs should be resumed even if the restore is canceled.
+	ctx = context.WithoutCancel(ctx)
+	// the gate is expired first, so no more jobs are paused by it once the job submitters see it, and the
+	// jobs paused by the gate are all inserted before that.
+	err := g.runInTxn(ctx, func(m *meta.Mutator, _ time.Time) error {
+		g.gate.ExpireTime = time.Time{}
+		return errors.Trace(m.SetRestoreDDLGate(g.gate))
+	})
+	if err == nil {
+		err = g.syncGateCaches(ctx)
+	}
+	if err == nil {
+		err = g.resumeAndDelete(ctx, g.gate)
+	}
+	if err != nil {
+		log.Warn("failed to release the DDL gate, the DDL jobs paused by it are resumed "+
+			"when a DDL gate is acquired next time", zap.String("task", g.gate.Task), zap.Error(err))
+		return
+	}
+	log.Info("the DDL gate is released", zap.String("task", g.gate.Task))
+}
+
+// syncGateCaches waits until the gates cached by the DDL job submitters are all refreshed after the gate
+// is changed, and then updates the global ID. The jobs inserted with the stale gates either commit before
+// the global ID is updated, or conflict with it and retry with the fresh gates.
+func (g *DDLGate) syncGateCaches(ctx context.Context) error {
+	var since time.Time
+	for {
+		done := false
+		err := g.runInTxn(ctx, func(m *meta.Mutator, now time.Time) error {
+			if since.IsZero() {
+				// the txn starts after the gate is changed.
+				since = now
+			}
+			if done = now.Sub(since) > g.cacheTTL; !done {
+				return nil
+			}
+			_, err := m.GenGlobalIDs(1)
+			return errors.Trace(err)
+		})
+		if err != nil {
+			return errors.Annotate(err, "failed to wait for the cached DDL gates")
+		}
+		if done {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return errors.Trace(ctx.Err())
+		case <-time.After(g.cacheTTL / 4):
+		}
+	}
+}
+
+// renewLoop extends the expire time of the gate until ctx is done.
+func (g *DDLGate) renewLoop(ctx context.Context) {
+	ticker := time.NewTicker(g.ttl / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			err := g.runInTxn(ctx, func(m *meta.Mutator, now time.Time) error {
+				g.gate.ExpireTime = now.Add(g.ttl)
+				return errors.Trace(m.SetRestoreDDLGate(g.gate))
+			})
+			if err != nil {
+				log.Warn("failed to renew the DDL gate, the DDL jobs may interleave with the restored meta "+
+					"if it expires", zap.String("task", g.gate.Task), zap.Error(err))
+			}
+		}
+	}
+}
+
+// resumeAndDelete resumes the DDL jobs paused by the expired gate, and deletes it after all of them are
+// resumed. The jobs covered by the other unexpired gates are kept paused.
+func (g *DDLGate) resumeAndDelete(ctx context.Context, gate *meta.RestoreDDLGate) error {
+	f, err := ddl.ParseRestoreDDLGateFilter(gate)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	var others []filter.Filter
+	err = g.runInTxn(ctx, func(m *meta.Mutator, now time.Time) error {
+		gates, err := m.ListRestoreDDLGates()
+		if err != nil {
+			return errors.Trace(err)
+		}
+		others = others[:0]
+		for _, other := range gates {
+			if other.Task == gate.Task || now.After(other.ExpireTime) {
+				continue
+			}
+			otherFilter, err := ddl.ParseRestoreDDLGateFilter(other)
+			if err != nil {
+				return errors.Trace(err)
+			}
+			others = append(others, otherFilter)
+		}
+		return nil
+	})
+	if err != nil {
+		return errors.Trace(err)
+	}
+	match := func(job *model.Job) bool {
+		if !ddl.RestoreDDLGateCovers(f, job) {
+			return false
+		}
+		for _, other := range others {
+			if ddl.RestoreDDLGateCovers(other, job) {
+				return false
+			}
+		}
+		return true
+	}
+	for i := 0; ; i++ {
+		resumed, pausing, err := g.pauser.ResumeJobs(ctx, match)
+		if err != nil {
+			return errors.Annotate(err, "failed to resume the DDL jobs")
+		}
+		if len(resumed) > 0 {
+			log.Info("resume the DDL jobs paused by the DDL gate",
+				zap.String("task", gate.Task), zap.Int64s("job-ids", resumed))
+		}
+		if len(pausing) == 0 {
+			break
+		}
+		if i >= ddlGateResumeRetry {
+			return errors.Errorf("the DDL jobs %v are still pausing", pausing)
+		}
+		select {
+		case <-ctx.Done():
+			return errors.Trace(ctx.Err())
+		case <-time.After(ddlGateResumeInterval):
+		}
+	}
+	err = g.runInTxn(ctx, func(m *meta.Mutator, _ time.Time) error {
+		return errors.Trace(m.DelRestoreDDLGate(gate.Task))
+	})
+	return errors.Annotate(err, "failed to delete the DDL gate")
+}
+
+func (g *DDLGate) covers(job *model.Job) bool {
+	return ddl.RestoreDDLGateCovers(g.filter, job)
+}
+
+// runInTxn runs fn in a meta txn, now is the physical time of the start ts of the txn.
+func (g *DDLGate) runInTxn(ctx context.Context, fn func(m *meta.Mutator, now time.Time) error) error {
+	ctx = kv.WithInternalSourceType(ctx, kv.InternalTxnBR)
+	return kv.RunInNewTxn(ctx, g.store, true, func(_ context.Context, txn kv.Transaction) error {
+		return fn(meta.NewMutator(txn), oracle.GetTimeFromTS(txn.StartTS()))
+	})
+}
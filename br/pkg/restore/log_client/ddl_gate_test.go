@@ -0,0 +1,165 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package logclient_test
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	berrors "github.com/pingcap/tidb/br/pkg/errors"
+	logclient "github.com/pingcap/tidb/br/pkg/restore/log_client"
+	"github.com/pingcap/tidb/pkg/kv"
+	"github.com/pingcap/tidb/pkg/meta"
+	"github.com/pingcap/tidb/pkg/meta/model"
+	"github.com/pingcap/tidb/pkg/store/mockstore"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeDDLJobPauser keeps the jobs in memory.
+type fakeDDLJobPauser struct {
+	mu   sync.Mutex
+	jobs []*model.Job
+}
+
+func (p *fakeDDLJobPauser) submit(id int64, schema, table string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.jobs = append(p.jobs, &model.Job{ID: id, SchemaName: schema, TableName: table, State: model.JobStateQueueing})
+}
+
+func (p *fakeDDLJobPauser) pausedJobs() []int64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var ids []int64
+	for _, job := range p.jobs {
+		if job.IsPausedByRestore() {
+			ids = append(ids, job.ID)
+		}
+	}
+	return ids
+}
+
+func (p *fakeDDLJobPauser) PauseJobs(_ context.Context, match func(*model.Job) bool) ([]int64, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var ids []int64
+	for _, job := range p.jobs {
+		if job.IsPausable() && match(job) {
+			job.State = model.JobStatePaused
+			job.AdminOperator = model.AdminCommandByRestore
+			ids = append(ids, job.ID)
+		}
+	}
+	return ids, nil
+}
+
+func (p *fakeDDLJobPauser) ResumeJobs(_ context.Context, match func(*model.Job) bool) (resumed, pausing []int64, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, job := range p.jobs {
+		if job.IsPausedByRestore() && match(job) {
+			job.State = model.JobStateQueueing
+			resumed = append(resumed, job.ID)
+		}
+	}
+	return resumed, nil, nil
+}
+
+func newDDLGate(store kv.Storage, pauser logclient.DDLJobPauser, task string, filters []string) (*logclient.DDLGate, error) {
+	gate, err := logclient.NewDDLGateWithTTL(store, pauser, task, filters, 5*time.Second)
+	if err == nil {
+		gate.SetCacheTTL(50 * time.Millisecond)
+	}
+	return gate, err
+}
+
+func listDDLGates(t *testing.T, store kv.Storage) []string {
+	var tasks []string
+	require.NoError(t, kv.RunInNewTxn(kv.WithInternalSourceType(context.Background(), kv.InternalTxnBR), store, false,
+		func(_ context.Context, txn kv.Transaction) error {
+			gates, err := meta.NewMutator(txn).ListRestoreDDLGates()
+			for _, gate := range gates {
+				tasks = append(tasks, gate.Task)
+			}
+			return err
+		}))
+	slices.Sort(tasks)
+	return tasks
+}
+
+func TestDDLGate(t *testing.T) {
+	store, err := mockstore.NewMockStore()
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, store.Close())
+	}()
+	ctx := context.Background()
+
+	pauser := &fakeDDLJobPauser{}
+	pauser.submit(1, "db1", "t1")
+	pauser.submit(2, "db2", "t1")
+	pauser.submit(3, "db1", "")
+	pauser.submit(4, "mysql", "t1")
+	gate1, err := newDDLGate(store, pauser, "restore-1", []string{"db1.*"})
+	require.NoError(t, err)
+	require.NoError(t, gate1.Acquire(ctx))
+	// only the jobs involving the restored tables are paused, and the system schemas are never gated.
+	require.Equal(t, []int64{1, 3}, pauser.pausedJobs())
+	require.Equal(t, []string{"restore-1"}, listDDLGates(t, store))
+
+	// the gates of the different tasks can be held at the same time.
+	gate2, err := newDDLGate(store, pauser, "restore-2", []string{"db*.*"})
+	require.NoError(t, err)
+	require.NoError(t, gate2.Acquire(ctx))
+	require.Equal(t, []int64{1, 2, 3}, pauser.pausedJobs())
+	require.Equal(t, []string{"restore-1", "restore-2"}, listDDLGates(t, store))
+	// but not the same task.
+	gate, err := newDDLGate(store, pauser, "restore-1", []string{"*.*"})
+	require.NoError(t, err)
+	require.ErrorIs(t, gate.Acquire(ctx), berrors.ErrRestoreDDLGateBusy)
+	gate.Release(ctx)
+
+	// the jobs covered by the other gate are kept paused.
+	gate1.Release(ctx)
+	require.Equal(t, []int64{1, 2, 3}, pauser.pausedJobs())
+	require.Equal(t, []string{"restore-2"}, listDDLGates(t, store))
+	gate2.Release(ctx)
+	require.Empty(t, pauser.pausedJobs())
+	require.Empty(t, listDDLGates(t, store))
+	// releasing twice is a no-op.
+	gate2.Release(ctx)
+}
+
+func TestDDLGateResumeExpired(t *testing.T) {
+	store, err := mockstore.NewMockStore()
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, store.Close())
+	}()
+	ctx := context.Background()
+
+	// a restore crashed with the jobs 1 and 2 paused, and its gate is expired.
+	pauser := &fakeDDLJobPauser{}
+	pauser.submit(1, "db1", "t1")
+	pauser.submit(2, "db1", "t2")
+	_, err = pauser.PauseJobs(ctx, func(*model.Job) bool { return true })
+	require.NoError(t, err)
+	require.NoError(t, kv.RunInNewTxn(kv.WithInternalSourceType(ctx, kv.InternalTxnBR), store, false,
+		func(_ context.Context, txn kv.Transaction) error {
+			return meta.NewMutator(txn).SetRestoreDDLGate(&meta.RestoreDDLGate{
+				Task: "restore-1", Filters: []string{"db1.*"}, ExpireTime: time.Now().Add(-time.Minute)})
+		}))
+
+	gate, err := newDDLGate(store, pauser, "restore-2", []string{"db1.t2"})
+	require.NoError(t, err)
+	require.NoError(t, gate.Acquire(ctx))
+	require.Equal(t, []string{"restore-2"}, listDDLGates(t, store))
+	// the job 1 is resumed, and the job 2 is paused again by the new gate.
+	require.Equal(t, []int64{2}, pauser.pausedJobs())
+	gate.Release(ctx)
+	require.Empty(t, pauser.pausedJobs())
+	require.Empty(t, listDDLGates(t, store))
+}
@@ -16,15 +16,21 @@ package logclient
 
 import (
 	"context"
+	"time"
 
 	"github.com/pingcap/errors"
 	backuppb "github.com/pingcap/kvproto/pkg/brpb"
 	"github.com/pingcap/kvproto/pkg/encryptionpb"
+	"github.com/pingcap/tidb/br/pkg/checkpoint"
 	"github.com/pingcap/tidb/br/pkg/glue"
+	snapclient "github.com/pingcap/tidb/br/pkg/restore/snap_client"
+	"github.com/pingcap/tidb/br/pkg/restore/split"
+	restoreutils "github.com/pingcap/tidb/br/pkg/restore/utils"
 	"github.com/pingcap/tidb/br/pkg/storage"
 	"github.com/pingcap/tidb/br/pkg/stream"
 	"github.com/pingcap/tidb/br/pkg/utils/iter"
 	"github.com/pingcap/tidb/pkg/domain"
+	"github.com/pingcap/tidb/pkg/kv"
 )
 
 var FilterFilesByRegion = filterFilesByRegion
@@ -89,7 +95,7 @@ func (rc *LogFileManager) ReadStreamMeta(ctx context.Context) ([]*MetaName, erro
 }
 
 func TEST_NewLogClient(clusterID, startTS, restoreTS, upstreamClusterID uint64, dom *domain.Domain, se glue.Session) *LogClient {
-	return &LogClient{
+	rc := &LogClient{
 		dom:               dom,
 		unsafeSession:     se,
 		upstreamClusterID: upstreamClusterID,
@@ -97,8 +103,11 @@ func TEST_NewLogClient(clusterID, startTS, restoreTS, upstreamClusterID uint64,
 			startTS:   startTS,
 			restoreTS: restoreTS,
 		},
-		clusterID: clusterID,
+		clusterID:  clusterID,
+		tableStats: snapclient.NewTableStatsCollector(),
 	}
+	rc.idReservation = stream.NewIDReservation(rc.ClaimGlobalIDs, idReservationBatchSize)
+	return rc
 }
 
 func TEST_NewLogFileManager(startTS, restoreTS, shiftStartTS uint64, helper streamMetadataHelper) *LogFileManager {
@@ -110,6 +119,21 @@ func TEST_NewLogFileManager(startTS, restoreTS, shiftStartTS uint64, helper stre
 	}
 }
 
+// TEST_ReadPrefetchedLogFiles prefetches the files, and reads the files in the order of readOrder.
+func (rc *LogFileManager) TEST_ReadPrefetchedLogFiles(ctx context.Context, files, readOrder []Log) ([][]byte, error) {
+	p := rc.prefetchLogFiles(ctx, files)
+	defer p.close()
+	buffs := make([][]byte, 0, len(readOrder))
+	for _, file := range readOrder {
+		buff, err := p.read(ctx, file)
+		if err != nil {
+			return buffs, err
+		}
+		buffs = append(buffs, buff)
+	}
+	return buffs, nil
+}
+
 type FakeStreamMetadataHelper struct {
 	streamMetadataHelper
 
@@ -127,3 +151,72 @@ func (helper *FakeStreamMetadataHelper) ReadFile(
 ) ([]byte, error) {
 	return helper.Data[offset : offset+length], nil
 }
+
+// TEST_SampleFiles samples the files as if they're imported by the rule.
+func (rc *LogClient) TEST_SampleFiles(files []*LogDataFileInfo, rule *restoreutils.RewriteRules) {
+	rc.sampleVerifier.sample(files, rule)
+}
+
+func (rc *LogClient) TEST_exportMetaKVEntry(upstream, rewritten *kv.Entry, cf string, ts uint64) error {
+	return rc.metaKVExporter.export(upstream, rewritten, cf, ts)
+}
+
+var MetaKVFilesDigest = metaKVFilesDigest
+
+func (rc *LogClient) TEST_MetaKVBatchSize() uint64 {
+	return rc.metaKVBatchSize()
+}
+
+func NewMetaKVSavepointForTest(
+	save func(ctx context.Context, sp *checkpoint.CheckpointMetaKVSavepoint) error,
+	digest string,
+	durable int,
+) *metaKVSavepoint {
+	return &metaKVSavepoint{save: save, digest: digest, durable: durable}
+}
+
+func (s *metaKVSavepoint) NextBatch() bool {
+	return s.nextBatch()
+}
+
+func (s *metaKVSavepoint) Advance(ctx context.Context, cf string, filterTS uint64) error {
+	return s.advance(ctx, cf, filterTS)
+}
+
+var LoadStoreZones = loadStoreZones
+
+func NewZoneLeaderManagerForTest(
+	client split.SplitClient,
+	storeZones map[uint64]string,
+	cfg ZoneLeaderConfig,
+	checkInterval time.Duration,
+) *ZoneLeaderManager {
+	m := newZoneLeaderManager(client, storeZones, cfg)
+	m.checkInterval = checkInterval
+	return m
+}
+
+type RewriteTSAllocator = rewriteTSAllocator
+
+func NewRewriteTSAllocator(getTS func(ctx context.Context) (uint64, error), batchKeys int) *RewriteTSAllocator {
+	return newRewriteTSAllocator(getTS, batchKeys)
+}
+
+func (a *rewriteTSAllocator) Next(ctx context.Context, rewriteTS uint64) (uint64, error) {
+	return a.next(ctx, rewriteTS)
+}
+
+func (a *rewriteTSAllocator) Range() RewriteTSRange {
+	return a.tsRng
+}
+
+// NewLogFileDeduperForTest returns the isDuplicate of a logFileDeduper remembering capacity keys.
+func NewLogFileDeduperForTest(capacity int) func(file *backuppb.DataFileInfo) bool {
+	return newLogFileDeduperWithCapacity(&duplicateFileStats{}, capacity).isDuplicate
+}
+
+func (g *DDLGate) SetCacheTTL(ttl time.Duration) {
+	g.cacheTTL = ttl
+}
+
+var TableRange = tableRange
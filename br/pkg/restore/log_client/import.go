@@ -240,10 +240,6 @@ func (importer *LogFileImporter) downloadAndApplyKVFile(
 			return RPCResultFromError(errors.Annotatef(berrors.ErrKVRewriteRuleNotFound,
 				"rewrite rule for file %+v not find (in %+v)", file, rules))
 		}
-		rule := import_sstpb.RewriteRule{
-			OldKeyPrefix: restoreutils.EncodeKeyPrefix(fileRule.GetOldKeyPrefix()),
-			NewKeyPrefix: restoreutils.EncodeKeyPrefix(fileRule.GetNewKeyPrefix()),
-		}
 
 		meta := &import_sstpb.KVMeta{
 			Name:        file.Path,
@@ -267,7 +263,7 @@ func (importer *LogFileImporter) downloadAndApplyKVFile(
 		}
 
 		metas = append(metas, meta)
-		rewriteRules = append(rewriteRules, &rule)
+		rewriteRules = append(rewriteRules, restoreutils.EncodeRewriteRule(fileRule))
 	}
 
 	reqCtx := &kvrpcpb.Context{
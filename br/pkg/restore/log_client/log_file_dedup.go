@@ -0,0 +1,112 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package logclient
+
+import (
+	"encoding/binary"
+	"sync"
+	"sync/atomic"
+
+	backuppb "github.com/pingcap/kvproto/pkg/brpb"
+	"github.com/pingcap/log"
+	"github.com/pingcap/tidb/br/pkg/logutil"
+	"go.uber.org/zap"
+)
+
+// logFileDeduper skips the data files having the same content as the files seen before. The log backup
+// task may flush the same kv events again after it restarts, and applying them again wastes time though
+// it's idempotent by MVCC. Only the files known to have the same content are skipped: the files of the
+// same SHA-256 checksum, or the files of the same CRC64XOR checksum of the kv pairs with the same key
+// range, ts range and count of the entries. The files partially overlapping are kept.
+//
+// The duplicate files are flushed soon after the original ones, so only the keys of the latest files are
+// remembered to bound the memory over a long restore window, the oldest key is forgotten once there are
+// capacity keys.
+type logFileDeduper struct {
+	mu   sync.Mutex
+	seen map[string]struct{}
+	// order is a ring of the keys in seen by the order they're added, next is the position of the oldest.
+	order []string
+	next  int
+
+	stats *duplicateFileStats
+}
+
+// defaultLogFileDedupCapacity is the number of the latest files remembered by the logFileDeduper.
+const defaultLogFileDedupCapacity = 256 * 1024
+
+// duplicateFileStats is the statistics of the data files skipped as duplicates.
+type duplicateFileStats struct {
+	files atomic.Int64
+	bytes atomic.Uint64
+}
+
+func (s *duplicateFileStats) add(file *backuppb.DataFileInfo) {
+	s.files.Add(1)
+	s.bytes.Add(file.Length)
+}
+
+func newLogFileDeduper(stats *duplicateFileStats) *logFileDeduper {
+	return newLogFileDeduperWithCapacity(stats, defaultLogFileDedupCapacity)
+}
+
+func newLogFileDeduperWithCapacity(stats *duplicateFileStats, capacity int) *logFileDeduper {
+	return &logFileDeduper{seen: make(map[string]struct{}), order: make([]string, 0, capacity), stats: stats}
+}
+
+// remember adds the key, and forgets the oldest key if there are too many keys. The caller must hold mu.
+func (d *logFileDeduper) remember(key string) {
+	d.seen[key] = struct{}{}
+	if len(d.order) < cap(d.order) {
+		d.order = append(d.order, key)
+		return
+	}
+	delete(d.seen, d.order[d.next])
+	d.order[d.next] = key
+	d.next = (d.next + 1) % len(d.order)
+}
+
+// logFileContentKey returns the key identifying the content of the file, false is returned if the file
+// doesn't have any checksum, e.g. written by the old versions.
+func logFileContentKey(file *backuppb.DataFileInfo) (string, bool) {
+	if len(file.Sha256) > 0 {
+		return "sha256:" + file.Cf + ":" + string(file.Sha256), true
+	}
+	if file.Crc64Xor == 0 {
+		return "", false
+	}
+	key := make([]byte, 0, 64+len(file.Cf)+len(file.StartKey)+len(file.EndKey))
+	key = append(key, "crc64xor:"...)
+	key = binary.BigEndian.AppendUint64(key, file.Crc64Xor)
+	key = binary.BigEndian.AppendUint64(key, uint64(file.NumberOfEntries))
+	key = binary.BigEndian.AppendUint64(key, file.MinTs)
+	key = binary.BigEndian.AppendUint64(key, file.MaxTs)
+	key = binary.BigEndian.AppendUint64(key, uint64(file.TableId))
+	key = binary.BigEndian.AppendUint64(key, uint64(len(file.Cf)))
+	key = append(key, file.Cf...)
+	key = binary.BigEndian.AppendUint64(key, uint64(len(file.StartKey)))
+	key = append(key, file.StartKey...)
+	key = append(key, file.EndKey...)
+	return string(key), true
+}
+
+// isDuplicate checks whether the file has the same content as a file seen before.
+func (d *logFileDeduper) isDuplicate(file *backuppb.DataFileInfo) bool {
+	key, ok := logFileContentKey(file)
+	if !ok {
+		return false
+	}
+	d.mu.Lock()
+	_, seen := d.seen[key]
+	if !seen {
+		d.remember(key)
+	}
+	d.mu.Unlock()
+	if seen {
+		log.Debug("skip the duplicate data file", zap.String("path", file.Path), zap.Uint64("offset", file.RangeOffset),
+			logutil.Key("start-key", file.StartKey), logutil.Key("end-key", file.EndKey),
+			zap.Uint64("min-ts", file.MinTs), zap.Uint64("max-ts", file.MaxTs))
+		d.stats.add(file)
+	}
+	return seen
+}
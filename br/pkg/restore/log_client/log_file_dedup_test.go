@@ -0,0 +1,88 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package logclient_test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	backuppb "github.com/pingcap/kvproto/pkg/brpb"
+	logclient "github.com/pingcap/tidb/br/pkg/restore/log_client"
+	"github.com/pingcap/tidb/br/pkg/utils/iter"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilterDuplicateDataFiles(t *testing.T) {
+	req := require.New(t)
+	ctx := context.Background()
+	loc, temp := (&mockMetaBuilder{
+		metas: nil,
+	}).b(true)
+	defer func() {
+		t.Log("temp dir", temp)
+		if !t.Failed() {
+			os.RemoveAll(temp)
+		}
+	}()
+	fm, err := logclient.CreateLogFileManager(ctx, logclient.LogFileManagerInit{
+		StartTS:   0,
+		RestoreTS: 10,
+		Storage:   loc,
+
+		MigrationsBuilder:         logclient.NewMigrationBuilder(0, 0, 10),
+		Migrations:                emptyMigrations(),
+		MetadataDownloadBatchSize: 32,
+	})
+	req.NoError(err)
+
+	withSha256 := func(f *backuppb.DataFileInfo, sum string, length uint64) *backuppb.DataFileInfo {
+		f.Sha256 = []byte(sum)
+		f.Length = length
+		return f
+	}
+	withCrc64Xor := func(f *backuppb.DataFileInfo, crc uint64, length uint64) *backuppb.DataFileInfo {
+		f.Crc64Xor = crc
+		f.StartKey = []byte("a")
+		f.EndKey = []byte("b")
+		f.Length = length
+		return f
+	}
+	metas := []*backuppb.Metadata{
+		m2(withSha256(wr(1, 2, 1), "sum1", 10), withCrc64Xor(wr(2, 3, 2), 42, 20), wr(3, 4, 3)),
+		// flushed again after the task restarts.
+		m2(withSha256(wr(1, 2, 1), "sum1", 10), withCrc64Xor(wr(2, 3, 2), 42, 20), wr(3, 4, 3)),
+		// the same checksum of the different cf or ts range.
+		m2(withSha256(dr(1, 2), "sum1", 10), withCrc64Xor(wr(2, 4, 2), 42, 20)),
+	}
+	metaIter := iter.Map(iter.FromSlice(metas), func(meta logclient.Meta) *logclient.MetaName {
+		return logclient.NewMetaName(meta, "")
+	})
+	files := iter.CollectAll(ctx, fm.FilterDataFiles(metaIter)).Item
+	restored := make([]*backuppb.DataFileInfo, 0, len(files))
+	for _, f := range files {
+		restored = append(restored, f.DataFileInfo)
+	}
+	req.Len(restored, 6)
+	req.NotContains(restored, metas[1].FileGroups[0].DataFilesInfo[0])
+	req.NotContains(restored, metas[1].FileGroups[0].DataFilesInfo[1])
+
+	dupFiles, dupBytes := fm.DuplicateDataFiles()
+	req.Equal(int64(2), dupFiles)
+	req.Equal(uint64(30), dupBytes)
+}
+
+func TestLogFileDeduperCapacity(t *testing.T) {
+	file := func(sum string) *backuppb.DataFileInfo {
+		return &backuppb.DataFileInfo{Sha256: []byte(sum), Cf: "write"}
+	}
+	isDuplicate := logclient.NewLogFileDeduperForTest(2)
+	require.False(t, isDuplicate(file("sum1")))
+	require.False(t, isDuplicate(file("sum2")))
+	require.True(t, isDuplicate(file("sum1")))
+	// the oldest key is forgotten.
+	require.False(t, isDuplicate(file("sum3")))
+	require.False(t, isDuplicate(file("sum1")))
+	require.True(t, isDuplicate(file("sum3")))
+	require.False(t, isDuplicate(file("sum2")))
+}
@@ -17,6 +17,7 @@ import (
 	"github.com/pingcap/log"
 	"github.com/pingcap/tidb/br/pkg/encryption"
 	berrors "github.com/pingcap/tidb/br/pkg/errors"
+	"github.com/pingcap/tidb/br/pkg/restore/membudget"
 	"github.com/pingcap/tidb/br/pkg/storage"
 	"github.com/pingcap/tidb/br/pkg/stream"
 	"github.com/pingcap/tidb/br/pkg/utils/iter"
@@ -36,6 +37,8 @@ type SubCompactionIter iter.TryNextor[*backuppb.LogFileSubcompaction]
 type MetaName struct {
 	meta Meta
 	name string
+	// tableIndex is the table ID index of the metadata, it's nil if the index isn't loaded or doesn't exist.
+	tableIndex *stream.TableIDIndex
 }
 
 // MetaNameIter is the type of iterator of metadata files' content with name.
@@ -107,6 +110,12 @@ type LogFileManager struct {
 	withMigrations      *WithMigrations
 
 	metadataDownloadBatchSize uint
+
+	// memTracker tracks the metadata of the DDL files held in memory, it's nil if not tracked.
+	memTracker *membudget.Tracker
+
+	// duplicates is the statistics of the data files skipped as duplicates.
+	duplicates duplicateFileStats
 }
 
 // LogFileManagerInit is the config needed for initializing the log file manager.
@@ -119,6 +128,7 @@ type LogFileManagerInit struct {
 	Migrations                *WithMigrations
 	MetadataDownloadBatchSize uint
 	EncryptionManager         *encryption.Manager
+	MemTracker                *membudget.Tracker
 }
 
 type DDLMetaGroup struct {
@@ -138,6 +148,7 @@ func CreateLogFileManager(ctx context.Context, init LogFileManagerInit) (*LogFil
 		withMigrations:      init.Migrations,
 
 		metadataDownloadBatchSize: init.MetadataDownloadBatchSize,
+		memTracker:                init.MemTracker,
 	}
 	err := fm.loadShiftTS(ctx)
 	if err != nil {
@@ -240,10 +251,25 @@ func (rc *LogFileManager) createMetaIterOver(ctx context.Context, s storage.Exte
 }
 
 func (rc *LogFileManager) FilterDataFiles(m MetaNameIter) LogIter {
+	return rc.filterDataFiles(m, nil, nil)
+}
+
+// filterDataFiles filters the data files like FilterDataFiles. If tableIDs isn't nil, the files of the
+// other tables are pruned too, by the table ID indexes of the physical files first, and then the table
+// IDs of the files. The files having the same content as the files before are skipped as duplicates.
+// onPruned is called with the number of entries of the pruned and skipped files if it isn't nil.
+func (rc *LogFileManager) filterDataFiles(m MetaNameIter, tableIDs map[int64]struct{}, onPruned func(kvCount int64)) LogIter {
 	ms := rc.withMigrations.Metas(m)
+	deduper := newLogFileDeduper(&rc.duplicates)
 	return iter.FlatMap(ms, func(m *MetaWithMigrations) LogIter {
 		gs := m.Physicals(iter.Enumerate(iter.FromSlice(m.meta.FileGroups)))
 		return iter.FlatMap(gs, func(gim *PhysicalWithMigrations) LogIter {
+			groupPruned := false
+			if tableIDs != nil {
+				if g := m.tableIndex.Group(gim.physical.Item.Path); g != nil && !g.MayContainAny(tableIDs) {
+					groupPruned = true
+				}
+			}
 			fs := iter.FilterOut(
 				gim.Logicals(iter.Enumerate(iter.FromSlice(gim.physical.Item.DataFilesInfo))),
 				func(di FileIndex) bool {
@@ -251,7 +277,22 @@ func (rc *LogFileManager) FilterDataFiles(m MetaNameIter) LogIter {
 					if m.meta.MetaVersion > backuppb.MetaVersion_V1 {
 						di.Item.Path = gim.physical.Item.Path
 					}
-					return di.Item.IsMeta || rc.ShouldFilterOut(di.Item)
+					if di.Item.IsMeta || rc.ShouldFilterOut(di.Item) {
+						return true
+					}
+					if tableIDs != nil {
+						if _, ok := tableIDs[di.Item.TableId]; groupPruned || !ok {
+							onPruned(di.Item.NumberOfEntries)
+							return true
+						}
+					}
+					if deduper.isDuplicate(di.Item) {
+						if onPruned != nil {
+							onPruned(di.Item.NumberOfEntries)
+						}
+						return true
+					}
+					return false
 				})
 			return iter.Map(fs, func(di FileIndex) *LogDataFileInfo {
 				return &LogDataFileInfo{
@@ -270,6 +311,12 @@ func (rc *LogFileManager) FilterDataFiles(m MetaNameIter) LogIter {
 	})
 }
 
+// DuplicateDataFiles returns the count and the bytes of the data files skipped as duplicates, which are
+// flushed more than once by the log backup task, e.g. after it restarts.
+func (rc *LogFileManager) DuplicateDataFiles() (files int64, bytes uint64) {
+	return rc.duplicates.files.Load(), rc.duplicates.bytes.Load()
+}
+
 // ShouldFilterOut checks whether a file should be filtered out via the current client.
 func (rc *LogFileManager) ShouldFilterOut(d *backuppb.DataFileInfo) bool {
 	return d.MinTs > rc.restoreTS ||
@@ -290,9 +337,17 @@ func (rc *LogFileManager) collectDDLFilesAndPrepareCache(
 	log.Info("finish to collect all ddl files", zap.Duration("take", time.Since(start)))
 
 	dataFileInfos := make([]*backuppb.DataFileInfo, 0)
+	var size int64
 	for _, g := range fs.Item {
 		rc.helper.InitCacheEntry(g.Path, len(g.FileMetas))
 		dataFileInfos = append(dataFileInfos, g.FileMetas...)
+		for _, f := range g.FileMetas {
+			size += int64(f.Size())
+		}
+	}
+	if rc.memTracker != nil {
+		// the DDL files are held until the meta kv entries are restored.
+		rc.memTracker.Consume(size)
 	}
 
 	return dataFileInfos, nil
@@ -322,6 +377,54 @@ func (rc *LogFileManager) LoadDMLFiles(ctx context.Context) (LogIter, error) {
 	return l, nil
 }
 
+// LoadDMLFilesOfTables loads the DML files like LoadDMLFiles, but prunes the files not belonging to the
+// tables before downloading them. The table ID indexes of the metadata are used to skip the whole
+// physical files if they exist. onPruned is called with the number of entries of the pruned files.
+func (rc *LogFileManager) LoadDMLFilesOfTables(
+	ctx context.Context,
+	tableIDs map[int64]struct{},
+	onPruned func(kvCount int64),
+) (LogIter, error) {
+	m, err := rc.streamingMeta(ctx)
+	if err != nil {
+		return nil, err
+	}
+	withIndex := iter.Transform(m, func(ctx context.Context, mn *MetaName) (*MetaName, error) {
+		index, err := stream.ReadTableIDIndex(ctx, rc.storage, mn.name)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		mn.tableIndex = index
+		return mn, nil
+	}, iter.WithChunkSize(rc.metadataDownloadBatchSize), iter.WithConcurrency(rc.metadataDownloadBatchSize))
+	return rc.filterDataFiles(withIndex, tableIDs, onPruned), nil
+}
+
+// DMLBytesOfTables estimates the bytes of the DML files of the tables in the restore window, keyed by the
+// table IDs. It's only an estimation: the files flushed more than once are counted repeatedly, and the
+// duplicated files aren't recorded as LoadDMLFiles does.
+func (rc *LogFileManager) DMLBytesOfTables(ctx context.Context, tableIDs map[int64]struct{}) (map[int64]uint64, error) {
+	m, err := rc.streamingMeta(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tableBytes := make(map[int64]uint64)
+	for r := m.TryNext(ctx); !r.Finished; r = m.TryNext(ctx) {
+		if r.Err != nil {
+			return nil, errors.Trace(r.Err)
+		}
+		for _, g := range r.Item.meta.FileGroups {
+			for _, d := range g.DataFilesInfo {
+				if _, ok := tableIDs[d.TableId]; !ok || d.IsMeta || rc.ShouldFilterOut(d) {
+					continue
+				}
+				tableBytes[d.TableId] += d.GetLength()
+			}
+		}
+	}
+	return tableBytes, nil
+}
+
 func (rc *LogFileManager) FilterMetaFiles(ms MetaNameIter) MetaGroupIter {
 	return iter.FlatMap(ms, func(m *MetaName) MetaGroupIter {
 		return iter.Map(iter.FromSlice(m.meta.FileGroups), func(g *backuppb.DataFileGroup) DDLMetaGroup {
@@ -335,7 +438,8 @@ func (rc *LogFileManager) FilterMetaFiles(ms MetaNameIter) MetaGroupIter {
 				}
 				// count the progress
 				TotalEntryCount += d.NumberOfEntries
-				return !d.IsMeta
+				// the history ddl jobs are also stored in the table since the concurrent DDL.
+				return !d.IsMeta && !stream.IsDDLHistoryTableFile(d)
 			})
 			return DDLMetaGroup{
 				Path: g.Path,
@@ -351,6 +455,20 @@ func (rc *LogFileManager) GetCompactionIter(ctx context.Context) iter.TryNextor[
 	return rc.withMigrations.Compactions(ctx, rc.storage)
 }
 
+// GetCompactionIterOfTables fetches the compactions like GetCompactionIter, but only reads the
+// subcompactions of the tables in the ts window of the restore. The compaction indexes are used to skip
+// the meta files without such subcompactions, and built if they don't exist.
+func (rc *LogFileManager) GetCompactionIterOfTables(
+	ctx context.Context,
+	tableIDs map[int64]struct{},
+) iter.TryNextor[*backuppb.LogFileSubcompaction] {
+	return rc.withMigrations.CompactionsOfTables(ctx, rc.storage, &CompactionFilter{
+		TableIDs:  tableIDs,
+		StartTS:   rc.shiftStartTS,
+		RestoreTS: rc.restoreTS,
+	})
+}
+
 // the kv entry with ts, the ts is decoded from entry.
 type KvEntryWithTS struct {
 	E  kv.Entry
@@ -373,19 +491,36 @@ func (rc *LogFileManager) ReadAllEntries(
 	file Log,
 	filterTS uint64,
 ) ([]*KvEntryWithTS, []*KvEntryWithTS, error) {
-	kvEntries := make([]*KvEntryWithTS, 0)
-	nextKvEntries := make([]*KvEntryWithTS, 0)
+	buff, err := rc.readLogFile(ctx, file)
+	if err != nil {
+		return nil, nil, errors.Trace(err)
+	}
+	return rc.parseAllEntries(file, buff, filterTS)
+}
 
+// readLogFile downloads the content of a log file and verifies its checksum.
+func (rc *LogFileManager) readLogFile(ctx context.Context, file Log) ([]byte, error) {
 	buff, err := rc.helper.ReadFile(ctx, file.Path, file.RangeOffset, file.RangeLength, file.CompressionType,
 		rc.storage, file.FileEncryptionInfo)
 	if err != nil {
-		return nil, nil, errors.Trace(err)
+		return nil, errors.Trace(err)
 	}
 
 	if checksum := sha256.Sum256(buff); !bytes.Equal(checksum[:], file.GetSha256()) {
-		return nil, nil, berrors.ErrInvalidMetaFile.GenWithStackByArgs(fmt.Sprintf(
+		return nil, berrors.ErrInvalidMetaFile.GenWithStackByArgs(fmt.Sprintf(
 			"checksum mismatch expect %x, got %x", file.GetSha256(), checksum[:]))
 	}
+	return buff, nil
+}
+
+// parseAllEntries parses the content of a log file, with filtering out no needed entries.
+func (rc *LogFileManager) parseAllEntries(
+	file Log,
+	buff []byte,
+	filterTS uint64,
+) ([]*KvEntryWithTS, []*KvEntryWithTS, error) {
+	kvEntries := make([]*KvEntryWithTS, 0)
+	nextKvEntries := make([]*KvEntryWithTS, 0)
 
 	iter := stream.NewEventIterator(buff)
 	for iter.Valid() {
@@ -396,8 +531,9 @@ func (rc *LogFileManager) ReadAllEntries(
 
 		txnEntry := kv.Entry{Key: iter.Key(), Value: iter.Value()}
 
-		if !stream.MaybeDBOrDDLJobHistoryKey(txnEntry.Key) {
-			// only restore mDB and mDDLHistory
+		if !stream.MaybeDBOrDDLJobHistoryKey(txnEntry.Key) && !stream.IsDDLHistoryTableKey(txnEntry.Key) &&
+			!stream.IsClusterMetaKey(txnEntry.Key) {
+			// only restore mDB, mDDLHistory, the rows of the ddl history table and the cluster meta
 			continue
 		}
 
@@ -645,3 +645,56 @@ func TestReadAllEntries(t *testing.T) {
 		}, nextKvEntries)
 	}
 }
+
+func TestLoadDMLFilesOfTables(t *testing.T) {
+	req := require.New(t)
+	ctx := context.Background()
+	tf := func(tableID int64) *backuppb.DataFileInfo {
+		f := wr(1, 1, 1)
+		f.TableId = tableID
+		f.NumberOfEntries = 10
+		return f
+	}
+	builder := &mockMetaBuilder{metas: []*backuppb.Metadata{
+		m2(tf(1), tf(2), tf(3)),
+		m2(tf(1), tf(2)),
+		m2(tf(2), tf(3)),
+	}}
+	loc, temp := builder.b(true)
+	defer func() {
+		t.Log("temp dir", temp)
+		if !t.Failed() {
+			os.RemoveAll(temp)
+		}
+	}()
+	metaPath := func(i int) string {
+		return path.Join(stream.GetStreamBackupMetaPrefix(), fmt.Sprintf("%06d.meta", i))
+	}
+	req.NoError(stream.WriteTableIDIndex(ctx, loc, metaPath(2), builder.metas[2]))
+	// the index of the second metadata claims there is no table 1, so the whole physical file is pruned.
+	fakeMeta := m2(tf(2))
+	fakeMeta.FileGroups[0].Path = builder.metas[1].FileGroups[0].Path
+	req.NoError(stream.WriteTableIDIndex(ctx, loc, metaPath(1), fakeMeta))
+
+	fm, err := logclient.CreateLogFileManager(ctx, logclient.LogFileManagerInit{
+		StartTS:   0,
+		RestoreTS: 10,
+		Storage:   loc,
+
+		MigrationsBuilder:         logclient.NewMigrationBuilder(0, 0, 10),
+		Migrations:                emptyMigrations(),
+		MetadataDownloadBatchSize: 32,
+	})
+	req.NoError(err)
+	var pruned int64
+	files, err := fm.LoadDMLFilesOfTables(ctx, map[int64]struct{}{1: {}}, func(kvCount int64) {
+		pruned += kvCount
+	})
+	req.NoError(err)
+	result := iter.CollectAll(ctx, files)
+	req.NoError(result.Err)
+	req.Len(result.Item, 1)
+	req.EqualValues(1, result.Item[0].TableId)
+	req.Equal(builder.metas[0].FileGroups[0].Path, result.Item[0].Path)
+	req.EqualValues(60, pruned)
+}
@@ -0,0 +1,86 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package logclient
+
+import (
+	"context"
+	"sync"
+
+	"github.com/pingcap/errors"
+)
+
+// maxLogFilePrefetchCount is the max number of the log files downloaded ahead of being read.
+const maxLogFilePrefetchCount = 16
+
+type prefetchedLogFile struct {
+	buff []byte
+	err  error
+}
+
+// logFilePrefetcher downloads the log files concurrently in the order of the files, which are sorted by
+// ts, so the files can be rewritten and restored while the later ones are being downloaded. The files
+// must be read in the same order. At most the count of the window files are downloaded but not read yet,
+// to bound the memory held by the prefetched content.
+type logFilePrefetcher struct {
+	files   []Log
+	results []chan prefetchedLogFile
+	next    int
+
+	window chan struct{}
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// prefetchLogFiles starts downloading the files in order, the prefetcher must be closed after used.
+func (rc *LogFileManager) prefetchLogFiles(ctx context.Context, files []Log) *logFilePrefetcher {
+	window := min(max(rc.metadataDownloadBatchSize, 1), maxLogFilePrefetchCount)
+	ctx, cancel := context.WithCancel(ctx)
+	p := &logFilePrefetcher{
+		files:   files,
+		results: make([]chan prefetchedLogFile, len(files)),
+		window:  make(chan struct{}, window),
+		cancel:  cancel,
+	}
+	for i := range p.results {
+		p.results[i] = make(chan prefetchedLogFile, 1)
+	}
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		for i, file := range files {
+			select {
+			case p.window <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			p.wg.Add(1)
+			go func() {
+				defer p.wg.Done()
+				buff, err := rc.readLogFile(ctx, file)
+				p.results[i] <- prefetchedLogFile{buff: buff, err: err}
+			}()
+		}
+	}()
+	return p
+}
+
+// read returns the content of the file, which must be the next one of the files to prefetch.
+func (p *logFilePrefetcher) read(ctx context.Context, file Log) ([]byte, error) {
+	if p.next >= len(p.files) || p.files[p.next] != file {
+		return nil, errors.Errorf("the log file %s isn't read in the order of prefetching", file.Path)
+	}
+	select {
+	case r := <-p.results[p.next]:
+		p.next++
+		<-p.window
+		return r.buff, errors.Trace(r.err)
+	case <-ctx.Done():
+		return nil, errors.Trace(ctx.Err())
+	}
+}
+
+// close stops downloading the files not read yet.
+func (p *logFilePrefetcher) close() {
+	p.cancel()
+	p.wg.Wait()
+}
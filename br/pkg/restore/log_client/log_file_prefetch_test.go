@@ -0,0 +1,53 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package logclient_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"testing"
+
+	backuppb "github.com/pingcap/kvproto/pkg/brpb"
+	logclient "github.com/pingcap/tidb/br/pkg/restore/log_client"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrefetchLogFiles(t *testing.T) {
+	ctx := context.Background()
+	var data []byte
+	files := make([]logclient.Log, 0, 40)
+	for i := range 40 {
+		content := []byte(fmt.Sprintf("content of file %d", i))
+		checksum := sha256.Sum256(content)
+		files = append(files, &backuppb.DataFileInfo{
+			Path:        fmt.Sprintf("file-%d", i),
+			RangeOffset: uint64(len(data)),
+			RangeLength: uint64(len(content)),
+			Sha256:      checksum[:],
+		})
+		data = append(data, content...)
+	}
+	fm := logclient.TEST_NewLogFileManager(0, 100, 0, &logclient.FakeStreamMetadataHelper{Data: data})
+
+	buffs, err := fm.TEST_ReadPrefetchedLogFiles(ctx, files, files)
+	require.NoError(t, err)
+	require.Len(t, buffs, len(files))
+	for i, buff := range buffs {
+		require.Equal(t, fmt.Sprintf("content of file %d", i), string(buff))
+	}
+
+	// only read a part of the files.
+	buffs, err = fm.TEST_ReadPrefetchedLogFiles(ctx, files, files[:3])
+	require.NoError(t, err)
+	require.Len(t, buffs, 3)
+
+	// the files must be read in order.
+	_, err = fm.TEST_ReadPrefetchedLogFiles(ctx, files, []logclient.Log{files[0], files[2]})
+	require.ErrorContains(t, err, "isn't read in the order of prefetching")
+
+	// the checksum mismatch is reported when the file is read.
+	files[1].Sha256 = []byte("mismatch")
+	_, err = fm.TEST_ReadPrefetchedLogFiles(ctx, files, files)
+	require.ErrorContains(t, err, "checksum mismatch")
+}
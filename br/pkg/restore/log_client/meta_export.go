@@ -0,0 +1,112 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package logclient
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/log"
+	berrors "github.com/pingcap/tidb/br/pkg/errors"
+	"github.com/pingcap/tidb/br/pkg/restore/log_client/metaexportpb"
+	"github.com/pingcap/tidb/br/pkg/stream"
+	"github.com/pingcap/tidb/br/pkg/utils"
+	"github.com/pingcap/tidb/pkg/kv"
+	"github.com/pingcap/tidb/pkg/meta"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+)
+
+// metaKVExporter streams the rewritten meta kv entries to an external consumer.
+type metaKVExporter struct {
+	addr   string
+	conn   *grpc.ClientConn
+	stream metaexportpb.MetaEntryConsumer_ExportMetaEntriesClient
+	count  uint64
+}
+
+// EnableMetaKVExport makes the client stream the rewritten meta kv entries to the MetaEntryConsumer
+// service at addr instead of applying them to TiKV, the client uses the TLS config of the cluster.
+// FinishMetaKVExport must be called after the meta kv files are restored.
+func (rc *LogClient) EnableMetaKVExport(ctx context.Context, addr string) error {
+	conn, err := utils.GRPCConn(ctx, addr, rc.tlsConf)
+	if err != nil {
+		return errors.Annotatef(err, "failed to connect to the meta entry consumer %s", addr)
+	}
+	stream, err := metaexportpb.NewMetaEntryConsumerClient(conn).ExportMetaEntries(ctx)
+	if err != nil {
+		_ = conn.Close()
+		return errors.Annotatef(err, "failed to export meta entries to %s", addr)
+	}
+	rc.metaKVExporter = &metaKVExporter{addr: addr, conn: conn, stream: stream}
+	return nil
+}
+
+// FinishMetaKVExport closes the stream of the meta kv entries, and checks whether the consumer has
+// received all of them.
+func (rc *LogClient) FinishMetaKVExport() (uint64, error) {
+	e := rc.metaKVExporter
+	if e == nil {
+		return 0, nil
+	}
+	resp, err := e.stream.CloseAndRecv()
+	if err != nil {
+		return 0, errors.Annotatef(err, "failed to finish exporting meta entries to %s", e.addr)
+	}
+	if resp.Count != e.count {
+		return 0, errors.Annotatef(berrors.ErrUnknown,
+			"the meta entry consumer %s received %d entries, but %d entries are sent", e.addr, resp.Count, e.count)
+	}
+	log.Info("finish exporting meta entries", zap.String("addr", e.addr), zap.Uint64("count", e.count))
+	return e.count, nil
+}
+
+func (e *metaKVExporter) export(upstream, rewritten *kv.Entry, cf string, ts uint64) error {
+	entry := &metaexportpb.MetaEntry{
+		Key:         rewritten.Key,
+		Value:       rewritten.Value,
+		Cf:          cf,
+		Ts:          ts,
+		UpstreamKey: upstream.Key,
+		Annotations: annotateMetaKey(rewritten.Key),
+	}
+	if err := e.stream.Send(entry); err != nil {
+		return errors.Annotatef(err, "failed to export meta entry to %s", e.addr)
+	}
+	e.count++
+	return nil
+}
+
+func (e *metaKVExporter) close() {
+	if err := e.conn.Close(); err != nil {
+		log.Warn("failed to close the connection to the meta entry consumer", zap.String("addr", e.addr), zap.Error(err))
+	}
+}
+
+// annotateMetaKey returns the IDs of the database and table of the meta key.
+func annotateMetaKey(key []byte) map[string]string {
+	rawKey, err := stream.ParseTxnMetaKeyFrom(key)
+	if err != nil {
+		return nil
+	}
+	annotations := make(map[string]string, 2)
+	if meta.IsDBkey(rawKey.Field) {
+		if dbID, err := meta.ParseDBKey(rawKey.Field); err == nil {
+			annotations["db-id"] = strconv.FormatInt(dbID, 10)
+		}
+		return annotations
+	}
+	if !meta.IsDBkey(rawKey.Key) {
+		return annotations
+	}
+	if dbID, err := meta.ParseDBKey(rawKey.Key); err == nil {
+		annotations["db-id"] = strconv.FormatInt(dbID, 10)
+	}
+	if meta.IsTableKey(rawKey.Field) {
+		if tableID, err := meta.ParseTableKey(rawKey.Field); err == nil {
+			annotations["table-id"] = strconv.FormatInt(tableID, 10)
+		}
+	}
+	return annotations
+}
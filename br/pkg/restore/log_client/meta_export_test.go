@@ -0,0 +1,82 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package logclient_test
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+
+	logclient "github.com/pingcap/tidb/br/pkg/restore/log_client"
+	"github.com/pingcap/tidb/br/pkg/restore/log_client/metaexportpb"
+	"github.com/pingcap/tidb/br/pkg/stream"
+	"github.com/pingcap/tidb/pkg/kv"
+	"github.com/pingcap/tidb/pkg/meta"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/keepalive"
+)
+
+type mockMetaEntryConsumer struct {
+	entries []*metaexportpb.MetaEntry
+}
+
+func (c *mockMetaEntryConsumer) ExportMetaEntries(s metaexportpb.MetaEntryConsumer_ExportMetaEntriesServer) error {
+	for {
+		entry, err := s.Recv()
+		if err == io.EOF {
+			return s.SendAndClose(&metaexportpb.ExportMetaEntriesResponse{Count: uint64(len(c.entries))})
+		}
+		if err != nil {
+			return err
+		}
+		c.entries = append(c.entries, entry)
+	}
+}
+
+func TestExportMetaKVEntries(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	consumer := &mockMetaEntryConsumer{}
+	server := grpc.NewServer()
+	metaexportpb.RegisterMetaEntryConsumerServer(server, consumer)
+	go func() {
+		_ = server.Serve(lis)
+	}()
+	defer server.Stop()
+
+	ctx := context.Background()
+	client := logclient.NewRestoreClient(nil, nil, nil, keepalive.ClientParameters{})
+	defer client.Close(ctx)
+	require.NoError(t, client.EnableMetaKVExport(ctx, lis.Addr().String()))
+
+	metaKey := func(key, field []byte) []byte {
+		return (&stream.RawMetaKey{Key: key, Field: field, Ts: 400}).EncodeMetaKey()
+	}
+	tableKey := metaKey(meta.DBkey(2), meta.TableKey(3))
+	dbKey := metaKey([]byte("DBs"), meta.DBkey(2))
+	require.NoError(t, client.TEST_exportMetaKVEntry(
+		&kv.Entry{Key: metaKey(meta.DBkey(12), meta.TableKey(13))},
+		&kv.Entry{Key: tableKey, Value: []byte("table")},
+		"default", 400,
+	))
+	require.NoError(t, client.TEST_exportMetaKVEntry(
+		&kv.Entry{Key: metaKey([]byte("DBs"), meta.DBkey(12))},
+		&kv.Entry{Key: dbKey, Value: []byte("db")},
+		"write", 401,
+	))
+	count, err := client.FinishMetaKVExport()
+	require.NoError(t, err)
+	require.EqualValues(t, 2, count)
+
+	require.Len(t, consumer.entries, 2)
+	require.Equal(t, tableKey, consumer.entries[0].Key)
+	require.Equal(t, []byte("table"), consumer.entries[0].Value)
+	require.Equal(t, "default", consumer.entries[0].Cf)
+	require.EqualValues(t, 400, consumer.entries[0].Ts)
+	require.Equal(t, metaKey(meta.DBkey(12), meta.TableKey(13)), consumer.entries[0].UpstreamKey)
+	require.Equal(t, map[string]string{"db-id": "2", "table-id": "3"}, consumer.entries[0].Annotations)
+	require.Equal(t, "write", consumer.entries[1].Cf)
+	require.Equal(t, map[string]string{"db-id": "2"}, consumer.entries[1].Annotations)
+}
@@ -0,0 +1,115 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package logclient
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+
+	"github.com/pingcap/errors"
+	backuppb "github.com/pingcap/kvproto/pkg/brpb"
+	"github.com/pingcap/log"
+	"github.com/pingcap/tidb/br/pkg/checkpoint"
+	"go.uber.org/zap"
+)
+
+// metaKVSavepoint tracks the batches of the meta kv apply. The batches are numbered in the order they're
+// applied, which only depends on the sorted meta kv files, and the savepoint is saved into the checkpoint
+// after all entries of a batch are put into the cluster. So the batches before the savepoint of the
+// previous run are durable, they're still rewritten to rebuild the states collected by the SchemasReplace,
+// but their entries aren't put again.
+//
+// A nil metaKVSavepoint means the savepoint is disabled, all batches are applied.
+type metaKVSavepoint struct {
+	save   func(ctx context.Context, sp *checkpoint.CheckpointMetaKVSavepoint) error
+	digest string
+	// durable is the number of the batches applied durably by the previous runs.
+	durable int
+	// batches is the number of the batches met in this run.
+	batches int
+}
+
+// metaKVFilesDigest returns the digest of the meta kv files and the batch limit, which decide how the files are
+// split into batches.
+func metaKVFilesDigest(batchLimit uint64, defaultFiles, writeFiles []*backuppb.DataFileInfo) string {
+	h := sha256.New()
+	var buf [8]byte
+	writeUint := func(v uint64) {
+		binary.BigEndian.PutUint64(buf[:], v)
+		_, _ = h.Write(buf[:])
+	}
+	writeUint(batchLimit)
+	for _, files := range [][]*backuppb.DataFileInfo{defaultFiles, writeFiles} {
+		writeUint(uint64(len(files)))
+		for _, f := range files {
+			writeUint(uint64(len(f.Path)))
+			_, _ = h.Write([]byte(f.Path))
+			writeUint(f.RangeOffset)
+			writeUint(f.Length)
+			writeUint(f.MinTs)
+			writeUint(f.MaxTs)
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// newMetaKVSavepoint loads the savepoint of the meta kv apply from the checkpoint, the savepoint is
+// enabled only if the checkpoint is used and the entries are put into the cluster.
+func (rc *LogClient) newMetaKVSavepoint(
+	ctx context.Context,
+	defaultFiles, writeFiles []*backuppb.DataFileInfo,
+) (*metaKVSavepoint, error) {
+	if !rc.useCheckpoint || rc.metaKVExporter != nil {
+		return nil, nil
+	}
+	s := &metaKVSavepoint{
+		save: func(ctx context.Context, sp *checkpoint.CheckpointMetaKVSavepoint) error {
+			return checkpoint.SaveCheckpointMetaKVSavepoint(ctx, rc.unsafeSession, sp)
+		},
+		digest: metaKVFilesDigest(rc.metaKVBatchSize(), defaultFiles, writeFiles),
+	}
+	if !checkpoint.ExistsCheckpointMetaKVSavepoint(ctx, rc.dom) {
+		return s, nil
+	}
+	sp, err := checkpoint.LoadCheckpointMetaKVSavepoint(ctx, rc.unsafeSession.GetSessionCtx().GetRestrictedSQLExecutor())
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if sp.FilesDigest != s.digest {
+		log.Warn("the meta kv files are changed since the savepoint is saved, re-apply all of them",
+			zap.String("digest", s.digest), zap.String("savepoint-digest", sp.FilesDigest))
+		return s, nil
+	}
+	log.Info("load the savepoint of the meta kv apply", zap.Int("applied-batches", sp.AppliedBatches),
+		zap.String("last-cf", sp.LastCF), zap.Uint64("last-filter-ts", sp.LastFilterTS))
+	s.durable = sp.AppliedBatches
+	return s, nil
+}
+
+// nextBatch moves to the next batch and returns whether it's applied durably by the previous runs.
+func (s *metaKVSavepoint) nextBatch() bool {
+	if s == nil {
+		return false
+	}
+	s.batches++
+	return s.batches <= s.durable
+}
+
+// advance saves the savepoint after the current batch is applied.
+func (s *metaKVSavepoint) advance(ctx context.Context, cf string, filterTS uint64) error {
+	if s == nil || s.batches <= s.durable {
+		return nil
+	}
+	if err := s.save(ctx, &checkpoint.CheckpointMetaKVSavepoint{
+		FilesDigest:    s.digest,
+		AppliedBatches: s.batches,
+		LastFilterTS:   filterTS,
+		LastCF:         cf,
+	}); err != nil {
+		return errors.Annotatef(err, "failed to save the savepoint of the meta kv batch %d", s.batches)
+	}
+	s.durable = s.batches
+	return nil
+}
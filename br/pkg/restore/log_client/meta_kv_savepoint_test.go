@@ -0,0 +1,85 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package logclient_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pingcap/errors"
+	backuppb "github.com/pingcap/kvproto/pkg/brpb"
+	"github.com/pingcap/tidb/br/pkg/checkpoint"
+	logclient "github.com/pingcap/tidb/br/pkg/restore/log_client"
+	"github.com/pingcap/tidb/br/pkg/stream"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetaKVSavepoint(t *testing.T) {
+	ctx := context.Background()
+	defaultFiles := []*backuppb.DataFileInfo{
+		{Path: "f1", MinTs: 100, MaxTs: 120, Length: logclient.MetaKVBatchSize},
+		{Path: "f2", MinTs: 130, MaxTs: 150, Length: logclient.MetaKVBatchSize},
+		{Path: "f3", MinTs: 160, MaxTs: 180, Length: logclient.MetaKVBatchSize},
+	}
+	writeFiles := []*backuppb.DataFileInfo{
+		{Path: "f4", MinTs: 100, MaxTs: 120, Length: 1},
+		{Path: "f5", MinTs: 130, MaxTs: 150, Length: 1},
+		{Path: "f6", MinTs: 160, MaxTs: 180, Length: 1},
+	}
+	digest := logclient.MetaKVFilesDigest(logclient.MetaKVBatchSize, defaultFiles, writeFiles)
+	require.Equal(t, digest, logclient.MetaKVFilesDigest(logclient.MetaKVBatchSize, defaultFiles, writeFiles))
+	require.NotEqual(t, digest, logclient.MetaKVFilesDigest(logclient.MetaKVBatchSize, defaultFiles[:2], writeFiles))
+	require.NotEqual(t, digest, logclient.MetaKVFilesDigest(logclient.MetaKVBatchSize, writeFiles, defaultFiles))
+	// the files are split into other batches if the batch size is bounded by the memory budget.
+	require.NotEqual(t, digest, logclient.MetaKVFilesDigest(1024, defaultFiles, writeFiles))
+
+	var saved *checkpoint.CheckpointMetaKVSavepoint
+	save := func(_ context.Context, sp *checkpoint.CheckpointMetaKVSavepoint) error {
+		saved = sp
+		return nil
+	}
+	run := func(savepoint interface {
+		NextBatch() bool
+		Advance(ctx context.Context, cf string, filterTS uint64) error
+	}, failAt int) (applied []string, err error) {
+		batches := 0
+		err = logclient.RestoreMetaKVFilesWithBatchMethod(ctx, defaultFiles, writeFiles, nil, nil, nil,
+			func(
+				_ context.Context,
+				files []*backuppb.DataFileInfo,
+				_ *stream.SchemasReplace,
+				_ []*logclient.KvEntryWithTS,
+				filterTS uint64,
+				_ func(uint64, uint64),
+				_ func(),
+				cf string,
+			) ([]*logclient.KvEntryWithTS, error) {
+				batches++
+				durable := savepoint.NextBatch()
+				if batches == failAt {
+					return nil, errors.New("crashed")
+				}
+				if !durable {
+					for _, f := range files {
+						applied = append(applied, f.Path)
+					}
+				}
+				return nil, savepoint.Advance(ctx, cf, filterTS)
+			})
+		return applied, err
+	}
+
+	// crash at the 4th batch, the batches of default cf f1 and write cf f4 and f5 are durable.
+	applied, err := run(logclient.NewMetaKVSavepointForTest(save, digest, 0), 4)
+	require.ErrorContains(t, err, "crashed")
+	require.Equal(t, []string{"f1", "f4", "f2"}, applied)
+	require.Equal(t, &checkpoint.CheckpointMetaKVSavepoint{
+		FilesDigest: digest, AppliedBatches: 3, LastFilterTS: 160, LastCF: stream.DefaultCF,
+	}, saved)
+
+	// resume from the savepoint, only the batches after the savepoint are applied.
+	applied, err = run(logclient.NewMetaKVSavepointForTest(save, digest, saved.AppliedBatches), 0)
+	require.NoError(t, err)
+	require.Equal(t, []string{"f5", "f3", "f6"}, applied)
+	require.Equal(t, 6, saved.AppliedBatches)
+}
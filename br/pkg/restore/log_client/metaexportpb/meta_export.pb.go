@@ -0,0 +1,966 @@
+// Code generated by protoc-gen-gogo. DO NOT EDIT.
+// source: br/pkg/restore/log_client/metaexportpb/meta_export.proto
+
+package metaexportpb
+
+import (
+	context "context"
+	fmt "fmt"
+	proto "github.com/gogo/protobuf/proto"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	io "io"
+	math "math"
+	math_bits "math/bits"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.GoGoProtoPackageIsVersion3 // please upgrade the proto package
+
+// MetaEntry is a meta kv entry of the log backup rewritten for the restored cluster.
+type MetaEntry struct {
+	// key is the rewritten key.
+	Key []byte `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	// value is the rewritten value.
+	Value []byte `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	// cf is the column family of the entry, "default" or "write".
+	Cf string `protobuf:"bytes,3,opt,name=cf,proto3" json:"cf,omitempty"`
+	// ts is the commit ts of the entry in the log backup.
+	Ts uint64 `protobuf:"varint,4,opt,name=ts,proto3" json:"ts,omitempty"`
+	// upstream_key is the key before rewriting.
+	UpstreamKey []byte `protobuf:"bytes,5,opt,name=upstream_key,json=upstreamKey,proto3" json:"upstream_key,omitempty"`
+	// annotations describe the entry, e.g. "db-id" and "table-id" of the meta key.
+	Annotations map[string]string `protobuf:"bytes,6,rep,name=annotations,proto3" json:"annotations,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+}
+
+func (m *MetaEntry) Reset()         { *m = MetaEntry{} }
+func (m *MetaEntry) String() string { return proto.CompactTextString(m) }
+func (*MetaEntry) ProtoMessage()    {}
+func (*MetaEntry) Descriptor() ([]byte, []int) {
+	return fileDescriptor_61408bc7d6dcda64, []int{0}
+}
+func (m *MetaEntry) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *MetaEntry) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_MetaEntry.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *MetaEntry) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_MetaEntry.Merge(m, src)
+}
+func (m *MetaEntry) XXX_Size() int {
+	return m.Size()
+}
+func (m *MetaEntry) XXX_DiscardUnknown() {
+	xxx_messageInfo_MetaEntry.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_MetaEntry proto.InternalMessageInfo
+
+func (m *MetaEntry) GetKey() []byte {
+	if m != nil {
+		return m.Key
+	}
+	return nil
+}
+
+func (m *MetaEntry) GetValue() []byte {
+	if m != nil {
+		return m.Value
+	}
+	return nil
+}
+
+func (m *MetaEntry) GetCf() string {
+	if m != nil {
+		return m.Cf
+	}
+	return ""
+}
+
+func (m *MetaEntry) GetTs() uint64 {
+	if m != nil {
+		return m.Ts
+	}
+	return 0
+}
+
+func (m *MetaEntry) GetUpstreamKey() []byte {
+	if m != nil {
+		return m.UpstreamKey
+	}
+	return nil
+}
+
+func (m *MetaEntry) GetAnnotations() map[string]string {
+	if m != nil {
+		return m.Annotations
+	}
+	return nil
+}
+
+type ExportMetaEntriesResponse struct {
+	// count is the number of the entries received.
+	Count uint64 `protobuf:"varint,1,opt,name=count,proto3" json:"count,omitempty"`
+}
+
+func (m *ExportMetaEntriesResponse) Reset()         { *m = ExportMetaEntriesResponse{} }
+func (m *ExportMetaEntriesResponse) String() string { return proto.CompactTextString(m) }
+func (*ExportMetaEntriesResponse) ProtoMessage()    {}
+func (*ExportMetaEntriesResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_61408bc7d6dcda64, []int{1}
+}
+func (m *ExportMetaEntriesResponse) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *ExportMetaEntriesResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_ExportMetaEntriesResponse.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *ExportMetaEntriesResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ExportMetaEntriesResponse.Merge(m, src)
+}
+func (m *ExportMetaEntriesResponse) XXX_Size() int {
+	return m.Size()
+}
+func (m *ExportMetaEntriesResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ExportMetaEntriesResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ExportMetaEntriesResponse proto.InternalMessageInfo
+
+func (m *ExportMetaEntriesResponse) GetCount() uint64 {
+	if m != nil {
+		return m.Count
+	}
+	return 0
+}
+
+func init() {
+	proto.RegisterType((*MetaEntry)(nil), "metaexportpb.MetaEntry")
+	proto.RegisterMapType((map[string]string)(nil), "metaexportpb.MetaEntry.AnnotationsEntry")
+	proto.RegisterType((*ExportMetaEntriesResponse)(nil), "metaexportpb.ExportMetaEntriesResponse")
+}
+
+func init() {
+	proto.RegisterFile("br/pkg/restore/log_client/metaexportpb/meta_export.proto", fileDescriptor_61408bc7d6dcda64)
+}
+
+var fileDescriptor_61408bc7d6dcda64 = []byte{
+	// 329 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x74, 0x51, 0x4d, 0x4f, 0xc2, 0x40,
+	0x14, 0xec, 0x96, 0x8f, 0xa4, 0x0b, 0x31, 0xb0, 0x31, 0xb1, 0x72, 0x68, 0x2a, 0x07, 0xed, 0xa9,
+	0x8d, 0x78, 0x21, 0x1e, 0x4c, 0xd4, 0x70, 0xd1, 0x78, 0xe9, 0x4d, 0x2f, 0xa4, 0x34, 0x0f, 0x42,
+	0x80, 0xdd, 0xcd, 0xee, 0xab, 0x91, 0xab, 0xbf, 0xc0, 0x9f, 0xe5, 0x91, 0xa3, 0x47, 0x03, 0x7f,
+	0xc4, 0x74, 0x11, 0x2c, 0x2a, 0xb7, 0x9d, 0xd9, 0x37, 0xf3, 0x66, 0xf2, 0x68, 0x77, 0xa0, 0x22,
+	0x39, 0x19, 0x45, 0x0a, 0x34, 0x0a, 0x05, 0xd1, 0x54, 0x8c, 0xfa, 0xe9, 0x74, 0x0c, 0x1c, 0xa3,
+	0x19, 0x60, 0x02, 0x2f, 0x52, 0x28, 0x94, 0x03, 0x03, 0xfa, 0x6b, 0x14, 0x4a, 0x25, 0x50, 0xb0,
+	0x7a, 0xf1, 0xbf, 0xfd, 0x6a, 0x53, 0xe7, 0x01, 0x30, 0xe9, 0x71, 0x54, 0x73, 0xd6, 0xa0, 0xa5,
+	0x09, 0xcc, 0x5d, 0xe2, 0x93, 0xa0, 0x1e, 0xe7, 0x4f, 0x76, 0x48, 0x2b, 0xcf, 0xc9, 0x34, 0x03,
+	0xd7, 0x36, 0xdc, 0x1a, 0xb0, 0x03, 0x6a, 0xa7, 0x43, 0xb7, 0xe4, 0x93, 0xc0, 0x89, 0xed, 0x74,
+	0x98, 0x63, 0xd4, 0x6e, 0xd9, 0x27, 0x41, 0x39, 0xb6, 0x51, 0xb3, 0x13, 0x5a, 0xcf, 0xa4, 0x46,
+	0x05, 0xc9, 0xac, 0x9f, 0x1b, 0x56, 0x8c, 0xb8, 0xb6, 0xe1, 0xee, 0x61, 0xce, 0xee, 0x68, 0x2d,
+	0xe1, 0x5c, 0x60, 0x82, 0x63, 0xc1, 0xb5, 0x5b, 0xf5, 0x4b, 0x41, 0xad, 0x13, 0x84, 0xc5, 0x70,
+	0xe1, 0x36, 0x58, 0x78, 0xfd, 0x33, 0x6a, 0x88, 0xb8, 0x28, 0x6e, 0x5d, 0xd1, 0xc6, 0xef, 0x81,
+	0x62, 0x15, 0xe7, 0x9f, 0x2a, 0xce, 0x77, 0x95, 0x4b, 0xbb, 0x4b, 0xda, 0xe7, 0xf4, 0xb8, 0x67,
+	0x76, 0x6e, 0x16, 0x8e, 0x41, 0xc7, 0xa0, 0xa5, 0xe0, 0x1a, 0x72, 0x59, 0x2a, 0x32, 0x8e, 0xc6,
+	0xaa, 0x1c, 0xaf, 0x41, 0x87, 0xd3, 0xe6, 0x36, 0xdd, 0xad, 0xe0, 0x3a, 0x9b, 0x81, 0x62, 0x8f,
+	0xb4, 0xf9, 0xc7, 0x87, 0x1d, 0xed, 0xe9, 0xd4, 0x3a, 0xdb, 0xfd, 0xd8, 0x9b, 0xa0, 0x6d, 0x05,
+	0xe4, 0xe6, 0xf4, 0x7d, 0xe9, 0x91, 0xc5, 0xd2, 0x23, 0x9f, 0x4b, 0x8f, 0xbc, 0xad, 0x3c, 0x6b,
+	0xb1, 0xf2, 0xac, 0x8f, 0x95, 0x67, 0x3d, 0xed, 0xdc, 0x73, 0x50, 0x35, 0x47, 0xbe, 0xf8, 0x1a,
+	0x00, 0x87, 0x5d, 0xb8, 0x9f, 0x20, 0x02, 0x00, 0x00,
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConn
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion4
+
+// MetaEntryConsumerClient is the client API for MetaEntryConsumer service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type MetaEntryConsumerClient interface {
+	// ExportMetaEntries receives the entries in the order of the restore.
+	ExportMetaEntries(ctx context.Context, opts ...grpc.CallOption) (MetaEntryConsumer_ExportMetaEntriesClient, error)
+}
+
+type metaEntryConsumerClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewMetaEntryConsumerClient(cc *grpc.ClientConn) MetaEntryConsumerClient {
+	return &metaEntryConsumerClient{cc}
+}
+
+func (c *metaEntryConsumerClient) ExportMetaEntries(ctx context.Context, opts ...grpc.CallOption) (MetaEntryConsumer_ExportMetaEntriesClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_MetaEntryConsumer_serviceDesc.Streams[0], "/metaexportpb.MetaEntryConsumer/ExportMetaEntries", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &metaEntryConsumerExportMetaEntriesClient{stream}
+	return x, nil
+}
+
+type MetaEntryConsumer_ExportMetaEntriesClient interface {
+	Send(*MetaEntry) error
+	CloseAndRecv() (*ExportMetaEntriesResponse, error)
+	grpc.ClientStream
+}
+
+type metaEntryConsumerExportMetaEntriesClient struct {
+	grpc.ClientStream
+}
+
+func (x *metaEntryConsumerExportMetaEntriesClient) Send(m *MetaEntry) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *metaEntryConsumerExportMetaEntriesClient) CloseAndRecv() (*ExportMetaEntriesResponse, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	m := new(ExportMetaEntriesResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// MetaEntryConsumerServer is the server API for MetaEntryConsumer service.
+type MetaEntryConsumerServer interface {
+	// ExportMetaEntries receives the entries in the order of the restore.
+	ExportMetaEntries(MetaEntryConsumer_ExportMetaEntriesServer) error
+}
+
+// UnimplementedMetaEntryConsumerServer can be embedded to have forward compatible implementations.
+type UnimplementedMetaEntryConsumerServer struct {
+}
+
+func (*UnimplementedMetaEntryConsumerServer) ExportMetaEntries(srv MetaEntryConsumer_ExportMetaEntriesServer) error {
+	return status.Errorf(codes.Unimplemented, "method ExportMetaEntries not implemented")
+}
+
+func RegisterMetaEntryConsumerServer(s *grpc.Server, srv MetaEntryConsumerServer) {
+	s.RegisterService(&_MetaEntryConsumer_serviceDesc, srv)
+}
+
+func _MetaEntryConsumer_ExportMetaEntries_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(MetaEntryConsumerServer).ExportMetaEntries(&metaEntryConsumerExportMetaEntriesServer{stream})
+}
+
+type MetaEntryConsumer_ExportMetaEntriesServer interface {
+	SendAndClose(*ExportMetaEntriesResponse) error
+	Recv() (*MetaEntry, error)
+	grpc.ServerStream
+}
+
+type metaEntryConsumerExportMetaEntriesServer struct {
+	grpc.ServerStream
+}
+
+func (x *metaEntryConsumerExportMetaEntriesServer) SendAndClose(m *ExportMetaEntriesResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *metaEntryConsumerExportMetaEntriesServer) Recv() (*MetaEntry, error) {
+	m := new(MetaEntry)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+var _MetaEntryConsumer_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "metaexportpb.MetaEntryConsumer",
+	HandlerType: (*MetaEntryConsumerServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ExportMetaEntries",
+			Handler:       _MetaEntryConsumer_ExportMetaEntries_Handler,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "br/pkg/restore/log_client/metaexportpb/meta_export.proto",
+}
+
+func (m *MetaEntry) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *MetaEntry) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *MetaEntry) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Annotations) > 0 {
+		for k := range m.Annotations {
+			v := m.Annotations[k]
+			baseI := i
+			i -= len(v)
+			copy(dAtA[i:], v)
+			i = encodeVarintMetaExport(dAtA, i, uint64(len(v)))
+			i--
+			dAtA[i] = 0x12
+			i -= len(k)
+			copy(dAtA[i:], k)
+			i = encodeVarintMetaExport(dAtA, i, uint64(len(k)))
+			i--
+			dAtA[i] = 0xa
+			i = encodeVarintMetaExport(dAtA, i, uint64(baseI-i))
+			i--
+			dAtA[i] = 0x32
+		}
+	}
+	if len(m.UpstreamKey) > 0 {
+		i -= len(m.UpstreamKey)
+		copy(dAtA[i:], m.UpstreamKey)
+		i = encodeVarintMetaExport(dAtA, i, uint64(len(m.UpstreamKey)))
+		i--
+		dAtA[i] = 0x2a
+	}
+	if m.Ts != 0 {
+		i = encodeVarintMetaExport(dAtA, i, uint64(m.Ts))
+		i--
+		dAtA[i] = 0x20
+	}
+	if len(m.Cf) > 0 {
+		i -= len(m.Cf)
+		copy(dAtA[i:], m.Cf)
+		i = encodeVarintMetaExport(dAtA, i, uint64(len(m.Cf)))
+		i--
+		dAtA[i] = 0x1a
+	}
+	if len(m.Value) > 0 {
+		i -= len(m.Value)
+		copy(dAtA[i:], m.Value)
+		i = encodeVarintMetaExport(dAtA, i, uint64(len(m.Value)))
+		i--
+		dAtA[i] = 0x12
+	}
+	if len(m.Key) > 0 {
+		i -= len(m.Key)
+		copy(dAtA[i:], m.Key)
+		i = encodeVarintMetaExport(dAtA, i, uint64(len(m.Key)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *ExportMetaEntriesResponse) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *ExportMetaEntriesResponse) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *ExportMetaEntriesResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.Count != 0 {
+		i = encodeVarintMetaExport(dAtA, i, uint64(m.Count))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
+func encodeVarintMetaExport(dAtA []byte, offset int, v uint64) int {
+	offset -= sovMetaExport(v)
+	base := offset
+	for v >= 1<<7 {
+		dAtA[offset] = uint8(v&0x7f | 0x80)
+		v >>= 7
+		offset++
+	}
+	dAtA[offset] = uint8(v)
+	return base
+}
+func (m *MetaEntry) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.Key)
+	if l > 0 {
+		n += 1 + l + sovMetaExport(uint64(l))
+	}
+	l = len(m.Value)
+	if l > 0 {
+		n += 1 + l + sovMetaExport(uint64(l))
+	}
+	l = len(m.Cf)
+	if l > 0 {
+		n += 1 + l + sovMetaExport(uint64(l))
+	}
+	if m.Ts != 0 {
+		n += 1 + sovMetaExport(uint64(m.Ts))
+	}
+	l = len(m.UpstreamKey)
+	if l > 0 {
+		n += 1 + l + sovMetaExport(uint64(l))
+	}
+	if len(m.Annotations) > 0 {
+		for k, v := range m.Annotations {
+			_ = k
+			_ = v
+			mapEntrySize := 1 + len(k) + sovMetaExport(uint64(len(k))) + 1 + len(v) + sovMetaExport(uint64(len(v)))
+			n += mapEntrySize + 1 + sovMetaExport(uint64(mapEntrySize))
+		}
+	}
+	return n
+}
+
+func (m *ExportMetaEntriesResponse) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Count != 0 {
+		n += 1 + sovMetaExport(uint64(m.Count))
+	}
+	return n
+}
+
+func sovMetaExport(x uint64) (n int) {
+	return (math_bits.Len64(x|1) + 6) / 7
+}
+func sozMetaExport(x uint64) (n int) {
+	return sovMetaExport(uint64((x << 1) ^ uint64((int64(x) >> 63))))
+}
+func (m *MetaEntry) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowMetaExport
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: MetaEntry: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: MetaEntry: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Key", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMetaExport
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthMetaExport
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthMetaExport
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Key = append(m.Key[:0], dAtA[iNdEx:postIndex]...)
+			if m.Key == nil {
+				m.Key = []byte{}
+			}
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Value", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMetaExport
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthMetaExport
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthMetaExport
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Value = append(m.Value[:0], dAtA[iNdEx:postIndex]...)
+			if m.Value == nil {
+				m.Value = []byte{}
+			}
+			iNdEx = postIndex
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Cf", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMetaExport
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthMetaExport
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthMetaExport
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Cf = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Ts", wireType)
+			}
+			m.Ts = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMetaExport
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Ts |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 5:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field UpstreamKey", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMetaExport
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthMetaExport
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthMetaExport
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.UpstreamKey = append(m.UpstreamKey[:0], dAtA[iNdEx:postIndex]...)
+			if m.UpstreamKey == nil {
+				m.UpstreamKey = []byte{}
+			}
+			iNdEx = postIndex
+		case 6:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Annotations", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMetaExport
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthMetaExport
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthMetaExport
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Annotations == nil {
+				m.Annotations = make(map[string]string)
+			}
+			var mapkey string
+			var mapvalue string
+			for iNdEx < postIndex {
+				entryPreIndex := iNdEx
+				var wire uint64
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return ErrIntOverflowMetaExport
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					wire |= uint64(b&0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				fieldNum := int32(wire >> 3)
+				if fieldNum == 1 {
+					var stringLenmapkey uint64
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return ErrIntOverflowMetaExport
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						stringLenmapkey |= uint64(b&0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+					intStringLenmapkey := int(stringLenmapkey)
+					if intStringLenmapkey < 0 {
+						return ErrInvalidLengthMetaExport
+					}
+					postStringIndexmapkey := iNdEx + intStringLenmapkey
+					if postStringIndexmapkey < 0 {
+						return ErrInvalidLengthMetaExport
+					}
+					if postStringIndexmapkey > l {
+						return io.ErrUnexpectedEOF
+					}
+					mapkey = string(dAtA[iNdEx:postStringIndexmapkey])
+					iNdEx = postStringIndexmapkey
+				} else if fieldNum == 2 {
+					var stringLenmapvalue uint64
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return ErrIntOverflowMetaExport
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						stringLenmapvalue |= uint64(b&0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+					intStringLenmapvalue := int(stringLenmapvalue)
+					if intStringLenmapvalue < 0 {
+						return ErrInvalidLengthMetaExport
+					}
+					postStringIndexmapvalue := iNdEx + intStringLenmapvalue
+					if postStringIndexmapvalue < 0 {
+						return ErrInvalidLengthMetaExport
+					}
+					if postStringIndexmapvalue > l {
+						return io.ErrUnexpectedEOF
+					}
+					mapvalue = string(dAtA[iNdEx:postStringIndexmapvalue])
+					iNdEx = postStringIndexmapvalue
+				} else {
+					iNdEx = entryPreIndex
+					skippy, err := skipMetaExport(dAtA[iNdEx:])
+					if err != nil {
+						return err
+					}
+					if (skippy < 0) || (iNdEx+skippy) < 0 {
+						return ErrInvalidLengthMetaExport
+					}
+					if (iNdEx + skippy) > postIndex {
+						return io.ErrUnexpectedEOF
+					}
+					iNdEx += skippy
+				}
+			}
+			m.Annotations[mapkey] = mapvalue
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipMetaExport(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthMetaExport
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *ExportMetaEntriesResponse) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowMetaExport
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: ExportMetaEntriesResponse: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: ExportMetaEntriesResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Count", wireType)
+			}
+			m.Count = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMetaExport
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Count |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipMetaExport(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthMetaExport
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func skipMetaExport(dAtA []byte) (n int, err error) {
+	l := len(dAtA)
+	iNdEx := 0
+	depth := 0
+	for iNdEx < l {
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return 0, ErrIntOverflowMetaExport
+			}
+			if iNdEx >= l {
+				return 0, io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		wireType := int(wire & 0x7)
+		switch wireType {
+		case 0:
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return 0, ErrIntOverflowMetaExport
+				}
+				if iNdEx >= l {
+					return 0, io.ErrUnexpectedEOF
+				}
+				iNdEx++
+				if dAtA[iNdEx-1] < 0x80 {
+					break
+				}
+			}
+		case 1:
+			iNdEx += 8
+		case 2:
+			var length int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return 0, ErrIntOverflowMetaExport
+				}
+				if iNdEx >= l {
+					return 0, io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				length |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if length < 0 {
+				return 0, ErrInvalidLengthMetaExport
+			}
+			iNdEx += length
+		case 3:
+			depth++
+		case 4:
+			if depth == 0 {
+				return 0, ErrUnexpectedEndOfGroupMetaExport
+			}
+			depth--
+		case 5:
+			iNdEx += 4
+		default:
+			return 0, fmt.Errorf("proto: illegal wireType %d", wireType)
+		}
+		if iNdEx < 0 {
+			return 0, ErrInvalidLengthMetaExport
+		}
+		if depth == 0 {
+			return iNdEx, nil
+		}
+	}
+	return 0, io.ErrUnexpectedEOF
+}
+
+var (
+	ErrInvalidLengthMetaExport        = fmt.Errorf("proto: negative length found during unmarshaling")
+	ErrIntOverflowMetaExport          = fmt.Errorf("proto: integer overflow")
+	ErrUnexpectedEndOfGroupMetaExport = fmt.Errorf("proto: unexpected end of group")
+)
@@ -19,6 +19,7 @@ import (
 
 	backuppb "github.com/pingcap/kvproto/pkg/brpb"
 	"github.com/pingcap/tidb/br/pkg/storage"
+	"github.com/pingcap/tidb/br/pkg/stream"
 	"github.com/pingcap/tidb/br/pkg/utils/iter"
 )
 
@@ -143,7 +144,7 @@ func (builder *WithMigrationsBuilder) coarseGrainedFilter(mig *backuppb.Migratio
 // Create the wrapper by migrations.
 func (builder *WithMigrationsBuilder) Build(migs []*backuppb.Migration) WithMigrations {
 	skipmap := make(metaSkipMap)
-	compactionDirs := make([]string, 0, 8)
+	compactions := make([]*backuppb.LogFileCompaction, 0, 8)
 
 	for _, mig := range migs {
 		// TODO: deal with TruncatedTo and DestructPrefix
@@ -152,13 +153,11 @@ func (builder *WithMigrationsBuilder) Build(migs []*backuppb.Migration) WithMigr
 		}
 		builder.updateSkipMap(skipmap, mig.EditMeta)
 
-		for _, c := range mig.Compactions {
-			compactionDirs = append(compactionDirs, c.Artifacts)
-		}
+		compactions = append(compactions, mig.Compactions...)
 	}
 	withMigrations := WithMigrations{
-		skipmap:        skipmap,
-		compactionDirs: compactionDirs,
+		skipmap:     skipmap,
+		compactions: compactions,
 	}
 	return withMigrations
 }
@@ -184,8 +183,9 @@ func (pwm *PhysicalWithMigrations) Logicals(fileIndexIter FileIndexIter) FileInd
 type MetaMigrationsIter = iter.TryNextor[*MetaWithMigrations]
 
 type MetaWithMigrations struct {
-	skipmap physicalSkipMap
-	meta    Meta
+	skipmap    physicalSkipMap
+	meta       Meta
+	tableIndex *stream.TableIDIndex
 }
 
 func (mwm *MetaWithMigrations) Physicals(groupIndexIter GroupIndexIter) PhysicalMigrationsIter {
@@ -208,8 +208,8 @@ func (mwm *MetaWithMigrations) Physicals(groupIndexIter GroupIndexIter) Physical
 }
 
 type WithMigrations struct {
-	skipmap        metaSkipMap
-	compactionDirs []string
+	skipmap     metaSkipMap
+	compactions []*backuppb.LogFileCompaction
 }
 
 func (wm *WithMigrations) Metas(metaNameIter MetaNameIter) MetaMigrationsIter {
@@ -225,16 +225,29 @@ func (wm *WithMigrations) Metas(metaNameIter MetaNameIter) MetaMigrationsIter {
 			}
 		}
 		return &MetaWithMigrations{
-			skipmap: phySkipmap,
-			meta:    mname.meta,
+			skipmap:    phySkipmap,
+			meta:       mname.meta,
+			tableIndex: mname.tableIndex,
 		}, false
 	})
 }
 
 func (wm *WithMigrations) Compactions(ctx context.Context, s storage.ExternalStorage) iter.TryNextor[*backuppb.LogFileSubcompaction] {
-	compactionDirIter := iter.FromSlice(wm.compactionDirs)
-	return iter.FlatMap(compactionDirIter, func(name string) iter.TryNextor[*backuppb.LogFileSubcompaction] {
-		// name is the absolute path in external storage.
-		return Subcompactions(ctx, name, s)
+	compactionIter := iter.FromSlice(wm.compactions)
+	return iter.FlatMap(compactionIter, func(c *backuppb.LogFileCompaction) iter.TryNextor[*backuppb.LogFileSubcompaction] {
+		// the artifacts is the absolute path in external storage.
+		return Subcompactions(ctx, c.Artifacts, s)
+	})
+}
+
+// CompactionsOfTables is like Compactions, but only reads the subcompactions selected by the filter.
+func (wm *WithMigrations) CompactionsOfTables(
+	ctx context.Context,
+	s storage.ExternalStorage,
+	filter *CompactionFilter,
+) iter.TryNextor[*backuppb.LogFileSubcompaction] {
+	compactionIter := iter.FromSlice(wm.compactions)
+	return iter.FlatMap(compactionIter, func(c *backuppb.LogFileCompaction) iter.TryNextor[*backuppb.LogFileSubcompaction] {
+		return SubcompactionsOfTables(ctx, c, s, filter)
 	})
 }
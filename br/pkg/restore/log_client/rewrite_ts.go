@@ -0,0 +1,88 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package logclient
+
+import (
+	"context"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/log"
+	berrors "github.com/pingcap/tidb/br/pkg/errors"
+	"github.com/pingcap/tidb/br/pkg/restore"
+	"github.com/tikv/client-go/v2/oracle"
+	"go.uber.org/zap"
+)
+
+// RewriteTSRange is the range of the commit ts allocated to the meta kv entries in batches.
+type RewriteTSRange struct {
+	// First and Last are the first and last ts allocated, they're 0 if no ts is allocated.
+	First uint64
+	Last  uint64
+	// Batches is the number of the ts allocated.
+	Batches int
+}
+
+// rewriteTSAllocator allocates a fresh commit ts for every batch of the meta kv entries in the write CF,
+// instead of rewriting all of them to the single RewriteTS. The entries are restored in the order of
+// their upstream commit ts, so the ts allocated must be strictly increasing to keep the later versions
+// of a key after the earlier ones.
+type rewriteTSAllocator struct {
+	getTS     func(ctx context.Context) (uint64, error)
+	batchKeys int
+
+	keys  int
+	tsRng RewriteTSRange
+}
+
+func newRewriteTSAllocator(getTS func(ctx context.Context) (uint64, error), batchKeys int) *rewriteTSAllocator {
+	return &rewriteTSAllocator{getTS: getTS, batchKeys: batchKeys}
+}
+
+// next returns the commit ts of the next entry, a new ts is allocated once the current one has been used
+// by the batch of entries. The first ts allocated must be after the RewriteTS.
+func (a *rewriteTSAllocator) next(ctx context.Context, rewriteTS uint64) (uint64, error) {
+	if a.tsRng.Batches > 0 && a.keys < a.batchKeys {
+		a.keys++
+		return a.tsRng.Last, nil
+	}
+	ts, err := a.getTS(ctx)
+	if err != nil {
+		return 0, errors.Trace(err)
+	}
+	prev := max(a.tsRng.Last, rewriteTS)
+	if ts <= prev {
+		return 0, errors.Annotatef(berrors.ErrRestoreTSRegression,
+			"the ts %d(%s) allocated for the meta kv entries isn't after the previous ts %d(%s)",
+			ts, oracle.GetTimeFromTS(ts), prev, oracle.GetTimeFromTS(prev))
+	}
+	if a.tsRng.Batches == 0 {
+		a.tsRng.First = ts
+	}
+	a.tsRng.Last = ts
+	a.tsRng.Batches++
+	a.keys = 1
+	log.Debug("allocated the rewrite ts of the meta kv entries", zap.Uint64("ts", ts),
+		zap.Int("batches", a.tsRng.Batches))
+	return ts, nil
+}
+
+// SetRewriteTSBatchKeys allocates a fresh commit ts from PD for every batch of the keys meta kv entries,
+// 0 rewrites all the entries to the RewriteTS.
+func (rc *LogClient) SetRewriteTSBatchKeys(keys int) {
+	if keys <= 0 {
+		rc.rewriteTSAllocator = nil
+		return
+	}
+	rc.rewriteTSAllocator = newRewriteTSAllocator(func(ctx context.Context) (uint64, error) {
+		return restore.GetTSWithRetry(ctx, rc.pdClient)
+	}, keys)
+}
+
+// AllocatedRewriteTSRange returns the range of the commit ts allocated to the meta kv entries in batches,
+// false is returned if the batching is disabled.
+func (rc *LogClient) AllocatedRewriteTSRange() (RewriteTSRange, bool) {
+	if rc.rewriteTSAllocator == nil {
+		return RewriteTSRange{}, false
+	}
+	return rc.rewriteTSAllocator.tsRng, true
+}
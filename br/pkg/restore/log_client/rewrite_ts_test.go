@@ -0,0 +1,38 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package logclient_test
+
+import (
+	"context"
+	"testing"
+
+	berrors "github.com/pingcap/tidb/br/pkg/errors"
+	logclient "github.com/pingcap/tidb/br/pkg/restore/log_client"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRewriteTSAllocator(t *testing.T) {
+	ctx := context.Background()
+	newAllocator := func(tss ...uint64) *logclient.RewriteTSAllocator {
+		return logclient.NewRewriteTSAllocator(func(context.Context) (uint64, error) {
+			ts := tss[0]
+			tss = tss[1:]
+			return ts, nil
+		}, 2)
+	}
+
+	a := newAllocator(110, 120, 115)
+	for _, expected := range []uint64{110, 110, 120, 120} {
+		ts, err := a.Next(ctx, 100)
+		require.NoError(t, err)
+		require.Equal(t, expected, ts)
+	}
+	require.Equal(t, logclient.RewriteTSRange{First: 110, Last: 120, Batches: 2}, a.Range())
+	// the ts must be strictly increasing.
+	_, err := a.Next(ctx, 100)
+	require.ErrorIs(t, err, berrors.ErrRestoreTSRegression)
+
+	// the first ts must be after the RewriteTS.
+	_, err = newAllocator(100).Next(ctx, 100)
+	require.ErrorIs(t, err, berrors.ErrRestoreTSRegression)
+}
@@ -0,0 +1,304 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package logclient
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/log"
+	berrors "github.com/pingcap/tidb/br/pkg/errors"
+	restoreutils "github.com/pingcap/tidb/br/pkg/restore/utils"
+	"github.com/pingcap/tidb/br/pkg/stream"
+	"github.com/pingcap/tidb/pkg/kv"
+	tidbutil "github.com/pingcap/tidb/pkg/util"
+	"github.com/pingcap/tidb/pkg/util/codec"
+	"github.com/pingcap/tidb/pkg/util/redact"
+	"go.uber.org/zap"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	// maxReportedMismatches is the max number of the mismatches kept in the SampleVerifyResult.
+	maxReportedMismatches = 10
+	// sampleVerifyBatchKeys is the max number of the keys read back by a BatchGet.
+	sampleVerifyBatchKeys = 256
+	// sampleVerifyConcurrency is the number of the BatchGets sent concurrently.
+	sampleVerifyConcurrency = 16
+	// sampleVerifyPendingKeys is the max number of the keys collected from the sampled files before they're
+	// read back, which bounds the memory.
+	sampleVerifyPendingKeys = 64 * 1024
+)
+
+// sampledFile is the write CF file sampled to verify, with the rewrite rules it's imported by.
+type sampledFile struct {
+	file *LogDataFileInfo
+	rule *restoreutils.RewriteRules
+}
+
+// sampleVerifier samples the write CF files imported by the log restore. The keys in the sampled files are
+// read back from the target cluster after the restore, so the fraction of the keys verified is about the
+// sample rate, without the cost of a full checksum.
+type sampleVerifier struct {
+	rate float64
+
+	mu    sync.Mutex
+	rand  *rand.Rand
+	files []sampledFile
+}
+
+func newSampleVerifier(rate float64, seed int64) *sampleVerifier {
+	return &sampleVerifier{rate: rate, rand: rand.New(rand.NewSource(seed))}
+}
+
+// sample samples the files imported by the rule.
+func (v *sampleVerifier) sample(files []*LogDataFileInfo, rule *restoreutils.RewriteRules) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	for _, file := range files {
+		// the values in the default CF are verified by the write CF entries referring to them.
+		if file.Cf != stream.WriteCF || file.GetNumberOfEntries() == 0 {
+			continue
+		}
+		if v.rand.Float64() < v.rate {
+			v.files = append(v.files, sampledFile{file: file, rule: rule})
+		}
+	}
+}
+
+// sampledKey is a PUT or DELETE record in a sampled file, to be read back at its commit ts.
+type sampledKey struct {
+	file  string
+	key   []byte
+	isPut bool
+	// value is the short value of the PUT record, nil if the value is in the default CF.
+	value         []byte
+	hasShortValue bool
+}
+
+// SampleMismatch is a key whose value read back from the target cluster differs from the log backup.
+type SampleMismatch struct {
+	File     string `json:"file"`
+	Key      string `json:"key"`
+	CommitTS uint64 `json:"commit-ts"`
+	Reason   string `json:"reason"`
+}
+
+// SampleVerifyResult is the result of verifying the sampled keys.
+type SampleVerifyResult struct {
+	// Files is the number of the sampled files.
+	Files int
+	// Keys is the number of the keys read back.
+	Keys int
+	// ExistenceOnly is the number of the keys whose values are in the default CF, only their existence is
+	// verified.
+	ExistenceOnly int
+	// MismatchCount is the number of the mismatched keys, the first of them are kept in Mismatches.
+	MismatchCount int
+	Mismatches    []SampleMismatch
+}
+
+func (r *SampleVerifyResult) addMismatch(mismatch SampleMismatch) {
+	r.MismatchCount++
+	if len(r.Mismatches) < maxReportedMismatches {
+		r.Mismatches = append(r.Mismatches, mismatch)
+	}
+}
+
+// SetVerifySampleRate sets the fraction of the restored keys verified after the kv files are restored,
+// 0 disables the verification.
+func (rc *LogClient) SetVerifySampleRate(rate float64) {
+	if rate <= 0 {
+		rc.sampleVerifier = nil
+		return
+	}
+	rc.sampleVerifier = newSampleVerifier(rate, time.Now().UnixNano())
+}
+
+// VerifySampledKVs reads the keys of the sampled files back from the target cluster, at the commit ts of
+// every version, and compares them against the entries in the log backup after rewritten. It must be
+// called before the GC is resumed, otherwise the old versions may be gone. An error is returned if any
+// key mismatches.
+func (rc *LogClient) VerifySampledKVs(ctx context.Context, store kv.Storage) (*SampleVerifyResult, error) {
+	result := &SampleVerifyResult{}
+	if rc.sampleVerifier == nil {
+		return result, nil
+	}
+	start := time.Now()
+	// the keys are grouped by the commit ts, so the keys of the same ts are read back by a BatchGet.
+	pending := make(map[uint64][]sampledKey)
+	pendingKeys := 0
+	for _, sampled := range rc.sampleVerifier.files {
+		buff, err := rc.readLogFile(ctx, sampled.file.DataFileInfo)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		n, err := rc.collectSampledKeys(sampled, buff, pending)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		result.Files++
+		pendingKeys += n
+		if pendingKeys >= sampleVerifyPendingKeys {
+			if err := verifySampledKeys(ctx, store, pending, result); err != nil {
+				return nil, errors.Trace(err)
+			}
+			pending = make(map[uint64][]sampledKey)
+			pendingKeys = 0
+		}
+	}
+	if err := verifySampledKeys(ctx, store, pending, result); err != nil {
+		return nil, errors.Trace(err)
+	}
+	log.Info("verified the sampled keys of the log restore", zap.Int("files", result.Files),
+		zap.Int("keys", result.Keys), zap.Int("existence-only", result.ExistenceOnly),
+		zap.Int("mismatches", result.MismatchCount), zap.Duration("take", time.Since(start)))
+	if result.MismatchCount > 0 {
+		for _, mismatch := range result.Mismatches {
+			log.Error("the restored key mismatches the log backup", zap.Any("mismatch", mismatch))
+		}
+		return result, errors.Annotatef(berrors.ErrRestoreVerifyFailed,
+			"%d of %d sampled keys mismatch the log backup, the first one is %s: %s", result.MismatchCount,
+			result.Keys, result.Mismatches[0].Key, result.Mismatches[0].Reason)
+	}
+	return result, nil
+}
+
+// collectSampledKeys collects the PUT and DELETE records of the write CF file in the restore window by
+// their commit ts, and returns the number of the records collected.
+func (rc *LogClient) collectSampledKeys(
+	sampled sampledFile,
+	buff []byte,
+	keysByTS map[uint64][]sampledKey,
+) (int, error) {
+	iter := stream.NewEventIterator(buff)
+	var writeValue stream.RawWriteCFValue
+	n := 0
+	for iter.Valid() {
+		iter.Next()
+		if iter.GetError() != nil {
+			return 0, errors.Trace(iter.GetError())
+		}
+		txnKey, value := iter.Key(), iter.Value()
+		commitTS, err := getKeyTS(txnKey)
+		if err != nil {
+			return 0, errors.Trace(err)
+		}
+		if commitTS < rc.startTS || commitTS > rc.restoreTS || len(value) == 0 {
+			continue
+		}
+		if err := writeValue.ParseFrom(value); err != nil {
+			return 0, errors.Trace(err)
+		}
+		isPut := writeValue.GetWriteType() == stream.WriteTypePut
+		if !isPut && !writeValue.IsDelete() {
+			// the locks and rollbacks don't change the value.
+			continue
+		}
+		_, rawKey, err := codec.DecodeBytes(txnKey[:len(txnKey)-8], nil)
+		if err != nil {
+			return 0, errors.Trace(err)
+		}
+		newKey, ok := rewriteSampledKey(rawKey, sampled.rule)
+		if !ok {
+			continue
+		}
+		key := sampledKey{file: sampled.file.Path, key: newKey, isPut: isPut, hasShortValue: writeValue.HasShortValue()}
+		if key.hasShortValue {
+			key.value = bytes.Clone(writeValue.GetShortValue())
+		}
+		keysByTS[commitTS] = append(keysByTS[commitTS], key)
+		n++
+	}
+	return n, nil
+}
+
+// verifySampledKeys reads the keys back from the target cluster by BatchGets at their commit ts, and
+// records the result.
+func verifySampledKeys(
+	ctx context.Context,
+	store kv.Storage,
+	keysByTS map[uint64][]sampledKey,
+	result *SampleVerifyResult,
+) error {
+	var mu sync.Mutex
+	eg, ectx := errgroup.WithContext(ctx)
+	pool := tidbutil.NewWorkerPool(sampleVerifyConcurrency, "verify sampled keys")
+	for commitTS, keys := range keysByTS {
+		for len(keys) > 0 {
+			batch := keys[:min(len(keys), sampleVerifyBatchKeys)]
+			keys = keys[len(batch):]
+			pool.ApplyOnErrorGroup(eg, func() error {
+				batchResult, err := verifySampledBatch(ectx, store, commitTS, batch)
+				if err != nil {
+					return errors.Trace(err)
+				}
+				mu.Lock()
+				defer mu.Unlock()
+				result.Keys += batchResult.Keys
+				result.ExistenceOnly += batchResult.ExistenceOnly
+				for _, mismatch := range batchResult.Mismatches {
+					result.addMismatch(mismatch)
+				}
+				// the mismatches beyond the ones kept by the batch are counted too.
+				result.MismatchCount += batchResult.MismatchCount - len(batchResult.Mismatches)
+				return nil
+			})
+		}
+	}
+	return errors.Trace(eg.Wait())
+}
+
+// verifySampledBatch reads the keys of the same commit ts back by a BatchGet.
+func verifySampledBatch(
+	ctx context.Context,
+	store kv.Storage,
+	commitTS uint64,
+	keys []sampledKey,
+) (*SampleVerifyResult, error) {
+	kvKeys := make([]kv.Key, 0, len(keys))
+	for _, key := range keys {
+		kvKeys = append(kvKeys, key.key)
+	}
+	values, err := store.GetSnapshot(kv.NewVersion(commitTS)).BatchGet(ctx, kvKeys)
+	if err != nil {
+		return nil, errors.Annotatef(err, "failed to read back the keys at %d", commitTS)
+	}
+	result := &SampleVerifyResult{Keys: len(keys)}
+	for _, key := range keys {
+		mismatch := SampleMismatch{File: key.file, Key: redact.Key(key.key), CommitTS: commitTS}
+		got, exists := values[string(key.key)]
+		switch {
+		case !key.isPut && exists:
+			mismatch.Reason = "the key deleted in the log backup exists"
+			result.addMismatch(mismatch)
+		case key.isPut && !exists:
+			mismatch.Reason = "the key put in the log backup doesn't exist"
+			result.addMismatch(mismatch)
+		case key.isPut && !key.hasShortValue:
+			result.ExistenceOnly++
+		case key.isPut && !bytes.Equal(got, key.value):
+			mismatch.Reason = fmt.Sprintf("the value is %d bytes, but %d bytes in the log backup",
+				len(got), len(key.value))
+			result.addMismatch(mismatch)
+		}
+	}
+	return result, nil
+}
+
+// rewriteSampledKey rewrites the raw key by the data rules, false is returned if no rule matches.
+func rewriteSampledKey(rawKey []byte, rule *restoreutils.RewriteRules) ([]byte, bool) {
+	for _, r := range rule.Data {
+		if bytes.HasPrefix(rawKey, r.GetOldKeyPrefix()) {
+			newKey := make([]byte, 0, len(r.GetNewKeyPrefix())+len(rawKey)-len(r.GetOldKeyPrefix()))
+			newKey = append(newKey, r.GetNewKeyPrefix()...)
+			return append(newKey, rawKey[len(r.GetOldKeyPrefix()):]...), true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,114 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package logclient_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"testing"
+	"time"
+
+	backuppb "github.com/pingcap/kvproto/pkg/brpb"
+	berrors "github.com/pingcap/tidb/br/pkg/errors"
+	logclient "github.com/pingcap/tidb/br/pkg/restore/log_client"
+	restoreutils "github.com/pingcap/tidb/br/pkg/restore/utils"
+	"github.com/pingcap/tidb/br/pkg/stream"
+	"github.com/pingcap/tidb/br/pkg/utiltest"
+	"github.com/pingcap/tidb/pkg/kv"
+	"github.com/pingcap/tidb/pkg/tablecodec"
+	"github.com/pingcap/tidb/pkg/types"
+	"github.com/pingcap/tidb/pkg/util/codec"
+	"github.com/pingcap/tidb/pkg/util/rowcodec"
+	"github.com/stretchr/testify/require"
+)
+
+func encodeWriteCFEntry(key []byte, commitTS uint64, writeType byte, shortValue []byte) []byte {
+	txnKey := codec.EncodeUintDesc(codec.EncodeBytes(nil, key), commitTS)
+	value := codec.EncodeUvarint([]byte{writeType}, commitTS-1)
+	if shortValue != nil {
+		value = append(value, 'v', byte(len(shortValue)))
+		value = append(value, shortValue...)
+	}
+	return stream.EncodeKVEntry(txnKey, value)
+}
+
+func TestVerifySampledKVs(t *testing.T) {
+	ctx := context.Background()
+	s := utiltest.CreateRestoreSchemaSuite(t)
+	store := s.Mock.Storage
+	rowKey := func(tableID, handle int64) []byte {
+		return tablecodec.EncodeRowKeyWithHandle(tableID, kv.IntHandle(handle))
+	}
+	rowValue := func(value string) []byte {
+		row, err := (&rowcodec.Encoder{}).Encode(time.UTC, []int64{1}, []types.Datum{types.NewStringDatum(value)}, nil, nil)
+		require.NoError(t, err)
+		return row
+	}
+	txn, err := store.Begin()
+	require.NoError(t, err)
+	require.NoError(t, txn.Set(rowKey(200, 1), rowValue("v1")))
+	require.NoError(t, txn.Set(rowKey(200, 2), rowValue("other")))
+	require.NoError(t, txn.Set(rowKey(200, 3), rowValue("long value")))
+	require.NoError(t, txn.Commit(ctx))
+	ver, err := store.CurrentVersion(kv.GlobalTxnScope)
+	require.NoError(t, err)
+	commitTS := ver.Ver
+	rule := restoreutils.GetRewriteRuleOfTable(100, 200, 0, nil, false)
+
+	verify := func(entries ...[]byte) (*logclient.SampleVerifyResult, error) {
+		buff := make([]byte, 0)
+		for _, entry := range entries {
+			buff = append(buff, entry...)
+		}
+		checksum := sha256.Sum256(buff)
+		file := &logclient.LogDataFileInfo{DataFileInfo: &backuppb.DataFileInfo{
+			Path: "log-1", Cf: stream.WriteCF, TableId: 100, NumberOfEntries: int64(len(entries)),
+			RangeLength: uint64(len(buff)), Sha256: checksum[:],
+		}}
+		defaultFile := &logclient.LogDataFileInfo{DataFileInfo: &backuppb.DataFileInfo{
+			Path: "log-2", Cf: stream.DefaultCF, TableId: 100, NumberOfEntries: 1,
+		}}
+		client := logclient.TEST_NewLogClient(1, 1, commitTS+100, 1, nil, nil)
+		client.LogFileManager = logclient.TEST_NewLogFileManager(1, commitTS+100, 1,
+			&logclient.FakeStreamMetadataHelper{Data: buff})
+		client.SetVerifySampleRate(1)
+		client.TEST_SampleFiles([]*logclient.LogDataFileInfo{file, defaultFile}, rule)
+		return client.VerifySampledKVs(ctx, store)
+	}
+
+	result, err := verify(
+		encodeWriteCFEntry(rowKey(100, 1), commitTS, stream.WriteTypePut, rowValue("v1")),
+		// the value is in the default CF, only its existence is verified.
+		encodeWriteCFEntry(rowKey(100, 3), commitTS, stream.WriteTypePut, nil),
+		encodeWriteCFEntry(rowKey(100, 4), commitTS, stream.WriteTypeDelete, nil),
+		// the locks and the entries out of the restore window are skipped.
+		encodeWriteCFEntry(rowKey(100, 2), commitTS, stream.WriteTypeLock, nil),
+		encodeWriteCFEntry(rowKey(100, 2), commitTS+200, stream.WriteTypePut, rowValue("v2")),
+	)
+	require.NoError(t, err)
+	require.Equal(t, logclient.SampleVerifyResult{Files: 1, Keys: 3, ExistenceOnly: 1}, *result)
+
+	result, err = verify(
+		encodeWriteCFEntry(rowKey(100, 1), commitTS, stream.WriteTypePut, rowValue("v1")),
+		encodeWriteCFEntry(rowKey(100, 2), commitTS, stream.WriteTypePut, rowValue("v2")),
+		encodeWriteCFEntry(rowKey(100, 3), commitTS, stream.WriteTypeDelete, nil),
+		encodeWriteCFEntry(rowKey(100, 5), commitTS, stream.WriteTypePut, rowValue("v5")),
+	)
+	require.ErrorIs(t, err, berrors.ErrRestoreVerifyFailed)
+	require.Equal(t, 4, result.Keys)
+	require.Equal(t, 3, result.MismatchCount)
+	require.Len(t, result.Mismatches, 3)
+	require.Equal(t, "log-1", result.Mismatches[0].File)
+	require.Equal(t, commitTS, result.Mismatches[0].CommitTS)
+
+	// the keys of the same commit ts are read back in several batches.
+	entries := make([][]byte, 0, 600)
+	for i := range 600 {
+		entries = append(entries, encodeWriteCFEntry(rowKey(100, int64(100+i)), commitTS, stream.WriteTypePut, rowValue("v")))
+	}
+	result, err = verify(entries...)
+	require.ErrorIs(t, err, berrors.ErrRestoreVerifyFailed)
+	require.Equal(t, 600, result.Keys)
+	require.Equal(t, 600, result.MismatchCount)
+	require.Len(t, result.Mismatches, 10)
+}
@@ -0,0 +1,327 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package logclient
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/docker/go-units"
+	"github.com/pingcap/errors"
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/pingcap/log"
+	"github.com/pingcap/tidb/br/pkg/conn"
+	"github.com/pingcap/tidb/br/pkg/conn/util"
+	berrors "github.com/pingcap/tidb/br/pkg/errors"
+	"github.com/pingcap/tidb/br/pkg/restore/split"
+	"github.com/pingcap/tidb/pkg/tablecodec"
+	"github.com/pingcap/tidb/pkg/util/codec"
+	pdhttp "github.com/tikv/pd/client/http"
+	"go.uber.org/zap"
+)
+
+const (
+	// DefaultZoneLabel is the label key of the stores marking their zones.
+	DefaultZoneLabel = "zone"
+	// DefaultZoneLeaderTransferCost is the default cost of moving a leader into the zone, in bytes of the
+	// cross-zone transfer it's worth.
+	DefaultZoneLeaderTransferCost = 8 * units.MiB
+
+	zoneLeaderCheckInterval = 10 * time.Second
+)
+
+// ZoneLeaderConfig is the config to prefer the leaders in the zone of the backup storage.
+type ZoneLeaderConfig struct {
+	// Zone is the zone of the backup storage.
+	Zone string
+	// LabelKey is the label key of the stores marking their zones.
+	LabelKey string
+	// LeaderTransferCost is the cost of moving a leader into the zone, in bytes.
+	LeaderTransferCost uint64
+	// WaitTimeout is the max duration to wait for PD to move the leaders, the restore continues with the
+	// leaders not moved yet after timeout.
+	WaitTimeout time.Duration
+}
+
+// zoneLeaderStats is the leader distribution of the regions of a table.
+type zoneLeaderStats struct {
+	regions int
+	// crossZone is the number of the regions whose leaders are out of the zone.
+	crossZone int
+	// unmovable is the number of the regions without any peer in the zone, their leaders can't be moved
+	// into the zone without moving the peers.
+	unmovable int
+}
+
+// worthMoving returns whether moving the leaders of the table into the zone is cheaper than downloading
+// its files across the zones. The kv files are downloaded by the leaders in the log restore, so the bytes
+// downloaded by the cross-zone leaders are estimated by the fraction of the regions they lead.
+func (s zoneLeaderStats) worthMoving(tableBytes, leaderTransferCost uint64) bool {
+	if s.crossZone == 0 || s.unmovable > 0 {
+		return false
+	}
+	crossZoneBytes := tableBytes / uint64(s.regions) * uint64(s.crossZone)
+	return crossZoneBytes > leaderTransferCost*uint64(s.crossZone)
+}
+
+// loadStoreZones loads the zones of the TiKV stores keyed by the store IDs.
+func loadStoreZones(ctx context.Context, pdClient util.StoreMeta, cfg ZoneLeaderConfig) (map[uint64]string, error) {
+	stores, err := conn.GetAllTiKVStoresWithRetry(ctx, pdClient, util.SkipTiFlash)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	storeZones := make(map[uint64]string, len(stores))
+	inZone := 0
+	for _, s := range stores {
+		if s.GetState() != metapb.StoreState_Up {
+			continue
+		}
+		for _, l := range s.GetLabels() {
+			if l.GetKey() == cfg.LabelKey {
+				storeZones[s.GetId()] = l.GetValue()
+				if l.GetValue() == cfg.Zone {
+					inZone++
+				}
+				break
+			}
+		}
+	}
+	if inZone == 0 {
+		return nil, errors.Annotatef(berrors.ErrInvalidArgument,
+			"no TiKV store is labeled with %s=%s, %d of %d stores have the label", cfg.LabelKey, cfg.Zone,
+			len(storeZones), len(stores))
+	}
+	log.Info("load the zones of the stores", zap.Any("store-zones", storeZones))
+	return storeZones, nil
+}
+
+// ZoneLeaderManager moves the leaders of the restored tables into the zone of the backup storage by the
+// placement rules, so the kv files are downloaded within the zone as much as possible.
+type ZoneLeaderManager struct {
+	toolClient split.SplitClient
+	storeZones map[uint64]string
+	cfg        ZoneLeaderConfig
+
+	checkInterval time.Duration
+	tables        []int64
+}
+
+// NewZoneLeaderManager creates a ZoneLeaderManager, the stores must be labeled with their zones.
+func (rc *LogClient) NewZoneLeaderManager(ctx context.Context, cfg ZoneLeaderConfig) (*ZoneLeaderManager, error) {
+	storeZones, err := loadStoreZones(ctx, rc.pdClient, cfg)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	// toolClient reuse the split.SplitClient to do miscellaneous things. It doesn't
+	// call split related functions so set the arguments to arbitrary values.
+	client := split.NewClient(rc.pdClient, rc.pdHTTPClient, rc.tlsConf, maxSplitKeysOnce, 3)
+	return newZoneLeaderManager(client, storeZones, cfg), nil
+}
+
+func newZoneLeaderManager(client split.SplitClient, storeZones map[uint64]string, cfg ZoneLeaderConfig) *ZoneLeaderManager {
+	return &ZoneLeaderManager{
+		toolClient:    client,
+		storeZones:    storeZones,
+		cfg:           cfg,
+		checkInterval: zoneLeaderCheckInterval,
+	}
+}
+
+const (
+	// ZoneLeaderRuleGroup is the placement rule group of the rules moving the leaders of the restored tables
+	// into the zone. It's removed as a whole after the restore, and before the restore in case it's left by
+	// a previous one that failed to remove it.
+	ZoneLeaderRuleGroup = "br-restore-zone-leader"
+	// zoneLeaderRuleGroupIndex places the group after the default group of PD, which is overridden by the
+	// group, and before the groups of the placement policies of TiDB, so the policies of the restored tables
+	// still take effect.
+	zoneLeaderRuleGroupIndex = 10
+)
+
+// zoneLeaderRuleID returns the id of the placement rule of the role set to prefer the leaders in the zone
+// for the tables from firstTableID to lastTableID.
+func zoneLeaderRuleID(firstTableID, lastTableID int64, role pdhttp.PeerRoleType) string {
+	return fmt.Sprintf("t%d-t%d-%s", firstTableID, lastTableID, role)
+}
+
+func tableRange(tableID int64) (start, end []byte) {
+	return codec.EncodeBytes([]byte{}, tablecodec.EncodeTablePrefix(tableID)),
+		codec.EncodeBytes([]byte{}, tablecodec.EncodeTablePrefix(tableID+1))
+}
+
+// tableLeaderStats scans the regions of the table and counts the leaders out of the zone.
+func (m *ZoneLeaderManager) tableLeaderStats(ctx context.Context, tableID int64) (zoneLeaderStats, error) {
+	start, end := tableRange(tableID)
+	regions, err := split.PaginateScanRegion(ctx, m.toolClient, start, end, split.ScanRegionPaginationLimit)
+	if err != nil {
+		return zoneLeaderStats{}, errors.Trace(err)
+	}
+	stats := zoneLeaderStats{regions: len(regions)}
+	for _, r := range regions {
+		if m.storeZones[r.Leader.GetStoreId()] == m.cfg.Zone {
+			continue
+		}
+		stats.crossZone++
+		hasPeerInZone := false
+		for _, p := range r.Region.GetPeers() {
+			if m.storeZones[p.GetStoreId()] == m.cfg.Zone {
+				hasPeerInZone = true
+				break
+			}
+		}
+		if !hasPeerInZone {
+			stats.unmovable++
+		}
+	}
+	return stats, nil
+}
+
+// PreferZone moves the leaders of the tables into the zone if it's worth the cost, the tables are keyed by
+// their downstream IDs with the estimated bytes to restore. It waits for PD to move the leaders until the
+// timeout, and returns the IDs of the tables whose leaders are moved.
+func (m *ZoneLeaderManager) PreferZone(ctx context.Context, tableBytes map[int64]uint64) ([]int64, error) {
+	// the rules left by a previous restore keep the leaders of its tables in the zone.
+	if err := m.toolClient.SetPlacementRuleBundle(ctx, &pdhttp.GroupBundle{ID: ZoneLeaderRuleGroup}); err != nil {
+		return nil, errors.Annotatef(err, "failed to remove the placement rule group %s left by a previous restore",
+			ZoneLeaderRuleGroup)
+	}
+	defaultRule, err := m.toolClient.GetPlacementRule(ctx, "pd", "default")
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if defaultRule.Count < 2 {
+		log.Info("skip preferring the leaders in the zone, the regions have only one replica")
+		return nil, nil
+	}
+	tables := make([]int64, 0, len(tableBytes))
+	for tableID, bytes := range tableBytes {
+		stats, err := m.tableLeaderStats(ctx, tableID)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if !stats.worthMoving(bytes, m.cfg.LeaderTransferCost) {
+			log.Debug("skip moving the leaders of the table into the zone", zap.Int64("table-id", tableID),
+				zap.Uint64("bytes", bytes), zap.Int("regions", stats.regions),
+				zap.Int("cross-zone", stats.crossZone), zap.Int("unmovable", stats.unmovable))
+			continue
+		}
+		tables = append(tables, tableID)
+	}
+	if len(tables) == 0 {
+		log.Info("no leader is worth moving into the zone", zap.String("zone", m.cfg.Zone),
+			zap.Int("total-tables", len(tableBytes)))
+		return nil, nil
+	}
+	slices.Sort(tables)
+	// the tables are recorded before setting the rules, so they're removed by Reset even if it fails halfway.
+	m.tables = tables
+	rules := m.zoneLeaderRules(defaultRule, tables)
+	if err := m.toolClient.SetPlacementRuleBundle(ctx, &pdhttp.GroupBundle{
+		ID:       ZoneLeaderRuleGroup,
+		Index:    zoneLeaderRuleGroupIndex,
+		Override: true,
+		Rules:    rules,
+	}); err != nil {
+		return nil, errors.Trace(err)
+	}
+	log.Info("set the placement rules to prefer the leaders in the zone", zap.String("zone", m.cfg.Zone),
+		zap.Int("tables", len(tables)), zap.Int("total-tables", len(tableBytes)), zap.Int("rules", len(rules)))
+	if err := m.waitLeaders(ctx); err != nil {
+		return nil, errors.Trace(err)
+	}
+	return tables, nil
+}
+
+// zoneLeaderRules replaces the default rule of the sorted tables by a leader in the zone and the rest
+// voters. The ranges of the tables with the consecutive IDs are merged into one.
+func (m *ZoneLeaderManager) zoneLeaderRules(defaultRule *pdhttp.Rule, tables []int64) []*pdhttp.Rule {
+	rules := make([]*pdhttp.Rule, 0, 2)
+	for i := 0; i < len(tables); {
+		j := i + 1
+		for j < len(tables) && tables[j] == tables[j-1]+1 {
+			j++
+		}
+		first, last := tables[i], tables[j-1]
+		start, _ := tableRange(first)
+		_, end := tableRange(last)
+		rules = append(rules, &pdhttp.Rule{
+			GroupID:     ZoneLeaderRuleGroup,
+			ID:          zoneLeaderRuleID(first, last, pdhttp.Leader),
+			StartKeyHex: hex.EncodeToString(start),
+			EndKeyHex:   hex.EncodeToString(end),
+			Role:        pdhttp.Leader,
+			Count:       1,
+			LabelConstraints: []pdhttp.LabelConstraint{{
+				Key:    m.cfg.LabelKey,
+				Op:     "in",
+				Values: []string{m.cfg.Zone},
+			}},
+			LocationLabels: defaultRule.LocationLabels,
+			IsolationLevel: defaultRule.IsolationLevel,
+		}, &pdhttp.Rule{
+			GroupID:        ZoneLeaderRuleGroup,
+			ID:             zoneLeaderRuleID(first, last, pdhttp.Voter),
+			StartKeyHex:    hex.EncodeToString(start),
+			EndKeyHex:      hex.EncodeToString(end),
+			Role:           pdhttp.Voter,
+			Count:          defaultRule.Count - 1,
+			LocationLabels: defaultRule.LocationLabels,
+			IsolationLevel: defaultRule.IsolationLevel,
+		})
+		i = j
+	}
+	return rules
+}
+
+// waitLeaders waits for PD to move the leaders of the tables into the zone. It only warns after timeout,
+// the restore is correct whatever the leaders are.
+func (m *ZoneLeaderManager) waitLeaders(ctx context.Context) error {
+	start := time.Now()
+	ticker := time.NewTicker(m.checkInterval)
+	defer ticker.Stop()
+	timeout := time.After(m.cfg.WaitTimeout)
+	for {
+		select {
+		case <-ticker.C:
+			crossZone := 0
+			for _, tableID := range m.tables {
+				stats, err := m.tableLeaderStats(ctx, tableID)
+				if err != nil {
+					return errors.Trace(err)
+				}
+				crossZone += stats.crossZone
+			}
+			if crossZone == 0 {
+				log.Info("the leaders are moved into the zone", zap.Duration("take", time.Since(start)))
+				return nil
+			}
+			log.Info("waiting for the leaders moved into the zone", zap.Int("cross-zone-regions", crossZone))
+		case <-timeout:
+			log.Warn("timeout to wait for the leaders moved into the zone, continue the restore",
+				zap.Duration("wait-timeout", m.cfg.WaitTimeout))
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Reset removes the placement rule group set by PreferZone. The group failed to be removed is retried by
+// the next Reset, or by the next restore, or should be removed manually, since it keeps the leaders of the
+// tables in the zone.
+func (m *ZoneLeaderManager) Reset(ctx context.Context) error {
+	if len(m.tables) == 0 {
+		return nil
+	}
+	if err := m.toolClient.SetPlacementRuleBundle(ctx, &pdhttp.GroupBundle{ID: ZoneLeaderRuleGroup}); err != nil {
+		log.Warn("failed to remove the zone leader rules", zap.String("group", ZoneLeaderRuleGroup),
+			zap.Int64s("tables", m.tables), zap.Error(err))
+		return errors.Annotatef(berrors.ErrPDInvalidResponse,
+			"failed to remove the placement rule group %s: %v", ZoneLeaderRuleGroup, err)
+	}
+	m.tables = nil
+	return nil
+}
@@ -0,0 +1,184 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package logclient_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/docker/go-units"
+	"github.com/pingcap/kvproto/pkg/metapb"
+	berrors "github.com/pingcap/tidb/br/pkg/errors"
+	logclient "github.com/pingcap/tidb/br/pkg/restore/log_client"
+	"github.com/pingcap/tidb/br/pkg/restore/split"
+	"github.com/pingcap/tidb/pkg/tablecodec"
+	"github.com/pingcap/tidb/pkg/util/codec"
+	"github.com/stretchr/testify/require"
+	"github.com/tikv/pd/client/clients/router"
+	pdhttp "github.com/tikv/pd/client/http"
+)
+
+// fakeZonePD moves the leaders of the regions into the zone once the leader rule is set.
+type fakeZonePD struct {
+	split.SplitClient
+	storeZones map[uint64]string
+	bundles    map[string]*pdhttp.GroupBundle
+	regions    []*router.Region
+}
+
+func (f *fakeZonePD) GetPlacementRule(_ context.Context, groupID, ruleID string) (*pdhttp.Rule, error) {
+	return &pdhttp.Rule{GroupID: groupID, ID: ruleID, Role: pdhttp.Voter, Count: 3, LocationLabels: []string{"zone", "host"}}, nil
+}
+
+func (f *fakeZonePD) SetPlacementRuleBundle(ctx context.Context, bundle *pdhttp.GroupBundle) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if len(bundle.Rules) == 0 {
+		delete(f.bundles, bundle.ID)
+		return nil
+	}
+	f.bundles[bundle.ID] = bundle
+	for _, rule := range bundle.Rules {
+		if rule.Role != pdhttp.Leader {
+			continue
+		}
+		start, _ := hex.DecodeString(rule.StartKeyHex)
+		end, _ := hex.DecodeString(rule.EndKeyHex)
+		for _, r := range f.regions {
+			if bytes.Compare(r.Meta.StartKey, start) < 0 || bytes.Compare(r.Meta.EndKey, end) > 0 {
+				continue
+			}
+			for _, p := range r.Meta.Peers {
+				if f.storeZones[p.StoreId] == rule.LabelConstraints[0].Values[0] {
+					r.Leader.StoreId = p.StoreId
+					break
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (f *fakeZonePD) ScanRegions(_ context.Context, startKey, endKey []byte, limit int) ([]*split.RegionInfo, error) {
+	regions := make([]*split.RegionInfo, 0)
+	for _, r := range f.regions {
+		if bytes.Compare(r.Meta.StartKey, endKey) < 0 && bytes.Compare(r.Meta.EndKey, startKey) > 0 &&
+			len(regions) < limit {
+			regions = append(regions, &split.RegionInfo{Region: r.Meta, Leader: r.Leader})
+		}
+	}
+	return regions, nil
+}
+
+func (f *fakeZonePD) addTableRegions(tableID int64, peers ...[]uint64) {
+	start := codec.EncodeBytes([]byte{}, tablecodec.EncodeTablePrefix(tableID))
+	end := codec.EncodeBytes([]byte{}, tablecodec.EncodeTablePrefix(tableID+1))
+	for i, storeIDs := range peers {
+		regionEnd := end
+		if i < len(peers)-1 {
+			regionEnd = append(append([]byte{}, start...), byte(i+1))
+		}
+		region := &router.Region{
+			Meta:   &metapb.Region{Id: uint64(len(f.regions) + 1), StartKey: start, EndKey: regionEnd},
+			Leader: &metapb.Peer{StoreId: storeIDs[0]},
+		}
+		for _, storeID := range storeIDs {
+			region.Meta.Peers = append(region.Meta.Peers, &metapb.Peer{StoreId: storeID})
+		}
+		f.regions = append(f.regions, region)
+		start = regionEnd
+	}
+}
+
+func TestPreferZoneLeaders(t *testing.T) {
+	ctx := context.Background()
+	storeZones := map[uint64]string{1: "a", 2: "a", 3: "a", 4: "b", 5: "b", 6: "b"}
+	pd := &fakeZonePD{
+		storeZones: storeZones,
+		bundles: map[string]*pdhttp.GroupBundle{
+			// left by a previous restore.
+			logclient.ZoneLeaderRuleGroup: {ID: logclient.ZoneLeaderRuleGroup, Rules: []*pdhttp.Rule{{ID: "t1-t1-leader"}}},
+		},
+	}
+	// the leader of the first region is out of the zone, the first peer is the leader.
+	pd.addTableRegions(100, []uint64{4, 1, 5}, []uint64{1, 4, 5})
+	pd.addTableRegions(101, []uint64{5, 2, 6})
+	// no peer in the zone.
+	pd.addTableRegions(102, []uint64{5, 4, 6})
+	// the table is too small to move the leaders.
+	pd.addTableRegions(103, []uint64{4, 2, 5})
+	// the leaders are already in the zone.
+	pd.addTableRegions(104, []uint64{3, 4, 5})
+	pd.addTableRegions(105, []uint64{6, 5, 3})
+
+	m := logclient.NewZoneLeaderManagerForTest(pd, storeZones, logclient.ZoneLeaderConfig{
+		Zone:               "a",
+		LabelKey:           logclient.DefaultZoneLabel,
+		LeaderTransferCost: logclient.DefaultZoneLeaderTransferCost,
+		WaitTimeout:        time.Minute,
+	}, 10*time.Millisecond)
+	moved, err := m.PreferZone(ctx, map[int64]uint64{
+		100: 100 * units.MiB,
+		101: 100 * units.MiB,
+		102: 100 * units.MiB,
+		103: units.MiB,
+		104: 100 * units.MiB,
+		105: 100 * units.MiB,
+	})
+	require.NoError(t, err)
+	require.Equal(t, []int64{100, 101, 105}, moved)
+	require.Len(t, pd.bundles, 1)
+	bundle := pd.bundles[logclient.ZoneLeaderRuleGroup]
+	require.True(t, bundle.Override)
+	// the ranges of the consecutive tables are merged.
+	ruleIDs := make([]string, 0, len(bundle.Rules))
+	for _, rule := range bundle.Rules {
+		ruleIDs = append(ruleIDs, rule.ID)
+	}
+	require.Equal(t, []string{"t100-t101-leader", "t100-t101-voter", "t105-t105-leader", "t105-t105-voter"}, ruleIDs)
+	start, _ := logclient.TableRange(100)
+	_, end := logclient.TableRange(101)
+	leader := bundle.Rules[0]
+	require.Equal(t, hex.EncodeToString(start), leader.StartKeyHex)
+	require.Equal(t, hex.EncodeToString(end), leader.EndKeyHex)
+	require.Equal(t, 1, leader.Count)
+	require.Equal(t, []pdhttp.LabelConstraint{{Key: "zone", Op: "in", Values: []string{"a"}}}, leader.LabelConstraints)
+	voters := bundle.Rules[1]
+	require.Equal(t, 2, voters.Count)
+	require.Equal(t, []string{"zone", "host"}, voters.LocationLabels)
+	require.Equal(t, uint64(1), pd.regions[0].Leader.StoreId)
+	require.Equal(t, uint64(2), pd.regions[2].Leader.StoreId)
+
+	// the group failed to be removed is retried by the next reset.
+	canceledCtx, cancel := context.WithCancel(ctx)
+	cancel()
+	require.ErrorIs(t, m.Reset(canceledCtx), berrors.ErrPDInvalidResponse)
+	require.Len(t, pd.bundles, 1)
+	require.NoError(t, m.Reset(ctx))
+	require.Empty(t, pd.bundles)
+}
+
+func TestLoadStoreZones(t *testing.T) {
+	ctx := context.Background()
+	label := func(zone string) []*metapb.StoreLabel {
+		return []*metapb.StoreLabel{{Key: "zone", Value: zone}}
+	}
+	pdClient := split.NewFakePDClient([]*metapb.Store{
+		{Id: 1, State: metapb.StoreState_Up, Labels: label("a")},
+		{Id: 2, State: metapb.StoreState_Up, Labels: label("b")},
+		{Id: 3, State: metapb.StoreState_Up},
+	}, false, nil)
+
+	storeZones, err := logclient.LoadStoreZones(ctx, pdClient, logclient.ZoneLeaderConfig{Zone: "a", LabelKey: "zone"})
+	require.NoError(t, err)
+	require.Equal(t, map[uint64]string{1: "a", 2: "b"}, storeZones)
+
+	_, err = logclient.LoadStoreZones(ctx, pdClient, logclient.ZoneLeaderConfig{Zone: "c", LabelKey: "zone"})
+	require.ErrorIs(t, err, berrors.ErrInvalidArgument)
+	_, err = logclient.LoadStoreZones(ctx, pdClient, logclient.ZoneLeaderConfig{Zone: "a", LabelKey: "az"})
+	require.ErrorIs(t, err, berrors.ErrInvalidArgument)
+}
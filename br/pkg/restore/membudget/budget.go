@@ -0,0 +1,156 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+// Package membudget limits the memory held by the major structures of a restore, e.g. the metadata
+// of the files, the ID maps and the delete-range queue. Each structure tracks its memory by a Tracker,
+// and the biggest spillable structures are spilled to disk when the budget is exceeded. The structures
+// without a Spiller are only tracked, and a warning is logged if they exceed the budget alone.
+package membudget
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/docker/go-units"
+	"github.com/pingcap/log"
+	"go.uber.org/zap"
+)
+
+// Spiller is implemented by the structures that can move their data to disk.
+type Spiller interface {
+	// Spill moves the data in memory to disk, and releases the memory by its tracker. It may be
+	// called concurrently with the other methods of the structure.
+	Spill() error
+}
+
+// Budget is the memory budget of a restore. A nil *Budget is unlimited, and its trackers only count.
+type Budget struct {
+	limit int64
+	used  atomic.Int64
+
+	mu       sync.Mutex
+	trackers []*Tracker
+	// spilling makes only one goroutine spill at a time.
+	spilling sync.Mutex
+	warned   atomic.Bool
+}
+
+// New creates a budget of limit bytes, nil is returned if the limit isn't positive.
+func New(limit int64) *Budget {
+	if limit <= 0 {
+		return nil
+	}
+	return &Budget{limit: limit}
+}
+
+// Limit returns the limit of the budget in bytes, 0 means unlimited.
+func (b *Budget) Limit() int64 {
+	if b == nil {
+		return 0
+	}
+	return b.limit
+}
+
+// Used returns the bytes tracked by all the trackers.
+func (b *Budget) Used() int64 {
+	if b == nil {
+		return 0
+	}
+	return b.used.Load()
+}
+
+// NewTracker creates a tracker of a structure. spiller can be nil if the structure can't be spilled.
+func (b *Budget) NewTracker(name string, spiller Spiller) *Tracker {
+	t := &Tracker{name: name, budget: b, spiller: spiller}
+	if b != nil {
+		b.mu.Lock()
+		b.trackers = append(b.trackers, t)
+		b.mu.Unlock()
+	}
+	return t
+}
+
+// reclaim spills the biggest spillable structures until the budget isn't exceeded.
+func (b *Budget) reclaim() {
+	if !b.spilling.TryLock() {
+		// another goroutine is spilling.
+		return
+	}
+	defer b.spilling.Unlock()
+
+	spilled := make(map[*Tracker]struct{})
+	for b.used.Load() > b.limit {
+		var biggest *Tracker
+		b.mu.Lock()
+		for _, t := range b.trackers {
+			if _, ok := spilled[t]; ok || t.spiller == nil {
+				continue
+			}
+			if biggest == nil || t.Used() > biggest.Used() {
+				biggest = t
+			}
+		}
+		b.mu.Unlock()
+		if biggest == nil || biggest.Used() == 0 {
+			if !b.warned.Swap(true) {
+				log.Warn("the memory budget of the restore is exceeded, but nothing can be spilled",
+					zap.String("limit", units.BytesSize(float64(b.limit))),
+					zap.String("used", units.BytesSize(float64(b.used.Load()))),
+					zap.Stringers("trackers", b.snapshot()))
+			}
+			return
+		}
+		spilled[biggest] = struct{}{}
+		used := biggest.Used()
+		if err := biggest.spiller.Spill(); err != nil {
+			log.Warn("failed to spill to disk", zap.String("tracker", biggest.name), zap.Error(err))
+			continue
+		}
+		log.Info("spilled to disk for the memory budget of the restore", zap.String("tracker", biggest.name),
+			zap.String("released", units.BytesSize(float64(used-biggest.Used()))),
+			zap.String("used", units.BytesSize(float64(b.used.Load()))))
+	}
+}
+
+func (b *Budget) snapshot() []*Tracker {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]*Tracker(nil), b.trackers...)
+}
+
+// Tracker tracks the memory of a structure.
+type Tracker struct {
+	name    string
+	budget  *Budget
+	spiller Spiller
+	used    atomic.Int64
+}
+
+// Consume tracks the bytes allocated. The biggest spillable structures are spilled if the budget is
+// exceeded, so it must not be called while holding the locks the spillers need.
+func (t *Tracker) Consume(bytes int64) {
+	t.used.Add(bytes)
+	if t.budget == nil {
+		return
+	}
+	if t.budget.used.Add(bytes) > t.budget.limit {
+		t.budget.reclaim()
+	}
+}
+
+// Release tracks the bytes freed.
+func (t *Tracker) Release(bytes int64) {
+	t.used.Add(-bytes)
+	if t.budget != nil {
+		t.budget.used.Add(-bytes)
+	}
+}
+
+// Used returns the bytes tracked.
+func (t *Tracker) Used() int64 {
+	return t.used.Load()
+}
+
+// String implements fmt.Stringer.
+func (t *Tracker) String() string {
+	return t.name + ":" + units.BytesSize(float64(t.Used()))
+}
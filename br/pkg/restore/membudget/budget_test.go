@@ -0,0 +1,92 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package membudget_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pingcap/tidb/br/pkg/restore/membudget"
+	"github.com/stretchr/testify/require"
+)
+
+type item struct {
+	Key   string
+	Value int
+}
+
+func sizeOfItem(i item) int64 {
+	return int64(16 + len(i.Key))
+}
+
+func TestUnlimitedBudget(t *testing.T) {
+	b := membudget.New(0)
+	require.Nil(t, b)
+	require.Zero(t, b.Limit())
+
+	l := membudget.NewSpillableList(b, "list", sizeOfItem)
+	for i := range 100 {
+		l.Append(item{Key: "key", Value: i})
+	}
+	require.Equal(t, 100, l.Len())
+	require.Zero(t, b.Used())
+
+	tracker := b.NewTracker("tracker", nil)
+	tracker.Consume(1024)
+	require.EqualValues(t, 1024, tracker.Used())
+	tracker.Release(1024)
+	require.Zero(t, tracker.Used())
+	require.NoError(t, l.Close())
+}
+
+func TestSpillTheBiggest(t *testing.T) {
+	t.Setenv("TMPDIR", t.TempDir())
+	b := membudget.New(1000)
+	small := membudget.NewSpillableList(b, "small", sizeOfItem)
+	big := membudget.NewSpillableList(b, "big", sizeOfItem)
+	unspillable := b.NewTracker("unspillable", nil)
+
+	unspillable.Consume(700)
+	small.Append(item{Key: "0123", Value: 0})
+	for i := range 20 {
+		big.Append(item{Key: "0123", Value: i})
+	}
+	// the big list is spilled once it exceeds the budget.
+	require.LessOrEqual(t, b.Used(), int64(1000))
+	require.Equal(t, 20, big.Len())
+
+	values := make([]int, 0, 20)
+	require.NoError(t, big.Iterate(func(i item) error {
+		values = append(values, i.Value)
+		return nil
+	}))
+	require.Len(t, values, 20)
+	for i, v := range values {
+		require.Equal(t, i, v)
+	}
+	// it can be appended after iterated.
+	big.Append(item{Key: "0123", Value: 20})
+	require.Equal(t, 21, big.Len())
+
+	// only the big list is spilled.
+	files, err := filepath.Glob(filepath.Join(os.TempDir(), "br-restore-spill-*"))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	require.NoError(t, big.Close())
+	require.NoError(t, small.Close())
+	files, err = filepath.Glob(filepath.Join(os.TempDir(), "br-restore-spill-*"))
+	require.NoError(t, err)
+	require.Empty(t, files)
+	require.EqualValues(t, 700, b.Used())
+}
+
+func TestNothingToSpill(t *testing.T) {
+	b := membudget.New(100)
+	tracker := b.NewTracker("unspillable", nil)
+	// consuming never fails even if the budget is exceeded.
+	tracker.Consume(200)
+	require.EqualValues(t, 200, b.Used())
+	require.EqualValues(t, 100, b.Limit())
+	require.Equal(t, "unspillable:200B", tracker.String())
+}
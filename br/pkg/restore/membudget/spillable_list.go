@@ -0,0 +1,136 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package membudget
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/pingcap/errors"
+)
+
+// SpillableList is a list appended in memory, and spilled to a temporary file as JSON lines when the
+// budget is exceeded. The items are iterated in the order appended.
+type SpillableList[T any] struct {
+	sizeOf  func(T) int64
+	tracker *Tracker
+
+	mu    sync.Mutex
+	items []T
+	size  int64
+	file  *os.File
+	// spilled is the number of the items in the file.
+	spilled int
+}
+
+// NewSpillableList creates a list tracked by the budget, sizeOf estimates the memory of an item.
+func NewSpillableList[T any](b *Budget, name string, sizeOf func(T) int64) *SpillableList[T] {
+	l := &SpillableList[T]{sizeOf: sizeOf}
+	l.tracker = b.NewTracker(name, l)
+	return l
+}
+
+// Append appends the item to the list.
+func (l *SpillableList[T]) Append(item T) {
+	size := l.sizeOf(item)
+	l.mu.Lock()
+	l.items = append(l.items, item)
+	l.size += size
+	l.mu.Unlock()
+	// consume out of the lock, the list may be spilled.
+	l.tracker.Consume(size)
+}
+
+// Len returns the number of the items.
+func (l *SpillableList[T]) Len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.spilled + len(l.items)
+}
+
+// Spill implements Spiller.
+func (l *SpillableList[T]) Spill() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.items) == 0 {
+		return nil
+	}
+	if l.file == nil {
+		f, err := os.CreateTemp(os.TempDir(), "br-restore-spill-*.jsonl")
+		if err != nil {
+			return errors.Trace(err)
+		}
+		l.file = f
+	}
+	w := bufio.NewWriter(l.file)
+	enc := json.NewEncoder(w)
+	for _, item := range l.items {
+		if err := enc.Encode(item); err != nil {
+			return errors.Annotatef(err, "failed to spill to %s", l.file.Name())
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return errors.Annotatef(err, "failed to spill to %s", l.file.Name())
+	}
+	l.spilled += len(l.items)
+	l.items = nil
+	l.tracker.Release(l.size)
+	l.size = 0
+	return nil
+}
+
+// Iterate calls fn on the items in the order appended, the list mustn't be appended meanwhile.
+func (l *SpillableList[T]) Iterate(fn func(T) error) (err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file != nil {
+		if _, err := l.file.Seek(0, io.SeekStart); err != nil {
+			return errors.Trace(err)
+		}
+		defer func() {
+			// the items spilled later are appended to the end of the file.
+			if _, seekErr := l.file.Seek(0, io.SeekEnd); err == nil && seekErr != nil {
+				err = errors.Trace(seekErr)
+			}
+		}()
+		dec := json.NewDecoder(bufio.NewReader(l.file))
+		for range l.spilled {
+			var item T
+			if err := dec.Decode(&item); err != nil {
+				return errors.Annotatef(err, "failed to read the spilled file %s", l.file.Name())
+			}
+			if err := fn(item); err != nil {
+				return err
+			}
+		}
+	}
+	for _, item := range l.items {
+		if err := fn(item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close releases the memory and removes the spilled file.
+func (l *SpillableList[T]) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.items = nil
+	l.tracker.Release(l.size)
+	l.size = 0
+	if l.file == nil {
+		return nil
+	}
+	name := l.file.Name()
+	err := l.file.Close()
+	l.file = nil
+	l.spilled = 0
+	if rmErr := os.Remove(name); err == nil {
+		err = rmErr
+	}
+	return errors.Trace(err)
+}
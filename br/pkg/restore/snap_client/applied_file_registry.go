@@ -0,0 +1,160 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package snapclient
+
+import (
+	"context"
+	"encoding/hex"
+	"sync"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/log"
+	"github.com/pingcap/tidb/br/pkg/checkpoint"
+	"github.com/pingcap/tidb/br/pkg/glue"
+	"github.com/pingcap/tidb/br/pkg/restore"
+	"github.com/pingcap/tidb/br/pkg/restore/split"
+	"go.uber.org/zap"
+)
+
+// appliedFileFlushBatch is the count of the pending applied files flushed together.
+const appliedFileFlushBatch = 1024
+
+// AppliedFileRegistry records the SST files ingested into the regions of the target cluster, so that the
+// files aren't downloaded into a region again when the import is retried or the restore is resumed. A file
+// is regarded as applied to a region only if the region isn't split or merged since it's ingested. Losing a
+// record only makes the file downloaded again, which is harmless since the ingest is idempotent, so the
+// failure of saving the records doesn't fail the restore. A nil registry records nothing.
+type AppliedFileRegistry struct {
+	dbName string
+
+	mu      sync.Mutex
+	applied map[checkpoint.AppliedFileKey]struct{}
+	pending []checkpoint.AppliedFileKey
+
+	// flushMu serializes the flushes, since the session isn't safe for concurrent use.
+	flushMu sync.Mutex
+	se      glue.Session
+}
+
+// NewAppliedFileRegistry creates the registry saving the records by the session, the session is closed by
+// Close. The session may be nil, then the records are only kept in the memory.
+func NewAppliedFileRegistry(se glue.Session, dbName string) *AppliedFileRegistry {
+	return &AppliedFileRegistry{
+		dbName:  dbName,
+		applied: make(map[checkpoint.AppliedFileKey]struct{}),
+		se:      se,
+	}
+}
+
+// Load loads the records saved by the previous restore.
+func (r *AppliedFileRegistry) Load(ctx context.Context) error {
+	if err := checkpoint.InitAppliedFileTable(ctx, r.se, r.dbName); err != nil {
+		return errors.Trace(err)
+	}
+	execCtx := r.se.GetSessionCtx().GetRestrictedSQLExecutor()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	err := checkpoint.LoadAppliedFiles(ctx, execCtx, r.dbName, func(key checkpoint.AppliedFileKey) {
+		r.applied[key] = struct{}{}
+	})
+	log.Info("load the applied files", zap.Int("count", len(r.applied)))
+	return errors.Trace(err)
+}
+
+// appliedFileKeys returns the keys of the files applied to the region, the files without sha256 can't be
+// recorded, then it returns false.
+func appliedFileKeys(region *split.RegionInfo, fileSets []restore.BackupFileSet) ([]checkpoint.AppliedFileKey, bool) {
+	keys := make([]checkpoint.AppliedFileKey, 0, len(fileSets)*2)
+	for _, set := range fileSets {
+		for _, file := range set.SSTFiles {
+			if len(file.Sha256) == 0 {
+				return nil, false
+			}
+			keys = append(keys, checkpoint.AppliedFileKey{
+				SHA256:       hex.EncodeToString(file.Sha256),
+				RegionID:     region.Region.GetId(),
+				EpochVersion: region.Region.GetRegionEpoch().GetVersion(),
+			})
+		}
+	}
+	return keys, len(keys) > 0
+}
+
+// IsApplied checks whether all the files are applied to the region.
+func (r *AppliedFileRegistry) IsApplied(region *split.RegionInfo, fileSets []restore.BackupFileSet) bool {
+	if r == nil {
+		return false
+	}
+	keys, ok := appliedFileKeys(region, fileSets)
+	if !ok {
+		return false
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, key := range keys {
+		if _, applied := r.applied[key]; !applied {
+			return false
+		}
+	}
+	return true
+}
+
+// Record records that the files are applied to the region, the records are saved in batches.
+func (r *AppliedFileRegistry) Record(ctx context.Context, region *split.RegionInfo, fileSets []restore.BackupFileSet) {
+	if r == nil {
+		return
+	}
+	keys, ok := appliedFileKeys(region, fileSets)
+	if !ok {
+		return
+	}
+	r.mu.Lock()
+	for _, key := range keys {
+		if _, applied := r.applied[key]; !applied {
+			r.applied[key] = struct{}{}
+			r.pending = append(r.pending, key)
+		}
+	}
+	full := len(r.pending) >= appliedFileFlushBatch
+	r.mu.Unlock()
+	if full {
+		r.Flush(ctx)
+	}
+}
+
+// Flush saves the pending records.
+func (r *AppliedFileRegistry) Flush(ctx context.Context) {
+	if r == nil {
+		return
+	}
+	r.flushMu.Lock()
+	defer r.flushMu.Unlock()
+	if r.se == nil {
+		return
+	}
+	r.mu.Lock()
+	pending := r.pending
+	r.pending = nil
+	r.mu.Unlock()
+	if len(pending) == 0 {
+		return
+	}
+	if err := checkpoint.SaveAppliedFiles(ctx, r.se, r.dbName, pending); err != nil {
+		log.Warn("failed to save the applied files, they may be downloaded again if the restore is resumed",
+			zap.Int("count", len(pending)), zap.Error(err))
+	}
+}
+
+// Close saves the pending records and closes the session, the records after it are kept in the memory.
+func (r *AppliedFileRegistry) Close(ctx context.Context) {
+	if r == nil {
+		return
+	}
+	r.Flush(ctx)
+	r.flushMu.Lock()
+	defer r.flushMu.Unlock()
+	if r.se != nil {
+		r.se.Close()
+		r.se = nil
+	}
+}
@@ -46,6 +46,7 @@ import (
 	tidalloc "github.com/pingcap/tidb/br/pkg/restore/internal/prealloc_table_id"
 	"github.com/pingcap/tidb/br/pkg/restore/split"
 	restoreutils "github.com/pingcap/tidb/br/pkg/restore/utils"
+	"github.com/pingcap/tidb/br/pkg/storage"
 	"github.com/pingcap/tidb/br/pkg/summary"
 	"github.com/pingcap/tidb/br/pkg/utils"
 	"github.com/pingcap/tidb/br/pkg/version"
@@ -55,6 +56,7 @@ import (
 	"github.com/pingcap/tidb/pkg/meta/model"
 	tidbutil "github.com/pingcap/tidb/pkg/util"
 	"github.com/pingcap/tidb/pkg/util/redact"
+	filter "github.com/pingcap/tidb/pkg/util/table-filter"
 	kvutil "github.com/tikv/client-go/v2/util"
 	pd "github.com/tikv/pd/client"
 	pdhttp "github.com/tikv/pd/client/http"
@@ -70,12 +72,15 @@ const (
 	resetSpeedLimitRetryTimes = 3
 	defaultDDLConcurrency     = 100
 	maxSplitKeysOnce          = 10240
+	// defaultRowRewriteConcurrency is the number of the tables whose rows are filtered at the same time.
+	defaultRowRewriteConcurrency = 4
 )
 
 const minBatchDdlSize = 1
 
 type SnapClient struct {
-	restorer restore.SstRestorer
+	restorer     restore.SstRestorer
+	fileImporter *SnapFileImporter
 	// Use a closure to lazy load checkpoint runner
 	getRestorerFn func(*checkpoint.CheckpointRunner[checkpoint.RestoreKeyType, checkpoint.RestoreValueType]) restore.SstRestorer
 	// Tool clients used by SnapClient
@@ -88,6 +93,18 @@ type SnapClient struct {
 	keepaliveConf       keepalive.ClientParameters
 	rateLimit           uint64
 	tlsConf             *tls.Config
+	phaseTimeouts       restoreutils.PhaseTimeouts
+
+	// the local backend of Lightning to import the files of the TiDB restore, nil means TiKV downloads
+	// and ingests the files.
+	localBackend           LocalIngestBackend
+	localStorage           storage.ExternalStorage
+	localEngineConcurrency int
+	// localKVReader reads the kvs of the files instead of the SST reader, e.g. for the data of the
+	// import adapters.
+	localKVReader KVReader
+	// tableStats collects the restore statistics of the tables.
+	tableStats *TableStatsCollector
 
 	switchCh chan struct{}
 
@@ -96,6 +113,29 @@ type SnapClient struct {
 	workerPool    *tidbutil.WorkerPool
 
 	noSchema bool
+	// checkExistingTablesEmpty is whether the existing tables must be empty if noSchema is set.
+	checkExistingTablesEmpty bool
+
+	// "db.table" -> the column mapping applied when the table is created.
+	columnMappings map[string]*ColumnMapping
+	// "db.table" -> the predicate of the rows to keep.
+	rowFilters map[string]string
+	// "db.table" -> the sanitizers rewriting the columns of the rows.
+	sanitizers  map[string][]Sanitizer
+	sanitizeKey []byte
+	// the physical table ID in the backup -> the sanitizer of the rows, it's filled by CheckSanitizers.
+	sanitizedTables map[int64]*tableSanitizer
+	// mergePartitions matches the partitioned tables restored as non-partitioned tables.
+	mergePartitions filter.Filter
+	// the ID of the table in the backup -> the table info to create, whose partitions are merged.
+	mergedTables map[int64]*model.TableInfo
+	// charsetConversion converts the charset of the databases and tables to create.
+	charsetConversion *utils.CharsetConversion
+	// placementTemplate replaces the placement policies of the databases and tables to create.
+	placementTemplate *utils.PlacementTemplate
+	// sequenceRestoreMode is how the restored sequences resume, the one recorded in the backupmeta is used
+	// if it's empty.
+	sequenceRestoreMode metautil.SequenceRestoreMode
 
 	databases map[string]*metautil.Database
 	ddlJobs   []*model.Job
@@ -118,6 +158,8 @@ type SnapClient struct {
 
 	// use db pool to speed up restoration in BR binary mode.
 	dbPool []*tidallocdb.DB
+	// the sessions dedicated to filtering the rows, they're created by Init only if the row filters are set.
+	filterRowsDBs []*tidallocdb.DB
 
 	dom *domain.Domain
 
@@ -151,8 +193,15 @@ type SnapClient struct {
 
 	// checkpoint information for snapshot restore
 	checkpointRunner *checkpoint.CheckpointRunner[checkpoint.RestoreKeyType, checkpoint.RestoreValueType]
+	// rollbackInfo is recorded into the checkpoint metadata at the first run.
+	rollbackInfo *checkpoint.RestoreRollbackInfo
+	// appliedFiles records the files applied to the regions, to skip downloading them when resumed.
+	appliedFiles *AppliedFileRegistry
 
 	checkpointChecksum map[int64]*checkpoint.ChecksumItem
+
+	// the resource group of the checksum requests.
+	resourceGroupName string
 }
 
 // NewRestoreClient returns a new RestoreClient.
@@ -167,10 +216,16 @@ func NewRestoreClient(
 		pdHTTPClient:  pdHTTPCli,
 		tlsConf:       tlsConf,
 		keepaliveConf: keepaliveConf,
+		tableStats:    NewTableStatsCollector(),
 		switchCh:      make(chan struct{}),
 	}
 }
 
+// TableStats returns the restore statistics of the physical tables whose files are imported.
+func (rc *SnapClient) TableStats() []TableStats {
+	return rc.tableStats.Stats()
+}
+
 func (rc *SnapClient) GetRestorer(checkpointRunner *checkpoint.CheckpointRunner[checkpoint.RestoreKeyType, checkpoint.RestoreValueType]) restore.SstRestorer {
 	if rc.restorer == nil {
 		rc.restorer = rc.getRestorerFn(checkpointRunner)
@@ -186,6 +241,9 @@ func (rc *SnapClient) closeConn() {
 	for _, db := range rc.dbPool {
 		db.Close()
 	}
+	for _, db := range rc.filterRowsDBs {
+		db.Close()
+	}
 }
 
 // Close a client.
@@ -210,6 +268,35 @@ func (rc *SnapClient) SetCrypter(crypter *backuppb.CipherInfo) {
 	rc.cipher = crypter
 }
 
+// SetPhaseTimeouts sets the timeouts to detect the stall of the split, scatter, download and ingest.
+func (rc *SnapClient) SetPhaseTimeouts(timeouts restoreutils.PhaseTimeouts) {
+	rc.phaseTimeouts = timeouts
+}
+
+// SetLightningLocalBackend makes the TiDB restore import the files read from the storage through the
+// local backend of Lightning, at most engineConcurrency engines are opened at the same time.
+func (rc *SnapClient) SetLightningLocalBackend(b LocalIngestBackend, s storage.ExternalStorage, engineConcurrency int) {
+	rc.localBackend = b
+	rc.localStorage = s
+	rc.localEngineConcurrency = engineConcurrency
+}
+
+// SetLightningLocalKVReader makes the local backend of Lightning read the kvs of the files by the reader.
+func (rc *SnapClient) SetLightningLocalKVReader(reader KVReader) {
+	rc.localKVReader = reader
+}
+
+// SetResourceGroupName sets the resource group of the checksum requests.
+func (rc *SnapClient) SetResourceGroupName(name string) {
+	rc.resourceGroupName = name
+}
+
+// getAllStores gets the stores to diagnose the stall of a restore phase.
+func (rc *SnapClient) getAllStores(ctx context.Context) ([]*metapb.Store, error) {
+	stores, err := rc.pdClient.GetAllStores(ctx)
+	return stores, errors.Trace(err)
+}
+
 // GetClusterID gets the cluster id from down-stream cluster.
 func (rc *SnapClient) GetClusterID(ctx context.Context) uint64 {
 	return rc.pdClient.GetClusterID(ctx)
@@ -306,6 +393,12 @@ func (rc *SnapClient) AllocTableIDs(ctx context.Context, tables []*metautil.Tabl
 	return nil
 }
 
+// SetRollbackInfo sets what the restore creates in the target cluster, it's recorded into the checkpoint
+// metadata by InitCheckpoint at the first run, so that the restore can be rolled back.
+func (rc *SnapClient) SetRollbackInfo(info *checkpoint.RestoreRollbackInfo) {
+	rc.rollbackInfo = info
+}
+
 // InitCheckpoint initialize the checkpoint status for the cluster. If the cluster is
 // restored for the first time, it will initialize the checkpoint metadata. Otherwrise,
 // it will load checkpoint metadata and checkpoint ranges/checksum from the external
@@ -380,6 +473,7 @@ func (rc *SnapClient) InitCheckpoint(
 		meta := &checkpoint.CheckpointMetadataForSnapshotRestore{
 			UpstreamClusterID: rc.backupMeta.ClusterId,
 			RestoredTS:        rc.backupMeta.EndVersion,
+			Rollback:          rc.rollbackInfo,
 		}
 		// a nil config means undo function
 		if config != nil {
@@ -398,13 +492,37 @@ func (rc *SnapClient) InitCheckpoint(
 	if err != nil {
 		return checkpointSetWithTableID, nil, errors.Trace(err)
 	}
+	if err := rc.initAppliedFileRegistry(ctx, g, store); err != nil {
+		return checkpointSetWithTableID, nil, errors.Trace(err)
+	}
 	return checkpointSetWithTableID, checkpointClusterConfig, nil
 }
 
+// initAppliedFileRegistry loads the files applied to the regions by the previous restore, the file
+// importer skips downloading them.
+func (rc *SnapClient) initAppliedFileRegistry(ctx context.Context, g glue.Glue, store kv.Storage) error {
+	se, err := g.CreateSession(store)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	registry := NewAppliedFileRegistry(se, checkpoint.SnapshotRestoreCheckpointDatabaseName)
+	if err := registry.Load(ctx); err != nil {
+		se.Close()
+		return errors.Trace(err)
+	}
+	rc.appliedFiles = registry
+	if rc.fileImporter != nil && rc.fileImporter.kvMode == TiDBFull {
+		rc.fileImporter.SetAppliedFileRegistry(registry)
+	}
+	return nil
+}
+
 func (rc *SnapClient) WaitForFinishCheckpoint(ctx context.Context, flush bool) {
 	if rc.checkpointRunner != nil {
 		rc.checkpointRunner.WaitForFinish(ctx, flush)
 	}
+	rc.appliedFiles.Close(ctx)
+	rc.appliedFiles = nil
 }
 
 // makeDBPool makes a session pool with specficated size by sessionFactory.
@@ -457,8 +575,31 @@ func (rc *SnapClient) Init(g glue.Glue, store kv.Storage) error {
 			zap.Error(err),
 			zap.Int("sessionCount", len(rc.dbPool)),
 		)
+		return errors.Trace(err)
 	}
-	return errors.Trace(err)
+	if len(rc.rowFilters) > 0 {
+		rc.filterRowsDBs, err = makeDBPool(defaultRowRewriteConcurrency, func() (*tidallocdb.DB, error) {
+			return newFilterRowsDB(g, store, rc.policyMode)
+		})
+		if err != nil {
+			return errors.Annotate(err, "failed to create the sessions filtering the rows")
+		}
+	}
+	return nil
+}
+
+// newFilterRowsDB creates a session deleting the filtered rows without checking the foreign keys, so the
+// rows of the child tables, which are filtered by their own predicates, aren't deleted by cascade.
+func newFilterRowsDB(g glue.Glue, store kv.Storage, policyMode string) (*tidallocdb.DB, error) {
+	db, _, err := tidallocdb.NewDB(g, store, policyMode)
+	if err != nil || db == nil {
+		return db, errors.Trace(err)
+	}
+	if err := db.Session().Execute(context.Background(), "SET SESSION foreign_key_checks = 0"); err != nil {
+		db.Close()
+		return nil, errors.Trace(err)
+	}
+	return db, nil
 }
 
 func SetSpeedLimitFn(ctx context.Context, stores []*metapb.Store, pool *tidbutil.WorkerPool) func(*SnapFileImporter, uint64) error {
@@ -558,10 +699,25 @@ func (rc *SnapClient) initClients(ctx context.Context, backend *backuppb.Storage
 		if err != nil {
 			return errors.Trace(err)
 		}
+		var balancedImporter restore.BalancedFileImporter = fileImporter
+		if rc.localBackend != nil {
+			log.Info("import the files through the local backend of lightning",
+				zap.Int("engine-concurrency", rc.localEngineConcurrency))
+			var reader KVReader = sstKVReader{storage: rc.localStorage, cipher: rc.cipher}
+			if rc.localKVReader != nil {
+				reader = rc.localKVReader
+			}
+			if len(rc.sanitizers) > 0 {
+				reader = &sanitizingKVReader{KVReader: reader, tables: rc.sanitizedTables}
+			}
+			balancedImporter = NewLightningLocalImporterWithReader(rc.localBackend, reader, rc.localEngineConcurrency)
+		}
+		balancedImporter = NewTableStatsImporter(balancedImporter, rc.tableStats)
 		rc.getRestorerFn = func(checkpointRunner *checkpoint.CheckpointRunner[checkpoint.RestoreKeyType, checkpoint.RestoreValueType]) restore.SstRestorer {
-			return restore.NewMultiTablesRestorer(ctx, fileImporter, rc.workerPool, checkpointRunner)
+			return restore.NewMultiTablesRestorer(ctx, balancedImporter, rc.workerPool, checkpointRunner)
 		}
 	}
+	rc.fileImporter = fileImporter
 	return nil
 }
 
@@ -602,6 +758,7 @@ func (rc *SnapClient) LoadSchemaIfNeededAndInitClient(
 	}
 	rc.backupMeta = backupMeta
 	log.Info("load backupmeta", zap.Int("databases", len(rc.databases)), zap.Int("jobs", len(rc.ddlJobs)))
+	rc.resolveSequenceRestoreMode()
 
 	return rc.initClients(c, backend, backupMeta.IsRawKv, backupMeta.IsTxnKv, RawStartKey, RawEndKey)
 }
@@ -727,7 +884,12 @@ func (rc *SnapClient) SetPolicyMap(p *sync.Map) {
 func (rc *SnapClient) CreatePolicies(ctx context.Context, policyMap *sync.Map) error {
 	var err error
 	policyMap.Range(func(key, value any) bool {
-		e := rc.db.CreatePlacementPolicy(ctx, value.(*model.PolicyInfo))
+		policy := value.(*model.PolicyInfo)
+		if rc.placementTemplate != nil && rc.placementTemplate.ReplacesPolicy(policy.Name) {
+			log.Info("skip the placement policy replaced by the template", zap.Stringer("name", policy.Name))
+			return true
+		}
+		e := rc.db.CreatePlacementPolicy(ctx, policy)
 		if e != nil {
 			err = e
 			return false
@@ -743,6 +905,18 @@ func (rc *SnapClient) CreateDatabases(ctx context.Context, dbs []*metautil.Datab
 		log.Info("skip create database")
 		return nil
 	}
+	if rc.charsetConversion != nil {
+		for _, db := range dbs {
+			if err := rc.charsetConversion.ConvertDB(db.Info); err != nil {
+				return errors.Trace(err)
+			}
+		}
+	}
+	if rc.placementTemplate != nil {
+		for _, db := range dbs {
+			rc.placementTemplate.ApplyDB(db.Info)
+		}
+	}
 
 	if len(rc.dbPool) == 0 {
 		log.Info("create databases sequentially")
@@ -800,8 +974,38 @@ func (rc *SnapClient) CreateTables(
 	newTS uint64,
 ) ([]*CreatedTable, error) {
 	log.Info("start create tables", zap.Int("total count", len(tables)))
+	if err := rc.applyColumnMappings(tables); err != nil {
+		return nil, errors.Trace(err)
+	}
+	if err := rc.applyPartitionMerges(tables); err != nil {
+		return nil, errors.Trace(err)
+	}
+	if err := rc.applyCharsetConversion(tables); err != nil {
+		return nil, errors.Trace(err)
+	}
+	rc.applyPlacementTemplate(tables)
 	rc.generateRebasedTables(tables)
 
+	// create the tables stage by stage, e.g. the views are created after the tables they select from.
+	stages := metautil.SortTablesByDependency(tables)
+	createdTables := make([]*CreatedTable, 0, len(tables))
+	for i, stage := range stages {
+		log.Info("create tables of stage", zap.Int("stage", i), zap.Int("count", len(stage)))
+		cts, err := rc.createTablesOfStage(ctx, stage, newTS)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		createdTables = append(createdTables, cts...)
+	}
+	return createdTables, nil
+}
+
+// createTablesOfStage creates the tables concurrently, they must not depend on each other.
+func (rc *SnapClient) createTablesOfStage(
+	ctx context.Context,
+	tables []*metautil.Table,
+	newTS uint64,
+) ([]*CreatedTable, error) {
 	// try to restore tables in batch
 	if rc.batchDdlSize > minBatchDdlSize && len(rc.dbPool) > 0 {
 		tables, err := rc.createTablesBatch(ctx, tables, newTS)
@@ -832,10 +1036,19 @@ func (rc *SnapClient) createTables(
 	if rc.IsSkipCreateSQL() {
 		log.Info("skip create table and alter autoIncID")
 	} else {
-		err := db.CreateTables(ctx, tables, rc.getRebasedTables(), rc.supportPolicy, rc.policyMap)
+		tablesToCreate := make([]*metautil.Table, 0, len(tables))
+		for _, table := range tables {
+			tablesToCreate = append(tablesToCreate, rc.tableToCreate(table))
+		}
+		err := db.CreateTables(ctx, tablesToCreate, rc.getRebasedTables(), rc.supportPolicy, rc.policyMap)
 		if err != nil {
 			return nil, errors.Trace(err)
 		}
+		for _, table := range tables {
+			if err := rc.addMappedColumns(ctx, db, table); err != nil {
+				return nil, errors.Trace(err)
+			}
+		}
 	}
 	cts := make([]*CreatedTable, 0, len(tables))
 	for _, table := range tables {
@@ -850,12 +1063,12 @@ func (rc *SnapClient) createTables(
 				table.Info.IsCommonHandle,
 				newTableInfo.IsCommonHandle)
 		}
-		rules := restoreutils.GetRewriteRules(newTableInfo, table.Info, newTS, true)
-		ct := &CreatedTable{
-			RewriteRule: rules,
-			Table:       newTableInfo,
-			OldTable:    table,
+		if rc.IsSkipCreateSQL() {
+			if err := prepareExistingTable(ctx, db, table, newTableInfo, rc.checkExistingTablesEmpty); err != nil {
+				return nil, errors.Trace(err)
+			}
 		}
+		ct := rc.newCreatedTable(newTableInfo, table, newTS)
 		log.Debug("new created tables", zap.Any("table", ct))
 		cts = append(cts, ct)
 	}
@@ -915,10 +1128,13 @@ func (rc *SnapClient) createTable(
 	if rc.IsSkipCreateSQL() {
 		log.Info("skip create table and alter autoIncID", zap.Stringer("table", table.Info.Name))
 	} else {
-		err := db.CreateTable(ctx, table, rc.getRebasedTables(), rc.supportPolicy, rc.policyMap)
+		err := db.CreateTable(ctx, rc.tableToCreate(table), rc.getRebasedTables(), rc.supportPolicy, rc.policyMap)
 		if err != nil {
 			return nil, errors.Trace(err)
 		}
+		if err := rc.addMappedColumns(ctx, db, table); err != nil {
+			return nil, errors.Trace(err)
+		}
 	}
 	newTableInfo, err := restore.GetTableSchema(rc.dom, table.DB.Name, table.Info.Name)
 	if err != nil {
@@ -931,13 +1147,12 @@ func (rc *SnapClient) createTable(
 			table.Info.IsCommonHandle,
 			newTableInfo.IsCommonHandle)
 	}
-	rules := restoreutils.GetRewriteRules(newTableInfo, table.Info, newTS, true)
-	et := &CreatedTable{
-		RewriteRule: rules,
-		Table:       newTableInfo,
-		OldTable:    table,
+	if rc.IsSkipCreateSQL() {
+		if err := prepareExistingTable(ctx, db, table, newTableInfo, rc.checkExistingTablesEmpty); err != nil {
+			return nil, errors.Trace(err)
+		}
 	}
-	return et, nil
+	return rc.newCreatedTable(newTableInfo, table, newTS), nil
 }
 
 func (rc *SnapClient) createTablesSingle(
@@ -1016,10 +1231,98 @@ func (rc *SnapClient) NeedCheckFreshCluster(ExplicitFilter bool, firstRun bool)
 }
 
 // EnableSkipCreateSQL sets switch of skip create schema and tables.
+// The data is restored into the existing tables with the same names, see CheckTableCompatibility.
 func (rc *SnapClient) EnableSkipCreateSQL() {
 	rc.noSchema = true
 }
 
+// SetCheckExistingTablesEmpty sets whether the existing tables the data is restored into must be empty.
+func (rc *SnapClient) SetCheckExistingTablesEmpty(check bool) {
+	rc.checkExistingTablesEmpty = check
+}
+
+// SetColumnMappings sets the column mappings applied when the tables are created, the keys are from TableKey.
+func (rc *SnapClient) SetColumnMappings(mappings map[string]*ColumnMapping) {
+	rc.columnMappings = mappings
+}
+
+// SetCharsetConversion sets the conversion of the charset of the databases and tables to create.
+func (rc *SnapClient) SetCharsetConversion(c *utils.CharsetConversion) {
+	rc.charsetConversion = c
+}
+
+// applyCharsetConversion converts the charset of the tables to create. The table infos are cloned before
+// converted like ApplyColumnMapping, so a table failed to convert is left as it is.
+func (rc *SnapClient) applyCharsetConversion(tables []*metautil.Table) error {
+	if rc.charsetConversion == nil {
+		return nil
+	}
+	converted := 0
+	for _, table := range tables {
+		info := table.Info.Clone()
+		changed, err := rc.charsetConversion.ConvertTable(info)
+		if err != nil {
+			return errors.Annotatef(err, "failed to convert the charset of %s.%s", table.DB.Name, table.Info.Name)
+		}
+		if changed {
+			table.Info = info
+			converted++
+		}
+	}
+	log.Info("convert the charset of tables", zap.String("from", rc.charsetConversion.From),
+		zap.String("to", rc.charsetConversion.To), zap.Int("converted", converted))
+	return nil
+}
+
+// SetSequenceRestoreMode sets how the restored sequences resume, the one recorded in the backupmeta is used
+// if it's empty.
+func (rc *SnapClient) SetSequenceRestoreMode(mode metautil.SequenceRestoreMode) {
+	rc.sequenceRestoreMode = mode
+}
+
+// resolveSequenceRestoreMode sets the sequence restore mode to the sessions creating the tables, the one
+// recorded in the backupmeta is used if it isn't specified, and the exact value is restored by default.
+func (rc *SnapClient) resolveSequenceRestoreMode() {
+	mode := rc.sequenceRestoreMode
+	if mode == "" {
+		mode = metautil.GetSequenceRestoreMode(rc.backupMeta)
+	}
+	if mode == "" {
+		mode = metautil.SequenceRestoreExact
+	}
+	rc.sequenceRestoreMode = mode
+	if rc.db != nil {
+		rc.db.SetSequenceRestoreMode(mode)
+	}
+	for _, db := range rc.dbPool {
+		db.SetSequenceRestoreMode(mode)
+	}
+	log.Info("set the sequence restore mode", zap.String("mode", string(mode)))
+}
+
+// SetPlacementTemplate sets the template replacing the placement policies of the databases and tables to
+// create. The policies replaced by the template aren't created.
+func (rc *SnapClient) SetPlacementTemplate(t *utils.PlacementTemplate) {
+	rc.placementTemplate = t
+}
+
+// applyPlacementTemplate replaces the placement policies of the tables to create. The table infos are
+// cloned before replaced like applyCharsetConversion.
+func (rc *SnapClient) applyPlacementTemplate(tables []*metautil.Table) {
+	if rc.placementTemplate == nil {
+		return
+	}
+	replaced := 0
+	for _, table := range tables {
+		info := table.Info.Clone()
+		if rc.placementTemplate.ApplyTable(info) {
+			table.Info = info
+			replaced++
+		}
+	}
+	log.Info("replace the placement policies of tables by the template", zap.Int("replaced", replaced))
+}
+
 // IsSkipCreateSQL returns whether we need skip create schema and tables in restore.
 func (rc *SnapClient) IsSkipCreateSQL() bool {
 	return rc.noSchema
@@ -1064,6 +1367,16 @@ func (rc *SnapClient) execAndValidateChecksum(
 		zap.String("table", tbl.OldTable.Info.Name.O),
 	)
 
+	if tbl.PartitionsMerged {
+		// the keys of the partitions can't be rewritten back from the merged table to calculate the checksum.
+		logger.Warn("the partitions of the table are merged, skipping checksum")
+		return nil
+	}
+	if rc.isSanitized(tbl.OldTable.Info.ID) {
+		// the rows are rewritten by the sanitizers, so the checksum of the backup doesn't match.
+		logger.Warn("the rows of the table are sanitized, skipping checksum")
+		return nil
+	}
 	expectedChecksumStats := metautil.CalculateChecksumStatsOnFiles(tbl.OldTable.Files)
 	if !expectedChecksumStats.ChecksumExists() {
 		logger.Warn("table has no checksum, skipping checksum")
@@ -1088,6 +1401,7 @@ func (rc *SnapClient) execAndValidateChecksum(
 			SetOldKeyspace(tbl.RewriteRule.OldKeyspace).
 			SetNewKeyspace(tbl.RewriteRule.NewKeyspace).
 			SetExplicitRequestSourceType(kvutil.ExplicitTypeBR).
+			SetResourceGroupName(rc.resourceGroupName).
 			Build()
 		if err != nil {
 			return errors.Trace(err)
@@ -0,0 +1,200 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package snapclient
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/log"
+	berrors "github.com/pingcap/tidb/br/pkg/errors"
+	"github.com/pingcap/tidb/br/pkg/metautil"
+	tidallocdb "github.com/pingcap/tidb/br/pkg/restore/internal/prealloc_db"
+	"github.com/pingcap/tidb/br/pkg/utils"
+	"github.com/pingcap/tidb/pkg/meta/model"
+	"github.com/pingcap/tidb/pkg/parser/ast"
+	"go.uber.org/zap"
+)
+
+// ColumnMapping changes the columns of a table when it's restored. The rows are encoded with the
+// column IDs, so the data needn't be rewritten: the dropped columns are ignored when the rows are
+// read, and the added columns are filled by their default values.
+type ColumnMapping struct {
+	// Drop is the names of the columns to drop.
+	Drop []string `json:"drop"`
+	// Rename maps the names of the columns in the backup to the new names.
+	Rename map[string]string `json:"rename"`
+	// Add is the definitions of the columns to add, e.g. "c int not null default 0".
+	Add []string `json:"add"`
+}
+
+// TableKey returns the key of the table in the maps set by SetColumnMappings and SetRowFilters.
+func TableKey(db, table string) string {
+	return strings.ToLower(db) + "." + strings.ToLower(table)
+}
+
+// ApplyColumnMapping returns the table info whose columns are dropped and renamed by the mapping.
+// The columns used by the indexes can be renamed but can't be dropped, and the columns used by the
+// partitioning, the generated columns, the constraints or TTL can be neither renamed nor dropped.
+func ApplyColumnMapping(info *model.TableInfo, m *ColumnMapping) (*model.TableInfo, error) {
+	if info.IsView() || info.IsSequence() {
+		return nil, errors.Annotatef(berrors.ErrInvalidArgument, "column mapping can't be applied to %s", info.Name)
+	}
+	nt := info.Clone()
+	dropped := make(map[string]struct{}, len(m.Drop))
+	for _, name := range m.Drop {
+		col := nt.FindPublicColumnByName(strings.ToLower(name))
+		if col == nil {
+			return nil, errors.Annotatef(berrors.ErrInvalidArgument, "column %s to drop not found", name)
+		}
+		if err := checkColumnUnused(nt, col.Name, true); err != nil {
+			return nil, errors.Trace(err)
+		}
+		dropped[col.Name.L] = struct{}{}
+	}
+	renamed := make(map[string]ast.CIStr, len(m.Rename))
+	for from, to := range m.Rename {
+		col := nt.FindPublicColumnByName(strings.ToLower(from))
+		if col == nil {
+			return nil, errors.Annotatef(berrors.ErrInvalidArgument, "column %s to rename not found", from)
+		}
+		if _, ok := dropped[col.Name.L]; ok {
+			return nil, errors.Annotatef(berrors.ErrInvalidArgument, "column %s is both dropped and renamed", from)
+		}
+		if err := checkColumnUnused(nt, col.Name, false); err != nil {
+			return nil, errors.Trace(err)
+		}
+		renamed[col.Name.L] = ast.NewCIStr(to)
+	}
+
+	for _, idx := range nt.Indices {
+		for _, idxCol := range idx.Columns {
+			if newName, ok := renamed[idxCol.Name.L]; ok {
+				idxCol.Name = newName
+			}
+		}
+	}
+	columns := make([]*model.ColumnInfo, 0, len(nt.Columns))
+	for _, col := range nt.Columns {
+		if _, ok := dropped[col.Name.L]; ok {
+			continue
+		}
+		if newName, ok := renamed[col.Name.L]; ok {
+			col.Name = newName
+		}
+		col.Offset = len(columns)
+		columns = append(columns, col)
+	}
+	nt.Columns = columns
+	names := make(map[string]struct{}, len(columns))
+	for _, col := range columns {
+		if _, ok := names[col.Name.L]; ok {
+			return nil, errors.Annotatef(berrors.ErrInvalidArgument, "duplicate column %s after renaming", col.Name)
+		}
+		names[col.Name.L] = struct{}{}
+	}
+	for _, idx := range nt.Indices {
+		for _, idxCol := range idx.Columns {
+			idxCol.Offset = nt.FindPublicColumnByName(idxCol.Name.L).Offset
+		}
+	}
+	return nt, nil
+}
+
+// checkColumnUnused checks whether the column is used by other parts of the table, the column used
+// by the indexes can be renamed but can't be dropped since the index data is restored too.
+func checkColumnUnused(info *model.TableInfo, name ast.CIStr, drop bool) error {
+	used := func(by string) error {
+		return errors.Annotatef(berrors.ErrInvalidArgument, "column %s is used by %s", name, by)
+	}
+	if drop {
+		for _, idx := range info.Indices {
+			if idx.FindColumnByName(name.L) != nil {
+				return used("index " + idx.Name.O)
+			}
+		}
+		if info.PKIsHandle && info.GetPkName().L == name.L {
+			return used("primary key")
+		}
+	}
+	for _, col := range info.Columns {
+		if _, ok := col.Dependences[name.L]; ok {
+			return used("generated column " + col.Name.O)
+		}
+	}
+	for _, c := range info.Constraints {
+		for _, colName := range c.ConstraintCols {
+			if colName.L == name.L {
+				return used("constraint " + c.Name.O)
+			}
+		}
+	}
+	for _, fk := range info.ForeignKeys {
+		for _, colName := range fk.Cols {
+			if colName.L == name.L {
+				return used("foreign key " + fk.Name.O)
+			}
+		}
+	}
+	if info.TTLInfo != nil && info.TTLInfo.ColumnName.L == name.L {
+		return used("TTL")
+	}
+	if pi := info.Partition; pi != nil {
+		for _, colName := range pi.Columns {
+			if colName.L == name.L {
+				return used("partitioning")
+			}
+		}
+		// conservatively match the names in the expression.
+		if regexp.MustCompile(`\b` + regexp.QuoteMeta(name.L) + `\b`).MatchString(strings.ToLower(pi.Expr)) {
+			return used("partitioning")
+		}
+	}
+	return nil
+}
+
+// applyColumnMappings applies the column mappings to the tables to create.
+func (rc *SnapClient) applyColumnMappings(tables []*metautil.Table) error {
+	applied := 0
+	for _, table := range tables {
+		m, ok := rc.columnMappings[TableKey(table.DB.Name.O, table.Info.Name.O)]
+		if !ok {
+			continue
+		}
+		info, err := ApplyColumnMapping(table.Info, m)
+		if err != nil {
+			return errors.Annotatef(err, "failed to apply column mapping to %s.%s", table.DB.Name, table.Info.Name)
+		}
+		log.Info("apply column mapping", zap.Stringer("db", table.DB.Name), zap.Stringer("table", table.Info.Name),
+			zap.Strings("drop", m.Drop), zap.Any("rename", m.Rename), zap.Strings("add", m.Add))
+		table.Info = info
+		// the statistics are of the columns in the backup.
+		table.Stats = nil
+		table.StatsFileIndexes = nil
+		applied++
+	}
+	if applied < len(rc.columnMappings) {
+		log.Warn("some column mappings match no table to restore",
+			zap.Int("mappings", len(rc.columnMappings)), zap.Int("applied", applied))
+	}
+	return nil
+}
+
+// addMappedColumns adds the columns of the column mapping after the table is created.
+func (rc *SnapClient) addMappedColumns(ctx context.Context, db *tidallocdb.DB, table *metautil.Table) error {
+	m, ok := rc.columnMappings[TableKey(table.DB.Name.O, table.Info.Name.O)]
+	if !ok {
+		return nil
+	}
+	for _, def := range m.Add {
+		sql := fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s",
+			utils.EncloseDBAndTable(table.DB.Name.O, table.Info.Name.O), def)
+		if err := db.Session().Execute(ctx, sql); err != nil {
+			return errors.Annotatef(err, "failed to add column by %q", sql)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package snapclient_test
+
+import (
+	"testing"
+
+	snapclient "github.com/pingcap/tidb/br/pkg/restore/snap_client"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyColumnMapping(t *testing.T) {
+	backup := buildTableInfo(t, "create table t (id int primary key, a int, b varchar(10), c int, "+
+		"d int as (c + 1), key idx_b(b)) partition by range (id) (partition p0 values less than (10))")
+
+	info, err := snapclient.ApplyColumnMapping(backup, &snapclient.ColumnMapping{
+		Drop:   []string{"A"},
+		Rename: map[string]string{"b": "B2"},
+	})
+	require.NoError(t, err)
+	names := make([]string, 0, len(info.Columns))
+	for i, col := range info.Columns {
+		require.Equal(t, i, col.Offset)
+		names = append(names, col.Name.O)
+	}
+	require.Equal(t, []string{"id", "B2", "c", "d"}, names)
+	// the column IDs are kept so that the rows can be read.
+	require.Equal(t, backup.Columns[2].ID, info.Columns[1].ID)
+	idx := info.FindIndexByName("idx_b")
+	require.Equal(t, "B2", idx.Columns[0].Name.O)
+	require.Equal(t, 1, idx.Columns[0].Offset)
+	// the backup table info is untouched.
+	require.Len(t, backup.Columns, 5)
+	require.Equal(t, "b", backup.Columns[2].Name.O)
+
+	cases := []struct {
+		mapping *snapclient.ColumnMapping
+		err     string
+	}{
+		{&snapclient.ColumnMapping{Drop: []string{"x"}}, "not found"},
+		{&snapclient.ColumnMapping{Drop: []string{"b"}}, "used by index idx_b"},
+		{&snapclient.ColumnMapping{Drop: []string{"id"}}, "used by"},
+		{&snapclient.ColumnMapping{Drop: []string{"c"}}, "used by generated column d"},
+		{&snapclient.ColumnMapping{Rename: map[string]string{"id": "id2"}}, "used by partitioning"},
+		{&snapclient.ColumnMapping{Rename: map[string]string{"a": "c"}}, "duplicate column c"},
+		{&snapclient.ColumnMapping{Drop: []string{"a"}, Rename: map[string]string{"a": "a2"}}, "both dropped and renamed"},
+	}
+	for _, c := range cases {
+		_, err := snapclient.ApplyColumnMapping(backup, c.mapping)
+		require.ErrorContains(t, err, c.err)
+	}
+}
@@ -0,0 +1,180 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package snapclient
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/log"
+	berrors "github.com/pingcap/tidb/br/pkg/errors"
+	"github.com/pingcap/tidb/br/pkg/metautil"
+	"github.com/pingcap/tidb/br/pkg/restore"
+	tidallocdb "github.com/pingcap/tidb/br/pkg/restore/internal/prealloc_db"
+	"github.com/pingcap/tidb/br/pkg/utils"
+	"github.com/pingcap/tidb/pkg/kv"
+	"github.com/pingcap/tidb/pkg/meta/model"
+	"github.com/pingcap/tidb/pkg/parser/ast"
+	"github.com/pingcap/tidb/pkg/util/sqlexec"
+	"go.uber.org/zap"
+)
+
+// prepareExistingTable checks whether the data of the backed up table can be restored into
+// the table created by the user, and rebases the auto IDs of the table above the restored rows.
+// The table must be empty if checkEmpty is set, otherwise the restored rows may overwrite its rows.
+func prepareExistingTable(
+	ctx context.Context,
+	db *tidallocdb.DB,
+	table *metautil.Table,
+	existing *model.TableInfo,
+	checkEmpty bool,
+) error {
+	if !table.Info.IsView() && !table.Info.IsSequence() {
+		if err := CheckTableCompatibility(existing, table.Info); err != nil {
+			return errors.Annotatef(err, "table %s.%s", table.DB.Name, table.Info.Name)
+		}
+		if checkEmpty {
+			if err := checkTableEmpty(ctx, db, table.DB.Name.O, existing.Name.O); err != nil {
+				return errors.Annotatef(err, "table %s.%s", table.DB.Name, table.Info.Name)
+			}
+		}
+	}
+	name := restore.UniqueTableName{DB: table.DB.Name.String(), Table: table.Info.Name.String()}
+	return errors.Trace(db.CreateTablePostRestore(ctx, table, map[restore.UniqueTableName]bool{name: true}))
+}
+
+// CheckTableCompatibility checks whether the data of the backed up table can be restored into the existing table.
+// The rows are encoded with the column IDs and the index keys with the index IDs, so the columns must be matched
+// by both the names and the IDs, and the indexes must be on the same columns. The index IDs are rewritten by names.
+func CheckTableCompatibility(existing, backup *model.TableInfo) error {
+	if existing.IsView() != backup.IsView() || existing.IsSequence() != backup.IsSequence() {
+		return errors.Annotate(berrors.ErrRestoreIncompatibleTable, "the table type mismatch")
+	}
+	if existing.PKIsHandle != backup.PKIsHandle || existing.IsCommonHandle != backup.IsCommonHandle {
+		return errors.Annotatef(berrors.ErrRestoreIncompatibleTable,
+			"the handle mismatch, clustered in cluster: %v, clustered in backup: %v",
+			existing.HasClusteredIndex(), backup.HasClusteredIndex())
+	}
+
+	if len(existing.Columns) != len(backup.Columns) {
+		return errors.Annotatef(berrors.ErrRestoreIncompatibleTable,
+			"column count mismatch, col in cluster: %d, col in backup: %d", len(existing.Columns), len(backup.Columns))
+	}
+	for _, backupCol := range backup.Columns {
+		col := existing.FindPublicColumnByName(backupCol.Name.L)
+		if col == nil {
+			return errors.Annotatef(berrors.ErrRestoreIncompatibleTable, "missing column %s in cluster", backupCol.Name)
+		}
+		if col.ID != backupCol.ID {
+			return errors.Annotatef(berrors.ErrRestoreIncompatibleTable,
+				"column %s has ID %d in cluster but %d in backup, the columns may be added or dropped in different orders",
+				col.Name, col.ID, backupCol.ID)
+		}
+		if !utils.IsTypeCompatible(backupCol.FieldType, col.FieldType) {
+			return errors.Annotatef(berrors.ErrRestoreIncompatibleTable,
+				"incompatible column, col in cluster: %s %s, col in backup: %s %s",
+				col.Name, col.FieldType.String(), backupCol.Name, backupCol.FieldType.String())
+		}
+	}
+
+	// the indexes only in the cluster would be left empty.
+	if len(existing.Indices) != len(backup.Indices) {
+		return errors.Annotatef(berrors.ErrRestoreIncompatibleTable,
+			"index count mismatch, index in cluster: %d, index in backup: %d", len(existing.Indices), len(backup.Indices))
+	}
+	for _, backupIdx := range backup.Indices {
+		idx := existing.FindIndexByName(backupIdx.Name.L)
+		if idx == nil {
+			return errors.Annotatef(berrors.ErrRestoreIncompatibleTable, "missing index %s in cluster", backupIdx.Name)
+		}
+		if indexSignature(idx) != indexSignature(backupIdx) {
+			return errors.Annotatef(berrors.ErrRestoreIncompatibleTable,
+				"incompatible index, index in cluster: %s, index in backup: %s", indexSignature(idx), indexSignature(backupIdx))
+		}
+	}
+
+	if (existing.Partition == nil) != (backup.Partition == nil) {
+		return errors.Annotate(berrors.ErrRestoreIncompatibleTable, "the partitioning mismatch")
+	}
+	if backup.Partition != nil {
+		if err := checkPartitionCompatibility(existing.Partition, backup.Partition); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	log.Info("the existing table is compatible with the backup", zap.Stringer("table", existing.Name),
+		zap.Int64("id in cluster", existing.ID), zap.Int64("id in backup", backup.ID))
+	return nil
+}
+
+// checkPartitionCompatibility checks whether the rows of every backed up partition belong to the partition
+// with the same name in the cluster. The range and list partitions only in the cluster are left empty, but
+// the rows are routed differently if the number of the hash or key partitions changes.
+func checkPartitionCompatibility(existing, backup *model.PartitionInfo) error {
+	if existing.Type != backup.Type || partitionExprSignature(existing) != partitionExprSignature(backup) {
+		return errors.Annotatef(berrors.ErrRestoreIncompatibleTable,
+			"incompatible partitioning, partition in cluster: %s %s, partition in backup: %s %s",
+			existing.Type, partitionExprSignature(existing), backup.Type, partitionExprSignature(backup))
+	}
+	if (backup.Type == ast.PartitionTypeHash || backup.Type == ast.PartitionTypeKey) &&
+		len(existing.Definitions) != len(backup.Definitions) {
+		return errors.Annotatef(berrors.ErrRestoreIncompatibleTable,
+			"partition count mismatch, partition in cluster: %d, partition in backup: %d",
+			len(existing.Definitions), len(backup.Definitions))
+	}
+	for _, def := range backup.Definitions {
+		i := existing.FindPartitionDefinitionByName(def.Name.L)
+		if i < 0 {
+			return errors.Annotatef(berrors.ErrRestoreIncompatibleTable, "missing partition %s in cluster", def.Name)
+		}
+		if partitionDefSignature(&existing.Definitions[i]) != partitionDefSignature(&def) {
+			return errors.Annotatef(berrors.ErrRestoreIncompatibleTable,
+				"incompatible partition, partition in cluster: %s, partition in backup: %s",
+				partitionDefSignature(&existing.Definitions[i]), partitionDefSignature(&def))
+		}
+	}
+	return nil
+}
+
+func partitionExprSignature(pi *model.PartitionInfo) string {
+	cols := make([]string, 0, len(pi.Columns))
+	for _, col := range pi.Columns {
+		cols = append(cols, col.L)
+	}
+	return fmt.Sprintf("(%s) columns(%s)", strings.ToLower(strings.ReplaceAll(pi.Expr, "`", "")), strings.Join(cols, ","))
+}
+
+func partitionDefSignature(def *model.PartitionDefinition) string {
+	s := def.Name.L
+	if len(def.LessThan) > 0 {
+		s += fmt.Sprintf(" values less than (%s)", strings.Join(def.LessThan, ","))
+	}
+	if len(def.InValues) > 0 {
+		s += fmt.Sprintf(" values in %v", def.InValues)
+	}
+	return s
+}
+
+// checkTableEmpty returns an error if the table has any row.
+func checkTableEmpty(ctx context.Context, db *tidallocdb.DB, dbName, tableName string) error {
+	exec := db.Session().GetSessionCtx().GetRestrictedSQLExecutor()
+	rows, _, err := exec.ExecRestrictedSQL(kv.WithInternalSourceType(ctx, kv.InternalTxnBR),
+		[]sqlexec.OptionFuncAlias{sqlexec.ExecOptionUseCurSession}, "SELECT 1 FROM %n.%n LIMIT 1", dbName, tableName)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if len(rows) > 0 {
+		return errors.Annotate(berrors.ErrRestoreIncompatibleTable,
+			"the table isn't empty, the restored rows may overwrite its rows, set --check-requirements=false to restore anyway")
+	}
+	return nil
+}
+
+func indexSignature(idx *model.IndexInfo) string {
+	s := fmt.Sprintf("%s(primary=%v,unique=%v,global=%v)", idx.Name.L, idx.Primary, idx.Unique, idx.Global)
+	for _, col := range idx.Columns {
+		s += fmt.Sprintf(" %s(%d)", col.Name.L, col.Length)
+	}
+	return s
+}
@@ -0,0 +1,120 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package snapclient_test
+
+import (
+	"testing"
+
+	berrors "github.com/pingcap/tidb/br/pkg/errors"
+	snapclient "github.com/pingcap/tidb/br/pkg/restore/snap_client"
+	"github.com/pingcap/tidb/pkg/ddl"
+	"github.com/pingcap/tidb/pkg/meta/metabuild"
+	"github.com/pingcap/tidb/pkg/meta/model"
+	"github.com/pingcap/tidb/pkg/parser"
+	"github.com/pingcap/tidb/pkg/parser/ast"
+	"github.com/stretchr/testify/require"
+)
+
+func buildTableInfo(t *testing.T, sql string) *model.TableInfo {
+	stmt, err := parser.New().ParseOneStmt(sql, "", "")
+	require.NoError(t, err)
+	ti, err := ddl.BuildTableInfoFromAST(metabuild.NewContext(), stmt.(*ast.CreateTableStmt))
+	require.NoError(t, err)
+	return ti
+}
+
+func TestCheckTableCompatibility(t *testing.T) {
+	backup := buildTableInfo(t, "create table t (id int primary key, a varchar(10), b int, key idx_a(a)) "+
+		"partition by hash(id) partitions 2")
+
+	cases := []struct {
+		sql string
+		err string
+	}{
+		{sql: "create table t (id int primary key, a varchar(10), b int, key idx_a(a)) partition by hash(id) partitions 2"},
+		// wider columns are fine.
+		{sql: "create table t (id int primary key, a varchar(20), b bigint, key idx_a(a)) partition by hash(id) partitions 2"},
+		// the rows are routed to the different partitions.
+		{
+			sql: "create table t (id int primary key, a varchar(10), b int, key idx_a(a)) partition by hash(id) partitions 4",
+			err: "partition count mismatch",
+		},
+		{
+			sql: "create table t (id int primary key, a varchar(10), b int, key idx_a(a)) partition by hash(id + 1) partitions 2",
+			err: "incompatible partitioning",
+		},
+		{
+			sql: "create table t (id int primary key, a varchar(10), b int, key idx_a(a)) partition by key(id) partitions 2",
+			err: "incompatible partitioning",
+		},
+		{
+			sql: "create table t (id int primary key, a varchar(5), b int, key idx_a(a)) partition by hash(id) partitions 2",
+			err: "incompatible column",
+		},
+		{
+			sql: "create table t (id int primary key, b int, a varchar(10), key idx_a(a)) partition by hash(id) partitions 2",
+			err: "has ID",
+		},
+		{
+			sql: "create table t (id int primary key, a varchar(10), b int, c int, key idx_a(a)) partition by hash(id) partitions 2",
+			err: "column count mismatch",
+		},
+		{
+			sql: "create table t (id int primary key, a varchar(10), b int, key idx_a(a), key idx_b(b)) partition by hash(id) partitions 2",
+			err: "index count mismatch",
+		},
+		{
+			sql: "create table t (id int primary key, a varchar(10), b int, key idx_a(b)) partition by hash(id) partitions 2",
+			err: "incompatible index",
+		},
+		{
+			sql: "create table t (id int primary key nonclustered, a varchar(10), b int, key idx_a(a)) partition by hash(id) partitions 2",
+			err: "handle mismatch",
+		},
+		{
+			sql: "create table t (id int primary key, a varchar(10), b int, key idx_a(a))",
+			err: "partitioning mismatch",
+		},
+	}
+	for _, c := range cases {
+		err := snapclient.CheckTableCompatibility(buildTableInfo(t, c.sql), backup)
+		if c.err == "" {
+			require.NoError(t, err, c.sql)
+			continue
+		}
+		require.ErrorIs(t, err, berrors.ErrRestoreIncompatibleTable, c.sql)
+		require.ErrorContains(t, err, c.err, c.sql)
+	}
+}
+
+func TestCheckRangePartitionCompatibility(t *testing.T) {
+	backup := buildTableInfo(t, "create table t (id int) partition by range (id) "+
+		"(partition p0 values less than (10), partition p1 values less than (20))")
+
+	cases := []struct {
+		sql string
+		err string
+	}{
+		// the partitions only in the cluster are left empty.
+		{sql: "create table t (id int) partition by range (id) " +
+			"(partition p0 values less than (10), partition p1 values less than (20), partition p2 values less than (30))"},
+		{
+			sql: "create table t (id int) partition by range (id) (partition p0 values less than (10))",
+			err: "missing partition p1",
+		},
+		{
+			sql: "create table t (id int) partition by range (id) " +
+				"(partition p0 values less than (15), partition p1 values less than (20))",
+			err: "incompatible partition",
+		},
+	}
+	for _, c := range cases {
+		err := snapclient.CheckTableCompatibility(buildTableInfo(t, c.sql), backup)
+		if c.err == "" {
+			require.NoError(t, err, c.sql)
+			continue
+		}
+		require.ErrorIs(t, err, berrors.ErrRestoreIncompatibleTable, c.sql)
+		require.ErrorContains(t, err, c.err, c.sql)
+	}
+}
@@ -19,6 +19,7 @@ import (
 	"context"
 
 	"github.com/pingcap/errors"
+	backuppb "github.com/pingcap/kvproto/pkg/brpb"
 	"github.com/pingcap/kvproto/pkg/import_sstpb"
 	"github.com/pingcap/kvproto/pkg/kvrpcpb"
 	"github.com/pingcap/kvproto/pkg/metapb"
@@ -41,8 +42,29 @@ var (
 	MapTableToFiles         = mapTableToFiles
 	GetFileRangeKey         = getFileRangeKey
 	GetSortedPhysicalTables = getSortedPhysicalTables
+	GetSortedTables         = getSortedTables
 )
 
+// SortTableFiles indexes the files by the flat table ID array, and returns the function locating the files
+// of a table.
+func SortTableFiles(files []*backuppb.File) (func(tableID int64) []*backuppb.File, int) {
+	result, hintSplitKeyCount := sortTableFiles(files)
+	return result.filesOf, hintSplitKeyCount
+}
+
+// SetFilterRowsBatchSize sets the number of rows of each batch filtering the rows, and returns the function
+// resetting it.
+func SetFilterRowsBatchSize(size int) func() {
+	old := filterRowsBatchSize
+	filterRowsBatchSize = size
+	return func() { filterRowsBatchSize = old }
+}
+
+// NewSanitizingKVReader wraps the reader to sanitize the rows of the tables checked by CheckSanitizers.
+func (rc *SnapClient) NewSanitizingKVReader(reader KVReader) KVReader {
+	return &sanitizingKVReader{KVReader: reader, tables: rc.sanitizedTables}
+}
+
 // MockClient create a fake Client used to test.
 func MockClient(dbs map[string]*metautil.Database) *SnapClient {
 	return &SnapClient{databases: dbs}
@@ -152,6 +152,11 @@ type SnapFileImporter struct {
 
 	cacheKey string
 	cond     *sync.Cond
+
+	downloadWatchdog *restoreutils.PhaseWatchdog
+	ingestWatchdog   *restoreutils.PhaseWatchdog
+
+	appliedFiles *AppliedFileRegistry
 }
 
 type SnapFileImporterOptions struct {
@@ -287,6 +292,19 @@ func (importer *SnapFileImporter) SetDownloadSpeedLimit(ctx context.Context, sto
 	return errors.Trace(err)
 }
 
+// SetPhaseWatchdogs sets the watchdogs tracking the download and ingest requests, it must not be
+// called while importing.
+func (importer *SnapFileImporter) SetPhaseWatchdogs(download, ingest *restoreutils.PhaseWatchdog) {
+	importer.downloadWatchdog = download
+	importer.ingestWatchdog = ingest
+}
+
+// SetAppliedFileRegistry sets the registry skipping the files applied to the regions, it must not be
+// called while importing.
+func (importer *SnapFileImporter) SetAppliedFileRegistry(registry *AppliedFileRegistry) {
+	importer.appliedFiles = registry
+}
+
 // CheckMultiIngestSupport checks whether all stores support multi-ingest
 func (importer *SnapFileImporter) CheckMultiIngestSupport(ctx context.Context, tikvStores []*metapb.Store) error {
 	storeIDs := make([]uint64, 0, len(tikvStores))
@@ -377,6 +395,10 @@ func (importer *SnapFileImporter) Import(
 	if err != nil {
 		return errors.Trace(err)
 	}
+	ctx, cancelDownload := importer.downloadWatchdog.Context(ctx)
+	defer cancelDownload()
+	ctx, cancelIngest := importer.ingestWatchdog.Context(ctx)
+	defer cancelIngest()
 
 	err = utils.WithRetry(ctx, func() error {
 		// Scan regions covered by the file range
@@ -391,6 +413,10 @@ func (importer *SnapFileImporter) Import(
 		// Try to download and ingest the file in every region
 		for _, regionInfo := range regionInfos {
 			info := regionInfo
+			if importer.appliedFiles.IsApplied(info, backupFileSets) {
+				log.Debug("skip the files applied to the region", logutil.Region(info.Region))
+				continue
+			}
 			// Try to download file.
 			downloadMetas, errDownload := importer.download(ctx, info, backupFileSets, importer.cipher, importer.apiVersion)
 			if errDownload != nil {
@@ -413,11 +439,16 @@ func (importer *SnapFileImporter) Import(
 					zap.Error(errIngest))
 				return errors.Trace(errIngest)
 			}
+			importer.appliedFiles.Record(ctx, info, backupFileSets)
 			log.Debug("ingest file done", logutil.Key("start", startKey), logutil.Key("end", endKey), zap.Stringer("take", time.Since(start)))
 		}
 		return nil
 	}, utils.NewImportSSTBackoffStrategy())
 	if err != nil {
+		// the requests are canceled once a phase stalls, the diagnosis is more useful than the cancellation.
+		if cause := context.Cause(ctx); cause != nil && berrors.Is(cause, berrors.ErrRestorePhaseStalled) {
+			err = cause
+		}
 		log.Error("import sst file failed after retry, stop the whole progress", restore.ZapBatchBackupFileSet(backupFileSets), zap.Error(err))
 		return errors.Trace(err)
 	}
@@ -664,7 +695,10 @@ func (importer *SnapFileImporter) downloadSST(
 				resp, err = utils.WithRetryV2(ectx, utils.NewDownloadSSTBackoffStrategy(), func(ctx context.Context) (*import_sstpb.DownloadResponse, error) {
 					dctx, cancel := context.WithTimeout(ctx, gRPCTimeOut)
 					defer cancel()
-					return importer.importClient.DownloadSST(dctx, peer.GetStoreId(), req)
+					done := importer.downloadWatchdog.Begin(regionInfo.Region.GetId(), peer.GetStoreId())
+					resp, err := importer.importClient.DownloadSST(dctx, peer.GetStoreId(), req)
+					done(err == nil && resp.GetError() == nil)
+					return resp, err
 				})
 				if err != nil {
 					return errors.Trace(err)
@@ -758,7 +792,9 @@ func (importer *SnapFileImporter) downloadRawKVSST(
 			for _, p := range regionInfo.Region.GetPeers() {
 				peer := p
 				eg.Go(func() error {
+					done := importer.downloadWatchdog.Begin(regionInfo.Region.GetId(), peer.GetStoreId())
 					resp, err := importer.importClient.DownloadSST(ectx, peer.GetStoreId(), req)
+					done(err == nil && resp.GetError() == nil)
 					if err != nil {
 						return errors.Trace(err)
 					}
@@ -894,7 +930,9 @@ func (importer *SnapFileImporter) ingestSSTs(
 		Ssts:    sstMetas,
 	}
 	log.Debug("ingest SSTs", logutil.SSTMetas(sstMetas), logutil.Leader(leader))
+	done := importer.ingestWatchdog.Begin(regionInfo.Region.GetId(), leader.GetStoreId())
 	resp, err := importer.importClient.MultiIngest(ctx, leader.GetStoreId(), req)
+	done(err == nil && resp.GetError() == nil)
 	return resp, errors.Trace(err)
 }
 
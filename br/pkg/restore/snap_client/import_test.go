@@ -17,16 +17,20 @@ package snapclient_test
 import (
 	"context"
 	"testing"
+	"time"
 
 	backuppb "github.com/pingcap/kvproto/pkg/brpb"
 	"github.com/pingcap/kvproto/pkg/import_sstpb"
 	"github.com/pingcap/kvproto/pkg/kvrpcpb"
 	"github.com/pingcap/kvproto/pkg/metapb"
+	berrors "github.com/pingcap/tidb/br/pkg/errors"
 	"github.com/pingcap/tidb/br/pkg/restore"
 	importclient "github.com/pingcap/tidb/br/pkg/restore/internal/import_client"
 	snapclient "github.com/pingcap/tidb/br/pkg/restore/snap_client"
 	"github.com/pingcap/tidb/br/pkg/restore/split"
 	restoreutils "github.com/pingcap/tidb/br/pkg/restore/utils"
+	"github.com/pingcap/tidb/pkg/kv"
+	"github.com/pingcap/tidb/pkg/tablecodec"
 	"github.com/pingcap/tidb/pkg/util/codec"
 	"github.com/stretchr/testify/require"
 )
@@ -114,6 +118,7 @@ type fakeImporterClient struct {
 	importclient.ImporterClient
 
 	speedLimit map[uint64]uint64
+	hangIngest bool
 }
 
 func newFakeImporterClient() *fakeImporterClient {
@@ -152,6 +157,10 @@ func (client *fakeImporterClient) MultiIngest(
 	storeID uint64,
 	req *import_sstpb.MultiIngestRequest,
 ) (*import_sstpb.IngestResponse, error) {
+	if client.hangIngest {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
 	return &import_sstpb.IngestResponse{}, nil
 }
 
@@ -208,3 +217,29 @@ func TestSnapImporterRaw(t *testing.T) {
 	err = importer.Close()
 	require.NoError(t, err)
 }
+
+func TestSnapImporterIngestStall(t *testing.T) {
+	ctx := context.Background()
+	splitClient := split.NewFakeSplitClient()
+	for _, region := range generateRegions() {
+		splitClient.AppendPdRegion(region)
+	}
+	importClient := newFakeImporterClient()
+	importClient.hangIngest = true
+	opt := snapclient.NewSnapFileImporterOptionsForTest(splitClient, importClient, generateStores(), snapclient.RewriteModeKeyspace, 10)
+	importer, err := snapclient.NewSnapFileImporter(ctx, kvrpcpb.APIVersion_V1, snapclient.TiDBFull, opt)
+	require.NoError(t, err)
+	_, ingestWatchdog := restoreutils.StartPhaseWatchdog(ctx, restoreutils.PhaseIngest, 100*time.Millisecond, nil)
+	importer.SetPhaseWatchdogs(nil, ingestWatchdog)
+	_, rules := generateFiles()
+	file := &backuppb.File{
+		Name:     "1_2_3_default.sst",
+		StartKey: tablecodec.EncodeRowKeyWithHandle(100, kv.IntHandle(1)),
+		EndKey:   tablecodec.EncodeRowKeyWithHandle(100, kv.IntHandle(10)),
+	}
+	err = importer.Import(ctx, restore.BackupFileSet{SSTFiles: []*backuppb.File{file}, RewriteRules: rules})
+	require.True(t, berrors.Is(err, berrors.ErrRestorePhaseStalled))
+	require.ErrorContains(t, err, "no ingest request finished in 100ms")
+	require.Equal(t, err.Error(), ingestWatchdog.Stop().Error())
+	require.NoError(t, importer.Close())
+}
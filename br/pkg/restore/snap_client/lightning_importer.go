@@ -0,0 +1,223 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package snapclient
+
+import (
+	"bytes"
+	"context"
+	"slices"
+
+	"github.com/google/uuid"
+	"github.com/pingcap/errors"
+	backuppb "github.com/pingcap/kvproto/pkg/brpb"
+	"github.com/pingcap/log"
+	berrors "github.com/pingcap/tidb/br/pkg/errors"
+	"github.com/pingcap/tidb/br/pkg/export"
+	"github.com/pingcap/tidb/br/pkg/restore"
+	restoreutils "github.com/pingcap/tidb/br/pkg/restore/utils"
+	"github.com/pingcap/tidb/br/pkg/storage"
+	"github.com/pingcap/tidb/br/pkg/summary"
+	"github.com/pingcap/tidb/pkg/lightning/backend"
+	"github.com/pingcap/tidb/pkg/lightning/backend/kv"
+	"github.com/pingcap/tidb/pkg/lightning/checkpoints"
+	"github.com/pingcap/tidb/pkg/lightning/common"
+	"github.com/pingcap/tidb/pkg/util/codec"
+	"go.uber.org/zap"
+)
+
+const (
+	// EngineTiKV restores the backup files by letting TiKV download and ingest them.
+	EngineTiKV = "tikv"
+	// EngineLightningLocal restores the backup files through the local backend of Lightning, the kvs are
+	// sorted locally and ingested into the regions split by the backend.
+	EngineLightningLocal = "lightning-local"
+
+	// lightningLocalBatchSize is the number of the kvs appended to the engine at once.
+	lightningLocalBatchSize = 4096
+)
+
+// LocalIngestBackend is the part of the local backend of Lightning used by the restore.
+type LocalIngestBackend interface {
+	OpenEngine(ctx context.Context, cfg *backend.EngineConfig, engineUUID uuid.UUID) error
+	LocalWriter(ctx context.Context, cfg *backend.LocalWriterConfig, engineUUID uuid.UUID) (backend.EngineWriter, error)
+	CloseEngine(ctx context.Context, cfg *backend.EngineConfig, engineUUID uuid.UUID) error
+	ImportEngine(ctx context.Context, engineUUID uuid.UUID, regionSplitSize, regionSplitKeys int64) error
+	CleanupEngine(ctx context.Context, engineUUID uuid.UUID) error
+}
+
+// KVReader reads the kvs of the backup files imported through the local backend of Lightning. The keys
+// are the raw keys before being rewritten.
+type KVReader interface {
+	ReadKVs(ctx context.Context, files []*backuppb.File, fn func(key, value []byte) error) error
+}
+
+// sstKVReader reads the kvs from the SST files of the backup.
+type sstKVReader struct {
+	storage storage.ExternalStorage
+	cipher  *backuppb.CipherInfo
+}
+
+func (r sstKVReader) ReadKVs(ctx context.Context, files []*backuppb.File, fn func(key, value []byte) error) error {
+	return export.ReadSnapshotRows(ctx, r.storage, r.cipher, files, func(key, value []byte) error {
+		_, rawKey, err := codec.DecodeBytes(key, nil)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		return fn(rawKey, value)
+	})
+}
+
+// LightningLocalImporter imports the backup files through the local backend of Lightning. The files of
+// each Import call are read and rewritten by BR, written into an engine of their own, and ingested
+// before the call returns, so the checkpoint of the restore still works. The duplicated keys are
+// handled by the conflict detection of the backend.
+type LightningLocalImporter struct {
+	backend LocalIngestBackend
+	reader  KVReader
+	// engineTokens limits the engines opened at the same time.
+	engineTokens chan struct{}
+}
+
+var _ restore.BalancedFileImporter = (*LightningLocalImporter)(nil)
+
+// NewLightningLocalImporter creates an importer reading the backup files from the storage, at most
+// engineConcurrency engines are opened at the same time.
+func NewLightningLocalImporter(
+	b LocalIngestBackend,
+	s storage.ExternalStorage,
+	cipher *backuppb.CipherInfo,
+	engineConcurrency int,
+) *LightningLocalImporter {
+	return NewLightningLocalImporterWithReader(b, sstKVReader{storage: s, cipher: cipher}, engineConcurrency)
+}
+
+// NewLightningLocalImporterWithReader creates an importer reading the kvs of the backup files by the
+// reader, e.g. the files which aren't SST files.
+func NewLightningLocalImporterWithReader(
+	b LocalIngestBackend,
+	reader KVReader,
+	engineConcurrency int,
+) *LightningLocalImporter {
+	return &LightningLocalImporter{
+		backend:      b,
+		reader:       reader,
+		engineTokens: make(chan struct{}, max(engineConcurrency, 1)),
+	}
+}
+
+// PauseForBackpressure implements restore.BalancedFileImporter, the backpressure is done by the engine
+// tokens in Import.
+func (*LightningLocalImporter) PauseForBackpressure() {}
+
+// Close implements restore.FileImporter. The backend is closed by its creator.
+func (*LightningLocalImporter) Close() error {
+	return nil
+}
+
+// Import implements restore.FileImporter.
+func (importer *LightningLocalImporter) Import(ctx context.Context, fileSets ...restore.BackupFileSet) error {
+	select {
+	case importer.engineTokens <- struct{}{}:
+	case <-ctx.Done():
+		return errors.Trace(ctx.Err())
+	}
+	defer func() { <-importer.engineTokens }()
+
+	engineUUID := uuid.New()
+	// the table info is only used by the logs of the conflict detection.
+	engineCfg := &backend.EngineConfig{TableInfo: &checkpoints.TidbTableInfo{}}
+	if err := importer.backend.OpenEngine(ctx, engineCfg, engineUUID); err != nil {
+		return errors.Annotate(err, "failed to open the engine")
+	}
+	defer func() {
+		if err := importer.backend.CleanupEngine(ctx, engineUUID); err != nil {
+			log.Warn("failed to clean up the engine", zap.Stringer("engine", engineUUID), zap.Error(err))
+		}
+	}()
+
+	kvs, size, err := importer.writeEngine(ctx, engineUUID, fileSets)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if err := importer.backend.CloseEngine(ctx, engineCfg, engineUUID); err != nil {
+		return errors.Annotate(err, "failed to close the engine")
+	}
+	// the region split size and keys of TiKV are used if they are larger.
+	if err := importer.backend.ImportEngine(ctx, engineUUID, 0, 0); err != nil {
+		return errors.Annotate(err, "failed to ingest the engine")
+	}
+	for _, set := range fileSets {
+		for _, file := range set.SSTFiles {
+			summary.CollectSuccessUnit(summary.TotalKV, 1, file.TotalKvs)
+			summary.CollectSuccessUnit(summary.TotalBytes, 1, file.TotalBytes)
+		}
+	}
+	log.Debug("imported the files through the local backend", zap.Stringer("engine", engineUUID),
+		restore.ZapBatchBackupFileSet(fileSets), zap.Int("kvs", kvs), zap.Int("size", size))
+	return nil
+}
+
+// writeEngine writes the rewritten kvs of the files into the engine, and returns the count and the size
+// of them.
+func (importer *LightningLocalImporter) writeEngine(
+	ctx context.Context,
+	engineUUID uuid.UUID,
+	fileSets []restore.BackupFileSet,
+) (count, size int, err error) {
+	writer, err := importer.backend.LocalWriter(ctx, &backend.LocalWriterConfig{}, engineUUID)
+	if err != nil {
+		return 0, 0, errors.Annotate(err, "failed to create the writer of the engine")
+	}
+	pairs := make([]common.KvPair, 0, lightningLocalBatchSize)
+	flush := func() error {
+		if len(pairs) == 0 {
+			return nil
+		}
+		err := writer.AppendRows(ctx, nil, kv.MakeRowsFromKvPairs(pairs))
+		pairs = make([]common.KvPair, 0, lightningLocalBatchSize)
+		return errors.Trace(err)
+	}
+	for _, set := range fileSets {
+		err := importer.reader.ReadKVs(ctx, set.SSTFiles, func(key, value []byte) error {
+			newKey, err := rewriteRawKeyForLocal(key, set.RewriteRules)
+			if err != nil {
+				return errors.Trace(err)
+			}
+			pairs = append(pairs, common.KvPair{Key: newKey, Val: slices.Clone(value)})
+			count++
+			size += len(newKey) + len(value)
+			if len(pairs) >= lightningLocalBatchSize {
+				return flush()
+			}
+			return nil
+		})
+		if err != nil {
+			_, _ = writer.Close(ctx)
+			return 0, 0, errors.Trace(err)
+		}
+	}
+	if err := flush(); err != nil {
+		_, _ = writer.Close(ctx)
+		return 0, 0, errors.Trace(err)
+	}
+	if _, err := writer.Close(ctx); err != nil {
+		return 0, 0, errors.Annotate(err, "failed to flush the writer of the engine")
+	}
+	return count, size, nil
+}
+
+// rewriteRawKeyForLocal rewrites the prefix of the raw key by the rules, the key is kept if there is no
+// rule.
+func rewriteRawKeyForLocal(key []byte, rules *restoreutils.RewriteRules) ([]byte, error) {
+	if rules == nil || len(rules.Data) == 0 {
+		return key, nil
+	}
+	for _, rule := range rules.Data {
+		if bytes.HasPrefix(key, rule.GetOldKeyPrefix()) {
+			newKey := make([]byte, 0, len(rule.GetNewKeyPrefix())+len(key)-len(rule.GetOldKeyPrefix()))
+			newKey = append(newKey, rule.GetNewKeyPrefix()...)
+			return append(newKey, key[len(rule.GetOldKeyPrefix()):]...), nil
+		}
+	}
+	return nil, errors.Annotatef(berrors.ErrRestoreInvalidRewrite, "no rewrite rule matches the key %x", key)
+}
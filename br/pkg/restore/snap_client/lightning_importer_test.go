@@ -0,0 +1,214 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package snapclient_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"sync"
+	"testing"
+
+	"github.com/cockroachdb/pebble/sstable"
+	"github.com/google/uuid"
+	backuppb "github.com/pingcap/kvproto/pkg/brpb"
+	"github.com/pingcap/kvproto/pkg/import_sstpb"
+	berrors "github.com/pingcap/tidb/br/pkg/errors"
+	"github.com/pingcap/tidb/br/pkg/restore"
+	snapclient "github.com/pingcap/tidb/br/pkg/restore/snap_client"
+	restoreutils "github.com/pingcap/tidb/br/pkg/restore/utils"
+	"github.com/pingcap/tidb/br/pkg/storage"
+	"github.com/pingcap/tidb/br/pkg/stream"
+	"github.com/pingcap/tidb/pkg/kv"
+	"github.com/pingcap/tidb/pkg/lightning/backend"
+	"github.com/pingcap/tidb/pkg/lightning/backend/encode"
+	lkv "github.com/pingcap/tidb/pkg/lightning/backend/kv"
+	"github.com/pingcap/tidb/pkg/lightning/common"
+	"github.com/pingcap/tidb/pkg/tablecodec"
+	"github.com/pingcap/tidb/pkg/util/codec"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeLocalBackend records the kvs ingested by each engine.
+type fakeLocalBackend struct {
+	mu       sync.Mutex
+	written  map[uuid.UUID][]common.KvPair
+	ingested [][]common.KvPair
+	cleaned  int
+}
+
+func newFakeLocalBackend() *fakeLocalBackend {
+	return &fakeLocalBackend{written: make(map[uuid.UUID][]common.KvPair)}
+}
+
+func (b *fakeLocalBackend) OpenEngine(_ context.Context, _ *backend.EngineConfig, engineUUID uuid.UUID) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.written[engineUUID] = nil
+	return nil
+}
+
+func (b *fakeLocalBackend) LocalWriter(_ context.Context, _ *backend.LocalWriterConfig, engineUUID uuid.UUID) (backend.EngineWriter, error) {
+	return &fakeEngineWriter{backend: b, engineUUID: engineUUID}, nil
+}
+
+func (*fakeLocalBackend) CloseEngine(context.Context, *backend.EngineConfig, uuid.UUID) error {
+	return nil
+}
+
+func (b *fakeLocalBackend) ImportEngine(_ context.Context, engineUUID uuid.UUID, _, _ int64) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.ingested = append(b.ingested, b.written[engineUUID])
+	return nil
+}
+
+func (b *fakeLocalBackend) CleanupEngine(_ context.Context, engineUUID uuid.UUID) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.written, engineUUID)
+	b.cleaned++
+	return nil
+}
+
+type fakeEngineWriter struct {
+	backend    *fakeLocalBackend
+	engineUUID uuid.UUID
+}
+
+func (w *fakeEngineWriter) AppendRows(_ context.Context, _ []string, rows encode.Rows) error {
+	w.backend.mu.Lock()
+	defer w.backend.mu.Unlock()
+	w.backend.written[w.engineUUID] = append(w.backend.written[w.engineUUID], lkv.Rows2KvPairs(rows)...)
+	return nil
+}
+
+func (*fakeEngineWriter) IsSynced() bool { return true }
+
+func (*fakeEngineWriter) Close(context.Context) (backend.ChunkFlushStatus, error) {
+	return nil, nil
+}
+
+type lightningTestKV struct {
+	key, value []byte
+}
+
+// writeLightningTestSST writes the kvs into a sst file with the data prefix like TiKV.
+func writeLightningTestSST(t *testing.T, s storage.ExternalStorage, name string, kvs ...lightningTestKV) {
+	var buf bytes.Buffer
+	w := sstable.NewWriter(&memSSTWritable{buf: &buf}, sstable.WriterOptions{})
+	for _, kv := range kvs {
+		require.NoError(t, w.Set(append([]byte{'z'}, kv.key...), kv.value))
+	}
+	require.NoError(t, w.Close())
+	require.NoError(t, s.WriteFile(context.Background(), name, buf.Bytes()))
+}
+
+type memSSTWritable struct {
+	buf *bytes.Buffer
+}
+
+func (w *memSSTWritable) Write(p []byte) error {
+	_, err := w.buf.Write(p)
+	return err
+}
+
+func (*memSSTWritable) Finish() error { return nil }
+
+func (*memSSTWritable) Abort() {}
+
+func TestLightningLocalImporter(t *testing.T) {
+	ctx := context.Background()
+	s, err := storage.NewLocalStorage(t.TempDir())
+	require.NoError(t, err)
+
+	// the ts are like the TSO, the start ts in the write cf value has at least 9 bytes.
+	const commitTS, startTS = uint64(1)<<60 + 2, uint64(1)<<60 + 1
+	rowKey := func(tableID, handle int64) []byte {
+		return tablecodec.EncodeRowKeyWithHandle(tableID, kv.IntHandle(handle))
+	}
+	withTS := func(key []byte, ts uint64) []byte {
+		return binary.BigEndian.AppendUint64(codec.EncodeBytes(nil, key), ^ts)
+	}
+	writeValue := func(tp stream.WriteType, shortValue []byte) []byte {
+		data := binary.AppendUvarint([]byte{tp}, startTS)
+		if len(shortValue) > 0 {
+			data = append(data, 'v', byte(len(shortValue)))
+			data = append(data, shortValue...)
+		}
+		return data
+	}
+	longValue := bytes.Repeat([]byte{'x'}, 300)
+	writeLightningTestSST(t, s, "1_write.sst",
+		lightningTestKV{withTS(rowKey(100, 1), commitTS), writeValue(stream.WriteTypePut, []byte("v1"))},
+		lightningTestKV{withTS(rowKey(100, 2), commitTS), writeValue(stream.WriteTypePut, nil)},
+		lightningTestKV{withTS(rowKey(100, 3), commitTS), writeValue(stream.WriteTypeDelete, nil)},
+	)
+	writeLightningTestSST(t, s, "1_default.sst",
+		lightningTestKV{withTS(rowKey(100, 2), startTS), longValue},
+	)
+	files := []*backuppb.File{
+		{Name: "1_default.sst", Cf: stream.DefaultCF, TotalKvs: 1, TotalBytes: 300},
+		{Name: "1_write.sst", Cf: stream.WriteCF, TotalKvs: 3, TotalBytes: 30},
+	}
+	rules := &restoreutils.RewriteRules{Data: []*import_sstpb.RewriteRule{{
+		OldKeyPrefix: tablecodec.EncodeTablePrefix(100),
+		NewKeyPrefix: tablecodec.EncodeTablePrefix(200),
+	}}}
+
+	b := newFakeLocalBackend()
+	importer := snapclient.NewLightningLocalImporter(b, s, nil, 2)
+	require.NoError(t, importer.Import(ctx, restore.BackupFileSet{TableID: 100, SSTFiles: files, RewriteRules: rules}))
+	require.Equal(t, [][]common.KvPair{{
+		{Key: rowKey(200, 1), Val: []byte("v1")},
+		{Key: rowKey(200, 2), Val: longValue},
+	}}, b.ingested)
+	require.Equal(t, 1, b.cleaned)
+
+	// the key not matching any rule can't be restored.
+	rules = &restoreutils.RewriteRules{Data: []*import_sstpb.RewriteRule{{
+		OldKeyPrefix: tablecodec.EncodeTablePrefix(101),
+		NewKeyPrefix: tablecodec.EncodeTablePrefix(201),
+	}}}
+	err = importer.Import(ctx, restore.BackupFileSet{TableID: 100, SSTFiles: files, RewriteRules: rules})
+	require.ErrorIs(t, err, berrors.ErrRestoreInvalidRewrite)
+	require.Len(t, b.ingested, 1)
+	require.Equal(t, 2, b.cleaned)
+	require.NoError(t, importer.Close())
+}
+
+// kvReaderFunc reads the kvs of the files by the function.
+type kvReaderFunc func(ctx context.Context, files []*backuppb.File, fn func(key, value []byte) error) error
+
+func (f kvReaderFunc) ReadKVs(ctx context.Context, files []*backuppb.File, fn func(key, value []byte) error) error {
+	return f(ctx, files, fn)
+}
+
+func TestLightningLocalImporterWithReader(t *testing.T) {
+	ctx := context.Background()
+	rowKey := func(tableID, handle int64) []byte {
+		return tablecodec.EncodeRowKeyWithHandle(tableID, kv.IntHandle(handle))
+	}
+	reader := kvReaderFunc(func(_ context.Context, files []*backuppb.File, fn func(key, value []byte) error) error {
+		for i, file := range files {
+			if err := fn(rowKey(100, int64(i+1)), []byte(file.Name)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	files := []*backuppb.File{{Name: "t.1.sql", Cf: stream.WriteCF}, {Name: "t.2.sql", Cf: stream.WriteCF}}
+	rules := &restoreutils.RewriteRules{Data: []*import_sstpb.RewriteRule{{
+		OldKeyPrefix: tablecodec.EncodeTablePrefix(100),
+		NewKeyPrefix: tablecodec.EncodeTablePrefix(200),
+	}}}
+
+	b := newFakeLocalBackend()
+	importer := snapclient.NewLightningLocalImporterWithReader(b, reader, 1)
+	require.NoError(t, importer.Import(ctx, restore.BackupFileSet{TableID: 100, SSTFiles: files, RewriteRules: rules}))
+	require.Equal(t, [][]common.KvPair{{
+		{Key: rowKey(200, 1), Val: []byte("t.1.sql")},
+		{Key: rowKey(200, 2), Val: []byte("t.2.sql")},
+	}}, b.ingested)
+	require.NoError(t, importer.Close())
+}
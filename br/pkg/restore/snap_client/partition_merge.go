@@ -0,0 +1,78 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package snapclient
+
+import (
+	"github.com/pingcap/errors"
+	"github.com/pingcap/log"
+	"github.com/pingcap/tidb/br/pkg/metautil"
+	restoreutils "github.com/pingcap/tidb/br/pkg/restore/utils"
+	"github.com/pingcap/tidb/br/pkg/utils"
+	"github.com/pingcap/tidb/pkg/meta/model"
+	filter "github.com/pingcap/tidb/pkg/util/table-filter"
+	"go.uber.org/zap"
+)
+
+// SetMergePartitions sets the filter of the partitioned tables restored as non-partitioned tables, the
+// rows of all the partitions are rewritten into the table. It's used by the snapshot restore of PITR, so
+// the log restore can merge the partitions of the tables too.
+func (rc *SnapClient) SetMergePartitions(f filter.Filter) {
+	rc.mergePartitions = f
+}
+
+// applyPartitionMerges records the tables info without the partitions to create for the tables to merge.
+// The table info in the backup keeps the partitions, so the backup files of the partitions are rewritten
+// into the table, see restoreutils.GetMergedRewriteRules.
+func (rc *SnapClient) applyPartitionMerges(tables []*metautil.Table) error {
+	if rc.mergePartitions == nil {
+		return nil
+	}
+	rc.mergedTables = make(map[int64]*model.TableInfo)
+	for _, table := range tables {
+		if table.Info == nil || table.Info.GetPartitionInfo() == nil || utils.IsSysDB(table.DB.Name.O) ||
+			!rc.mergePartitions.MatchTable(table.DB.Name.O, table.Info.Name.O) {
+			continue
+		}
+		if err := restoreutils.CheckPartitionsMergeable(table.Info); err != nil {
+			return errors.Annotatef(err, "failed to merge the partitions of %s.%s", table.DB.Name, table.Info.Name)
+		}
+		info := table.Info.Clone()
+		info.Partition = nil
+		rc.mergedTables[table.Info.ID] = info
+		// the statistics are of the partitions in the backup.
+		table.Stats = nil
+		table.StatsFileIndexes = nil
+		log.Info("merge the partitions of the table", zap.Stringer("db", table.DB.Name),
+			zap.Stringer("table", table.Info.Name), zap.Int("partitions", len(table.Info.Partition.Definitions)))
+	}
+	return nil
+}
+
+// tableToCreate returns the table to create, the partitions of the tables to merge are removed.
+func (rc *SnapClient) tableToCreate(table *metautil.Table) *metautil.Table {
+	info, ok := rc.mergedTables[table.Info.ID]
+	if !ok {
+		return table
+	}
+	merged := *table
+	merged.Info = info
+	return &merged
+}
+
+// newCreatedTable returns the created table with the rewrite rules from the table in the backup, the
+// partitions are rewritten into the table if they're merged.
+func (rc *SnapClient) newCreatedTable(newTableInfo *model.TableInfo, table *metautil.Table, newTS uint64) *CreatedTable {
+	if _, ok := rc.mergedTables[table.Info.ID]; ok && newTableInfo.Partition == nil {
+		return &CreatedTable{
+			RewriteRule:      restoreutils.GetMergedRewriteRules(newTableInfo, table.Info, newTS, true),
+			Table:            newTableInfo,
+			OldTable:         table,
+			PartitionsMerged: true,
+		}
+	}
+	return &CreatedTable{
+		RewriteRule: restoreutils.GetRewriteRules(newTableInfo, table.Info, newTS, true),
+		Table:       newTableInfo,
+		OldTable:    table,
+	}
+}
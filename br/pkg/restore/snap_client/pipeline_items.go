@@ -20,6 +20,7 @@ import (
 
 	"github.com/pingcap/errors"
 	"github.com/pingcap/log"
+	berrors "github.com/pingcap/tidb/br/pkg/errors"
 	"github.com/pingcap/tidb/br/pkg/glue"
 	"github.com/pingcap/tidb/br/pkg/metautil"
 	restoreutils "github.com/pingcap/tidb/br/pkg/restore/utils"
@@ -27,6 +28,8 @@ import (
 	"github.com/pingcap/tidb/br/pkg/summary"
 	"github.com/pingcap/tidb/pkg/domain/infosync"
 	"github.com/pingcap/tidb/pkg/kv"
+	"github.com/pingcap/tidb/pkg/lightning/common"
+	"github.com/pingcap/tidb/pkg/meta/autoid"
 	"github.com/pingcap/tidb/pkg/meta/model"
 	tidbutil "github.com/pingcap/tidb/pkg/util"
 	"github.com/pingcap/tidb/pkg/util/engine"
@@ -47,12 +50,19 @@ type CreatedTable struct {
 	RewriteRule *restoreutils.RewriteRules
 	Table       *model.TableInfo
 	OldTable    *metautil.Table
+	// PartitionsMerged is set if the partitions of the table in the backup are merged into the table.
+	PartitionsMerged bool
+	// FilteredRows is the number of the rows deleted by the row filter after restored.
+	FilteredRows uint64
 }
 
 type PhysicalTable struct {
 	NewPhysicalID int64
 	OldPhysicalID int64
 	RewriteRules  *restoreutils.RewriteRules
+	// GlobalIndexes is set for the logical table of a partitioned table, whose keys are only the global
+	// indexes.
+	GlobalIndexes bool
 }
 
 func defaultOutputTableChan() chan *CreatedTable {
@@ -131,6 +141,84 @@ func (rc *SnapClient) GoValidateChecksum(
 	return outCh
 }
 
+// GoFilterRows forks a goroutine to delete the rows not matching the row filters after restore.
+// The statistics in the backup are skipped for the filtered tables since they don't match the rows anymore.
+// Each table is filtered by one of the sessions dedicated to filtering.
+func (rc *SnapClient) GoFilterRows(
+	ctx context.Context,
+	inCh <-chan *CreatedTable,
+	errCh chan<- error,
+) chan *CreatedTable {
+	log.Info("Start to filter rows")
+	outCh := defaultOutputTableChan()
+	sessions := make(chan glue.Session, len(rc.filterRowsDBs))
+	for _, db := range rc.filterRowsDBs {
+		sessions <- db.Session()
+	}
+	workers := tidbutil.NewWorkerPool(uint(max(len(rc.filterRowsDBs), 1)), "FilterRows")
+	go concurrentHandleTablesCh(ctx, inCh, outCh, errCh, workers, func(c context.Context, tbl *CreatedTable) error {
+		oldTable := tbl.OldTable
+		predicate, ok := rc.rowFilters[TableKey(oldTable.DB.Name.O, oldTable.Info.Name.O)]
+		if !ok {
+			return nil
+		}
+		if len(rc.filterRowsDBs) == 0 {
+			return errors.Errorf("no session to filter the rows of %s.%s, the row filters must be set before Init",
+				oldTable.DB.Name, tbl.Table.Name)
+		}
+		se := <-sessions
+		defer func() { sessions <- se }()
+		start := time.Now()
+		deleted, err := filterRows(c, se, oldTable.DB.Name.O, tbl.Table, predicate)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		tbl.FilteredRows = deleted
+		oldTable.Stats = nil
+		oldTable.StatsFileIndexes = nil
+		log.Info("filter rows done", zap.Stringer("db", oldTable.DB.Name), zap.Stringer("table", tbl.Table.Name),
+			zap.String("predicate", predicate), zap.Uint64("deleted", deleted), zap.Duration("cost", time.Since(start)))
+		return nil
+	}, func() {
+		log.Info("all rows filtered")
+	})
+	return outCh
+}
+
+// GoRebaseAutoIDs rebases the auto id allocators of the restored tables, so the ids allocated later don't
+// conflict with the imported rows. The bases are the max ids used by the rows of the old table, nil means
+// no id is used.
+func (rc *SnapClient) GoRebaseAutoIDs(
+	ctx context.Context,
+	inCh <-chan *CreatedTable,
+	errCh chan<- error,
+	bases func(oldTableID int64) map[autoid.AllocatorType]int64,
+) chan *CreatedTable {
+	log.Info("Start to rebase auto ids")
+	outCh := defaultOutputTableChan()
+	workers := tidbutil.NewWorkerPool(defaultChecksumConcurrency, "RebaseAutoIDs")
+	go concurrentHandleTablesCh(ctx, inCh, outCh, errCh, workers, func(c context.Context, tbl *CreatedTable) error {
+		oldTable := tbl.OldTable
+		tableBases := bases(oldTable.Info.ID)
+		if len(tableBases) == 0 {
+			return nil
+		}
+		db, ok := rc.dom.InfoSchema().SchemaByName(oldTable.DB.Name)
+		if !ok {
+			return errors.Annotatef(berrors.ErrRestoreSchemaNotExists, "database %s not found", oldTable.DB.Name)
+		}
+		if err := common.RebaseTableAllocators(c, tableBases, rc.dom, db.ID, tbl.Table); err != nil {
+			return errors.Annotatef(err, "failed to rebase the auto ids of %s.%s", oldTable.DB.Name, tbl.Table.Name)
+		}
+		log.Info("rebase auto ids done", zap.Stringer("db", oldTable.DB.Name), zap.Stringer("table", tbl.Table.Name),
+			zap.Any("bases", tableBases))
+		return nil
+	}, func() {
+		log.Info("all auto ids rebased")
+	})
+	return outCh
+}
+
 func (rc *SnapClient) GoUpdateMetaAndLoadStats(
 	ctx context.Context,
 	s storage.ExternalStorage,
@@ -182,6 +270,8 @@ func (rc *SnapClient) GoUpdateMetaAndLoadStats(
 			log.Info("start update metas", zap.Stringer("table", oldTable.Info.Name), zap.Stringer("db", oldTable.DB.Name))
 			// the total kvs contains the index kvs, but the stats meta needs the count of rows
 			count := int64(oldTable.TotalKvs / uint64(len(oldTable.Info.Indices)+1))
+			// the rows deleted by the row filter are gone.
+			count = max(count-int64(tbl.FilteredRows), 0)
 			if statsErr = statsHandler.SaveMetaToStorage(tbl.Table.ID, count, 0, "br restore"); statsErr != nil {
 				log.Error("update stats meta failed", zap.Any("table", tbl.Table), zap.Error(statsErr))
 			}
@@ -159,7 +159,7 @@ func (manager *onlinePlacementRuleManager) setupPlacementRules(ctx context.Conte
 		Values: []string{restoreLabelValue},
 	})
 	for tableID := range manager.restoreTables {
-		rule.ID = getRuleID(tableID)
+		rule.ID = RestorePlacementRuleID(tableID)
 		rule.StartKeyHex = hex.EncodeToString(codec.EncodeBytes([]byte{}, tablecodec.EncodeTablePrefix(tableID)))
 		rule.EndKeyHex = hex.EncodeToString(codec.EncodeBytes([]byte{}, tablecodec.EncodeTablePrefix(tableID+1)))
 		err = manager.toolClient.SetPlacementRule(ctx, rule)
@@ -234,7 +234,8 @@ func (manager *onlinePlacementRuleManager) waitPlacementSchedule(ctx context.Con
 	}
 }
 
-func getRuleID(tableID int64) string {
+// RestorePlacementRuleID returns the id of the placement rule of the table set by the online restore.
+func RestorePlacementRuleID(tableID int64) string {
 	return "restore-t" + strconv.FormatInt(tableID, 10)
 }
 
@@ -243,7 +244,7 @@ func (manager *onlinePlacementRuleManager) ResetPlacementRules(ctx context.Conte
 	log.Info("start resetting placement rules")
 	var failedTables []int64
 	for tableID := range manager.restoreTables {
-		err := manager.toolClient.DeletePlacementRule(ctx, "pd", getRuleID(tableID))
+		err := manager.toolClient.DeletePlacementRule(ctx, "pd", RestorePlacementRuleID(tableID))
 		if err != nil {
 			log.Info("failed to delete placement rule for table", zap.Int64("table-id", tableID))
 			failedTables = append(failedTables, tableID)
@@ -0,0 +1,179 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package snapclient
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/pingcap/errors"
+	berrors "github.com/pingcap/tidb/br/pkg/errors"
+	"github.com/pingcap/tidb/br/pkg/glue"
+	"github.com/pingcap/tidb/br/pkg/utils"
+	"github.com/pingcap/tidb/pkg/kv"
+	"github.com/pingcap/tidb/pkg/meta/model"
+	"github.com/pingcap/tidb/pkg/parser"
+	"github.com/pingcap/tidb/pkg/parser/ast"
+	"github.com/pingcap/tidb/pkg/parser/format"
+	"github.com/pingcap/tidb/pkg/planner/core/resolve"
+	"github.com/pingcap/tidb/pkg/types"
+	// the parser driver is required to parse the literals in the predicates.
+	_ "github.com/pingcap/tidb/pkg/types/parser_driver"
+	"github.com/pingcap/tidb/pkg/util/chunk"
+	"github.com/pingcap/tidb/pkg/util/sqlescape"
+	"github.com/pingcap/tidb/pkg/util/sqlexec"
+)
+
+// filterRowsBatchSize is the number of rows deleted by each batch when filtering the rows.
+var filterRowsBatchSize = 10000
+
+// ParseRowFilters parses the row filters like `db.t: created_at >= "2024-01-01"`, and returns
+// the predicates keyed by TableKey. Only the rows matching the predicate of the table are kept.
+func ParseRowFilters(filters []string) (map[string]string, error) {
+	predicates := make(map[string]string, len(filters))
+	for _, filter := range filters {
+		name, predicate, ok := strings.Cut(filter, ":")
+		if !ok || strings.TrimSpace(predicate) == "" {
+			return nil, errors.Annotatef(berrors.ErrInvalidArgument,
+				"invalid row filter %q, it should be like \"db.table: predicate\"", filter)
+		}
+		db, table, ok := strings.Cut(strings.TrimSpace(name), ".")
+		if !ok || db == "" || table == "" {
+			return nil, errors.Annotatef(berrors.ErrInvalidArgument,
+				"invalid table %q of row filter, it should be like \"db.table\"", name)
+		}
+		restored, err := restorePredicate(predicate)
+		if err != nil {
+			return nil, errors.Annotatef(berrors.ErrInvalidArgument, "invalid predicate of row filter %q: %v", filter, err)
+		}
+		key := TableKey(db, table)
+		if _, ok := predicates[key]; ok {
+			return nil, errors.Annotatef(berrors.ErrInvalidArgument, "duplicate row filters of table %s", name)
+		}
+		predicates[key] = restored
+	}
+	return predicates, nil
+}
+
+// restorePredicate parses the predicate as a single expression, and restores it from the AST so that
+// it can be embedded into other statements safely.
+func restorePredicate(predicate string) (string, error) {
+	stmt, err := parser.New().ParseOneStmt("SELECT 1 FROM t WHERE "+predicate, "", "")
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	sel, ok := stmt.(*ast.SelectStmt)
+	if !ok || sel.Where == nil {
+		return "", errors.New("not an expression")
+	}
+	var sb strings.Builder
+	if err := sel.Where.Restore(format.NewRestoreCtx(format.DefaultRestoreFlags, &sb)); err != nil {
+		return "", errors.Trace(err)
+	}
+	return sb.String(), nil
+}
+
+// SetRowFilters sets the predicates from ParseRowFilters, the rows not matching them are deleted after
+// the tables are restored. TiKV downloads, rewrites and ingests the SST files by itself, and BR never sees
+// the rows, so they can't be dropped earlier. The deleted rows stay in the MVCC history until GC.
+func (rc *SnapClient) SetRowFilters(predicates map[string]string) {
+	rc.rowFilters = predicates
+}
+
+// filterRows deletes the rows not matching the predicate, including the rows whose predicate is NULL.
+// The rows are deleted in batches of the handle ranges to limit the size of the transactions, each batch
+// only scans the rows after the last one instead of the whole table.
+func filterRows(ctx context.Context, se glue.Session, db string, tableInfo *model.TableInfo, predicate string) (uint64, error) {
+	table := utils.EncloseDBAndTable(db, tableInfo.Name.O)
+	handle := handleColumns(tableInfo)
+	exec := se.GetSessionCtx().GetRestrictedSQLExecutor()
+	ctx = kv.WithInternalSourceType(ctx, kv.InternalTxnBR)
+	deleted := uint64(0)
+	lower := ""
+	for {
+		// the upper bound of the batch is the last handle of the next filterRowsBatchSize rows.
+		sql := fmt.Sprintf("SELECT %s FROM %s", handle, table)
+		if lower != "" {
+			sql += fmt.Sprintf(" WHERE (%s) > (%s)", handle, lower)
+		}
+		sql += fmt.Sprintf(" ORDER BY %s LIMIT 1 OFFSET %d", handle, filterRowsBatchSize-1)
+		rows, fields, err := exec.ExecRestrictedSQL(ctx, []sqlexec.OptionFuncAlias{sqlexec.ExecOptionUseCurSession}, sql)
+		if err != nil {
+			return deleted, errors.Annotatef(err, "failed to get the handle range by %q", sql)
+		}
+		upper := ""
+		if len(rows) > 0 {
+			if upper, err = handleLiterals(rows[0], fields); err != nil {
+				return deleted, errors.Trace(err)
+			}
+		}
+
+		conds := make([]string, 0, 3)
+		if lower != "" {
+			conds = append(conds, fmt.Sprintf("(%s) > (%s)", handle, lower))
+		}
+		if upper != "" {
+			conds = append(conds, fmt.Sprintf("(%s) <= (%s)", handle, upper))
+		}
+		conds = append(conds, fmt.Sprintf("(%s) IS NOT TRUE", predicate))
+		sql = fmt.Sprintf("DELETE FROM %s WHERE %s", table, strings.Join(conds, " AND "))
+		if err := se.Execute(ctx, sql); err != nil {
+			return deleted, errors.Annotatef(err, "failed to filter rows by %q", sql)
+		}
+		deleted += se.GetSessionCtx().GetSessionVars().StmtCtx.AffectedRows()
+		if upper == "" {
+			return deleted, nil
+		}
+		lower = upper
+	}
+}
+
+// handleColumns returns the columns of the handle of the table separated by commas.
+func handleColumns(tableInfo *model.TableInfo) string {
+	if tableInfo.PKIsHandle {
+		if col := tableInfo.GetPkColInfo(); col != nil {
+			return utils.EncloseName(col.Name.O)
+		}
+	}
+	if tableInfo.IsCommonHandle {
+		if pk := tableInfo.GetPrimaryKey(); pk != nil {
+			names := make([]string, 0, len(pk.Columns))
+			for _, col := range pk.Columns {
+				names = append(names, utils.EncloseName(col.Name.O))
+			}
+			return strings.Join(names, ", ")
+		}
+	}
+	return utils.EncloseName(model.ExtraHandleName.O)
+}
+
+// handleLiterals formats the handle of the row as the literals separated by commas.
+func handleLiterals(row chunk.Row, fields []*resolve.ResultField) (string, error) {
+	literals := make([]string, 0, len(fields))
+	for i, field := range fields {
+		d := row.GetDatum(i, &field.Column.FieldType)
+		var literal string
+		switch d.Kind() {
+		case types.KindInt64, types.KindUint64, types.KindString, types.KindBytes, types.KindFloat32, types.KindFloat64:
+			s, err := sqlescape.EscapeSQL("%?", d.GetValue())
+			if err != nil {
+				return "", errors.Trace(err)
+			}
+			literal = s
+		case types.KindMysqlDecimal:
+			// the decimal is compared as a float if it's quoted.
+			literal = d.GetMysqlDecimal().String()
+		default:
+			s, err := d.ToString()
+			if err != nil {
+				return "", errors.Trace(err)
+			}
+			if literal, err = sqlescape.EscapeSQL("%?", s); err != nil {
+				return "", errors.Trace(err)
+			}
+		}
+		literals = append(literals, literal)
+	}
+	return strings.Join(literals, ", "), nil
+}
@@ -0,0 +1,138 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package snapclient_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pingcap/tidb/br/pkg/gluetidb"
+	"github.com/pingcap/tidb/br/pkg/metautil"
+	"github.com/pingcap/tidb/br/pkg/restore"
+	snapclient "github.com/pingcap/tidb/br/pkg/restore/snap_client"
+	"github.com/pingcap/tidb/br/pkg/restore/split"
+	"github.com/pingcap/tidb/pkg/kv"
+	"github.com/pingcap/tidb/pkg/parser/ast"
+	"github.com/pingcap/tidb/pkg/util/sqlexec"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRowFilters(t *testing.T) {
+	predicates, err := snapclient.ParseRowFilters([]string{
+		`DB.T: created_at >= "2024-01-01"`,
+		"db.t2:tenant_id = 1 and a > 0",
+	})
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{
+		"db.t":  "`created_at`>=_UTF8MB4'2024-01-01'",
+		"db.t2": "`tenant_id`=1 AND `a`>0",
+	}, predicates)
+
+	for _, filter := range []string{
+		"db.t",
+		"db.t: ",
+		"t: a > 1",
+		"db.t: a >",
+		"db.t: a > 1) OR (1",
+		"db.t: a > 1; DROP TABLE db.t",
+	} {
+		_, err := snapclient.ParseRowFilters([]string{filter})
+		require.Error(t, err, filter)
+	}
+	// the comments are dropped.
+	predicates, err = snapclient.ParseRowFilters([]string{"db.t: a > 1 -- "})
+	require.NoError(t, err)
+	require.Equal(t, "`a`>1", predicates["db.t"])
+	_, err = snapclient.ParseRowFilters([]string{"db.t: a > 1", "DB.t: a < 1"})
+	require.ErrorContains(t, err, "duplicate")
+}
+
+func TestFilterRows(t *testing.T) {
+	ctx := kv.WithInternalSourceType(context.Background(), kv.InternalTxnBR)
+	g := gluetidb.New()
+	se, err := g.CreateSession(mc.Storage)
+	require.NoError(t, err)
+	defer se.Close()
+	for _, sql := range []string{
+		"create database if not exists filter_rows",
+		"create table filter_rows.t (id int primary key, tenant int)",
+		"insert into filter_rows.t values (1, 1), (2, 2), (3, 1), (4, null)",
+		// the rows of the child table are kept by its own predicate even if the parent rows are deleted.
+		"create table filter_rows.t2 (id int primary key, tenant int, pid int, " +
+			"foreign key (pid) references filter_rows.t (id) on delete cascade)",
+		"insert into filter_rows.t2 values (1, 2, 2), (2, 1, 2)",
+		"create table filter_rows.t3 (a varchar(10), b int, tenant int, primary key (a, b) clustered)",
+		"insert into filter_rows.t3 values ('a', 1, 1), ('a', 2, 2), ('b%', 1, 1), ('b%', 2, null), ('c', 1, 1)",
+		"create table filter_rows.t4 (id int, tenant int)",
+		"insert into filter_rows.t4 values (1, 1), (2, 2), (3, 2), (4, 1), (5, 2)",
+	} {
+		require.NoError(t, se.ExecuteInternal(ctx, sql))
+	}
+
+	client := snapclient.NewRestoreClient(mc.PDClient, mc.PDHTTPCli, nil, split.DefaultTestKeepaliveCfg)
+	predicates, err := snapclient.ParseRowFilters([]string{"filter_rows.t: tenant = 1", "filter_rows.t2: tenant = 1",
+		"filter_rows.t3: tenant = 1", "filter_rows.t4: tenant = 1"})
+	require.NoError(t, err)
+	client.SetRowFilters(predicates)
+	// the sessions filtering the rows are created by Init.
+	require.NoError(t, client.Init(g, mc.Storage))
+	defer client.Close()
+
+	dbName := ast.NewCIStr("filter_rows")
+	dbInfo, ok := mc.Domain.InfoSchema().SchemaByName(dbName)
+	require.True(t, ok)
+	// the rows are deleted by the handle ranges of 2 rows.
+	defer snapclient.SetFilterRowsBatchSize(2)()
+	// the tables are filtered at the same time by the dedicated sessions.
+	names := []string{"t", "t2", "t3", "t4"}
+	inCh := make(chan *snapclient.CreatedTable, len(names))
+	for _, name := range names {
+		tableInfo, err := restore.GetTableSchema(mc.Domain, dbName, ast.NewCIStr(name))
+		require.NoError(t, err)
+		inCh <- &snapclient.CreatedTable{Table: tableInfo, OldTable: &metautil.Table{DB: dbInfo, Info: tableInfo}}
+	}
+	close(inCh)
+	errCh := make(chan error, 1)
+	outCh := client.GoFilterRows(ctx, inCh, errCh)
+	filtered := make(map[string]uint64, len(names))
+	for tbl := range outCh {
+		filtered[tbl.Table.Name.O] = tbl.FilteredRows
+	}
+	select {
+	case err := <-errCh:
+		require.NoError(t, err)
+	default:
+	}
+
+	exec := se.GetSessionCtx().GetRestrictedSQLExecutor()
+	rows, _, err := exec.ExecRestrictedSQL(ctx, []sqlexec.OptionFuncAlias{sqlexec.ExecOptionUseCurSession},
+		"select id from filter_rows.t order by id")
+	require.NoError(t, err)
+	ids := make([]int64, 0, len(rows))
+	for _, row := range rows {
+		ids = append(ids, row.GetInt64(0))
+	}
+	require.Equal(t, []int64{1, 3}, ids)
+	rows, _, err = exec.ExecRestrictedSQL(ctx, []sqlexec.OptionFuncAlias{sqlexec.ExecOptionUseCurSession},
+		"select id from filter_rows.t2")
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	require.EqualValues(t, 2, rows[0].GetInt64(0))
+	rows, _, err = exec.ExecRestrictedSQL(ctx, []sqlexec.OptionFuncAlias{sqlexec.ExecOptionUseCurSession},
+		"select concat(a, b) from filter_rows.t3 order by a, b")
+	require.NoError(t, err)
+	keys := make([]string, 0, len(rows))
+	for _, row := range rows {
+		keys = append(keys, row.GetString(0))
+	}
+	require.Equal(t, []string{"a1", "b%1", "c1"}, keys)
+	rows, _, err = exec.ExecRestrictedSQL(ctx, []sqlexec.OptionFuncAlias{sqlexec.ExecOptionUseCurSession},
+		"select id from filter_rows.t4 order by id")
+	require.NoError(t, err)
+	ids = ids[:0]
+	for _, row := range rows {
+		ids = append(ids, row.GetInt64(0))
+	}
+	require.Equal(t, []int64{1, 4}, ids)
+	require.Equal(t, map[string]uint64{"t": 2, "t2": 1, "t3": 2, "t4": 3}, filtered)
+}
@@ -0,0 +1,486 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package snapclient
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/pingcap/errors"
+	backuppb "github.com/pingcap/kvproto/pkg/brpb"
+	berrors "github.com/pingcap/tidb/br/pkg/errors"
+	"github.com/pingcap/tidb/br/pkg/export"
+	"github.com/pingcap/tidb/br/pkg/metautil"
+	"github.com/pingcap/tidb/pkg/expression"
+	"github.com/pingcap/tidb/pkg/expression/exprstatic"
+	"github.com/pingcap/tidb/pkg/lightning/backend/encode"
+	"github.com/pingcap/tidb/pkg/lightning/backend/kv"
+	"github.com/pingcap/tidb/pkg/lightning/log"
+	"github.com/pingcap/tidb/pkg/meta/model"
+	"github.com/pingcap/tidb/pkg/parser"
+	"github.com/pingcap/tidb/pkg/parser/ast"
+	"github.com/pingcap/tidb/pkg/parser/format"
+	"github.com/pingcap/tidb/pkg/parser/mysql"
+	"github.com/pingcap/tidb/pkg/table/tables"
+	"github.com/pingcap/tidb/pkg/tablecodec"
+	"github.com/pingcap/tidb/pkg/types"
+	"github.com/pingcap/tidb/pkg/util/chunk"
+)
+
+const (
+	// sanitizeHash replaces the values by their hashes.
+	sanitizeHash = "hash"
+	// MinSanitizeKeyLen is the min length of the key of the hashes.
+	MinSanitizeKeyLen = 16
+	// maxSanitizeHashRetries is the max times the hash of a value is derived again if it collides with
+	// the hash of another value in a unique index.
+	maxSanitizeHashRetries = 1024
+)
+
+// Sanitizer rewrites a column of all the restored rows of a table.
+type Sanitizer struct {
+	// Column is the lower-cased name of the column in the backup.
+	Column string
+	// Hash replaces the values by the prefix of the hex digests of their HMAC-SHA256, which is as long as
+	// the column allows, so that the joins on the column are kept. Only the string columns can be hashed.
+	Hash bool
+	// Expr is the expression evaluated on the row replacing the value if it isn't Hash.
+	Expr string
+}
+
+// ParseSanitizers parses the sanitizers like `db.t.email: hash`, and returns them keyed by TableKey. The value
+// of a sanitizer is `hash`, or an expression evaluated on the row, e.g. `null` or `concat('user-', id)`.
+func ParseSanitizers(sanitizers []string) (map[string][]Sanitizer, error) {
+	result := make(map[string][]Sanitizer, len(sanitizers))
+	columns := make(map[string]struct{}, len(sanitizers))
+	for _, sanitizer := range sanitizers {
+		name, value, ok := strings.Cut(sanitizer, ":")
+		if !ok || strings.TrimSpace(value) == "" {
+			return nil, errors.Annotatef(berrors.ErrInvalidArgument,
+				"invalid sanitizer %q, it should be like \"db.table.column: hash|expression\"", sanitizer)
+		}
+		parts := strings.Split(strings.TrimSpace(name), ".")
+		if len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+			return nil, errors.Annotatef(berrors.ErrInvalidArgument,
+				"invalid column %q of sanitizer, it should be like \"db.table.column\"", name)
+		}
+		key, column := TableKey(parts[0], parts[1]), strings.ToLower(parts[2])
+		if _, ok := columns[key+"."+column]; ok {
+			return nil, errors.Annotatef(berrors.ErrInvalidArgument, "duplicate sanitizers of column %s", name)
+		}
+		columns[key+"."+column] = struct{}{}
+
+		s := Sanitizer{Column: column}
+		if strings.EqualFold(strings.TrimSpace(value), sanitizeHash) {
+			s.Hash = true
+		} else {
+			restored, err := restoreExpression(value)
+			if err != nil {
+				return nil, errors.Annotatef(berrors.ErrInvalidArgument, "invalid expression of sanitizer %q: %v", sanitizer, err)
+			}
+			s.Expr = restored
+		}
+		result[key] = append(result[key], s)
+	}
+	return result, nil
+}
+
+// restoreExpression parses the expression, and restores it from the AST like restorePredicate.
+func restoreExpression(expression string) (string, error) {
+	stmt, err := parser.New().ParseOneStmt("SELECT "+expression+" FROM t", "", "")
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	sel, ok := stmt.(*ast.SelectStmt)
+	if !ok || sel.Fields == nil || len(sel.Fields.Fields) != 1 || sel.Fields.Fields[0].Expr == nil ||
+		sel.Where != nil {
+		return "", errors.New("not an expression")
+	}
+	var sb strings.Builder
+	if err := sel.Fields.Fields[0].Expr.Restore(format.NewRestoreCtx(format.DefaultRestoreFlags, &sb)); err != nil {
+		return "", errors.Trace(err)
+	}
+	return sb.String(), nil
+}
+
+// SetSanitizers sets the sanitizers from ParseSanitizers and the key of the hashes. The rows are sanitized
+// while the kvs are read before they're rewritten and ingested, so the original values never reach the
+// cluster, which needs the local backend of Lightning: TiKV downloads and ingests the SST files by itself
+// otherwise. It must be called before Init.
+func (rc *SnapClient) SetSanitizers(sanitizers map[string][]Sanitizer, hashKey []byte) error {
+	for _, columns := range sanitizers {
+		for _, s := range columns {
+			if s.Hash && len(hashKey) < MinSanitizeKeyLen {
+				return errors.Annotatef(berrors.ErrInvalidArgument,
+					"the key of the hashes of the sanitizers must be at least %d bytes, got %d", MinSanitizeKeyLen, len(hashKey))
+			}
+		}
+	}
+	rc.sanitizers = sanitizers
+	rc.sanitizeKey = hashKey
+	rc.sanitizedTables = make(map[int64]*tableSanitizer)
+	return nil
+}
+
+// CheckSanitizers checks the columns of the sanitizers exist in the tables to restore, and prepares the
+// sanitizing of the rows of the tables. The columns are named as in the backup. The statistics in the backup
+// are skipped for the sanitized tables. It's called before any data is restored.
+func (rc *SnapClient) CheckSanitizers(tables []*metautil.Table) error {
+	for _, table := range tables {
+		key := TableKey(table.DB.Name.O, table.Info.Name.O)
+		sanitizers, ok := rc.sanitizers[key]
+		if !ok {
+			continue
+		}
+		if predicate, ok := rc.rowFilters[key]; ok {
+			columns, err := referencedColumns(predicate)
+			if err != nil {
+				return errors.Trace(err)
+			}
+			for _, s := range sanitizers {
+				if _, ok := columns[s.Column]; ok {
+					return errors.Annotatef(berrors.ErrInvalidArgument,
+						"the row filter of %s.%s references the sanitized column %s, the rows are filtered after "+
+							"they're sanitized", table.DB.Name, table.Info.Name, s.Column)
+				}
+			}
+		}
+		sanitizer, err := newTableSanitizer(table.Info, sanitizers, rc.sanitizeKey)
+		if err != nil {
+			return errors.Annotatef(err, "failed to sanitize %s.%s", table.DB.Name, table.Info.Name)
+		}
+		rc.sanitizedTables[table.Info.ID] = sanitizer
+		if partitions := table.Info.GetPartitionInfo(); partitions != nil {
+			for _, def := range partitions.Definitions {
+				rc.sanitizedTables[def.ID] = sanitizer
+			}
+		}
+		table.Stats = nil
+		table.StatsFileIndexes = nil
+	}
+	return nil
+}
+
+// isSanitized returns whether the rows of the table are sanitized.
+func (rc *SnapClient) isSanitized(tableID int64) bool {
+	_, ok := rc.sanitizedTables[tableID]
+	return ok
+}
+
+// referencedColumns returns the lower-cased names of the columns referenced by the predicate.
+func referencedColumns(predicate string) (map[string]struct{}, error) {
+	stmt, err := parser.New().ParseOneStmt("SELECT 1 FROM t WHERE "+predicate, "", "")
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	collector := &columnNameCollector{names: make(map[string]struct{})}
+	stmt.Accept(collector)
+	return collector.names, nil
+}
+
+type columnNameCollector struct {
+	names map[string]struct{}
+}
+
+func (c *columnNameCollector) Enter(n ast.Node) (ast.Node, bool) {
+	if col, ok := n.(*ast.ColumnNameExpr); ok {
+		c.names[col.Name.Name.L] = struct{}{}
+	}
+	return n, false
+}
+
+func (*columnNameCollector) Leave(n ast.Node) (ast.Node, bool) {
+	return n, true
+}
+
+// sanitizedColumn is a column of a sanitized table.
+type sanitizedColumn struct {
+	Sanitizer
+	offset int
+	// hashLen is the length of the hashes of the column, the hex digests are truncated to fit the column.
+	hashLen int
+	// uniqueLen is the length of the prefixes of the hashes which must be unique, since the column is in
+	// a unique index. It's 0 if the column isn't in any unique index or the hashes aren't truncated.
+	uniqueLen int
+
+	mu sync.Mutex
+	// owners are the full digests of the values by the prefixes of their hashes.
+	owners map[string]string
+}
+
+// tableSanitizer sanitizes the rows of a table. It's shared by the readers of the files of the table.
+type tableSanitizer struct {
+	info    *model.TableInfo
+	key     []byte
+	decoder *export.RowDecoder
+	columns []*sanitizedColumn
+}
+
+func newTableSanitizer(info *model.TableInfo, sanitizers []Sanitizer, key []byte) (*tableSanitizer, error) {
+	decoder, err := export.NewRowDecoder(info)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	s := &tableSanitizer{info: info, key: key, decoder: decoder}
+	for _, sanitizer := range sanitizers {
+		col := info.FindPublicColumnByName(sanitizer.Column)
+		if col == nil {
+			return nil, errors.Annotatef(berrors.ErrInvalidArgument, "column %s of sanitizer not found", sanitizer.Column)
+		}
+		if col.IsGenerated() {
+			return nil, errors.Annotatef(berrors.ErrInvalidArgument,
+				"column %s is generated, it's computed from the sanitized columns", sanitizer.Column)
+		}
+		column := &sanitizedColumn{Sanitizer: sanitizer, offset: col.Offset}
+		if sanitizer.Hash {
+			if !types.IsString(col.GetType()) {
+				return nil, errors.Annotatef(berrors.ErrInvalidArgument,
+					"column %s is %s, only the string columns can be hashed, use an expression instead",
+					sanitizer.Column, col.FieldType.String())
+			}
+			column.hashLen = sha256.Size * 2
+			if flen := col.GetFlen(); flen > 0 && flen < column.hashLen {
+				column.hashLen = flen
+			}
+			column.uniqueLen = uniqueHashLen(info, col, column.hashLen)
+			if column.uniqueLen > 0 {
+				column.owners = make(map[string]string)
+			}
+		} else if _, err := expression.ParseSimpleExpr(exprstatic.NewExprContext(), sanitizer.Expr,
+			expression.WithTableInfo("", info)); err != nil {
+			return nil, errors.Annotatef(berrors.ErrInvalidArgument, "invalid expression %q of column %s: %v",
+				sanitizer.Expr, sanitizer.Column, err)
+		}
+		s.columns = append(s.columns, column)
+	}
+	return s, nil
+}
+
+// uniqueHashLen returns the length of the prefixes of the hashes which must be unique, or 0 if the column
+// isn't in any unique index or the hashes aren't truncated. The prefix indexes only keep the prefixes
+// unique.
+func uniqueHashLen(info *model.TableInfo, col *model.ColumnInfo, hashLen int) int {
+	uniqueLen := 0
+	for _, idx := range info.Indices {
+		if !idx.Unique && !idx.Primary {
+			continue
+		}
+		for _, idxCol := range idx.Columns {
+			if idxCol.Offset != col.Offset {
+				continue
+			}
+			l := hashLen
+			if idxCol.Length != types.UnspecifiedLength && idxCol.Length < l {
+				l = idxCol.Length
+			}
+			if uniqueLen == 0 || l < uniqueLen {
+				uniqueLen = l
+			}
+		}
+	}
+	if uniqueLen >= sha256.Size*2 {
+		return 0
+	}
+	return uniqueLen
+}
+
+// hash returns the keyed hash of the value truncated to fit the column. The truncated hashes of different
+// values may collide, which breaks the unique indexes, so the hash of a value colliding with another
+// value's is derived again by a counter. Which of the values gets the original hash depends on the order
+// they're read, and the values restored before the restore is resumed from the checkpoint are unknown.
+func (s *tableSanitizer) hash(col *sanitizedColumn, value []byte) (string, error) {
+	digest := s.digest(value, -1)
+	hash := hex.EncodeToString(digest)[:col.hashLen]
+	if col.owners == nil {
+		return hash, nil
+	}
+	owner := string(digest)
+	col.mu.Lock()
+	defer col.mu.Unlock()
+	for i := 0; ; i++ {
+		prefix := hash[:col.uniqueLen]
+		existing, ok := col.owners[prefix]
+		if !ok {
+			col.owners[prefix] = owner
+			return hash, nil
+		}
+		if existing == owner {
+			return hash, nil
+		}
+		if i >= maxSanitizeHashRetries {
+			return "", errors.Annotatef(berrors.ErrInvalidArgument,
+				"too many collisions of the hashes of column %s truncated to %d characters, the column is too "+
+					"short to be hashed", col.Column, col.uniqueLen)
+		}
+		hash = hex.EncodeToString(s.digest(value, i))[:col.hashLen]
+	}
+}
+
+// digest returns the HMAC-SHA256 of the value, and of the value suffixed by the counter if it isn't
+// negative.
+func (s *tableSanitizer) digest(value []byte, counter int) []byte {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write(value)
+	if counter >= 0 {
+		mac.Write([]byte{0})
+		mac.Write(strconv.AppendInt(nil, int64(counter), 10))
+	}
+	return mac.Sum(nil)
+}
+
+// rowSanitizer sanitizes the rows of a table read by a ReadKVs call. The encoder and the expressions
+// aren't safe for concurrent use, so they aren't shared.
+type rowSanitizer struct {
+	*tableSanitizer
+	encoder encode.Encoder
+	evalCtx *exprstatic.EvalContext
+	// exprs are the expressions of the columns, nil for the hashed ones.
+	exprs []expression.Expression
+	// permutation maps the columns of the table and the _tidb_rowid to the row, the generated columns are
+	// computed again from the sanitized values.
+	permutation []int
+	row         []types.Datum
+	values      []types.Datum
+}
+
+func (s *tableSanitizer) newRowSanitizer(ctx context.Context) (*rowSanitizer, error) {
+	tbl, err := tables.TableFromMeta(kv.NewPanickingAllocators(s.info.SepAutoInc()), s.info)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	encoder, err := kv.NewTableKVEncoder(&encode.EncodingConfig{
+		SessionOptions: encode.SessionOptions{
+			SQLMode: mysql.ModeStrictAllTables,
+			// the decoded timestamps are in UTC.
+			SysVars: map[string]string{"time_zone": "+00:00"},
+		},
+		Table:  tbl,
+		Logger: log.FromContext(ctx),
+	}, nil)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	exprCtx := exprstatic.NewExprContext()
+	r := &rowSanitizer{
+		tableSanitizer: s,
+		encoder:        encoder,
+		evalCtx:        exprCtx.GetStaticEvalCtx(),
+		exprs:          make([]expression.Expression, len(s.columns)),
+		permutation:    make([]int, len(s.info.Columns)+1),
+		row:            make([]types.Datum, len(s.info.Columns)+1),
+		values:         make([]types.Datum, len(s.columns)),
+	}
+	for i, col := range s.columns {
+		if col.Hash {
+			continue
+		}
+		if r.exprs[i], err = expression.ParseSimpleExpr(exprCtx, col.Expr, expression.WithTableInfo("", s.info)); err != nil {
+			encoder.Close()
+			return nil, errors.Trace(err)
+		}
+	}
+	for i, col := range s.info.Columns {
+		r.permutation[i] = i
+		if col.IsGenerated() {
+			r.permutation[i] = -1
+		}
+	}
+	r.permutation[len(s.info.Columns)] = len(s.info.Columns)
+	return r, nil
+}
+
+// sanitize decodes the row by the raw record key and the value, sanitizes it and encodes it again into
+// the kvs of the row and its indexes.
+func (r *rowSanitizer) sanitize(key, value []byte, fn func(key, value []byte) error) error {
+	_, handle, datums, err := r.decoder.DecodeRecord(key, value)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	for i := range r.row {
+		r.row[i].SetNull()
+	}
+	for i, col := range r.decoder.Columns() {
+		r.row[col.Offset] = datums[i]
+	}
+	rowID := int64(0)
+	if handle.IsInt() {
+		rowID = handle.IntValue()
+		r.row[len(r.info.Columns)] = types.NewIntDatum(rowID)
+	}
+
+	// the expressions are evaluated on the original row.
+	row := chunk.MutRowFromDatums(r.row[:len(r.info.Columns)]).ToRow()
+	for i, col := range r.columns {
+		original := r.row[col.offset]
+		switch {
+		case !col.Hash:
+			if r.values[i], err = r.exprs[i].Eval(r.evalCtx, row); err != nil {
+				return errors.Annotatef(err, "failed to evaluate the sanitizer of column %s", col.Column)
+			}
+		case original.IsNull():
+			r.values[i] = original
+		default:
+			hash, err := r.hash(col, original.GetBytes())
+			if err != nil {
+				return errors.Trace(err)
+			}
+			r.values[i] = types.NewStringDatum(hash)
+		}
+	}
+	for i, col := range r.columns {
+		r.row[col.offset] = r.values[i]
+	}
+
+	encoded, err := r.encoder.Encode(r.row, rowID, r.permutation, 0)
+	if err != nil {
+		return errors.Annotatef(err, "failed to encode the sanitized row of handle %s", handle)
+	}
+	for _, pair := range kv.Row2KvPairs(encoded) {
+		if err := fn(pair.Key, pair.Val); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return nil
+}
+
+// sanitizingKVReader sanitizes the rows of the tables read by the KVReader. The index kvs of the sanitized
+// tables are dropped, and encoded again from the sanitized rows by the table info of the backup, so the
+// kvs are still rewritten by the rewrite rules of the table.
+type sanitizingKVReader struct {
+	KVReader
+	// tables are the sanitizers of the tables by the physical IDs in the backup, they're set by
+	// CheckSanitizers before any kv is read.
+	tables map[int64]*tableSanitizer
+}
+
+func (r *sanitizingKVReader) ReadKVs(ctx context.Context, files []*backuppb.File, fn func(key, value []byte) error) error {
+	sanitizers := make(map[*tableSanitizer]*rowSanitizer)
+	defer func() {
+		for _, s := range sanitizers {
+			s.encoder.Close()
+		}
+	}()
+	return r.KVReader.ReadKVs(ctx, files, func(key, value []byte) error {
+		table, ok := r.tables[tablecodec.DecodeTableID(key)]
+		if !ok {
+			return fn(key, value)
+		}
+		if !tablecodec.IsRecordKey(key) {
+			return nil
+		}
+		s, ok := sanitizers[table]
+		if !ok {
+			var err error
+			if s, err = table.newRowSanitizer(ctx); err != nil {
+				return errors.Annotatef(err, "failed to sanitize %s", table.info.Name)
+			}
+			sanitizers[table] = s
+		}
+		return errors.Trace(s.sanitize(key, value, fn))
+	})
+}
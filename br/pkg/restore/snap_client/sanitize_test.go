@@ -0,0 +1,205 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package snapclient_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	backuppb "github.com/pingcap/kvproto/pkg/brpb"
+	"github.com/pingcap/tidb/br/pkg/export"
+	"github.com/pingcap/tidb/br/pkg/gluetidb"
+	"github.com/pingcap/tidb/br/pkg/metautil"
+	"github.com/pingcap/tidb/br/pkg/restore"
+	snapclient "github.com/pingcap/tidb/br/pkg/restore/snap_client"
+	"github.com/pingcap/tidb/br/pkg/restore/split"
+	"github.com/pingcap/tidb/pkg/kv"
+	"github.com/pingcap/tidb/pkg/meta/model"
+	"github.com/pingcap/tidb/pkg/parser/ast"
+	"github.com/pingcap/tidb/pkg/tablecodec"
+	"github.com/pingcap/tidb/pkg/types"
+	"github.com/pingcap/tidb/pkg/util/rowcodec"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSanitizers(t *testing.T) {
+	sanitizers, err := snapclient.ParseSanitizers([]string{
+		"DB.T.Email: hash",
+		"db.t.phone: null",
+		"db.t2.name:concat('user-', id)",
+	})
+	require.NoError(t, err)
+	require.Equal(t, map[string][]snapclient.Sanitizer{
+		"db.t":  {{Column: "email", Hash: true}, {Column: "phone", Expr: "NULL"}},
+		"db.t2": {{Column: "name", Expr: "CONCAT(_UTF8MB4'user-', `id`)"}},
+	}, sanitizers)
+
+	for _, sanitizer := range []string{
+		"db.t.c",
+		"db.t.c: ",
+		"t.c: null",
+		"db.t.c: concat(",
+		"db.t.c: 1, 2",
+		"db.t.c: 1 FROM t; DROP TABLE db.t; SELECT 1",
+	} {
+		_, err := snapclient.ParseSanitizers([]string{sanitizer})
+		require.Error(t, err, sanitizer)
+	}
+	_, err = snapclient.ParseSanitizers([]string{"db.t.c: null", "DB.t.C: hash"})
+	require.ErrorContains(t, err, "duplicate")
+}
+
+// encodeTestRow encodes the record kv of the row of the table with the int handle.
+func encodeTestRow(t *testing.T, info *model.TableInfo, handle int64, row ...types.Datum) (key, value []byte) {
+	colIDs := make([]int64, 0, len(row))
+	for _, col := range info.Columns[:len(row)] {
+		colIDs = append(colIDs, col.ID)
+	}
+	value, err := tablecodec.EncodeRow(time.UTC, row, colIDs, nil, nil, nil, &rowcodec.Encoder{Enable: true})
+	require.NoError(t, err)
+	return tablecodec.EncodeRowKeyWithHandle(info.ID, kv.IntHandle(handle)), value
+}
+
+func TestSanitizingKVReader(t *testing.T) {
+	ctx := kv.WithInternalSourceType(context.Background(), kv.InternalTxnBR)
+	g := gluetidb.New()
+	se, err := g.CreateSession(mc.Storage)
+	require.NoError(t, err)
+	defer se.Close()
+	for _, sql := range []string{
+		"create database if not exists sanitize_rows",
+		"create table sanitize_rows.t (id int primary key, email varchar(32), phone varchar(16), key idx_email (email))",
+		"create table sanitize_rows.u (id int primary key, code varchar(8), unique key uk_code (code(1)))",
+	} {
+		require.NoError(t, se.ExecuteInternal(ctx, sql))
+	}
+	dbName := ast.NewCIStr("sanitize_rows")
+	dbInfo, ok := mc.Domain.InfoSchema().SchemaByName(dbName)
+	require.True(t, ok)
+	info, err := restore.GetTableSchema(mc.Domain, dbName, ast.NewCIStr("t"))
+	require.NoError(t, err)
+	uniqueInfo, err := restore.GetTableSchema(mc.Domain, dbName, ast.NewCIStr("u"))
+	require.NoError(t, err)
+	tables := []*metautil.Table{{DB: dbInfo, Info: info}, {DB: dbInfo, Info: uniqueInfo}}
+	key := []byte("0123456789abcdef")
+
+	client := snapclient.NewRestoreClient(mc.PDClient, mc.PDHTTPCli, nil, split.DefaultTestKeepaliveCfg)
+	sanitizers, err := snapclient.ParseSanitizers([]string{"sanitize_rows.t.email: hash"})
+	require.NoError(t, err)
+	require.ErrorContains(t, client.SetSanitizers(sanitizers, key[:8]), "at least 16 bytes")
+	for sanitizer, msg := range map[string]string{
+		"sanitize_rows.t.id: hash":         "only the string columns can be hashed",
+		"sanitize_rows.t.missing: null":    "not found",
+		"sanitize_rows.t.phone: no_such()": "invalid expression",
+	} {
+		sanitizers, err := snapclient.ParseSanitizers([]string{sanitizer})
+		require.NoError(t, err)
+		require.NoError(t, client.SetSanitizers(sanitizers, key))
+		require.ErrorContains(t, client.CheckSanitizers(tables), msg)
+	}
+	client.SetRowFilters(map[string]string{"sanitize_rows.t": "`email` IS NOT NULL"})
+	require.NoError(t, client.SetSanitizers(sanitizers, key))
+	require.ErrorContains(t, client.CheckSanitizers(tables), "references the sanitized column email")
+	client.SetRowFilters(nil)
+
+	sanitizers, err = snapclient.ParseSanitizers([]string{
+		"sanitize_rows.t.email: hash",
+		"sanitize_rows.t.phone: concat('p-', id)",
+		"sanitize_rows.u.code: hash",
+	})
+	require.NoError(t, err)
+	require.NoError(t, client.SetSanitizers(sanitizers, key))
+	require.NoError(t, client.CheckSanitizers(tables))
+
+	// the rows are sanitized and their indexes are encoded again, the kvs of the other tables are kept.
+	otherKey := tablecodec.EncodeRowKeyWithHandle(info.ID+1000, kv.IntHandle(1))
+	reader := client.NewSanitizingKVReader(kvReaderFunc(func(_ context.Context, _ []*backuppb.File, fn func(key, value []byte) error) error {
+		for i, email := range []any{"a@pingcap.com", nil} {
+			key, value := encodeTestRow(t, info, int64(i+1), types.NewIntDatum(int64(i+1)), types.NewDatum(email),
+				types.NewStringDatum("123"))
+			if err := fn(key, value); err != nil {
+				return err
+			}
+		}
+		if err := fn(tablecodec.EncodeIndexSeekKey(info.ID, info.Indices[0].ID, []byte("a@pingcap.com")), []byte("0")); err != nil {
+			return err
+		}
+		return fn(otherKey, []byte("other"))
+	}))
+	decoder, err := export.NewRowDecoder(info)
+	require.NoError(t, err)
+	var rows [][]types.Datum
+	indexes, others := 0, 0
+	require.NoError(t, reader.ReadKVs(ctx, nil, func(key, value []byte) error {
+		switch {
+		case tablecodec.DecodeTableID(key) != info.ID:
+			require.Equal(t, []byte(otherKey), key)
+			others++
+		case tablecodec.IsIndexKey(key):
+			indexes++
+		default:
+			// the kvs are only valid in fn.
+			_, _, row, err := decoder.DecodeRecord(key, bytes.Clone(value))
+			require.NoError(t, err)
+			rows = append(rows, row)
+		}
+		return nil
+	}))
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte("a@pingcap.com"))
+	require.Len(t, rows, 2)
+	require.Equal(t, hex.EncodeToString(mac.Sum(nil))[:32], rows[0][1].GetString())
+	require.Equal(t, "p-1", rows[0][2].GetString())
+	require.True(t, rows[1][1].IsNull())
+	require.Equal(t, "p-2", rows[1][2].GetString())
+	require.Equal(t, 2, indexes)
+	require.Equal(t, 1, others)
+
+	// the truncated hashes of the values in the unique indexes don't collide, the prefix index of a char
+	// keeps at most 16 of them unique.
+	readCodes := func(n int) ([]string, error) {
+		var codes []string
+		decoder, err := export.NewRowDecoder(uniqueInfo)
+		require.NoError(t, err)
+		reader := client.NewSanitizingKVReader(kvReaderFunc(func(_ context.Context, _ []*backuppb.File, fn func(key, value []byte) error) error {
+			for i := range n {
+				key, value := encodeTestRow(t, uniqueInfo, int64(i+1), types.NewIntDatum(int64(i+1)),
+					types.NewStringDatum(fmt.Sprintf("code%d", i)))
+				if err := fn(key, value); err != nil {
+					return err
+				}
+			}
+			return nil
+		}))
+		err = reader.ReadKVs(ctx, nil, func(key, value []byte) error {
+			if tablecodec.IsRecordKey(key) {
+				_, _, row, err := decoder.DecodeRecord(key, value)
+				require.NoError(t, err)
+				codes = append(codes, strings.Clone(row[1].GetString()))
+			}
+			return nil
+		})
+		return codes, err
+	}
+	codes, err := readCodes(16)
+	require.NoError(t, err)
+	prefixes := make(map[byte]struct{})
+	for _, code := range codes {
+		require.Len(t, code, 8)
+		prefixes[code[0]] = struct{}{}
+	}
+	require.Len(t, prefixes, 16)
+	// the hashes of the values read again are kept.
+	again, err := readCodes(16)
+	require.NoError(t, err)
+	require.Equal(t, codes, again)
+	_, err = readCodes(17)
+	require.ErrorContains(t, err, "too many collisions")
+}
@@ -0,0 +1,142 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package snapclient
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/pingcap/errors"
+	backuppb "github.com/pingcap/kvproto/pkg/brpb"
+	"github.com/pingcap/tidb/br/pkg/restore"
+	restoreutils "github.com/pingcap/tidb/br/pkg/restore/utils"
+)
+
+// TableStats is the restore statistics of a physical table. The files skipped by the checkpoint aren't
+// counted.
+type TableStats struct {
+	PhysicalID int64
+	Files      int
+	KVs        uint64
+	Bytes      uint64
+	// RewrittenKVs is the count of the kvs of the files whose keys are rewritten to another table ID.
+	RewrittenKVs uint64
+	// Duration is the import time spent on the table. The time of each import call is split among the
+	// tables of the call by their bytes, and the calls are concurrent, so it isn't the wall time.
+	Duration time.Duration
+}
+
+// TableStatsCollector collects the restore statistics of the tables.
+type TableStatsCollector struct {
+	mu     sync.Mutex
+	tables map[int64]*TableStats
+}
+
+// NewTableStatsCollector creates an empty TableStatsCollector.
+func NewTableStatsCollector() *TableStatsCollector {
+	return &TableStatsCollector{tables: make(map[int64]*TableStats)}
+}
+
+// Add adds the statistics of a part of the files of the table.
+func (c *TableStatsCollector) Add(stats TableStats) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	item, ok := c.tables[stats.PhysicalID]
+	if !ok {
+		item = &TableStats{PhysicalID: stats.PhysicalID}
+		c.tables[stats.PhysicalID] = item
+	}
+	item.Files += stats.Files
+	item.KVs += stats.KVs
+	item.Bytes += stats.Bytes
+	item.RewrittenKVs += stats.RewrittenKVs
+	item.Duration += stats.Duration
+}
+
+// Collect records the file sets imported by an import call taking the elapsed time.
+func (c *TableStatsCollector) Collect(sets []restore.BackupFileSet, elapsed time.Duration) {
+	stats := make([]TableStats, 0, len(sets))
+	totalBytes, totalFiles := uint64(0), 0
+	for _, set := range sets {
+		s := TableStats{PhysicalID: restoredTableID(set)}
+		for _, file := range set.SSTFiles {
+			s.Files += 1
+			s.KVs += file.TotalKvs
+			s.Bytes += file.TotalBytes
+			if isFileRewritten(file, set.RewriteRules) {
+				s.RewrittenKVs += file.TotalKvs
+			}
+		}
+		totalBytes += s.Bytes
+		totalFiles += s.Files
+		stats = append(stats, s)
+	}
+	for _, s := range stats {
+		switch {
+		case totalBytes > 0:
+			s.Duration = time.Duration(float64(elapsed) * float64(s.Bytes) / float64(totalBytes))
+		case totalFiles > 0:
+			s.Duration = elapsed * time.Duration(s.Files) / time.Duration(totalFiles)
+		}
+		c.Add(s)
+	}
+}
+
+// Stats returns the statistics of the tables ordered by the physical ID.
+func (c *TableStatsCollector) Stats() []TableStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stats := make([]TableStats, 0, len(c.tables))
+	for _, item := range c.tables {
+		stats = append(stats, *item)
+	}
+	slices.SortFunc(stats, func(a, b TableStats) int {
+		return cmp.Compare(a.PhysicalID, b.PhysicalID)
+	})
+	return stats
+}
+
+// restoredTableID returns the ID of the restored table of the file set. The file sets of the snapshot
+// restore are keyed by the restored table IDs, and the ones of the compacted SST files of the log backup
+// by the upstream table IDs.
+func restoredTableID(set restore.BackupFileSet) int64 {
+	if set.RewriteRules != nil {
+		if id := restoreutils.GetRewriteTableID(set.TableID, set.RewriteRules); id != 0 {
+			return id
+		}
+	}
+	return set.TableID
+}
+
+// isFileRewritten checks whether the rewrite rule matching the file changes the prefix of its keys.
+func isFileRewritten(file *backuppb.File, rules *restoreutils.RewriteRules) bool {
+	if rules == nil {
+		return false
+	}
+	rule := restoreutils.FindMatchedRewriteRule(file, rules)
+	return rule != nil && !bytes.Equal(rule.GetOldKeyPrefix(), rule.GetNewKeyPrefix())
+}
+
+// tableStatsImporter collects the statistics of the file sets imported by the importer.
+type tableStatsImporter struct {
+	restore.BalancedFileImporter
+	collector *TableStatsCollector
+}
+
+// NewTableStatsImporter wraps the importer to collect the statistics of the imported file sets.
+func NewTableStatsImporter(importer restore.BalancedFileImporter, collector *TableStatsCollector) restore.BalancedFileImporter {
+	return &tableStatsImporter{BalancedFileImporter: importer, collector: collector}
+}
+
+func (importer *tableStatsImporter) Import(ctx context.Context, fileSets ...restore.BackupFileSet) error {
+	start := time.Now()
+	if err := importer.BalancedFileImporter.Import(ctx, fileSets...); err != nil {
+		return errors.Trace(err)
+	}
+	importer.collector.Collect(fileSets, time.Since(start))
+	return nil
+}
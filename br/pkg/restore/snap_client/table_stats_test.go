@@ -0,0 +1,60 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package snapclient_test
+
+import (
+	"testing"
+	"time"
+
+	backuppb "github.com/pingcap/kvproto/pkg/brpb"
+	"github.com/pingcap/kvproto/pkg/import_sstpb"
+	"github.com/pingcap/tidb/br/pkg/restore"
+	snapclient "github.com/pingcap/tidb/br/pkg/restore/snap_client"
+	restoreutils "github.com/pingcap/tidb/br/pkg/restore/utils"
+	"github.com/pingcap/tidb/pkg/kv"
+	"github.com/pingcap/tidb/pkg/tablecodec"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTableStatsCollector(t *testing.T) {
+	rewriteRules := func(oldID, newID int64) *restoreutils.RewriteRules {
+		return &restoreutils.RewriteRules{Data: []*import_sstpb.RewriteRule{{
+			OldKeyPrefix: tablecodec.EncodeTablePrefix(oldID),
+			NewKeyPrefix: tablecodec.EncodeTablePrefix(newID),
+		}}}
+	}
+	file := func(tableID int64, kvs uint64) *backuppb.File {
+		return &backuppb.File{
+			StartKey:   tablecodec.EncodeRowKeyWithHandle(tableID, kv.IntHandle(1)),
+			EndKey:     tablecodec.EncodeRowKeyWithHandle(tableID, kv.IntHandle(100)),
+			TotalKvs:   kvs,
+			TotalBytes: kvs * 10,
+		}
+	}
+
+	collector := snapclient.NewTableStatsCollector()
+	// the import time is split among the tables by the bytes, table 101 is rewritten from table 1 and the
+	// table 2 keeps its ID.
+	collector.Collect([]restore.BackupFileSet{
+		{TableID: 101, SSTFiles: []*backuppb.File{file(1, 1), file(1, 2)}, RewriteRules: rewriteRules(1, 101)},
+		{TableID: 2, SSTFiles: []*backuppb.File{file(2, 1)}, RewriteRules: rewriteRules(2, 2)},
+	}, 4*time.Second)
+	// the compacted files of the log backup are keyed by the upstream table ID.
+	collector.Collect([]restore.BackupFileSet{
+		{TableID: 1, SSTFiles: []*backuppb.File{file(1, 4)}, RewriteRules: rewriteRules(1, 101)},
+	}, 2*time.Second)
+	// the files without bytes split the time by the count.
+	collector.Collect([]restore.BackupFileSet{
+		{TableID: 3, SSTFiles: []*backuppb.File{{}, {}, {}}},
+		{TableID: 4, SSTFiles: []*backuppb.File{{}}},
+	}, 4*time.Second)
+	// the kv files of the log backup are added by the log client.
+	collector.Add(snapclient.TableStats{PhysicalID: 2, Files: 1, KVs: 5, Bytes: 50, Duration: time.Second})
+
+	require.Equal(t, []snapclient.TableStats{
+		{PhysicalID: 2, Files: 2, KVs: 6, Bytes: 60, Duration: 2 * time.Second},
+		{PhysicalID: 3, Files: 3, Duration: 3 * time.Second},
+		{PhysicalID: 4, Files: 1, Duration: time.Second},
+		{PhysicalID: 101, Files: 3, KVs: 7, Bytes: 70, RewrittenKVs: 7, Duration: 5 * time.Second},
+	}, collector.Stats())
+}
@@ -0,0 +1,191 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package snapclient
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/pingcap/errors"
+	backuppb "github.com/pingcap/kvproto/pkg/brpb"
+	"github.com/pingcap/log"
+	berrors "github.com/pingcap/tidb/br/pkg/errors"
+	"github.com/pingcap/tidb/br/pkg/metautil"
+	"github.com/pingcap/tidb/pkg/meta/model"
+	"github.com/pingcap/tidb/pkg/parser/ast"
+	"github.com/pingcap/tidb/pkg/parser/mysql"
+	"github.com/pingcap/tidb/pkg/tablecodec"
+	"go.uber.org/zap"
+)
+
+// ParseTenantFilters parses the tenant filters like `db.t: 1,2,3`, and returns the tenant IDs keyed by
+// TableKey. The tables must be partitioned by the integer tenant column.
+func ParseTenantFilters(filters []string) (map[string][]int64, error) {
+	tenants := make(map[string][]int64, len(filters))
+	for _, filter := range filters {
+		name, list, ok := strings.Cut(filter, ":")
+		if !ok || strings.TrimSpace(list) == "" {
+			return nil, errors.Annotatef(berrors.ErrInvalidArgument,
+				"invalid tenant filter %q, it should be like \"db.table: 1,2,3\"", filter)
+		}
+		db, table, ok := strings.Cut(strings.TrimSpace(name), ".")
+		if !ok || db == "" || table == "" {
+			return nil, errors.Annotatef(berrors.ErrInvalidArgument,
+				"invalid table %q of tenant filter, it should be like \"db.table\"", name)
+		}
+		ids := make([]int64, 0)
+		for _, s := range strings.Split(list, ",") {
+			id, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+			if err != nil {
+				return nil, errors.Annotatef(berrors.ErrInvalidArgument, "invalid tenant %q of tenant filter %q", s, filter)
+			}
+			ids = append(ids, id)
+		}
+		key := TableKey(db, table)
+		if _, ok := tenants[key]; ok {
+			return nil, errors.Annotatef(berrors.ErrInvalidArgument, "duplicate tenant filters of table %s", name)
+		}
+		tenants[key] = ids
+	}
+	return tenants, nil
+}
+
+// SelectPartitions returns the IDs of the partitions holding the rows of the tenants. The table must be
+// partitioned by RANGE, LIST or HASH on a single integer column, and mustn't have global indexes whose
+// data can't be split by partitions.
+func SelectPartitions(info *model.TableInfo, tenants []int64) (map[int64]struct{}, error) {
+	pi := info.GetPartitionInfo()
+	if pi == nil {
+		return nil, errors.Annotatef(berrors.ErrInvalidArgument, "table %s isn't partitioned", info.Name)
+	}
+	for _, idx := range info.Indices {
+		if idx.Global {
+			return nil, errors.Annotatef(berrors.ErrInvalidArgument,
+				"table %s has the global index %s", info.Name, idx.Name)
+		}
+	}
+	if err := checkTenantColumn(info); err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	selected := make(map[int64]struct{}, len(tenants))
+	for _, tenant := range tenants {
+		def, err := locateTenantPartition(pi, tenant)
+		if err != nil {
+			return nil, errors.Annotatef(err, "failed to locate the partition of tenant %d in table %s", tenant, info.Name)
+		}
+		if def == nil {
+			log.Warn("no partition holds the tenant", zap.Stringer("table", info.Name), zap.Int64("tenant", tenant))
+			continue
+		}
+		selected[def.ID] = struct{}{}
+	}
+	return selected, nil
+}
+
+// checkTenantColumn checks whether the table is partitioned by a single integer column.
+func checkTenantColumn(info *model.TableInfo) error {
+	pi := info.Partition
+	var name string
+	switch {
+	case len(pi.Columns) == 1:
+		name = pi.Columns[0].L
+	case len(pi.Columns) == 0:
+		name = strings.ToLower(strings.Trim(pi.Expr, "`"))
+	}
+	col := info.FindPublicColumnByName(name)
+	if name == "" || col == nil {
+		return errors.Annotatef(berrors.ErrInvalidArgument,
+			"table %s isn't partitioned by a single column", info.Name)
+	}
+	if !mysql.IsIntegerType(col.GetType()) {
+		return errors.Annotatef(berrors.ErrInvalidArgument,
+			"table %s is partitioned by the non-integer column %s", info.Name, col.Name)
+	}
+	return nil
+}
+
+// locateTenantPartition returns the partition holding the rows of the tenant, or nil if there is none.
+func locateTenantPartition(pi *model.PartitionInfo, tenant int64) (*model.PartitionDefinition, error) {
+	switch pi.Type {
+	case ast.PartitionTypeRange:
+		for i := range pi.Definitions {
+			bound := pi.Definitions[i].LessThan[0]
+			if strings.EqualFold(bound, "MAXVALUE") {
+				return &pi.Definitions[i], nil
+			}
+			v, err := strconv.ParseInt(bound, 10, 64)
+			if err != nil {
+				return nil, errors.Annotatef(berrors.ErrInvalidArgument, "unsupported partition bound %s", bound)
+			}
+			if tenant < v {
+				return &pi.Definitions[i], nil
+			}
+		}
+		return nil, nil
+	case ast.PartitionTypeList:
+		var defaultDef *model.PartitionDefinition
+		for i := range pi.Definitions {
+			for _, values := range pi.Definitions[i].InValues {
+				if len(values) == 1 && strings.EqualFold(values[0], "DEFAULT") {
+					defaultDef = &pi.Definitions[i]
+					continue
+				}
+				for _, value := range values {
+					v, err := strconv.ParseInt(value, 10, 64)
+					if err != nil {
+						return nil, errors.Annotatef(berrors.ErrInvalidArgument, "unsupported partition value %s", value)
+					}
+					if v == tenant {
+						return &pi.Definitions[i], nil
+					}
+				}
+			}
+		}
+		return defaultDef, nil
+	case ast.PartitionTypeHash:
+		idx := tenant % int64(len(pi.Definitions))
+		if idx < 0 {
+			idx = -idx
+		}
+		return &pi.Definitions[idx], nil
+	default:
+		return nil, errors.Annotatef(berrors.ErrInvalidArgument, "unsupported partition type %s", pi.Type)
+	}
+}
+
+// FilterTenantFiles keeps only the backup files of the partitions holding the tenants for the tables
+// having tenant filters, so only the key ranges of these partitions are split and restored. The
+// checksums of the tables are calculated on the files kept.
+func FilterTenantFiles(tables []*metautil.Table, filters map[string][]int64) error {
+	applied := 0
+	for _, table := range tables {
+		tenants, ok := filters[TableKey(table.DB.Name.O, table.Info.Name.O)]
+		if !ok {
+			continue
+		}
+		selected, err := SelectPartitions(table.Info, tenants)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		files := make([]*backuppb.File, 0, len(table.Files))
+		for _, file := range table.Files {
+			if _, ok := selected[tablecodec.DecodeTableID(file.GetStartKey())]; ok {
+				files = append(files, file)
+			}
+		}
+		log.Info("filter files by tenants", zap.Stringer("db", table.DB.Name), zap.Stringer("table", table.Info.Name),
+			zap.Int64s("tenants", tenants), zap.Int("partitions", len(selected)),
+			zap.Int("files", len(files)), zap.Int("skipped files", len(table.Files)-len(files)))
+		table.Files = files
+		// the statistics are of all the partitions.
+		table.Stats = nil
+		table.StatsFileIndexes = nil
+		applied++
+	}
+	if applied < len(filters) {
+		log.Warn("some tenant filters match no table to restore",
+			zap.Int("filters", len(filters)), zap.Int("applied", applied))
+	}
+	return nil
+}
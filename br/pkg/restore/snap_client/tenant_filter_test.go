@@ -0,0 +1,154 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package snapclient_test
+
+import (
+	"testing"
+
+	backuppb "github.com/pingcap/kvproto/pkg/brpb"
+	"github.com/pingcap/tidb/br/pkg/metautil"
+	snapclient "github.com/pingcap/tidb/br/pkg/restore/snap_client"
+	"github.com/pingcap/tidb/pkg/meta/model"
+	"github.com/pingcap/tidb/pkg/parser/ast"
+	statsutil "github.com/pingcap/tidb/pkg/statistics/util"
+	"github.com/pingcap/tidb/pkg/tablecodec"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTenantFilters(t *testing.T) {
+	tenants, err := snapclient.ParseTenantFilters([]string{"DB.T: 1, 2,3", "db.t2:-1"})
+	require.NoError(t, err)
+	require.Equal(t, map[string][]int64{"db.t": {1, 2, 3}, "db.t2": {-1}}, tenants)
+
+	for _, filter := range []string{"db.t", "db.t: ", "t: 1", "db.t: 1,a", "db.t: 1,"} {
+		_, err := snapclient.ParseTenantFilters([]string{filter})
+		require.Error(t, err, filter)
+	}
+	_, err = snapclient.ParseTenantFilters([]string{"db.t: 1", "DB.t: 2"})
+	require.ErrorContains(t, err, "duplicate")
+}
+
+// buildPartitionedTableInfo builds the table info whose partitions have distinct IDs.
+func buildPartitionedTableInfo(t *testing.T, sql string) *model.TableInfo {
+	info := buildTableInfo(t, sql)
+	if info.Partition != nil {
+		for i := range info.Partition.Definitions {
+			info.Partition.Definitions[i].ID = int64(100 + i)
+		}
+	}
+	return info
+}
+
+func partitionNames(info *model.TableInfo, ids map[int64]struct{}) []string {
+	names := make([]string, 0, len(ids))
+	for _, def := range info.Partition.Definitions {
+		if _, ok := ids[def.ID]; ok {
+			names = append(names, def.Name.O)
+		}
+	}
+	return names
+}
+
+func TestSelectPartitions(t *testing.T) {
+	cases := []struct {
+		sql     string
+		tenants []int64
+		names   []string
+		err     string
+	}{
+		{
+			sql: "create table t (tenant_id int, a int) partition by range (tenant_id) " +
+				"(partition p0 values less than (10), partition p1 values less than (20), partition p2 values less than maxvalue)",
+			tenants: []int64{1, 10, 100},
+			names:   []string{"p0", "p1", "p2"},
+		},
+		{
+			sql: "create table t (tenant_id int, a int) partition by range columns (tenant_id) " +
+				"(partition p0 values less than (10), partition p1 values less than (20))",
+			tenants: []int64{15, 20},
+			names:   []string{"p1"},
+		},
+		{
+			sql: "create table t (tenant_id int, a int) partition by list (tenant_id) " +
+				"(partition p0 values in (1, 3), partition p1 values in (2), partition p2 default)",
+			tenants: []int64{3, 4},
+			names:   []string{"p0", "p2"},
+		},
+		{
+			sql: "create table t (tenant_id int, a int) partition by list columns (tenant_id) " +
+				"(partition p0 values in (1), partition p1 values in (2))",
+			tenants: []int64{2, 5},
+			names:   []string{"p1"},
+		},
+		{
+			sql:     "create table t (tenant_id bigint, a int) partition by hash (tenant_id) partitions 4",
+			tenants: []int64{5, -6},
+			names:   []string{"p1", "p2"},
+		},
+		{
+			sql:     "create table t (tenant_id int, a int)",
+			tenants: []int64{1},
+			err:     "isn't partitioned",
+		},
+		{
+			sql:     "create table t (tenant_id int, a int) partition by hash (tenant_id + 1) partitions 4",
+			tenants: []int64{1},
+			err:     "isn't partitioned by a single column",
+		},
+		{
+			sql:     "create table t (tenant_id varchar(10), a int) partition by key (tenant_id) partitions 4",
+			tenants: []int64{1},
+			err:     "non-integer column",
+		},
+		{
+			sql:     "create table t (tenant_id int, a int) partition by key (tenant_id) partitions 4",
+			tenants: []int64{1},
+			err:     "unsupported partition type",
+		},
+	}
+	for _, c := range cases {
+		info := buildPartitionedTableInfo(t, c.sql)
+		ids, err := snapclient.SelectPartitions(info, c.tenants)
+		if c.err != "" {
+			require.ErrorContains(t, err, c.err, c.sql)
+			continue
+		}
+		require.NoError(t, err, c.sql)
+		require.Equal(t, c.names, partitionNames(info, ids), c.sql)
+	}
+}
+
+func TestFilterTenantFiles(t *testing.T) {
+	info := buildPartitionedTableInfo(t, "create table t (tenant_id int, a int) partition by list (tenant_id) "+
+		"(partition p0 values in (1), partition p1 values in (2))")
+	fileOf := func(name string, physicalID int64) *backuppb.File {
+		return &backuppb.File{
+			Name:     name,
+			StartKey: tablecodec.EncodeTablePrefix(physicalID),
+			EndKey:   tablecodec.EncodeTablePrefix(physicalID + 1),
+			Crc64Xor: uint64(physicalID),
+			TotalKvs: 1,
+		}
+	}
+	table := &metautil.Table{
+		DB:    &model.DBInfo{Name: ast.NewCIStr("db")},
+		Info:  info,
+		Files: []*backuppb.File{fileOf("a", 100), fileOf("b", 101), fileOf("c", 101)},
+		Stats: &statsutil.JSONTable{},
+	}
+	other := &metautil.Table{
+		DB:    &model.DBInfo{Name: ast.NewCIStr("db")},
+		Info:  &model.TableInfo{Name: ast.NewCIStr("t2")},
+		Files: []*backuppb.File{fileOf("d", 102)},
+	}
+	err := snapclient.FilterTenantFiles([]*metautil.Table{table, other}, map[string][]int64{"db.t": {2}})
+	require.NoError(t, err)
+	require.Len(t, table.Files, 2)
+	for _, file := range table.Files {
+		require.Equal(t, int64(101), tablecodec.DecodeTableID(file.StartKey))
+	}
+	require.Nil(t, table.Stats)
+	// the checksum is calculated on the files kept.
+	require.Equal(t, uint64(2), metautil.CalculateChecksumStatsOnFiles(table.Files).TotalKvs)
+	require.Len(t, other.Files, 1)
+}
@@ -15,8 +15,11 @@
 package snapclient
 
 import (
+	"bytes"
+	"cmp"
 	"context"
 	"fmt"
+	"slices"
 	"sort"
 	"strings"
 	"time"
@@ -41,9 +44,13 @@ func getSortedPhysicalTables(createdTables []*CreatedTable) []*PhysicalTable {
 			NewPhysicalID: createdTable.Table.ID,
 			OldPhysicalID: createdTable.OldTable.Info.ID,
 			RewriteRules:  createdTable.RewriteRule,
+			GlobalIndexes: createdTable.Table.Partition != nil,
 		})
 
 		partitionIDMap := restoreutils.GetPartitionIDMap(createdTable.Table, createdTable.OldTable.Info)
+		if createdTable.PartitionsMerged {
+			partitionIDMap = restoreutils.GetMergedPartitionIDMap(createdTable.Table, createdTable.OldTable.Info)
+		}
 		for oldID, newID := range partitionIDMap {
 			physicalTables = append(physicalTables, &PhysicalTable{
 				NewPhysicalID: newID,
@@ -59,6 +66,85 @@ func getSortedPhysicalTables(createdTables []*CreatedTable) []*PhysicalTable {
 	return physicalTables
 }
 
+// getSortedTables is getSortedPhysicalTables for the tables without partitions, whose physical IDs are
+// the table IDs, so it skips the partition ID maps and allocates the physical tables at once.
+func getSortedTables(createdTables []*CreatedTable) []*PhysicalTable {
+	tables := make([]PhysicalTable, 0, len(createdTables))
+	for _, createdTable := range createdTables {
+		tables = append(tables, PhysicalTable{
+			NewPhysicalID: createdTable.Table.ID,
+			OldPhysicalID: createdTable.OldTable.Info.ID,
+			RewriteRules:  createdTable.RewriteRule,
+		})
+	}
+	slices.SortFunc(tables, func(a, b PhysicalTable) int {
+		return cmp.Compare(a.NewPhysicalID, b.NewPhysicalID)
+	})
+	physicalTables := make([]*PhysicalTable, 0, len(tables))
+	for i := range tables {
+		physicalTables = append(physicalTables, &tables[i])
+	}
+	return physicalTables
+}
+
+// hasPartitionedTables checks whether any of the tables is partitioned in the backup or in the cluster.
+func hasPartitionedTables(createdTables []*CreatedTable) bool {
+	for _, createdTable := range createdTables {
+		if createdTable.Table.Partition != nil || createdTable.OldTable.Info.Partition != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// tableFiles locates the backup files of a table.
+type tableFiles interface {
+	filesOf(tableID int64) []*backuppb.File
+}
+
+type tableFilesMap map[int64][]*backuppb.File
+
+func (m tableFilesMap) filesOf(tableID int64) []*backuppb.File {
+	return m[tableID]
+}
+
+// tableFilesArray holds the backup files sorted by the table ID in a flat array, and locates the files of
+// a table by binary searching the table IDs. It's more compact than tableFilesMap for a huge number of
+// tables, since there is neither a map entry nor a growing slice for each table.
+type tableFilesArray struct {
+	files    []*backuppb.File
+	tableIDs []int64
+	// the files of tableIDs[i] are files[offsets[i]:offsets[i+1]].
+	offsets []int
+}
+
+func (a *tableFilesArray) filesOf(tableID int64) []*backuppb.File {
+	i, found := slices.BinarySearch(a.tableIDs, tableID)
+	if !found {
+		return nil
+	}
+	return a.files[a.offsets[i]:a.offsets[i+1]]
+}
+
+// fileTableID returns the table ID of the file, and panics if the file holds none or many tables.
+func fileTableID(file *backuppb.File) int64 {
+	tableID := tablecodec.DecodeTableID(file.GetStartKey())
+	tableEndID := tablecodec.DecodeTableID(file.GetEndKey())
+	if tableID != tableEndID {
+		log.Panic("key range spread between many files.",
+			zap.String("file name", file.Name),
+			logutil.Key("startKey", file.StartKey),
+			logutil.Key("endKey", file.EndKey))
+	}
+	if tableID == 0 {
+		log.Panic("invalid table key of file",
+			zap.String("file name", file.Name),
+			logutil.Key("startKey", file.StartKey),
+			logutil.Key("endKey", file.EndKey))
+	}
+	return tableID
+}
+
 // mapTableToFiles makes a map that mapping table ID to its backup files.
 // aware that one file can and only can hold one table.
 func mapTableToFiles(files []*backuppb.File) (map[int64][]*backuppb.File, int) {
@@ -66,20 +152,7 @@ func mapTableToFiles(files []*backuppb.File) (map[int64][]*backuppb.File, int) {
 	// count the write cf file that hint for split key slice size
 	maxSplitKeyCount := 0
 	for _, file := range files {
-		tableID := tablecodec.DecodeTableID(file.GetStartKey())
-		tableEndID := tablecodec.DecodeTableID(file.GetEndKey())
-		if tableID != tableEndID {
-			log.Panic("key range spread between many files.",
-				zap.String("file name", file.Name),
-				logutil.Key("startKey", file.StartKey),
-				logutil.Key("endKey", file.EndKey))
-		}
-		if tableID == 0 {
-			log.Panic("invalid table key of file",
-				zap.String("file name", file.Name),
-				logutil.Key("startKey", file.StartKey),
-				logutil.Key("endKey", file.EndKey))
-		}
+		tableID := fileTableID(file)
 		result[tableID] = append(result[tableID], file)
 		if file.Cf == restoreutils.WriteCFName {
 			maxSplitKeyCount += 1
@@ -88,6 +161,31 @@ func mapTableToFiles(files []*backuppb.File) (map[int64][]*backuppb.File, int) {
 	return result, maxSplitKeyCount
 }
 
+// sortTableFiles is mapTableToFiles returning a tableFilesArray, the files of a table keep their order.
+func sortTableFiles(files []*backuppb.File) (*tableFilesArray, int) {
+	maxSplitKeyCount := 0
+	for _, file := range files {
+		fileTableID(file)
+		if file.Cf == restoreutils.WriteCFName {
+			maxSplitKeyCount += 1
+		}
+	}
+	sorted := slices.Clone(files)
+	slices.SortStableFunc(sorted, func(a, b *backuppb.File) int {
+		return cmp.Compare(tablecodec.DecodeTableID(a.GetStartKey()), tablecodec.DecodeTableID(b.GetStartKey()))
+	})
+	result := &tableFilesArray{files: sorted}
+	for i, file := range sorted {
+		tableID := tablecodec.DecodeTableID(file.GetStartKey())
+		if len(result.tableIDs) == 0 || result.tableIDs[len(result.tableIDs)-1] != tableID {
+			result.tableIDs = append(result.tableIDs, tableID)
+			result.offsets = append(result.offsets, i)
+		}
+	}
+	result.offsets = append(result.offsets, len(sorted))
+	return result, maxSplitKeyCount
+}
+
 // filterOutFiles filters out files that exist in the checkpoint set.
 func filterOutFiles(checkpointSet map[string]struct{}, files []*backuppb.File, onProgress func(int64)) []*backuppb.File {
 	progress := int(0)
@@ -117,6 +215,16 @@ func filterOutFiles(checkpointSet map[string]struct{}, files []*backuppb.File, o
 	return newFiles
 }
 
+// hasIndexFiles checks whether any of the files holds the index keys.
+func hasIndexFiles(files []*backuppb.File) bool {
+	for _, file := range files {
+		if tablecodec.IsIndexKey(file.GetStartKey()) {
+			return true
+		}
+	}
+	return false
+}
+
 // If there are many tables with only a few rows, the number of merged SSTs will be too large.
 // So set a threshold to avoid it.
 const MergedRangeCountThreshold = 1536
@@ -130,9 +238,23 @@ func SortAndValidateFileRanges(
 	splitOnTable bool,
 	onProgress func(int64),
 ) ([][]byte, []restore.BatchBackupFileSet, error) {
-	sortedPhysicalTables := getSortedPhysicalTables(createdTables)
-	// mapping table ID to its backup files
-	fileOfTable, hintSplitKeyCount := mapTableToFiles(allFiles)
+	var (
+		sortedPhysicalTables []*PhysicalTable
+		// mapping table ID to its backup files
+		fileOfTable       tableFiles
+		hintSplitKeyCount int
+	)
+	if hasPartitionedTables(createdTables) {
+		sortedPhysicalTables = getSortedPhysicalTables(createdTables)
+		var filesMap map[int64][]*backuppb.File
+		filesMap, hintSplitKeyCount = mapTableToFiles(allFiles)
+		fileOfTable = tableFilesMap(filesMap)
+	} else {
+		// the physical IDs are the table IDs, so the partition bookkeeping is skipped.
+		log.Info("no partitioned table is restored, index the files by the flat table ID array")
+		sortedPhysicalTables = getSortedTables(createdTables)
+		fileOfTable, hintSplitKeyCount = sortTableFiles(allFiles)
+	}
 	// sort, merge, and validate files in each tables, and generate split keys by the way
 	var (
 		// to generate region split keys, merge the small ranges over the adjacent tables
@@ -150,8 +272,21 @@ func SortAndValidateFileRanges(
 	)
 
 	log.Info("start to merge ranges", zap.Uint64("kv size threshold", splitSizeBytes), zap.Uint64("kv count threshold", splitKeyCount))
+	// splitGroup generates a split key at the end of the merged ranges, and starts a new files group.
+	splitGroup := func() {
+		groupSize, groupCount = 0, 0
+		mergedRangeCount = 0
+		if lastKey != nil {
+			sortedSplitKeys = append(sortedSplitKeys, lastKey)
+			lastKey = nil
+		}
+		if lastFilesGroup != nil {
+			tableIDWithFilesGroup = append(tableIDWithFilesGroup, lastFilesGroup)
+			lastFilesGroup = nil
+		}
+	}
 	for _, table := range sortedPhysicalTables {
-		files := fileOfTable[table.OldPhysicalID]
+		files := fileOfTable.filesOf(table.OldPhysicalID)
 		for _, file := range files {
 			if err := restoreutils.ValidateFileRewriteRule(file, table.RewriteRules); err != nil {
 				return nil, nil, errors.Trace(err)
@@ -177,6 +312,16 @@ func SortAndValidateFileRanges(
 			zap.Int("Merged(keys avg)", stat.MergedRegionKeysAvg),
 			zap.Int("Merged(bytes avg)", stat.MergedRegionBytesAvg))
 
+		// The global indexes are stored in the logical table of the partitioned table, which isn't split
+		// from the adjacent tables when the table is created, because only the partitions are split. So
+		// split the ranges of the global indexes from the adjacent ranges, and they are scattered like
+		// the ranges of the table data.
+		splitGlobalIndexes := table.GlobalIndexes && hasIndexFiles(files)
+		if splitGlobalIndexes {
+			log.Info("split the ranges of the global indexes", zap.Int64("new physical ID", table.NewPhysicalID))
+			splitGroup()
+		}
+
 		// skip some ranges if recorded by checkpoint
 		// Notice that skip ranges after select split keys in order to make the split keys
 		// always the same.
@@ -244,6 +389,10 @@ func SortAndValidateFileRanges(
 			}
 		}
 
+		if splitGlobalIndexes {
+			splitGroup()
+		}
+
 		// If the config split-table/split-region-on-table is on, it skip merging ranges over tables.
 		if splitOnTable {
 			log.Info("merge ranges across tables due to split on table",
@@ -265,6 +414,11 @@ func SortAndValidateFileRanges(
 	if lastKey != nil {
 		sortedSplitKeys = append(sortedSplitKeys, lastKey)
 	}
+	// the ranges of the partitions merged into a table overlap after rewritten, so are their split keys.
+	if !slices.IsSortedFunc(sortedSplitKeys, bytes.Compare) {
+		slices.SortFunc(sortedSplitKeys, bytes.Compare)
+		sortedSplitKeys = slices.CompactFunc(sortedSplitKeys, bytes.Equal)
+	}
 	// append the last files group anyway
 	if lastFilesGroup != nil {
 		log.Info("merge ranges across tables due to the last group",
@@ -329,10 +483,12 @@ func (rc *SnapClient) SplitPoints(
 	onProgress func(int64),
 	isRawKv bool,
 ) error {
-	splitClientOpts := make([]split.ClientOptionalParameter, 0, 2)
+	ctx, splitWatchdog := restoreutils.StartPhaseWatchdog(ctx, restoreutils.PhaseSplit, rc.phaseTimeouts.Split, rc.getAllStores)
+	ctx, scatterWatchdog := restoreutils.StartPhaseWatchdog(ctx, restoreutils.PhaseScatter, rc.phaseTimeouts.Scatter, rc.getAllStores)
+	splitClientOpts := make([]split.ClientOptionalParameter, 0, 3)
 	splitClientOpts = append(splitClientOpts, split.WithOnSplit(func(keys [][]byte) {
 		onProgress(int64(len(keys)))
-	}))
+	}), split.WithPhaseWatchdogs(splitWatchdog, scatterWatchdog))
 	// TODO seems duplicate with metaClient.
 	if isRawKv {
 		splitClientOpts = append(splitClientOpts, split.WithRawKV())
@@ -347,7 +503,23 @@ func (rc *SnapClient) SplitPoints(
 		splitClientOpts...,
 	))
 
-	return splitter.ExecuteSortedKeys(ctx, sortedSplitKeys)
+	err := splitter.ExecuteSortedKeys(ctx, sortedSplitKeys)
+	return stopPhaseWatchdogs(err, splitWatchdog, scatterWatchdog)
+}
+
+// stopPhaseWatchdogs stops the watchdogs. The diagnosis of a stalled phase takes the place of err, which
+// is mostly the cancellation caused by the stall.
+func stopPhaseWatchdogs(err error, watchdogs ...*restoreutils.PhaseWatchdog) error {
+	var stallErr error
+	for _, w := range watchdogs {
+		if werr := w.Stop(); werr != nil && stallErr == nil {
+			stallErr = werr
+		}
+	}
+	if stallErr != nil {
+		return errors.Trace(stallErr)
+	}
+	return errors.Trace(err)
 }
 
 func getFileRangeKey(f string) string {
@@ -385,10 +557,18 @@ func (rc *SnapClient) RestoreSSTFiles(
 		}
 	})
 
+	// the files are imported with the context of the restorer, so the importer attaches the requests to
+	// the watchdogs by itself.
+	_, downloadWatchdog := restoreutils.StartPhaseWatchdog(ctx, restoreutils.PhaseDownload, rc.phaseTimeouts.Download, rc.getAllStores)
+	_, ingestWatchdog := restoreutils.StartPhaseWatchdog(ctx, restoreutils.PhaseIngest, rc.phaseTimeouts.Ingest, rc.getAllStores)
+	if rc.fileImporter != nil {
+		rc.fileImporter.SetPhaseWatchdogs(downloadWatchdog, ingestWatchdog)
+		defer rc.fileImporter.SetPhaseWatchdogs(nil, nil)
+	}
 	r := rc.GetRestorer(rc.checkpointRunner)
 	retErr = r.GoRestore(onProgress, tableIDWithFilesGroup...)
-	if retErr != nil {
-		return retErr
+	if retErr == nil {
+		retErr = r.WaitUntilFinish()
 	}
-	return r.WaitUntilFinish()
+	return stopPhaseWatchdogs(retErr, downloadWatchdog, ingestWatchdog)
 }
@@ -17,6 +17,7 @@ package snapclient_test
 import (
 	"fmt"
 	"math/rand"
+	"slices"
 	"testing"
 
 	backuppb "github.com/pingcap/kvproto/pkg/brpb"
@@ -73,6 +74,66 @@ func TestMapTableToFiles(t *testing.T) {
 	require.Equal(t, 3, hintSplitKeyCount)
 }
 
+func TestSortTableFiles(t *testing.T) {
+	tableFile := func(tableID int64, name, cf string) *backuppb.File {
+		return &backuppb.File{
+			Name:     name,
+			StartKey: tablecodec.EncodeTablePrefix(tableID),
+			EndKey:   tablecodec.EncodeTablePrefix(tableID),
+			Cf:       cf,
+		}
+	}
+	filesOfTable1 := []*backuppb.File{
+		tableFile(1, "table1-1.sst", restoreutils.WriteCFName),
+		tableFile(1, "table1-2.sst", restoreutils.WriteCFName),
+		tableFile(1, "table1-3.sst", restoreutils.DefaultCFName),
+	}
+	filesOfTable2 := []*backuppb.File{
+		tableFile(2, "table2-1.sst", restoreutils.WriteCFName),
+		tableFile(2, "table2-2.sst", restoreutils.DefaultCFName),
+	}
+	filesOfTable5 := []*backuppb.File{
+		tableFile(5, "table5-1.sst", restoreutils.DefaultCFName),
+	}
+	allFiles := slices.Concat(filesOfTable2, filesOfTable5[:1], filesOfTable1[:2], filesOfTable1[2:])
+
+	filesOf, hintSplitKeyCount := snapclient.SortTableFiles(allFiles)
+	require.Equal(t, filesOfTable1, filesOf(1))
+	require.Equal(t, filesOfTable2, filesOf(2))
+	require.Equal(t, filesOfTable5, filesOf(5))
+	require.Empty(t, filesOf(3))
+	require.Empty(t, filesOf(6))
+	require.Equal(t, 3, hintSplitKeyCount)
+	// the input files keep their order.
+	require.Equal(t, "table2-1.sst", allFiles[0].Name)
+
+	filesOf, hintSplitKeyCount = snapclient.SortTableFiles(nil)
+	require.Empty(t, filesOf(1))
+	require.Zero(t, hintSplitKeyCount)
+}
+
+func BenchmarkTableFilesIndex(b *testing.B) {
+	const tableCount = 100000
+	allFiles := make([]*backuppb.File, 0, tableCount*2)
+	for id := int64(1); id <= tableCount; id++ {
+		allFiles = append(allFiles,
+			file(id, 0, 100, 100, 1000, restoreutils.DefaultCFName),
+			file(id, 0, 100, 100, 1000, restoreutils.WriteCFName))
+	}
+	b.Run("map", func(b *testing.B) {
+		b.ReportAllocs()
+		for range b.N {
+			snapclient.MapTableToFiles(allFiles)
+		}
+	})
+	b.Run("flat", func(b *testing.B) {
+		b.ReportAllocs()
+		for range b.N {
+			snapclient.SortTableFiles(allFiles)
+		}
+	})
+}
+
 func newPartitionID(ids []int64) *model.PartitionInfo {
 	definitions := make([]model.PartitionDefinition, 0, len(ids))
 	for i, id := range ids {
@@ -121,6 +182,21 @@ func TestGetSortedPhysicalTables(t *testing.T) {
 	require.Equal(t, []int64{23, 54, 200, 400, 900, 5354, 9030, 22353}, newIDs)
 }
 
+func TestGetSortedTables(t *testing.T) {
+	newTable := func(oldID, newID int64) *snapclient.CreatedTable {
+		return &snapclient.CreatedTable{
+			Table:    &model.TableInfo{ID: newID},
+			OldTable: &metautil.Table{Info: &model.TableInfo{ID: oldID}},
+		}
+	}
+	createdTables := []*snapclient.CreatedTable{newTable(100, 200), newTable(300, 150), newTable(50, 400)}
+	physicalTables := snapclient.GetSortedTables(createdTables)
+	oldIDs, newIDs := physicalIDs(physicalTables)
+	require.Equal(t, []int64{300, 100, 50}, oldIDs)
+	require.Equal(t, []int64{150, 200, 400}, newIDs)
+	require.Equal(t, physicalTables, snapclient.GetSortedPhysicalTables(createdTables))
+}
+
 type MockUpdateCh struct {
 	glue.Progress
 }
@@ -675,3 +751,71 @@ func TestSortAndValidateFileRanges(t *testing.T) {
 		}
 	}
 }
+
+func TestSortAndValidateGlobalIndexRanges(t *testing.T) {
+	w := restoreutils.WriteCFName
+	// downstream id: [100:10100] [101:9101] [102:8102] [200:10200]
+	// sorted physical: [102, 101, 100, 200]
+	indexKey := func(tableID int64, value byte) []byte {
+		return tablecodec.EncodeIndexSeekKey(tableID, 1, []byte{value})
+	}
+	globalIndexFile := &backuppb.File{
+		Name:       "file_100_1_write.sst",
+		StartKey:   indexKey(100, 1),
+		EndKey:     indexKey(100, 2),
+		TotalKvs:   10,
+		TotalBytes: 10,
+		Cf:         w,
+	}
+	allFiles := []*backuppb.File{
+		file(102, 1, 2, 10, 10, w), file(101, 1, 2, 10, 10, w), globalIndexFile, file(200, 1, 2, 10, 10, w),
+	}
+	for _, splitOnTable := range []bool{false, true} {
+		createdTables := generateCreatedTables(t, []int64{100, 200}, map[int64][]int64{100: {101, 102}}, downstreamID)
+		splitKeys, groups, err := snapclient.SortAndValidateFileRanges(createdTables, allFiles, nil, 1000, 1000, splitOnTable, func(int64) {})
+		require.NoError(t, err)
+		tableIDs := make([][]int64, 0, len(groups))
+		for _, group := range groups {
+			ids := make([]int64, 0, len(group))
+			for _, files := range group {
+				ids = append(ids, files.TableID)
+			}
+			tableIDs = append(tableIDs, ids)
+		}
+		// the global indexes are split from the adjacent tables even if the small ranges could be merged.
+		if splitOnTable {
+			require.Equal(t, [][]byte{indexKey(downstreamID(100), 2)}, splitKeys)
+			require.Equal(t, [][]int64{{downstreamID(102)}, {downstreamID(101)}, {downstreamID(100)}, {downstreamID(200)}}, tableIDs)
+		} else {
+			require.Equal(t, [][]byte{key(101, 2), indexKey(downstreamID(100), 2), key(200, 2)}, splitKeys)
+			require.Equal(t, [][]int64{{downstreamID(102), downstreamID(101)}, {downstreamID(100)}, {downstreamID(200)}}, tableIDs)
+		}
+	}
+}
+
+func TestSortAndValidateMergedPartitionRanges(t *testing.T) {
+	w := restoreutils.WriteCFName
+	// the partitions 101 and 102 are merged into the table 100, their ranges overlap after rewritten.
+	createdTable := &snapclient.CreatedTable{
+		Table: &model.TableInfo{ID: downstreamID(100)},
+		OldTable: &metautil.Table{
+			DB:   &model.DBInfo{Name: ast.NewCIStr("test")},
+			Info: &model.TableInfo{ID: 100, Partition: newPartitionID([]int64{101, 102})},
+		},
+	}
+	createdTable.RewriteRule = restoreutils.GetMergedRewriteRules(createdTable.Table, createdTable.OldTable.Info, 0, true)
+	createdTable.PartitionsMerged = true
+	allFiles := []*backuppb.File{file(102, 3, 8, 10, 10, w), file(101, 1, 5, 10, 10, w)}
+	splitKeys, groups, err := snapclient.SortAndValidateFileRanges(
+		[]*snapclient.CreatedTable{createdTable}, allFiles, nil, 1, 1, false, func(int64) {})
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{key(100, 5), key(100, 8)}, splitKeys)
+	restoredFiles := 0
+	for _, group := range groups {
+		for _, files := range group {
+			require.Equal(t, downstreamID(100), files.TableID)
+			restoredFiles += len(files.SSTFiles)
+		}
+	}
+	require.Equal(t, 2, restoredFiles)
+}
@@ -24,6 +24,7 @@ import (
 	"github.com/pingcap/tidb/br/pkg/conn/util"
 	berrors "github.com/pingcap/tidb/br/pkg/errors"
 	"github.com/pingcap/tidb/br/pkg/logutil"
+	restoreutils "github.com/pingcap/tidb/br/pkg/restore/utils"
 	"github.com/pingcap/tidb/br/pkg/utils"
 	"github.com/pingcap/tidb/pkg/kv"
 	"github.com/pingcap/tidb/pkg/lightning/common"
@@ -94,6 +95,9 @@ type SplitClient interface {
 	SetPlacementRule(ctx context.Context, rule *pdhttp.Rule) error
 	// DeletePlacementRule removes a placement rule from PD.
 	DeletePlacementRule(ctx context.Context, groupID, ruleID string) error
+	// SetPlacementRuleBundle replaces the rules of the group by the ones of the bundle, the group is removed
+	// if the bundle has no rules. The other groups are kept.
+	SetPlacementRuleBundle(ctx context.Context, bundle *pdhttp.GroupBundle) error
 	// SetStoresLabel add or update specified label of stores. If labelValue
 	// is empty, it clears the label.
 	SetStoresLabel(ctx context.Context, stores []uint64, labelKey, labelValue string) error
@@ -125,6 +129,9 @@ type pdClient struct {
 	onSplit          func(key [][]byte)
 	splitConcurrency int
 	splitBatchKeyCnt int
+
+	splitWatchdog   *restoreutils.PhaseWatchdog
+	scatterWatchdog *restoreutils.PhaseWatchdog
 }
 
 type ClientOptionalParameter func(*pdClient)
@@ -143,6 +150,14 @@ func WithOnSplit(onSplit func(key [][]byte)) ClientOptionalParameter {
 	}
 }
 
+// WithPhaseWatchdogs sets the watchdogs tracking the split requests and the scattering regions.
+func WithPhaseWatchdogs(split, scatter *restoreutils.PhaseWatchdog) ClientOptionalParameter {
+	return func(c *pdClient) {
+		c.splitWatchdog = split
+		c.scatterWatchdog = scatter
+	}
+}
+
 // NewClient creates a SplitClient.
 //
 // splitBatchKeyCnt controls how many keys are sent to TiKV in a batch in split
@@ -371,7 +386,9 @@ func sendSplitRegionRequest(
 	}
 	defer conn.Close()
 	client := tikvpb.NewTikvClient(conn)
+	done := c.splitWatchdog.Begin(regionInfo.Region.GetId(), storeID)
 	resp, err := splitRegionWithFailpoint(ctx, regionInfo, peer, client, keys, c.isRawKv)
+	done(err == nil && resp.RegionError == nil)
 	if err != nil {
 		return false, nil, err
 	}
@@ -775,6 +792,10 @@ func (c *pdClient) DeletePlacementRule(ctx context.Context, groupID, ruleID stri
 	return c.httpCli.DeletePlacementRule(ctx, groupID, ruleID)
 }
 
+func (c *pdClient) SetPlacementRuleBundle(ctx context.Context, bundle *pdhttp.GroupBundle) error {
+	return c.httpCli.SetPlacementRuleBundles(ctx, []*pdhttp.GroupBundle{bundle}, true)
+}
+
 func (c *pdClient) SetStoresLabel(
 	ctx context.Context, stores []uint64, labelKey, labelValue string,
 ) error {
@@ -850,7 +871,14 @@ func (c *pdClient) WaitRegionsScattered(ctx context.Context, regions []*RegionIn
 		retryCnt      = -1
 		needRescatter = make([]*RegionInfo, 0, len(regions))
 		needRecheck   = make([]*RegionInfo, 0, len(regions))
+		// the regions being scattered, each scattered region is the progress of the scatter phase.
+		scattering = make(map[uint64]func(succeeded bool))
 	)
+	defer func() {
+		for _, done := range scattering {
+			done(false)
+		}
+	}()
 
 	err := utils.WithRetryReturnLastErr(ctx, func() error {
 		retryCnt++
@@ -874,6 +902,12 @@ func (c *pdClient) WaitRegionsScattered(ctx context.Context, regions []*RegionIn
 			}
 
 			ok, rescatter, err := c.isScatterRegionFinished(ctx, regionID)
+			if done, tracked := scattering[regionID]; tracked && ok {
+				done(true)
+				delete(scattering, regionID)
+			} else if !tracked && !ok {
+				scattering[regionID] = c.scatterWatchdog.Begin(regionID, region.Leader.GetStoreId())
+			}
 			if err != nil {
 				if !common.IsRetryableError(err) {
 					brlog.FromContext(ctx).Warn(
@@ -0,0 +1,80 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package tiflashrec
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/tidb/br/pkg/storage"
+	"github.com/pingcap/tidb/pkg/meta/model"
+)
+
+// RecorderFile is the TiFlashRecorder saved into the external storage, so the TiFlash replicas can be
+// applied later, e.g. off-peak, instead of right after the restore.
+type RecorderFile struct {
+	// ClusterID is the ID of the cluster restored into, the table IDs are the ones in it.
+	ClusterID uint64 `json:"cluster-id"`
+	// RestoreTS is the restore ts of the restore saving the file.
+	RestoreTS uint64         `json:"restore-ts"`
+	Items     []RecorderItem `json:"items"`
+}
+
+// RecorderItem is the TiFlash replica of a table.
+type RecorderItem struct {
+	TableID int64                    `json:"table-id"`
+	Replica model.TiFlashReplicaInfo `json:"replica"`
+}
+
+// RecorderFileName returns the name of the file the TiFlashRecorder of the restore is saved into.
+func RecorderFileName(restoreTS uint64) string {
+	return fmt.Sprintf("tiflash-replicas-%d.json", restoreTS)
+}
+
+// ToFile returns the file of the recorder, the items are sorted by the table ID.
+func (r *TiFlashRecorder) ToFile(clusterID, restoreTS uint64) *RecorderFile {
+	file := &RecorderFile{
+		ClusterID: clusterID,
+		RestoreTS: restoreTS,
+		Items:     make([]RecorderItem, 0, len(r.items)),
+	}
+	r.Iterate(func(tableID int64, replica model.TiFlashReplicaInfo) {
+		file.Items = append(file.Items, RecorderItem{TableID: tableID, Replica: replica})
+	})
+	sort.Slice(file.Items, func(i, j int) bool { return file.Items[i].TableID < file.Items[j].TableID })
+	return file
+}
+
+// Recorder returns the recorder of the items in the file.
+func (f *RecorderFile) Recorder() *TiFlashRecorder {
+	r := New()
+	for _, item := range f.Items {
+		r.items[item.TableID] = item.Replica
+	}
+	return r
+}
+
+// WriteRecorderFile saves the file into the storage.
+func WriteRecorderFile(ctx context.Context, s storage.ExternalStorage, name string, file *RecorderFile) error {
+	data, err := json.Marshal(file)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Annotatef(s.WriteFile(ctx, name, data), "failed to save the TiFlash replicas to %s", name)
+}
+
+// ReadRecorderFile reads the file saved by WriteRecorderFile.
+func ReadRecorderFile(ctx context.Context, s storage.ExternalStorage, name string) (*RecorderFile, error) {
+	data, err := s.ReadFile(ctx, name)
+	if err != nil {
+		return nil, errors.Annotatef(err, "failed to read the TiFlash replicas from %s", name)
+	}
+	file := &RecorderFile{}
+	if err := json.Unmarshal(data, file); err != nil {
+		return nil, errors.Annotatef(err, "failed to parse the TiFlash replicas in %s", name)
+	}
+	return file, nil
+}
@@ -0,0 +1,38 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package tiflashrec_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pingcap/tidb/br/pkg/restore/tiflashrec"
+	"github.com/pingcap/tidb/br/pkg/storage"
+	"github.com/pingcap/tidb/pkg/meta/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecorderFile(t *testing.T) {
+	ctx := context.Background()
+	s, err := storage.NewLocalStorage(t.TempDir())
+	require.NoError(t, err)
+
+	rec := tiflashrec.New()
+	rec.AddTable(43, model.TiFlashReplicaInfo{Count: 2, LocationLabels: []string{"zone"}})
+	rec.AddTable(42, model.TiFlashReplicaInfo{Count: 1})
+	name := tiflashrec.RecorderFileName(400)
+	require.NoError(t, tiflashrec.WriteRecorderFile(ctx, s, name, rec.ToFile(7, 400)))
+
+	file, err := tiflashrec.ReadRecorderFile(ctx, s, name)
+	require.NoError(t, err)
+	require.Equal(t, uint64(7), file.ClusterID)
+	require.Equal(t, uint64(400), file.RestoreTS)
+	require.Equal(t, []tiflashrec.RecorderItem{
+		{TableID: 42, Replica: model.TiFlashReplicaInfo{Count: 1}},
+		{TableID: 43, Replica: model.TiFlashReplicaInfo{Count: 2, LocationLabels: []string{"zone"}}},
+	}, file.Items)
+	require.Equal(t, rec.GetItems(), file.Recorder().GetItems())
+
+	_, err = tiflashrec.ReadRecorderFile(ctx, s, tiflashrec.RecorderFileName(401))
+	require.Error(t, err)
+}
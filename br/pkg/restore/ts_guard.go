@@ -0,0 +1,244 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package restore
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/kvproto/pkg/kvrpcpb"
+	"github.com/pingcap/log"
+	berrors "github.com/pingcap/tidb/br/pkg/errors"
+	"github.com/pingcap/tidb/pkg/kv"
+	"github.com/pingcap/tidb/pkg/store/helper"
+	"github.com/pingcap/tidb/pkg/util/redact"
+	"github.com/tikv/client-go/v2/oracle"
+	pd "github.com/tikv/pd/client"
+	"go.uber.org/zap"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	// defaultTSGuardSamplesPerRange is the number of the keys sampled from each end of a range.
+	defaultTSGuardSamplesPerRange = 8
+	// defaultTSGuardMaxRanges is the number of the ranges sampled at most, so the cost of the check doesn't
+	// grow with the number of the restored tables.
+	defaultTSGuardMaxRanges = 256
+	tsGuardConcurrency      = 16
+	// maxTSGuardReportedKeys is the number of the newer keys listed in the error at most.
+	maxTSGuardReportedKeys = 5
+)
+
+// KeyProber reads the keys and their MVCC versions in the target cluster.
+type KeyProber interface {
+	// SampleKeys returns up to limit keys in [startKey, endKey) visible at the ts, from the end of the range
+	// if reverse is true.
+	SampleKeys(ctx context.Context, startKey, endKey kv.Key, ts uint64, limit int, reverse bool) ([]kv.Key, error)
+	// LatestWriteTS returns the ts of the latest put or delete of the key, including the one not committed
+	// yet, 0 if there is none.
+	LatestWriteTS(ctx context.Context, key kv.Key) (uint64, error)
+}
+
+type storeKeyProber struct {
+	store  kv.Storage
+	helper *helper.Helper
+}
+
+// NewStoreKeyProber returns a KeyProber reading the keys by the snapshots of the store and their MVCC
+// versions by the MVCC API of TiKV.
+func NewStoreKeyProber(store kv.Storage) (KeyProber, error) {
+	s, ok := store.(helper.Storage)
+	if !ok {
+		return nil, errors.Annotatef(berrors.ErrUnsupportedOperation, "the storage %T doesn't support reading the MVCC", store)
+	}
+	return &storeKeyProber{store: store, helper: helper.NewHelper(s)}, nil
+}
+
+func (p *storeKeyProber) SampleKeys(
+	ctx context.Context, startKey, endKey kv.Key, ts uint64, limit int, reverse bool,
+) ([]kv.Key, error) {
+	snap := p.store.GetSnapshot(kv.NewVersion(ts))
+	snap.SetOption(kv.ScanBatchSize, limit)
+	var (
+		iter kv.Iterator
+		err  error
+	)
+	if reverse {
+		iter, err = snap.IterReverse(endKey, startKey)
+	} else {
+		iter, err = snap.Iter(startKey, endKey)
+	}
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	defer iter.Close()
+	keys := make([]kv.Key, 0, limit)
+	for iter.Valid() && len(keys) < limit {
+		if err := ctx.Err(); err != nil {
+			return nil, errors.Trace(err)
+		}
+		keys = append(keys, iter.Key().Clone())
+		if err := iter.Next(); err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	return keys, nil
+}
+
+func (p *storeKeyProber) LatestWriteTS(_ context.Context, key kv.Key) (uint64, error) {
+	// the keys of the snapshot are decoded, encode them with the keyspace prefix for TiKV.
+	resp, err := p.helper.GetMvccByEncodedKey(p.store.GetCodec().EncodeKey(key))
+	if err != nil {
+		return 0, errors.Trace(err)
+	}
+	return LatestWriteTS(resp.GetInfo()), nil
+}
+
+// LatestWriteTS returns the ts of the latest put or delete in the MVCC versions. The rollbacks and locks
+// don't change what the reads see, so they're ignored. The start ts of a pending put or delete is counted,
+// since it's committed after that.
+func LatestWriteTS(info *kvrpcpb.MvccInfo) uint64 {
+	var ts uint64
+	for _, w := range info.GetWrites() {
+		if w.GetType() == kvrpcpb.Op_Put || w.GetType() == kvrpcpb.Op_Del {
+			ts = max(ts, w.GetCommitTs())
+		}
+	}
+	if lock := info.GetLock(); lock != nil && (lock.GetType() == kvrpcpb.Op_Put || lock.GetType() == kvrpcpb.Op_Del) {
+		ts = max(ts, lock.GetStartTs())
+	}
+	return ts
+}
+
+// TSRegressionGuard refuses the restore writing the kvs with a ts lower than the data already visible in
+// the target cluster. Such kvs are shadowed by the newer versions for the reads, and they're collected by
+// GC earlier than expected, e.g. the log restore into the tables written after its RewriteTS is allocated.
+type TSRegressionGuard struct {
+	pdClient        pd.Client
+	prober          KeyProber
+	samplesPerRange int
+	maxRanges       int
+}
+
+// NewTSRegressionGuard creates the guard sampling the keys of the ranges by the prober.
+func NewTSRegressionGuard(pdClient pd.Client, prober KeyProber) *TSRegressionGuard {
+	return &TSRegressionGuard{
+		pdClient:        pdClient,
+		prober:          prober,
+		samplesPerRange: defaultTSGuardSamplesPerRange,
+		maxRanges:       defaultTSGuardMaxRanges,
+	}
+}
+
+// WithMaxRanges sets the number of the ranges sampled at most.
+func (g *TSRegressionGuard) WithMaxRanges(n int) *TSRegressionGuard {
+	g.maxRanges = n
+	return g
+}
+
+// newerKey is an existing key written after the rewrite ts.
+type newerKey struct {
+	key kv.Key
+	ts  uint64
+}
+
+// Check returns ErrRestoreTSRegression if the rewrite ts is after the current ts of the target cluster, or
+// any existing key sampled from both ends of the ranges is written after the rewrite ts. The keys
+// written at the rewrite ts are the ones restored by the previous run of the same restore, so they're
+// allowed. It's a sampling check, the newer keys in the middle of a large range, or in the ranges not
+// sampled when there are more than the max ranges, may be missed.
+func (g *TSRegressionGuard) Check(ctx context.Context, rewriteTS uint64, ranges []kv.KeyRange) error {
+	currentTS, err := GetTSWithRetry(ctx, g.pdClient)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if currentTS < rewriteTS {
+		return errors.Annotatef(berrors.ErrRestoreTSRegression,
+			"the rewrite ts %d(%s) is after the current ts %d(%s) of the target cluster",
+			rewriteTS, oracle.GetTimeFromTS(rewriteTS), currentTS, oracle.GetTimeFromTS(currentTS))
+	}
+
+	sampled := sampleKeyRanges(ranges, g.maxRanges)
+	var (
+		mu    sync.Mutex
+		newer []newerKey
+	)
+	eg, ectx := errgroup.WithContext(ctx)
+	eg.SetLimit(tsGuardConcurrency)
+	for _, rg := range sampled {
+		eg.Go(func() error {
+			keys, err := g.sampleRange(ectx, rg, currentTS)
+			if err != nil {
+				return errors.Trace(err)
+			}
+			for _, key := range keys {
+				ts, err := g.prober.LatestWriteTS(ectx, key)
+				if err != nil {
+					return errors.Trace(err)
+				}
+				if ts > rewriteTS {
+					mu.Lock()
+					newer = append(newer, newerKey{key: key, ts: ts})
+					mu.Unlock()
+				}
+			}
+			return nil
+		})
+	}
+	if err := eg.Wait(); err != nil {
+		return errors.Annotate(err, "failed to check the existing data in the restored ranges")
+	}
+	if len(newer) == 0 {
+		log.Info("no existing data is written after the rewrite ts",
+			zap.Uint64("rewrite-ts", rewriteTS), zap.Uint64("current-ts", currentTS),
+			zap.Int("ranges", len(ranges)), zap.Int("sampled-ranges", len(sampled)))
+		return nil
+	}
+
+	reported := make([]string, 0, maxTSGuardReportedKeys)
+	for _, k := range newer[:min(len(newer), maxTSGuardReportedKeys)] {
+		reported = append(reported, fmt.Sprintf("%s at %d(%s)", redact.Key(k.key), k.ts, oracle.GetTimeFromTS(k.ts)))
+	}
+	return errors.Annotatef(berrors.ErrRestoreTSRegression,
+		"%d sampled keys in the restored ranges are written after the rewrite ts %d(%s), e.g. %s",
+		len(newer), rewriteTS, oracle.GetTimeFromTS(rewriteTS), strings.Join(reported, ", "))
+}
+
+// sampleKeyRanges returns up to n ranges evenly spread over the ranges.
+func sampleKeyRanges(ranges []kv.KeyRange, n int) []kv.KeyRange {
+	if n <= 0 || len(ranges) <= n {
+		return ranges
+	}
+	sampled := make([]kv.KeyRange, 0, n)
+	for i := range n {
+		sampled = append(sampled, ranges[i*len(ranges)/n])
+	}
+	return sampled
+}
+
+// sampleRange returns the keys from both ends of the range.
+func (g *TSRegressionGuard) sampleRange(ctx context.Context, rg kv.KeyRange, ts uint64) ([]kv.Key, error) {
+	head, err := g.prober.SampleKeys(ctx, rg.StartKey, rg.EndKey, ts, g.samplesPerRange, false)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if len(head) < g.samplesPerRange {
+		// all the keys of the range are sampled.
+		return head, nil
+	}
+	tail, err := g.prober.SampleKeys(ctx, rg.StartKey, rg.EndKey, ts, g.samplesPerRange, true)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	last := head[len(head)-1]
+	for _, key := range tail {
+		// skip the keys sampled from both ends of a small range.
+		if key.Cmp(last) > 0 {
+			head = append(head, key)
+		}
+	}
+	return head, nil
+}
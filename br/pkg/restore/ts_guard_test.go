@@ -0,0 +1,112 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package restore_test
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/pingcap/kvproto/pkg/kvrpcpb"
+	berrors "github.com/pingcap/tidb/br/pkg/errors"
+	"github.com/pingcap/tidb/br/pkg/restore"
+	"github.com/pingcap/tidb/br/pkg/restore/split"
+	"github.com/pingcap/tidb/pkg/kv"
+	"github.com/stretchr/testify/require"
+	"github.com/tikv/client-go/v2/oracle"
+)
+
+type fakeKeyProber struct {
+	// keys are the sorted existing keys and their latest write ts.
+	keys    []kv.Key
+	writeTS map[string]uint64
+
+	mu     sync.Mutex
+	probed []string
+}
+
+func (p *fakeKeyProber) SampleKeys(
+	_ context.Context, startKey, endKey kv.Key, _ uint64, limit int, reverse bool,
+) ([]kv.Key, error) {
+	var keys []kv.Key
+	for _, key := range p.keys {
+		if key.Cmp(startKey) >= 0 && key.Cmp(endKey) < 0 {
+			keys = append(keys, key)
+		}
+	}
+	if reverse {
+		slices.Reverse(keys)
+	}
+	return keys[:min(len(keys), limit)], nil
+}
+
+func (p *fakeKeyProber) LatestWriteTS(_ context.Context, key kv.Key) (uint64, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.probed = append(p.probed, string(key))
+	return p.writeTS[string(key)], nil
+}
+
+func TestTSRegressionGuard(t *testing.T) {
+	ctx := context.Background()
+	retryTimes := -1000
+	pdClient := split.NewFakePDClient(nil, false, &retryTimes)
+	currentTS := oracle.ComposeTS(1, 1)
+	rewriteTS := currentTS - 10
+
+	prober := &fakeKeyProber{writeTS: map[string]uint64{}}
+	for i := range 20 {
+		key := kv.Key{'a', byte(i)}
+		prober.keys = append(prober.keys, key)
+		prober.writeTS[string(key)] = rewriteTS - 1
+	}
+	prober.keys = append(prober.keys, kv.Key("b"))
+	// the key restored by the previous run is written at the rewrite ts.
+	prober.writeTS["b"] = rewriteTS
+	guard := restore.NewTSRegressionGuard(pdClient, prober)
+	ranges := []kv.KeyRange{{StartKey: kv.Key("a"), EndKey: kv.Key("b")}, {StartKey: kv.Key("b"), EndKey: kv.Key("c")}}
+	require.NoError(t, guard.Check(ctx, rewriteTS, ranges))
+	// 8 keys from each end of the first range and the only key of the second one.
+	require.Len(t, prober.probed, 17)
+
+	// the keys in the middle of a range aren't sampled.
+	prober.writeTS[string(kv.Key{'a', 10})] = currentTS
+	require.NoError(t, guard.Check(ctx, rewriteTS, ranges))
+	prober.writeTS[string(kv.Key{'a', 19})] = currentTS
+	err := guard.Check(ctx, rewriteTS, ranges)
+	require.True(t, berrors.ErrRestoreTSRegression.Equal(err))
+	require.ErrorContains(t, err, "1 sampled keys in the restored ranges are written after the rewrite ts")
+
+	// the rewrite ts after the current ts is rejected.
+	err = guard.Check(ctx, currentTS+1, nil)
+	require.True(t, berrors.ErrRestoreTSRegression.Equal(err))
+	require.ErrorContains(t, err, "is after the current ts")
+
+	// only the max ranges are sampled.
+	prober.probed = nil
+	ranges = ranges[:0]
+	for i := range 20 {
+		key := kv.Key{'a', byte(i)}
+		ranges = append(ranges, kv.KeyRange{StartKey: key, EndKey: key.PrefixNext()})
+	}
+	require.NoError(t, guard.WithMaxRanges(4).Check(ctx, currentTS, ranges))
+	slices.Sort(prober.probed)
+	require.Equal(t, []string{string(kv.Key{'a', 0}), string(kv.Key{'a', 5}), string(kv.Key{'a', 10}), string(kv.Key{'a', 15})},
+		prober.probed)
+}
+
+func TestLatestWriteTS(t *testing.T) {
+	require.Zero(t, restore.LatestWriteTS(nil))
+	info := &kvrpcpb.MvccInfo{Writes: []*kvrpcpb.MvccWrite{
+		{Type: kvrpcpb.Op_Rollback, StartTs: 50, CommitTs: 50},
+		{Type: kvrpcpb.Op_Lock, StartTs: 40, CommitTs: 45},
+		{Type: kvrpcpb.Op_Put, StartTs: 20, CommitTs: 30},
+		{Type: kvrpcpb.Op_Del, StartTs: 10, CommitTs: 15},
+	}}
+	require.EqualValues(t, 30, restore.LatestWriteTS(info))
+	info.Lock = &kvrpcpb.MvccLock{Type: kvrpcpb.Op_Lock, StartTs: 60}
+	require.EqualValues(t, 30, restore.LatestWriteTS(info))
+	info.Lock = &kvrpcpb.MvccLock{Type: kvrpcpb.Op_Put, StartTs: 60}
+	require.EqualValues(t, 60, restore.LatestWriteTS(info))
+}
@@ -0,0 +1,150 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package restore
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/log"
+	berrors "github.com/pingcap/tidb/br/pkg/errors"
+	"github.com/tikv/client-go/v2/oracle"
+	pd "github.com/tikv/pd/client"
+	"go.uber.org/zap"
+)
+
+// TSProvider provides the ts the restored kvs are rewritten to, e.g. the RewriteTS of the log restore.
+type TSProvider interface {
+	// GetTS returns a ts of the target cluster.
+	GetTS(ctx context.Context) (uint64, error)
+	// String returns the description of the provider used in the logs and errors.
+	String() string
+}
+
+type pdTSProvider struct {
+	pdClient pd.Client
+}
+
+// NewPDTSProvider returns a TSProvider allocating the ts from the PD of the target cluster.
+func NewPDTSProvider(pdClient pd.Client) TSProvider {
+	return &pdTSProvider{pdClient: pdClient}
+}
+
+func (p *pdTSProvider) GetTS(ctx context.Context) (uint64, error) {
+	return GetTSWithRetry(ctx, p.pdClient)
+}
+
+func (*pdTSProvider) String() string {
+	return "pd"
+}
+
+type fixedTSProvider struct {
+	ts uint64
+}
+
+// NewFixedTSProvider returns a TSProvider always returning the given ts, e.g. composed from a fixed
+// physical time.
+func NewFixedTSProvider(ts uint64) TSProvider {
+	return &fixedTSProvider{ts: ts}
+}
+
+func (p *fixedTSProvider) GetTS(context.Context) (uint64, error) {
+	return p.ts, nil
+}
+
+func (p *fixedTSProvider) String() string {
+	return fmt.Sprintf("fixed ts %d", p.ts)
+}
+
+type httpTSProvider struct {
+	url    string
+	client *http.Client
+}
+
+// NewHTTPTSProvider returns a TSProvider getting the ts from an external TSO service, which responds the
+// GET request to the URL with the ts in decimal.
+func NewHTTPTSProvider(url string, client *http.Client) TSProvider {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &httpTSProvider{url: url, client: client}
+}
+
+func (p *httpTSProvider) GetTS(ctx context.Context) (uint64, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.url, nil)
+	if err != nil {
+		return 0, errors.Trace(err)
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return 0, errors.Annotatef(err, "failed to get ts from %s", p.url)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	if err != nil {
+		return 0, errors.Annotatef(err, "failed to get ts from %s", p.url)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return 0, errors.Errorf("failed to get ts from %s, status %s: %s", p.url, resp.Status, strings.TrimSpace(string(body)))
+	}
+	ts, err := strconv.ParseUint(strings.TrimSpace(string(body)), 10, 64)
+	if err != nil {
+		return 0, errors.Annotatef(berrors.ErrInvalidArgument, "invalid ts %q from %s", strings.TrimSpace(string(body)), p.url)
+	}
+	return ts, nil
+}
+
+func (p *httpTSProvider) String() string {
+	return "tso service " + p.url
+}
+
+// GetRewriteTS gets a ts from the provider and checks it against the target cluster. The ts after the current
+// ts is rejected, or the kvs written with it are invisible to the reads until the TSO of the target cluster
+// catches up. The ts not after the GC safepoint or snapshotRestoredTS, the ts the snapshot restored in the
+// same restore is visible at, is rejected too, or the kvs written with it are shadowed by the newer versions
+// or collected by the GC. snapshotRestoredTS is 0 if no snapshot is restored.
+func GetRewriteTS(ctx context.Context, provider TSProvider, pdClient pd.Client, snapshotRestoredTS uint64) (uint64, error) {
+	ts, err := provider.GetTS(ctx)
+	if err != nil {
+		return 0, errors.Trace(err)
+	}
+	if ts == 0 {
+		return 0, errors.Annotatef(berrors.ErrInvalidArgument, "the %s provides the zero ts", provider)
+	}
+	if _, ok := provider.(*pdTSProvider); ok {
+		return ts, nil
+	}
+	currentTS, err := GetTSWithRetry(ctx, pdClient)
+	if err != nil {
+		return 0, errors.Trace(err)
+	}
+	if ts > currentTS {
+		return 0, errors.Annotatef(berrors.ErrInvalidArgument,
+			"the ts %d(%s) provided by the %s is after the current ts %d(%s) of the target cluster",
+			ts, oracle.GetTimeFromTS(ts), provider, currentTS, oracle.GetTimeFromTS(currentTS))
+	}
+	if ts <= snapshotRestoredTS {
+		return 0, errors.Annotatef(berrors.ErrInvalidArgument,
+			"the ts %d(%s) provided by the %s isn't after the ts %d(%s) the snapshot is restored at",
+			ts, oracle.GetTimeFromTS(ts), provider, snapshotRestoredTS, oracle.GetTimeFromTS(snapshotRestoredTS))
+	}
+	// the GC safepoint isn't advanced by updating it to 0.
+	safePoint, err := pdClient.UpdateGCSafePoint(ctx, 0)
+	if err != nil {
+		return 0, errors.Annotate(err, "failed to get the GC safepoint of the target cluster")
+	}
+	if ts <= safePoint {
+		return 0, errors.Annotatef(berrors.ErrInvalidArgument,
+			"the ts %d(%s) provided by the %s isn't after the GC safepoint %d(%s) of the target cluster",
+			ts, oracle.GetTimeFromTS(ts), provider, safePoint, oracle.GetTimeFromTS(safePoint))
+	}
+	log.Info("got the rewrite ts", zap.Stringer("provider", provider), zap.Uint64("ts", ts),
+		zap.Uint64("current-ts", currentTS), zap.Uint64("snapshot-restored-ts", snapshotRestoredTS),
+		zap.Uint64("gc-safepoint", safePoint))
+	return ts, nil
+}
@@ -0,0 +1,70 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package restore_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pingcap/tidb/br/pkg/restore"
+	"github.com/pingcap/tidb/br/pkg/restore/split"
+	"github.com/stretchr/testify/require"
+	"github.com/tikv/client-go/v2/oracle"
+)
+
+type gcSafePointPDClient struct {
+	*split.FakePDClient
+	safePoint uint64
+}
+
+func (c *gcSafePointPDClient) UpdateGCSafePoint(context.Context, uint64) (uint64, error) {
+	return c.safePoint, nil
+}
+
+func TestGetRewriteTS(t *testing.T) {
+	ctx := context.Background()
+	retryTimes := -1000
+	pdClient := &gcSafePointPDClient{FakePDClient: split.NewFakePDClient(nil, false, &retryTimes)}
+	// the fake PD allocates the ts composed of the physical time 1 and the logical time 1.
+	currentTS := oracle.ComposeTS(1, 1)
+
+	ts, err := restore.GetRewriteTS(ctx, restore.NewPDTSProvider(pdClient), pdClient, 0)
+	require.NoError(t, err)
+	require.Equal(t, currentTS, ts)
+
+	ts, err = restore.GetRewriteTS(ctx, restore.NewFixedTSProvider(currentTS-1), pdClient, 0)
+	require.NoError(t, err)
+	require.Equal(t, currentTS-1, ts)
+	_, err = restore.GetRewriteTS(ctx, restore.NewFixedTSProvider(currentTS+1), pdClient, 0)
+	require.ErrorContains(t, err, "is after the current ts")
+	_, err = restore.GetRewriteTS(ctx, restore.NewFixedTSProvider(0), pdClient, 0)
+	require.ErrorContains(t, err, "provides the zero ts")
+	_, err = restore.GetRewriteTS(ctx, restore.NewFixedTSProvider(currentTS-1), pdClient, currentTS-1)
+	require.ErrorContains(t, err, "isn't after the ts")
+	pdClient.safePoint = currentTS - 1
+	_, err = restore.GetRewriteTS(ctx, restore.NewFixedTSProvider(currentTS-1), pdClient, 0)
+	require.ErrorContains(t, err, "isn't after the GC safepoint")
+	pdClient.safePoint = 0
+
+	response := fmt.Sprintf("%d\n", currentTS)
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(response))
+	}))
+	defer server.Close()
+	provider := restore.NewHTTPTSProvider(server.URL, server.Client())
+	ts, err = restore.GetRewriteTS(ctx, provider, pdClient, 0)
+	require.NoError(t, err)
+	require.Equal(t, currentTS, ts)
+
+	response = "not a ts"
+	_, err = restore.GetRewriteTS(ctx, provider, pdClient, 0)
+	require.ErrorContains(t, err, `invalid ts "not a ts"`)
+	status = http.StatusServiceUnavailable
+	_, err = restore.GetRewriteTS(ctx, provider, pdClient, 0)
+	require.ErrorContains(t, err, "503 Service Unavailable")
+}
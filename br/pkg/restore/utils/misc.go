@@ -15,6 +15,8 @@
 package utils
 
 import (
+	"github.com/pingcap/errors"
+	berrors "github.com/pingcap/tidb/br/pkg/errors"
 	"github.com/pingcap/tidb/pkg/meta/model"
 	"github.com/pingcap/tidb/pkg/util/codec"
 )
@@ -51,6 +53,36 @@ func GetTableIDMap(newTable, oldTable *model.TableInfo) map[int64]int64 {
 	return tableIDMap
 }
 
+// GetMergedPartitionIDMap creates a map maping the partitions of the old table to the new table, which is
+// the old table restored as a non-partitioned table by --merge-partitions.
+func GetMergedPartitionIDMap(newTable, oldTable *model.TableInfo) map[int64]int64 {
+	tableIDMap := make(map[int64]int64)
+	if oldTable.Partition != nil {
+		for _, old := range oldTable.Partition.Definitions {
+			tableIDMap[old.ID] = newTable.ID
+		}
+	}
+	return tableIDMap
+}
+
+// CheckPartitionsMergeable checks whether the partitions of the table can be merged into the table. The
+// handles must be unique across the partitions, which is only guaranteed for the clustered primary key
+// including the partition columns, the _tidb_rowid of the partitions may be the same, e.g. a partition
+// exchanged with a table. The rows of the global indexes are keyed by the partition IDs, which are gone
+// after merged.
+func CheckPartitionsMergeable(tableInfo *model.TableInfo) error {
+	if !tableInfo.PKIsHandle && !tableInfo.IsCommonHandle {
+		return errors.Annotate(berrors.ErrRestoreInvalidRewrite,
+			"the table has no clustered primary key, the row IDs of the partitions may be the same")
+	}
+	for _, index := range tableInfo.Indices {
+		if index.Global {
+			return errors.Annotatef(berrors.ErrRestoreInvalidRewrite, "the global index %s can't be merged", index.Name.O)
+		}
+	}
+	return nil
+}
+
 // GetIndexIDMap creates a map maping old indexID to new indexID.
 func GetIndexIDMap(newTable, oldTable *model.TableInfo) map[int64]int64 {
 	indexIDMap := make(map[int64]int64)
@@ -17,7 +17,10 @@ package utils_test
 import (
 	"testing"
 
+	berrors "github.com/pingcap/tidb/br/pkg/errors"
 	"github.com/pingcap/tidb/br/pkg/restore/utils"
+	"github.com/pingcap/tidb/pkg/meta/model"
+	"github.com/pingcap/tidb/pkg/parser/ast"
 	"github.com/stretchr/testify/require"
 )
 
@@ -43,3 +46,19 @@ func TestEncodeKeyPrefix(t *testing.T) {
 	encodeKey = utils.EncodeKeyPrefix(keyPrefix)
 	require.Equal(t, []byte{'1', '2'}, encodeKey)
 }
+
+func TestGetMergedPartitionIDMap(t *testing.T) {
+	oldTable := &model.TableInfo{ID: 100, Partition: &model.PartitionInfo{Definitions: []model.PartitionDefinition{
+		{ID: 101, Name: ast.NewCIStr("p0")}, {ID: 102, Name: ast.NewCIStr("p1")},
+	}}}
+	newTable := &model.TableInfo{ID: 200}
+	// the partitions aren't merged unless asked.
+	require.Empty(t, utils.GetPartitionIDMap(newTable, oldTable))
+	require.Equal(t, map[int64]int64{101: 200, 102: 200}, utils.GetMergedPartitionIDMap(newTable, oldTable))
+
+	require.ErrorIs(t, utils.CheckPartitionsMergeable(oldTable), berrors.ErrRestoreInvalidRewrite)
+	oldTable.PKIsHandle = true
+	require.NoError(t, utils.CheckPartitionsMergeable(oldTable))
+	oldTable.Indices = []*model.IndexInfo{{Name: ast.NewCIStr("idx"), Global: true}}
+	require.ErrorContains(t, utils.CheckPartitionsMergeable(oldTable), "the global index idx can't be merged")
+}
@@ -0,0 +1,277 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package utils
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/pingcap/log"
+	berrors "github.com/pingcap/tidb/br/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// RestorePhase is a phase of restoring the SST files.
+type RestorePhase string
+
+const (
+	PhaseSplit    RestorePhase = "split"
+	PhaseScatter  RestorePhase = "scatter"
+	PhaseDownload RestorePhase = "download"
+	PhaseIngest   RestorePhase = "ingest"
+)
+
+// PhaseTimeouts are the timeouts of the restore phases. A phase is stalled once it has pending requests
+// but no request finishes in the timeout, zero disables the detection of the phase.
+type PhaseTimeouts struct {
+	Split    time.Duration
+	Scatter  time.Duration
+	Download time.Duration
+	Ingest   time.Duration
+}
+
+const (
+	maxDiagnosedRegions  = 64
+	maxDiagnosedRequests = 16
+	diagnoseStoreTimeout = 10 * time.Second
+	maxWatchInterval     = 10 * time.Second
+)
+
+// StoresGetter gets the stores of the cluster to diagnose the stall.
+type StoresGetter func(ctx context.Context) ([]*metapb.Store, error)
+
+type pendingRequest struct {
+	regionID uint64
+	storeID  uint64
+	start    time.Time
+}
+
+// PhaseWatchdog detects the stall of a restore phase. The requests of the phase are tracked by Begin,
+// the time without any pending request isn't counted. Once the phase stalls, the watchdog dumps the
+// pending regions, the slowest requests and the stores, and cancels the phase with the diagnosis.
+// A nil PhaseWatchdog is valid and detects nothing.
+type PhaseWatchdog struct {
+	phase   RestorePhase
+	timeout time.Duration
+	stores  StoresGetter
+
+	parent context.Context
+	ctx    context.Context
+	cancel context.CancelCauseFunc
+	done   chan struct{}
+
+	mu         sync.Mutex
+	stallSince time.Time
+	idleSince  time.Time
+	nextID     uint64
+	pending    map[uint64]pendingRequest
+	err        error
+}
+
+// StartPhaseWatchdog starts to watch the phase. The returned context is canceled with the diagnosis
+// once the phase stalls, the watchdog must be stopped by Stop. If the timeout is zero, the context is
+// returned as is with a nil watchdog.
+func StartPhaseWatchdog(
+	ctx context.Context,
+	phase RestorePhase,
+	timeout time.Duration,
+	stores StoresGetter,
+) (context.Context, *PhaseWatchdog) {
+	if timeout <= 0 {
+		return ctx, nil
+	}
+	now := time.Now()
+	w := &PhaseWatchdog{
+		phase:      phase,
+		timeout:    timeout,
+		stores:     stores,
+		parent:     ctx,
+		done:       make(chan struct{}),
+		stallSince: now,
+		idleSince:  now,
+		pending:    make(map[uint64]pendingRequest),
+	}
+	w.ctx, w.cancel = context.WithCancelCause(ctx)
+	go w.watch()
+	return w.ctx, w
+}
+
+// Begin tracks a request of the phase to the region on the store, the returned function must be called
+// once the request returns, a succeeded request is the progress of the phase.
+func (w *PhaseWatchdog) Begin(regionID, storeID uint64) func(succeeded bool) {
+	if w == nil {
+		return func(bool) {}
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	now := time.Now()
+	if len(w.pending) == 0 {
+		w.stallSince = w.stallSince.Add(now.Sub(w.idleSince))
+	}
+	id := w.nextID
+	w.nextID++
+	w.pending[id] = pendingRequest{regionID: regionID, storeID: storeID, start: now}
+	return func(succeeded bool) {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		if _, ok := w.pending[id]; !ok {
+			return
+		}
+		delete(w.pending, id)
+		now := time.Now()
+		if succeeded {
+			w.stallSince = now
+		}
+		if len(w.pending) == 0 {
+			w.idleSince = now
+		}
+	}
+}
+
+// Context returns a context derived from ctx which is also canceled once the phase stalls, it's for
+// the requests whose context isn't derived from the one of StartPhaseWatchdog.
+func (w *PhaseWatchdog) Context(ctx context.Context) (context.Context, context.CancelFunc) {
+	if w == nil {
+		return ctx, func() {}
+	}
+	cctx, cancel := context.WithCancelCause(ctx)
+	stop := context.AfterFunc(w.ctx, func() {
+		if err := w.Err(); err != nil {
+			cancel(err)
+		}
+	})
+	return cctx, func() {
+		stop()
+		cancel(nil)
+	}
+}
+
+// Err returns the diagnosis if the phase has stalled.
+func (w *PhaseWatchdog) Err() error {
+	if w == nil {
+		return nil
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.err
+}
+
+// Stop stops watching the phase and returns the diagnosis if the phase has stalled.
+func (w *PhaseWatchdog) Stop() error {
+	if w == nil {
+		return nil
+	}
+	w.cancel(nil)
+	<-w.done
+	return w.Err()
+}
+
+func (w *PhaseWatchdog) watch() {
+	defer close(w.done)
+	interval := min(w.timeout/4, maxWatchInterval)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-w.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		pending, stalled := w.checkStall(time.Now())
+		if !stalled {
+			continue
+		}
+		err := w.diagnose(pending)
+		w.mu.Lock()
+		w.err = err
+		w.mu.Unlock()
+		w.cancel(err)
+		return
+	}
+}
+
+// checkStall returns the pending requests if the phase has stalled.
+func (w *PhaseWatchdog) checkStall(now time.Time) ([]pendingRequest, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.pending) == 0 || now.Sub(w.stallSince) < w.timeout {
+		return nil, false
+	}
+	pending := make([]pendingRequest, 0, len(w.pending))
+	for _, req := range w.pending {
+		pending = append(pending, req)
+	}
+	slices.SortFunc(pending, func(a, b pendingRequest) int {
+		return a.start.Compare(b.start)
+	})
+	return pending, true
+}
+
+// diagnose builds the error of the stall from the pending requests sorted by the start time.
+func (w *PhaseWatchdog) diagnose(pending []pendingRequest) error {
+	now := time.Now()
+	regionIDs := make([]uint64, 0, len(pending))
+	pendingOnStore := make(map[uint64]int)
+	for _, req := range pending {
+		regionIDs = append(regionIDs, req.regionID)
+		pendingOnStore[req.storeID]++
+	}
+	slices.Sort(regionIDs)
+	regionIDs = slices.Compact(regionIDs)
+	regions := make([]string, 0, min(len(regionIDs), maxDiagnosedRegions)+1)
+	for _, id := range regionIDs[:min(len(regionIDs), maxDiagnosedRegions)] {
+		regions = append(regions, fmt.Sprint(id))
+	}
+	if len(regionIDs) > maxDiagnosedRegions {
+		regions = append(regions, fmt.Sprintf("...(%d more)", len(regionIDs)-maxDiagnosedRegions))
+	}
+	slowRequests := make([]string, 0, min(len(pending), maxDiagnosedRequests))
+	for _, req := range pending[:min(len(pending), maxDiagnosedRequests)] {
+		slowRequests = append(slowRequests, fmt.Sprintf("region %d on store %d for %s",
+			req.regionID, req.storeID, now.Sub(req.start).Round(time.Second)))
+	}
+	storeStatuses := w.diagnoseStores(pendingOnStore)
+
+	log.Error("restore phase stalled",
+		zap.String("phase", string(w.phase)),
+		zap.Duration("timeout", w.timeout),
+		zap.Int("pending-requests", len(pending)),
+		zap.Strings("pending-regions", regions),
+		zap.Strings("slow-requests", slowRequests),
+		zap.Strings("stores", storeStatuses))
+	return errors.Annotatef(berrors.ErrRestorePhaseStalled,
+		"no %s request finished in %s with %d requests pending; pending regions: [%s]; slowest requests: [%s]; "+
+			"stores: [%s]; please check the stores and the regions above, or raise the %s timeout if the cluster is just slow",
+		w.phase, w.timeout, len(pending), strings.Join(regions, ", "), strings.Join(slowRequests, "; "),
+		strings.Join(storeStatuses, "; "), w.phase)
+}
+
+// diagnoseStores returns the statuses of the stores having pending requests or not being up.
+func (w *PhaseWatchdog) diagnoseStores(pendingOnStore map[uint64]int) []string {
+	if w.stores == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(w.parent, diagnoseStoreTimeout)
+	defer cancel()
+	stores, err := w.stores(ctx)
+	if err != nil {
+		log.Warn("failed to get the stores to diagnose the stall", zap.Error(err))
+		return []string{fmt.Sprintf("unknown: %s", err)}
+	}
+	statuses := make([]string, 0, len(stores))
+	for _, store := range stores {
+		cnt := pendingOnStore[store.GetId()]
+		if cnt == 0 && store.GetState() == metapb.StoreState_Up {
+			continue
+		}
+		statuses = append(statuses, fmt.Sprintf("store %d at %s is %s with %d pending requests",
+			store.GetId(), store.GetAddress(), store.GetState(), cnt))
+	}
+	return statuses
+}
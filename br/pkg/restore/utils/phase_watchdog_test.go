@@ -0,0 +1,76 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package utils_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pingcap/kvproto/pkg/metapb"
+	berrors "github.com/pingcap/tidb/br/pkg/errors"
+	restoreutils "github.com/pingcap/tidb/br/pkg/restore/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPhaseWatchdogStall(t *testing.T) {
+	stores := func(context.Context) ([]*metapb.Store, error) {
+		return []*metapb.Store{
+			{Id: 1, Address: "tikv-1:20160", State: metapb.StoreState_Up},
+			{Id: 2, Address: "tikv-2:20160", State: metapb.StoreState_Offline},
+			{Id: 3, Address: "tikv-3:20160", State: metapb.StoreState_Up},
+		}, nil
+	}
+	ctx, w := restoreutils.StartPhaseWatchdog(context.Background(), restoreutils.PhaseDownload, 100*time.Millisecond, stores)
+	// the time without pending requests isn't counted.
+	time.Sleep(200 * time.Millisecond)
+	require.NoError(t, w.Err())
+
+	reqCtx, cancel := w.Context(context.Background())
+	defer cancel()
+	w.Begin(10, 1)
+	w.Begin(11, 1)
+	<-ctx.Done()
+	<-reqCtx.Done()
+	err := w.Stop()
+	require.True(t, berrors.Is(err, berrors.ErrRestorePhaseStalled))
+	require.Equal(t, err, context.Cause(ctx))
+	require.Equal(t, err, context.Cause(reqCtx))
+	require.ErrorContains(t, err, "pending regions: [10, 11]")
+	require.ErrorContains(t, err, "region 10 on store 1")
+	require.ErrorContains(t, err, "store 1 at tikv-1:20160 is Up with 2 pending requests")
+	require.ErrorContains(t, err, "store 2 at tikv-2:20160 is Offline with 0 pending requests")
+	require.NotContains(t, err.Error(), "tikv-3")
+}
+
+func TestPhaseWatchdogProgress(t *testing.T) {
+	ctx, w := restoreutils.StartPhaseWatchdog(context.Background(), restoreutils.PhaseIngest, 100*time.Millisecond, nil)
+	// a slow request doesn't stall the phase as long as the others finish.
+	w.Begin(1, 1)
+	for i := 0; i < 10; i++ {
+		done := w.Begin(uint64(i+2), 1)
+		time.Sleep(30 * time.Millisecond)
+		done(true)
+	}
+	require.NoError(t, ctx.Err())
+	require.NoError(t, w.Stop())
+
+	// the failed requests aren't the progress.
+	ctx, w = restoreutils.StartPhaseWatchdog(context.Background(), restoreutils.PhaseIngest, 100*time.Millisecond, nil)
+	w.Begin(1, 1)
+	for ctx.Err() == nil {
+		done := w.Begin(2, 1)
+		time.Sleep(10 * time.Millisecond)
+		done(false)
+	}
+	require.ErrorContains(t, w.Stop(), "no ingest request finished in 100ms")
+
+	// a nil watchdog detects nothing.
+	ctx, w = restoreutils.StartPhaseWatchdog(context.Background(), restoreutils.PhaseSplit, 0, nil)
+	require.Nil(t, w)
+	w.Begin(1, 1)(false)
+	reqCtx, cancel := w.Context(ctx)
+	cancel()
+	require.NoError(t, reqCtx.Err())
+	require.NoError(t, w.Stop())
+}
@@ -0,0 +1,123 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package utils
+
+import (
+	"bytes"
+
+	"github.com/pingcap/kvproto/pkg/import_sstpb"
+)
+
+// The memcomparable encoding of codec.EncodeBytes splits the raw key into groups of 8 bytes, each
+// group is followed by a marker, which is 0xFF minus the count of the padding bytes of the group.
+// So the ith raw byte is always at i+i/8 of the encoded key, and a prefix can be patched in place
+// if it's replaced by another one of the same length.
+const (
+	encGroupSize = 8
+	encMarker    = byte(0xFF)
+)
+
+func encodedPos(i int) int {
+	return i + i/encGroupSize
+}
+
+// encodedHasPrefix checks whether the raw key of the encoded key has the prefix, without decoding it.
+func encodedHasPrefix(encoded, prefix []byte) bool {
+	for i := 0; i < len(prefix); i += encGroupSize {
+		start := encodedPos(i)
+		markerPos := start + encGroupSize
+		if markerPos >= len(encoded) {
+			return false
+		}
+		n := min(encGroupSize, len(prefix)-i)
+		// the padding bytes of the last group aren't part of the raw key.
+		if encGroupSize-int(encMarker-encoded[markerPos]) < n {
+			return false
+		}
+		if !bytes.Equal(encoded[start:start+n], prefix[i:i+n]) {
+			return false
+		}
+	}
+	return true
+}
+
+// PrefixDelta is a rewrite rule whose old and new key prefixes are of the same length, e.g. the rule
+// rewriting the table ID only. It's encoded as the bytes differing between the prefixes at their
+// positions in the encoded key, so rewriting an encoded key only patches these bytes, instead of
+// decoding the key, replacing the prefix and encoding it again.
+type PrefixDelta struct {
+	rule      *import_sstpb.RewriteRule
+	positions []int
+	values    []byte
+}
+
+// NewPrefixDelta creates the delta of the rule, false is returned if the lengths of the prefixes differ.
+func NewPrefixDelta(rule *import_sstpb.RewriteRule) (*PrefixDelta, bool) {
+	d := &PrefixDelta{}
+	if !d.Reset(rule) {
+		return nil, false
+	}
+	return d, true
+}
+
+// Reset makes the delta of the rule reusing the buffers of the delta, false is returned if the lengths of
+// the prefixes differ.
+func (d *PrefixDelta) Reset(rule *import_sstpb.RewriteRule) bool {
+	oldPrefix, newPrefix := rule.GetOldKeyPrefix(), rule.GetNewKeyPrefix()
+	if len(oldPrefix) != len(newPrefix) {
+		return false
+	}
+	d.rule, d.positions, d.values = rule, d.positions[:0], d.values[:0]
+	for i := range oldPrefix {
+		if oldPrefix[i] != newPrefix[i] {
+			d.positions = append(d.positions, encodedPos(i))
+			d.values = append(d.values, newPrefix[i])
+		}
+	}
+	return true
+}
+
+// Rule returns the rewrite rule of the delta.
+func (d *PrefixDelta) Rule() *import_sstpb.RewriteRule {
+	return d.rule
+}
+
+// Match checks whether the encoded key has the old prefix.
+func (d *PrefixDelta) Match(encoded []byte) bool {
+	return encodedHasPrefix(encoded, d.rule.GetOldKeyPrefix())
+}
+
+// RewriteInPlace replaces the old prefix of the encoded key with the new one in place, the key must
+// match the delta.
+func (d *PrefixDelta) RewriteInPlace(encoded []byte) {
+	for i, pos := range d.positions {
+		encoded[pos] = d.values[i]
+	}
+}
+
+// rewriteEncodedPrefix replaces the old prefix of the encoded key with the new one of the same length in
+// place, like PrefixDelta.RewriteInPlace but without building the delta.
+func rewriteEncodedPrefix(encoded, oldPrefix, newPrefix []byte) {
+	for i := range oldPrefix {
+		if oldPrefix[i] != newPrefix[i] {
+			encoded[encodedPos(i)] = newPrefix[i]
+		}
+	}
+}
+
+// rewriteEncodedKeyByDelta rewrites the encoded key without decoding it if the first rule matching it
+// has prefixes of the same length. false is returned if the key must be rewritten by decoding it.
+func rewriteEncodedKeyByDelta(key []byte, rewriteRules *RewriteRules) ([]byte, *import_sstpb.RewriteRule, bool) {
+	for _, rule := range rewriteRules.Data {
+		if !encodedHasPrefix(key, rule.GetOldKeyPrefix()) {
+			continue
+		}
+		if len(rule.GetOldKeyPrefix()) != len(rule.GetNewKeyPrefix()) {
+			return nil, nil, false
+		}
+		ret := bytes.Clone(key)
+		rewriteEncodedPrefix(ret, rule.GetOldKeyPrefix(), rule.GetNewKeyPrefix())
+		return ret, rule, true
+	}
+	return nil, nil, false
+}
@@ -0,0 +1,126 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package utils_test
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	backuppb "github.com/pingcap/kvproto/pkg/brpb"
+	"github.com/pingcap/kvproto/pkg/import_sstpb"
+	"github.com/pingcap/tidb/br/pkg/restore/utils"
+	"github.com/pingcap/tidb/pkg/kv"
+	"github.com/pingcap/tidb/pkg/tablecodec"
+	"github.com/pingcap/tidb/pkg/util/codec"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrefixDelta(t *testing.T) {
+	rules := []*import_sstpb.RewriteRule{
+		{OldKeyPrefix: tablecodec.GenTablePrefix(767), NewKeyPrefix: tablecodec.GenTablePrefix(511)},
+		{OldKeyPrefix: tablecodec.GenTableRecordPrefix(1), NewKeyPrefix: tablecodec.GenTableRecordPrefix(1 << 40)},
+		{OldKeyPrefix: tablecodec.EncodeTableIndexPrefix(3, 1), NewKeyPrefix: tablecodec.EncodeTableIndexPrefix(4, 2)},
+		{OldKeyPrefix: []byte("abcdefgh"), NewKeyPrefix: []byte("ABCDEFGH")},
+	}
+	suffixes := [][]byte{nil, []byte("1"), []byte("1234567"), []byte("12345678"), []byte("the suffix of a row key")}
+	for _, rule := range rules {
+		d, ok := utils.NewPrefixDelta(rule)
+		require.True(t, ok)
+		require.Equal(t, rule, d.Rule())
+		for _, suffix := range suffixes {
+			raw := append(append([]byte{}, rule.OldKeyPrefix...), suffix...)
+			encoded := codec.EncodeBytes(nil, raw)
+			require.True(t, d.Match(encoded), "%X", raw)
+
+			expected := codec.EncodeBytes(nil, append(append([]byte{}, rule.NewKeyPrefix...), suffix...))
+			d.RewriteInPlace(encoded)
+			require.Equal(t, expected, encoded)
+			require.Equal(t, expected, utils.RewriteAndEncodeRawKey(raw, rule))
+		}
+		// the prefix is longer than the raw key, the padding bytes mustn't match.
+		short := codec.EncodeBytes(nil, rule.OldKeyPrefix[:len(rule.OldKeyPrefix)-1])
+		require.False(t, d.Match(short))
+		require.False(t, d.Match(codec.EncodeBytes(nil, rule.NewKeyPrefix)))
+	}
+
+	// the delta is reused for the rules without allocating.
+	var reused utils.PrefixDelta
+	require.True(t, reused.Reset(rules[3]))
+	allocs := testing.AllocsPerRun(10, func() {
+		for _, rule := range rules {
+			require.True(t, reused.Reset(rule))
+		}
+	})
+	require.Zero(t, allocs)
+	require.Equal(t, rules[3], reused.Rule())
+	encoded := codec.EncodeBytes(nil, []byte("abcdefgh1"))
+	reused.RewriteInPlace(encoded)
+	require.Equal(t, codec.EncodeBytes(nil, []byte("ABCDEFGH1")), encoded)
+
+	_, ok := utils.NewPrefixDelta(&import_sstpb.RewriteRule{
+		OldKeyPrefix: []byte("t1"),
+		NewKeyPrefix: []byte("t10"),
+	})
+	require.False(t, ok)
+}
+
+func TestRewriteEncodedKeysWithDifferentLengths(t *testing.T) {
+	rewriteRules := utils.RewriteRules{
+		Data: []*import_sstpb.RewriteRule{
+			// the first matched rule is used even if the later one can be patched in place.
+			{OldKeyPrefix: tablecodec.GenTablePrefix(1), NewKeyPrefix: tablecodec.GenTableRecordPrefix(2)},
+			{OldKeyPrefix: tablecodec.GenTableRecordPrefix(1), NewKeyPrefix: tablecodec.GenTableRecordPrefix(3)},
+		},
+	}
+	file := &backuppb.DataFileInfo{
+		Path:     "backup.log",
+		StartKey: codec.EncodeBytes(nil, tablecodec.EncodeRowKeyWithHandle(1, kv.IntHandle(1))),
+		EndKey:   codec.EncodeBytes(nil, tablecodec.EncodeRowKeyWithHandle(1, kv.IntHandle(2))),
+	}
+	start, end, err := utils.GetRewriteEncodedKeys(file, &rewriteRules)
+	require.NoError(t, err)
+	expectedPrefix := append(tablecodec.GenTableRecordPrefix(2), tablecodec.GenTableRecordPrefix(1)[len(tablecodec.GenTablePrefix(1)):]...)
+	require.Equal(t, codec.EncodeBytes(nil, append(append([]byte{}, expectedPrefix...), kv.IntHandle(1).Encoded()...)), start)
+	require.Equal(t, codec.EncodeBytes(nil, append(append([]byte{}, expectedPrefix...), kv.IntHandle(2).Encoded()...)), end)
+}
+
+func BenchmarkRewriteEncodedKey(b *testing.B) {
+	rule := &import_sstpb.RewriteRule{
+		OldKeyPrefix: tablecodec.GenTableRecordPrefix(100),
+		NewKeyPrefix: tablecodec.GenTableRecordPrefix(200),
+	}
+	// the row key of a wide table with a clustered index of long strings.
+	handle := bytes.Repeat([]byte("wide"), 64)
+	keys := make([][]byte, 0, 1024)
+	for i := range 1024 {
+		raw := append(tablecodec.GenTableRecordPrefix(100), fmt.Appendf(handle, "%d", i)...)
+		keys = append(keys, codec.EncodeBytes(nil, raw))
+	}
+
+	b.Run("decode", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			key := keys[i%len(keys)]
+			_, raw, err := codec.DecodeBytes(key, nil)
+			if err != nil {
+				b.Fatal(err)
+			}
+			raw = bytes.Replace(raw, rule.OldKeyPrefix, rule.NewKeyPrefix, 1)
+			_ = codec.EncodeBytes(nil, raw)
+		}
+	})
+	b.Run("delta", func(b *testing.B) {
+		b.ReportAllocs()
+		d, _ := utils.NewPrefixDelta(rule)
+		buf := make([]byte, 0, len(keys[0])+16)
+		for i := 0; i < b.N; i++ {
+			key := keys[i%len(keys)]
+			if !d.Match(key) {
+				b.Fatal("not matched")
+			}
+			buf = append(buf[:0], key...)
+			d.RewriteInPlace(buf)
+		}
+	})
+}
@@ -16,6 +16,7 @@ package utils
 
 import (
 	"bytes"
+	"slices"
 	"strings"
 
 	"github.com/pingcap/errors"
@@ -73,9 +74,20 @@ func EmptyRewriteRule() *RewriteRules {
 func GetRewriteRules(
 	newTable, oldTable *model.TableInfo, newTimeStamp uint64, getDetailRule bool,
 ) *RewriteRules {
-	tableIDs := GetTableIDMap(newTable, oldTable)
-	indexIDs := GetIndexIDMap(newTable, oldTable)
+	return getRewriteRules(GetTableIDMap(newTable, oldTable), GetIndexIDMap(newTable, oldTable), newTimeStamp, getDetailRule)
+}
 
+// GetMergedRewriteRules returns the rewrite rule of the new table and the old table, whose partitions are
+// merged into the new table by --merge-partitions.
+func GetMergedRewriteRules(
+	newTable, oldTable *model.TableInfo, newTimeStamp uint64, getDetailRule bool,
+) *RewriteRules {
+	tableIDs := GetMergedPartitionIDMap(newTable, oldTable)
+	tableIDs[oldTable.ID] = newTable.ID
+	return getRewriteRules(tableIDs, GetIndexIDMap(newTable, oldTable), newTimeStamp, getDetailRule)
+}
+
+func getRewriteRules(tableIDs, indexIDs map[int64]int64, newTimeStamp uint64, getDetailRule bool) *RewriteRules {
 	dataRules := make([]*import_sstpb.RewriteRule, 0)
 	for oldTableID, newTableID := range tableIDs {
 		if getDetailRule {
@@ -177,6 +189,54 @@ func GetRewriteRuleOfTable(
 	return &RewriteRules{Data: dataRules, NewTableID: newTableID}
 }
 
+// SortedDataRules returns the data rules of all the tables, sorted by the old key prefix, so the rules sent
+// to TiKV import are in a deterministic order.
+func SortedDataRules(rules map[int64]*RewriteRules) []*import_sstpb.RewriteRule {
+	dataRules := make([]*import_sstpb.RewriteRule, 0, len(rules))
+	for _, r := range rules {
+		dataRules = append(dataRules, r.Data...)
+	}
+	slices.SortFunc(dataRules, func(a, b *import_sstpb.RewriteRule) int {
+		return bytes.Compare(a.OldKeyPrefix, b.OldKeyPrefix)
+	})
+	return dataRules
+}
+
+// ValidateRewriteRules checks the data rules of all the tables don't overlap, i.e. no key is matched by
+// two rules, and no two rules rewrite the keys to the same prefix.
+func ValidateRewriteRules(rules map[int64]*RewriteRules) error {
+	dataRules := SortedDataRules(rules)
+	// the prefixes overlapping each other are adjacent after sorted.
+	for i := 1; i < len(dataRules); i++ {
+		prev, cur := dataRules[i-1], dataRules[i]
+		if bytes.HasPrefix(cur.OldKeyPrefix, prev.OldKeyPrefix) {
+			return errors.Annotatef(berrors.ErrRestoreInvalidRewrite, "the old key prefix %s overlaps %s",
+				redact.Key(cur.OldKeyPrefix), redact.Key(prev.OldKeyPrefix))
+		}
+	}
+	slices.SortFunc(dataRules, func(a, b *import_sstpb.RewriteRule) int {
+		return bytes.Compare(a.NewKeyPrefix, b.NewKeyPrefix)
+	})
+	for i := 1; i < len(dataRules); i++ {
+		prev, cur := dataRules[i-1], dataRules[i]
+		if bytes.HasPrefix(cur.NewKeyPrefix, prev.NewKeyPrefix) {
+			return errors.Annotatef(berrors.ErrRestoreInvalidRewrite,
+				"the old key prefixes %s and %s are both rewritten to %s", redact.Key(prev.OldKeyPrefix),
+				redact.Key(cur.OldKeyPrefix), redact.Key(prev.NewKeyPrefix))
+		}
+	}
+	return nil
+}
+
+// EncodeRewriteRule returns the rule with the encoded key prefixes, which is how TiKV import rewrites the
+// keys of the kv files of the log backup.
+func EncodeRewriteRule(rule *import_sstpb.RewriteRule) *import_sstpb.RewriteRule {
+	return &import_sstpb.RewriteRule{
+		OldKeyPrefix: EncodeKeyPrefix(rule.GetOldKeyPrefix()),
+		NewKeyPrefix: EncodeKeyPrefix(rule.GetNewKeyPrefix()),
+	}
+}
+
 // ValidateFileRewriteRule uses rewrite rules to validate the ranges of a file.
 func ValidateFileRewriteRule(file *backuppb.File, rewriteRules *RewriteRules) error {
 	// Check if the start key has a matched rewrite key
@@ -228,6 +288,9 @@ func rewriteEncodedKey(key []byte, rewriteRules *RewriteRules) ([]byte, *import_
 		return key, nil
 	}
 	if len(key) > 0 {
+		if ret, rule, ok := rewriteEncodedKeyByDelta(key, rewriteRules); ok {
+			return ret, rule
+		}
 		_, rawKey, _ := codec.DecodeBytes(key, nil)
 		return rewriteRawKey(rawKey, rewriteRules)
 	}
@@ -247,6 +310,13 @@ func rewriteRawKey(key []byte, rewriteRules *RewriteRules) ([]byte, *import_sstp
 }
 
 func RewriteAndEncodeRawKey(key []byte, rule *import_sstpb.RewriteRule) []byte {
+	oldPrefix, newPrefix := rule.GetOldKeyPrefix(), rule.GetNewKeyPrefix()
+	if len(oldPrefix) == len(newPrefix) && bytes.HasPrefix(key, oldPrefix) {
+		// patch the encoded key to avoid copying the raw key.
+		ret := codec.EncodeBytes([]byte{}, key)
+		rewriteEncodedPrefix(ret, oldPrefix, newPrefix)
+		return ret
+	}
 	ret := bytes.Replace(key, rule.GetOldKeyPrefix(), rule.GetNewKeyPrefix(), 1)
 	return codec.EncodeBytes([]byte{}, ret)
 }
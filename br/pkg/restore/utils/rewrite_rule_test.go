@@ -410,6 +410,48 @@ func TestGetRewriteRuleOfTable(t *testing.T) {
 	}
 }
 
+func TestValidateRewriteRules(t *testing.T) {
+	rules := map[int64]*utils.RewriteRules{
+		1: utils.GetRewriteRuleOfTable(1, 11, 0, nil, false),
+		2: utils.GetRewriteRuleOfTable(2, 12, 0, nil, false),
+	}
+	require.NoError(t, utils.ValidateRewriteRules(rules))
+
+	// the detail rules of the table 1 overlap its table prefix rule.
+	overlapped := map[int64]*utils.RewriteRules{
+		1: utils.GetRewriteRuleOfTable(1, 11, 0, nil, false),
+		3: utils.GetRewriteRuleOfTable(1, 13, 0, nil, true),
+	}
+	require.ErrorIs(t, utils.ValidateRewriteRules(overlapped), berrors.ErrRestoreInvalidRewrite)
+
+	// the tables 1 and 2 are both rewritten to the table 11.
+	merged := map[int64]*utils.RewriteRules{
+		1: utils.GetRewriteRuleOfTable(1, 11, 0, nil, false),
+		2: utils.GetRewriteRuleOfTable(2, 11, 0, nil, false),
+	}
+	require.ErrorIs(t, utils.ValidateRewriteRules(merged), berrors.ErrRestoreInvalidRewrite)
+}
+
+func TestEncodeRewriteRule(t *testing.T) {
+	rule := utils.EncodeRewriteRule(&import_sstpb.RewriteRule{
+		OldKeyPrefix: tablecodec.EncodeTablePrefix(1),
+		NewKeyPrefix: tablecodec.EncodeTablePrefix(11),
+		NewTimestamp: 42,
+	})
+	// the encoded prefixes match the encoded keys of the tables.
+	require.True(t, bytes.HasPrefix(codec.EncodeBytes(nil, tablecodec.EncodeRowKeyWithHandle(1, kv.IntHandle(1))),
+		rule.OldKeyPrefix))
+	require.True(t, bytes.HasPrefix(codec.EncodeBytes(nil, tablecodec.EncodeRowKeyWithHandle(11, kv.IntHandle(1))),
+		rule.NewKeyPrefix))
+	require.Zero(t, rule.NewTimestamp)
+
+	data, err := rule.Marshal()
+	require.NoError(t, err)
+	decoded := &import_sstpb.RewriteRule{}
+	require.NoError(t, decoded.Unmarshal(data))
+	require.Equal(t, rule, decoded)
+}
+
 type fakeApplyFile struct {
 	StartKey []byte
 	EndKey   []byte
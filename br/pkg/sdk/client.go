@@ -0,0 +1,229 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+// Package sdk is the Go API to run the backup and restore tasks of BR inside the current process, so
+// the platforms can embed BR into their own controllers instead of running the br binary.
+//
+// A process can run only one task at a time, since the tasks share the global configurations of TiDB.
+// The tasks called concurrently are run one by one.
+package sdk
+
+import (
+	"context"
+	"sync"
+
+	"github.com/pingcap/errors"
+	berrors "github.com/pingcap/tidb/br/pkg/errors"
+	"github.com/pingcap/tidb/br/pkg/glue"
+	"github.com/pingcap/tidb/br/pkg/gluetidb"
+	"github.com/pingcap/tidb/br/pkg/storage"
+	"github.com/pingcap/tidb/br/pkg/task"
+	"github.com/pingcap/tidb/pkg/config"
+	filter "github.com/pingcap/tidb/pkg/util/table-filter"
+)
+
+// taskMu serializes the tasks in the process.
+var taskMu sync.Mutex
+
+// Config is the configuration of the client.
+type Config struct {
+	// PD is the addresses of the PD servers of the cluster.
+	PD []string
+	// CA, Cert and Key are the paths of the TLS files to connect to the cluster, TLS is disabled if
+	// they are empty.
+	CA   string
+	Cert string
+	Key  string
+}
+
+// BackupRequest is the request of a snapshot backup.
+type BackupRequest struct {
+	// Storage is the URL of the external storage to save the backup, e.g. "s3://bucket/prefix".
+	Storage string
+	// Filter is the table filters of the tables to back up, e.g. "db.*". The user tables are backed
+	// up if it's empty.
+	Filter []string
+	// BackupTS is the TSO of the snapshot to back up, the current TSO is used if it's 0.
+	BackupTS uint64
+	// LastBackupTS is the backup TS of the last backup, a non-zero value makes an incremental backup.
+	LastBackupTS uint64
+	// RateLimit is the rate limit of each TiKV in bytes per second, 0 means unlimited.
+	RateLimit uint64
+	// Concurrency is the concurrency of the backup, the default one is used if it's 0.
+	Concurrency uint32
+	// Progress receives the progress of the task if it isn't nil, it must be received concurrently.
+	Progress chan<- Progress
+}
+
+// RestoreRequest is the request of a snapshot restore.
+type RestoreRequest struct {
+	// Storage is the URL of the external storage of the backup.
+	Storage string
+	// Filter is the table filters of the tables to restore, all the tables in the backup are restored
+	// if it's empty.
+	Filter []string
+	// Concurrency is the concurrency of the restore, the default one is used if it's 0.
+	Concurrency uint32
+	// Progress receives the progress of the task if it isn't nil, it must be received concurrently.
+	Progress chan<- Progress
+}
+
+// RestorePointRequest is the request of a point in time restore.
+type RestorePointRequest struct {
+	// Storage is the URL of the external storage of the log backup.
+	Storage string
+	// FullBackupStorage is the URL of the external storage of the snapshot backup restored before
+	// the log backup. It's mutually exclusive with StartTS.
+	FullBackupStorage string
+	// StartTS is the TSO to start restoring the log backup from, used when the snapshot has been
+	// restored already.
+	StartTS uint64
+	// RestoreTS is the TSO to restore to, the max TS of the log backup is used if it's 0.
+	RestoreTS uint64
+	// Filter is the table filters of the tables to restore, all the tables are restored if it's empty.
+	Filter []string
+	// Progress receives the progress of the task if it isn't nil, it must be received concurrently.
+	Progress chan<- Progress
+}
+
+// Result is the result of a finished task.
+type Result struct {
+	// BackupTS is the TSO of the snapshot backed up or restored.
+	BackupTS uint64
+	// RestoreTS is the TSO of the snapshot restore, it isn't set by Backup.
+	RestoreTS uint64
+	// Size is the size of the backup files in bytes.
+	Size uint64
+}
+
+// Client runs the backup and restore tasks of a cluster.
+type Client struct {
+	cfg  Config
+	glue glue.Glue
+}
+
+// NewClient creates a client of the cluster. It updates the global configurations of TiDB like the br
+// binary does, so that the sessions created by the tasks don't serve the other TiDB components.
+func NewClient(cfg Config) (*Client, error) {
+	if len(cfg.PD) == 0 {
+		return nil, wrapError(errors.Annotate(berrors.ErrInvalidArgument, "the PD addresses are required"))
+	}
+	config.UpdateGlobal(func(conf *config.Config) {
+		// Need to be skipped when the cluster has TiDB type coprocessor tasks
+		conf.AdvertiseAddress = config.UnavailableIP
+		// No need to cache the coproceesor result
+		conf.TiKVClient.CoprCache.CapacityMB = 0
+		// have to skip grant table, in order to NotifyUpdatePrivilege in binary mode
+		conf.Security.SkipGrantTable = true
+	})
+	return &Client{cfg: cfg, glue: gluetidb.New()}, nil
+}
+
+// Backup backs up the snapshot of the cluster, it returns after the task finishes.
+func (c *Client) Backup(ctx context.Context, req *BackupRequest) (*Result, error) {
+	cfg, err := c.taskConfig(req.Storage, req.Filter)
+	if err != nil {
+		return nil, wrapError(err)
+	}
+	cfg.OverrideDefaultForBackup()
+	cfg.RateLimit = req.RateLimit
+	if req.Concurrency > 0 {
+		cfg.Concurrency = req.Concurrency
+	}
+	backupCfg := task.DefaultBackupConfig(cfg)
+	backupCfg.BackupTS = req.BackupTS
+	backupCfg.LastBackupTS = req.LastBackupTS
+
+	g := newProgressGlue(c.glue, req.Progress)
+	return c.run(func() error {
+		return task.RunBackup(ctx, g, task.FullBackupCmd, &backupCfg)
+	}, g)
+}
+
+// Restore restores the snapshot backup into the cluster, it returns after the task finishes.
+func (c *Client) Restore(ctx context.Context, req *RestoreRequest) (*Result, error) {
+	cfg, err := c.taskConfig(req.Storage, req.Filter)
+	if err != nil {
+		return nil, wrapError(err)
+	}
+	if req.Concurrency > 0 {
+		cfg.Concurrency = req.Concurrency
+	}
+	restoreCfg := task.DefaultRestoreConfig(cfg)
+
+	g := newProgressGlue(c.glue, req.Progress)
+	return c.run(func() error {
+		return task.RunRestore(ctx, g, task.FullRestoreCmd, &restoreCfg)
+	}, g)
+}
+
+// RestorePoint restores the cluster to a point in time by the log backup, it returns after the task
+// finishes.
+func (c *Client) RestorePoint(ctx context.Context, req *RestorePointRequest) (*Result, error) {
+	if req.StartTS > 0 && req.FullBackupStorage != "" {
+		return nil, wrapError(errors.Annotate(berrors.ErrInvalidArgument,
+			"the start TS and the full backup storage are mutually exclusive"))
+	}
+	cfg, err := c.taskConfig(req.Storage, req.Filter)
+	if err != nil {
+		return nil, wrapError(err)
+	}
+	restoreCfg := task.DefaultRestoreConfig(cfg)
+	restoreCfg.StartTS = req.StartTS
+	restoreCfg.RestoreTS = req.RestoreTS
+	if req.FullBackupStorage != "" {
+		if _, err := storage.ParseRawURL(req.FullBackupStorage); err != nil {
+			return nil, wrapError(errors.Annotate(berrors.ErrInvalidArgument, "invalid full backup storage URL"))
+		}
+		restoreCfg.FullBackupStorage = req.FullBackupStorage
+	}
+
+	g := newProgressGlue(c.glue, req.Progress)
+	return c.run(func() error {
+		return task.RunRestore(ctx, g, task.PointRestoreCmd, &restoreCfg)
+	}, g)
+}
+
+// run runs the task exclusively in the process, and collects the result recorded by the task.
+func (*Client) run(fn func() error, g *progressGlue) (*Result, error) {
+	taskMu.Lock()
+	defer taskMu.Unlock()
+	if err := fn(); err != nil {
+		return nil, wrapError(err)
+	}
+	return g.result(), nil
+}
+
+// taskConfig builds the common configuration of the tasks like the BRIE statements do.
+func (c *Client) taskConfig(storageURL string, filters []string) (task.Config, error) {
+	cfg := task.DefaultConfig()
+	cfg.PD = c.cfg.PD
+	cfg.TLS = task.TLSConfig{CA: c.cfg.CA, Cert: c.cfg.Cert, Key: c.cfg.Key}
+	// the progress is reported by the channel instead of the progress bar.
+	cfg.LogProgress = true
+
+	if storageURL == "" {
+		return cfg, errors.Annotate(berrors.ErrInvalidArgument, "the storage URL is required")
+	}
+	u, err := storage.ParseRawURL(storageURL)
+	if err != nil {
+		return cfg, errors.Annotate(berrors.ErrInvalidArgument, "invalid storage URL")
+	}
+	switch u.Scheme {
+	case "s3":
+		storage.ExtractQueryParameters(u, &cfg.S3)
+	case "gs", "gcs":
+		storage.ExtractQueryParameters(u, &cfg.GCS)
+	}
+	cfg.Storage = u.String()
+
+	if len(filters) > 0 {
+		tableFilter, err := filter.Parse(filters)
+		if err != nil {
+			return cfg, errors.Annotatef(berrors.ErrInvalidArgument, "invalid table filter: %v", err)
+		}
+		cfg.FilterStr = filters
+		cfg.ExplicitFilter = true
+		cfg.TableFilter = filter.CaseInsensitive(tableFilter)
+	}
+	return cfg, nil
+}
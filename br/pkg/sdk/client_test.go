@@ -0,0 +1,109 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package sdk
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/pingcap/errors"
+	berrors "github.com/pingcap/tidb/br/pkg/errors"
+	"github.com/pingcap/tidb/br/pkg/gluetikv"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTaskConfig(t *testing.T) {
+	c := &Client{cfg: Config{PD: []string{"pd:2379"}, CA: "ca.pem"}}
+	cfg, err := c.taskConfig("s3://bucket/prefix?region=us-west-2", []string{"DB.*", "!db.t"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"pd:2379"}, cfg.PD)
+	require.Equal(t, "ca.pem", cfg.TLS.CA)
+	require.Equal(t, "us-west-2", cfg.S3.Region)
+	require.True(t, cfg.ExplicitFilter)
+	require.True(t, cfg.TableFilter.MatchTable("db", "t2"))
+	require.False(t, cfg.TableFilter.MatchTable("db", "T"))
+	require.False(t, cfg.TableFilter.MatchTable("db2", "t"))
+
+	// the default filter is used if no filter is given.
+	cfg, err = c.taskConfig("local:///tmp/backup", nil)
+	require.NoError(t, err)
+	require.False(t, cfg.ExplicitFilter)
+	require.True(t, cfg.TableFilter.MatchTable("db", "t"))
+
+	_, err = c.taskConfig("", nil)
+	require.ErrorIs(t, err, berrors.ErrInvalidArgument)
+	_, err = c.taskConfig("local:///tmp/backup", []string{"db.t."})
+	require.ErrorIs(t, err, berrors.ErrInvalidArgument)
+}
+
+func TestRequestValidation(t *testing.T) {
+	_, err := NewClient(Config{})
+	var sdkErr *Error
+	require.ErrorAs(t, err, &sdkErr)
+	require.Equal(t, ErrorKindInvalidArgument, sdkErr.Kind)
+
+	c := &Client{cfg: Config{PD: []string{"pd:2379"}}}
+	_, err = c.RestorePoint(context.Background(), &RestorePointRequest{
+		Storage:           "local:///tmp/log",
+		FullBackupStorage: "local:///tmp/backup",
+		StartTS:           1,
+	})
+	require.ErrorAs(t, err, &sdkErr)
+	require.Equal(t, ErrorKindInvalidArgument, sdkErr.Kind)
+	require.Equal(t, "BR:Common:ErrInvalidArgument", sdkErr.Code)
+}
+
+func TestWrapError(t *testing.T) {
+	cases := []struct {
+		err  error
+		kind ErrorKind
+		code string
+	}{
+		{errors.Annotate(berrors.ErrRestoreChecksumMismatch, "table t"), ErrorKindChecksumMismatch, "BR:Restore:ErrRestoreChecksumMismatch"},
+		{errors.Trace(berrors.ErrStorageInvalidPermission), ErrorKindStorage, "BR:ExternalStorage:ErrStorageInvalidPermission"},
+		{berrors.ErrTablesAlreadyExisted.FastGenByArgs(), ErrorKindConflict, "BR:Restore:ErrTablesAlreadyExisted"},
+		{errors.Trace(fmt.Errorf("restore: %w", context.Canceled)), ErrorKindCanceled, ""},
+		{errors.New("unknown"), ErrorKindInternal, ""},
+	}
+	for _, c := range cases {
+		err := wrapError(c.err)
+		var sdkErr *Error
+		require.ErrorAs(t, err, &sdkErr)
+		require.Equal(t, c.kind, sdkErr.Kind, c.err.Error())
+		require.Equal(t, c.code, sdkErr.Code, c.err.Error())
+		require.Equal(t, c.err.Error(), err.Error())
+		require.ErrorIs(t, err, errors.Cause(c.err))
+	}
+	require.NoError(t, wrapError(nil))
+}
+
+func TestProgressGlue(t *testing.T) {
+	ch := make(chan Progress, 1)
+	g := newProgressGlue(gluetikv.Glue{}, ch)
+	p := g.StartProgress(context.Background(), "Full Backup", 3, false)
+	p.Inc()
+	require.Equal(t, Progress{Step: "Full Backup", Current: 1, Total: 3}, <-ch)
+	// the updates are dropped if the channel is full.
+	p.IncBy(1)
+	p.Inc()
+	require.EqualValues(t, 3, p.GetCurrent())
+	require.Equal(t, Progress{Step: "Full Backup", Current: 2, Total: 3}, <-ch)
+
+	done := make(chan struct{})
+	go func() {
+		p.Close()
+		close(done)
+	}()
+	require.Equal(t, Progress{Step: "Full Backup", Current: 3, Total: 3, Done: true}, <-ch)
+	<-done
+
+	g.Record("BackupTS", 42)
+	g.Record("Size", 1024)
+	require.Equal(t, &Result{BackupTS: 42, Size: 1024}, g.result())
+
+	// the progress is logged if there is no channel.
+	p = newProgressGlue(gluetikv.Glue{}, nil).StartProgress(context.Background(), "Full Backup", 1, false)
+	p.Inc()
+	p.Close()
+}
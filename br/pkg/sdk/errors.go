@@ -0,0 +1,106 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package sdk
+
+import (
+	"github.com/pingcap/errors"
+	berrors "github.com/pingcap/tidb/br/pkg/errors"
+)
+
+// ErrorKind classifies the errors returned by the client.
+type ErrorKind string
+
+// The kinds of the errors.
+const (
+	// ErrorKindInvalidArgument means the request is invalid, retrying it won't help.
+	ErrorKindInvalidArgument ErrorKind = "InvalidArgument"
+	// ErrorKindStorage means the external storage can't be accessed.
+	ErrorKindStorage ErrorKind = "Storage"
+	// ErrorKindChecksumMismatch means the data is corrupted.
+	ErrorKindChecksumMismatch ErrorKind = "ChecksumMismatch"
+	// ErrorKindConflict means the cluster conflicts with the task, e.g. the tables to restore exist.
+	ErrorKindConflict ErrorKind = "Conflict"
+	// ErrorKindCanceled means the context of the task is canceled or exceeds the deadline.
+	ErrorKindCanceled ErrorKind = "Canceled"
+	// ErrorKindInternal is the other errors, the task may succeed by retrying.
+	ErrorKindInternal ErrorKind = "Internal"
+)
+
+var errorKinds = []struct {
+	kind ErrorKind
+	errs []*errors.Error
+}{
+	{ErrorKindInvalidArgument, []*errors.Error{
+		berrors.ErrInvalidArgument,
+		berrors.ErrUndefinedRestoreDbOrTable,
+		berrors.ErrUnsupportedOperation,
+		berrors.ErrVersionMismatch,
+		berrors.ErrRestoreInvalidBackup,
+		berrors.ErrMigrationVersionNotSupported,
+	}},
+	{ErrorKindStorage, []*errors.Error{
+		berrors.ErrStorageUnknown,
+		berrors.ErrStorageInvalidConfig,
+		berrors.ErrStorageInvalidPermission,
+	}},
+	{ErrorKindChecksumMismatch, []*errors.Error{
+		berrors.ErrBackupChecksumMismatch,
+		berrors.ErrRestoreChecksumMismatch,
+	}},
+	{ErrorKindConflict, []*errors.Error{
+		berrors.ErrRestoreNotFreshCluster,
+		berrors.ErrDatabasesAlreadyExisted,
+		berrors.ErrTablesAlreadyExisted,
+		berrors.ErrRestoreIncompatibleTable,
+		berrors.ErrRestoreIncompatibleSys,
+		berrors.ErrStreamLogTaskExist,
+	}},
+}
+
+// Error is the error returned by the client.
+type Error struct {
+	// Kind is the kind of the error.
+	Kind ErrorKind
+	// Code is the RFC code of the error like "BR:Common:ErrInvalidArgument", it's empty if the error
+	// isn't from BR.
+	Code string
+	// Err is the original error.
+	Err error
+}
+
+// Error implements error.
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the original error.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// wrapError wraps the error returned by the task into *Error.
+func wrapError(err error) error {
+	if err == nil {
+		return nil
+	}
+	e := &Error{Kind: ErrorKindInternal, Err: err}
+	if normalized, ok := errors.Find(err, func(e error) bool {
+		_, ok := e.(*errors.Error)
+		return ok
+	}).(*errors.Error); ok {
+		e.Code = string(normalized.RFCCode())
+	}
+	if berrors.IsContextCanceled(err) {
+		e.Kind = ErrorKindCanceled
+		return e
+	}
+	for _, k := range errorKinds {
+		for _, target := range k.errs {
+			if berrors.Is(err, target) {
+				e.Kind = k.kind
+				return e
+			}
+		}
+	}
+	return e
+}
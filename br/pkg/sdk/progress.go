@@ -0,0 +1,100 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package sdk
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"github.com/pingcap/tidb/br/pkg/glue"
+)
+
+// Progress is the progress of a step of the task, e.g. "Full Backup" or "Restore Meta Files".
+type Progress struct {
+	// Step is the name of the step.
+	Step string
+	// Current is the finished units of the step.
+	Current int64
+	// Total is the total units of the step.
+	Total int64
+	// Done is whether the step is finished.
+	Done bool
+}
+
+// progressGlue sends the progress of the task to the channel, and records the result of the task.
+type progressGlue struct {
+	glue.Glue
+	ch chan<- Progress
+
+	mu      sync.Mutex
+	records map[string]uint64
+}
+
+func newProgressGlue(g glue.Glue, ch chan<- Progress) *progressGlue {
+	return &progressGlue{Glue: g, ch: ch, records: make(map[string]uint64)}
+}
+
+// StartProgress implements glue.Glue.
+func (g *progressGlue) StartProgress(ctx context.Context, cmdName string, total int64, _ bool) glue.Progress {
+	if g.ch == nil {
+		// the progress bar isn't printed to stdout by the embedded tasks.
+		return g.Glue.StartProgress(ctx, cmdName, total, true)
+	}
+	return &chanProgress{ctx: ctx, ch: g.ch, step: cmdName, total: total}
+}
+
+// Record implements glue.Glue.
+func (g *progressGlue) Record(name string, value uint64) {
+	g.mu.Lock()
+	g.records[name] = value
+	g.mu.Unlock()
+	g.Glue.Record(name, value)
+}
+
+func (g *progressGlue) result() *Result {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return &Result{
+		BackupTS:  g.records["BackupTS"],
+		RestoreTS: g.records["RestoreTS"],
+		Size:      g.records["Size"],
+	}
+}
+
+// chanProgress sends the progress to the channel. The updates are dropped if the receiver is slow,
+// but the completion of the step is always sent unless the task is canceled.
+type chanProgress struct {
+	ctx     context.Context
+	ch      chan<- Progress
+	step    string
+	total   int64
+	current atomic.Int64
+}
+
+// Inc implements glue.Progress.
+func (p *chanProgress) Inc() {
+	p.IncBy(1)
+}
+
+// IncBy implements glue.Progress.
+func (p *chanProgress) IncBy(cnt int64) {
+	current := p.current.Add(cnt)
+	select {
+	case p.ch <- Progress{Step: p.step, Current: current, Total: p.total}:
+	default:
+	}
+}
+
+// GetCurrent implements glue.Progress.
+func (p *chanProgress) GetCurrent() int64 {
+	return p.current.Load()
+}
+
+// Close implements glue.Progress.
+func (p *chanProgress) Close() {
+	select {
+	case p.ch <- Progress{Step: p.step, Current: p.current.Load(), Total: p.total, Done: true}:
+	case <-p.ctx.Done():
+	}
+}
@@ -12,6 +12,7 @@ import (
 	"os"
 	"path"
 	"strings"
+	"time"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
@@ -21,6 +22,7 @@ import (
 	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/bloberror"
 	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/blockblob"
 	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/container"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/sas"
 	"github.com/google/uuid"
 	"github.com/pingcap/errors"
 	backuppb "github.com/pingcap/kvproto/pkg/brpb"
@@ -441,6 +443,16 @@ func (s *AzureBlobStorage) ReadFile(ctx context.Context, name string) ([]byte, e
 	return data, body.Close()
 }
 
+// PresignFile implements Presigner. It requires the storage to be accessed by the shared key.
+func (s *AzureBlobStorage) PresignFile(_ context.Context, name string, expire time.Duration) (string, error) {
+	client := s.containerClient.NewBlobClient(s.withPrefix(name))
+	u, err := client.GetSASURL(sas.BlobPermissions{Read: true}, time.Now().Add(expire), nil)
+	if err != nil {
+		return "", errors.Annotatef(err, "failed to presign azure blob file %s", s.withPrefix(name))
+	}
+	return u, nil
+}
+
 // FileExists checks if a file exists in Azure Blob Storage.
 func (s *AzureBlobStorage) FileExists(ctx context.Context, name string) (bool, error) {
 	client := s.containerClient.NewBlockBlobClient(s.withPrefix(name))
@@ -0,0 +1,345 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package storage
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/pingcap/errors"
+	backuppb "github.com/pingcap/kvproto/pkg/brpb"
+	"github.com/pingcap/log"
+	berrors "github.com/pingcap/tidb/br/pkg/errors"
+	"github.com/tencentyun/cos-go-sdk-v5"
+	"go.uber.org/zap"
+)
+
+const (
+	// cosSDKProvider marks the S3 backend accessed by the SDK of Tencent Cloud COS. TiKV accesses it by
+	// the S3 compatible API of COS.
+	cosSDKProvider = "cos-sdk"
+
+	cosSecretIDEnv     = "TENCENTCLOUD_SECRET_ID"
+	cosSecretKeyEnv    = "TENCENTCLOUD_SECRET_KEY"
+	cosSessionTokenEnv = "TENCENTCLOUD_SESSION_TOKEN"
+)
+
+// cosEndpoint returns the endpoint of COS in the region, the internal endpoint is only accessible in the
+// VPC of the region, and the traffic through it isn't charged.
+func cosEndpoint(region string, internal bool) string {
+	if internal {
+		return fmt.Sprintf("https://cos-internal.%s.tencentcos.cn", region)
+	}
+	return fmt.Sprintf("https://cos.%s.myqcloud.com", region)
+}
+
+// COSStorage is the storage of Tencent Cloud COS accessed by its native SDK.
+type COSStorage struct {
+	client    *cos.Client
+	bucketURL *url.URL
+	options   *backuppb.S3
+}
+
+// NewCOSStorage creates the storage of Tencent Cloud COS, the bucket is named as <name>-<appid>. The
+// credentials are read from the environment variables TENCENTCLOUD_SECRET_ID, TENCENTCLOUD_SECRET_KEY
+// and TENCENTCLOUD_SESSION_TOKEN if they aren't provided, the session token is the one of STS.
+func NewCOSStorage(
+	ctx context.Context,
+	backend *backuppb.S3,
+	opts *ExternalStorageOptions,
+) (*COSStorage, error) {
+	qs := *backend
+	if qs.Endpoint == "" {
+		return nil, errors.Annotate(berrors.ErrStorageInvalidConfig, "cos endpoint is empty, please specify the region or the endpoint")
+	}
+	if len(qs.RoleArn) > 0 {
+		return nil, errors.Annotatef(berrors.ErrStorageInvalidConfig, "cos does not support role arn, arn: %s", qs.RoleArn)
+	}
+	bucketURL, err := url.Parse(qs.Endpoint)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	// COS only supports the virtual hosted style.
+	bucketURL.Host = qs.Bucket + "." + bucketURL.Host
+
+	secretID, secretKey, sessionToken := qs.AccessKey, qs.SecretAccessKey, qs.SessionToken
+	if secretID == "" && secretKey == "" && !opts.NoCredentials {
+		secretID = os.Getenv(cosSecretIDEnv)
+		secretKey = os.Getenv(cosSecretKeyEnv)
+		sessionToken = os.Getenv(cosSessionTokenEnv)
+	}
+	httpClient := &http.Client{}
+	if opts.HTTPClient != nil {
+		*httpClient = *opts.HTTPClient
+	}
+	httpClient.Transport = &cos.AuthorizationTransport{
+		SecretID:     secretID,
+		SecretKey:    secretKey,
+		SessionToken: sessionToken,
+		Transport:    httpClient.Transport,
+	}
+	client := cos.NewClient(&cos.BaseURL{BucketURL: bucketURL}, httpClient)
+
+	if !opts.SendCredentials {
+		// Clear the credentials if exists so that they will not be sent to TiKV
+		backend.AccessKey = ""
+		backend.SecretAccessKey = ""
+		backend.SessionToken = ""
+	} else if qs.AccessKey == "" || qs.SecretAccessKey == "" {
+		backend.AccessKey = secretID
+		backend.SecretAccessKey = secretKey
+		backend.SessionToken = sessionToken
+	}
+
+	if len(qs.Prefix) > 0 && !strings.HasSuffix(qs.Prefix, "/") {
+		qs.Prefix += "/"
+	}
+	rs := &COSStorage{client: client, bucketURL: bucketURL, options: &qs}
+	for _, p := range opts.CheckPermissions {
+		if err := rs.checkPermission(ctx, p); err != nil {
+			return nil, errors.Annotatef(berrors.ErrStorageInvalidPermission, "check permission %s failed due to %v", p, err)
+		}
+	}
+	return rs, nil
+}
+
+func (rs *COSStorage) checkPermission(ctx context.Context, p Permission) error {
+	switch p {
+	case AccessBuckets:
+		_, err := rs.client.Bucket.Head(ctx)
+		return errors.Trace(err)
+	case ListObjects:
+		_, _, err := rs.client.Bucket.Get(ctx, &cos.BucketGetOptions{Prefix: rs.options.Prefix, MaxKeys: 1})
+		return errors.Trace(err)
+	case GetObject:
+		resp, err := rs.client.Object.Get(ctx, "not-exists", nil)
+		if err == nil {
+			_ = resp.Body.Close()
+		}
+		if cos.IsNotFoundError(err) {
+			// if key not exists and we reach this error, that means we have the correct permission
+			// to GetObject other we will get another error
+			return nil
+		}
+		return errors.Trace(err)
+	case PutAndDeleteObject:
+		file := fmt.Sprintf("access-check/%s", uuid.New().String())
+		if err := rs.WriteFile(ctx, file, []byte("check")); err != nil {
+			return errors.Trace(err)
+		}
+		return errors.Trace(rs.DeleteFile(ctx, file))
+	default:
+		return nil
+	}
+}
+
+// putHeaderOptions returns the header options of creating the object.
+func (rs *COSStorage) putHeaderOptions() (*cos.ACLHeaderOptions, *cos.ObjectPutHeaderOptions) {
+	var acl *cos.ACLHeaderOptions
+	if rs.options.Acl != "" {
+		acl = &cos.ACLHeaderOptions{XCosACL: rs.options.Acl}
+	}
+	return acl, &cos.ObjectPutHeaderOptions{
+		XCosStorageClass:         rs.options.StorageClass,
+		XCosServerSideEncryption: rs.options.Sse,
+	}
+}
+
+// WriteFile writes data to a file to storage.
+func (rs *COSStorage) WriteFile(ctx context.Context, file string, data []byte) error {
+	acl, header := rs.putHeaderOptions()
+	_, err := rs.client.Object.Put(ctx, rs.options.Prefix+file, bytes.NewReader(data), &cos.ObjectPutOptions{
+		ACLHeaderOptions:       acl,
+		ObjectPutHeaderOptions: header,
+	})
+	return errors.Trace(err)
+}
+
+// ReadFile reads the file from the storage and returns the contents.
+func (rs *COSStorage) ReadFile(ctx context.Context, file string) ([]byte, error) {
+	key := rs.options.Prefix + file
+	resp, err := rs.client.Object.Get(ctx, key, nil)
+	if err != nil {
+		return nil, errors.Annotatef(err, "failed to read cos file, file info: bucket='%s', key='%s'",
+			rs.options.Bucket, key)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	return data, errors.Trace(err)
+}
+
+// DeleteFile delete the file in the storage.
+func (rs *COSStorage) DeleteFile(ctx context.Context, file string) error {
+	_, err := rs.client.Object.Delete(ctx, rs.options.Prefix+file)
+	return errors.Trace(err)
+}
+
+// DeleteFiles delete the files in batch in the storage.
+func (rs *COSStorage) DeleteFiles(ctx context.Context, files []string) error {
+	for len(files) > 0 {
+		batch := files[:min(len(files), s3DeleteObjectsLimit)]
+		objects := make([]cos.Object, 0, len(batch))
+		for _, file := range batch {
+			objects = append(objects, cos.Object{Key: rs.options.Prefix + file})
+		}
+		res, _, err := rs.client.Object.DeleteMulti(ctx, &cos.ObjectDeleteMultiOptions{Quiet: true, Objects: objects})
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if len(res.Errors) > 0 {
+			e := res.Errors[0]
+			return errors.Annotatef(berrors.ErrStorageUnknown, "failed to delete %d files, e.g. %s: %s %s",
+				len(res.Errors), e.Key, e.Code, e.Message)
+		}
+		files = files[len(batch):]
+	}
+	return nil
+}
+
+// FileExists check if file exists on the storage.
+func (rs *COSStorage) FileExists(ctx context.Context, file string) (bool, error) {
+	exists, err := rs.client.Object.IsExist(ctx, rs.options.Prefix+file)
+	return exists, errors.Trace(err)
+}
+
+// WalkDir traverse all the files in a dir.
+//
+// fn is the function called for each regular file visited by WalkDir.
+// The first argument is the file path that can be used in `Open`
+// function; the second argument is the size in byte of the file determined
+// by path.
+func (rs *COSStorage) WalkDir(ctx context.Context, opt *WalkOption, fn func(string, int64) error) error {
+	if opt == nil {
+		opt = &WalkOption{}
+	}
+	prefix := path.Join(rs.options.Prefix, opt.SubDir)
+	if len(prefix) > 0 && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	prefix += opt.ObjPrefix
+
+	maxKeys := 1000
+	if opt.ListCount > 0 {
+		maxKeys = int(opt.ListCount)
+	}
+	req := &cos.BucketGetOptions{Prefix: prefix, MaxKeys: maxKeys}
+	for {
+		res, _, err := rs.client.Bucket.Get(ctx, req)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		for _, object := range res.Contents {
+			req.Marker = object.Key
+			// trim the prefix of the storage and the '/' to ensure that the path returned is consistent
+			// with the local storage.
+			p := strings.TrimPrefix(strings.TrimPrefix(object.Key, rs.options.Prefix), "/")
+			// filter out the empty directory items
+			if object.Size <= 0 && strings.HasSuffix(p, "/") {
+				continue
+			}
+			if err := fn(p, object.Size); err != nil {
+				return errors.Trace(err)
+			}
+		}
+		if !res.IsTruncated {
+			return nil
+		}
+		if res.NextMarker != "" {
+			req.Marker = res.NextMarker
+		}
+	}
+}
+
+// URI returns cos://<base>/<prefix>.
+func (rs *COSStorage) URI() string {
+	return "cos://" + rs.options.Bucket + "/" + rs.options.Prefix
+}
+
+// Open a Reader by file path.
+func (rs *COSStorage) Open(ctx context.Context, path string, o *ReaderOption) (ExternalFileReader, error) {
+	return openRangeObjectReader(ctx, rs.open, path, o)
+}
+
+func (rs *COSStorage) open(ctx context.Context, path string, startOffset, endOffset int64) (io.ReadCloser, RangeInfo, error) {
+	rangeHeader := httpRange(startOffset, endOffset)
+	resp, err := rs.client.Object.Get(ctx, rs.options.Prefix+path, &cos.ObjectGetOptions{Range: rangeHeader})
+	if err != nil {
+		return nil, RangeInfo{}, errors.Trace(err)
+	}
+	r, err := rangeInfoFromHeader(resp.Header, path, rangeHeader, startOffset, endOffset)
+	if err != nil {
+		_ = resp.Body.Close()
+		return nil, RangeInfo{}, errors.Trace(err)
+	}
+	return resp.Body, r, nil
+}
+
+// cosUploader does multi-part upload to COS.
+type cosUploader struct {
+	client   *cos.Client
+	key      string
+	uploadID string
+	parts    []cos.Object
+}
+
+// Write uploads a part.
+func (u *cosUploader) Write(ctx context.Context, data []byte) (int, error) {
+	partNumber := len(u.parts) + 1
+	resp, err := u.client.Object.UploadPart(ctx, u.key, u.uploadID, partNumber, bytes.NewReader(data), nil)
+	if err != nil {
+		return 0, errors.Trace(err)
+	}
+	u.parts = append(u.parts, cos.Object{PartNumber: partNumber, ETag: resp.Header.Get("ETag")})
+	return len(data), nil
+}
+
+// Close completes the multi-part upload.
+func (u *cosUploader) Close(ctx context.Context) error {
+	_, _, err := u.client.Object.CompleteMultipartUpload(ctx, u.key, u.uploadID,
+		&cos.CompleteMultipartUploadOptions{Parts: u.parts})
+	if err != nil {
+		if _, abortErr := u.client.Object.AbortMultipartUpload(ctx, u.key, u.uploadID); abortErr != nil {
+			log.Warn("failed to abort the multi-part upload", zap.String("key", u.key), zap.Error(abortErr))
+		}
+	}
+	return errors.Trace(err)
+}
+
+// Create creates multi upload request.
+func (rs *COSStorage) Create(ctx context.Context, name string, option *WriterOption) (ExternalFileWriter, error) {
+	key := rs.options.Prefix + name
+	acl, header := rs.putHeaderOptions()
+	res, _, err := rs.client.Object.InitiateMultipartUpload(ctx, key, &cos.InitiateMultipartUploadOptions{
+		ACLHeaderOptions:       acl,
+		ObjectPutHeaderOptions: header,
+	})
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	uploader := &cosUploader{client: rs.client, key: key, uploadID: res.UploadID, parts: make([]cos.Object, 0, 128)}
+	bufSize := WriteBufferSize
+	if option != nil && option.PartSize > 0 {
+		bufSize = int(option.PartSize)
+	}
+	return newBufferedWriter(uploader, bufSize, NoCompression), nil
+}
+
+// Rename implements ExternalStorage interface.
+func (rs *COSStorage) Rename(ctx context.Context, oldFileName, newFileName string) error {
+	source := rs.bucketURL.Host + "/" + rs.options.Prefix + oldFileName
+	if _, _, err := rs.client.Object.Copy(ctx, rs.options.Prefix+newFileName, source, nil); err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(rs.DeleteFile(ctx, oldFileName))
+}
+
+// Close implements ExternalStorage interface.
+func (*COSStorage) Close() {}
@@ -8,9 +8,12 @@ import (
 	goerrors "errors"
 	"fmt"
 	"io"
+	"net/http"
 	"os"
 	"path"
+	"strconv"
 	"strings"
+	"time"
 
 	"cloud.google.com/go/storage"
 	"github.com/pingcap/errors"
@@ -192,6 +195,69 @@ func (s *GCSStorage) ReadFile(ctx context.Context, name string) ([]byte, error)
 	return b, errors.Trace(err)
 }
 
+// ReadFileWithVersion implements ConditionalWriter, the version is the
+// generation of the object.
+func (s *GCSStorage) ReadFileWithVersion(ctx context.Context, name string) ([]byte, string, error) {
+	object := s.objectName(name)
+	rc, err := s.GetBucketHandle().Object(object).NewReader(ctx)
+	if err != nil {
+		if errors.Cause(err) == storage.ErrObjectNotExist { // nolint:errorlint
+			return nil, "", nil
+		}
+		return nil, "", errors.Annotatef(err,
+			"failed to read gcs file, file info: input.bucket='%s', input.key='%s'",
+			s.gcs.Bucket, object)
+	}
+	defer rc.Close()
+	b, err := io.ReadAll(rc)
+	if err != nil {
+		return nil, "", errors.Trace(err)
+	}
+	return b, strconv.FormatInt(rc.Attrs.Generation, 10), nil
+}
+
+// WriteFileIfMatch implements ConditionalWriter by the preconditions of GCS.
+func (s *GCSStorage) WriteFileIfMatch(ctx context.Context, name string, data []byte, version string) (string, error) {
+	cond := storage.Conditions{DoesNotExist: true}
+	if len(version) > 0 {
+		generation, err := strconv.ParseInt(version, 10, 64)
+		if err != nil {
+			return "", errors.Annotatef(err, "invalid gcs object generation %s", version)
+		}
+		cond = storage.Conditions{GenerationMatch: generation}
+	}
+	object := s.objectName(name)
+	wc := s.GetBucketHandle().Object(object).If(cond).NewWriter(ctx)
+	wc.StorageClass = s.gcs.StorageClass
+	wc.PredefinedACL = s.gcs.PredefinedAcl
+	if _, err := wc.Write(data); err != nil {
+		_ = wc.Close()
+		return "", errors.Trace(err)
+	}
+	if err := wc.Close(); err != nil {
+		if e := (&googleapi.Error{}); goerrors.As(err, &e) && e.Code == http.StatusPreconditionFailed {
+			return "", errors.Annotatef(berrors.ErrStorageConditionNotMet,
+				"file info: input.bucket='%s', input.key='%s'", s.gcs.Bucket, object)
+		}
+		return "", errors.Trace(err)
+	}
+	return strconv.FormatInt(wc.Attrs().Generation, 10), nil
+}
+
+// PresignFile implements Presigner.
+func (s *GCSStorage) PresignFile(_ context.Context, name string, expire time.Duration) (string, error) {
+	object := s.objectName(name)
+	u, err := s.GetBucketHandle().SignedURL(object, &storage.SignedURLOptions{
+		Scheme:  storage.SigningSchemeV4,
+		Method:  "GET",
+		Expires: time.Now().Add(expire),
+	})
+	if err != nil {
+		return "", errors.Annotatef(err, "failed to presign gcs file %s", object)
+	}
+	return u, nil
+}
+
 // FileExists return true if file exists.
 func (s *GCSStorage) FileExists(ctx context.Context, name string) (bool, error) {
 	object := s.objectName(name)
@@ -266,6 +332,12 @@ func (s *GCSStorage) WalkDir(ctx context.Context, opt *WalkOption, fn func(strin
 	}
 
 	query := &storage.Query{Prefix: prefix}
+	var startAfter string
+	if len(opt.StartAfter) > 0 {
+		// the start offset is inclusive.
+		startAfter = path.Join(s.gcs.Prefix, opt.StartAfter)
+		query.StartOffset = startAfter
+	}
 	// only need each object's name and size
 	err := query.SetAttrSelection([]string{"Name", "Size"})
 	if err != nil {
@@ -280,6 +352,9 @@ func (s *GCSStorage) WalkDir(ctx context.Context, opt *WalkOption, fn func(strin
 		if err != nil {
 			return errors.Trace(err)
 		}
+		if attrs.Name == startAfter {
+			continue
+		}
 		// when walk on specify directory, the result include storage.Prefix,
 		// which can not be reuse in other API(Open/Read) directly.
 		// so we use TrimPrefix to filter Prefix for next Open/Read.
@@ -453,6 +528,9 @@ func (s *GCSStorage) Reset(ctx context.Context) error {
 }
 
 func shouldRetry(err error) bool {
+	if isRetryBudgetExhaustedError(err) {
+		return false
+	}
 	if storage.ShouldRetry(err) {
 		return true
 	}
@@ -15,6 +15,7 @@ import (
 
 	"github.com/fsouza/fake-gcs-server/fakestorage"
 	backuppb "github.com/pingcap/kvproto/pkg/brpb"
+	berrors "github.com/pingcap/tidb/br/pkg/errors"
 	"github.com/pingcap/tidb/pkg/util/intest"
 	"github.com/stretchr/testify/require"
 	"golang.org/x/sync/errgroup"
@@ -570,3 +571,38 @@ func TestSpeedReadManyFiles(t *testing.T) {
 	require.NoError(t, eg.Wait())
 	t.Logf("read %d large files cost %v", len(testFiles), time.Since(now))
 }
+
+func TestGCSConditionalWrite(t *testing.T) {
+	ctx := context.Background()
+	server, err := fakestorage.NewServerWithOptions(fakestorage.Options{NoListener: true})
+	require.NoError(t, err)
+	bucketName := "testbucket"
+	server.CreateBucketWithOpts(fakestorage.CreateBucketOpts{Name: bucketName})
+	stg, err := NewGCSStorage(ctx, &backuppb.GCS{
+		Bucket:          bucketName,
+		Prefix:          "a/b/",
+		CredentialsBlob: "Fake Credentials",
+	}, &ExternalStorageOptions{HTTPClient: server.HTTPClient()})
+	require.NoError(t, err)
+
+	data, version, err := stg.ReadFileWithVersion(ctx, "cp")
+	require.NoError(t, err)
+	require.Nil(t, data)
+	require.Empty(t, version)
+
+	v1, err := stg.WriteFileIfMatch(ctx, "cp", []byte("1"), "")
+	require.NoError(t, err)
+	_, err = stg.WriteFileIfMatch(ctx, "cp", []byte("2"), "")
+	require.ErrorIs(t, err, berrors.ErrStorageConditionNotMet)
+
+	v2, err := stg.WriteFileIfMatch(ctx, "cp", []byte("2"), v1)
+	require.NoError(t, err)
+	require.NotEqual(t, v1, v2)
+	_, err = stg.WriteFileIfMatch(ctx, "cp", []byte("3"), v1)
+	require.ErrorIs(t, err, berrors.ErrStorageConditionNotMet)
+
+	data, version, err = stg.ReadFileWithVersion(ctx, "cp")
+	require.NoError(t, err)
+	require.Equal(t, []byte("2"), data)
+	require.Equal(t, v2, version)
+}
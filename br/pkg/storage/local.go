@@ -5,15 +5,19 @@ package storage
 import (
 	"bufio"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"io"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/pingcap/errors"
 	"github.com/pingcap/failpoint"
 	"github.com/pingcap/log"
+	berrors "github.com/pingcap/tidb/br/pkg/errors"
 	"github.com/pingcap/tidb/br/pkg/logutil"
 	"go.uber.org/zap"
 )
@@ -105,6 +109,94 @@ func (l *LocalStorage) ReadFile(_ context.Context, name string) ([]byte, error)
 	return os.ReadFile(path)
 }
 
+const (
+	// localLockFileSuffix is the suffix of the lock file of a conditional write.
+	localLockFileSuffix = ".lock"
+	// localStaleLockTTL is how long a lock file is kept before it's taken as left
+	// by a crashed writer and removed.
+	localStaleLockTTL = time.Minute
+	// localLockRetryInterval is the interval to retry locking a locked file.
+	localLockRetryInterval = 10 * time.Millisecond
+)
+
+func localFileVersion(data []byte) string {
+	checksum := sha256.Sum256(data)
+	return hex.EncodeToString(checksum[:])
+}
+
+// ReadFileWithVersion implements ConditionalWriter, the version is the checksum
+// of the content.
+func (l *LocalStorage) ReadFileWithVersion(ctx context.Context, name string) ([]byte, string, error) {
+	data, err := l.ReadFile(ctx, name)
+	if os.IsNotExist(err) {
+		return nil, "", nil
+	}
+	if err != nil {
+		return nil, "", errors.Trace(err)
+	}
+	return data, localFileVersion(data), nil
+}
+
+// lockFile locks the file by creating its lock file exclusively, so the
+// conditional writes of the file are serialized across the processes sharing
+// the file system. The returned function unlocks the file.
+func (l *LocalStorage) lockFile(ctx context.Context, name string) (func(), error) {
+	lockPath := filepath.Join(l.base, name) + localLockFileSuffix
+	if err := mkdirAll(filepath.Dir(lockPath)); err != nil {
+		return nil, errors.Trace(err)
+	}
+	for {
+		f, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, localFilePerm)
+		if err == nil {
+			if err := f.Close(); err != nil {
+				return nil, errors.Trace(err)
+			}
+			return func() {
+				if err := os.Remove(lockPath); err != nil {
+					log.Warn("failed to remove the lock file", zap.String("path", lockPath), zap.Error(err))
+				}
+			}, nil
+		}
+		if !os.IsExist(err) {
+			return nil, errors.Trace(err)
+		}
+		if info, err := os.Stat(lockPath); err == nil && time.Since(info.ModTime()) > localStaleLockTTL {
+			log.Warn("remove the stale lock file", zap.String("path", lockPath), zap.Time("modified", info.ModTime()))
+			if err := os.Remove(lockPath); err != nil && !os.IsNotExist(err) {
+				return nil, errors.Trace(err)
+			}
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return nil, errors.Annotatef(ctx.Err(), "failed to lock the file %s", name)
+		case <-time.After(localLockRetryInterval):
+		}
+	}
+}
+
+// WriteFileIfMatch implements ConditionalWriter. The local file system has no
+// conditional writes, so the file is locked by a lock file during the write.
+func (l *LocalStorage) WriteFileIfMatch(ctx context.Context, name string, data []byte, version string) (string, error) {
+	unlock, err := l.lockFile(ctx, name)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	defer unlock()
+
+	_, current, err := l.ReadFileWithVersion(ctx, name)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	if current != version {
+		return "", errors.Annotatef(berrors.ErrStorageConditionNotMet, "file %s", name)
+	}
+	if err := l.WriteFile(ctx, name, data); err != nil {
+		return "", errors.Trace(err)
+	}
+	return localFileVersion(data), nil
+}
+
 // FileExists implement ExternalStorage.FileExists.
 func (l *LocalStorage) FileExists(_ context.Context, name string) (bool, error) {
 	path := filepath.Join(l.base, name)
@@ -268,7 +360,11 @@ func (l *LocalStorage) Create(_ context.Context, name string, _ *WriterOption) (
 
 // Rename implements ExternalStorage interface.
 func (l *LocalStorage) Rename(_ context.Context, oldFileName, newFileName string) error {
-	return errors.Trace(os.Rename(filepath.Join(l.base, oldFileName), filepath.Join(l.base, newFileName)))
+	newPath := filepath.Join(l.base, newFileName)
+	if err := mkdirAll(filepath.Dir(newPath)); err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(os.Rename(filepath.Join(l.base, oldFileName), newPath))
 }
 
 // Close implements ExternalStorage interface.
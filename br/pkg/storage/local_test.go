@@ -10,8 +10,10 @@ import (
 	"path/filepath"
 	"runtime"
 	"testing"
+	"time"
 
 	"github.com/pingcap/errors"
+	berrors "github.com/pingcap/tidb/br/pkg/errors"
 	"github.com/stretchr/testify/require"
 )
 
@@ -257,3 +259,45 @@ func TestWalkBrokenSymLink(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, map[string]int64{"file-that-should-be-ignored": 0}, files)
 }
+
+func TestLocalConditionalWrite(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewLocalStorage(t.TempDir())
+	require.NoError(t, err)
+
+	data, version, err := store.ReadFileWithVersion(ctx, "cp")
+	require.NoError(t, err)
+	require.Nil(t, data)
+	require.Empty(t, version)
+
+	v1, err := store.WriteFileIfMatch(ctx, "cp", []byte("1"), "")
+	require.NoError(t, err)
+	// the file must not exist when the version is empty.
+	_, err = store.WriteFileIfMatch(ctx, "cp", []byte("2"), "")
+	require.ErrorIs(t, err, berrors.ErrStorageConditionNotMet)
+
+	v2, err := store.WriteFileIfMatch(ctx, "cp", []byte("2"), v1)
+	require.NoError(t, err)
+	require.NotEqual(t, v1, v2)
+	_, err = store.WriteFileIfMatch(ctx, "cp", []byte("3"), v1)
+	require.ErrorIs(t, err, berrors.ErrStorageConditionNotMet)
+
+	data, version, err = store.ReadFileWithVersion(ctx, "cp")
+	require.NoError(t, err)
+	require.Equal(t, []byte("2"), data)
+	require.Equal(t, v2, version)
+
+	// the file locked by another process can't be written.
+	lockPath := filepath.Join(store.base, "cp"+localLockFileSuffix)
+	require.NoError(t, os.WriteFile(lockPath, nil, localFilePerm))
+	timeoutCtx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel()
+	_, err = store.WriteFileIfMatch(timeoutCtx, "cp", []byte("3"), v2)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	// the stale lock is removed.
+	staleTime := time.Now().Add(-2 * localStaleLockTTL)
+	require.NoError(t, os.Chtimes(lockPath, staleTime, staleTime))
+	_, err = store.WriteFileIfMatch(ctx, "cp", []byte("3"), v2)
+	require.NoError(t, err)
+	require.NoFileExists(t, lockPath)
+}
@@ -0,0 +1,334 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package storage
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/aliyun/aliyun-oss-go-sdk/oss"
+	"github.com/google/uuid"
+	"github.com/pingcap/errors"
+	backuppb "github.com/pingcap/kvproto/pkg/brpb"
+	"github.com/pingcap/log"
+	berrors "github.com/pingcap/tidb/br/pkg/errors"
+	"go.uber.org/zap"
+)
+
+const (
+	// ossSDKProvider marks the S3 backend accessed by the SDK of Alibaba Cloud OSS. TiKV accesses it by
+	// the S3 compatible API of OSS.
+	ossSDKProvider = "oss-sdk"
+
+	ossAccessKeyEnv     = "ALIBABA_CLOUD_ACCESS_KEY_ID"
+	ossSecretKeyEnv     = "ALIBABA_CLOUD_ACCESS_KEY_SECRET"
+	ossSecurityTokenEnv = "ALIBABA_CLOUD_SECURITY_TOKEN"
+)
+
+// ossEndpoint returns the endpoint of OSS in the region, the internal endpoint is only accessible in the
+// VPC of the region, and the traffic through it isn't charged.
+func ossEndpoint(region string, internal bool) string {
+	region = strings.TrimPrefix(region, "oss-")
+	if internal {
+		return fmt.Sprintf("https://oss-%s-internal.aliyuncs.com", region)
+	}
+	return fmt.Sprintf("https://oss-%s.aliyuncs.com", region)
+}
+
+// OSSStorage is the storage of Alibaba Cloud OSS accessed by its native SDK.
+type OSSStorage struct {
+	bucket  *oss.Bucket
+	options *backuppb.S3
+}
+
+// NewOSSStorage creates the storage of Alibaba Cloud OSS. The credentials are read from the environment
+// variables ALIBABA_CLOUD_ACCESS_KEY_ID, ALIBABA_CLOUD_ACCESS_KEY_SECRET and ALIBABA_CLOUD_SECURITY_TOKEN
+// if they aren't provided, the security token is the one of STS.
+func NewOSSStorage(
+	ctx context.Context,
+	backend *backuppb.S3,
+	opts *ExternalStorageOptions,
+) (*OSSStorage, error) {
+	qs := *backend
+	if qs.Endpoint == "" {
+		return nil, errors.Annotate(berrors.ErrStorageInvalidConfig, "oss endpoint is empty, please specify the region or the endpoint")
+	}
+	if len(qs.RoleArn) > 0 {
+		return nil, errors.Annotatef(berrors.ErrStorageInvalidConfig, "oss does not support role arn, arn: %s", qs.RoleArn)
+	}
+	accessKey, secretKey, securityToken := qs.AccessKey, qs.SecretAccessKey, qs.SessionToken
+	if accessKey == "" && secretKey == "" && !opts.NoCredentials {
+		accessKey = os.Getenv(ossAccessKeyEnv)
+		secretKey = os.Getenv(ossSecretKeyEnv)
+		securityToken = os.Getenv(ossSecurityTokenEnv)
+	}
+
+	clientOpts := make([]oss.ClientOption, 0, 2)
+	if securityToken != "" {
+		clientOpts = append(clientOpts, oss.SecurityToken(securityToken))
+	}
+	if opts.HTTPClient != nil {
+		clientOpts = append(clientOpts, oss.HTTPClient(opts.HTTPClient))
+	}
+	client, err := oss.New(qs.Endpoint, accessKey, secretKey, clientOpts...)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	bucket, err := client.Bucket(qs.Bucket)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	if !opts.SendCredentials {
+		// Clear the credentials if exists so that they will not be sent to TiKV
+		backend.AccessKey = ""
+		backend.SecretAccessKey = ""
+		backend.SessionToken = ""
+	} else if qs.AccessKey == "" || qs.SecretAccessKey == "" {
+		backend.AccessKey = accessKey
+		backend.SecretAccessKey = secretKey
+		backend.SessionToken = securityToken
+	}
+
+	if len(qs.Prefix) > 0 && !strings.HasSuffix(qs.Prefix, "/") {
+		qs.Prefix += "/"
+	}
+	rs := &OSSStorage{bucket: bucket, options: &qs}
+	for _, p := range opts.CheckPermissions {
+		if err := rs.checkPermission(ctx, p); err != nil {
+			return nil, errors.Annotatef(berrors.ErrStorageInvalidPermission, "check permission %s failed due to %v", p, err)
+		}
+	}
+	return rs, nil
+}
+
+func (rs *OSSStorage) checkPermission(ctx context.Context, p Permission) error {
+	switch p {
+	case AccessBuckets:
+		_, err := rs.bucket.Client.GetBucketInfo(rs.bucket.BucketName, oss.WithContext(ctx))
+		return errors.Trace(err)
+	case ListObjects:
+		_, err := rs.bucket.ListObjectsV2(oss.Prefix(rs.options.Prefix), oss.MaxKeys(1), oss.WithContext(ctx))
+		return errors.Trace(err)
+	case GetObject:
+		_, err := rs.bucket.GetObject("not-exists", oss.WithContext(ctx))
+		if isOSSNotFound(err) {
+			// if key not exists and we reach this error, that means we have the correct permission
+			// to GetObject other we will get another error
+			return nil
+		}
+		return errors.Trace(err)
+	case PutAndDeleteObject:
+		file := fmt.Sprintf("access-check/%s", uuid.New().String())
+		if err := rs.WriteFile(ctx, file, []byte("check")); err != nil {
+			return errors.Trace(err)
+		}
+		return errors.Trace(rs.DeleteFile(ctx, file))
+	default:
+		return nil
+	}
+}
+
+func isOSSNotFound(err error) bool {
+	serviceErr, ok := errors.Cause(err).(oss.ServiceError) // nolint:errorlint
+	return ok && serviceErr.StatusCode == http.StatusNotFound
+}
+
+// putOptions returns the options of creating the object.
+func (rs *OSSStorage) putOptions(ctx context.Context) []oss.Option {
+	options := []oss.Option{oss.WithContext(ctx)}
+	if rs.options.Acl != "" {
+		options = append(options, oss.ObjectACL(oss.ACLType(rs.options.Acl)))
+	}
+	if rs.options.Sse != "" {
+		options = append(options, oss.ServerSideEncryption(rs.options.Sse))
+	}
+	if rs.options.SseKmsKeyId != "" {
+		options = append(options, oss.ServerSideEncryptionKeyID(rs.options.SseKmsKeyId))
+	}
+	if rs.options.StorageClass != "" {
+		options = append(options, oss.ObjectStorageClass(oss.StorageClassType(rs.options.StorageClass)))
+	}
+	return options
+}
+
+// WriteFile writes data to a file to storage.
+func (rs *OSSStorage) WriteFile(ctx context.Context, file string, data []byte) error {
+	err := rs.bucket.PutObject(rs.options.Prefix+file, bytes.NewReader(data), rs.putOptions(ctx)...)
+	return errors.Trace(err)
+}
+
+// ReadFile reads the file from the storage and returns the contents.
+func (rs *OSSStorage) ReadFile(ctx context.Context, file string) ([]byte, error) {
+	key := rs.options.Prefix + file
+	body, err := rs.bucket.GetObject(key, oss.WithContext(ctx))
+	if err != nil {
+		return nil, errors.Annotatef(err, "failed to read oss file, file info: bucket='%s', key='%s'",
+			rs.options.Bucket, key)
+	}
+	defer body.Close()
+	data, err := io.ReadAll(body)
+	return data, errors.Trace(err)
+}
+
+// DeleteFile delete the file in the storage.
+func (rs *OSSStorage) DeleteFile(ctx context.Context, file string) error {
+	return errors.Trace(rs.bucket.DeleteObject(rs.options.Prefix+file, oss.WithContext(ctx)))
+}
+
+// DeleteFiles delete the files in batch in the storage.
+func (rs *OSSStorage) DeleteFiles(ctx context.Context, files []string) error {
+	for len(files) > 0 {
+		batch := files[:min(len(files), s3DeleteObjectsLimit)]
+		keys := make([]string, 0, len(batch))
+		for _, file := range batch {
+			keys = append(keys, rs.options.Prefix+file)
+		}
+		if _, err := rs.bucket.DeleteObjects(keys, oss.DeleteObjectsQuiet(true), oss.WithContext(ctx)); err != nil {
+			return errors.Trace(err)
+		}
+		files = files[len(batch):]
+	}
+	return nil
+}
+
+// FileExists check if file exists on the storage.
+func (rs *OSSStorage) FileExists(ctx context.Context, file string) (bool, error) {
+	exists, err := rs.bucket.IsObjectExist(rs.options.Prefix+file, oss.WithContext(ctx))
+	return exists, errors.Trace(err)
+}
+
+// WalkDir traverse all the files in a dir.
+//
+// fn is the function called for each regular file visited by WalkDir.
+// The first argument is the file path that can be used in `Open`
+// function; the second argument is the size in byte of the file determined
+// by path.
+func (rs *OSSStorage) WalkDir(ctx context.Context, opt *WalkOption, fn func(string, int64) error) error {
+	if opt == nil {
+		opt = &WalkOption{}
+	}
+	prefix := path.Join(rs.options.Prefix, opt.SubDir)
+	if len(prefix) > 0 && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	prefix += opt.ObjPrefix
+
+	maxKeys := 1000
+	if opt.ListCount > 0 {
+		maxKeys = int(opt.ListCount)
+	}
+	options := []oss.Option{oss.Prefix(prefix), oss.MaxKeys(maxKeys), oss.WithContext(ctx)}
+	for {
+		res, err := rs.bucket.ListObjectsV2(options...)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		for _, object := range res.Objects {
+			// trim the prefix of the storage and the '/' to ensure that the path returned is consistent
+			// with the local storage.
+			p := strings.TrimPrefix(strings.TrimPrefix(object.Key, rs.options.Prefix), "/")
+			// filter out the empty directory items
+			if object.Size <= 0 && strings.HasSuffix(p, "/") {
+				continue
+			}
+			if err := fn(p, object.Size); err != nil {
+				return errors.Trace(err)
+			}
+		}
+		if !res.IsTruncated {
+			return nil
+		}
+		options = []oss.Option{
+			oss.Prefix(prefix), oss.MaxKeys(maxKeys), oss.ContinuationToken(res.NextContinuationToken), oss.WithContext(ctx),
+		}
+	}
+}
+
+// URI returns oss://<base>/<prefix>.
+func (rs *OSSStorage) URI() string {
+	return "oss://" + rs.options.Bucket + "/" + rs.options.Prefix
+}
+
+// Open a Reader by file path.
+func (rs *OSSStorage) Open(ctx context.Context, path string, o *ReaderOption) (ExternalFileReader, error) {
+	return openRangeObjectReader(ctx, rs.open, path, o)
+}
+
+func (rs *OSSStorage) open(ctx context.Context, path string, startOffset, endOffset int64) (io.ReadCloser, RangeInfo, error) {
+	options := []oss.Option{oss.WithContext(ctx)}
+	rangeHeader := httpRange(startOffset, endOffset)
+	if rangeHeader != "" {
+		options = append(options, oss.NormalizedRange(strings.TrimPrefix(rangeHeader, "bytes=")))
+	}
+	result, err := rs.bucket.DoGetObject(&oss.GetObjectRequest{ObjectKey: rs.options.Prefix + path}, options)
+	if err != nil {
+		return nil, RangeInfo{}, errors.Trace(err)
+	}
+	r, err := rangeInfoFromHeader(result.Response.Headers, path, rangeHeader, startOffset, endOffset)
+	if err != nil {
+		_ = result.Response.Close()
+		return nil, RangeInfo{}, errors.Trace(err)
+	}
+	return result.Response, r, nil
+}
+
+// ossUploader does multi-part upload to OSS.
+type ossUploader struct {
+	bucket *oss.Bucket
+	imur   oss.InitiateMultipartUploadResult
+	parts  []oss.UploadPart
+}
+
+// Write uploads a part.
+func (u *ossUploader) Write(ctx context.Context, data []byte) (int, error) {
+	part, err := u.bucket.UploadPart(u.imur, bytes.NewReader(data), int64(len(data)), len(u.parts)+1, oss.WithContext(ctx))
+	if err != nil {
+		return 0, errors.Trace(err)
+	}
+	u.parts = append(u.parts, part)
+	return len(data), nil
+}
+
+// Close completes the multi-part upload.
+func (u *ossUploader) Close(ctx context.Context) error {
+	_, err := u.bucket.CompleteMultipartUpload(u.imur, u.parts, oss.WithContext(ctx))
+	if err != nil {
+		if abortErr := u.bucket.AbortMultipartUpload(u.imur, oss.WithContext(ctx)); abortErr != nil {
+			log.Warn("failed to abort the multi-part upload", zap.String("key", u.imur.Key), zap.Error(abortErr))
+		}
+	}
+	return errors.Trace(err)
+}
+
+// Create creates multi upload request.
+func (rs *OSSStorage) Create(ctx context.Context, name string, option *WriterOption) (ExternalFileWriter, error) {
+	imur, err := rs.bucket.InitiateMultipartUpload(rs.options.Prefix+name, rs.putOptions(ctx)...)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	uploader := &ossUploader{bucket: rs.bucket, imur: imur, parts: make([]oss.UploadPart, 0, 128)}
+	bufSize := WriteBufferSize
+	if option != nil && option.PartSize > 0 {
+		bufSize = int(option.PartSize)
+	}
+	return newBufferedWriter(uploader, bufSize, NoCompression), nil
+}
+
+// Rename implements ExternalStorage interface.
+func (rs *OSSStorage) Rename(ctx context.Context, oldFileName, newFileName string) error {
+	_, err := rs.bucket.CopyObject(rs.options.Prefix+oldFileName, rs.options.Prefix+newFileName, rs.putOptions(ctx)...)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(rs.DeleteFile(ctx, oldFileName))
+}
+
+// Close implements ExternalStorage interface.
+func (*OSSStorage) Close() {}
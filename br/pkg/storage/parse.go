@@ -3,6 +3,7 @@
 package storage
 
 import (
+	"context"
 	"net/url"
 	"path/filepath"
 	"reflect"
@@ -38,12 +39,22 @@ func ParseRawURL(rawURL string) (*url.URL, error) {
 // ParseBackendFromURL constructs a structured backend description from the
 // *url.URL.
 func ParseBackendFromURL(u *url.URL, options *BackendOptions) (*backuppb.StorageBackend, error) {
-	return parseBackend(u, "", options)
+	return parseBackend(context.Background(), u, "", options)
 }
 
 // ParseBackend constructs a structured backend description from the
 // storage URL.
 func ParseBackend(rawURL string, options *BackendOptions) (*backuppb.StorageBackend, error) {
+	return ParseBackendWithContext(context.Background(), rawURL, options)
+}
+
+// ParseBackendWithContext is like ParseBackend, the context is used to read
+// the credentials referenced by the storage URL.
+func ParseBackendWithContext(
+	ctx context.Context,
+	rawURL string,
+	options *BackendOptions,
+) (*backuppb.StorageBackend, error) {
 	if len(rawURL) == 0 {
 		return nil, errors.Annotate(berrors.ErrStorageInvalidConfig, "empty store is not allowed")
 	}
@@ -51,14 +62,23 @@ func ParseBackend(rawURL string, options *BackendOptions) (*backuppb.StorageBack
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
-	return parseBackend(u, rawURL, options)
+	return parseBackend(ctx, u, rawURL, options)
 }
 
-func parseBackend(u *url.URL, rawURL string, options *BackendOptions) (*backuppb.StorageBackend, error) {
+func parseBackend(
+	ctx context.Context,
+	u *url.URL,
+	rawURL string,
+	options *BackendOptions,
+) (*backuppb.StorageBackend, error) {
 	if rawURL == "" {
 		// try to handle hdfs for ParseBackendFromURL caller
 		rawURL = u.String()
 	}
+	secretParams, err := resolveCredentialRef(ctx, u)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
 	switch u.Scheme {
 	case "":
 		absPath, err := filepath.Abs(rawURL)
@@ -98,6 +118,35 @@ func parseBackend(u *url.URL, rawURL string, options *BackendOptions) (*backuppb
 		}
 		return &backuppb.StorageBackend{Backend: &backuppb.StorageBackend_S3{S3: s3}}, nil
 
+	case "oss", "cos":
+		if u.Host == "" {
+			return nil, errors.Annotatef(berrors.ErrStorageInvalidConfig, "please specify the bucket for %s in %s", u.Scheme, rawURL)
+		}
+		prefix := strings.Trim(u.Path, "/")
+		s3 := &backuppb.S3{Bucket: u.Host, Prefix: prefix}
+		if options == nil {
+			options = &BackendOptions{}
+		}
+		ExtractQueryParameters(u, &options.S3)
+		// both OSS and COS only support the virtual hosted style.
+		options.S3.ForcePathStyle = false
+		if options.S3.Endpoint == "" && options.S3.Region != "" {
+			if u.Scheme == "oss" {
+				options.S3.Endpoint = ossEndpoint(options.S3.Region, options.S3.InternalEndpoint)
+			} else {
+				options.S3.Endpoint = cosEndpoint(options.S3.Region, options.S3.InternalEndpoint)
+			}
+		}
+		if err := options.S3.Apply(s3); err != nil {
+			return nil, errors.Trace(err)
+		}
+		if u.Scheme == "oss" {
+			s3.Provider = ossSDKProvider
+		} else {
+			s3.Provider = cosSDKProvider
+		}
+		return &backuppb.StorageBackend{Backend: &backuppb.StorageBackend_S3{S3: s3}}, nil
+
 	case "gs", "gcs":
 		if u.Host == "" {
 			return nil, errors.Annotatef(berrors.ErrStorageInvalidConfig, "please specify the bucket for gcs in %s", rawURL)
@@ -111,6 +160,9 @@ func parseBackend(u *url.URL, rawURL string, options *BackendOptions) (*backuppb
 		if err := options.GCS.apply(gcs); err != nil {
 			return nil, errors.Trace(err)
 		}
+		if blob := secretParams[credentialsBlobParam]; blob != "" && options.GCS.CredentialsFile == "" {
+			gcs.CredentialsBlob = blob
+		}
 		return &backuppb.StorageBackend{Backend: &backuppb.StorageBackend_Gcs{Gcs: gcs}}, nil
 
 	case "azure", "azblob":
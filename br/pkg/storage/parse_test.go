@@ -108,6 +108,32 @@ func TestCreateStorage(t *testing.T) {
 	require.Equal(t, testRoleARN, s3.RoleArn)
 	require.Equal(t, testExternalID, s3.ExternalId)
 
+	s, err = ParseBackend("oss://bucket6/prefix/?region=cn-hangzhou&internal-endpoint=true", nil)
+	require.NoError(t, err)
+	s3 = s.GetS3()
+	require.NotNil(t, s3)
+	require.Equal(t, "bucket6", s3.Bucket)
+	require.Equal(t, "prefix", s3.Prefix)
+	require.Equal(t, "https://oss-cn-hangzhou-internal.aliyuncs.com", s3.Endpoint)
+	require.Equal(t, ossSDKProvider, s3.Provider)
+	require.False(t, s3.ForcePathStyle)
+
+	s, err = ParseBackend("cos://bucket7-1250000000/prefix?region=ap-guangzhou&session-token=token", nil)
+	require.NoError(t, err)
+	s3 = s.GetS3()
+	require.NotNil(t, s3)
+	require.Equal(t, "bucket7-1250000000", s3.Bucket)
+	require.Equal(t, "https://cos.ap-guangzhou.myqcloud.com", s3.Endpoint)
+	require.Equal(t, "token", s3.SessionToken)
+	require.Equal(t, cosSDKProvider, s3.Provider)
+
+	s, err = ParseBackend("cos://bucket7-1250000000/prefix?endpoint=https://cos.example.com&region=ap-guangzhou", nil)
+	require.NoError(t, err)
+	require.Equal(t, "https://cos.example.com", s.GetS3().Endpoint)
+
+	_, err = ParseBackend("oss:///prefix", nil)
+	require.ErrorContains(t, err, "please specify the bucket for oss")
+
 	gcsOpt := &BackendOptions{
 		GCS: GCSBackendOptions{
 			Endpoint: "https://gcs.example.com/",
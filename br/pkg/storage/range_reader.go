@@ -0,0 +1,217 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package storage
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/log"
+	berrors "github.com/pingcap/tidb/br/pkg/errors"
+	"github.com/pingcap/tidb/br/pkg/logutil"
+	"github.com/pingcap/tidb/pkg/util/prefetch"
+	"go.uber.org/zap"
+)
+
+// rangeOpenFn opens the object for the bytes in [startOffset, endOffset), endOffset 0 means the end of
+// the object.
+type rangeOpenFn func(ctx context.Context, name string, startOffset, endOffset int64) (io.ReadCloser, RangeInfo, error)
+
+// httpRange returns the value of the Range header to read the bytes in [startOffset, endOffset) of an
+// object, it's empty if the whole object is read.
+//
+// The whole object is read without the Range header, so that even if the object is empty, we can still
+// get the response without errors, which is similar to opening an empty file in local file system.
+func httpRange(startOffset, endOffset int64) string {
+	switch {
+	case endOffset > startOffset:
+		// the end of the Range header is inclusive
+		return fmt.Sprintf("bytes=%d-%d", startOffset, endOffset-1)
+	case startOffset == 0:
+		return ""
+	default:
+		return fmt.Sprintf("bytes=%d-", startOffset)
+	}
+}
+
+// rangeInfoFromHeader gets the range of the response of the request with the Range header rangeHeader,
+// and checks it's the requested one.
+func rangeInfoFromHeader(
+	header http.Header,
+	name, rangeHeader string,
+	startOffset, endOffset int64,
+) (RangeInfo, error) {
+	var r RangeInfo
+	if rangeHeader == "" {
+		// the response of the request without the Range header has no Content-Range.
+		size, err := strconv.ParseInt(header.Get("Content-Length"), 10, 64)
+		if err != nil {
+			return RangeInfo{}, errors.Annotatef(berrors.ErrStorageUnknown,
+				"open file '%s' failed. The object has no valid content length: %v", name, err)
+		}
+		r = RangeInfo{Start: 0, End: size - 1, Size: size}
+	} else {
+		contentRange := header.Get("Content-Range")
+		var err error
+		if r, err = ParseRangeInfo(&contentRange); err != nil {
+			return RangeInfo{}, errors.Trace(err)
+		}
+	}
+	if startOffset != r.Start || (endOffset != 0 && endOffset != r.End+1) {
+		return r, errors.Annotatef(berrors.ErrStorageUnknown, "open file '%s' failed, expected range: %s, got: %v",
+			name, rangeHeader, r)
+	}
+	return r, nil
+}
+
+// rangeObjectReader reads an object by the range requests and adds the `Seek` method, it reopens the
+// object to retry the failed reads.
+type rangeObjectReader struct {
+	ctx          context.Context
+	open         rangeOpenFn
+	name         string
+	reader       io.ReadCloser
+	pos          int64
+	rangeInfo    RangeInfo
+	prefetchSize int
+}
+
+func openRangeObjectReader(ctx context.Context, open rangeOpenFn, name string, o *ReaderOption) (*rangeObjectReader, error) {
+	start := int64(0)
+	end := int64(0)
+	prefetchSize := 0
+	if o != nil {
+		if o.StartOffset != nil {
+			start = *o.StartOffset
+		}
+		if o.EndOffset != nil {
+			end = *o.EndOffset
+		}
+		prefetchSize = o.PrefetchSize
+	}
+	reader, r, err := open(ctx, name, start, end)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if prefetchSize > 0 {
+		reader = prefetch.NewReader(reader, prefetchSize)
+	}
+	return &rangeObjectReader{
+		ctx:          ctx,
+		open:         open,
+		name:         name,
+		reader:       reader,
+		pos:          start,
+		rangeInfo:    r,
+		prefetchSize: prefetchSize,
+	}, nil
+}
+
+func (r *rangeObjectReader) reopen(startOffset, endOffset int64) (RangeInfo, error) {
+	newReader, info, err := r.open(r.ctx, r.name, startOffset, endOffset)
+	if err != nil {
+		return info, errors.Trace(err)
+	}
+	r.reader = newReader
+	if r.prefetchSize > 0 {
+		r.reader = prefetch.NewReader(r.reader, r.prefetchSize)
+	}
+	return info, nil
+}
+
+// Read implement the io.Reader interface.
+func (r *rangeObjectReader) Read(p []byte) (n int, err error) {
+	retryCnt := 0
+	maxCnt := r.rangeInfo.End + 1 - r.pos
+	if maxCnt <= 0 {
+		return 0, io.EOF
+	}
+	if maxCnt > int64(len(p)) {
+		maxCnt = int64(len(p))
+	}
+	n, err = r.reader.Read(p[:maxCnt])
+	for err != nil && errors.Cause(err) != io.EOF && !isRetryBudgetExhaustedError(err) && retryCnt < maxErrorRetries { //nolint:errorlint
+		log.Warn("read object failed, will retry",
+			zap.String("file", r.name), zap.Int("retryCnt", retryCnt), zap.Error(err))
+		// the partial read is dropped and read again from the current position.
+		end := r.rangeInfo.End + 1
+		if end == r.rangeInfo.Size {
+			end = 0
+		}
+		_ = r.reader.Close()
+		if _, err1 := r.reopen(r.pos, end); err1 != nil {
+			log.Warn("reopen the object failed", zap.String("file", r.name), zap.Error(err1))
+			return 0, err
+		}
+		retryCnt++
+		n, err = r.reader.Read(p[:maxCnt])
+	}
+	r.pos += int64(n)
+	return
+}
+
+// Close implement the io.Closer interface.
+func (r *rangeObjectReader) Close() error {
+	return r.reader.Close()
+}
+
+// Seek implement the io.Seeker interface.
+func (r *rangeObjectReader) Seek(offset int64, whence int) (int64, error) {
+	var realOffset int64
+	switch whence {
+	case io.SeekStart:
+		realOffset = offset
+	case io.SeekCurrent:
+		realOffset = r.pos + offset
+	case io.SeekEnd:
+		realOffset = r.rangeInfo.Size + offset
+	default:
+		return 0, errors.Annotatef(berrors.ErrStorageUnknown, "Seek: invalid whence '%d'", whence)
+	}
+	if realOffset < 0 {
+		return 0, errors.Annotatef(berrors.ErrStorageUnknown, "Seek in '%s': invalid offset to seek '%d'.", r.name, realOffset)
+	}
+
+	if realOffset == r.pos {
+		return realOffset, nil
+	} else if realOffset >= r.rangeInfo.Size {
+		// a range matching zero length data can't be requested, so always return io.EOF after seeking
+		// out of the object.
+		if err := r.reader.Close(); err != nil {
+			log.Warn("close the object reader failed, will ignore this error", logutil.ShortError(err))
+		}
+		r.reader = io.NopCloser(bytes.NewReader(nil))
+		r.pos = r.rangeInfo.Size
+		return r.pos, nil
+	}
+
+	// if seek ahead no more than 64k, we discard these data
+	if realOffset > r.pos && realOffset-r.pos <= maxSkipOffsetByRead {
+		_, err := io.CopyN(io.Discard, r, realOffset-r.pos)
+		if err != nil {
+			return r.pos, errors.Trace(err)
+		}
+		return realOffset, nil
+	}
+
+	if err := r.reader.Close(); err != nil {
+		return 0, errors.Trace(err)
+	}
+	info, err := r.reopen(realOffset, 0)
+	if err != nil {
+		return 0, errors.Trace(err)
+	}
+	r.rangeInfo = info
+	r.pos = realOffset
+	return realOffset, nil
+}
+
+// GetFileSize implements the ExternalFileReader interface.
+func (r *rangeObjectReader) GetFileSize() (int64, error) {
+	return r.rangeInfo.Size, nil
+}
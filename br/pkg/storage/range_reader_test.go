@@ -0,0 +1,81 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package storage
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+	"testing/iotest"
+
+	"github.com/pingcap/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRangeObjectReader(t *testing.T) {
+	content := []byte("0123456789abcdefghijklmnopqrstuvwxyz")
+	failures := 0
+	open := func(_ context.Context, name string, startOffset, endOffset int64) (io.ReadCloser, RangeInfo, error) {
+		rangeHeader := httpRange(startOffset, endOffset)
+		header := http.Header{}
+		end := int64(len(content))
+		if endOffset > 0 {
+			end = endOffset
+		}
+		if rangeHeader == "" {
+			header.Set("Content-Length", "36")
+		} else {
+			header.Set("Content-Range", fmt.Sprintf("bytes %d-%d/36", startOffset, end-1))
+		}
+		r, err := rangeInfoFromHeader(header, name, rangeHeader, startOffset, endOffset)
+		if err != nil {
+			return nil, r, err
+		}
+		var reader io.Reader = bytes.NewReader(content[startOffset:end])
+		if failures > 0 {
+			failures--
+			reader = io.MultiReader(bytes.NewReader(content[startOffset:startOffset+1]), iotest.ErrReader(errors.New("connection reset")))
+		}
+		return io.NopCloser(reader), r, nil
+	}
+
+	ctx := context.Background()
+	r, err := openRangeObjectReader(ctx, open, "file", nil)
+	require.NoError(t, err)
+	size, err := r.GetFileSize()
+	require.NoError(t, err)
+	require.EqualValues(t, 36, size)
+
+	buf := make([]byte, 10)
+	_, err = io.ReadFull(r, buf)
+	require.NoError(t, err)
+	require.Equal(t, "0123456789", string(buf))
+
+	// seek out of the window of skipping by reading and the read fails once.
+	offset, err := r.Seek(-6, io.SeekEnd)
+	require.NoError(t, err)
+	require.EqualValues(t, 30, offset)
+	failures = 1
+	data, err := io.ReadAll(r)
+	require.NoError(t, err)
+	require.Equal(t, "uvwxyz", string(data))
+
+	offset, err = r.Seek(100, io.SeekStart)
+	require.NoError(t, err)
+	require.EqualValues(t, 36, offset)
+	n, err := r.Read(buf)
+	require.Equal(t, 0, n)
+	require.Equal(t, io.EOF, err)
+	require.NoError(t, r.Close())
+
+	start, end := int64(3), int64(8)
+	r, err = openRangeObjectReader(ctx, open, "file", &ReaderOption{StartOffset: &start, EndOffset: &end})
+	require.NoError(t, err)
+	data, err = io.ReadAll(r)
+	require.NoError(t, err)
+	require.Equal(t, "34567", string(data))
+	require.NoError(t, r.Close())
+}
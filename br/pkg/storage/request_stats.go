@@ -0,0 +1,229 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package storage
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+
+	"github.com/pingcap/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+)
+
+// RequestClass is the class of the requests to the external storage, the object storages charge the
+// requests by the class.
+type RequestClass int
+
+const (
+	// RequestGet reads an object.
+	RequestGet RequestClass = iota
+	// RequestPut creates an object, including the requests of the multi-part upload, copying and
+	// deleting the objects in batch, which are charged as PUT by S3.
+	RequestPut
+	// RequestList lists the objects.
+	RequestList
+	// RequestHead reads the metadata of an object or a bucket.
+	RequestHead
+	// RequestDelete deletes an object.
+	RequestDelete
+
+	requestClassCount
+)
+
+var requestClassNames = [requestClassCount]string{"GET", "PUT", "LIST", "HEAD", "DELETE"}
+
+// RequestClasses are all the classes of the requests.
+var RequestClasses = []RequestClass{RequestGet, RequestPut, RequestList, RequestHead, RequestDelete}
+
+// String implements fmt.Stringer.
+func (c RequestClass) String() string {
+	return requestClassNames[c]
+}
+
+var (
+	storageRequestCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "br",
+			Subsystem: "storage",
+			Name:      "requests_total",
+			Help:      "The number of the requests sent to the external storage by the BR process by class.",
+		}, []string{"type"})
+
+	storageRequestBytes = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "br",
+			Subsystem: "storage",
+			Name:      "request_bytes_total",
+			Help:      "The bytes transferred by the requests to the external storage by the BR process by class.",
+		}, []string{"type"})
+)
+
+func init() { // nolint:gochecknoinits
+	prometheus.MustRegister(storageRequestCounter)
+	prometheus.MustRegister(storageRequestBytes)
+}
+
+// listQueryKeys are the query parameters of listing the objects of S3 and its compatible storages, GCS
+// and Azure Blob Storage.
+var listQueryKeys = []string{"list-type", "prefix", "delimiter", "marker", "continuation-token", "pageToken"}
+
+// isCredentialRequest returns whether the request gets the credentials of the storage instead of accessing
+// it, i.e. to the metadata service of EC2 or the STS of AWS, e.g. assuming the role.
+func isCredentialRequest(req *http.Request) bool {
+	return req.URL.Host == ec2MetaAddress || strings.HasPrefix(req.URL.Hostname(), "sts.")
+}
+
+// classifyRequest returns the class of the request by its method and query.
+func classifyRequest(req *http.Request) RequestClass {
+	switch req.Method {
+	case http.MethodHead:
+		return RequestHead
+	case http.MethodDelete:
+		return RequestDelete
+	case http.MethodPut, http.MethodPost, http.MethodPatch:
+		return RequestPut
+	}
+	query := req.URL.Query()
+	if query.Get("comp") == "list" {
+		return RequestList
+	}
+	for _, key := range listQueryKeys {
+		if query.Has(key) {
+			return RequestList
+		}
+	}
+	return RequestGet
+}
+
+// RequestStats counts the requests sent to the external storage by the BR process and the bytes transferred
+// by them of a task by class. The retried requests are counted as many times as they are sent. The counts
+// are also exported as the metrics.
+// Only the requests of BR itself are counted, e.g. the metadata and the checkpoints. The backup and restore
+// of the data files are done by TiKV, whose requests, the bulk of a task, aren't included. The requests
+// getting the credentials, e.g. from STS, aren't counted either, because they aren't charged by the storage.
+type RequestStats struct {
+	requests [requestClassCount]atomic.Int64
+	bytes    [requestClassCount]atomic.Int64
+}
+
+// NewRequestStats creates the request stats.
+func NewRequestStats() *RequestStats {
+	return &RequestStats{}
+}
+
+// apply returns the options whose HTTP client counts the requests. The options are copied, because some
+// storages, e.g. GCS, modify the client.
+func (s *RequestStats) apply(opts *ExternalStorageOptions) *ExternalStorageOptions {
+	if s == nil {
+		return opts
+	}
+	newOpts := *opts
+	newOpts.HTTPClient = s.wrapClient(opts.HTTPClient)
+	return &newOpts
+}
+
+// wrapClient returns a copy of the client counting the requests, nil means the default client.
+func (s *RequestStats) wrapClient(client *http.Client) *http.Client {
+	if s == nil {
+		return client
+	}
+	newClient := &http.Client{}
+	if client != nil {
+		*newClient = *client
+	}
+	transport := newClient.Transport
+	if transport == nil {
+		transport, _ = CloneDefaultHttpTransport()
+	}
+	newClient.Transport = &requestStatsTransport{stats: s, inner: transport}
+	return newClient
+}
+
+func (s *RequestStats) addRequest(class RequestClass) {
+	s.requests[class].Add(1)
+	storageRequestCounter.WithLabelValues(class.String()).Inc()
+}
+
+func (s *RequestStats) addBytes(class RequestClass, n int64) {
+	s.bytes[class].Add(n)
+	storageRequestBytes.WithLabelValues(class.String()).Add(float64(n))
+}
+
+// Requests returns the number of the requests of the class.
+func (s *RequestStats) Requests(class RequestClass) int64 {
+	if s == nil {
+		return 0
+	}
+	return s.requests[class].Load()
+}
+
+// Bytes returns the bytes transferred by the requests of the class, including both the request bodies
+// and the response bodies.
+func (s *RequestStats) Bytes(class RequestClass) int64 {
+	if s == nil {
+		return 0
+	}
+	return s.bytes[class].Load()
+}
+
+// Report logs the requests sent by the BR process during the task. Nothing is reported if no request is
+// sent, e.g. the storage is local.
+func (s *RequestStats) Report() {
+	if s == nil {
+		return
+	}
+	fields := make([]zap.Field, 0, 2*len(RequestClasses))
+	var total int64
+	for _, class := range RequestClasses {
+		total += s.Requests(class)
+		fields = append(fields, zap.Int64(class.String()+"-requests", s.Requests(class)),
+			zap.Int64(class.String()+"-bytes", s.Bytes(class)))
+	}
+	if total == 0 {
+		return
+	}
+	log.Info("the requests sent to the external storage by the BR process during the task, "+
+		"the requests sent by TiKV aren't included", fields...)
+}
+
+// requestStatsTransport is the transport of the storage counting the requests.
+type requestStatsTransport struct {
+	stats *RequestStats
+	inner http.RoundTripper
+}
+
+func (t *requestStatsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if isCredentialRequest(req) {
+		return t.inner.RoundTrip(req)
+	}
+	class := classifyRequest(req)
+	t.stats.addRequest(class)
+	if req.ContentLength > 0 {
+		t.stats.addBytes(class, req.ContentLength)
+	}
+	resp, err := t.inner.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = &requestStatsBody{ReadCloser: resp.Body, stats: t.stats, class: class}
+	return resp, nil
+}
+
+// requestStatsBody counts the bytes of the response body actually read, since the reader may close the
+// body before reading it to the end, e.g. seeking in the object.
+type requestStatsBody struct {
+	io.ReadCloser
+	stats *RequestStats
+	class RequestClass
+}
+
+func (b *requestStatsBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 {
+		b.stats.addBytes(b.class, int64(n))
+	}
+	return n, err
+}
@@ -0,0 +1,92 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package storage
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestClassifyRequest(t *testing.T) {
+	cases := []struct {
+		method string
+		url    string
+		class  RequestClass
+	}{
+		{http.MethodGet, "https://bucket.s3.amazonaws.com/prefix/1.sst", RequestGet},
+		{http.MethodGet, "https://bucket.s3.amazonaws.com/?list-type=2&prefix=prefix%2F", RequestList},
+		{http.MethodGet, "https://oss-cn-hangzhou.aliyuncs.com/?marker=a&max-keys=1000", RequestList},
+		{http.MethodGet, "https://account.blob.core.windows.net/container?restype=container&comp=list", RequestList},
+		{http.MethodGet, "https://storage.googleapis.com/storage/v1/b/bucket/o?pageToken=abc", RequestList},
+		{http.MethodHead, "https://bucket.s3.amazonaws.com/prefix/backupmeta", RequestHead},
+		{http.MethodPut, "https://bucket.s3.amazonaws.com/prefix/1.sst?partNumber=1&uploadId=x", RequestPut},
+		{http.MethodPost, "https://bucket.s3.amazonaws.com/?delete", RequestPut},
+		{http.MethodDelete, "https://bucket.s3.amazonaws.com/prefix/1.sst", RequestDelete},
+	}
+	for _, c := range cases {
+		req, err := http.NewRequest(c.method, c.url, nil)
+		require.NoError(t, err)
+		require.Equal(t, c.class, classifyRequest(req), "%s %s", c.method, c.url)
+		require.False(t, isCredentialRequest(req))
+	}
+	for _, url := range []string{"https://sts.amazonaws.com/", "https://sts.us-west-2.amazonaws.com/",
+		"http://169.254.169.254/latest/meta-data/iam/security-credentials/"} {
+		req, err := http.NewRequest(http.MethodPost, url, nil)
+		require.NoError(t, err)
+		require.True(t, isCredentialRequest(req), url)
+	}
+}
+
+func TestRequestStats(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+		if r.Method == http.MethodGet {
+			_, _ = w.Write([]byte("0123456789"))
+		}
+	}))
+	defer server.Close()
+
+	stats := NewRequestStats()
+	opts := stats.apply(&ExternalStorageOptions{})
+	client := opts.HTTPClient
+
+	resp, err := client.Get(server.URL + "/file")
+	require.NoError(t, err)
+	_, err = io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+
+	// only the bytes read are counted.
+	resp, err = client.Get(server.URL + "/file")
+	require.NoError(t, err)
+	_, err = io.ReadFull(resp.Body, make([]byte, 4))
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+
+	resp, err = client.Get(server.URL + "/?list-type=2")
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+
+	req, err := http.NewRequest(http.MethodPut, server.URL+"/file", strings.NewReader("abcdef"))
+	require.NoError(t, err)
+	resp, err = client.Do(req)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+
+	require.EqualValues(t, 2, stats.Requests(RequestGet))
+	require.EqualValues(t, 14, stats.Bytes(RequestGet))
+	require.EqualValues(t, 1, stats.Requests(RequestList))
+	require.EqualValues(t, 1, stats.Requests(RequestPut))
+	require.EqualValues(t, 6, stats.Bytes(RequestPut))
+	require.EqualValues(t, 0, stats.Requests(RequestHead))
+
+	// the nil stats count nothing.
+	var nilStats *RequestStats
+	require.Same(t, opts, nilStats.apply(opts))
+	require.EqualValues(t, 0, nilStats.Requests(RequestGet))
+}
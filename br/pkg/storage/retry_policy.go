@@ -0,0 +1,334 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package storage
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/log"
+	berrors "github.com/pingcap/tidb/br/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// DegradedPeriod is a period in which the requests to the endpoint kept failing.
+type DegradedPeriod struct {
+	Endpoint string
+	Start    time.Time
+	End      time.Time
+	Failures int64
+}
+
+type circuitBreaker struct {
+	// consecutiveFailures is reset by a successful request.
+	consecutiveFailures int
+	// openUntil is the end of the cooldown, the requests wait for it before being sent. It's zero if the
+	// breaker is closed.
+	openUntil time.Time
+	// probing is closed when the request probing the endpoint after the cooldown finishes, the other requests
+	// wait for it while the breaker is half-open. It's nil if no request is probing.
+	probing chan struct{}
+	// degraded is the current degraded period, nil if the endpoint isn't degraded.
+	degraded *DegradedPeriod
+	failures int64
+}
+
+// RetryPolicy is the task-level policy of the failed requests to the external storage, shared by all the
+// storages of the task. The retries are still done by the SDKs of the storages and the callers, and the
+// policy limits them:
+//   - every failed request, i.e. the network error, 5xx and 429, consumes the error budget of the task,
+//     and the task fails with ErrStorageRetryBudgetExhausted once the budget is exhausted, instead of retrying
+//     forever or aborting at whichever call site exceeds its own retry limit first.
+//   - the circuit breaker of the endpoint opens after the consecutive failures reach the threshold, and the
+//     requests to the endpoint wait for the cooldown before being sent, so the retries don't hammer the
+//     endpoint which is throttling. After the cooldown the breaker is half-open, a single request is sent
+//     to probe the endpoint while the others wait for its result. The breaker closes if the probe succeeds,
+//     or opens again if it fails.
+//
+// The periods in which the breakers are open are reported when the task finishes.
+type RetryPolicy struct {
+	budget    int64
+	threshold int
+	cooldown  time.Duration
+
+	failures atomic.Int64
+	mu       sync.Mutex
+	breakers map[string]*circuitBreaker
+	degraded []DegradedPeriod
+}
+
+// NewRetryPolicy creates the retry policy. The budget is the max number of the failed requests of the task,
+// 0 means unlimited. The circuit breaker is disabled if the threshold is 0.
+func NewRetryPolicy(budget int64, threshold int, cooldown time.Duration) *RetryPolicy {
+	return &RetryPolicy{
+		budget:    budget,
+		threshold: threshold,
+		cooldown:  cooldown,
+		breakers:  make(map[string]*circuitBreaker),
+	}
+}
+
+// apply returns the options whose HTTP client sends the requests by the policy. The options are copied,
+// because some storages, e.g. GCS, modify the client.
+func (p *RetryPolicy) apply(opts *ExternalStorageOptions) *ExternalStorageOptions {
+	if p == nil {
+		return opts
+	}
+	newOpts := *opts
+	newOpts.HTTPClient = p.wrapClient(opts.HTTPClient)
+	return &newOpts
+}
+
+// wrapClient returns a copy of the client sending the requests by the policy, nil means the default client.
+func (p *RetryPolicy) wrapClient(client *http.Client) *http.Client {
+	if p == nil {
+		return client
+	}
+	newClient := &http.Client{}
+	if client != nil {
+		*newClient = *client
+	}
+	transport := newClient.Transport
+	if transport == nil {
+		transport, _ = CloneDefaultHttpTransport()
+	}
+	newClient.Transport = &retryPolicyTransport{policy: p, inner: transport}
+	return newClient
+}
+
+func (p *RetryPolicy) breaker(endpoint string) *circuitBreaker {
+	b, ok := p.breakers[endpoint]
+	if !ok {
+		b = &circuitBreaker{}
+		p.breakers[endpoint] = b
+	}
+	return b
+}
+
+// wait waits for the cooldown of the endpoint if its circuit breaker is open, and for the probe if it's
+// half-open. It returns whether the request is the one probing the endpoint, which should be finished by
+// onSuccess, onFailure or abortProbe.
+func (p *RetryPolicy) wait(ctx context.Context, endpoint string) (probe bool, err error) {
+	for {
+		p.mu.Lock()
+		b := p.breaker(endpoint)
+		if b.openUntil.IsZero() {
+			p.mu.Unlock()
+			return false, nil
+		}
+		d := time.Until(b.openUntil)
+		if d <= 0 && b.probing == nil {
+			b.probing = make(chan struct{})
+			p.mu.Unlock()
+			return true, nil
+		}
+		probing := b.probing
+		p.mu.Unlock()
+
+		if d > 0 {
+			timer := time.NewTimer(d)
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				timer.Stop()
+				return false, errors.Trace(ctx.Err())
+			}
+			continue
+		}
+		select {
+		case <-probing:
+		case <-ctx.Done():
+			return false, errors.Trace(ctx.Err())
+		}
+	}
+}
+
+// finishProbe wakes up the requests waiting for the probe of the breaker.
+func (b *circuitBreaker) finishProbe() {
+	if b.probing != nil {
+		close(b.probing)
+		b.probing = nil
+	}
+}
+
+// abortProbe finishes the probe canceled by the caller, another waiting request probes the endpoint then.
+func (p *RetryPolicy) abortProbe(endpoint string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.breaker(endpoint).finishProbe()
+}
+
+// onSuccess closes the circuit breaker of the endpoint.
+func (p *RetryPolicy) onSuccess(endpoint string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	b := p.breaker(endpoint)
+	b.consecutiveFailures = 0
+	b.openUntil = time.Time{}
+	b.finishProbe()
+	if b.degraded != nil {
+		period := *b.degraded
+		period.End = time.Now()
+		p.degraded = append(p.degraded, period)
+		b.degraded = nil
+		log.Info("the external storage endpoint is recovered", zap.String("endpoint", endpoint),
+			zap.Duration("degraded", period.End.Sub(period.Start)), zap.Int64("failures", period.Failures))
+	}
+}
+
+// onFailure records the failed request, and returns an error if the budget is exhausted. The breaker opens
+// again if the request is the probe.
+func (p *RetryPolicy) onFailure(endpoint string, reason string, probe bool) error {
+	failures := p.failures.Add(1)
+	p.mu.Lock()
+	b := p.breaker(endpoint)
+	b.failures++
+	b.consecutiveFailures++
+	if b.degraded != nil {
+		b.degraded.Failures++
+	}
+	if probe || (p.threshold > 0 && b.consecutiveFailures >= p.threshold) {
+		b.openUntil = time.Now().Add(p.cooldown)
+		if b.degraded == nil {
+			b.degraded = &DegradedPeriod{Endpoint: endpoint, Start: time.Now(), Failures: int64(b.consecutiveFailures)}
+			log.Warn("the external storage endpoint keeps failing, pause the requests to it",
+				zap.String("endpoint", endpoint), zap.Int("consecutive-failures", b.consecutiveFailures),
+				zap.Duration("cooldown", p.cooldown), zap.String("reason", reason))
+		}
+	}
+	if probe {
+		b.finishProbe()
+	}
+	p.mu.Unlock()
+	if p.budget > 0 && failures > p.budget {
+		return errors.Annotatef(berrors.ErrStorageRetryBudgetExhausted,
+			"%d requests to the external storage failed, the last one to %s: %s", failures, endpoint, reason)
+	}
+	return nil
+}
+
+// Failures returns the number of the failed requests.
+func (p *RetryPolicy) Failures() int64 {
+	return p.failures.Load()
+}
+
+// DegradedPeriods returns the periods in which the circuit breakers are open, including the ones not
+// recovered yet.
+func (p *RetryPolicy) DegradedPeriods() []DegradedPeriod {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	periods := append([]DegradedPeriod{}, p.degraded...)
+	for _, b := range p.breakers {
+		if b.degraded != nil {
+			period := *b.degraded
+			period.End = time.Now()
+			periods = append(periods, period)
+		}
+	}
+	sort.Slice(periods, func(i, j int) bool { return periods[i].Start.Before(periods[j].Start) })
+	return periods
+}
+
+// Report logs the failed requests and the degraded periods when the task finishes. Nothing is reported
+// if no request failed.
+func (p *RetryPolicy) Report() {
+	if p == nil || p.Failures() == 0 {
+		return
+	}
+	periods := p.DegradedPeriods()
+	var degraded time.Duration
+	for _, period := range periods {
+		degraded += period.End.Sub(period.Start)
+		log.Warn("the external storage endpoint was degraded", zap.String("endpoint", period.Endpoint),
+			zap.Time("start", period.Start), zap.Time("end", period.End),
+			zap.Duration("duration", period.End.Sub(period.Start)), zap.Int64("failures", period.Failures))
+	}
+	p.mu.Lock()
+	endpointFailures := make(map[string]int64, len(p.breakers))
+	for endpoint, b := range p.breakers {
+		if b.failures > 0 {
+			endpointFailures[endpoint] = b.failures
+		}
+	}
+	p.mu.Unlock()
+	log.Warn("some requests to the external storage failed during the task",
+		zap.Int64("failures", p.Failures()), zap.Any("endpoint-failures", endpointFailures),
+		zap.Int("degraded-periods", len(periods)), zap.Duration("degraded-duration", degraded))
+}
+
+// retryPolicyTransport is the transport of the storage sending the requests by the retry policy.
+type retryPolicyTransport struct {
+	policy *RetryPolicy
+	inner  http.RoundTripper
+}
+
+func (t *retryPolicyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	endpoint := req.URL.Host
+	if isCredentialRequest(req) {
+		return t.inner.RoundTrip(req)
+	}
+	probe, err := t.policy.wait(req.Context(), endpoint)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := t.inner.RoundTrip(req)
+	var reason string
+	switch {
+	case err != nil:
+		if req.Context().Err() != nil {
+			// canceled by the caller, not the failure of the endpoint.
+			if probe {
+				t.policy.abortProbe(endpoint)
+			}
+			return nil, err
+		}
+		reason = err.Error()
+	case resp.StatusCode >= http.StatusInternalServerError || resp.StatusCode == http.StatusTooManyRequests:
+		reason = resp.Status
+	default:
+		t.policy.onSuccess(endpoint)
+		resp.Body = &retryPolicyBody{ReadCloser: resp.Body, policy: t.policy, endpoint: endpoint, ctx: req.Context()}
+		return resp, nil
+	}
+	if budgetErr := t.policy.onFailure(endpoint, reason, probe); budgetErr != nil {
+		if resp != nil {
+			_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+			_ = resp.Body.Close()
+		}
+		return nil, budgetErr
+	}
+	return resp, err
+}
+
+// retryPolicyBody records the failure of reading the body, e.g. the connection is reset in the middle, which
+// is retried by reopening the file.
+type retryPolicyBody struct {
+	io.ReadCloser
+	policy   *RetryPolicy
+	endpoint string
+	ctx      context.Context
+}
+
+func (b *retryPolicyBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err != nil && err != io.EOF && b.ctx.Err() == nil { //nolint:errorlint
+		if budgetErr := b.policy.onFailure(b.endpoint, err.Error(), false); budgetErr != nil {
+			return n, budgetErr
+		}
+	}
+	return n, err
+}
+
+// isRetryBudgetExhaustedError checks the error by the message, because the error is wrapped by the SDKs
+// without unwrapping, e.g. by the awserr of S3.
+func isRetryBudgetExhaustedError(err error) bool {
+	return err != nil && (berrors.Is(err, berrors.ErrStorageRetryBudgetExhausted) ||
+		strings.Contains(err.Error(), string(berrors.ErrStorageRetryBudgetExhausted.RFCCode())))
+}
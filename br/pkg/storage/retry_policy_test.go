@@ -0,0 +1,190 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package storage
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/pingcap/errors"
+	backuppb "github.com/pingcap/kvproto/pkg/brpb"
+	berrors "github.com/pingcap/tidb/br/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+// newFlakyServer returns the server failing the requests with 503 while failing is set.
+func newFlakyServer(failing *atomic.Bool, requests *atomic.Int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if failing.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+}
+
+func TestRetryPolicyCircuitBreaker(t *testing.T) {
+	var failing atomic.Bool
+	var requests atomic.Int32
+	server := newFlakyServer(&failing, &requests)
+	defer server.Close()
+
+	cooldown := 200 * time.Millisecond
+	policy := NewRetryPolicy(0, 2, cooldown)
+	client := policy.apply(&ExternalStorageOptions{}).HTTPClient
+	get := func(ctx context.Context) (int, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+		require.NoError(t, err)
+		resp, err := client.Do(req)
+		if err != nil {
+			return 0, err
+		}
+		defer resp.Body.Close()
+		return resp.StatusCode, nil
+	}
+
+	failing.Store(true)
+	for range 2 {
+		code, err := get(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, http.StatusServiceUnavailable, code)
+	}
+	require.EqualValues(t, 2, policy.Failures())
+	require.Len(t, policy.DegradedPeriods(), 1)
+
+	// the breaker is open, the request is paused until the cooldown ends or it's canceled.
+	ctx, cancel := context.WithTimeout(context.Background(), cooldown/4)
+	_, err := get(ctx)
+	cancel()
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.EqualValues(t, 2, requests.Load())
+	require.EqualValues(t, 2, policy.Failures())
+
+	failing.Store(false)
+	start := time.Now()
+	code, err := get(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, code)
+	require.GreaterOrEqual(t, time.Since(start), cooldown/2)
+
+	periods := policy.DegradedPeriods()
+	require.Len(t, periods, 1)
+	require.Equal(t, server.Listener.Addr().String(), periods[0].Endpoint)
+	require.EqualValues(t, 2, periods[0].Failures)
+	require.False(t, periods[0].End.Before(periods[0].Start))
+
+	// the endpoint is recovered, the requests aren't paused.
+	start = time.Now()
+	_, err = get(context.Background())
+	require.NoError(t, err)
+	require.Less(t, time.Since(start), cooldown/2)
+	policy.Report()
+}
+
+func TestRetryPolicyHalfOpen(t *testing.T) {
+	var failing atomic.Bool
+	var requests atomic.Int32
+	server := newFlakyServer(&failing, &requests)
+	defer server.Close()
+
+	cooldown := 200 * time.Millisecond
+	policy := NewRetryPolicy(0, 1, cooldown)
+	client := policy.apply(&ExternalStorageOptions{}).HTTPClient
+	getAll := func(n int) []int {
+		codes := make([]int, n)
+		var wg sync.WaitGroup
+		for i := range n {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				resp, err := client.Get(server.URL)
+				require.NoError(t, err)
+				codes[i] = resp.StatusCode
+				require.NoError(t, resp.Body.Close())
+			}()
+		}
+		wg.Wait()
+		return codes
+	}
+
+	failing.Store(true)
+	require.Equal(t, []int{http.StatusServiceUnavailable}, getAll(1))
+	require.EqualValues(t, 1, requests.Load())
+
+	// a single request probes the endpoint after every cooldown while the others wait.
+	var codes []int
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		codes = getAll(5)
+	}()
+	time.Sleep(cooldown + cooldown/2)
+	require.EqualValues(t, 2, requests.Load())
+	failing.Store(false)
+	<-done
+	// the failed probe and the successful one, after which the others are sent.
+	require.EqualValues(t, 6, requests.Load())
+	slices.Sort(codes)
+	require.Equal(t, []int{http.StatusOK, http.StatusOK, http.StatusOK, http.StatusOK, http.StatusServiceUnavailable}, codes)
+	periods := policy.DegradedPeriods()
+	require.Len(t, periods, 1)
+	require.EqualValues(t, 2, periods[0].Failures)
+}
+
+func TestRetryPolicyBudget(t *testing.T) {
+	var failing atomic.Bool
+	var requests atomic.Int32
+	server := newFlakyServer(&failing, &requests)
+	defer server.Close()
+	failing.Store(true)
+
+	policy := NewRetryPolicy(2, 0, 0)
+	client := policy.apply(&ExternalStorageOptions{}).HTTPClient
+	for range 2 {
+		resp, err := client.Get(server.URL)
+		require.NoError(t, err)
+		require.NoError(t, resp.Body.Close())
+	}
+	_, err := client.Get(server.URL)
+	require.ErrorContains(t, err, string(berrors.ErrStorageRetryBudgetExhausted.RFCCode()))
+	require.True(t, isRetryBudgetExhaustedError(err))
+	// the SDKs may wrap the error without unwrapping.
+	require.True(t, isRetryBudgetExhaustedError(awserr.New("RequestError", "send request failed", err)))
+	require.False(t, isRetryBudgetExhaustedError(errors.New("connection reset")))
+	require.Empty(t, policy.DegradedPeriods())
+}
+
+func TestS3RetryBudgetExhausted(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	policy := NewRetryPolicy(1, 0, 0)
+	s, err := New(ctx, &backuppb.StorageBackend{Backend: &backuppb.StorageBackend_S3{S3: &backuppb.S3{
+		Endpoint:        server.URL,
+		Bucket:          "test",
+		Prefix:          "budget",
+		AccessKey:       "none",
+		SecretAccessKey: "none",
+		Provider:        "skip check region",
+		ForcePathStyle:  true,
+	}}}, &ExternalStorageOptions{RetryPolicy: policy})
+	require.NoError(t, err)
+	// the S3 SDK stops retrying once the budget is exhausted.
+	_, err = s.ReadFile(ctx, "file")
+	require.True(t, isRetryBudgetExhaustedError(err))
+	require.EqualValues(t, 2, requests.Load())
+	require.EqualValues(t, 2, policy.Failures())
+}
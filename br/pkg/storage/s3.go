@@ -158,6 +158,9 @@ type S3BackendOptions struct {
 	RoleARN               string `json:"role-arn" toml:"role-arn"`
 	ExternalID            string `json:"external-id" toml:"external-id"`
 	ObjectLockEnabled     bool   `json:"object-lock-enabled" toml:"object-lock-enabled"`
+	// InternalEndpoint selects the internal endpoint of the region for OSS and COS, it's only used when
+	// the endpoint isn't specified.
+	InternalEndpoint bool `json:"internal-endpoint" toml:"internal-endpoint"`
 }
 
 // Apply apply s3 options on backuppb.S3.
@@ -275,6 +278,9 @@ func NewS3StorageForTest(svc s3iface.S3API, options *backuppb.S3) *S3Storage {
 // auto access without ak / sk.
 func autoNewCred(qs *backuppb.S3) (cred *credentials.Credentials, err error) {
 	if qs.AccessKey != "" && qs.SecretAccessKey != "" {
+		if cred := newSecretCredentials(qs.AccessKey); cred != nil {
+			return cred, nil
+		}
 		return credentials.NewStaticCredentials(qs.AccessKey, qs.SecretAccessKey, qs.SessionToken), nil
 	}
 	endpoint := qs.Endpoint
@@ -350,6 +356,9 @@ func NewS3Storage(ctx context.Context, backend *backuppb.S3, opts *ExternalStora
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
+	// the session requires the transport of the client is *http.Transport to load the custom CA bundle, so
+	// the client is wrapped by the retry policy and the request stats after the session is created.
+	ses.Config.HTTPClient = opts.RetryPolicy.wrapClient(opts.RequestStats.wrapClient(ses.Config.HTTPClient))
 
 	if !opts.SendCredentials {
 		// Clear the credentials if exists so that they will not be sent to TiKV
@@ -573,6 +582,62 @@ func (rs *S3Storage) WriteFile(ctx context.Context, file string, data []byte) er
 	return errors.Trace(err)
 }
 
+// the error codes of the failed conditional writes, S3 returns ConditionalRequestConflict
+// if there is a concurrent conditional write of the same object.
+const (
+	s3ErrCodePreconditionFailed         = "PreconditionFailed"
+	s3ErrCodeConditionalRequestConflict = "ConditionalRequestConflict"
+)
+
+// ReadFileWithVersion implements ConditionalWriter, the version is the ETag.
+func (rs *S3Storage) ReadFileWithVersion(ctx context.Context, file string) ([]byte, string, error) {
+	input := &s3.GetObjectInput{
+		Bucket: aws.String(rs.options.Bucket),
+		Key:    aws.String(rs.options.Prefix + file),
+	}
+	result, err := rs.svc.GetObjectWithContext(ctx, input)
+	if err != nil {
+		if aerr, ok := errors.Cause(err).(awserr.Error); ok { // nolint:errorlint
+			switch aerr.Code() {
+			case s3.ErrCodeNoSuchKey, notFound:
+				return nil, "", nil
+			}
+		}
+		return nil, "", errors.Annotatef(err,
+			"failed to read s3 file, file info: input.bucket='%s', input.key='%s'",
+			*input.Bucket, *input.Key)
+	}
+	//nolint: errcheck
+	defer result.Body.Close()
+	data, err := io.ReadAll(result.Body)
+	if err != nil {
+		return nil, "", errors.Trace(err)
+	}
+	return data, aws.StringValue(result.ETag), nil
+}
+
+// WriteFileIfMatch implements ConditionalWriter by the conditional writes of S3.
+// note that some S3 compatible storages ignore the conditions.
+func (rs *S3Storage) WriteFileIfMatch(ctx context.Context, file string, data []byte, version string) (string, error) {
+	input := buildPutObjectInput(rs.options, file, data)
+	header := map[string]string{"If-None-Match": "*"}
+	if len(version) > 0 {
+		header = map[string]string{"If-Match": version}
+	}
+	output, err := rs.svc.PutObjectWithContext(ctx, input, request.WithSetRequestHeaders(header))
+	if err != nil {
+		if aerr, ok := errors.Cause(err).(awserr.Error); ok { // nolint:errorlint
+			switch aerr.Code() {
+			case s3ErrCodePreconditionFailed, s3ErrCodeConditionalRequestConflict:
+				return "", errors.Annotatef(berrors.ErrStorageConditionNotMet,
+					"file info: input.bucket='%s', input.key='%s'", *input.Bucket, *input.Key)
+			}
+		}
+		return "", errors.Trace(err)
+	}
+	return aws.StringValue(output.ETag), nil
+}
+
 // ReadFile reads the file from the storage and returns the contents.
 func (rs *S3Storage) ReadFile(ctx context.Context, file string) ([]byte, error) {
 	var (
@@ -599,7 +664,7 @@ func (rs *S3Storage) ReadFile(ctx context.Context, file string) ([]byte, error)
 			readErr = errors.Errorf("read: connection reset by peer")
 		})
 		if readErr != nil {
-			if isDeadlineExceedError(readErr) || isCancelError(readErr) {
+			if isDeadlineExceedError(readErr) || isCancelError(readErr) || isRetryBudgetExhaustedError(readErr) {
 				return nil, errors.Annotatef(readErr, "failed to read body from get object result, file info: input.bucket='%s', input.key='%s', retryCnt='%d'",
 					*input.Bucket, *input.Key, retryCnt)
 			}
@@ -656,6 +721,19 @@ func (rs *S3Storage) DeleteFiles(ctx context.Context, files []string) error {
 	return nil
 }
 
+// PresignFile implements Presigner.
+func (rs *S3Storage) PresignFile(_ context.Context, file string, expire time.Duration) (string, error) {
+	req, _ := rs.svc.GetObjectRequest(&s3.GetObjectInput{
+		Bucket: aws.String(rs.options.Bucket),
+		Key:    aws.String(rs.options.Prefix + file),
+	})
+	u, err := req.Presign(expire)
+	if err != nil {
+		return "", errors.Annotatef(err, "failed to presign s3 file %s", file)
+	}
+	return u, nil
+}
+
 // FileExists check if file exists on s3 storage.
 func (rs *S3Storage) FileExists(ctx context.Context, file string) (bool, error) {
 	input := &s3.HeadObjectInput{
@@ -704,6 +782,9 @@ func (rs *S3Storage) WalkDir(ctx context.Context, opt *WalkOption, fn func(strin
 		Prefix:  aws.String(prefix),
 		MaxKeys: aws.Int64(maxKeys),
 	}
+	if len(opt.StartAfter) > 0 {
+		req.Marker = aws.String(path.Join(rs.options.Prefix, opt.StartAfter))
+	}
 
 	for {
 		// FIXME: We can't use ListObjectsV2, it is not universally supported.
@@ -923,7 +1004,7 @@ func (r *s3ObjectReader) Read(p []byte) (n int, err error) {
 	n, err = r.reader.Read(p[:maxCnt])
 	// TODO: maybe we should use !errors.Is(err, io.EOF) here to avoid error lint, but currently, pingcap/errors
 	// doesn't implement this method yet.
-	for err != nil && errors.Cause(err) != io.EOF && retryCnt < maxErrorRetries { //nolint:errorlint
+	for err != nil && errors.Cause(err) != io.EOF && !isRetryBudgetExhaustedError(err) && retryCnt < maxErrorRetries { //nolint:errorlint
 		log.L().Warn(
 			"read s3 object failed, will retry",
 			zap.String("file", r.name),
@@ -1184,6 +1265,9 @@ func (rl retryerWithLog) ShouldRetry(r *request.Request) bool {
 		log.Warn("failed to get EC2 metadata. skipping.", logutil.ShortError(r.Error))
 		return false
 	}
+	if isRetryBudgetExhaustedError(r.Error) {
+		return false
+	}
 	if isConnectionResetError(r.Error) {
 		return true
 	}
@@ -15,14 +15,18 @@ import (
 	"strings"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/s3"
 	"github.com/pingcap/errors"
 	"github.com/pingcap/failpoint"
 	backuppb "github.com/pingcap/kvproto/pkg/brpb"
+	berrors "github.com/pingcap/tidb/br/pkg/errors"
 	"github.com/pingcap/tidb/br/pkg/mock"
 	. "github.com/pingcap/tidb/br/pkg/storage"
 	"github.com/stretchr/testify/require"
@@ -437,6 +441,23 @@ func TestS3URI(t *testing.T) {
 	require.Equal(t, "s3://bucket/prefix/", storage.URI())
 }
 
+func TestS3Presign(t *testing.T) {
+	sess, err := session.NewSession(&aws.Config{
+		Region:      aws.String("us-west-2"),
+		Credentials: credentials.NewStaticCredentials("ab", "cd", ""),
+	})
+	require.NoError(t, err)
+	s := NewS3StorageForTest(s3.New(sess), &backuppb.S3{Bucket: "bucket", Prefix: "prefix/"})
+
+	var presigner Presigner = s
+	u, err := presigner.PresignFile(context.Background(), "1/1_default.sst", 10*time.Minute)
+	require.NoError(t, err)
+	require.Contains(t, u, "bucket")
+	require.Contains(t, u, "/prefix/1/1_default.sst")
+	require.Contains(t, u, "X-Amz-Expires=600")
+	require.Contains(t, u, "X-Amz-Signature=")
+}
+
 func TestS3Range(t *testing.T) {
 	contentRange := "bytes 0-9/443"
 	ri, err := ParseRangeInfo(&contentRange)
@@ -1139,6 +1160,40 @@ func TestWalkDir(t *testing.T) {
 	require.Len(t, contents, i)
 }
 
+func TestWalkDirStartAfter(t *testing.T) {
+	s := createS3Suite(t)
+	ctx := aws.BackgroundContext()
+
+	contents := []*s3.Object{
+		{
+			Key:  aws.String("prefix/sp/10-t.jpg"),
+			Size: aws.Int64(44151),
+		},
+	}
+	s.s3.EXPECT().
+		ListObjectsWithContext(ctx, gomock.Any()).
+		DoAndReturn(func(_ context.Context, input *s3.ListObjectsInput, opt ...request.Option) (*s3.ListObjectsOutput, error) {
+			require.Equal(t, "prefix/sp/", aws.StringValue(input.Prefix))
+			require.Equal(t, "prefix/sp/10-f.png", aws.StringValue(input.Marker))
+			return &s3.ListObjectsOutput{
+				IsTruncated: aws.Bool(false),
+				Contents:    contents,
+			}, nil
+		})
+
+	var paths []string
+	err := s.storage.WalkDir(
+		ctx,
+		&WalkOption{SubDir: "sp", StartAfter: "sp/10-f.png"},
+		func(path string, size int64) error {
+			paths = append(paths, path)
+			return nil
+		},
+	)
+	require.NoError(t, err)
+	require.Equal(t, []string{"sp/10-t.jpg"}, paths)
+}
+
 // TestWalkDirBucket checks WalkDir retrieves all directory content under a bucket.
 func TestWalkDirWithEmptyPrefix(t *testing.T) {
 	controller := gomock.NewController(t)
@@ -1472,3 +1527,50 @@ func TestS3ReadFileRetryable(t *testing.T) {
 	require.Error(t, err)
 	require.True(t, strings.Contains(err.Error(), errMsg))
 }
+
+func TestS3ConditionalWrite(t *testing.T) {
+	s := createS3Suite(t)
+	ctx := aws.BackgroundContext()
+
+	s.s3.EXPECT().
+		GetObjectWithContext(ctx, gomock.Any()).
+		Return(nil, awserr.New(s3.ErrCodeNoSuchKey, "no such key", nil))
+	data, version, err := s.storage.ReadFileWithVersion(ctx, "cp")
+	require.NoError(t, err)
+	require.Nil(t, data)
+	require.Empty(t, version)
+
+	conditionHeader := func(opts []request.Option) http.Header {
+		req := &request.Request{HTTPRequest: &http.Request{Header: http.Header{}}}
+		req.ApplyOptions(opts...)
+		req.Handlers.Build.Run(req)
+		return req.HTTPRequest.Header
+	}
+	s.s3.EXPECT().
+		PutObjectWithContext(ctx, gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, input *s3.PutObjectInput, opts ...request.Option) (*s3.PutObjectOutput, error) {
+			require.Equal(t, "prefix/cp", aws.StringValue(input.Key))
+			require.Equal(t, "*", conditionHeader(opts).Get("If-None-Match"))
+			return &s3.PutObjectOutput{ETag: aws.String(`"v1"`)}, nil
+		})
+	version, err = s.storage.WriteFileIfMatch(ctx, "cp", []byte("1"), "")
+	require.NoError(t, err)
+	require.Equal(t, `"v1"`, version)
+
+	s.s3.EXPECT().
+		PutObjectWithContext(ctx, gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ *s3.PutObjectInput, opts ...request.Option) (*s3.PutObjectOutput, error) {
+			require.Equal(t, `"v1"`, conditionHeader(opts).Get("If-Match"))
+			return nil, awserr.New("PreconditionFailed", "precondition failed", nil)
+		})
+	_, err = s.storage.WriteFileIfMatch(ctx, "cp", []byte("2"), `"v1"`)
+	require.ErrorIs(t, err, berrors.ErrStorageConditionNotMet)
+
+	s.s3.EXPECT().
+		GetObjectWithContext(ctx, gomock.Any()).
+		Return(&s3.GetObjectOutput{Body: io.NopCloser(strings.NewReader("2")), ETag: aws.String(`"v2"`)}, nil)
+	data, version, err = s.storage.ReadFileWithVersion(ctx, "cp")
+	require.NoError(t, err)
+	require.Equal(t, []byte("2"), data)
+	require.Equal(t, `"v2"`, version)
+}
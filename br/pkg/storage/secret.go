@@ -0,0 +1,448 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+	"github.com/pingcap/errors"
+	"github.com/pingcap/log"
+	berrors "github.com/pingcap/tidb/br/pkg/errors"
+	"github.com/pingcap/tidb/pkg/util"
+	"go.uber.org/zap"
+)
+
+const (
+	// credentialRefParam is the query parameter of the storage URL referencing the credentials in a secret
+	// manager, in the form of `<provider>:<path>[#<key>]`, e.g. `vault:secret/data/br#s3`.
+	credentialRefParam = "credential-ref"
+	// credentialsBlobParam is the parameter in the secret holding the content of the GCS credentials file.
+	credentialsBlobParam = "credentials-blob"
+
+	// defaultSecretTTL is how long a secret is cached if the secret manager doesn't tell.
+	defaultSecretTTL = 5 * time.Minute
+	// secretResolveTimeout is the timeout of reading a secret from the secret manager.
+	secretResolveTimeout = 30 * time.Second
+
+	vaultSecretProviderName = "vault"
+	awsSecretProviderName   = "aws-sm"
+)
+
+// SecretProvider reads the secrets from a secret manager.
+type SecretProvider interface {
+	// GetSecret returns the fields of the secret at the path, and how long they're valid, zero means unknown.
+	GetSecret(ctx context.Context, path string) (map[string]string, time.Duration, error)
+}
+
+var (
+	secretProvidersMu sync.RWMutex
+	secretProviders   = map[string]SecretProvider{
+		vaultSecretProviderName: &vaultSecretProvider{},
+		awsSecretProviderName:   awsSecretProvider{},
+	}
+	defaultSecretCache = newSecretCache()
+)
+
+// RegisterSecretProvider registers the provider referenced by the name in the credential references, the
+// existing one of the name is replaced.
+func RegisterSecretProvider(name string, provider SecretProvider) {
+	secretProvidersMu.Lock()
+	defer secretProvidersMu.Unlock()
+	secretProviders[name] = provider
+}
+
+func getSecretProvider(name string) (SecretProvider, bool) {
+	secretProvidersMu.RLock()
+	defer secretProvidersMu.RUnlock()
+	provider, ok := secretProviders[name]
+	return provider, ok
+}
+
+// credentialRef is a parsed reference to the credentials in a secret manager.
+type credentialRef struct {
+	provider string
+	path     string
+	// key is the field of the secret holding the parameters in a JSON object, all the fields of the secret
+	// are the parameters if it's empty.
+	key string
+}
+
+func parseCredentialRef(ref string) (credentialRef, error) {
+	provider, rest, ok := strings.Cut(ref, ":")
+	if !ok || provider == "" {
+		return credentialRef{}, errors.Annotatef(berrors.ErrStorageInvalidConfig,
+			"invalid credential reference %q, should be like <provider>:<path>[#<key>]", ref)
+	}
+	path, key, _ := strings.Cut(rest, "#")
+	if path == "" {
+		return credentialRef{}, errors.Annotatef(berrors.ErrStorageInvalidConfig,
+			"the path of the secret is missing in the credential reference %q", ref)
+	}
+	return credentialRef{provider: provider, path: path, key: key}, nil
+}
+
+func (r credentialRef) String() string {
+	if r.key == "" {
+		return r.provider + ":" + r.path
+	}
+	return r.provider + ":" + r.path + "#" + r.key
+}
+
+type cachedSecret struct {
+	fields   map[string]string
+	expireAt time.Time
+}
+
+// secretCache caches the secrets until they expire, so the secret manager isn't asked every time a storage
+// is created, and the rotated secrets are read again once the cached ones expire.
+type secretCache struct {
+	mu      sync.Mutex
+	secrets map[string]cachedSecret
+	// refOfAccessKey maps the S3 access key resolved from a credential reference to the reference, so that
+	// the S3 storage refreshes the credentials once the secret is rotated. accessKeyOfRef is the reverse, the
+	// access key replaced by the rotation is forgotten.
+	refOfAccessKey map[string]credentialRef
+	accessKeyOfRef map[credentialRef]string
+	now            func() time.Time
+}
+
+func newSecretCache() *secretCache {
+	return &secretCache{
+		secrets:        make(map[string]cachedSecret),
+		refOfAccessKey: make(map[string]credentialRef),
+		accessKeyOfRef: make(map[credentialRef]string),
+		now:            time.Now,
+	}
+}
+
+// bindAccessKey records the S3 access key is resolved from the credential reference.
+func (c *secretCache) bindAccessKey(accessKey string, ref credentialRef) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if old, ok := c.accessKeyOfRef[ref]; ok && old != accessKey {
+		delete(c.refOfAccessKey, old)
+	}
+	c.accessKeyOfRef[ref] = accessKey
+	c.refOfAccessKey[accessKey] = ref
+}
+
+// credentials returns the S3 credentials reading the secret if the access key is resolved from a credential
+// reference, nil otherwise.
+func (c *secretCache) credentials(accessKey string) *credentials.Credentials {
+	c.mu.Lock()
+	ref, ok := c.refOfAccessKey[accessKey]
+	c.mu.Unlock()
+	if !ok {
+		return nil
+	}
+	return credentials.NewCredentials(&secretCredentialsProvider{cache: c, ref: ref})
+}
+
+func (c *secretCache) get(ctx context.Context, ref credentialRef) (map[string]string, error) {
+	cacheKey := ref.provider + ":" + ref.path
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if secret, ok := c.secrets[cacheKey]; ok && c.now().Before(secret.expireAt) {
+		return secret.fields, nil
+	}
+	provider, ok := getSecretProvider(ref.provider)
+	if !ok {
+		return nil, errors.Annotatef(berrors.ErrStorageInvalidConfig, "unknown secret provider %q in the "+
+			"credential reference, should be %q or %q", ref.provider, vaultSecretProviderName, awsSecretProviderName)
+	}
+	fields, ttl, err := provider.GetSecret(ctx, ref.path)
+	if err != nil {
+		return nil, errors.Annotatef(err, "failed to read the secret %s:%s", ref.provider, ref.path)
+	}
+	if ttl <= 0 {
+		ttl = defaultSecretTTL
+	}
+	c.secrets[cacheKey] = cachedSecret{fields: fields, expireAt: c.now().Add(ttl)}
+	log.Info("read the secret of the storage credentials", zap.String("provider", ref.provider),
+		zap.String("path", ref.path), zap.Duration("ttl", ttl))
+	return fields, nil
+}
+
+func (c *secretCache) expired(ref credentialRef) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	secret, ok := c.secrets[ref.provider+":"+ref.path]
+	return !ok || !c.now().Before(secret.expireAt)
+}
+
+// resolve returns the storage parameters referenced by the credential reference.
+func (c *secretCache) resolve(ctx context.Context, ref credentialRef) (map[string]string, error) {
+	fields, err := c.get(ctx, ref)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if ref.key == "" {
+		return fields, nil
+	}
+	value, ok := fields[ref.key]
+	if !ok {
+		return nil, errors.Annotatef(berrors.ErrStorageInvalidConfig, "the key %q isn't in the secret %s:%s",
+			ref.key, ref.provider, ref.path)
+	}
+	params, err := decodeSecretFields([]byte(value))
+	if err != nil {
+		// the value may be secret itself, don't print it.
+		return nil, errors.Annotatef(berrors.ErrStorageInvalidConfig,
+			"the key %q of the secret %s:%s isn't a JSON object", ref.key, ref.provider, ref.path)
+	}
+	return params, nil
+}
+
+// decodeSecretFields decodes a JSON object into the fields, the values not of string are kept in JSON,
+// e.g. the GCS credentials blob stored as an object.
+func decodeSecretFields(data []byte) (map[string]string, error) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, errors.Trace(err)
+	}
+	fields := make(map[string]string, len(raw))
+	for k, v := range raw {
+		var s string
+		if err := json.Unmarshal(v, &s); err == nil {
+			fields[k] = s
+		} else {
+			fields[k] = string(v)
+		}
+	}
+	return fields, nil
+}
+
+// HasCredentialRef checks whether the storage URL references the credentials in a secret manager.
+func HasCredentialRef(rawURL string) bool {
+	u, err := ParseRawURL(rawURL)
+	return err == nil && u.Query().Get(credentialRefParam) != ""
+}
+
+// resolveCredentialRef replaces the credential reference in the query of the URL by the parameters it
+// references, the parameters set in the URL explicitly take precedence. The parameters resolved are
+// returned, nil if there isn't a credential reference.
+func resolveCredentialRef(ctx context.Context, u *url.URL) (map[string]string, error) {
+	query := u.Query()
+	rawRef := query.Get(credentialRefParam)
+	if rawRef == "" {
+		return nil, nil
+	}
+	if !strings.Contains(rawRef, "#") && u.Fragment != "" {
+		// the unescaped `#` of the key starts the fragment of the URL.
+		rawRef += "#" + u.Fragment
+		u.Fragment = ""
+	}
+	ref, err := parseCredentialRef(rawRef)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	ctx, cancel := context.WithTimeout(ctx, secretResolveTimeout)
+	defer cancel()
+	params, err := defaultSecretCache.resolve(ctx, ref)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	query.Del(credentialRefParam)
+	for k, v := range params {
+		if !query.Has(k) {
+			query.Set(k, v)
+		}
+	}
+	u.RawQuery = query.Encode()
+	if accessKey := query.Get("access-key"); accessKey != "" && params["access-key"] == accessKey {
+		defaultSecretCache.bindAccessKey(accessKey, ref)
+	}
+	return params, nil
+}
+
+// secretCredentialsProvider provides the S3 credentials from the secret referenced by the credential
+// reference, the secret is read again once the cached one expires, so the rotated keys are picked up by
+// the long running tasks.
+type secretCredentialsProvider struct {
+	cache *secretCache
+	ref   credentialRef
+}
+
+// newSecretCredentials returns the credentials reading the secret if the access key is resolved from a
+// credential reference, nil otherwise.
+func newSecretCredentials(accessKey string) *credentials.Credentials {
+	return defaultSecretCache.credentials(accessKey)
+}
+
+func (p *secretCredentialsProvider) Retrieve() (credentials.Value, error) {
+	return p.RetrieveWithContext(context.Background())
+}
+
+// RetrieveWithContext implements credentials.ProviderWithContext, the context is of the request to S3.
+func (p *secretCredentialsProvider) RetrieveWithContext(c credentials.Context) (credentials.Value, error) {
+	ctx, cancel := context.WithTimeout(c, secretResolveTimeout)
+	defer cancel()
+	params, err := p.cache.resolve(ctx, p.ref)
+	if err != nil {
+		return credentials.Value{}, errors.Trace(err)
+	}
+	if params["access-key"] == "" || params["secret-access-key"] == "" {
+		return credentials.Value{}, errors.Annotatef(berrors.ErrStorageInvalidConfig,
+			"the access key or the secret access key is missing in the secret %s", p.ref)
+	}
+	p.cache.bindAccessKey(params["access-key"], p.ref)
+	return credentials.Value{
+		AccessKeyID:     params["access-key"],
+		SecretAccessKey: params["secret-access-key"],
+		SessionToken:    params["session-token"],
+		ProviderName:    "SecretCredentialsProvider",
+	}, nil
+}
+
+func (p *secretCredentialsProvider) IsExpired() bool {
+	return p.cache.expired(p.ref)
+}
+
+// vaultSecretProvider reads the secrets from HashiCorp Vault by its HTTP API, the address and the token are
+// read from the environment variables VAULT_ADDR and VAULT_TOKEN, and VAULT_NAMESPACE if set. The TLS
+// certificates are read from VAULT_CACERT, VAULT_CLIENT_CERT and VAULT_CLIENT_KEY. The path is the API path
+// without `/v1`, e.g. `secret/data/br` of the KV secrets engine version 2.
+type vaultSecretProvider struct {
+	mu sync.Mutex
+	// client is created by the TLS environment variables tlsEnv, and reused until they change.
+	client *http.Client
+	tlsEnv vaultTLSEnv
+}
+
+// vaultTLSEnv is the environment variables of the TLS certificates of vault.
+type vaultTLSEnv struct {
+	caPath, certPath, keyPath string
+}
+
+func loadVaultTLSEnv() vaultTLSEnv {
+	return vaultTLSEnv{
+		caPath:   os.Getenv("VAULT_CACERT"),
+		certPath: os.Getenv("VAULT_CLIENT_CERT"),
+		keyPath:  os.Getenv("VAULT_CLIENT_KEY"),
+	}
+}
+
+// httpClient returns the HTTP client to vault, it's created once and shared by the secrets read.
+func (p *vaultSecretProvider) httpClient() (*http.Client, error) {
+	env := loadVaultTLSEnv()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.client != nil && p.tlsEnv == env {
+		return p.client, nil
+	}
+	client, err := newVaultHTTPClient(env)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	p.client, p.tlsEnv = client, env
+	return client, nil
+}
+
+// newVaultHTTPClient creates the HTTP client to vault with the TLS certificates, the client doesn't share
+// the transport with the other HTTP clients.
+func newVaultHTTPClient(env vaultTLSEnv) (*http.Client, error) {
+	tlsConfig, err := util.NewTLSConfig(
+		util.WithCAPath(env.caPath),
+		util.WithCertAndKeyPath(env.certPath, env.keyPath),
+	)
+	if err != nil {
+		return nil, errors.Annotate(err, "failed to load the TLS certificates of vault")
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if tlsConfig != nil {
+		transport.TLSClientConfig = tlsConfig
+	}
+	return &http.Client{Transport: transport, Timeout: secretResolveTimeout}, nil
+}
+
+func (p *vaultSecretProvider) GetSecret(ctx context.Context, path string) (map[string]string, time.Duration, error) {
+	addr, token := os.Getenv("VAULT_ADDR"), os.Getenv("VAULT_TOKEN")
+	if addr == "" || token == "" {
+		return nil, 0, errors.Annotate(berrors.ErrStorageInvalidConfig,
+			"VAULT_ADDR and VAULT_TOKEN must be set to read the secret from vault")
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		strings.TrimSuffix(addr, "/")+"/v1/"+strings.TrimPrefix(path, "/"), nil)
+	if err != nil {
+		return nil, 0, errors.Trace(err)
+	}
+	req.Header.Set("X-Vault-Token", token)
+	if namespace := os.Getenv("VAULT_NAMESPACE"); namespace != "" {
+		req.Header.Set("X-Vault-Namespace", namespace)
+	}
+	client, err := p.httpClient()
+	if err != nil {
+		return nil, 0, errors.Trace(err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, 0, errors.Trace(err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, 0, errors.Trace(err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, 0, errors.Errorf("vault responds %s", resp.Status)
+	}
+	var secret struct {
+		LeaseDuration int64           `json:"lease_duration"`
+		Data          json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(body, &secret); err != nil {
+		return nil, 0, errors.Annotate(err, "failed to decode the response of vault")
+	}
+	// the KV secrets engine version 2 nests the fields in `data` with the `metadata`.
+	var kv2 struct {
+		Data     json.RawMessage `json:"data"`
+		Metadata json.RawMessage `json:"metadata"`
+	}
+	data := secret.Data
+	if err := json.Unmarshal(data, &kv2); err == nil && len(kv2.Data) > 0 && len(kv2.Metadata) > 0 {
+		data = kv2.Data
+	}
+	fields, err := decodeSecretFields(data)
+	if err != nil {
+		return nil, 0, errors.Annotate(err, "failed to decode the secret of vault")
+	}
+	return fields, time.Duration(secret.LeaseDuration) * time.Second, nil
+}
+
+// awsSecretProvider reads the secrets from AWS Secrets Manager, the path is the name or the ARN of the
+// secret whose value is a JSON object. The AWS credentials and region are read in the same way as the S3
+// storage without the credentials.
+type awsSecretProvider struct{}
+
+func (awsSecretProvider) GetSecret(ctx context.Context, path string) (map[string]string, time.Duration, error) {
+	ses, err := session.NewSessionWithOptions(session.Options{SharedConfigState: session.SharedConfigEnable})
+	if err != nil {
+		return nil, 0, errors.Trace(err)
+	}
+	out, err := secretsmanager.New(ses).GetSecretValueWithContext(ctx, &secretsmanager.GetSecretValueInput{
+		SecretId: aws.String(path),
+	})
+	if err != nil {
+		return nil, 0, errors.Trace(err)
+	}
+	if out.SecretString == nil {
+		return nil, 0, errors.Annotate(berrors.ErrStorageInvalidConfig, "the binary secret isn't supported")
+	}
+	fields, err := decodeSecretFields([]byte(aws.StringValue(out.SecretString)))
+	if err != nil {
+		return nil, 0, errors.Annotate(berrors.ErrStorageInvalidConfig, "the secret isn't a JSON object")
+	}
+	return fields, 0, nil
+}
@@ -0,0 +1,181 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package storage
+
+import (
+	"context"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	berrors "github.com/pingcap/tidb/br/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeSecretProvider struct {
+	secrets map[string]map[string]string
+	ttl     time.Duration
+	reads   int
+}
+
+func (p *fakeSecretProvider) GetSecret(_ context.Context, path string) (map[string]string, time.Duration, error) {
+	p.reads++
+	return p.secrets[path], p.ttl, nil
+}
+
+func TestResolveCredentialRef(t *testing.T) {
+	now := time.Unix(1000, 0)
+	defaultSecretCache.now = func() time.Time { return now }
+	defer func() { defaultSecretCache.now = time.Now }()
+	provider := &fakeSecretProvider{ttl: time.Minute, secrets: map[string]map[string]string{
+		"br/s3": {"access-key": "ak1", "secret-access-key": "sk1"},
+		"br/all": {
+			"s3":  `{"access-key": "ak2", "secret-access-key": "sk2", "session-token": "token"}`,
+			"gcs": `{"credentials-blob": {"type": "service_account"}}`,
+		},
+	}}
+	RegisterSecretProvider("fake", provider)
+
+	s, err := ParseBackend("s3://bucket/prefix?credential-ref=fake:br/s3", nil)
+	require.NoError(t, err)
+	require.Equal(t, "ak1", s.GetS3().AccessKey)
+	require.Equal(t, "sk1", s.GetS3().SecretAccessKey)
+	require.Equal(t, "prefix", s.GetS3().Prefix)
+	// the parameters in the URL take precedence.
+	s, err = ParseBackend("s3://bucket/prefix?credential-ref=fake:br/s3&access-key=ak0", nil)
+	require.NoError(t, err)
+	require.Equal(t, "ak0", s.GetS3().AccessKey)
+	require.Equal(t, "sk1", s.GetS3().SecretAccessKey)
+
+	// the key may be in the fragment if the `#` isn't escaped.
+	s, err = ParseBackend("s3://bucket/prefix?credential-ref=fake:br/all#s3", nil)
+	require.NoError(t, err)
+	require.Equal(t, "ak2", s.GetS3().AccessKey)
+	require.Equal(t, "token", s.GetS3().SessionToken)
+	s, err = ParseBackend("gcs://bucket/prefix?credential-ref=fake:br/all%23gcs", nil)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"type": "service_account"}`, s.GetGcs().CredentialsBlob)
+	// the secrets are cached.
+	require.Equal(t, 2, provider.reads)
+
+	_, err = ParseBackend("s3://bucket/prefix?credential-ref=fake:br/all#azblob", nil)
+	require.True(t, berrors.ErrStorageInvalidConfig.Equal(err))
+	_, err = ParseBackend("s3://bucket/prefix?credential-ref=unknown:br/s3", nil)
+	require.ErrorContains(t, err, `unknown secret provider "unknown"`)
+	_, err = ParseBackend("s3://bucket/prefix?credential-ref=br/s3", nil)
+	require.True(t, berrors.ErrStorageInvalidConfig.Equal(err))
+
+	// the S3 credentials are refreshed once the rotated secret is read.
+	cred := newSecretCredentials("ak1")
+	require.NotNil(t, cred)
+	v, err := cred.Get()
+	require.NoError(t, err)
+	require.Equal(t, "ak1", v.AccessKeyID)
+	provider.secrets["br/s3"] = map[string]string{"access-key": "ak3", "secret-access-key": "sk3"}
+	require.False(t, cred.IsExpired())
+	now = now.Add(2 * time.Minute)
+	require.True(t, cred.IsExpired())
+	v, err = cred.Get()
+	require.NoError(t, err)
+	require.Equal(t, "ak3", v.AccessKeyID)
+	require.Equal(t, "sk3", v.SecretAccessKey)
+	// the rotated access key is forgotten.
+	require.Nil(t, newSecretCredentials("ak1"))
+	require.NotNil(t, newSecretCredentials("ak3"))
+	require.Nil(t, newSecretCredentials("static"))
+
+	// the secret is read by the context of the caller.
+	now = now.Add(2 * time.Minute)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	RegisterSecretProvider("fake", ctxSecretProvider{})
+	_, err = ParseBackendWithContext(ctx, "s3://bucket/prefix?credential-ref=fake:br/s3", nil)
+	require.ErrorIs(t, err, context.Canceled)
+	require.True(t, HasCredentialRef("s3://bucket/prefix?credential-ref=fake:br/s3"))
+	require.False(t, HasCredentialRef("s3://bucket/prefix?access-key=ak"))
+}
+
+type ctxSecretProvider struct{}
+
+func (ctxSecretProvider) GetSecret(ctx context.Context, _ string) (map[string]string, time.Duration, error) {
+	return nil, 0, ctx.Err()
+}
+
+func TestVaultSecretProvider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "root" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/secret/data/br":
+			_, _ = w.Write([]byte(`{"lease_duration": 0, "data": {"data": {"access-key": "ak"}, "metadata": {"version": 3}}}`))
+		case "/v1/kv/br":
+			_, _ = w.Write([]byte(`{"lease_duration": 60, "data": {"access-key": "ak"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	ctx := context.Background()
+	provider := &vaultSecretProvider{client: server.Client()}
+
+	t.Setenv("VAULT_ADDR", "")
+	_, _, err := provider.GetSecret(ctx, "kv/br")
+	require.True(t, berrors.ErrStorageInvalidConfig.Equal(err))
+
+	t.Setenv("VAULT_ADDR", server.URL+"/")
+	t.Setenv("VAULT_TOKEN", "root")
+	fields, ttl, err := provider.GetSecret(ctx, "secret/data/br")
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"access-key": "ak"}, fields)
+	require.Zero(t, ttl)
+	fields, ttl, err = provider.GetSecret(ctx, "/kv/br")
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"access-key": "ak"}, fields)
+	require.Equal(t, time.Minute, ttl)
+	_, _, err = provider.GetSecret(ctx, "kv/missing")
+	require.ErrorContains(t, err, "404")
+
+	t.Setenv("VAULT_TOKEN", "wrong")
+	_, _, err = provider.GetSecret(ctx, "kv/br")
+	require.ErrorContains(t, err, "403")
+}
+
+func TestVaultSecretProviderTLS(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"lease_duration": 60, "data": {"access-key": "ak"}}`))
+	}))
+	defer server.Close()
+	ctx := context.Background()
+	provider := &vaultSecretProvider{}
+	t.Setenv("VAULT_ADDR", server.URL)
+	t.Setenv("VAULT_TOKEN", "root")
+
+	// the certificate of the server isn't trusted without the CA.
+	_, _, err := provider.GetSecret(ctx, "kv/br")
+	require.Error(t, err)
+
+	caPath := filepath.Join(t.TempDir(), "ca.pem")
+	require.NoError(t, os.WriteFile(caPath, pem.EncodeToMemory(&pem.Block{
+		Type:  "CERTIFICATE",
+		Bytes: server.Certificate().Raw,
+	}), 0o600))
+	t.Setenv("VAULT_CACERT", caPath)
+	fields, _, err := provider.GetSecret(ctx, "kv/br")
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"access-key": "ak"}, fields)
+	// the client is reused until the certificates change.
+	client := provider.client
+	_, _, err = provider.GetSecret(ctx, "kv/br")
+	require.NoError(t, err)
+	require.Same(t, client, provider.client)
+
+	t.Setenv("VAULT_CACERT", filepath.Join(t.TempDir(), "missing.pem"))
+	_, _, err = provider.GetSecret(ctx, "kv/br")
+	require.ErrorContains(t, err, "TLS certificates of vault")
+}
@@ -6,6 +6,7 @@ import (
 	"context"
 	"io"
 	"net/http"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/pingcap/errors"
@@ -24,6 +25,25 @@ type StrongConsisency interface {
 	MarkStrongConsistency()
 }
 
+// Presigner is implemented by the storages which can share a file by a time-limited URL.
+type Presigner interface {
+	// PresignFile returns a URL to download the file without credentials before it expires.
+	PresignFile(ctx context.Context, name string, expire time.Duration) (string, error)
+}
+
+// ConditionalWriter is implemented by the storages which can write a file only
+// if it isn't changed since it's read, so that the concurrent writers of a file
+// are detected by optimistic locking.
+type ConditionalWriter interface {
+	// ReadFileWithVersion reads a complete file and its version. the content is
+	// nil and the version is empty if the file doesn't exist.
+	ReadFileWithVersion(ctx context.Context, name string) ([]byte, string, error)
+	// WriteFileIfMatch writes a complete file if its version is still the given
+	// one, or if it doesn't exist when the version is empty. it returns the new
+	// version, or ErrStorageConditionNotMet if the file is changed.
+	WriteFileIfMatch(ctx context.Context, name string, data []byte, version string) (string, error)
+}
+
 const (
 	// AccessBuckets represents bucket access permission
 	// it replace the origin skip-check-path.
@@ -57,6 +77,11 @@ type WalkOption struct {
 	// For example. we have 10000 <Hash>.sst files and 10 backupmeta.(\d+) files.
 	// we can use ObjPrefix = "backupmeta" to retrieve all meta files quickly.
 	ObjPrefix string
+	// StartAfter is a hint to list only the files whose paths are after it in
+	// the lexicographical order, so the newly-added files can be listed without
+	// listing the older ones again. Note that only part of storage support it,
+	// the others list all the files, so the caller should still filter them.
+	StartAfter string
 	// ListCount is the number of entries per page.
 	//
 	// In cloud storages such as S3 and GCS, the files listed and sent in pages.
@@ -195,6 +220,20 @@ type ExternalStorageOptions struct {
 	// CheckObjectLockOptions check the s3 bucket has enabled the ObjectLock.
 	// if enabled. it will send the options to tikv.
 	CheckS3ObjectLockOptions bool
+
+	// RetryPolicy limits the retries of the failed requests of the task, it's only used by s3/azure/gcs/oss/cos.
+	// Nil means the retries are only limited by the storages.
+	RetryPolicy *RetryPolicy
+
+	// RequestStats counts the requests of the task sent through the storage by the BR process, it's only
+	// used by s3/azure/gcs/oss/cos. The requests sent by TiKV to the same storage aren't seen by it.
+	RequestStats *RequestStats
+}
+
+// wrapHTTPClient returns the options whose HTTP client counts the requests and sends them by the retry
+// policy, the requests are counted as they are actually sent.
+func (opts *ExternalStorageOptions) wrapHTTPClient() *ExternalStorageOptions {
+	return opts.RetryPolicy.apply(opts.RequestStats.apply(opts))
 }
 
 // Create creates ExternalStorage.
@@ -224,7 +263,7 @@ func NewFromURL(ctx context.Context, uri string) (ExternalStorage, error) {
 	if u.Scheme == "memstore" {
 		return NewMemStorage(), nil
 	}
-	b, err := parseBackend(u, uri, nil)
+	b, err := parseBackend(ctx, u, uri, nil)
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
@@ -252,8 +291,16 @@ func New(ctx context.Context, backend *backuppb.StorageBackend, opts *ExternalSt
 			return nil, errors.Annotate(berrors.ErrStorageInvalidConfig, "s3 config not found")
 		}
 		if backend.S3.Provider == ks3SDKProvider {
-			return NewKS3Storage(ctx, backend.S3, opts)
+			return NewKS3Storage(ctx, backend.S3, opts.wrapHTTPClient())
+		}
+		switch backend.S3.Provider {
+		case ossSDKProvider:
+			return NewOSSStorage(ctx, backend.S3, opts.wrapHTTPClient())
+		case cosSDKProvider:
+			return NewCOSStorage(ctx, backend.S3, opts.wrapHTTPClient())
 		}
+		// the retry policy and the request stats are applied after the session of S3 is created, see
+		// NewS3Storage.
 		return NewS3Storage(ctx, backend.S3, opts)
 	case *backuppb.StorageBackend_Noop:
 		return newNoopStorage(), nil
@@ -261,9 +308,9 @@ func New(ctx context.Context, backend *backuppb.StorageBackend, opts *ExternalSt
 		if backend.Gcs == nil {
 			return nil, errors.Annotate(berrors.ErrStorageInvalidConfig, "GCS config not found")
 		}
-		return NewGCSStorage(ctx, backend.Gcs, opts)
+		return NewGCSStorage(ctx, backend.Gcs, opts.wrapHTTPClient())
 	case *backuppb.StorageBackend_AzureBlobStorage:
-		return newAzureBlobStorage(ctx, backend.AzureBlobStorage, opts)
+		return newAzureBlobStorage(ctx, backend.AzureBlobStorage, opts.wrapHTTPClient())
 	default:
 		return nil, errors.Annotatef(berrors.ErrStorageInvalidConfig, "storage %T is not supported yet", backend)
 	}
@@ -0,0 +1,98 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package stream
+
+import (
+	"bytes"
+	"slices"
+
+	"github.com/pingcap/log"
+	"github.com/pingcap/tidb/pkg/kv"
+	"github.com/pingcap/tidb/pkg/util/codec"
+	"go.uber.org/zap"
+)
+
+// ClusterMetaFamily is a family of the meta keys carrying the cluster-scoped state rather than the
+// schemas, e.g. the BDR role. They describe the upstream cluster, so they're stripped by default.
+type ClusterMetaFamily struct {
+	Name string
+	// Keys are the names of the meta keys in the family, a key ending with ':' matches the keys with it
+	// as the prefix.
+	Keys []string
+	// Preservable is whether the family is kept by `--preserve-cluster-meta`. The families maintained
+	// by the target cluster itself, e.g. the global ID and the DDL owner's state, are always stripped.
+	Preservable bool
+}
+
+// ClusterMetaFamilies are the known cluster-scoped meta key families, see pkg/meta for the keys.
+var ClusterMetaFamilies = []ClusterMetaFamily{
+	{Name: "bdr-role", Keys: []string{"BDRRole"}, Preservable: true},
+	{Name: "schema-cache-size", Keys: []string{"SchemaCacheSize"}, Preservable: true},
+	{Name: "request-unit-stats", Keys: []string{"RequestUnitStats"}, Preservable: true},
+	{Name: "ddl-table-version", Keys: []string{"DDLTableVersion"}},
+	{Name: "ddl-job-queue", Keys: []string{"DDLJobList", "DDLJobAddIdxList"}},
+	{Name: "metadata-lock", Keys: []string{"metadataLock"}},
+	{Name: "schema-version", Keys: []string{"SchemaVersionKey", "Diff:"}},
+	{Name: "global-id", Keys: []string{"NextGlobalID", "PolicyGlobalID"}},
+	{Name: "bootstrap-version", Keys: []string{"BootstrapKey"}},
+}
+
+// PreservableClusterMetaFamilies returns the names of the families kept by `--preserve-cluster-meta`.
+func PreservableClusterMetaFamilies() []string {
+	names := make([]string, 0, len(ClusterMetaFamilies))
+	for _, family := range ClusterMetaFamilies {
+		if family.Preservable {
+			names = append(names, family.Name)
+		}
+	}
+	return names
+}
+
+// matchClusterMetaFamily returns the family of the txn key if it's a cluster-scoped meta key.
+func matchClusterMetaFamily(txnKey []byte) (*ClusterMetaFamily, bool) {
+	if len(txnKey) == 0 || txnKey[0] != 'm' {
+		return nil, false
+	}
+	_, rawKey, err := codec.DecodeBytes(txnKey, nil)
+	if err != nil || len(rawKey) == 0 {
+		return nil, false
+	}
+	_, name, err := codec.DecodeBytes(rawKey[1:], nil)
+	if err != nil {
+		return nil, false
+	}
+	for i := range ClusterMetaFamilies {
+		family := &ClusterMetaFamilies[i]
+		matched := slices.ContainsFunc(family.Keys, func(key string) bool {
+			if key[len(key)-1] == ':' {
+				return bytes.HasPrefix(name, []byte(key))
+			}
+			return string(name) == key
+		})
+		if matched {
+			return family, true
+		}
+	}
+	return nil, false
+}
+
+// IsClusterMetaKey returns whether the txn key is a cluster-scoped meta key.
+func IsClusterMetaKey(txnKey []byte) bool {
+	_, ok := matchClusterMetaFamily(txnKey)
+	return ok
+}
+
+// rewriteClusterMeta keeps the cluster-scoped meta entry as is if the family is preserved, the commit
+// ts of the entry in write cf is rewritten like the other meta entries. nil is returned if it's stripped.
+func (sr *SchemasReplace) rewriteClusterMeta(e *kv.Entry, cf string, family *ClusterMetaFamily) *kv.Entry {
+	if !sr.PreserveClusterMeta || !family.Preservable {
+		log.Debug("strip the cluster meta entry", zap.String("family", family.Name), zap.String("cf", cf))
+		return nil
+	}
+	key := e.Key
+	if cf == WriteCF && len(key) >= 8 {
+		key = codec.EncodeUintDesc(slices.Clone(key[:len(key)-8]), sr.RewriteTS)
+	}
+	log.Info("preserve the cluster meta entry", zap.String("family", family.Name), zap.String("cf", cf))
+	return &kv.Entry{Key: key, Value: e.Value}
+}
@@ -0,0 +1,64 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package stream
+
+import (
+	"testing"
+
+	"github.com/pingcap/tidb/pkg/kv"
+	"github.com/pingcap/tidb/pkg/meta"
+	"github.com/pingcap/tidb/pkg/structure"
+	"github.com/pingcap/tidb/pkg/util/codec"
+	"github.com/stretchr/testify/require"
+)
+
+func encodeTxnStringMetaKey(key string, ts uint64) []byte {
+	rawKey := structure.NewStructure(nil, nil, []byte("m")).EncodeStringDataKey([]byte(key))
+	return codec.EncodeUintDesc(codec.EncodeBytes(nil, rawKey), ts)
+}
+
+func TestMatchClusterMetaFamily(t *testing.T) {
+	family, ok := matchClusterMetaFamily(encodeTxnStringMetaKey("BDRRole", 1))
+	require.True(t, ok)
+	require.Equal(t, "bdr-role", family.Name)
+	family, ok = matchClusterMetaFamily(encodeTxnStringMetaKey("Diff:42", 1))
+	require.True(t, ok)
+	require.Equal(t, "schema-version", family.Name)
+	require.False(t, IsClusterMetaKey(encodeTxnMetaKey([]byte("DBs"), meta.DBkey(1), 1)))
+	require.False(t, IsClusterMetaKey(encodeTxnStringMetaKey("Unknown", 1)))
+	require.Equal(t, []string{"bdr-role", "schema-cache-size", "request-unit-stats"}, PreservableClusterMetaFamilies())
+}
+
+func TestRewriteClusterMeta(t *testing.T) {
+	var (
+		startTS  uint64 = 400036290571534337
+		commitTS uint64 = 400036290571534338
+	)
+	sr := MockEmptySchemasReplace(nil, nil)
+	roleKey := encodeTxnStringMetaKey("BDRRole", commitTS)
+	writeValue := codec.EncodeUvarint([]byte{WriteTypePut}, startTS)
+	versionKey := encodeTxnStringMetaKey("NextGlobalID", commitTS)
+
+	// stripped by default.
+	for _, key := range [][]byte{roleKey, versionKey} {
+		entry, err := sr.RewriteKvEntry(&kv.Entry{Key: key, Value: writeValue}, WriteCF)
+		require.NoError(t, err)
+		require.Nil(t, entry)
+	}
+
+	sr.PreserveClusterMeta = true
+	entry, err := sr.RewriteKvEntry(&kv.Entry{Key: roleKey, Value: writeValue}, WriteCF)
+	require.NoError(t, err)
+	require.Equal(t, writeValue, entry.Value)
+	require.Equal(t, encodeTxnStringMetaKey("BDRRole", sr.RewriteTS), []byte(entry.Key))
+	// the entries in default cf keep the start ts.
+	defaultKey := encodeTxnStringMetaKey("BDRRole", startTS)
+	entry, err = sr.RewriteKvEntry(&kv.Entry{Key: defaultKey, Value: []byte("primary")}, DefaultCF)
+	require.NoError(t, err)
+	require.Equal(t, defaultKey, []byte(entry.Key))
+	require.Equal(t, []byte("primary"), entry.Value)
+	// the families maintained by the target cluster are always stripped.
+	entry, err = sr.RewriteKvEntry(&kv.Entry{Key: versionKey, Value: writeValue}, WriteCF)
+	require.NoError(t, err)
+	require.Nil(t, entry)
+}
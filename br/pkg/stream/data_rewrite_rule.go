@@ -0,0 +1,86 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package stream
+
+import (
+	"github.com/pingcap/errors"
+	"github.com/pingcap/log"
+	berrors "github.com/pingcap/tidb/br/pkg/errors"
+	restoreutils "github.com/pingcap/tidb/br/pkg/restore/utils"
+	"github.com/pingcap/tidb/br/pkg/utils"
+	"go.uber.org/zap"
+)
+
+// DataRewriteRules builds the rewrite rules of the data keys of the tables to restore by the ID mapping,
+// keyed by the upstream ID of the table or partition. The rules rewrite the table prefix, so the index IDs
+// must be kept by the downstream tables.
+//
+// The mapping is validated: every table and partition is mapped to a downstream ID, an upstream ID isn't
+// mapped to different downstream IDs, and the rules don't overlap each other. Only the partitions merged
+// into their tables are rewritten to the same prefix as the tables, see MergePartitions.
+func (sr *SchemasReplace) DataRewriteRules() (map[int64]*restoreutils.RewriteRules, error) {
+	rules := make(map[int64]*restoreutils.RewriteRules)
+	merged := make(map[int64]struct{})
+	addRule := func(dbReplace *DBReplace, tableReplace *TableReplace, oldID UpstreamID, newID DownstreamID) error {
+		if newID <= 0 {
+			return errors.Annotatef(berrors.ErrRestoreInvalidRewrite,
+				"the table or partition %d of %s.%s isn't mapped to the downstream",
+				oldID, dbReplace.Name, tableReplace.Name)
+		}
+		if rule, exist := rules[oldID]; exist {
+			if rule.NewTableID != newID {
+				return errors.Annotatef(berrors.ErrRestoreInvalidRewrite,
+					"the table or partition %d of %s.%s is mapped to both %d and %d",
+					oldID, dbReplace.Name, tableReplace.Name, rule.NewTableID, newID)
+			}
+			return nil
+		}
+		log.Info("add rewrite rule",
+			zap.String("tableName", dbReplace.Name+"."+tableReplace.Name),
+			zap.Int64("oldID", oldID), zap.Int64("newID", newID))
+		rules[oldID] = restoreutils.GetRewriteRuleOfTable(oldID, newID, 0, tableReplace.IndexMap, false)
+		return nil
+	}
+
+	for _, dbReplace := range sr.DbMap {
+		if utils.IsSysDB(dbReplace.Name) || !sr.TableFilter.MatchSchema(dbReplace.Name) {
+			continue
+		}
+		for oldTableID, tableReplace := range dbReplace.TableMap {
+			if !sr.TableFilter.MatchTable(dbReplace.Name, tableReplace.Name) {
+				continue
+			}
+			for oldIndexID, newIndexID := range tableReplace.IndexMap {
+				if oldIndexID != newIndexID {
+					return nil, errors.Annotatef(berrors.ErrRestoreInvalidRewrite,
+						"the index %d of %s.%s is mapped to %d, but only the table prefix is rewritten",
+						oldIndexID, dbReplace.Name, tableReplace.Name, newIndexID)
+				}
+			}
+			if err := addRule(dbReplace, tableReplace, oldTableID, tableReplace.TableID); err != nil {
+				return nil, err
+			}
+			for oldID, newID := range tableReplace.PartitionMap {
+				if err := addRule(dbReplace, tableReplace, oldID, newID); err != nil {
+					return nil, err
+				}
+				if tableReplace.MergePartitions {
+					merged[oldID] = struct{}{}
+				}
+			}
+		}
+	}
+	validated := rules
+	if len(merged) > 0 {
+		validated = make(map[int64]*restoreutils.RewriteRules, len(rules))
+		for oldID, rule := range rules {
+			if _, ok := merged[oldID]; !ok {
+				validated[oldID] = rule
+			}
+		}
+	}
+	if err := restoreutils.ValidateRewriteRules(validated); err != nil {
+		return nil, errors.Trace(err)
+	}
+	return rules, nil
+}
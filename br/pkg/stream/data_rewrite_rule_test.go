@@ -0,0 +1,76 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package stream
+
+import (
+	"testing"
+
+	"github.com/pingcap/kvproto/pkg/import_sstpb"
+	berrors "github.com/pingcap/tidb/br/pkg/errors"
+	restoreutils "github.com/pingcap/tidb/br/pkg/restore/utils"
+	"github.com/pingcap/tidb/pkg/tablecodec"
+	filter "github.com/pingcap/tidb/pkg/util/table-filter"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDataRewriteRules(t *testing.T) {
+	newSchemasReplace := func(tables map[UpstreamID]*TableReplace) *SchemasReplace {
+		db := NewDBReplace("test", 100)
+		db.TableMap = tables
+		sys := NewDBReplace("mysql", 200)
+		sys.TableMap[10] = NewTableReplace("user", 210)
+		return NewSchemasReplace(map[UpstreamID]*DBReplace{1: db, 2: sys}, nil, 0, filter.All(), nil)
+	}
+
+	partitioned := NewTableReplace("p", 110)
+	partitioned.PartitionMap[12] = 112
+	partitioned.PartitionMap[13] = 113
+	partitioned.IndexMap[1] = 1
+	rules, err := newSchemasReplace(map[UpstreamID]*TableReplace{
+		11: partitioned,
+		14: NewTableReplace("t", 114),
+	}).DataRewriteRules()
+	require.NoError(t, err)
+	// the system tables aren't rewritten.
+	require.Len(t, rules, 4)
+	for oldID, newID := range map[int64]int64{11: 110, 12: 112, 13: 113, 14: 114} {
+		require.Equal(t, newID, rules[oldID].NewTableID)
+		require.Equal(t, []*import_sstpb.RewriteRule{{
+			OldKeyPrefix: tablecodec.EncodeTablePrefix(oldID),
+			NewKeyPrefix: tablecodec.EncodeTablePrefix(newID),
+		}}, rules[oldID].Data)
+	}
+	sorted := restoreutils.SortedDataRules(rules)
+	require.Len(t, sorted, 4)
+	for i, oldID := range []int64{11, 12, 13, 14} {
+		require.Equal(t, []byte(tablecodec.EncodeTablePrefix(oldID)), sorted[i].OldKeyPrefix)
+	}
+
+	// a partition isn't mapped to the downstream.
+	gap := NewTableReplace("p", 110)
+	gap.PartitionMap[12] = 0
+	_, err = newSchemasReplace(map[UpstreamID]*TableReplace{11: gap}).DataRewriteRules()
+	require.ErrorIs(t, err, berrors.ErrRestoreInvalidRewrite)
+
+	// a partition is mapped to both a partition and a table.
+	conflict := NewTableReplace("p", 110)
+	conflict.PartitionMap[14] = 112
+	_, err = newSchemasReplace(map[UpstreamID]*TableReplace{
+		11: conflict,
+		14: NewTableReplace("t", 114),
+	}).DataRewriteRules()
+	require.ErrorIs(t, err, berrors.ErrRestoreInvalidRewrite)
+
+	// two tables are rewritten to the same table.
+	_, err = newSchemasReplace(map[UpstreamID]*TableReplace{
+		11: NewTableReplace("t1", 110),
+		14: NewTableReplace("t2", 110),
+	}).DataRewriteRules()
+	require.ErrorIs(t, err, berrors.ErrRestoreInvalidRewrite)
+
+	// the index ID changes.
+	reindexed := NewTableReplace("t", 110)
+	reindexed.IndexMap[1] = 2
+	_, err = newSchemasReplace(map[UpstreamID]*TableReplace{11: reindexed}).DataRewriteRules()
+	require.ErrorIs(t, err, berrors.ErrRestoreInvalidRewrite)
+}
@@ -0,0 +1,56 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package stream
+
+import (
+	"time"
+
+	"github.com/pingcap/errors"
+	backuppb "github.com/pingcap/kvproto/pkg/brpb"
+	"github.com/pingcap/tidb/pkg/ddl"
+	"github.com/pingcap/tidb/pkg/meta/model"
+	"github.com/pingcap/tidb/pkg/parser/mysql"
+	"github.com/pingcap/tidb/pkg/tablecodec"
+	"github.com/pingcap/tidb/pkg/types"
+	"github.com/pingcap/tidb/pkg/util/codec"
+)
+
+// ddlHistoryJobMetaColumnID is the column ID of `job_meta` in `mysql.tidb_ddl_history`, the columns are
+// numbered by the order in ddl.HistoryTableSQL.
+const ddlHistoryJobMetaColumnID = 2
+
+// IsDDLHistoryTableFile returns whether the data file contains the rows of `mysql.tidb_ddl_history`, which
+// stores the history DDL jobs besides mDDLJobHistory since the concurrent DDL.
+func IsDDLHistoryTableFile(file *backuppb.DataFileInfo) bool {
+	return !file.IsMeta && file.TableId == ddl.HistoryTableID
+}
+
+// IsDDLHistoryTableKey returns whether the txn key is a row of `mysql.tidb_ddl_history`.
+func IsDDLHistoryTableKey(txnKey []byte) bool {
+	if len(txnKey) == 0 || txnKey[0] != 't' {
+		return false
+	}
+	_, rawKey, err := codec.DecodeBytes(txnKey, nil)
+	if err != nil {
+		return false
+	}
+	return tablecodec.IsRecordKey(rawKey) && tablecodec.DecodeTableID(rawKey) == ddl.HistoryTableID
+}
+
+// decodeDDLHistoryJob decodes the job from the row of `mysql.tidb_ddl_history`.
+func decodeDDLHistoryJob(row []byte) (*model.Job, error) {
+	cols := map[int64]*types.FieldType{ddlHistoryJobMetaColumnID: types.NewFieldType(mysql.TypeLongBlob)}
+	datums, err := tablecodec.DecodeRowToDatumMap(row, cols, time.UTC)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	jobMeta, ok := datums[ddlHistoryJobMetaColumnID]
+	if !ok || jobMeta.IsNull() {
+		return nil, errors.New("no job_meta in the row of the ddl history")
+	}
+	job := &model.Job{}
+	if err := job.Decode(jobMeta.GetBytes()); err != nil {
+		return nil, errors.Trace(err)
+	}
+	return job, nil
+}
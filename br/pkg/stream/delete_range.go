@@ -0,0 +1,193 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package stream
+
+import (
+	"strings"
+	"time"
+
+	"github.com/docker/go-units"
+	"github.com/pingcap/errors"
+	"github.com/pingcap/log"
+	berrors "github.com/pingcap/tidb/br/pkg/errors"
+	"github.com/pingcap/tidb/pkg/ddl"
+	"go.uber.org/zap"
+)
+
+const (
+	// DefaultDelRangeBatchRows is the default max number of the delete ranges inserted by a statement.
+	DefaultDelRangeBatchRows = 1024
+	// DefaultDelRangeBatchBytes is the default max length of a statement inserting the delete ranges.
+	DefaultDelRangeBatchBytes = 4 * units.MiB
+
+	// delRangeRowOverhead is the length of a row in the statement besides the keys, i.e. the job ID, the
+	// element ID and the ts formatted into the placeholders, with the quotes and the separators.
+	delRangeRowOverhead = 80
+)
+
+// DelRangeBatchLimit limits the statements inserting the delete ranges of a DDL job, otherwise the
+// statement of a job dropping thousands of partitions may exceed the max length of the SQL.
+type DelRangeBatchLimit struct {
+	// MaxRows is the max number of the delete ranges in a statement, 0 means unlimited.
+	MaxRows int
+	// MaxBytes is the max length of a statement with the parameters formatted, 0 means unlimited.
+	MaxBytes int
+}
+
+// DefaultDelRangeBatchLimit returns the default limit of the statements inserting the delete ranges.
+func DefaultDelRangeBatchLimit() DelRangeBatchLimit {
+	return DelRangeBatchLimit{MaxRows: DefaultDelRangeBatchRows, MaxBytes: DefaultDelRangeBatchBytes}
+}
+
+// delRangeRowSize estimates the length of the row of the delete range in the statement.
+func delRangeRowSize(params *DelRangeParams) int {
+	return delRangeRowOverhead + len(params.StartKey) + len(params.EndKey)
+}
+
+// Batches splits the delete ranges of the query into the queries under the limit, a delete range
+// exceeding the limit by itself is inserted by a statement alone. The statements are rebuilt from the
+// delete ranges, so the trailing separator left by the tables without rewrite rules doesn't matter.
+// The job IDs and element IDs are kept, so the delete ranges are still unique after split.
+func (q *PreDelRangeQuery) Batches(limit DelRangeBatchLimit) []*PreDelRangeQuery {
+	batches := make([]*PreDelRangeQuery, 0, 1)
+	start, size := 0, len(ddl.BRInsertDeleteRangeSQLPrefix)
+	for i := range q.ParamsList {
+		rowSize := delRangeRowSize(&q.ParamsList[i])
+		full := limit.MaxRows > 0 && i-start >= limit.MaxRows
+		full = full || (limit.MaxBytes > 0 && i > start && size+rowSize > limit.MaxBytes)
+		if full {
+			batches = append(batches, newDelRangeQuery(q.ParamsList[start:i]))
+			start, size = i, len(ddl.BRInsertDeleteRangeSQLPrefix)
+		}
+		size += rowSize
+	}
+	if start < len(q.ParamsList) {
+		batches = append(batches, newDelRangeQuery(q.ParamsList[start:]))
+	}
+	return batches
+}
+
+// DelRangePace paces the statements inserting the delete ranges after the restore. The delete ranges
+// are executed by the GC workers once inserted, inserting tens of thousands of them at once may overload
+// the GC workers.
+type DelRangePace struct {
+	// Interval is the min sleep between the statements, 0 means no sleep.
+	Interval time.Duration
+	// Duration spreads the statements over the duration, 0 means not spread.
+	Duration time.Duration
+}
+
+// SleepBetween returns the sleep between the statements given the number of the statements, it's the
+// larger one of the Interval and the Duration spread over the gaps between the statements.
+func (p DelRangePace) SleepBetween(statements int) time.Duration {
+	sleep := p.Interval
+	if p.Duration > 0 && statements > 1 {
+		sleep = max(sleep, p.Duration/time.Duration(statements-1))
+	}
+	return sleep
+}
+
+func newDelRangeQuery(paramsList []DelRangeParams) *PreDelRangeQuery {
+	var sql strings.Builder
+	sql.WriteString(ddl.BRInsertDeleteRangeSQLPrefix)
+	for i := range paramsList {
+		if i > 0 {
+			sql.WriteString(",")
+		}
+		sql.WriteString(ddl.BRInsertDeleteRangeSQLValue)
+	}
+	return &PreDelRangeQuery{Sql: sql.String(), ParamsList: paramsList}
+}
+
+// DelRangeConflictPolicy is how the delete ranges of the same job ID and element ID but different ranges
+// are handled, e.g. the DDL job retried in the upstream history. The identical ones are always skipped.
+type DelRangeConflictPolicy string
+
+const (
+	// DelRangeConflictKeepFirst keeps the first delete range, like the INSERT IGNORE does.
+	DelRangeConflictKeepFirst DelRangeConflictPolicy = "keep-first"
+	// DelRangeConflictError fails the restore.
+	DelRangeConflictError DelRangeConflictPolicy = "error"
+)
+
+// ParseDelRangeConflictPolicy parses the DelRangeConflictPolicy, the empty string is DelRangeConflictKeepFirst.
+func ParseDelRangeConflictPolicy(s string) (DelRangeConflictPolicy, error) {
+	switch policy := DelRangeConflictPolicy(strings.ToLower(s)); policy {
+	case "":
+		return DelRangeConflictKeepFirst, nil
+	case DelRangeConflictKeepFirst, DelRangeConflictError:
+		return policy, nil
+	default:
+		return "", errors.Annotatef(berrors.ErrInvalidArgument, "unknown delete range conflict policy %q, "+
+			"should be one of %q and %q", s, DelRangeConflictKeepFirst, DelRangeConflictError)
+	}
+}
+
+// The kinds of the delete ranges skipped by the dedup.
+const (
+	delRangeDuplicated = "duplicated"
+	delRangeConflicted = "conflicted"
+)
+
+type delRangeKey struct {
+	jobID  int64
+	elemID int64
+}
+
+type delRangeValue struct {
+	startKey string
+	endKey   string
+}
+
+// DelRangeDedupStats is the statistics of the delete ranges skipped by the dedup.
+type DelRangeDedupStats struct {
+	// Duplicated is the number of the delete ranges recorded again with the same range.
+	Duplicated uint64
+	// Conflicted is the number of the delete ranges recorded again with a different range.
+	Conflicted uint64
+}
+
+// delRangeDedup dedups the delete ranges by the job ID and element ID. The job of the upstream history
+// may be restored more than once, e.g. it's written to both mDDLJobHistory and mysql.tidb_ddl_history,
+// or retried by the upstream.
+type delRangeDedup struct {
+	recorded map[delRangeKey]delRangeValue
+	stats    DelRangeDedupStats
+}
+
+// check returns whether the delete range is recorded for the first time, the error is returned if it
+// conflicts with the recorded one under DelRangeConflictError.
+func (d *delRangeDedup) check(policy DelRangeConflictPolicy, params DelRangeParams) (bool, error) {
+	if d.recorded == nil {
+		d.recorded = make(map[delRangeKey]delRangeValue)
+	}
+	key := delRangeKey{jobID: params.JobID, elemID: params.ElemID}
+	value := delRangeValue{startKey: params.StartKey, endKey: params.EndKey}
+	recorded, ok := d.recorded[key]
+	switch {
+	case !ok:
+		d.recorded[key] = value
+		return true, nil
+	case recorded == value:
+		d.stats.Duplicated++
+		delRangeDedupCounter.WithLabelValues(delRangeDuplicated).Inc()
+		return false, nil
+	case policy == DelRangeConflictError:
+		return false, errors.Annotatef(berrors.ErrRestoreInvalidBackup, "the delete range of the job %d element %d "+
+			"is [%s, %s), but [%s, %s) is recorded before", params.JobID, params.ElemID,
+			value.startKey, value.endKey, recorded.startKey, recorded.endKey)
+	default:
+		log.Warn("skip the delete range conflicting with the recorded one", zap.Int64("jobID", params.JobID),
+			zap.Int64("elemID", params.ElemID), zap.String("startKey", value.startKey),
+			zap.String("endKey", value.endKey), zap.String("recordedStartKey", recorded.startKey),
+			zap.String("recordedEndKey", recorded.endKey))
+		d.stats.Conflicted++
+		delRangeDedupCounter.WithLabelValues(delRangeConflicted).Inc()
+		return false, nil
+	}
+}
+
+// DelRangeDedupStats returns the statistics of the delete ranges skipped by the dedup.
+func (sr *SchemasReplace) DelRangeDedupStats() DelRangeDedupStats {
+	return sr.delRangeRecorder.dedup.stats
+}
@@ -0,0 +1,107 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package stream
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	berrors "github.com/pingcap/tidb/br/pkg/errors"
+	"github.com/pingcap/tidb/pkg/ddl"
+	"github.com/pingcap/tidb/pkg/meta/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDelRangeQueryBatches(t *testing.T) {
+	query := &PreDelRangeQuery{Sql: ddl.BRInsertDeleteRangeSQLPrefix}
+	for i := range 10 {
+		query.ParamsList = append(query.ParamsList, DelRangeParams{
+			JobID: 1, ElemID: int64(i + 1), StartKey: fmt.Sprintf("%020d", i), EndKey: fmt.Sprintf("%020d", i+1),
+		})
+	}
+	checkBatches := func(batches []*PreDelRangeQuery, sizes ...int) {
+		require.Len(t, batches, len(sizes))
+		elemID := int64(1)
+		for i, batch := range batches {
+			require.Len(t, batch.ParamsList, sizes[i])
+			// every delete range has its placeholders, without the trailing separator.
+			require.Equal(t, sizes[i], strings.Count(batch.Sql, ddl.BRInsertDeleteRangeSQLValue))
+			require.True(t, strings.HasPrefix(batch.Sql, ddl.BRInsertDeleteRangeSQLPrefix))
+			require.False(t, strings.HasSuffix(batch.Sql, ","))
+			for _, params := range batch.ParamsList {
+				require.Equal(t, elemID, params.ElemID)
+				elemID++
+			}
+		}
+	}
+
+	checkBatches(query.Batches(DelRangeBatchLimit{}), 10)
+	checkBatches(query.Batches(DelRangeBatchLimit{MaxRows: 4}), 4, 4, 2)
+	checkBatches(query.Batches(DefaultDelRangeBatchLimit()), 10)
+	// every row is 120 bytes.
+	rowSize := delRangeRowSize(&query.ParamsList[0])
+	require.Equal(t, 120, rowSize)
+	limit := DelRangeBatchLimit{MaxBytes: len(ddl.BRInsertDeleteRangeSQLPrefix) + 3*rowSize}
+	checkBatches(query.Batches(limit), 3, 3, 3, 1)
+	limit.MaxRows = 2
+	checkBatches(query.Batches(limit), 2, 2, 2, 2, 2)
+	// the row exceeding the limit by itself is inserted alone.
+	checkBatches(query.Batches(DelRangeBatchLimit{MaxBytes: 1}), 1, 1, 1, 1, 1, 1, 1, 1, 1, 1)
+	// no statement for the query without delete ranges.
+	require.Empty(t, (&PreDelRangeQuery{Sql: ddl.BRInsertDeleteRangeSQLPrefix}).Batches(DefaultDelRangeBatchLimit()))
+}
+
+func TestDelRangeDedup(t *testing.T) {
+	newSchemasReplace := func(policy DelRangeConflictPolicy) (*SchemasReplace, *mockInsertDeleteRange) {
+		midr := newMockInsertDeleteRange()
+		sr := MockEmptySchemasReplace(midr, map[int64]*DBReplace{
+			mDDLJobDBOldID: {
+				DbID: mDDLJobDBNewID,
+				TableMap: map[int64]*TableReplace{
+					mDDLJobTable0OldID: {TableID: mDDLJobTable0NewID},
+					mDDLJobTable1OldID: {TableID: mDDLJobTable1NewID},
+				},
+			},
+		})
+		sr.DelRangeConflictPolicy = policy
+		return sr, midr
+	}
+	// the job retried by the upstream drops another table.
+	conflictJob := genFinishedJob(&model.Job{ID: dropTable1Job.ID, Version: model.GetJobVerInUse(),
+		Type: model.ActionDropTable, SchemaID: mDDLJobDBOldID, TableID: mDDLJobTable0OldID}, &model.DropTableArgs{})
+
+	sr, midr := newSchemasReplace(DelRangeConflictKeepFirst)
+	require.NoError(t, sr.restoreFromHistory(dropTable1Job))
+	query := <-midr.queryCh
+	require.Len(t, query.ParamsList, 1)
+	require.Equal(t, encodeTableKey(mDDLJobTable1NewID), query.ParamsList[0].StartKey)
+	// the job restored again isn't recorded.
+	require.NoError(t, sr.restoreFromHistory(dropTable1Job))
+	require.NoError(t, sr.restoreFromHistory(conflictJob))
+	require.Len(t, midr.queryCh, 0)
+	require.Equal(t, DelRangeDedupStats{Duplicated: 1, Conflicted: 1}, sr.DelRangeDedupStats())
+
+	sr, midr = newSchemasReplace(DelRangeConflictError)
+	require.NoError(t, sr.restoreFromHistory(dropTable1Job))
+	<-midr.queryCh
+	require.ErrorIs(t, sr.restoreFromHistory(conflictJob), berrors.ErrRestoreInvalidBackup)
+	require.Len(t, midr.queryCh, 0)
+
+	policy, err := ParseDelRangeConflictPolicy("")
+	require.NoError(t, err)
+	require.Equal(t, DelRangeConflictKeepFirst, policy)
+	_, err = ParseDelRangeConflictPolicy("overwrite")
+	require.ErrorIs(t, err, berrors.ErrInvalidArgument)
+}
+
+func TestDelRangePace(t *testing.T) {
+	require.Zero(t, DelRangePace{}.SleepBetween(100))
+	require.Equal(t, time.Second, DelRangePace{Interval: time.Second}.SleepBetween(100))
+	// the statements are spread over the duration.
+	require.Equal(t, time.Minute, DelRangePace{Duration: 10 * time.Minute}.SleepBetween(11))
+	require.Equal(t, 2*time.Minute, DelRangePace{Interval: 2 * time.Minute, Duration: 10 * time.Minute}.SleepBetween(11))
+	// there is no gap to spread over.
+	require.Zero(t, DelRangePace{Duration: 10 * time.Minute}.SleepBetween(1))
+}
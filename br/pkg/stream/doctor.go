@@ -0,0 +1,506 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package stream
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/pingcap/errors"
+	backuppb "github.com/pingcap/kvproto/pkg/brpb"
+	"github.com/pingcap/log"
+	"github.com/pingcap/tidb/br/pkg/glue"
+	"github.com/pingcap/tidb/br/pkg/storage"
+	. "github.com/pingcap/tidb/br/pkg/streamhelper"
+	"github.com/tikv/client-go/v2/oracle"
+	pdhttp "github.com/tikv/pd/client/http"
+	"go.uber.org/zap"
+)
+
+// DoctorSeverity is the severity of a finding of the doctor.
+type DoctorSeverity string
+
+const (
+	DoctorOK    DoctorSeverity = "ok"
+	DoctorWarn  DoctorSeverity = "warn"
+	DoctorError DoctorSeverity = "error"
+)
+
+// The checks of the doctor.
+const (
+	DoctorCheckStores     = "stores"
+	DoctorCheckAdvancer   = "advancer"
+	DoctorCheckSafePoint  = "safepoint"
+	DoctorCheckStorage    = "storage"
+	DoctorCheckCheckpoint = "checkpoint"
+)
+
+// DoctorFinding is the result of a check of the doctor, with the probable causes and the remediation
+// steps if it's a problem.
+type DoctorFinding struct {
+	Check       string         `json:"check"`
+	Severity    DoctorSeverity `json:"severity"`
+	Message     string         `json:"message"`
+	Causes      []string       `json:"causes,omitempty"`
+	Remediation []string       `json:"remediation,omitempty"`
+}
+
+// DoctorStore is the state of a TiKV store inspected by the doctor.
+type DoctorStore struct {
+	ID      uint64
+	Address string
+	// State is the state name of the store in PD, like "Up", "Disconnected" and "Down".
+	State string
+	// MetricsErr is the error of fetching the metrics from the status port of the store.
+	MetricsErr error
+	// LogBackupObserved is whether the store reports the metrics of the log backup.
+	LogBackupObserved bool
+}
+
+// DoctorSafePoint is a service GC safe point in PD.
+type DoctorSafePoint struct {
+	SafePoint uint64
+	ExpiredAt time.Time
+}
+
+// DoctorSnapshot is the states of the cluster and a log backup task collected by the doctor.
+type DoctorSnapshot struct {
+	Now time.Time
+
+	TaskName     string
+	Paused       bool
+	CheckpointTS uint64
+	LastErrors   map[uint64]backuppb.StreamBackupError
+
+	Stores []DoctorStore
+	// SafePoints are the service GC safe points by the service ID.
+	SafePoints map[string]DoctorSafePoint
+	// PauseSafePointID is the ID of the service GC safe point set by pausing the task.
+	PauseSafePointID string
+	GCSafePoint      uint64
+	// AdvancerOwners are the owners of the advancer sampled in order, the empty string means no owner.
+	AdvancerOwners []string
+	ObserveWindow  time.Duration
+	// StorageErr is the error of writing the probe file into the storage of the task.
+	StorageErr error
+}
+
+// Diagnose checks the snapshot for the common failure modes of the log backup, the checkpoint lagging
+// more than maxLag is a problem.
+func Diagnose(s *DoctorSnapshot, maxLag time.Duration) []DoctorFinding {
+	var findings []DoctorFinding
+	findings = append(findings, diagnoseStores(s)...)
+	findings = append(findings, diagnoseAdvancer(s)...)
+	findings = append(findings, diagnoseSafePoint(s, maxLag)...)
+	findings = append(findings, diagnoseStorage(s)...)
+	findings = append(findings, diagnoseCheckpoint(s, maxLag)...)
+	return findings
+}
+
+// okIfNone returns the finding of the check passed if there aren't any problems.
+func okIfNone(findings []DoctorFinding, check, message string) []DoctorFinding {
+	if len(findings) == 0 {
+		return []DoctorFinding{{Check: check, Severity: DoctorOK, Message: message}}
+	}
+	return findings
+}
+
+func formatDoctorTS(ts uint64) string {
+	return fmt.Sprintf("%s(%d)", FormatDate(oracle.GetTimeFromTS(ts)), ts)
+}
+
+func diagnoseStores(s *DoctorSnapshot) []DoctorFinding {
+	var findings []DoctorFinding
+	for _, store := range s.Stores {
+		switch {
+		case store.State != "Up" && store.State != "Offline":
+			findings = append(findings, DoctorFinding{
+				Check:    DoctorCheckStores,
+				Severity: DoctorError,
+				Message:  fmt.Sprintf("store %d (%s) is %s in PD", store.ID, store.Address, store.State),
+				Causes: []string{
+					"the TiKV is down or restarting",
+					"the network between the TiKV and PD is partitioned",
+				},
+				Remediation: []string{
+					"check the process and the log of the TiKV",
+					"the checkpoint can't advance until the regions led by the store are available again",
+				},
+			})
+		case store.MetricsErr != nil:
+			findings = append(findings, DoctorFinding{
+				Check:    DoctorCheckStores,
+				Severity: DoctorWarn,
+				Message: fmt.Sprintf("failed to fetch the metrics from the status port of store %d (%s): %s",
+					store.ID, store.Address, store.MetricsErr),
+				Causes: []string{
+					"the status port of the TiKV is blocked by the firewall",
+					"the TLS config of BR mismatches the TiKV",
+				},
+				Remediation: []string{
+					"make sure the status port of the TiKV is reachable from BR",
+				},
+			})
+		case !store.LogBackupObserved:
+			findings = append(findings, DoctorFinding{
+				Check:    DoctorCheckStores,
+				Severity: DoctorError,
+				Message:  fmt.Sprintf("store %d (%s) doesn't report the metrics of the log backup", store.ID, store.Address),
+				Causes: []string{
+					"the log backup is disabled by `log-backup.enable = false` in the TiKV config",
+					"the version of the TiKV doesn't support the log backup",
+				},
+				Remediation: []string{
+					"set `log-backup.enable = true` in the TiKV config and restart the TiKV",
+					"upgrade the TiKV to the same version as the other stores",
+				},
+			})
+		}
+	}
+	return okIfNone(findings, DoctorCheckStores, fmt.Sprintf("all %d stores report the log backup", len(s.Stores)))
+}
+
+func diagnoseAdvancer(s *DoctorSnapshot) []DoctorFinding {
+	changes := 0
+	owned := false
+	for i, owner := range s.AdvancerOwners {
+		owned = owned || owner != ""
+		if i > 0 && owner != s.AdvancerOwners[i-1] {
+			changes++
+		}
+	}
+	switch {
+	case !owned:
+		return []DoctorFinding{{
+			Check:    DoctorCheckAdvancer,
+			Severity: DoctorError,
+			Message:  fmt.Sprintf("no TiDB owns the advancer of the log backup in %s", s.ObserveWindow),
+			Causes: []string{
+				"all TiDB servers are down or can't connect to PD",
+			},
+			Remediation: []string{
+				"check the TiDB servers, the advancer runs on the owner of them",
+			},
+		}}
+	case changes > 0:
+		return []DoctorFinding{{
+			Check:    DoctorCheckAdvancer,
+			Severity: DoctorWarn,
+			Message:  fmt.Sprintf("the owner of the advancer changed %d times in %s", changes, s.ObserveWindow),
+			Causes: []string{
+				"the TiDB servers restart frequently, like being OOM killed",
+				"the etcd session of the owner expires because of the high latency of PD",
+			},
+			Remediation: []string{
+				"check the restarts of the TiDB servers and the `daemon became owner` lines in their logs",
+				"check the load and the latency of PD",
+			},
+		}}
+	}
+	return okIfNone(nil, DoctorCheckAdvancer,
+		fmt.Sprintf("the advancer is owned by %s", s.AdvancerOwners[len(s.AdvancerOwners)-1]))
+}
+
+func diagnoseSafePoint(s *DoctorSnapshot, maxLag time.Duration) []DoctorFinding {
+	var findings []DoctorFinding
+	if s.GCSafePoint > s.CheckpointTS {
+		findings = append(findings, DoctorFinding{
+			Check:    DoctorCheckSafePoint,
+			Severity: DoctorError,
+			Message: fmt.Sprintf("the GC safe point %s is after the checkpoint %s, the data after the checkpoint may be lost",
+				formatDoctorTS(s.GCSafePoint), formatDoctorTS(s.CheckpointTS)),
+			Causes: []string{
+				"the service safe point of the log backup expired while the task was stuck or paused",
+			},
+			Remediation: []string{
+				"the task can't continue, stop it, take a new snapshot backup and start a new log backup task",
+			},
+		})
+		return findings
+	}
+
+	id := LogBackupServiceID
+	if s.Paused {
+		id = s.PauseSafePointID
+	}
+	sp, ok := s.SafePoints[id]
+	switch {
+	case !ok:
+		findings = append(findings, DoctorFinding{
+			Check:    DoctorCheckSafePoint,
+			Severity: DoctorWarn,
+			Message:  fmt.Sprintf("the service safe point %s isn't in PD", id),
+			Causes: []string{
+				"the advancer isn't running or fails to update the service safe point",
+				"the task has been paused longer than the TTL of the service safe point",
+			},
+			Remediation: []string{
+				"check the advancer on the owner TiDB",
+				"resume the task before the GC safe point passes the checkpoint",
+			},
+		})
+	case sp.ExpiredAt.Before(s.Now):
+		findings = append(findings, DoctorFinding{
+			Check:    DoctorCheckSafePoint,
+			Severity: DoctorError,
+			Message:  fmt.Sprintf("the service safe point %s expired at %s", id, FormatDate(sp.ExpiredAt)),
+			Causes: []string{
+				"the holder of the service safe point stopped updating it",
+			},
+			Remediation: []string{
+				"resume the task or check the advancer before the GC safe point passes the checkpoint",
+			},
+		})
+	case !s.Paused && oracle.GetTimeFromTS(s.CheckpointTS).Sub(oracle.GetTimeFromTS(sp.SafePoint)) > maxLag:
+		findings = append(findings, DoctorFinding{
+			Check:    DoctorCheckSafePoint,
+			Severity: DoctorWarn,
+			Message: fmt.Sprintf("the service safe point %s is stuck at %s, while the checkpoint is %s",
+				id, formatDoctorTS(sp.SafePoint), formatDoctorTS(s.CheckpointTS)),
+			Causes: []string{
+				"the owner of the advancer fails to update the service safe point in PD",
+			},
+			Remediation: []string{
+				"check the errors of updating the service safe point in the log of the owner TiDB",
+			},
+		})
+	}
+	return okIfNone(findings, DoctorCheckSafePoint,
+		fmt.Sprintf("the service safe point %s is %s", id, formatDoctorTS(sp.SafePoint)))
+}
+
+// isStorageError checks whether the error reported by the store is about the storage.
+func isStorageError(e *backuppb.StreamBackupError) bool {
+	msg := strings.ToLower(e.ErrorCode + " " + e.ErrorMessage)
+	for _, keyword := range []string{
+		"storage", "s3", "gcs", "azure", "blob", "bucket", "access denied", "forbidden", "permission", "credential",
+	} {
+		if strings.Contains(msg, keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+func diagnoseStorage(s *DoctorSnapshot) []DoctorFinding {
+	var findings []DoctorFinding
+	remediation := []string{
+		"check the credentials and the permission of writing the storage, the credentials may be expired",
+		"check the quota and the network of the storage",
+	}
+	if s.StorageErr != nil {
+		findings = append(findings, DoctorFinding{
+			Check:    DoctorCheckStorage,
+			Severity: DoctorError,
+			Message:  fmt.Sprintf("failed to write the probe file into the storage: %s", s.StorageErr),
+			Causes: []string{
+				"BR can't write the storage with the credentials of the task",
+			},
+			Remediation: remediation,
+		})
+	}
+	for _, storeID := range slices.Sorted(maps.Keys(s.LastErrors)) {
+		e := s.LastErrors[storeID]
+		if !isStorageError(&e) {
+			continue
+		}
+		findings = append(findings, DoctorFinding{
+			Check:    DoctorCheckStorage,
+			Severity: DoctorError,
+			Message:  fmt.Sprintf("store %d failed to write the storage: [%s] %s", storeID, e.ErrorCode, e.ErrorMessage),
+			Causes: []string{
+				"the TiKV can't write the storage, the task is paused by the error",
+			},
+			Remediation: append(slices.Clone(remediation), "resume the task by `br log resume` after fixing the storage"),
+		})
+	}
+	return okIfNone(findings, DoctorCheckStorage, "the storage is writable")
+}
+
+func diagnoseCheckpoint(s *DoctorSnapshot, maxLag time.Duration) []DoctorFinding {
+	var findings []DoctorFinding
+	for _, storeID := range slices.Sorted(maps.Keys(s.LastErrors)) {
+		e := s.LastErrors[storeID]
+		if isStorageError(&e) {
+			continue
+		}
+		findings = append(findings, DoctorFinding{
+			Check:    DoctorCheckCheckpoint,
+			Severity: DoctorError,
+			Message:  fmt.Sprintf("store %d reported the error: [%s] %s", storeID, e.ErrorCode, e.ErrorMessage),
+			Causes: []string{
+				"the task is paused by the fatal error of the store",
+			},
+			Remediation: []string{
+				"fix the error and resume the task by `br log resume`",
+			},
+		})
+	}
+	lag := s.Now.Sub(oracle.GetTimeFromTS(s.CheckpointTS)).Round(time.Second)
+	switch {
+	case s.Paused && len(findings) == 0:
+		findings = append(findings, DoctorFinding{
+			Check:    DoctorCheckCheckpoint,
+			Severity: DoctorWarn,
+			Message:  fmt.Sprintf("the task is paused, the checkpoint lags %s", lag),
+			Remediation: []string{
+				"resume the task by `br log resume`",
+			},
+		})
+	case !s.Paused && lag > maxLag:
+		findings = append(findings, DoctorFinding{
+			Check:    DoctorCheckCheckpoint,
+			Severity: DoctorError,
+			Message:  fmt.Sprintf("the checkpoint %s lags %s", formatDoctorTS(s.CheckpointTS), lag),
+			Causes: []string{
+				"some stores don't report the log backup",
+				"the advancer isn't running or its owner keeps changing",
+				"the resolved ts of some regions is blocked by the long running transactions or the stuck locks",
+			},
+			Remediation: []string{
+				"fix the problems of the stores and the advancer found above",
+				"check the resolved ts and the `tikv_log_backup_*` metrics of the stores",
+			},
+		})
+	}
+	return okIfNone(findings, DoctorCheckCheckpoint, fmt.Sprintf("the checkpoint lags %s", lag))
+}
+
+// CollectDoctorStores collects the states of the TiKV stores from PD and their status ports, the
+// TiFlash stores and the tombstone stores are skipped.
+func CollectDoctorStores(ctx context.Context, pdHTTP pdhttp.Client, client *http.Client, tls bool) ([]DoctorStore, error) {
+	stores, err := pdHTTP.GetStores(ctx)
+	if err != nil {
+		return nil, errors.Annotate(err, "failed to get the stores from PD")
+	}
+	prefix := "http://"
+	if tls {
+		prefix = "https://"
+	}
+	result := make([]DoctorStore, 0, len(stores.Stores))
+	for _, info := range stores.Stores {
+		meta := info.Store
+		if meta.StateName == "Tombstone" || isTiFlashStore(meta.Labels) {
+			continue
+		}
+		store := DoctorStore{ID: uint64(meta.ID), Address: meta.Address, State: meta.StateName}
+		if meta.StateName == "Up" || meta.StateName == "Offline" {
+			store.LogBackupObserved, store.MetricsErr = fetchLogBackupObserved(ctx, client, prefix+meta.StatusAddress)
+		}
+		result = append(result, store)
+	}
+	return result, nil
+}
+
+func isTiFlashStore(labels []pdhttp.StoreLabel) bool {
+	for _, label := range labels {
+		if label.Key == "engine" && strings.Contains(label.Value, "tiflash") {
+			return true
+		}
+	}
+	return false
+}
+
+// fetchLogBackupObserved checks whether the store reports the metrics of the log backup endpoint.
+func fetchLogBackupObserved(ctx context.Context, client *http.Client, statusURL string) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, statusURL+"/metrics", nil)
+	if err != nil {
+		return false, errors.Trace(err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return false, errors.Trace(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return false, errors.Errorf("unexpected status %s", resp.Status)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return false, errors.Trace(err)
+	}
+	return logCountSumRe.Match(data), nil
+}
+
+// SampleAdvancerOwners gets the owner of the advancer every interval in the window.
+func SampleAdvancerOwners(
+	ctx context.Context,
+	meta *MetaDataClient,
+	window, interval time.Duration,
+) ([]string, error) {
+	var owners []string
+	deadline := time.Now().Add(window)
+	for {
+		owner, err := meta.GetAdvancerOwner(ctx)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		owners = append(owners, owner)
+		if !time.Now().Add(interval).Before(deadline) {
+			return owners, nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
+
+// ProbeStorage writes, reads back and deletes a probe file in the storage.
+func ProbeStorage(ctx context.Context, s storage.ExternalStorage, now time.Time) error {
+	name := fmt.Sprintf("br-doctor-probe-%d", now.UnixNano())
+	content := []byte(name)
+	if err := s.WriteFile(ctx, name, content); err != nil {
+		return errors.Annotatef(err, "failed to write %s", name)
+	}
+	data, err := s.ReadFile(ctx, name)
+	if err != nil {
+		return errors.Annotatef(err, "failed to read %s", name)
+	}
+	if !bytes.Equal(data, content) {
+		return errors.Errorf("the content of %s read back mismatches", name)
+	}
+	if err := s.DeleteFile(ctx, name); err != nil {
+		// the probe file is harmless, the storage may forbid deleting.
+		log.Warn("failed to delete the probe file", zap.String("file", name), zap.Error(err))
+	}
+	return nil
+}
+
+// PrintDoctorFindings prints the findings of the task.
+func PrintDoctorFindings(console glue.ConsoleOperations, task string, findings []DoctorFinding) {
+	console.Printf("> task: %s <\n", task)
+	for _, f := range findings {
+		var head string
+		switch f.Severity {
+		case DoctorOK:
+			head = statusOK(f.Check)
+		case DoctorWarn:
+			head = statusBlock(f.Check)
+		default:
+			head = statusErr(f.Check)
+		}
+		console.Printf("%s: %s\n", head, f.Message)
+		if len(f.Causes) > 0 {
+			console.Println(color.New(color.Faint).Sprint("  probable causes:"))
+			for _, c := range f.Causes {
+				console.Printf("    - %s\n", c)
+			}
+		}
+		if len(f.Remediation) > 0 {
+			console.Println(color.New(color.Faint).Sprint("  remediation:"))
+			for _, r := range f.Remediation {
+				console.Printf("    - %s\n", r)
+			}
+		}
+	}
+}
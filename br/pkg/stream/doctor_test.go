@@ -0,0 +1,123 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package stream
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/pingcap/errors"
+	backuppb "github.com/pingcap/kvproto/pkg/brpb"
+	"github.com/pingcap/tidb/br/pkg/storage"
+	"github.com/pingcap/tidb/br/pkg/streamhelper"
+	"github.com/stretchr/testify/require"
+	"github.com/tikv/client-go/v2/oracle"
+)
+
+func findingsOf(findings []DoctorFinding, check string) []DoctorFinding {
+	var result []DoctorFinding
+	for _, f := range findings {
+		if f.Check == check {
+			result = append(result, f)
+		}
+	}
+	return result
+}
+
+func TestDiagnose(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	checkpoint := oracle.GoTimeToTS(now.Add(-time.Minute))
+	healthy := func() *DoctorSnapshot {
+		return &DoctorSnapshot{
+			Now:          now,
+			TaskName:     "pitr",
+			CheckpointTS: checkpoint,
+			Stores: []DoctorStore{
+				{ID: 1, Address: "tikv-1:20160", State: "Up", LogBackupObserved: true},
+				{ID: 2, Address: "tikv-2:20160", State: "Up", LogBackupObserved: true},
+			},
+			SafePoints: map[string]DoctorSafePoint{
+				streamhelper.LogBackupServiceID: {SafePoint: checkpoint - 1, ExpiredAt: now.Add(time.Hour)},
+			},
+			PauseSafePointID: "pitr_pause_safepoint",
+			GCSafePoint:      oracle.GoTimeToTS(now.Add(-time.Hour)),
+			AdvancerOwners:   []string{"tidb-1", "tidb-1"},
+			ObserveWindow:    2 * time.Second,
+		}
+	}
+	findings := Diagnose(healthy(), 10*time.Minute)
+	require.Len(t, findings, 5)
+	for _, f := range findings {
+		require.Equal(t, DoctorOK, f.Severity, f.Message)
+	}
+
+	s := healthy()
+	s.Stores[0].State = "Disconnected"
+	s.Stores[1].LogBackupObserved = false
+	s.AdvancerOwners = []string{"tidb-1", "tidb-2", "tidb-1"}
+	s.SafePoints[streamhelper.LogBackupServiceID] = DoctorSafePoint{
+		SafePoint: oracle.GoTimeToTS(now.Add(-2 * time.Hour)), ExpiredAt: now.Add(time.Hour),
+	}
+	s.CheckpointTS = oracle.GoTimeToTS(now.Add(-time.Hour))
+	s.StorageErr = errors.New("access denied")
+	findings = Diagnose(s, 10*time.Minute)
+	stores := findingsOf(findings, DoctorCheckStores)
+	require.Len(t, stores, 2)
+	require.Equal(t, DoctorError, stores[0].Severity)
+	require.Contains(t, stores[0].Message, "store 1 (tikv-1:20160) is Disconnected")
+	require.Contains(t, stores[1].Message, "store 2 (tikv-2:20160) doesn't report the metrics of the log backup")
+	require.NotEmpty(t, stores[1].Remediation)
+	advancer := findingsOf(findings, DoctorCheckAdvancer)
+	require.Equal(t, DoctorWarn, advancer[0].Severity)
+	require.Contains(t, advancer[0].Message, "changed 2 times")
+	safePoint := findingsOf(findings, DoctorCheckSafePoint)
+	require.Equal(t, DoctorWarn, safePoint[0].Severity)
+	require.Contains(t, safePoint[0].Message, "is stuck at")
+	require.Equal(t, DoctorError, findingsOf(findings, DoctorCheckStorage)[0].Severity)
+	lag := findingsOf(findings, DoctorCheckCheckpoint)
+	require.Equal(t, DoctorError, lag[0].Severity)
+	require.Contains(t, lag[0].Message, "lags 1h0m0s")
+
+	// the paused task with the errors reported by the stores.
+	s = healthy()
+	s.Paused = true
+	s.AdvancerOwners = []string{"", ""}
+	s.LastErrors = map[uint64]backuppb.StreamBackupError{
+		2: {ErrorCode: "KV:LogBackup:RaftReq", ErrorMessage: "region not found"},
+		1: {ErrorCode: "KV:LogBackup:Io", ErrorMessage: "failed to put object to s3: AccessDenied"},
+	}
+	s.GCSafePoint = checkpoint + 1
+	findings = Diagnose(s, 10*time.Minute)
+	require.Equal(t, DoctorError, findingsOf(findings, DoctorCheckAdvancer)[0].Severity)
+	safePoint = findingsOf(findings, DoctorCheckSafePoint)
+	require.Len(t, safePoint, 1)
+	require.Contains(t, safePoint[0].Message, "is after the checkpoint")
+	storageFindings := findingsOf(findings, DoctorCheckStorage)
+	require.Len(t, storageFindings, 1)
+	require.Contains(t, storageFindings[0].Message, "store 1 failed to write the storage")
+	lag = findingsOf(findings, DoctorCheckCheckpoint)
+	require.Len(t, lag, 1)
+	require.Contains(t, lag[0].Message, "store 2 reported the error")
+
+	// the pause safe point is checked for the paused task.
+	s = healthy()
+	s.Paused = true
+	findings = Diagnose(s, 10*time.Minute)
+	safePoint = findingsOf(findings, DoctorCheckSafePoint)
+	require.Equal(t, DoctorWarn, safePoint[0].Severity)
+	require.Contains(t, safePoint[0].Message, "pitr_pause_safepoint isn't in PD")
+	require.Equal(t, DoctorWarn, findingsOf(findings, DoctorCheckCheckpoint)[0].Severity)
+}
+
+func TestProbeStorage(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	s, err := storage.NewLocalStorage(dir)
+	require.NoError(t, err)
+	require.NoError(t, ProbeStorage(ctx, s, time.Unix(1, 0)))
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Empty(t, entries)
+}
@@ -0,0 +1,117 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package stream
+
+import (
+	"context"
+	"sync"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/log"
+	"go.uber.org/zap"
+)
+
+// IDRange is the contiguous range [Start, End) of the global IDs.
+type IDRange struct {
+	Start int64
+	End   int64
+}
+
+// ClaimIDsFn claims n contiguous global IDs from the meta of the target cluster atomically, and returns
+// the first one of them.
+type ClaimIDsFn func(ctx context.Context, n int) (int64, error)
+
+// IDReservation hands out the downstream IDs of the databases, tables and partitions from the ranges
+// claimed from the global ID of the target cluster. The IDs in the claimed ranges are never allocated
+// to the tables created concurrently in the target cluster, so the restore into a live cluster doesn't
+// collide with them, and the IDs of a restore are contiguous as much as possible.
+//
+// The IDs of a range not handed out are wasted, which is harmless since the global ID is far from
+// exhausted.
+type IDReservation struct {
+	mu        sync.Mutex
+	claim     ClaimIDsFn
+	batchSize int
+	ranges    []IDRange
+	// next is the next ID to be handed out of the last range.
+	next int64
+}
+
+// NewIDReservation creates the reservation claiming batchSize IDs at least every time.
+func NewIDReservation(claim ClaimIDsFn, batchSize int) *IDReservation {
+	return &IDReservation{claim: claim, batchSize: max(batchSize, 1)}
+}
+
+// Reserve claims a new range of n IDs at least if the IDs left are fewer than n, it's used to reserve
+// the IDs upfront if the number of them needed is known.
+func (r *IDReservation) Reserve(ctx context.Context, n int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.reserveLocked(ctx, n)
+}
+
+func (r *IDReservation) reserveLocked(ctx context.Context, n int) error {
+	if r.leftLocked() >= int64(n) {
+		return nil
+	}
+	n = max(n, r.batchSize)
+	start, err := r.claim(ctx, n)
+	if err != nil {
+		return errors.Annotatef(err, "failed to reserve %d global ids", n)
+	}
+	rg := IDRange{Start: start, End: start + int64(n)}
+	if len(r.ranges) > 0 && r.ranges[len(r.ranges)-1].End == rg.Start && r.next == rg.Start {
+		// no table is created between the claims, extend the last range.
+		r.ranges[len(r.ranges)-1].End = rg.End
+	} else {
+		r.ranges = append(r.ranges, rg)
+		r.next = rg.Start
+	}
+	log.Info("reserved the global ids", zap.Int64("start", rg.Start), zap.Int64("end", rg.End))
+	return nil
+}
+
+func (r *IDReservation) leftLocked() int64 {
+	if len(r.ranges) == 0 {
+		return 0
+	}
+	return r.ranges[len(r.ranges)-1].End - r.next
+}
+
+// GenGlobalID hands out an ID from the reserved ranges, a new range is claimed if they are exhausted.
+func (r *IDReservation) GenGlobalID(ctx context.Context) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.reserveLocked(ctx, 1); err != nil {
+		return 0, errors.Trace(err)
+	}
+	id := r.next
+	r.next++
+	return id, nil
+}
+
+// Ranges returns the ranges claimed.
+func (r *IDReservation) Ranges() []IDRange {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]IDRange(nil), r.ranges...)
+}
+
+// Contains checks whether the ID is in the ranges claimed.
+func (r *IDReservation) Contains(id int64) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, rg := range r.ranges {
+		if id >= rg.Start && id < rg.End {
+			return true
+		}
+	}
+	return false
+}
+
+// Unused returns the number of the IDs claimed but not handed out yet.
+func (r *IDReservation) Unused() int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.leftLocked()
+}
@@ -0,0 +1,54 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package stream
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pingcap/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIDReservation(t *testing.T) {
+	ctx := context.Background()
+	globalID := int64(100)
+	claims := 0
+	claim := func(_ context.Context, n int) (int64, error) {
+		claims++
+		start := globalID + 1
+		globalID += int64(n)
+		return start, nil
+	}
+	r := NewIDReservation(claim, 4)
+
+	for i := int64(101); i <= 106; i++ {
+		id, err := r.GenGlobalID(ctx)
+		require.NoError(t, err)
+		require.Equal(t, i, id)
+	}
+	// the contiguous claims are merged.
+	require.Equal(t, 2, claims)
+	require.Equal(t, []IDRange{{Start: 101, End: 109}}, r.Ranges())
+	require.EqualValues(t, 2, r.Unused())
+
+	// a table is created concurrently between the claims.
+	globalID++
+	require.NoError(t, r.Reserve(ctx, 2))
+	require.Equal(t, 2, claims)
+	require.NoError(t, r.Reserve(ctx, 10))
+	require.Equal(t, 3, claims)
+	require.Equal(t, []IDRange{{Start: 101, End: 109}, {Start: 110, End: 120}}, r.Ranges())
+	id, err := r.GenGlobalID(ctx)
+	require.NoError(t, err)
+	require.EqualValues(t, 110, id)
+	require.True(t, r.Contains(108))
+	require.False(t, r.Contains(109))
+	require.True(t, r.Contains(119))
+
+	failed := NewIDReservation(func(context.Context, int) (int64, error) {
+		return 0, errors.New("meta is locked")
+	}, 4)
+	_, err = failed.GenGlobalID(ctx)
+	require.ErrorContains(t, err, "failed to reserve 4 global ids")
+}
@@ -0,0 +1,164 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package stream
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"sync"
+
+	"github.com/pingcap/errors"
+	backuppb "github.com/pingcap/kvproto/pkg/brpb"
+	berrors "github.com/pingcap/tidb/br/pkg/errors"
+	"github.com/pingcap/tidb/br/pkg/storage"
+	"github.com/pingcap/tidb/br/pkg/streamhelper"
+)
+
+const (
+	// logArchiveProbeFile is written and deleted to probe whether the storage of the log backup is available.
+	logArchiveProbeFile = "log_backup_storage_probe"
+	// logArchiveVerifyWorkers is the number of workers reading the metadata when verifying the archive.
+	logArchiveVerifyWorkers = 128
+)
+
+// LogArchiveVerifier accesses the storage of the log backup tasks for the advancer to handle the storage
+// outages.
+type LogArchiveVerifier struct {
+	// StorageOptions returns the options to access the storage.
+	StorageOptions func(*backuppb.StorageBackend) storage.ExternalStorageOptions
+}
+
+var _ streamhelper.LogArchive = &LogArchiveVerifier{}
+
+func (a *LogArchiveVerifier) open(ctx context.Context, info *backuppb.StreamBackupTaskInfo) (storage.ExternalStorage, error) {
+	var opts storage.ExternalStorageOptions
+	if a.StorageOptions != nil {
+		opts = a.StorageOptions(info.GetStorage())
+	}
+	s, err := storage.New(ctx, info.GetStorage(), &opts)
+	return s, errors.Trace(err)
+}
+
+// Probe checks whether the storage is available by writing and deleting a small file.
+func (a *LogArchiveVerifier) Probe(ctx context.Context, info *backuppb.StreamBackupTaskInfo) error {
+	s, err := a.open(ctx, info)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer s.Close()
+	if err := s.WriteFile(ctx, logArchiveProbeFile, []byte(info.GetName())); err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(s.DeleteFile(ctx, logArchiveProbeFile))
+}
+
+// VerifyNoGaps checks the archive between the timestamps is continuous, i.e. the files flushed by each store
+// cover the range continuously and all exist.
+func (a *LogArchiveVerifier) VerifyNoGaps(ctx context.Context, info *backuppb.StreamBackupTaskInfo, since, until uint64) error {
+	s, err := a.open(ctx, info)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer s.Close()
+	return errors.Trace(verifyLogArchiveNoGaps(ctx, s, since, until))
+}
+
+// flushedRange is the range of the timestamps flushed by a metadata file of a store. The resolved ts of the
+// metadata is the min resolved ts of its files, all the changes of the store before it are flushed by the
+// earlier flushes, so the flush covers the changes since its resolved ts until its max ts.
+type flushedRange struct {
+	minTS uint64
+	maxTS uint64
+}
+
+func verifyLogArchiveNoGaps(ctx context.Context, s storage.ExternalStorage, since, until uint64) error {
+	var (
+		mu      sync.Mutex
+		missing []string
+		flushed = make(map[int64][]flushedRange)
+	)
+	err := FastUnmarshalMetaData(ctx, s, logArchiveVerifyWorkers, func(path string, raw []byte) error {
+		m, err := (*MetadataHelper).ParseToMetadataHard(nil, raw)
+		if err != nil {
+			return errors.Annotatef(err, "failed to parse metadata of file %s", path)
+		}
+		if m.MaxTs < since {
+			return nil
+		}
+		var paths []string
+		if m.MetaVersion > backuppb.MetaVersion_V1 {
+			for _, group := range m.FileGroups {
+				if group.MaxTs >= since {
+					paths = append(paths, group.Path)
+				}
+			}
+		} else {
+			for _, file := range m.Files {
+				if file.MaxTs >= since {
+					paths = append(paths, file.Path)
+				}
+			}
+		}
+		for _, p := range paths {
+			exists, err := s.FileExists(ctx, p)
+			if err != nil {
+				return errors.Annotatef(err, "failed to check the file %s referred by %s", p, path)
+			}
+			if !exists {
+				mu.Lock()
+				missing = append(missing, p)
+				mu.Unlock()
+			}
+		}
+		r := flushedRange{minTS: m.MinTs, maxTS: m.MaxTs}
+		if m.ResolvedTs > 0 {
+			r.minTS = min(r.minTS, m.ResolvedTs)
+		}
+		if r.minTS <= until {
+			mu.Lock()
+			flushed[m.StoreId] = append(flushed[m.StoreId], r)
+			mu.Unlock()
+		}
+		return nil
+	})
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if len(missing) > 0 {
+		slices.Sort(missing)
+		return errors.Annotatef(berrors.ErrPiTRArchiveGap, "%d files flushed since %d are missing, the first is %s",
+			len(missing), since, missing[0])
+	}
+	return errors.Trace(checkFlushedRangesContinuous(flushed, since, until))
+}
+
+// checkFlushedRangesContinuous checks the ranges flushed by each store between the timestamps have no holes.
+// The store doesn't flush anything if it has no changes, so the ranges before its first flush and after its
+// last flush aren't checked.
+func checkFlushedRangesContinuous(flushed map[int64][]flushedRange, since, until uint64) error {
+	stores := make([]int64, 0, len(flushed))
+	for store := range flushed {
+		stores = append(stores, store)
+	}
+	slices.Sort(stores)
+	for _, store := range stores {
+		ranges := flushed[store]
+		slices.SortFunc(ranges, func(a, b flushedRange) int {
+			return cmp.Compare(a.minTS, b.minTS)
+		})
+		covered := ranges[0].maxTS
+		for _, r := range ranges[1:] {
+			if covered >= until {
+				break
+			}
+			if r.minTS > covered {
+				return errors.Annotatef(berrors.ErrPiTRArchiveGap,
+					"the store %d flushed nothing between %d and %d, the archive between %d and %d isn't continuous",
+					store, covered, r.minTS, since, until)
+			}
+			covered = max(covered, r.maxTS)
+		}
+	}
+	return nil
+}
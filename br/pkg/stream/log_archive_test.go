@@ -0,0 +1,78 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package stream
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	backuppb "github.com/pingcap/kvproto/pkg/brpb"
+	berrors "github.com/pingcap/tidb/br/pkg/errors"
+	"github.com/pingcap/tidb/br/pkg/storage"
+	"github.com/stretchr/testify/require"
+)
+
+// writeFlush writes the metadata and the data file of a flush of the store.
+func writeFlush(t *testing.T, s storage.ExternalStorage, store int64, resolvedTS, minTS, maxTS uint64) string {
+	ctx := context.Background()
+	path := fmt.Sprintf("%d_%04d_to_%04d.log", store, minTS, maxTS)
+	require.NoError(t, s.WriteFile(ctx, path, []byte("test")))
+	meta := &backuppb.Metadata{
+		StoreId:     store,
+		ResolvedTs:  resolvedTS,
+		MinTs:       minTS,
+		MaxTs:       maxTS,
+		MetaVersion: backuppb.MetaVersion_V2,
+		FileGroups: []*backuppb.DataFileGroup{{
+			Path:          path,
+			MinTs:         minTS,
+			MaxTs:         maxTS,
+			MinResolvedTs: resolvedTS,
+			DataFilesInfo: []*backuppb.DataFileInfo{{Length: 1}},
+			Length:        1,
+		}},
+	}
+	bs, err := meta.Marshal()
+	require.NoError(t, err)
+	name := fmt.Sprintf("%s/%d_%04d.meta", GetStreamBackupMetaPrefix(), store, minTS)
+	require.NoError(t, s.WriteFile(ctx, name, bs))
+	return name
+}
+
+func TestLogArchiveVerifier(t *testing.T) {
+	ctx := context.Background()
+	s := tmp(t)
+	info := &backuppb.StreamBackupTaskInfo{
+		Name:    "test",
+		Storage: &backuppb.StorageBackend{Backend: &backuppb.StorageBackend_Local{Local: &backuppb.Local{Path: s.Base()}}},
+	}
+	verifier := &LogArchiveVerifier{}
+
+	require.NoError(t, verifier.Probe(ctx, info))
+	exists, err := s.FileExists(ctx, logArchiveProbeFile)
+	require.NoError(t, err)
+	require.False(t, exists)
+
+	// each flush covers the changes since its resolved ts.
+	writeFlush(t, s, 1, 8, 10, 20)
+	lost := writeFlush(t, s, 1, 18, 21, 30)
+	writeFlush(t, s, 1, 28, 32, 40)
+	writeFlush(t, s, 2, 5, 6, 50)
+	require.NoError(t, verifier.VerifyNoGaps(ctx, info, 10, 40))
+
+	require.NoError(t, s.DeleteFile(ctx, "1_0021_to_0030.log"))
+	require.NoError(t, verifier.VerifyNoGaps(ctx, info, 31, 40))
+	err = verifier.VerifyNoGaps(ctx, info, 10, 40)
+	require.True(t, berrors.ErrPiTRArchiveGap.Equal(err))
+	require.ErrorContains(t, err, "1_0021_to_0030.log")
+
+	// the changes between 20 and 28 of the store 1 are lost with the flush.
+	require.NoError(t, s.DeleteFile(ctx, lost))
+	err = verifier.VerifyNoGaps(ctx, info, 10, 40)
+	require.True(t, berrors.ErrPiTRArchiveGap.Equal(err))
+	require.ErrorContains(t, err, "the store 1 flushed nothing between 20 and 28")
+	// the ranges out of the verified range aren't checked.
+	require.NoError(t, verifier.VerifyNoGaps(ctx, info, 10, 19))
+	require.NoError(t, verifier.VerifyNoGaps(ctx, info, 29, 40))
+}
@@ -0,0 +1,102 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package stream
+
+import (
+	"context"
+	"encoding/binary"
+	"strings"
+	"time"
+
+	"github.com/pingcap/errors"
+	backuppb "github.com/pingcap/kvproto/pkg/brpb"
+	berrors "github.com/pingcap/tidb/br/pkg/errors"
+	"github.com/pingcap/tidb/br/pkg/metautil"
+	"github.com/pingcap/tidb/br/pkg/storage"
+	"github.com/tikv/client-go/v2/oracle"
+)
+
+// ShiftDuration is the safe duration before a ts, the values of the transactions committed after the ts
+// may be written in it.
+const ShiftDuration = time.Hour
+
+// LogRange is the range of the ts the log backup can be restored to.
+type LogRange struct {
+	// MinTS is the start ts of the log backup, or the ts it's truncated to.
+	MinTS uint64
+	// MaxTS is the global checkpoint of the log backup.
+	MaxTS     uint64
+	ClusterID uint64
+}
+
+// GetLogRange gets the range of the ts of the log backup in the storage.
+func GetLogRange(ctx context.Context, s storage.ExternalStorage) (LogRange, error) {
+	// logStartTS: Get log start ts from backupmeta file.
+	metaData, err := s.ReadFile(ctx, metautil.MetaFile)
+	if err != nil {
+		return LogRange{}, errors.Trace(err)
+	}
+	backupMeta := &backuppb.BackupMeta{}
+	if err = backupMeta.Unmarshal(metaData); err != nil {
+		return LogRange{}, errors.Trace(err)
+	}
+	// endVersion > 0 represents that the storage has been used for `br backup`
+	if backupMeta.GetEndVersion() > 0 {
+		return LogRange{}, errors.Annotate(berrors.ErrStorageUnknown,
+			"the storage has been used for full backup")
+	}
+	logStartTS := backupMeta.GetStartVersion()
+
+	// truncateTS: get log truncate ts from TruncateSafePointFileName.
+	// If truncateTS equals 0, which represents the stream log has never been truncated.
+	truncateTS, err := GetTSFromFile(ctx, s, TruncateSafePointFileName)
+	if err != nil {
+		return LogRange{}, errors.Trace(err)
+	}
+	logMinTS := max(logStartTS, truncateTS)
+
+	// get max global resolved ts from metas.
+	logMaxTS, err := getGlobalCheckpoint(ctx, s)
+	if err != nil {
+		return LogRange{}, errors.Trace(err)
+	}
+	logMaxTS = max(logMinTS, logMaxTS)
+
+	return LogRange{
+		MinTS:     logMinTS,
+		MaxTS:     logMaxTS,
+		ClusterID: backupMeta.ClusterId,
+	}, nil
+}
+
+func getGlobalCheckpoint(ctx context.Context, s storage.ExternalStorage) (uint64, error) {
+	var globalCheckPointTS uint64 = 0
+	opt := storage.WalkOption{SubDir: GetStreamBackupGlobalCheckpointPrefix()}
+	err := s.WalkDir(ctx, &opt, func(path string, size int64) error {
+		if !strings.HasSuffix(path, ".ts") {
+			return nil
+		}
+
+		buff, err := s.ReadFile(ctx, path)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		ts := binary.LittleEndian.Uint64(buff)
+		globalCheckPointTS = max(ts, globalCheckPointTS)
+		return nil
+	})
+	return globalCheckPointTS, errors.Trace(err)
+}
+
+// ShiftTS gets a smaller shiftTS than startTS.
+// It has a safe duration between shiftTS and startTS for trasaction.
+func ShiftTS(startTS uint64) uint64 {
+	physical := oracle.ExtractPhysical(startTS)
+	logical := oracle.ExtractLogical(startTS)
+
+	shiftPhysical := physical - ShiftDuration.Milliseconds()
+	if shiftPhysical < 0 {
+		return 0
+	}
+	return oracle.ComposeTS(shiftPhysical, logical)
+}
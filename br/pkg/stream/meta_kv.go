@@ -15,6 +15,9 @@
 package stream
 
 import (
+	"encoding/binary"
+	"math/bits"
+
 	"github.com/pingcap/errors"
 	berrors "github.com/pingcap/tidb/br/pkg/errors"
 	"github.com/pingcap/tidb/pkg/kv"
@@ -150,61 +153,67 @@ type RawWriteCFValue struct {
 	txnSource uint64
 }
 
-// ParseFrom decodes the value to get the struct `RawWriteCFValue`.
+// ParseFrom decodes the value to get the struct `RawWriteCFValue`. It's in the hot loop of the log restore,
+// so it doesn't allocate, and the short value refers to data.
 func (v *RawWriteCFValue) ParseFrom(data []byte) error {
 	if len(data) < 9 {
 		return errors.Annotatef(berrors.ErrInvalidArgument,
 			"invalid input value, len:%v", len(data))
 	}
 
-	t, err := WriteTypeFrom(data[0])
-	if err != nil {
-		return errors.Trace(err)
+	t := data[0]
+	switch t {
+	case WriteTypePut, WriteTypeDelete, WriteTypeLock, WriteTypeRollback:
+	default:
+		return errors.Annotatef(berrors.ErrInvalidArgument, "invalid write type:%c", t)
 	}
-	v.t = t
+	// the value may be reused, the fields absent from data are reset.
+	*v = RawWriteCFValue{t: t}
 
-	data, ts, err := codec.DecodeUvarint(data[1:])
-	if err != nil {
-		return errors.Trace(err)
+	ts, n := binary.Uvarint(data[1:])
+	if n <= 0 {
+		return errors.Annotate(berrors.ErrInvalidArgument, "decode start ts failed")
 	}
 	v.startTs = ts
+	data = data[1+n:]
 
-l_for:
 	for len(data) > 0 {
 		switch data[0] {
 		case flagShortValuePrefix:
-			vlen := data[1]
-			if len(data[2:]) < int(vlen) {
+			if len(data) < 2 || len(data[2:]) < int(data[1]) {
 				return errors.Annotatef(berrors.ErrInvalidArgument,
-					"the length of short value is invalid, vlen: %v", int(vlen))
+					"the length of short value is invalid, len: %v", len(data))
 			}
-			v.shortValue = data[2 : vlen+2]
-			data = data[vlen+2:]
+			end := 2 + int(data[1])
+			v.shortValue = data[2:end:end]
+			data = data[end:]
 		case flagOverlappedRollback:
 			v.hasOverlappedRollback = true
 			data = data[1:]
 		case flagGCFencePrefix:
-			v.hasGCFence = true
-			data, v.gcFence, err = codec.DecodeUint(data[1:])
-			if err != nil {
+			if len(data) < 9 {
 				return errors.Annotate(berrors.ErrInvalidArgument, "decode gc fence failed")
 			}
+			v.hasGCFence = true
+			v.gcFence = binary.BigEndian.Uint64(data[1:9])
+			data = data[9:]
 		case flagLastChangePrefix:
-			data, v.lastChangeTs, err = codec.DecodeUint(data[1:])
-			if err != nil {
+			if len(data) < 9 {
 				return errors.Annotate(berrors.ErrInvalidArgument, "decode last change ts failed")
 			}
-			data, v.versionsToLastChange, err = codec.DecodeUvarint(data)
-			if err != nil {
+			v.lastChangeTs = binary.BigEndian.Uint64(data[1:9])
+			if v.versionsToLastChange, n = binary.Uvarint(data[9:]); n <= 0 {
 				return errors.Annotate(berrors.ErrInvalidArgument, "decode versions to last change failed")
 			}
+			data = data[9+n:]
 		case flagTxnSourcePrefix:
-			data, v.txnSource, err = codec.DecodeUvarint(data[1:])
-			if err != nil {
+			if v.txnSource, n = binary.Uvarint(data[1:]); n <= 0 {
 				return errors.Annotate(berrors.ErrInvalidArgument, "decode txn source failed")
 			}
+			data = data[1+n:]
 		default:
-			break l_for
+			// the flags added by the newer TiKV are ignored.
+			return nil
 		}
 	}
 	return nil
@@ -245,9 +254,13 @@ func (v *RawWriteCFValue) GetWriteType() byte {
 
 // EncodeTo encodes the RawWriteCFValue to get encoded value.
 func (v *RawWriteCFValue) EncodeTo() []byte {
-	data := make([]byte, 0, 9)
+	return v.AppendTo(make([]byte, 0, v.encodedLen()))
+}
+
+// AppendTo appends the encoded value to data.
+func (v *RawWriteCFValue) AppendTo(data []byte) []byte {
 	data = append(data, v.t)
-	data = codec.EncodeUvarint(data, v.startTs)
+	data = binary.AppendUvarint(data, v.startTs)
 
 	if len(v.shortValue) > 0 {
 		data = append(data, flagShortValuePrefix, byte(len(v.shortValue)))
@@ -258,16 +271,41 @@ func (v *RawWriteCFValue) EncodeTo() []byte {
 	}
 	if v.hasGCFence {
 		data = append(data, flagGCFencePrefix)
-		data = codec.EncodeUint(data, v.gcFence)
+		data = binary.BigEndian.AppendUint64(data, v.gcFence)
 	}
 	if v.lastChangeTs > 0 || v.versionsToLastChange > 0 {
 		data = append(data, flagLastChangePrefix)
-		data = codec.EncodeUint(data, v.lastChangeTs)
-		data = codec.EncodeUvarint(data, v.versionsToLastChange)
+		data = binary.BigEndian.AppendUint64(data, v.lastChangeTs)
+		data = binary.AppendUvarint(data, v.versionsToLastChange)
 	}
 	if v.txnSource > 0 {
 		data = append(data, flagTxnSourcePrefix)
-		data = codec.EncodeUvarint(data, v.txnSource)
+		data = binary.AppendUvarint(data, v.txnSource)
 	}
 	return data
 }
+
+// encodedLen returns the length of the encoded value.
+func (v *RawWriteCFValue) encodedLen() int {
+	n := 1 + uvarintLen(v.startTs)
+	if len(v.shortValue) > 0 {
+		n += 2 + len(v.shortValue)
+	}
+	if v.hasOverlappedRollback {
+		n++
+	}
+	if v.hasGCFence {
+		n += 9
+	}
+	if v.lastChangeTs > 0 || v.versionsToLastChange > 0 {
+		n += 9 + uvarintLen(v.versionsToLastChange)
+	}
+	if v.txnSource > 0 {
+		n += 1 + uvarintLen(v.txnSource)
+	}
+	return n
+}
+
+func uvarintLen(x uint64) int {
+	return (bits.Len64(x|1) + 6) / 7
+}
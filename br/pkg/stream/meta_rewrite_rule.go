@@ -0,0 +1,408 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package stream
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/log"
+	berrors "github.com/pingcap/tidb/br/pkg/errors"
+	"github.com/pingcap/tidb/br/pkg/utils"
+	"github.com/pingcap/tidb/pkg/meta"
+	"github.com/pingcap/tidb/pkg/meta/model"
+	"github.com/pingcap/tidb/pkg/parser/ast"
+	"go.uber.org/zap"
+)
+
+// MetaKeyType is the type of the meta kv entries rewritten by SchemasReplace.
+type MetaKeyType int
+
+// The types of the meta kv entries.
+const (
+	// MetaKeyDB is the entry of a database, its value is the DBInfo.
+	MetaKeyDB MetaKeyType = iota
+	// MetaKeyTable is the entry of a table in a database, its value is the TableInfo.
+	MetaKeyTable
+	// MetaKeyAutoIncrementID is the entry of the auto increment ID of a table.
+	MetaKeyAutoIncrementID
+	// MetaKeyAutoTableID is the entry of the auto row ID of a table.
+	MetaKeyAutoTableID
+	// MetaKeySequence is the entry of the value of a sequence.
+	MetaKeySequence
+	// MetaKeyAutoRandomTableID is the entry of the auto random ID of a table.
+	MetaKeyAutoRandomTableID
+
+	metaKeyTypeCount
+)
+
+func (t MetaKeyType) String() string {
+	switch t {
+	case MetaKeyDB:
+		return "DB"
+	case MetaKeyTable:
+		return "Table"
+	case MetaKeyAutoIncrementID:
+		return "AutoIncrementID"
+	case MetaKeyAutoTableID:
+		return "AutoTableID"
+	case MetaKeySequence:
+		return "Sequence"
+	case MetaKeyAutoRandomTableID:
+		return "AutoRandomTableID"
+	default:
+		return fmt.Sprintf("MetaKeyType(%d)", int(t))
+	}
+}
+
+// tableMetaKeyCodecs are the codecs of the fields of the meta keys belonging to a table, indexed by
+// the MetaKeyType.
+var tableMetaKeyCodecs = [metaKeyTypeCount]struct {
+	is     func([]byte) bool
+	parse  func([]byte) (int64, error)
+	encode func(int64) []byte
+}{
+	MetaKeyTable:             {meta.IsTableKey, meta.ParseTableKey, meta.TableKey},
+	MetaKeyAutoIncrementID:   {meta.IsAutoIncrementIDKey, meta.ParseAutoIncrementIDKey, meta.AutoIncrementIDKey},
+	MetaKeyAutoTableID:       {meta.IsAutoTableIDKey, meta.ParseAutoTableIDKey, meta.AutoTableIDKey},
+	MetaKeySequence:          {meta.IsSequenceKey, meta.ParseSequenceKey, meta.SequenceKey},
+	MetaKeyAutoRandomTableID: {meta.IsAutoRandomTableIDKey, meta.ParseAutoRandomTableIDKey, meta.AutoRandomTableIDKey},
+}
+
+// parseMetaKeyType returns the type of the meta key, false is returned if the key isn't rewritten.
+func parseMetaKeyType(rawKey *RawMetaKey) (MetaKeyType, bool) {
+	if meta.IsDBkey(rawKey.Field) {
+		return MetaKeyDB, true
+	}
+	if !meta.IsDBkey(rawKey.Key) {
+		return 0, false
+	}
+	for t := MetaKeyTable; t < metaKeyTypeCount; t++ {
+		if tableMetaKeyCodecs[t].is(rawKey.Field) {
+			return t, true
+		}
+	}
+	return 0, false
+}
+
+// MetaKVEntry is a meta kv entry decoded for the rewrite rules, the rules rewrite it in place.
+type MetaKVEntry struct {
+	KeyType MetaKeyType
+	// CF is the column family of the entry.
+	CF string
+	// Key is the decoded key.
+	Key *RawMetaKey
+	// DBInfo is the value of the MetaKeyDB entries. It's nil if the entry in write cf has no short value.
+	DBInfo *model.DBInfo
+	// TableInfo is the value of the MetaKeyTable entries. It's nil if the entry in write cf has no
+	// short value.
+	TableInfo *model.TableInfo
+	// Deleted is whether the entry in write cf is a deletion.
+	Deleted bool
+	// DBReplace is the ID mapping of the database of the entry, it's set by the built-in ID remapping
+	// rule, so it's available to the rules registered by RegisterRule.
+	DBReplace *DBReplace
+
+	// value is the raw value if neither DBInfo nor TableInfo is decoded.
+	value []byte
+	// infoJSON is the JSON the DBInfo or TableInfo is decoded from.
+	infoJSON      []byte
+	writeCFValue  *RawWriteCFValue
+	unknownFields *utils.UnknownJSONFields
+	tracer        *rewriteTracer
+}
+
+// decodeMetaKVEntry decodes the value in default cf, or the short value in write cf. The entry and the
+// write cf value are decoded into the scratch.
+func decodeMetaKVEntry(
+	s *rewriteScratch,
+	keyType MetaKeyType,
+	rawKey *RawMetaKey,
+	value []byte,
+	cf string,
+) (*MetaKVEntry, error) {
+	e := &s.entry
+	*e = MetaKVEntry{KeyType: keyType, CF: cf, Key: rawKey, value: value}
+	if keyType != MetaKeyDB && keyType != MetaKeyTable {
+		return e, nil
+	}
+	switch cf {
+	case DefaultCF:
+	case WriteCF:
+		rawWriteCFValue := &s.writeCFValue
+		*rawWriteCFValue = RawWriteCFValue{}
+		if err := rawWriteCFValue.ParseFrom(value); err != nil {
+			return nil, errors.Trace(err)
+		}
+		e.Deleted = rawWriteCFValue.IsDelete()
+		if rawWriteCFValue.IsDelete() || rawWriteCFValue.IsRollback() || !rawWriteCFValue.HasShortValue() {
+			return e, nil
+		}
+		e.writeCFValue = rawWriteCFValue
+		value = rawWriteCFValue.GetShortValue()
+	default:
+		panic(fmt.Sprintf("not support cf:%s", cf))
+	}
+
+	var err error
+	e.infoJSON = value
+	if keyType == MetaKeyDB {
+		e.DBInfo = new(model.DBInfo)
+		err = utils.MetaJSON().Unmarshal(value, e.DBInfo)
+	} else {
+		e.TableInfo = new(model.TableInfo)
+		// the backup may be taken by a newer TiDB, keep the fields unknown to this version.
+		e.unknownFields, err = utils.UnmarshalWithUnknownFields(value, e.TableInfo)
+	}
+	if err != nil {
+		if e.writeCFValue != nil {
+			log.Info("failed to rewrite short value",
+				zap.ByteString("write-type", []byte{e.writeCFValue.GetWriteType()}),
+				zap.Int("short-value-len", len(value)))
+		}
+		return nil, errors.Trace(err)
+	}
+	return e, nil
+}
+
+// encodeInfo encodes the DBInfo or TableInfo of the entry, nil is returned if neither is decoded.
+func (e *MetaKVEntry) encodeInfo() ([]byte, error) {
+	switch {
+	case e.DBInfo != nil:
+		value, err := utils.MetaJSON().Marshal(e.DBInfo)
+		return value, errors.Trace(err)
+	case e.TableInfo != nil:
+		value, err := utils.MarshalWithUnknownFields(e.TableInfo, e.unknownFields)
+		return value, errors.Trace(err)
+	}
+	return nil, nil
+}
+
+// checkRoundTrip returns an error if the info JSON changes after a round trip, i.e. decoded and encoded
+// again. It's checked before the rules are applied, so all the fields are compared.
+func (e *MetaKVEntry) checkRoundTrip() error {
+	if e.DBInfo == nil && e.TableInfo == nil {
+		return nil
+	}
+	roundTrip, err := e.encodeInfo()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(checkLossyJSON(e.KeyType, e.Key, e.infoJSON, roundTrip))
+}
+
+// checkRewrittenValue returns an error if the value encoded from the rewritten entry changes after decoded
+// and encoded again, which means the written entry isn't read as rewritten. The whole value is compared,
+// including the fields not rewritten and the write cf value around the info.
+func (e *MetaKVEntry) checkRewrittenValue(value []byte) error {
+	var s rewriteScratch
+	again, err := decodeMetaKVEntry(&s, e.KeyType, e.Key, value, e.CF)
+	if err != nil {
+		return errors.Annotatef(berrors.ErrRestoreLossyMeta, "the rewritten %s entry of key %q field %q can't be "+
+			"decoded: %v", e.KeyType, e.Key.Key, e.Key.Field, err)
+	}
+	againValue, err := again.reencodeValue()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if bytes.Equal(value, againValue) {
+		return nil
+	}
+	paths := make([]string, 0)
+	if again.infoJSON != nil {
+		againInfo, err := again.encodeInfo()
+		if err != nil {
+			return errors.Trace(err)
+		}
+		// the fields changed, lost or added are all reported.
+		for _, pair := range [][2][]byte{{again.infoJSON, againInfo}, {againInfo, again.infoJSON}} {
+			diff, err := utils.LossyJSONPaths(pair[0], pair[1])
+			if err != nil {
+				return errors.Trace(err)
+			}
+			for _, path := range diff {
+				if !slices.Contains(paths, path) {
+					paths = append(paths, path)
+				}
+			}
+		}
+		slices.Sort(paths)
+	}
+	return errors.Annotatef(berrors.ErrRestoreLossyMeta, "the rewritten %s entry of key %q field %q isn't read "+
+		"back as written, the fields %v differ", e.KeyType, e.Key.Key, e.Key.Field, paths)
+}
+
+func checkLossyJSON(keyType MetaKeyType, key *RawMetaKey, orig, roundTrip []byte) error {
+	paths, err := utils.LossyJSONPaths(orig, roundTrip)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if len(paths) == 0 {
+		return nil
+	}
+	return errors.Annotatef(berrors.ErrRestoreLossyMeta, "the %s entry of key %q field %q loses the fields %v "+
+		"after a round trip", keyType, key.Key, key.Field, paths)
+}
+
+// encodeValue encodes the value of the entry after rewritten.
+func (e *MetaKVEntry) encodeValue() ([]byte, error) {
+	if e.DBInfo == nil && e.TableInfo == nil {
+		return e.value, nil
+	}
+	if paths := e.unknownFields.Paths(); e.TableInfo != nil && len(paths) > 0 {
+		log.Warn("the table info has fields unknown to this version, they are kept as is but may not take effect",
+			zap.Stringer("table", e.TableInfo.Name), zap.Int64("table-id", e.TableInfo.ID), zap.Strings("fields", paths))
+	}
+	return e.reencodeValue()
+}
+
+// reencodeValue encodes the value of the entry from the decoded info.
+func (e *MetaKVEntry) reencodeValue() ([]byte, error) {
+	if e.DBInfo == nil && e.TableInfo == nil {
+		return e.value, nil
+	}
+	value, err := e.encodeInfo()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if e.writeCFValue == nil {
+		return value, nil
+	}
+	e.writeCFValue.UpdateShortValue(value)
+	return e.writeCFValue.EncodeTo(), nil
+}
+
+// MetaRewriteRule is a step of rewriting the meta kv entries. It rewrites the entry in place, and
+// returns false if the entry should be skipped. The entry and its key are reused after the entry is
+// rewritten, so the rule mustn't retain them.
+type MetaRewriteRule func(e *MetaKVEntry) (bool, error)
+
+// RegisterRule appends the rule to the rules of the key type. The rules of a key type are applied
+// in order, after the built-in ones remapping the IDs and disabling the TTL of the tables.
+func (sr *SchemasReplace) RegisterRule(keyType MetaKeyType, rule MetaRewriteRule) {
+	sr.rules[keyType] = append(sr.rules[keyType], rule)
+}
+
+// applyRules applies the rules of the key type to the entry, false is returned if the entry is skipped.
+func (sr *SchemasReplace) applyRules(e *MetaKVEntry) (bool, error) {
+	for i, rule := range sr.rules[e.KeyType] {
+		keep, err := rule(e)
+		if err != nil {
+			e.tracer.trace("the rule fails", zap.Int("rule", i), zap.Error(err))
+			return false, errors.Trace(err)
+		}
+		if !keep {
+			e.tracer.trace("the rule skips the entry", zap.Int("rule", i))
+			return false, nil
+		}
+	}
+	if e.CF == WriteCF {
+		e.Key.UpdateTS(sr.RewriteTS)
+		e.tracer.trace("rewrite the commit ts", zap.Uint64("rewrite-ts", sr.RewriteTS))
+	}
+	return true, nil
+}
+
+// NewRenameRule returns the rule renaming the databases and tables, it should be registered for
+// MetaKeyDB and MetaKeyTable. The keys of names are the upstream names in the form of "db" or
+// "db.table", the values are the new names of the databases or tables, e.g. {"db.t": "t2"} renames
+// the table db.t to db.t2. The names are case-insensitive.
+func NewRenameRule(names map[string]string) MetaRewriteRule {
+	lowerNames := make(map[string]string, len(names))
+	for name, newName := range names {
+		lowerNames[strings.ToLower(name)] = newName
+	}
+	return func(e *MetaKVEntry) (bool, error) {
+		switch {
+		case e.DBInfo != nil:
+			if newName, ok := lowerNames[e.DBInfo.Name.L]; ok {
+				e.DBInfo.Name = ast.NewCIStr(newName)
+			}
+		case e.TableInfo != nil && e.DBReplace != nil:
+			name := strings.ToLower(e.DBReplace.Name) + "." + e.TableInfo.Name.L
+			if newName, ok := lowerNames[name]; ok {
+				e.TableInfo.Name = ast.NewCIStr(newName)
+			}
+		}
+		return true, nil
+	}
+}
+
+// MetaAttribute is an attribute of the databases and tables that can be stripped.
+type MetaAttribute int
+
+// The attributes can be stripped.
+const (
+	// MetaAttributePlacement is the placement policy of the databases, tables and partitions.
+	MetaAttributePlacement MetaAttribute = iota
+	// MetaAttributeTTL is the TTL of the tables.
+	MetaAttributeTTL
+)
+
+// NewStripAttributesRule returns the rule stripping the attributes from the databases and tables,
+// it should be registered for MetaKeyDB and MetaKeyTable.
+func NewStripAttributesRule(attrs ...MetaAttribute) MetaRewriteRule {
+	return func(e *MetaKVEntry) (bool, error) {
+		for _, attr := range attrs {
+			switch attr {
+			case MetaAttributePlacement:
+				stripPlacement(e)
+			case MetaAttributeTTL:
+				if e.TableInfo != nil {
+					e.TableInfo.TTLInfo = nil
+				}
+			default:
+				return false, errors.Errorf("unknown meta attribute %d", attr)
+			}
+		}
+		return true, nil
+	}
+}
+
+// NewConvertCharsetRule returns the rule converting the charset of the databases and tables, it should
+// be registered for MetaKeyDB and MetaKeyTable.
+func NewConvertCharsetRule(c *utils.CharsetConversion) MetaRewriteRule {
+	return func(e *MetaKVEntry) (bool, error) {
+		if e.DBInfo != nil {
+			return true, errors.Trace(c.ConvertDB(e.DBInfo))
+		}
+		if e.TableInfo != nil {
+			_, err := c.ConvertTable(e.TableInfo)
+			return true, errors.Trace(err)
+		}
+		return true, nil
+	}
+}
+
+// NewPlacementTemplateRule returns the rule replacing the placement policies of the databases and tables
+// by the template, it should be registered for MetaKeyDB and MetaKeyTable. The IDs of the downstream
+// policies must be resolved, see PlacementTemplate.ResolvePolicyIDs.
+func NewPlacementTemplateRule(t *utils.PlacementTemplate) MetaRewriteRule {
+	return func(e *MetaKVEntry) (bool, error) {
+		if e.DBInfo != nil {
+			t.ApplyDB(e.DBInfo)
+		}
+		if e.TableInfo != nil {
+			t.ApplyTable(e.TableInfo)
+		}
+		return true, nil
+	}
+}
+
+func stripPlacement(e *MetaKVEntry) {
+	if e.DBInfo != nil {
+		e.DBInfo.PlacementPolicyRef = nil
+	}
+	if e.TableInfo == nil {
+		return
+	}
+	e.TableInfo.PlacementPolicyRef = nil
+	if e.TableInfo.Partition != nil {
+		for i := range e.TableInfo.Partition.Definitions {
+			e.TableInfo.Partition.Definitions[i].PlacementPolicyRef = nil
+		}
+	}
+}
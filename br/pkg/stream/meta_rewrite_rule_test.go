@@ -0,0 +1,225 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package stream
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/tidb/br/pkg/utils"
+	"github.com/pingcap/tidb/pkg/kv"
+	"github.com/pingcap/tidb/pkg/meta"
+	"github.com/pingcap/tidb/pkg/meta/model"
+	"github.com/pingcap/tidb/pkg/parser/ast"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMetaKeyType(t *testing.T) {
+	cases := []struct {
+		key     *RawMetaKey
+		keyType MetaKeyType
+		ok      bool
+	}{
+		{&RawMetaKey{Key: []byte("DBs"), Field: meta.DBkey(1)}, MetaKeyDB, true},
+		{&RawMetaKey{Key: meta.DBkey(1), Field: meta.TableKey(2)}, MetaKeyTable, true},
+		{&RawMetaKey{Key: meta.DBkey(1), Field: meta.AutoIncrementIDKey(2)}, MetaKeyAutoIncrementID, true},
+		{&RawMetaKey{Key: meta.DBkey(1), Field: meta.AutoTableIDKey(2)}, MetaKeyAutoTableID, true},
+		{&RawMetaKey{Key: meta.DBkey(1), Field: meta.SequenceKey(2)}, MetaKeySequence, true},
+		{&RawMetaKey{Key: meta.DBkey(1), Field: meta.AutoRandomTableIDKey(2)}, MetaKeyAutoRandomTableID, true},
+		{&RawMetaKey{Key: []byte("NextGlobalID")}, 0, false},
+	}
+	for _, c := range cases {
+		keyType, ok := parseMetaKeyType(c.key)
+		require.Equal(t, c.ok, ok)
+		require.Equal(t, c.keyType, keyType)
+	}
+}
+
+func TestRegisterRule(t *testing.T) {
+	const (
+		dbID    int64 = 1
+		tableID int64 = 2
+	)
+	dbMap := map[UpstreamID]*DBReplace{dbID: NewDBReplace("db", dbID+100)}
+	dbMap[dbID].TableMap[tableID] = NewTableReplace("t", tableID+100)
+	dbMap[dbID].TableMap[tableID+1] = NewTableReplace("t2", tableID+101)
+	sr := MockEmptySchemasReplace(nil, dbMap)
+
+	sr.RegisterRule(MetaKeyDB, NewStripAttributesRule(MetaAttributePlacement))
+	sr.RegisterRule(MetaKeyTable, NewStripAttributesRule(MetaAttributePlacement, MetaAttributeTTL))
+	sr.RegisterRule(MetaKeyDB, NewRenameRule(map[string]string{"DB": "db2"}))
+	sr.RegisterRule(MetaKeyTable, NewRenameRule(map[string]string{"db.T": "t3"}))
+	// the custom rule sees the downstream IDs and skips the table t2.
+	sr.RegisterRule(MetaKeyTable, func(e *MetaKVEntry) (bool, error) {
+		if e.TableInfo == nil {
+			return true, nil
+		}
+		if e.TableInfo.ID == tableID+101 {
+			return false, nil
+		}
+		e.TableInfo.Charset = "utf8mb4"
+		return true, nil
+	})
+
+	policy := &model.PolicyRefInfo{ID: 1, Name: ast.NewCIStr("p")}
+	value, err := json.Marshal(&model.DBInfo{ID: dbID, Name: ast.NewCIStr("db"), PlacementPolicyRef: policy})
+	require.NoError(t, err)
+	e, err := sr.RewriteKvEntry(&kv.Entry{Key: encodeTxnMetaKey([]byte("DBs"), meta.DBkey(dbID), 1), Value: value}, DefaultCF)
+	require.NoError(t, err)
+	var dbInfo model.DBInfo
+	require.NoError(t, json.Unmarshal(e.Value, &dbInfo))
+	require.Equal(t, dbID+100, dbInfo.ID)
+	require.Equal(t, "db2", dbInfo.Name.O)
+	require.Nil(t, dbInfo.PlacementPolicyRef)
+
+	value, err = json.Marshal(&model.TableInfo{
+		ID:                 tableID,
+		Name:               ast.NewCIStr("t"),
+		Charset:            "latin1",
+		PlacementPolicyRef: policy,
+		TTLInfo:            &model.TTLInfo{Enable: true},
+	})
+	require.NoError(t, err)
+	e, err = sr.RewriteKvEntry(&kv.Entry{Key: encodeTxnMetaKey(meta.DBkey(dbID), meta.TableKey(tableID), 1), Value: value}, DefaultCF)
+	require.NoError(t, err)
+	var tableInfo model.TableInfo
+	require.NoError(t, json.Unmarshal(e.Value, &tableInfo))
+	require.Equal(t, tableID+100, tableInfo.ID)
+	require.Equal(t, "t3", tableInfo.Name.O)
+	require.Equal(t, "utf8mb4", tableInfo.Charset)
+	require.Nil(t, tableInfo.PlacementPolicyRef)
+	require.Nil(t, tableInfo.TTLInfo)
+
+	value, err = json.Marshal(&model.TableInfo{ID: tableID + 1, Name: ast.NewCIStr("t2")})
+	require.NoError(t, err)
+	e, err = sr.RewriteKvEntry(&kv.Entry{Key: encodeTxnMetaKey(meta.DBkey(dbID), meta.TableKey(tableID+1), 1), Value: value}, DefaultCF)
+	require.NoError(t, err)
+	require.Nil(t, e)
+
+	// the errors of the rules are returned.
+	sr.RegisterRule(MetaKeyAutoIncrementID, func(*MetaKVEntry) (bool, error) {
+		return false, errors.New("rule failed")
+	})
+	_, err = sr.RewriteKvEntry(&kv.Entry{Key: encodeTxnMetaKey(meta.DBkey(dbID), meta.AutoIncrementIDKey(tableID), 1), Value: []byte("1")}, DefaultCF)
+	require.ErrorContains(t, err, "rule failed")
+}
+
+func TestConvertCharsetRule(t *testing.T) {
+	const (
+		dbID    int64 = 1
+		tableID int64 = 2
+	)
+	dbMap := map[UpstreamID]*DBReplace{dbID: NewDBReplace("db", dbID+100)}
+	dbMap[dbID].TableMap[tableID] = NewTableReplace("t", tableID+100)
+	sr := MockEmptySchemasReplace(nil, dbMap)
+	conversion, err := utils.ParseCharsetConversion("utf8:utf8mb4")
+	require.NoError(t, err)
+	sr.RegisterRule(MetaKeyDB, NewConvertCharsetRule(conversion))
+	sr.RegisterRule(MetaKeyTable, NewConvertCharsetRule(conversion))
+
+	value, err := json.Marshal(&model.DBInfo{ID: dbID, Name: ast.NewCIStr("db"), Charset: "utf8", Collate: "utf8_bin"})
+	require.NoError(t, err)
+	e, err := sr.RewriteKvEntry(&kv.Entry{Key: encodeTxnMetaKey([]byte("DBs"), meta.DBkey(dbID), 1), Value: value}, DefaultCF)
+	require.NoError(t, err)
+	var dbInfo model.DBInfo
+	require.NoError(t, json.Unmarshal(e.Value, &dbInfo))
+	require.Equal(t, "utf8mb4", dbInfo.Charset)
+	require.Equal(t, "utf8mb4_bin", dbInfo.Collate)
+
+	value, err = json.Marshal(&model.TableInfo{ID: tableID, Name: ast.NewCIStr("t"), Charset: "utf8",
+		Collate: "utf8_general_ci"})
+	require.NoError(t, err)
+	e, err = sr.RewriteKvEntry(&kv.Entry{Key: encodeTxnMetaKey(meta.DBkey(dbID), meta.TableKey(tableID), 1), Value: value}, DefaultCF)
+	require.NoError(t, err)
+	var tableInfo model.TableInfo
+	require.NoError(t, json.Unmarshal(e.Value, &tableInfo))
+	require.Equal(t, "utf8mb4", tableInfo.Charset)
+	require.Equal(t, "utf8mb4_general_ci", tableInfo.Collate)
+
+	value, err = json.Marshal(&model.TableInfo{ID: tableID, Name: ast.NewCIStr("t"), Charset: "utf8",
+		Collate: "utf8_tolower_ci"})
+	require.NoError(t, err)
+	_, err = sr.RewriteKvEntry(&kv.Entry{Key: encodeTxnMetaKey(meta.DBkey(dbID), meta.TableKey(tableID), 1), Value: value}, DefaultCF)
+	require.ErrorContains(t, err, "the index keys need to be re-encoded")
+}
+
+func TestPlacementTemplateRule(t *testing.T) {
+	const (
+		dbID    int64 = 1
+		tableID int64 = 2
+	)
+	dbMap := map[UpstreamID]*DBReplace{dbID: NewDBReplace("db", dbID+100)}
+	dbMap[dbID].TableMap[tableID] = NewTableReplace("t", tableID+100)
+	sr := MockEmptySchemasReplace(nil, dbMap)
+	tmpl, err := utils.ParsePlacementTemplate([]byte("policies:\n  three_dc: single_dc\n  two_region: none"))
+	require.NoError(t, err)
+	require.NoError(t, tmpl.ResolvePolicyIDs(func(ast.CIStr) (int64, bool) { return 10, true }))
+	sr.RegisterRule(MetaKeyDB, NewPlacementTemplateRule(tmpl))
+	sr.RegisterRule(MetaKeyTable, NewPlacementTemplateRule(tmpl))
+
+	value, err := json.Marshal(&model.DBInfo{ID: dbID, Name: ast.NewCIStr("db"),
+		PlacementPolicyRef: &model.PolicyRefInfo{ID: 1, Name: ast.NewCIStr("two_region")}})
+	require.NoError(t, err)
+	e, err := sr.RewriteKvEntry(&kv.Entry{Key: encodeTxnMetaKey([]byte("DBs"), meta.DBkey(dbID), 1), Value: value}, DefaultCF)
+	require.NoError(t, err)
+	var dbInfo model.DBInfo
+	require.NoError(t, json.Unmarshal(e.Value, &dbInfo))
+	require.Nil(t, dbInfo.PlacementPolicyRef)
+
+	value, err = json.Marshal(&model.TableInfo{ID: tableID, Name: ast.NewCIStr("t"),
+		PlacementPolicyRef: &model.PolicyRefInfo{ID: 3, Name: ast.NewCIStr("Three_DC")}})
+	require.NoError(t, err)
+	e, err = sr.RewriteKvEntry(&kv.Entry{Key: encodeTxnMetaKey(meta.DBkey(dbID), meta.TableKey(tableID), 1), Value: value}, DefaultCF)
+	require.NoError(t, err)
+	var tableInfo model.TableInfo
+	require.NoError(t, json.Unmarshal(e.Value, &tableInfo))
+	require.Equal(t, &model.PolicyRefInfo{ID: 10, Name: ast.NewCIStr("single_dc")}, tableInfo.PlacementPolicyRef)
+}
+
+func TestValidateRoundTrip(t *testing.T) {
+	const (
+		dbID    int64 = 1
+		tableID int64 = 2
+	)
+	dbMap := map[UpstreamID]*DBReplace{dbID: NewDBReplace("db", dbID+100)}
+	dbMap[dbID].TableMap[tableID] = NewTableReplace("t", tableID+100)
+	sr := MockEmptySchemasReplace(nil, dbMap)
+	dbKey := encodeTxnMetaKey([]byte("DBs"), meta.DBkey(dbID), 1)
+	tableKey := encodeTxnMetaKey(meta.DBkey(dbID), meta.TableKey(tableID), 1)
+
+	// the DBInfo drops the fields of a newer version.
+	dbValue := []byte(`{"id":1,"db_name":{"O":"db","L":"db"},"state":5,"future_field":{"enable":true}}`)
+	_, err := sr.RewriteKvEntry(&kv.Entry{Key: dbKey, Value: dbValue}, DefaultCF)
+	require.NoError(t, err)
+	sr.ValidateRoundTrip = true
+	_, err = sr.RewriteKvEntry(&kv.Entry{Key: dbKey, Value: dbValue}, DefaultCF)
+	require.ErrorContains(t, err, "loses the fields [future_field]")
+
+	// the TableInfo keeps them, and the rewritten IDs are written.
+	tableValue := []byte(`{"id":2,"name":{"O":"t","L":"t"},"state":5,"future_field":{"enable":true}}`)
+	e, err := sr.RewriteKvEntry(&kv.Entry{Key: tableKey, Value: tableValue}, DefaultCF)
+	require.NoError(t, err)
+	require.Contains(t, string(e.Value), `"future_field":{"enable":true}`)
+	require.Contains(t, string(e.Value), `"id":102`)
+	dbValue, err = json.Marshal(&model.DBInfo{ID: dbID, Name: ast.NewCIStr("db")})
+	require.NoError(t, err)
+	_, err = sr.RewriteKvEntry(&kv.Entry{Key: dbKey, Value: dbValue}, DefaultCF)
+	require.NoError(t, err)
+
+	// the rewritten entry which isn't read back as written is rejected.
+	entry := &MetaKVEntry{KeyType: MetaKeyDB, CF: DefaultCF, Key: &RawMetaKey{Key: []byte("DBs"), Field: meta.DBkey(dbID)},
+		DBInfo: &model.DBInfo{ID: dbID}}
+	require.ErrorContains(t, entry.checkRewrittenValue([]byte(`{"id":1,"db_name":{"O":"db","L":"db"},"extra":[1]}`)), "the fields [extra] differ")
+	// the fields not rewritten are compared too.
+	require.ErrorContains(t, entry.checkRewrittenValue([]byte(`{"id":1}`)), "isn't read back as written")
+	written, err := utils.MetaJSON().Marshal(&model.DBInfo{ID: dbID, Name: ast.NewCIStr("db")})
+	require.NoError(t, err)
+	require.NoError(t, entry.checkRewrittenValue(written))
+
+	// the write cf value around the info is compared as a whole.
+	entry.CF = WriteCF
+	writeCFValue := &RawWriteCFValue{t: WriteTypePut, startTs: 1}
+	writeCFValue.UpdateShortValue(written)
+	require.NoError(t, entry.checkRewrittenValue(writeCFValue.EncodeTo()))
+}
@@ -0,0 +1,35 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package stream
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// The results of rewriting the meta kv entries.
+const (
+	metaKVRewritten = "rewritten"
+	metaKVSkipped   = "skipped"
+	metaKVFailed    = "failed"
+)
+
+var metaKVRewriteCounter = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: "br",
+		Subsystem: "stream",
+		Name:      "meta_kv_rewrite_total",
+		Help:      "The count of the meta kv entries rewritten by the log restore.",
+	}, []string{"type", "result"})
+
+var delRangeDedupCounter = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: "br",
+		Subsystem: "stream",
+		Name:      "delete_range_dedup_total",
+		Help:      "The count of the delete ranges skipped by the log restore since they're recorded before.",
+	}, []string{"type"})
+
+func init() { // nolint:gochecknoinits
+	prometheus.MustRegister(metaKVRewriteCounter)
+	prometheus.MustRegister(delRangeDedupCounter)
+}
@@ -0,0 +1,121 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package stream
+
+import (
+	"github.com/pingcap/errors"
+	berrors "github.com/pingcap/tidb/br/pkg/errors"
+	restoreutils "github.com/pingcap/tidb/br/pkg/restore/utils"
+	"github.com/pingcap/tidb/br/pkg/utils"
+	"github.com/pingcap/tidb/pkg/meta/model"
+	filter "github.com/pingcap/tidb/pkg/util/table-filter"
+	"go.uber.org/zap"
+)
+
+// MergePartitions restores the partitioned tables matched by the filter as non-partitioned tables, the
+// rows of all the partitions of a table are rewritten into the table, and the partition info is removed
+// from its table info. It returns the names of the merged tables keyed by their downstream IDs.
+//
+// The rows of a partition can't be told from the other rows of the merged table, so the restore fails if
+// the partitions of a merged table are dropped, truncated or exchanged, see checkMergedPartitionJob.
+func (sr *SchemasReplace) MergePartitions(f filter.Filter) map[DownstreamID]string {
+	merged := make(map[DownstreamID]string)
+	for _, dbReplace := range sr.DbMap {
+		if utils.IsSysDB(dbReplace.Name) {
+			continue
+		}
+		for _, tableReplace := range dbReplace.TableMap {
+			if !f.MatchTable(dbReplace.Name, tableReplace.Name) {
+				continue
+			}
+			tableReplace.MergePartitions = true
+			if sr.delRangeRecorder.mergedTables == nil {
+				sr.delRangeRecorder.mergedTables = make(map[DownstreamID]struct{})
+			}
+			sr.delRangeRecorder.mergedTables[tableReplace.TableID] = struct{}{}
+			for partitionID := range tableReplace.PartitionMap {
+				sr.mergePartition(tableReplace, partitionID)
+			}
+			merged[tableReplace.TableID] = dbReplace.Name + "." + tableReplace.Name
+		}
+	}
+	return merged
+}
+
+// mergePartition maps the partition to its table.
+func (sr *SchemasReplace) mergePartition(tableReplace *TableReplace, partitionID UpstreamID) {
+	tableReplace.PartitionMap[partitionID] = tableReplace.TableID
+	sr.delRangeRecorder.globalTableIdMap[partitionID] = tableReplace.TableID
+}
+
+// mergeTableInfoPartitions merges the partitions of the table info into the table, the partitions added
+// after MergePartitions are merged too.
+func (sr *SchemasReplace) mergeTableInfoPartitions(
+	tableInfo *model.TableInfo,
+	tableReplace *TableReplace,
+	tracer *rewriteTracer,
+) (bool, error) {
+	partitions := tableInfo.GetPartitionInfo()
+	if partitions == nil {
+		return true, nil
+	}
+	if err := restoreutils.CheckPartitionsMergeable(tableInfo); err != nil {
+		return false, errors.Annotatef(err, "failed to merge the partitions of %s", tableReplace.Name)
+	}
+	for _, def := range partitions.Definitions {
+		sr.mergePartition(tableReplace, def.ID)
+		tracer.trace("merge the partition into the table", zap.Int64("partition-id", def.ID),
+			zap.Int64("table-id", tableReplace.TableID))
+	}
+	tableInfo.Partition = nil
+	return true, nil
+}
+
+// isMergedTable checks whether the partitions of the upstream table are merged into the table.
+func (sr *SchemasReplace) isMergedTable(schemaID, tableID UpstreamID) bool {
+	dbReplace, ok := sr.DbMap[schemaID]
+	if !ok {
+		return false
+	}
+	tableReplace, ok := dbReplace.TableMap[tableID]
+	return ok && tableReplace.MergePartitions
+}
+
+// checkMergedPartitionJob fails the restore if the job removes the rows of some partitions of a merged
+// table, which can't be told from the rows of the other partitions.
+func (sr *SchemasReplace) checkMergedPartitionJob(job *model.Job) error {
+	if job.IsCancelled() || job.IsRollbackDone() {
+		return nil
+	}
+	schemaID, tableID := job.SchemaID, job.TableID
+	switch job.Type {
+	case model.ActionDropTablePartition, model.ActionTruncateTablePartition:
+	case model.ActionExchangeTablePartition:
+		// the job is of the non-partitioned table, the partitioned table is in the args.
+		args, err := model.GetExchangeTablePartitionArgs(job)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		schemaID, tableID = args.PTSchemaID, args.PTTableID
+	default:
+		return nil
+	}
+	if !sr.isMergedTable(schemaID, tableID) {
+		return nil
+	}
+	return errors.Annotatef(berrors.ErrRestoreInvalidRewrite,
+		"the partitions of the merged table %d are changed by the ddl job %d (%s), whose rows can't be told "+
+			"from the other partitions, please don't merge the partitions of the table", tableID, job.ID, job.Type)
+}
+
+// movesPartitionRows returns whether the job moves the rows of the partitions to the new partitions, the
+// moved rows are under the same keys in a merged table, so the ranges of the old partitions must not be
+// deleted from it.
+func movesPartitionRows(job *model.Job) bool {
+	switch job.Type {
+	case model.ActionReorganizePartition, model.ActionRemovePartitioning, model.ActionAlterTablePartitioning:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,110 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package stream
+
+import (
+	"encoding/json"
+	"testing"
+
+	berrors "github.com/pingcap/tidb/br/pkg/errors"
+	"github.com/pingcap/tidb/pkg/meta/model"
+	"github.com/pingcap/tidb/pkg/parser/ast"
+	filter "github.com/pingcap/tidb/pkg/util/table-filter"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergePartitions(t *testing.T) {
+	newSchemasReplace := func() (*SchemasReplace, *mockInsertDeleteRange) {
+		table0 := NewTableReplace("t0", mDDLJobTable0NewID)
+		table0.PartitionMap[mDDLJobPartition0OldID] = mDDLJobPartition0NewID
+		table0.PartitionMap[mDDLJobPartition1OldID] = mDDLJobPartition1NewID
+		table0.PartitionMap[mDDLJobPartition2OldID] = mDDLJobPartition2NewID
+		db := NewDBReplace("db", mDDLJobDBNewID)
+		db.TableMap[mDDLJobTable0OldID] = table0
+		db.TableMap[mDDLJobTable1OldID] = NewTableReplace("t1", mDDLJobTable1NewID)
+		midr := newMockInsertDeleteRange()
+		return MockEmptySchemasReplace(midr, map[UpstreamID]*DBReplace{mDDLJobDBOldID: db}), midr
+	}
+
+	sr, midr := newSchemasReplace()
+	merged := sr.MergePartitions(filter.NewSchemasFilter("other"))
+	require.Empty(t, merged)
+	merged = sr.MergePartitions(filter.NewTablesFilter(filter.Table{Schema: "db", Name: "t0"}))
+	require.Equal(t, map[DownstreamID]string{mDDLJobTable0NewID: "db.t0"}, merged)
+	tableReplace := sr.DbMap[mDDLJobDBOldID].TableMap[mDDLJobTable0OldID]
+	require.True(t, tableReplace.MergePartitions)
+	for _, newID := range tableReplace.PartitionMap {
+		require.Equal(t, mDDLJobTable0NewID, newID)
+	}
+
+	// all the partitions are rewritten into the table.
+	rules, err := sr.DataRewriteRules()
+	require.NoError(t, err)
+	require.Len(t, rules, 5)
+	for _, oldID := range []int64{mDDLJobTable0OldID, mDDLJobPartition0OldID, mDDLJobPartition1OldID, mDDLJobPartition2OldID} {
+		require.Equal(t, mDDLJobTable0NewID, rules[oldID].NewTableID)
+	}
+
+	// the partition info is removed, the partition added later is merged too.
+	tbl := &model.TableInfo{
+		ID:   mDDLJobTable0OldID,
+		Name: ast.NewCIStr("t0"),
+		Partition: &model.PartitionInfo{Enable: true, Definitions: []model.PartitionDefinition{
+			{ID: mDDLJobPartition0OldID, Name: ast.NewCIStr("p0")},
+			{ID: 76, Name: ast.NewCIStr("p3")},
+		}},
+	}
+	// the row IDs of the partitions may be the same.
+	value, err := json.Marshal(tbl)
+	require.NoError(t, err)
+	_, err = sr.rewriteTableInfo(value, mDDLJobDBOldID)
+	require.ErrorIs(t, err, berrors.ErrRestoreInvalidRewrite)
+	require.ErrorContains(t, err, "no clustered primary key")
+	tbl.PKIsHandle = true
+	value, err = json.Marshal(tbl)
+	require.NoError(t, err)
+	newValue, err := sr.rewriteTableInfo(value, mDDLJobDBOldID)
+	require.NoError(t, err)
+	tableInfo := &model.TableInfo{}
+	require.NoError(t, json.Unmarshal(newValue, tableInfo))
+	require.Equal(t, mDDLJobTable0NewID, tableInfo.ID)
+	require.Nil(t, tableInfo.Partition)
+	require.Equal(t, mDDLJobTable0NewID, tableReplace.PartitionMap[76])
+
+	// the partitions can't be dropped or exchanged from the merged table.
+	require.ErrorIs(t, sr.restoreFromHistory(dropTable0Partition1Job), berrors.ErrRestoreInvalidRewrite)
+	exchangeJob := &model.Job{ID: 118, Version: model.GetJobVerInUse(),
+		Type: model.ActionExchangeTablePartition, SchemaID: mDDLJobDBOldID, TableID: mDDLJobTable1OldID}
+	exchangeJob.FillArgs(&model.ExchangeTablePartitionArgs{PartitionID: mDDLJobPartition1OldID,
+		PTSchemaID: mDDLJobDBOldID, PTTableID: mDDLJobTable0OldID})
+	jobBytes, err := exchangeJob.Encode(true)
+	require.NoError(t, err)
+	exchangeTable0Partition1Job := &model.Job{}
+	require.NoError(t, exchangeTable0Partition1Job.Decode(jobBytes))
+	require.ErrorIs(t, sr.restoreFromHistory(exchangeTable0Partition1Job), berrors.ErrRestoreInvalidRewrite)
+	require.Empty(t, midr.queryCh)
+	// the delete ranges of the moved rows are skipped, the table-level ones delete the table.
+	require.NoError(t, sr.restoreFromHistory(reorganizeTable0Partition1Job))
+	query := <-midr.queryCh
+	require.Empty(t, query.ParamsList)
+	require.NoError(t, sr.restoreFromHistory(dropTable0Job))
+	for len(midr.queryCh) > 0 {
+		query = <-midr.queryCh
+		for _, params := range query.ParamsList {
+			require.Equal(t, encodeTableKey(mDDLJobTable0NewID), params.StartKey)
+		}
+	}
+
+	// the global index can't be merged.
+	tbl.Indices = []*model.IndexInfo{{ID: 1, Name: ast.NewCIStr("g"), Global: true}}
+	value, err = json.Marshal(tbl)
+	require.NoError(t, err)
+	_, err = sr.rewriteTableInfo(value, mDDLJobDBOldID)
+	require.ErrorIs(t, err, berrors.ErrRestoreInvalidRewrite)
+
+	// without merged, the partitions are kept.
+	sr, _ = newSchemasReplace()
+	rules, err = sr.DataRewriteRules()
+	require.NoError(t, err)
+	require.Equal(t, mDDLJobPartition1NewID, rules[mDDLJobPartition1OldID].NewTableID)
+}
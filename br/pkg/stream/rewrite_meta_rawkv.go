@@ -16,8 +16,8 @@ package stream
 
 import (
 	"context"
-	"encoding/json"
-	"fmt"
+	"strings"
+	"sync/atomic"
 
 	"github.com/pingcap/errors"
 	"github.com/pingcap/log"
@@ -47,6 +47,9 @@ type TableReplace struct {
 	TableID      DownstreamID
 	PartitionMap map[UpstreamID]DownstreamID
 	IndexMap     map[UpstreamID]DownstreamID
+	// MergePartitions restores the table as a non-partitioned table, all of its partitions are mapped to
+	// the TableID, see SchemasReplace.MergePartitions.
+	MergePartitions bool
 }
 
 // DBReplace specifies database information mapping from up-stream cluster to up-stream cluster.
@@ -67,6 +70,64 @@ type SchemasReplace struct {
 	TableFilter      filter.Filter // used to filter schema/table
 
 	AfterTableRewritten func(deleted bool, tableInfo *model.TableInfo)
+	// PreserveClusterMeta keeps the preservable cluster-scoped meta entries, see ClusterMetaFamilies.
+	PreserveClusterMeta bool
+	// ValidateRoundTrip is the safe mode checking the DBInfo and TableInfo entries are decoded and encoded
+	// without loss before rewritten, and the whole values written are read back as written. The lossy
+	// entries fail the rewrite instead of being written, e.g. the fields of a newer version dropped or the
+	// numbers losing precision.
+	ValidateRoundTrip bool
+	// DebugKeyPrefix traces the rewrite of the kv entries whose keys in the log backup have the prefix, every
+	// decision is logged at INFO level. Nothing is traced if it's empty.
+	DebugKeyPrefix []byte
+	// MissingPartitionPolicy is how the partitions of a table missing in its PartitionMap are handled,
+	// MissingPartitionError by default.
+	MissingPartitionPolicy MissingPartitionPolicy
+	// GenGlobalID allocates the downstream ID of a missing partition under MissingPartitionAllocateNewID.
+	GenGlobalID func(ctx context.Context) (int64, error)
+	// PersistIDMap saves the ID maps once the ID of a missing partition is allocated, before the table info
+	// referring to it is restored, so the retried restore rewrites the partition to the same ID.
+	PersistIDMap func(ctx context.Context) error
+	// RollbackRecordPolicy is how the rollback and lock records of the write CF are handled,
+	// RollbackRecordApply by default.
+	RollbackRecordPolicy RollbackRecordPolicy
+	// DelRangeConflictPolicy is how the delete ranges of the same job and element but different ranges
+	// are handled, DelRangeConflictKeepFirst by default.
+	DelRangeConflictPolicy DelRangeConflictPolicy
+	// RollbackRecordCollector receives the rollback and lock records under RollbackRecordCollect.
+	RollbackRecordCollector *RollbackRecordCollector
+	skippedRollbackRecords  atomic.Uint64
+
+	// rules are the rules to rewrite the meta kv entries, indexed by MetaKeyType.
+	rules [metaKeyTypeCount][]MetaRewriteRule
+}
+
+// MissingPartitionPolicy is how the partition of a table missing in the PartitionMap of the table is
+// handled when rewriting the table info, e.g. the partition created by a DDL the ID maps don't track.
+type MissingPartitionPolicy string
+
+const (
+	// MissingPartitionError fails the restore.
+	MissingPartitionError MissingPartitionPolicy = "error"
+	// MissingPartitionSkip drops the partition definition from the table info, the data of the partition
+	// isn't restored either.
+	MissingPartitionSkip MissingPartitionPolicy = "skip-partition"
+	// MissingPartitionAllocateNewID allocates a new downstream ID for the partition and adds it to the
+	// PartitionMap, so the data of the partition is rewritten to it.
+	MissingPartitionAllocateNewID MissingPartitionPolicy = "allocate-new-id"
+)
+
+// ParseMissingPartitionPolicy parses the MissingPartitionPolicy, the empty string is MissingPartitionError.
+func ParseMissingPartitionPolicy(s string) (MissingPartitionPolicy, error) {
+	switch policy := MissingPartitionPolicy(strings.ToLower(s)); policy {
+	case "":
+		return MissingPartitionError, nil
+	case MissingPartitionError, MissingPartitionSkip, MissingPartitionAllocateNewID:
+		return policy, nil
+	default:
+		return "", errors.Annotatef(berrors.ErrInvalidArgument, "unknown missing partition policy %q, "+
+			"should be one of %q, %q and %q", s, MissingPartitionError, MissingPartitionSkip, MissingPartitionAllocateNewID)
+	}
 }
 
 // NewTableReplace creates a TableReplace struct.
@@ -106,7 +167,7 @@ func NewSchemasReplace(
 		}
 	}
 
-	return &SchemasReplace{
+	sr := &SchemasReplace{
 		DbMap:            dbMap,
 		delRangeRecorder: newDelRangeExecWrapper(globalTableIdMap, recordDeleteRange),
 		ingestRecorder:   ingestrec.New(),
@@ -114,328 +175,173 @@ func NewSchemasReplace(
 		RewriteTS:        restoreTS,
 		TableFilter:      tableFilter,
 	}
-}
-
-func (sr *SchemasReplace) rewriteKeyForDB(key []byte, cf string) ([]byte, error) {
-	rawMetaKey, err := ParseTxnMetaKeyFrom(key)
-	if err != nil {
-		return nil, errors.Trace(err)
-	}
-
-	dbID, err := meta.ParseDBKey(rawMetaKey.Field)
-	if err != nil {
-		return nil, errors.Trace(err)
-	}
-
-	dbMap, exist := sr.DbMap[dbID]
-	if !exist {
-		// db filtered out
-		return nil, nil
-	}
-
-	rawMetaKey.UpdateField(meta.DBkey(dbMap.DbID))
-	if cf == WriteCF {
-		rawMetaKey.UpdateTS(sr.RewriteTS)
-	}
-	return rawMetaKey.EncodeMetaKey(), nil
-}
-
-func (sr *SchemasReplace) rewriteDBInfo(value []byte) ([]byte, error) {
-	dbInfo := new(model.DBInfo)
-	if err := json.Unmarshal(value, dbInfo); err != nil {
-		return nil, errors.Trace(err)
-	}
-
-	dbMap, exist := sr.DbMap[dbInfo.ID]
-	if !exist {
-		// db filtered out
-		return nil, nil
-	}
-
-	dbInfo.ID = dbMap.DbID
-	newValue, err := json.Marshal(dbInfo)
-	if err != nil {
-		return nil, err
-	}
-	return newValue, nil
-}
-
-func (sr *SchemasReplace) rewriteEntryForDB(e *kv.Entry, cf string) (*kv.Entry, error) {
-	r, err := sr.rewriteValue(
-		e.Value,
-		cf,
-		func(value []byte) ([]byte, error) {
-			return sr.rewriteDBInfo(value)
-		},
-	)
-	if err != nil {
-		return nil, errors.Trace(err)
-	}
-
-	newValue := r.NewValue
-	newKey, err := sr.rewriteKeyForDB(e.Key, cf)
-	if err != nil {
-		return nil, errors.Trace(err)
+	for keyType := MetaKeyDB; keyType < metaKeyTypeCount; keyType++ {
+		sr.RegisterRule(keyType, sr.remapIDs)
 	}
-
-	return &kv.Entry{Key: newKey, Value: newValue}, nil
+	sr.RegisterRule(MetaKeyTable, disableTTL)
+	return sr
 }
 
-func (sr *SchemasReplace) rewriteKeyForTable(
-	key []byte,
-	cf string,
-	parseField func([]byte) (tableID int64, err error),
-	encodeField func(tableID int64) []byte,
-) ([]byte, error) {
-	var (
-		err   error
-		exist bool
-	)
-	rawMetaKey, err := ParseTxnMetaKeyFrom(key)
-	if err != nil {
-		return nil, errors.Trace(err)
+// remapIDs is the built-in rule rewriting the upstream IDs in the entry to the downstream ones, the
+// entries of the databases and tables filtered out are skipped.
+func (sr *SchemasReplace) remapIDs(e *MetaKVEntry) (bool, error) {
+	if e.KeyType == MetaKeyDB {
+		dbID, err := meta.ParseDBKey(e.Key.Field)
+		if err != nil {
+			return false, errors.Trace(err)
+		}
+		dbReplace, exist := sr.DbMap[dbID]
+		if !exist {
+			// db filtered out
+			e.tracer.trace("skip the database missing in the id maps", zap.Int64("db-id", dbID))
+			return false, nil
+		}
+		e.tracer.trace("remap the database", zap.Int64("db-id", dbID), zap.Int64("new-db-id", dbReplace.DbID))
+		e.DBReplace = dbReplace
+		e.Key.UpdateField(meta.DBkey(dbReplace.DbID))
+		if e.DBInfo != nil {
+			e.DBInfo.ID = dbReplace.DbID
+		}
+		return true, nil
 	}
 
-	dbID, err := meta.ParseDBKey(rawMetaKey.Key)
+	codec := tableMetaKeyCodecs[e.KeyType]
+	dbID, err := meta.ParseDBKey(e.Key.Key)
 	if err != nil {
-		return nil, errors.Trace(err)
+		return false, errors.Trace(err)
 	}
-	tableID, err := parseField(rawMetaKey.Field)
+	tableID, err := codec.parse(e.Key.Field)
 	if err != nil {
-		log.Warn("parse table key failed", zap.ByteString("field", rawMetaKey.Field))
-		return nil, errors.Trace(err)
+		log.Warn("parse table key failed", zap.ByteString("field", e.Key.Field))
+		return false, errors.Trace(err)
 	}
-
 	dbReplace, exist := sr.DbMap[dbID]
 	if !exist {
 		// db filtered out
-		return nil, nil
+		e.tracer.trace("skip the table of the database missing in the id maps", zap.Int64("db-id", dbID),
+			zap.Int64("table-id", tableID))
+		return false, nil
 	}
-
 	tableReplace, exist := dbReplace.TableMap[tableID]
 	if !exist {
 		// table filtered out
-		return nil, nil
-	}
-
-	rawMetaKey.UpdateKey(meta.DBkey(dbReplace.DbID))
-	rawMetaKey.UpdateField(encodeField(tableReplace.TableID))
-	if cf == WriteCF {
-		rawMetaKey.UpdateTS(sr.RewriteTS)
-	}
-	return rawMetaKey.EncodeMetaKey(), nil
+		e.tracer.trace("skip the table missing in the id maps", zap.Int64("db-id", dbID),
+			zap.Int64("table-id", tableID))
+		return false, nil
+	}
+	e.tracer.trace("remap the table", zap.Int64("db-id", dbID), zap.Int64("new-db-id", dbReplace.DbID),
+		zap.Int64("table-id", tableID), zap.Int64("new-table-id", tableReplace.TableID))
+	e.DBReplace = dbReplace
+	e.Key.UpdateKey(meta.DBkey(dbReplace.DbID))
+	e.Key.UpdateField(codec.encode(tableReplace.TableID))
+	if e.TableInfo != nil {
+		return sr.remapTableInfoIDs(e.TableInfo, dbReplace, e.tracer)
+	}
+	return true, nil
 }
 
-func (sr *SchemasReplace) rewriteTableInfo(value []byte, dbID int64) ([]byte, error) {
-	var (
-		tableInfo    model.TableInfo
-		err          error
-		exist        bool
-		dbReplace    *DBReplace
-		tableReplace *TableReplace
-	)
-	if err := json.Unmarshal(value, &tableInfo); err != nil {
-		return nil, errors.Trace(err)
-	}
-
-	// construct or find the id map.
-	dbReplace, exist = sr.DbMap[dbID]
-	if !exist {
-		// db filtered out
-		return nil, nil
-	}
-
-	tableReplace, exist = dbReplace.TableMap[tableInfo.ID]
+func (sr *SchemasReplace) remapTableInfoIDs(
+	tableInfo *model.TableInfo,
+	dbReplace *DBReplace,
+	tracer *rewriteTracer,
+) (bool, error) {
+	tableReplace, exist := dbReplace.TableMap[tableInfo.ID]
 	if !exist {
 		// table filtered out
-		return nil, nil
+		tracer.trace("skip the table info missing in the id maps", zap.Int64("table-id", tableInfo.ID))
+		return false, nil
 	}
 
 	// update table ID and partition ID.
 	tableInfo.ID = tableReplace.TableID
+	if tableReplace.MergePartitions {
+		return sr.mergeTableInfoPartitions(tableInfo, tableReplace, tracer)
+	}
 	partitions := tableInfo.GetPartitionInfo()
 	if partitions != nil {
-		for i, tbl := range partitions.Definitions {
-			newID, exist := tableReplace.PartitionMap[tbl.ID]
+		definitions := partitions.Definitions[:0]
+		for _, def := range partitions.Definitions {
+			newID, exist := tableReplace.PartitionMap[def.ID]
 			if !exist {
-				log.Error("expect partition info in table replace but got none", zap.Int64("partitionID", tbl.ID))
-				return nil, errors.Annotatef(berrors.ErrInvalidArgument, "failed to find partition id:%v in replace maps", tbl.ID)
+				var err error
+				if newID, exist, err = sr.handleMissingPartition(tableReplace, def.ID); err != nil {
+					tracer.trace("failed to remap the partition missing in the id maps", zap.Int64("partition-id", def.ID),
+						zap.Error(err))
+					return false, errors.Trace(err)
+				}
+				if !exist {
+					tracer.trace("drop the partition missing in the id maps", zap.Int64("partition-id", def.ID))
+					continue
+				}
+				tracer.trace("allocate the id of the partition missing in the id maps", zap.Int64("partition-id", def.ID),
+					zap.Int64("new-partition-id", newID))
+			} else {
+				tracer.trace("remap the partition", zap.Int64("partition-id", def.ID), zap.Int64("new-partition-id", newID))
 			}
-			partitions.Definitions[i].ID = newID
+			def.ID = newID
+			definitions = append(definitions, def)
 		}
+		partitions.Definitions = definitions
 	}
-
-	// Force to disable TTL_ENABLE when restore
-	if tableInfo.TTLInfo != nil {
-		tableInfo.TTLInfo.Enable = false
-	}
-	if sr.AfterTableRewritten != nil {
-		sr.AfterTableRewritten(false, &tableInfo)
-	}
-
-	// marshal to json
-	newValue, err := json.Marshal(&tableInfo)
-	if err != nil {
-		return nil, errors.Trace(err)
-	}
-	return newValue, nil
-}
-
-func (sr *SchemasReplace) rewriteEntryForTable(e *kv.Entry, cf string) (*kv.Entry, error) {
-	dbID, err := ParseDBIDFromTableKey(e.Key)
-	if err != nil {
-		return nil, errors.Trace(err)
-	}
-
-	result, err := sr.rewriteValue(
-		e.Value,
-		cf,
-		func(value []byte) ([]byte, error) {
-			return sr.rewriteTableInfo(value, dbID)
-		},
-	)
-	if err != nil {
-		return nil, errors.Trace(err)
-	}
-
-	var newTableID int64 = 0
-	newKey, err := sr.rewriteKeyForTable(e.Key, cf, meta.ParseTableKey, func(tableID int64) []byte {
-		newTableID = tableID
-		return meta.TableKey(tableID)
-	})
-	if err != nil {
-		return nil, errors.Trace(err)
-	}
-
-	// NOTE: the normal path is in the `SchemaReplace.rewriteTableInfo`
-	//       for now, we rewrite key and value separately hence we cannot
-	//       get a view of (is_delete, table_id, table_info) at the same time :(.
-	//       Maybe we can extract the rewrite part from rewriteTableInfo.
-	if result.Deleted && sr.AfterTableRewritten != nil {
-		sr.AfterTableRewritten(true, &model.TableInfo{ID: newTableID})
-	}
-
-	return &kv.Entry{Key: newKey, Value: result.NewValue}, nil
-}
-
-func (sr *SchemasReplace) rewriteEntryForAutoIncrementIDKey(e *kv.Entry, cf string) (*kv.Entry, error) {
-	newKey, err := sr.rewriteKeyForTable(
-		e.Key,
-		cf,
-		meta.ParseAutoIncrementIDKey,
-		meta.AutoIncrementIDKey,
-	)
-	if err != nil {
-		return nil, errors.Trace(err)
-	}
-
-	return &kv.Entry{Key: newKey, Value: e.Value}, nil
-}
-
-func (sr *SchemasReplace) rewriteEntryForAutoTableIDKey(e *kv.Entry, cf string) (*kv.Entry, error) {
-	newKey, err := sr.rewriteKeyForTable(
-		e.Key,
-		cf,
-		meta.ParseAutoTableIDKey,
-		meta.AutoTableIDKey,
-	)
-	if err != nil {
-		return nil, errors.Trace(err)
-	}
-
-	return &kv.Entry{Key: newKey, Value: e.Value}, nil
-}
-
-func (sr *SchemasReplace) rewriteEntryForSequenceKey(e *kv.Entry, cf string) (*kv.Entry, error) {
-	newKey, err := sr.rewriteKeyForTable(
-		e.Key,
-		cf,
-		meta.ParseSequenceKey,
-		meta.SequenceKey,
-	)
-	if err != nil {
-		return nil, errors.Trace(err)
-	}
-
-	return &kv.Entry{Key: newKey, Value: e.Value}, nil
+	return true, nil
 }
 
-func (sr *SchemasReplace) rewriteEntryForAutoRandomTableIDKey(e *kv.Entry, cf string) (*kv.Entry, error) {
-	newKey, err := sr.rewriteKeyForTable(
-		e.Key,
-		cf,
-		meta.ParseAutoRandomTableIDKey,
-		meta.AutoRandomTableIDKey,
-	)
-	if err != nil {
-		return nil, errors.Trace(err)
-	}
-
-	return &kv.Entry{Key: newKey, Value: e.Value}, nil
-}
-
-type rewriteResult struct {
-	Deleted  bool
-	NewValue []byte
-}
-
-// rewriteValue rewrite the value if cf is "default", or rewrite the shortValue if cf is "write".
-func (sr *SchemasReplace) rewriteValue(value []byte, cf string, rewrite func([]byte) ([]byte, error)) (rewriteResult, error) {
-	switch cf {
-	case DefaultCF:
-		newValue, err := rewrite(value)
-		if err != nil {
-			return rewriteResult{}, errors.Trace(err)
-		}
-		return rewriteResult{
-			NewValue: newValue,
-			Deleted:  false,
-		}, nil
-	case WriteCF:
-		rawWriteCFValue := new(RawWriteCFValue)
-		if err := rawWriteCFValue.ParseFrom(value); err != nil {
-			return rewriteResult{}, errors.Trace(err)
+// handleMissingPartition handles the partition missing in the PartitionMap of the table by the
+// MissingPartitionPolicy, and returns the downstream ID of it, or false if it's dropped.
+func (sr *SchemasReplace) handleMissingPartition(tableReplace *TableReplace, partitionID UpstreamID) (DownstreamID, bool, error) {
+	switch sr.MissingPartitionPolicy {
+	case MissingPartitionSkip:
+		log.Warn("skip the partition missing in table replace", zap.String("table", tableReplace.Name),
+			zap.Int64("partitionID", partitionID))
+		return 0, false, nil
+	case MissingPartitionAllocateNewID:
+		if sr.GenGlobalID == nil {
+			return 0, false, errors.Annotatef(berrors.ErrInvalidArgument,
+				"failed to allocate the id for partition id:%v, no id allocator", partitionID)
 		}
-
-		if rawWriteCFValue.IsDelete() {
-			return rewriteResult{
-				NewValue: value,
-				Deleted:  true,
-			}, nil
-		}
-		if rawWriteCFValue.IsRollback() {
-			return rewriteResult{
-				NewValue: value,
-				Deleted:  false,
-			}, nil
-		}
-		if !rawWriteCFValue.HasShortValue() {
-			return rewriteResult{
-				NewValue: value,
-			}, nil
-		}
-
-		shortValue, err := rewrite(rawWriteCFValue.GetShortValue())
+		newID, err := sr.GenGlobalID(context.Background())
 		if err != nil {
-			log.Info("failed to rewrite short value",
-				zap.ByteString("write-type", []byte{rawWriteCFValue.GetWriteType()}),
-				zap.Int("short-value-len", len(rawWriteCFValue.GetShortValue())))
-			return rewriteResult{}, errors.Trace(err)
+			return 0, false, errors.Trace(err)
 		}
-
-		rawWriteCFValue.UpdateShortValue(shortValue)
-		return rewriteResult{NewValue: rawWriteCFValue.EncodeTo()}, nil
+		tableReplace.PartitionMap[partitionID] = newID
+		sr.delRangeRecorder.globalTableIdMap[partitionID] = newID
+		if sr.PersistIDMap != nil {
+			if err := sr.PersistIDMap(context.Background()); err != nil {
+				return 0, false, errors.Annotatef(err, "failed to save the id of partition id:%v", partitionID)
+			}
+		}
+		log.Warn("allocate the new id for the partition missing in table replace", zap.String("table", tableReplace.Name),
+			zap.Int64("partitionID", partitionID), zap.Int64("newPartitionID", newID))
+		return newID, true, nil
 	default:
-		panic(fmt.Sprintf("not support cf:%s", cf))
+		log.Error("expect partition info in table replace but got none", zap.Int64("partitionID", partitionID))
+		return 0, false, errors.Annotatef(berrors.ErrInvalidArgument, "failed to find partition id:%v in replace maps", partitionID)
+	}
+}
+
+// disableTTL is the built-in rule forcing to disable TTL_ENABLE when restore.
+func disableTTL(e *MetaKVEntry) (bool, error) {
+	if e.TableInfo != nil && e.TableInfo.TTLInfo != nil {
+		e.TableInfo.TTLInfo.Enable = false
 	}
+	return true, nil
 }
 
 func (sr *SchemasReplace) GetIngestRecorder() *ingestrec.IngestRecorder {
 	return sr.ingestRecorder
 }
 
-// RewriteKvEntry uses to rewrite tableID/dbID in entry.key and entry.value
+// RewriteKvEntry rewrites the meta kv entry by the rules of its key type, see RegisterRule. nil is
+// returned if the entry is skipped.
 func (sr *SchemasReplace) RewriteKvEntry(e *kv.Entry, cf string) (*kv.Entry, error) {
+	return sr.RewriteKvEntryTo(nil, e, cf)
+}
+
+// RewriteKvEntryTo is RewriteKvEntry allocating the rewritten entry from the arena, the entry is only valid
+// until the arena is reset or released.
+func (sr *SchemasReplace) RewriteKvEntryTo(arena *KvEntryArena, e *kv.Entry, cf string) (*kv.Entry, error) {
+	tracer := sr.traceKey(e.Key, cf)
+	tracer.trace("rewrite the entry", zap.Int("value-len", len(e.Value)))
 	// skip mDDLJob
 	if !IsMetaDBKey(e.Key) {
 		if cf == DefaultCF && IsMetaDDLJobHistoryKey(e.Key) { // mDDLJobHistory
@@ -443,38 +349,107 @@ func (sr *SchemasReplace) RewriteKvEntry(e *kv.Entry, cf string) (*kv.Entry, err
 			if err := job.Decode(e.Value); err != nil {
 				log.Debug("failed to decode the job",
 					zap.String("error", err.Error()), zap.String("job", string(e.Value)))
+				tracer.trace("skip the ddl job history entry failed to decode", zap.Error(err))
 				// The value in write-cf is like "p\XXXX\XXX" need not restore. skip it
 				// The value in default-cf that can Decode() need restore.
 				return nil, nil
 			}
 
+			tracer.trace("restore the delete ranges from the ddl job history entry", zap.Int64("job-id", job.ID),
+				zap.Stringer("job-type", job.Type))
 			return nil, sr.restoreFromHistory(job)
 		}
+		// the job may be written to both mDDLJobHistory and the table, it's fine to record it twice since
+		// the delete ranges are inserted by the job ID and the ingest indexes are recorded by the index ID.
+		if cf == DefaultCF && IsDDLHistoryTableKey(e.Key) { // mysql.tidb_ddl_history
+			job, err := decodeDDLHistoryJob(e.Value)
+			if err != nil {
+				log.Debug("failed to decode the job from the ddl history table", zap.Error(err))
+				tracer.trace("skip the ddl history table entry failed to decode", zap.Error(err))
+				return nil, nil
+			}
+			tracer.trace("restore the delete ranges from the ddl history table entry", zap.Int64("job-id", job.ID),
+				zap.Stringer("job-type", job.Type))
+			return nil, sr.restoreFromHistory(job)
+		}
+		if family, ok := matchClusterMetaFamily(e.Key); ok {
+			newEntry := sr.rewriteClusterMeta(e, cf, family)
+			tracer.trace("rewrite the cluster meta entry", zap.String("family", family.Name),
+				zap.Bool("preserved", newEntry != nil))
+			return newEntry, nil
+		}
+		tracer.trace("skip the entry out of the meta db keys")
 		return nil, nil
 	}
+	if skip, err := sr.filterRollbackRecord(e, cf, tracer); err != nil || skip {
+		return nil, errors.Trace(err)
+	}
 
-	rawKey, err := ParseTxnMetaKeyFrom(e.Key)
+	scratch := getRewriteScratch()
+	defer scratch.release()
+	rawKey, err := scratch.parseMetaKey(e.Key)
 	if err != nil {
+		tracer.trace("failed to parse the meta key", zap.Error(err))
 		return nil, errors.Trace(err)
 	}
+	keyType, ok := parseMetaKeyType(rawKey)
+	if !ok {
+		tracer.traceRawKey("skip the meta key not rewritten", rawKey)
+		return nil, nil
+	}
+	tracer.traceRawKey("parse the meta key", rawKey)
+	result := metaKVFailed
+	defer func() {
+		metaKVRewriteCounter.WithLabelValues(keyType.String(), result).Inc()
+	}()
 
-	if meta.IsDBkey(rawKey.Field) {
-		return sr.rewriteEntryForDB(e, cf)
-	} else if !meta.IsDBkey(rawKey.Key) {
+	entry, err := decodeMetaKVEntry(scratch, keyType, rawKey, e.Value, cf)
+	if err != nil {
+		tracer.trace("failed to decode the entry", zap.Error(err))
+		return nil, errors.Trace(err)
+	}
+	entry.tracer = tracer
+	tracer.trace("decode the entry", zap.Stringer("key-type", keyType), zap.Bool("deleted", entry.Deleted),
+		zap.Bool("has-db-info", entry.DBInfo != nil), zap.Bool("has-table-info", entry.TableInfo != nil))
+	if sr.ValidateRoundTrip {
+		if err := entry.checkRoundTrip(); err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	keep, err := sr.applyRules(entry)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if !keep {
+		result = metaKVSkipped
 		return nil, nil
 	}
-	if meta.IsTableKey(rawKey.Field) {
-		return sr.rewriteEntryForTable(e, cf)
-	} else if meta.IsAutoIncrementIDKey(rawKey.Field) {
-		return sr.rewriteEntryForAutoIncrementIDKey(e, cf)
-	} else if meta.IsAutoTableIDKey(rawKey.Field) {
-		return sr.rewriteEntryForAutoTableIDKey(e, cf)
-	} else if meta.IsSequenceKey(rawKey.Field) {
-		return sr.rewriteEntryForSequenceKey(e, cf)
-	} else if meta.IsAutoRandomTableIDKey(rawKey.Field) {
-		return sr.rewriteEntryForAutoRandomTableIDKey(e, cf)
-	}
-	return nil, nil
+	if keyType == MetaKeyTable && sr.AfterTableRewritten != nil {
+		if entry.TableInfo != nil {
+			sr.AfterTableRewritten(false, entry.TableInfo)
+		} else if entry.Deleted {
+			newTableID, err := meta.ParseTableKey(entry.Key.Field)
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			sr.AfterTableRewritten(true, &model.TableInfo{ID: newTableID})
+		}
+	}
+
+	newValue, err := entry.encodeValue()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if sr.ValidateRoundTrip {
+		if err := entry.checkRewrittenValue(newValue); err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	newKey := scratch.encodeMetaKey(arena, entry.Key)
+	tracer.traceRawKey("encode the rewritten entry", entry.Key)
+	tracer.traceNewEntry(newKey, newValue)
+	result = metaKVRewritten
+	return arena.newEntry(newKey, newValue), nil
 }
 
 func (sr *SchemasReplace) tryRecordIngestIndex(job *model.Job) error {
@@ -493,7 +468,12 @@ func (sr *SchemasReplace) tryRecordIngestIndex(job *model.Job) error {
 }
 
 func (sr *SchemasReplace) restoreFromHistory(job *model.Job) error {
+	if err := sr.checkMergedPartitionJob(job); err != nil {
+		return errors.Trace(err)
+	}
 	if ddl.JobNeedGC(job) {
+		sr.delRangeRecorder.conflictPolicy = sr.DelRangeConflictPolicy
+		sr.delRangeRecorder.skipMergedTables = movesPartitionRows(job)
 		if err := ddl.AddDelRangeJobInternal(context.TODO(), sr.delRangeRecorder, job); err != nil {
 			return err
 		}
@@ -519,8 +499,20 @@ type brDelRangeExecWrapper struct {
 
 	recordDeleteRange func(*PreDelRangeQuery)
 
+	// mergedTables are the tables whose partitions are merged into them, see SchemasReplace.MergePartitions.
+	mergedTables map[DownstreamID]struct{}
+	// skipMergedTables skips the delete ranges rewritten to the merged tables, it's set for the jobs
+	// moving the rows between the partitions.
+	skipMergedTables bool
+
+	dedup          delRangeDedup
+	conflictPolicy DelRangeConflictPolicy
+
 	// temporary values
 	query *PreDelRangeQuery
+	// skipped is the number of the delete ranges of the query skipped by the dedup.
+	skipped int
+	err     error
 }
 
 func newDelRangeExecWrapper(
@@ -544,23 +536,54 @@ func (bdr *brDelRangeExecWrapper) PrepareParamsList(sz int) {
 	bdr.query = &PreDelRangeQuery{
 		ParamsList: make([]DelRangeParams, 0, sz),
 	}
+	bdr.skipped = 0
+	bdr.err = nil
 }
 
 func (bdr *brDelRangeExecWrapper) RewriteTableID(tableID int64) (int64, bool) {
 	newTableID, exists := bdr.globalTableIdMap[tableID]
 	if !exists {
 		log.Warn("failed to find the downstream id when rewrite delete range", zap.Int64("old tableID", tableID))
+		return newTableID, exists
+	}
+	if _, merged := bdr.mergedTables[newTableID]; merged && bdr.skipMergedTables {
+		// the rows are moved under the same keys of the merged table, deleting the range would delete them.
+		log.Warn("skip the delete range of the partition merged into the table", zap.Int64("old tableID", tableID),
+			zap.Int64("new tableID", newTableID))
+		return 0, false
 	}
 	return newTableID, exists
 }
 
 func (bdr *brDelRangeExecWrapper) AppendParamsList(jobID, elemID int64, startKey, endKey string) {
-	bdr.query.ParamsList = append(bdr.query.ParamsList, DelRangeParams{jobID, elemID, startKey, endKey})
+	params := DelRangeParams{jobID, elemID, startKey, endKey}
+	first, err := bdr.dedup.check(bdr.conflictPolicy, params)
+	if err != nil && bdr.err == nil {
+		bdr.err = err
+	}
+	if !first {
+		bdr.skipped++
+		return
+	}
+	bdr.query.ParamsList = append(bdr.query.ParamsList, params)
 }
 
 func (bdr *brDelRangeExecWrapper) ConsumeDeleteRange(ctx context.Context, sql string) error {
+	defer func() {
+		bdr.query = nil
+	}()
+	if bdr.err != nil {
+		return bdr.err
+	}
+	if bdr.skipped > 0 {
+		if len(bdr.query.ParamsList) == 0 {
+			// all of the delete ranges are recorded before.
+			return nil
+		}
+		// the statement is rebuilt for the delete ranges left.
+		sql = newDelRangeQuery(bdr.query.ParamsList).Sql
+	}
 	bdr.query.Sql = sql
 	bdr.recordDeleteRange(bdr.query)
-	bdr.query = nil
 	return nil
 }
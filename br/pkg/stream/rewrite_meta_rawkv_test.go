@@ -3,18 +3,26 @@
 package stream
 
 import (
+	"context"
 	"encoding/hex"
 	"encoding/json"
 	"testing"
+	"time"
 
+	"github.com/pingcap/errors"
+	backuppb "github.com/pingcap/kvproto/pkg/brpb"
 	"github.com/pingcap/tidb/pkg/ddl"
+	"github.com/pingcap/tidb/pkg/kv"
 	"github.com/pingcap/tidb/pkg/meta"
 	"github.com/pingcap/tidb/pkg/meta/model"
 	"github.com/pingcap/tidb/pkg/parser/ast"
 	"github.com/pingcap/tidb/pkg/parser/mysql"
 	"github.com/pingcap/tidb/pkg/tablecodec"
 	"github.com/pingcap/tidb/pkg/types"
+	"github.com/pingcap/tidb/pkg/util/codec"
+	"github.com/pingcap/tidb/pkg/util/rowcodec"
 	filter "github.com/pingcap/tidb/pkg/util/table-filter"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/require"
 )
 
@@ -34,6 +42,49 @@ func MockEmptySchemasReplace(midr *mockInsertDeleteRange, dbMap map[UpstreamID]*
 	)
 }
 
+// rewriteMetaKey rewrites the key by the rules without the value.
+func (sr *SchemasReplace) rewriteMetaKey(key []byte, cf string) ([]byte, error) {
+	rawKey, err := ParseTxnMetaKeyFrom(key)
+	if err != nil {
+		return nil, err
+	}
+	keyType, ok := parseMetaKeyType(rawKey)
+	if !ok {
+		return nil, nil
+	}
+	e := &MetaKVEntry{KeyType: keyType, CF: cf, Key: rawKey}
+	if keep, err := sr.applyRules(e); err != nil || !keep {
+		return nil, err
+	}
+	return e.Key.EncodeMetaKey(), nil
+}
+
+// rewriteDBInfo rewrites the value of the database in default cf.
+func (sr *SchemasReplace) rewriteDBInfo(value []byte) ([]byte, error) {
+	var dbInfo model.DBInfo
+	if err := json.Unmarshal(value, &dbInfo); err != nil {
+		return nil, err
+	}
+	return sr.rewriteMetaValue(encodeTxnMetaKey([]byte("DBs"), meta.DBkey(dbInfo.ID), 1), value)
+}
+
+// rewriteTableInfo rewrites the value of the table in default cf.
+func (sr *SchemasReplace) rewriteTableInfo(value []byte, dbID int64) ([]byte, error) {
+	var tableInfo model.TableInfo
+	if err := json.Unmarshal(value, &tableInfo); err != nil {
+		return nil, err
+	}
+	return sr.rewriteMetaValue(encodeTxnMetaKey(meta.DBkey(dbID), meta.TableKey(tableInfo.ID), 1), value)
+}
+
+func (sr *SchemasReplace) rewriteMetaValue(key, value []byte) ([]byte, error) {
+	e, err := sr.RewriteKvEntry(&kv.Entry{Key: key, Value: value}, DefaultCF)
+	if err != nil || e == nil {
+		return nil, err
+	}
+	return e.Value, nil
+}
+
 func produceDBInfoValue(dbName string, dbID int64) ([]byte, error) {
 	dbInfo := model.DBInfo{
 		ID:   dbID,
@@ -69,7 +120,7 @@ func TestRewriteKeyForDB(t *testing.T) {
 	sr := MockEmptySchemasReplace(nil, dbMap)
 
 	// set restoreKV status and rewrite it.
-	newKey, err := sr.rewriteKeyForDB(encodedKey, DefaultCF)
+	newKey, err := sr.rewriteMetaKey(encodedKey, DefaultCF)
 	require.Nil(t, err)
 	decodedKey, err := ParseTxnMetaKeyFrom(newKey)
 	require.Nil(t, err)
@@ -79,7 +130,7 @@ func TestRewriteKeyForDB(t *testing.T) {
 	require.Equal(t, newDBID, downstreamID)
 
 	// rewrite it again, and get the same result.
-	newKey, err = sr.rewriteKeyForDB(encodedKey, WriteCF)
+	newKey, err = sr.rewriteMetaKey(encodedKey, WriteCF)
 	require.Nil(t, err)
 	decodedKey, err = ParseTxnMetaKeyFrom(newKey)
 	require.Nil(t, err)
@@ -169,7 +220,7 @@ func TestRewriteKeyForTable(t *testing.T) {
 		sr := MockEmptySchemasReplace(nil, dbMap)
 
 		// set restoreKV status and rewrite it.
-		newKey, err := sr.rewriteKeyForTable(encodedKey, DefaultCF, ca.decodeTableFn, ca.encodeTableFn)
+		newKey, err := sr.rewriteMetaKey(encodedKey, DefaultCF)
 		require.Nil(t, err)
 		decodedKey, err := ParseTxnMetaKeyFrom(newKey)
 		require.Nil(t, err)
@@ -183,7 +234,7 @@ func TestRewriteKeyForTable(t *testing.T) {
 		require.Equal(t, newTblID, downStreamTblID)
 
 		// rewrite it again, and get the same result.
-		newKey, err = sr.rewriteKeyForTable(encodedKey, WriteCF, ca.decodeTableFn, ca.encodeTableFn)
+		newKey, err = sr.rewriteMetaKey(encodedKey, WriteCF)
 		require.Nil(t, err)
 		decodedKey, err = ParseTxnMetaKeyFrom(newKey)
 		require.Nil(t, err)
@@ -346,6 +397,145 @@ func TestRewriteTableInfoForPartitionTable(t *testing.T) {
 	require.Equal(t, tableInfo.Partition.Definitions[1].ID, newID2)
 }
 
+func TestRewriteTableInfoWithMissingPartition(t *testing.T) {
+	const (
+		dbID    int64 = 40
+		tableID int64 = 100
+		pt1ID   int64 = 101
+		pt2ID   int64 = 102
+	)
+	tbl := model.TableInfo{
+		ID:   tableID,
+		Name: ast.NewCIStr("t1"),
+		Partition: &model.PartitionInfo{
+			Enable: true,
+			Definitions: []model.PartitionDefinition{
+				{ID: pt1ID, Name: ast.NewCIStr("pt1")},
+				{ID: pt2ID, Name: ast.NewCIStr("pt2")},
+			},
+		},
+	}
+	value, err := json.Marshal(&tbl)
+	require.NoError(t, err)
+
+	// only the partition pt1 is in the PartitionMap.
+	newSchemasReplace := func(policy MissingPartitionPolicy) *SchemasReplace {
+		dbMap := make(map[UpstreamID]*DBReplace)
+		dbMap[dbID] = NewDBReplace("db", dbID+100)
+		dbMap[dbID].TableMap[tableID] = NewTableReplace("t1", tableID+100)
+		dbMap[dbID].TableMap[tableID].PartitionMap[pt1ID] = pt1ID + 100
+		sr := NewSchemasReplace(dbMap, nil, 0, filter.All(), nil)
+		sr.MissingPartitionPolicy = policy
+		return sr
+	}
+
+	sr := newSchemasReplace(MissingPartitionError)
+	_, err = sr.rewriteTableInfo(value, dbID)
+	require.ErrorContains(t, err, "failed to find partition id:102 in replace maps")
+
+	var tableInfo model.TableInfo
+	sr = newSchemasReplace(MissingPartitionSkip)
+	newValue, err := sr.rewriteTableInfo(value, dbID)
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(newValue, &tableInfo))
+	require.Len(t, tableInfo.Partition.Definitions, 1)
+	require.Equal(t, pt1ID+100, tableInfo.Partition.Definitions[0].ID)
+	require.NotContains(t, sr.DbMap[dbID].TableMap[tableID].PartitionMap, pt2ID)
+
+	sr = newSchemasReplace(MissingPartitionAllocateNewID)
+	_, err = sr.rewriteTableInfo(value, dbID)
+	require.ErrorContains(t, err, "no id allocator")
+	sr.GenGlobalID = func(context.Context) (int64, error) {
+		return 1000, nil
+	}
+	var savedIDMap []*backuppb.PitrDBMap
+	sr.PersistIDMap = func(context.Context) error {
+		savedIDMap = NewTableMappingManager(sr.DbMap, nil).ToProto()
+		return nil
+	}
+	newValue, err = sr.rewriteTableInfo(value, dbID)
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(newValue, &tableInfo))
+	require.Len(t, tableInfo.Partition.Definitions, 2)
+	require.Equal(t, pt1ID+100, tableInfo.Partition.Definitions[0].ID)
+	require.Equal(t, int64(1000), tableInfo.Partition.Definitions[1].ID)
+	require.Equal(t, int64(1000), sr.DbMap[dbID].TableMap[tableID].PartitionMap[pt2ID])
+	newID, exist := sr.delRangeRecorder.RewriteTableID(pt2ID)
+	require.True(t, exist)
+	require.Equal(t, int64(1000), newID)
+
+	// the retried restore loads the saved id maps, the partition is rewritten to the same id.
+	sr = NewSchemasReplace(FromDBMapProto(savedIDMap), nil, 0, filter.All(), nil)
+	sr.MissingPartitionPolicy = MissingPartitionAllocateNewID
+	sr.GenGlobalID = func(context.Context) (int64, error) {
+		return 2000, nil
+	}
+	newValue, err = sr.rewriteTableInfo(value, dbID)
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(newValue, &tableInfo))
+	require.Equal(t, int64(1000), tableInfo.Partition.Definitions[1].ID)
+
+	sr = newSchemasReplace(MissingPartitionAllocateNewID)
+	sr.GenGlobalID = func(context.Context) (int64, error) {
+		return 1000, nil
+	}
+	sr.PersistIDMap = func(context.Context) error {
+		return errors.New("failed to save")
+	}
+	_, err = sr.rewriteTableInfo(value, dbID)
+	require.ErrorContains(t, err, "failed to save")
+
+	policy, err := ParseMissingPartitionPolicy("")
+	require.NoError(t, err)
+	require.Equal(t, MissingPartitionError, policy)
+	policy, err = ParseMissingPartitionPolicy("Skip-Partition")
+	require.NoError(t, err)
+	require.Equal(t, MissingPartitionSkip, policy)
+	_, err = ParseMissingPartitionPolicy("ignore")
+	require.ErrorContains(t, err, "unknown missing partition policy")
+}
+
+func TestRewriteTableInfoWithUnknownFields(t *testing.T) {
+	var (
+		dbID    int64 = 40
+		tableID int64 = 100
+		pt1ID   int64 = 101
+	)
+	tbl := model.TableInfo{
+		ID:   tableID,
+		Name: ast.NewCIStr("t1"),
+		Partition: &model.PartitionInfo{
+			Enable:      true,
+			Definitions: []model.PartitionDefinition{{ID: pt1ID, Name: ast.NewCIStr("p1")}},
+		},
+	}
+	value, err := json.Marshal(&tbl)
+	require.NoError(t, err)
+	// the fields added by a newer version of TiDB.
+	var raw map[string]any
+	require.NoError(t, json.Unmarshal(value, &raw))
+	raw["future_feature"] = map[string]any{"enable": true}
+	raw["partition"].(map[string]any)["definitions"].([]any)[0].(map[string]any)["future_option"] = "x"
+	value, err = json.Marshal(raw)
+	require.NoError(t, err)
+
+	dbMap := make(map[UpstreamID]*DBReplace)
+	dbMap[dbID] = NewDBReplace("db", dbID+100)
+	dbMap[dbID].TableMap[tableID] = NewTableReplace("t1", tableID+100)
+	dbMap[dbID].TableMap[tableID].PartitionMap[pt1ID] = pt1ID + 100
+	sr := MockEmptySchemasReplace(nil, dbMap)
+
+	newValue, err := sr.rewriteTableInfo(value, dbID)
+	require.NoError(t, err)
+	var tableInfo model.TableInfo
+	require.NoError(t, json.Unmarshal(newValue, &tableInfo))
+	require.Equal(t, tableID+100, tableInfo.ID)
+	require.Equal(t, pt1ID+100, tableInfo.Partition.Definitions[0].ID)
+	require.NoError(t, json.Unmarshal(newValue, &raw))
+	require.Equal(t, map[string]any{"enable": true}, raw["future_feature"])
+	require.Equal(t, "x", raw["partition"].(map[string]any)["definitions"].([]any)[0].(map[string]any)["future_option"])
+}
+
 func TestRewriteTableInfoForExchangePartition(t *testing.T) {
 	var (
 		dbID1      int64 = 100
@@ -579,17 +769,18 @@ var (
 	reorganizeTable0Partition1Job *model.Job
 	removeTable0Partition1Job     *model.Job
 	alterTable0Partition1Job      *model.Job
-	rollBackTable0IndexJob        = &model.Job{Version: model.JobVersion1, Type: model.ActionAddIndex, State: model.JobStateRollbackDone, SchemaID: mDDLJobDBOldID, TableID: mDDLJobTable0OldID, RawArgs: json.RawMessage(`[2,false,[72,73,74]]`)}
-	rollBackTable1IndexJob        = &model.Job{Version: model.JobVersion1, Type: model.ActionAddIndex, State: model.JobStateRollbackDone, SchemaID: mDDLJobDBOldID, TableID: mDDLJobTable1OldID, RawArgs: json.RawMessage(`[2,false,[]]`)}
-	addTable0IndexJob             = &model.Job{Version: model.JobVersion1, Type: model.ActionAddIndex, State: model.JobStateSynced, SchemaID: mDDLJobDBOldID, TableID: mDDLJobTable0OldID, RawArgs: json.RawMessage(`[2,false,[72,73,74]]`)}
-	addTable1IndexJob             = &model.Job{Version: model.JobVersion1, Type: model.ActionAddIndex, State: model.JobStateSynced, SchemaID: mDDLJobDBOldID, TableID: mDDLJobTable1OldID, RawArgs: json.RawMessage(`[2,false,[]]`)}
-	dropTable0IndexJob            = &model.Job{Version: model.JobVersion1, Type: model.ActionDropIndex, SchemaID: mDDLJobDBOldID, TableID: mDDLJobTable0OldID, RawArgs: json.RawMessage(`["",false,2,[72,73,74]]`)}
-	dropTable1IndexJob            = &model.Job{Version: model.JobVersion1, Type: model.ActionDropIndex, SchemaID: mDDLJobDBOldID, TableID: mDDLJobTable1OldID, RawArgs: json.RawMessage(`["",false,2,[]]`)}
-	dropTable0ColumnJob           = &model.Job{Version: model.JobVersion1, Type: model.ActionDropColumn, SchemaID: mDDLJobDBOldID, TableID: mDDLJobTable0OldID, RawArgs: json.RawMessage(`["",false,[2,3],[72,73,74]]`)}
-	dropTable1ColumnJob           = &model.Job{Version: model.JobVersion1, Type: model.ActionDropColumn, SchemaID: mDDLJobDBOldID, TableID: mDDLJobTable1OldID, RawArgs: json.RawMessage(`["",false,[2,3],[]]`)}
-	modifyTable0ColumnJob         = &model.Job{Version: model.JobVersion1, Type: model.ActionModifyColumn, SchemaID: mDDLJobDBOldID, TableID: mDDLJobTable0OldID, RawArgs: json.RawMessage(`[[2,3],[72,73,74]]`)}
-	modifyTable1ColumnJob         = &model.Job{Version: model.JobVersion1, Type: model.ActionModifyColumn, SchemaID: mDDLJobDBOldID, TableID: mDDLJobTable1OldID, RawArgs: json.RawMessage(`[[2,3],[]]`)}
+	rollBackTable0IndexJob        = &model.Job{ID: 101, Version: model.JobVersion1, Type: model.ActionAddIndex, State: model.JobStateRollbackDone, SchemaID: mDDLJobDBOldID, TableID: mDDLJobTable0OldID, RawArgs: json.RawMessage(`[2,false,[72,73,74]]`)}
+	rollBackTable1IndexJob        = &model.Job{ID: 102, Version: model.JobVersion1, Type: model.ActionAddIndex, State: model.JobStateRollbackDone, SchemaID: mDDLJobDBOldID, TableID: mDDLJobTable1OldID, RawArgs: json.RawMessage(`[2,false,[]]`)}
+	addTable0IndexJob             = &model.Job{ID: 103, Version: model.JobVersion1, Type: model.ActionAddIndex, State: model.JobStateSynced, SchemaID: mDDLJobDBOldID, TableID: mDDLJobTable0OldID, RawArgs: json.RawMessage(`[2,false,[72,73,74]]`)}
+	addTable1IndexJob             = &model.Job{ID: 104, Version: model.JobVersion1, Type: model.ActionAddIndex, State: model.JobStateSynced, SchemaID: mDDLJobDBOldID, TableID: mDDLJobTable1OldID, RawArgs: json.RawMessage(`[2,false,[]]`)}
+	dropTable0IndexJob            = &model.Job{ID: 105, Version: model.JobVersion1, Type: model.ActionDropIndex, SchemaID: mDDLJobDBOldID, TableID: mDDLJobTable0OldID, RawArgs: json.RawMessage(`["",false,2,[72,73,74]]`)}
+	dropTable1IndexJob            = &model.Job{ID: 106, Version: model.JobVersion1, Type: model.ActionDropIndex, SchemaID: mDDLJobDBOldID, TableID: mDDLJobTable1OldID, RawArgs: json.RawMessage(`["",false,2,[]]`)}
+	dropTable0ColumnJob           = &model.Job{ID: 107, Version: model.JobVersion1, Type: model.ActionDropColumn, SchemaID: mDDLJobDBOldID, TableID: mDDLJobTable0OldID, RawArgs: json.RawMessage(`["",false,[2,3],[72,73,74]]`)}
+	dropTable1ColumnJob           = &model.Job{ID: 108, Version: model.JobVersion1, Type: model.ActionDropColumn, SchemaID: mDDLJobDBOldID, TableID: mDDLJobTable1OldID, RawArgs: json.RawMessage(`["",false,[2,3],[]]`)}
+	modifyTable0ColumnJob         = &model.Job{ID: 109, Version: model.JobVersion1, Type: model.ActionModifyColumn, SchemaID: mDDLJobDBOldID, TableID: mDDLJobTable0OldID, RawArgs: json.RawMessage(`[[2,3],[72,73,74]]`)}
+	modifyTable1ColumnJob         = &model.Job{ID: 110, Version: model.JobVersion1, Type: model.ActionModifyColumn, SchemaID: mDDLJobDBOldID, TableID: mDDLJobTable1OldID, RawArgs: json.RawMessage(`[[2,3],[]]`)}
 	multiSchemaChangeJob0         = &model.Job{
+		ID:       118,
 		Version:  model.JobVersion1,
 		Type:     model.ActionMultiSchemaChange,
 		SchemaID: mDDLJobDBOldID,
@@ -608,6 +799,7 @@ var (
 		},
 	}
 	multiSchemaChangeJob1 = &model.Job{
+		ID:       119,
 		Version:  model.JobVersion1,
 		Type:     model.ActionMultiSchemaChange,
 		SchemaID: mDDLJobDBOldID,
@@ -636,19 +828,19 @@ func genFinishedJob(job *model.Job, args model.FinishedJobArgs) *model.Job {
 }
 
 func init() {
-	dropSchemaJob = genFinishedJob(&model.Job{Version: model.GetJobVerInUse(), Type: model.ActionDropSchema,
+	dropSchemaJob = genFinishedJob(&model.Job{ID: 111, Version: model.GetJobVerInUse(), Type: model.ActionDropSchema,
 		SchemaID: mDDLJobDBOldID}, &model.DropSchemaArgs{AllDroppedTableIDs: []int64{71, 72, 73, 74, 75}})
-	alterTable0Partition1Job = genFinishedJob(&model.Job{Version: model.GetJobVerInUse(), Type: model.ActionAlterTablePartitioning,
+	alterTable0Partition1Job = genFinishedJob(&model.Job{ID: 112, Version: model.GetJobVerInUse(), Type: model.ActionAlterTablePartitioning,
 		SchemaID: mDDLJobDBOldID, TableID: mDDLJobTable0OldID}, &model.TablePartitionArgs{OldPhysicalTblIDs: []int64{73}})
-	removeTable0Partition1Job = genFinishedJob(&model.Job{Version: model.GetJobVerInUse(), Type: model.ActionRemovePartitioning,
+	removeTable0Partition1Job = genFinishedJob(&model.Job{ID: 113, Version: model.GetJobVerInUse(), Type: model.ActionRemovePartitioning,
 		SchemaID: mDDLJobDBOldID, TableID: mDDLJobTable0OldID}, &model.TablePartitionArgs{OldPhysicalTblIDs: []int64{73}})
-	reorganizeTable0Partition1Job = genFinishedJob(&model.Job{Version: model.GetJobVerInUse(), Type: model.ActionReorganizePartition,
+	reorganizeTable0Partition1Job = genFinishedJob(&model.Job{ID: 114, Version: model.GetJobVerInUse(), Type: model.ActionReorganizePartition,
 		SchemaID: mDDLJobDBOldID, TableID: mDDLJobTable0OldID}, &model.TablePartitionArgs{OldPhysicalTblIDs: []int64{73}})
-	dropTable0Partition1Job = genFinishedJob(&model.Job{Version: model.GetJobVerInUse(), Type: model.ActionDropTablePartition,
+	dropTable0Partition1Job = genFinishedJob(&model.Job{ID: 115, Version: model.GetJobVerInUse(), Type: model.ActionDropTablePartition,
 		SchemaID: mDDLJobDBOldID, TableID: mDDLJobTable0OldID}, &model.TablePartitionArgs{OldPhysicalTblIDs: []int64{73}})
-	dropTable0Job = genFinishedJob(&model.Job{Version: model.GetJobVerInUse(), Type: model.ActionDropTable,
+	dropTable0Job = genFinishedJob(&model.Job{ID: 116, Version: model.GetJobVerInUse(), Type: model.ActionDropTable,
 		SchemaID: mDDLJobDBOldID, TableID: mDDLJobTable0OldID}, &model.DropTableArgs{OldPartitionIDs: []int64{72, 73, 74}})
-	dropTable1Job = genFinishedJob(&model.Job{Version: model.GetJobVerInUse(), Type: model.ActionDropTable,
+	dropTable1Job = genFinishedJob(&model.Job{ID: 117, Version: model.GetJobVerInUse(), Type: model.ActionDropTable,
 		SchemaID: mDDLJobDBOldID, TableID: mDDLJobTable1OldID}, &model.DropTableArgs{})
 }
 
@@ -981,7 +1173,84 @@ func TestDeleteRangeForMDDLJob2(t *testing.T) {
 	require.Equal(t, "INSERT IGNORE INTO mysql.gc_delete_range VALUES (%?, %?, %?, %?, %?),(%?, %?, %?, %?, %?),(%?, %?, %?, %?, %?),(%?, %?, %?, %?, %?),", qargs.Sql)
 }
 
+func TestDeleteRangeForDDLHistoryTable(t *testing.T) {
+	midr := newMockInsertDeleteRange()
+	dbReplace := &DBReplace{
+		DbID: mDDLJobDBNewID,
+		TableMap: map[int64]*TableReplace{
+			mDDLJobTable0OldID: {
+				TableID: mDDLJobTable0NewID,
+				PartitionMap: map[int64]int64{
+					mDDLJobPartition0OldID: mDDLJobPartition0NewID,
+					mDDLJobPartition1OldID: mDDLJobPartition1NewID,
+					mDDLJobPartition2OldID: mDDLJobPartition2NewID,
+				},
+			},
+			mDDLJobTable1OldID: {TableID: mDDLJobTable1NewID},
+		},
+	}
+	schemaReplace := MockEmptySchemasReplace(midr, map[int64]*DBReplace{mDDLJobDBOldID: dbReplace})
+
+	historyKey := func(tableID, jobID int64) []byte {
+		key := tablecodec.EncodeRowKeyWithHandle(tableID, kv.IntHandle(jobID))
+		return codec.EncodeUintDesc(codec.EncodeBytes(nil, key), 400036290571534337)
+	}
+	jobMeta, err := dropSchemaJob.Encode(true)
+	require.NoError(t, err)
+	var encoder rowcodec.Encoder
+	row, err := encoder.Encode(time.UTC, []int64{ddlHistoryJobMetaColumnID, 3},
+		[]types.Datum{types.NewBytesDatum(jobMeta), types.NewStringDatum("test")}, nil, nil)
+	require.NoError(t, err)
+
+	require.True(t, IsDDLHistoryTableKey(historyKey(ddl.HistoryTableID, 1)))
+	require.False(t, IsDDLHistoryTableKey(historyKey(ddl.JobTableID, 1)))
+	require.False(t, IsDDLHistoryTableKey(encodeTxnMetaKey([]byte("DDLJobHistory"), []byte("1"), 1)))
+
+	// the row in the write cf isn't the job.
+	entry, err := schemaReplace.RewriteKvEntry(&kv.Entry{Key: historyKey(ddl.HistoryTableID, 1), Value: row}, WriteCF)
+	require.NoError(t, err)
+	require.Nil(t, entry)
+	require.Len(t, midr.queryCh, 0)
+
+	entry, err = schemaReplace.RewriteKvEntry(&kv.Entry{Key: historyKey(ddl.HistoryTableID, 1), Value: row}, DefaultCF)
+	require.NoError(t, err)
+	require.Nil(t, entry)
+	qargs := <-midr.queryCh
+	require.Equal(t, len(mDDLJobALLNewTableIDSet), len(qargs.ParamsList))
+	for _, params := range qargs.ParamsList {
+		_, exist := mDDLJobALLNewTableKeySet[params.StartKey]
+		require.True(t, exist)
+	}
+}
+
 func TestCompatibleAlert(t *testing.T) {
 	require.Equal(t, ddl.BRInsertDeleteRangeSQLPrefix, `INSERT IGNORE INTO mysql.gc_delete_range VALUES `)
 	require.Equal(t, ddl.BRInsertDeleteRangeSQLValue, `(%?, %?, %?, %?, %?)`)
 }
+
+func TestMetaKVRewriteCounter(t *testing.T) {
+	const dbID, tableID, filteredTableID int64 = 1, 2, 3
+	dbMap := map[UpstreamID]*DBReplace{dbID: NewDBReplace("db", dbID+100)}
+	dbMap[dbID].TableMap[tableID] = NewTableReplace("t", tableID+100)
+	sr := MockEmptySchemasReplace(nil, dbMap)
+	rewrite := func(id int64, value []byte) error {
+		_, err := sr.RewriteKvEntry(&kv.Entry{Key: encodeTxnMetaKey(meta.DBkey(dbID), meta.TableKey(id), 1), Value: value}, DefaultCF)
+		return err
+	}
+	count := func(result string) float64 {
+		return testutil.ToFloat64(metaKVRewriteCounter.WithLabelValues(MetaKeyTable.String(), result))
+	}
+	rewritten, skipped, failed := count(metaKVRewritten), count(metaKVSkipped), count(metaKVFailed)
+
+	value, err := json.Marshal(&model.TableInfo{ID: tableID, Name: ast.NewCIStr("t")})
+	require.NoError(t, err)
+	require.NoError(t, rewrite(tableID, value))
+	value, err = json.Marshal(&model.TableInfo{ID: filteredTableID, Name: ast.NewCIStr("t")})
+	require.NoError(t, err)
+	require.NoError(t, rewrite(filteredTableID, value))
+	require.Error(t, rewrite(tableID, []byte("{")))
+
+	require.Equal(t, rewritten+1, count(metaKVRewritten))
+	require.Equal(t, skipped+1, count(metaKVSkipped))
+	require.Equal(t, failed+1, count(metaKVFailed))
+}
@@ -0,0 +1,178 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package stream
+
+import (
+	"bytes"
+	"sync"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/tidb/pkg/kv"
+	"github.com/pingcap/tidb/pkg/structure"
+	"github.com/pingcap/tidb/pkg/tablecodec"
+	"github.com/pingcap/tidb/pkg/util/codec"
+)
+
+const (
+	// kvEntryArenaBlockEntries is the count of the entries in a block of KvEntryArena.
+	kvEntryArenaBlockEntries = 1024
+	// kvEntryArenaChunkSize is the size of a byte chunk of KvEntryArena, the larger allocations don't go
+	// through the chunks.
+	kvEntryArenaChunkSize = 64 * 1024
+)
+
+// rewriteScratch is the state of rewriting an entry, it's pooled since there may be millions of meta kv
+// entries to rewrite. It's owned by RewriteKvEntry, nothing in it may be retained after the entry is
+// rewritten.
+type rewriteScratch struct {
+	key          RawMetaKey
+	entry        MetaKVEntry
+	writeCFValue RawWriteCFValue
+
+	// the buffers of decoding and encoding the meta key.
+	rawKey []byte
+	field  []byte
+	name   []byte
+}
+
+var rewriteScratchPool = sync.Pool{
+	New: func() any { return &rewriteScratch{} },
+}
+
+func getRewriteScratch() *rewriteScratch {
+	return rewriteScratchPool.Get().(*rewriteScratch)
+}
+
+func (s *rewriteScratch) release() {
+	s.key = RawMetaKey{}
+	s.entry = MetaKVEntry{}
+	s.writeCFValue = RawWriteCFValue{}
+	rewriteScratchPool.Put(s)
+}
+
+// parseMetaKey is ParseTxnMetaKeyFrom decoding into the buffers of the scratch.
+func (s *rewriteScratch) parseMetaKey(txnKey kv.Key) (*RawMetaKey, error) {
+	lessBuff, rawKey, err := codec.DecodeBytes(txnKey, s.rawKey[:0])
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	s.rawKey = rawKey
+
+	if !bytes.HasPrefix(rawKey, tablecodec.MetaPrefix()) {
+		return nil, errors.New("invalid encoded hash data key prefix")
+	}
+	ek, key, err := codec.DecodeBytes(rawKey[len(tablecodec.MetaPrefix()):], s.name[:0])
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	s.name = key
+	ek, tp, err := codec.DecodeUint(ek)
+	if err != nil {
+		return nil, errors.Trace(err)
+	} else if structure.TypeFlag(tp) != structure.HashData {
+		return nil, errors.Errorf("invalid encoded hash data key flag %c", byte(tp))
+	}
+	_, field, err := codec.DecodeBytes(ek, s.field[:0])
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	s.field = field
+
+	_, ts, err := codec.DecodeUintDesc(lessBuff)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	s.key = RawMetaKey{Key: key, Field: field, Ts: ts}
+	return &s.key, nil
+}
+
+// encodeMetaKey is RawMetaKey.EncodeMetaKey encoding into the arena, the raw key is encoded into the
+// buffer of the scratch.
+func (s *rewriteScratch) encodeMetaKey(arena *KvEntryArena, k *RawMetaKey) kv.Key {
+	rawKey := append(s.rawKey[:0], tablecodec.MetaPrefix()...)
+	rawKey = codec.EncodeBytes(rawKey, k.Key)
+	rawKey = codec.EncodeUint(rawKey, uint64(structure.HashData))
+	rawKey = codec.EncodeBytes(rawKey, k.Field)
+	s.rawKey = rawKey
+
+	encodedKey := arena.alloc(codec.EncodedBytesLength(len(rawKey)) + 8)
+	encodedKey = codec.EncodeBytes(encodedKey, rawKey)
+	return codec.EncodeUintDesc(encodedKey, k.Ts)
+}
+
+// KvEntryArena allocates the rewritten kv entries in batches, to save the allocations of rewriting millions
+// of entries. The entries allocated from the arena are owned by it, they're only valid until the arena is
+// reset or released, so the arena must not be reset before the entries are written out. A nil arena
+// allocates the entries from the heap. It isn't safe for concurrent use.
+type KvEntryArena struct {
+	blocks [][]kv.Entry
+	// block is the index of the block allocating from, entries is the count of the entries allocated from it.
+	block   int
+	entries int
+
+	chunks [][]byte
+	// chunk is the index of the chunk allocating from, the allocated bytes of a chunk are its length.
+	chunk int
+}
+
+var kvEntryArenaPool = sync.Pool{
+	New: func() any { return &KvEntryArena{} },
+}
+
+// AcquireKvEntryArena gets an empty arena from the pool, it should be returned by Release.
+func AcquireKvEntryArena() *KvEntryArena {
+	return kvEntryArenaPool.Get().(*KvEntryArena)
+}
+
+// Release resets the arena and returns it to the pool, the arena and its entries mustn't be used after it.
+func (a *KvEntryArena) Release() {
+	a.Reset()
+	kvEntryArenaPool.Put(a)
+}
+
+// Reset invalidates all the entries allocated from the arena, and reuses their memory.
+func (a *KvEntryArena) Reset() {
+	for _, block := range a.blocks[:min(a.block+1, len(a.blocks))] {
+		clear(block)
+	}
+	a.block, a.entries = 0, 0
+	for i := range a.chunks[:min(a.chunk+1, len(a.chunks))] {
+		a.chunks[i] = a.chunks[i][:0]
+	}
+	a.chunk = 0
+}
+
+// newEntry returns an entry allocated from the arena.
+func (a *KvEntryArena) newEntry(key, value []byte) *kv.Entry {
+	if a == nil {
+		return &kv.Entry{Key: key, Value: value}
+	}
+	if a.block < len(a.blocks) && a.entries == kvEntryArenaBlockEntries {
+		a.block++
+		a.entries = 0
+	}
+	if a.block == len(a.blocks) {
+		a.blocks = append(a.blocks, make([]kv.Entry, kvEntryArenaBlockEntries))
+	}
+	e := &a.blocks[a.block][a.entries]
+	a.entries++
+	e.Key, e.Value = key, value
+	return e
+}
+
+// alloc returns an empty slice whose capacity is n, appending to it within the capacity doesn't allocate.
+func (a *KvEntryArena) alloc(n int) []byte {
+	if a == nil || n > kvEntryArenaChunkSize/4 {
+		return make([]byte, 0, n)
+	}
+	if a.chunk < len(a.chunks) && cap(a.chunks[a.chunk])-len(a.chunks[a.chunk]) < n {
+		a.chunk++
+	}
+	if a.chunk == len(a.chunks) {
+		a.chunks = append(a.chunks, make([]byte, 0, kvEntryArenaChunkSize))
+	}
+	chunk := a.chunks[a.chunk]
+	start := len(chunk)
+	a.chunks[a.chunk] = chunk[:start+n]
+	return chunk[start : start : start+n]
+}
@@ -0,0 +1,166 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package stream
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/pingcap/tidb/pkg/kv"
+	"github.com/pingcap/tidb/pkg/meta"
+	"github.com/pingcap/tidb/pkg/meta/model"
+	"github.com/pingcap/tidb/pkg/parser/ast"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRewriteScratchMetaKey(t *testing.T) {
+	s := getRewriteScratch()
+	defer s.release()
+	arena := AcquireKvEntryArena()
+	defer arena.Release()
+
+	for _, txnKey := range [][]byte{
+		encodeTxnMetaKey([]byte("DBs"), meta.DBkey(1), 400036290571534337),
+		encodeTxnMetaKey(meta.DBkey(1), meta.TableKey(2), 1),
+		encodeTxnMetaKey(meta.DBkey(1), meta.AutoIncrementIDKey(2), 2),
+	} {
+		expected, err := ParseTxnMetaKeyFrom(txnKey)
+		require.NoError(t, err)
+		rawKey, err := s.parseMetaKey(txnKey)
+		require.NoError(t, err)
+		require.Equal(t, expected, rawKey)
+		require.Equal(t, expected.EncodeMetaKey(), s.encodeMetaKey(arena, rawKey))
+		require.Equal(t, expected.EncodeMetaKey(), s.encodeMetaKey(nil, rawKey))
+	}
+
+	_, err := s.parseMetaKey([]byte("invalid"))
+	require.Error(t, err)
+	_, err = s.parseMetaKey(encodeTxnMetaKey(nil, nil, 1)[1:])
+	require.Error(t, err)
+}
+
+func TestKvEntryArena(t *testing.T) {
+	arena := AcquireKvEntryArena()
+	defer arena.Release()
+
+	check := func() {
+		entries := make([]*kv.Entry, 0, 3*kvEntryArenaBlockEntries)
+		for i := range cap(entries) {
+			key := fmt.Appendf(arena.alloc(16), "key-%d", i)
+			entries = append(entries, arena.newEntry(key, []byte("value")))
+		}
+		// the entries allocated before are kept as is.
+		for i, e := range entries {
+			require.Equal(t, fmt.Sprintf("key-%d", i), string(e.Key))
+			require.Equal(t, "value", string(e.Value))
+		}
+	}
+	check()
+	require.Len(t, arena.blocks, 3)
+	chunks := len(arena.chunks)
+
+	// the memory is reused after reset.
+	arena.Reset()
+	check()
+	require.Len(t, arena.blocks, 3)
+	require.Len(t, arena.chunks, chunks)
+
+	// the large allocation doesn't go through the chunks.
+	large := arena.alloc(kvEntryArenaChunkSize)
+	require.Equal(t, kvEntryArenaChunkSize, cap(large))
+	require.Len(t, arena.chunks, chunks)
+
+	// the allocations don't overlap.
+	a := append(arena.alloc(4), "aaaa"...)
+	b := append(arena.alloc(4), "bbbb"...)
+	a = append(a, "overflow"...)
+	require.Equal(t, "aaaaoverflow", string(a))
+	require.Equal(t, "bbbb", string(b))
+}
+
+func TestRewriteKvEntryTo(t *testing.T) {
+	const (
+		dbID    int64 = 1
+		tableID int64 = 2
+	)
+	dbMap := map[UpstreamID]*DBReplace{dbID: NewDBReplace("db", dbID+100)}
+	dbMap[dbID].TableMap[tableID] = NewTableReplace("t", tableID+100)
+	sr := MockEmptySchemasReplace(nil, dbMap)
+
+	dbValue, err := json.Marshal(&model.DBInfo{ID: dbID, Name: ast.NewCIStr("db")})
+	require.NoError(t, err)
+	tableValue, err := json.Marshal(&model.TableInfo{ID: tableID, Name: ast.NewCIStr("t")})
+	require.NoError(t, err)
+	writeValue := (&RawWriteCFValue{t: WriteTypePut, startTs: 1, shortValue: dbValue}).EncodeTo()
+	cases := []struct {
+		entry *kv.Entry
+		cf    string
+	}{
+		{&kv.Entry{Key: encodeTxnMetaKey([]byte("DBs"), meta.DBkey(dbID), 1), Value: dbValue}, DefaultCF},
+		{&kv.Entry{Key: encodeTxnMetaKey(meta.DBkey(dbID), meta.TableKey(tableID), 1), Value: tableValue}, DefaultCF},
+		{&kv.Entry{Key: encodeTxnMetaKey([]byte("DBs"), meta.DBkey(dbID), 2), Value: writeValue}, WriteCF},
+		{&kv.Entry{Key: encodeTxnMetaKey(meta.DBkey(dbID), meta.AutoIncrementIDKey(tableID), 3), Value: []byte("1")}, DefaultCF},
+	}
+
+	arena := AcquireKvEntryArena()
+	defer arena.Release()
+	expected := make([]*kv.Entry, 0, len(cases))
+	rewritten := make([]*kv.Entry, 0, len(cases))
+	for _, c := range cases {
+		e, err := sr.RewriteKvEntry(c.entry, c.cf)
+		require.NoError(t, err)
+		require.NotNil(t, e)
+		expected = append(expected, e)
+		e, err = sr.RewriteKvEntryTo(arena, c.entry, c.cf)
+		require.NoError(t, err)
+		rewritten = append(rewritten, e)
+	}
+	// the entries allocated from the arena are valid until it's reset.
+	require.Equal(t, expected, rewritten)
+}
+
+func BenchmarkRewriteKvEntry(b *testing.B) {
+	const tables = 10000
+	dbMap := map[UpstreamID]*DBReplace{1: NewDBReplace("db", 101)}
+	entries := make([]*kv.Entry, 0, tables)
+	for i := range int64(tables) {
+		dbMap[1].TableMap[i+2] = NewTableReplace(fmt.Sprintf("t%d", i), i+10002)
+		entries = append(entries, &kv.Entry{
+			Key:   encodeTxnMetaKey(meta.DBkey(1), meta.AutoIncrementIDKey(i+2), 1),
+			Value: []byte("1"),
+		})
+	}
+	sr := MockEmptySchemasReplace(nil, dbMap)
+
+	var size int
+	b.Run("heap", func(b *testing.B) {
+		b.ReportAllocs()
+		for range b.N {
+			size = 0
+			for _, e := range entries {
+				newEntry, err := sr.RewriteKvEntry(e, DefaultCF)
+				if err != nil {
+					b.Fatal(err)
+				}
+				size += len(newEntry.Key)
+			}
+		}
+	})
+	b.Run("arena", func(b *testing.B) {
+		b.ReportAllocs()
+		arena := AcquireKvEntryArena()
+		defer arena.Release()
+		for range b.N {
+			size = 0
+			for _, e := range entries {
+				newEntry, err := sr.RewriteKvEntryTo(arena, e, DefaultCF)
+				if err != nil {
+					b.Fatal(err)
+				}
+				size += len(newEntry.Key)
+			}
+			arena.Reset()
+		}
+	})
+}
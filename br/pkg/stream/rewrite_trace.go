@@ -0,0 +1,56 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package stream
+
+import (
+	"bytes"
+	"encoding/hex"
+
+	"github.com/pingcap/log"
+	"go.uber.org/zap"
+)
+
+// rewriteTracer logs the decisions of rewriting a kv entry matching the DebugKeyPrefix of the
+// SchemasReplace. The nil tracer logs nothing, so it's called unconditionally.
+type rewriteTracer struct {
+	key []byte
+	cf  string
+}
+
+// traceKey returns the tracer of the key, or nil if the key doesn't match the DebugKeyPrefix.
+func (sr *SchemasReplace) traceKey(key []byte, cf string) *rewriteTracer {
+	if len(sr.DebugKeyPrefix) == 0 || !bytes.HasPrefix(key, sr.DebugKeyPrefix) {
+		return nil
+	}
+	return &rewriteTracer{key: key, cf: cf}
+}
+
+// trace logs a decision at INFO level, so it's visible without lowering the log level of the restore.
+func (t *rewriteTracer) trace(msg string, fields ...zap.Field) {
+	if t == nil {
+		return
+	}
+	// the fields are copied rather than appended to, so they don't escape in the hot path of a nil tracer.
+	all := make([]zap.Field, 0, len(fields)+2)
+	all = append(all, zap.String("key", hex.EncodeToString(t.key)), zap.String("cf", t.cf))
+	all = append(all, fields...)
+	log.Info("[rewrite-trace] "+msg, all...)
+}
+
+// traceRawKey logs the fields of the decoded meta key.
+func (t *rewriteTracer) traceRawKey(msg string, rawKey *RawMetaKey) {
+	if t == nil {
+		return
+	}
+	t.trace(msg, zap.ByteString("meta-key", rawKey.Key), zap.ByteString("meta-field", rawKey.Field),
+		zap.Uint64("ts", rawKey.Ts))
+}
+
+// traceNewEntry logs the rewritten entry, the key is encoded only if it's traced.
+func (t *rewriteTracer) traceNewEntry(newKey, newValue []byte) {
+	if t == nil {
+		return
+	}
+	t.trace("write the rewritten entry", zap.String("new-key", hex.EncodeToString(newKey)),
+		zap.Int("new-value-len", len(newValue)))
+}
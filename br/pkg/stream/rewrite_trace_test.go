@@ -0,0 +1,77 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package stream
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/pingcap/log"
+	"github.com/pingcap/tidb/pkg/meta"
+	"github.com/pingcap/tidb/pkg/meta/model"
+	"github.com/pingcap/tidb/pkg/parser/ast"
+	filter "github.com/pingcap/tidb/pkg/util/table-filter"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestTraceRewriteKey(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	defer log.ReplaceGlobals(zap.New(core), nil)()
+
+	const dbID, tableID, filteredTableID int64 = 1, 2, 3
+	dbMap := map[UpstreamID]*DBReplace{dbID: NewDBReplace("db", dbID+100)}
+	dbMap[dbID].TableMap[tableID] = NewTableReplace("t", tableID+100)
+	sr := NewSchemasReplace(dbMap, nil, 0, filter.All(), nil)
+	tableKey := func(id int64) []byte {
+		return encodeTxnMetaKey(meta.DBkey(dbID), meta.TableKey(id), 1)
+	}
+	tableValue := func(id int64) []byte {
+		value, err := json.Marshal(&model.TableInfo{ID: id, Name: ast.NewCIStr("t")})
+		require.NoError(t, err)
+		return value
+	}
+	traces := func() []string {
+		messages := make([]string, 0)
+		for _, entry := range logs.TakeAll() {
+			if strings.HasPrefix(entry.Message, "[rewrite-trace]") {
+				messages = append(messages, entry.Message)
+			}
+		}
+		return messages
+	}
+
+	// nothing is traced without the prefix.
+	_, err := sr.rewriteMetaValue(tableKey(tableID), tableValue(tableID))
+	require.NoError(t, err)
+	require.Empty(t, traces())
+
+	sr.DebugKeyPrefix = tableKey(filteredTableID)
+	_, err = sr.rewriteMetaValue(tableKey(tableID), tableValue(tableID))
+	require.NoError(t, err)
+	require.Empty(t, traces())
+	newValue, err := sr.rewriteMetaValue(tableKey(filteredTableID), tableValue(filteredTableID))
+	require.NoError(t, err)
+	require.Nil(t, newValue)
+	require.Equal(t, []string{
+		"[rewrite-trace] rewrite the entry",
+		"[rewrite-trace] parse the meta key",
+		"[rewrite-trace] decode the entry",
+		"[rewrite-trace] skip the table missing in the id maps",
+		"[rewrite-trace] the rule skips the entry",
+	}, traces())
+
+	sr.DebugKeyPrefix = tableKey(tableID)
+	_, err = sr.rewriteMetaValue(tableKey(tableID), tableValue(tableID))
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"[rewrite-trace] rewrite the entry",
+		"[rewrite-trace] parse the meta key",
+		"[rewrite-trace] decode the entry",
+		"[rewrite-trace] remap the table",
+		"[rewrite-trace] encode the rewritten entry",
+		"[rewrite-trace] write the rewritten entry",
+	}, traces())
+}
@@ -0,0 +1,192 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package stream
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/pingcap/errors"
+	berrors "github.com/pingcap/tidb/br/pkg/errors"
+	"github.com/pingcap/tidb/pkg/kv"
+	"github.com/pingcap/tidb/pkg/util/codec"
+)
+
+// RollbackRecordPolicy is how the rollback and lock records in the write CF of the meta kv files are
+// handled by the log restore. They don't carry any value, a rollback record marks a transaction is rolled
+// back and a lock record marks a key is locked by a pessimistic or `SELECT FOR UPDATE` transaction.
+type RollbackRecordPolicy string
+
+const (
+	// RollbackRecordApply writes the records into the target cluster like the other records.
+	RollbackRecordApply RollbackRecordPolicy = "apply"
+	// RollbackRecordDrop skips the records.
+	RollbackRecordDrop RollbackRecordPolicy = "drop"
+	// RollbackRecordCollect skips the records and writes them into the diagnostics file by the
+	// RollbackRecordCollector instead.
+	RollbackRecordCollect RollbackRecordPolicy = "collect"
+)
+
+// ParseRollbackRecordPolicy parses the RollbackRecordPolicy, the empty string is RollbackRecordApply.
+func ParseRollbackRecordPolicy(s string) (RollbackRecordPolicy, error) {
+	switch policy := RollbackRecordPolicy(strings.ToLower(s)); policy {
+	case "":
+		return RollbackRecordApply, nil
+	case RollbackRecordApply, RollbackRecordDrop, RollbackRecordCollect:
+		return policy, nil
+	default:
+		return "", errors.Annotatef(berrors.ErrInvalidArgument, "unknown rollback record policy %q, "+
+			"should be one of %q, %q and %q", s, RollbackRecordApply, RollbackRecordDrop, RollbackRecordCollect)
+	}
+}
+
+// isRollbackRecord checks whether the value in write CF is a rollback or lock record by its write type.
+func isRollbackRecord(value []byte) bool {
+	return len(value) > 0 && (value[0] == WriteTypeRollback || value[0] == WriteTypeLock)
+}
+
+// RollbackRecordCollector writes the collected rollback and lock records into a local file, a StreamKVInfo
+// in JSON per line, the same as the output of `br stream search`. It's safe for concurrent use.
+type RollbackRecordCollector struct {
+	mu    sync.Mutex
+	file  *os.File
+	w     *bufio.Writer
+	count uint64
+	// collected are the records collected by the previous runs, the meta kv files after the checkpoint
+	// are replayed by the resumed restore, so their records are collected again.
+	collected map[string]struct{}
+}
+
+// NewRollbackRecordCollector creates the collector writing into the file. The existing file is truncated,
+// unless resume is set for a restore resumed from the checkpoint, whose records are appended to the ones
+// collected by the previous run, skipping the ones already in the file.
+func NewRollbackRecordCollector(path string, resume bool) (*RollbackRecordCollector, error) {
+	flag := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if resume {
+		flag = os.O_RDWR | os.O_CREATE
+	}
+	f, err := os.OpenFile(path, flag, 0o644)
+	if err != nil {
+		return nil, errors.Annotatef(err, "failed to open the rollback record file %s", path)
+	}
+	c := &RollbackRecordCollector{file: f, w: bufio.NewWriter(f), collected: make(map[string]struct{})}
+	if resume {
+		if err := c.loadCollected(); err != nil {
+			_ = f.Close()
+			return nil, errors.Trace(err)
+		}
+	}
+	return c, nil
+}
+
+// loadCollected reads the records collected by the previous runs, the partial record written by a crashed
+// run is truncated, and moves to the end of the file for appending.
+func (c *RollbackRecordCollector) loadCollected() error {
+	data, err := io.ReadAll(c.file)
+	if err != nil {
+		return errors.Annotatef(err, "failed to read the rollback record file %s", c.file.Name())
+	}
+	end := bytes.LastIndexByte(data, '\n') + 1
+	for _, line := range bytes.Split(data[:end], []byte{'\n'}) {
+		if len(line) > 0 {
+			c.collected[string(line)] = struct{}{}
+		}
+	}
+	if err := c.file.Truncate(int64(end)); err != nil {
+		return errors.Annotatef(err, "failed to truncate the rollback record file %s", c.file.Name())
+	}
+	_, err = c.file.Seek(int64(end), io.SeekStart)
+	return errors.Trace(err)
+}
+
+// Collect writes the record, the key is the encoded key with the commit ts in the meta kv files.
+func (c *RollbackRecordCollector) Collect(key kv.Key, value []byte) error {
+	if len(key) < 8 {
+		return errors.Annotatef(berrors.ErrInvalidArgument, "invalid write cf key %X", key)
+	}
+	_, commitTS, err := codec.DecodeUintDesc(key[len(key)-8:])
+	if err != nil {
+		return errors.Trace(err)
+	}
+	_, rawKey, err := codec.DecodeBytes(key[:len(key)-8], nil)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	var writeValue RawWriteCFValue
+	if err := writeValue.ParseFrom(value); err != nil {
+		return errors.Trace(err)
+	}
+	line, err := json.Marshal(&StreamKVInfo{
+		Key:       strings.ToUpper(hex.EncodeToString(rawKey)),
+		WriteType: writeValue.GetWriteType(),
+		StartTs:   writeValue.GetStartTs(),
+		CommitTs:  commitTS,
+		CFName:    WriteCF,
+	})
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.collected[string(line)]; ok {
+		return nil
+	}
+	c.collected[string(line)] = struct{}{}
+	if _, err := c.w.Write(append(line, '\n')); err != nil {
+		return errors.Annotatef(err, "failed to write the rollback record file %s", c.file.Name())
+	}
+	c.count++
+	return nil
+}
+
+// Count returns the number of the records collected by this run.
+func (c *RollbackRecordCollector) Count() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.count
+}
+
+// Close flushes the records and closes the file.
+func (c *RollbackRecordCollector) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.w.Flush(); err != nil {
+		_ = c.file.Close()
+		return errors.Annotatef(err, "failed to write the rollback record file %s", c.file.Name())
+	}
+	return errors.Trace(c.file.Close())
+}
+
+// filterRollbackRecord returns true if the entry is a rollback or lock record skipped by the
+// RollbackRecordPolicy, the record is collected under RollbackRecordCollect.
+func (sr *SchemasReplace) filterRollbackRecord(e *kv.Entry, cf string, tracer *rewriteTracer) (bool, error) {
+	if cf != WriteCF || !isRollbackRecord(e.Value) {
+		return false, nil
+	}
+	switch sr.RollbackRecordPolicy {
+	case RollbackRecordDrop:
+		tracer.trace("drop the rollback or lock record")
+	case RollbackRecordCollect:
+		if err := sr.RollbackRecordCollector.Collect(e.Key, e.Value); err != nil {
+			return false, errors.Trace(err)
+		}
+		tracer.trace("collect the rollback or lock record")
+	default:
+		return false, nil
+	}
+	sr.skippedRollbackRecords.Add(1)
+	return true, nil
+}
+
+// SkippedRollbackRecords returns the number of the rollback and lock records skipped by the
+// RollbackRecordPolicy, including the collected ones.
+func (sr *SchemasReplace) SkippedRollbackRecords() uint64 {
+	return sr.skippedRollbackRecords.Load()
+}
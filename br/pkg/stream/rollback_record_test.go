@@ -0,0 +1,117 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package stream
+
+import (
+	"bufio"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	berrors "github.com/pingcap/tidb/br/pkg/errors"
+	"github.com/pingcap/tidb/pkg/kv"
+	"github.com/pingcap/tidb/pkg/meta"
+	"github.com/pingcap/tidb/pkg/tablecodec"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRollbackRecordPolicy(t *testing.T) {
+	const (
+		dbID    int64  = 1
+		startTS uint64 = 400036290571534337
+	)
+	dbMap := map[UpstreamID]*DBReplace{dbID: NewDBReplace("db", dbID+100)}
+	rollbackKey := encodeTxnMetaKey([]byte("DBs"), meta.DBkey(dbID), startTS)
+	rollback := &kv.Entry{Key: rollbackKey, Value: (&RawWriteCFValue{t: WriteTypeRollback, startTs: startTS}).EncodeTo()}
+	lock := &kv.Entry{
+		Key:   encodeTxnMetaKey([]byte("DBs"), meta.DBkey(dbID), startTS+11),
+		Value: (&RawWriteCFValue{t: WriteTypeLock, startTs: startTS + 10}).EncodeTo(),
+	}
+
+	// the records are applied by default.
+	sr := MockEmptySchemasReplace(nil, dbMap)
+	for _, e := range []*kv.Entry{rollback, lock} {
+		newEntry, err := sr.RewriteKvEntry(e, WriteCF)
+		require.NoError(t, err)
+		require.NotNil(t, newEntry)
+	}
+	// the value in default CF isn't a write record even if it starts with the same byte.
+	_, err := sr.RewriteKvEntry(&kv.Entry{Key: rollbackKey, Value: []byte("R")}, DefaultCF)
+	require.Error(t, err)
+
+	sr = MockEmptySchemasReplace(nil, dbMap)
+	sr.RollbackRecordPolicy = RollbackRecordDrop
+	for _, e := range []*kv.Entry{rollback, lock} {
+		newEntry, err := sr.RewriteKvEntry(e, WriteCF)
+		require.NoError(t, err)
+		require.Nil(t, newEntry)
+	}
+	require.EqualValues(t, 2, sr.SkippedRollbackRecords())
+
+	path := filepath.Join(t.TempDir(), "rollback.jsonl")
+	// the file is truncated by the new restore.
+	require.NoError(t, os.WriteFile(path, []byte("stale\n"), 0o644))
+	collector, err := NewRollbackRecordCollector(path, false)
+	require.NoError(t, err)
+	sr = MockEmptySchemasReplace(nil, dbMap)
+	sr.RollbackRecordPolicy = RollbackRecordCollect
+	sr.RollbackRecordCollector = collector
+	newEntry, err := sr.RewriteKvEntry(rollback, WriteCF)
+	require.NoError(t, err)
+	require.Nil(t, newEntry)
+	// the records of the keys out of the meta db keys are skipped without being collected.
+	_, err = sr.RewriteKvEntry(&kv.Entry{
+		Key:   encodeTxnMetaKey([]byte("Policies"), []byte("policy"), startTS),
+		Value: (&RawWriteCFValue{t: WriteTypeRollback, startTs: startTS}).EncodeTo(),
+	}, WriteCF)
+	require.NoError(t, err)
+	require.EqualValues(t, 1, collector.Count())
+	require.NoError(t, collector.Close())
+
+	// the partial record written by the crashed run is truncated.
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o644)
+	require.NoError(t, err)
+	_, err = f.WriteString(`{"key":`)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	// the records are appended by the restore resumed from the checkpoint, the replayed ones are skipped.
+	collector, err = NewRollbackRecordCollector(path, true)
+	require.NoError(t, err)
+	sr.RollbackRecordCollector = collector
+	for _, e := range []*kv.Entry{rollback, lock} {
+		newEntry, err = sr.RewriteKvEntry(e, WriteCF)
+		require.NoError(t, err)
+		require.Nil(t, newEntry)
+	}
+	require.EqualValues(t, 1, collector.Count())
+	require.NoError(t, collector.Close())
+
+	f, err = os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+	var records []StreamKVInfo
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var record StreamKVInfo
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &record))
+		records = append(records, record)
+	}
+	rawKey := strings.ToUpper(hex.EncodeToString(tablecodec.EncodeMetaKey([]byte("DBs"), meta.DBkey(dbID))))
+	require.Equal(t, []StreamKVInfo{
+		{Key: rawKey, WriteType: WriteTypeRollback, StartTs: startTS, CommitTs: startTS, CFName: WriteCF},
+		{Key: rawKey, WriteType: WriteTypeLock, StartTs: startTS + 10, CommitTs: startTS + 11, CFName: WriteCF},
+	}, records)
+
+	policy, err := ParseRollbackRecordPolicy("")
+	require.NoError(t, err)
+	require.Equal(t, RollbackRecordApply, policy)
+	policy, err = ParseRollbackRecordPolicy("Collect")
+	require.NoError(t, err)
+	require.Equal(t, RollbackRecordCollect, policy)
+	_, err = ParseRollbackRecordPolicy("keep")
+	require.True(t, berrors.ErrInvalidArgument.Equal(err))
+}
@@ -0,0 +1,361 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package stream
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/pingcap/errors"
+	backuppb "github.com/pingcap/kvproto/pkg/brpb"
+	"github.com/pingcap/log"
+	berrors "github.com/pingcap/tidb/br/pkg/errors"
+	"github.com/pingcap/tidb/br/pkg/storage"
+	"github.com/pingcap/tidb/pkg/kv"
+	"github.com/pingcap/tidb/pkg/meta"
+	"github.com/pingcap/tidb/pkg/meta/model"
+	"go.uber.org/zap"
+)
+
+// schemaSearchWorkers is the number of workers reading the metadata of the log backup.
+const schemaSearchWorkers = 128
+
+// MatchSchemaName returns whether the table has the name, or has a column with the name, the name is
+// case-insensitive. The returned string tells which one is matched.
+func MatchSchemaName(info *model.TableInfo, name string) (string, bool) {
+	if info.Name.L == strings.ToLower(name) {
+		return "table", true
+	}
+	for _, col := range info.Columns {
+		if col.Name.L == strings.ToLower(name) {
+			return fmt.Sprintf("column %s", col.Name.O), true
+		}
+	}
+	return "", false
+}
+
+// SchemaEvent is a change of a table found in the meta kv files of the log backup.
+type SchemaEvent struct {
+	TS      uint64
+	DBID    int64
+	TableID int64
+	// Info is the table info after the change, it is nil if the table is dropped at TS.
+	Info *model.TableInfo
+}
+
+// SchemaSearchResult is the result of searching the schema in the log backup.
+type SchemaSearchResult struct {
+	// DBNames are the names of the databases written in the log backup, keyed by the ID.
+	DBNames map[int64]string
+	// Events are sorted by the ts.
+	Events []SchemaEvent
+	// Files are the data files of the tables, keyed by the physical table ID.
+	Files map[int64][]*backuppb.DataFileInfo
+}
+
+type schemaValueKey struct {
+	tableID int64
+	startTs uint64
+}
+
+type schemaWrite struct {
+	dbID     int64
+	tableID  int64
+	startTs  uint64
+	commitTs uint64
+	info     *model.TableInfo
+}
+
+// SchemaSearch is used for searching the history of the tables by the table or column name from the
+// meta kv files of the log backup.
+type SchemaSearch struct {
+	storage storage.ExternalStorage
+	helper  *MetadataHelper
+	name    string
+	startTs uint64
+	endTs   uint64
+
+	dbNames map[int64]string
+	// values are the matched table infos in the default cf.
+	values map[schemaValueKey]*model.TableInfo
+	puts   []schemaWrite
+	drops  []schemaWrite
+}
+
+// NewSchemaSearch creates an instance of SchemaSearch, an empty name matches all the tables.
+func NewSchemaSearch(storage storage.ExternalStorage, helper *MetadataHelper, name string) *SchemaSearch {
+	return &SchemaSearch{
+		storage: storage,
+		helper:  helper,
+		name:    name,
+		dbNames: make(map[int64]string),
+		values:  make(map[schemaValueKey]*model.TableInfo),
+	}
+}
+
+// SetStartTS set start timestamp searched from
+func (s *SchemaSearch) SetStartTS(startTs uint64) {
+	s.startTs = startTs
+}
+
+// SetEndTs set end timestamp searched to
+func (s *SchemaSearch) SetEndTs(endTs uint64) {
+	s.endTs = endTs
+}
+
+func (s *SchemaSearch) inRange(minTs, maxTs uint64) bool {
+	return (s.startTs == 0 || maxTs >= s.startTs) && (s.endTs == 0 || minTs <= s.endTs)
+}
+
+// Search searches the meta kv files, and then the data files of the matched tables. knownIDs are the
+// IDs of the matched tables found elsewhere, e.g. in the snapshot backup, so that their changes are
+// searched even if the name doesn't match after the changes.
+func (s *SchemaSearch) Search(ctx context.Context, knownIDs map[int64]struct{}) (*SchemaSearchResult, error) {
+	if err := s.searchMetaFiles(ctx); err != nil {
+		return nil, errors.Trace(err)
+	}
+	events := s.events(knownIDs)
+	physicalIDs := make(map[int64]struct{}, len(knownIDs))
+	for id := range knownIDs {
+		physicalIDs[id] = struct{}{}
+	}
+	for _, event := range events {
+		physicalIDs[event.TableID] = struct{}{}
+		if event.Info == nil || event.Info.GetPartitionInfo() == nil {
+			continue
+		}
+		for _, def := range event.Info.GetPartitionInfo().Definitions {
+			physicalIDs[def.ID] = struct{}{}
+		}
+	}
+	dataFiles, err := s.collectFiles(ctx, func(file *backuppb.DataFileInfo) bool {
+		_, ok := physicalIDs[file.TableId]
+		return !file.IsMeta && ok
+	})
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	files := make(map[int64][]*backuppb.DataFileInfo)
+	for _, file := range dataFiles {
+		files[file.TableId] = append(files[file.TableId], file)
+	}
+	for _, fs := range files {
+		sort.Slice(fs, func(i, j int) bool { return fs[i].MinTs < fs[j].MinTs })
+	}
+	return &SchemaSearchResult{DBNames: s.dbNames, Events: events, Files: files}, nil
+}
+
+// SearchEvents only searches the meta kv files for the changes of the matched tables, the data files
+// aren't collected.
+func (s *SchemaSearch) SearchEvents(ctx context.Context) (*SchemaSearchResult, error) {
+	if err := s.searchMetaFiles(ctx); err != nil {
+		return nil, errors.Trace(err)
+	}
+	return &SchemaSearchResult{DBNames: s.dbNames, Events: s.events(nil)}, nil
+}
+
+func (s *SchemaSearch) searchMetaFiles(ctx context.Context) error {
+	metaFiles, err := s.collectFiles(ctx, func(file *backuppb.DataFileInfo) bool { return file.IsMeta })
+	if err != nil {
+		return errors.Trace(err)
+	}
+	sort.Slice(metaFiles, func(i, j int) bool { return metaFiles[i].MinTs < metaFiles[j].MinTs })
+	// the files of the metadata v2 are read by range from the cached group file.
+	refs := make(map[string]int)
+	for _, file := range metaFiles {
+		if file.RangeLength > 0 {
+			refs[file.Path]++
+		}
+	}
+	for path, ref := range refs {
+		s.helper.InitCacheEntry(path, ref)
+	}
+	for _, file := range metaFiles {
+		if err := s.searchFromMetaFile(ctx, file); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return nil
+}
+
+// collectFiles collects the files in the ts range that match the filter from the metadata. The path
+// of the file in the metadata v2 is set to the path of its group.
+func (s *SchemaSearch) collectFiles(
+	ctx context.Context,
+	filter func(*backuppb.DataFileInfo) bool,
+) ([]*backuppb.DataFileInfo, error) {
+	var mu sync.Mutex
+	files := make([]*backuppb.DataFileInfo, 0)
+	err := FastUnmarshalMetaData(ctx, s.storage, schemaSearchWorkers, func(path string, raw []byte) error {
+		m, err := s.helper.ParseToMetadata(raw)
+		if err != nil {
+			return errors.Annotatef(err, "failed to parse metadata of file %s", path)
+		}
+		if !s.inRange(m.MinTs, m.MaxTs) {
+			return nil
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		for _, group := range m.FileGroups {
+			for _, file := range group.DataFilesInfo {
+				if !s.inRange(file.MinTs, file.MaxTs) || !filter(file) {
+					continue
+				}
+				if m.MetaVersion > backuppb.MetaVersion_V1 {
+					file.Path = group.Path
+				}
+				files = append(files, file)
+			}
+		}
+		return nil
+	})
+	return files, errors.Trace(err)
+}
+
+func (s *SchemaSearch) searchFromMetaFile(ctx context.Context, file *backuppb.DataFileInfo) error {
+	buff, err := s.helper.ReadFile(ctx, file.Path, file.RangeOffset, file.RangeLength, file.CompressionType,
+		s.storage, file.FileEncryptionInfo)
+	if err != nil {
+		return errors.Annotatef(err, "read meta kv file error, file: %s", file.Path)
+	}
+	if checksum := sha256.Sum256(buff); !bytes.Equal(checksum[:], file.GetSha256()) {
+		return berrors.ErrInvalidMetaFile.GenWithStackByArgs(fmt.Sprintf(
+			"checksum mismatch expect %x, got %x", file.GetSha256(), checksum[:]))
+	}
+
+	iter := NewEventIterator(buff)
+	for iter.Valid() {
+		iter.Next()
+		if err := iter.GetError(); err != nil {
+			return errors.Trace(err)
+		}
+		if err := s.observe(iter.Key(), iter.Value(), file.Cf); err != nil {
+			return errors.Annotatef(err, "failed to parse meta kv, file: %s", file.Path)
+		}
+	}
+	log.Info("finish search meta kv file", zap.String("file", file.Path), zap.String("cf", file.Cf))
+	return nil
+}
+
+// observe collects the database names and the changes of the tables from a meta kv entry.
+func (s *SchemaSearch) observe(key, value []byte, cf string) error {
+	if !IsMetaDBKey(key) || len(value) == 0 {
+		return nil
+	}
+	rawKey, err := ParseTxnMetaKeyFrom(key)
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	if meta.IsDBkey(rawKey.Field) {
+		dbValue, err := extractValue(&kv.Entry{Key: key, Value: value}, cf)
+		if err != nil || dbValue == nil {
+			return errors.Trace(err)
+		}
+		dbInfo := new(model.DBInfo)
+		if err := json.Unmarshal(dbValue, dbInfo); err != nil {
+			return errors.Trace(err)
+		}
+		s.dbNames[dbInfo.ID] = dbInfo.Name.O
+		return nil
+	}
+	if !meta.IsDBkey(rawKey.Key) || !meta.IsTableKey(rawKey.Field) {
+		return nil
+	}
+	dbID, err := meta.ParseDBKey(rawKey.Key)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	tableID, err := meta.ParseTableKey(rawKey.Field)
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	switch cf {
+	case DefaultCF:
+		// the ts of the default cf is the start ts, the commit ts is greater than it.
+		if s.endTs > 0 && rawKey.Ts > s.endTs {
+			return nil
+		}
+		info, ok, err := s.matchTableInfo(value)
+		if err != nil || !ok {
+			return errors.Trace(err)
+		}
+		s.values[schemaValueKey{tableID: tableID, startTs: rawKey.Ts}] = info
+	case WriteCF:
+		if !s.inRange(rawKey.Ts, rawKey.Ts) {
+			return nil
+		}
+		writeValue := new(RawWriteCFValue)
+		if err := writeValue.ParseFrom(value); err != nil {
+			return errors.Trace(err)
+		}
+		write := schemaWrite{dbID: dbID, tableID: tableID, startTs: writeValue.GetStartTs(), commitTs: rawKey.Ts}
+		switch writeValue.GetWriteType() {
+		case WriteTypeDelete:
+			s.drops = append(s.drops, write)
+		case WriteTypePut:
+			if writeValue.HasShortValue() {
+				info, ok, err := s.matchTableInfo(writeValue.GetShortValue())
+				if err != nil || !ok {
+					return errors.Trace(err)
+				}
+				write.info = info
+			}
+			s.puts = append(s.puts, write)
+		}
+	}
+	return nil
+}
+
+func (s *SchemaSearch) matchTableInfo(value []byte) (*model.TableInfo, bool, error) {
+	info := new(model.TableInfo)
+	if err := json.Unmarshal(value, info); err != nil {
+		return nil, false, errors.Trace(err)
+	}
+	if s.name == "" {
+		return info, true, nil
+	}
+	_, ok := MatchSchemaName(info, s.name)
+	return info, ok, nil
+}
+
+// events joins the writes with the values, and returns the changes of the matched tables.
+func (s *SchemaSearch) events(knownIDs map[int64]struct{}) []SchemaEvent {
+	matchedIDs := make(map[int64]struct{}, len(knownIDs))
+	for id := range knownIDs {
+		matchedIDs[id] = struct{}{}
+	}
+	events := make([]SchemaEvent, 0)
+	for _, put := range s.puts {
+		info := put.info
+		if info == nil {
+			info = s.values[schemaValueKey{tableID: put.tableID, startTs: put.startTs}]
+		}
+		if info == nil {
+			continue
+		}
+		matchedIDs[put.tableID] = struct{}{}
+		events = append(events, SchemaEvent{TS: put.commitTs, DBID: put.dbID, TableID: put.tableID, Info: info})
+	}
+	for _, drop := range s.drops {
+		if _, ok := matchedIDs[drop.tableID]; ok {
+			events = append(events, SchemaEvent{TS: drop.commitTs, DBID: drop.dbID, TableID: drop.tableID})
+		}
+	}
+	// the table moved to another database is dropped from the old one and put into the new one at the
+	// same ts, so the drop goes first.
+	sort.SliceStable(events, func(i, j int) bool {
+		if events[i].TS != events[j].TS {
+			return events[i].TS < events[j].TS
+		}
+		return events[i].Info == nil && events[j].Info != nil
+	})
+	return events
+}
@@ -0,0 +1,96 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package stream
+
+import (
+	"testing"
+
+	"github.com/pingcap/tidb/pkg/meta"
+	"github.com/pingcap/tidb/pkg/meta/model"
+	"github.com/pingcap/tidb/pkg/parser/ast"
+	"github.com/pingcap/tidb/pkg/util/codec"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchSchemaName(t *testing.T) {
+	info := &model.TableInfo{
+		Name:    ast.NewCIStr("Orders"),
+		Columns: []*model.ColumnInfo{{Name: ast.NewCIStr("id")}, {Name: ast.NewCIStr("Customer")}},
+	}
+	matched, ok := MatchSchemaName(info, "orders")
+	require.True(t, ok)
+	require.Equal(t, "table", matched)
+	matched, ok = MatchSchemaName(info, "CUSTOMER")
+	require.True(t, ok)
+	require.Equal(t, "column Customer", matched)
+	_, ok = MatchSchemaName(info, "price")
+	require.False(t, ok)
+}
+
+func TestSchemaSearchObserve(t *testing.T) {
+	var (
+		dbID    int64 = 2
+		tableID int64 = 100
+		newID   int64 = 104
+		// the ts is large enough to be encoded as a valid write cf value.
+		base uint64 = 400036290571534337
+	)
+	writeValue := func(t byte, startTs uint64) []byte {
+		return codec.EncodeUvarint([]byte{t}, base+startTs)
+	}
+	tableKey := func(id int64, ts uint64) []byte {
+		return encodeTxnMetaKey(meta.DBkey(dbID), meta.TableKey(id), base+ts)
+	}
+
+	s := NewSchemaSearch(nil, nil, "t1")
+	s.SetStartTS(base + 10)
+	dbValue, err := produceDBInfoValue("test", dbID)
+	require.NoError(t, err)
+	require.NoError(t, s.observe(encodeTxnMetaKey([]byte("DBs"), meta.DBkey(dbID), 5), dbValue, DefaultCF))
+
+	// the table is created, and then truncated, i.e. dropped and created with the new ID.
+	tableValue, err := produceTableInfoValue("t1", tableID)
+	require.NoError(t, err)
+	require.NoError(t, s.observe(tableKey(tableID, 11), tableValue, DefaultCF))
+	require.NoError(t, s.observe(tableKey(tableID, 12), writeValue(WriteTypePut, 11), WriteCF))
+	require.NoError(t, s.observe(tableKey(tableID, 21), writeValue(WriteTypeDelete, 20), WriteCF))
+	newValue, err := produceTableInfoValue("t1", newID)
+	require.NoError(t, err)
+	require.NoError(t, s.observe(tableKey(newID, 20), newValue, DefaultCF))
+	require.NoError(t, s.observe(tableKey(newID, 21), writeValue(WriteTypePut, 20), WriteCF))
+	// the other tables and the writes out of the range are ignored.
+	otherValue, err := produceTableInfoValue("t2", 102)
+	require.NoError(t, err)
+	require.NoError(t, s.observe(tableKey(102, 14), otherValue, DefaultCF))
+	require.NoError(t, s.observe(tableKey(102, 15), writeValue(WriteTypePut, 14), WriteCF))
+	require.NoError(t, s.observe(tableKey(102, 16), writeValue(WriteTypeDelete, 15), WriteCF))
+	require.NoError(t, s.observe(tableKey(tableID, 7), tableValue, DefaultCF))
+	require.NoError(t, s.observe(tableKey(tableID, 8), writeValue(WriteTypePut, 7), WriteCF))
+
+	require.Equal(t, map[int64]string{dbID: "test"}, s.dbNames)
+	events := s.events(nil)
+	require.Len(t, events, 3)
+	require.Equal(t, base+12, events[0].TS)
+	require.Equal(t, tableID, events[0].TableID)
+	require.Equal(t, "t1", events[0].Info.Name.O)
+	// the drop goes first at the same ts.
+	require.Equal(t, base+21, events[1].TS)
+	require.Equal(t, tableID, events[1].TableID)
+	require.Nil(t, events[1].Info)
+	require.Equal(t, newID, events[2].TableID)
+	require.Equal(t, dbID, events[2].DBID)
+
+	// the drop of the known table is found even if the name doesn't match.
+	events = s.events(map[int64]struct{}{102: {}})
+	require.Len(t, events, 4)
+	require.Equal(t, int64(102), events[1].TableID)
+	require.Nil(t, events[1].Info)
+
+	// an empty name matches all the tables.
+	s = NewSchemaSearch(nil, nil, "")
+	require.NoError(t, s.observe(tableKey(102, 14), otherValue, DefaultCF))
+	require.NoError(t, s.observe(tableKey(102, 15), writeValue(WriteTypePut, 14), WriteCF))
+	events = s.events(nil)
+	require.Len(t, events, 1)
+	require.Equal(t, "t2", events[0].Info.Name.O)
+}
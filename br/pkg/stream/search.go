@@ -91,7 +91,7 @@ func (s *StreamBackupSearch) readDataFiles(ctx context.Context, ch chan<- *backu
 	pool := util.NewWorkerPool(64, "read backup meta")
 	eg, egCtx := errgroup.WithContext(ctx)
 	err := s.storage.WalkDir(egCtx, opt, func(path string, size int64) error {
-		if !strings.Contains(path, GetStreamBackupMetaPrefix()) {
+		if !strings.HasSuffix(path, metaSuffix) {
 			return nil
 		}
 
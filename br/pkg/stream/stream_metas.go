@@ -41,8 +41,18 @@ const (
 	migrationPrefix   = "v1/migrations"
 
 	SupportedMigVersion = pb.MigrationVersion_M1
+
+	// CompactionIndexSuffix is the suffix of the compaction index files. The index of the artifacts of a
+	// compaction is saved beside the artifacts directory, with the suffix appended to its name, so it's
+	// never walked as a subcompaction meta file.
+	CompactionIndexSuffix = ".cidx"
 )
 
+// CompactionIndexPath returns the path of the index of the compaction artifacts.
+func CompactionIndexPath(artifacts string) string {
+	return strings.TrimSuffix(artifacts, "/") + CompactionIndexSuffix
+}
+
 func NewMigration() *pb.Migration {
 	return &pb.Migration{
 		Version: pb.MigrationVersion_M1,
@@ -71,6 +81,11 @@ type FileGroupInfo struct {
 	MaxTS   uint64
 	Length  uint64
 	KVCount int64
+	// Path is the path of the physical file of the group.
+	Path string
+	// TableMinTS is the min ts of the data of each table in the group, it's only recorded
+	// in the dry run for the truncate report.
+	TableMinTS map[int64]uint64
 }
 
 // keep these meta-information for statistics and filtering
@@ -113,13 +128,25 @@ func (ms *StreamMetadataSet) LoadUntilAndCalculateShiftTS(
 			fileGroupInfos := make([]*FileGroupInfo, 0, len(m.FileGroups))
 			for _, group := range m.FileGroups {
 				var kvCount int64 = 0
+				var tableMinTS map[int64]uint64
+				if ms.DryRun {
+					tableMinTS = make(map[int64]uint64)
+				}
 				for _, file := range group.DataFilesInfo {
 					kvCount += file.NumberOfEntries
+					if tableMinTS == nil || file.IsMeta || file.TableId == 0 {
+						continue
+					}
+					if ts, ok := tableMinTS[file.TableId]; !ok || file.MinTs < ts {
+						tableMinTS[file.TableId] = file.MinTs
+					}
 				}
 				fileGroupInfos = append(fileGroupInfos, &FileGroupInfo{
-					MaxTS:   group.MaxTs,
-					Length:  group.Length,
-					KVCount: kvCount,
+					MaxTS:      group.MaxTs,
+					Length:     group.Length,
+					KVCount:    kvCount,
+					Path:       group.Path,
+					TableMinTS: tableMinTS,
 				})
 			}
 			metadataMap.Lock()
@@ -184,6 +211,56 @@ func (ms *StreamMetadataSet) IterateFilesFullyBefore(before uint64, f func(d *Fi
 	})
 }
 
+// TruncatedFile is a physical data file deleted by the truncation.
+type TruncatedFile struct {
+	Path   string
+	Length uint64
+	MaxTS  uint64
+}
+
+// TruncateReport is the preview of truncating the log backup.
+type TruncateReport struct {
+	// Files are the data files to be deleted, sorted by the path.
+	Files     []TruncatedFile
+	TotalSize uint64
+	// TableEarliestTS is the earliest restorable ts of each table whose data is truncated, it's
+	// the later one of the truncated ts and the min ts of the data kept for the table.
+	TableEarliestTS map[int64]uint64
+}
+
+// TruncateReport reports the data files fully before shiftUntilTS, which are deleted by truncating
+// the log backup until the ts. The per-table earliest restorable ts is only reported if the metadata
+// is loaded in the dry run.
+func (ms *StreamMetadataSet) TruncateReport(shiftUntilTS, until uint64) *TruncateReport {
+	report := &TruncateReport{TableEarliestTS: make(map[int64]uint64)}
+	keptMinTS := make(map[int64]uint64)
+	ms.iterateDataFiles(func(d *FileGroupInfo) (shouldBreak bool) {
+		if d.MaxTS < shiftUntilTS {
+			report.Files = append(report.Files, TruncatedFile{Path: d.Path, Length: d.Length, MaxTS: d.MaxTS})
+			report.TotalSize += d.Length
+			for tableID := range d.TableMinTS {
+				report.TableEarliestTS[tableID] = until
+			}
+			return false
+		}
+		for tableID, ts := range d.TableMinTS {
+			if kept, ok := keptMinTS[tableID]; !ok || ts < kept {
+				keptMinTS[tableID] = ts
+			}
+		}
+		return false
+	})
+	for tableID := range report.TableEarliestTS {
+		if ts, ok := keptMinTS[tableID]; ok {
+			report.TableEarliestTS[tableID] = max(ts, until)
+		}
+	}
+	slices.SortFunc(report.Files, func(a, b TruncatedFile) int {
+		return strings.Compare(a.Path, b.Path)
+	})
+	return report
+}
+
 type updateFnHook struct {
 	NoHooks
 	updateFn func(num int64)
@@ -967,7 +1044,7 @@ func (m MigrationExt) cleanUpFor(ctx context.Context, medit *pb.MetaEdit, out *M
 // But this won't really clean up the real log files.
 func (m MigrationExt) applyMetaEdit(ctx context.Context, medit *pb.MetaEdit) (err error) {
 	if medit.DestructSelf {
-		return m.s.DeleteFile(ctx, medit.Path)
+		return m.deleteMetadata(ctx, medit.Path)
 	}
 
 	mContent, err := m.s.ReadFile(ctx, medit.Path)
@@ -1036,9 +1113,10 @@ func (m MigrationExt) applyMetaEditTo(ctx context.Context, medit *pb.MetaEdit, m
 
 	if isEmptyMetadata(metadata) {
 		// As it is empty, even no hint to destruct self, we can safely delete it.
-		return m.s.DeleteFile(ctx, medit.Path)
+		return m.deleteMetadata(ctx, medit.Path)
 	}
 
+	// the table ID index of the metadata is kept, it still covers the remaining files.
 	updateMetadataInternalStat(metadata)
 	newContent, err := metadata.Marshal()
 	if err != nil {
@@ -1047,6 +1125,14 @@ func (m MigrationExt) applyMetaEditTo(ctx context.Context, medit *pb.MetaEdit, m
 	return truncateAndWrite(ctx, m.s, medit.Path, newContent)
 }
 
+// deleteMetadata deletes the metadata file and its table ID index built by the log restore.
+func (m MigrationExt) deleteMetadata(ctx context.Context, path string) error {
+	if err := m.s.DeleteFile(ctx, path); err != nil {
+		return err
+	}
+	return DeleteTableIDIndex(ctx, m.s, path)
+}
+
 func (m MigrationExt) tryRemovePrefix(ctx context.Context, pfx string, out *MigratedTo) {
 	enumerateAndDelete := func(prefix string) error {
 		if isInsane(prefix) {
@@ -1068,6 +1154,22 @@ func (m MigrationExt) tryRemovePrefix(ctx context.Context, pfx string, out *Migr
 	}
 }
 
+// tryRemoveCompactionIndex removes the index of the compaction artifacts built by the log restore, it's
+// beside the artifacts directory so it isn't removed with the artifacts.
+func (m MigrationExt) tryRemoveCompactionIndex(ctx context.Context, artifacts string, out *MigratedTo) {
+	if artifacts == "" {
+		return
+	}
+	path := CompactionIndexPath(artifacts)
+	exists, err := m.s.FileExists(ctx, path)
+	if err == nil && exists {
+		err = m.s.DeleteFile(ctx, path)
+	}
+	if err != nil {
+		out.Warnings = append(out.Warnings, errors.Annotatef(err, "failed to delete the compaction index %s", path))
+	}
+}
+
 // doTruncating tries to remove outdated compaction, filling the not-yet removed compactions to the new migration.
 func (m MigrationExt) doTruncating(ctx context.Context, mig *pb.Migration, result *MigratedTo) {
 	// NOTE: Execution of truncation wasn't implemented here.
@@ -1078,6 +1180,7 @@ func (m MigrationExt) doTruncating(ctx context.Context, mig *pb.Migration, resul
 			result.NewBase.Compactions = append(result.NewBase.Compactions, compaction)
 		} else {
 			m.tryRemovePrefix(ctx, compaction.Artifacts, result)
+			m.tryRemoveCompactionIndex(ctx, compaction.Artifacts, result)
 			m.tryRemovePrefix(ctx, compaction.GeneratedFiles, result)
 		}
 	}
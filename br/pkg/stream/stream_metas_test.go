@@ -2669,6 +2669,57 @@ func TestRetryRemoveCompaction(t *testing.T) {
 	require.NoFileExists(t, path.Join(s.Base(), aDir(1), "monolith"))
 }
 
+func TestTruncateRemovesCompactionIndex(t *testing.T) {
+	s := tmp(t)
+	ctx := context.Background()
+	aDir := func(n uint64) string {
+		dir := fmt.Sprintf("%05d/metas", n)
+		require.NoError(t, s.WriteFile(ctx, path.Join(dir, "monolith"), []byte("🪨")))
+		require.NoError(t, s.WriteFile(ctx, CompactionIndexPath(dir), []byte("{}")))
+		return dir
+	}
+	cDir := func(n uint64) string {
+		dir := fmt.Sprintf("%05d/output", n)
+		require.NoError(t, s.WriteFile(ctx, path.Join(dir, "monolith"), []byte("🪨")))
+		return dir
+	}
+
+	est := MigerationExtension(s)
+	mg := est.MigrateTo(ctx, mig(
+		mCompaction(cDir(1), aDir(1), 15, 25),
+		mCompaction(cDir(2), aDir(2), 28, 32),
+		mTruncatedTo(27),
+	))
+	require.Empty(t, mg.Warnings)
+	require.NoFileExists(t, path.Join(s.Base(), "00001/metas"+CompactionIndexSuffix))
+	require.FileExists(t, path.Join(s.Base(), "00002/metas"+CompactionIndexSuffix))
+}
+
+func TestTruncateRemovesTableIDIndex(t *testing.T) {
+	s := tmp(t)
+	ctx := context.Background()
+	mN := func(n uint64) string { return fmt.Sprintf("v1/backupmeta/%05d.meta", n) }
+
+	metas := map[uint64]*backuppb.Metadata{
+		1: mf(1, [][]*backuppb.DataFileInfo{{fi(10, 20, DefaultCF, 0), fi(15, 30, WriteCF, 8)}}),
+		2: mf(2, [][]*backuppb.DataFileInfo{{fi(45, 64, WriteCF, 32), fi(65, 70, WriteCF, 55)}}),
+	}
+	for n, meta := range metas {
+		meta.FileGroups[0].Path = fmt.Sprintf("v1/%05d.log", n)
+		require.NoError(t, s.WriteFile(ctx, meta.FileGroups[0].Path, []byte("🪨")))
+		pmt(s, mN(n), meta)
+		require.NoError(t, s.WriteFile(ctx, mN(n)+TableIDIndexSuffix, []byte("{}")))
+	}
+
+	est := MigerationExtension(s)
+	res := est.MigrateTo(ctx, mig(mTruncatedTo(65)))
+	require.Empty(t, res.Warnings)
+	// the index of the deleted metadata is deleted, the index of the rewritten one is kept.
+	require.NoFileExists(t, path.Join(s.Base(), mN(1)))
+	require.NoFileExists(t, path.Join(s.Base(), mN(1)+TableIDIndexSuffix))
+	require.FileExists(t, path.Join(s.Base(), mN(2)+TableIDIndexSuffix))
+}
+
 func TestWithSimpleTruncate(t *testing.T) {
 	s := tmp(t)
 	ctx := context.Background()
@@ -2810,3 +2861,55 @@ func TestCreator(t *testing.T) {
 	require.Contains(t, mig.Creator, "br")
 	require.Equal(t, mig.Version, SupportedMigVersion)
 }
+
+func TestTruncateReport(t *testing.T) {
+	ctx := context.Background()
+	l, err := storage.NewLocalStorage(t.TempDir())
+	require.NoError(t, err)
+
+	group := func(path string, minTS, maxTS uint64, files ...*backuppb.DataFileInfo) *backuppb.DataFileGroup {
+		return &backuppb.DataFileGroup{Path: path, MinTs: minTS, MaxTs: maxTS, DataFilesInfo: files, Length: 10}
+	}
+	meta := &backuppb.Metadata{
+		MinTs: 1,
+		MaxTs: 30,
+		FileGroups: []*backuppb.DataFileGroup{
+			group("a.log", 1, 5,
+				&backuppb.DataFileInfo{TableId: 1, MinTs: 1, MaxTs: 5, Length: 1},
+				&backuppb.DataFileInfo{TableId: 2, MinTs: 2, MaxTs: 5, Length: 1},
+				&backuppb.DataFileInfo{IsMeta: true, MinTs: 3, MaxTs: 4, Length: 1}),
+			group("b.log", 6, 9,
+				&backuppb.DataFileInfo{TableId: 1, MinTs: 6, MaxTs: 9, Length: 1}),
+			group("c.log", 8, 30,
+				&backuppb.DataFileInfo{TableId: 1, MinTs: 8, MaxTs: 30, Length: 1},
+				&backuppb.DataFileInfo{TableId: 3, MinTs: 20, MaxTs: 30, Length: 1}),
+		},
+		MetaVersion: backuppb.MetaVersion_V2,
+	}
+	bs, err := meta.Marshal()
+	require.NoError(t, err)
+	require.NoError(t, l.WriteFile(ctx, fmt.Sprintf("%s/0000.meta", GetStreamBackupMetaPrefix()), bs))
+
+	s := StreamMetadataSet{
+		Helper:                    NewMetadataHelper(),
+		MetadataDownloadBatchSize: 128,
+		DryRun:                    true,
+	}
+	shiftUntilTS, err := s.LoadUntilAndCalculateShiftTS(ctx, l, 12)
+	require.NoError(t, err)
+	require.Equal(t, uint64(12), shiftUntilTS)
+
+	report := s.TruncateReport(shiftUntilTS, 12)
+	require.Equal(t, []TruncatedFile{
+		{Path: "a.log", Length: 10, MaxTS: 5},
+		{Path: "b.log", Length: 10, MaxTS: 9},
+	}, report.Files)
+	require.Equal(t, uint64(20), report.TotalSize)
+	// table 1 keeps the data from ts 8, which is before the truncated ts.
+	require.Equal(t, map[int64]uint64{1: 12, 2: 12}, report.TableEarliestTS)
+
+	report = s.TruncateReport(7, 5)
+	require.Equal(t, []TruncatedFile{{Path: "a.log", Length: 10, MaxTS: 5}}, report.Files)
+	require.Equal(t, uint64(10), report.TotalSize)
+	require.Equal(t, map[int64]uint64{1: 6, 2: 5}, report.TableEarliestTS)
+}
@@ -18,6 +18,7 @@ import (
 	"context"
 	"crypto/sha256"
 	"encoding/hex"
+	"slices"
 	"strings"
 
 	"github.com/klauspost/compress/zstd"
@@ -27,9 +28,12 @@ import (
 	"github.com/pingcap/log"
 	"github.com/pingcap/tidb/br/pkg/encryption"
 	"github.com/pingcap/tidb/br/pkg/storage"
+	"github.com/pingcap/tidb/br/pkg/utils"
+	"github.com/pingcap/tidb/pkg/ddl"
 	"github.com/pingcap/tidb/pkg/kv"
 	"github.com/pingcap/tidb/pkg/meta"
 	"github.com/pingcap/tidb/pkg/meta/model"
+	"github.com/pingcap/tidb/pkg/parser/ast"
 	"github.com/pingcap/tidb/pkg/tablecodec"
 	"github.com/pingcap/tidb/pkg/util"
 	filter "github.com/pingcap/tidb/pkg/util/table-filter"
@@ -78,11 +82,32 @@ func buildObserveTableRange(table *model.TableInfo) []kv.KeyRange {
 	return appendTableObserveRanges(tblIDs)
 }
 
+// isExcludedSysTable checks whether the data of the table is excluded from the log backup by default.
+// The log restore never replays the data of the system schemas, except `mysql.tidb_ddl_history` which
+// is read to replay the DDL jobs.
+func isExcludedSysTable(dbName ast.CIStr, tableID int64) bool {
+	return utils.IsSysDB(dbName.L) && tableID != ddl.HistoryTableID
+}
+
+// tableIDs returns the IDs of the physical tables of `table`.
+func tableIDs(table *model.TableInfo) []int64 {
+	pis := table.GetPartitionInfo()
+	if pis == nil {
+		return []int64{table.ID}
+	}
+	ids := make([]int64, 0, len(pis.Definitions))
+	for _, def := range pis.Definitions {
+		ids = append(ids, def.ID)
+	}
+	return ids
+}
+
 // buildObserveTableRanges builds key ranges to observe table kv-events.
 func buildObserveTableRanges(
 	storage kv.Storage,
 	tableFilter filter.Filter,
 	backupTS uint64,
+	includeSysTables bool,
 ) ([]kv.KeyRange, error) {
 	snapshot := storage.GetSnapshot(kv.NewVersion(backupTS))
 	m := meta.NewReader(snapshot)
@@ -103,6 +128,10 @@ func buildObserveTableRanges(
 				// Skip tables other than the given table.
 				return nil
 			}
+			if !includeSysTables && isExcludedSysTable(dbInfo.Name, tableInfo.ID) {
+				log.Info("skip to observe the system table", zap.Stringer("db", dbInfo.Name), zap.Stringer("table", tableInfo.Name))
+				return nil
+			}
 			log.Info("start to observe the table", zap.Stringer("db", dbInfo.Name), zap.Stringer("table", tableInfo.Name))
 
 			tableRanges := buildObserveTableRange(tableInfo)
@@ -128,19 +157,71 @@ func buildObserverAllRange() []kv.KeyRange {
 	return append(rgs, kv.KeyRange{StartKey: sk, EndKey: ek})
 }
 
-// BuildObserveDataRanges builds key ranges to observe data KV.
+// buildObserveAllRangeExceptSysTables builds key ranges to observe all data kv-events except the ones of
+// the system tables existing at `backupTS`, so the tables created later are still observed.
+func buildObserveAllRangeExceptSysTables(storage kv.Storage, backupTS uint64) ([]kv.KeyRange, error) {
+	snapshot := storage.GetSnapshot(kv.NewVersion(backupTS))
+	m := meta.NewReader(snapshot)
+
+	dbs, err := m.ListDatabases()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	excluded := make([]int64, 0)
+	for _, dbInfo := range dbs {
+		if !utils.IsSysDB(dbInfo.Name.L) {
+			continue
+		}
+		if err := m.IterTables(dbInfo.ID, func(tableInfo *model.TableInfo) error {
+			if isExcludedSysTable(dbInfo.Name, tableInfo.ID) {
+				excluded = append(excluded, tableIDs(tableInfo)...)
+			}
+			return nil
+		}); err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	log.Info("skip to observe the system tables", zap.Int("count", len(excluded)))
+	return excludeTableRanges(buildObserverAllRange()[0], excluded), nil
+}
+
+// excludeTableRanges splits `all` into the key ranges without the keys of the tables.
+func excludeTableRanges(all kv.KeyRange, tblIDs []int64) []kv.KeyRange {
+	slices.Sort(tblIDs)
+	tblIDs = slices.Compact(tblIDs)
+	ranges := make([]kv.KeyRange, 0, len(tblIDs)+1)
+	startKey := all.StartKey
+	for _, tid := range tblIDs {
+		tablePrefix := tablecodec.EncodeTablePrefix(tid)
+		if startKey.Cmp(tablePrefix) < 0 {
+			ranges = append(ranges, kv.KeyRange{StartKey: startKey, EndKey: tablePrefix})
+		}
+		startKey = tablePrefix.PrefixNext()
+	}
+	if startKey.Cmp(all.EndKey) < 0 {
+		ranges = append(ranges, kv.KeyRange{StartKey: startKey, EndKey: all.EndKey})
+	}
+	return ranges
+}
+
+// BuildObserveDataRanges builds key ranges to observe data KV. The data of the system tables, which
+// the log restore skips, isn't observed unless `includeSysTables` is set.
 func BuildObserveDataRanges(
 	storage kv.Storage,
 	filterStr []string,
 	tableFilter filter.Filter,
 	backupTS uint64,
+	includeSysTables bool,
 ) ([]kv.KeyRange, error) {
 	if len(filterStr) == 1 && filterStr[0] == string("*.*") {
-		return buildObserverAllRange(), nil
+		if includeSysTables {
+			return buildObserverAllRange(), nil
+		}
+		return buildObserveAllRangeExceptSysTables(storage, backupTS)
 	}
 	// TODO: currently it's a dead code, the iterator metakvs can be optimized
 	//  to marshal only necessary fields.
-	return buildObserveTableRanges(storage, tableFilter, backupTS)
+	return buildObserveTableRanges(storage, tableFilter, backupTS, includeSysTables)
 }
 
 // BuildObserveMetaRange specifies build key ranges to observe meta KV(contains all of metas)
@@ -393,3 +474,65 @@ func FastUnmarshalMetaData(
 	}
 	return eg.Wait()
 }
+
+// RewriteFileEncryptionInfo walks all metadata files and calls `rewrite` for the encryption
+// info of every data file. The metadata file is written back if any of its data files changed.
+// It returns the number of metadata files written back.
+func RewriteFileEncryptionInfo(
+	ctx context.Context,
+	s storage.ExternalStorage,
+	rewrite func(info *encryptionpb.FileEncryptionInfo) (bool, error),
+) (int, error) {
+	updated := 0
+	opt := &storage.WalkOption{SubDir: GetStreamBackupMetaPrefix()}
+	err := s.WalkDir(ctx, opt, func(path string, size int64) error {
+		if !strings.HasSuffix(path, metaSuffix) {
+			return nil
+		}
+		b, err := s.ReadFile(ctx, path)
+		if err != nil {
+			return errors.Annotatef(err, "during reading meta file %s from storage", path)
+		}
+		meta := &backuppb.Metadata{}
+		if err := meta.Unmarshal(b); err != nil {
+			return errors.Annotatef(err, "failed to parse meta file %s", path)
+		}
+
+		changed := false
+		rewriteFiles := func(files []*backuppb.DataFileInfo) error {
+			for _, f := range files {
+				if f.FileEncryptionInfo == nil {
+					continue
+				}
+				ok, err := rewrite(f.FileEncryptionInfo)
+				if err != nil {
+					return errors.Annotatef(err, "failed to rewrite encryption info of %s", f.Path)
+				}
+				changed = changed || ok
+			}
+			return nil
+		}
+		if err := rewriteFiles(meta.Files); err != nil {
+			return err
+		}
+		for _, g := range meta.FileGroups {
+			if err := rewriteFiles(g.DataFilesInfo); err != nil {
+				return err
+			}
+		}
+		if !changed {
+			return nil
+		}
+
+		data, err := meta.Marshal()
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if err := truncateAndWrite(ctx, s, path, data); err != nil {
+			return errors.Trace(err)
+		}
+		updated += 1
+		return nil
+	})
+	return updated, errors.Trace(err)
+}
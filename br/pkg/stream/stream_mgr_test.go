@@ -0,0 +1,39 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package stream
+
+import (
+	"testing"
+
+	"github.com/pingcap/tidb/pkg/ddl"
+	"github.com/pingcap/tidb/pkg/kv"
+	"github.com/pingcap/tidb/pkg/parser/ast"
+	"github.com/pingcap/tidb/pkg/tablecodec"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExcludeSysTableRanges(t *testing.T) {
+	require.True(t, isExcludedSysTable(ast.NewCIStr("mysql"), 1))
+	require.True(t, isExcludedSysTable(ast.NewCIStr("SYS"), 1))
+	require.False(t, isExcludedSysTable(ast.NewCIStr("mysql"), ddl.HistoryTableID))
+	require.False(t, isExcludedSysTable(ast.NewCIStr("test"), 1))
+
+	all := buildObserverAllRange()[0]
+	require.Equal(t, []kv.KeyRange{all}, excludeTableRanges(all, nil))
+
+	prefix := tablecodec.EncodeTablePrefix
+	ranges := excludeTableRanges(all, []int64{5, 3, 4, 3, 10})
+	require.Equal(t, []kv.KeyRange{
+		{StartKey: all.StartKey, EndKey: prefix(3)},
+		{StartKey: prefix(5).PrefixNext(), EndKey: prefix(10)},
+		{StartKey: prefix(10).PrefixNext(), EndKey: all.EndKey},
+	}, ranges)
+	// the adjacent tables are merged, and the keys of the excluded tables aren't covered.
+	require.Equal(t, prefix(4), prefix(3).PrefixNext())
+	for _, r := range ranges {
+		for _, id := range []int64{3, 4, 5, 10} {
+			key := tablecodec.EncodeRowKeyWithHandle(id, kv.IntHandle(1))
+			require.False(t, key.Cmp(r.StartKey) >= 0 && key.Cmp(r.EndKey) < 0, id)
+		}
+	}
+}
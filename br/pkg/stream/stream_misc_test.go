@@ -4,9 +4,11 @@ package stream_test
 
 import (
 	"context"
+	"path"
 	"testing"
 
 	backuppb "github.com/pingcap/kvproto/pkg/brpb"
+	"github.com/pingcap/kvproto/pkg/encryptionpb"
 	"github.com/pingcap/tidb/br/pkg/storage"
 	"github.com/pingcap/tidb/br/pkg/stream"
 	"github.com/pingcap/tidb/br/pkg/streamhelper"
@@ -77,3 +79,47 @@ func TestMetadataHelperReadFile(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, data1, get_data)
 }
+
+func TestRewriteFileEncryptionInfo(t *testing.T) {
+	ctx := context.Background()
+	s, err := storage.NewLocalStorage(t.TempDir())
+	require.NoError(t, err)
+
+	encrypted := func(path string) *backuppb.DataFileInfo {
+		return &backuppb.DataFileInfo{
+			Path: path,
+			FileEncryptionInfo: &encryptionpb.FileEncryptionInfo{
+				Mode: &encryptionpb.FileEncryptionInfo_MasterKeyBased{
+					MasterKeyBased: &encryptionpb.MasterKeyBased{
+						DataKeyEncryptedContent: []*encryptionpb.EncryptedContent{{Content: []byte("k1")}},
+					},
+				},
+			},
+		}
+	}
+	metas := map[string]*backuppb.Metadata{
+		"1.meta": {FileGroups: []*backuppb.DataFileGroup{{DataFilesInfo: []*backuppb.DataFileInfo{encrypted("a"), {Path: "b"}}}}},
+		"2.meta": {FileGroups: []*backuppb.DataFileGroup{{DataFilesInfo: []*backuppb.DataFileInfo{{Path: "c"}}}}},
+	}
+	for name, meta := range metas {
+		data, err := meta.Marshal()
+		require.NoError(t, err)
+		require.NoError(t, s.WriteFile(ctx, path.Join(stream.GetStreamBackupMetaPrefix(), name), data))
+	}
+
+	updated, err := stream.RewriteFileEncryptionInfo(ctx, s, func(info *encryptionpb.FileEncryptionInfo) (bool, error) {
+		mk := info.GetMasterKeyBased()
+		mk.DataKeyEncryptedContent = append(mk.DataKeyEncryptedContent, &encryptionpb.EncryptedContent{Content: []byte("k2")})
+		return true, nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, 1, updated)
+
+	data, err := s.ReadFile(ctx, path.Join(stream.GetStreamBackupMetaPrefix(), "1.meta"))
+	require.NoError(t, err)
+	meta := &backuppb.Metadata{}
+	require.NoError(t, meta.Unmarshal(data))
+	contents := meta.FileGroups[0].DataFilesInfo[0].FileEncryptionInfo.GetMasterKeyBased().DataKeyEncryptedContent
+	require.Len(t, contents, 2)
+	require.Equal(t, []byte("k2"), contents[1].Content)
+}
@@ -22,6 +22,7 @@ import (
 	"github.com/pingcap/tidb/br/pkg/glue"
 	"github.com/pingcap/tidb/br/pkg/httputil"
 	"github.com/pingcap/tidb/br/pkg/logutil"
+	"github.com/pingcap/tidb/br/pkg/metautil"
 	"github.com/pingcap/tidb/br/pkg/storage"
 	. "github.com/pingcap/tidb/br/pkg/streamhelper"
 	"github.com/tikv/client-go/v2/oracle"
@@ -45,6 +46,8 @@ type TaskStatus struct {
 	QPS float64
 	// Last error reported by the store.
 	LastErrors map[uint64]backuppb.StreamBackupError
+	// Labels are the user-defined labels of the task.
+	Labels map[string]string
 }
 
 type TaskPrinter interface {
@@ -66,6 +69,15 @@ func PrintTaskWithJSON(c glue.ConsoleOperations) TaskPrinter {
 	}
 }
 
+// PrintTaskWithJSONLines make a TaskPrinter,
+// which prints each task as a line of json with the time it's polled, it's used to watch the tasks.
+func PrintTaskWithJSONLines(c glue.ConsoleOperations) TaskPrinter {
+	return &printByJSONLines{
+		console: c,
+		now:     time.Now,
+	}
+}
+
 type printByTable struct {
 	console       glue.ConsoleOperations
 	pendingTables []*glue.Table
@@ -136,6 +148,9 @@ func (p *printByTable) AddTask(task TaskStatus) {
 	}
 	s := storage.FormatBackendURL(task.Info.GetStorage())
 	table.Add("storage", s.String())
+	if len(task.Labels) > 0 {
+		table.Add("labels", metautil.FormatLabels(task.Labels))
+	}
 	table.Add("speed(est.)", fmt.Sprintf("%s ops/s", color.New(color.Bold).Sprintf("%.2f", task.QPS)))
 
 	now := time.Now()
@@ -192,26 +207,47 @@ func (p *printByJSON) AddTask(t TaskStatus) {
 	p.cache = append(p.cache, t)
 }
 
+type storeLastError struct {
+	StoreID   uint64                     `json:"store_id"`
+	LastError backuppb.StreamBackupError `json:"last_error"`
+}
+
+func (t *TaskStatus) storeLastErrors() []storeLastError {
+	se := make([]storeLastError, 0, len(t.LastErrors))
+	for store, lastError := range t.LastErrors {
+		se = append(se, storeLastError{
+			StoreID:   store,
+			LastError: lastError,
+		})
+	}
+	return se
+}
+
+func mustMarshal(i any) string {
+	r, err := json.Marshal(i)
+	if err != nil {
+		log.Panic("Failed to marshal a trivial struct to json", zap.Reflect("object", i), zap.Error(err))
+	}
+	return string(r)
+}
+
 func (p *printByJSON) PrintTasks() {
 	type storeProgress struct {
 		StoreID    uint64 `json:"store_id"`
 		Checkpoint uint64 `json:"checkpoint"`
 	}
-	type storeLastError struct {
-		StoreID   uint64                     `json:"store_id"`
-		LastError backuppb.StreamBackupError `json:"last_error"`
-	}
 	type jsonTask struct {
-		Name         string           `json:"name"`
-		StartTS      uint64           `json:"start_ts,omitempty"`
-		EndTS        uint64           `json:"end_ts,omitempty"`
-		Status       string           `json:"status"`
-		TableFilter  []string         `json:"table_filter"`
-		Progress     []storeProgress  `json:"progress"`
-		Storage      string           `json:"storage"`
-		CheckpointTS uint64           `json:"checkpoint"`
-		EstQPS       float64          `json:"estimate_qps"`
-		LastErrors   []storeLastError `json:"last_errors"`
+		Name         string            `json:"name"`
+		StartTS      uint64            `json:"start_ts,omitempty"`
+		EndTS        uint64            `json:"end_ts,omitempty"`
+		Status       string            `json:"status"`
+		TableFilter  []string          `json:"table_filter"`
+		Progress     []storeProgress   `json:"progress"`
+		Storage      string            `json:"storage"`
+		CheckpointTS uint64            `json:"checkpoint"`
+		EstQPS       float64           `json:"estimate_qps"`
+		LastErrors   []storeLastError  `json:"last_errors"`
+		Labels       map[string]string `json:"labels,omitempty"`
 	}
 	taskToJSON := func(t TaskStatus) jsonTask {
 		s := storage.FormatBackendURL(t.Info.GetStorage())
@@ -224,13 +260,6 @@ func (p *printByJSON) PrintTasks() {
 				})
 			}
 		}
-		se := make([]storeLastError, 0, len(t.LastErrors))
-		for store, lastError := range t.LastErrors {
-			se = append(se, storeLastError{
-				StoreID:   store,
-				LastError: lastError,
-			})
-		}
 		return jsonTask{
 			Name:         t.Info.GetName(),
 			StartTS:      t.Info.GetStartTs(),
@@ -241,15 +270,9 @@ func (p *printByJSON) PrintTasks() {
 			Storage:      s.String(),
 			CheckpointTS: t.globalCheckpoint,
 			EstQPS:       t.QPS,
-			LastErrors:   se,
-		}
-	}
-	mustMarshal := func(i any) string {
-		r, err := json.Marshal(i)
-		if err != nil {
-			log.Panic("Failed to marshal a trivial struct to json", zap.Reflect("object", i), zap.Error(err))
+			LastErrors:   t.storeLastErrors(),
+			Labels:       t.Labels,
 		}
-		return string(r)
 	}
 
 	tasks := make([]jsonTask, 0, len(p.cache))
@@ -259,6 +282,67 @@ func (p *printByJSON) PrintTasks() {
 	p.console.Println(mustMarshal(tasks))
 }
 
+type printByJSONLines struct {
+	cache   []TaskStatus
+	console glue.ConsoleOperations
+	now     func() time.Time
+}
+
+func (p *printByJSONLines) AddTask(t TaskStatus) {
+	p.cache = append(p.cache, t)
+}
+
+// PrintTasks prints the tasks added since the last call, one line for each task.
+func (p *printByJSONLines) PrintTasks() {
+	type storeProgress struct {
+		StoreID       uint64 `json:"store_id"`
+		Checkpoint    uint64 `json:"checkpoint"`
+		CheckpointLag int64  `json:"checkpoint_lag_ms"`
+	}
+	// only the last error of each store is known, so the stores reporting an error are counted instead of
+	// the errors.
+	type jsonLine struct {
+		Time          string           `json:"time"`
+		Name          string           `json:"name"`
+		Status        string           `json:"status"`
+		CheckpointTS  uint64           `json:"checkpoint"`
+		CheckpointLag int64            `json:"checkpoint_lag_ms"`
+		Progress      []storeProgress  `json:"progress"`
+		EstQPS        float64          `json:"estimate_qps"`
+		ErrorStores   int              `json:"error_stores"`
+		LastErrors    []storeLastError `json:"last_errors"`
+	}
+
+	now := p.now()
+	lagOf := func(ts uint64) int64 {
+		return now.Sub(oracle.GetTimeFromTS(ts)).Milliseconds()
+	}
+	for _, t := range p.cache {
+		sp := make([]storeProgress, 0, len(t.Checkpoints))
+		for _, checkpoint := range t.Checkpoints {
+			if checkpoint.Type() == CheckpointTypeStore {
+				sp = append(sp, storeProgress{
+					StoreID:       checkpoint.ID,
+					Checkpoint:    checkpoint.TS,
+					CheckpointLag: lagOf(checkpoint.TS),
+				})
+			}
+		}
+		p.console.Println(mustMarshal(jsonLine{
+			Time:          now.Format(time.RFC3339),
+			Name:          t.Info.GetName(),
+			Status:        t.statusString(),
+			CheckpointTS:  t.globalCheckpoint,
+			CheckpointLag: lagOf(t.globalCheckpoint),
+			Progress:      sp,
+			EstQPS:        t.QPS,
+			ErrorStores:   len(t.LastErrors),
+			LastErrors:    t.storeLastErrors(),
+		}))
+	}
+	p.cache = p.cache[:0]
+}
+
 var logCountSumRe = regexp.MustCompile(`tikv_(?:stream|log_backup)_handle_kv_batch_sum ([0-9]+)`)
 
 type PDInfoProvider interface {
@@ -389,6 +473,10 @@ func (ctl *StatusController) fillTask(ctx context.Context, task Task, client *ht
 		return s, err
 	}
 
+	if s.Labels, err = task.Labels(ctx); err != nil {
+		return s, errors.Trace(err)
+	}
+
 	s.QPS, err = MaybeQPS(ctx, ctl.mgr, client)
 	if err != nil {
 		return s, errors.Annotatef(err, "failed to get QPS of task %s", s.Info.Name)
@@ -443,3 +531,26 @@ func (ctl *StatusController) PrintStatusOfTask(ctx context.Context, name string)
 	ctl.printToView(tasks)
 	return nil
 }
+
+// WatchStatusOfTask prints the status of tasks with the name every interval until the context is done.
+// The failures of getting the status are logged, and retried in the next round.
+func (ctl *StatusController) WatchStatusOfTask(ctx context.Context, name string, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		tasks, err := ctl.getTask(ctx, name)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			log.Warn("failed to get the status of the log backup task", zap.String("task", name), zap.Error(err))
+		} else {
+			ctl.printToView(tasks)
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
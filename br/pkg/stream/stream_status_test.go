@@ -0,0 +1,99 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package stream
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	backuppb "github.com/pingcap/kvproto/pkg/brpb"
+	"github.com/pingcap/tidb/br/pkg/glue"
+	"github.com/pingcap/tidb/br/pkg/streamhelper"
+	"github.com/stretchr/testify/require"
+	"github.com/tikv/client-go/v2/oracle"
+)
+
+type bufferConsole struct {
+	bytes.Buffer
+}
+
+func (c *bufferConsole) Out() io.Writer {
+	return &c.Buffer
+}
+
+func (c *bufferConsole) In() io.Reader {
+	return strings.NewReader("")
+}
+
+func TestPrintTaskWithJSONLines(t *testing.T) {
+	console := &bufferConsole{}
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	printer := &printByJSONLines{
+		console: glue.ConsoleOperations{ConsoleGlue: console},
+		now:     func() time.Time { return now },
+	}
+	tsAgo := func(d time.Duration) uint64 {
+		return oracle.GoTimeToTS(now.Add(-d))
+	}
+
+	printer.AddTask(TaskStatus{
+		Info:             backuppb.StreamBackupTaskInfo{Name: "task1"},
+		globalCheckpoint: tsAgo(time.Minute),
+		Checkpoints: []streamhelper.Checkpoint{
+			{ID: 1, TS: tsAgo(time.Second)},
+			{ID: 2, TS: tsAgo(time.Minute)},
+		},
+		QPS: 42,
+	})
+	printer.AddTask(TaskStatus{
+		Info:             backuppb.StreamBackupTaskInfo{Name: "task2"},
+		paused:           true,
+		globalCheckpoint: tsAgo(time.Hour),
+		LastErrors: map[uint64]backuppb.StreamBackupError{
+			1: {ErrorCode: "KV:LogBackup:RaftReq", ErrorMessage: "failed"},
+		},
+	})
+	printer.PrintTasks()
+
+	type line struct {
+		Time          string  `json:"time"`
+		Name          string  `json:"name"`
+		Status        string  `json:"status"`
+		CheckpointLag int64   `json:"checkpoint_lag_ms"`
+		EstQPS        float64 `json:"estimate_qps"`
+		ErrorStores   int     `json:"error_stores"`
+		Progress      []struct {
+			StoreID       uint64 `json:"store_id"`
+			CheckpointLag int64  `json:"checkpoint_lag_ms"`
+		} `json:"progress"`
+	}
+	lines := strings.Split(strings.TrimSpace(console.String()), "\n")
+	require.Len(t, lines, 2)
+	var l1, l2 line
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &l1))
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &l2))
+
+	require.Equal(t, "2026-01-01T00:00:00Z", l1.Time)
+	require.Equal(t, "task1", l1.Name)
+	require.Equal(t, "NORMAL", l1.Status)
+	require.EqualValues(t, time.Minute.Milliseconds(), l1.CheckpointLag)
+	require.EqualValues(t, 42, l1.EstQPS)
+	require.Zero(t, l1.ErrorStores)
+	require.Len(t, l1.Progress, 2)
+	require.EqualValues(t, 1, l1.Progress[0].StoreID)
+	require.EqualValues(t, time.Second.Milliseconds(), l1.Progress[0].CheckpointLag)
+
+	require.Equal(t, "task2", l2.Name)
+	require.Equal(t, "ERROR", l2.Status)
+	require.EqualValues(t, time.Hour.Milliseconds(), l2.CheckpointLag)
+	require.Equal(t, 1, l2.ErrorStores)
+
+	// the tasks printed aren't printed again in the next round.
+	console.Reset()
+	printer.PrintTasks()
+	require.Empty(t, console.String())
+}
@@ -0,0 +1,138 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package stream
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/tidb/br/pkg/storage"
+	filter "github.com/pingcap/tidb/pkg/util/table-filter"
+)
+
+const tableFilterChangesPrefix = "v1/table_filter_changes"
+
+// TableFilterChange records that the table filter of the log backup task takes effect since ChangeTS.
+// The first change is recorded when the task starts, and the later ones when the filter is updated.
+type TableFilterChange struct {
+	ChangeTS    uint64   `json:"change-ts"`
+	TableFilter []string `json:"table-filter"`
+	// IncludeSysTables is whether the data of the system tables is observed since ChangeTS.
+	IncludeSysTables bool `json:"include-sys-tables"`
+}
+
+func tableFilterChangePath(changeTS uint64) string {
+	return path.Join(tableFilterChangesPrefix, fmt.Sprintf("%016X.json", changeTS))
+}
+
+// SaveTableFilterChange saves the change of the table filter to the storage of the log backup.
+func SaveTableFilterChange(ctx context.Context, s storage.ExternalStorage, change TableFilterChange) error {
+	data, err := json.Marshal(change)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Annotatef(s.WriteFile(ctx, tableFilterChangePath(change.ChangeTS), data),
+		"failed to save the table filter change at %d", change.ChangeTS)
+}
+
+// LoadTableFilterChanges loads the changes of the table filter from the storage of the log backup,
+// sorted by the change ts. It's empty if the log backup is created by BR not recording the changes.
+func LoadTableFilterChanges(ctx context.Context, s storage.ExternalStorage) ([]TableFilterChange, error) {
+	changes := make([]TableFilterChange, 0)
+	err := s.WalkDir(ctx, &storage.WalkOption{SubDir: tableFilterChangesPrefix}, func(p string, _ int64) error {
+		if !strings.HasSuffix(p, ".json") {
+			return nil
+		}
+		data, err := s.ReadFile(ctx, p)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		var change TableFilterChange
+		if err := json.Unmarshal(data, &change); err != nil {
+			return errors.Annotatef(err, "failed to parse the table filter change %s", p)
+		}
+		changes = append(changes, change)
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	slices.SortFunc(changes, func(a, b TableFilterChange) int {
+		return cmp.Compare(a.ChangeTS, b.ChangeTS)
+	})
+	return changes, nil
+}
+
+// SaveInitialTableFilter saves the table filter in effect since the start of the task if no change is
+// recorded, i.e. the task is started by the BR not recording the changes, which observes the data of the
+// system tables as well.
+func SaveInitialTableFilter(ctx context.Context, s storage.ExternalStorage, startTS uint64, tableFilter []string) error {
+	changes, err := LoadTableFilterChanges(ctx, s)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if len(changes) > 0 {
+		return nil
+	}
+	return errors.Trace(SaveTableFilterChange(ctx, s, TableFilterChange{
+		ChangeTS:         startTS,
+		TableFilter:      tableFilter,
+		IncludeSysTables: true,
+	}))
+}
+
+// CoverageWindow is the range [From, To) of ts in which a table isn't covered by the log backup.
+type CoverageWindow struct {
+	From uint64
+	To   uint64
+}
+
+// TableFilterHistory tells whether a table is covered by the log backup at a point of time.
+type TableFilterHistory struct {
+	changes []TableFilterChange
+	filters []filter.Filter
+}
+
+// NewTableFilterHistory creates the history from the changes sorted by the change ts.
+func NewTableFilterHistory(changes []TableFilterChange) (*TableFilterHistory, error) {
+	h := &TableFilterHistory{changes: changes}
+	for _, change := range changes {
+		f, err := filter.Parse(change.TableFilter)
+		if err != nil {
+			return nil, errors.Annotatef(err, "invalid table filter changed at %d", change.ChangeTS)
+		}
+		h.filters = append(h.filters, filter.CaseInsensitive(f))
+	}
+	return h, nil
+}
+
+// UncoveredWindows returns the windows in [startTS, endTS] in which the table isn't covered by the
+// log backup. The first filter is considered in effect since the beginning of the log backup.
+func (h *TableFilterHistory) UncoveredWindows(schema, table string, startTS, endTS uint64) []CoverageWindow {
+	var windows []CoverageWindow
+	for i, change := range h.changes {
+		from := max(change.ChangeTS, startTS)
+		if i == 0 {
+			from = startTS
+		}
+		to := endTS + 1
+		if i+1 < len(h.changes) {
+			to = min(h.changes[i+1].ChangeTS, to)
+		}
+		if from >= to || h.filters[i].MatchTable(schema, table) {
+			continue
+		}
+		if n := len(windows); n > 0 && windows[n-1].To == from {
+			windows[n-1].To = to
+			continue
+		}
+		windows = append(windows, CoverageWindow{From: from, To: to})
+	}
+	return windows
+}
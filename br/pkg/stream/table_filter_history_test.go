@@ -0,0 +1,80 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package stream
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pingcap/tidb/br/pkg/storage"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSaveAndLoadTableFilterChanges(t *testing.T) {
+	ctx := context.Background()
+	s, err := storage.NewLocalStorage(t.TempDir())
+	require.NoError(t, err)
+
+	changes, err := LoadTableFilterChanges(ctx, s)
+	require.NoError(t, err)
+	require.Empty(t, changes)
+
+	for _, change := range []TableFilterChange{
+		{ChangeTS: 300, TableFilter: []string{"db1.*"}},
+		{ChangeTS: 100, TableFilter: []string{"*.*"}},
+		{ChangeTS: 200, TableFilter: []string{"db1.*", "db2.*"}},
+	} {
+		require.NoError(t, SaveTableFilterChange(ctx, s, change))
+	}
+	changes, err = LoadTableFilterChanges(ctx, s)
+	require.NoError(t, err)
+	require.Equal(t, []TableFilterChange{
+		{ChangeTS: 100, TableFilter: []string{"*.*"}},
+		{ChangeTS: 200, TableFilter: []string{"db1.*", "db2.*"}},
+		{ChangeTS: 300, TableFilter: []string{"db1.*"}},
+	}, changes)
+}
+
+func TestSaveInitialTableFilter(t *testing.T) {
+	ctx := context.Background()
+	s, err := storage.NewLocalStorage(t.TempDir())
+	require.NoError(t, err)
+
+	require.NoError(t, SaveInitialTableFilter(ctx, s, 100, []string{"db1.*"}))
+	// it's saved only once.
+	require.NoError(t, SaveInitialTableFilter(ctx, s, 200, []string{"db2.*"}))
+	require.NoError(t, SaveTableFilterChange(ctx, s, TableFilterChange{ChangeTS: 300, TableFilter: []string{"*.*"}}))
+	changes, err := LoadTableFilterChanges(ctx, s)
+	require.NoError(t, err)
+	require.Equal(t, []TableFilterChange{
+		{ChangeTS: 100, TableFilter: []string{"db1.*"}, IncludeSysTables: true},
+		{ChangeTS: 300, TableFilter: []string{"*.*"}},
+	}, changes)
+}
+
+func TestTableFilterHistory(t *testing.T) {
+	h, err := NewTableFilterHistory(nil)
+	require.NoError(t, err)
+	// all the tables are covered if the changes aren't recorded.
+	require.Empty(t, h.UncoveredWindows("db1", "t1", 100, 200))
+
+	h, err = NewTableFilterHistory([]TableFilterChange{
+		{ChangeTS: 100, TableFilter: []string{"db1.*"}},
+		{ChangeTS: 200, TableFilter: []string{"db1.*", "db2.*"}},
+		{ChangeTS: 300, TableFilter: []string{"db1.*"}},
+		{ChangeTS: 400, TableFilter: []string{"db1.*", "DB2.T1"}},
+	})
+	require.NoError(t, err)
+
+	require.Empty(t, h.UncoveredWindows("db1", "t1", 50, 500))
+	// db2 is added at 200, removed at 300, and db2.t1 is added back at 400.
+	require.Equal(t, []CoverageWindow{{From: 50, To: 200}, {From: 300, To: 400}},
+		h.UncoveredWindows("db2", "t1", 50, 500))
+	require.Equal(t, []CoverageWindow{{From: 50, To: 200}, {From: 300, To: 501}},
+		h.UncoveredWindows("db2", "t2", 50, 500))
+	require.Empty(t, h.UncoveredWindows("db2", "t2", 200, 299))
+	require.Equal(t, []CoverageWindow{{From: 300, To: 351}}, h.UncoveredWindows("db2", "t1", 250, 350))
+
+	_, err = NewTableFilterHistory([]TableFilterChange{{ChangeTS: 100, TableFilter: []string{"["}}})
+	require.Error(t, err)
+}
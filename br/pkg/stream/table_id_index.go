@@ -0,0 +1,223 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package stream
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"strings"
+
+	"github.com/pingcap/errors"
+	backuppb "github.com/pingcap/kvproto/pkg/brpb"
+	"github.com/pingcap/tidb/br/pkg/storage"
+)
+
+const (
+	// TableIDIndexSuffix is the suffix of the table ID index files. The index of a metadata file is
+	// saved beside it, with the suffix appended to its name.
+	TableIDIndexSuffix = ".tidx"
+
+	// bloomBitsPerTable and bloomHashes make the false positive rate of the bloom filters about 1%.
+	bloomBitsPerTable = 10
+	bloomHashes       = 7
+)
+
+// TableIDIndex indexes the table IDs of the data files in a metadata file, so the log restore can
+// skip the physical files without the tables to restore before downloading them.
+//
+// The metadata files are only shrunk after written, e.g. by truncating, so the index built on a
+// metadata file keeps covering it.
+type TableIDIndex struct {
+	Groups []*GroupTableIDIndex `json:"groups"`
+
+	groupByPath map[string]*GroupTableIDIndex
+}
+
+// GroupTableIDIndex indexes the table IDs of the data files in a physical file.
+type GroupTableIDIndex struct {
+	// Path is the path of the physical file.
+	Path string `json:"path"`
+	// Unprunable is set if the physical file has meta kv files, or files whose table ID is unknown.
+	Unprunable bool  `json:"unprunable,omitempty"`
+	MinTableID int64 `json:"min-table-id"`
+	MaxTableID int64 `json:"max-table-id"`
+	// Bloom is the bloom filter of the table IDs.
+	Bloom []uint64 `json:"bloom,omitempty"`
+}
+
+// BuildTableIDIndex builds the table ID index of the metadata, which must be parsed by
+// MetadataHelper.ParseToMetadata.
+func BuildTableIDIndex(meta *backuppb.Metadata) *TableIDIndex {
+	index := &TableIDIndex{Groups: make([]*GroupTableIDIndex, 0, len(meta.FileGroups))}
+	for _, group := range meta.FileGroups {
+		g := &GroupTableIDIndex{Path: group.Path, MinTableID: math.MaxInt64, MaxTableID: math.MinInt64}
+		for _, file := range group.DataFilesInfo {
+			if file.IsMeta || file.TableId == 0 {
+				g.Unprunable = true
+				break
+			}
+			g.MinTableID = min(g.MinTableID, file.TableId)
+			g.MaxTableID = max(g.MaxTableID, file.TableId)
+		}
+		if !g.Unprunable {
+			g.Bloom = make([]uint64, (len(group.DataFilesInfo)*bloomBitsPerTable+63)/64)
+			for _, file := range group.DataFilesInfo {
+				g.addToBloom(file.TableId)
+			}
+		}
+		index.Groups = append(index.Groups, g)
+	}
+	return index
+}
+
+// bloomPosition returns the i-th position of the table ID in the bloom filter by double hashing.
+func (g *GroupTableIDIndex) bloomPosition(tableID int64, i int) (word int, mask uint64) {
+	h1 := splitMix64(uint64(tableID))
+	h2 := splitMix64(h1) | 1
+	pos := (h1 + uint64(i)*h2) % (uint64(len(g.Bloom)) * 64)
+	return int(pos / 64), 1 << (pos % 64)
+}
+
+func (g *GroupTableIDIndex) addToBloom(tableID int64) {
+	for i := range bloomHashes {
+		word, mask := g.bloomPosition(tableID, i)
+		g.Bloom[word] |= mask
+	}
+}
+
+// MayContain returns whether the physical file may have the data of the table.
+func (g *GroupTableIDIndex) MayContain(tableID int64) bool {
+	if g.Unprunable {
+		return true
+	}
+	if tableID < g.MinTableID || tableID > g.MaxTableID || len(g.Bloom) == 0 {
+		return false
+	}
+	for i := range bloomHashes {
+		word, mask := g.bloomPosition(tableID, i)
+		if g.Bloom[word]&mask == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// MayContainAny returns whether the physical file may have the data of any of the tables.
+func (g *GroupTableIDIndex) MayContainAny(tableIDs map[int64]struct{}) bool {
+	if g.Unprunable {
+		return true
+	}
+	for tableID := range tableIDs {
+		if g.MayContain(tableID) {
+			return true
+		}
+	}
+	return false
+}
+
+func splitMix64(x uint64) uint64 {
+	x += 0x9e3779b97f4a7c15
+	x = (x ^ (x >> 30)) * 0xbf58476d1ce4e5b9
+	x = (x ^ (x >> 27)) * 0x94d049bb133111eb
+	return x ^ (x >> 31)
+}
+
+// WriteTableIDIndex builds and saves the table ID index of the metadata file.
+func WriteTableIDIndex(ctx context.Context, s storage.ExternalStorage, metaPath string, meta *backuppb.Metadata) error {
+	data, err := json.Marshal(BuildTableIDIndex(meta))
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Annotatef(s.WriteFile(ctx, metaPath+TableIDIndexSuffix, data),
+		"failed to write the table ID index of %s", metaPath)
+}
+
+// ReadTableIDIndex reads the table ID index of the metadata file, nil is returned if there is no index.
+func ReadTableIDIndex(ctx context.Context, s storage.ExternalStorage, metaPath string) (*TableIDIndex, error) {
+	path := metaPath + TableIDIndexSuffix
+	exists, err := s.FileExists(ctx, path)
+	if err != nil || !exists {
+		return nil, errors.Trace(err)
+	}
+	data, err := s.ReadFile(ctx, path)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	index := &TableIDIndex{}
+	if err := json.Unmarshal(data, index); err != nil {
+		return nil, errors.Annotatef(err, "failed to parse the table ID index %s", path)
+	}
+	index.groupByPath = make(map[string]*GroupTableIDIndex, len(index.Groups))
+	for _, g := range index.Groups {
+		index.groupByPath[g.Path] = g
+	}
+	return index, nil
+}
+
+// DeleteTableIDIndex deletes the table ID index of the metadata file if there is one.
+func DeleteTableIDIndex(ctx context.Context, s storage.ExternalStorage, metaPath string) error {
+	path := metaPath + TableIDIndexSuffix
+	exists, err := s.FileExists(ctx, path)
+	if err != nil || !exists {
+		return errors.Trace(err)
+	}
+	return errors.Annotatef(s.DeleteFile(ctx, path), "failed to delete the table ID index %s", path)
+}
+
+// Group returns the index of the physical file, nil is returned if the physical file isn't indexed.
+// The index must be read by ReadTableIDIndex.
+func (index *TableIDIndex) Group(path string) *GroupTableIDIndex {
+	if index == nil {
+		return nil
+	}
+	return index.groupByPath[path]
+}
+
+// BuildTableIDIndexes builds the table ID indexes of the metadata files without one in the storage, and
+// deletes the indexes whose metadata files have been deleted, e.g. by the BR not deleting the indexes.
+// It returns the number of the indexes built and deleted.
+func BuildTableIDIndexes(ctx context.Context, s storage.ExternalStorage) (built, deleted int, err error) {
+	metas := make([]string, 0)
+	indexes := make(map[string]struct{})
+	opt := &storage.WalkOption{SubDir: GetStreamBackupMetaPrefix()}
+	err = s.WalkDir(ctx, opt, func(path string, size int64) error {
+		if strings.HasSuffix(path, metaSuffix) {
+			metas = append(metas, path)
+		} else if strings.HasSuffix(path, metaSuffix+TableIDIndexSuffix) {
+			indexes[strings.TrimSuffix(path, TableIDIndexSuffix)] = struct{}{}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, 0, errors.Trace(err)
+	}
+
+	helper := NewMetadataHelper()
+	for _, path := range metas {
+		if _, ok := indexes[path]; ok {
+			delete(indexes, path)
+			continue
+		}
+		b, err := s.ReadFile(ctx, path)
+		if err != nil {
+			return built, deleted, errors.Annotatef(err, "during reading meta file %s from storage", path)
+		}
+		meta, err := helper.ParseToMetadata(b)
+		if err != nil {
+			return built, deleted, errors.Annotatef(err, "failed to parse meta file %s", path)
+		}
+		if err := WriteTableIDIndex(ctx, s, path, meta); err != nil {
+			return built, deleted, errors.Trace(err)
+		}
+		built++
+	}
+	// the remaining indexes have no metadata files.
+	for path := range indexes {
+		if err := s.DeleteFile(ctx, path+TableIDIndexSuffix); err != nil {
+			return built, deleted, errors.Annotatef(err, "failed to delete the table ID index of %s", path)
+		}
+		deleted++
+	}
+	return built, deleted, nil
+}
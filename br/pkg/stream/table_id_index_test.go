@@ -0,0 +1,93 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package stream
+
+import (
+	"context"
+	"path"
+	"testing"
+
+	backuppb "github.com/pingcap/kvproto/pkg/brpb"
+	"github.com/pingcap/tidb/br/pkg/storage"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTableIDIndex(t *testing.T) {
+	files := make([]*backuppb.DataFileInfo, 0, 100)
+	for i := range 100 {
+		files = append(files, &backuppb.DataFileInfo{TableId: int64(1000 + i*2)})
+	}
+	meta := &backuppb.Metadata{FileGroups: []*backuppb.DataFileGroup{
+		{Path: "g1", DataFilesInfo: files},
+		{Path: "g2", DataFilesInfo: []*backuppb.DataFileInfo{{TableId: 10}, {IsMeta: true}}},
+		{Path: "g3", DataFilesInfo: []*backuppb.DataFileInfo{{TableId: 10}, {TableId: 0}}},
+	}}
+	index := BuildTableIDIndex(meta)
+	require.Len(t, index.Groups, 3)
+
+	g := index.Groups[0]
+	require.False(t, g.Unprunable)
+	require.EqualValues(t, 1000, g.MinTableID)
+	require.EqualValues(t, 1198, g.MaxTableID)
+	for _, f := range files {
+		require.True(t, g.MayContain(f.TableId))
+	}
+	require.False(t, g.MayContain(999))
+	require.False(t, g.MayContain(1199))
+	falsePositives := 0
+	for i := range 99 {
+		if g.MayContain(int64(1001 + i*2)) {
+			falsePositives++
+		}
+	}
+	require.Less(t, falsePositives, 10)
+	require.True(t, g.MayContainAny(map[int64]struct{}{1: {}, 1100: {}}))
+	require.False(t, g.MayContainAny(map[int64]struct{}{1: {}, 2000: {}}))
+
+	require.True(t, index.Groups[1].Unprunable)
+	require.True(t, index.Groups[1].MayContainAny(map[int64]struct{}{1: {}}))
+	require.True(t, index.Groups[2].Unprunable)
+}
+
+func TestBuildTableIDIndexes(t *testing.T) {
+	ctx := context.Background()
+	s, err := storage.NewLocalStorage(t.TempDir())
+	require.NoError(t, err)
+	meta := &backuppb.Metadata{
+		MetaVersion: backuppb.MetaVersion_V2,
+		FileGroups: []*backuppb.DataFileGroup{
+			{Path: "g1", DataFilesInfo: []*backuppb.DataFileInfo{{TableId: 1}, {TableId: 2}}},
+		},
+	}
+	data, err := meta.Marshal()
+	require.NoError(t, err)
+	metaPath := path.Join(GetStreamBackupMetaPrefix(), "1.meta")
+	require.NoError(t, s.WriteFile(ctx, metaPath, data))
+
+	index, err := ReadTableIDIndex(ctx, s, metaPath)
+	require.NoError(t, err)
+	require.Nil(t, index)
+	require.Nil(t, index.Group("g1"))
+
+	// the index without the metadata file is deleted.
+	orphan := path.Join(GetStreamBackupMetaPrefix(), "2.meta") + TableIDIndexSuffix
+	require.NoError(t, s.WriteFile(ctx, orphan, []byte("{}")))
+	built, deleted, err := BuildTableIDIndexes(ctx, s)
+	require.NoError(t, err)
+	require.Equal(t, 1, built)
+	require.Equal(t, 1, deleted)
+	exists, err := s.FileExists(ctx, orphan)
+	require.NoError(t, err)
+	require.False(t, exists)
+	index, err = ReadTableIDIndex(ctx, s, metaPath)
+	require.NoError(t, err)
+	require.True(t, index.Group("g1").MayContain(2))
+	require.False(t, index.Group("g1").MayContain(3))
+	require.Nil(t, index.Group("g2"))
+
+	// the metadata files with indexes are skipped.
+	built, deleted, err = BuildTableIDIndexes(ctx, s)
+	require.NoError(t, err)
+	require.Equal(t, 0, built)
+	require.Equal(t, 0, deleted)
+}
@@ -180,6 +180,20 @@ func (tc *TableMappingManager) parseTableValueAndUpdateIdMapping(dbID int64, val
 	return nil
 }
 
+// MemoryUsage estimates the memory of the id maps in bytes.
+func (tc *TableMappingManager) MemoryUsage() int64 {
+	// the entry of the maps of IDs takes about 48 bytes, including the overhead of the buckets.
+	const idMapEntrySize = 48
+	size := int64(len(tc.globalIdMap)) * idMapEntrySize
+	for _, dr := range tc.DbReplaceMap {
+		size += int64(64 + len(dr.Name))
+		for _, tr := range dr.TableMap {
+			size += int64(96+len(tr.Name)) + int64(len(tr.PartitionMap)+len(tr.IndexMap))*idMapEntrySize
+		}
+	}
+	return size
+}
+
 // ToProto produces schemas id maps from up-stream to down-stream.
 func (tc *TableMappingManager) ToProto() []*backuppb.PitrDBMap {
 	dbMaps := make([]*backuppb.PitrDBMap, 0, len(tc.DbReplaceMap))
@@ -3,7 +3,7 @@
 package stream
 
 import (
-	"strings"
+	"bytes"
 	"time"
 )
 
@@ -14,13 +14,13 @@ func FormatDate(ts time.Time) string {
 }
 
 func IsMetaDBKey(key []byte) bool {
-	return strings.HasPrefix(string(key), "mDB")
+	return bytes.HasPrefix(key, []byte("mDB"))
 }
 
 func IsMetaDDLJobHistoryKey(key []byte) bool {
-	return strings.HasPrefix(string(key), "mDDLJobH")
+	return bytes.HasPrefix(key, []byte("mDDLJobH"))
 }
 
 func MaybeDBOrDDLJobHistoryKey(key []byte) bool {
-	return strings.HasPrefix(string(key), "mD")
+	return bytes.HasPrefix(key, []byte("mD"))
 }
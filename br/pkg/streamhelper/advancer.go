@@ -22,6 +22,7 @@ import (
 	"github.com/pingcap/tidb/br/pkg/utils"
 	"github.com/pingcap/tidb/pkg/kv"
 	"github.com/pingcap/tidb/pkg/metrics"
+	"github.com/pingcap/tidb/pkg/tablecodec"
 	"github.com/pingcap/tidb/pkg/util"
 	"github.com/pingcap/tidb/pkg/util/redact"
 	tikvstore "github.com/tikv/client-go/v2/kv"
@@ -58,28 +59,77 @@ import (
 type CheckpointAdvancer struct {
 	env Env
 
-	// The concurrency accessed task:
+	// The concurrency accessed tasks, indexed by the task name:
 	// both by the task listener and ticking.
-	task      *backuppb.StreamBackupTaskInfo
-	taskRange []kv.KeyRange
-	taskMu    sync.Mutex
+	tasks  map[string]*advancingTask
+	taskMu sync.Mutex
 
 	// the read-only config.
 	// once tick begin, this should not be changed for now.
 	cfg config.Config
 
+	// the checkpoints of the ranges of each task, indexed by the task name.
+	// the data ranges of the tasks are disjoint, but all of them observe the meta range.
+	checkpoints   map[string]*taskCheckpoints
+	checkpointsMu sync.Mutex
+
+	subscriber   *FlushSubscriber
+	subscriberMu sync.Mutex
+
+	// the archive used to handle the storage outages, nil if not set.
+	archive LogArchive
+}
+
+// taskCheckpoints is the checkpoints of the ranges of a task.
+type taskCheckpoints struct {
+	// dataRanges is the ranges observed by the task except the meta range shared by all the tasks,
+	// the flush events in them belong to the task.
+	dataRanges []kv.KeyRange
+	vsf        *spans.ValueSortedFull
+}
+
+// advancingTask is a log backup task whose checkpoint is being advanced.
+type advancingTask struct {
+	info     *backuppb.StreamBackupTaskInfo
+	ranges   []kv.KeyRange
+	isPaused bool
+
 	// the cached last checkpoint.
 	// if no progress, this cache can help us don't to send useless requests.
 	lastCheckpoint   *checkpoint
 	lastCheckpointMu sync.Mutex
 	inResolvingLock  atomic.Bool
-	isPaused         atomic.Bool
 
-	checkpoints   *spans.ValueSortedFull
-	checkpointsMu sync.Mutex
-
-	subscriber   *FlushSubscriber
-	subscriberMu sync.Mutex
+	// the storage outage of the task, synced by `taskMu`.
+	outage       *StorageOutage
+	outageLoaded bool
+	// the TSOs since the stores report the storage errors and of the last probe of the storage.
+	storageErrorSince uint64
+	lastStorageProbe  uint64
+	// the archive is verified in the background after a storage outage, the result is handled by the next
+	// tick. A failed verification is retried at `nextVerify`. `verifyMu` guards the fields set by the
+	// background verification.
+	verifyMu      sync.Mutex
+	verifying     bool
+	verifyDone    bool
+	verifyErr     error
+	verifyBackoff time.Duration
+	nextVerify    uint64
+	verifyGen     uint64
+}
+
+// updateLastCheckpoint modify the checkpoint in ticking.
+func (t *advancingTask) updateLastCheckpoint(p *checkpoint) {
+	t.lastCheckpointMu.Lock()
+	t.lastCheckpoint = p
+	t.lastCheckpointMu.Unlock()
+}
+
+// getLastCheckpoint returns the cached last checkpoint.
+func (t *advancingTask) getLastCheckpoint() *checkpoint {
+	t.lastCheckpointMu.Lock()
+	defer t.lastCheckpointMu.Unlock()
+	return t.lastCheckpoint
 }
 
 // HasTask returns whether the advancer has been bound to a task.
@@ -87,7 +137,7 @@ func (c *CheckpointAdvancer) HasTask() bool {
 	c.taskMu.Lock()
 	defer c.taskMu.Unlock()
 
-	return c.task != nil
+	return len(c.tasks) > 0
 }
 
 // HasSubscriptions returns whether the advancer is associated with a subscriber.
@@ -148,8 +198,10 @@ func (c *checkpoint) needResolveLocks() bool {
 // NewCheckpointAdvancer creates a checkpoint advancer with the env.
 func NewCheckpointAdvancer(env Env) *CheckpointAdvancer {
 	return &CheckpointAdvancer{
-		env: env,
-		cfg: config.Default(),
+		env:         env,
+		cfg:         config.Default(),
+		tasks:       make(map[string]*advancingTask),
+		checkpoints: make(map[string]*taskCheckpoints),
 	}
 }
 
@@ -168,13 +220,6 @@ func (c *CheckpointAdvancer) UpdateConfigWith(f func(*config.Config)) {
 	c.UpdateConfig(cfg)
 }
 
-// UpdateLastCheckpoint modify the checkpoint in ticking.
-func (c *CheckpointAdvancer) UpdateLastCheckpoint(p *checkpoint) {
-	c.lastCheckpointMu.Lock()
-	c.lastCheckpoint = p
-	c.lastCheckpointMu.Unlock()
-}
-
 // Config returns the current config.
 func (c *CheckpointAdvancer) Config() config.Config {
 	return c.cfg
@@ -182,7 +227,14 @@ func (c *CheckpointAdvancer) Config() config.Config {
 
 // GetInResolvingLock only used for test.
 func (c *CheckpointAdvancer) GetInResolvingLock() bool {
-	return c.inResolvingLock.Load()
+	c.taskMu.Lock()
+	defer c.taskMu.Unlock()
+	for _, t := range c.tasks {
+		if t.inResolvingLock.Load() {
+			return true
+		}
+	}
+	return false
 }
 
 // GetCheckpointInRange scans the regions in the range,
@@ -220,7 +272,7 @@ func (c *CheckpointAdvancer) recordTimeCost(message string, fields ...zap.Field)
 }
 
 // tryAdvance tries to advance the checkpoint ts of a set of ranges which shares the same checkpoint.
-func (c *CheckpointAdvancer) tryAdvance(ctx context.Context, length int,
+func (c *CheckpointAdvancer) tryAdvance(ctx context.Context, t *advancingTask, length int,
 	getRange func(int) kv.KeyRange) (err error) {
 	defer c.recordTimeCost("try advance", zap.Int("len", length))()
 	defer utils.PanicToErr(&err)
@@ -230,11 +282,11 @@ func (c *CheckpointAdvancer) tryAdvance(ctx context.Context, length int,
 	eg, cx := errgroup.WithContext(ctx)
 	collector := NewClusterCollector(ctx, c.env)
 	collector.SetOnSuccessHook(func(u uint64, kr kv.KeyRange) {
-		c.checkpointsMu.Lock()
-		defer c.checkpointsMu.Unlock()
-		c.checkpoints.Merge(spans.Valued{Key: kr, Value: u})
+		c.withTaskCheckpoints(t.info.Name, func(vsf *spans.ValueSortedFull) {
+			vsf.Merge(spans.Valued{Key: kr, Value: u})
+		})
 	})
-	clampedRanges := utils.IntersectAll(ranges, slices.Clone(c.taskRange))
+	clampedRanges := utils.IntersectAll(ranges, slices.Clone(t.ranges))
 	for _, r := range clampedRanges {
 		workers.ApplyOnErrorGroup(eg, func() (e error) {
 			defer c.recordTimeCost("get regions in range")()
@@ -263,11 +315,68 @@ func tsoAfter(ts uint64, n time.Duration) uint64 {
 	return oracle.GoTimeToTS(oracle.GetTimeFromTS(ts).Add(n))
 }
 
+// WithCheckpoints calls f with the checkpoints of each task.
 func (c *CheckpointAdvancer) WithCheckpoints(f func(*spans.ValueSortedFull)) {
 	c.checkpointsMu.Lock()
 	defer c.checkpointsMu.Unlock()
 
-	f(c.checkpoints)
+	for _, cps := range c.checkpoints {
+		f(cps.vsf)
+	}
+}
+
+func (c *CheckpointAdvancer) withTaskCheckpoints(taskName string, f func(*spans.ValueSortedFull)) {
+	c.checkpointsMu.Lock()
+	defer c.checkpointsMu.Unlock()
+
+	if cps, ok := c.checkpoints[taskName]; ok {
+		f(cps.vsf)
+	}
+}
+
+// mergeFlushEvent merges the flush event into the checkpoints of the tasks it belongs to. The flush
+// event doesn't tell the task, so it's routed by the range: the part in the data ranges of a task is
+// merged into the task. The part in the meta range can't be told apart if there are several tasks,
+// so it's dropped and the meta range is advanced by polling. It returns false if no task accepts it.
+func (c *CheckpointAdvancer) mergeFlushEvent(event spans.Valued) bool {
+	c.checkpointsMu.Lock()
+	defer c.checkpointsMu.Unlock()
+
+	if len(c.checkpoints) == 1 {
+		for _, cps := range c.checkpoints {
+			cps.vsf.Merge(event)
+		}
+		return true
+	}
+	accepted := false
+	for _, cps := range c.checkpoints {
+		for _, r := range utils.IntersectAll([]kv.KeyRange{event.Key}, slices.Clone(cps.dataRanges)) {
+			cps.vsf.Merge(spans.Valued{Key: r, Value: event.Value})
+			accepted = true
+		}
+	}
+	return accepted
+}
+
+// excludeMetaRange returns the ranges without the meta range.
+func excludeMetaRange(ranges []kv.KeyRange) []kv.KeyRange {
+	metaStart := kv.Key(tablecodec.MetaPrefix())
+	metaEnd := metaStart.PrefixNext()
+	meta := kv.KeyRange{StartKey: metaStart, EndKey: metaEnd}
+	result := make([]kv.KeyRange, 0, len(ranges)+1)
+	for _, r := range ranges {
+		if !spans.Overlaps(r, meta) {
+			result = append(result, r)
+			continue
+		}
+		if bytes.Compare(r.StartKey, metaStart) < 0 {
+			result = append(result, kv.KeyRange{StartKey: r.StartKey, EndKey: metaStart})
+		}
+		if len(r.EndKey) == 0 || bytes.Compare(r.EndKey, metaEnd) > 0 {
+			result = append(result, kv.KeyRange{StartKey: metaEnd, EndKey: r.EndKey})
+		}
+	}
+	return result
 }
 
 func (c *CheckpointAdvancer) fetchRegionHint(ctx context.Context, startKey []byte) string {
@@ -288,12 +397,12 @@ func (c *CheckpointAdvancer) fetchRegionHint(ctx context.Context, startKey []byt
 		prs, logutil.StringifyRangeOf(r.GetStartKey(), r.GetEndKey()))
 }
 
-func (c *CheckpointAdvancer) CalculateGlobalCheckpointLight(ctx context.Context,
+func (c *CheckpointAdvancer) calculateGlobalCheckpointLight(ctx context.Context, t *advancingTask,
 	threshold time.Duration) (spans.Valued, error) {
 	var targets []spans.Valued
 	var minValue spans.Valued
 	thresholdTso := tsoBefore(threshold)
-	c.WithCheckpoints(func(vsf *spans.ValueSortedFull) {
+	c.withTaskCheckpoints(t.info.Name, func(vsf *spans.ValueSortedFull) {
 		vsf.TraverseValuesLessThan(thresholdTso, func(v spans.Valued) bool {
 			targets = append(targets, v)
 			return true
@@ -308,7 +417,7 @@ func (c *CheckpointAdvancer) CalculateGlobalCheckpointLight(ctx context.Context,
 		logger = log.Info
 	}
 	logger("current last region", zap.String("category", "log backup advancer hint"),
-		zap.Stringer("min", minValue), zap.Int("for-polling", len(targets)),
+		zap.String("task", t.info.Name), zap.Stringer("min", minValue), zap.Int("for-polling", len(targets)),
 		zap.String("min-ts", oracle.GetTimeFromTS(minValue.Value).Format(time.RFC3339)),
 		zap.String("region-hint", hint),
 	)
@@ -316,7 +425,7 @@ func (c *CheckpointAdvancer) CalculateGlobalCheckpointLight(ctx context.Context,
 	if len(targets) == 0 {
 		return minValue, nil
 	}
-	err := c.tryAdvance(ctx, len(targets), func(i int) kv.KeyRange { return targets[i].Key })
+	err := c.tryAdvance(ctx, t, len(targets), func(i int) kv.KeyRange { return targets[i].Key })
 	if err != nil {
 		return minValue, err
 	}
@@ -404,10 +513,30 @@ func (c *CheckpointAdvancer) StartTaskListener(ctx context.Context) {
 	}()
 }
 
-func (c *CheckpointAdvancer) setCheckpoints(cps *spans.ValueSortedFull) {
+// setCheckpoints resets the checkpoints of the task observing the ranges, or removes them if ranges is nil.
+func (c *CheckpointAdvancer) setCheckpoints(taskName string, ranges []kv.KeyRange) {
 	c.checkpointsMu.Lock()
-	c.checkpoints = cps
-	c.checkpointsMu.Unlock()
+	defer c.checkpointsMu.Unlock()
+	if ranges == nil {
+		delete(c.checkpoints, taskName)
+		return
+	}
+	c.checkpoints[taskName] = &taskCheckpoints{
+		dataRanges: excludeMetaRange(ranges),
+		vsf:        spans.Sorted(spans.NewFullWith(ranges, 0)),
+	}
+}
+
+// gcSafePointTarget returns the GC safe point blocking none of the tasks.
+func (c *CheckpointAdvancer) gcSafePointTarget() (uint64, bool) {
+	target, ok := uint64(0), false
+	for _, t := range c.tasks {
+		safeTS := t.getLastCheckpoint().safeTS()
+		if !ok || safeTS < target {
+			target, ok = safeTS, true
+		}
+	}
+	return target, ok
 }
 
 func (c *CheckpointAdvancer) onTaskEvent(ctx context.Context, e TaskEvent) error {
@@ -415,51 +544,64 @@ func (c *CheckpointAdvancer) onTaskEvent(ctx context.Context, e TaskEvent) error
 	defer c.taskMu.Unlock()
 	switch e.Type {
 	case EventAdd:
-		utils.LogBackupTaskCountInc()
-		c.task = e.Info
-		c.taskRange = spans.Collapse(len(e.Ranges), func(i int) kv.KeyRange { return e.Ranges[i] })
-		c.setCheckpoints(spans.Sorted(spans.NewFullWith(e.Ranges, 0)))
+		if _, ok := c.tasks[e.Name]; !ok {
+			utils.LogBackupTaskCountInc()
+		}
+		t := &advancingTask{
+			info:   e.Info,
+			ranges: spans.Collapse(len(e.Ranges), func(i int) kv.KeyRange { return e.Ranges[i] }),
+		}
+		c.setCheckpoints(e.Name, t.ranges)
 		globalCheckpointTs, err := c.env.GetGlobalCheckpointForTask(ctx, e.Name)
 		if err != nil {
 			// ignore the error, just log it
 			log.Warn("failed to get global checkpoint, skipping.", logutil.ShortError(err))
 		}
-		if globalCheckpointTs < c.task.StartTs {
-			globalCheckpointTs = c.task.StartTs
+		if globalCheckpointTs < t.info.StartTs {
+			globalCheckpointTs = t.info.StartTs
 		}
-		log.Info("get global checkpoint", zap.Uint64("checkpoint", globalCheckpointTs))
-		c.lastCheckpoint = newCheckpointWithTS(globalCheckpointTs)
-		p, err := c.env.BlockGCUntil(ctx, globalCheckpointTs-1)
+		log.Info("get global checkpoint", zap.String("task", e.Name), zap.Uint64("checkpoint", globalCheckpointTs))
+		t.lastCheckpoint = newCheckpointWithTS(globalCheckpointTs)
+		c.tasks[e.Name] = t
+		target, _ := c.gcSafePointTarget()
+		p, err := c.env.BlockGCUntil(ctx, target)
 		if err != nil {
 			log.Warn("failed to upload service GC safepoint, skipping.", logutil.ShortError(err))
 		}
 		log.Info("added event", zap.Stringer("task", redact.TaskInfoRedacted{Info: e.Info}),
-			zap.Stringer("ranges", logutil.StringifyKeys(c.taskRange)), zap.Uint64("current-checkpoint", p))
+			zap.Stringer("ranges", logutil.StringifyKeys(t.ranges)), zap.Uint64("current-checkpoint", p))
 	case EventDel:
-		utils.LogBackupTaskCountDec()
-		c.task = nil
-		c.isPaused.Store(false)
-		c.taskRange = nil
+		if _, ok := c.tasks[e.Name]; ok {
+			utils.LogBackupTaskCountDec()
+		}
+		delete(c.tasks, e.Name)
+		// The subscriptions are shared by the tasks, so they're only cleared with the last task, the
+		// stale events of the removed task are dropped for no task observes the range.
 		// This would be synced by `taskMu`, perhaps we'd better rename that to `tickMu`.
 		// Do the null check because some of test cases won't equip the advancer with subscriber.
-		if c.subscriber != nil {
+		if c.subscriber != nil && len(c.tasks) == 0 {
 			c.subscriber.Clear()
 		}
-		c.setCheckpoints(nil)
+		c.setCheckpoints(e.Name, nil)
 		if err := c.env.ClearV3GlobalCheckpointForTask(ctx, e.Name); err != nil {
 			log.Warn("failed to clear global checkpoint", logutil.ShortError(err))
 		}
-		if err := c.env.UnblockGC(ctx); err != nil {
+		if target, ok := c.gcSafePointTarget(); ok {
+			// the other tasks still need the GC safe point.
+			if _, err := c.env.BlockGCUntil(ctx, target); err != nil {
+				log.Warn("failed to upload service GC safepoint", logutil.ShortError(err))
+			}
+		} else if err := c.env.UnblockGC(ctx); err != nil {
 			log.Warn("failed to remove service GC safepoint", logutil.ShortError(err))
 		}
 		metrics.LastCheckpoint.DeleteLabelValues(e.Name)
 	case EventPause:
-		if c.task.GetName() == e.Name {
-			c.isPaused.Store(true)
+		if t, ok := c.tasks[e.Name]; ok {
+			t.isPaused = true
 		}
 	case EventResume:
-		if c.task.GetName() == e.Name {
-			c.isPaused.Store(false)
+		if t, ok := c.tasks[e.Name]; ok {
+			t.isPaused = false
 		}
 	case EventErr:
 		return e.Err
@@ -467,25 +609,25 @@ func (c *CheckpointAdvancer) onTaskEvent(ctx context.Context, e TaskEvent) error
 	return nil
 }
 
-func (c *CheckpointAdvancer) setCheckpoint(s spans.Valued) bool {
+func (c *CheckpointAdvancer) setCheckpoint(t *advancingTask, s spans.Valued) bool {
 	cp := newCheckpointWithSpan(s)
-	if cp.TS < c.lastCheckpoint.TS {
-		log.Warn("failed to update global checkpoint: stale",
-			zap.Uint64("old", c.lastCheckpoint.TS), zap.Uint64("new", cp.TS))
+	if cp.TS < t.lastCheckpoint.TS {
+		log.Warn("failed to update global checkpoint: stale", zap.String("task", t.info.Name),
+			zap.Uint64("old", t.lastCheckpoint.TS), zap.Uint64("new", cp.TS))
 		return false
 	}
 	// Need resolve lock for different range and same TS
 	// so check the range and TS here.
-	if cp.equal(c.lastCheckpoint) {
+	if cp.equal(t.lastCheckpoint) {
 		return false
 	}
-	c.UpdateLastCheckpoint(cp)
-	metrics.LastCheckpoint.WithLabelValues(c.task.GetName()).Set(float64(c.lastCheckpoint.TS))
+	t.updateLastCheckpoint(cp)
+	metrics.LastCheckpoint.WithLabelValues(t.info.GetName()).Set(float64(t.lastCheckpoint.TS))
 	return true
 }
 
 // advanceCheckpointBy advances the checkpoint by a checkpoint getter function.
-func (c *CheckpointAdvancer) advanceCheckpointBy(ctx context.Context,
+func (c *CheckpointAdvancer) advanceCheckpointBy(ctx context.Context, t *advancingTask,
 	getCheckpoint func(context.Context) (spans.Valued, error)) error {
 	start := time.Now()
 	cp, err := getCheckpoint(ctx)
@@ -493,11 +635,11 @@ func (c *CheckpointAdvancer) advanceCheckpointBy(ctx context.Context,
 		return err
 	}
 
-	if c.setCheckpoint(cp) {
+	if c.setCheckpoint(t, cp) {
 		log.Info("uploading checkpoint for task",
 			zap.Stringer("checkpoint", oracle.GetTimeFromTS(cp.Value)),
 			zap.Uint64("checkpoint", cp.Value),
-			zap.String("task", c.task.Name),
+			zap.String("task", t.info.Name),
 			zap.Stringer("take", time.Since(start)))
 	}
 	return nil
@@ -528,17 +670,14 @@ func (c *CheckpointAdvancer) SpawnSubscriptionHandler(ctx context.Context) {
 					return
 				}
 				failpoint.Inject("subscription-handler-loop", func() {})
-				c.WithCheckpoints(func(vsf *spans.ValueSortedFull) {
-					if vsf == nil {
-						log.Warn("Span tree not found, perhaps stale event of removed tasks.",
-							zap.String("category", "log backup subscription manager"))
-						return
-					}
-					log.Debug("Accepting region flush event.",
-						zap.Stringer("range", logutil.StringifyRange(event.Key)),
-						zap.Uint64("checkpoint", event.Value))
-					vsf.Merge(event)
-				})
+				if !c.mergeFlushEvent(event) {
+					log.Warn("Span tree not found, perhaps stale event of removed tasks.",
+						zap.String("category", "log backup subscription manager"))
+					continue
+				}
+				log.Debug("Accepted region flush event.",
+					zap.Stringer("range", logutil.StringifyRange(event.Key)),
+					zap.Uint64("checkpoint", event.Value))
 			}
 		}
 	}()
@@ -559,15 +698,15 @@ func (c *CheckpointAdvancer) subscribeTick(ctx context.Context) error {
 	return c.subscriber.PendingErrors()
 }
 
-func (c *CheckpointAdvancer) isCheckpointLagged(ctx context.Context) (bool, error) {
+func (c *CheckpointAdvancer) isCheckpointLagged(ctx context.Context, t *advancingTask) (bool, error) {
 	if c.cfg.CheckPointLagLimit <= 0 {
 		return false, nil
 	}
-	globalTs, err := c.env.GetGlobalCheckpointForTask(ctx, c.task.Name)
+	globalTs, err := c.env.GetGlobalCheckpointForTask(ctx, t.info.Name)
 	if err != nil {
 		return false, err
 	}
-	if globalTs < c.task.StartTs {
+	if globalTs < t.info.StartTs {
 		// unreachable.
 		return false, nil
 	}
@@ -580,118 +719,134 @@ func (c *CheckpointAdvancer) isCheckpointLagged(ctx context.Context) (bool, erro
 	lagDuration := oracle.GetTimeFromTS(now).Sub(oracle.GetTimeFromTS(globalTs))
 	if lagDuration > c.cfg.CheckPointLagLimit {
 		log.Warn("checkpoint lag is too large", zap.String("category", "log backup advancer"),
-			zap.Stringer("lag", lagDuration))
+			zap.String("task", t.info.Name), zap.Stringer("lag", lagDuration))
 		return true, nil
 	}
 	return false, nil
 }
 
-func (c *CheckpointAdvancer) importantTick(ctx context.Context) error {
-	c.checkpointsMu.Lock()
-	c.setCheckpoint(c.checkpoints.Min())
-	c.checkpointsMu.Unlock()
-	if err := c.env.UploadV3GlobalCheckpointForTask(ctx, c.task.Name, c.lastCheckpoint.TS); err != nil {
+func (c *CheckpointAdvancer) importantTick(ctx context.Context, t *advancingTask) error {
+	c.withTaskCheckpoints(t.info.Name, func(vsf *spans.ValueSortedFull) {
+		c.setCheckpoint(t, vsf.Min())
+	})
+	if err := c.env.UploadV3GlobalCheckpointForTask(ctx, t.info.Name, t.lastCheckpoint.TS); err != nil {
 		return errors.Annotate(err, "failed to upload global checkpoint")
 	}
-	isLagged, err := c.isCheckpointLagged(ctx)
+	isLagged, err := c.isCheckpointLagged(ctx, t)
 	if err != nil {
 		// ignore the error, just log it
 		log.Warn("failed to check timestamp", logutil.ShortError(err))
 	}
-	if isLagged {
-		err := c.env.PauseTask(ctx, c.task.Name)
+	if isLagged && !t.inStorageOutage() {
+		err := c.env.PauseTask(ctx, t.info.Name)
 		if err != nil {
 			return errors.Annotate(err, "failed to pause task")
 		}
 		return errors.Annotate(errors.Errorf("check point lagged too large"), "check point lagged too large")
 	}
-	p, err := c.env.BlockGCUntil(ctx, c.lastCheckpoint.safeTS())
+	// the GC safe point is shared by all the tasks.
+	target, _ := c.gcSafePointTarget()
+	p, err := c.env.BlockGCUntil(ctx, target)
 	if err != nil {
 		return errors.Annotatef(err,
 			"failed to update service GC safe point, current checkpoint is %d, target checkpoint is %d",
-			c.lastCheckpoint.safeTS(), p)
+			target, p)
 	}
-	if p <= c.lastCheckpoint.safeTS() {
+	if p <= target {
 		log.Info("updated log backup GC safe point.",
-			zap.Uint64("checkpoint", p), zap.Uint64("target", c.lastCheckpoint.safeTS()))
+			zap.Uint64("checkpoint", p), zap.Uint64("target", target))
 	}
-	if p > c.lastCheckpoint.safeTS() {
+	if p > target {
 		log.Warn("update log backup GC safe point failed: stale.",
-			zap.Uint64("checkpoint", p), zap.Uint64("target", c.lastCheckpoint.safeTS()))
+			zap.Uint64("checkpoint", p), zap.Uint64("target", target))
 	}
 	return nil
 }
 
-func (c *CheckpointAdvancer) optionalTick(cx context.Context) error {
+func (c *CheckpointAdvancer) optionalTick(cx context.Context, t *advancingTask, threshold time.Duration) error {
 	// lastCheckpoint is not increased too long enough.
 	// assume the cluster has expired locks for whatever reasons.
 	var targets []spans.Valued
-	if c.lastCheckpoint != nil && c.lastCheckpoint.needResolveLocks() && c.inResolvingLock.CompareAndSwap(false, true) {
-		c.WithCheckpoints(func(vsf *spans.ValueSortedFull) {
+	if t.lastCheckpoint != nil && t.lastCheckpoint.needResolveLocks() && t.inResolvingLock.CompareAndSwap(false, true) {
+		c.withTaskCheckpoints(t.info.Name, func(vsf *spans.ValueSortedFull) {
 			// when get locks here. assume these locks are not belong to same txn,
 			// but these locks' start ts are close to 1 minute. try resolve these locks at one time
-			vsf.TraverseValuesLessThan(tsoAfter(c.lastCheckpoint.TS, time.Minute), func(v spans.Valued) bool {
+			vsf.TraverseValuesLessThan(tsoAfter(t.lastCheckpoint.TS, time.Minute), func(v spans.Valued) bool {
 				targets = append(targets, v)
 				return true
 			})
 		})
 		if len(targets) != 0 {
-			log.Info("Advancer starts to resolve locks", zap.Int("targets", len(targets)))
+			log.Info("Advancer starts to resolve locks", zap.String("task", t.info.Name), zap.Int("targets", len(targets)))
 			// use new context here to avoid timeout
 			ctx := context.Background()
-			c.asyncResolveLocksForRanges(ctx, targets)
+			c.asyncResolveLocksForRanges(ctx, t, targets)
 		} else {
 			// don't forget set state back
-			c.inResolvingLock.Store(false)
+			t.inResolvingLock.Store(false)
 		}
 	}
-	threshold := c.Config().GetDefaultStartPollThreshold()
-	if err := c.subscribeTick(cx); err != nil {
-		log.Warn("Subscriber meet error, would polling the checkpoint.", zap.String("category", "log backup advancer"),
-			logutil.ShortError(err))
-		threshold = c.Config().GetSubscriberErrorStartPollThreshold()
-	}
 
-	return c.advanceCheckpointBy(cx, func(cx context.Context) (spans.Valued, error) {
-		return c.CalculateGlobalCheckpointLight(cx, threshold)
+	return c.advanceCheckpointBy(cx, t, func(cx context.Context) (spans.Valued, error) {
+		return c.calculateGlobalCheckpointLight(cx, t, threshold)
 	})
 }
 
 func (c *CheckpointAdvancer) tick(ctx context.Context) error {
 	c.taskMu.Lock()
 	defer c.taskMu.Unlock()
-	if c.task == nil || c.isPaused.Load() {
+	c.tickStorageOutages(ctx)
+	tasks := make([]*advancingTask, 0, len(c.tasks))
+	for _, t := range c.tasks {
+		if !t.isPaused {
+			tasks = append(tasks, t)
+		}
+	}
+	if len(tasks) == 0 {
 		log.Info("No tasks yet, skipping advancing.")
 		return nil
 	}
+	slices.SortFunc(tasks, func(a, b *advancingTask) int {
+		return strings.Compare(a.info.Name, b.info.Name)
+	})
 
 	var errs error
 
 	cx, cancel := context.WithTimeout(ctx, c.Config().TickTimeout())
 	defer cancel()
-	err := c.optionalTick(cx)
-	if err != nil {
-		log.Warn("option tick failed.", zap.String("category", "log backup advancer"), logutil.ShortError(err))
-		errs = multierr.Append(errs, err)
+	threshold := c.Config().GetDefaultStartPollThreshold()
+	if err := c.subscribeTick(cx); err != nil {
+		log.Warn("Subscriber meet error, would polling the checkpoint.", zap.String("category", "log backup advancer"),
+			logutil.ShortError(err))
+		threshold = c.Config().GetSubscriberErrorStartPollThreshold()
 	}
+	for _, t := range tasks {
+		err := c.optionalTick(cx, t, threshold)
+		if err != nil {
+			log.Warn("option tick failed.", zap.String("category", "log backup advancer"),
+				zap.String("task", t.info.Name), logutil.ShortError(err))
+			errs = multierr.Append(errs, err)
+		}
 
-	err = c.importantTick(ctx)
-	if err != nil {
-		log.Warn("important tick failed.", zap.String("category", "log backup advancer"), logutil.ShortError(err))
-		errs = multierr.Append(errs, err)
+		err = c.importantTick(ctx, t)
+		if err != nil {
+			log.Warn("important tick failed.", zap.String("category", "log backup advancer"),
+				zap.String("task", t.info.Name), logutil.ShortError(err))
+			errs = multierr.Append(errs, err)
+		}
 	}
 
 	return errs
 }
 
-func (c *CheckpointAdvancer) asyncResolveLocksForRanges(ctx context.Context, targets []spans.Valued) {
+func (c *CheckpointAdvancer) asyncResolveLocksForRanges(ctx context.Context, t *advancingTask, targets []spans.Valued) {
 	// run in another goroutine
 	// do not block main tick here
 	go func() {
 		failpoint.Inject("AsyncResolveLocks", func() {})
 		maxTs := uint64(0)
-		for _, t := range targets {
-			maxTs = max(maxTs, t.Value)
+		for _, target := range targets {
+			maxTs = max(maxTs, target.Value)
 		}
 		handler := func(ctx context.Context, r tikvstore.KeyRange) (rangetask.TaskStat, error) {
 			// we will scan all locks and try to resolve them by check txn status.
@@ -723,13 +878,14 @@ func (c *CheckpointAdvancer) asyncResolveLocksForRanges(ctx context.Context, tar
 		wg.Wait()
 		log.Info("finish resolve locks for checkpoint", zap.String("category", "advancer"),
 			zap.String("uuid", "log backup advancer"),
-			logutil.Key("StartKey", c.lastCheckpoint.StartKey),
-			logutil.Key("EndKey", c.lastCheckpoint.EndKey),
+			zap.String("task", t.info.Name),
+			logutil.Key("StartKey", t.lastCheckpoint.StartKey),
+			logutil.Key("EndKey", t.lastCheckpoint.EndKey),
 			zap.Int("targets", len(targets)))
-		c.lastCheckpointMu.Lock()
-		c.lastCheckpoint.resolveLockTime = time.Now()
-		c.lastCheckpointMu.Unlock()
-		c.inResolvingLock.Store(false)
+		t.lastCheckpointMu.Lock()
+		t.lastCheckpoint.resolveLockTime = time.Now()
+		t.lastCheckpointMu.Unlock()
+		t.inResolvingLock.Store(false)
 	}()
 }
 
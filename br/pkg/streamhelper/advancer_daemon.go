@@ -3,9 +3,11 @@
 package streamhelper
 
 import (
+	"bytes"
 	"context"
 
 	"github.com/google/uuid"
+	"github.com/pingcap/errors"
 	"github.com/pingcap/tidb/br/pkg/utils"
 	"github.com/pingcap/tidb/pkg/metrics"
 	"github.com/pingcap/tidb/pkg/owner"
@@ -56,3 +58,17 @@ func OwnerManagerForLogBackup(ctx context.Context, etcdCli *clientv3.Client) own
 	id := uuid.New()
 	return owner.NewOwnerManager(ctx, etcdCli, ownerPrompt, id.String(), ownerPath)
 }
+
+// GetAdvancerOwner returns the ID of the owner of the advancer, or an empty string if there isn't an owner.
+func (c *MetaDataClient) GetAdvancerOwner(ctx context.Context) (string, error) {
+	resp, err := c.Get(ctx, ownerPath, clientv3.WithFirstCreate()...)
+	if err != nil {
+		return "", errors.Annotate(err, "failed to get the owner of the advancer")
+	}
+	if len(resp.Kvs) == 0 {
+		return "", nil
+	}
+	// the value may be suffixed with the operation of the owner like `{id}_{op}`.
+	id, _, _ := bytes.Cut(resp.Kvs[0].Value, []byte("_"))
+	return string(id), nil
+}
@@ -23,7 +23,8 @@ import (
 )
 
 const (
-	logBackupServiceID    = "log-backup-coordinator"
+	// LogBackupServiceID is the ID of the service GC safe point set by the advancer.
+	LogBackupServiceID    = "log-backup-coordinator"
 	logBackupSafePointTTL = 24 * time.Hour
 )
 
@@ -50,7 +51,7 @@ type PDRegionScanner struct {
 // If the arguments is `0`, this would remove the service safe point.
 func (c PDRegionScanner) BlockGCUntil(ctx context.Context, at uint64) (uint64, error) {
 	minimalSafePoint, err := c.UpdateServiceGCSafePoint(
-		ctx, logBackupServiceID, int64(logBackupSafePointTTL.Seconds()), at)
+		ctx, LogBackupServiceID, int64(logBackupSafePointTTL.Seconds()), at)
 	if err != nil {
 		return 0, errors.Annotate(err, "failed to block gc until")
 	}
@@ -62,7 +63,7 @@ func (c PDRegionScanner) BlockGCUntil(ctx context.Context, at uint64) (uint64, e
 
 func (c PDRegionScanner) UnblockGC(ctx context.Context) error {
 	// set ttl to 0, means remove the safe point.
-	_, err := c.UpdateServiceGCSafePoint(ctx, logBackupServiceID, 0, math.MaxUint64)
+	_, err := c.UpdateServiceGCSafePoint(ctx, LogBackupServiceID, 0, math.MaxUint64)
 	return err
 }
 
@@ -115,6 +116,7 @@ type clusterEnv struct {
 }
 
 var _ Env = &clusterEnv{}
+var _ StorageOutageMeta = &clusterEnv{}
 
 // GetLogBackupClient gets the log backup client.
 func (t clusterEnv) GetLogBackupClient(ctx context.Context, storeID uint64) (logbackup.LogBackupClient, error) {
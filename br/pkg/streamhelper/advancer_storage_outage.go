@@ -0,0 +1,306 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package streamhelper
+
+import (
+	"context"
+	"time"
+
+	"github.com/pingcap/errors"
+	backuppb "github.com/pingcap/kvproto/pkg/brpb"
+	"github.com/pingcap/log"
+	berrors "github.com/pingcap/tidb/br/pkg/errors"
+	"github.com/pingcap/tidb/br/pkg/logutil"
+	"github.com/tikv/client-go/v2/oracle"
+	"go.uber.org/zap"
+)
+
+/*
+The storage outage of a log backup task is handled by the advancer like:
+
+  - normal: once the stores keep reporting the storage errors for `StorageOutageLimit`, or TiKV pauses the task
+    for a storage error, the outage and the current checkpoint are recorded and the task is paused.
+  - paused: the storage is probed every `StorageOutageProbeInterval`, the task is resumed once it recovers.
+  - catching up: once the checkpoint passes the time the task is resumed, the archive since the recorded
+    checkpoint is verified in the background, and retried with backoff if the verification fails. If there
+    are gaps, the task is paused again and left to the user.
+
+The outage is recorded in the metadata storage, so the next owner of the advancer can continue it. The storage
+outages are handled only if the archive is set by `SetLogArchive`.
+*/
+
+// maxArchiveVerifyBackoff is the max gap between the retries of verifying the archive.
+const maxArchiveVerifyBackoff = 30 * time.Minute
+
+// StorageOutage is a storage outage of a log backup task recorded by the advancer.
+type StorageOutage struct {
+	// Checkpoint is the global checkpoint when the task is paused, the archive since it is verified
+	// once the task catches up.
+	Checkpoint uint64 `json:"checkpoint"`
+	PausedTS   uint64 `json:"paused_ts"`
+	// ResumedTS is zero until the storage recovers and the task is resumed.
+	ResumedTS uint64 `json:"resumed_ts"`
+	Error     string `json:"error"`
+	// GapError is set if the archive has gaps after the task catches up, the task won't be resumed
+	// automatically then.
+	GapError string `json:"gap_error,omitempty"`
+}
+
+// StorageOutageMeta is the metadata the advancer needs to handle the storage outages. The advancer won't
+// handle them if the env doesn't implement it.
+type StorageOutageMeta interface {
+	LastErrorsOfTask(ctx context.Context, taskName string) (map[uint64]backuppb.StreamBackupError, error)
+	ResumeTask(ctx context.Context, taskName string) error
+	CleanLastErrorOfTask(ctx context.Context, taskName string) error
+	GetStorageOutage(ctx context.Context, taskName string) (*StorageOutage, error)
+	PutStorageOutage(ctx context.Context, taskName string, outage *StorageOutage) error
+	DeleteStorageOutage(ctx context.Context, taskName string) error
+}
+
+// LogArchive accesses the external storage the log backup task archives to.
+type LogArchive interface {
+	// Probe checks whether the storage is available.
+	Probe(ctx context.Context, info *backuppb.StreamBackupTaskInfo) error
+	// VerifyNoGaps checks the archive between the timestamps is continuous, i.e. the files flushed by each
+	// store cover the range continuously and all exist. It returns `ErrPiTRArchiveGap` if there are gaps.
+	VerifyNoGaps(ctx context.Context, info *backuppb.StreamBackupTaskInfo, since, until uint64) error
+}
+
+// SetLogArchive sets the archive used to probe the storage and verify the archive after a storage outage.
+// Without it, the storage outages aren't handled.
+func (c *CheckpointAdvancer) SetLogArchive(archive LogArchive) {
+	c.archive = archive
+}
+
+// storageErrorCode is the error code TiKV reports when it fails to write the files to the external storage.
+const storageErrorCode = "KV:LogBackup:Io"
+
+// isStorageError checks whether the error reported by TiKV is caused by the external storage.
+func isStorageError(e *backuppb.StreamBackupError) bool {
+	return e.ErrorCode == storageErrorCode
+}
+
+func (c *CheckpointAdvancer) tickStorageOutages(ctx context.Context) {
+	meta, ok := c.env.(StorageOutageMeta)
+	if !ok || c.archive == nil || c.cfg.StorageOutageLimit <= 0 {
+		return
+	}
+	for _, t := range c.tasks {
+		if err := c.tickStorageOutage(ctx, meta, t); err != nil {
+			log.Warn("failed to handle the storage outage", zap.String("category", "log backup advancer"),
+				zap.String("task", t.info.Name), logutil.ShortError(err))
+		}
+	}
+}
+
+func (c *CheckpointAdvancer) tickStorageOutage(ctx context.Context, meta StorageOutageMeta, t *advancingTask) error {
+	if !t.outageLoaded {
+		outage, err := meta.GetStorageOutage(ctx, t.info.Name)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		t.outage, t.outageLoaded = outage, true
+	}
+	now, err := c.env.FetchCurrentTS(ctx)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if t.isPaused && t.outage != nil {
+		return c.tryRecoverFromStorageOutage(ctx, meta, t, now)
+	}
+	if t.outage != nil && t.outage.GapError != "" {
+		// the user has resumed the task after handling the gaps.
+		log.Info("the task paused for the gaps in the archive is resumed", zap.String("task", t.info.Name),
+			zap.String("gap", t.outage.GapError))
+		return c.clearStorageOutage(ctx, meta, t)
+	}
+
+	lastErrs, err := meta.LastErrorsOfTask(ctx, t.info.Name)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	var storageErr *backuppb.StreamBackupError
+	for _, e := range lastErrs {
+		if isStorageError(&e) {
+			storageErr = &e
+			break
+		}
+	}
+	if t.isPaused {
+		// only take over the tasks paused by TiKV for the storage errors, but not the ones paused by the
+		// user or the checkpoint lag.
+		if storageErr == nil {
+			return nil
+		}
+		return c.pauseForStorageOutage(ctx, meta, t, storageErr, now)
+	}
+	if storageErr == nil {
+		t.storageErrorSince = 0
+		if t.outage != nil && t.outage.ResumedTS != 0 && t.lastCheckpoint.TS > t.outage.ResumedTS {
+			return c.verifyArchiveAfterStorageOutage(ctx, meta, t, now)
+		}
+		return nil
+	}
+	if t.storageErrorSince == 0 {
+		t.storageErrorSince = now
+	}
+	if oracle.GetTimeFromTS(now).Sub(oracle.GetTimeFromTS(t.storageErrorSince)) < c.cfg.StorageOutageLimit {
+		return nil
+	}
+	return c.pauseForStorageOutage(ctx, meta, t, storageErr, now)
+}
+
+func (c *CheckpointAdvancer) pauseForStorageOutage(
+	ctx context.Context,
+	meta StorageOutageMeta,
+	t *advancingTask,
+	cause *backuppb.StreamBackupError,
+	now uint64,
+) error {
+	outage := t.outage
+	if outage == nil {
+		outage = &StorageOutage{Checkpoint: t.lastCheckpoint.TS}
+	}
+	// keep the checkpoint of the first pause if the storage fails again before the task catches up.
+	outage.PausedTS, outage.ResumedTS = now, 0
+	outage.Error = cause.ErrorCode + ": " + cause.ErrorMessage
+	if err := meta.PutStorageOutage(ctx, t.info.Name, outage); err != nil {
+		return errors.Trace(err)
+	}
+	t.outage, t.storageErrorSince, t.lastStorageProbe = outage, 0, now
+	t.resetArchiveVerify()
+	if !t.isPaused {
+		if err := c.env.PauseTask(ctx, t.info.Name); err != nil {
+			return errors.Annotate(err, "failed to pause task")
+		}
+		// don't wait for the pause event, or the next tick may take the task as running.
+		t.isPaused = true
+	}
+	log.Warn("paused the task for the storage outage", zap.String("category", "log backup advancer"),
+		zap.String("task", t.info.Name), zap.Uint64("checkpoint", outage.Checkpoint),
+		zap.Uint64("store", cause.StoreId), zap.String("error", outage.Error))
+	return nil
+}
+
+func (c *CheckpointAdvancer) tryRecoverFromStorageOutage(
+	ctx context.Context,
+	meta StorageOutageMeta,
+	t *advancingTask,
+	now uint64,
+) error {
+	if t.outage.GapError != "" {
+		return nil
+	}
+	if oracle.GetTimeFromTS(now).Sub(oracle.GetTimeFromTS(t.lastStorageProbe)) < c.cfg.StorageOutageProbeInterval {
+		return nil
+	}
+	t.lastStorageProbe = now
+	if err := c.archive.Probe(ctx, t.info); err != nil {
+		log.Info("the storage of the task is still unavailable", zap.String("task", t.info.Name),
+			logutil.ShortError(err))
+		return nil
+	}
+	t.outage.ResumedTS = now
+	if err := meta.PutStorageOutage(ctx, t.info.Name, t.outage); err != nil {
+		return errors.Trace(err)
+	}
+	if err := meta.CleanLastErrorOfTask(ctx, t.info.Name); err != nil {
+		return errors.Trace(err)
+	}
+	if err := meta.ResumeTask(ctx, t.info.Name); err != nil {
+		return errors.Annotate(err, "failed to resume task")
+	}
+	t.isPaused = false
+	log.Info("resumed the task paused for the storage outage", zap.String("category", "log backup advancer"),
+		zap.String("task", t.info.Name), zap.Uint64("checkpoint", t.outage.Checkpoint),
+		zap.Duration("paused", oracle.GetTimeFromTS(now).Sub(oracle.GetTimeFromTS(t.outage.PausedTS))))
+	return nil
+}
+
+// verifyArchiveAfterStorageOutage verifies the archive in the background, since it reads all the metadata
+// flushed since the outage. It handles the result of the last verification if there is one.
+func (c *CheckpointAdvancer) verifyArchiveAfterStorageOutage(
+	ctx context.Context,
+	meta StorageOutageMeta,
+	t *advancingTask,
+	now uint64,
+) error {
+	t.verifyMu.Lock()
+	if t.verifying {
+		t.verifyMu.Unlock()
+		return nil
+	}
+	if !t.verifyDone {
+		if now < t.nextVerify {
+			t.verifyMu.Unlock()
+			return nil
+		}
+		t.verifying = true
+		gen := t.verifyGen
+		t.verifyMu.Unlock()
+		info, since, until := t.info, t.outage.Checkpoint, t.lastCheckpoint.TS
+		go func() {
+			err := c.archive.VerifyNoGaps(ctx, info, since, until)
+			t.verifyMu.Lock()
+			t.verifying = false
+			// drop the result if the outage is cleared or paused again meanwhile.
+			if gen == t.verifyGen {
+				t.verifyDone, t.verifyErr = true, err
+			}
+			t.verifyMu.Unlock()
+		}()
+		return nil
+	}
+	err := t.verifyErr
+	t.verifyDone, t.verifyErr = false, nil
+	t.verifyMu.Unlock()
+
+	if err != nil && !berrors.ErrPiTRArchiveGap.Equal(err) {
+		t.verifyBackoff = min(max(2*t.verifyBackoff, c.cfg.StorageOutageProbeInterval), maxArchiveVerifyBackoff)
+		t.nextVerify = oracle.GoTimeToTS(oracle.GetTimeFromTS(now).Add(t.verifyBackoff))
+		return errors.Annotatef(err, "failed to verify the archive, retry after %s", t.verifyBackoff)
+	}
+	t.verifyBackoff, t.nextVerify = 0, 0
+	if err != nil {
+		t.outage.GapError = err.Error()
+		if err := meta.PutStorageOutage(ctx, t.info.Name, t.outage); err != nil {
+			return errors.Trace(err)
+		}
+		if err := c.env.PauseTask(ctx, t.info.Name); err != nil {
+			return errors.Annotate(err, "failed to pause task")
+		}
+		t.isPaused = true
+		log.Error("the archive has gaps after the storage outage, paused the task",
+			zap.String("category", "log backup advancer"), zap.String("task", t.info.Name),
+			zap.Uint64("checkpoint", t.outage.Checkpoint), logutil.ShortError(err))
+		return nil
+	}
+	log.Info("the task has caught up after the storage outage", zap.String("category", "log backup advancer"),
+		zap.String("task", t.info.Name), zap.Uint64("outage-checkpoint", t.outage.Checkpoint),
+		zap.Uint64("checkpoint", t.lastCheckpoint.TS))
+	return c.clearStorageOutage(ctx, meta, t)
+}
+
+func (c *CheckpointAdvancer) clearStorageOutage(ctx context.Context, meta StorageOutageMeta, t *advancingTask) error {
+	if err := meta.DeleteStorageOutage(ctx, t.info.Name); err != nil {
+		return errors.Trace(err)
+	}
+	t.outage = nil
+	t.resetArchiveVerify()
+	return nil
+}
+
+// resetArchiveVerify drops the result of the verification of the archive, including the running one.
+func (t *advancingTask) resetArchiveVerify() {
+	t.verifyMu.Lock()
+	defer t.verifyMu.Unlock()
+	t.verifyGen++
+	t.verifyDone, t.verifyErr = false, nil
+	t.verifyBackoff, t.nextVerify = 0, 0
+}
+
+// inStorageOutage checks whether the task is paused for or catching up after a storage outage, the
+// checkpoint lag is expected then.
+func (t *advancingTask) inStorageOutage() bool {
+	return t.outage != nil
+}
@@ -6,6 +6,7 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"slices"
 	"sync"
 	"testing"
 	"time"
@@ -15,6 +16,7 @@ import (
 	backup "github.com/pingcap/kvproto/pkg/brpb"
 	logbackup "github.com/pingcap/kvproto/pkg/logbackuppb"
 	"github.com/pingcap/log"
+	berrors "github.com/pingcap/tidb/br/pkg/errors"
 	"github.com/pingcap/tidb/br/pkg/streamhelper"
 	"github.com/pingcap/tidb/br/pkg/streamhelper/config"
 	"github.com/pingcap/tidb/br/pkg/streamhelper/spans"
@@ -1040,3 +1042,371 @@ func TestRedactBackend(t *testing.T) {
 	redacted = redact.TaskInfoRedacted{Info: info}
 	require.Equal(t, "storage:<azure_blob_storage:<endpoint:\"http://\" bucket:\"test\" prefix:\"test\" shared_key:\"[REDACTED]\" access_sig:\"[REDACTED]\" encryption_key:<[REDACTED]> > > name:\"test\" ", redacted.String())
 }
+
+type taskScopedCheckpointEnv struct {
+	*testEnv
+	mu          sync.Mutex
+	checkpoints map[string]uint64
+}
+
+func (e *taskScopedCheckpointEnv) UploadV3GlobalCheckpointForTask(_ context.Context, taskName string, checkpoint uint64) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if checkpoint < e.checkpoints[taskName] {
+		return errors.Errorf("checkpoint of %s rolling back", taskName)
+	}
+	e.checkpoints[taskName] = checkpoint
+	return nil
+}
+
+func (e *taskScopedCheckpointEnv) GetGlobalCheckpointForTask(_ context.Context, taskName string) (uint64, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.checkpoints[taskName], nil
+}
+
+func (e *taskScopedCheckpointEnv) ClearV3GlobalCheckpointForTask(_ context.Context, taskName string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	delete(e.checkpoints, taskName)
+	return nil
+}
+
+func (e *taskScopedCheckpointEnv) getCheckpoint(taskName string) (uint64, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	cp, ok := e.checkpoints[taskName]
+	return cp, ok
+}
+
+func TestMultipleTasks(t *testing.T) {
+	c := createFakeCluster(t, 4, false)
+	ctx := context.Background()
+	c.splitAndScatter("0001", "0002", "0012", "0034", "0048")
+	env := &taskScopedCheckpointEnv{testEnv: newTestEnv(c, t), checkpoints: make(map[string]uint64)}
+	env.task.Ranges = []kv.KeyRange{{StartKey: []byte("0001"), EndKey: []byte("0012")}}
+	adv := streamhelper.NewCheckpointAdvancer(env)
+	adv.StartTaskListener(ctx)
+	env.taskCh <- streamhelper.TaskEvent{
+		Type:   streamhelper.EventAdd,
+		Name:   "another",
+		Info:   &backup.StreamBackupTaskInfo{Name: "another", StartTs: 5},
+		Ranges: []kv.KeyRange{{StartKey: []byte("0034"), EndKey: []byte("0048")}},
+	}
+
+	// both of the tasks are advanced by the same advancer.
+	cp := c.advanceCheckpointBy(time.Minute)
+	require.Eventually(t, func() bool {
+		require.NoError(t, adv.OnTick(ctx))
+		cp1, _ := env.getCheckpoint("whole")
+		cp2, _ := env.getCheckpoint("another")
+		return cp1 == cp && cp2 == cp
+	}, 3*time.Second, 100*time.Millisecond)
+	require.Equal(t, cp-1, env.serviceGCSafePoint)
+
+	// the GC safe point is kept for the left task.
+	env.unregisterTask()
+	require.Eventually(t, func() bool {
+		_, ok := env.getCheckpoint("whole")
+		return !ok
+	}, 3*time.Second, 100*time.Millisecond)
+	cp = c.advanceCheckpointBy(time.Minute)
+	require.NoError(t, adv.OnTick(ctx))
+	cp2, _ := env.getCheckpoint("another")
+	require.Equal(t, cp, cp2)
+	c.mu.Lock()
+	require.False(t, c.serviceGCSafePointDeleted)
+	require.Equal(t, cp-1, c.serviceGCSafePoint)
+	c.mu.Unlock()
+
+	env.taskCh <- streamhelper.TaskEvent{Type: streamhelper.EventDel, Name: "another"}
+	require.Eventually(t, func() bool {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		return c.serviceGCSafePointDeleted
+	}, 3*time.Second, 100*time.Millisecond)
+	require.False(t, adv.HasTask())
+}
+
+func TestMultipleTasksFlushEvents(t *testing.T) {
+	c := createFakeCluster(t, 4, false)
+	ctx := context.Background()
+	c.splitAndScatter("0001", "0012", "0034", "0048", "m", "n")
+	installSubscribeSupport(c)
+	metaRange := kv.KeyRange{StartKey: []byte("m"), EndKey: []byte("n")}
+	env := &taskScopedCheckpointEnv{testEnv: newTestEnv(c, t), checkpoints: make(map[string]uint64)}
+	env.task.Ranges = []kv.KeyRange{{StartKey: []byte("0001"), EndKey: []byte("0012")}, metaRange}
+	adv := streamhelper.NewCheckpointAdvancer(env)
+	adv.StartTaskListener(ctx)
+	adv.SpawnSubscriptionHandler(ctx)
+	env.taskCh <- streamhelper.TaskEvent{
+		Type:   streamhelper.EventAdd,
+		Name:   "another",
+		Info:   &backup.StreamBackupTaskInfo{Name: "another", StartTs: 5},
+		Ranges: []kv.KeyRange{{StartKey: []byte("0034"), EndKey: []byte("0048")}, metaRange},
+	}
+	countTasks := func() int {
+		count := 0
+		adv.WithCheckpoints(func(*spans.ValueSortedFull) { count++ })
+		return count
+	}
+	require.Eventually(t, func() bool { return countTasks() == 2 }, 3*time.Second, 100*time.Millisecond)
+	require.NoError(t, adv.OnTick(ctx))
+
+	// the flush events are only merged into the task observing the regions, and the events of
+	// the shared meta range are dropped for they can't be told apart.
+	cp := c.advanceCheckpoints()
+	c.flushAllExcept("0034")
+	notAdvanced := func() [][]kv.KeyRange {
+		var ranges [][]kv.KeyRange
+		adv.WithCheckpoints(func(vsf *spans.ValueSortedFull) {
+			var rs []kv.KeyRange
+			vsf.TraverseValuesLessThan(cp, func(v spans.Valued) bool {
+				rs = append(rs, v.Key)
+				return true
+			})
+			slices.SortFunc(rs, func(a, b kv.KeyRange) int { return bytes.Compare(a.StartKey, b.StartKey) })
+			ranges = append(ranges, rs)
+		})
+		slices.SortFunc(ranges, func(a, b []kv.KeyRange) int { return len(a) - len(b) })
+		return ranges
+	}
+	require.Eventually(t, func() bool {
+		return len(notAdvanced()[0]) == 1
+	}, 3*time.Second, 100*time.Millisecond)
+	require.Equal(t, [][]kv.KeyRange{
+		{metaRange},
+		{{StartKey: []byte("0034"), EndKey: []byte("0048")}, metaRange},
+	}, notAdvanced())
+
+	// removing a task keeps the subscriptions of the others.
+	env.taskCh <- streamhelper.TaskEvent{Type: streamhelper.EventDel, Name: "another"}
+	require.Eventually(t, func() bool { return countTasks() == 1 }, 3*time.Second, 100*time.Millisecond)
+	require.True(t, adv.HasSubscriptions())
+}
+
+type storageOutageEnv struct {
+	*testEnv
+
+	outageMu sync.Mutex
+	lastErrs map[uint64]backup.StreamBackupError
+	outage   *streamhelper.StorageOutage
+	resumed  int
+}
+
+func (e *storageOutageEnv) LastErrorsOfTask(_ context.Context, _ string) (map[uint64]backup.StreamBackupError, error) {
+	e.outageMu.Lock()
+	defer e.outageMu.Unlock()
+	errs := make(map[uint64]backup.StreamBackupError, len(e.lastErrs))
+	for id, err := range e.lastErrs {
+		errs[id] = err
+	}
+	return errs, nil
+}
+
+func (e *storageOutageEnv) ResumeTask(ctx context.Context, _ string) error {
+	e.outageMu.Lock()
+	e.resumed++
+	e.outageMu.Unlock()
+	return e.testEnv.ResumeTask(ctx)
+}
+
+func (e *storageOutageEnv) CleanLastErrorOfTask(_ context.Context, _ string) error {
+	e.setLastError("", "")
+	return nil
+}
+
+func (e *storageOutageEnv) GetStorageOutage(_ context.Context, _ string) (*streamhelper.StorageOutage, error) {
+	return e.getOutage(), nil
+}
+
+func (e *storageOutageEnv) PutStorageOutage(_ context.Context, _ string, outage *streamhelper.StorageOutage) error {
+	e.outageMu.Lock()
+	defer e.outageMu.Unlock()
+	cloned := *outage
+	e.outage = &cloned
+	return nil
+}
+
+func (e *storageOutageEnv) DeleteStorageOutage(_ context.Context, _ string) error {
+	e.outageMu.Lock()
+	defer e.outageMu.Unlock()
+	e.outage = nil
+	return nil
+}
+
+func (e *storageOutageEnv) setLastError(code, msg string) {
+	e.outageMu.Lock()
+	defer e.outageMu.Unlock()
+	e.lastErrs = nil
+	if msg != "" {
+		e.lastErrs = map[uint64]backup.StreamBackupError{1: {ErrorCode: code, ErrorMessage: msg, StoreId: 1}}
+	}
+}
+
+func (e *storageOutageEnv) getOutage() *streamhelper.StorageOutage {
+	e.outageMu.Lock()
+	defer e.outageMu.Unlock()
+	if e.outage == nil {
+		return nil
+	}
+	cloned := *e.outage
+	return &cloned
+}
+
+func (e *storageOutageEnv) getResumed() int {
+	e.outageMu.Lock()
+	defer e.outageMu.Unlock()
+	return e.resumed
+}
+
+type fakeLogArchive struct {
+	mu            sync.Mutex
+	probeErr      error
+	gapErr        error
+	verifyErr     error
+	verified      int
+	verifiedSince uint64
+}
+
+func (a *fakeLogArchive) Probe(context.Context, *backup.StreamBackupTaskInfo) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.probeErr
+}
+
+func (a *fakeLogArchive) VerifyNoGaps(_ context.Context, _ *backup.StreamBackupTaskInfo, since, _ uint64) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.verified++
+	a.verifiedSince = since
+	if a.verifyErr != nil {
+		return a.verifyErr
+	}
+	return a.gapErr
+}
+
+func (a *fakeLogArchive) getVerified() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.verified
+}
+
+func TestStorageOutage(t *testing.T) {
+	c := createFakeCluster(t, 4, false)
+	defer func() {
+		if t.Failed() {
+			fmt.Println(c)
+		}
+	}()
+	c.splitAndScatter("01", "02", "022", "023", "033", "04", "043")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	env := &storageOutageEnv{testEnv: newTestEnv(c, t)}
+	archive := &fakeLogArchive{}
+	adv := streamhelper.NewCheckpointAdvancer(env)
+	adv.UpdateConfigWith(func(c *config.Config) {
+		c.StorageOutageLimit = time.Minute
+		c.StorageOutageProbeInterval = time.Minute
+	})
+	adv.SetLogArchive(archive)
+	adv.StartTaskListener(ctx)
+	require.Eventually(t, adv.HasTask, 5*time.Second, 100*time.Millisecond)
+	c.advanceClusterTimeBy(time.Minute)
+	c.advanceCheckpointBy(time.Minute)
+	require.NoError(t, adv.OnTick(ctx))
+	cp := env.getCheckpoint()
+	catchUp := func() {
+		lag := oracle.GetTimeFromTS(c.currentTS).Sub(oracle.GetTimeFromTS(env.getCheckpoint()))
+		c.advanceCheckpointBy(lag + time.Minute)
+	}
+
+	// only the storage errors are taken.
+	env.setLastError("KV:LogBackup:Other", "failed to put object: s3 service unavailable")
+	require.NoError(t, adv.OnTick(ctx))
+	c.advanceClusterTimeBy(2 * time.Minute)
+	require.NoError(t, adv.OnTick(ctx))
+	require.Nil(t, env.getOutage())
+
+	// the task is paused once the storage errors last long enough.
+	env.setLastError("KV:LogBackup:Io", "failed to put object: s3 service unavailable")
+	require.NoError(t, adv.OnTick(ctx))
+	require.Nil(t, env.getOutage())
+	c.advanceClusterTimeBy(2 * time.Minute)
+	require.NoError(t, adv.OnTick(ctx))
+	outage := env.getOutage()
+	require.NotNil(t, outage)
+	require.Equal(t, cp, outage.Checkpoint)
+	require.Contains(t, outage.Error, "s3 service unavailable")
+
+	// the task is resumed once the storage recovers.
+	archive.probeErr = errors.New("still unavailable")
+	c.advanceClusterTimeBy(2 * time.Minute)
+	require.NoError(t, adv.OnTick(ctx))
+	require.Zero(t, env.getResumed())
+	archive.probeErr = nil
+	c.advanceClusterTimeBy(2 * time.Minute)
+	require.NoError(t, adv.OnTick(ctx))
+	require.Equal(t, 1, env.getResumed())
+	require.NotZero(t, env.getOutage().ResumedTS)
+	lastErrs, err := env.LastErrorsOfTask(ctx, "whole")
+	require.NoError(t, err)
+	require.Empty(t, lastErrs)
+
+	// the archive is verified once the task catches up, the failed verification is retried with backoff.
+	require.NoError(t, adv.OnTick(ctx))
+	require.NotNil(t, env.getOutage())
+	archive.mu.Lock()
+	archive.verifyErr = errors.New("failed to read the metadata")
+	archive.mu.Unlock()
+	catchUp()
+	require.Eventually(t, func() bool {
+		require.NoError(t, adv.OnTick(ctx))
+		return archive.getVerified() == 1
+	}, 5*time.Second, 100*time.Millisecond)
+	require.Never(t, func() bool {
+		require.NoError(t, adv.OnTick(ctx))
+		return archive.getVerified() > 1
+	}, time.Second, 100*time.Millisecond)
+	require.NotNil(t, env.getOutage())
+	archive.mu.Lock()
+	archive.verifyErr = nil
+	archive.mu.Unlock()
+	c.advanceClusterTimeBy(2 * time.Minute)
+	catchUp()
+	require.Eventually(t, func() bool {
+		require.NoError(t, adv.OnTick(ctx))
+		return env.getOutage() == nil
+	}, 5*time.Second, 100*time.Millisecond)
+	require.Equal(t, cp, archive.verifiedSince)
+
+	// the task paused by TiKV for a storage error is taken over at once, and it's left to the user if the
+	// archive has gaps.
+	cp = env.getCheckpoint()
+	env.setLastError("KV:LogBackup:Io", "failed to upload the files to the external storage")
+	require.NoError(t, env.PauseTask(ctx, "whole"))
+	require.Eventually(t, func() bool {
+		require.NoError(t, adv.OnTick(ctx))
+		return env.getOutage() != nil
+	}, 5*time.Second, 100*time.Millisecond)
+	require.Equal(t, cp, env.getOutage().Checkpoint)
+	c.advanceClusterTimeBy(2 * time.Minute)
+	require.NoError(t, adv.OnTick(ctx))
+	require.Equal(t, 2, env.getResumed())
+	archive.mu.Lock()
+	archive.gapErr = errors.Annotate(berrors.ErrPiTRArchiveGap, "1 files are missing")
+	archive.mu.Unlock()
+	catchUp()
+	require.Eventually(t, func() bool {
+		require.NoError(t, adv.OnTick(ctx))
+		return env.getOutage().GapError != ""
+	}, 5*time.Second, 100*time.Millisecond)
+	c.advanceClusterTimeBy(2 * time.Minute)
+	require.NoError(t, adv.OnTick(ctx))
+	require.Equal(t, 2, env.getResumed())
+	require.NoError(t, env.testEnv.ResumeTask(ctx))
+	require.Eventually(t, func() bool {
+		require.NoError(t, adv.OnTick(ctx))
+		return env.getOutage() == nil
+	}, 5*time.Second, 100*time.Millisecond)
+}
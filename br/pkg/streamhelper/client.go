@@ -5,6 +5,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/binary"
+	"encoding/json"
 	"strconv"
 	"strings"
 
@@ -131,7 +132,7 @@ func (c *MetaDataClient) PutTask(ctx context.Context, task TaskInfo) error {
 		return errors.Annotatef(err, "failed to marshal task %s", task.PBInfo.Name)
 	}
 
-	ops := make([]clientv3.Op, 0, 2+len(task.Ranges))
+	ops := make([]clientv3.Op, 0, 3+len(task.Ranges))
 	ops = append(ops, clientv3.OpPut(TaskOf(task.PBInfo.Name), string(data)))
 	for _, r := range task.Ranges {
 		ops = append(ops, clientv3.OpPut(RangeKeyOf(task.PBInfo.Name, r.StartKey), string(r.EndKey)))
@@ -144,6 +145,13 @@ func (c *MetaDataClient) PutTask(ctx context.Context, task TaskInfo) error {
 	if task.Pausing {
 		ops = append(ops, clientv3.OpPut(Pause(task.PBInfo.Name), ""))
 	}
+	if len(task.Labels) > 0 {
+		labels, err := json.Marshal(task.Labels)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		ops = append(ops, clientv3.OpPut(LabelsOf(task.PBInfo.Name), string(labels)))
+	}
 
 	txn := c.KV.Txn(ctx)
 	_, err = txn.Then(ops...).Commit()
@@ -153,6 +161,68 @@ func (c *MetaDataClient) PutTask(ctx context.Context, task TaskInfo) error {
 	return nil
 }
 
+// UpdateTaskFilter replaces the table filter and the ranges of the task. The update fails if the task
+// is modified after it's fetched as the revision modRevision.
+func (c *MetaDataClient) UpdateTaskFilter(
+	ctx context.Context,
+	info backuppb.StreamBackupTaskInfo,
+	modRevision int64,
+	ranges Ranges,
+) error {
+	data, err := info.Marshal()
+	if err != nil {
+		return errors.Annotatef(err, "failed to marshal task %s", info.Name)
+	}
+
+	oldRanges, err := c.TaskByInfo(info).Ranges(ctx)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	// etcd doesn't allow deleting and putting the same key in a txn, so only delete the stale ranges.
+	newKeys := make(map[string]struct{}, len(ranges))
+	ops := make([]clientv3.Op, 0, 1+len(ranges)+len(oldRanges))
+	ops = append(ops, clientv3.OpPut(TaskOf(info.Name), string(data)))
+	for _, r := range ranges {
+		key := RangeKeyOf(info.Name, r.StartKey)
+		newKeys[key] = struct{}{}
+		ops = append(ops, clientv3.OpPut(key, string(r.EndKey)))
+	}
+	for _, r := range oldRanges {
+		key := RangeKeyOf(info.Name, r.StartKey)
+		if _, ok := newKeys[key]; !ok {
+			ops = append(ops, clientv3.OpDelete(key))
+		}
+	}
+	resp, err := c.KV.Txn(ctx).
+		If(clientv3.Compare(clientv3.ModRevision(TaskOf(info.Name)), "=", modRevision)).
+		Then(ops...).
+		Commit()
+	if err != nil {
+		return errors.Annotatef(err, "failed to commit the filter change for task %s", info.Name)
+	}
+	if !resp.Succeeded {
+		return errors.Annotatef(berrors.ErrPiTRTaskNotFound,
+			"the task %s is deleted or modified by others during the update", info.Name)
+	}
+	return nil
+}
+
+// GetTaskWithRevision gets the task and the revision it's modified at.
+func (c *MetaDataClient) GetTaskWithRevision(ctx context.Context, taskName string) (*Task, int64, error) {
+	resp, err := c.Get(ctx, TaskOf(taskName))
+	if err != nil {
+		return nil, 0, errors.Annotatef(err, "failed to fetch task %s", taskName)
+	}
+	if len(resp.Kvs) == 0 {
+		return nil, 0, errors.Annotatef(berrors.ErrPiTRTaskNotFound, "no such task %s", taskName)
+	}
+	var taskInfo backuppb.StreamBackupTaskInfo
+	if err = proto.Unmarshal(resp.Kvs[0].Value, &taskInfo); err != nil {
+		return nil, 0, errors.Annotatef(err, "invalid binary presentation of task info (name = %s)", taskName)
+	}
+	return &Task{cli: c, Info: taskInfo}, resp.Kvs[0].ModRevision, nil
+}
+
 // DeleteTask deletes a task, along with its metadata.
 func (c *MetaDataClient) DeleteTask(ctx context.Context, taskName string) error {
 	_, err := c.KV.Txn(ctx).
@@ -162,6 +232,8 @@ func (c *MetaDataClient) DeleteTask(ctx context.Context, taskName string) error
 			clientv3.OpDelete(CheckPointsOf(taskName), clientv3.WithPrefix()),
 			clientv3.OpDelete(Pause(taskName)),
 			clientv3.OpDelete(LastErrorPrefixOf(taskName), clientv3.WithPrefix()),
+			clientv3.OpDelete(StorageOutageOf(taskName)),
+			clientv3.OpDelete(LabelsOf(taskName)),
 			clientv3.OpDelete(GlobalCheckpointOf(taskName)),
 			clientv3.OpDelete(StorageCheckpointOf(taskName), clientv3.WithPrefix()),
 		).
@@ -196,6 +268,84 @@ func (c *MetaDataClient) CleanLastErrorOfTask(ctx context.Context, taskName stri
 	return nil
 }
 
+// LastErrorsOfTask gets the last errors reported by the stores for the task, keyed by the store IDs.
+func (c *MetaDataClient) LastErrorsOfTask(ctx context.Context, taskName string) (map[uint64]backuppb.StreamBackupError, error) {
+	storeToError := map[uint64]backuppb.StreamBackupError{}
+	prefix := LastErrorPrefixOf(taskName)
+	result, err := c.Get(ctx, prefix, clientv3.WithPrefix())
+	if err != nil {
+		return nil, errors.Annotatef(err, "failed to get the last error for task %s", taskName)
+	}
+	for _, r := range result.Kvs {
+		storeIDStr := strings.TrimPrefix(string(r.Key), prefix)
+		storeID, err := strconv.ParseUint(storeIDStr, 10, 64)
+		if err != nil {
+			return nil, errors.Annotatef(err, "failed to parse the store ID string %s", storeIDStr)
+		}
+		var lastErr backuppb.StreamBackupError
+		if err := proto.Unmarshal(r.Value, &lastErr); err != nil {
+			return nil, errors.Annotatef(err, "failed to parse wire encoding for store %d", storeID)
+		}
+		storeToError[storeID] = lastErr
+	}
+	return storeToError, nil
+}
+
+// LabelsOfTask gets the user-defined labels of the task, nil is returned if there isn't any.
+func (c *MetaDataClient) LabelsOfTask(ctx context.Context, taskName string) (map[string]string, error) {
+	resp, err := c.KV.Get(ctx, LabelsOf(taskName))
+	if err != nil {
+		return nil, errors.Annotatef(err, "failed to get the labels of task %s", taskName)
+	}
+	if len(resp.Kvs) == 0 {
+		return nil, nil
+	}
+	labels := make(map[string]string)
+	if err := json.Unmarshal(resp.Kvs[0].Value, &labels); err != nil {
+		return nil, errors.Annotatef(berrors.ErrPiTRMalformedMetadata,
+			"failed to parse the labels of task %s: %v", taskName, err)
+	}
+	return labels, nil
+}
+
+// GetStorageOutage gets the storage outage of the task recorded by the advancer, nil is returned if there
+// isn't any.
+func (c *MetaDataClient) GetStorageOutage(ctx context.Context, taskName string) (*StorageOutage, error) {
+	resp, err := c.KV.Get(ctx, StorageOutageOf(taskName))
+	if err != nil {
+		return nil, errors.Annotatef(err, "failed to get the storage outage of task %s", taskName)
+	}
+	if len(resp.Kvs) == 0 {
+		return nil, nil
+	}
+	outage := &StorageOutage{}
+	if err := json.Unmarshal(resp.Kvs[0].Value, outage); err != nil {
+		return nil, errors.Annotatef(berrors.ErrPiTRMalformedMetadata,
+			"failed to parse the storage outage of task %s: %v", taskName, err)
+	}
+	return outage, nil
+}
+
+// PutStorageOutage records the storage outage of the task.
+func (c *MetaDataClient) PutStorageOutage(ctx context.Context, taskName string, outage *StorageOutage) error {
+	value, err := json.Marshal(outage)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if _, err := c.KV.Put(ctx, StorageOutageOf(taskName), string(value)); err != nil {
+		return errors.Annotatef(err, "failed to put the storage outage of task %s", taskName)
+	}
+	return nil
+}
+
+// DeleteStorageOutage deletes the storage outage of the task once it recovers.
+func (c *MetaDataClient) DeleteStorageOutage(ctx context.Context, taskName string) error {
+	if _, err := c.KV.Delete(ctx, StorageOutageOf(taskName)); err != nil {
+		return errors.Annotatef(err, "failed to delete the storage outage of task %s", taskName)
+	}
+	return nil
+}
+
 // GetTask get the basic task handle from the metadata storage.
 func (c *MetaDataClient) GetTask(ctx context.Context, taskName string) (*Task, error) {
 	resp, err := c.Get(ctx, TaskOf(taskName))
@@ -410,23 +560,10 @@ func (t *Task) UploadGlobalCheckpoint(ctx context.Context, ts uint64) error {
 }
 
 func (t *Task) LastError(ctx context.Context) (map[uint64]backuppb.StreamBackupError, error) {
-	storeToError := map[uint64]backuppb.StreamBackupError{}
-	prefix := LastErrorPrefixOf(t.Info.Name)
-	result, err := t.cli.Get(ctx, prefix, clientv3.WithPrefix())
-	if err != nil {
-		return nil, errors.Annotatef(err, "failed to get the last error for task %s", t.Info.GetName())
-	}
-	for _, r := range result.Kvs {
-		storeIDStr := strings.TrimPrefix(string(r.Key), prefix)
-		storeID, err := strconv.ParseUint(storeIDStr, 10, 64)
-		if err != nil {
-			return nil, errors.Annotatef(err, "failed to parse the store ID string %s", storeIDStr)
-		}
-		var lastErr backuppb.StreamBackupError
-		if err := proto.Unmarshal(r.Value, &lastErr); err != nil {
-			return nil, errors.Annotatef(err, "failed to parse wire encoding for store %d", storeID)
-		}
-		storeToError[storeID] = lastErr
-	}
-	return storeToError, nil
+	return t.cli.LastErrorsOfTask(ctx, t.Info.Name)
+}
+
+// Labels returns the user-defined labels of the task.
+func (t *Task) Labels(ctx context.Context) (map[string]string, error) {
+	return t.cli.LabelsOfTask(ctx, t.Info.Name)
 }
@@ -14,6 +14,9 @@ const (
 	flagTryAdvanceThreshold = "try-advance-threshold"
 	flagCheckPointLagLimit  = "check-point-lag-limit"
 
+	flagStorageOutageLimit         = "storage-outage-limit"
+	flagStorageOutageProbeInterval = "storage-outage-probe-interval"
+
 	// used for chaos testing
 	flagOwnershipCycleInterval = "ownership-cycle-interval"
 )
@@ -24,6 +27,9 @@ const (
 	DefaultBackOffTime         = 5 * time.Second
 	DefaultTickInterval        = 12 * time.Second
 
+	DefaultStorageOutageLimit         = 10 * time.Minute
+	DefaultStorageOutageProbeInterval = time.Minute
+
 	// used for chaos testing, default to disable
 	DefaultOwnershipCycleInterval = 0
 )
@@ -41,6 +47,10 @@ type Config struct {
 	TryAdvanceThreshold time.Duration `toml:"try-advance-threshold" json:"try-advance-threshold"`
 	// The maximum lag could be tolerated for the checkpoint lag.
 	CheckPointLagLimit time.Duration `toml:"check-point-lag-limit" json:"check-point-lag-limit"`
+	// The duration of the storage errors reported by the stores to pause the task for the storage outage.
+	StorageOutageLimit time.Duration `toml:"storage-outage-limit" json:"storage-outage-limit"`
+	// The gap between probing whether the storage of the task paused for the outage recovers.
+	StorageOutageProbeInterval time.Duration `toml:"storage-outage-probe-interval" json:"storage-outage-probe-interval"`
 
 	// Following configs are used in chaos testings, better not to enable in prod
 	//
@@ -57,6 +67,12 @@ func DefineFlagsForCheckpointAdvancerConfig(f *pflag.FlagSet) {
 		"If the checkpoint lag is greater than how long, we would try to poll TiKV for checkpoints.")
 	f.Duration(flagCheckPointLagLimit, DefaultCheckPointLagLimit,
 		"The maximum lag could be tolerated for the checkpoint lag.")
+	f.Duration(flagStorageOutageLimit, DefaultStorageOutageLimit,
+		"If the stores keep reporting the storage errors for how long, we would pause the task until the storage "+
+			"recovers, then resume it and verify the archive has no gaps. 0 disables it. It's only handled by "+
+			"'br log advancer', which accesses the storage of the task.")
+	f.Duration(flagStorageOutageProbeInterval, DefaultStorageOutageProbeInterval,
+		"The gap between probing whether the storage of the task paused for the outage recovers.")
 
 	// used for chaos testing
 	f.Duration(flagOwnershipCycleInterval, DefaultOwnershipCycleInterval,
@@ -73,6 +89,9 @@ func Default() Config {
 		TryAdvanceThreshold:    DefaultTryAdvanceThreshold,
 		CheckPointLagLimit:     DefaultCheckPointLagLimit,
 		OwnershipCycleInterval: DefaultOwnershipCycleInterval,
+
+		StorageOutageLimit:         DefaultStorageOutageLimit,
+		StorageOutageProbeInterval: DefaultStorageOutageProbeInterval,
 	}
 }
 
@@ -98,6 +117,14 @@ func (conf *Config) GetFromFlags(f *pflag.FlagSet) error {
 	if err != nil {
 		return err
 	}
+	conf.StorageOutageLimit, err = f.GetDuration(flagStorageOutageLimit)
+	if err != nil {
+		return err
+	}
+	conf.StorageOutageProbeInterval, err = f.GetDuration(flagStorageOutageProbeInterval)
+	if err != nil {
+		return err
+	}
 	return nil
 }
 
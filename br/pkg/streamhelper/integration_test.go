@@ -146,6 +146,7 @@ func TestIntegration(t *testing.T) {
 	t.Run("TestStreamCheckpoint", func(t *testing.T) { testStreamCheckpoint(t, streamhelper.AdvancerExt{MetaDataClient: metaCli}) })
 	t.Run("testStoptask", func(t *testing.T) { testStoptask(t, streamhelper.AdvancerExt{MetaDataClient: metaCli}) })
 	t.Run("TestStreamClose", func(t *testing.T) { testStreamClose(t, streamhelper.AdvancerExt{MetaDataClient: metaCli}) })
+	t.Run("testUpdateTaskFilter", func(t *testing.T) { testUpdateTaskFilter(t, metaCli, etcd) })
 }
 
 func TestChecking(t *testing.T) {
@@ -208,9 +209,53 @@ func testBasic(t *testing.T, metaCli streamhelper.MetaDataClient, etcd *embed.Et
 	require.NoError(t, err)
 	require.False(t, paused)
 
+	labels, err := remoteTask.Labels(ctx)
+	require.NoError(t, err)
+	require.Nil(t, labels)
+
 	require.NoError(t, metaCli.DeleteTask(ctx, taskName))
 	keyNotExists(t, []byte(streamhelper.TaskOf(taskName)), etcd)
 	rangeIsEmpty(t, []byte(streamhelper.RangesOf(taskName)), etcd)
+
+	// the labels are kept with the task.
+	task.Labels = map[string]string{"env": "prod"}
+	require.NoError(t, metaCli.PutTask(ctx, task))
+	keyIs(t, []byte(streamhelper.TaskOf(taskName)), taskData, etcd)
+	keyIs(t, []byte(streamhelper.LabelsOf(taskName)), []byte(`{"env":"prod"}`), etcd)
+	labels, err = metaCli.LabelsOfTask(ctx, taskName)
+	require.NoError(t, err)
+	require.Equal(t, task.Labels, labels)
+	require.NoError(t, metaCli.DeleteTask(ctx, taskName))
+	keyNotExists(t, []byte(streamhelper.LabelsOf(taskName)), etcd)
+}
+
+func testUpdateTaskFilter(t *testing.T, metaCli streamhelper.MetaDataClient, etcd *embed.Etcd) {
+	ctx := context.Background()
+	taskName := "update_filter"
+	task := simpleTask(taskName, 3)
+	require.NoError(t, metaCli.PutTask(ctx, task))
+
+	remoteTask, rev, err := metaCli.GetTaskWithRevision(ctx, taskName)
+	require.NoError(t, err)
+	info := remoteTask.Info
+	info.TableFilter = []string{"test.*"}
+	newRanges := streamhelper.Ranges{
+		{StartKey: tablecodec.EncodeTablePrefix(3), EndKey: tablecodec.EncodeTablePrefix(4)},
+	}
+	require.NoError(t, metaCli.UpdateTaskFilter(ctx, info, rev, newRanges))
+	remoteTask, err = metaCli.GetTask(ctx, taskName)
+	require.NoError(t, err)
+	require.Equal(t, []string{"test.*"}, remoteTask.Info.TableFilter)
+	ranges, err := remoteTask.Ranges(ctx)
+	require.NoError(t, err)
+	require.Equal(t, newRanges, ranges)
+
+	// the revision is stale after the update.
+	err = metaCli.UpdateTaskFilter(ctx, info, rev, newRanges)
+	require.ErrorIs(t, errors.Cause(err), berrors.ErrPiTRTaskNotFound)
+
+	require.NoError(t, metaCli.DeleteTask(ctx, taskName))
+	rangeIsEmpty(t, []byte(streamhelper.RangesOf(taskName)), etcd)
 }
 
 func testGetStorageCheckpoint(t *testing.T, metaCli streamhelper.MetaDataClient) {
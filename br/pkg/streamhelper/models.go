@@ -26,6 +26,8 @@ const (
 	taskRangesPath       = "/ranges"
 	taskPausePath        = "/pause"
 	taskLastErrorPath    = "/last-error"
+	storageOutagePath    = "/storage-outage"
+	taskLabelsPath       = "/labels"
 	checkpointTypeGlobal = "central_global"
 	checkpointTypeRegion = "region"
 	checkpointTypeStore  = "store"
@@ -105,6 +107,18 @@ func LastErrorPrefixOf(task string) string {
 	return strings.TrimSuffix(path.Join(streamKeyPrefix, taskLastErrorPath, task), "/") + "/"
 }
 
+// StorageOutageOf returns the path of the storage outage of the task recorded by the advancer.
+// Normally it would be <prefix>/storage-outage/<task-name>.
+func StorageOutageOf(task string) string {
+	return path.Join(streamKeyPrefix, storageOutagePath, task)
+}
+
+// LabelsOf returns the path of the user-defined labels of the task.
+// Normally it would be <prefix>/labels/<task-name> -> <labels(json)>.
+func LabelsOf(task string) string {
+	return path.Join(streamKeyPrefix, taskLabelsPath, task)
+}
+
 // Ranges is a vector of [start_key, end_key) pairs.
 type Ranges = []Range
 type Range = kv.KeyRange
@@ -114,6 +128,8 @@ type TaskInfo struct {
 	PBInfo  backuppb.StreamBackupTaskInfo
 	Ranges  Ranges
 	Pausing bool
+	// Labels are the user-defined labels of the task, they're kept out of PBInfo which is read by TiKV.
+	Labels map[string]string
 }
 
 // NewTask creates a new task with the name.
@@ -8,7 +8,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
-	"strconv"
 	"strings"
 	"sync/atomic"
 	"time"
@@ -35,12 +34,8 @@ import (
 	"github.com/pingcap/tidb/br/pkg/version"
 	"github.com/pingcap/tidb/pkg/config"
 	"github.com/pingcap/tidb/pkg/kv"
-	"github.com/pingcap/tidb/pkg/parser/mysql"
-	"github.com/pingcap/tidb/pkg/sessionctx/stmtctx"
 	"github.com/pingcap/tidb/pkg/statistics/handle"
-	"github.com/pingcap/tidb/pkg/types"
 	"github.com/spf13/pflag"
-	"github.com/tikv/client-go/v2/oracle"
 	kvutil "github.com/tikv/client-go/v2/util"
 	"go.uber.org/zap"
 )
@@ -58,11 +53,23 @@ const (
 	flagKeyspaceName     = "keyspace-name"
 	flagReplicaReadLabel = "replica-read-label"
 	flagTableConcurrency = "table-concurrency"
+	flagStorageLayout    = "storage-layout"
+	flagBackupLabel      = "label"
+
+	flagBackupClusterConfig = "cluster-config"
+
+	flagAdaptiveConcurrency           = "adaptive-concurrency"
+	flagAdaptiveConcurrencyFloor      = "adaptive-concurrency-floor"
+	flagAdaptiveConcurrencyCeiling    = "adaptive-concurrency-ceiling"
+	flagAdaptiveConcurrencyMaxLatency = "adaptive-concurrency-max-latency"
 
 	flagGCTTL = "gcttl"
 
 	defaultBackupConcurrency = 4
 	maxBackupConcurrency     = 256
+
+	// the store is overloaded if the backup workers have more pending tasks.
+	adaptiveConcurrencyMaxPendingTasks = 16
 )
 
 const (
@@ -93,7 +100,25 @@ type BackupConfig struct {
 	UseCheckpoint    bool              `json:"use-checkpoint" toml:"use-checkpoint"`
 	ReplicaReadLabel map[string]string `json:"replica-read-label" toml:"replica-read-label"`
 	TableConcurrency uint              `json:"table-concurrency" toml:"table-concurrency"`
+	// StorageLayout is how the data files are organized in the external storage.
+	StorageLayout backup.StorageLayout `json:"storage-layout" toml:"storage-layout"`
+	// SequenceRestoreMode is recorded in the backupmeta, it's how the sequences resume by default when
+	// the backup is restored.
+	SequenceRestoreMode metautil.SequenceRestoreMode `json:"sequence-restore-mode" toml:"sequence-restore-mode"`
+	// Labels are the user-defined labels recorded in the backupmeta, used to filter the backups by `backup list`.
+	Labels map[string]string `json:"labels" toml:"labels"`
+	// SkipClusterConfig skips saving the config of the cluster alongside the backup, which is used by
+	// `br restore config`. It's saved by default, including the backups by the SQL statements which don't
+	// parse the flags.
+	SkipClusterConfig bool `json:"skip-cluster-config" toml:"skip-cluster-config"`
+	// AdaptiveConcurrency adjusts the in-flight backup requests of every store between the floor and the
+	// ceiling by the pressure of TiKV, starting from `--concurrency`. The running requests aren't throttled.
+	AdaptiveConcurrency           bool          `json:"adaptive-concurrency" toml:"adaptive-concurrency"`
+	AdaptiveConcurrencyFloor      uint          `json:"adaptive-concurrency-floor" toml:"adaptive-concurrency-floor"`
+	AdaptiveConcurrencyCeiling    uint          `json:"adaptive-concurrency-ceiling" toml:"adaptive-concurrency-ceiling"`
+	AdaptiveConcurrencyMaxLatency time.Duration `json:"adaptive-concurrency-max-latency" toml:"adaptive-concurrency-max-latency"`
 	CompressionConfig
+	BackupHookConfig
 
 	// for ebs-based backup
 	FullBackupType          FullBackupType `json:"full-backup-type" toml:"full-backup-type"`
@@ -155,6 +180,27 @@ func DefineBackupFlags(flags *pflag.FlagSet) {
 	_ = flags.MarkHidden(flagUseCheckpoint)
 
 	flags.String(flagReplicaReadLabel, "", "specify the label of the stores to be used for backup, e.g. 'label_key:label_value'")
+
+	flags.String(flagStorageLayout, string(backup.StorageLayoutFlat),
+		"how the data files are organized in the storage, value can be one of 'flat|per-table'. "+
+			"'per-table' puts the files of each table under 'tables/<db>/<table>/' so they can be copied per table")
+	flags.String(flagSequenceRestoreMode, string(metautil.SequenceRestoreExact),
+		"how the sequences resume when the backup is restored, value can be one of 'exact|skip-cache|reset'. "+
+			"It's recorded in the backup and can be overridden by the restore")
+	defineBackupLabelFlag(flags)
+	flags.Bool(flagBackupClusterConfig, true, "save the PD schedule config, the placement rules, the store labels "+
+		"and the important TiKV configs alongside the backup, so they can be compared with or applied to "+
+		"the cluster restored into by 'br restore config'")
+
+	flags.Bool(flagAdaptiveConcurrency, false, "adjust the number of the in-flight backup requests of the sub ranges to "+
+		"every store by the pressure of TiKV, which is read from the metrics proxy of PD. It starts from --"+flagConcurrency+
+		", and a lowered value takes effect when the running requests finish, so a backup of a single range isn't throttled")
+	flags.Uint(flagAdaptiveConcurrencyFloor, 1, "the min backup concurrency of a store when --"+flagAdaptiveConcurrency+" is set")
+	flags.Uint(flagAdaptiveConcurrencyCeiling, 16, "the max backup concurrency of a store when --"+flagAdaptiveConcurrency+" is set")
+	flags.Duration(flagAdaptiveConcurrencyMaxLatency, 100*time.Millisecond,
+		"the backup concurrency of a store is lowered once the 99th percentile latency of its foreground requests exceeds it")
+
+	DefineBackupHookFlags(flags)
 }
 
 // ParseFromFlags parses the backup-related flags from the flag set.
@@ -183,6 +229,34 @@ func (cfg *BackupConfig) ParseFromFlags(flags *pflag.FlagSet, skipCommonConfig b
 	if err != nil {
 		return errors.Trace(err)
 	}
+	storageLayout, err := flags.GetString(flagStorageLayout)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if cfg.StorageLayout, err = backup.ParseStorageLayout(storageLayout); err != nil {
+		return errors.Trace(err)
+	}
+	sequenceRestoreMode, err := flags.GetString(flagSequenceRestoreMode)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if cfg.SequenceRestoreMode, err = metautil.ParseSequenceRestoreMode(sequenceRestoreMode); err != nil {
+		return errors.Trace(err)
+	}
+	if cfg.Labels, err = parseBackupLabelFlag(flags); err != nil {
+		return errors.Trace(err)
+	}
+	clusterConfig, err := flags.GetBool(flagBackupClusterConfig)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	cfg.SkipClusterConfig = !clusterConfig
+	if err = cfg.parseAdaptiveConcurrencyFlags(flags); err != nil {
+		return errors.Trace(err)
+	}
+	if err = cfg.BackupHookConfig.ParseFromFlags(flags); err != nil {
+		return errors.Trace(err)
+	}
 	cfg.UseCheckpoint, err = flags.GetBool(flagUseCheckpoint)
 	if err != nil {
 		return errors.Trace(err)
@@ -271,6 +345,32 @@ func (cfg *BackupConfig) ParseFromFlags(flags *pflag.FlagSet, skipCommonConfig b
 	return nil
 }
 
+func (cfg *BackupConfig) parseAdaptiveConcurrencyFlags(flags *pflag.FlagSet) error {
+	var err error
+	if cfg.AdaptiveConcurrency, err = flags.GetBool(flagAdaptiveConcurrency); err != nil {
+		return errors.Trace(err)
+	}
+	if cfg.AdaptiveConcurrencyFloor, err = flags.GetUint(flagAdaptiveConcurrencyFloor); err != nil {
+		return errors.Trace(err)
+	}
+	if cfg.AdaptiveConcurrencyCeiling, err = flags.GetUint(flagAdaptiveConcurrencyCeiling); err != nil {
+		return errors.Trace(err)
+	}
+	if cfg.AdaptiveConcurrencyMaxLatency, err = flags.GetDuration(flagAdaptiveConcurrencyMaxLatency); err != nil {
+		return errors.Trace(err)
+	}
+	if !cfg.AdaptiveConcurrency {
+		return nil
+	}
+	if cfg.AdaptiveConcurrencyFloor == 0 || cfg.AdaptiveConcurrencyFloor > cfg.AdaptiveConcurrencyCeiling ||
+		cfg.AdaptiveConcurrencyCeiling > maxBackupConcurrency {
+		return errors.Annotatef(berrors.ErrInvalidArgument,
+			"--%s and --%s should satisfy 0 < floor <= ceiling <= %d",
+			flagAdaptiveConcurrencyFloor, flagAdaptiveConcurrencyCeiling, maxBackupConcurrency)
+	}
+	return nil
+}
+
 // parseCompressionFlags parses the backup-related flags from the flag set.
 func parseCompressionFlags(flags *pflag.FlagSet) (*CompressionConfig, error) {
 	compressionStr, err := flags.GetString(flagCompressionType)
@@ -376,13 +476,15 @@ func isFullBackup(cmdName string) bool {
 }
 
 // RunBackup starts a backup task inside the current goroutine.
-func RunBackup(c context.Context, g glue.Glue, cmdName string, cfg *BackupConfig) error {
+func RunBackup(c context.Context, g glue.Glue, cmdName string, cfg *BackupConfig) (err error) {
 	cfg.Adjust()
 	config.UpdateGlobal(func(conf *config.Config) {
 		conf.KeyspaceName = cfg.KeyspaceName
 	})
 
 	defer summary.Summary(cmdName)
+	defer cfg.storageRetryPolicy.Report()
+	defer cfg.collectStorageRequestStats()
 	ctx, cancel := context.WithCancel(c)
 	defer cancel()
 
@@ -392,7 +494,7 @@ func RunBackup(c context.Context, g glue.Glue, cmdName string, cfg *BackupConfig
 		ctx = opentracing.ContextWithSpan(ctx, span1)
 	}
 
-	u, err := storage.ParseBackend(cfg.Storage, &cfg.BackendOptions)
+	u, err := storage.ParseBackendWithContext(ctx, cfg.Storage, &cfg.BackendOptions)
 	if err != nil {
 		return errors.Trace(err)
 	}
@@ -447,6 +549,8 @@ func RunBackup(c context.Context, g glue.Glue, cmdName string, cfg *BackupConfig
 		NoCredentials:            cfg.NoCreds,
 		SendCredentials:          cfg.SendCreds,
 		CheckS3ObjectLockOptions: true,
+		RetryPolicy:              cfg.storageRetryPolicy,
+		RequestStats:             cfg.storageRequestStats,
 	}
 	if err = client.SetStorageAndCheckNotInUse(ctx, u, &opts); err != nil {
 		return errors.Trace(err)
@@ -478,6 +582,20 @@ func RunBackup(c context.Context, g glue.Glue, cmdName string, cfg *BackupConfig
 		return errors.Trace(err)
 	}
 	g.Record("BackupTS", backupTS)
+
+	var backupSize uint64
+	defer func() {
+		recordToCatalog(c, &cfg.Config, cfg.Storage, &metautil.CatalogRecord{
+			Operation: metautil.CatalogBackup,
+			Kind:      metautil.CatalogKindSnapshot,
+			StartTS:   cfg.LastBackupTS,
+			EndTS:     backupTS,
+			ClusterID: client.GetClusterID(),
+			Size:      backupSize,
+			Labels:    cfg.Labels,
+		}, err)
+	}()
+
 	safePointID := client.GetSafePointID()
 	sp := utils.BRServiceSafePoint{
 		BackupTS: backupTS,
@@ -492,30 +610,42 @@ func RunBackup(c context.Context, g glue.Glue, cmdName string, cfg *BackupConfig
 	}
 
 	log.Info("current backup safePoint job", zap.Object("safePoint", sp))
-	cctx, gcSafePointKeeperCancel := context.WithCancel(ctx)
+	releaseGCSafePoint, err := registerServiceSafePoint(ctx, mgr.GetPDClient(), &cfg.Config, cmdName, sp)
+	if err != nil {
+		return errors.Trace(err)
+	}
 	gcSafePointKeeperRemovable := false
 	defer func() {
 		// don't reset the gc-safe-point if checkpoint mode is used and backup is not finished
-		if cfg.UseCheckpoint && !gcSafePointKeeperRemovable {
+		keep := cfg.UseCheckpoint && !gcSafePointKeeperRemovable
+		if keep {
 			log.Info("skip removing gc-safepoint keeper for next retry", zap.String("gc-id", sp.ID))
-			return
 		}
-		log.Info("start to remove gc-safepoint keeper")
-		// close the gc safe point keeper at first
-		gcSafePointKeeperCancel()
-		// set the ttl to 0 to remove the gc-safe-point
-		sp.TTL = 0
-		if err := utils.UpdateServiceSafePoint(ctx, mgr.GetPDClient(), sp); err != nil {
+		if err := releaseGCSafePoint(ctx, keep); err != nil {
 			log.Warn("failed to update service safe point, backup may fail if gc triggered",
 				zap.Error(err),
 			)
 		}
-		log.Info("finish removing gc-safepoint keeper")
 	}()
-	err = utils.StartServiceSafePointKeeper(cctx, mgr.GetPDClient(), sp)
-	if err != nil {
+
+	// the hook runs after the GC safe point is registered, so the snapshot at the backup ts isn't GCed
+	// while it's running. The options of the storage are omitted to not leak the credentials.
+	storageURL := storage.FormatBackendURL(u)
+	hookCtx := HookContext{
+		Command:      cmdName,
+		Storage:      storageURL.String(),
+		BackupTS:     backupTS,
+		LastBackupTS: cfg.LastBackupTS,
+	}
+	if cfg.httpClient, err = cfg.TLS.ToHTTPClient(); err != nil {
 		return errors.Trace(err)
 	}
+	if err = cfg.runPreBackupHook(ctx, hookCtx); err != nil {
+		return errors.Trace(err)
+	}
+	defer func() {
+		cfg.runPostBackupHook(c, hookCtx, backupSize, err)
+	}()
 
 	if cfg.RemoveSchedulers {
 		log.Debug("removing some PD schedulers")
@@ -577,6 +707,22 @@ func RunBackup(c context.Context, g glue.Glue, cmdName string, cfg *BackupConfig
 		m.NewCollationsEnabled = newCollationEnable
 		m.ApiVersion = mgr.GetStorage().GetCodec().GetAPIVersion()
 	})
+	if cfg.SequenceRestoreMode != "" {
+		metawriter.Update(func(m *backuppb.BackupMeta) {
+			err = metautil.SetSequenceRestoreMode(m, cfg.SequenceRestoreMode)
+		})
+		if err != nil {
+			return errors.Trace(err)
+		}
+	}
+	if len(cfg.Labels) > 0 {
+		metawriter.Update(func(m *backuppb.BackupMeta) {
+			err = metautil.SetBackupLabels(m, cfg.Labels)
+		})
+		if err != nil {
+			return errors.Trace(err)
+		}
+	}
 
 	log.Info("get placement policies", zap.Int("count", len(policies)))
 	if len(policies) != 0 {
@@ -585,6 +731,10 @@ func RunBackup(c context.Context, g glue.Glue, cmdName string, cfg *BackupConfig
 		})
 	}
 
+	if !cfg.SkipClusterConfig {
+		saveClusterConfig(ctx, mgr, client.GetStorage(), backupTS, &cfg.CipherInfo)
+	}
+
 	// nothing to backup
 	if len(ranges) == 0 {
 		pdAddress := strings.Join(cfg.PD, ",")
@@ -619,6 +769,23 @@ func RunBackup(c context.Context, g glue.Glue, cmdName string, cfg *BackupConfig
 		}
 	}
 
+	if cfg.StorageLayout == backup.StorageLayoutPerTable {
+		layout, err := schemas.BuildTableLayout(mgr.GetStorage())
+		if err != nil {
+			return errors.Trace(err)
+		}
+		client.SetTableLayout(layout)
+	}
+	if cfg.AdaptiveConcurrency {
+		client.SetConcurrencyController(backup.NewConcurrencyController(uint(cfg.Concurrency),
+			backup.AdaptiveConcurrencyConfig{
+				Floor:                cfg.AdaptiveConcurrencyFloor,
+				Ceiling:              cfg.AdaptiveConcurrencyCeiling,
+				MaxPendingTasks:      adaptiveConcurrencyMaxPendingTasks,
+				MaxForegroundLatency: cfg.AdaptiveConcurrencyMaxLatency,
+			}, backup.NewPDMetricsPressureSource(mgr.GetPDClient(), mgr.GetPDTLSConfig())))
+	}
+
 	summary.CollectInt("backup total ranges", len(ranges))
 	progressTotalCount, progressUnit, err := getProgressCountOfRanges(ctx, mgr, ranges)
 	if err != nil {
@@ -738,6 +905,7 @@ func RunBackup(c context.Context, g glue.Glue, cmdName string, cfg *BackupConfig
 		}
 	}
 	archiveSize := metawriter.ArchiveSize()
+	backupSize = archiveSize
 	g.Record(summary.BackupDataSize, archiveSize)
 	//backup from tidb will fetch a general Size issue https://github.com/pingcap/tidb/issues/27247
 	g.Record("Size", archiveSize)
@@ -774,30 +942,7 @@ func getProgressCountOfRanges(
 
 // ParseTSString port from tidb setSnapshotTS.
 func ParseTSString(ts string, tzCheck bool) (uint64, error) {
-	if len(ts) == 0 {
-		return 0, nil
-	}
-	if tso, err := strconv.ParseUint(ts, 10, 64); err == nil {
-		return tso, nil
-	}
-
-	loc := time.Local
-	sc := stmtctx.NewStmtCtxWithTimeZone(loc)
-	if tzCheck {
-		tzIdx, _, _, _, _ := types.GetTimezone(ts)
-		if tzIdx < 0 {
-			return 0, errors.Errorf("must set timezone when using datetime format ts, e.g. '2018-05-11 01:42:23+0800'")
-		}
-	}
-	t, err := types.ParseTime(sc.TypeCtx(), ts, mysql.TypeTimestamp, types.MaxFsp)
-	if err != nil {
-		return 0, errors.Trace(err)
-	}
-	t1, err := t.GoTime(loc)
-	if err != nil {
-		return 0, errors.Trace(err)
-	}
-	return oracle.GoTimeToTS(t1), nil
+	return utils.ParseTSString(ts, tzCheck)
 }
 
 func DefaultBackupConfig(commonConfig Config) BackupConfig {
@@ -0,0 +1,86 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package task
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/log"
+	berrors "github.com/pingcap/tidb/br/pkg/errors"
+	"github.com/pingcap/tidb/br/pkg/metautil"
+	"go.uber.org/zap"
+)
+
+const (
+	flagConsistencyHook        = "consistency-hook"
+	flagConsistencyHookTimeout = "consistency-hook-timeout"
+)
+
+// barrierResponse is the output of the acquire-barrier hook.
+type barrierResponse struct {
+	Marker string `json:"marker"`
+}
+
+// parseBarrierResponse parses the marker from the last non-empty line of the hook output, so the logs of
+// the command hook printed before it are ignored.
+func parseBarrierResponse(output []byte) (string, error) {
+	lines := bytes.Split(bytes.TrimSpace(output), []byte("\n"))
+	var resp barrierResponse
+	if err := json.Unmarshal(lines[len(lines)-1], &resp); err != nil {
+		return "", errors.Annotatef(berrors.ErrInvalidArgument,
+			`the acquire-barrier hook should output {"marker": "..."}, but got %q: %v`, output, err)
+	}
+	if resp.Marker == "" {
+		return "", errors.Annotatef(berrors.ErrInvalidArgument,
+			"the acquire-barrier hook outputs an empty marker: %q", output)
+	}
+	return resp.Marker, nil
+}
+
+// acquireConsistencyPoint asks the application to hold a logical barrier, e.g. to drain its queues, and
+// takes the backup ts by getTS while the barrier is held. The barrier is released once the ts is taken,
+// the failure of releasing it is only logged since the ts is consistent anyway.
+func acquireConsistencyPoint(
+	ctx context.Context,
+	client *http.Client,
+	hook string,
+	timeout time.Duration,
+	hookCtx HookContext,
+	getTS func(ctx context.Context) (uint64, error),
+) (*metautil.ConsistencyPoint, error) {
+	hookCtx.Event = HookEventAcquireBarrier
+	output, err := runHook(ctx, client, hook, timeout, &hookCtx)
+	if err != nil {
+		return nil, errors.Annotate(err, "acquire-barrier hook failed")
+	}
+	marker, err := parseBarrierResponse(output)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	log.Info("the barrier of the application is acquired", zap.String("marker", marker))
+
+	backupTS, tsErr := getTS(ctx)
+	hookCtx.Event = HookEventReleaseBarrier
+	hookCtx.Marker = marker
+	hookCtx.BackupTS = backupTS
+	if tsErr != nil {
+		hookCtx.Result = "failure"
+		hookCtx.Error = tsErr.Error()
+	} else {
+		hookCtx.Result = "success"
+	}
+	// the barrier should be released even if the backup is canceled.
+	if _, err := runHook(context.WithoutCancel(ctx), client, hook, timeout, &hookCtx); err != nil {
+		log.Warn("release-barrier hook failed, the application may need to release the barrier manually",
+			zap.String("marker", marker), zap.Error(err))
+	}
+	if tsErr != nil {
+		return nil, errors.Trace(tsErr)
+	}
+	return &metautil.ConsistencyPoint{Marker: marker, BackupTS: backupTS}, nil
+}
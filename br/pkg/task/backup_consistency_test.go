@@ -0,0 +1,89 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package task
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/pingcap/errors"
+	berrors "github.com/pingcap/tidb/br/pkg/errors"
+	"github.com/pingcap/tidb/br/pkg/metautil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAcquireConsistencyPoint(t *testing.T) {
+	ctx := context.Background()
+	var (
+		received []HookContext
+		// holding is whether the barrier is held when the ts is taken.
+		holding  bool
+		response = `{"marker": "offset-1024"}`
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		var c HookContext
+		require.NoError(t, json.Unmarshal(b, &c))
+		received = append(received, c)
+		if c.Event == HookEventAcquireBarrier {
+			holding = true
+			_, _ = w.Write([]byte(response))
+			return
+		}
+		holding = false
+	}))
+	defer server.Close()
+	hookCtx := HookContext{Command: TxnBackupCmd, Storage: "local:///tmp/backup"}
+	getTS := func(context.Context) (uint64, error) {
+		require.True(t, holding)
+		return 42, nil
+	}
+
+	cp, err := acquireConsistencyPoint(ctx, nil, server.URL, time.Minute, hookCtx, getTS)
+	require.NoError(t, err)
+	require.Equal(t, &metautil.ConsistencyPoint{Marker: "offset-1024", BackupTS: 42}, cp)
+	require.False(t, holding)
+	require.Equal(t, []HookContext{
+		{Event: HookEventAcquireBarrier, Command: TxnBackupCmd, Storage: "local:///tmp/backup"},
+		{Event: HookEventReleaseBarrier, Command: TxnBackupCmd, Storage: "local:///tmp/backup", BackupTS: 42,
+			Result: "success", Marker: "offset-1024"},
+	}, received)
+
+	// the barrier is released even if the ts can't be taken.
+	received = nil
+	_, err = acquireConsistencyPoint(ctx, nil, server.URL, time.Minute, hookCtx, func(context.Context) (uint64, error) {
+		return 0, errors.New("pd is down")
+	})
+	require.ErrorContains(t, err, "pd is down")
+	require.Len(t, received, 2)
+	require.Equal(t, "failure", received[1].Result)
+	require.Equal(t, "pd is down", received[1].Error)
+
+	// the backup fails without the marker, and no barrier needs to be released.
+	received = nil
+	response = `{}`
+	_, err = acquireConsistencyPoint(ctx, nil, server.URL, time.Minute, hookCtx, getTS)
+	require.ErrorIs(t, err, berrors.ErrInvalidArgument)
+	require.Len(t, received, 1)
+}
+
+func TestAcquireConsistencyPointByCommand(t *testing.T) {
+	ctx := context.Background()
+	hook := `if [ "$BR_HOOK_EVENT" = acquire-barrier ]; then echo draining; echo '{"marker": "m1"}'; fi`
+	cp, err := acquireConsistencyPoint(ctx, nil, hook, time.Minute, HookContext{}, func(context.Context) (uint64, error) {
+		return 7, nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, &metautil.ConsistencyPoint{Marker: "m1", BackupTS: 7}, cp)
+
+	_, err = acquireConsistencyPoint(ctx, nil, "echo not json", time.Minute, HookContext{},
+		func(context.Context) (uint64, error) { return 7, nil })
+	require.ErrorIs(t, err, berrors.ErrInvalidArgument)
+	require.ErrorContains(t, err, "not json")
+}
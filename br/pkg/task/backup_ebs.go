@@ -110,7 +110,7 @@ func RunBackupEBS(c context.Context, g glue.Glue, cfg *BackupConfig) error {
 		ctx = opentracing.ContextWithSpan(ctx, span1)
 	}
 
-	backend, err := storage.ParseBackend(cfg.Storage, &cfg.BackendOptions)
+	backend, err := storage.ParseBackendWithContext(ctx, cfg.Storage, &cfg.BackendOptions)
 	if err != nil {
 		return errors.Trace(err)
 	}
@@ -124,6 +124,8 @@ func RunBackupEBS(c context.Context, g glue.Glue, cfg *BackupConfig) error {
 	opts := storage.ExternalStorageOptions{
 		NoCredentials:   cfg.NoCreds,
 		SendCredentials: cfg.SendCreds,
+		RetryPolicy:     cfg.storageRetryPolicy,
+		RequestStats:    cfg.storageRequestStats,
 	}
 	if err = client.SetStorageAndCheckNotInUse(ctx, backend, &opts); err != nil {
 		return errors.Trace(err)
@@ -0,0 +1,114 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package task
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/docker/go-units"
+	"github.com/pingcap/errors"
+	"github.com/pingcap/log"
+	"github.com/pingcap/tidb/br/pkg/backup"
+	"github.com/pingcap/tidb/br/pkg/conn"
+	berrors "github.com/pingcap/tidb/br/pkg/errors"
+	"github.com/pingcap/tidb/br/pkg/glue"
+	"github.com/pingcap/tidb/br/pkg/summary"
+	"github.com/pingcap/tidb/pkg/kv"
+	"github.com/pingcap/tidb/pkg/meta/model"
+	"go.uber.org/zap"
+)
+
+// EstimateCmd is the name of `br backup estimate`.
+const EstimateCmd = "Estimate"
+
+// RunBackupEstimate prints the expected size, file count and duration of backing up the tables
+// matched by the filter, without running the backup.
+func RunBackupEstimate(c context.Context, g glue.Glue, cmdName string, cfg *BackupConfig) error {
+	cfg.Adjust()
+	ctx, cancel := context.WithCancel(c)
+	defer cancel()
+
+	// the domain is needed by the session reading the counts of the rows.
+	mgr, err := NewMgr(ctx, g, cfg.PD, cfg.TLS, GetKeepalive(&cfg.Config), cfg.CheckRequirements, true, conn.NormalVersionChecker)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer mgr.Close()
+
+	backupTS := cfg.BackupTS
+	if backupTS == 0 {
+		if backupTS, err = mgr.GetCurrentTsFromPD(ctx); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	if cfg.LastBackupTS > 0 {
+		log.Warn("the estimation of incremental backup is the same as full backup",
+			zap.Uint64("last-backup-ts", cfg.LastBackupTS))
+	}
+
+	_, schemas, _, err := backup.BuildBackupRangeAndInitSchema(mgr.GetStorage(), cfg.TableFilter, backupTS, false, false)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if schemas == nil {
+		return errors.Annotate(berrors.ErrInvalidArgument, "no table matches the filter")
+	}
+
+	counts, err := loadStatsMetaCounts(ctx, g, mgr)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	countRows := func(_ *model.TableInfo, physicalID int64) (int64, bool) {
+		count, ok := counts[physicalID]
+		return count, ok
+	}
+	estimation, err := schemas.Estimate(ctx, mgr.GetStorage(), mgr.GetRegionStats, countRows, cfg.RateLimit)
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	console := glue.GetConsole(g)
+	table := console.CreateTable()
+	table.Add("tables", fmt.Sprint(estimation.Tables))
+	table.Add("regions", fmt.Sprint(estimation.Regions))
+	table.Add("storage size", units.HumanSize(float64(estimation.StorageSize)))
+	table.Add("mvcc amplification", fmt.Sprintf("%.2f", estimation.MVCCAmplification()))
+	table.Add("backup size", units.HumanSize(float64(estimation.BackupSize)))
+	table.Add("backup files", fmt.Sprint(estimation.Files))
+	if estimation.Duration > 0 {
+		table.Add("duration", fmt.Sprintf("%s (%s/s per store, %d stores)",
+			estimation.Duration.Round(time.Second), units.HumanSize(float64(cfg.RateLimit)), estimation.Stores))
+	} else {
+		table.Add("duration", "unknown without --"+flagRateLimit)
+	}
+	table.Print()
+
+	summary.Log(cmdName, zap.Int("tables", estimation.Tables), zap.Int("regions", estimation.Regions),
+		zap.Uint64("storage-size", estimation.StorageSize), zap.Uint64("backup-size", estimation.BackupSize),
+		zap.Int("files", estimation.Files), zap.Duration("duration", estimation.Duration))
+	summary.SetSuccessStatus(true)
+	return nil
+}
+
+// loadStatsMetaCounts reads the counts of the rows of the physical tables from mysql.stats_meta to estimate
+// the MVCC amplification. BR never loads the statistics, so they can't be read from the stats handle.
+func loadStatsMetaCounts(ctx context.Context, g glue.Glue, mgr *conn.Mgr) (map[int64]int64, error) {
+	counts := make(map[int64]int64)
+	err := g.UseOneShotSession(mgr.GetStorage(), false, func(se glue.Session) error {
+		rows, _, err := se.GetSessionCtx().GetRestrictedSQLExecutor().ExecRestrictedSQL(
+			kv.WithInternalSourceType(ctx, kv.InternalTxnBR),
+			nil,
+			"SELECT table_id, count FROM mysql.stats_meta",
+		)
+		if err != nil {
+			return errors.Annotate(err, "failed to read the counts of the rows from mysql.stats_meta")
+		}
+		for _, row := range rows {
+			counts[row.GetInt64(0)] = row.GetInt64(1)
+		}
+		return nil
+	})
+	return counts, errors.Trace(err)
+}
@@ -0,0 +1,192 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package task
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/log"
+	"github.com/spf13/pflag"
+	"go.uber.org/zap"
+)
+
+const (
+	flagPreBackupHook  = "pre-backup-hook"
+	flagPostBackupHook = "post-backup-hook"
+	flagHookTimeout    = "hook-timeout"
+
+	defaultHookTimeout = 5 * time.Minute
+)
+
+// HookEvent is the event triggering a backup hook.
+type HookEvent string
+
+const (
+	HookEventPreBackup  HookEvent = "pre-backup"
+	HookEventPostBackup HookEvent = "post-backup"
+	// HookEventAcquireBarrier asks the application to hold a logical barrier before the backup ts is
+	// taken, the hook responds the marker of the barrier.
+	HookEventAcquireBarrier HookEvent = "acquire-barrier"
+	// HookEventReleaseBarrier lets the application release the barrier after the backup ts is taken.
+	HookEventReleaseBarrier HookEvent = "release-barrier"
+)
+
+// HookContext is passed to the backup hooks in JSON.
+type HookContext struct {
+	Event        HookEvent `json:"event"`
+	Command      string    `json:"command"`
+	Storage      string    `json:"storage"`
+	BackupTS     uint64    `json:"backup-ts"`
+	LastBackupTS uint64    `json:"last-backup-ts,omitempty"`
+	// Result is either "success" or "failure", only set for the post-backup hook.
+	Result     string `json:"result,omitempty"`
+	Error      string `json:"error,omitempty"`
+	BackupSize uint64 `json:"backup-size,omitempty"`
+	// Marker is the marker of the barrier, only set for the release-barrier hook.
+	Marker string `json:"marker,omitempty"`
+}
+
+// BackupHookConfig is the config of the hooks running before and after the backup.
+// A hook is either a webhook URL receiving the context by POST, or a shell command
+// receiving the context from the stdin.
+type BackupHookConfig struct {
+	PreBackupHook  string        `json:"pre-backup-hook" toml:"pre-backup-hook"`
+	PostBackupHook string        `json:"post-backup-hook" toml:"post-backup-hook"`
+	HookTimeout    time.Duration `json:"hook-timeout" toml:"hook-timeout"`
+
+	// httpClient is the client of the webhooks, nil means the default client.
+	httpClient *http.Client
+}
+
+// DefineBackupHookFlags defines the flags of the backup hooks.
+func DefineBackupHookFlags(flags *pflag.FlagSet) {
+	flags.String(flagPreBackupHook, "", "the shell command or the http(s) webhook to run before the backup, "+
+		"the context is passed in JSON by the stdin or the request body. The backup fails if the hook fails")
+	flags.String(flagPostBackupHook, "", "the shell command or the http(s) webhook to run after the backup, "+
+		"the context including the result is passed in JSON by the stdin or the request body")
+	flags.Duration(flagHookTimeout, defaultHookTimeout, "the timeout of each backup hook")
+}
+
+// ParseFromFlags parses the hook flags from the flag set.
+func (cfg *BackupHookConfig) ParseFromFlags(flags *pflag.FlagSet) error {
+	var err error
+	if cfg.PreBackupHook, err = flags.GetString(flagPreBackupHook); err != nil {
+		return errors.Trace(err)
+	}
+	if cfg.PostBackupHook, err = flags.GetString(flagPostBackupHook); err != nil {
+		return errors.Trace(err)
+	}
+	if cfg.HookTimeout, err = flags.GetDuration(flagHookTimeout); err != nil {
+		return errors.Trace(err)
+	}
+	return nil
+}
+
+// runPreBackupHook runs the pre-backup hook, the backup should be aborted if it fails.
+func (cfg *BackupHookConfig) runPreBackupHook(ctx context.Context, hookCtx HookContext) error {
+	hookCtx.Event = HookEventPreBackup
+	_, err := runHook(ctx, cfg.httpClient, cfg.PreBackupHook, cfg.HookTimeout, &hookCtx)
+	return errors.Annotate(err, "pre-backup hook failed")
+}
+
+// runPostBackupHook runs the post-backup hook with the result of the backup.
+// The failure of the hook is only logged since the backup has been done.
+func (cfg *BackupHookConfig) runPostBackupHook(ctx context.Context, hookCtx HookContext, backupSize uint64, backupErr error) {
+	hookCtx.Event = HookEventPostBackup
+	hookCtx.Result = "success"
+	hookCtx.BackupSize = backupSize
+	if backupErr != nil {
+		hookCtx.Result = "failure"
+		hookCtx.Error = backupErr.Error()
+	}
+	// the hook should report the canceled backup too, so it runs in its own context, which is always
+	// bounded in case the hook hangs.
+	timeout := cfg.HookTimeout
+	if timeout <= 0 {
+		timeout = defaultHookTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), timeout)
+	defer cancel()
+	if _, err := runHook(ctx, cfg.httpClient, cfg.PostBackupHook, timeout, &hookCtx); err != nil {
+		log.Warn("post-backup hook failed", zap.Error(err))
+	}
+}
+
+// runHook runs the hook and returns its output, i.e. the response body of the webhook or the output of
+// the command.
+func runHook(ctx context.Context, client *http.Client, hook string, timeout time.Duration, hookCtx *HookContext) ([]byte, error) {
+	if hook == "" {
+		return nil, nil
+	}
+	body, err := json.Marshal(hookCtx)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	var output []byte
+	if strings.HasPrefix(hook, "http://") || strings.HasPrefix(hook, "https://") {
+		output, err = runWebhook(ctx, client, hook, body)
+	} else {
+		output, err = runCommandHook(ctx, hook, hookCtx.Event, body)
+	}
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	log.Info("backup hook finished", zap.String("event", string(hookCtx.Event)),
+		zap.ByteString("output", output), zap.Duration("take", time.Since(start)))
+	return output, nil
+}
+
+func runWebhook(ctx context.Context, client *http.Client, url string, body []byte) ([]byte, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	// the timeout is controlled by the context.
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	defer resp.Body.Close()
+	output, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if resp.StatusCode/100 != 2 {
+		return nil, errors.Errorf("webhook responds %d: %s", resp.StatusCode, output)
+	}
+	return output, nil
+}
+
+func runCommandHook(ctx context.Context, command string, event HookEvent, body []byte) ([]byte, error) {
+	//nolint:gosec
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Stdin = bytes.NewReader(body)
+	cmd.Env = append(os.Environ(), "BR_HOOK_EVENT="+string(event))
+	// the children of the shell may keep the output open after the shell is killed.
+	cmd.WaitDelay = time.Second
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return nil, errors.Annotatef(err, "output: %s", output)
+	}
+	return output, nil
+}
@@ -0,0 +1,103 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package task
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pingcap/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackupCommandHook(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	ctxFile := filepath.Join(dir, "context.json")
+	cfg := &BackupHookConfig{
+		PreBackupHook:  "cat > " + ctxFile + " && echo $BR_HOOK_EVENT",
+		PostBackupHook: "cat > " + ctxFile,
+		HookTimeout:    time.Minute,
+	}
+	hookCtx := HookContext{Command: FullBackupCmd, Storage: "s3://bucket/prefix", BackupTS: 42}
+
+	readContext := func() HookContext {
+		b, err := os.ReadFile(ctxFile)
+		require.NoError(t, err)
+		var c HookContext
+		require.NoError(t, json.Unmarshal(b, &c))
+		return c
+	}
+	require.NoError(t, cfg.runPreBackupHook(ctx, hookCtx))
+	require.Equal(t, HookContext{
+		Event: HookEventPreBackup, Command: FullBackupCmd, Storage: "s3://bucket/prefix", BackupTS: 42,
+	}, readContext())
+
+	cfg.runPostBackupHook(ctx, hookCtx, 1024, nil)
+	require.Equal(t, HookContext{
+		Event: HookEventPostBackup, Command: FullBackupCmd, Storage: "s3://bucket/prefix", BackupTS: 42,
+		Result: "success", BackupSize: 1024,
+	}, readContext())
+
+	cfg.runPostBackupHook(ctx, hookCtx, 0, errors.New("oops"))
+	c := readContext()
+	require.Equal(t, "failure", c.Result)
+	require.Equal(t, "oops", c.Error)
+
+	// the post-backup hook runs even if the backup is canceled.
+	canceledCtx, cancel := context.WithCancel(ctx)
+	cancel()
+	cfg.runPostBackupHook(canceledCtx, hookCtx, 0, context.Canceled)
+	require.Equal(t, "failure", readContext().Result)
+
+	// the backup is aborted if the pre-backup hook fails.
+	cfg.PreBackupHook = "echo quiesce failed && exit 1"
+	require.ErrorContains(t, cfg.runPreBackupHook(ctx, hookCtx), "quiesce failed")
+
+	cfg.PreBackupHook = "sleep 10"
+	cfg.HookTimeout = 100 * time.Millisecond
+	require.Error(t, cfg.runPreBackupHook(ctx, hookCtx))
+
+	// no hook is configured.
+	require.NoError(t, (&BackupHookConfig{}).runPreBackupHook(ctx, hookCtx))
+}
+
+func TestBackupWebhook(t *testing.T) {
+	ctx := context.Background()
+	received := make(chan HookContext, 1)
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodPost, r.Method)
+		require.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		b, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		var c HookContext
+		require.NoError(t, json.Unmarshal(b, &c))
+		received <- c
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	cfg := &BackupHookConfig{PreBackupHook: server.URL, PostBackupHook: server.URL, HookTimeout: time.Minute}
+	hookCtx := HookContext{Command: DBBackupCmd, Storage: "local:///tmp/backup", BackupTS: 42, LastBackupTS: 10}
+	require.NoError(t, cfg.runPreBackupHook(ctx, hookCtx))
+	c := <-received
+	require.Equal(t, HookEventPreBackup, c.Event)
+	require.Equal(t, uint64(10), c.LastBackupTS)
+
+	cfg.runPostBackupHook(ctx, hookCtx, 2048, nil)
+	c = <-received
+	require.Equal(t, HookEventPostBackup, c.Event)
+	require.Equal(t, uint64(2048), c.BackupSize)
+
+	status = http.StatusServiceUnavailable
+	require.ErrorContains(t, cfg.runPreBackupHook(ctx, hookCtx), "503")
+	<-received
+}
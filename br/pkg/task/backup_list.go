@@ -0,0 +1,395 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package task
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/docker/go-units"
+
+	"github.com/pingcap/errors"
+	backuppb "github.com/pingcap/kvproto/pkg/brpb"
+	"github.com/pingcap/log"
+	berrors "github.com/pingcap/tidb/br/pkg/errors"
+	"github.com/pingcap/tidb/br/pkg/glue"
+	"github.com/pingcap/tidb/br/pkg/metautil"
+	"github.com/pingcap/tidb/br/pkg/storage"
+	"github.com/pingcap/tidb/br/pkg/stream"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/tikv/client-go/v2/oracle"
+	"go.uber.org/zap"
+)
+
+// BackupListCmd is the name of `br backup list`.
+const BackupListCmd = "Backup List"
+
+const (
+	backupKindSnapshot = metautil.CatalogKindSnapshot
+	backupKindLog      = metautil.CatalogKindLog
+	backupKindRaw      = "raw"
+
+	backupSourceCatalog = "catalog"
+	backupSourceScan    = "scan"
+
+	flagBackupListScan = "scan"
+)
+
+// defineBackupLabelFlag defines the flag of the user-defined labels of the backup.
+func defineBackupLabelFlag(flags *pflag.FlagSet) {
+	flags.String(flagBackupLabel, "",
+		"the user-defined labels recorded in the backup, in the form of 'key1=value1,key2=value2'. "+
+			"They are shown by 'br backup list', which can filter the backups by them, and by 'br log status' "+
+			"for the log backup")
+}
+
+func parseBackupLabelFlag(flags *pflag.FlagSet) (map[string]string, error) {
+	labels, err := flags.GetString(flagBackupLabel)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	result, err := metautil.ParseLabels(labels)
+	return result, errors.Annotatef(err, "failed to parse --%s", flagBackupLabel)
+}
+
+// BackupListConfig is the config for `br backup list`.
+type BackupListConfig struct {
+	Config
+
+	// Labels are the labels the listed backups must have, all of the backups are listed if it's empty.
+	Labels     map[string]string `json:"labels" toml:"labels"`
+	JSONOutput bool              `json:"json-output" toml:"json-output"`
+	// Scan finds the backups by walking the storage root even if there is a catalog.
+	Scan bool `json:"scan" toml:"scan"`
+}
+
+// DefineBackupListFlags defines flags for `br backup list`.
+func DefineBackupListFlags(command *cobra.Command) {
+	command.Flags().Bool(flagStreamJSONOutput, false, "Print JSON as the output.")
+	command.Flags().Bool(flagBackupListScan, false, "Find the backups by reading the backupmeta files under the "+
+		"storage root, instead of the catalog recorded by --"+flagCatalog)
+}
+
+// ParseFromFlags parses the config from the flag set.
+func (cfg *BackupListConfig) ParseFromFlags(flags *pflag.FlagSet) error {
+	if err := cfg.Config.ParseFromFlags(flags); err != nil {
+		return errors.Trace(err)
+	}
+	if cfg.Storage == "" {
+		return errors.Annotatef(berrors.ErrInvalidArgument, "--%s is required", flagStorage)
+	}
+	var err error
+	if cfg.Labels, err = parseBackupLabelFlag(flags); err != nil {
+		return errors.Trace(err)
+	}
+	if cfg.JSONOutput, err = flags.GetBool(flagStreamJSONOutput); err != nil {
+		return errors.Trace(err)
+	}
+	cfg.Scan, err = flags.GetBool(flagBackupListScan)
+	return errors.Trace(err)
+}
+
+// listedBackup is a backup found under the storage root. For the snapshot backup, the StartTS is the ts
+// the differential backup begins from and the EndTS is the backup ts. For the log backup, they're the
+// range of the ts it can be restored to.
+type listedBackup struct {
+	Path      string            `json:"path"`
+	Kind      string            `json:"kind"`
+	StartTS   uint64            `json:"start_ts"`
+	EndTS     uint64            `json:"end_ts"`
+	ClusterID uint64            `json:"cluster_id"`
+	Labels    map[string]string `json:"labels,omitempty"`
+	// Size and Status are only known from the catalog.
+	Size   uint64 `json:"size,omitempty"`
+	Status string `json:"status,omitempty"`
+	// Error is why the backup can't be read, the other fields except the path are unknown then.
+	Error string `json:"error,omitempty"`
+	// Source is where the backup is found, the catalog or the scan of the storage root.
+	Source string `json:"source"`
+}
+
+// catalogPath returns the path of the storage relative to the storage root of the catalog, or the URL of
+// the storage without the options if it isn't under the root.
+func catalogPath(root, storageURL string, opts *storage.BackendOptions) (string, error) {
+	format := func(raw string) (string, error) {
+		u, err := storage.ParseBackend(raw, opts)
+		if err != nil {
+			return "", errors.Trace(err)
+		}
+		formatted := storage.FormatBackendURL(u)
+		return strings.TrimSuffix(formatted.String(), "/"), nil
+	}
+	rootURL, err := format(root)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	backupURL, err := format(storageURL)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	if rel, ok := strings.CutPrefix(backupURL, rootURL+"/"); ok {
+		return rel, nil
+	}
+	if backupURL == rootURL {
+		return ".", nil
+	}
+	return backupURL, nil
+}
+
+// recordToCatalog appends the record of the operation on the storage to the catalog at cfg.Catalog. The
+// catalog is only an index of the backups, so the failure is logged instead of failing the operation.
+func recordToCatalog(ctx context.Context, cfg *Config, storageURL string, record *metautil.CatalogRecord, opErr error) {
+	if cfg.Catalog == "" {
+		return
+	}
+	record.Time = time.Now()
+	record.Status = metautil.CatalogSuccess
+	if opErr != nil {
+		record.Status = metautil.CatalogFailed
+		record.Error = opErr.Error()
+	}
+	err := func() error {
+		var err error
+		if record.Path, err = catalogPath(cfg.Catalog, storageURL, &cfg.BackendOptions); err != nil {
+			return errors.Trace(err)
+		}
+		_, s, err := GetStorage(ctx, cfg.Catalog, cfg)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		defer s.Close()
+		return errors.Trace(metautil.AppendCatalogRecord(ctx, s, record))
+	}()
+	if err != nil {
+		log.Warn("failed to record the operation into the catalog", zap.String("catalog", cfg.Catalog),
+			zap.String("operation", record.Operation), zap.Error(err))
+		return
+	}
+	log.Info("recorded the operation into the catalog", zap.String("catalog", cfg.Catalog),
+		zap.String("operation", record.Operation), zap.String("path", record.Path), zap.String("status", record.Status))
+}
+
+// listBackupsFromCatalog returns the backups in the catalog having all of the labels, the latest record of
+// a backup takes effect. It returns false if there is no catalog.
+func listBackupsFromCatalog(ctx context.Context, root storage.ExternalStorage, labels map[string]string) ([]*listedBackup, bool, error) {
+	records, exists, err := metautil.ReadCatalog(ctx, root)
+	if err != nil || !exists {
+		return nil, false, errors.Trace(err)
+	}
+	latest := make(map[string]*metautil.CatalogRecord)
+	for _, record := range records {
+		if record.Operation == metautil.CatalogBackup {
+			latest[record.Path] = record
+		}
+	}
+	backups := make([]*listedBackup, 0, len(latest))
+	for _, record := range latest {
+		if !metautil.MatchLabels(record.Labels, labels) {
+			continue
+		}
+		backups = append(backups, &listedBackup{
+			Path:      record.Path,
+			Kind:      record.Kind,
+			StartTS:   record.StartTS,
+			EndTS:     record.EndTS,
+			ClusterID: record.ClusterID,
+			Labels:    record.Labels,
+			Size:      record.Size,
+			Status:    record.Status,
+			Source:    backupSourceCatalog,
+		})
+	}
+	sort.Slice(backups, func(i, j int) bool {
+		if backups[i].EndTS != backups[j].EndTS {
+			return backups[i].EndTS < backups[j].EndTS
+		}
+		return backups[i].Path < backups[j].Path
+	})
+	return backups, true, nil
+}
+
+// listBackups finds the backups under the storage root cfg.Storage, and returns the ones having all of the
+// labels sorted by the end ts. The backups are read from the catalog at the root if there is one, unless
+// scan is set, otherwise they're found by their backupmeta files. It returns true if the backups are read
+// from the catalog, then the backups not recorded in the catalog aren't listed.
+func listBackups(ctx context.Context, cfg *Config, labels map[string]string, scan bool) ([]*listedBackup, bool, error) {
+	_, root, err := GetStorage(ctx, cfg.Storage, cfg)
+	if err != nil {
+		return nil, false, errors.Trace(err)
+	}
+	if !scan {
+		backups, exists, err := listBackupsFromCatalog(ctx, root, labels)
+		if err != nil {
+			return nil, false, errors.Trace(err)
+		}
+		if exists {
+			return backups, true, nil
+		}
+	}
+	dirs := make([]string, 0)
+	logDirs := make(map[string]struct{})
+	err = root.WalkDir(ctx, &storage.WalkOption{}, func(name string, _ int64) error {
+		if path.Base(name) == metautil.MetaFile {
+			dirs = append(dirs, path.Dir(name))
+		}
+		if dir, ok := logBackupDirOf(name); ok {
+			logDirs[dir] = struct{}{}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, false, errors.Trace(err)
+	}
+	sort.Strings(dirs)
+
+	backups := make([]*listedBackup, 0, len(dirs))
+	for _, dir := range dirs {
+		_, isLog := logDirs[dir]
+		backup, err := readListedBackup(ctx, cfg, root, dir, isLog)
+		if err != nil {
+			// the broken backup is reported instead of failing the listing.
+			log.Warn("failed to read the backup", zap.String("path", dir), zap.Error(err))
+			backups = append(backups, &listedBackup{Path: dir, Error: err.Error(), Source: backupSourceScan})
+			continue
+		}
+		if metautil.MatchLabels(backup.Labels, labels) {
+			backups = append(backups, backup)
+		}
+	}
+	sort.SliceStable(backups, func(i, j int) bool { return backups[i].EndTS < backups[j].EndTS })
+	return backups, false, nil
+}
+
+// logBackupDirOf returns the directory of the log backup if the file is the metadata of the log backup.
+func logBackupDirOf(name string) (string, bool) {
+	name = strings.TrimPrefix(name, "/")
+	for _, prefix := range []string{
+		stream.GetStreamBackupMetaPrefix() + "/",
+		stream.GetStreamBackupGlobalCheckpointPrefix() + "/",
+	} {
+		if strings.HasPrefix(name, prefix) {
+			return ".", true
+		}
+		if idx := strings.Index(name, "/"+prefix); idx >= 0 {
+			return name[:idx], true
+		}
+	}
+	return "", false
+}
+
+// readListedBackup reads the backup in the directory under the storage root, the log backup is the one
+// having the log metadata besides its backupmeta.
+func readListedBackup(
+	ctx context.Context,
+	cfg *Config,
+	root storage.ExternalStorage,
+	dir string,
+	isLog bool,
+) (*listedBackup, error) {
+	subCfg := *cfg
+	var err error
+	if subCfg.Storage, err = subStorageURL(cfg.Storage, dir); err != nil {
+		return nil, errors.Trace(err)
+	}
+	backup := &listedBackup{Path: dir, Source: backupSourceScan}
+	if isLog {
+		// the backupmeta of the log backup isn't encrypted.
+		metaData, err := root.ReadFile(ctx, path.Join(dir, metautil.MetaFile))
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		backupMeta := &backuppb.BackupMeta{}
+		if err := backupMeta.Unmarshal(metaData); err != nil {
+			return nil, errors.Annotate(err, "failed to parse the backupmeta of the log backup")
+		}
+		backup.Labels = metautil.GetBackupLabels(backupMeta)
+		_, s, err := GetStorage(ctx, subCfg.Storage, &subCfg)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		defer s.Close()
+		logInfo, err := getLogRangeWithStorage(ctx, s)
+		if err != nil {
+			return nil, errors.Annotate(err, "failed to read the log backup")
+		}
+		backup.Kind = backupKindLog
+		backup.StartTS, backup.EndTS, backup.ClusterID = logInfo.logMinTS, logInfo.logMaxTS, logInfo.clusterID
+		return backup, nil
+	}
+
+	_, _, backupMeta, err := ReadBackupMeta(ctx, metautil.MetaFile, &subCfg)
+	if err != nil {
+		return nil, errors.Annotate(err, "failed to read the snapshot backup")
+	}
+	backup.Labels = metautil.GetBackupLabels(backupMeta)
+	backup.Kind = backupKindSnapshot
+	if backupMeta.IsRawKv {
+		backup.Kind = backupKindRaw
+	}
+	backup.StartTS, backup.EndTS, backup.ClusterID = backupMeta.StartVersion, backupMeta.EndVersion, backupMeta.ClusterId
+	return backup, nil
+}
+
+// RunBackupList prints the backups under the storage root having all of the given labels.
+func RunBackupList(c context.Context, g glue.Glue, cmdName string, cfg *BackupListConfig) error {
+	ctx, cancel := context.WithCancel(c)
+	defer cancel()
+
+	backups, fromCatalog, err := listBackups(ctx, &cfg.Config, cfg.Labels, cfg.Scan)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	log.Info("listed the backups", zap.String("cmd", cmdName), zap.String("storage", cfg.Storage),
+		zap.String("labels", metautil.FormatLabels(cfg.Labels)), zap.Int("backups", len(backups)),
+		zap.Bool("from-catalog", fromCatalog))
+
+	console := glue.GetConsole(g)
+	if cfg.JSONOutput {
+		data, err := json.Marshal(backups)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		console.Println(string(data))
+		return nil
+	}
+	if fromCatalog {
+		console.Println("The backups are listed from the catalog, the ones not recorded in it, e.g. taken " +
+			"without --" + flagCatalog + ", aren't listed. Use --" + flagBackupListScan + " to find all of them.")
+	}
+	if len(backups) == 0 {
+		console.Println("No backup found.")
+		return nil
+	}
+	formatTS := func(ts uint64) string {
+		if ts == 0 {
+			return "-"
+		}
+		return fmt.Sprintf("%d (%s)", ts, stream.FormatDate(oracle.GetTimeFromTS(ts)))
+	}
+	for _, b := range backups {
+		table := console.CreateTable()
+		table.Add("path", b.Path)
+		if b.Error != "" {
+			table.Add("error", b.Error)
+			table.Print()
+			continue
+		}
+		table.Add("kind", b.Kind)
+		table.Add("start", formatTS(b.StartTS))
+		table.Add("end", formatTS(b.EndTS))
+		table.Add("cluster id", fmt.Sprint(b.ClusterID))
+		table.Add("labels", metautil.FormatLabels(b.Labels))
+		if b.Status != "" {
+			table.Add("size", units.HumanSize(float64(b.Size)))
+			table.Add("status", b.Status)
+		}
+		table.Print()
+	}
+	return nil
+}
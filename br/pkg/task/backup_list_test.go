@@ -0,0 +1,142 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package task
+
+import (
+	"context"
+	"encoding/binary"
+	"path"
+	"testing"
+
+	"github.com/pingcap/errors"
+	backuppb "github.com/pingcap/kvproto/pkg/brpb"
+	"github.com/pingcap/kvproto/pkg/encryptionpb"
+	"github.com/pingcap/tidb/br/pkg/metautil"
+	"github.com/pingcap/tidb/br/pkg/storage"
+	"github.com/pingcap/tidb/br/pkg/stream"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListBackups(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+	s, err := storage.NewLocalStorage(root)
+	require.NoError(t, err)
+
+	writeMeta := func(dir string, meta *backuppb.BackupMeta, labels map[string]string) {
+		if labels != nil {
+			require.NoError(t, metautil.SetBackupLabels(meta, labels))
+		}
+		data, err := meta.Marshal()
+		require.NoError(t, err)
+		require.NoError(t, s.WriteFile(ctx, path.Join(dir, metautil.MetaFile), data))
+	}
+	writeMeta("prod/full2", &backuppb.BackupMeta{ClusterId: 1, EndVersion: 20},
+		map[string]string{"env": "prod", "owner": "team-x"})
+	writeMeta("prod/full1", &backuppb.BackupMeta{ClusterId: 1, EndVersion: 10},
+		map[string]string{"env": "prod", "owner": "team-y"})
+	writeMeta("dev/full", &backuppb.BackupMeta{ClusterId: 2, EndVersion: 15}, map[string]string{"env": "dev"})
+	writeMeta("unlabeled", &backuppb.BackupMeta{ClusterId: 2, EndVersion: 5}, nil)
+	writeMeta("prod/log", &backuppb.BackupMeta{ClusterId: 1, StartVersion: 5}, map[string]string{"env": "prod"})
+	require.NoError(t, s.WriteFile(ctx, path.Join("prod/log", stream.GetStreamBackupGlobalCheckpointPrefix(), "1.ts"),
+		binary.LittleEndian.AppendUint64(nil, 30)))
+	// the raw backup has no end version either, but it isn't a log backup.
+	writeMeta("raw", &backuppb.BackupMeta{ClusterId: 2, IsRawKv: true}, nil)
+	// the broken backup is reported.
+	require.NoError(t, s.WriteFile(ctx, path.Join("broken", metautil.MetaFile), []byte("broken")))
+
+	cfg := &Config{
+		Storage:    "local://" + root,
+		CipherInfo: backuppb.CipherInfo{CipherType: encryptionpb.EncryptionMethod_PLAINTEXT},
+	}
+	backups, fromCatalog, err := listBackups(ctx, cfg, nil, false)
+	require.NoError(t, err)
+	require.False(t, fromCatalog)
+	paths := make([]string, 0, len(backups))
+	for _, b := range backups {
+		paths = append(paths, b.Path)
+	}
+	require.Equal(t, []string{"broken", "raw", "unlabeled", "prod/full1", "dev/full", "prod/full2", "prod/log"}, paths)
+	require.NotEmpty(t, backups[0].Error)
+	require.Equal(t, backupKindRaw, backups[1].Kind)
+
+	backups, _, err = listBackups(ctx, cfg, map[string]string{"env": "prod"}, false)
+	require.NoError(t, err)
+	require.Equal(t, []*listedBackup{
+		{Path: "broken", Error: backups[0].Error, Source: backupSourceScan},
+		{Path: "prod/full1", Kind: backupKindSnapshot, EndTS: 10, ClusterID: 1,
+			Labels: map[string]string{"env": "prod", "owner": "team-y"}, Source: backupSourceScan},
+		{Path: "prod/full2", Kind: backupKindSnapshot, EndTS: 20, ClusterID: 1,
+			Labels: map[string]string{"env": "prod", "owner": "team-x"}, Source: backupSourceScan},
+		{Path: "prod/log", Kind: backupKindLog, StartTS: 5, EndTS: 30, ClusterID: 1,
+			Labels: map[string]string{"env": "prod"}, Source: backupSourceScan},
+	}, backups)
+
+	backups, _, err = listBackups(ctx, cfg, map[string]string{"env": "prod", "owner": "team-x"}, false)
+	require.NoError(t, err)
+	require.Len(t, backups, 2)
+	require.Equal(t, "prod/full2", backups[1].Path)
+
+	require.Equal(t, "prod/log", mustLogBackupDirOf(t, "prod/log/v1/backupmeta/1.meta"))
+	require.Equal(t, ".", mustLogBackupDirOf(t, "v1/global_checkpoint/1.ts"))
+	_, ok := logBackupDirOf("full/1/backup.sst")
+	require.False(t, ok)
+}
+
+func mustLogBackupDirOf(t *testing.T, name string) string {
+	dir, ok := logBackupDirOf(name)
+	require.True(t, ok)
+	return dir
+}
+
+func TestListBackupsFromCatalog(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+	cfg := &Config{
+		Storage:    "local://" + root,
+		CipherInfo: backuppb.CipherInfo{CipherType: encryptionpb.EncryptionMethod_PLAINTEXT},
+		Catalog:    "local://" + root,
+	}
+	rel, err := catalogPath("s3://bucket/root?endpoint=http://minio",
+		"s3://bucket/root/full1?access-key=ak&secret-access-key=sk", &storage.BackendOptions{})
+	require.NoError(t, err)
+	require.Equal(t, "full1", rel)
+	rel, err = catalogPath("s3://bucket/root",
+		"s3://bucket/other/full1?access-key=ak&secret-access-key=sk", &storage.BackendOptions{})
+	require.NoError(t, err)
+	require.Equal(t, "s3://bucket/other/full1", rel)
+
+	recordToCatalog(ctx, cfg, "local://"+path.Join(root, "full1"), &metautil.CatalogRecord{
+		Operation: metautil.CatalogBackup, Kind: metautil.CatalogKindSnapshot, EndTS: 10, Size: 1024,
+		Labels: map[string]string{"env": "prod"}}, nil)
+	recordToCatalog(ctx, cfg, "local://"+path.Join(root, "full2"), &metautil.CatalogRecord{
+		Operation: metautil.CatalogBackup, Kind: metautil.CatalogKindSnapshot, EndTS: 20,
+		Labels: map[string]string{"env": "prod"}}, errors.New("region unavailable"))
+	// the retried backup replaces the failed one.
+	recordToCatalog(ctx, cfg, "local://"+path.Join(root, "full2"), &metautil.CatalogRecord{
+		Operation: metautil.CatalogBackup, Kind: metautil.CatalogKindSnapshot, EndTS: 20, Size: 2048,
+		Labels: map[string]string{"env": "prod"}}, nil)
+	recordToCatalog(ctx, cfg, "local://"+path.Join(root, "dev"), &metautil.CatalogRecord{
+		Operation: metautil.CatalogBackup, Kind: metautil.CatalogKindSnapshot, EndTS: 15,
+		Labels: map[string]string{"env": "dev"}}, nil)
+	recordToCatalog(ctx, cfg, "local://"+path.Join(root, "full1"), &metautil.CatalogRecord{
+		Operation: metautil.CatalogRestore, Kind: metautil.CatalogKindSnapshot, EndTS: 10}, nil)
+
+	backups, fromCatalog, err := listBackups(ctx, cfg, map[string]string{"env": "prod"}, false)
+	require.NoError(t, err)
+	require.True(t, fromCatalog)
+	require.Len(t, backups, 2)
+	require.Equal(t, backupSourceCatalog, backups[0].Source)
+	require.Equal(t, "full1", backups[0].Path)
+	require.Equal(t, uint64(1024), backups[0].Size)
+	require.Equal(t, metautil.CatalogSuccess, backups[0].Status)
+	require.Equal(t, "full2", backups[1].Path)
+	require.Equal(t, uint64(2048), backups[1].Size)
+	require.Equal(t, metautil.CatalogSuccess, backups[1].Status)
+
+	// there is no backupmeta under the root, so nothing is found by scanning.
+	backups, fromCatalog, err = listBackups(ctx, cfg, nil, true)
+	require.NoError(t, err)
+	require.False(t, fromCatalog)
+	require.Empty(t, backups)
+}
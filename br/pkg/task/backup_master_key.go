@@ -0,0 +1,107 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package task
+
+import (
+	"context"
+
+	"github.com/pingcap/errors"
+	backuppb "github.com/pingcap/kvproto/pkg/brpb"
+	"github.com/pingcap/kvproto/pkg/encryptionpb"
+	"github.com/pingcap/log"
+	"github.com/pingcap/tidb/br/pkg/encryption"
+	encryptionkey "github.com/pingcap/tidb/br/pkg/encryption/master_key"
+	berrors "github.com/pingcap/tidb/br/pkg/errors"
+	"github.com/pingcap/tidb/br/pkg/glue"
+	"github.com/pingcap/tidb/br/pkg/stream"
+	"github.com/pingcap/tidb/br/pkg/summary"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"go.uber.org/zap"
+)
+
+const (
+	flagNewMasterKeyConfig = "new-master-key"
+
+	AddMasterKeyCmd = "Add Master Key"
+)
+
+// AddMasterKeyConfig is the config for `br backup add-master-key`.
+type AddMasterKeyConfig struct {
+	Config
+
+	// NewMasterKeys are the master keys used to wrap the data keys again,
+	// the existing master keys are read from `--master-key`.
+	NewMasterKeys []*encryptionpb.MasterKey `json:"new-master-keys" toml:"new-master-keys"`
+}
+
+// DefineAddMasterKeyFlags defines flags for `br backup add-master-key`.
+func DefineAddMasterKeyFlags(command *cobra.Command) {
+	command.Flags().String(flagNewMasterKeyConfig, "", "The master keys to be added to the data key envelope, "+
+		"the existing master keys should be provided by --master-key. "+
+		"examples: \"aws-kms:///${key-id}?AWS_ACCESS_KEY_ID=${access-key}&AWS_SECRET_ACCESS_KEY=${secret-key}"+
+		"&REGION=${region}\"")
+}
+
+// ParseFromFlags parses the config from the flag set.
+func (cfg *AddMasterKeyConfig) ParseFromFlags(flags *pflag.FlagSet) error {
+	if err := cfg.Config.ParseFromFlags(flags); err != nil {
+		return errors.Trace(err)
+	}
+	if len(cfg.MasterKeyConfig.MasterKeys) == 0 {
+		return errors.Annotatef(berrors.ErrInvalidArgument,
+			"--%s is required to unwrap the data keys", flagMasterKeyConfig)
+	}
+	newMasterKeyString, err := flags.GetString(flagNewMasterKeyConfig)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if newMasterKeyString == "" {
+		return errors.Annotatef(berrors.ErrInvalidArgument, "--%s is required", flagNewMasterKeyConfig)
+	}
+	cfg.NewMasterKeys, err = encryption.ParseMasterKeys(newMasterKeyString)
+	return errors.Trace(err)
+}
+
+// RunAddMasterKey wraps the data key of every encrypted log backup file with the new master keys,
+// so the backup can be restored as long as any one of the old or new master keys is available.
+func RunAddMasterKey(c context.Context, g glue.Glue, cmdName string, cfg *AddMasterKeyConfig) error {
+	ctx, cancel := context.WithCancel(c)
+	defer cancel()
+
+	s, err := (&StreamConfig{Config: cfg.Config}).makeStorage(ctx)
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	manager, err := encryption.NewManager(&backuppb.CipherInfo{}, &cfg.MasterKeyConfig)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer manager.Close()
+
+	newMasterKeys, err := encryptionkey.NewMultiMasterKeyBackend(cfg.NewMasterKeys)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer newMasterKeys.Close()
+
+	files := 0
+	metas, err := stream.RewriteFileEncryptionInfo(ctx, s, func(info *encryptionpb.FileEncryptionInfo) (bool, error) {
+		changed, err := manager.AddMasterKey(ctx, info, newMasterKeys)
+		if changed {
+			files += 1
+		}
+		return changed, err
+	})
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	log.Info("added master keys to the data key envelope",
+		zap.Int("new-master-keys", len(cfg.NewMasterKeys)),
+		zap.Int("updated-metadata", metas), zap.Int("updated-files", files))
+	summary.Log(cmdName, zap.Int("updated-metadata", metas), zap.Int("updated-files", files))
+	summary.SetSuccessStatus(true)
+	return nil
+}
@@ -0,0 +1,174 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package task
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/pingcap/errors"
+	backuppb "github.com/pingcap/kvproto/pkg/brpb"
+	"github.com/pingcap/log"
+	berrors "github.com/pingcap/tidb/br/pkg/errors"
+	"github.com/pingcap/tidb/br/pkg/glue"
+	"github.com/pingcap/tidb/br/pkg/metautil"
+	"github.com/pingcap/tidb/br/pkg/storage"
+	"github.com/pingcap/tidb/br/pkg/summary"
+	filter "github.com/pingcap/tidb/pkg/util/table-filter"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"go.uber.org/zap"
+)
+
+const (
+	flagPresignExpire = "expire"
+
+	PresignCmd = "Presign"
+
+	maxPresignExpire = 7 * 24 * time.Hour
+)
+
+// PresignConfig is the config for `br backup presign`.
+type PresignConfig struct {
+	Config
+
+	// PresignTables are the table filters of the tables to be shared, e.g. `db.t`.
+	PresignTables []string      `json:"presign-tables" toml:"presign-tables"`
+	Expire        time.Duration `json:"expire" toml:"expire"`
+}
+
+// DefineBackupPresignFlags defines flags for `br backup presign`.
+func DefineBackupPresignFlags(command *cobra.Command) {
+	command.Flags().StringArrayP(flagTable, "t", nil,
+		"The tables to be shared, in the format of table filter, e.g. 'db.t' or 'db.*'")
+	_ = command.MarkFlagRequired(flagTable)
+	command.Flags().Duration(flagPresignExpire, time.Hour, "How long the pre-signed URLs keep valid")
+}
+
+// ParseFromFlags parses the config from the flag set.
+func (cfg *PresignConfig) ParseFromFlags(flags *pflag.FlagSet) error {
+	if err := cfg.Config.ParseFromFlags(flags); err != nil {
+		return errors.Trace(err)
+	}
+	var err error
+	if cfg.PresignTables, err = flags.GetStringArray(flagTable); err != nil {
+		return errors.Trace(err)
+	}
+	if len(cfg.PresignTables) == 0 {
+		return errors.Annotatef(berrors.ErrInvalidArgument, "--%s is required", flagTable)
+	}
+	tableFilter, err := filter.Parse(cfg.PresignTables)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	cfg.TableFilter = filter.CaseInsensitive(tableFilter)
+	if cfg.Expire, err = flags.GetDuration(flagPresignExpire); err != nil {
+		return errors.Trace(err)
+	}
+	if cfg.Expire <= 0 || cfg.Expire > maxPresignExpire {
+		return errors.Annotatef(berrors.ErrInvalidArgument,
+			"--%s must be in (0, %s]", flagPresignExpire, maxPresignExpire)
+	}
+	return nil
+}
+
+// PresignedFile is a file shared by a pre-signed URL.
+type PresignedFile struct {
+	Name     string    `json:"name"`
+	Size     uint64    `json:"size,omitempty"`
+	URL      string    `json:"url"`
+	ExpireAt time.Time `json:"expire-at"`
+}
+
+// RunBackupPresign prints the pre-signed URLs of the files needed to restore the given tables,
+// one JSON object per line.
+func RunBackupPresign(c context.Context, g glue.Glue, cmdName string, cfg *PresignConfig) error {
+	ctx, cancel := context.WithCancel(c)
+	defer cancel()
+
+	_, s, backupMeta, err := ReadBackupMeta(ctx, metautil.MetaFile, &cfg.Config)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer s.Close()
+	presigner, ok := s.(storage.Presigner)
+	if !ok {
+		return errors.Annotatef(berrors.ErrStorageInvalidConfig,
+			"the storage %s doesn't support pre-signed URLs", s.URI())
+	}
+
+	files, tables, err := filesToRestoreTables(ctx, s, backupMeta, &cfg.CipherInfo, cfg.TableFilter)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if tables == 0 {
+		return errors.Annotatef(berrors.ErrInvalidArgument, "no table matches %v in the backup", cfg.PresignTables)
+	}
+
+	console := glue.GetConsole(g)
+	expireAt := time.Now().Add(cfg.Expire)
+	for _, f := range files {
+		u, err := presigner.PresignFile(ctx, f.Name, cfg.Expire)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		f.URL, f.ExpireAt = u, expireAt
+		b, err := json.Marshal(f)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		console.Println(string(b))
+	}
+
+	log.Info("pre-signed backup files", zap.Strings("tables", cfg.PresignTables),
+		zap.Int("files", len(files)), zap.Duration("expire", cfg.Expire))
+	summary.Log(cmdName, zap.Int("tables", tables), zap.Int("files", len(files)))
+	summary.SetSuccessStatus(true)
+	return nil
+}
+
+// filesToRestoreTables returns the metadata files and the data and statistic files of the tables
+// matched by the filter, along with the number of the matched tables.
+func filesToRestoreTables(
+	ctx context.Context,
+	s storage.ExternalStorage,
+	backupMeta *backuppb.BackupMeta,
+	cipher *backuppb.CipherInfo,
+	tableFilter filter.Filter,
+) ([]*PresignedFile, int, error) {
+	files := []*PresignedFile{{Name: metautil.MetaFile}}
+	// the index of backupmeta v2 has only one level, see `walkLeafMetaFile`.
+	for _, index := range []*backuppb.MetaFile{
+		backupMeta.FileIndex, backupMeta.SchemaIndex, backupMeta.RawRangeIndex, backupMeta.DdlIndexes,
+	} {
+		for _, f := range index.GetMetaFiles() {
+			files = append(files, &PresignedFile{Name: f.Name, Size: f.Size_})
+		}
+	}
+
+	reader := metautil.NewMetaReader(backupMeta, s, cipher)
+	// load the stats to keep the stats file indexes, the stats files are not read.
+	dbs, err := metautil.LoadBackupTables(ctx, reader, true)
+	if err != nil {
+		return nil, 0, errors.Trace(err)
+	}
+	tables := 0
+	for _, db := range dbs {
+		for _, tbl := range db.Tables {
+			if tbl.Info == nil || !tableFilter.MatchTable(db.Info.Name.O, tbl.Info.Name.O) {
+				continue
+			}
+			tables += 1
+			for _, f := range tbl.Files {
+				files = append(files, &PresignedFile{Name: f.Name, Size: f.Size_})
+			}
+			for _, stats := range tbl.StatsFileIndexes {
+				if stats.Name != "" {
+					files = append(files, &PresignedFile{Name: stats.Name, Size: stats.SizeEnc})
+				}
+			}
+		}
+	}
+	return files, tables, nil
+}
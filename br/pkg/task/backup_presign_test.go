@@ -0,0 +1,63 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package task
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	backuppb "github.com/pingcap/kvproto/pkg/brpb"
+	"github.com/pingcap/tidb/br/pkg/metautil"
+	"github.com/pingcap/tidb/br/pkg/storage"
+	"github.com/pingcap/tidb/pkg/meta/model"
+	"github.com/pingcap/tidb/pkg/parser/ast"
+	"github.com/pingcap/tidb/pkg/tablecodec"
+	filter "github.com/pingcap/tidb/pkg/util/table-filter"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilesToRestoreTables(t *testing.T) {
+	ctx := context.Background()
+	s, err := storage.NewLocalStorage(t.TempDir())
+	require.NoError(t, err)
+
+	dbInfo := &model.DBInfo{ID: 1, Name: ast.NewCIStr("test")}
+	dbData, err := json.Marshal(dbInfo)
+	require.NoError(t, err)
+	backupMeta := &backuppb.BackupMeta{}
+	for _, tbl := range []*model.TableInfo{
+		{ID: 100, Name: ast.NewCIStr("t1")},
+		{ID: 200, Name: ast.NewCIStr("t2")},
+	} {
+		tableData, err := json.Marshal(tbl)
+		require.NoError(t, err)
+		schema := &backuppb.Schema{Db: dbData, Table: tableData}
+		if tbl.ID == 100 {
+			schema.StatsIndex = []*backuppb.StatsFileIndex{{Name: "stats_100", SizeEnc: 10}}
+		}
+		backupMeta.Schemas = append(backupMeta.Schemas, schema)
+		backupMeta.Files = append(backupMeta.Files, &backuppb.File{
+			Name:     tbl.Name.O + "_default.sst",
+			StartKey: tablecodec.EncodeTablePrefix(tbl.ID),
+			Size_:    42,
+		})
+	}
+
+	tableFilter, err := filter.Parse([]string{"test.t1"})
+	require.NoError(t, err)
+	files, tables, err := filesToRestoreTables(ctx, s, backupMeta, &backuppb.CipherInfo{}, tableFilter)
+	require.NoError(t, err)
+	require.Equal(t, 1, tables)
+	require.Equal(t, []*PresignedFile{
+		{Name: metautil.MetaFile},
+		{Name: "t1_default.sst", Size: 42},
+		{Name: "stats_100", Size: 10},
+	}, files)
+
+	tableFilter, err = filter.Parse([]string{"other.*"})
+	require.NoError(t, err)
+	_, tables, err = filesToRestoreTables(ctx, s, backupMeta, &backuppb.CipherInfo{}, tableFilter)
+	require.NoError(t, err)
+	require.Zero(t, tables)
+}
@@ -40,6 +40,8 @@ type RawKvConfig struct {
 	CF       string `json:"cf" toml:"cf"`
 	CompressionConfig
 	RemoveSchedulers bool `json:"remove-schedulers" toml:"remove-schedulers"`
+
+	Labels map[string]string `json:"labels" toml:"labels"`
 }
 
 // DefineRawBackupFlags defines common flags for the backup command.
@@ -109,6 +111,9 @@ func (cfg *RawKvConfig) ParseBackupConfigFromFlags(flags *pflag.FlagSet) error {
 	if err != nil {
 		return errors.Trace(err)
 	}
+	if cfg.Labels, err = parseBackupLabelFlag(flags); err != nil {
+		return errors.Trace(err)
+	}
 	level, err := flags.GetInt32(flagCompressionLevel)
 	if err != nil {
 		return errors.Trace(err)
@@ -123,6 +128,8 @@ func RunBackupRaw(c context.Context, g glue.Glue, cmdName string, cfg *RawKvConf
 	cfg.adjust()
 
 	defer summary.Summary(cmdName)
+	defer cfg.storageRetryPolicy.Report()
+	defer cfg.collectStorageRequestStats()
 	ctx, cancel := context.WithCancel(c)
 	defer cancel()
 
@@ -132,7 +139,7 @@ func RunBackupRaw(c context.Context, g glue.Glue, cmdName string, cfg *RawKvConf
 		ctx = opentracing.ContextWithSpan(ctx, span1)
 	}
 
-	u, err := storage.ParseBackend(cfg.Storage, &cfg.BackendOptions)
+	u, err := storage.ParseBackendWithContext(ctx, cfg.Storage, &cfg.BackendOptions)
 	if err != nil {
 		return errors.Trace(err)
 	}
@@ -149,6 +156,8 @@ func RunBackupRaw(c context.Context, g glue.Glue, cmdName string, cfg *RawKvConf
 		NoCredentials:            cfg.NoCreds,
 		SendCredentials:          cfg.SendCreds,
 		CheckS3ObjectLockOptions: true,
+		RetryPolicy:              cfg.storageRetryPolicy,
+		RequestStats:             cfg.storageRequestStats,
 	}
 	if err = client.SetStorageAndCheckNotInUse(ctx, u, &opts); err != nil {
 		return errors.Trace(err)
@@ -235,7 +244,13 @@ func RunBackupRaw(c context.Context, g glue.Glue, cmdName string, cfg *RawKvConf
 		m.ClusterVersion = clusterVersion
 		m.BrVersion = brVersion
 		m.ApiVersion = client.GetApiVersion()
+		if len(cfg.Labels) > 0 {
+			err = metautil.SetBackupLabels(m, cfg.Labels)
+		}
 	})
+	if err != nil {
+		return errors.Trace(err)
+	}
 	err = metaWriter.FinishWriteMetas(ctx, metautil.AppendDataFile)
 	if err != nil {
 		return errors.Trace(err)
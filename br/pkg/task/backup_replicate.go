@@ -0,0 +1,421 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package task
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"io"
+	"strings"
+	"sync/atomic"
+
+	"github.com/pingcap/errors"
+	backuppb "github.com/pingcap/kvproto/pkg/brpb"
+	"github.com/pingcap/log"
+	"github.com/pingcap/tidb/br/pkg/checkpoint"
+	"github.com/pingcap/tidb/br/pkg/config"
+	berrors "github.com/pingcap/tidb/br/pkg/errors"
+	"github.com/pingcap/tidb/br/pkg/glue"
+	"github.com/pingcap/tidb/br/pkg/metautil"
+	"github.com/pingcap/tidb/br/pkg/storage"
+	"github.com/pingcap/tidb/br/pkg/stream"
+	"github.com/pingcap/tidb/br/pkg/summary"
+	"github.com/pingcap/tidb/pkg/util"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"go.uber.org/zap"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	flagReplicateTarget      = "target"
+	flagReplicateConcurrency = "copy-concurrency"
+	flagReplicateVerify      = "verify"
+	flagReplicateStartTS     = "log-start-ts"
+	flagReplicateEndTS       = "log-end-ts"
+
+	ReplicateCmd = "Replicate"
+
+	// replicateChunkSize is the size of a ranged read when copying a file.
+	replicateChunkSize = 4 * 1024 * 1024
+	// replicateRangeConcurrency is the number of ranges of a file being read at the same time.
+	replicateRangeConcurrency = 4
+)
+
+// ReplicateConfig is the config for `br backup replicate`.
+type ReplicateConfig struct {
+	Config
+
+	// TargetStorage is the storage the backup is copied to, `--storage` is the source.
+	TargetStorage string `json:"target" toml:"target"`
+	// CopyConcurrency is the number of files being copied at the same time.
+	CopyConcurrency uint `json:"copy-concurrency" toml:"copy-concurrency"`
+	// Verify reads the copied file back and compares its checksum with the source.
+	Verify bool `json:"verify" toml:"verify"`
+	// LogStartTS and LogEndTS limit the log backup metadata to be copied, zero means unlimited.
+	LogStartTS uint64 `json:"log-start-ts" toml:"log-start-ts"`
+	LogEndTS   uint64 `json:"log-end-ts" toml:"log-end-ts"`
+}
+
+// DefineReplicateFlags defines flags for `br backup replicate`.
+func DefineReplicateFlags(command *cobra.Command) {
+	command.Flags().String(flagReplicateTarget, "", "The storage the backup is copied to, "+
+		"the options of the target storage can be given by the query of the URL")
+	command.Flags().Uint(flagReplicateConcurrency, 16, "The number of files being copied at the same time")
+	command.Flags().Bool(flagReplicateVerify, true, "Read the copied files back and compare their checksum")
+	command.Flags().String(flagReplicateStartTS, "", "Only copy the log backup after this ts, "+
+		"support TSO or datetime, e.g. '400036290571534337' or '2018-05-11 01:42:23+0800'")
+	command.Flags().String(flagReplicateEndTS, "", "Only copy the log backup before this ts, "+
+		"support TSO or datetime, e.g. '400036290571534337' or '2018-05-11 01:42:23+0800'")
+}
+
+// ParseFromFlags parses the config from the flag set.
+func (cfg *ReplicateConfig) ParseFromFlags(flags *pflag.FlagSet) error {
+	if err := cfg.Config.ParseFromFlags(flags); err != nil {
+		return errors.Trace(err)
+	}
+	var err error
+	if cfg.TargetStorage, err = flags.GetString(flagReplicateTarget); err != nil {
+		return errors.Trace(err)
+	}
+	if cfg.TargetStorage == "" {
+		return errors.Annotatef(berrors.ErrInvalidArgument, "--%s is required", flagReplicateTarget)
+	}
+	if cfg.CopyConcurrency, err = flags.GetUint(flagReplicateConcurrency); err != nil {
+		return errors.Trace(err)
+	}
+	if cfg.CopyConcurrency == 0 {
+		return errors.Annotatef(berrors.ErrInvalidArgument, "--%s must be positive", flagReplicateConcurrency)
+	}
+	if cfg.Verify, err = flags.GetBool(flagReplicateVerify); err != nil {
+		return errors.Trace(err)
+	}
+	tsString, err := flags.GetString(flagReplicateStartTS)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if cfg.LogStartTS, err = ParseTSString(tsString, true); err != nil {
+		return errors.Trace(err)
+	}
+	if tsString, err = flags.GetString(flagReplicateEndTS); err != nil {
+		return errors.Trace(err)
+	}
+	if cfg.LogEndTS, err = ParseTSString(tsString, true); err != nil {
+		return errors.Trace(err)
+	}
+	if cfg.LogEndTS > 0 && cfg.LogEndTS < cfg.LogStartTS {
+		return errors.Annotatef(berrors.ErrInvalidArgument,
+			"--%s(%d) is less than --%s(%d)", flagReplicateEndTS, cfg.LogEndTS, flagReplicateStartTS, cfg.LogStartTS)
+	}
+	return nil
+}
+
+func (cfg *ReplicateConfig) hasLogRange() bool {
+	return cfg.LogStartTS > 0 || cfg.LogEndTS > 0
+}
+
+// overlaps returns whether the log backup metadata overlaps the log range.
+func (cfg *ReplicateConfig) overlaps(meta *backuppb.Metadata) bool {
+	if cfg.LogEndTS > 0 && meta.MinTs > cfg.LogEndTS {
+		return false
+	}
+	return meta.MaxTs >= cfg.LogStartTS
+}
+
+// replicateStats is the statistic of a replication.
+type replicateStats struct {
+	copiedFiles  atomic.Int64
+	copiedBytes  atomic.Int64
+	skippedFiles atomic.Int64
+}
+
+// RunReplicate copies a backup from `--storage` to `--target`. The files already in the target with
+// the same size are skipped, so an interrupted replication can be resumed by running it again.
+// The backupmeta of the snapshot and log backups refers to the files by their paths relative to the
+// storage root, so the copy is restorable from the target as is. The EBS snapshot backups are refused,
+// their backupmeta refers to the volume snapshots in the region of the source instead.
+func RunReplicate(c context.Context, g glue.Glue, cmdName string, cfg *ReplicateConfig) error {
+	ctx, cancel := context.WithCancel(c)
+	defer cancel()
+
+	_, src, err := GetStorage(ctx, cfg.Storage, &cfg.Config)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer src.Close()
+	_, dst, err := GetStorage(ctx, cfg.TargetStorage, &cfg.Config)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer dst.Close()
+
+	stats, err := replicate(ctx, src, dst, cfg)
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	fields := []zap.Field{
+		zap.Int64("copied-files", stats.copiedFiles.Load()),
+		zap.Int64("copied-bytes", stats.copiedBytes.Load()),
+		zap.Int64("skipped-files", stats.skippedFiles.Load()),
+	}
+	log.Info("replicated backup", append(fields,
+		zap.String("source", src.URI()), zap.String("target", dst.URI()))...)
+	summary.Log(cmdName, fields...)
+	summary.SetSuccessStatus(true)
+	return nil
+}
+
+func replicate(
+	ctx context.Context,
+	src, dst storage.ExternalStorage,
+	cfg *ReplicateConfig,
+) (*replicateStats, error) {
+	if err := checkReplicable(ctx, src); err != nil {
+		return nil, errors.Trace(err)
+	}
+	skip, err := logFilesOutOfRange(ctx, src, cfg)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	copied := make(map[string]int64)
+	if err := dst.WalkDir(ctx, &storage.WalkOption{}, func(path string, size int64) error {
+		copied[path] = size
+		return nil
+	}); err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	stats := &replicateStats{}
+	pool := util.NewWorkerPool(cfg.CopyConcurrency, "replicate")
+	eg, ectx := errgroup.WithContext(ctx)
+	err = src.WalkDir(ectx, &storage.WalkOption{}, func(path string, size int64) error {
+		// the checkpoints belong to the backup task writing the source, don't take them.
+		if strings.HasPrefix(path, checkpoint.CheckpointDir+"/") || skip[path] {
+			return nil
+		}
+		if copiedSize, ok := copied[path]; ok && copiedSize == size {
+			stats.skippedFiles.Add(1)
+			return nil
+		}
+		name, fileSize := path, size
+		pool.ApplyOnErrorGroup(eg, func() error {
+			if err := replicateFile(ectx, src, dst, name, fileSize, cfg.Verify); err != nil {
+				return errors.Trace(err)
+			}
+			stats.copiedFiles.Add(1)
+			stats.copiedBytes.Add(fileSize)
+			return nil
+		})
+		return nil
+	})
+	if err != nil {
+		if copyErr := eg.Wait(); copyErr != nil {
+			return nil, errors.Annotatef(copyErr, "walking the source storage meets error %s", err)
+		}
+		return nil, errors.Trace(err)
+	}
+	if err := eg.Wait(); err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	if cfg.LogStartTS > 0 {
+		// the log before the start ts isn't copied, so mark it truncated to make
+		// the restore fail fast instead of restoring partial data.
+		truncatedTS, err := stream.GetTSFromFile(ctx, src, stream.TruncateSafePointFileName)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if truncatedTS < cfg.LogStartTS {
+			if err := stream.SetTSToFile(ctx, dst, cfg.LogStartTS, stream.TruncateSafePointFileName); err != nil {
+				return nil, errors.Trace(err)
+			}
+		}
+	}
+	return stats, nil
+}
+
+// checkReplicable refuses the backups whose backupmeta refers to the data out of the storage, i.e. the
+// EBS snapshot backups, whose backupmeta is in JSON rather than protobuf.
+func checkReplicable(ctx context.Context, s storage.ExternalStorage) error {
+	exists, err := s.FileExists(ctx, metautil.MetaFile)
+	if err != nil || !exists {
+		return errors.Trace(err)
+	}
+	data, err := s.ReadFile(ctx, metautil.MetaFile)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if len(data) == 0 || data[0] != '{' {
+		return nil
+	}
+	ebsMeta := &config.EBSBasedBRMeta{}
+	if err := json.Unmarshal(data, ebsMeta); err != nil || ebsMeta.TiKVComponent == nil {
+		return nil
+	}
+	return errors.Annotatef(berrors.ErrUnsupportedOperation,
+		"the EBS snapshot backup refers to the volume snapshots in region %q out of the storage, "+
+			"it can't be replicated by copying the files", ebsMeta.Region)
+}
+
+// logFilesOutOfRange returns the log backup metadata and data files not overlapping the log range.
+// A data file is kept as long as any metadata kept refers to it.
+func logFilesOutOfRange(
+	ctx context.Context,
+	s storage.ExternalStorage,
+	cfg *ReplicateConfig,
+) (map[string]bool, error) {
+	skip := make(map[string]bool)
+	if !cfg.hasLogRange() {
+		return skip, nil
+	}
+	keep := make(map[string]struct{})
+	err := s.WalkDir(ctx, &storage.WalkOption{SubDir: stream.GetStreamBackupMetaPrefix()}, func(path string, _ int64) error {
+		if !strings.HasSuffix(path, ".meta") {
+			return nil
+		}
+		b, err := s.ReadFile(ctx, path)
+		if err != nil {
+			return errors.Annotatef(err, "during reading meta file %s from storage", path)
+		}
+		meta := &backuppb.Metadata{}
+		if err := meta.Unmarshal(b); err != nil {
+			return errors.Annotatef(err, "failed to parse meta file %s", path)
+		}
+		paths := make([]string, 0, len(meta.Files)+len(meta.FileGroups))
+		for _, f := range meta.Files {
+			paths = append(paths, f.Path)
+		}
+		for _, g := range meta.FileGroups {
+			paths = append(paths, g.Path)
+		}
+		if cfg.overlaps(meta) {
+			for _, p := range paths {
+				keep[p] = struct{}{}
+			}
+			return nil
+		}
+		skip[path] = true
+		for _, p := range paths {
+			skip[p] = true
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	for p := range keep {
+		delete(skip, p)
+	}
+	return skip, nil
+}
+
+// replicateFile copies a file by reading its ranges concurrently and writing them in order.
+func replicateFile(
+	ctx context.Context,
+	src, dst storage.ExternalStorage,
+	name string,
+	size int64,
+	verify bool,
+) error {
+	w, err := dst.Create(ctx, name, &storage.WriterOption{Concurrency: replicateRangeConcurrency})
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer func() {
+		if w != nil {
+			_ = w.Close(ctx)
+		}
+	}()
+
+	n := int((size + replicateChunkSize - 1) / replicateChunkSize)
+	chunks := make([][]byte, n)
+	done := make([]chan struct{}, n)
+	for i := range done {
+		done[i] = make(chan struct{})
+	}
+	// tokens bounds the ranges read but not written yet.
+	tokens := make(chan struct{}, replicateRangeConcurrency)
+	eg, ectx := errgroup.WithContext(ctx)
+	eg.Go(func() error {
+		for i := range n {
+			select {
+			case tokens <- struct{}{}:
+			case <-ectx.Done():
+				return ectx.Err()
+			}
+			idx := i
+			eg.Go(func() error {
+				start := int64(idx) * replicateChunkSize
+				end := min(start+replicateChunkSize, size)
+				r, err := src.Open(ectx, name, &storage.ReaderOption{StartOffset: &start, EndOffset: &end})
+				if err != nil {
+					return errors.Trace(err)
+				}
+				defer r.Close()
+				chunk := make([]byte, end-start)
+				if _, err := io.ReadFull(r, chunk); err != nil {
+					return errors.Annotatef(err, "failed to read %s[%d, %d)", name, start, end)
+				}
+				chunks[idx] = chunk
+				close(done[idx])
+				return nil
+			})
+		}
+		return nil
+	})
+	hasher := sha256.New()
+	eg.Go(func() error {
+		for i := range n {
+			select {
+			case <-done[i]:
+			case <-ectx.Done():
+				return ectx.Err()
+			}
+			hasher.Write(chunks[i])
+			if _, err := w.Write(ectx, chunks[i]); err != nil {
+				return errors.Annotatef(err, "failed to write %s", name)
+			}
+			chunks[i] = nil
+			<-tokens
+		}
+		return nil
+	})
+	if err := eg.Wait(); err != nil {
+		return errors.Trace(err)
+	}
+	err = w.Close(ctx)
+	w = nil
+	if err != nil {
+		return errors.Annotatef(err, "failed to write %s", name)
+	}
+
+	if !verify {
+		return nil
+	}
+	checksum, err := fileChecksum(ctx, dst, name)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if !bytes.Equal(checksum, hasher.Sum(nil)) {
+		return errors.Annotatef(berrors.ErrBackupChecksumMismatch,
+			"the checksum of %s in the target storage mismatches the source", name)
+	}
+	return nil
+}
+
+func fileChecksum(ctx context.Context, s storage.ExternalStorage, name string) ([]byte, error) {
+	r, err := s.Open(ctx, name, nil)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	defer r.Close()
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, r); err != nil {
+		return nil, errors.Annotatef(err, "failed to read %s", name)
+	}
+	return hasher.Sum(nil), nil
+}
@@ -0,0 +1,101 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package task
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	backuppb "github.com/pingcap/kvproto/pkg/brpb"
+	berrors "github.com/pingcap/tidb/br/pkg/errors"
+	"github.com/pingcap/tidb/br/pkg/storage"
+	"github.com/pingcap/tidb/br/pkg/stream"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReplicate(t *testing.T) {
+	ctx := context.Background()
+	src, err := storage.NewLocalStorage(t.TempDir())
+	require.NoError(t, err)
+	dst, err := storage.NewLocalStorage(t.TempDir())
+	require.NoError(t, err)
+
+	large := bytes.Repeat([]byte("0123456789"), replicateChunkSize/5+7)
+	require.NoError(t, src.WriteFile(ctx, "backupmeta", []byte("meta")))
+	require.NoError(t, src.WriteFile(ctx, "1/1_default.sst", large))
+	require.NoError(t, src.WriteFile(ctx, "empty.sst", nil))
+	require.NoError(t, src.WriteFile(ctx, "checkpoints/backup/checkpoint.meta", []byte("ckpt")))
+
+	cfg := &ReplicateConfig{CopyConcurrency: 2, Verify: true}
+	stats, err := replicate(ctx, src, dst, cfg)
+	require.NoError(t, err)
+	require.EqualValues(t, 3, stats.copiedFiles.Load())
+	require.EqualValues(t, len(large)+4, stats.copiedBytes.Load())
+	content, err := dst.ReadFile(ctx, "1/1_default.sst")
+	require.NoError(t, err)
+	require.Equal(t, large, content)
+	exists, err := dst.FileExists(ctx, "checkpoints/backup/checkpoint.meta")
+	require.NoError(t, err)
+	require.False(t, exists)
+
+	// resume: only the missing or partially copied files are copied again.
+	require.NoError(t, dst.WriteFile(ctx, "backupmeta", []byte("me")))
+	stats, err = replicate(ctx, src, dst, cfg)
+	require.NoError(t, err)
+	require.EqualValues(t, 1, stats.copiedFiles.Load())
+	require.EqualValues(t, 2, stats.skippedFiles.Load())
+	content, err = dst.ReadFile(ctx, "backupmeta")
+	require.NoError(t, err)
+	require.Equal(t, []byte("meta"), content)
+
+	// the EBS snapshot backup refers to the volume snapshots out of the storage.
+	ebs, err := storage.NewLocalStorage(t.TempDir())
+	require.NoError(t, err)
+	require.NoError(t, ebs.WriteFile(ctx, "backupmeta", []byte(`{"tikv":{"replicas":3,"stores":[]},"region":"us-west-2"}`)))
+	_, err = replicate(ctx, ebs, dst, cfg)
+	require.True(t, berrors.ErrUnsupportedOperation.Equal(err))
+	require.ErrorContains(t, err, `region "us-west-2"`)
+}
+
+func TestReplicateLogRange(t *testing.T) {
+	ctx := context.Background()
+	src, err := storage.NewLocalStorage(t.TempDir())
+	require.NoError(t, err)
+	dst, err := storage.NewLocalStorage(t.TempDir())
+	require.NoError(t, err)
+
+	writeMeta := func(name string, minTS, maxTS uint64, paths ...string) {
+		meta := &backuppb.Metadata{MinTs: minTS, MaxTs: maxTS, MetaVersion: backuppb.MetaVersion_V2}
+		for _, p := range paths {
+			meta.FileGroups = append(meta.FileGroups, &backuppb.DataFileGroup{Path: p})
+			require.NoError(t, src.WriteFile(ctx, p, []byte(p)))
+		}
+		b, err := meta.Marshal()
+		require.NoError(t, err)
+		require.NoError(t, src.WriteFile(ctx, stream.GetStreamBackupMetaPrefix()+"/"+name, b))
+	}
+	writeMeta("1.meta", 10, 20, "v1/1/a.log", "v1/1/shared.log")
+	writeMeta("2.meta", 30, 40, "v1/2/b.log", "v1/1/shared.log")
+	writeMeta("3.meta", 50, 60, "v1/3/c.log")
+
+	cfg := &ReplicateConfig{CopyConcurrency: 4, LogStartTS: 25, LogEndTS: 45}
+	_, err = replicate(ctx, src, dst, cfg)
+	require.NoError(t, err)
+	for path, expected := range map[string]bool{
+		"v1/backupmeta/1.meta": false,
+		"v1/backupmeta/2.meta": true,
+		"v1/backupmeta/3.meta": false,
+		"v1/1/a.log":           false,
+		"v1/1/shared.log":      true,
+		"v1/2/b.log":           true,
+		"v1/3/c.log":           false,
+	} {
+		exists, err := dst.FileExists(ctx, path)
+		require.NoError(t, err)
+		require.Equal(t, expected, exists, path)
+	}
+	truncatedTS, err := stream.GetTSFromFile(ctx, dst, stream.TruncateSafePointFileName)
+	require.NoError(t, err)
+	require.EqualValues(t, 25, truncatedTS)
+}
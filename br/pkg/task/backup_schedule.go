@@ -0,0 +1,306 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package task
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/log"
+	berrors "github.com/pingcap/tidb/br/pkg/errors"
+	"github.com/pingcap/tidb/br/pkg/glue"
+	"github.com/pingcap/tidb/br/pkg/metautil"
+	"github.com/pingcap/tidb/br/pkg/storage"
+	"github.com/pingcap/tidb/br/pkg/utils"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"go.uber.org/zap"
+)
+
+const (
+	flagScheduleInterval   = "interval"
+	flagScheduleFullEvery  = "full-every"
+	flagScheduleRetention  = "retention"
+	flagScheduleLogStorage = "log-storage"
+	flagScheduleOnce       = "once"
+
+	// backupScheduleStateFile records the backups taken by the schedule under the storage root.
+	backupScheduleStateFile  = "backup-schedule.json"
+	backupScheduleLockPath   = "backup-schedule.lock"
+	hintOnBackupScheduleLock = "There might be another backup schedule running on the storage, or a schedule that " +
+		"didn't exit properly. You may wait for it or manually delete the lock file " + backupScheduleLockPath +
+		" at the external storage."
+
+	scheduledBackupFull = "full"
+	// scheduledBackupDiff is the incremental backup since the last full backup, so a full backup and one
+	// differential backup are enough to restore to its ts.
+	scheduledBackupDiff = "diff"
+
+	// BackupScheduleCmd is the name of `br backup schedule`.
+	BackupScheduleCmd = "Backup Schedule"
+)
+
+// BackupScheduleConfig is the config for `br backup schedule`.
+type BackupScheduleConfig struct {
+	BackupConfig
+
+	// Interval is the interval between the starts of two scheduled backups.
+	Interval time.Duration `json:"interval" toml:"interval"`
+	// FullEvery is the number of backups in a full backup and the differential backups after it.
+	FullEvery uint `json:"full-every" toml:"full-every"`
+	// Retention is the number of the full backups kept with their differential backups, zero means unlimited.
+	Retention uint `json:"retention" toml:"retention"`
+	// LogStorage is the running log backup, whose logs before the oldest kept full backup are truncated.
+	LogStorage string `json:"log-storage" toml:"log-storage"`
+	// Once takes a single scheduled backup and exits, which is used to run the schedule by cron.
+	Once bool `json:"once" toml:"once"`
+}
+
+// DefineBackupScheduleFlags defines flags for `br backup schedule`.
+func DefineBackupScheduleFlags(command *cobra.Command) {
+	command.Flags().Duration(flagScheduleInterval, 24*time.Hour, "The interval between the starts of two backups")
+	command.Flags().Uint(flagScheduleFullEvery, 1, "Take a full backup every N backups, "+
+		"the backups between them are differential backups since the last full backup")
+	command.Flags().Uint(flagScheduleRetention, 7, "Keep the last N full backups and their differential backups, "+
+		"the older ones are deleted, 0 means unlimited")
+	command.Flags().String(flagScheduleLogStorage, "", "The storage of the running log backup, "+
+		"the backups are checked to be covered by it, and the logs before the oldest kept full backup are truncated")
+	command.Flags().Bool(flagScheduleOnce, false, "Take a single backup of the schedule and exit, "+
+		"for running the schedule by cron")
+}
+
+// ParseFromFlags parses the config from the flag set.
+func (cfg *BackupScheduleConfig) ParseFromFlags(flags *pflag.FlagSet) error {
+	if err := cfg.BackupConfig.ParseFromFlags(flags, false); err != nil {
+		return errors.Trace(err)
+	}
+	if cfg.Storage == "" {
+		return errors.Annotatef(berrors.ErrInvalidArgument, "--%s is required", flagStorage)
+	}
+	if cfg.BackupTS > 0 || cfg.LastBackupTS > 0 {
+		return errors.Annotatef(berrors.ErrInvalidArgument,
+			"--%s and --%s are decided by the schedule", flagBackupTS, flagLastBackupTS)
+	}
+	var err error
+	if cfg.Interval, err = flags.GetDuration(flagScheduleInterval); err != nil {
+		return errors.Trace(err)
+	}
+	if cfg.Interval <= 0 {
+		return errors.Annotatef(berrors.ErrInvalidArgument, "--%s should be positive", flagScheduleInterval)
+	}
+	if cfg.FullEvery, err = flags.GetUint(flagScheduleFullEvery); err != nil {
+		return errors.Trace(err)
+	}
+	if cfg.FullEvery == 0 {
+		return errors.Annotatef(berrors.ErrInvalidArgument, "--%s should be positive", flagScheduleFullEvery)
+	}
+	if cfg.Retention, err = flags.GetUint(flagScheduleRetention); err != nil {
+		return errors.Trace(err)
+	}
+	if cfg.LogStorage, err = flags.GetString(flagScheduleLogStorage); err != nil {
+		return errors.Trace(err)
+	}
+	cfg.Once, err = flags.GetBool(flagScheduleOnce)
+	return errors.Trace(err)
+}
+
+// scheduledBackup is a backup taken by the schedule in the directory Name under the storage root.
+type scheduledBackup struct {
+	Name     string `json:"name"`
+	Kind     string `json:"kind"`
+	BackupTS uint64 `json:"backup-ts"`
+	// LastBackupTS is the ts of the full backup a differential backup is based on.
+	LastBackupTS uint64 `json:"last-backup-ts,omitempty"`
+}
+
+// backupScheduleState is the backups taken by the schedule in the order of the ts.
+type backupScheduleState struct {
+	Backups []*scheduledBackup `json:"backups"`
+}
+
+func readBackupScheduleState(ctx context.Context, s storage.ExternalStorage) (*backupScheduleState, error) {
+	state := &backupScheduleState{Backups: make([]*scheduledBackup, 0)}
+	exists, err := s.FileExists(ctx, backupScheduleStateFile)
+	if err != nil || !exists {
+		return state, errors.Trace(err)
+	}
+	data, err := s.ReadFile(ctx, backupScheduleStateFile)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, errors.Annotatef(err, "failed to parse %s", backupScheduleStateFile)
+	}
+	return state, nil
+}
+
+func writeBackupScheduleState(ctx context.Context, s storage.ExternalStorage, state *backupScheduleState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(s.WriteFile(ctx, backupScheduleStateFile, data))
+}
+
+// nextScheduledBackup returns the next backup of the schedule, which is a full backup every fullEvery
+// backups, and a differential backup since the last full backup otherwise.
+func nextScheduledBackup(state *backupScheduleState, fullEvery uint, now time.Time) *scheduledBackup {
+	next := &scheduledBackup{Kind: scheduledBackupFull}
+	for i := len(state.Backups) - 1; i >= 0; i-- {
+		if state.Backups[i].Kind != scheduledBackupFull {
+			continue
+		}
+		if uint(len(state.Backups)-i) < fullEvery {
+			next.Kind, next.LastBackupTS = scheduledBackupDiff, state.Backups[i].BackupTS
+		}
+		break
+	}
+	next.Name = fmt.Sprintf("%s-%s", next.Kind, now.UTC().Format("20060102-150405"))
+	return next
+}
+
+// expireScheduledBackups splits the backups into the expired ones and the kept ones, which are the last
+// retention full backups and the backups after them. It also returns the ts of the oldest kept full
+// backup, before which the logs aren't needed anymore.
+func expireScheduledBackups(
+	state *backupScheduleState,
+	retention uint,
+) (expired, kept []*scheduledBackup, oldestFullTS uint64) {
+	cut, fulls := 0, uint(0)
+	for i := len(state.Backups) - 1; i >= 0; i-- {
+		if state.Backups[i].Kind != scheduledBackupFull {
+			continue
+		}
+		fulls++
+		cut, oldestFullTS = i, state.Backups[i].BackupTS
+		if retention > 0 && fulls == retention {
+			break
+		}
+	}
+	return state.Backups[:cut], state.Backups[cut:], oldestFullTS
+}
+
+// deleteScheduledBackup deletes the files of a backup under the storage root.
+func deleteScheduledBackup(ctx context.Context, s storage.ExternalStorage, backup *scheduledBackup) error {
+	files := make([]string, 0)
+	err := s.WalkDir(ctx, &storage.WalkOption{SubDir: backup.Name}, func(name string, _ int64) error {
+		files = append(files, name)
+		return nil
+	})
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(s.DeleteFiles(ctx, files))
+}
+
+// runScheduledBackup takes the next backup of the schedule, deletes the expired backups and truncates the
+// logs before the oldest kept full backup.
+func runScheduledBackup(ctx context.Context, g glue.Glue, cfg *BackupScheduleConfig) (err error) {
+	_, root, err := GetStorage(ctx, cfg.Storage, &cfg.Config)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	lock, err := storage.TryLockRemote(ctx, root, backupScheduleLockPath, hintOnBackupScheduleLock)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer utils.WithCleanUp(&err, 10*time.Second, func(ctx context.Context) error {
+		return lock.Unlock(ctx)
+	})
+	state, err := readBackupScheduleState(ctx, root)
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	next := nextScheduledBackup(state, cfg.FullEvery, time.Now())
+	backupCfg := cfg.BackupConfig
+	backupCfg.LastBackupTS = next.LastBackupTS
+	if backupCfg.Storage, err = subStorageURL(cfg.Storage, next.Name); err != nil {
+		return errors.Trace(err)
+	}
+	log.Info("start the scheduled backup", zap.String("name", next.Name), zap.String("kind", next.Kind),
+		zap.Uint64("last-backup-ts", next.LastBackupTS))
+	if err := RunBackup(ctx, g, FullBackupCmd, &backupCfg); err != nil {
+		return errors.Annotatef(err, "failed to take the scheduled backup %s", next.Name)
+	}
+	_, _, backupMeta, err := ReadBackupMeta(ctx, metautil.MetaFile, &backupCfg.Config)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	next.BackupTS = backupMeta.GetEndVersion()
+	state.Backups = append(state.Backups, next)
+	if err := writeBackupScheduleState(ctx, root, state); err != nil {
+		return errors.Trace(err)
+	}
+
+	var logInfo backupLogInfo
+	if cfg.LogStorage != "" {
+		_, logStorage, err := GetStorage(ctx, cfg.LogStorage, &cfg.Config)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if logInfo, err = getLogRangeWithStorage(ctx, logStorage); err != nil {
+			return errors.Annotate(err, "failed to read the log backup")
+		}
+		clusterMismatched := logInfo.clusterID != 0 && backupMeta.ClusterId != 0 && logInfo.clusterID != backupMeta.ClusterId
+		if clusterMismatched || next.BackupTS < logInfo.logMinTS {
+			log.Warn("the scheduled backup isn't covered by the log backup, it can't be the base of pitr",
+				zap.String("name", next.Name), zap.Uint64("backup-ts", next.BackupTS),
+				zap.Uint64("cluster-id", backupMeta.ClusterId), zap.Uint64("log-min-ts", logInfo.logMinTS),
+				zap.Uint64("log-cluster-id", logInfo.clusterID))
+		}
+	}
+
+	expired, kept, oldestFullTS := expireScheduledBackups(state, cfg.Retention)
+	for _, backup := range expired {
+		if err := deleteScheduledBackup(ctx, root, backup); err != nil {
+			return errors.Annotatef(err, "failed to delete the expired backup %s", backup.Name)
+		}
+		state.Backups = state.Backups[1:]
+		if err := writeBackupScheduleState(ctx, root, state); err != nil {
+			return errors.Trace(err)
+		}
+		log.Info("deleted the expired backup", zap.String("name", backup.Name), zap.Uint64("backup-ts", backup.BackupTS))
+	}
+
+	if cfg.LogStorage != "" && oldestFullTS > logInfo.logMinTS {
+		truncateCfg := StreamConfig{Config: cfg.Config, Until: oldestFullTS, SkipPrompt: true}
+		truncateCfg.Storage = cfg.LogStorage
+		if err := RunStreamTruncate(ctx, g, StreamTruncate, &truncateCfg); err != nil {
+			return errors.Annotate(err, "failed to truncate the log backup")
+		}
+	}
+	log.Info("finished the scheduled backup", zap.String("name", next.Name), zap.Uint64("backup-ts", next.BackupTS),
+		zap.Int("expired", len(expired)), zap.Int("kept", len(kept)), zap.Uint64("oldest-full-backup-ts", oldestFullTS))
+	return nil
+}
+
+// RunBackupSchedule takes the backups periodically into the directories under the storage root until the
+// context is canceled, each of the backups deletes the expired backups and truncates the log backup.
+func RunBackupSchedule(c context.Context, g glue.Glue, cmdName string, cfg *BackupScheduleConfig) error {
+	ctx, cancel := context.WithCancel(c)
+	defer cancel()
+
+	for {
+		start := time.Now()
+		if err := runScheduledBackup(ctx, g, cfg); err != nil {
+			if cfg.Once || ctx.Err() != nil {
+				return errors.Trace(err)
+			}
+			log.Warn("the scheduled backup failed, retry in the next round", zap.String("cmd", cmdName), zap.Error(err))
+		}
+		if cfg.Once {
+			return nil
+		}
+		wait := time.Until(start.Add(cfg.Interval))
+		log.Info("wait for the next scheduled backup", zap.String("cmd", cmdName), zap.Duration("wait", wait))
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(wait):
+		}
+	}
+}
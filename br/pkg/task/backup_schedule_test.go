@@ -0,0 +1,91 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package task
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pingcap/tidb/br/pkg/storage"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseBackupScheduleFlags(t *testing.T) {
+	parse := func(args ...string) (*BackupScheduleConfig, error) {
+		command := &cobra.Command{}
+		DefineCommonFlags(command.Flags())
+		DefineBackupFlags(command.Flags())
+		DefineBackupScheduleFlags(command)
+		require.NoError(t, command.Flags().Parse(args))
+		cfg := &BackupScheduleConfig{}
+		return cfg, cfg.ParseFromFlags(command.Flags())
+	}
+
+	cfg, err := parse("-s", "local:///root", "--interval", "6h", "--full-every", "4", "--retention", "2",
+		"--log-storage", "local:///log", "--once")
+	require.NoError(t, err)
+	require.Equal(t, 6*time.Hour, cfg.Interval)
+	require.EqualValues(t, 4, cfg.FullEvery)
+	require.EqualValues(t, 2, cfg.Retention)
+	require.Equal(t, "local:///log", cfg.LogStorage)
+	require.True(t, cfg.Once)
+
+	_, err = parse("-s", "local:///root", "--lastbackupts", "100")
+	require.ErrorContains(t, err, "decided by the schedule")
+	_, err = parse("-s", "local:///root", "--full-every", "0")
+	require.ErrorContains(t, err, "--full-every should be positive")
+}
+
+func TestBackupScheduleRounds(t *testing.T) {
+	ctx := context.Background()
+	s, err := storage.NewLocalStorage(t.TempDir())
+	require.NoError(t, err)
+
+	// a full backup every 3 backups, and the last 2 full backups are kept.
+	state, err := readBackupScheduleState(ctx, s)
+	require.NoError(t, err)
+	require.Empty(t, state.Backups)
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := range 7 {
+		next := nextScheduledBackup(state, 3, now.Add(time.Duration(i)*time.Hour))
+		next.BackupTS = uint64(10 * (i + 1))
+		state.Backups = append(state.Backups, next)
+	}
+	kinds := make([]string, 0, len(state.Backups))
+	for _, backup := range state.Backups {
+		kinds = append(kinds, backup.Kind)
+	}
+	require.Equal(t, []string{"full", "diff", "diff", "full", "diff", "diff", "full"}, kinds)
+	require.Equal(t, "full-20260101-000000", state.Backups[0].Name)
+	require.Equal(t, "diff-20260101-010000", state.Backups[1].Name)
+	require.Equal(t, uint64(10), state.Backups[2].LastBackupTS)
+	require.Equal(t, uint64(40), state.Backups[5].LastBackupTS)
+	require.Zero(t, state.Backups[6].LastBackupTS)
+
+	require.NoError(t, writeBackupScheduleState(ctx, s, state))
+	state, err = readBackupScheduleState(ctx, s)
+	require.NoError(t, err)
+	require.Len(t, state.Backups, 7)
+
+	expired, kept, oldestFullTS := expireScheduledBackups(state, 2)
+	require.Len(t, expired, 3)
+	require.Len(t, kept, 4)
+	require.Equal(t, uint64(40), oldestFullTS)
+	expired, kept, oldestFullTS = expireScheduledBackups(state, 0)
+	require.Empty(t, expired)
+	require.Len(t, kept, 7)
+	require.Equal(t, uint64(10), oldestFullTS)
+
+	require.NoError(t, s.WriteFile(ctx, "full-20260101-000000/backupmeta", []byte("meta")))
+	require.NoError(t, s.WriteFile(ctx, "full-20260101-000000/1/1.sst", []byte("data")))
+	require.NoError(t, s.WriteFile(ctx, "diff-20260101-010000/backupmeta", []byte("meta")))
+	require.NoError(t, deleteScheduledBackup(ctx, s, state.Backups[0]))
+	exists, err := s.FileExists(ctx, "full-20260101-000000/1/1.sst")
+	require.NoError(t, err)
+	require.False(t, exists)
+	exists, err = s.FileExists(ctx, "diff-20260101-010000/backupmeta")
+	require.NoError(t, err)
+	require.True(t, exists)
+}
@@ -5,6 +5,7 @@ package task
 import (
 	"bytes"
 	"context"
+	"time"
 
 	"github.com/opentracing/opentracing-go"
 	"github.com/pingcap/errors"
@@ -37,6 +38,13 @@ type TxnKvConfig struct {
 
 	CompressionConfig
 	RemoveSchedulers bool `json:"remove-schedulers" toml:"remove-schedulers"`
+
+	// ConsistencyHook is the shell command or the http(s) webhook holding a logical barrier of the
+	// application while the backup ts is taken, the marker of the barrier is embedded into the backupmeta.
+	ConsistencyHook        string        `json:"consistency-hook" toml:"consistency-hook"`
+	ConsistencyHookTimeout time.Duration `json:"consistency-hook-timeout" toml:"consistency-hook-timeout"`
+
+	Labels map[string]string `json:"labels" toml:"labels"`
 }
 
 // DefineTxnBackupFlags defines common flags for the backup command.
@@ -50,6 +58,12 @@ func DefineTxnBackupFlags(command *cobra.Command) {
 		"disable the balance, shuffle and region-merge schedulers in PD to speed up backup")
 	// This flag can impact the online cluster, so hide it in case of abuse.
 	_ = command.Flags().MarkHidden(flagRemoveSchedulers)
+	command.Flags().String(flagConsistencyHook, "", "the shell command or the http(s) webhook to acquire a logical "+
+		"barrier of the application, e.g. after draining its queues, before the backup ts is taken. The context is "+
+		"passed in JSON by the stdin or the request body, and the hook should output {\"marker\": \"...\"}. "+
+		"The hook is called again with the marker and the backup ts to release the barrier, and the marker is "+
+		"embedded into the backupmeta for the application-consistent restore")
+	command.Flags().Duration(flagConsistencyHookTimeout, defaultHookTimeout, "the timeout of each call of the consistency hook")
 }
 
 // ParseFromFlags parses the txn kv backup&restore common flags from the flag set.
@@ -80,6 +94,15 @@ func (cfg *TxnKvConfig) ParseBackupConfigFromFlags(flags *pflag.FlagSet) error {
 	if err != nil {
 		return errors.Trace(err)
 	}
+	if cfg.ConsistencyHook, err = flags.GetString(flagConsistencyHook); err != nil {
+		return errors.Trace(err)
+	}
+	if cfg.ConsistencyHookTimeout, err = flags.GetDuration(flagConsistencyHookTimeout); err != nil {
+		return errors.Trace(err)
+	}
+	if cfg.Labels, err = parseBackupLabelFlag(flags); err != nil {
+		return errors.Trace(err)
+	}
 	level, err := flags.GetInt32(flagCompressionLevel)
 	if err != nil {
 		return errors.Trace(err)
@@ -101,6 +124,8 @@ func (cfg *TxnKvConfig) Adjust() {
 func RunBackupTxn(c context.Context, g glue.Glue, cmdName string, cfg *TxnKvConfig) error {
 	cfg.Adjust()
 	defer summary.Summary(cmdName)
+	defer cfg.storageRetryPolicy.Report()
+	defer cfg.collectStorageRequestStats()
 
 	ctx, cancel := context.WithCancel(c)
 	defer cancel()
@@ -111,7 +136,7 @@ func RunBackupTxn(c context.Context, g glue.Glue, cmdName string, cfg *TxnKvConf
 		ctx = opentracing.ContextWithSpan(ctx, span1)
 	}
 
-	u, err := storage.ParseBackend(cfg.Storage, &cfg.BackendOptions)
+	u, err := storage.ParseBackendWithContext(ctx, cfg.Storage, &cfg.BackendOptions)
 	if err != nil {
 		return errors.Trace(err)
 	}
@@ -128,6 +153,8 @@ func RunBackupTxn(c context.Context, g glue.Glue, cmdName string, cfg *TxnKvConf
 		NoCredentials:            cfg.NoCreds,
 		SendCredentials:          cfg.SendCreds,
 		CheckS3ObjectLockOptions: true,
+		RetryPolicy:              cfg.storageRetryPolicy,
+		RequestStats:             cfg.storageRequestStats,
 	}
 	if err = client.SetStorageAndCheckNotInUse(ctx, u, &opts); err != nil {
 		return errors.Trace(err)
@@ -183,8 +210,27 @@ func RunBackupTxn(c context.Context, g glue.Glue, cmdName string, cfg *TxnKvConf
 		}
 		updateCh.Inc()
 	}
-	backupTS, err := client.GetCurrentTS(ctx)
-	if err != nil {
+	var (
+		backupTS         uint64
+		consistencyPoint *metautil.ConsistencyPoint
+	)
+	if cfg.ConsistencyHook != "" {
+		httpClient, err := cfg.TLS.ToHTTPClient()
+		if err != nil {
+			return errors.Trace(err)
+		}
+		// the options of the storage are omitted to not leak the credentials.
+		storageURL := storage.FormatBackendURL(u)
+		hookCtx := HookContext{Command: cmdName, Storage: storageURL.String()}
+		consistencyPoint, err = acquireConsistencyPoint(
+			ctx, httpClient, cfg.ConsistencyHook, cfg.ConsistencyHookTimeout, hookCtx, client.GetCurrentTS)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		backupTS = consistencyPoint.BackupTS
+		summary.Log("backup at the consistency point of the application",
+			zap.String("marker", consistencyPoint.Marker), zap.Uint64("backup-ts", backupTS))
+	} else if backupTS, err = client.GetCurrentTS(ctx); err != nil {
 		return errors.Trace(err)
 	}
 	g.Record("BackupTS", backupTS)
@@ -219,7 +265,16 @@ func RunBackupTxn(c context.Context, g glue.Glue, cmdName string, cfg *TxnKvConf
 		m.ClusterVersion = clusterVersion
 		m.BrVersion = brVersion
 		m.ApiVersion = client.GetApiVersion()
+		if consistencyPoint != nil {
+			err = metautil.SetConsistencyPoint(m, consistencyPoint)
+		}
+		if err == nil && len(cfg.Labels) > 0 {
+			err = metautil.SetBackupLabels(m, cfg.Labels)
+		}
 	})
+	if err != nil {
+		return errors.Trace(err)
+	}
 	err = metaWriter.FinishWriteMetas(ctx, metautil.AppendDataFile)
 	if err != nil {
 		return errors.Trace(err)
@@ -0,0 +1,404 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package task
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/pingcap/errors"
+	backuppb "github.com/pingcap/kvproto/pkg/brpb"
+	"github.com/pingcap/log"
+	"github.com/pingcap/tidb/br/pkg/conn"
+	"github.com/pingcap/tidb/br/pkg/conn/util"
+	"github.com/pingcap/tidb/br/pkg/httputil"
+	"github.com/pingcap/tidb/br/pkg/logutil"
+	"github.com/pingcap/tidb/br/pkg/metautil"
+	"github.com/pingcap/tidb/br/pkg/storage"
+	"github.com/pingcap/tidb/pkg/ddl/placement"
+	pdhttp "github.com/tikv/pd/client/http"
+	"go.uber.org/zap"
+)
+
+// clusterConfigTiKVItems are the TiKV config items saved alongside the backup, they affect how the restored
+// data is split, compacted and garbage collected.
+var clusterConfigTiKVItems = []string{
+	"coprocessor.region-split-size",
+	"coprocessor.region-split-keys",
+	"coprocessor.region-max-size",
+	"coprocessor.region-max-keys",
+	"raftstore.region-split-check-diff",
+	"gc.ratio-threshold",
+	"gc.enable-compaction-filter",
+	"import.num-threads",
+	"resolved-ts.advance-ts-interval",
+	"storage.api-version",
+	"storage.enable-ttl",
+	"log-backup.enable",
+}
+
+// clusterConfigTiKVStaticItems are the TiKV config items can't be changed online.
+var clusterConfigTiKVStaticItems = map[string]struct{}{
+	"storage.api-version": {},
+	"storage.enable-ttl":  {},
+	"log-backup.enable":   {},
+}
+
+// clusterConfigUnportableSchedules are the PD schedule config items bound to the store IDs or the
+// schedulers, they can't be reproduced in another cluster.
+var clusterConfigUnportableSchedules = []string{
+	"store-limit",
+	"schedulers-v2",
+	"schedulers-payload",
+}
+
+// captureClusterConfig captures the config of the cluster to save alongside the backup.
+func captureClusterConfig(ctx context.Context, mgr *conn.Mgr, backupTS uint64) (*metautil.ClusterConfigSnapshot, error) {
+	schedule, err := mgr.GetPDHTTPClient().GetScheduleConfig(ctx)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	for _, item := range clusterConfigUnportableSchedules {
+		delete(schedule, item)
+	}
+	bundles, err := mgr.GetPDHTTPClient().GetAllPlacementRuleBundles(ctx)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	// the rules of the tables and the TiFlash replicas are restored with the tables.
+	bundles = slices.DeleteFunc(bundles, func(b *pdhttp.GroupBundle) bool {
+		return strings.HasPrefix(b.ID, placement.BundleIDPrefix) || b.ID == placement.TiFlashRuleGroupID
+	})
+	stores, err := conn.GetAllTiKVStoresWithRetry(ctx, mgr.GetPDClient(), util.SkipTiFlash)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	tikvConfigs, err := mgr.GetConfigItemsOfTiKV(ctx, httputil.NewClient(mgr.GetTLSConfig()), clusterConfigTiKVItems)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	snapshot := &metautil.ClusterConfigSnapshot{
+		ClusterID:      mgr.GetPDClient().GetClusterID(ctx),
+		BackupTS:       backupTS,
+		PDSchedule:     schedule,
+		PlacementRules: bundles,
+	}
+	for _, s := range stores {
+		tikvConfig, ok := tikvConfigs[s.GetId()]
+		if !ok {
+			// the store isn't up.
+			continue
+		}
+		store := &metautil.ClusterConfigStore{ID: s.GetId(), Address: s.GetAddress(), TiKV: tikvConfig}
+		for _, l := range s.GetLabels() {
+			if store.Labels == nil {
+				store.Labels = make(map[string]string)
+			}
+			store.Labels[l.GetKey()] = l.GetValue()
+		}
+		snapshot.Stores = append(snapshot.Stores, store)
+	}
+	return snapshot, nil
+}
+
+// saveClusterConfig saves the config of the cluster alongside the backup. The backup doesn't depend on it,
+// so it only warns on failure.
+func saveClusterConfig(
+	ctx context.Context,
+	mgr *conn.Mgr,
+	s storage.ExternalStorage,
+	backupTS uint64,
+	cipher *backuppb.CipherInfo,
+) {
+	snapshot, err := captureClusterConfig(ctx, mgr, backupTS)
+	if err == nil {
+		err = metautil.WriteClusterConfig(ctx, s, snapshot, cipher)
+	}
+	if err != nil {
+		log.Warn("failed to save the cluster config, `br restore config` can't be used with the backup",
+			logutil.ShortError(err))
+		return
+	}
+	log.Info("saved the cluster config", zap.String("file", metautil.ClusterConfigFile),
+		zap.Int("schedule-items", len(snapshot.PDSchedule)), zap.Int("rule-groups", len(snapshot.PlacementRules)),
+		zap.Int("stores", len(snapshot.Stores)))
+}
+
+// clusterConfigKind is the kind of the cluster config items.
+type clusterConfigKind string
+
+const (
+	clusterConfigPDSchedule     clusterConfigKind = "pd-schedule"
+	clusterConfigPlacementRules clusterConfigKind = "placement-rules"
+	clusterConfigStoreLabels    clusterConfigKind = "store-labels"
+	clusterConfigTiKV           clusterConfigKind = "tikv"
+)
+
+// clusterConfigDiff is an item of the cluster config differing between the backup and the target cluster.
+type clusterConfigDiff struct {
+	Kind   clusterConfigKind
+	Item   string
+	Backup string
+	Target string
+	// Applicable is whether the item of the backup can be applied to the target cluster online.
+	Applicable bool
+	// Reason is why the item can't be applied, if it's known.
+	Reason string
+}
+
+const clusterConfigAbsent = "<absent>"
+
+// formatClusterConfigValue formats the value of a config item to compare and report.
+func formatClusterConfigValue(v any) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
+
+// diffClusterConfig compares the config in the backup with the target cluster, the items only in the target
+// cluster are ignored since they may be introduced by a newer version.
+func diffClusterConfig(backup, target *metautil.ClusterConfigSnapshot) []clusterConfigDiff {
+	diffs := make([]clusterConfigDiff, 0)
+	for _, item := range slices.Sorted(maps.Keys(backup.PDSchedule)) {
+		backupValue := formatClusterConfigValue(backup.PDSchedule[item])
+		targetValue := clusterConfigAbsent
+		if v, ok := target.PDSchedule[item]; ok {
+			targetValue = formatClusterConfigValue(v)
+		}
+		if backupValue != targetValue {
+			diffs = append(diffs, clusterConfigDiff{
+				Kind: clusterConfigPDSchedule, Item: item, Backup: backupValue, Target: targetValue, Applicable: true,
+			})
+		}
+	}
+
+	targetBundles := make(map[string]*pdhttp.GroupBundle, len(target.PlacementRules))
+	for _, b := range target.PlacementRules {
+		targetBundles[b.ID] = b
+	}
+	for _, b := range backup.PlacementRules {
+		diff := clusterConfigDiff{Kind: clusterConfigPlacementRules, Item: b.ID}
+		diff.Backup, diff.Target = formatRuleBundles(b, targetBundles[b.ID])
+		if diff.Backup == diff.Target {
+			continue
+		}
+		// the rules can't be satisfied leave the regions unhealthy, including the default rule of PD.
+		diff.Reason = unsatisfiableRule(b, target.Stores)
+		diff.Applicable = diff.Reason == ""
+		diffs = append(diffs, diff)
+	}
+
+	backupLabels, targetLabels := storeLabelDistribution(backup.Stores), storeLabelDistribution(target.Stores)
+	for _, key := range slices.Sorted(maps.Keys(backupLabels)) {
+		targetValue, ok := targetLabels[key]
+		if !ok {
+			targetValue = clusterConfigAbsent
+		}
+		// the stores of the clusters can't be mapped to each other, they have to be relabeled manually.
+		if backupLabels[key] != targetValue {
+			diffs = append(diffs, clusterConfigDiff{
+				Kind: clusterConfigStoreLabels, Item: key, Backup: backupLabels[key], Target: targetValue,
+			})
+		}
+	}
+
+	for _, item := range clusterConfigTiKVItems {
+		backupValue, uniform := tikvConfigValue(backup.Stores, item)
+		if backupValue == clusterConfigAbsent {
+			continue
+		}
+		targetValue, _ := tikvConfigValue(target.Stores, item)
+		if backupValue != targetValue {
+			_, static := clusterConfigTiKVStaticItems[item]
+			diffs = append(diffs, clusterConfigDiff{
+				Kind: clusterConfigTiKV, Item: item, Backup: backupValue, Target: targetValue,
+				Applicable: uniform && !static,
+			})
+		}
+	}
+	return diffs
+}
+
+// formatRuleBundles formats the rule groups to compare. The rules are summarized by their roles and counts,
+// and the JSON of the groups are used if the summaries are the same but the rules differ.
+func formatRuleBundles(backup, target *pdhttp.GroupBundle) (backupValue, targetValue string) {
+	summarize := func(b *pdhttp.GroupBundle) string {
+		rules := make([]string, 0, len(b.Rules))
+		for _, r := range b.Rules {
+			rules = append(rules, fmt.Sprintf("%s:%s*%d", r.ID, r.Role, r.Count))
+		}
+		slices.Sort(rules)
+		return strings.Join(rules, ", ")
+	}
+	if target == nil {
+		return summarize(backup), clusterConfigAbsent
+	}
+	backupJSON, targetJSON := formatClusterConfigValue(backup), formatClusterConfigValue(target)
+	if backupJSON == targetJSON {
+		return backupJSON, targetJSON
+	}
+	backupValue, targetValue = summarize(backup), summarize(target)
+	if backupValue == targetValue {
+		return backupJSON, targetJSON
+	}
+	return backupValue, targetValue
+}
+
+// unsatisfiableRule returns why a rule of the group can't be satisfied by the stores, or the empty string if
+// all the rules can be. A rule is unsatisfiable if the stores matching its label constraints are fewer than
+// its count, or a location label of it doesn't exist on any store.
+func unsatisfiableRule(b *pdhttp.GroupBundle, stores []*metautil.ClusterConfigStore) string {
+	for _, r := range b.Rules {
+		matched := 0
+		for _, s := range stores {
+			if matchLabelConstraints(s.Labels, r.LabelConstraints) {
+				matched++
+			}
+		}
+		if matched < r.Count {
+			return fmt.Sprintf("rule %s needs %d stores matching its label constraints, but the target cluster has %d",
+				r.ID, r.Count, matched)
+		}
+		for _, key := range r.LocationLabels {
+			if !slices.ContainsFunc(stores, func(s *metautil.ClusterConfigStore) bool {
+				_, ok := s.Labels[key]
+				return ok
+			}) {
+				return fmt.Sprintf("the location label %s of rule %s doesn't exist on the target stores", key, r.ID)
+			}
+		}
+	}
+	return ""
+}
+
+// matchLabelConstraints checks whether the labels of a store match all the constraints, in the same way as PD.
+func matchLabelConstraints(labels map[string]string, constraints []pdhttp.LabelConstraint) bool {
+	for _, c := range constraints {
+		value, ok := labels[c.Key]
+		var matched bool
+		switch c.Op {
+		case pdhttp.In:
+			matched = ok && slices.Contains(c.Values, value)
+		case pdhttp.NotIn:
+			matched = !ok || !slices.Contains(c.Values, value)
+		case pdhttp.Exists:
+			matched = ok
+		case pdhttp.NotExists:
+			matched = !ok
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+// storeLabelDistribution returns the distribution of the values of every label key, like "a:3, b:3".
+func storeLabelDistribution(stores []*metautil.ClusterConfigStore) map[string]string {
+	counts := make(map[string]map[string]int)
+	for _, s := range stores {
+		for key, value := range s.Labels {
+			if counts[key] == nil {
+				counts[key] = make(map[string]int)
+			}
+			counts[key][value]++
+		}
+	}
+	distribution := make(map[string]string, len(counts))
+	for key, values := range counts {
+		items := make([]string, 0, len(values))
+		for _, value := range slices.Sorted(maps.Keys(values)) {
+			items = append(items, fmt.Sprintf("%s:%d", value, values[value]))
+		}
+		distribution[key] = strings.Join(items, ", ")
+	}
+	return distribution
+}
+
+// tikvConfigValue returns the value of the TiKV config item, and whether all the stores have the same value.
+// The distinct values are listed if they differ.
+func tikvConfigValue(stores []*metautil.ClusterConfigStore, item string) (string, bool) {
+	values := make(map[string]struct{})
+	for _, s := range stores {
+		if v, ok := s.TiKV[item]; ok {
+			values[formatClusterConfigValue(v)] = struct{}{}
+		}
+	}
+	switch len(values) {
+	case 0:
+		return clusterConfigAbsent, false
+	case 1:
+		for v := range values {
+			return v, true
+		}
+	}
+	return "mixed: " + strings.Join(slices.Sorted(maps.Keys(values)), ", "), false
+}
+
+// clusterConfigApplier applies the cluster config items to the target cluster.
+type clusterConfigApplier interface {
+	SetScheduleConfig(ctx context.Context, config map[string]any) error
+	SetPlacementRuleBundles(ctx context.Context, bundles []*pdhttp.GroupBundle, partial bool) error
+	SetTiKVConfig(ctx context.Context, items map[string]any) error
+}
+
+// applyClusterConfig applies the applicable items of the diffs from the backup, the items of each kind are
+// applied together. It returns the errors of the kinds failed to apply.
+func applyClusterConfig(
+	ctx context.Context,
+	applier clusterConfigApplier,
+	backup *metautil.ClusterConfigSnapshot,
+	diffs []clusterConfigDiff,
+) map[clusterConfigKind]error {
+	schedule := make(map[string]any)
+	bundles := make([]*pdhttp.GroupBundle, 0)
+	tikv := make(map[string]any)
+	for _, diff := range diffs {
+		if !diff.Applicable {
+			continue
+		}
+		switch diff.Kind {
+		case clusterConfigPDSchedule:
+			schedule[diff.Item] = backup.PDSchedule[diff.Item]
+		case clusterConfigPlacementRules:
+			idx := slices.IndexFunc(backup.PlacementRules, func(b *pdhttp.GroupBundle) bool { return b.ID == diff.Item })
+			bundles = append(bundles, backup.PlacementRules[idx])
+		case clusterConfigTiKV:
+			for _, s := range backup.Stores {
+				if v, ok := s.TiKV[diff.Item]; ok {
+					tikv[diff.Item] = v
+					break
+				}
+			}
+		}
+	}
+
+	errs := make(map[clusterConfigKind]error)
+	if len(schedule) > 0 {
+		if err := applier.SetScheduleConfig(ctx, schedule); err != nil {
+			errs[clusterConfigPDSchedule] = errors.Trace(err)
+		}
+	}
+	if len(bundles) > 0 {
+		// the groups not in the backup are kept.
+		if err := applier.SetPlacementRuleBundles(ctx, bundles, true); err != nil {
+			errs[clusterConfigPlacementRules] = errors.Trace(err)
+		}
+	}
+	if len(tikv) > 0 {
+		if err := applier.SetTiKVConfig(ctx, tikv); err != nil {
+			errs[clusterConfigTiKV] = errors.Trace(err)
+		}
+	}
+	return errs
+}
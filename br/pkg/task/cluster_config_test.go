@@ -0,0 +1,107 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package task
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/tidb/br/pkg/metautil"
+	"github.com/stretchr/testify/require"
+	pdhttp "github.com/tikv/pd/client/http"
+)
+
+type fakeClusterConfigApplier struct {
+	schedule map[string]any
+	bundles  []*pdhttp.GroupBundle
+	tikv     map[string]any
+}
+
+func (f *fakeClusterConfigApplier) SetScheduleConfig(_ context.Context, config map[string]any) error {
+	f.schedule = config
+	return nil
+}
+
+func (f *fakeClusterConfigApplier) SetPlacementRuleBundles(_ context.Context, bundles []*pdhttp.GroupBundle, partial bool) error {
+	if !partial {
+		return errors.New("the bundles must be set partially")
+	}
+	f.bundles = bundles
+	return nil
+}
+
+func (f *fakeClusterConfigApplier) SetTiKVConfig(_ context.Context, items map[string]any) error {
+	f.tikv = items
+	return errors.New("mock tikv error")
+}
+
+func TestDiffAndApplyClusterConfig(t *testing.T) {
+	rule := func(id string, count int, constraints ...pdhttp.LabelConstraint) *pdhttp.Rule {
+		return &pdhttp.Rule{GroupID: "pd", ID: id, Role: pdhttp.Voter, Count: count, LabelConstraints: constraints}
+	}
+	store := func(zone string, splitSize string, apiVersion float64) *metautil.ClusterConfigStore {
+		return &metautil.ClusterConfigStore{
+			Labels: map[string]string{"zone": zone},
+			TiKV:   map[string]any{"coprocessor.region-split-size": splitSize, "storage.api-version": apiVersion},
+		}
+	}
+	backup := &metautil.ClusterConfigSnapshot{
+		PDSchedule: map[string]any{
+			"max-merge-region-size":    float64(54),
+			"leader-schedule-limit":    float64(4),
+			"enable-cross-table-merge": true,
+		},
+		PlacementRules: []*pdhttp.GroupBundle{
+			{ID: "pd", Rules: []*pdhttp.Rule{rule("default", 5)}},
+			{ID: "dr", Rules: []*pdhttp.Rule{rule("primary", 2, pdhttp.LabelConstraint{Key: "zone", Op: pdhttp.In, Values: []string{"a"}})}},
+			// the zone b doesn't exist in the target cluster.
+			{ID: "remote", Rules: []*pdhttp.Rule{rule("secondary", 1, pdhttp.LabelConstraint{Key: "zone", Op: pdhttp.In, Values: []string{"b"}})}},
+			{ID: "rack", Rules: []*pdhttp.Rule{{ID: "isolated", Role: pdhttp.Voter, Count: 3, LocationLabels: []string{"rack"}}}},
+		},
+		Stores: []*metautil.ClusterConfigStore{store("a", "144MiB", 2), store("b", "144MiB", 2), store("b", "144MiB", 2)},
+	}
+	target := &metautil.ClusterConfigSnapshot{
+		PDSchedule: map[string]any{
+			"max-merge-region-size": float64(20),
+			"leader-schedule-limit": float64(4),
+			"new-item":              "x",
+		},
+		PlacementRules: []*pdhttp.GroupBundle{{ID: "pd", Rules: []*pdhttp.Rule{rule("default", 3)}}},
+		Stores: []*metautil.ClusterConfigStore{
+			store("a", "96MiB", 1), store("a", "144MiB", 1), store("c", "144MiB", 1), store("c", "144MiB", 1),
+			store("c", "144MiB", 1),
+		},
+	}
+
+	diffs := diffClusterConfig(backup, target)
+	require.Equal(t, []clusterConfigDiff{
+		{Kind: clusterConfigPDSchedule, Item: "enable-cross-table-merge", Backup: "true", Target: clusterConfigAbsent, Applicable: true},
+		{Kind: clusterConfigPDSchedule, Item: "max-merge-region-size", Backup: "54", Target: "20", Applicable: true},
+		{Kind: clusterConfigPlacementRules, Item: "pd", Backup: "default:voter*5", Target: "default:voter*3", Applicable: true},
+		{Kind: clusterConfigPlacementRules, Item: "dr", Backup: "primary:voter*2", Target: clusterConfigAbsent, Applicable: true},
+		{
+			Kind: clusterConfigPlacementRules, Item: "remote", Backup: "secondary:voter*1", Target: clusterConfigAbsent,
+			Reason: "rule secondary needs 1 stores matching its label constraints, but the target cluster has 0",
+		},
+		{
+			Kind: clusterConfigPlacementRules, Item: "rack", Backup: "isolated:voter*3", Target: clusterConfigAbsent,
+			Reason: "the location label rack of rule isolated doesn't exist on the target stores",
+		},
+		{Kind: clusterConfigStoreLabels, Item: "zone", Backup: "a:1, b:2", Target: "a:2, c:3"},
+		{Kind: clusterConfigTiKV, Item: "coprocessor.region-split-size", Backup: "144MiB", Target: "mixed: 144MiB, 96MiB", Applicable: true},
+		{Kind: clusterConfigTiKV, Item: "storage.api-version", Backup: "2", Target: "1"},
+	}, diffs)
+	require.Empty(t, diffClusterConfig(backup, backup))
+
+	applier := &fakeClusterConfigApplier{}
+	errs := applyClusterConfig(context.Background(), applier, backup, diffs)
+	require.Len(t, errs, 1)
+	require.ErrorContains(t, errs[clusterConfigTiKV], "mock tikv error")
+	require.Equal(t, map[string]any{"enable-cross-table-merge": true, "max-merge-region-size": float64(54)}, applier.schedule)
+	require.Equal(t, backup.PlacementRules[:2], applier.bundles)
+	require.Equal(t, map[string]any{"coprocessor.region-split-size": "144MiB"}, applier.tikv)
+
+	// the backups by the SQL statements save the cluster config without parsing the flags.
+	require.False(t, DefaultBackupConfig(Config{}).SkipClusterConfig)
+}
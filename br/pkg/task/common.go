@@ -3,11 +3,10 @@
 package task
 
 import (
-	"bytes"
 	"context"
 	"crypto/tls"
-	"encoding/hex"
 	"fmt"
+	"net/http"
 	"net/url"
 	"os"
 	"path"
@@ -16,6 +15,7 @@ import (
 
 	gcs "cloud.google.com/go/storage"
 	"github.com/docker/go-units"
+	"github.com/go-sql-driver/mysql"
 	"github.com/gogo/protobuf/proto"
 	"github.com/pingcap/errors"
 	backuppb "github.com/pingcap/kvproto/pkg/brpb"
@@ -23,10 +23,12 @@ import (
 	"github.com/pingcap/log"
 	"github.com/pingcap/tidb/br/pkg/conn"
 	"github.com/pingcap/tidb/br/pkg/conn/util"
+	"github.com/pingcap/tidb/br/pkg/encryption"
 	berrors "github.com/pingcap/tidb/br/pkg/errors"
 	"github.com/pingcap/tidb/br/pkg/glue"
 	"github.com/pingcap/tidb/br/pkg/metautil"
 	"github.com/pingcap/tidb/br/pkg/storage"
+	"github.com/pingcap/tidb/br/pkg/summary"
 	"github.com/pingcap/tidb/br/pkg/utils"
 	"github.com/pingcap/tidb/pkg/sessionctx/variable"
 	filter "github.com/pingcap/tidb/pkg/util/table-filter"
@@ -34,7 +36,6 @@ import (
 	"github.com/spf13/pflag"
 	"github.com/tikv/client-go/v2/config"
 	pd "github.com/tikv/pd/client"
-	"go.etcd.io/etcd/client/pkg/v3/transport"
 	clientv3 "go.etcd.io/etcd/client/v3"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
@@ -57,6 +58,12 @@ const (
 	// flagKey is the name of TLS key flag.
 	flagKey = "key"
 
+	// flagTLSComponentPD, flagTLSComponentTiKV and flagTLSComponentHTTP are the prefixes of the TLS flags
+	// of the connections to PD, TiKV and the external HTTP endpoints, e.g. `--pd.ca`.
+	flagTLSComponentPD   = "pd"
+	flagTLSComponentTiKV = "tikv"
+	flagTLSComponentHTTP = "http"
+
 	flagDatabase = "db"
 	flagTable    = "table"
 
@@ -101,22 +108,22 @@ const (
 	flagMetadataDownloadBatchSize    = "metadata-download-batch-size"
 	defaultMetadataDownloadBatchSize = 128
 
-	unlimited           = 0
-	crypterAES128KeyLen = 16
-	crypterAES192KeyLen = 24
-	crypterAES256KeyLen = 32
+	flagStorageRetryBudget         = "storage-retry-budget"
+	flagStorageBreakerThreshold    = "storage-circuit-breaker-threshold"
+	flagStorageBreakerCooldown     = "storage-circuit-breaker-cooldown"
+	defaultStorageBreakerThreshold = 10
+	defaultStorageBreakerCooldown  = 30 * time.Second
+
+	flagCatalog = "catalog"
+
+	unlimited = 0
 
 	flagFullBackupType = "type"
 
-	masterKeysDelimiter     = ","
 	flagMasterKeyConfig     = "master-key"
 	flagMasterKeyCipherType = "master-key-crypter-method"
 )
 
-const (
-	cipherKeyNonHexErrorMsg = "cipher key must be a valid hexadecimal string"
-)
-
 // FullBackupType type when doing full backup or restore
 type FullBackupType string
 
@@ -135,6 +142,22 @@ type TLSConfig struct {
 	CA   string `json:"ca" toml:"ca"`
 	Cert string `json:"cert" toml:"cert"`
 	Key  string `json:"key" toml:"key"`
+
+	// PD and TiKV override the files above for the connections to PD and TiKV, the files not set are
+	// inherited. The connections reload the rotated certificates on each handshake, except the ones of
+	// the storage of the TiDB embedded in BR, which reads the files when it connects to a server.
+	PD   TLSFiles `json:"pd" toml:"pd"`
+	TiKV TLSFiles `json:"tikv" toml:"tikv"`
+	// HTTP is used for the connections to the external HTTP endpoints, e.g. the webhooks and the TSO
+	// service, which are verified by the system CAs if it isn't set.
+	HTTP TLSFiles `json:"http" toml:"http"`
+}
+
+// TLSFiles is the CA, certificate and key files of the TLS connections to a component.
+type TLSFiles struct {
+	CA   string `json:"ca" toml:"ca"`
+	Cert string `json:"cert" toml:"cert"`
+	Key  string `json:"key" toml:"key"`
 }
 
 // IsEnabled checks if TLS open or not.
@@ -142,20 +165,58 @@ func (tls *TLSConfig) IsEnabled() bool {
 	return tls.CA != ""
 }
 
-// ToTLSConfig generate tls.Config.
-func (tls *TLSConfig) ToTLSConfig() (*tls.Config, error) {
-	tlsInfo := transport.TLSInfo{
-		CertFile:      tls.Cert,
-		KeyFile:       tls.Key,
-		TrustedCAFile: tls.CA,
+func (tls *TLSConfig) withFiles(files TLSFiles) TLSConfig {
+	override := func(value, inherited string) string {
+		if value != "" {
+			return value
+		}
+		return inherited
+	}
+	return TLSConfig{
+		CA:   override(files.CA, tls.CA),
+		Cert: override(files.Cert, tls.Cert),
+		Key:  override(files.Key, tls.Key),
 	}
-	tlsConfig, err := tlsInfo.ClientConfig()
+}
+
+// ForPD returns the TLS config of the connections to PD.
+func (tls *TLSConfig) ForPD() TLSConfig {
+	return tls.withFiles(tls.PD)
+}
+
+// ForTiKV returns the TLS config of the connections to TiKV.
+func (tls *TLSConfig) ForTiKV() TLSConfig {
+	return tls.withFiles(tls.TiKV)
+}
+
+// ToTLSConfig generate tls.Config, which reloads the CA and the certificate when the files change.
+func (tls *TLSConfig) ToTLSConfig() (*tls.Config, error) {
+	tlsConfig, err := utils.NewReloadingTLSConfig(tls.CA, tls.Cert, tls.Key)
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
 	return tlsConfig, nil
 }
 
+// ToHTTPClient returns the client of the external HTTP endpoints, nil means the default client.
+func (tls *TLSConfig) ToHTTPClient() (*http.Client, error) {
+	if tls.HTTP == (TLSFiles{}) {
+		return nil, nil
+	}
+	if tls.HTTP.CA == "" {
+		return nil, errors.Annotatef(berrors.ErrInvalidArgument, "--%s.%s is required by --%s.%s and --%s.%s",
+			flagTLSComponentHTTP, flagCA, flagTLSComponentHTTP, flagCert, flagTLSComponentHTTP, flagKey)
+	}
+	tlsConfig, err := utils.NewReloadingTLSConfig(tls.HTTP.CA, tls.HTTP.Cert, tls.HTTP.Key)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return &http.Client{Transport: &http.Transport{
+		Proxy:           http.ProxyFromEnvironment,
+		TLSClientConfig: tlsConfig,
+	}}, nil
+}
+
 // Convert the TLS config to the PD security option.
 func (tls *TLSConfig) ToPDSecurityOption() pd.SecurityOption {
 	securityOption := pd.SecurityOption{}
@@ -177,6 +238,32 @@ func (tls *TLSConfig) ToKVSecurity() config.Security {
 // ParseFromFlags parses the TLS config from the flag set.
 func (tls *TLSConfig) ParseFromFlags(flags *pflag.FlagSet) (err error) {
 	tls.CA, tls.Cert, tls.Key, err = ParseTLSTripleFromFlags(flags)
+	if err != nil {
+		return
+	}
+	for component, files := range map[string]*TLSFiles{
+		flagTLSComponentPD:   &tls.PD,
+		flagTLSComponentTiKV: &tls.TiKV,
+		flagTLSComponentHTTP: &tls.HTTP,
+	} {
+		if flags.Lookup(component+"."+flagCA) == nil {
+			// the flags are only defined by the commands connecting to the cluster.
+			continue
+		}
+		if files.CA, err = flags.GetString(component + "." + flagCA); err != nil {
+			return
+		}
+		if files.Cert, err = flags.GetString(component + "." + flagCert); err != nil {
+			return
+		}
+		if files.Key, err = flags.GetString(component + "." + flagKey); err != nil {
+			return
+		}
+	}
+	if !tls.IsEnabled() && (tls.PD.CA != "" || tls.TiKV.CA != "") {
+		return errors.Annotatef(berrors.ErrInvalidArgument, "--%s.%s and --%s.%s require --%s",
+			flagTLSComponentPD, flagCA, flagTLSComponentTiKV, flagCA, flagCA)
+	}
 	return
 }
 
@@ -187,7 +274,8 @@ func dialEtcdWithCfg(ctx context.Context, cfg Config) (*clientv3.Client, error)
 	)
 
 	if cfg.TLS.IsEnabled() {
-		tlsConfig, err = cfg.TLS.ToTLSConfig()
+		pdTLS := cfg.TLS.ForPD()
+		tlsConfig, err = pdTLS.ToTLSConfig()
 		if err != nil {
 			return nil, errors.Trace(err)
 		}
@@ -215,6 +303,37 @@ func dialEtcdWithCfg(ctx context.Context, cfg Config) (*clientv3.Client, error)
 	return etcdCLI, nil
 }
 
+// registerServiceSafePoint registers the service safe point of the task. Its ownership is claimed in
+// the etcd of the cluster, so the tasks of the other BR processes or TiDB instances can't take it. The
+// returned function releases it, see utils.SafePointLease.Release.
+func registerServiceSafePoint(
+	ctx context.Context,
+	pdClient pd.Client,
+	cfg *Config,
+	task string,
+	sp utils.BRServiceSafePoint,
+) (func(ctx context.Context, keep bool) error, error) {
+	etcdCLI, err := dialEtcdWithCfg(ctx, *cfg)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	lease, err := utils.GlobalSafePointManager().Register(ctx, pdClient, etcdCLI, task, sp)
+	if err != nil {
+		if err := etcdCLI.Close(); err != nil {
+			log.Warn("failed to close etcd client", zap.Error(err))
+		}
+		return nil, errors.Trace(err)
+	}
+	return func(ctx context.Context, keep bool) error {
+		defer func() {
+			if err := etcdCLI.Close(); err != nil {
+				log.Warn("failed to close etcd client", zap.Error(err))
+			}
+		}()
+		return errors.Trace(lease.Release(ctx, keep))
+	}, nil
+}
+
 // Config is the common configuration for all BRIE tasks.
 type Config struct {
 	storage.BackendOptions
@@ -284,16 +403,46 @@ type Config struct {
 
 	// Metadata download batch size, such as metadata for log restore
 	MetadataDownloadBatchSize uint `json:"metadata-download-batch-size" toml:"metadata-download-batch-size"`
+
+	// StorageRetryBudget is the max number of the failed requests to the external storage of the task,
+	// 0 means unlimited.
+	StorageRetryBudget int64 `json:"storage-retry-budget" toml:"storage-retry-budget"`
+	// StorageBreakerThreshold is the number of the consecutive failed requests to an endpoint of the external
+	// storage opening its circuit breaker, 0 means the circuit breaker is disabled.
+	StorageBreakerThreshold int `json:"storage-circuit-breaker-threshold" toml:"storage-circuit-breaker-threshold"`
+	// StorageBreakerCooldown is the time the requests wait for after the circuit breaker opens.
+	StorageBreakerCooldown time.Duration `json:"storage-circuit-breaker-cooldown" toml:"storage-circuit-breaker-cooldown"`
+
+	// Catalog is the storage root whose catalog the operation is recorded into, empty means not recorded.
+	Catalog string `json:"catalog" toml:"catalog"`
+
+	// storageRetryPolicy is shared by the external storages of the task, created when the flags are parsed.
+	// The retries are only limited by the storages if it's nil.
+	storageRetryPolicy *storage.RetryPolicy
+	// storageRequestStats counts the requests to the external storages sent by the BR process of the task,
+	// created when the flags are parsed. The requests of the data files sent by TiKV aren't included.
+	storageRequestStats *storage.RequestStats
 }
 
 // DefineCommonFlags defines the flags common to all BRIE commands.
 func DefineCommonFlags(flags *pflag.FlagSet) {
 	flags.BoolP(flagSendCreds, "c", true, "Whether send credentials to tikv")
-	flags.StringP(flagStorage, "s", "", `specify the url where backup storage, eg, "s3://bucket/path/prefix"`)
+	flags.StringP(flagStorage, "s", "", `specify the url where backup storage, eg, "s3://bucket/path/prefix". `+
+		`The credentials can be read from HashiCorp Vault or AWS Secrets Manager at runtime by the credential-ref `+
+		`parameter, e.g. "s3://bucket/prefix?credential-ref=vault:secret/data/br%23s3" or "credential-ref=aws-sm:br-s3"`)
 	flags.StringSliceP(flagPD, "u", []string{"127.0.0.1:2379"}, "PD address")
 	flags.String(flagCA, "", "CA certificate path for TLS connection")
 	flags.String(flagCert, "", "Certificate path for TLS connection")
 	flags.String(flagKey, "", "Private key path for TLS connection")
+	for component, name := range map[string]string{flagTLSComponentPD: "PD", flagTLSComponentTiKV: "TiKV"} {
+		flags.String(component+"."+flagCA, "", "CA certificate path for TLS connection to "+name+", overriding --"+flagCA)
+		flags.String(component+"."+flagCert, "", "Certificate path for TLS connection to "+name+", overriding --"+flagCert)
+		flags.String(component+"."+flagKey, "", "Private key path for TLS connection to "+name+", overriding --"+flagKey)
+	}
+	flags.String(flagTLSComponentHTTP+"."+flagCA, "", "CA certificate path for TLS connection to the external HTTP "+
+		"endpoints, e.g. the webhooks and the TSO service, the system CAs are used if not set")
+	flags.String(flagTLSComponentHTTP+"."+flagCert, "", "Certificate path for TLS connection to the external HTTP endpoints")
+	flags.String(flagTLSComponentHTTP+"."+flagKey, "", "Private key path for TLS connection to the external HTTP endpoints")
 	flags.Uint(flagChecksumConcurrency, variable.DefChecksumTableConcurrency, "The concurrency of checksumming in one table")
 
 	flags.Uint64(flagRateLimit, unlimited, "The rate limit of the task, MB/s per node")
@@ -335,6 +484,16 @@ func DefineCommonFlags(flags *pflag.FlagSet) {
 	flags.Uint(flagMetadataDownloadBatchSize, defaultMetadataDownloadBatchSize,
 		"the batch size of downloading metadata, such as log restore metadata for truncate or restore")
 
+	flags.Int64(flagStorageRetryBudget, unlimited, "the max number of the failed requests to the external storage "+
+		"of the whole task, the task fails once it's exceeded, instead of retrying forever. 0 means unlimited")
+	flags.Int(flagStorageBreakerThreshold, defaultStorageBreakerThreshold, "the number of the consecutive failed "+
+		"requests to an endpoint of the external storage pausing the requests to it, 0 means never pause")
+	flags.Duration(flagStorageBreakerCooldown, defaultStorageBreakerCooldown,
+		"the time the requests to an endpoint of the external storage are paused for, a single request probes "+
+			"the endpoint after it, and the others are sent once the probe succeeds")
+	flags.String(flagCatalog, "", "the storage root whose catalog the backup or restore is recorded into, "+
+		"so 'br backup list' lists the backups under the root without reading each of them")
+
 	// log backup plaintext key flags
 	flags.String(flagLogBackupCipherType, "plaintext", "Encrypt/decrypt method, "+
 		"be one of plaintext|aes128-ctr|aes192-ctr|aes256-ctr case-insensitively, "+
@@ -430,100 +589,12 @@ func ParseTLSTripleFromFlags(flags *pflag.FlagSet) (ca, cert, key string, err er
 	return
 }
 
-func parseCipherType(t string) (encryptionpb.EncryptionMethod, error) {
-	ct := encryptionpb.EncryptionMethod_UNKNOWN
-	switch t {
-	case "plaintext", "PLAINTEXT":
-		ct = encryptionpb.EncryptionMethod_PLAINTEXT
-	case "aes128-ctr", "AES128-CTR":
-		ct = encryptionpb.EncryptionMethod_AES128_CTR
-	case "aes192-ctr", "AES192-CTR":
-		ct = encryptionpb.EncryptionMethod_AES192_CTR
-	case "aes256-ctr", "AES256-CTR":
-		ct = encryptionpb.EncryptionMethod_AES256_CTR
-	default:
-		return ct, errors.Annotatef(berrors.ErrInvalidArgument, "invalid crypter method '%s'", t)
-	}
-
-	return ct, nil
-}
-
-func checkCipherKey(cipherKey, cipherKeyFile string) error {
-	if (len(cipherKey) == 0) == (len(cipherKeyFile) == 0) {
-		return errors.Annotate(berrors.ErrInvalidArgument,
-			"exactly one of cipher key or keyfile path should be provided")
-	}
-	return nil
-}
-
-func GetCipherKeyContent(cipherKey, cipherKeyFile string) ([]byte, error) {
-	if err := checkCipherKey(cipherKey, cipherKeyFile); err != nil {
-		return nil, errors.Trace(err)
-	}
-
-	var hexString string
-
-	// Check if cipher-key is provided directly
-	if len(cipherKey) > 0 {
-		hexString = cipherKey
-	} else {
-		// Read content from cipher-file
-		content, err := os.ReadFile(cipherKeyFile)
-		if err != nil {
-			return nil, errors.Annotate(err, "failed to read cipher file")
-		}
-		hexString = string(bytes.TrimSuffix(content, []byte("\n")))
-	}
-
-	// Attempt to decode the hex string
-	decodedKey, err := hex.DecodeString(hexString)
-	if err != nil {
-		return nil, errors.Annotate(berrors.ErrInvalidArgument, cipherKeyNonHexErrorMsg)
-	}
-
-	return decodedKey, nil
-}
-
-func checkCipherKeyMatch(cipher *backuppb.CipherInfo) error {
-	switch cipher.CipherType {
-	case encryptionpb.EncryptionMethod_PLAINTEXT:
-		return nil
-	case encryptionpb.EncryptionMethod_AES128_CTR:
-		if len(cipher.CipherKey) != crypterAES128KeyLen {
-			return errors.Annotatef(berrors.ErrInvalidArgument, "AES-128 key length mismatch: expected %d, got %d",
-				crypterAES128KeyLen, len(cipher.CipherKey))
-		}
-	case encryptionpb.EncryptionMethod_AES192_CTR:
-		if len(cipher.CipherKey) != crypterAES192KeyLen {
-			return errors.Annotatef(berrors.ErrInvalidArgument, "AES-192 key length mismatch: expected %d, got %d",
-				crypterAES192KeyLen, len(cipher.CipherKey))
-		}
-	case encryptionpb.EncryptionMethod_AES256_CTR:
-		if len(cipher.CipherKey) != crypterAES256KeyLen {
-			return errors.Annotatef(berrors.ErrInvalidArgument, "AES-256 key length mismatch: expected %d, got %d",
-				crypterAES256KeyLen, len(cipher.CipherKey))
-		}
-	default:
-		return errors.Errorf("Unknown encryption method: %v", cipher.CipherType)
-	}
-	return nil
-}
-
 func (cfg *Config) parseCipherInfo(flags *pflag.FlagSet) error {
 	crypterStr, err := flags.GetString(flagFullBackupCipherType)
 	if err != nil {
 		return errors.Trace(err)
 	}
 
-	cfg.CipherInfo.CipherType, err = parseCipherType(crypterStr)
-	if err != nil {
-		return errors.Trace(err)
-	}
-
-	if cfg.CipherInfo.CipherType == encryptionpb.EncryptionMethod_PLAINTEXT {
-		return nil
-	}
-
 	key, err := flags.GetString(flagFullBackupCipherKey)
 	if err != nil {
 		return errors.Trace(err)
@@ -534,16 +605,11 @@ func (cfg *Config) parseCipherInfo(flags *pflag.FlagSet) error {
 		return errors.Trace(err)
 	}
 
-	cfg.CipherInfo.CipherKey, err = GetCipherKeyContent(key, keyFilePath)
-	if err != nil {
-		return errors.Trace(err)
-	}
-
-	err = checkCipherKeyMatch(&cfg.CipherInfo)
+	cipher, err := encryption.ParseCipherInfo(crypterStr, key, keyFilePath)
 	if err != nil {
 		return errors.Trace(err)
 	}
-
+	cfg.CipherInfo = *cipher
 	return nil
 }
 
@@ -553,15 +619,6 @@ func (cfg *Config) parseLogBackupCipherInfo(flags *pflag.FlagSet) (bool, error)
 		return false, errors.Trace(err)
 	}
 
-	cfg.LogBackupCipherInfo.CipherType, err = parseCipherType(crypterStr)
-	if err != nil {
-		return false, errors.Trace(err)
-	}
-
-	if !utils.IsEffectiveEncryptionMethod(cfg.LogBackupCipherInfo.CipherType) {
-		return false, nil
-	}
-
 	key, err := flags.GetString(flagLogBackupCipherKey)
 	if err != nil {
 		return false, errors.Trace(err)
@@ -572,17 +629,12 @@ func (cfg *Config) parseLogBackupCipherInfo(flags *pflag.FlagSet) (bool, error)
 		return false, errors.Trace(err)
 	}
 
-	cfg.LogBackupCipherInfo.CipherKey, err = GetCipherKeyContent(key, keyFilePath)
+	cipher, err := encryption.ParseCipherInfo(crypterStr, key, keyFilePath)
 	if err != nil {
 		return false, errors.Trace(err)
 	}
-
-	err = checkCipherKeyMatch(&cfg.CipherInfo)
-	if err != nil {
-		return false, errors.Trace(err)
-	}
-
-	return true, nil
+	cfg.LogBackupCipherInfo = *cipher
+	return utils.IsEffectiveEncryptionMethod(cipher.CipherType), nil
 }
 
 func (cfg *Config) normalizePDURLs() error {
@@ -728,10 +780,37 @@ func (cfg *Config) ParseFromFlags(flags *pflag.FlagSet) error {
 	if cfg.MetadataDownloadBatchSize, err = flags.GetUint(flagMetadataDownloadBatchSize); err != nil {
 		return errors.Trace(err)
 	}
+	if cfg.Catalog, err = flags.GetString(flagCatalog); err != nil {
+		return errors.Trace(err)
+	}
+	if err = cfg.parseStorageRetryPolicy(flags); err != nil {
+		return errors.Trace(err)
+	}
+	cfg.storageRequestStats = storage.NewRequestStats()
 
 	return cfg.normalizePDURLs()
 }
 
+func (cfg *Config) parseStorageRetryPolicy(flags *pflag.FlagSet) error {
+	var err error
+	if cfg.StorageRetryBudget, err = flags.GetInt64(flagStorageRetryBudget); err != nil {
+		return errors.Trace(err)
+	}
+	if cfg.StorageBreakerThreshold, err = flags.GetInt(flagStorageBreakerThreshold); err != nil {
+		return errors.Trace(err)
+	}
+	if cfg.StorageBreakerCooldown, err = flags.GetDuration(flagStorageBreakerCooldown); err != nil {
+		return errors.Trace(err)
+	}
+	if cfg.StorageRetryBudget < 0 || cfg.StorageBreakerThreshold < 0 || cfg.StorageBreakerCooldown < 0 {
+		return errors.Annotatef(berrors.ErrInvalidArgument, "--%s, --%s and --%s can't be negative",
+			flagStorageRetryBudget, flagStorageBreakerThreshold, flagStorageBreakerCooldown)
+	}
+	cfg.storageRetryPolicy = storage.NewRetryPolicy(
+		cfg.StorageRetryBudget, cfg.StorageBreakerThreshold, cfg.StorageBreakerCooldown)
+	return nil
+}
+
 func (cfg *Config) parseAndValidateMasterKeyInfo(hasPlaintextKey bool, flags *pflag.FlagSet) error {
 	masterKeyString, err := flags.GetString(flagMasterKeyConfig)
 	if err != nil {
@@ -751,28 +830,11 @@ func (cfg *Config) parseAndValidateMasterKeyInfo(hasPlaintextKey bool, flags *pf
 		return errors.Errorf("encryption method flag '%s' is not defined: %v", flagMasterKeyCipherType, err)
 	}
 
-	encryptionMethod, err := parseCipherType(encryptionMethodString)
+	masterKeyConfig, err := encryption.ParseMasterKeyConfig(encryptionMethodString, masterKeyString)
 	if err != nil {
-		return errors.Errorf("failed to parse encryption method: %v", err)
-	}
-
-	if !utils.IsEffectiveEncryptionMethod(encryptionMethod) {
-		return errors.Errorf("invalid encryption method: %s", encryptionMethodString)
-	}
-
-	masterKeyStrings := strings.Split(masterKeyString, masterKeysDelimiter)
-	cfg.MasterKeyConfig = backuppb.MasterKeyConfig{
-		EncryptionType: encryptionMethod,
-		MasterKeys:     make([]*encryptionpb.MasterKey, 0, len(masterKeyStrings)),
-	}
-
-	for _, keyString := range masterKeyStrings {
-		masterKey, err := validateAndParseMasterKeyString(strings.TrimSpace(keyString))
-		if err != nil {
-			return errors.Wrapf(err, "invalid master key configuration: %s", keyString)
-		}
-		cfg.MasterKeyConfig.MasterKeys = append(cfg.MasterKeyConfig.MasterKeys, &masterKey)
+		return errors.Trace(err)
 	}
+	cfg.MasterKeyConfig = *masterKeyConfig
 
 	return nil
 }
@@ -792,8 +854,10 @@ func NewMgr(ctx context.Context,
 	versionCheckerType conn.VersionCheckerType,
 ) (*conn.Mgr, error) {
 	var (
-		tlsConf *tls.Config
-		err     error
+		pdTLSConf    *tls.Config
+		tikvTLSConf  *tls.Config
+		tikvSecurity config.Security
+		err          error
 	)
 	if len(pds) == 0 {
 		return nil, errors.Annotate(berrors.ErrInvalidArgument, "pd address can not be empty")
@@ -801,18 +865,20 @@ func NewMgr(ctx context.Context,
 
 	securityOption := pd.SecurityOption{}
 	if tlsConfig.IsEnabled() {
-		securityOption.CAPath = tlsConfig.CA
-		securityOption.CertPath = tlsConfig.Cert
-		securityOption.KeyPath = tlsConfig.Key
-		tlsConf, err = tlsConfig.ToTLSConfig()
-		if err != nil {
+		pdTLS, tikvTLS := tlsConfig.ForPD(), tlsConfig.ForTiKV()
+		securityOption = pdTLS.ToPDSecurityOption()
+		if pdTLSConf, err = pdTLS.ToTLSConfig(); err != nil {
 			return nil, errors.Trace(err)
 		}
+		if tikvTLSConf, err = tikvTLS.ToTLSConfig(); err != nil {
+			return nil, errors.Trace(err)
+		}
+		tikvSecurity = tikvTLS.ToKVSecurity()
 	}
 
 	// Is it necessary to remove `StoreBehavior`?
 	return conn.NewMgr(
-		ctx, g, pds, tlsConf, securityOption, keepalive, util.SkipTiFlash,
+		ctx, g, pds, pdTLSConf, securityOption, tikvTLSConf, tikvSecurity, keepalive, util.SkipTiFlash,
 		checkRequirements, needDomain, versionCheckerType,
 	)
 }
@@ -823,7 +889,7 @@ func GetStorage(
 	storageName string,
 	cfg *Config,
 ) (*backuppb.StorageBackend, storage.ExternalStorage, error) {
-	u, err := storage.ParseBackend(storageName, &cfg.BackendOptions)
+	u, err := storage.ParseBackendWithContext(ctx, storageName, &cfg.BackendOptions)
 	if err != nil {
 		return nil, nil, errors.Trace(err)
 	}
@@ -838,9 +904,39 @@ func storageOpts(cfg *Config) *storage.ExternalStorageOptions {
 	return &storage.ExternalStorageOptions{
 		NoCredentials:   cfg.NoCreds,
 		SendCredentials: cfg.SendCreds,
+		RetryPolicy:     cfg.storageRetryPolicy,
+		RequestStats:    cfg.storageRequestStats,
+	}
+}
+
+// collectStorageRequestStats collects the requests sent to the external storages by the BR process into the
+// summary. The requests sent by TiKV, e.g. of the data files, aren't included.
+func (cfg *Config) collectStorageRequestStats() {
+	stats := cfg.storageRequestStats
+	for _, class := range storage.RequestClasses {
+		if stats.Requests(class) == 0 {
+			continue
+		}
+		summary.CollectUint(fmt.Sprintf("storage %s requests by br", class), uint64(stats.Requests(class)))
+		summary.CollectUint(fmt.Sprintf("storage %s bytes by br", class), uint64(stats.Bytes(class)))
 	}
 }
 
+// storageRequestFields returns the fields of the requests sent to the external storages by the BR process,
+// for the summaries logged directly, e.g. of the log restore.
+func (cfg *Config) storageRequestFields() []zap.Field {
+	stats := cfg.storageRequestStats
+	fields := make([]zap.Field, 0, 2*len(storage.RequestClasses))
+	for _, class := range storage.RequestClasses {
+		if stats.Requests(class) == 0 {
+			continue
+		}
+		fields = append(fields, zap.Int64(fmt.Sprintf("storage-%s-requests-by-br", class), stats.Requests(class)),
+			zap.Int64(fmt.Sprintf("storage-%s-bytes-by-br", class), stats.Bytes(class)))
+	}
+	return fields
+}
+
 // ReadBackupMeta reads the backupmeta file from the storage.
 func ReadBackupMeta(
 	ctx context.Context,
@@ -909,6 +1005,17 @@ func flagToZapField(f *pflag.Flag) zap.Field {
 	case flagMasterKeyConfig:
 		// TODO: we don't really need to hide the entirety of --master-key, consider parsing the URL here.
 		return zap.String(f.Name, "<redacted>")
+	case flagSQLEndpoint:
+		if f.Value.String() == "" {
+			return zap.String(f.Name, "")
+		}
+		dsn, err := mysql.ParseDSN(f.Value.String())
+		if err != nil {
+			return zap.String(f.Name, "<invalid DSN>")
+		}
+		// hide the password here.
+		dsn.Passwd = ""
+		return zap.String(f.Name, dsn.FormatDSN())
 	default:
 		return zap.Stringer(f.Name, f.Value)
 	}
@@ -5,6 +5,7 @@ package task
 import (
 	"fmt"
 	"testing"
+	"time"
 
 	backup "github.com/pingcap/kvproto/pkg/brpb"
 	"github.com/pingcap/kvproto/pkg/encryptionpb"
@@ -81,6 +82,18 @@ func TestUrlNoQuery(t *testing.T) {
 			expectedValue: "<redacted>",
 			// expectedValue: "local:///path/abcd,aws-kms:///abcd,azure-kms:///abcd/v1"
 		},
+		{
+			inputName:     flagSQLEndpoint,
+			expectedName:  "sql-endpoint",
+			inputValue:    "root:secret@tcp(tidb:4000)/?tls=true",
+			expectedValue: "root@tcp(tidb:4000)/?tls=true",
+		},
+		{
+			inputName:     flagSQLEndpoint,
+			expectedName:  "sql-endpoint",
+			inputValue:    "root:secret@tcp(tidb:4000",
+			expectedValue: "<invalid DSN>",
+		},
 	}
 
 	for _, tc := range testCases {
@@ -123,137 +136,53 @@ func TestStripingPDURL(t *testing.T) {
 	require.Equal(t, "127.0.0.1:2379", noChange)
 }
 
-func TestCheckCipherKeyMatch(t *testing.T) {
-	cases := []struct {
-		name       string
-		cipherInfo *backup.CipherInfo
-		expectErr  bool
-		errMsg     string
-	}{
-		{
-			name: "PLAINTEXT",
-			cipherInfo: &backup.CipherInfo{
-				CipherType: encryptionpb.EncryptionMethod_PLAINTEXT,
-			},
-			expectErr: false,
-		},
-		{
-			name: "UNKNOWN",
-			cipherInfo: &backup.CipherInfo{
-				CipherType: encryptionpb.EncryptionMethod_UNKNOWN,
-			},
-			expectErr: true,
-			errMsg:    "Unknown encryption method: UNKNOWN",
-		},
-		{
-			name: "AES128_CTR valid",
-			cipherInfo: &backup.CipherInfo{
-				CipherType: encryptionpb.EncryptionMethod_AES128_CTR,
-				CipherKey:  make([]byte, crypterAES128KeyLen),
-			},
-			expectErr: false,
-		},
-		{
-			name: "AES128_CTR invalid length",
-			cipherInfo: &backup.CipherInfo{
-				CipherType: encryptionpb.EncryptionMethod_AES128_CTR,
-				CipherKey:  make([]byte, crypterAES128KeyLen-1),
-			},
-			expectErr: true,
-			errMsg:    fmt.Sprintf("AES-128 key length mismatch: expected %d, got %d", crypterAES128KeyLen, crypterAES128KeyLen-1),
-		},
-		{
-			name: "AES192_CTR valid",
-			cipherInfo: &backup.CipherInfo{
-				CipherType: encryptionpb.EncryptionMethod_AES192_CTR,
-				CipherKey:  make([]byte, crypterAES192KeyLen),
-			},
-			expectErr: false,
-		},
-		{
-			name: "AES192_CTR invalid length",
-			cipherInfo: &backup.CipherInfo{
-				CipherType: encryptionpb.EncryptionMethod_AES192_CTR,
-				CipherKey:  make([]byte, crypterAES192KeyLen+1),
-			},
-			expectErr: true,
-			errMsg:    fmt.Sprintf("AES-192 key length mismatch: expected %d, got %d", crypterAES192KeyLen, crypterAES192KeyLen+1),
-		},
-		{
-			name: "AES256_CTR valid",
-			cipherInfo: &backup.CipherInfo{
-				CipherType: encryptionpb.EncryptionMethod_AES256_CTR,
-				CipherKey:  make([]byte, crypterAES256KeyLen),
-			},
-			expectErr: false,
-		},
-		{
-			name: "AES256_CTR invalid length",
-			cipherInfo: &backup.CipherInfo{
-				CipherType: encryptionpb.EncryptionMethod_AES256_CTR,
-				CipherKey:  make([]byte, 0),
-			},
-			expectErr: true,
-			errMsg:    fmt.Sprintf("AES-256 key length mismatch: expected %d, got %d", crypterAES256KeyLen, 0),
-		},
-	}
+func TestParseTLSComponentFlags(t *testing.T) {
+	flags := &pflag.FlagSet{}
+	DefineCommonFlags(flags)
+	require.NoError(t, flags.Parse([]string{
+		"--ca", "ca.pem", "--cert", "br.pem", "--key", "br.key",
+		"--tikv.ca", "tikv-ca.pem", "--tikv.cert", "tikv.pem", "--tikv.key", "tikv.key",
+		"--pd.cert", "pd.pem", "--pd.key", "pd.key",
+	}))
+	var cfg TLSConfig
+	require.NoError(t, cfg.ParseFromFlags(flags))
+	require.Equal(t, TLSConfig{CA: "ca.pem", Cert: "pd.pem", Key: "pd.key"}, cfg.ForPD())
+	require.Equal(t, TLSConfig{CA: "tikv-ca.pem", Cert: "tikv.pem", Key: "tikv.key"}, cfg.ForTiKV())
+	client, err := cfg.ToHTTPClient()
+	require.NoError(t, err)
+	require.Nil(t, client)
 
-	for _, c := range cases {
-		t.Run(c.name, func(t *testing.T) {
-			err := checkCipherKeyMatch(c.cipherInfo)
-			if c.expectErr {
-				require.Error(t, err)
-				require.Contains(t, err.Error(), c.errMsg)
-			} else {
-				require.NoError(t, err)
-			}
-		})
-	}
+	flags = &pflag.FlagSet{}
+	DefineCommonFlags(flags)
+	require.NoError(t, flags.Parse([]string{"--pd.ca", "pd-ca.pem"}))
+	require.ErrorContains(t, cfg.ParseFromFlags(flags), "require --ca")
+
+	flags = &pflag.FlagSet{}
+	DefineCommonFlags(flags)
+	require.NoError(t, flags.Parse([]string{"--http.cert", "http.pem", "--http.key", "http.key"}))
+	cfg = TLSConfig{}
+	require.NoError(t, cfg.ParseFromFlags(flags))
+	require.False(t, cfg.IsEnabled())
+	_, err = cfg.ToHTTPClient()
+	require.ErrorContains(t, err, "--http.ca is required")
 }
 
-func TestCheckCipherKey(t *testing.T) {
-	cases := []struct {
-		cipherKey string
-		keyFile   string
-		ok        bool
-	}{
-		{
-			cipherKey: "0123456789abcdef0123456789abcdef",
-			keyFile:   "",
-			ok:        true,
-		},
-		{
-			cipherKey: "0123456789abcdef0123456789abcdef",
-			keyFile:   "/tmp/abc",
-			ok:        false,
-		},
-		{
-			cipherKey: "",
-			keyFile:   "/tmp/abc",
-			ok:        true,
-		},
-		{
-			cipherKey: "",
-			keyFile:   "",
-			ok:        false,
-		},
-	}
+func TestParseStorageRetryPolicy(t *testing.T) {
+	flags := &pflag.FlagSet{}
+	DefineCommonFlags(flags)
+	var cfg Config
+	require.NoError(t, cfg.parseStorageRetryPolicy(flags))
+	require.EqualValues(t, 0, cfg.StorageRetryBudget)
+	require.Equal(t, defaultStorageBreakerThreshold, cfg.StorageBreakerThreshold)
+	require.Equal(t, defaultStorageBreakerCooldown, cfg.StorageBreakerCooldown)
+	require.NotNil(t, cfg.storageRetryPolicy)
+	require.Same(t, cfg.storageRetryPolicy, storageOpts(&cfg).RetryPolicy)
 
-	for _, c := range cases {
-		err := checkCipherKey(c.cipherKey, c.keyFile)
-		if c.ok {
-			require.NoError(t, err)
-		} else {
-			require.Error(t, err)
-		}
-	}
-}
+	cfg.storageRequestStats = storage.NewRequestStats()
+	require.Same(t, cfg.storageRequestStats, storageOpts(&cfg).RequestStats)
 
-func TestGetCipherKey(t *testing.T) {
-	nonHexKey := "this is not a hex string"
-	_, err := GetCipherKeyContent(nonHexKey, "")
-	require.Error(t, err)
-	require.Contains(t, err.Error(), cipherKeyNonHexErrorMsg)
+	require.NoError(t, flags.Parse([]string{"--storage-retry-budget", "-1"}))
+	require.ErrorContains(t, cfg.parseStorageRetryPolicy(flags), "can't be negative")
 }
 
 func must[T any](t T, err error) T {
@@ -281,6 +210,10 @@ func expectedDefaultConfig() Config {
 		CipherInfo:                backup.CipherInfo{CipherType: 1},
 		LogBackupCipherInfo:       backup.CipherInfo{CipherType: 1},
 		MetadataDownloadBatchSize: 0x80,
+		StorageBreakerThreshold:   defaultStorageBreakerThreshold,
+		StorageBreakerCooldown:    defaultStorageBreakerCooldown,
+		storageRetryPolicy:        storage.NewRetryPolicy(0, defaultStorageBreakerThreshold, defaultStorageBreakerCooldown),
+		storageRequestStats:       storage.NewRequestStats(),
 	}
 }
 
@@ -296,6 +229,14 @@ func expectedDefaultBackupConfig() BackupConfig {
 		IgnoreStats:     true,
 		UseBackupMetaV2: true,
 		UseCheckpoint:   true,
+		StorageLayout:   "flat",
+
+		AdaptiveConcurrencyFloor:      1,
+		AdaptiveConcurrencyCeiling:    16,
+		AdaptiveConcurrencyMaxLatency: 100 * time.Millisecond,
+		BackupHookConfig: BackupHookConfig{
+			HookTimeout: 5 * time.Minute,
+		},
 	}
 }
 
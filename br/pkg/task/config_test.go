@@ -18,8 +18,12 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/docker/go-units"
 	"github.com/golang/protobuf/proto"
 	backuppb "github.com/pingcap/kvproto/pkg/brpb"
 	"github.com/pingcap/kvproto/pkg/encryptionpb"
@@ -28,11 +32,14 @@ import (
 	"github.com/pingcap/tidb/br/pkg/metautil"
 	snapclient "github.com/pingcap/tidb/br/pkg/restore/snap_client"
 	"github.com/pingcap/tidb/br/pkg/storage"
+	"github.com/pingcap/tidb/br/pkg/stream"
 	"github.com/pingcap/tidb/br/pkg/utils"
 	"github.com/pingcap/tidb/pkg/meta/model"
 	"github.com/pingcap/tidb/pkg/parser/ast"
 	"github.com/pingcap/tidb/pkg/statistics/util"
 	"github.com/pingcap/tidb/pkg/tablecodec"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 	"github.com/stretchr/testify/require"
 	pd "github.com/tikv/pd/client"
 	"github.com/tikv/pd/client/opt"
@@ -90,6 +97,184 @@ func TestAdjustRestoreConfigForStreamRestore(t *testing.T) {
 	require.Equal(t, restoreCfg.Concurrency, restoreCfg.PitrConcurrency)
 }
 
+func TestParseSchemaOnlyRestoreFlags(t *testing.T) {
+	parse := func(args ...string) (*RestoreConfig, error) {
+		flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+		DefineCommonFlags(flags)
+		DefineRestoreFlags(flags)
+		require.NoError(t, flags.Parse(args))
+		cfg := &RestoreConfig{}
+		return cfg, cfg.ParseFromFlags(flags, true)
+	}
+
+	cfg, err := parse()
+	require.NoError(t, err)
+	require.False(t, cfg.SchemaOnly)
+	require.True(t, cfg.UseCheckpoint)
+
+	cfg, err = parse("--schema-only")
+	require.NoError(t, err)
+	require.True(t, cfg.SchemaOnly)
+	require.False(t, cfg.UseCheckpoint)
+
+	_, err = parse("--schema-only", "--no-schema")
+	require.ErrorContains(t, err, "conflicts")
+
+	cfg, err = parse("--tenant-filter", "db.t: 1,2", "--tenant-filter", "db.t2: 3")
+	require.NoError(t, err)
+	require.Equal(t, []string{"db.t: 1,2", "db.t2: 3"}, cfg.TenantFilters)
+	_, err = parse("--tenant-filter", "db.t: a")
+	require.ErrorContains(t, err, "invalid tenant")
+	_, err = parse("--schema-only", "--tenant-filter", "db.t: 1")
+	require.ErrorContains(t, err, "conflicts")
+
+	cfg, err = parse()
+	require.NoError(t, err)
+	require.Equal(t, pausePDSchedulerScopeGlobal, cfg.PausePDSchedulerScope)
+	cfg, err = parse("--pause-pd-scheduler-scope", "table")
+	require.NoError(t, err)
+	require.Equal(t, pausePDSchedulerScopeTable, cfg.PausePDSchedulerScope)
+	_, err = parse("--pause-pd-scheduler-scope", "store")
+	require.ErrorContains(t, err, "should be global or table")
+
+	cfg, err = parse()
+	require.NoError(t, err)
+	require.Equal(t, snapclient.EngineTiKV, cfg.Engine)
+	cfg, err = parse("--engine", "lightning-local", "--sorted-kv-dir", "/tmp/sorted")
+	require.NoError(t, err)
+	require.Equal(t, snapclient.EngineLightningLocal, cfg.Engine)
+	require.Equal(t, "/tmp/sorted", cfg.SortedKVDir)
+	_, err = parse("--engine", "importer")
+	require.ErrorContains(t, err, "should be tikv or lightning-local")
+}
+
+func TestParseFollowRestoreFlags(t *testing.T) {
+	parse := func(args ...string) (*RestoreConfig, error) {
+		command := &cobra.Command{}
+		DefineStreamRestoreFlags(command)
+		require.NoError(t, command.Flags().Parse(args))
+		cfg := &RestoreConfig{}
+		return cfg, cfg.ParseStreamRestoreFlags(command.Flags())
+	}
+
+	cfg, err := parse()
+	require.NoError(t, err)
+	require.False(t, cfg.Follow)
+	require.False(t, cfg.ValidateMetaRoundTrip)
+	cfg, err = parse("--validate-meta-round-trip")
+	require.NoError(t, err)
+	require.True(t, cfg.ValidateMetaRoundTrip)
+	require.Equal(t, stream.MissingPartitionError, cfg.MissingPartitionPolicy)
+	cfg, err = parse("--missing-partition-policy", "allocate-new-id")
+	require.NoError(t, err)
+	require.Equal(t, stream.MissingPartitionAllocateNewID, cfg.MissingPartitionPolicy)
+	_, err = parse("--missing-partition-policy", "ignore")
+	require.ErrorContains(t, err, "unknown missing partition policy")
+	require.Empty(t, cfg.MergePartitions)
+	cfg, err = parse("--merge-partitions", "db.orders", "--merge-partitions", "logs.*")
+	require.NoError(t, err)
+	require.Equal(t, []string{"db.orders", "logs.*"}, cfg.MergePartitions)
+	_, err = parse("--merge-partitions", "db")
+	require.ErrorContains(t, err, "invalid --merge-partitions")
+	require.Zero(t, cfg.VerifySampleRate)
+	cfg, err = parse("--verify-sample-rate", "0.01")
+	require.NoError(t, err)
+	require.Equal(t, 0.01, cfg.VerifySampleRate)
+	_, err = parse("--verify-sample-rate", "1.5")
+	require.ErrorContains(t, err, "invalid --verify-sample-rate")
+	require.Empty(t, cfg.ZoneLeader.Zone)
+	require.Equal(t, "zone", cfg.ZoneLeader.LabelKey)
+	require.Equal(t, uint64(8*units.MiB), cfg.ZoneLeader.LeaderTransferCost)
+	cfg, err = parse("--storage-zone", "us-west-2a", "--zone-label", "az", "--zone-leader-transfer-cost", "64MiB")
+	require.NoError(t, err)
+	require.Equal(t, "us-west-2a", cfg.ZoneLeader.Zone)
+	require.Equal(t, "az", cfg.ZoneLeader.LabelKey)
+	require.Equal(t, uint64(64*units.MiB), cfg.ZoneLeader.LeaderTransferCost)
+	_, err = parse("--zone-leader-transfer-cost", "a lot")
+	require.ErrorContains(t, err, "invalid --zone-leader-transfer-cost")
+	_, err = parse("--storage-zone", "us-west-2a", "--zone-label", "")
+	require.ErrorContains(t, err, "--zone-label mustn't be empty")
+	require.Empty(t, cfg.DebugRewriteKeyPrefix)
+	cfg, err = parse("--debug-rewrite-key", "6d44423a31")
+	require.NoError(t, err)
+	require.Equal(t, []byte("mDB:1"), cfg.DebugRewriteKeyPrefix)
+	_, err = parse("--debug-rewrite-key", "mDB")
+	require.ErrorContains(t, err, "invalid --debug-rewrite-key")
+
+	cfg, err = parse("--follow", "--follow-interval", "30s")
+	require.NoError(t, err)
+	require.True(t, cfg.Follow)
+	require.Equal(t, 30*time.Second, cfg.FollowInterval)
+
+	_, err = parse("--follow", "--follow-interval", "0s")
+	require.ErrorContains(t, err, "must be positive")
+	_, err = parse("--follow", "--restored-ts", "400036290571534337")
+	require.ErrorContains(t, err, "can't be used with")
+
+	cfg, err = parse("--export-meta-entries", "127.0.0.1:10080")
+	require.NoError(t, err)
+	require.Equal(t, "127.0.0.1:10080", cfg.ExportMetaEntries)
+	_, err = parse("--export-meta-entries", "127.0.0.1:10080", "--full-backup-storage", "local:///tmp/full")
+	require.ErrorContains(t, err, "only exports the meta entries")
+}
+
+func TestParseRewriteTSSource(t *testing.T) {
+	parse := func(args ...string) (*RestoreConfig, error) {
+		command := &cobra.Command{}
+		DefineStreamRestoreFlags(command)
+		require.NoError(t, command.Flags().Parse(args))
+		cfg := &RestoreConfig{}
+		return cfg, cfg.ParseStreamRestoreFlags(command.Flags())
+	}
+
+	cfg, err := parse()
+	require.NoError(t, err)
+	provider, err := newRewriteTSProvider(cfg.RewriteTSSource, nil, &TLSConfig{})
+	require.NoError(t, err)
+	require.Equal(t, "pd", provider.String())
+
+	cfg, err = parse("--rewrite-ts-source", "400036290571534337")
+	require.NoError(t, err)
+	provider, err = newRewriteTSProvider(cfg.RewriteTSSource, nil, &TLSConfig{})
+	require.NoError(t, err)
+	ts, err := provider.GetTS(context.Background())
+	require.NoError(t, err)
+	require.EqualValues(t, 400036290571534337, ts)
+
+	provider, err = newRewriteTSProvider("https://tso.example.com/ts", nil, &TLSConfig{})
+	require.NoError(t, err)
+	require.Equal(t, "tso service https://tso.example.com/ts", provider.String())
+
+	_, err = parse("--rewrite-ts-source", "tomorrow")
+	require.ErrorContains(t, err, "invalid --rewrite-ts-source")
+
+	require.Zero(t, cfg.RewriteTSBatchKeys)
+	cfg, err = parse("--rewrite-ts-batch-keys", "10000")
+	require.NoError(t, err)
+	require.Equal(t, 10000, cfg.RewriteTSBatchKeys)
+	_, err = parse("--rewrite-ts-batch-keys", "-1")
+	require.ErrorContains(t, err, "mustn't be negative")
+	_, err = parse("--rewrite-ts-batch-keys", "10000", "--rewrite-ts-source", "400036290571534337")
+	require.ErrorContains(t, err, "can only be used with --rewrite-ts-source=pd")
+}
+
+func TestLoadColumnMappings(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mapping.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"DB.T": {"drop": ["a"], "rename": {"b": "c"}, "add": ["d int default 1"]}}`), 0o644))
+	mappings, err := loadColumnMappings(path)
+	require.NoError(t, err)
+	require.Equal(t, map[string]*snapclient.ColumnMapping{
+		"db.t": {Drop: []string{"a"}, Rename: map[string]string{"b": "c"}, Add: []string{"d int default 1"}},
+	}, mappings)
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"t": {"drop": ["a"]}}`), 0o644))
+	_, err = loadColumnMappings(path)
+	require.ErrorContains(t, err, "db.table")
+
+	_, err = loadColumnMappings(filepath.Join(t.TempDir(), "not-exist.json"))
+	require.Error(t, err)
+}
+
 func TestCheckRestoreDBAndTable(t *testing.T) {
 	cases := []struct {
 		cfgSchemas map[string]struct{}
@@ -0,0 +1,210 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package task
+
+import (
+	"context"
+	"strings"
+
+	"github.com/pingcap/errors"
+	berrors "github.com/pingcap/tidb/br/pkg/errors"
+	"github.com/pingcap/tidb/br/pkg/export"
+	"github.com/pingcap/tidb/br/pkg/glue"
+	"github.com/pingcap/tidb/br/pkg/metautil"
+	"github.com/pingcap/tidb/br/pkg/summary"
+	"github.com/pingcap/tidb/br/pkg/utils"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"go.uber.org/zap"
+)
+
+const (
+	flagExportAsOfTS = "as-of-ts"
+	flagExportFormat = "format"
+	flagExportOutput = "output"
+	flagExportLayout = "layout"
+	flagRowsPerFile  = "rows-per-file"
+
+	// exportLayoutFile writes a file for each table.
+	exportLayoutFile = "file"
+	// exportLayoutDataset writes a directory for each table, which is read by Spark and Trino as a dataset.
+	exportLayoutDataset = "dataset"
+
+	defaultRowsPerFile = 1000000
+
+	// ExportCmd is the name of `br export`.
+	ExportCmd = "Export"
+)
+
+// ExportConfig is the config for `br export`.
+type ExportConfig struct {
+	Config
+
+	// FullBackupStorage is the snapshot backup, `--storage` is the log backup after it, which can be
+	// empty if the tables are exported as of the snapshot backup.
+	FullBackupStorage string `json:"full-backup-storage" toml:"full-backup-storage"`
+	// AsOfTS is the ts of the exported tables, zero means the ts of the snapshot backup.
+	AsOfTS uint64        `json:"as-of-ts" toml:"as-of-ts"`
+	Format export.Format `json:"format" toml:"format"`
+	// Output is the external storage the files are written to.
+	Output string `json:"output" toml:"output"`
+	// Dataset writes the tables as the datasets partitioned by the partitions of the tables.
+	Dataset bool `json:"dataset" toml:"dataset"`
+	// RowsPerFile is the max rows of a file of the dataset, zero means unlimited.
+	RowsPerFile uint64 `json:"rows-per-file" toml:"rows-per-file"`
+}
+
+// DefineExportFlags defines flags for `br export`.
+func DefineExportFlags(command *cobra.Command) {
+	command.Flags().String(FlagStreamFullBackupStorage, "", "The snapshot backup to export, "+
+		"--storage is taken as the log backup after it if it is given")
+	command.Flags().String(flagExportAsOfTS, "", "Export the tables as of this ts, the ts of the snapshot backup "+
+		"by default, support TSO or datetime, e.g. '400036290571534337' or '2018-05-11 01:42:23+0800'")
+	command.Flags().String(flagExportFormat, string(export.FormatParquet), "The format of the exported files, csv or parquet")
+	command.Flags().String(flagExportOutput, "", "The storage the exported files are written to, "+
+		"a file <db>.<table>.<format> is written for each table")
+	command.Flags().String(flagExportLayout, exportLayoutFile, "The layout of the exported files, "+
+		"'file' writes a file for each table, 'dataset' writes the directory <db>/<table>/ for each table, "+
+		"in which the rows of a partition are written into the sub directory "+export.PartitionDirPrefix+"<partition>/, "+
+		"so it can be read by Spark and Trino directly")
+	command.Flags().Uint64(flagRowsPerFile, defaultRowsPerFile, "The max rows of a file in the 'dataset' layout, "+
+		"0 means unlimited")
+	_ = command.MarkFlagRequired(FlagStreamFullBackupStorage)
+	_ = command.MarkFlagRequired(flagExportOutput)
+}
+
+// ParseFromFlags parses the config from the flag set.
+func (cfg *ExportConfig) ParseFromFlags(flags *pflag.FlagSet) error {
+	if err := cfg.Config.ParseFromFlags(flags); err != nil {
+		return errors.Trace(err)
+	}
+	var err error
+	if cfg.FullBackupStorage, err = flags.GetString(FlagStreamFullBackupStorage); err != nil {
+		return errors.Trace(err)
+	}
+	if cfg.FullBackupStorage == "" {
+		return errors.Annotatef(berrors.ErrInvalidArgument, "--%s is required", FlagStreamFullBackupStorage)
+	}
+	if cfg.Output, err = flags.GetString(flagExportOutput); err != nil {
+		return errors.Trace(err)
+	}
+	if cfg.Output == "" {
+		return errors.Annotatef(berrors.ErrInvalidArgument, "--%s is required", flagExportOutput)
+	}
+	format, err := flags.GetString(flagExportFormat)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if cfg.Format, err = export.ParseFormat(format); err != nil {
+		return errors.Trace(err)
+	}
+	layout, err := flags.GetString(flagExportLayout)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	switch strings.ToLower(layout) {
+	case exportLayoutFile:
+	case exportLayoutDataset:
+		cfg.Dataset = true
+	default:
+		return errors.Annotatef(berrors.ErrInvalidArgument, "unsupported layout %q, should be %s or %s",
+			layout, exportLayoutFile, exportLayoutDataset)
+	}
+	if cfg.RowsPerFile, err = flags.GetUint64(flagRowsPerFile); err != nil {
+		return errors.Trace(err)
+	}
+	tsString, err := flags.GetString(flagExportAsOfTS)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if cfg.AsOfTS, err = ParseTSString(tsString, true); err != nil {
+		return errors.Trace(err)
+	}
+	if cfg.Storage == "" && cfg.AsOfTS > 0 {
+		return errors.Annotatef(berrors.ErrInvalidArgument,
+			"--%s requires the log backup given by --%s", flagExportAsOfTS, flagStorage)
+	}
+	return nil
+}
+
+// openExportSource opens the snapshot backup `cfg.FullBackupStorage` and the log backup `cfg.Storage`
+// after it.
+func openExportSource(ctx context.Context, cfg *ExportConfig) (*export.Source, error) {
+	snapshotCfg := cfg.Config
+	snapshotCfg.Storage = cfg.FullBackupStorage
+	_, snapshotStorage, backupMeta, err := ReadBackupMeta(ctx, metautil.MetaFile, &snapshotCfg)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	sourceCfg := &export.SourceConfig{
+		SnapshotStorage: snapshotStorage,
+		BackupMeta:      backupMeta,
+		Cipher:          &cfg.CipherInfo,
+		LogCipher:       &cfg.LogBackupCipherInfo,
+		MasterKeyConfig: &cfg.MasterKeyConfig,
+		AsOfTS:          cfg.AsOfTS,
+		TableFilter:     cfg.TableFilter,
+	}
+	if cfg.Storage != "" {
+		if _, sourceCfg.LogStorage, err = GetStorage(ctx, cfg.Storage, &cfg.Config); err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	source, err := export.OpenSource(ctx, sourceCfg)
+	return source, errors.Trace(err)
+}
+
+// RunExport reconstructs the tables as of a ts from the snapshot backup and the log backup after it, and
+// writes them into the output storage. No cluster is needed.
+func RunExport(c context.Context, g glue.Glue, cmdName string, cfg *ExportConfig) error {
+	ctx, cancel := context.WithCancel(c)
+	defer cancel()
+	defer summary.Summary(cmdName)
+
+	source, err := openExportSource(ctx, cfg)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer source.Close()
+	tables := source.Tables
+	if len(tables) == 0 {
+		return errors.Annotate(berrors.ErrInvalidArgument, "no table is matched by the filter as of the ts")
+	}
+
+	outputCfg := cfg.Config
+	outputCfg.Storage = cfg.Output
+	_, output, err := GetStorage(ctx, cfg.Output, &outputCfg)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	exporter := source.NewExporter(output, cfg.Format)
+	if cfg.Dataset {
+		exporter.SetDataset(cfg.RowsPerFile)
+	}
+
+	console := glue.GetConsole(g)
+	progress := g.StartProgress(ctx, cmdName, int64(len(tables)), !cfg.LogProgress)
+	defer progress.Close()
+	var (
+		totalRows  uint64
+		totalFiles int
+	)
+	for _, table := range tables {
+		rows, files, err := exporter.ExportTable(ctx, table)
+		if err != nil {
+			return errors.Annotatef(err, "failed to export %s", utils.EncloseDBAndTable(table.DB, table.Info.Name.O))
+		}
+		totalRows += rows
+		totalFiles += files
+		progress.Inc()
+		console.Printf("%s: %d rows in %d files -> %s\n", utils.EncloseDBAndTable(table.DB, table.Info.Name.O),
+			rows, files, exporter.FileName(table))
+	}
+	summary.CollectInt("tables", len(tables))
+	summary.CollectInt("files", totalFiles)
+	summary.CollectUint("rows", totalRows)
+	summary.Log(cmdName, zap.Uint64("snapshot-ts", source.SnapshotTS), zap.Uint64("as-of-ts", source.AsOfTS),
+		zap.Int("tables", len(tables)), zap.Int("files", totalFiles), zap.Uint64("rows", totalRows))
+	summary.SetSuccessStatus(true)
+	return nil
+}
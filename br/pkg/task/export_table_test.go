@@ -0,0 +1,43 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package task
+
+import (
+	"testing"
+
+	"github.com/pingcap/tidb/br/pkg/export"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseExportFlags(t *testing.T) {
+	parse := func(args ...string) (*ExportConfig, error) {
+		command := &cobra.Command{}
+		DefineCommonFlags(command.Flags())
+		DefineExportFlags(command)
+		require.NoError(t, command.Flags().Parse(args))
+		cfg := &ExportConfig{}
+		return cfg, cfg.ParseFromFlags(command.Flags())
+	}
+
+	cfg, err := parse("--full-backup-storage", "local:///full", "--output", "local:///out")
+	require.NoError(t, err)
+	require.Equal(t, export.FormatParquet, cfg.Format)
+	require.False(t, cfg.Dataset)
+	require.Zero(t, cfg.AsOfTS)
+
+	cfg, err = parse("--full-backup-storage", "local:///full", "--output", "local:///out", "-s", "local:///log",
+		"--as-of-ts", "400036290571534337", "--format", "csv", "--layout", "dataset", "--rows-per-file", "10")
+	require.NoError(t, err)
+	require.Equal(t, export.FormatCSV, cfg.Format)
+	require.True(t, cfg.Dataset)
+	require.EqualValues(t, 10, cfg.RowsPerFile)
+	require.EqualValues(t, 400036290571534337, cfg.AsOfTS)
+
+	_, err = parse("--full-backup-storage", "local:///full", "--output", "local:///out", "--layout", "hive")
+	require.ErrorContains(t, err, `unsupported layout "hive"`)
+	_, err = parse("--full-backup-storage", "local:///full", "--output", "local:///out", "--as-of-ts", "1")
+	require.ErrorContains(t, err, "requires the log backup")
+	_, err = parse("--output", "local:///out")
+	require.ErrorContains(t, err, "--full-backup-storage is required")
+}
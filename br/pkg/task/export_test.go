@@ -0,0 +1,15 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package task
+
+// WithResourceGroup is exported for the test.
+var WithResourceGroup = withResourceGroup
+
+// RestoreTokenFingerprint is exported for the test.
+var RestoreTokenFingerprint = restoreTokenFingerprint
+
+// AcquireRestoreToken is exported for the test.
+var AcquireRestoreToken = acquireRestoreToken
+
+// FinishRestoreToken is exported for the test.
+var FinishRestoreToken = finishRestoreToken
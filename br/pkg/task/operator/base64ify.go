@@ -15,7 +15,7 @@ func Base64ify(ctx context.Context, cfg Base64ifyConfig) error {
 }
 
 func runEncode(ctx context.Context, cfg Base64ifyConfig) error {
-	s, err := storage.ParseBackend(cfg.StorageURI, &cfg.BackendOptions)
+	s, err := storage.ParseBackendWithContext(ctx, cfg.StorageURI, &cfg.BackendOptions)
 	if err != nil {
 		return err
 	}
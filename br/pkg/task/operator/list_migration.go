@@ -18,7 +18,7 @@ func statusOK(message string) string {
 }
 
 func RunListMigrations(ctx context.Context, cfg ListMigrationConfig) error {
-	backend, err := storage.ParseBackend(cfg.StorageURI, &cfg.BackendOptions)
+	backend, err := storage.ParseBackendWithContext(ctx, cfg.StorageURI, &cfg.BackendOptions)
 	if err != nil {
 		return err
 	}
@@ -79,7 +79,7 @@ func RunMigrateTo(ctx context.Context, cfg MigrateToConfig) error {
 		return err
 	}
 
-	backend, err := storage.ParseBackend(cfg.StorageURI, &cfg.BackendOptions)
+	backend, err := storage.ParseBackendWithContext(ctx, cfg.StorageURI, &cfg.BackendOptions)
 	if err != nil {
 		return err
 	}
@@ -27,14 +27,15 @@ import (
 
 func dialPD(ctx context.Context, cfg *task.Config) (*pdutil.PdController, error) {
 	var tc *tls.Config
-	if cfg.TLS.IsEnabled() {
+	pdTLS := cfg.TLS.ForPD()
+	if pdTLS.IsEnabled() {
 		var err error
-		tc, err = cfg.TLS.ToTLSConfig()
+		tc, err = pdTLS.ToTLSConfig()
 		if err != nil {
 			return nil, err
 		}
 	}
-	mgr, err := pdutil.NewPdController(ctx, cfg.PD, tc, cfg.TLS.ToPDSecurityOption())
+	mgr, err := pdutil.NewPdController(ctx, cfg.PD, tc, pdTLS.ToPDSecurityOption())
 	if err != nil {
 		return nil, err
 	}
@@ -111,8 +112,8 @@ func AdaptEnvForSnapshotBackup(ctx context.Context, cfg *PauseGcConfig) error {
 	}
 	mgr.SchedulerPauseTTL = cfg.TTL
 	var tconf *tls.Config
-	if cfg.TLS.IsEnabled() {
-		tconf, err = cfg.TLS.ToTLSConfig()
+	if tikvTLS := cfg.TLS.ForTiKV(); tikvTLS.IsEnabled() {
+		tconf, err = tikvTLS.ToTLSConfig()
 		if err != nil {
 			return errors.Annotate(err, "invalid tls config")
 		}
@@ -0,0 +1,390 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package task
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/pingcap/errors"
+	backuppb "github.com/pingcap/kvproto/pkg/brpb"
+	"github.com/pingcap/log"
+	berrors "github.com/pingcap/tidb/br/pkg/errors"
+	"github.com/pingcap/tidb/br/pkg/glue"
+	"github.com/pingcap/tidb/br/pkg/metautil"
+	"github.com/pingcap/tidb/br/pkg/storage"
+	"github.com/pingcap/tidb/br/pkg/stream"
+	"github.com/pingcap/tidb/br/pkg/utils"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/tikv/client-go/v2/oracle"
+	"go.uber.org/zap"
+)
+
+// PITRTimelineCmd is the name of `br pitr timeline`.
+const PITRTimelineCmd = "PITR Timeline"
+
+// PITRTimelineConfig is the config for `br pitr timeline`.
+type PITRTimelineConfig struct {
+	Config
+
+	JSONOutput bool `json:"json-output" toml:"json-output"`
+}
+
+// DefinePITRTimelineFlags defines flags for `br pitr timeline`.
+func DefinePITRTimelineFlags(command *cobra.Command) {
+	command.Flags().Bool(flagStreamJSONOutput, false, "Print JSON as the output.")
+}
+
+// ParseFromFlags parses the config from the flag set.
+func (cfg *PITRTimelineConfig) ParseFromFlags(flags *pflag.FlagSet) error {
+	if err := cfg.Config.ParseFromFlags(flags); err != nil {
+		return errors.Trace(err)
+	}
+	if cfg.Storage == "" {
+		return errors.Annotatef(berrors.ErrInvalidArgument, "--%s is required", flagStorage)
+	}
+	var err error
+	cfg.JSONOutput, err = flags.GetBool(flagStreamJSONOutput)
+	return errors.Trace(err)
+}
+
+// timelineSnapshot is a snapshot backup found under the storage root.
+type timelineSnapshot struct {
+	Path      string `json:"path"`
+	BackupTS  uint64 `json:"backup_ts"`
+	ClusterID uint64 `json:"cluster_id"`
+	// tables are the enclosed names of the tables in the backup.
+	tables []string
+}
+
+// timelineLog is a log backup found under the storage root.
+type timelineLog struct {
+	Path      string `json:"path"`
+	MinTS     uint64 `json:"min_ts"`
+	MaxTS     uint64 `json:"max_ts"`
+	ClusterID uint64 `json:"cluster_id"`
+}
+
+// timelineRange is a range of ts in [StartTS, EndTS], the backups are the ones the ts in it are restored from.
+type timelineRange struct {
+	StartTS   uint64   `json:"start_ts"`
+	EndTS     uint64   `json:"end_ts"`
+	StartTime string   `json:"start_time"`
+	EndTime   string   `json:"end_time"`
+	Snapshots []string `json:"snapshots,omitempty"`
+	Logs      []string `json:"logs,omitempty"`
+}
+
+// tableTimeline is the restorable ranges of a table sorted by the ts, the gaps are the ranges between them,
+// in which the table can't be restored to.
+type tableTimeline struct {
+	Table  string           `json:"table"`
+	Ranges []*timelineRange `json:"ranges"`
+	Gaps   []*timelineRange `json:"gaps"`
+}
+
+// pitrTimeline is the output of `br pitr timeline`.
+type pitrTimeline struct {
+	Snapshots []*timelineSnapshot `json:"snapshot_backups"`
+	Logs      []*timelineLog      `json:"log_backups"`
+	Tables    []*tableTimeline    `json:"tables"`
+}
+
+func newTimelineRange(startTS, endTS uint64) *timelineRange {
+	return &timelineRange{
+		StartTS:   startTS,
+		EndTS:     endTS,
+		StartTime: stream.FormatDate(oracle.GetTimeFromTS(startTS)),
+		EndTime:   stream.FormatDate(oracle.GetTimeFromTS(endTS)),
+	}
+}
+
+// logCoversSnapshot returns whether the log backup can be restored after the snapshot backup, that is the
+// same cluster and the log backup covers the ts of the snapshot backup.
+func logCoversSnapshot(logBackup *timelineLog, snapshot *timelineSnapshot) bool {
+	if logBackup.ClusterID != 0 && snapshot.ClusterID != 0 && logBackup.ClusterID != snapshot.ClusterID {
+		return false
+	}
+	return logBackup.MinTS <= snapshot.BackupTS && snapshot.BackupTS < logBackup.MaxTS
+}
+
+// buildPITRTimeline merges the snapshot backups and the log backups into the restorable ranges of each
+// table. A table can be restored to the ts of a snapshot backup containing it, and to any ts up to the
+// max ts of a log backup covering the snapshot backup.
+func buildPITRTimeline(snapshots []*timelineSnapshot, logs []*timelineLog) []*tableTimeline {
+	pieces := make(map[string][]*timelineRange)
+	for _, snapshot := range snapshots {
+		for _, table := range snapshot.tables {
+			piece := newTimelineRange(snapshot.BackupTS, snapshot.BackupTS)
+			piece.Snapshots = []string{snapshot.Path}
+			pieces[table] = append(pieces[table], piece)
+			for _, logBackup := range logs {
+				if !logCoversSnapshot(logBackup, snapshot) {
+					continue
+				}
+				piece := newTimelineRange(snapshot.BackupTS, logBackup.MaxTS)
+				piece.Snapshots = []string{snapshot.Path}
+				piece.Logs = []string{logBackup.Path}
+				pieces[table] = append(pieces[table], piece)
+			}
+		}
+	}
+
+	appendUnique := func(items []string, item string) []string {
+		for _, existing := range items {
+			if existing == item {
+				return items
+			}
+		}
+		return append(items, item)
+	}
+	timelines := make([]*tableTimeline, 0, len(pieces))
+	for table, ranges := range pieces {
+		sort.SliceStable(ranges, func(i, j int) bool {
+			if ranges[i].StartTS != ranges[j].StartTS {
+				return ranges[i].StartTS < ranges[j].StartTS
+			}
+			return ranges[i].EndTS < ranges[j].EndTS
+		})
+		timeline := &tableTimeline{Table: table, Ranges: make([]*timelineRange, 0), Gaps: make([]*timelineRange, 0)}
+		var last *timelineRange
+		for _, piece := range ranges {
+			if last != nil && piece.StartTS <= last.EndTS {
+				if piece.EndTS > last.EndTS {
+					last.EndTS, last.EndTime = piece.EndTS, piece.EndTime
+				}
+				for _, name := range piece.Snapshots {
+					last.Snapshots = appendUnique(last.Snapshots, name)
+				}
+				for _, name := range piece.Logs {
+					last.Logs = appendUnique(last.Logs, name)
+				}
+				continue
+			}
+			if last != nil {
+				timeline.Gaps = append(timeline.Gaps, newTimelineRange(last.EndTS, piece.StartTS))
+			}
+			last = piece
+			timeline.Ranges = append(timeline.Ranges, last)
+		}
+		timelines = append(timelines, timeline)
+	}
+	sort.Slice(timelines, func(i, j int) bool { return timelines[i].Table < timelines[j].Table })
+	return timelines
+}
+
+// subStorageURL returns the URL of the directory dir under the storage root.
+func subStorageURL(root, dir string) (string, error) {
+	if dir == "" || dir == "." {
+		return root, nil
+	}
+	u, err := url.Parse(root)
+	if err != nil {
+		return "", errors.Annotatef(berrors.ErrInvalidArgument, "invalid storage %q: %v", root, err)
+	}
+	u.Path = path.Join(u.Path, dir)
+	return u.String(), nil
+}
+
+// errSkipBackupFiles stops listing the files of a backup found in findBackupDirs.
+var errSkipBackupFiles = errors.New("skip the files of the backup")
+
+// findBackupDirs finds the directories holding a backupmeta file under the storage root. The directories of
+// a listed file are checked from the top, and once a backup is found, the listing starts again after the
+// files of it if the storage supports StartAfter, so the data files of the backups aren't listed.
+func findBackupDirs(ctx context.Context, root storage.ExternalStorage) ([]string, error) {
+	var (
+		dirs     = make([]string, 0)
+		isBackup = make(map[string]bool)
+		// startAfter is after all the files of the last backup found.
+		startAfter string
+		// whether the storage lists the files after startAfter only.
+		skippable = true
+	)
+	checkDir := func(dir string) (bool, error) {
+		if found, ok := isBackup[dir]; ok {
+			return found, nil
+		}
+		found, err := root.FileExists(ctx, path.Join(dir, metautil.MetaFile))
+		if err != nil {
+			return false, errors.Trace(err)
+		}
+		isBackup[dir] = found
+		if found {
+			dirs = append(dirs, dir)
+		}
+		return found, nil
+	}
+	for {
+		from := startAfter
+		err := root.WalkDir(ctx, &storage.WalkOption{StartAfter: from}, func(name string, _ int64) error {
+			if len(from) > 0 && name <= from {
+				// the files before are checked by the last listing.
+				skippable = false
+				return nil
+			}
+			parts := strings.Split(name, "/")
+			for i := range parts {
+				dir := path.Join(parts[:i]...)
+				if dir == "" {
+					dir = "."
+				}
+				found, err := checkDir(dir)
+				if err != nil {
+					return err
+				}
+				if !found {
+					continue
+				}
+				if skippable {
+					// the largest code point sorts after the files in the directory.
+					if dir == "." {
+						startAfter = "\U0010FFFF"
+					} else {
+						startAfter = dir + "/\U0010FFFF"
+					}
+					return errSkipBackupFiles
+				}
+				return nil
+			}
+			return nil
+		})
+		if errors.Cause(err) == errSkipBackupFiles {
+			continue
+		}
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		sort.Strings(dirs)
+		return dirs, nil
+	}
+}
+
+// scanPITRTimeline finds the snapshot backups and the log backups by their backupmeta files under the
+// storage root cfg.Storage.
+func scanPITRTimeline(ctx context.Context, cfg *Config) (*pitrTimeline, error) {
+	_, root, err := GetStorage(ctx, cfg.Storage, cfg)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	dirs, err := findBackupDirs(ctx, root)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	timeline := &pitrTimeline{Snapshots: make([]*timelineSnapshot, 0), Logs: make([]*timelineLog, 0)}
+	for _, dir := range dirs {
+		subCfg := *cfg
+		if subCfg.Storage, err = subStorageURL(cfg.Storage, dir); err != nil {
+			return nil, errors.Trace(err)
+		}
+		metaData, err := root.ReadFile(ctx, path.Join(dir, metautil.MetaFile))
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		// the backupmeta of the log backup isn't encrypted and has no end version.
+		backupMeta := &backuppb.BackupMeta{}
+		if err := backupMeta.Unmarshal(metaData); err == nil && backupMeta.GetEndVersion() == 0 {
+			_, s, err := GetStorage(ctx, subCfg.Storage, &subCfg)
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			logInfo, err := getLogRangeWithStorage(ctx, s)
+			if err != nil {
+				return nil, errors.Annotatef(err, "failed to read the log backup %s", dir)
+			}
+			timeline.Logs = append(timeline.Logs, &timelineLog{
+				Path: dir, MinTS: logInfo.logMinTS, MaxTS: logInfo.logMaxTS, ClusterID: logInfo.clusterID})
+			continue
+		}
+
+		_, s, backupMeta, err := ReadBackupMeta(ctx, metautil.MetaFile, &subCfg)
+		if err != nil {
+			return nil, errors.Annotatef(err, "failed to read the snapshot backup %s", dir)
+		}
+		reader := metautil.NewMetaReader(backupMeta, s, &cfg.CipherInfo)
+		dbs, err := metautil.LoadBackupTables(ctx, reader, false)
+		if err != nil {
+			return nil, errors.Annotatef(err, "failed to read the tables of the snapshot backup %s", dir)
+		}
+		snapshot := &timelineSnapshot{Path: dir, BackupTS: backupMeta.GetEndVersion(), ClusterID: backupMeta.ClusterId}
+		for _, db := range dbs {
+			for _, table := range db.Tables {
+				if table.Info == nil || !cfg.TableFilter.MatchTable(db.Info.Name.O, table.Info.Name.O) {
+					continue
+				}
+				snapshot.tables = append(snapshot.tables, utils.EncloseDBAndTable(db.Info.Name.O, table.Info.Name.O))
+			}
+		}
+		timeline.Snapshots = append(timeline.Snapshots, snapshot)
+	}
+	sort.SliceStable(timeline.Snapshots, func(i, j int) bool {
+		return timeline.Snapshots[i].BackupTS < timeline.Snapshots[j].BackupTS
+	})
+	timeline.Tables = buildPITRTimeline(timeline.Snapshots, timeline.Logs)
+	return timeline, nil
+}
+
+// RunPITRTimeline prints the restorable ranges of each table merged from the snapshot backups and the log
+// backups under the storage root, and the gaps between them.
+func RunPITRTimeline(c context.Context, g glue.Glue, cmdName string, cfg *PITRTimelineConfig) error {
+	ctx, cancel := context.WithCancel(c)
+	defer cancel()
+
+	timeline, err := scanPITRTimeline(ctx, &cfg.Config)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	log.Info("scanned the pitr timeline", zap.String("cmd", cmdName), zap.String("storage", cfg.Storage),
+		zap.Int("snapshot-backups", len(timeline.Snapshots)), zap.Int("log-backups", len(timeline.Logs)),
+		zap.Int("tables", len(timeline.Tables)))
+
+	console := glue.GetConsole(g)
+	if cfg.JSONOutput {
+		data, err := json.Marshal(timeline)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		console.Println(string(data))
+		return nil
+	}
+
+	formatTS := func(ts uint64) string {
+		return fmt.Sprintf("%d (%s)", ts, stream.FormatDate(oracle.GetTimeFromTS(ts)))
+	}
+	console.Println("snapshot backups:")
+	for _, snapshot := range timeline.Snapshots {
+		console.Printf("  %s: backup ts %s, %d tables\n", snapshot.Path, formatTS(snapshot.BackupTS), len(snapshot.tables))
+	}
+	console.Println("log backups:")
+	for _, logBackup := range timeline.Logs {
+		console.Printf("  %s: from %s to %s\n", logBackup.Path, formatTS(logBackup.MinTS), formatTS(logBackup.MaxTS))
+	}
+	console.Println("restorable ranges:")
+	if len(timeline.Tables) == 0 {
+		console.Println("  no table can be restored from the backups")
+	}
+	for _, table := range timeline.Tables {
+		console.Printf("  %s:\n", table.Table)
+		for i, r := range table.Ranges {
+			if i > 0 {
+				gap := table.Gaps[i-1]
+				console.Println(color.RedString("    gap (%s, %s), can't be restored to",
+					formatTS(gap.StartTS), formatTS(gap.EndTS)))
+			}
+			line := fmt.Sprintf("    [%s, %s] snapshot %v", formatTS(r.StartTS), formatTS(r.EndTS), r.Snapshots)
+			if len(r.Logs) > 0 {
+				line += fmt.Sprintf(", log %v", r.Logs)
+			}
+			console.Println(color.GreenString(line))
+		}
+	}
+	return nil
+}
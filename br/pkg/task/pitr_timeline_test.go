@@ -0,0 +1,149 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package task
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"path"
+	"testing"
+
+	backuppb "github.com/pingcap/kvproto/pkg/brpb"
+	"github.com/pingcap/kvproto/pkg/encryptionpb"
+	"github.com/pingcap/tidb/br/pkg/metautil"
+	"github.com/pingcap/tidb/br/pkg/storage"
+	"github.com/pingcap/tidb/br/pkg/stream"
+	"github.com/pingcap/tidb/pkg/meta/model"
+	"github.com/pingcap/tidb/pkg/parser/ast"
+	filter "github.com/pingcap/tidb/pkg/util/table-filter"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildPITRTimeline(t *testing.T) {
+	snapshots := []*timelineSnapshot{
+		{Path: "full1", BackupTS: 10, ClusterID: 1, tables: []string{"`test`.`t1`", "`test`.`t2`"}},
+		{Path: "full2", BackupTS: 20, ClusterID: 1, tables: []string{"`test`.`t1`"}},
+		{Path: "full3", BackupTS: 50, ClusterID: 1, tables: []string{"`test`.`t1`"}},
+	}
+	logs := []*timelineLog{
+		{Path: "log1", MinTS: 5, MaxTS: 30, ClusterID: 1},
+		// the log backup of another cluster isn't restored after the snapshot backups.
+		{Path: "log2", MinTS: 5, MaxTS: 100, ClusterID: 2},
+	}
+	timelines := buildPITRTimeline(snapshots, logs)
+	require.Len(t, timelines, 2)
+
+	t1 := timelines[0]
+	require.Equal(t, "`test`.`t1`", t1.Table)
+	require.Len(t, t1.Ranges, 2)
+	require.Equal(t, uint64(10), t1.Ranges[0].StartTS)
+	require.Equal(t, uint64(30), t1.Ranges[0].EndTS)
+	require.Equal(t, []string{"full1", "full2"}, t1.Ranges[0].Snapshots)
+	require.Equal(t, []string{"log1"}, t1.Ranges[0].Logs)
+	require.Equal(t, uint64(50), t1.Ranges[1].StartTS)
+	require.Equal(t, uint64(50), t1.Ranges[1].EndTS)
+	require.Equal(t, []string{"full3"}, t1.Ranges[1].Snapshots)
+	require.Empty(t, t1.Ranges[1].Logs)
+	require.Len(t, t1.Gaps, 1)
+	require.Equal(t, uint64(30), t1.Gaps[0].StartTS)
+	require.Equal(t, uint64(50), t1.Gaps[0].EndTS)
+
+	t2 := timelines[1]
+	require.Equal(t, "`test`.`t2`", t2.Table)
+	require.Len(t, t2.Ranges, 1)
+	require.Equal(t, uint64(10), t2.Ranges[0].StartTS)
+	require.Equal(t, uint64(30), t2.Ranges[0].EndTS)
+	require.Empty(t, t2.Gaps)
+}
+
+func TestScanPITRTimeline(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+	s, err := storage.NewLocalStorage(root)
+	require.NoError(t, err)
+
+	dbData, err := json.Marshal(&model.DBInfo{ID: 1, Name: ast.NewCIStr("test")})
+	require.NoError(t, err)
+	snapshotMeta := &backuppb.BackupMeta{ClusterId: 1, EndVersion: 10}
+	for _, tbl := range []*model.TableInfo{
+		{ID: 100, Name: ast.NewCIStr("t1")},
+		{ID: 200, Name: ast.NewCIStr("t2")},
+	} {
+		tableData, err := json.Marshal(tbl)
+		require.NoError(t, err)
+		snapshotMeta.Schemas = append(snapshotMeta.Schemas, &backuppb.Schema{Db: dbData, Table: tableData})
+	}
+	data, err := snapshotMeta.Marshal()
+	require.NoError(t, err)
+	require.NoError(t, s.WriteFile(ctx, path.Join("full", metautil.MetaFile), data))
+
+	data, err = (&backuppb.BackupMeta{ClusterId: 1, StartVersion: 5}).Marshal()
+	require.NoError(t, err)
+	require.NoError(t, s.WriteFile(ctx, path.Join("log", metautil.MetaFile), data))
+	require.NoError(t, s.WriteFile(ctx, path.Join("log", stream.GetStreamBackupGlobalCheckpointPrefix(), "1.ts"),
+		binary.LittleEndian.AppendUint64(nil, 30)))
+
+	tableFilter, err := filter.Parse([]string{"test.t1"})
+	require.NoError(t, err)
+	cfg := &Config{
+		Storage:     "local://" + root,
+		CipherInfo:  backuppb.CipherInfo{CipherType: encryptionpb.EncryptionMethod_PLAINTEXT},
+		TableFilter: tableFilter,
+	}
+	timeline, err := scanPITRTimeline(ctx, cfg)
+	require.NoError(t, err)
+	require.Len(t, timeline.Snapshots, 1)
+	require.Equal(t, "full", timeline.Snapshots[0].Path)
+	require.Equal(t, uint64(10), timeline.Snapshots[0].BackupTS)
+	require.Equal(t, []string{"`test`.`t1`"}, timeline.Snapshots[0].tables)
+	require.Equal(t, []*timelineLog{{Path: "log", MinTS: 5, MaxTS: 30, ClusterID: 1}}, timeline.Logs)
+	require.Len(t, timeline.Tables, 1)
+	require.Equal(t, "`test`.`t1`", timeline.Tables[0].Table)
+	require.Len(t, timeline.Tables[0].Ranges, 1)
+	require.Equal(t, uint64(10), timeline.Tables[0].Ranges[0].StartTS)
+	require.Equal(t, uint64(30), timeline.Tables[0].Ranges[0].EndTS)
+
+	url, err := subStorageURL("s3://bucket/root?endpoint=http://minio", "full")
+	require.NoError(t, err)
+	require.Equal(t, "s3://bucket/root/full?endpoint=http://minio", url)
+}
+
+// startAfterStorage lists the files after WalkOption.StartAfter only, like the object storages.
+type startAfterStorage struct {
+	storage.ExternalStorage
+	listed []string
+}
+
+func (s *startAfterStorage) WalkDir(ctx context.Context, opt *storage.WalkOption, fn func(string, int64) error) error {
+	return s.ExternalStorage.WalkDir(ctx, opt, func(name string, size int64) error {
+		if name <= opt.StartAfter {
+			return nil
+		}
+		s.listed = append(s.listed, name)
+		return fn(name, size)
+	})
+}
+
+func TestFindBackupDirs(t *testing.T) {
+	ctx := context.Background()
+	s, err := storage.NewLocalStorage(t.TempDir())
+	require.NoError(t, err)
+	for _, name := range []string{
+		"full/1/a.sst", "full/backupmeta", "full/v1/b", "log/backupmeta", "log/v1/c",
+		"nested/sub/1/d.sst", "nested/sub/backupmeta", "other/e",
+	} {
+		require.NoError(t, s.WriteFile(ctx, name, []byte("x")))
+	}
+
+	dirs, err := findBackupDirs(ctx, s)
+	require.NoError(t, err)
+	require.Equal(t, []string{"full", "log", "nested/sub"}, dirs)
+
+	// the files of the backups are skipped after the backups are found.
+	skipping := &startAfterStorage{ExternalStorage: s}
+	dirs, err = findBackupDirs(ctx, skipping)
+	require.NoError(t, err)
+	require.Equal(t, []string{"full", "log", "nested/sub"}, dirs)
+	require.Equal(t, []string{"full/1/a.sst", "log/backupmeta", "nested/sub/1/d.sst", "other/e"}, skipping.listed)
+}
@@ -0,0 +1,59 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package task
+
+import (
+	"context"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/log"
+	"github.com/pingcap/tidb/br/pkg/glue"
+	"github.com/pingcap/tidb/pkg/kv"
+	"go.uber.org/zap"
+)
+
+// resourceGroupGlue binds the sessions created by the glue to the resource group, so the SQL executed
+// by the restore is limited by the RU of the group.
+type resourceGroupGlue struct {
+	glue.Glue
+	name string
+}
+
+// withResourceGroup returns the glue binding the sessions to the resource group, the glue is returned
+// as is if the name is empty or it's bound already.
+func withResourceGroup(g glue.Glue, name string) glue.Glue {
+	if bound, ok := g.(*resourceGroupGlue); name == "" || (ok && bound.name == name) {
+		return g
+	}
+	log.Info("run the sql of the restore in the resource group", zap.String("resource-group", name))
+	return &resourceGroupGlue{Glue: g, name: name}
+}
+
+// CreateSession implements glue.Glue.
+func (g *resourceGroupGlue) CreateSession(store kv.Storage) (glue.Session, error) {
+	se, err := g.Glue.CreateSession(store)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if err := g.bind(se); err != nil {
+		se.Close()
+		return nil, errors.Trace(err)
+	}
+	return se, nil
+}
+
+// UseOneShotSession implements glue.Glue.
+func (g *resourceGroupGlue) UseOneShotSession(store kv.Storage, closeDomain bool, fn func(se glue.Session) error) error {
+	return g.Glue.UseOneShotSession(store, closeDomain, func(se glue.Session) error {
+		if err := g.bind(se); err != nil {
+			return errors.Trace(err)
+		}
+		return fn(se)
+	})
+}
+
+// bind switches the session to the resource group, it fails if the group doesn't exist.
+func (g *resourceGroupGlue) bind(se glue.Session) error {
+	err := se.ExecuteInternal(context.Background(), "SET RESOURCE GROUP %n", g.name)
+	return errors.Annotatef(err, "failed to use the resource group %s", g.name)
+}
@@ -0,0 +1,42 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package task_test
+
+import (
+	"testing"
+
+	"github.com/pingcap/tidb/br/pkg/glue"
+	gluemock "github.com/pingcap/tidb/br/pkg/gluetidb/mock"
+	"github.com/pingcap/tidb/br/pkg/task"
+	"github.com/pingcap/tidb/pkg/testkit"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResourceGroupGlue(t *testing.T) {
+	store := testkit.CreateMockStore(t)
+	tk := testkit.NewTestKit(t, store)
+	tk.MustExec("create resource group rg_br RU_PER_SEC=1000")
+	mockGlue := &gluemock.MockGlue{}
+	mockGlue.SetSession(tk.Session())
+
+	require.Same(t, mockGlue, task.WithResourceGroup(mockGlue, ""))
+	g := task.WithResourceGroup(mockGlue, "rg_br")
+	require.Same(t, g, task.WithResourceGroup(g, "rg_br"))
+
+	se, err := g.CreateSession(store)
+	require.NoError(t, err)
+	require.Equal(t, "rg_br", se.GetSessionCtx().GetSessionVars().ResourceGroupName)
+
+	tk.MustExec("set resource group default")
+	err = g.UseOneShotSession(store, false, func(se glue.Session) error {
+		require.Equal(t, "rg_br", se.GetSessionCtx().GetSessionVars().ResourceGroupName)
+		return nil
+	})
+	require.NoError(t, err)
+
+	err = task.WithResourceGroup(mockGlue, "rg_missing").UseOneShotSession(store, false, func(glue.Session) error {
+		require.FailNow(t, "the session shouldn't be used")
+		return nil
+	})
+	require.ErrorContains(t, err, "failed to use the resource group rg_missing")
+}
@@ -5,8 +5,14 @@ package task
 import (
 	"cmp"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"maps"
+	"os"
 	"slices"
+	"strconv"
 	"strings"
 	"sync/atomic"
 	"time"
@@ -21,14 +27,21 @@ import (
 	pconfig "github.com/pingcap/tidb/br/pkg/config"
 	"github.com/pingcap/tidb/br/pkg/conn"
 	connutil "github.com/pingcap/tidb/br/pkg/conn/util"
+	"github.com/pingcap/tidb/br/pkg/encryption"
 	berrors "github.com/pingcap/tidb/br/pkg/errors"
 	"github.com/pingcap/tidb/br/pkg/glue"
 	"github.com/pingcap/tidb/br/pkg/httputil"
 	"github.com/pingcap/tidb/br/pkg/logutil"
 	"github.com/pingcap/tidb/br/pkg/metautil"
+	"github.com/pingcap/tidb/br/pkg/pdutil"
 	"github.com/pingcap/tidb/br/pkg/restore"
+	importadapter "github.com/pingcap/tidb/br/pkg/restore/import_adapter"
+	logclient "github.com/pingcap/tidb/br/pkg/restore/log_client"
 	snapclient "github.com/pingcap/tidb/br/pkg/restore/snap_client"
 	"github.com/pingcap/tidb/br/pkg/restore/tiflashrec"
+	restoreutils "github.com/pingcap/tidb/br/pkg/restore/utils"
+	"github.com/pingcap/tidb/br/pkg/storage"
+	"github.com/pingcap/tidb/br/pkg/stream"
 	"github.com/pingcap/tidb/br/pkg/summary"
 	"github.com/pingcap/tidb/br/pkg/utils"
 	"github.com/pingcap/tidb/br/pkg/version"
@@ -39,8 +52,11 @@ import (
 	"github.com/pingcap/tidb/pkg/kv"
 	"github.com/pingcap/tidb/pkg/meta/model"
 	"github.com/pingcap/tidb/pkg/parser/ast"
+	"github.com/pingcap/tidb/pkg/tablecodec"
+	tidbcodec "github.com/pingcap/tidb/pkg/util/codec"
 	"github.com/pingcap/tidb/pkg/util/collate"
 	"github.com/pingcap/tidb/pkg/util/engine"
+	filter "github.com/pingcap/tidb/pkg/util/table-filter"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 	"github.com/tikv/client-go/v2/tikv"
@@ -54,10 +70,32 @@ import (
 const (
 	flagOnline                   = "online"
 	flagNoSchema                 = "no-schema"
+	flagSchemaOnly               = "schema-only"
+	flagColumnMapping            = "column-mapping"
+	flagRowFilter                = "row-filter"
+	flagSanitize                 = "sanitize"
+	flagSanitizeKey              = "sanitize-key"
+	flagSanitizeKeyFile          = "sanitize-key-file"
+	flagConvertCharset           = "convert-charset"
+	flagPlacementTemplate        = "placement-template"
+	flagSequenceRestoreMode      = "sequence-restore-mode"
+	flagTenantFilter             = "tenant-filter"
 	flagLoadStats                = "load-stats"
 	flagGranularity              = "granularity"
 	flagConcurrencyPerStore      = "tikv-max-restore-concurrency"
 	flagAllowPITRFromIncremental = "allow-pitr-from-incremental"
+	flagIdempotencyToken         = "idempotency-token"
+	flagAllowCrossCluster        = "allow-cross-cluster"
+	flagPausePDSchedulerScope    = "pause-pd-scheduler-scope"
+	flagSplitTimeout             = "split-timeout"
+	flagScatterTimeout           = "scatter-timeout"
+	flagDownloadTimeout          = "download-timeout"
+	flagIngestTimeout            = "ingest-timeout"
+	flagEncryptionAtRest         = "encryption-at-rest-config"
+	flagResourceGroup            = "resource-group"
+	flagEngine                   = "engine"
+	flagSortedKVDir              = "sorted-kv-dir"
+	flagTableStatsReport         = "table-stats-report"
 
 	// FlagMergeRegionSizeBytes is the flag name of merge small regions by size
 	FlagMergeRegionSizeBytes = "merge-region-size-bytes"
@@ -89,9 +127,67 @@ const (
 	FlagPiTRBatchCount  = "pitr-batch-count"
 	FlagPiTRBatchSize   = "pitr-batch-size"
 	FlagPiTRConcurrency = "pitr-concurrency"
+	// FlagStreamExportMetaEntries is the address to export the rewritten meta kv entries of the log restore to.
+	FlagStreamExportMetaEntries = "export-meta-entries"
+	// FlagStreamMemoryLimit is the memory budget of the log restore, only the delete-range queue is spilled.
+	FlagStreamMemoryLimit = "memory-limit"
+	// FlagStreamFollow keeps restoring the new entries of the log backup after restored.
+	FlagStreamFollow = "follow"
+	// FlagStreamFollowInterval is the interval to restore the new entries of the log backup.
+	FlagStreamFollowInterval = "follow-interval"
+	// FlagStreamPreserveClusterMeta keeps the cluster-scoped meta keys of the log backup, e.g. the BDR role.
+	FlagStreamPreserveClusterMeta = "preserve-cluster-meta"
+	// FlagStreamRewriteTSSource is where the ts the restored kvs are rewritten to comes from.
+	FlagStreamRewriteTSSource = "rewrite-ts-source"
+	// FlagStreamRewriteTSBatchKeys allocates a fresh commit ts from PD for every batch of the meta kv entries.
+	FlagStreamRewriteTSBatchKeys = "rewrite-ts-batch-keys"
+	// FlagStreamValidateMetaRoundTrip checks the meta kv entries are rewritten without loss.
+	FlagStreamValidateMetaRoundTrip = "validate-meta-round-trip"
+	// FlagStreamMissingPartitionPolicy is how the partitions missing in the ID maps are handled.
+	FlagStreamMissingPartitionPolicy = "missing-partition-policy"
+	// FlagStreamDebugRewriteKey traces the rewrite of the meta kv entries with the key prefix.
+	FlagStreamDebugRewriteKey = "debug-rewrite-key"
+	// FlagStreamForce skips the check refusing the log restore older than the existing data.
+	FlagStreamForce = "force"
+	// FlagStreamPauseDDL pauses the DDL jobs involving the restored tables while the meta kv entries are applied.
+	FlagStreamPauseDDL = "pause-ddl"
+	// FlagStreamRollbackRecordPolicy is how the rollback and lock records of the meta kv files are handled.
+	FlagStreamRollbackRecordPolicy = "rollback-record-policy"
+	// FlagStreamRollbackRecordFile is the file the collected rollback and lock records are written into.
+	FlagStreamRollbackRecordFile = "rollback-record-file"
+	// FlagStreamDeleteRangeBatchRows and FlagStreamDeleteRangeBatchSize limit the statements inserting the
+	// delete ranges of the DDL jobs in the log backup.
+	FlagStreamDeleteRangeBatchRows = "delete-range-batch-rows"
+	FlagStreamDeleteRangeBatchSize = "delete-range-batch-size"
+	// FlagStreamDeleteRangeConflictPolicy is how the delete ranges of a DDL job restored more than once with
+	// different ranges are handled.
+	FlagStreamDeleteRangeConflictPolicy = "delete-range-conflict-policy"
+	// FlagStreamDeleteRangeInterval and FlagStreamDeleteRangeDuration pace the statements inserting the
+	// delete ranges after the restore.
+	FlagStreamDeleteRangeInterval = "delete-range-interval"
+	FlagStreamDeleteRangeDuration = "delete-range-duration"
+	// FlagStreamDeferTiFlashReplicas is the storage the TiFlash replicas are saved to instead of being set
+	// right after the restore.
+	FlagStreamDeferTiFlashReplicas = "defer-tiflash-replicas"
+	// FlagStreamMergePartitions is the table filter rules of the partitioned tables restored as
+	// non-partitioned tables.
+	FlagStreamMergePartitions = "merge-partitions"
+	// FlagStreamVerifySampleRate is the fraction of the restored keys read back from the cluster to verify.
+	FlagStreamVerifySampleRate = "verify-sample-rate"
+	// FlagStreamStorageZone is the zone of the backup storage, the leaders of the restored tables are moved
+	// into the zone if it's worth the cost.
+	FlagStreamStorageZone = "storage-zone"
+	// FlagStreamZoneLabel, FlagStreamZoneLeaderTransferCost and FlagStreamZoneLeaderWait tune how the
+	// leaders are moved into the zone of the backup storage.
+	FlagStreamZoneLabel              = "zone-label"
+	FlagStreamZoneLeaderTransferCost = "zone-leader-transfer-cost"
+	FlagStreamZoneLeaderWait         = "zone-leader-wait"
 
 	FlagResetSysUsers = "reset-sys-users"
 
+	// rewriteTSSourcePD allocates the RewriteTS from the PD of the target cluster.
+	rewriteTSSourcePD = "pd"
+
 	defaultPiTRBatchCount     = 8
 	defaultPiTRBatchSize      = 16 * 1024 * 1024
 	defaultRestoreConcurrency = 128
@@ -100,11 +196,19 @@ const (
 	defaultStatsConcurrency   = 12
 	defaultBatchFlushInterval = 16 * time.Second
 	defaultFlagDdlBatchSize   = 128
+	defaultPhaseTimeout       = 30 * time.Minute
 	maxRestoreBatchSizeLimit  = 10240
 	pb                        = 1024 * 1024 * 1024 * 1024 * 1024
 	resetSpeedLimitRetryTimes = 3
 )
 
+const (
+	// pausePDSchedulerScopeGlobal pauses the pd schedulers of the whole cluster.
+	pausePDSchedulerScopeGlobal = "global"
+	// pausePDSchedulerScopeTable pauses the pd schedulers by the `schedule=deny` label of the restored tables.
+	pausePDSchedulerScopeTable = "table"
+)
+
 const (
 	FullRestoreCmd  = "Full Restore"
 	DBRestoreCmd    = "DataBase Restore"
@@ -130,6 +234,27 @@ type RestoreCommonConfig struct {
 	WithSysTable bool `json:"with-sys-table" toml:"with-sys-table"`
 
 	ResetSysUsers []string `json:"reset-sys-users" toml:"reset-sys-users"`
+
+	// the phases of restoring the SST files fail with the diagnosis once they make no progress in the
+	// timeouts, zero disables the detection.
+	SplitTimeout    time.Duration `json:"split-timeout" toml:"split-timeout"`
+	ScatterTimeout  time.Duration `json:"scatter-timeout" toml:"scatter-timeout"`
+	DownloadTimeout time.Duration `json:"download-timeout" toml:"download-timeout"`
+	IngestTimeout   time.Duration `json:"ingest-timeout" toml:"ingest-timeout"`
+
+	// EncryptionAtRest is how the encryption at rest configured on the target TiKV stores is checked before
+	// restoring.
+	EncryptionAtRest EncryptionAtRestPolicy `json:"encryption-at-rest-config" toml:"encryption-at-rest-config"`
+}
+
+// phaseTimeouts returns the timeouts to detect the stall of the restore phases.
+func (cfg *RestoreCommonConfig) phaseTimeouts() restoreutils.PhaseTimeouts {
+	return restoreutils.PhaseTimeouts{
+		Split:    cfg.SplitTimeout,
+		Scatter:  cfg.ScatterTimeout,
+		Download: cfg.DownloadTimeout,
+		Ingest:   cfg.IngestTimeout,
+	}
 }
 
 // adjust adjusts the abnormal config value in the current config.
@@ -172,6 +297,23 @@ func DefineRestoreCommonFlags(flags *pflag.FlagSet) {
 	flags.Bool(flagWithSysTable, true, "whether restore system privilege tables on default setting")
 	flags.StringArrayP(FlagResetSysUsers, "", []string{"cloud_admin", "root"}, "whether reset these users after restoration")
 	flags.Bool(flagUseFSR, false, "whether enable FSR for AWS snapshots")
+	flags.Duration(flagSplitTimeout, defaultPhaseTimeout,
+		"fail the restore with the diagnosis if no region is split in the duration, 0 to disable")
+	flags.Duration(flagScatterTimeout, 0,
+		"fail the restore with the diagnosis if no region finishes scattering in the duration, "+
+			"0 to disable and continue after waiting for the scattering at most 30 minutes")
+	flags.Duration(flagDownloadTimeout, defaultPhaseTimeout,
+		"fail the restore with the diagnosis if no SST file is downloaded in the duration, 0 to disable")
+	flags.Duration(flagIngestTimeout, defaultPhaseTimeout,
+		"fail the restore with the diagnosis if no SST file is ingested in the duration, 0 to disable")
+	flags.String(flagEncryptionAtRest, string(EncryptionAtRestReport), fmt.Sprintf("how the encryption at rest "+
+		"configured on the target TiKV stores is checked before restoring, the restored data is encrypted by the "+
+		"stores themselves whatever the backup is encrypted. Only the configs of the stores are read, whether "+
+		"their master keys are accessible isn't checked. %q reports the config of every store and warns if an "+
+		"encrypted backup is restored into the stores without encryption, %q fails the restore unless every "+
+		"store is configured to encrypt the data with the data keys protected by a master key, and %q skips "+
+		"the check",
+		EncryptionAtRestReport, EncryptionAtRestRequire, EncryptionAtRestSkip))
 
 	_ = flags.MarkHidden(FlagResetSysUsers)
 	_ = flags.MarkHidden(FlagMergeRegionSizeBytes)
@@ -222,6 +364,24 @@ func (cfg *RestoreCommonConfig) ParseFromFlags(flags *pflag.FlagSet) error {
 	if err != nil {
 		return errors.Trace(err)
 	}
+	for flag, timeout := range map[string]*time.Duration{
+		flagSplitTimeout:    &cfg.SplitTimeout,
+		flagScatterTimeout:  &cfg.ScatterTimeout,
+		flagDownloadTimeout: &cfg.DownloadTimeout,
+		flagIngestTimeout:   &cfg.IngestTimeout,
+	} {
+		if *timeout, err = flags.GetDuration(flag); err != nil {
+			return errors.Trace(err)
+		}
+		if *timeout < 0 {
+			return errors.Annotatef(berrors.ErrInvalidArgument, "--%s must not be negative", flag)
+		}
+	}
+	encryptionAtRest, err := flags.GetString(flagEncryptionAtRest)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	cfg.EncryptionAtRest, err = ParseEncryptionAtRestPolicy(encryptionAtRest)
 	return errors.Trace(err)
 }
 
@@ -231,12 +391,23 @@ type RestoreConfig struct {
 	RestoreCommonConfig
 
 	NoSchema           bool          `json:"no-schema" toml:"no-schema"`
+	SchemaOnly         bool          `json:"schema-only" toml:"schema-only"`
+	ColumnMapping      string        `json:"column-mapping" toml:"column-mapping"`
+	RowFilters         []string      `json:"row-filter" toml:"row-filter"`
+	Sanitizers         []string      `json:"sanitize" toml:"sanitize"`
+	SanitizeKey        []byte        `json:"-" toml:"-"`
+	ConvertCharset     string        `json:"convert-charset" toml:"convert-charset"`
+	PlacementTemplate  string        `json:"placement-template" toml:"placement-template"`
+	TenantFilters      []string      `json:"tenant-filter" toml:"tenant-filter"`
 	LoadStats          bool          `json:"load-stats" toml:"load-stats"`
 	PDConcurrency      uint          `json:"pd-concurrency" toml:"pd-concurrency"`
 	StatsConcurrency   uint          `json:"stats-concurrency" toml:"stats-concurrency"`
 	BatchFlushInterval time.Duration `json:"batch-flush-interval" toml:"batch-flush-interval"`
 	// DdlBatchSize use to define the size of batch ddl to create tables
 	DdlBatchSize uint `json:"ddl-batch-size" toml:"ddl-batch-size"`
+	// SequenceRestoreMode is how the restored sequences resume, the one recorded in the backup is used if
+	// it's empty.
+	SequenceRestoreMode metautil.SequenceRestoreMode `json:"sequence-restore-mode" toml:"sequence-restore-mode"`
 
 	WithPlacementPolicy string `json:"with-tidb-placement-mode" toml:"with-tidb-placement-mode"`
 
@@ -248,6 +419,35 @@ type RestoreConfig struct {
 	// In this restore mode, the restore will not perform timestamp rewrite on the incremental data.
 	AllowPITRFromIncremental bool `json:"allow-pitr-from-incremental" toml:"allow-pitr-from-incremental"`
 
+	// IdempotencyToken identifies the restore retried by the orchestrator, the retried restore is
+	// skipped if the restore with the token is finished.
+	IdempotencyToken string `json:"idempotency-token" toml:"idempotency-token"`
+	// AllowCrossCluster allows restoring the backups of a cluster other than the target cluster.
+	AllowCrossCluster bool `json:"allow-cross-cluster" toml:"allow-cross-cluster"`
+
+	// PausePDSchedulerScope is the scope of pausing the pd schedulers, the schedulers are paused only for the
+	// regions of the restored tables if it is `table`.
+	PausePDSchedulerScope string `json:"pause-pd-scheduler-scope" toml:"pause-pd-scheduler-scope"`
+
+	// ResourceGroup is the resource group of the SQL executed by BR on the target cluster, e.g. the
+	// DDLs, the delete ranges and the checksum, so they don't starve the production queries.
+	ResourceGroup string `json:"resource-group" toml:"resource-group"`
+
+	// Engine is how the backup files are imported, `tikv` lets TiKV download and ingest them, and
+	// `lightning-local` sorts the kvs locally and ingests them through the local backend of Lightning.
+	Engine string `json:"engine" toml:"engine"`
+	// SortedKVDir is the directory to sort the kvs for the `lightning-local` engine.
+	SortedKVDir string `json:"sorted-kv-dir" toml:"sorted-kv-dir"`
+	// TableStatsReport is the local CSV file the restore statistics of the tables are appended to.
+	TableStatsReport string `json:"table-stats-report" toml:"table-stats-report"`
+	// SQLEndpoint is the DSN of the TiDB restoring the snapshot backup by the RESTORE statement, for the
+	// environments where BR can only access the SQL port of the target cluster.
+	SQLEndpoint string `json:"sql-endpoint" toml:"sql-endpoint"`
+	restoreSQL  string `json:"-" toml:"-"`
+	// ImportFormat is the format of the data exported by another tool to restore, e.g. `dumpling` or
+	// `parquet`, the data is a BR backup if it's empty.
+	ImportFormat string `json:"import-format" toml:"import-format"`
+
 	// [startTs, RestoreTS] is used to `restore log` from StartTS to RestoreTS.
 	StartTS uint64 `json:"start-ts" toml:"start-ts"`
 	// if not specified system will restore to the max TS available
@@ -256,10 +456,75 @@ type RestoreConfig struct {
 	PitrBatchCount  uint32                      `json:"pitr-batch-count" toml:"pitr-batch-count"`
 	PitrBatchSize   uint32                      `json:"pitr-batch-size" toml:"pitr-batch-size"`
 	PitrConcurrency uint32                      `json:"-" toml:"-"`
+	// ExportMetaEntries is the address of the MetaEntryConsumer service, the rewritten meta kv entries are
+	// streamed to it instead of being applied if it's set.
+	ExportMetaEntries string `json:"export-meta-entries" toml:"export-meta-entries"`
+	// MemoryLimit is the memory budget in bytes of the log restore, 0 means unlimited. The file metadata, the
+	// ID maps, the meta kv entries and the delete-range queue are tracked against it, but only the delete-range
+	// queue is spilled to disk when it's exceeded.
+	MemoryLimit uint64 `json:"memory-limit" toml:"memory-limit"`
+	// Follow keeps restoring the new entries of the log backup every FollowInterval after restored,
+	// which makes the cluster a warm standby of the upstream.
+	Follow         bool          `json:"follow" toml:"follow"`
+	FollowInterval time.Duration `json:"follow-interval" toml:"follow-interval"`
+	// PreserveClusterMeta keeps the preservable cluster-scoped meta keys of the log backup, e.g. the BDR
+	// role, they're stripped by default.
+	PreserveClusterMeta bool `json:"preserve-cluster-meta" toml:"preserve-cluster-meta"`
+	// RewriteTSSource is where the RewriteTS of the log restore comes from, the PD of the target cluster by
+	// default, or a fixed TSO or datetime, or the URL of an external TSO service.
+	RewriteTSSource string `json:"rewrite-ts-source" toml:"rewrite-ts-source"`
+	// RewriteTSBatchKeys allocates a fresh commit ts from PD for every batch of the meta kv entries, instead
+	// of rewriting all of them to the RewriteTS. 0 disables the batching.
+	RewriteTSBatchKeys int `json:"rewrite-ts-batch-keys" toml:"rewrite-ts-batch-keys"`
+	// ValidateMetaRoundTrip fails the log restore if a database or table info of the log backup changes
+	// after decoded and encoded by this version, instead of writing it silently.
+	ValidateMetaRoundTrip bool `json:"validate-meta-round-trip" toml:"validate-meta-round-trip"`
+	// MissingPartitionPolicy is how the partitions of a table missing in the ID maps are handled when
+	// rewriting the table infos, e.g. of the ancient backups with odd DDL histories.
+	MissingPartitionPolicy stream.MissingPartitionPolicy `json:"missing-partition-policy" toml:"missing-partition-policy"`
+	// DebugRewriteKeyPrefix logs every decision of rewriting the meta kv entries whose keys in the log backup
+	// have the prefix, e.g. to find out why a table isn't restored.
+	DebugRewriteKeyPrefix []byte `json:"debug-rewrite-key" toml:"debug-rewrite-key"`
+	// MetaRewriteRules are the extra rules to rewrite the meta kv entries of the log backup, they're
+	// applied after the built-in ones. It's used by the tools embedding BR, e.g. to force the charset.
+	MetaRewriteRules map[stream.MetaKeyType][]stream.MetaRewriteRule `json:"-" toml:"-"`
+	// Force skips the check refusing the log restore whose RewriteTS is before the data already written into
+	// the tables to restore of the target cluster.
+	Force bool `json:"force" toml:"force"`
+	// PauseDDL pauses the DDL jobs of the target cluster involving the restored tables while the meta kv
+	// entries are applied, including the jobs submitted in the meantime.
+	PauseDDL bool `json:"pause-ddl" toml:"pause-ddl"`
+	// RollbackRecordPolicy is how the rollback and lock records in the write CF of the meta kv files are
+	// handled, they're written into RollbackRecordFile under the collect policy.
+	RollbackRecordPolicy stream.RollbackRecordPolicy `json:"rollback-record-policy" toml:"rollback-record-policy"`
+	RollbackRecordFile   string                      `json:"rollback-record-file" toml:"rollback-record-file"`
+	// DeleteRangeBatch limits the statements inserting the delete ranges of the DDL jobs in the log backup,
+	// the delete ranges of a job are split into several statements if exceeded.
+	DeleteRangeBatch stream.DelRangeBatchLimit `json:"delete-range-batch" toml:"delete-range-batch"`
+	// DeleteRangeConflictPolicy is how the delete ranges of the same job and element but different ranges
+	// are handled, the identical ones are always recorded once.
+	DeleteRangeConflictPolicy stream.DelRangeConflictPolicy `json:"delete-range-conflict-policy" toml:"delete-range-conflict-policy"`
+	// DeleteRangePace paces the statements inserting the delete ranges, so the GC workers aren't overloaded
+	// by the delete ranges inserted at once.
+	DeleteRangePace stream.DelRangePace `json:"delete-range-pace" toml:"delete-range-pace"`
+	// DeferTiFlashReplicas is the storage the TiFlash replicas are saved to if it's set, they're set by
+	// `br restore tiflash-replicas` later instead of right after the restore.
+	DeferTiFlashReplicas string `json:"defer-tiflash-replicas" toml:"defer-tiflash-replicas"`
+	// MergePartitions is the table filter rules of the partitioned tables restored as non-partitioned
+	// tables, the rows of all the partitions of such a table are restored into the table.
+	MergePartitions []string `json:"merge-partitions" toml:"merge-partitions"`
+	// VerifySampleRate is the fraction of the restored keys read back from the cluster and compared against
+	// the log backup after the kv files are restored, 0 disables the verification.
+	VerifySampleRate float64 `json:"verify-sample-rate" toml:"verify-sample-rate"`
+	// ZoneLeader moves the leaders of the restored tables into the zone of the backup storage before the kv
+	// files are restored, so they're downloaded within the zone. It's disabled if the zone is empty.
+	ZoneLeader logclient.ZoneLeaderConfig `json:"zone-leader" toml:"zone-leader"`
 
 	UseCheckpoint     bool   `json:"use-checkpoint" toml:"use-checkpoint"`
 	upstreamClusterID uint64 `json:"-" toml:"-"`
 	WaitTiflashReady  bool   `json:"wait-tiflash-ready" toml:"wait-tiflash-ready"`
+	// snapshotRestoredTS is the ts after the snapshot of the PiTR is restored, 0 if it isn't restored in this run.
+	snapshotRestoredTS uint64 `json:"-" toml:"-"`
 
 	// for ebs-based restore
 	FullBackupType      FullBackupType        `json:"full-backup-type" toml:"full-backup-type"`
@@ -278,13 +543,70 @@ type RestoreConfig struct {
 
 // DefineRestoreFlags defines common flags for the restore tidb command.
 func DefineRestoreFlags(flags *pflag.FlagSet) {
-	flags.Bool(flagNoSchema, false, "skip creating schemas and tables, reuse existing empty ones")
+	flags.Bool(flagNoSchema, false, "skip creating schemas and tables, restore the data into the existing empty tables "+
+		"with the same names, the columns, indexes and partitions of the tables must be compatible with the backup, "+
+		"the tables must be empty unless --check-requirements=false")
 	flags.Bool(flagLoadStats, true, "Run load stats at end of snapshot restore task")
-	// Do not expose this flag
-	_ = flags.MarkHidden(flagNoSchema)
+	flags.Bool(flagSchemaOnly, false, "only restore the databases, tables, sequences and placement policies without the data, "+
+		"the DDLs in the log backup are replayed too for the point in time restore")
+	flags.String(flagColumnMapping, "", "the path of the JSON file changing the columns of the restored tables, "+
+		`e.g. {"db.t": {"drop": ["c1"], "rename": {"c2": "c3"}, "add": ["c4 int not null default 0"]}}`)
+	flags.StringArray(flagRowFilter, nil, "only restore the rows matching the predicate of the table, "+
+		`e.g. 'db.t: created_at >= "2024-01-01"'. TiKV ingests all the rows in the backup, the other rows are `+
+		"deleted after the table is restored, so they're written twice and can still be read by the stale reads "+
+		"until they're GCed")
+	flags.StringArray(flagSanitize, nil, "rewrite the column of all the rows before they're ingested, e.g. to scrub "+
+		"the personal data when restoring into a staging cluster, it requires --engine lightning-local since TiKV "+
+		"ingests the backup files by itself otherwise. 'db.t.c: hash' replaces the values by the prefixes of the hex "+
+		"HMAC-SHA256 keyed by --sanitize-key as long as the column allows, only the string columns can be hashed, "+
+		"and 'db.t.c: <expression>' by the expression evaluated on the row, e.g. 'db.t.phone: null'. The columns "+
+		"are named as in the backup, and the sanitized tables aren't checksummed")
+	flags.String(flagSanitizeKey, "", "the hex key of the hashes of --sanitize, at least 16 bytes")
+	flags.String(flagSanitizeKeyFile, "", "the path of the file containing the hex key of the hashes of --sanitize")
+	flags.String(flagConvertCharset, "", "convert the charset of the restored databases, tables and columns, e.g. "+
+		"'utf8:utf8mb4'. The collations are converted to the ones of the same suffix, only the conversions keeping "+
+		"the encoding of the data and the index keys are supported")
+	flags.String(flagPlacementTemplate, "", "the path of the YAML file mapping the upstream placement policies to the "+
+		"downstream ones or 'none', e.g. to restore the backup of a cluster over 3 data centers into a single one")
+	flags.String(flagSequenceRestoreMode, "", "how the restored sequences resume, value can be one of "+
+		"'exact|skip-cache|reset'. 'exact' resumes right after the value in the backup, 'skip-cache' jumps a "+
+		"cache size further to avoid reusing the values handed out after the backup, and 'reset' restarts from "+
+		"the start values. The mode recorded in the backup is used if it's not set")
+	flags.StringArray(flagTenantFilter, nil, "only restore the partitions holding the tenants of the table partitioned by "+
+		"the tenant column, e.g. 'db.t: 1,2,3'. The log backup entries of the other partitions are skipped too")
 	flags.String(FlagWithPlacementPolicy, "STRICT", "correspond to tidb global/session variable with-tidb-placement-mode")
 	flags.String(FlagKeyspaceName, "", "correspond to tidb config keyspace-name")
 
+	flags.String(flagIdempotencyToken, "", "the token identifying the restore, the restore is skipped if the restore "+
+		"with the same token is finished in the cluster in the last 7 days, and the data files ingested by the "+
+		"unfinished one are skipped")
+	flags.Bool(flagAllowCrossCluster, false, "allow restoring the backups taken from a cluster other than the "+
+		"target cluster. It doesn't apply to restoring the dropped tables, which is always in place")
+
+	flags.String(flagPausePDSchedulerScope, pausePDSchedulerScopeGlobal, "the scope of pausing the pd schedulers "+
+		"during the snapshot restore, 'global' pauses the schedulers of the whole cluster, 'table' pauses them only for "+
+		"the regions of the restored tables and resumes them table by table once the table is restored")
+	flags.String(flagResourceGroup, "", "the resource group to run the SQL executed by BR on the target cluster, "+
+		"including the DDLs, the delete ranges and the checksum, the group must exist. "+
+		"The default resource group of the BR user is used if not set")
+	flags.String(flagEngine, snapclient.EngineTiKV, "how the backup files are imported, 'tikv' lets TiKV download and "+
+		"ingest the files, 'lightning-local' reads the files in BR, sorts the kvs in --sorted-kv-dir and ingests them "+
+		"through the local backend of lightning, it only supports the full snapshot restore of the TiDB data")
+	flags.String(flagSortedKVDir, "", "the directory to sort the kvs for the 'lightning-local' engine, "+
+		"a temporary directory is used if not set")
+	flags.String(flagTableStatsReport, "", "the local CSV file the restore statistics of the tables are appended to, "+
+		"the point in time restore appends the tables of the snapshot restore and the log restore in turn")
+	flags.String(flagSQLEndpoint, "", "the DSN of the TiDB to restore the snapshot backup by its RESTORE statement, "+
+		"e.g. 'user:password@tcp(127.0.0.1:4000)/?tls=true', for the environments where BR can't access PD and TiKV. "+
+		"The backup is downloaded by the TiDB, so the storage must be accessible from it. It's much slower than "+
+		"restoring by BR, because the TiDB runs one backup or restore at a time and BR doesn't take part in it, "+
+		"and the flags without an equivalent option of the RESTORE statement, e.g. --filter and --pd, aren't supported, "+
+		"except the backend options, e.g. --s3.endpoint, passed by the query parameters of the storage URL")
+	flags.String(flagImportFormat, "", "restore the data exported by another tool instead of a BR backup, "+
+		"'dumpling' for the SQL, CSV or Parquet files with the schema files exported by Dumpling or MyDumper, "+
+		"'parquet' for the Parquet files whose schemas are inferred if the schema files are missing, "+
+		"'auto' to detect the format. The data is imported by the 'lightning-local' engine without the checksum")
+
 	flags.Bool(flagUseCheckpoint, true, "use checkpoint mode")
 	_ = flags.MarkHidden(flagUseCheckpoint)
 
@@ -308,6 +630,116 @@ func DefineStreamRestoreFlags(command *cobra.Command) {
 	command.Flags().Uint32(FlagPiTRBatchCount, defaultPiTRBatchCount, "specify the batch count to restore log.")
 	command.Flags().Uint32(FlagPiTRBatchSize, defaultPiTRBatchSize, "specify the batch size to retore log.")
 	command.Flags().Uint32(FlagPiTRConcurrency, defaultPiTRConcurrency, "specify the concurrency to restore log.")
+	command.Flags().String(FlagStreamExportMetaEntries, "", "the address of the gRPC MetaEntryConsumer service, "+
+		"the rewritten meta kv entries of the log backup are streamed to it instead of being applied, "+
+		"nothing is restored into the cluster, so it can't be used with --full-backup-storage")
+	command.Flags().String(FlagStreamMemoryLimit, "", "the memory budget of the log restore, e.g. 4GiB. The "+
+		"memory of the file metadata, the ID maps, the meta kv entries and the delete-range queue is tracked "+
+		"against it, but only the delete-range queue is spilled to disk when it's exceeded, the others are only "+
+		"reported by a warning. Unlimited if not set")
+	command.Flags().Bool(FlagStreamFollow, false, "keep restoring the new entries of the log backup after restored, "+
+		"the cluster works as a warm standby until BR exits. The TiFlash replicas aren't restored in this mode")
+	command.Flags().Duration(FlagStreamFollowInterval, time.Minute, "the interval to restore the new entries "+
+		"of the log backup in the follow mode")
+	command.Flags().Bool(FlagStreamPreserveClusterMeta, false, fmt.Sprintf("keep the cluster-scoped meta keys of "+
+		"the log backup instead of stripping them, only the families %v can be kept, "+
+		"the others are maintained by the target cluster itself", stream.PreservableClusterMetaFamilies()))
+	command.Flags().String(FlagStreamRewriteTSSource, rewriteTSSourcePD, "where the ts the restored kvs are "+
+		"rewritten to comes from, 'pd' allocates it from the PD of the target cluster, a TSO or datetime uses the "+
+		"fixed ts, e.g. '400036290571534337' or '2018-05-11 01:42:23+0800', and an http(s) URL gets it from "+
+		"an external TSO service responding the ts in decimal. The ts must not be after the current ts of the target cluster")
+	command.Flags().Int(FlagStreamRewriteTSBatchKeys, 0, "allocate a fresh commit ts from PD for every batch of the "+
+		"meta kv entries instead of rewriting all of them to the single rewrite ts, e.g. 10000. The ts allocated are "+
+		"strictly increasing in the order of the entries, 0 disables the batching")
+	command.Flags().Bool(FlagStreamValidateMetaRoundTrip, false, "check the database and table infos of the log backup "+
+		"are decoded and encoded without loss before and after rewritten, and fail the restore instead of writing "+
+		"the lossy ones, e.g. the fields added by a newer version are dropped or the numbers lose precision")
+	command.Flags().String(FlagStreamMissingPartitionPolicy, string(stream.MissingPartitionError), fmt.Sprintf(
+		"how the partitions of a table missing in the id maps of the log restore are handled, %q fails the restore, "+
+			"%q drops the partition and its data, and %q allocates a new id for the partition and restores it",
+		stream.MissingPartitionError, stream.MissingPartitionSkip, stream.MissingPartitionAllocateNewID))
+	command.Flags().String(FlagStreamDebugRewriteKey, "", "the hex prefix of the keys in the log backup, every "+
+		"decision of rewriting the meta kv entries with the prefix is logged at INFO level, e.g. the parsed key, "+
+		"the hits and misses of the id maps and the rewritten key")
+	command.Flags().Bool(FlagStreamForce, false, "restore even if the existing keys sampled in the tables to "+
+		"restore of the target cluster are written after the rewrite ts. The restored kvs are shadowed by such keys "+
+		"for the reads and may be collected by GC earlier than expected. The keys are sampled from both ends of a "+
+		"bounded number of the tables before anything is restored")
+	command.Flags().Bool(FlagStreamPauseDDL, false, "pause the DDL jobs of the target cluster involving the "+
+		"restored tables while the meta kv entries of the log backup are applied, the jobs submitted in the "+
+		"meantime wait until the entries are applied. The jobs paused by a crashed restore are resumed by the "+
+		"next restore with the flag")
+	command.Flags().String(FlagStreamRollbackRecordPolicy, string(stream.RollbackRecordApply), fmt.Sprintf(
+		"how the rollback and lock records in the write CF of the meta kv files are handled, %q writes them into the "+
+			"target cluster, %q skips them, and %q skips them and writes them into --%s in JSON lines for the analyses. "+
+			"The data kv files are applied by TiKV and aren't affected",
+		stream.RollbackRecordApply, stream.RollbackRecordDrop, stream.RollbackRecordCollect, FlagStreamRollbackRecordFile))
+	command.Flags().String(FlagStreamRollbackRecordFile, "", fmt.Sprintf("the local file the rollback and lock "+
+		"records are written into under --%s=%s", FlagStreamRollbackRecordPolicy, stream.RollbackRecordCollect))
+	command.Flags().Int(FlagStreamDeleteRangeBatchRows, stream.DefaultDelRangeBatchRows,
+		"the max number of the delete ranges inserted into mysql.gc_delete_range by a statement")
+	command.Flags().String(FlagStreamDeleteRangeBatchSize, units.BytesSize(stream.DefaultDelRangeBatchBytes),
+		"the max length of a statement inserting the delete ranges into mysql.gc_delete_range, e.g. 4MiB, "+
+			"the delete ranges of a DDL job dropping many partitions are split into several statements if exceeded")
+	command.Flags().String(FlagStreamDeleteRangeConflictPolicy, string(stream.DelRangeConflictKeepFirst), fmt.Sprintf(
+		"how to handle the delete ranges of a DDL job restored more than once with different ranges, "+
+			"'%s' keeps the first one, '%s' fails the restore. The identical delete ranges are always recorded once",
+		stream.DelRangeConflictKeepFirst, stream.DelRangeConflictError))
+	command.Flags().Duration(FlagStreamDeleteRangeInterval, 0,
+		"the min sleep between the statements inserting the delete ranges into mysql.gc_delete_range after the restore")
+	command.Flags().Duration(FlagStreamDeleteRangeDuration, 0, fmt.Sprintf(
+		"spread the statements inserting the delete ranges over the duration after the restore, e.g. 30m, "+
+			"so the GC workers aren't overloaded. The sleep between the statements is at least --%s",
+		FlagStreamDeleteRangeInterval))
+	command.Flags().String(FlagStreamDeferTiFlashReplicas, "", "the storage the TiFlash replicas of the restored "+
+		"tables are saved to instead of being set right after the restore, e.g. 's3://bucket/path'. "+
+		"Set them later by 'br restore tiflash-replicas', e.g. off-peak")
+	command.Flags().StringArray(FlagStreamMergePartitions, nil, "restore the partitioned tables matching the "+
+		"table filter rules as non-partitioned tables, e.g. 'db.orders', the rows of all the partitions are "+
+		"restored into the table. The tables with global indexes can't be merged, and the restore fails if "+
+		"the partitions of a merged table are dropped, truncated or exchanged in the log backup")
+	command.Flags().Float64(FlagStreamVerifySampleRate, 0, "the fraction of the restored keys read back from the "+
+		"cluster and compared against the log backup after the restore, e.g. 0.01. The keys are sampled by the "+
+		"log files, 0 disables the verification")
+	command.Flags().String(FlagStreamStorageZone, "", "the zone of the backup storage, e.g. 'us-west-2a'. The "+
+		"leaders of the restored tables are moved into the TiKV stores of the zone before the kv files are "+
+		"restored, if the cross-zone transfer saved is worth the cost, so the files are downloaded within the zone")
+	command.Flags().String(FlagStreamZoneLabel, logclient.DefaultZoneLabel, "the label key of the TiKV stores "+
+		"marking their zones")
+	command.Flags().String(FlagStreamZoneLeaderTransferCost, units.BytesSize(logclient.DefaultZoneLeaderTransferCost),
+		"the cost of moving a leader into the zone of the backup storage, in bytes of the cross-zone transfer "+
+			"it's worth. The leaders of a table are moved only if the bytes downloaded across the zones exceed it")
+	command.Flags().Duration(FlagStreamZoneLeaderWait, 5*time.Minute, "the max duration to wait for the leaders "+
+		"moved into the zone of the backup storage, the restore continues after timeout")
+}
+
+// parseZoneLeaderFlags parses the flags to move the leaders into the zone of the backup storage.
+func (cfg *RestoreConfig) parseZoneLeaderFlags(flags *pflag.FlagSet) error {
+	var err error
+	if cfg.ZoneLeader.Zone, err = flags.GetString(FlagStreamStorageZone); err != nil {
+		return errors.Trace(err)
+	}
+	if cfg.ZoneLeader.LabelKey, err = flags.GetString(FlagStreamZoneLabel); err != nil {
+		return errors.Trace(err)
+	}
+	transferCost, err := flags.GetString(FlagStreamZoneLeaderTransferCost)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	transferBytes, err := units.RAMInBytes(transferCost)
+	if err != nil || transferBytes < 0 {
+		return errors.Annotatef(berrors.ErrInvalidArgument, "invalid --%s %q, should be a size",
+			FlagStreamZoneLeaderTransferCost, transferCost)
+	}
+	cfg.ZoneLeader.LeaderTransferCost = uint64(transferBytes)
+	if cfg.ZoneLeader.WaitTimeout, err = flags.GetDuration(FlagStreamZoneLeaderWait); err != nil {
+		return errors.Trace(err)
+	}
+	if cfg.ZoneLeader.Zone != "" && cfg.ZoneLeader.LabelKey == "" {
+		return errors.Annotatef(berrors.ErrInvalidArgument, "--%s mustn't be empty with --%s",
+			FlagStreamZoneLabel, FlagStreamStorageZone)
+	}
+	return nil
 }
 
 // ParseStreamRestoreFlags parses the `restore stream` flags from the flag set.
@@ -345,9 +777,178 @@ func (cfg *RestoreConfig) ParseStreamRestoreFlags(flags *pflag.FlagSet) error {
 	if cfg.PitrConcurrency, err = flags.GetUint32(FlagPiTRConcurrency); err != nil {
 		return errors.Trace(err)
 	}
+	if cfg.ExportMetaEntries, err = flags.GetString(FlagStreamExportMetaEntries); err != nil {
+		return errors.Trace(err)
+	}
+	memoryLimit, err := flags.GetString(FlagStreamMemoryLimit)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if memoryLimit != "" {
+		limit, err := units.RAMInBytes(memoryLimit)
+		if err != nil {
+			return errors.Annotatef(berrors.ErrInvalidArgument, "invalid --%s %q: %v", FlagStreamMemoryLimit, memoryLimit, err)
+		}
+		cfg.MemoryLimit = uint64(max(limit, 0))
+	}
+	if cfg.PreserveClusterMeta, err = flags.GetBool(FlagStreamPreserveClusterMeta); err != nil {
+		return errors.Trace(err)
+	}
+	if cfg.RewriteTSSource, err = flags.GetString(FlagStreamRewriteTSSource); err != nil {
+		return errors.Trace(err)
+	}
+	if _, err = newRewriteTSProvider(cfg.RewriteTSSource, nil, &cfg.TLS); err != nil {
+		return errors.Trace(err)
+	}
+	if cfg.RewriteTSBatchKeys, err = flags.GetInt(FlagStreamRewriteTSBatchKeys); err != nil {
+		return errors.Trace(err)
+	}
+	if cfg.RewriteTSBatchKeys < 0 {
+		return errors.Annotatef(berrors.ErrInvalidArgument, "--%s mustn't be negative", FlagStreamRewriteTSBatchKeys)
+	}
+	if cfg.RewriteTSBatchKeys > 0 && cfg.RewriteTSSource != "" && !strings.EqualFold(cfg.RewriteTSSource, rewriteTSSourcePD) {
+		return errors.Annotatef(berrors.ErrInvalidArgument, "--%s can only be used with --%s=%s, the ts are "+
+			"allocated from PD", FlagStreamRewriteTSBatchKeys, FlagStreamRewriteTSSource, rewriteTSSourcePD)
+	}
+	if cfg.ValidateMetaRoundTrip, err = flags.GetBool(FlagStreamValidateMetaRoundTrip); err != nil {
+		return errors.Trace(err)
+	}
+	missingPartitionPolicy, err := flags.GetString(FlagStreamMissingPartitionPolicy)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if cfg.MissingPartitionPolicy, err = stream.ParseMissingPartitionPolicy(missingPartitionPolicy); err != nil {
+		return errors.Trace(err)
+	}
+	debugRewriteKey, err := flags.GetString(FlagStreamDebugRewriteKey)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if cfg.DebugRewriteKeyPrefix, err = hex.DecodeString(debugRewriteKey); err != nil {
+		return errors.Annotatef(berrors.ErrInvalidArgument, "invalid --%s %q: %v", FlagStreamDebugRewriteKey, debugRewriteKey, err)
+	}
+	if cfg.Force, err = flags.GetBool(FlagStreamForce); err != nil {
+		return errors.Trace(err)
+	}
+	if cfg.PauseDDL, err = flags.GetBool(FlagStreamPauseDDL); err != nil {
+		return errors.Trace(err)
+	}
+	rollbackRecordPolicy, err := flags.GetString(FlagStreamRollbackRecordPolicy)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if cfg.RollbackRecordPolicy, err = stream.ParseRollbackRecordPolicy(rollbackRecordPolicy); err != nil {
+		return errors.Trace(err)
+	}
+	if cfg.RollbackRecordFile, err = flags.GetString(FlagStreamRollbackRecordFile); err != nil {
+		return errors.Trace(err)
+	}
+	if (cfg.RollbackRecordPolicy == stream.RollbackRecordCollect) != (cfg.RollbackRecordFile != "") {
+		return errors.Annotatef(berrors.ErrInvalidArgument, "--%s must be set if and only if --%s is %q",
+			FlagStreamRollbackRecordFile, FlagStreamRollbackRecordPolicy, stream.RollbackRecordCollect)
+	}
+	if cfg.DeleteRangeBatch.MaxRows, err = flags.GetInt(FlagStreamDeleteRangeBatchRows); err != nil {
+		return errors.Trace(err)
+	}
+	if cfg.DeleteRangeBatch.MaxRows <= 0 {
+		return errors.Annotatef(berrors.ErrInvalidArgument, "--%s must be positive", FlagStreamDeleteRangeBatchRows)
+	}
+	batchSize, err := flags.GetString(FlagStreamDeleteRangeBatchSize)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	batchBytes, err := units.RAMInBytes(batchSize)
+	if err != nil || batchBytes <= 0 {
+		return errors.Annotatef(berrors.ErrInvalidArgument, "invalid --%s %q, should be a positive size",
+			FlagStreamDeleteRangeBatchSize, batchSize)
+	}
+	cfg.DeleteRangeBatch.MaxBytes = int(batchBytes)
+	conflictPolicy, err := flags.GetString(FlagStreamDeleteRangeConflictPolicy)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if cfg.DeleteRangeConflictPolicy, err = stream.ParseDelRangeConflictPolicy(conflictPolicy); err != nil {
+		return errors.Trace(err)
+	}
+	if cfg.DeferTiFlashReplicas, err = flags.GetString(FlagStreamDeferTiFlashReplicas); err != nil {
+		return errors.Trace(err)
+	}
+	if cfg.MergePartitions, err = flags.GetStringArray(FlagStreamMergePartitions); err != nil {
+		return errors.Trace(err)
+	}
+	if cfg.VerifySampleRate, err = flags.GetFloat64(FlagStreamVerifySampleRate); err != nil {
+		return errors.Trace(err)
+	}
+	if cfg.VerifySampleRate < 0 || cfg.VerifySampleRate > 1 {
+		return errors.Annotatef(berrors.ErrInvalidArgument, "invalid --%s %v, should be in [0, 1]",
+			FlagStreamVerifySampleRate, cfg.VerifySampleRate)
+	}
+	if len(cfg.MergePartitions) > 0 {
+		if _, err := filter.Parse(cfg.MergePartitions); err != nil {
+			return errors.Annotatef(berrors.ErrInvalidArgument, "invalid --%s: %v", FlagStreamMergePartitions, err)
+		}
+	}
+	if err = cfg.parseZoneLeaderFlags(flags); err != nil {
+		return errors.Trace(err)
+	}
+	if cfg.DeleteRangePace.Interval, err = flags.GetDuration(FlagStreamDeleteRangeInterval); err != nil {
+		return errors.Trace(err)
+	}
+	if cfg.DeleteRangePace.Duration, err = flags.GetDuration(FlagStreamDeleteRangeDuration); err != nil {
+		return errors.Trace(err)
+	}
+	if cfg.DeleteRangePace.Interval < 0 || cfg.DeleteRangePace.Duration < 0 {
+		return errors.Annotatef(berrors.ErrInvalidArgument, "--%s and --%s mustn't be negative",
+			FlagStreamDeleteRangeInterval, FlagStreamDeleteRangeDuration)
+	}
+	if cfg.Follow, err = flags.GetBool(FlagStreamFollow); err != nil {
+		return errors.Trace(err)
+	}
+	if cfg.FollowInterval, err = flags.GetDuration(FlagStreamFollowInterval); err != nil {
+		return errors.Trace(err)
+	}
+	if cfg.Follow {
+		if cfg.FollowInterval <= 0 {
+			return errors.Annotatef(berrors.ErrInvalidArgument, "--%s must be positive", FlagStreamFollowInterval)
+		}
+		if cfg.RestoreTS > 0 || cfg.ExportMetaEntries != "" {
+			return errors.Annotatef(berrors.ErrInvalidArgument, "--%s can't be used with --%s or --%s",
+				FlagStreamFollow, FlagStreamRestoreTS, FlagStreamExportMetaEntries)
+		}
+	}
+	if cfg.ExportMetaEntries != "" && len(cfg.FullBackupStorage) > 0 {
+		// the meta entries aren't applied, so the restored snapshot would be left without the log data.
+		return errors.Annotatef(berrors.ErrInvalidArgument, "--%s only exports the meta entries, it can't be used with --%s",
+			FlagStreamExportMetaEntries, FlagStreamFullBackupStorage)
+	}
+	if cfg.ExportMetaEntries != "" && cfg.UseCheckpoint {
+		// the entries exported already can't be recalled by the checkpoint.
+		log.Info("the meta entries are exported, disable checkpoint.")
+		cfg.UseCheckpoint = false
+	}
 	return nil
 }
 
+// newRewriteTSProvider returns the provider of the RewriteTS given by --rewrite-ts-source.
+// The external TSO service is accessed by the --http.* TLS files if they're set.
+func newRewriteTSProvider(source string, pdClient pd.Client, tlsCfg *TLSConfig) (restore.TSProvider, error) {
+	switch {
+	case source == "" || strings.EqualFold(source, rewriteTSSourcePD):
+		return restore.NewPDTSProvider(pdClient), nil
+	case strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://"):
+		httpClient, err := tlsCfg.ToHTTPClient()
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		return restore.NewHTTPTSProvider(source, httpClient), nil
+	}
+	ts, err := ParseTSString(source, true)
+	if err != nil {
+		return nil, errors.Annotatef(berrors.ErrInvalidArgument, "invalid --%s %q: %v", FlagStreamRewriteTSSource, source, err)
+	}
+	return restore.NewFixedTSProvider(ts), nil
+}
+
 // ParseFromFlags parses the restore-related flags from the flag set.
 func (cfg *RestoreConfig) ParseFromFlags(flags *pflag.FlagSet, skipCommonConfig bool) error {
 	var err error
@@ -408,6 +1009,101 @@ func (cfg *RestoreConfig) ParseFromFlags(flags *pflag.FlagSet, skipCommonConfig
 	if err != nil {
 		return errors.Annotatef(err, "failed to get flag %s", flagUseCheckpoint)
 	}
+	cfg.SchemaOnly, err = flags.GetBool(flagSchemaOnly)
+	if err != nil {
+		return errors.Annotatef(err, "failed to get flag %s", flagSchemaOnly)
+	}
+	if cfg.SchemaOnly {
+		if cfg.NoSchema {
+			return errors.Annotatef(berrors.ErrInvalidArgument, "--%s conflicts with --%s", flagSchemaOnly, flagNoSchema)
+		}
+		// creating the schemas is fast, there is nothing worth resuming from a checkpoint.
+		cfg.UseCheckpoint = false
+	}
+	cfg.ColumnMapping, err = flags.GetString(flagColumnMapping)
+	if err != nil {
+		return errors.Annotatef(err, "failed to get flag %s", flagColumnMapping)
+	}
+	if cfg.ColumnMapping != "" && cfg.NoSchema {
+		return errors.Annotatef(berrors.ErrInvalidArgument, "--%s conflicts with --%s", flagColumnMapping, flagNoSchema)
+	}
+	cfg.RowFilters, err = flags.GetStringArray(flagRowFilter)
+	if err != nil {
+		return errors.Annotatef(err, "failed to get flag %s", flagRowFilter)
+	}
+	if len(cfg.RowFilters) > 0 {
+		if cfg.SchemaOnly {
+			return errors.Annotatef(berrors.ErrInvalidArgument, "--%s conflicts with --%s", flagRowFilter, flagSchemaOnly)
+		}
+		if _, err := snapclient.ParseRowFilters(cfg.RowFilters); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	cfg.Sanitizers, err = flags.GetStringArray(flagSanitize)
+	if err != nil {
+		return errors.Annotatef(err, "failed to get flag %s", flagSanitize)
+	}
+	if len(cfg.Sanitizers) > 0 {
+		if cfg.SchemaOnly {
+			return errors.Annotatef(berrors.ErrInvalidArgument, "--%s conflicts with --%s", flagSanitize, flagSchemaOnly)
+		}
+		sanitizers, err := snapclient.ParseSanitizers(cfg.Sanitizers)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if err := cfg.parseSanitizeKey(flags, sanitizers); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	cfg.ConvertCharset, err = flags.GetString(flagConvertCharset)
+	if err != nil {
+		return errors.Annotatef(err, "failed to get flag %s", flagConvertCharset)
+	}
+	if cfg.ConvertCharset != "" {
+		if cfg.NoSchema {
+			return errors.Annotatef(berrors.ErrInvalidArgument, "--%s conflicts with --%s", flagConvertCharset, flagNoSchema)
+		}
+		if _, err := utils.ParseCharsetConversion(cfg.ConvertCharset); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	cfg.PlacementTemplate, err = flags.GetString(flagPlacementTemplate)
+	if err != nil {
+		return errors.Annotatef(err, "failed to get flag %s", flagPlacementTemplate)
+	}
+	if cfg.PlacementTemplate != "" && cfg.NoSchema {
+		return errors.Annotatef(berrors.ErrInvalidArgument, "--%s conflicts with --%s", flagPlacementTemplate, flagNoSchema)
+	}
+	sequenceRestoreMode, err := flags.GetString(flagSequenceRestoreMode)
+	if err != nil {
+		return errors.Annotatef(err, "failed to get flag %s", flagSequenceRestoreMode)
+	}
+	if cfg.SequenceRestoreMode, err = metautil.ParseSequenceRestoreMode(sequenceRestoreMode); err != nil {
+		return errors.Trace(err)
+	}
+	cfg.TenantFilters, err = flags.GetStringArray(flagTenantFilter)
+	if err != nil {
+		return errors.Annotatef(err, "failed to get flag %s", flagTenantFilter)
+	}
+	if len(cfg.TenantFilters) > 0 {
+		if cfg.SchemaOnly {
+			return errors.Annotatef(berrors.ErrInvalidArgument, "--%s conflicts with --%s", flagTenantFilter, flagSchemaOnly)
+		}
+		if _, err := snapclient.ParseTenantFilters(cfg.TenantFilters); err != nil {
+			return errors.Trace(err)
+		}
+	}
+
+	cfg.IdempotencyToken, err = flags.GetString(flagIdempotencyToken)
+	if err != nil {
+		return errors.Annotatef(err, "failed to get flag %s", flagIdempotencyToken)
+	}
+	if len(cfg.IdempotencyToken) > 256 {
+		return errors.Annotatef(berrors.ErrInvalidArgument, "--%s is longer than 256 bytes", flagIdempotencyToken)
+	}
+	if cfg.AllowCrossCluster, err = flags.GetBool(flagAllowCrossCluster); err != nil {
+		return errors.Annotatef(err, "failed to get flag %s", flagAllowCrossCluster)
+	}
 
 	cfg.WaitTiflashReady, err = flags.GetBool(FlagWaitTiFlashReady)
 	if err != nil {
@@ -419,6 +1115,52 @@ func (cfg *RestoreConfig) ParseFromFlags(flags *pflag.FlagSet, skipCommonConfig
 		return errors.Annotatef(err, "failed to get flag %s", flagAllowPITRFromIncremental)
 	}
 
+	cfg.PausePDSchedulerScope, err = flags.GetString(flagPausePDSchedulerScope)
+	if err != nil {
+		return errors.Annotatef(err, "failed to get flag %s", flagPausePDSchedulerScope)
+	}
+	if cfg.PausePDSchedulerScope != pausePDSchedulerScopeGlobal && cfg.PausePDSchedulerScope != pausePDSchedulerScopeTable {
+		return errors.Annotatef(berrors.ErrInvalidArgument, "--%s should be %s or %s, but got %s",
+			flagPausePDSchedulerScope, pausePDSchedulerScopeGlobal, pausePDSchedulerScopeTable, cfg.PausePDSchedulerScope)
+	}
+	cfg.ResourceGroup, err = flags.GetString(flagResourceGroup)
+	if err != nil {
+		return errors.Annotatef(err, "failed to get flag %s", flagResourceGroup)
+	}
+	cfg.Engine, err = flags.GetString(flagEngine)
+	if err != nil {
+		return errors.Annotatef(err, "failed to get flag %s", flagEngine)
+	}
+	if cfg.Engine != snapclient.EngineTiKV && cfg.Engine != snapclient.EngineLightningLocal {
+		return errors.Annotatef(berrors.ErrInvalidArgument, "--%s should be %s or %s, but got %s",
+			flagEngine, snapclient.EngineTiKV, snapclient.EngineLightningLocal, cfg.Engine)
+	}
+	cfg.SortedKVDir, err = flags.GetString(flagSortedKVDir)
+	if err != nil {
+		return errors.Annotatef(err, "failed to get flag %s", flagSortedKVDir)
+	}
+	cfg.TableStatsReport, err = flags.GetString(flagTableStatsReport)
+	if err != nil {
+		return errors.Annotatef(err, "failed to get flag %s", flagTableStatsReport)
+	}
+	if err := cfg.parseImportFormat(flags); err != nil {
+		return errors.Trace(err)
+	}
+	if len(cfg.Sanitizers) > 0 && cfg.Engine != snapclient.EngineLightningLocal {
+		// TiKV downloads and ingests the backup files by itself, BR never sees the rows.
+		return errors.Annotatef(berrors.ErrInvalidArgument, "--%s requires --%s %s",
+			flagSanitize, flagEngine, snapclient.EngineLightningLocal)
+	}
+	cfg.SQLEndpoint, err = flags.GetString(flagSQLEndpoint)
+	if err != nil {
+		return errors.Annotatef(err, "failed to get flag %s", flagSQLEndpoint)
+	}
+	if cfg.SQLEndpoint != "" {
+		if cfg.restoreSQL, err = buildRestoreSQL(flags, cfg); err != nil {
+			return errors.Trace(err)
+		}
+	}
+
 	if flags.Lookup(flagFullBackupType) != nil {
 		// for restore full only
 		fullBackupType, err := flags.GetString(flagFullBackupType)
@@ -489,6 +1231,35 @@ func (cfg *RestoreConfig) ParseFromFlags(flags *pflag.FlagSet, skipCommonConfig
 	return nil
 }
 
+// parseSanitizeKey reads the key of the hashes, which is required if any column is hashed.
+func (cfg *RestoreConfig) parseSanitizeKey(flags *pflag.FlagSet, sanitizers map[string][]snapclient.Sanitizer) error {
+	key, err := flags.GetString(flagSanitizeKey)
+	if err != nil {
+		return errors.Annotatef(err, "failed to get flag %s", flagSanitizeKey)
+	}
+	keyFile, err := flags.GetString(flagSanitizeKeyFile)
+	if err != nil {
+		return errors.Annotatef(err, "failed to get flag %s", flagSanitizeKeyFile)
+	}
+	hashed := false
+	for _, columns := range sanitizers {
+		for _, s := range columns {
+			hashed = hashed || s.Hash
+		}
+	}
+	if !hashed && key == "" && keyFile == "" {
+		return nil
+	}
+	if cfg.SanitizeKey, err = encryption.GetCipherKeyContent(key, keyFile); err != nil {
+		return errors.Annotatef(err, "invalid --%s or --%s", flagSanitizeKey, flagSanitizeKeyFile)
+	}
+	if len(cfg.SanitizeKey) < snapclient.MinSanitizeKeyLen {
+		return errors.Annotatef(berrors.ErrInvalidArgument, "--%s should be at least %d bytes, but got %d",
+			flagSanitizeKey, snapclient.MinSanitizeKeyLen, len(cfg.SanitizeKey))
+	}
+	return nil
+}
+
 // Adjust is use for BR(binary) and BR in TiDB.
 // When new config was added and not included in parser.
 // we should set proper value in this function.
@@ -530,6 +1301,15 @@ func (cfg *RestoreConfig) adjustRestoreConfigForStreamRestore() {
 	if cfg.PitrBatchSize == 0 {
 		cfg.PitrBatchSize = defaultPiTRBatchSize
 	}
+	if cfg.DeleteRangeBatch.MaxRows == 0 {
+		cfg.DeleteRangeBatch.MaxRows = stream.DefaultDelRangeBatchRows
+	}
+	if cfg.DeleteRangeBatch.MaxBytes == 0 {
+		cfg.DeleteRangeBatch.MaxBytes = stream.DefaultDelRangeBatchBytes
+	}
+	if cfg.DeleteRangeConflictPolicy == "" {
+		cfg.DeleteRangeConflictPolicy = stream.DelRangeConflictKeepFirst
+	}
 	// another goroutine is used to iterate the backup file
 	cfg.PitrConcurrency += 1
 	log.Info("set restore kv files concurrency", zap.Int("concurrency", int(cfg.PitrConcurrency)))
@@ -546,13 +1326,82 @@ func configureRestoreClient(ctx context.Context, client *snapclient.SnapClient,
 	if cfg.NoSchema {
 		client.EnableSkipCreateSQL()
 	}
+	if cfg.ColumnMapping != "" {
+		mappings, err := loadColumnMappings(cfg.ColumnMapping)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		client.SetColumnMappings(mappings)
+	}
+	if len(cfg.RowFilters) > 0 {
+		predicates, err := snapclient.ParseRowFilters(cfg.RowFilters)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		client.SetRowFilters(predicates)
+	}
+	if len(cfg.Sanitizers) > 0 {
+		sanitizers, err := snapclient.ParseSanitizers(cfg.Sanitizers)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if err := client.SetSanitizers(sanitizers, cfg.SanitizeKey); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	if len(cfg.MergePartitions) > 0 {
+		f, err := filter.Parse(cfg.MergePartitions)
+		if err != nil {
+			return errors.Annotatef(berrors.ErrInvalidArgument, "invalid --%s: %v", FlagStreamMergePartitions, err)
+		}
+		client.SetMergePartitions(f)
+	}
+	if cfg.ConvertCharset != "" {
+		conversion, err := utils.ParseCharsetConversion(cfg.ConvertCharset)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		client.SetCharsetConversion(conversion)
+	}
+	if cfg.PlacementTemplate != "" {
+		template, err := utils.LoadPlacementTemplate(cfg.PlacementTemplate)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		client.SetPlacementTemplate(template)
+	}
+	client.SetSequenceRestoreMode(cfg.SequenceRestoreMode)
 	client.SetBatchDdlSize(cfg.DdlBatchSize)
 	client.SetPlacementPolicyMode(cfg.WithPlacementPolicy)
 	client.SetWithSysTable(cfg.WithSysTable)
+	client.SetPhaseTimeouts(cfg.phaseTimeouts())
+	client.SetResourceGroupName(cfg.ResourceGroup)
 	client.SetRewriteMode(ctx)
 	return nil
 }
 
+// loadColumnMappings loads the column mappings from the JSON file.
+func loadColumnMappings(path string) (map[string]*snapclient.ColumnMapping, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Annotatef(err, "failed to read column mapping file %s", path)
+	}
+	var raw map[string]*snapclient.ColumnMapping
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, errors.Annotatef(berrors.ErrInvalidArgument, "invalid column mapping file %s: %v", path, err)
+	}
+	mappings := make(map[string]*snapclient.ColumnMapping, len(raw))
+	for name, m := range raw {
+		db, table, ok := strings.Cut(name, ".")
+		if !ok || db == "" || table == "" || m == nil {
+			return nil, errors.Annotatef(berrors.ErrInvalidArgument,
+				"invalid column mapping of %q, the key should be like \"db.table\"", name)
+		}
+		mappings[snapclient.TableKey(db, table)] = m
+	}
+	return mappings, nil
+}
+
 func CheckNewCollationEnable(
 	backupNewCollationEnable string,
 	g glue.Glue,
@@ -667,6 +1516,10 @@ func DefaultRestoreConfig(commonConfig Config) RestoreConfig {
 
 // RunRestore starts a restore task inside the current goroutine.
 func RunRestore(c context.Context, g glue.Glue, cmdName string, cfg *RestoreConfig) error {
+	g = withResourceGroup(g, cfg.ResourceGroup)
+	// the restore may consist of the snapshot restore and the log restore, report once at the end.
+	defer cfg.storageRetryPolicy.Report()
+	defer cfg.storageRequestStats.Report()
 	etcdCLI, err := dialEtcdWithCfg(c, cfg.Config)
 	if err != nil {
 		return err
@@ -698,7 +1551,28 @@ func RunRestore(c context.Context, g glue.Glue, cmdName string, cfg *RestoreConf
 	}
 	defer mgr.Close()
 
+	// the fingerprint is of the config given by the user, since the restore adjusts it, e.g. the restored ts.
+	var tokenOwner string
+	if cfg.IdempotencyToken != "" {
+		var finished bool
+		finished, tokenOwner, err = acquireRestoreToken(c, g, mgr.GetStorage(), cfg, restoreTokenFingerprint(cmdName, cfg))
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if finished {
+			summary.Log("the restore with the token is finished, skipped",
+				zap.String("token", cfg.IdempotencyToken))
+			return nil
+		}
+	}
+
+	if err := checkEncryptionAtRestConfig(c, mgr, cfg.EncryptionAtRest, &cfg.Config); err != nil {
+		return errors.Trace(err)
+	}
+
 	var restoreError error
+	// the config is adjusted by the restore, so the rounds of following start from the original one.
+	followCfg := *cfg
 	if IsStreamRestore(cmdName) {
 		if err := version.CheckClusterVersion(c, mgr.GetPDClient(), version.CheckVersionForBRPiTR); err != nil {
 			return errors.Trace(err)
@@ -710,44 +1584,187 @@ func RunRestore(c context.Context, g glue.Glue, cmdName string, cfg *RestoreConf
 		}
 		restoreError = runSnapshotRestore(c, mgr, g, cmdName, cfg, nil)
 	}
+	catalogKind := metautil.CatalogKindSnapshot
+	if IsStreamRestore(cmdName) {
+		catalogKind = metautil.CatalogKindLog
+	}
+	recordToCatalog(c, &cfg.Config, cfg.Storage, &metautil.CatalogRecord{
+		Operation: metautil.CatalogRestore,
+		Kind:      catalogKind,
+		EndTS:     cfg.RestoreTS,
+		ClusterID: mgr.GetPDClient().GetClusterID(c),
+	}, restoreError)
 	if restoreError != nil {
 		return errors.Trace(restoreError)
 	}
-	// Clear the checkpoint data
-	if cfg.UseCheckpoint {
-		se, err := g.CreateSession(mgr.GetStorage())
-		if err != nil {
-			log.Warn("failed to remove checkpoint data", zap.Error(err))
-		} else {
-			if IsStreamRestore(cmdName) {
-				log.Info("start to remove checkpoint data for PITR restore")
-				err = checkpoint.RemoveCheckpointDataForLogRestore(c, mgr.GetDomain(), se)
-				if err != nil {
-					log.Warn("failed to remove checkpoint data for log restore", zap.Error(err))
-				}
-				err = checkpoint.RemoveCheckpointDataForSstRestore(c, mgr.GetDomain(), se, checkpoint.CustomSSTRestoreCheckpointDatabaseName)
-				if err != nil {
-					log.Warn("failed to remove checkpoint data for compacted restore", zap.Error(err))
-				}
-				err = checkpoint.RemoveCheckpointDataForSstRestore(c, mgr.GetDomain(), se, checkpoint.SnapshotRestoreCheckpointDatabaseName)
-				if err != nil {
-					log.Warn("failed to remove checkpoint data for snapshot restore", zap.Error(err))
-				}
-			} else {
-				err = checkpoint.RemoveCheckpointDataForSstRestore(c, mgr.GetDomain(), se, checkpoint.SnapshotRestoreCheckpointDatabaseName)
-				if err != nil {
-					log.Warn("failed to remove checkpoint data for snapshot restore", zap.Error(err))
-				}
-			}
-			log.Info("all the checkpoint data is removed.")
+	// the token is finished before the checkpoint data is removed, so the retry is either skipped or
+	// resumed from the checkpoint.
+	if cfg.IdempotencyToken != "" {
+		if err := finishRestoreToken(c, g, mgr.GetStorage(), cfg, tokenOwner); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	removeCheckpointData(c, g, mgr, cmdName, cfg)
+	if IsStreamRestore(cmdName) && cfg.Follow {
+		return errors.Trace(followLogBackup(c, g, mgr, cmdName, &followCfg, cfg.RestoreTS))
+	}
+	return nil
+}
+
+// restoreTokenFingerprint identifies the restore by the command, the backups to restore and the tables
+// filtered.
+func restoreTokenFingerprint(cmdName string, cfg *RestoreConfig) string {
+	fields := []string{cmdName, cfg.Storage, cfg.FullBackupStorage, strconv.FormatUint(cfg.RestoreTS, 10)}
+	for _, filter := range cfg.FilterStr {
+		fields = append(fields, "filter="+filter)
+	}
+	for _, db := range slices.Sorted(maps.Keys(cfg.Schemas)) {
+		fields = append(fields, "db="+db)
+	}
+	for _, table := range slices.Sorted(maps.Keys(cfg.Tables)) {
+		fields = append(fields, "table="+table)
+	}
+	h := sha256.New()
+	for _, field := range fields {
+		h.Write([]byte(field))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// acquireRestoreToken records the restore token as running and owned by this run of the restore, it
+// returns true if the restore with the token is finished already. The token of an unfinished restore is
+// taken over, and only one of the runs acquiring the token at the same time succeeds.
+func acquireRestoreToken(
+	c context.Context,
+	g glue.Glue,
+	store kv.Storage,
+	cfg *RestoreConfig,
+	fingerprint string,
+) (finished bool, owner string, err error) {
+	se, err := g.CreateSession(store)
+	if err != nil {
+		return false, "", errors.Trace(err)
+	}
+	defer se.Close()
+	if err := checkpoint.InitRestoreTokenTable(c, se); err != nil {
+		return false, "", errors.Trace(err)
+	}
+	owner = uuid.NewString()
+	if err := checkpoint.InsertRestoreToken(c, se, &checkpoint.RestoreToken{
+		Token:       cfg.IdempotencyToken,
+		Fingerprint: fingerprint,
+		Status:      checkpoint.RestoreTokenRunning,
+		Owner:       owner,
+	}); err != nil {
+		return false, "", errors.Trace(err)
+	}
+	execCtx := se.GetSessionCtx().GetRestrictedSQLExecutor()
+	token, err := checkpoint.LoadRestoreToken(c, execCtx, cfg.IdempotencyToken)
+	if err != nil {
+		return false, "", errors.Trace(err)
+	}
+	if token == nil {
+		return false, "", errors.Annotatef(berrors.ErrInvalidArgument,
+			"the token %s is deleted by another restore", cfg.IdempotencyToken)
+	}
+	if token.Fingerprint != fingerprint {
+		return false, "", errors.Annotatef(berrors.ErrInvalidArgument,
+			"the token %s is used by another restore", cfg.IdempotencyToken)
+	}
+	if token.Status == checkpoint.RestoreTokenFinished {
+		return true, "", nil
+	}
+	if token.Owner != owner {
+		log.Info("retry the unfinished restore with the token", zap.String("token", cfg.IdempotencyToken))
+		if err := checkpoint.TakeOverRestoreToken(c, se, cfg.IdempotencyToken, token.Owner, owner); err != nil {
+			return false, "", errors.Trace(err)
+		}
+		if token, err = checkpoint.LoadRestoreToken(c, execCtx, cfg.IdempotencyToken); err != nil {
+			return false, "", errors.Trace(err)
+		}
+		if token == nil || token.Owner != owner {
+			return false, "", errors.Annotatef(berrors.ErrInvalidArgument,
+				"the token %s is acquired by another restore at the same time", cfg.IdempotencyToken)
 		}
 	}
+	if !cfg.UseCheckpoint {
+		log.Warn("the checkpoint is disabled, the data files may be ingested again if the restore is retried",
+			zap.String("token", cfg.IdempotencyToken))
+	}
+	return false, owner, nil
+}
+
+// finishRestoreToken finishes the restore token owned by this run of the restore, and deletes the tokens
+// finished before the retention.
+func finishRestoreToken(c context.Context, g glue.Glue, store kv.Storage, cfg *RestoreConfig, owner string) error {
+	se, err := g.CreateSession(store)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer se.Close()
+	if err := checkpoint.FinishRestoreToken(c, se, cfg.IdempotencyToken, owner); err != nil {
+		return errors.Trace(err)
+	}
+	token, err := checkpoint.LoadRestoreToken(c, se.GetSessionCtx().GetRestrictedSQLExecutor(), cfg.IdempotencyToken)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if token == nil || token.Owner != owner || token.Status != checkpoint.RestoreTokenFinished {
+		return errors.Annotatef(berrors.ErrInvalidArgument,
+			"the token %s is taken over by another restore", cfg.IdempotencyToken)
+	}
+	if err := checkpoint.DeleteExpiredRestoreTokens(c, se, checkpoint.RestoreTokenRetention); err != nil {
+		log.Warn("failed to delete the expired restore tokens", zap.Error(err))
+	}
 	return nil
 }
 
-func runSnapshotRestore(c context.Context, mgr *conn.Mgr, g glue.Glue, cmdName string, cfg *RestoreConfig, checkInfo *PiTRTaskInfo) error {
+// removeCheckpointData clears the checkpoint data after the restore finished.
+func removeCheckpointData(c context.Context, g glue.Glue, mgr *conn.Mgr, cmdName string, cfg *RestoreConfig) {
+	if !cfg.UseCheckpoint {
+		return
+	}
+	se, err := g.CreateSession(mgr.GetStorage())
+	if err != nil {
+		log.Warn("failed to remove checkpoint data", zap.Error(err))
+		return
+	}
+	if IsStreamRestore(cmdName) {
+		log.Info("start to remove checkpoint data for PITR restore")
+		err = checkpoint.RemoveCheckpointDataForLogRestore(c, mgr.GetDomain(), se)
+		if err != nil {
+			log.Warn("failed to remove checkpoint data for log restore", zap.Error(err))
+		}
+		err = checkpoint.RemoveCheckpointDataForSstRestore(c, mgr.GetDomain(), se, checkpoint.CustomSSTRestoreCheckpointDatabaseName)
+		if err != nil {
+			log.Warn("failed to remove checkpoint data for compacted restore", zap.Error(err))
+		}
+		err = checkpoint.RemoveCheckpointDataForSstRestore(c, mgr.GetDomain(), se, checkpoint.SnapshotRestoreCheckpointDatabaseName)
+		if err != nil {
+			log.Warn("failed to remove checkpoint data for snapshot restore", zap.Error(err))
+		}
+	} else {
+		err = checkpoint.RemoveCheckpointDataForSstRestore(c, mgr.GetDomain(), se, checkpoint.SnapshotRestoreCheckpointDatabaseName)
+		if err != nil {
+			log.Warn("failed to remove checkpoint data for snapshot restore", zap.Error(err))
+		}
+	}
+	log.Info("all the checkpoint data is removed.")
+}
+
+func runSnapshotRestore(c context.Context, mgr *conn.Mgr, g glue.Glue, cmdName string, cfg *RestoreConfig, checkInfo *PiTRTaskInfo) (err error) {
 	cfg.Adjust()
 	defer summary.Summary(cmdName)
+	defer cfg.collectStorageRequestStats()
+	// the cleanup runs after the other deferred functions, such as flushing the checkpoint.
+	cleaner := &restoreCleaner{}
+	canceled := func() bool { return err != nil && c.Err() != nil }
+	defer func() {
+		if canceled() {
+			cleaner.cleanupAndReport(g)
+		}
+	}()
 	ctx, cancel := context.WithCancel(c)
 	defer cancel()
 
@@ -779,7 +1796,7 @@ func runSnapshotRestore(c context.Context, mgr *conn.Mgr, g glue.Glue, cmdName s
 	cfg.ConcurrencyPerStore = kvConfigs.ImportGoroutines
 	// using tikv config to set the concurrency-per-store for client.
 	client.SetConcurrencyPerStore(cfg.ConcurrencyPerStore.Value)
-	err := configureRestoreClient(ctx, client, cfg)
+	err = configureRestoreClient(ctx, client, cfg)
 	if err != nil {
 		return errors.Trace(err)
 	}
@@ -790,9 +1807,23 @@ func runSnapshotRestore(c context.Context, mgr *conn.Mgr, g glue.Glue, cmdName s
 	if err != nil {
 		return errors.Trace(err)
 	}
-	u, s, backupMeta, err := ReadBackupMeta(ctx, metautil.MetaFile, &cfg.Config)
-	if err != nil {
-		return errors.Trace(err)
+	var (
+		u            *backuppb.StorageBackend
+		s            storage.ExternalStorage
+		backupMeta   *backuppb.BackupMeta
+		importBackup *importadapter.Backup
+	)
+	if cfg.ImportFormat != "" {
+		u, s, importBackup, err = readImportBackup(ctx, g, mgr.GetStorage(), cfg)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		backupMeta = importBackup.Meta
+	} else {
+		u, s, backupMeta, err = ReadBackupMeta(ctx, metautil.MetaFile, &cfg.Config)
+		if err != nil {
+			return errors.Trace(err)
+		}
 	}
 	if cfg.CheckRequirements {
 		err := checkIncompatibleChangefeed(ctx, backupMeta.EndVersion, mgr.GetDomain().GetEtcdClient())
@@ -812,6 +1843,27 @@ func runSnapshotRestore(c context.Context, mgr *conn.Mgr, g glue.Glue, cmdName s
 	if _, err = CheckNewCollationEnable(backupMeta.GetNewCollationsEnabled(), g, mgr.GetStorage(), cfg.CheckRequirements); err != nil {
 		return errors.Trace(err)
 	}
+	if err = checkRestoreCluster(restoreClusterCheck{
+		Backup:     "snapshot backup",
+		Upstream:   backupMeta.ClusterId,
+		Downstream: mgr.GetPDClient().GetClusterID(ctx),
+	}, cfg.AllowCrossCluster); err != nil {
+		return errors.Trace(err)
+	}
+
+	if cfg.Engine == snapclient.EngineLightningLocal {
+		if err := checkLightningLocalEngine(cfg, backupMeta); err != nil {
+			return errors.Trace(err)
+		}
+		closeEngine, err := setupLightningLocalEngine(ctx, client, cfg, s)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		defer closeEngine()
+		if importBackup != nil {
+			client.SetLightningLocalKVReader(importBackup)
+		}
+	}
 
 	reader := metautil.NewMetaReader(backupMeta, s, &cfg.CipherInfo)
 	if err = client.LoadSchemaIfNeededAndInitClient(c, backupMeta, u, reader, cfg.LoadStats, nil, nil); err != nil {
@@ -840,6 +1892,28 @@ func runSnapshotRestore(c context.Context, mgr *conn.Mgr, g glue.Glue, cmdName s
 	if len(dbs) == 0 && len(tables) != 0 {
 		return errors.Annotate(berrors.ErrRestoreInvalidBackup, "contain tables but no databases")
 	}
+	if len(cfg.Sanitizers) > 0 {
+		if err := client.CheckSanitizers(tables); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	if cfg.SchemaOnly {
+		log.Info("schema-only restore, skip restoring the data", zap.Int("skipped file count", len(files)))
+		files = nil
+	}
+	if len(cfg.TenantFilters) > 0 {
+		tenants, err := snapclient.ParseTenantFilters(cfg.TenantFilters)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if err := snapclient.FilterTenantFiles(tables, tenants); err != nil {
+			return errors.Trace(err)
+		}
+		files = nil
+		for _, table := range tables {
+			files = append(files, table.Files...)
+		}
+	}
 
 	if cfg.CheckRequirements && checkpointFirstRun {
 		if err := checkDiskSpace(ctx, mgr, files, tables); err != nil {
@@ -862,13 +1936,44 @@ func runSnapshotRestore(c context.Context, mgr *conn.Mgr, g glue.Glue, cmdName s
 	}
 
 	importModeSwitcher := restore.NewImportModeSwitcher(mgr.GetPDClient(), cfg.Config.SwitchModeInterval, mgr.GetTLSConfig())
-	restoreSchedulers, schedulersConfig, err := restore.RestorePreWork(ctx, mgr, importModeSwitcher, cfg.Online, true)
+	// no need to switch to the import mode or remove the schedulers if no data is ingested.
+	skipPreWork := cfg.Online || cfg.SchemaOnly
+	// pause the schedulers only for the regions of the restored tables, so that the other tables are still
+	// balanced in the shared cluster.
+	var keyRangePauser *pdutil.KeyRangeSchedulerPauser
+	if cfg.PausePDSchedulerScope == pausePDSchedulerScopeTable && !skipPreWork {
+		if mgr.CanPauseSchedulerByKeyRange() {
+			keyRangePauser = pdutil.NewKeyRangeSchedulerPauser(mgr.GetPDHTTPClient())
+			defer keyRangePauser.ResumeAll()
+		} else {
+			log.Warn("the cluster doesn't support pausing the schedulers by key range, pause them globally")
+		}
+	}
+	var (
+		restoreSchedulers pdutil.UndoFunc
+		schedulersConfig  *pdutil.ClusterConfig
+	)
+	if keyRangePauser != nil {
+		restoreSchedulers = pdutil.Nop
+		err = importModeSwitcher.GoSwitchToImportMode(ctx)
+	} else {
+		restoreSchedulers, schedulersConfig, err = restore.RestorePreWork(ctx, mgr, importModeSwitcher, skipPreWork, true)
+	}
 	if err != nil {
 		return errors.Trace(err)
 	}
 
+	if !skipPreWork {
+		cleaner.register("resume the pd schedulers", func(ctx context.Context) error {
+			return multierr.Combine(importModeSwitcher.SwitchToNormalMode(ctx), restoreSchedulers(ctx))
+		})
+	}
 	schedulersRemovable := false
 	defer func() {
+		if canceled() {
+			log.Info("the restore is canceled, the pd schedulers are resumed by the cleanup")
+			return
+		}
 		// don't reset pd scheduler if checkpoint mode is used and restored is not finished
 		if cfg.UseCheckpoint && !schedulersRemovable {
 			log.Info("skip removing pd schehduler for next retry")
@@ -877,12 +1982,13 @@ func runSnapshotRestore(c context.Context, mgr *conn.Mgr, g glue.Glue, cmdName s
 		log.Info("start to remove the pd scheduler")
 		// run the post-work to avoid being stuck in the import
 		// mode or emptied schedulers.
-		restore.RestorePostWork(ctx, importModeSwitcher, restoreSchedulers, cfg.Online)
+		restore.RestorePostWork(ctx, importModeSwitcher, restoreSchedulers, skipPreWork)
 		log.Info("finish removing pd scheduler")
 	}()
 
 	if isFullRestore(cmdName) {
-		if client.NeedCheckFreshCluster(cfg.ExplicitFilter, checkpointFirstRun) {
+		// the tables are created by the user if no schema is restored.
+		if client.NeedCheckFreshCluster(cfg.ExplicitFilter, checkpointFirstRun) && !cfg.NoSchema {
 			if err = client.CheckTargetClusterFresh(ctx); err != nil {
 				return errors.Trace(err)
 			}
@@ -894,7 +2000,7 @@ func runSnapshotRestore(c context.Context, mgr *conn.Mgr, g glue.Glue, cmdName s
 		if cfg.WithSysTable {
 			client.InitFullClusterRestore(cfg.ExplicitFilter)
 		}
-	} else if client.IsFull() && checkpointFirstRun && cfg.CheckRequirements {
+	} else if client.IsFull() && checkpointFirstRun && cfg.CheckRequirements && !cfg.NoSchema {
 		if err := checkTableExistence(ctx, mgr, tables, g); err != nil {
 			schedulersRemovable = true
 			return errors.Trace(err)
@@ -908,14 +2014,24 @@ func runSnapshotRestore(c context.Context, mgr *conn.Mgr, g glue.Glue, cmdName s
 	}
 
 	// preallocate the table id, because any ddl job or database creation(include checkpoint) also allocates the global ID
-	err = client.AllocTableIDs(ctx, tables)
-	if err != nil {
-		return errors.Trace(err)
+	if !cfg.NoSchema {
+		err = client.AllocTableIDs(ctx, tables)
+		if err != nil {
+			return errors.Trace(err)
+		}
 	}
 
 	// reload or register the checkpoint
 	var checkpointSetWithTableID map[int64]map[string]struct{}
 	if cfg.UseCheckpoint {
+		if checkpointFirstRun {
+			// record what the restore creates before creating anything, so that it can be rolled back.
+			rollbackInfo, err := collectRestoreRollbackInfo(ctx, mgr, client, cmdName, dbs, tables, cfg.Online)
+			if err != nil {
+				return errors.Trace(err)
+			}
+			client.SetRollbackInfo(rollbackInfo)
+		}
 		sets, restoreSchedulersConfigFromCheckpoint, err := client.InitCheckpoint(ctx, g, mgr.GetStorage(), schedulersConfig, checkpointFirstRun)
 		if err != nil {
 			return errors.Trace(err)
@@ -940,27 +2056,20 @@ func runSnapshotRestore(c context.Context, mgr *conn.Mgr, g glue.Glue, cmdName s
 	}
 	g.Record("BackupTS", backupMeta.EndVersion)
 	g.Record("RestoreTS", restoreTS)
-	cctx, gcSafePointKeeperCancel := context.WithCancel(ctx)
-	defer func() {
-		log.Info("start to remove gc-safepoint keeper")
-		// close the gc safe point keeper at first
-		gcSafePointKeeperCancel()
-		// set the ttl to 0 to remove the gc-safe-point
-		sp.TTL = 0
-		if err := utils.UpdateServiceSafePoint(ctx, mgr.GetPDClient(), sp); err != nil {
-			log.Warn("failed to update service safe point, backup may fail if gc triggered",
-				zap.Error(err),
-			)
-		}
-		log.Info("finish removing gc-safepoint keeper")
-	}()
 	// restore checksum will check safe point with its start ts, see details at
 	// https://github.com/pingcap/tidb/blob/180c02127105bed73712050594da6ead4d70a85f/store/tikv/kv.go#L186-L190
 	// so, we should keep the safe point unchangeable. to avoid GC life time is shorter than transaction duration.
-	err = utils.StartServiceSafePointKeeper(cctx, mgr.GetPDClient(), sp)
+	releaseGCSafePoint, err := registerServiceSafePoint(ctx, mgr.GetPDClient(), &cfg.Config, cmdName, sp)
 	if err != nil {
 		return errors.Trace(err)
 	}
+	defer func() {
+		if err := releaseGCSafePoint(ctx, false); err != nil {
+			log.Warn("failed to update service safe point, backup may fail if gc triggered",
+				zap.Error(err),
+			)
+		}
+	}()
 
 	ddlJobs := FilterDDLJobs(client.GetDDLJobs(), tables)
 	ddlJobs = FilterDDLJobByRules(ddlJobs, DDLJobBlockListRule)
@@ -1016,6 +2125,11 @@ func runSnapshotRestore(c context.Context, mgr *conn.Mgr, g glue.Glue, cmdName s
 		return nil
 	}
 
+	if cfg.UseCheckpoint {
+		cleaner.note("drop the restored tables", "skipped, kept for resuming from the checkpoint")
+	} else {
+		cleaner.register("drop the restored tables", dropNewTablesFunc(g, mgr, dbs, tables))
+	}
 	if err = client.CreateDatabases(ctx, dbs); err != nil {
 		return errors.Trace(err)
 	}
@@ -1034,6 +2148,8 @@ func runSnapshotRestore(c context.Context, mgr *conn.Mgr, g glue.Glue, cmdName s
 		}
 	}
 
+	// the tables restored by the earlier run or the base backup aren't empty.
+	client.SetCheckExistingTablesEmpty(cfg.NoSchema && cfg.CheckRequirements && checkpointFirstRun && !client.IsIncremental())
 	createdTables, err := client.CreateTables(ctx, tables, newTS)
 	if err != nil {
 		return errors.Trace(err)
@@ -1088,6 +2204,14 @@ func runSnapshotRestore(c context.Context, mgr *conn.Mgr, g glue.Glue, cmdName s
 		}
 	}
 
+	if cfg.SchemaOnly {
+		// the statistics and the system tables are data as well, skip them too.
+		log.Info("schema-only restore finished", zap.Int("table count", len(createdTables)))
+		schedulersRemovable = true
+		summary.SetSuccessStatus(true)
+		return nil
+	}
+
 	// Split/Scatter + Download/Ingest
 	progressLen := int64(rangeSize + len(files))
 	if cfg.Checksum {
@@ -1103,6 +2227,17 @@ func runSnapshotRestore(c context.Context, mgr *conn.Mgr, g glue.Glue, cmdName s
 	if err != nil {
 		return errors.Trace(err)
 	}
+	cleaner.note("merge the split regions", "skipped, the empty regions are merged by pd")
+	if keyRangePauser != nil {
+		// the key ranges are paused before splitting and scattering the regions of the tables.
+		if err := pauseSchedulersOfTables(ctx, keyRangePauser, codec, createdTables); err != nil {
+			return errors.Trace(err)
+		}
+		log.Info("paused the pd schedulers of the tables", zap.Int("key ranges", keyRangePauser.Paused()))
+	}
+	if cfg.Online {
+		cleaner.register("remove the placement rules", placementRuleManager.ResetPlacementRules)
+	}
 	onProgress := func(n int64) {
 		if n == 0 {
 			updateCh.Inc()
@@ -1126,6 +2261,11 @@ func runSnapshotRestore(c context.Context, mgr *conn.Mgr, g glue.Glue, cmdName s
 	errCh := make(chan error, 32)
 	postHandleCh := afterTableRestoredCh(ctx, createdTables)
 
+	// pipeline rebase the auto ids of the tables after the rows encoded by BR are restored
+	if importBackup != nil {
+		postHandleCh = client.GoRebaseAutoIDs(ctx, postHandleCh, errCh, importBackup.AutoIDBases)
+	}
+
 	// pipeline checksum only when enabled and is not incremental snapshot repair mode cuz incremental doesn't have
 	// enough information in backup meta to validate checksum
 	if cfg.Checksum && !client.IsIncremental() {
@@ -1133,6 +2273,11 @@ func runSnapshotRestore(c context.Context, mgr *conn.Mgr, g glue.Glue, cmdName s
 			ctx, postHandleCh, mgr.GetStorage().GetClient(), errCh, updateCh, cfg.ChecksumConcurrency)
 	}
 
+	// pipeline filter rows after the checksum of the restored data
+	if len(cfg.RowFilters) > 0 {
+		postHandleCh = client.GoFilterRows(ctx, postHandleCh, errCh)
+	}
+
 	// pipeline update meta and load stats
 	postHandleCh = client.GoUpdateMetaAndLoadStats(ctx, s, postHandleCh, errCh, cfg.StatsConcurrency, cfg.LoadStats)
 
@@ -1141,6 +2286,11 @@ func runSnapshotRestore(c context.Context, mgr *conn.Mgr, g glue.Glue, cmdName s
 		postHandleCh = client.GoWaitTiFlashReady(ctx, postHandleCh, updateCh, errCh)
 	}
 
+	// resume the pd schedulers of the table once it is restored
+	if keyRangePauser != nil {
+		postHandleCh = resumeSchedulersAfterTableRestored(ctx, keyRangePauser, postHandleCh)
+	}
+
 	finish := dropToBlackhole(ctx, postHandleCh, errCh)
 
 	select {
@@ -1163,6 +2313,8 @@ func runSnapshotRestore(c context.Context, mgr *conn.Mgr, g glue.Glue, cmdName s
 
 	schedulersRemovable = true
 
+	reportTableStats(cfg.TableStatsReport, tableStatsPhaseSnapshot, aggregateTableStats(client.TableStats(), createdTables))
+
 	// Set task summary to success status.
 	summary.SetSuccessStatus(true)
 	return nil
@@ -1638,6 +2790,65 @@ func checkIsInActions(action model.ActionType, actions map[model.ActionType]stru
 	return ok
 }
 
+func physicalTableIDs(table *model.TableInfo) []int64 {
+	ids := []int64{table.ID}
+	if table.Partition != nil {
+		for _, def := range table.Partition.Definitions {
+			ids = append(ids, def.ID)
+		}
+	}
+	return ids
+}
+
+// pauseSchedulersOfTables pauses the pd schedulers for the regions of the physical tables at once.
+func pauseSchedulersOfTables(
+	ctx context.Context,
+	pauser *pdutil.KeyRangeSchedulerPauser,
+	keyCodec tikv.Codec,
+	tables []*snapclient.CreatedTable,
+) error {
+	var ranges []pdutil.PausedKeyRange
+	for _, table := range tables {
+		for _, id := range physicalTableIDs(table.Table) {
+			ranges = append(ranges, pdutil.PausedKeyRange{
+				ID:       strconv.FormatInt(id, 10),
+				StartKey: tidbcodec.EncodeBytes(nil, keyCodec.EncodeKey(tablecodec.EncodeTablePrefix(id))),
+				EndKey:   tidbcodec.EncodeBytes(nil, keyCodec.EncodeKey(tablecodec.EncodeTablePrefix(id+1))),
+			})
+		}
+	}
+	if len(ranges) == 0 {
+		return nil
+	}
+	return errors.Annotatef(pauser.Pause(ctx, ranges...), "failed to pause the pd schedulers of %d tables", len(tables))
+}
+
+// resumeSchedulersAfterTableRestored resumes the pd schedulers for the regions of the restored tables.
+func resumeSchedulersAfterTableRestored(
+	ctx context.Context,
+	pauser *pdutil.KeyRangeSchedulerPauser,
+	inCh <-chan *snapclient.CreatedTable,
+) <-chan *snapclient.CreatedTable {
+	outCh := make(chan *snapclient.CreatedTable)
+	go func() {
+		defer close(outCh)
+		for table := range inCh {
+			ids := physicalTableIDs(table.Table)
+			keys := make([]string, 0, len(ids))
+			for _, id := range ids {
+				keys = append(keys, strconv.FormatInt(id, 10))
+			}
+			pauser.Resume(keys...)
+			select {
+			case <-ctx.Done():
+				return
+			case outCh <- table:
+			}
+		}
+	}()
+	return outCh
+}
+
 func afterTableRestoredCh(ctx context.Context, createdTables []*snapclient.CreatedTable) <-chan *snapclient.CreatedTable {
 	outCh := make(chan *snapclient.CreatedTable)
 
@@ -0,0 +1,82 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package task
+
+import (
+	"context"
+
+	"github.com/pingcap/errors"
+	backuppb "github.com/pingcap/kvproto/pkg/brpb"
+	"github.com/pingcap/log"
+	berrors "github.com/pingcap/tidb/br/pkg/errors"
+	"github.com/pingcap/tidb/br/pkg/glue"
+	importadapter "github.com/pingcap/tidb/br/pkg/restore/import_adapter"
+	snapclient "github.com/pingcap/tidb/br/pkg/restore/snap_client"
+	"github.com/pingcap/tidb/br/pkg/storage"
+	"github.com/pingcap/tidb/br/pkg/utils"
+	"github.com/pingcap/tidb/pkg/kv"
+	"github.com/spf13/pflag"
+	"go.uber.org/zap"
+)
+
+const flagImportFormat = "import-format"
+
+// parseImportFormat parses the format of the data exported by another tool. The data is encoded into kvs
+// by BR, so it's imported by the `lightning-local` engine, and there is no checksum to validate.
+func (cfg *RestoreConfig) parseImportFormat(flags *pflag.FlagSet) error {
+	var err error
+	cfg.ImportFormat, err = flags.GetString(flagImportFormat)
+	if err != nil {
+		return errors.Annotatef(err, "failed to get flag %s", flagImportFormat)
+	}
+	if cfg.ImportFormat == "" {
+		return nil
+	}
+	if err := importadapter.CheckFormat(cfg.ImportFormat); err != nil {
+		return errors.Trace(err)
+	}
+	if flags.Changed(flagEngine) && cfg.Engine != snapclient.EngineLightningLocal {
+		return errors.Annotatef(berrors.ErrInvalidArgument, "--%s only supports the %s engine, but got %s",
+			flagImportFormat, snapclient.EngineLightningLocal, cfg.Engine)
+	}
+	cfg.Engine = snapclient.EngineLightningLocal
+	if cfg.Checksum {
+		log.Info("the checksum is disabled, because the data exported by another tool has no checksum",
+			zap.String("import-format", cfg.ImportFormat))
+		cfg.Checksum = false
+	}
+	return nil
+}
+
+// readImportBackup loads the data exported by another tool as a synthetic backup. The data has no
+// new_collations_enabled, so it's the same as the cluster's.
+func readImportBackup(
+	ctx context.Context,
+	g glue.Glue,
+	kvStore kv.Storage,
+	cfg *RestoreConfig,
+) (*backuppb.StorageBackend, storage.ExternalStorage, *importadapter.Backup, error) {
+	u, s, err := GetStorage(ctx, cfg.Storage, &cfg.Config)
+	if err != nil {
+		return nil, nil, nil, errors.Trace(err)
+	}
+	backup, err := importadapter.Load(ctx, s, cfg.ImportFormat)
+	if err != nil {
+		return nil, nil, nil, errors.Trace(err)
+	}
+	se, err := g.CreateSession(kvStore)
+	if err != nil {
+		return nil, nil, nil, errors.Trace(err)
+	}
+	defer se.Close()
+	newCollationEnable, err := se.GetGlobalVariable(utils.GetTidbNewCollationEnabled())
+	if err != nil {
+		return nil, nil, nil, errors.Trace(err)
+	}
+	backup.Meta.NewCollationsEnabled = newCollationEnable
+	log.Info("loaded the data exported by another tool",
+		zap.String("import-format", cfg.ImportFormat),
+		zap.Int("schemas", len(backup.Meta.Schemas)),
+		zap.Int("files", len(backup.Meta.Files)))
+	return u, s, backup, nil
+}
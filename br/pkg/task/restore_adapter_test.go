@@ -0,0 +1,81 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package task
+
+import (
+	"testing"
+
+	berrors "github.com/pingcap/tidb/br/pkg/errors"
+	snapclient "github.com/pingcap/tidb/br/pkg/restore/snap_client"
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseImportFormat(t *testing.T) {
+	parse := func(args ...string) (*RestoreConfig, error) {
+		flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+		DefineCommonFlags(flags)
+		DefineRestoreFlags(flags)
+		flags.StringArrayP(flagFilter, "f", []string{"*.*"}, "")
+		flags.Bool(flagCaseSensitive, false, "")
+		require.NoError(t, flags.Parse(args))
+		cfg := &RestoreConfig{}
+		return cfg, cfg.ParseFromFlags(flags, false)
+	}
+
+	cfg, err := parse("-s", "local:///backup")
+	require.NoError(t, err)
+	require.Empty(t, cfg.ImportFormat)
+	require.Equal(t, snapclient.EngineTiKV, cfg.Engine)
+	require.True(t, cfg.Checksum)
+
+	// the data exported by another tool is imported by the lightning-local engine without the checksum.
+	cfg, err = parse("-s", "local:///dump", "--import-format", "dumpling")
+	require.NoError(t, err)
+	require.Equal(t, "dumpling", cfg.ImportFormat)
+	require.Equal(t, snapclient.EngineLightningLocal, cfg.Engine)
+	require.False(t, cfg.Checksum)
+
+	cfg, err = parse("-s", "local:///dump", "--import-format", "auto", "--engine", "lightning-local")
+	require.NoError(t, err)
+	require.Equal(t, snapclient.EngineLightningLocal, cfg.Engine)
+
+	_, err = parse("-s", "local:///dump", "--import-format", "parquet", "--engine", "tikv")
+	require.ErrorIs(t, err, berrors.ErrInvalidArgument)
+	require.ErrorContains(t, err, "only supports the lightning-local engine")
+	_, err = parse("-s", "local:///dump", "--import-format", "csv")
+	require.ErrorIs(t, err, berrors.ErrInvalidArgument)
+	require.ErrorContains(t, err, "should be one of auto, dumpling, parquet")
+}
+
+func TestParseSanitizeFlags(t *testing.T) {
+	parse := func(args ...string) (*RestoreConfig, error) {
+		flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+		DefineCommonFlags(flags)
+		DefineRestoreFlags(flags)
+		flags.StringArrayP(flagFilter, "f", []string{"*.*"}, "")
+		flags.Bool(flagCaseSensitive, false, "")
+		require.NoError(t, flags.Parse(args))
+		cfg := &RestoreConfig{}
+		return cfg, cfg.ParseFromFlags(flags, false)
+	}
+	key := "000102030405060708090a0b0c0d0e0f"
+
+	cfg, err := parse("-s", "local:///backup", "--engine", "lightning-local", "--sanitize", "db.t.c: hash",
+		"--sanitize-key", key)
+	require.NoError(t, err)
+	require.Len(t, cfg.SanitizeKey, 16)
+	// the data exported by another tool is imported by the lightning-local engine.
+	cfg, err = parse("-s", "local:///dump", "--import-format", "dumpling", "--sanitize", "db.t.c: null")
+	require.NoError(t, err)
+	require.Empty(t, cfg.SanitizeKey)
+
+	_, err = parse("-s", "local:///backup", "--sanitize", "db.t.c: hash", "--sanitize-key", key)
+	require.ErrorContains(t, err, "--sanitize requires --engine lightning-local")
+	_, err = parse("-s", "local:///backup", "--engine", "lightning-local", "--sanitize", "db.t.c: hash")
+	require.ErrorContains(t, err, "exactly one of cipher key or keyfile path should be provided")
+	_, err = parse("-s", "local:///backup", "--engine", "lightning-local", "--sanitize", "db.t.c: hash",
+		"--sanitize-key", key[:16])
+	require.ErrorIs(t, err, berrors.ErrInvalidArgument)
+	require.ErrorContains(t, err, "should be at least 16 bytes")
+}
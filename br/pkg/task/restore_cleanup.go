@@ -0,0 +1,106 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package task
+
+import (
+	"context"
+	"time"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/log"
+	"github.com/pingcap/tidb/br/pkg/conn"
+	"github.com/pingcap/tidb/br/pkg/glue"
+	"github.com/pingcap/tidb/br/pkg/metautil"
+	"go.uber.org/zap"
+)
+
+// restoreCleanupTimeout is the timeout of cleaning up the canceled restore, the context of the restore
+// is canceled so the cleanup runs in a new one.
+const restoreCleanupTimeout = 5 * time.Minute
+
+type restoreCleanupStep struct {
+	name string
+	// note is reported as is if the step has nothing to do but tell the user about the leftover.
+	note string
+	fn   func(ctx context.Context) error
+}
+
+// restoreCleanupResult is the result of a cleanup step in the report.
+type restoreCleanupResult struct {
+	name   string
+	result string
+	err    error
+}
+
+// restoreCleaner rolls back the changes of the restore if it is canceled, say, by Ctrl-C, so that
+// the cluster isn't left with the paused schedulers, the temporary placement rules and the partially
+// restored tables.
+type restoreCleaner struct {
+	steps []restoreCleanupStep
+}
+
+// register registers a step that rolls back a change of the restore.
+func (c *restoreCleaner) register(name string, fn func(ctx context.Context) error) {
+	c.steps = append(c.steps, restoreCleanupStep{name: name, fn: fn})
+}
+
+// note registers a leftover that isn't rolled back, it is shown in the report.
+func (c *restoreCleaner) note(name, note string) {
+	c.steps = append(c.steps, restoreCleanupStep{name: name, note: note})
+}
+
+// cleanup runs the steps in the reverse order of the registration and returns the report.
+func (c *restoreCleaner) cleanup() []restoreCleanupResult {
+	ctx, cancel := context.WithTimeout(context.Background(), restoreCleanupTimeout)
+	defer cancel()
+
+	results := make([]restoreCleanupResult, 0, len(c.steps))
+	for i := len(c.steps) - 1; i >= 0; i-- {
+		step := c.steps[i]
+		if step.fn == nil {
+			results = append(results, restoreCleanupResult{name: step.name, result: step.note})
+			continue
+		}
+		if err := step.fn(ctx); err != nil {
+			log.Warn("failed to clean up the canceled restore", zap.String("step", step.name), zap.Error(err))
+			results = append(results, restoreCleanupResult{name: step.name, result: "failed: " + err.Error(), err: err})
+			continue
+		}
+		log.Info("cleaned up the canceled restore", zap.String("step", step.name))
+		results = append(results, restoreCleanupResult{name: step.name, result: "done"})
+	}
+	return results
+}
+
+// cleanupAndReport cleans up the canceled restore and prints the report.
+func (c *restoreCleaner) cleanupAndReport(g glue.Glue) {
+	if len(c.steps) == 0 {
+		return
+	}
+	console := glue.GetConsole(g)
+	console.Println("the restore is canceled, cleaning up...")
+	table := console.CreateTable()
+	for _, result := range c.cleanup() {
+		table.Add(result.name, result.result)
+	}
+	table.Print()
+}
+
+// dropNewTablesFunc returns the function dropping the databases and tables to restore that don't exist
+// for now, i.e. the ones created by the restore.
+func dropNewTablesFunc(
+	g glue.Glue,
+	mgr *conn.Mgr,
+	dbs []*metautil.Database,
+	tables []*metautil.Table,
+) func(ctx context.Context) error {
+	info := newRestoreRollbackInfo(mgr.GetDomain().InfoSchema(), dbs, tables, nil)
+	return func(ctx context.Context) error {
+		se, err := g.CreateSession(mgr.GetStorage())
+		if err != nil {
+			return errors.Trace(err)
+		}
+		defer se.Close()
+		return dropRollbackObjects(ctx, se, info)
+	}
+}
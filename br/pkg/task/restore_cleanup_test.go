@@ -0,0 +1,36 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package task
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pingcap/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRestoreCleaner(t *testing.T) {
+	cleaner := &restoreCleaner{}
+	order := make([]string, 0)
+	cleaner.register("resume", func(ctx context.Context) error {
+		require.NoError(t, ctx.Err())
+		order = append(order, "resume")
+		return nil
+	})
+	cleaner.note("merge", "skipped")
+	cleaner.register("drop", func(context.Context) error {
+		order = append(order, "drop")
+		return errors.New("the table is locked")
+	})
+
+	results := cleaner.cleanup()
+	// the steps run in the reverse order, and the failed step doesn't stop the others.
+	require.Equal(t, []string{"drop", "resume"}, order)
+	require.Len(t, results, 3)
+	require.Equal(t, "drop", results[0].name)
+	require.Error(t, results[0].err)
+	require.Equal(t, "failed: the table is locked", results[0].result)
+	require.Equal(t, restoreCleanupResult{name: "merge", result: "skipped"}, results[1])
+	require.Equal(t, restoreCleanupResult{name: "resume", result: "done"}, results[2])
+}
@@ -0,0 +1,63 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package task
+
+import (
+	"github.com/pingcap/errors"
+	"github.com/pingcap/log"
+	berrors "github.com/pingcap/tidb/br/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// restoreClusterCheck is what a restore path checks about the clusters the backups come from.
+type restoreClusterCheck struct {
+	// Backup is the name of the backup in the messages, e.g. "snapshot backup".
+	Backup string
+	// Upstream is the cluster the backup comes from, 0 if the backup doesn't record it.
+	Upstream uint64
+	// Downstream is the target cluster of the restore.
+	Downstream uint64
+	// InPlace is set if the restore replays the backup of the target cluster onto itself, such as restoring
+	// the dropped tables, so the backup of another cluster is never restored.
+	InPlace bool
+}
+
+// checkRestoreCluster is the policy of the cluster ID shared by the restore paths. The backup of another
+// cluster is only restored with --allow-cross-cluster, or ALLOW_CROSS_CLUSTER = 1 of the RESTORE statement, and
+// never by the in-place restore. The backups taken
+// by the old BR don't record the cluster ID, they're restored with a warning.
+func checkRestoreCluster(check restoreClusterCheck, allowCrossCluster bool) error {
+	fields := []zap.Field{zap.String("backup", check.Backup),
+		zap.Uint64("upstream", check.Upstream), zap.Uint64("downstream", check.Downstream)}
+	switch {
+	case check.Upstream == 0:
+		log.Warn("the backup doesn't record the cluster it comes from, skip checking the cluster", fields...)
+		return nil
+	case check.Upstream == check.Downstream:
+		return nil
+	case check.InPlace:
+		return errors.Annotatef(berrors.ErrRestoreClusterMismatch,
+			"the %s comes from the cluster %d, but it can only be restored in place to the cluster it comes from, "+
+				"the target cluster is %d", check.Backup, check.Upstream, check.Downstream)
+	case !allowCrossCluster:
+		return errors.Annotatef(berrors.ErrRestoreClusterMismatch,
+			"the %s comes from the cluster %d instead of the target cluster %d, "+
+				"please specify --%s (or ALLOW_CROSS_CLUSTER = 1 of the RESTORE statement) "+
+				"if it's restored to another cluster on purpose",
+			check.Backup, check.Upstream, check.Downstream, flagAllowCrossCluster)
+	default:
+		log.Info("restore the backup of another cluster", fields...)
+		return nil
+	}
+}
+
+// checkBackupsFromSameCluster checks the snapshot backup and the log backup restored together come from the
+// same cluster, it's required regardless of --allow-cross-cluster.
+func checkBackupsFromSameCluster(snapshotCluster, logCluster uint64) error {
+	if snapshotCluster > 0 && logCluster > 0 && snapshotCluster != logCluster {
+		return errors.Annotatef(berrors.ErrRestoreClusterMismatch,
+			"the full snapshot(from cluster ID:%v) and log(from cluster ID:%v) come from different cluster.",
+			snapshotCluster, logCluster)
+	}
+	return nil
+}
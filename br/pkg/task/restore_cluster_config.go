@@ -0,0 +1,162 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package task
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/log"
+	"github.com/pingcap/tidb/br/pkg/conn"
+	berrors "github.com/pingcap/tidb/br/pkg/errors"
+	"github.com/pingcap/tidb/br/pkg/glue"
+	"github.com/pingcap/tidb/br/pkg/httputil"
+	"github.com/pingcap/tidb/br/pkg/metautil"
+	"github.com/pingcap/tidb/br/pkg/summary"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	pdhttp "github.com/tikv/pd/client/http"
+	"go.uber.org/zap"
+)
+
+const (
+	flagApplyClusterConfig = "apply"
+
+	// RestoreClusterConfigCmd is the name of `br restore config`.
+	RestoreClusterConfigCmd = "Restore Cluster Config"
+)
+
+// RestoreClusterConfigConfig is the config for `br restore config`.
+type RestoreClusterConfigConfig struct {
+	RestoreConfig
+
+	// Apply applies the config in the backup to the target cluster, otherwise they're only compared.
+	Apply bool `json:"apply" toml:"apply"`
+	// DryRun reports the config items to be applied by Apply without applying them.
+	DryRun bool `json:"dry-run" toml:"dry-run"`
+}
+
+// DefineRestoreClusterConfigFlags defines flags for `br restore config`.
+func DefineRestoreClusterConfigFlags(command *cobra.Command) {
+	command.Flags().Bool(flagDryRun, false, "with --"+flagApplyClusterConfig+", only report the config items to be "+
+		"applied to the target cluster without applying them")
+	command.Flags().Bool(flagApplyClusterConfig, false, "apply the cluster config in the backup to the target cluster, "+
+		"the store labels and the TiKV config items can't be changed online are only reported")
+}
+
+// ParseFromFlags parses the config from the flag set.
+func (cfg *RestoreClusterConfigConfig) ParseFromFlags(flags *pflag.FlagSet) error {
+	if err := cfg.RestoreConfig.ParseFromFlags(flags, false); err != nil {
+		return errors.Trace(err)
+	}
+	var err error
+	if cfg.DryRun, err = flags.GetBool(flagDryRun); err != nil {
+		return errors.Trace(err)
+	}
+	if cfg.Apply, err = flags.GetBool(flagApplyClusterConfig); err != nil {
+		return errors.Trace(err)
+	}
+	if cfg.DryRun && !cfg.Apply {
+		return errors.Annotatef(berrors.ErrInvalidArgument, "--%s requires --%s", flagDryRun, flagApplyClusterConfig)
+	}
+	return nil
+}
+
+// mgrClusterConfigApplier applies the cluster config items by the PD and TiKV of the mgr.
+type mgrClusterConfigApplier struct {
+	mgr *conn.Mgr
+}
+
+func (a mgrClusterConfigApplier) SetScheduleConfig(ctx context.Context, config map[string]any) error {
+	return a.mgr.GetPDHTTPClient().SetScheduleConfig(ctx, config)
+}
+
+func (a mgrClusterConfigApplier) SetPlacementRuleBundles(
+	ctx context.Context,
+	bundles []*pdhttp.GroupBundle,
+	partial bool,
+) error {
+	return a.mgr.GetPDHTTPClient().SetPlacementRuleBundles(ctx, bundles, partial)
+}
+
+func (a mgrClusterConfigApplier) SetTiKVConfig(ctx context.Context, items map[string]any) error {
+	return a.mgr.SetConfigOfTiKV(ctx, httputil.NewClient(a.mgr.GetTLSConfig()), items)
+}
+
+// RunRestoreClusterConfig compares the cluster config saved alongside the backup with the target cluster,
+// and applies them with --apply.
+func RunRestoreClusterConfig(c context.Context, g glue.Glue, cmdName string, cfg *RestoreClusterConfigConfig) error {
+	g = withResourceGroup(g, cfg.ResourceGroup)
+	cfg.Adjust()
+	defer summary.Summary(cmdName)
+	ctx, cancel := context.WithCancel(c)
+	defer cancel()
+
+	_, s, err := GetStorage(ctx, cfg.Storage, &cfg.Config)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	backup, exists, err := metautil.ReadClusterConfig(ctx, s, &cfg.CipherInfo)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if !exists {
+		return errors.Annotatef(berrors.ErrInvalidArgument,
+			"the backup in %s has no cluster config, it's taken by an old BR or with --%s=false",
+			s.URI(), flagBackupClusterConfig)
+	}
+	mgr, err := NewMgr(ctx, g, cfg.PD, cfg.TLS, GetKeepalive(&cfg.Config), cfg.CheckRequirements, false, conn.NormalVersionChecker)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer mgr.Close()
+	target, err := captureClusterConfig(ctx, mgr, 0)
+	if err != nil {
+		return errors.Annotate(err, "failed to get the config of the target cluster")
+	}
+
+	diffs := diffClusterConfig(backup, target)
+	var errs map[clusterConfigKind]error
+	apply := cfg.Apply && !cfg.DryRun
+	if apply {
+		errs = applyClusterConfig(ctx, mgrClusterConfigApplier{mgr: mgr}, backup, diffs)
+	}
+	console := glue.GetConsole(g)
+	report := console.CreateTable()
+	defer report.Print()
+	applicable := 0
+	for _, diff := range diffs {
+		if diff.Applicable {
+			applicable++
+		}
+		status := fmt.Sprintf("backup: %s, target: %s", diff.Backup, diff.Target)
+		switch {
+		case !diff.Applicable && diff.Reason != "":
+			status += ", can't be applied: " + diff.Reason
+		case !diff.Applicable:
+			status += ", can't be applied online"
+		case !cfg.Apply:
+			status += ", can be applied with --" + flagApplyClusterConfig
+		case cfg.DryRun:
+			status += ", to be applied"
+		case errs[diff.Kind] != nil:
+			status += ", failed: " + errs[diff.Kind].Error()
+		default:
+			status += ", applied"
+		}
+		log.Info("the cluster config differs", zap.String("kind", string(diff.Kind)), zap.String("item", diff.Item),
+			zap.String("backup", diff.Backup), zap.String("target", diff.Target), zap.Bool("applicable", diff.Applicable))
+		report.Add(fmt.Sprintf("%s %s", diff.Kind, diff.Item), status)
+	}
+	for _, kind := range []clusterConfigKind{clusterConfigPDSchedule, clusterConfigPlacementRules, clusterConfigTiKV} {
+		if err := errs[kind]; err != nil {
+			return errors.Annotatef(err, "failed to apply the %s config, %d kinds of the config failed", kind, len(errs))
+		}
+	}
+
+	summary.Log(cmdName, zap.Uint64("backup-cluster-id", backup.ClusterID), zap.Uint64("backup-ts", backup.BackupTS),
+		zap.Int("differences", len(diffs)), zap.Int("applicable", applicable), zap.Bool("applied", apply))
+	summary.SetSuccessStatus(true)
+	return nil
+}
@@ -0,0 +1,47 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package task
+
+import (
+	"testing"
+
+	berrors "github.com/pingcap/tidb/br/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckRestoreCluster(t *testing.T) {
+	cases := []struct {
+		check             restoreClusterCheck
+		allowCrossCluster bool
+		err               string
+	}{
+		{check: restoreClusterCheck{Upstream: 1, Downstream: 1}},
+		{check: restoreClusterCheck{Upstream: 1, Downstream: 1, InPlace: true}},
+		// the old backups without the cluster ID are restored anywhere.
+		{check: restoreClusterCheck{Upstream: 0, Downstream: 2}},
+		{check: restoreClusterCheck{Upstream: 0, Downstream: 2, InPlace: true}},
+		{
+			check: restoreClusterCheck{Backup: "snapshot backup", Upstream: 1, Downstream: 2},
+			err:   "please specify --allow-cross-cluster",
+		},
+		{check: restoreClusterCheck{Upstream: 1, Downstream: 2}, allowCrossCluster: true},
+		{
+			check:             restoreClusterCheck{Backup: "log backup", Upstream: 1, Downstream: 2, InPlace: true},
+			allowCrossCluster: true,
+			err:               "can only be restored in place",
+		},
+	}
+	for i, c := range cases {
+		err := checkRestoreCluster(c.check, c.allowCrossCluster)
+		if c.err == "" {
+			require.NoError(t, err, i)
+			continue
+		}
+		require.ErrorIs(t, err, berrors.ErrRestoreClusterMismatch, i)
+		require.ErrorContains(t, err, c.err, i)
+	}
+
+	require.NoError(t, checkBackupsFromSameCluster(1, 1))
+	require.NoError(t, checkBackupsFromSameCluster(0, 1))
+	require.ErrorIs(t, checkBackupsFromSameCluster(1, 2), berrors.ErrRestoreClusterMismatch)
+}
@@ -0,0 +1,563 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package task
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/log"
+	"github.com/pingcap/tidb/br/pkg/conn"
+	"github.com/pingcap/tidb/br/pkg/encryption"
+	berrors "github.com/pingcap/tidb/br/pkg/errors"
+	"github.com/pingcap/tidb/br/pkg/glue"
+	"github.com/pingcap/tidb/br/pkg/restore"
+	logclient "github.com/pingcap/tidb/br/pkg/restore/log_client"
+	"github.com/pingcap/tidb/br/pkg/stream"
+	"github.com/pingcap/tidb/br/pkg/summary"
+	"github.com/pingcap/tidb/br/pkg/utils"
+	"github.com/pingcap/tidb/pkg/kv"
+	"github.com/pingcap/tidb/pkg/meta/model"
+	"github.com/pingcap/tidb/pkg/parser/ast"
+	filter "github.com/pingcap/tidb/pkg/util/table-filter"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"go.uber.org/zap"
+)
+
+const (
+	flagDroppedTableName     = "name"
+	flagDroppedTableBeforeTS = "before-ts"
+
+	// RestoreDroppedTableCmd is the name of `br restore dropped-table`.
+	RestoreDroppedTableCmd = "Restore Dropped Table"
+)
+
+// RestoreDroppedTableConfig is the config for `br restore dropped-table`.
+type RestoreDroppedTableConfig struct {
+	RestoreConfig
+
+	DB    string `json:"db" toml:"db"`
+	Table string `json:"table" toml:"table"`
+	// BeforeTS is the ts the table is dropped before, the last drop is restored if it's zero.
+	BeforeTS uint64 `json:"before-ts" toml:"before-ts"`
+}
+
+// DefineRestoreDroppedTableFlags defines flags for `br restore dropped-table`.
+func DefineRestoreDroppedTableFlags(command *cobra.Command) {
+	command.Flags().String(flagDroppedTableName, "", "The dropped table to restore, in the form of 'db.table'")
+	command.Flags().String(flagDroppedTableBeforeTS, "", "Restore the table dropped before this ts, "+
+		"the last drop is restored if not set. "+
+		"support TSO or datetime, e.g. '400036290571534337' or '2018-05-11 01:42:23+0800'")
+	command.Flags().String(FlagStreamFullBackupStorage, "", "The snapshot backup containing the table, "+
+		"only the data written in the log backup is restored if not set")
+	command.Flags().Uint32(FlagPiTRBatchCount, defaultPiTRBatchCount, "specify the batch count to restore log.")
+	command.Flags().Uint32(FlagPiTRBatchSize, defaultPiTRBatchSize, "specify the batch size to retore log.")
+	command.Flags().Uint32(FlagPiTRConcurrency, defaultPiTRConcurrency, "specify the concurrency to restore log.")
+	_ = command.MarkFlagRequired(flagDroppedTableName)
+}
+
+// ParseFromFlags parses the config from the flag set.
+func (cfg *RestoreDroppedTableConfig) ParseFromFlags(flags *pflag.FlagSet) error {
+	if err := cfg.RestoreConfig.ParseFromFlags(flags, false); err != nil {
+		return errors.Trace(err)
+	}
+	name, err := flags.GetString(flagDroppedTableName)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	var ok bool
+	cfg.DB, cfg.Table, ok = strings.Cut(name, ".")
+	if !ok || cfg.DB == "" || cfg.Table == "" {
+		return errors.Annotatef(berrors.ErrInvalidArgument, "--%s should be in the form of `db.table`, but got %q",
+			flagDroppedTableName, name)
+	}
+	tsString, err := flags.GetString(flagDroppedTableBeforeTS)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if cfg.BeforeTS, err = ParseTSString(tsString, true); err != nil {
+		return errors.Trace(err)
+	}
+	if cfg.FullBackupStorage, err = flags.GetString(FlagStreamFullBackupStorage); err != nil {
+		return errors.Trace(err)
+	}
+	if cfg.PitrBatchCount, err = flags.GetUint32(FlagPiTRBatchCount); err != nil {
+		return errors.Trace(err)
+	}
+	if cfg.PitrBatchSize, err = flags.GetUint32(FlagPiTRBatchSize); err != nil {
+		return errors.Trace(err)
+	}
+	if cfg.PitrConcurrency, err = flags.GetUint32(FlagPiTRConcurrency); err != nil {
+		return errors.Trace(err)
+	}
+
+	// only the dropped table is restored from the snapshot backup and the log backup.
+	cfg.TableFilter = filter.CaseInsensitive(filter.NewTablesFilter(filter.Table{Schema: cfg.DB, Name: cfg.Table}))
+	cfg.Schemas = map[string]struct{}{utils.EncloseName(cfg.DB): {}}
+	cfg.Tables = map[string]struct{}{utils.EncloseDBAndTable(cfg.DB, cfg.Table): {}}
+	cfg.ExplicitFilter = true
+	return nil
+}
+
+// droppedTable is the table found to be dropped in the log backup.
+type droppedTable struct {
+	dbID    int64
+	tableID int64
+	// info is the last table info before the drop.
+	info   *model.TableInfo
+	dropTS uint64
+	// snapshotInfo is the table info in the snapshot backup, nil if the table isn't in it.
+	snapshotInfo *model.TableInfo
+}
+
+// locateDroppedTable finds the last drop of the table named db.table at or before beforeTS, zero beforeTS
+// means unlimited. Both of the events are sorted by the ts, as the ones returned by searchSchema.
+func locateDroppedTable(
+	db, table string,
+	beforeTS uint64,
+	snapshotEvents []stream.SchemaEvent,
+	logEvents []stream.SchemaEvent,
+	dbNames map[int64]string,
+) (*droppedTable, error) {
+	type state struct {
+		dbID         int64
+		info         *model.TableInfo
+		snapshotInfo *model.TableInfo
+	}
+	states := make(map[int64]*state)
+	var found *droppedTable
+
+	events := make([]stream.SchemaEvent, 0, len(snapshotEvents)+len(logEvents))
+	inSnapshot := make([]bool, 0, cap(events))
+	i, j := 0, 0
+	for i < len(snapshotEvents) || j < len(logEvents) {
+		if j >= len(logEvents) || (i < len(snapshotEvents) && snapshotEvents[i].TS <= logEvents[j].TS) {
+			events, inSnapshot = append(events, snapshotEvents[i]), append(inSnapshot, true)
+			i++
+			continue
+		}
+		events, inSnapshot = append(events, logEvents[j]), append(inSnapshot, false)
+		j++
+	}
+
+	for k, event := range events {
+		if beforeTS > 0 && event.TS > beforeTS {
+			break
+		}
+		st, ok := states[event.TableID]
+		if !ok {
+			st = &state{}
+			states[event.TableID] = st
+		}
+		if event.Info != nil {
+			st.dbID, st.info = event.DBID, event.Info
+			if inSnapshot[k] {
+				st.snapshotInfo = event.Info
+			}
+			continue
+		}
+
+		// the table moved to another database is dropped and put at the same ts.
+		moved := false
+		for _, next := range events[k+1:] {
+			if next.TS != event.TS {
+				break
+			}
+			if next.TableID == event.TableID && next.Info != nil {
+				moved = true
+				break
+			}
+		}
+		if !moved && st.info != nil && strings.EqualFold(st.info.Name.O, table) &&
+			strings.EqualFold(dbNames[st.dbID], db) {
+			found = &droppedTable{dbID: st.dbID, tableID: event.TableID, info: st.info, dropTS: event.TS,
+				snapshotInfo: st.snapshotInfo}
+		}
+		if !moved {
+			delete(states, event.TableID)
+		}
+	}
+	if found == nil {
+		return nil, errors.Annotatef(berrors.ErrInvalidArgument,
+			"no drop of %s is found in the log backup", utils.EncloseDBAndTable(db, table))
+	}
+	return found, nil
+}
+
+// sameTableLayout returns whether the columns, the indexes and the partitions of the tables are the same,
+// so that the data written with one of them can be read by the other.
+func sameTableLayout(a, b *model.TableInfo) bool {
+	if len(a.Columns) != len(b.Columns) || len(a.Indices) != len(b.Indices) {
+		return false
+	}
+	for i, col := range a.Columns {
+		if col.ID != b.Columns[i].ID || col.Name.L != b.Columns[i].Name.L {
+			return false
+		}
+	}
+	for i, index := range a.Indices {
+		if index.ID != b.Indices[i].ID || index.Name.L != b.Indices[i].Name.L {
+			return false
+		}
+	}
+	partitionIDs := func(info *model.TableInfo) []int64 {
+		if info.GetPartitionInfo() == nil {
+			return nil
+		}
+		ids := make([]int64, 0, len(info.GetPartitionInfo().Definitions))
+		for _, def := range info.GetPartitionInfo().Definitions {
+			ids = append(ids, def.ID)
+		}
+		return ids
+	}
+	aIDs, bIDs := partitionIDs(a), partitionIDs(b)
+	if len(aIDs) != len(bIDs) {
+		return false
+	}
+	for i := range aIDs {
+		if aIDs[i] != bIDs[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// newDroppedTableReplace maps the IDs of the dropped table to the restored one, the partitions and the
+// indexes are matched by the name.
+func newDroppedTableReplace(dropped *droppedTable, dbName string, dbID int64, restored *model.TableInfo,
+) (map[stream.UpstreamID]*stream.DBReplace, error) {
+	tableReplace := stream.NewTableReplace(dropped.info.Name.O, restored.ID)
+	if partitions := dropped.info.GetPartitionInfo(); partitions != nil {
+		if restored.GetPartitionInfo() == nil {
+			return nil, errors.Annotatef(berrors.ErrRestoreSchemaNotExists,
+				"the restored table %s isn't partitioned", dropped.info.Name.O)
+		}
+		for _, def := range partitions.Definitions {
+			newID := restored.GetPartitionInfo().GetPartitionIDByName(def.Name.L)
+			if newID == -1 {
+				return nil, errors.Annotatef(berrors.ErrRestoreSchemaNotExists,
+					"the partition %s isn't restored", def.Name.O)
+			}
+			tableReplace.PartitionMap[def.ID] = newID
+		}
+	}
+	for _, index := range dropped.info.Indices {
+		if newIndex := restored.FindIndexByName(index.Name.L); newIndex != nil {
+			tableReplace.IndexMap[index.ID] = newIndex.ID
+		}
+	}
+	dbReplace := stream.NewDBReplace(dbName, dbID)
+	dbReplace.TableMap[dropped.tableID] = tableReplace
+	return map[stream.UpstreamID]*stream.DBReplace{dropped.dbID: dbReplace}, nil
+}
+
+// RunRestoreDroppedTable restores a single dropped table into the current cluster without the full PiTR.
+// The table is created by the last table info before the drop, or restored from the snapshot backup if it
+// exists in it, and then the data of the table in the log backup is restored until the drop.
+func RunRestoreDroppedTable(c context.Context, g glue.Glue, cmdName string, cfg *RestoreDroppedTableConfig) error {
+	g = withResourceGroup(g, cfg.ResourceGroup)
+	cfg.Adjust()
+	defer summary.Summary(cmdName)
+	ctx, cancel := context.WithCancel(c)
+	defer cancel()
+
+	searched, err := searchSchema(ctx, &cfg.Config, cfg.FullBackupStorage, cfg.Table, 0, cfg.BeforeTS)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	dropped, err := locateDroppedTable(cfg.DB, cfg.Table, cfg.BeforeTS,
+		searched.snapshotEvents, searched.log.Events, searched.dbNames)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	dbName := searched.dbNames[dropped.dbID]
+	log.Info("found the dropped table", zap.String("db", dbName), zap.String("table", dropped.info.Name.O),
+		zap.Int64("table-id", dropped.tableID), zap.Uint64("drop-ts", dropped.dropTS),
+		zap.Bool("in-snapshot", dropped.snapshotInfo != nil))
+
+	_, s, err := GetStorage(ctx, cfg.Storage, &cfg.Config)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	logInfo, err := getLogRangeWithStorage(ctx, s)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	cfg.upstreamClusterID = logInfo.clusterID
+	cfg.StartTS = logInfo.logMinTS
+	if dropped.snapshotInfo != nil {
+		if !strings.EqualFold(dropped.snapshotInfo.Name.O, dropped.info.Name.O) ||
+			!sameTableLayout(dropped.snapshotInfo, dropped.info) {
+			return errors.Annotatef(berrors.ErrInvalidArgument,
+				"the table %s is changed after the snapshot backup, please restore it by `br restore point`",
+				utils.EncloseDBAndTable(dbName, dropped.info.Name.O))
+		}
+		cfg.StartTS = searched.snapshotTS
+	} else {
+		log.Warn("the table isn't in the snapshot backup, only the data written in the log backup is restored",
+			zap.Uint64("log-min-ts", logInfo.logMinTS))
+	}
+	// the data of the table is removed by the gc after the drop, so all the data before the drop is restored.
+	cfg.RestoreTS = dropped.dropTS - 1
+	if err := checkLogRange(cfg.StartTS, cfg.RestoreTS, logInfo.logMinTS, logInfo.logMaxTS); err != nil {
+		return errors.Trace(err)
+	}
+
+	mgr, err := NewMgr(ctx, g, cfg.PD, cfg.TLS, GetKeepalive(&cfg.Config), cfg.CheckRequirements, true, conn.NormalVersionChecker)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer mgr.Close()
+	if err := checkRestoreCluster(restoreClusterCheck{
+		Backup:     "log backup",
+		Upstream:   logInfo.clusterID,
+		Downstream: mgr.GetPDClient().GetClusterID(ctx),
+		InPlace:    true,
+	}, cfg.AllowCrossCluster); err != nil {
+		return errors.Trace(err)
+	}
+	if mgr.GetDomain().InfoSchema().TableExists(ast.NewCIStr(dbName), dropped.info.Name) {
+		return errors.Annotatef(berrors.ErrInvalidArgument, "the table %s already exists",
+			utils.EncloseDBAndTable(dbName, dropped.info.Name.O))
+	}
+
+	if dropped.snapshotInfo != nil {
+		snapshotCfg := cfg.RestoreConfig
+		snapshotCfg.Storage = cfg.FullBackupStorage
+		snapshotCfg.FullBackupStorage = ""
+		snapshotCfg.StartTS, snapshotCfg.RestoreTS = 0, 0
+		// the snapshot backup is restored in place as well.
+		snapshotCfg.AllowCrossCluster = false
+		if err := RunRestore(ctx, g, TableRestoreCmd, &snapshotCfg); err != nil {
+			return errors.Annotate(err, "failed to restore the table from the snapshot backup")
+		}
+	} else if err := createDroppedTable(ctx, g, mgr, dbName, dropped.info); err != nil {
+		return errors.Trace(err)
+	}
+	if err := mgr.GetDomain().Reload(); err != nil {
+		return errors.Trace(err)
+	}
+	is := mgr.GetDomain().InfoSchema()
+	dbInfo, ok := is.SchemaByName(ast.NewCIStr(dbName))
+	if !ok {
+		return errors.Annotatef(berrors.ErrRestoreSchemaNotExists, "the database %s isn't restored", dbName)
+	}
+	restored, err := is.TableByName(ctx, dbInfo.Name, dropped.info.Name)
+	if err != nil {
+		return errors.Annotatef(err, "the table %s isn't restored", dropped.info.Name.O)
+	}
+	dbMap, err := newDroppedTableReplace(dropped, dbName, dbInfo.ID, restored.Meta())
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	cfg.adjustRestoreConfigForStreamRestore()
+	if err := restoreDroppedTableData(ctx, mgr, g, &cfg.RestoreConfig, dbMap); err != nil {
+		return errors.Trace(err)
+	}
+	if err := rebaseDroppedTableAutoID(ctx, g, mgr, dbName, restored.Meta()); err != nil {
+		return errors.Trace(err)
+	}
+
+	glue.GetConsole(g).Printf("the table %s dropped at %d is restored with the new ID %d\n",
+		utils.EncloseDBAndTable(dbName, dropped.info.Name.O), dropped.dropTS, restored.Meta().ID)
+	summary.Log("restored the dropped table, please run `ADMIN CHECK TABLE` to check its data and indexes",
+		zap.String("db", dbName), zap.String("table", dropped.info.Name.O),
+		zap.Int64("old-id", dropped.tableID), zap.Int64("new-id", restored.Meta().ID))
+	return nil
+}
+
+// createDroppedTable creates the table by the table info in the log backup. The TiFlash replicas, the
+// placement policies and the TTL aren't restored.
+func createDroppedTable(ctx context.Context, g glue.Glue, mgr *conn.Mgr, dbName string, info *model.TableInfo) error {
+	info = info.Clone()
+	info.TiFlashReplica = nil
+	info.PlacementPolicyRef = nil
+	if partitions := info.GetPartitionInfo(); partitions != nil {
+		for i := range partitions.Definitions {
+			partitions.Definitions[i].PlacementPolicyRef = nil
+		}
+	}
+	if info.TTLInfo != nil {
+		info.TTLInfo.Enable = false
+	}
+	return g.UseOneShotSession(mgr.GetStorage(), false, func(se glue.Session) error {
+		if err := se.ExecuteInternal(ctx, "CREATE DATABASE IF NOT EXISTS %n", dbName); err != nil {
+			return errors.Annotatef(err, "failed to create the database %s", dbName)
+		}
+		if err := se.CreateTable(ctx, ast.NewCIStr(dbName), info); err != nil {
+			return errors.Annotatef(err, "failed to create the table %s", info.Name.O)
+		}
+		return nil
+	})
+}
+
+// restoreDroppedTableData restores the compacted sst files and the kv files of the tables in the dbMap
+// from cfg.StartTS to cfg.RestoreTS, the meta kv files aren't restored.
+func restoreDroppedTableData(
+	ctx context.Context,
+	mgr *conn.Mgr,
+	g glue.Glue,
+	cfg *RestoreConfig,
+	dbMap map[stream.UpstreamID]*stream.DBReplace,
+) error {
+	var (
+		totalKVCount uint64
+		totalSize    uint64
+		mu           sync.Mutex
+	)
+	restoreCfg := tweakLocalConfForRestore()
+	defer restoreCfg()
+
+	client, err := createRestoreClient(ctx, g, cfg, mgr)
+	if err != nil {
+		return errors.Annotate(err, "failed to create restore client")
+	}
+	defer client.Close(ctx)
+	currentTS, err := restore.GetTSWithRetry(ctx, mgr.GetPDClient())
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if err := client.SetCurrentTS(currentTS); err != nil {
+		return errors.Trace(err)
+	}
+
+	importModeSwitcher := restore.NewImportModeSwitcher(mgr.GetPDClient(), cfg.Config.SwitchModeInterval, mgr.GetTLSConfig())
+	restoreSchedulers, _, err := restore.RestorePreWork(ctx, mgr, importModeSwitcher, cfg.Online, false)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer restore.RestorePostWork(ctx, importModeSwitcher, restoreSchedulers, cfg.Online)
+
+	restoreGc, oldRatio, err := KeepGcDisabled(g, mgr.GetStorage())
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer func() {
+		if strings.HasPrefix(oldRatio, "-") {
+			log.Warn("the original gc-ratio is negative, reset by default value 1.1", zap.String("old-gc-ratio", oldRatio))
+			oldRatio = utils.DefaultGcRatioVal
+		}
+		if err := restoreGc(oldRatio); err != nil {
+			log.Error("failed to set gc enabled", zap.Error(err))
+		}
+	}()
+
+	encryptionManager, err := encryption.NewManager(&cfg.LogBackupCipherInfo, &cfg.MasterKeyConfig)
+	if err != nil {
+		return errors.Annotate(err, "failed to create encryption manager for log restore")
+	}
+	defer encryptionManager.Close()
+	if err := client.InstallLogFileManager(ctx, cfg.StartTS, cfg.RestoreTS, cfg.MetadataDownloadBatchSize,
+		encryptionManager); err != nil {
+		return errors.Trace(err)
+	}
+	migs, err := client.GetMigrations(ctx)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	client.BuildMigrations(migs)
+
+	schemasReplace := stream.NewSchemasReplace(dbMap, nil, client.CurrentTS(), cfg.TableFilter, client.RecordDeleteRange)
+	rewriteRules, err := schemasReplace.DataRewriteRules()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	updateStats := func(kvCount uint64, size uint64) {
+		mu.Lock()
+		defer mu.Unlock()
+		totalKVCount += kvCount
+		totalSize += size
+	}
+
+	se, err := g.CreateSession(mgr.GetStorage())
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer se.Close()
+	execCtx := se.GetSessionCtx().GetRestrictedSQLExecutor()
+	splitSize, splitKeys := utils.GetRegionSplitInfo(execCtx)
+
+	tableIDs := make(map[int64]struct{}, len(rewriteRules))
+	for tableID := range rewriteRules {
+		tableIDs[tableID] = struct{}{}
+	}
+	pd := g.StartProgress(ctx, "Restore Files(SST + KV)", logclient.TotalEntryCount, !cfg.LogProgress)
+	err = withProgress(pd, func(p glue.Progress) error {
+		compactedSplitIter, err := client.WrapCompactedFilesIterWithSplitHelper(
+			ctx, client.LogFileManager.GetCompactionIterOfTables(ctx, tableIDs), rewriteRules, nil, updateStats,
+			splitSize, splitKeys)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if err := client.RestoreCompactedSstFiles(ctx, compactedSplitIter, rewriteRules, importModeSwitcher, p.IncBy); err != nil {
+			return errors.Trace(err)
+		}
+
+		logFilesIter, err := client.LoadDMLFilesOfTables(ctx, tableIDs, p.IncBy)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		logFilesIterWithSplit, err := client.WrapLogFilesIterWithSplitHelper(ctx, logFilesIter, execCtx, rewriteRules,
+			updateStats, splitSize, splitKeys)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		return client.RestoreKVFiles(ctx, rewriteRules, logFilesIterWithSplit,
+			cfg.PitrBatchCount, cfg.PitrBatchSize, updateStats, p.IncBy, &cfg.LogBackupCipherInfo, cfg.MasterKeyConfig.MasterKeys)
+	})
+	if err != nil {
+		return errors.Annotate(err, "failed to restore kv files")
+	}
+	if err := client.CleanUpKVFiles(ctx); err != nil {
+		return errors.Annotate(err, "failed to clean up")
+	}
+	log.Info("restored the data of the dropped table", zap.Uint64("start-ts", cfg.StartTS),
+		zap.Uint64("restore-ts", cfg.RestoreTS), zap.Uint64("total-kv-count", totalKVCount),
+		zap.Uint64("total-size", totalSize))
+	return nil
+}
+
+// rebaseDroppedTableAutoID rebases the auto ID of the restored table after the restored rows, since the
+// auto ID allocated before the drop isn't restored with the data.
+func rebaseDroppedTableAutoID(ctx context.Context, g glue.Glue, mgr *conn.Mgr, dbName string, info *model.TableInfo) error {
+	if info.ContainsAutoRandomBits() {
+		log.Warn("the auto random base isn't rebased, please rebase it by `ALTER TABLE ... AUTO_RANDOM_BASE`",
+			zap.String("table", info.Name.O))
+		return nil
+	}
+	columns := make([]string, 0, 2)
+	if col := info.GetAutoIncrementColInfo(); col != nil {
+		columns = append(columns, col.Name.O)
+	}
+	if !info.PKIsHandle && !info.IsCommonHandle {
+		columns = append(columns, model.ExtraHandleName.O)
+	}
+	if len(columns) == 0 {
+		return nil
+	}
+	return g.UseOneShotSession(mgr.GetStorage(), false, func(se glue.Session) error {
+		var maxID int64
+		for _, col := range columns {
+			rows, _, err := se.GetSessionCtx().GetRestrictedSQLExecutor().ExecRestrictedSQL(
+				kv.WithInternalSourceType(ctx, kv.InternalTxnBR),
+				nil,
+				"SELECT IFNULL(MAX(%n), 0) FROM %n.%n",
+				col, dbName, info.Name.O,
+			)
+			if err != nil {
+				return errors.Annotatef(err, "failed to get the max %s of %s", col, info.Name.O)
+			}
+			maxID = max(maxID, rows[0].GetInt64(0))
+		}
+		if err := se.ExecuteInternal(ctx, "ALTER TABLE %n.%n AUTO_INCREMENT = %?", dbName, info.Name.O, maxID+1); err != nil {
+			return errors.Annotatef(err, "failed to rebase the auto id of %s", info.Name.O)
+		}
+		log.Info("rebased the auto id of the restored table", zap.String("table", info.Name.O),
+			zap.Int64("auto-id", maxID+1))
+		return nil
+	})
+}
@@ -0,0 +1,78 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package task
+
+import (
+	"testing"
+
+	"github.com/pingcap/tidb/br/pkg/stream"
+	"github.com/pingcap/tidb/pkg/meta/model"
+	"github.com/pingcap/tidb/pkg/parser/ast"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocateDroppedTable(t *testing.T) {
+	newInfo := func(id int64, name string) *model.TableInfo {
+		return &model.TableInfo{
+			ID:      id,
+			Name:    ast.NewCIStr(name),
+			Columns: []*model.ColumnInfo{{ID: 1, Name: ast.NewCIStr("id")}},
+		}
+	}
+	dbNames := map[int64]string{1: "test", 2: "archive"}
+	snapshot := []stream.SchemaEvent{{TS: 10, DBID: 1, TableID: 100, Info: newInfo(100, "t1")}}
+	events := []stream.SchemaEvent{
+		// the table is moved to another database, and then moved back and dropped.
+		{TS: 20, DBID: 1, TableID: 100},
+		{TS: 20, DBID: 2, TableID: 100, Info: newInfo(100, "t1")},
+		{TS: 30, DBID: 2, TableID: 100},
+		{TS: 30, DBID: 1, TableID: 100, Info: newInfo(100, "t1")},
+		{TS: 40, DBID: 1, TableID: 100},
+		// the table is created again, and then dropped.
+		{TS: 50, DBID: 1, TableID: 104, Info: newInfo(104, "t1")},
+		{TS: 60, DBID: 1, TableID: 104},
+	}
+
+	dropped, err := locateDroppedTable("TEST", "T1", 0, snapshot, events, dbNames)
+	require.NoError(t, err)
+	require.Equal(t, int64(104), dropped.tableID)
+	require.Equal(t, uint64(60), dropped.dropTS)
+	require.Nil(t, dropped.snapshotInfo)
+
+	dropped, err = locateDroppedTable("test", "t1", 55, snapshot, events, dbNames)
+	require.NoError(t, err)
+	require.Equal(t, int64(100), dropped.tableID)
+	require.Equal(t, int64(1), dropped.dbID)
+	require.Equal(t, uint64(40), dropped.dropTS)
+	require.NotNil(t, dropped.snapshotInfo)
+
+	// the moves aren't drops.
+	_, err = locateDroppedTable("test", "t1", 35, snapshot, events, dbNames)
+	require.ErrorContains(t, err, "no drop of `test`.`t1` is found")
+	_, err = locateDroppedTable("archive", "t1", 0, snapshot, events, dbNames)
+	require.Error(t, err)
+}
+
+func TestSameTableLayout(t *testing.T) {
+	newInfo := func() *model.TableInfo {
+		return &model.TableInfo{
+			Columns: []*model.ColumnInfo{{ID: 1, Name: ast.NewCIStr("id")}, {ID: 2, Name: ast.NewCIStr("v")}},
+			Indices: []*model.IndexInfo{{ID: 1, Name: ast.NewCIStr("idx")}},
+			Partition: &model.PartitionInfo{Enable: true, Definitions: []model.PartitionDefinition{
+				{ID: 101, Name: ast.NewCIStr("p0")},
+			}},
+		}
+	}
+	a, b := newInfo(), newInfo()
+	require.True(t, sameTableLayout(a, b))
+
+	b.Columns = append(b.Columns, &model.ColumnInfo{ID: 3, Name: ast.NewCIStr("w")})
+	require.False(t, sameTableLayout(a, b))
+	b = newInfo()
+	b.Indices[0].ID = 2
+	require.False(t, sameTableLayout(a, b))
+	// the truncated partition has a new ID.
+	b = newInfo()
+	b.Partition.Definitions[0].ID = 102
+	require.False(t, sameTableLayout(a, b))
+}
@@ -120,11 +120,9 @@ func (h *restoreEBSMetaHelper) preRestore(ctx context.Context) error {
 	}
 
 	securityOption := pd.SecurityOption{}
-	if h.cfg.TLS.IsEnabled() {
-		securityOption.CAPath = h.cfg.TLS.CA
-		securityOption.CertPath = h.cfg.TLS.Cert
-		securityOption.KeyPath = h.cfg.TLS.Key
-		tlsConf, err = h.cfg.TLS.ToTLSConfig()
+	if pdTLS := h.cfg.TLS.ForPD(); pdTLS.IsEnabled() {
+		securityOption = pdTLS.ToPDSecurityOption()
+		tlsConf, err = pdTLS.ToTLSConfig()
 		if err != nil {
 			return errors.Trace(err)
 		}
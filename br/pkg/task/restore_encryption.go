@@ -0,0 +1,118 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package task
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/log"
+	pconfig "github.com/pingcap/tidb/br/pkg/config"
+	"github.com/pingcap/tidb/br/pkg/conn"
+	berrors "github.com/pingcap/tidb/br/pkg/errors"
+	"github.com/pingcap/tidb/br/pkg/httputil"
+	"github.com/pingcap/tidb/br/pkg/logutil"
+	"github.com/pingcap/tidb/br/pkg/summary"
+	"github.com/pingcap/tidb/br/pkg/utils"
+	"go.uber.org/zap"
+)
+
+// EncryptionAtRestPolicy is how the encryption at rest configured on the target TiKV stores is checked before
+// restoring. The backup files are decrypted by the stores with the keys of the backup, and the ingested data
+// is encrypted by the stores with their own data keys, so they needn't match. Only the configs of the stores
+// are checked, whether the stores can access their master keys isn't.
+type EncryptionAtRestPolicy string
+
+const (
+	// EncryptionAtRestReport reports the encryption at rest configured on every store, and warns if an
+	// encrypted backup is restored into the stores without encryption.
+	EncryptionAtRestReport EncryptionAtRestPolicy = "report"
+	// EncryptionAtRestRequire fails the restore unless every store is configured to encrypt the data at rest
+	// with the data keys protected by a master key.
+	EncryptionAtRestRequire EncryptionAtRestPolicy = "require"
+	// EncryptionAtRestSkip skips the check.
+	EncryptionAtRestSkip EncryptionAtRestPolicy = "skip"
+)
+
+// ParseEncryptionAtRestPolicy parses the EncryptionAtRestPolicy, the empty string is EncryptionAtRestReport.
+func ParseEncryptionAtRestPolicy(s string) (EncryptionAtRestPolicy, error) {
+	switch policy := EncryptionAtRestPolicy(strings.ToLower(s)); policy {
+	case "":
+		return EncryptionAtRestReport, nil
+	case EncryptionAtRestReport, EncryptionAtRestRequire, EncryptionAtRestSkip:
+		return policy, nil
+	default:
+		return "", errors.Annotatef(berrors.ErrInvalidArgument, "unknown encryption at rest policy %q, "+
+			"should be one of %q, %q and %q", s, EncryptionAtRestReport, EncryptionAtRestRequire, EncryptionAtRestSkip)
+	}
+}
+
+// isBackupEncrypted returns whether the snapshot backup or the log backup is encrypted.
+func isBackupEncrypted(cfg *Config) bool {
+	return utils.IsEffectiveEncryptionMethod(cfg.CipherInfo.CipherType) ||
+		utils.IsEffectiveEncryptionMethod(cfg.LogBackupCipherInfo.CipherType) ||
+		len(cfg.MasterKeyConfig.GetMasterKeys()) > 0
+}
+
+// checkEncryptionAtRestConfig checks the encryption at rest configured on the target TiKV stores before
+// restoring, it fails early if the stores don't meet the policy.
+func checkEncryptionAtRestConfig(ctx context.Context, mgr *conn.Mgr, policy EncryptionAtRestPolicy, cfg *Config) error {
+	if policy == EncryptionAtRestSkip {
+		return nil
+	}
+	encryptions, err := mgr.GetEncryptionAtRestOfTiKV(ctx, httputil.NewClient(mgr.GetTLSConfig()))
+	if err != nil {
+		if policy == EncryptionAtRestRequire {
+			return errors.Annotate(berrors.ErrRestoreEncryptionAtRest.Wrap(err),
+				"failed to get the encryption at rest of the TiKV stores")
+		}
+		log.Warn("failed to get the encryption at rest of the TiKV stores, skip checking it", logutil.ShortError(err))
+		return nil
+	}
+	return verifyEncryptionAtRest(encryptions, policy, isBackupEncrypted(cfg))
+}
+
+// verifyEncryptionAtRest reports the encryption at rest of every store, and verifies them by the policy.
+func verifyEncryptionAtRest(
+	encryptions map[uint64]pconfig.EncryptionAtRest,
+	policy EncryptionAtRestPolicy,
+	backupEncrypted bool,
+) error {
+	var plaintextStores, unprotectedStores []uint64
+	storeIDs := slices.Sorted(maps.Keys(encryptions))
+	for _, storeID := range storeIDs {
+		encryption := encryptions[storeID]
+		log.Info("the encryption at rest configured on the TiKV store", zap.Uint64("store-id", storeID),
+			zap.String("data-encryption-method", encryption.DataEncryptionMethod),
+			zap.String("master-key-type", encryption.MasterKeyType))
+		switch {
+		case !encryption.Enabled():
+			plaintextStores = append(plaintextStores, storeID)
+		case !encryption.DataKeysProtected():
+			unprotectedStores = append(unprotectedStores, storeID)
+		}
+	}
+	summary.CollectInt("stores encrypted at rest", len(storeIDs)-len(plaintextStores))
+
+	if policy == EncryptionAtRestRequire && len(plaintextStores)+len(unprotectedStores) > 0 {
+		return errors.Annotatef(berrors.ErrRestoreEncryptionAtRest,
+			"%d of %d TiKV stores don't meet --%s=%s, the stores without encryption: %v, "+
+				"the stores with the data keys in plaintext: %v", len(plaintextStores)+len(unprotectedStores),
+			len(storeIDs), flagEncryptionAtRest, EncryptionAtRestRequire, plaintextStores, unprotectedStores)
+	}
+	if len(plaintextStores) > 0 && backupEncrypted {
+		log.Warn("the encrypted backup is restored into the TiKV stores without encryption at rest, "+
+			"the restored data is stored in plaintext", zap.Uint64s("store-ids", plaintextStores))
+	} else if len(plaintextStores) > 0 && len(plaintextStores) < len(storeIDs) {
+		log.Warn("only some of the TiKV stores encrypt the data at rest, the restored data is stored in "+
+			"plaintext on the others", zap.Uint64s("store-ids", plaintextStores))
+	}
+	if len(unprotectedStores) > 0 {
+		log.Warn("the data keys of the TiKV stores aren't protected by a master key",
+			zap.Uint64s("store-ids", unprotectedStores))
+	}
+	return nil
+}
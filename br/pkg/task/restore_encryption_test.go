@@ -0,0 +1,58 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package task
+
+import (
+	"testing"
+
+	backuppb "github.com/pingcap/kvproto/pkg/brpb"
+	"github.com/pingcap/kvproto/pkg/encryptionpb"
+	pconfig "github.com/pingcap/tidb/br/pkg/config"
+	berrors "github.com/pingcap/tidb/br/pkg/errors"
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyEncryptionAtRest(t *testing.T) {
+	encrypted := pconfig.EncryptionAtRest{DataEncryptionMethod: "aes256-ctr", MasterKeyType: "kms"}
+	unprotected := pconfig.EncryptionAtRest{DataEncryptionMethod: "aes256-ctr", MasterKeyType: "plaintext"}
+	plaintext := pconfig.EncryptionAtRest{DataEncryptionMethod: "plaintext", MasterKeyType: "plaintext"}
+
+	all := map[uint64]pconfig.EncryptionAtRest{1: encrypted, 2: encrypted}
+	require.NoError(t, verifyEncryptionAtRest(all, EncryptionAtRestRequire, true))
+
+	mixed := map[uint64]pconfig.EncryptionAtRest{1: encrypted, 2: unprotected, 3: plaintext, 4: {}}
+	require.NoError(t, verifyEncryptionAtRest(mixed, EncryptionAtRestReport, true))
+	err := verifyEncryptionAtRest(mixed, EncryptionAtRestRequire, false)
+	require.ErrorIs(t, err, berrors.ErrRestoreEncryptionAtRest)
+	require.ErrorContains(t, err, "3 of 4 TiKV stores")
+	require.ErrorContains(t, err, "without encryption: [3 4]")
+	require.ErrorContains(t, err, "data keys in plaintext: [2]")
+}
+
+func TestParseEncryptionAtRestFlag(t *testing.T) {
+	parse := func(args ...string) (*RestoreCommonConfig, error) {
+		flags := pflag.NewFlagSet("restore", pflag.ContinueOnError)
+		DefineRestoreCommonFlags(flags)
+		require.NoError(t, flags.Parse(args))
+		cfg := &RestoreCommonConfig{}
+		return cfg, cfg.ParseFromFlags(flags)
+	}
+
+	cfg, err := parse()
+	require.NoError(t, err)
+	require.Equal(t, EncryptionAtRestReport, cfg.EncryptionAtRest)
+	cfg, err = parse("--encryption-at-rest-config", "Require")
+	require.NoError(t, err)
+	require.Equal(t, EncryptionAtRestRequire, cfg.EncryptionAtRest)
+	_, err = parse("--encryption-at-rest-config", "always")
+	require.ErrorContains(t, err, "unknown encryption at rest policy")
+
+	require.False(t, isBackupEncrypted(&Config{}))
+	require.True(t, isBackupEncrypted(&Config{
+		CipherInfo: backuppb.CipherInfo{CipherType: encryptionpb.EncryptionMethod_AES256_CTR},
+	}))
+	require.True(t, isBackupEncrypted(&Config{
+		MasterKeyConfig: backuppb.MasterKeyConfig{MasterKeys: []*encryptionpb.MasterKey{{}}},
+	}))
+}
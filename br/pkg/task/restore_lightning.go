@@ -0,0 +1,115 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package task
+
+import (
+	"context"
+	"os"
+	"runtime"
+	"strings"
+
+	"github.com/pingcap/errors"
+	backuppb "github.com/pingcap/kvproto/pkg/brpb"
+	"github.com/pingcap/log"
+	berrors "github.com/pingcap/tidb/br/pkg/errors"
+	snapclient "github.com/pingcap/tidb/br/pkg/restore/snap_client"
+	"github.com/pingcap/tidb/br/pkg/storage"
+	"github.com/pingcap/tidb/pkg/lightning/backend/local"
+	"github.com/pingcap/tidb/pkg/lightning/common"
+	lightning "github.com/pingcap/tidb/pkg/lightning/config"
+	"github.com/pingcap/tidb/pkg/util"
+	kvutil "github.com/tikv/client-go/v2/util"
+	"go.uber.org/zap"
+)
+
+// lightningLocalEngineConcurrency is the number of the engines sorting the kvs at the same time, it
+// bounds the disk usage of the sorted kv dir.
+const lightningLocalEngineConcurrency = 4
+
+// checkLightningLocalEngine checks whether the restore can be done by the `lightning-local` engine. The
+// engine commits the kvs at a new ts, so the data of the log backup or the incremental backup would be
+// shadowed by them.
+func checkLightningLocalEngine(cfg *RestoreConfig, backupMeta *backuppb.BackupMeta) error {
+	if backupMeta.IsRawKv || backupMeta.IsTxnKv {
+		return errors.Annotatef(berrors.ErrRestoreModeMismatch,
+			"the %s engine doesn't support restoring the raw kv or txn kv data", snapclient.EngineLightningLocal)
+	}
+	if backupMeta.StartVersion != 0 && backupMeta.StartVersion != backupMeta.EndVersion {
+		return errors.Annotatef(berrors.ErrInvalidArgument,
+			"the %s engine doesn't support the incremental restore", snapclient.EngineLightningLocal)
+	}
+	if len(cfg.FullBackupStorage) > 0 {
+		return errors.Annotatef(berrors.ErrInvalidArgument,
+			"the %s engine doesn't support the point in time restore", snapclient.EngineLightningLocal)
+	}
+	return nil
+}
+
+// setupLightningLocalEngine creates the local backend of Lightning and lets the client import the files
+// from the backup storage through it. The returned function closes the backend and removes the
+// temporary sorted kv dir.
+func setupLightningLocalEngine(
+	ctx context.Context,
+	client *snapclient.SnapClient,
+	cfg *RestoreConfig,
+	s storage.ExternalStorage,
+) (func(), error) {
+	sortedKVDir := cfg.SortedKVDir
+	removeDir := false
+	if sortedKVDir == "" {
+		dir, err := os.MkdirTemp("", "br-lightning-local-")
+		if err != nil {
+			return nil, errors.Annotate(err, "failed to create the sorted kv dir")
+		}
+		sortedKVDir, removeDir = dir, true
+	}
+	removeSortedKVDir := func() {
+		if !removeDir {
+			return
+		}
+		if err := os.RemoveAll(sortedKVDir); err != nil {
+			log.Warn("failed to remove the sorted kv dir", zap.String("dir", sortedKVDir), zap.Error(err))
+		}
+	}
+
+	tls, err := common.NewTLS(cfg.TLS.CA, cfg.TLS.Cert, cfg.TLS.Key, "", nil, nil, nil)
+	if err != nil {
+		removeSortedKVDir()
+		return nil, errors.Trace(err)
+	}
+	backendCfg := local.BackendConfig{
+		PDAddr:                  strings.Join(cfg.PD, ","),
+		LocalStoreDir:           sortedKVDir,
+		MaxConnPerStore:         lightning.DefaultRangeConcurrency,
+		WorkerConcurrency:       lightning.DefaultRangeConcurrency * 2,
+		KVWriteBatchSize:        lightning.KVWriteBatchSize,
+		RegionSplitBatchSize:    lightning.DefaultRegionSplitBatchSize,
+		RegionSplitConcurrency:  runtime.GOMAXPROCS(0),
+		MemTableSize:            lightning.DefaultEngineMemCacheSize,
+		LocalWriterMemCacheSize: lightning.DefaultLocalWriterMemCacheSize,
+		ShouldCheckTiKV:         cfg.CheckRequirements,
+		// the duplicated keys in the backup files mean the files are broken.
+		DupeDetectEnabled:     true,
+		DuplicateDetectOpt:    common.DupDetectOpt{ReportErrOnDup: true},
+		ShouldCheckWriteStall: true,
+		MaxOpenFiles:          int(util.GenRLimit("br-lightning-local")),
+		KeyspaceName:          cfg.KeyspaceName,
+		// the schedulers are paused by the restore.
+		PausePDSchedulerScope:       lightning.PausePDSchedulerScopeGlobal,
+		ResourceGroupName:           cfg.ResourceGroup,
+		TaskType:                    kvutil.ExplicitTypeBR,
+		DisableAutomaticCompactions: true,
+		BlockSize:                   lightning.DefaultBlockSize,
+	}
+	b, err := local.NewBackend(ctx, tls, backendCfg, nil)
+	if err != nil {
+		removeSortedKVDir()
+		return nil, errors.Annotate(err, "failed to create the local backend of lightning")
+	}
+	log.Info("restore through the local backend of lightning", zap.String("sorted-kv-dir", sortedKVDir))
+	client.SetLightningLocalBackend(b, s, lightningLocalEngineConcurrency)
+	return func() {
+		b.Close()
+		removeSortedKVDir()
+	}, nil
+}
@@ -0,0 +1,26 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package task
+
+import (
+	"testing"
+
+	backuppb "github.com/pingcap/kvproto/pkg/brpb"
+	berrors "github.com/pingcap/tidb/br/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckLightningLocalEngine(t *testing.T) {
+	cfg := &RestoreConfig{}
+	require.NoError(t, checkLightningLocalEngine(cfg, &backuppb.BackupMeta{EndVersion: 100}))
+	require.NoError(t, checkLightningLocalEngine(cfg, &backuppb.BackupMeta{StartVersion: 100, EndVersion: 100}))
+
+	err := checkLightningLocalEngine(cfg, &backuppb.BackupMeta{IsRawKv: true})
+	require.ErrorIs(t, err, berrors.ErrRestoreModeMismatch)
+	err = checkLightningLocalEngine(cfg, &backuppb.BackupMeta{StartVersion: 50, EndVersion: 100})
+	require.ErrorContains(t, err, "incremental restore")
+
+	cfg.FullBackupStorage = "local:///full"
+	err = checkLightningLocalEngine(cfg, &backuppb.BackupMeta{EndVersion: 100})
+	require.ErrorContains(t, err, "point in time restore")
+}
@@ -69,6 +69,8 @@ func RunRestoreRaw(c context.Context, g glue.Glue, cmdName string, cfg *RestoreR
 	cfg.adjust()
 
 	defer summary.Summary(cmdName)
+	defer cfg.storageRetryPolicy.Report()
+	defer cfg.collectStorageRequestStats()
 	ctx, cancel := context.WithCancel(c)
 	defer cancel()
 
@@ -80,6 +82,10 @@ func RunRestoreRaw(c context.Context, g glue.Glue, cmdName string, cfg *RestoreR
 	}
 	defer mgr.Close()
 
+	if err := checkEncryptionAtRestConfig(ctx, mgr, cfg.EncryptionAtRest, &cfg.Config); err != nil {
+		return errors.Trace(err)
+	}
+
 	// need retrieve these configs from tikv if not set in command.
 	kvConfigs := &kvconfig.KVConfig{
 		MergeRegionSize:     cfg.MergeSmallRegionSizeBytes,
@@ -101,6 +107,7 @@ func RunRestoreRaw(c context.Context, g glue.Glue, cmdName string, cfg *RestoreR
 	client.SetRateLimit(cfg.RateLimit)
 	client.SetCrypter(&cfg.CipherInfo)
 	client.SetConcurrencyPerStore(cfg.ConcurrencyPerStore.Value)
+	client.SetPhaseTimeouts(cfg.phaseTimeouts())
 	err = client.Init(g, mgr.GetStorage())
 	defer client.Close()
 	if err != nil {
@@ -0,0 +1,195 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package task
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/log"
+	"github.com/pingcap/tidb/br/pkg/checkpoint"
+	"github.com/pingcap/tidb/br/pkg/conn"
+	berrors "github.com/pingcap/tidb/br/pkg/errors"
+	"github.com/pingcap/tidb/br/pkg/glue"
+	"github.com/pingcap/tidb/br/pkg/logutil"
+	logclient "github.com/pingcap/tidb/br/pkg/restore/log_client"
+	"github.com/pingcap/tidb/br/pkg/restore/tiflashrec"
+	"github.com/pingcap/tidb/br/pkg/summary"
+	"github.com/pingcap/tidb/br/pkg/utils"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"go.uber.org/zap"
+)
+
+const (
+	flagRepairFromCheckpoint = "from-checkpoint"
+
+	// RepairIndexesCmd is the name of `br restore repair-indexes`.
+	RepairIndexesCmd = "Repair Indexes"
+)
+
+// RepairIndexesConfig is the config for `br restore repair-indexes`.
+type RepairIndexesConfig struct {
+	RestoreConfig
+
+	// FromCheckpoint re-drives the repairs saved in the checkpoint of the log restore.
+	FromCheckpoint bool `json:"from-checkpoint" toml:"from-checkpoint"`
+}
+
+// DefineRepairIndexesFlags defines flags for `br restore repair-indexes`.
+func DefineRepairIndexesFlags(command *cobra.Command) {
+	command.Flags().Bool(flagRepairFromCheckpoint, false, "re-attempt the ingest index repairs not finished "+
+		"by the previous log restore, which are saved in its checkpoint")
+}
+
+// ParseFromFlags parses the config from the flag set.
+func (cfg *RepairIndexesConfig) ParseFromFlags(flags *pflag.FlagSet) error {
+	if err := cfg.RestoreConfig.ParseFromFlags(flags, false); err != nil {
+		return errors.Trace(err)
+	}
+	var err error
+	if cfg.FromCheckpoint, err = flags.GetBool(flagRepairFromCheckpoint); err != nil {
+		return errors.Trace(err)
+	}
+	// the repairs are only saved in the checkpoint for now.
+	if !cfg.FromCheckpoint {
+		return errors.Annotatef(berrors.ErrInvalidArgument, "--%s is required", flagRepairFromCheckpoint)
+	}
+	return nil
+}
+
+// RunRepairIndexes re-drives the repairs of the ingest indexes left by the previous log restore. The
+// indexes already repaired are skipped, the others are dropped if not yet, and re-added. Once all of them
+// are repaired, the log restore is finished as it would be by itself.
+func RunRepairIndexes(c context.Context, g glue.Glue, cmdName string, cfg *RepairIndexesConfig) error {
+	g = withResourceGroup(g, cfg.ResourceGroup)
+	cfg.Adjust()
+	defer summary.Summary(cmdName)
+	ctx, cancel := context.WithCancel(c)
+	defer cancel()
+
+	mgr, err := NewMgr(ctx, g, cfg.PD, cfg.TLS, GetKeepalive(&cfg.Config), cfg.CheckRequirements, true, conn.NormalVersionChecker)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer mgr.Close()
+	client := logclient.NewRestoreClient(mgr.GetPDClient(), mgr.GetPDHTTPClient(), mgr.GetTLSConfig(), GetKeepalive(&cfg.Config))
+	if err := client.Init(ctx, g, mgr.GetStorage()); err != nil {
+		return errors.Trace(err)
+	}
+	defer client.Close(ctx)
+
+	// the metadata is loaded before the repairs, since it's removed with the checkpoint once they succeed.
+	meta, err := loadLogRestoreCheckpointMetadata(ctx, g, mgr)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	records, repairErr := client.RepairIngestIndexFromCheckpoint(ctx, g)
+	console := glue.GetConsole(g)
+	report := console.CreateTable()
+	for _, record := range records {
+		log.Info("re-drove the repair of the ingest index", zap.Any("record", record))
+		state := string(record.State)
+		if record.Error != "" {
+			state = fmt.Sprintf("%s: %s", state, record.Error)
+		}
+		report.Add(fmt.Sprintf("%s.%s.%s", record.SchemaName, record.TableName, record.IndexName), state)
+	}
+	report.Print()
+	if repairErr != nil {
+		return errors.Trace(repairErr)
+	}
+	if err := finishRepairedLogRestore(ctx, g, mgr, &cfg.RestoreConfig, meta); err != nil {
+		return errors.Trace(err)
+	}
+
+	summary.Log(cmdName, zap.Int("indexes", len(records)))
+	summary.SetSuccessStatus(true)
+	return nil
+}
+
+// loadLogRestoreCheckpointMetadata loads the metadata saved in the checkpoint by the log restore.
+func loadLogRestoreCheckpointMetadata(
+	ctx context.Context,
+	g glue.Glue,
+	mgr *conn.Mgr,
+) (*checkpoint.CheckpointMetadataForLogRestore, error) {
+	if !checkpoint.ExistsLogRestoreCheckpointMetadata(ctx, mgr.GetDomain()) {
+		return nil, errors.Annotate(berrors.ErrInvalidArgument,
+			"no checkpoint of the log restore, the log restore may not use the checkpoint or be finished")
+	}
+	se, err := g.CreateSession(mgr.GetStorage())
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	defer se.Close()
+	meta, err := checkpoint.LoadCheckpointMetadataForLogRestore(ctx, se.GetSessionCtx().GetRestrictedSQLExecutor())
+	return meta, errors.Trace(err)
+}
+
+// finishRepairedLogRestore finishes the log restore stopped by the failed repairs of the ingest indexes
+// as it's finished by itself, once the repairs succeed: the TiFlash replicas and the GC of TiKV disabled
+// by it are restored, the restore token is finished, and the checkpoint is removed.
+func finishRepairedLogRestore(
+	ctx context.Context,
+	g glue.Glue,
+	mgr *conn.Mgr,
+	cfg *RestoreConfig,
+	meta *checkpoint.CheckpointMetadataForLogRestore,
+) error {
+	if len(meta.TiFlashItems) > 0 {
+		recorder := tiflashrec.New()
+		recorder.Load(meta.TiFlashItems)
+		sqls := recorder.GenerateAlterTableDDLs(mgr.GetDomain().InfoSchema())
+		log.Info("Generating SQLs for restoring TiFlash Replica", zap.Strings("sqls", sqls))
+		err := g.UseOneShotSession(mgr.GetStorage(), false, func(se glue.Session) error {
+			for _, sql := range sqls {
+				if errExec := se.ExecuteInternal(ctx, sql); errExec != nil {
+					logutil.WarnTerm("Failed to restore tiflash replica config, you may execute the sql restore it manually.",
+						logutil.ShortError(errExec),
+						zap.String("sql", sql),
+					)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return errors.Trace(err)
+		}
+	}
+
+	gcRatio := meta.GcRatio
+	// If the ratio is negative, which is not normal status, set the default value after PiTR finished.
+	if strings.HasPrefix(gcRatio, "-") {
+		log.Warn("the original gc-ratio is negative, reset by default value 1.1", zap.String("old-gc-ratio", gcRatio))
+		gcRatio = utils.DefaultGcRatioVal
+	}
+	err := g.UseOneShotSession(mgr.GetStorage(), false, func(se glue.Session) error {
+		return utils.SetGcRatio(se.GetSessionCtx().GetRestrictedSQLExecutor(), gcRatio)
+	})
+	if err != nil {
+		return errors.Annotate(err, "failed to restore the gc-ratio-threshold")
+	}
+	log.Info("finish restoring gc", zap.String("ratio", gcRatio))
+
+	if cfg.IdempotencyToken != "" {
+		// the repair is a retry of the restore, which takes over the token.
+		finished, owner, err := acquireRestoreToken(ctx, g, mgr.GetStorage(), cfg,
+			restoreTokenFingerprint(PointRestoreCmd, cfg))
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if !finished {
+			if err := finishRestoreToken(ctx, g, mgr.GetStorage(), cfg, owner); err != nil {
+				return errors.Trace(err)
+			}
+		}
+	}
+	// the checkpoint of the log restore is always used, since the repairs are loaded from it.
+	checkpointCfg := *cfg
+	checkpointCfg.UseCheckpoint = true
+	removeCheckpointData(ctx, g, mgr, PointRestoreCmd, &checkpointCfg)
+	return nil
+}
@@ -0,0 +1,438 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package task
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"sort"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/log"
+	"github.com/pingcap/tidb/br/pkg/checkpoint"
+	"github.com/pingcap/tidb/br/pkg/conn"
+	berrors "github.com/pingcap/tidb/br/pkg/errors"
+	"github.com/pingcap/tidb/br/pkg/glue"
+	"github.com/pingcap/tidb/br/pkg/metautil"
+	snapclient "github.com/pingcap/tidb/br/pkg/restore/snap_client"
+	"github.com/pingcap/tidb/br/pkg/summary"
+	"github.com/pingcap/tidb/pkg/infoschema"
+	"github.com/pingcap/tidb/pkg/kv"
+	"github.com/pingcap/tidb/pkg/meta"
+	"github.com/pingcap/tidb/pkg/meta/model"
+	"github.com/pingcap/tidb/pkg/parser/ast"
+	"github.com/pingcap/tidb/pkg/tablecodec"
+	"github.com/pingcap/tidb/pkg/util"
+	"go.uber.org/zap"
+)
+
+// RestoreRollbackCmd is the name of `br restore rollback`.
+const RestoreRollbackCmd = "Restore Rollback"
+
+const insertRollbackDeleteRangeSQL = "INSERT IGNORE INTO mysql.gc_delete_range VALUES (%?, %?, %?, %?, %?);"
+
+func rollbackTableKind(info *model.TableInfo) string {
+	switch {
+	case info.IsView():
+		return checkpoint.RollbackKindView
+	case info.IsSequence():
+		return checkpoint.RollbackKindSequence
+	default:
+		return checkpoint.RollbackKindTable
+	}
+}
+
+func dropTableStmt(kind string) string {
+	switch kind {
+	case checkpoint.RollbackKindView:
+		return "DROP VIEW IF EXISTS %n.%n;"
+	case checkpoint.RollbackKindSequence:
+		return "DROP SEQUENCE IF EXISTS %n.%n;"
+	default:
+		return "DROP TABLE IF EXISTS %n.%n;"
+	}
+}
+
+// newRestoreRollbackInfo collects the databases, tables and placement policies to restore that don't
+// exist for now, i.e. the ones the restore creates.
+func newRestoreRollbackInfo(
+	is infoschema.InfoSchema,
+	dbs []*metautil.Database,
+	tables []*metautil.Table,
+	policies []*model.PolicyInfo,
+) *checkpoint.RestoreRollbackInfo {
+	info := &checkpoint.RestoreRollbackInfo{SchemaVersion: is.SchemaMetaVersion()}
+	newDBs := make(map[string]struct{})
+	for _, db := range dbs {
+		if !is.SchemaExists(db.Info.Name) {
+			newDBs[db.Info.Name.L] = struct{}{}
+			info.Databases = append(info.Databases, db.Info.Name.O)
+		}
+	}
+	for _, table := range tables {
+		// the tables in the new databases are dropped with the databases.
+		if _, ok := newDBs[table.DB.Name.L]; ok {
+			continue
+		}
+		if !is.TableExists(table.DB.Name, table.Info.Name) {
+			info.Tables = append(info.Tables, checkpoint.RollbackTable{
+				DB:   table.DB.Name.O,
+				Name: table.Info.Name.O,
+				Kind: rollbackTableKind(table.Info),
+			})
+		}
+	}
+	for _, policy := range policies {
+		if _, ok := is.PolicyByName(policy.Name); !ok {
+			info.Policies = append(info.Policies, policy.Name.O)
+		}
+	}
+	slices.Sort(info.Policies)
+	return info
+}
+
+// collectRestoreRollbackInfo collects what the snapshot restore creates, it must be called before the
+// restore creates anything.
+func collectRestoreRollbackInfo(
+	ctx context.Context,
+	mgr *conn.Mgr,
+	client *snapclient.SnapClient,
+	cmdName string,
+	dbs []*metautil.Database,
+	tables []*metautil.Table,
+	online bool,
+) (*checkpoint.RestoreRollbackInfo, error) {
+	var policies []*model.PolicyInfo
+	// the policies are created only by the full restore.
+	if client.GetSupportPolicy() && isFullRestore(cmdName) {
+		policyMap, err := client.GetPlacementPolicies()
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		policyMap.Range(func(_, value any) bool {
+			policies = append(policies, value.(*model.PolicyInfo))
+			return true
+		})
+	}
+	startTS, err := mgr.GetCurrentTsFromPD(ctx)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	info := newRestoreRollbackInfo(mgr.GetDomain().InfoSchema(), dbs, tables, policies)
+	info.StartTS = startTS
+	info.Online = online
+	log.Info("collected the rollback information of the restore",
+		zap.Uint64("start-ts", info.StartTS),
+		zap.Int64("schema-version", info.SchemaVersion),
+		zap.Strings("databases", info.Databases),
+		zap.Int("tables", len(info.Tables)),
+		zap.Strings("policies", info.Policies))
+	return info, nil
+}
+
+// dropRollbackObjects drops the tables, databases and placement policies created by the restore.
+func dropRollbackObjects(ctx context.Context, se glue.Session, info *checkpoint.RestoreRollbackInfo) error {
+	for _, table := range info.Tables {
+		if err := se.ExecuteInternal(ctx, dropTableStmt(table.Kind), table.DB, table.Name); err != nil {
+			return errors.Annotatef(err, "failed to drop the table %s.%s", table.DB, table.Name)
+		}
+	}
+	for _, dbName := range info.Databases {
+		if err := se.ExecuteInternal(ctx, "DROP DATABASE IF EXISTS %n;", dbName); err != nil {
+			return errors.Annotatef(err, "failed to drop the database %s", dbName)
+		}
+	}
+	for _, policy := range info.Policies {
+		if err := se.ExecuteInternal(ctx, "DROP PLACEMENT POLICY IF EXISTS %n;", policy); err != nil {
+			return errors.Annotatef(err, "failed to drop the placement policy %s", policy)
+		}
+	}
+	return nil
+}
+
+func appendPhysicalIDs(ids map[int64]struct{}, info *model.TableInfo) {
+	ids[info.ID] = struct{}{}
+	if pi := info.GetPartitionInfo(); pi != nil {
+		for _, def := range pi.Definitions {
+			ids[def.ID] = struct{}{}
+		}
+	}
+}
+
+// rollbackPhysicalIDs returns the physical IDs of the tables to drop by the rollback, the data of them
+// is removed by the delete-range of the DROP statements.
+func rollbackPhysicalIDs(
+	ctx context.Context,
+	is infoschema.InfoSchema,
+	info *checkpoint.RestoreRollbackInfo,
+) (map[int64]struct{}, error) {
+	ids := make(map[int64]struct{})
+	for _, dbName := range info.Databases {
+		tableInfos, err := is.SchemaTableInfos(ctx, ast.NewCIStr(dbName))
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		for _, tableInfo := range tableInfos {
+			appendPhysicalIDs(ids, tableInfo)
+		}
+	}
+	for _, table := range info.Tables {
+		tableInfo, err := is.TableInfoByName(ast.NewCIStr(table.DB), ast.NewCIStr(table.Name))
+		if err != nil {
+			if infoschema.ErrTableNotExists.Equal(err) || infoschema.ErrDatabaseNotExists.Equal(err) {
+				continue
+			}
+			return nil, errors.Trace(err)
+		}
+		appendPhysicalIDs(ids, tableInfo)
+	}
+	return ids, nil
+}
+
+// orphanRestoredIDs splits the physical IDs in the checkpoint that aren't dropped by the rollback into the
+// orphan ones, whose tables are never created or already gone, and the ones of the tables not created by
+// the restore, which are kept as is.
+func orphanRestoredIDs(
+	is infoschema.InfoSchema,
+	restoredIDs, droppedIDs map[int64]struct{},
+) (orphans, kept []int64) {
+	for id := range restoredIDs {
+		if _, ok := droppedIDs[id]; ok {
+			continue
+		}
+		if _, ok := is.TableByID(context.Background(), id); ok {
+			kept = append(kept, id)
+			continue
+		}
+		if tbl, _, _ := is.FindTableByPartitionID(id); tbl != nil {
+			kept = append(kept, id)
+			continue
+		}
+		orphans = append(orphans, id)
+	}
+	slices.Sort(orphans)
+	slices.Sort(kept)
+	return orphans, kept
+}
+
+// diffSchemas returns the databases and tables differ between the schemas before the restore and the
+// current ones, the system and checkpoint databases are ignored.
+func diffSchemas(ctx context.Context, before, after infoschema.InfoSchema) ([]string, error) {
+	collect := func(is infoschema.InfoSchema) (map[string]struct{}, error) {
+		names := make(map[string]struct{})
+		for _, dbName := range is.AllSchemaNames() {
+			if util.IsMemOrSysDB(dbName.L) || checkpoint.IsCheckpointDB(dbName) {
+				continue
+			}
+			names[fmt.Sprintf("database %s", dbName.L)] = struct{}{}
+			tableInfos, err := is.SchemaTableInfos(ctx, dbName)
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			for _, tableInfo := range tableInfos {
+				names[fmt.Sprintf("table %s.%s", dbName.L, tableInfo.Name.L)] = struct{}{}
+			}
+		}
+		return names, nil
+	}
+	beforeNames, err := collect(before)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	afterNames, err := collect(after)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	diffs := make([]string, 0)
+	for name := range afterNames {
+		if _, ok := beforeNames[name]; !ok {
+			diffs = append(diffs, name+" is left")
+		}
+	}
+	for name := range beforeNames {
+		if _, ok := afterNames[name]; !ok {
+			diffs = append(diffs, name+" is missing")
+		}
+	}
+	sort.Strings(diffs)
+	return diffs, nil
+}
+
+// RunRestoreRollback rolls back the partially completed snapshot restore by its checkpoint. It drops the
+// tables, databases and placement policies created by the restore, removes the data ingested into the
+// tables never created, clears the placement rules of the online restore, and verifies the schemas are
+// the same as the ones before the restore.
+func RunRestoreRollback(c context.Context, g glue.Glue, cmdName string, cfg *RestoreConfig) error {
+	g = withResourceGroup(g, cfg.ResourceGroup)
+	cfg.Adjust()
+	defer summary.Summary(cmdName)
+	ctx, cancel := context.WithCancel(c)
+	defer cancel()
+
+	mgr, err := NewMgr(ctx, g, cfg.PD, cfg.TLS, GetKeepalive(&cfg.Config), cfg.CheckRequirements, true, conn.NormalVersionChecker)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer mgr.Close()
+	dom := mgr.GetDomain()
+	if !checkpoint.ExistsSstRestoreCheckpoint(ctx, dom, checkpoint.SnapshotRestoreCheckpointDatabaseName) {
+		return errors.Annotate(berrors.ErrInvalidArgument, "no checkpoint of the snapshot restore, nothing to roll back")
+	}
+	se, err := g.CreateSession(mgr.GetStorage())
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer se.Close()
+	execCtx := se.GetSessionCtx().GetRestrictedSQLExecutor()
+	cpMeta, err := checkpoint.LoadCheckpointMetadataForSnapshotRestore(ctx, execCtx)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	info := cpMeta.Rollback
+	if info == nil {
+		return errors.Annotate(berrors.ErrInvalidArgument,
+			"the checkpoint doesn't record what the restore creates, it may be created by an older BR")
+	}
+	restoredIDs := make(map[int64]struct{})
+	if _, err := checkpoint.LoadCheckpointDataForSstRestore(ctx, execCtx, checkpoint.SnapshotRestoreCheckpointDatabaseName,
+		func(tableID checkpoint.RestoreKeyType, _ checkpoint.RestoreValueType) {
+			restoredIDs[tableID] = struct{}{}
+		}); err != nil {
+		return errors.Trace(err)
+	}
+
+	droppedIDs, err := rollbackPhysicalIDs(ctx, dom.InfoSchema(), info)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	orphans, kept := orphanRestoredIDs(dom.InfoSchema(), restoredIDs, droppedIDs)
+	if len(kept) > 0 {
+		log.Warn("the data restored into the tables not created by the restore is kept", zap.Int64s("physical-ids", kept))
+	}
+
+	console := glue.GetConsole(g)
+	report := console.CreateTable()
+	defer report.Print()
+
+	if err := dropRollbackObjects(ctx, se, info); err != nil {
+		report.Add("drop the restored objects", "failed: "+err.Error())
+		return errors.Trace(err)
+	}
+	report.Add("drop the restored objects", fmt.Sprintf("%d databases, %d tables, %d placement policies",
+		len(info.Databases), len(info.Tables), len(info.Policies)))
+
+	if err := insertRollbackDeleteRanges(ctx, mgr, se, orphans); err != nil {
+		report.Add("delete the ingested ranges", "failed: "+err.Error())
+		return errors.Trace(err)
+	}
+	report.Add("delete the ingested ranges", fmt.Sprintf("%d by dropping, %d orphan", len(droppedIDs), len(orphans)))
+
+	if info.Online {
+		ids := make([]int64, 0, len(droppedIDs)+len(orphans))
+		for id := range droppedIDs {
+			ids = append(ids, id)
+		}
+		ids = append(ids, orphans...)
+		for _, id := range ids {
+			if err := mgr.GetPDHTTPClient().DeletePlacementRule(ctx, "pd", snapclient.RestorePlacementRuleID(id)); err != nil {
+				report.Add("delete the placement rules", "failed: "+err.Error())
+				return errors.Annotatef(err, "failed to delete the placement rule of the table %d", id)
+			}
+		}
+		report.Add("delete the placement rules", fmt.Sprintf("%d", len(ids)))
+	}
+
+	if err := dom.Reload(); err != nil {
+		return errors.Trace(err)
+	}
+	current := dom.InfoSchema()
+	if err := verifyRestoreRollback(ctx, dom.GetSnapshotInfoSchema, current, info); err != nil {
+		report.Add("verify the schemas", "failed: "+err.Error())
+		return errors.Trace(err)
+	}
+	report.Add("verify the schemas", fmt.Sprintf("same as the ones at %d, schema version %d -> %d",
+		info.StartTS, info.SchemaVersion, current.SchemaMetaVersion()))
+
+	if err := checkpoint.RemoveCheckpointDataForSstRestore(ctx, dom, se, checkpoint.SnapshotRestoreCheckpointDatabaseName); err != nil {
+		report.Add("remove the checkpoint", "failed: "+err.Error())
+		return errors.Trace(err)
+	}
+	report.Add("remove the checkpoint", "done")
+
+	summary.Log(cmdName,
+		zap.Strings("databases", info.Databases),
+		zap.Int("tables", len(info.Tables)),
+		zap.Strings("policies", info.Policies),
+		zap.Int("orphan-ranges", len(orphans)))
+	summary.SetSuccessStatus(true)
+	return nil
+}
+
+// insertRollbackDeleteRanges removes the data ingested into the tables never created, the GC worker
+// deletes the ranges later.
+func insertRollbackDeleteRanges(ctx context.Context, mgr *conn.Mgr, se glue.Session, ids []int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	var jobID int64
+	err := kv.RunInNewTxn(kv.WithInternalSourceType(ctx, kv.InternalTxnBR), mgr.GetStorage(), true,
+		func(_ context.Context, txn kv.Transaction) error {
+			var e error
+			jobID, e = meta.NewMutator(txn).GenGlobalID()
+			return e
+		})
+	if err != nil {
+		return errors.Trace(err)
+	}
+	ts, err := mgr.GetCurrentTsFromPD(ctx)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	for i, id := range ids {
+		startKey := hex.EncodeToString(tablecodec.EncodeTablePrefix(id))
+		endKey := hex.EncodeToString(tablecodec.EncodeTablePrefix(id + 1))
+		log.Info("insert into the delete range for the rollback",
+			zap.Int64("jobID", jobID), zap.Int64("physical-id", id), zap.Uint64("ts", ts))
+		if err := se.ExecuteInternal(ctx, insertRollbackDeleteRangeSQL, jobID, int64(i+1), startKey, endKey, ts); err != nil {
+			return errors.Annotatef(err, "failed to insert the delete range of the table %d", id)
+		}
+	}
+	return nil
+}
+
+// verifyRestoreRollback checks the schemas are the same as the ones before the restore. If the schemas
+// before the restore are garbage collected, it only checks the objects created by the restore are gone.
+func verifyRestoreRollback(
+	ctx context.Context,
+	snapshotInfoSchema func(ts uint64) (infoschema.InfoSchema, error),
+	current infoschema.InfoSchema,
+	info *checkpoint.RestoreRollbackInfo,
+) error {
+	before, err := snapshotInfoSchema(info.StartTS)
+	if err != nil {
+		log.Warn("failed to load the schemas before the restore, only check the restored objects are dropped",
+			zap.Uint64("start-ts", info.StartTS), zap.Error(err))
+		for _, dbName := range info.Databases {
+			if current.SchemaExists(ast.NewCIStr(dbName)) {
+				return errors.Annotatef(berrors.ErrRestoreRollbackFailed, "the database %s is left", dbName)
+			}
+		}
+		for _, table := range info.Tables {
+			if current.TableExists(ast.NewCIStr(table.DB), ast.NewCIStr(table.Name)) {
+				return errors.Annotatef(berrors.ErrRestoreRollbackFailed, "the table %s.%s is left", table.DB, table.Name)
+			}
+		}
+		return nil
+	}
+	diffs, err := diffSchemas(ctx, before, current)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if len(diffs) > 0 {
+		return errors.Annotatef(berrors.ErrRestoreRollbackFailed,
+			"the schemas differ from the ones before the restore, maybe changed concurrently: %v", diffs)
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package task
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/tidb/br/pkg/checkpoint"
+	berrors "github.com/pingcap/tidb/br/pkg/errors"
+	"github.com/pingcap/tidb/br/pkg/metautil"
+	"github.com/pingcap/tidb/pkg/infoschema"
+	"github.com/pingcap/tidb/pkg/meta/model"
+	"github.com/pingcap/tidb/pkg/parser/ast"
+	"github.com/stretchr/testify/require"
+)
+
+func mockRollbackTable(id int64, name string, partitionIDs ...int64) *model.TableInfo {
+	info := &model.TableInfo{ID: id, Name: ast.NewCIStr(name), State: model.StatePublic}
+	if len(partitionIDs) > 0 {
+		info.Partition = &model.PartitionInfo{Enable: true}
+		for _, pid := range partitionIDs {
+			info.Partition.Definitions = append(info.Partition.Definitions, model.PartitionDefinition{ID: pid})
+		}
+	}
+	return info
+}
+
+func TestNewRestoreRollbackInfo(t *testing.T) {
+	is := infoschema.MockInfoSchema([]*model.TableInfo{mockRollbackTable(100, "t1")})
+	testDB := &model.DBInfo{Name: ast.NewCIStr("test")}
+	newDB := &model.DBInfo{Name: ast.NewCIStr("new_db")}
+	dbs := []*metautil.Database{{Info: testDB}, {Info: newDB}}
+	tables := []*metautil.Table{
+		{DB: testDB, Info: mockRollbackTable(1, "t1")},
+		{DB: testDB, Info: mockRollbackTable(2, "t2")},
+		{DB: testDB, Info: &model.TableInfo{ID: 3, Name: ast.NewCIStr("v"), View: &model.ViewInfo{}}},
+		{DB: newDB, Info: mockRollbackTable(4, "t")},
+	}
+	policies := []*model.PolicyInfo{{Name: ast.NewCIStr("p2")}, {Name: ast.NewCIStr("p1")}}
+
+	info := newRestoreRollbackInfo(is, dbs, tables, policies)
+	require.Equal(t, []string{"new_db"}, info.Databases)
+	// the existing table and the ones in the new database aren't recorded.
+	require.Equal(t, []checkpoint.RollbackTable{
+		{DB: "test", Name: "t2", Kind: checkpoint.RollbackKindTable},
+		{DB: "test", Name: "v", Kind: checkpoint.RollbackKindView},
+	}, info.Tables)
+	require.Equal(t, []string{"p1", "p2"}, info.Policies)
+	require.Equal(t, is.SchemaMetaVersion(), info.SchemaVersion)
+
+	require.Equal(t, "DROP VIEW IF EXISTS %n.%n;", dropTableStmt(checkpoint.RollbackKindView))
+	require.Equal(t, "DROP SEQUENCE IF EXISTS %n.%n;", dropTableStmt(checkpoint.RollbackKindSequence))
+	require.Equal(t, "DROP TABLE IF EXISTS %n.%n;", dropTableStmt(checkpoint.RollbackKindTable))
+}
+
+func TestRollbackPhysicalIDs(t *testing.T) {
+	is := infoschema.MockInfoSchema([]*model.TableInfo{
+		mockRollbackTable(100, "created", 101, 102),
+		mockRollbackTable(200, "existing"),
+	})
+	info := &checkpoint.RestoreRollbackInfo{Tables: []checkpoint.RollbackTable{
+		{DB: "test", Name: "created", Kind: checkpoint.RollbackKindTable},
+		{DB: "test", Name: "never_created", Kind: checkpoint.RollbackKindTable},
+	}}
+	dropped, err := rollbackPhysicalIDs(context.Background(), is, info)
+	require.NoError(t, err)
+	require.Equal(t, map[int64]struct{}{100: {}, 101: {}, 102: {}}, dropped)
+
+	restored := map[int64]struct{}{101: {}, 102: {}, 200: {}, 300: {}, 301: {}}
+	orphans, kept := orphanRestoredIDs(is, restored, dropped)
+	require.Equal(t, []int64{300, 301}, orphans)
+	require.Equal(t, []int64{200}, kept)
+}
+
+func TestVerifyRestoreRollback(t *testing.T) {
+	ctx := context.Background()
+	before := infoschema.MockInfoSchema([]*model.TableInfo{mockRollbackTable(100, "t1")})
+	info := &checkpoint.RestoreRollbackInfo{
+		StartTS: 42,
+		Tables:  []checkpoint.RollbackTable{{DB: "test", Name: "t2", Kind: checkpoint.RollbackKindTable}},
+	}
+	snapshot := func(ts uint64) (infoschema.InfoSchema, error) {
+		require.EqualValues(t, 42, ts)
+		return before, nil
+	}
+	gced := func(uint64) (infoschema.InfoSchema, error) {
+		return nil, errors.New("GC life time is shorter than transaction duration")
+	}
+
+	same := infoschema.MockInfoSchema([]*model.TableInfo{mockRollbackTable(100, "t1")})
+	require.NoError(t, verifyRestoreRollback(ctx, snapshot, same, info))
+
+	left := infoschema.MockInfoSchema([]*model.TableInfo{mockRollbackTable(100, "t1"), mockRollbackTable(101, "t2")})
+	err := verifyRestoreRollback(ctx, snapshot, left, info)
+	require.True(t, berrors.ErrRestoreRollbackFailed.Equal(err))
+	require.ErrorContains(t, err, "table test.t2 is left")
+	err = verifyRestoreRollback(ctx, gced, left, info)
+	require.True(t, berrors.ErrRestoreRollbackFailed.Equal(err))
+
+	// the schemas before the restore are gone, only the restored objects are checked.
+	changed := infoschema.MockInfoSchema([]*model.TableInfo{mockRollbackTable(102, "t3")})
+	require.NoError(t, verifyRestoreRollback(ctx, gced, changed, info))
+	err = verifyRestoreRollback(ctx, snapshot, changed, info)
+	require.ErrorContains(t, err, "table test.t1 is missing")
+	require.ErrorContains(t, err, "table test.t3 is left")
+}
@@ -0,0 +1,283 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package task
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	_ "github.com/go-sql-driver/mysql" // mysql driver
+	"github.com/pingcap/errors"
+	"github.com/pingcap/log"
+	berrors "github.com/pingcap/tidb/br/pkg/errors"
+	"github.com/pingcap/tidb/br/pkg/storage"
+	"github.com/pingcap/tidb/br/pkg/summary"
+	"github.com/pingcap/tidb/pkg/parser/ast"
+	"github.com/pingcap/tidb/pkg/parser/format"
+	"github.com/spf13/pflag"
+	"go.uber.org/zap"
+)
+
+const (
+	flagSQLEndpoint = "sql-endpoint"
+
+	// restoreSQLProgressInterval is the interval of logging the progress of the restore via the SQL endpoint.
+	restoreSQLProgressInterval = 30 * time.Second
+)
+
+// restoreSQLFlags are the flags of the restore having an equivalent in the RESTORE statement.
+var restoreSQLFlags = map[string]struct{}{
+	flagStorage:           {},
+	flagSQLEndpoint:       {},
+	flagDatabase:          {},
+	flagTable:             {},
+	flagConcurrency:       {},
+	FlagChecksum:          {},
+	flagSendCreds:         {},
+	flagOnline:            {},
+	flagWithSysTable:      {},
+	flagLoadStats:         {},
+	FlagWaitTiFlashReady:  {},
+	flagAllowCrossCluster: {},
+	flagRateLimit:         {},
+	flagRateLimitUnit:     {},
+}
+
+// restoreSQLBackendSchemes maps the prefixes of the flags of the backend options to the schemes of the
+// storage URLs reading them from the query parameters.
+var restoreSQLBackendSchemes = map[string][]string{
+	"s3.":     {"s3", "ks3", "oss", "cos"},
+	"gcs.":    {"gs", "gcs"},
+	"azblob.": {"azure", "azblob"},
+}
+
+// newRestoreFlagSet defines the flags of the snapshot restore, the other flags, e.g. the log flags, only
+// affect the BR process.
+func newRestoreFlagSet() *pflag.FlagSet {
+	flags := pflag.NewFlagSet("restore", pflag.ContinueOnError)
+	DefineCommonFlags(flags)
+	DefineRestoreFlags(flags)
+	flags.StringArray(flagFilter, nil, "")
+	flags.Bool(flagCaseSensitive, false, "")
+	return flags
+}
+
+// buildRestoreSQL builds the RESTORE statement executed by the TiDB of the SQL endpoint. Only the options
+// of the statement and the backend options, passed by the query parameters of the storage URL, can be passed
+// to TiDB, so any other flag of the restore is rejected.
+func buildRestoreSQL(flags *pflag.FlagSet, cfg *RestoreConfig) (string, error) {
+	storageURL, err := storage.ParseRawURL(cfg.Storage)
+	if err != nil {
+		return "", errors.Annotate(berrors.ErrStorageInvalidConfig, err.Error())
+	}
+	query := storageURL.Query()
+	queryChanged := false
+	restoreFlags := newRestoreFlagSet()
+	flags.Visit(func(f *pflag.Flag) {
+		if err != nil {
+			return
+		}
+		if _, ok := restoreSQLFlags[f.Name]; ok {
+			return
+		}
+		for prefix, schemes := range restoreSQLBackendSchemes {
+			// the credentials file would be read by the TiDB instead of BR.
+			if !strings.HasPrefix(f.Name, prefix) || f.Name == "gcs.credentials-file" {
+				continue
+			}
+			if slices.Contains(schemes, storageURL.Scheme) {
+				// the parameter in the URL takes precedence over the flag, as it does without the SQL endpoint.
+				key := strings.TrimPrefix(f.Name, prefix)
+				if !query.Has(key) && !query.Has(strings.ReplaceAll(key, "-", "_")) {
+					query.Set(key, f.Value.String())
+					queryChanged = true
+				}
+			}
+			return
+		}
+		if restoreFlags.Lookup(f.Name) != nil {
+			err = errors.Annotatef(berrors.ErrInvalidArgument, "--%s isn't supported with --%s",
+				f.Name, flagSQLEndpoint)
+		}
+	})
+	if err != nil {
+		return "", err
+	}
+	stmtStorage := cfg.Storage
+	if queryChanged {
+		storageURL.RawQuery = query.Encode()
+		stmtStorage = storageURL.String()
+	}
+
+	stmt := &ast.BRIEStmt{Kind: ast.BRIEKindRestore, Storage: stmtStorage}
+	if dbFlag := flags.Lookup(flagDatabase); dbFlag != nil {
+		db := dbFlag.Value.String()
+		if tblFlag := flags.Lookup(flagTable); tblFlag != nil {
+			stmt.Tables = []*ast.TableName{{Schema: ast.NewCIStr(db), Name: ast.NewCIStr(tblFlag.Value.String())}}
+		} else {
+			stmt.Schemas = []string{db}
+		}
+	}
+	boolValue := func(b bool) uint64 {
+		if b {
+			return 1
+		}
+		return 0
+	}
+	checksum := ast.BRIEOptionLevelOff
+	if cfg.Checksum {
+		checksum = ast.BRIEOptionLevelRequired
+	}
+	stmt.Options = []*ast.BRIEOption{
+		{Tp: ast.BRIEOptionConcurrency, UintValue: uint64(cfg.Concurrency)},
+		{Tp: ast.BRIEOptionChecksum, UintValue: uint64(checksum)},
+		{Tp: ast.BRIEOptionSendCreds, UintValue: boolValue(cfg.SendCreds)},
+		{Tp: ast.BRIEOptionOnline, UintValue: boolValue(cfg.Online)},
+		{Tp: ast.BRIEOptionWithSysTable, UintValue: boolValue(cfg.WithSysTable)},
+		{Tp: ast.BRIEOptionLoadStats, UintValue: boolValue(cfg.LoadStats)},
+		{Tp: ast.BRIEOptionWaitTiflashReady, UintValue: boolValue(cfg.WaitTiflashReady)},
+		{Tp: ast.BRIEOptionAllowCrossCluster, UintValue: boolValue(cfg.AllowCrossCluster)},
+	}
+	if cfg.RateLimit > 0 {
+		stmt.Options = append(stmt.Options, &ast.BRIEOption{Tp: ast.BRIEOptionRateLimit, UintValue: cfg.RateLimit})
+	}
+
+	var sb strings.Builder
+	if err := stmt.Restore(format.NewRestoreCtx(format.DefaultRestoreFlags, &sb)); err != nil {
+		return "", errors.Trace(err)
+	}
+	return sb.String(), nil
+}
+
+// RunRestoreViaSQL restores the snapshot backup by the RESTORE statement of the TiDB at the SQL endpoint,
+// for the environments where BR can't access PD and TiKV. The restore runs inside the TiDB, which downloads
+// the backup from the storage, so the storage must be accessible from the TiDB instead of BR, and the
+// restore is slower than by BR because the TiDB restores at most one backup or restore task at the same time
+// and the work isn't spread to the BR host.
+func RunRestoreViaSQL(c context.Context, cmdName string, cfg *RestoreConfig) error {
+	if cmdName != FullRestoreCmd && cmdName != DBRestoreCmd && cmdName != TableRestoreCmd {
+		return errors.Annotatef(berrors.ErrInvalidArgument, "--%s only supports the snapshot restore", flagSQLEndpoint)
+	}
+	defer summary.Summary(cmdName)
+	db, err := sql.Open("mysql", cfg.SQLEndpoint)
+	if err != nil {
+		return errors.Annotate(err, "failed to connect to the SQL endpoint")
+	}
+	defer db.Close()
+
+	start := time.Now()
+	if err := runRestoreSQL(c, db, cfg.restoreSQL, restoreSQLProgressInterval); err != nil {
+		summary.SetSuccessStatus(false)
+		return errors.Trace(err)
+	}
+	summary.CollectDuration("restore via the SQL endpoint", time.Since(start))
+	summary.SetSuccessStatus(true)
+	return nil
+}
+
+// runRestoreSQL executes the RESTORE statement, and logs the progress of it every interval.
+func runRestoreSQL(ctx context.Context, db *sql.DB, stmt string, interval time.Duration) error {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return errors.Annotate(err, "failed to connect to the SQL endpoint")
+	}
+	defer conn.Close()
+	var connID uint64
+	if err := conn.QueryRowContext(ctx, "SELECT CONNECTION_ID()").Scan(&connID); err != nil {
+		return errors.Trace(err)
+	}
+
+	progressCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go logRestoreSQLProgress(progressCtx, db, connID, interval)
+
+	log.Info("start to restore via the SQL endpoint", zap.Uint64("connection", connID))
+	rows, err := conn.QueryContext(ctx, stmt)
+	if err != nil {
+		return errors.Annotate(err, "failed to restore via the SQL endpoint")
+	}
+	columns, results, err := scanStringRows(rows)
+	if err != nil {
+		return errors.Annotate(err, "failed to restore via the SQL endpoint")
+	}
+	for _, values := range results {
+		fields := make([]zap.Field, 0, len(columns))
+		for i, column := range columns {
+			value := values[i]
+			if column == "Destination" {
+				// the storage may carry the credentials.
+				value = ast.RedactURL(value)
+			}
+			fields = append(fields, zap.String(column, value))
+		}
+		log.Info("restored via the SQL endpoint", fields...)
+	}
+	return nil
+}
+
+// scanStringRows reads all the rows as strings, NULL is read as an empty string.
+func scanStringRows(rows *sql.Rows) ([]string, [][]string, error) {
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, nil, errors.Trace(err)
+	}
+	var results [][]string
+	for rows.Next() {
+		values := make([]sql.NullString, len(columns))
+		dest := make([]any, len(columns))
+		for i := range values {
+			dest[i] = &values[i]
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, nil, errors.Trace(err)
+		}
+		result := make([]string, len(columns))
+		for i, value := range values {
+			result[i] = value.String
+		}
+		results = append(results, result)
+	}
+	return columns, results, errors.Trace(rows.Err())
+}
+
+// logRestoreSQLProgress logs the progress of the RESTORE statement of the connection every interval.
+func logRestoreSQLProgress(ctx context.Context, db *sql.DB, connID uint64, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		rows, err := db.QueryContext(ctx, "SHOW RESTORES")
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Warn("failed to get the progress of the restore via the SQL endpoint", zap.Error(err))
+			}
+			continue
+		}
+		columns, results, err := scanStringRows(rows)
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Warn("failed to get the progress of the restore via the SQL endpoint", zap.Error(err))
+			}
+			continue
+		}
+		for _, values := range results {
+			task := make(map[string]string, len(columns))
+			for i, column := range columns {
+				task[column] = values[i]
+			}
+			if task["Connection"] == strconv.FormatUint(connID, 10) {
+				log.Info("restoring via the SQL endpoint",
+					zap.String("state", task["State"]), zap.String("progress", task["Progress"]+"%"))
+			}
+		}
+	}
+}
@@ -0,0 +1,97 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package task
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildRestoreSQL(t *testing.T) {
+	parse := func(target string, args ...string) (*RestoreConfig, error) {
+		flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+		DefineCommonFlags(flags)
+		DefineRestoreFlags(flags)
+		switch target {
+		case "db":
+			flags.String(flagDatabase, "", "")
+		case "table":
+			flags.String(flagDatabase, "", "")
+			flags.String(flagTable, "", "")
+		default:
+			flags.StringArrayP(flagFilter, "f", []string{"*.*"}, "")
+			flags.Bool(flagCaseSensitive, false, "")
+		}
+		require.NoError(t, flags.Parse(args))
+		cfg := &RestoreConfig{}
+		return cfg, cfg.ParseFromFlags(flags, false)
+	}
+
+	cfg, err := parse("full", "-s", "local:///backup")
+	require.NoError(t, err)
+	require.Empty(t, cfg.restoreSQL)
+
+	cfg, err = parse("full", "-s", "s3://bucket/backup?access-key=ak", "--sql-endpoint", "root@tcp(tidb:4000)/",
+		"--ratelimit", "10", "--with-sys-table=false", "--allow-cross-cluster")
+	require.NoError(t, err)
+	require.Equal(t, "RESTORE DATABASE * FROM 's3://bucket/backup?access-key=ak' CONCURRENCY = 128 "+
+		"CHECKSUM = REQUIRED SEND_CREDENTIALS_TO_TIKV = 1 ONLINE = 0 WITH_SYS_TABLE = 0 LOAD_STATS = 1 "+
+		"WAIT_TIFLASH_READY = 0 ALLOW_CROSS_CLUSTER = 1 RATE_LIMIT = 10 MB/SECOND", cfg.restoreSQL)
+
+	cfg, err = parse("db", "-s", "local:///backup", "--sql-endpoint", "root@tcp(tidb:4000)/", "--db", "d`b",
+		"--checksum=false")
+	require.NoError(t, err)
+	require.Contains(t, cfg.restoreSQL, "RESTORE DATABASE `d``b` FROM 'local:///backup' CONCURRENCY = 128 CHECKSUM = OFF ")
+
+	cfg, err = parse("table", "-s", "local:///backup", "--sql-endpoint", "root@tcp(tidb:4000)/", "--db", "db",
+		"--table", "t")
+	require.NoError(t, err)
+	require.Contains(t, cfg.restoreSQL, "RESTORE TABLE `db`.`t` FROM 'local:///backup' ")
+
+	_, err = parse("full", "-s", "local:///backup", "--sql-endpoint", "root@tcp(tidb:4000)/", "-f", "db.*")
+	require.ErrorContains(t, err, "--filter isn't supported with --sql-endpoint")
+	_, err = parse("full", "-s", "local:///backup", "--sql-endpoint", "root@tcp(tidb:4000)/",
+		"--row-filter", "db.t: a > 1")
+	require.ErrorContains(t, err, "--row-filter isn't supported with --sql-endpoint")
+	_, err = parse("full", "-s", "local:///backup", "--sql-endpoint", "root@tcp(tidb:4000)/", "--pd", "pd:2379")
+	require.ErrorContains(t, err, "--pd isn't supported with --sql-endpoint")
+	_, err = parse("full", "-s", "local:///backup", "--sql-endpoint", "root@tcp(tidb:4000)/",
+		"--crypter.method", "aes128-ctr", "--crypter.key", "0123456789abcdef0123456789abcdef")
+	require.ErrorContains(t, err, "isn't supported with --sql-endpoint")
+
+	// the backend options are passed by the query parameters, the ones in the URL take precedence.
+	cfg, err = parse("full", "-s", "s3://bucket/backup?region=us-west-1", "--sql-endpoint", "root@tcp(tidb:4000)/",
+		"--s3.endpoint", "http://minio:9000", "--s3.region", "us-east-1", "--gcs.endpoint", "http://gcs")
+	require.NoError(t, err)
+	require.Contains(t, cfg.restoreSQL,
+		"FROM 's3://bucket/backup?endpoint=http%3A%2F%2Fminio%3A9000&region=us-west-1' ")
+	_, err = parse("full", "-s", "gcs://bucket/backup", "--sql-endpoint", "root@tcp(tidb:4000)/",
+		"--gcs.credentials-file", "/creds.json")
+	require.ErrorContains(t, err, "--gcs.credentials-file isn't supported with --sql-endpoint")
+}
+
+func TestRunRestoreSQL(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	const stmt = "RESTORE DATABASE * FROM 's3://bucket/backup?access-key=ak'"
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT CONNECTION_ID()")).WillReturnRows(sqlmock.NewRows([]string{"CONNECTION_ID()"}).AddRow(42))
+	mock.ExpectQuery(regexp.QuoteMeta(stmt)).WillReturnRows(
+		sqlmock.NewRows([]string{"Destination", "Size", "BackupTS", "Cluster TS", "Queue Time", "Execution Time"}).
+			AddRow("s3://bucket/backup?access-key=ak", 1024, 100, 200, "2026-01-01 00:00:00", "2026-01-01 00:00:01"))
+	require.NoError(t, runRestoreSQL(context.Background(), db, stmt, time.Hour))
+	require.NoError(t, mock.ExpectationsWereMet())
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT CONNECTION_ID()")).WillReturnRows(sqlmock.NewRows([]string{"CONNECTION_ID()"}).AddRow(42))
+	mock.ExpectQuery(regexp.QuoteMeta(stmt)).WillReturnError(context.DeadlineExceeded)
+	err = runRestoreSQL(context.Background(), db, stmt, time.Hour)
+	require.ErrorContains(t, err, "failed to restore via the SQL endpoint")
+	require.NoError(t, mock.ExpectationsWereMet())
+}
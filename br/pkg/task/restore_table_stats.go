@@ -0,0 +1,199 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package task
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/csv"
+	"os"
+	"slices"
+	"strconv"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/log"
+	snapclient "github.com/pingcap/tidb/br/pkg/restore/snap_client"
+	"github.com/pingcap/tidb/br/pkg/summary"
+	"github.com/pingcap/tidb/pkg/infoschema"
+	"github.com/pingcap/tidb/pkg/meta/model"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// slowestTableCount is the count of the slowest tables reported in the restore summary.
+const slowestTableCount = 10
+
+const (
+	// tableStatsPhaseSnapshot is the phase of the tables restored from the snapshot backup.
+	tableStatsPhaseSnapshot = "snapshot"
+	// tableStatsPhaseLog is the phase of the tables restored from the log backup.
+	tableStatsPhaseLog = "log"
+)
+
+// restoreTableStats is the restore statistics of a table, the partitions are counted into their table.
+type restoreTableStats struct {
+	DB    string
+	Table string
+	snapclient.TableStats
+}
+
+// MarshalLogObject implements zapcore.ObjectMarshaler.
+func (s restoreTableStats) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("db", s.DB)
+	enc.AddString("table", s.Table)
+	enc.AddInt("files", s.Files)
+	enc.AddUint64("kvs", s.KVs)
+	enc.AddUint64("bytes", s.Bytes)
+	enc.AddUint64("rewritten-kvs", s.RewrittenKVs)
+	enc.AddDuration("duration", s.Duration)
+	return nil
+}
+
+// add counts the statistics of a physical table into the table.
+func (s *restoreTableStats) add(physical snapclient.TableStats) {
+	s.Files += physical.Files
+	s.KVs += physical.KVs
+	s.Bytes += physical.Bytes
+	s.RewrittenKVs += physical.RewrittenKVs
+	s.Duration += physical.Duration
+}
+
+// aggregateTableStats counts the statistics of the physical tables into the created tables.
+func aggregateTableStats(stats []snapclient.TableStats, tables []*snapclient.CreatedTable) []restoreTableStats {
+	physicalToTable := make(map[int64]int, len(tables))
+	result := make([]restoreTableStats, 0, len(tables))
+	for _, table := range tables {
+		physicalToTable[table.Table.ID] = len(result)
+		if partitions := table.Table.GetPartitionInfo(); partitions != nil {
+			for _, def := range partitions.Definitions {
+				physicalToTable[def.ID] = len(result)
+			}
+		}
+		result = append(result, restoreTableStats{
+			DB:         table.OldTable.DB.Name.O,
+			Table:      table.Table.Name.O,
+			TableStats: snapclient.TableStats{PhysicalID: table.Table.ID},
+		})
+	}
+	for _, s := range stats {
+		i, ok := physicalToTable[s.PhysicalID]
+		if !ok {
+			log.Warn("the restored table isn't created by the restore", zap.Int64("table-id", s.PhysicalID))
+			continue
+		}
+		result[i].add(s)
+	}
+	return result
+}
+
+// slowestTables returns at most n tables ordered by the import time descending.
+func slowestTables(stats []restoreTableStats, n int) []restoreTableStats {
+	sorted := slices.Clone(stats)
+	slices.SortStableFunc(sorted, func(a, b restoreTableStats) int {
+		return cmp.Compare(b.Duration, a.Duration)
+	})
+	return sorted[:min(n, len(sorted))]
+}
+
+// resolveTableStats counts the statistics of the physical tables into the tables of the info schema by the
+// downstream IDs, for the log restore which doesn't create the tables by itself.
+func resolveTableStats(stats []snapclient.TableStats, is infoschema.InfoSchema) []restoreTableStats {
+	tableIndexes := make(map[int64]int, len(stats))
+	result := make([]restoreTableStats, 0, len(stats))
+	for _, s := range stats {
+		var (
+			info *model.TableInfo
+			db   *model.DBInfo
+			ok   bool
+		)
+		if info, ok = is.TableInfoByID(s.PhysicalID); ok {
+			db, ok = is.SchemaByID(info.DBID)
+		} else if tbl, partitionDB, _ := is.FindTableByPartitionID(s.PhysicalID); tbl != nil {
+			info, db, ok = tbl.Meta(), partitionDB, true
+		}
+		if !ok {
+			log.Warn("the restored table doesn't exist", zap.Int64("table-id", s.PhysicalID))
+			continue
+		}
+		i, ok := tableIndexes[info.ID]
+		if !ok {
+			i = len(result)
+			tableIndexes[info.ID] = i
+			result = append(result, restoreTableStats{
+				DB:         db.Name.O,
+				Table:      info.Name.O,
+				TableStats: snapclient.TableStats{PhysicalID: info.ID},
+			})
+		}
+		result[i].add(s)
+	}
+	return result
+}
+
+// encodeTableStatsCSV encodes the statistics of the tables restored in the phase into a CSV, with the
+// header if it's set.
+func encodeTableStatsCSV(phase string, stats []restoreTableStats, header bool) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	records := make([][]string, 0, len(stats)+1)
+	if header {
+		records = append(records, []string{
+			"phase", "db", "table", "table_id", "files", "kvs", "bytes", "rewritten_kvs", "duration_seconds",
+		})
+	}
+	for _, s := range stats {
+		records = append(records, []string{
+			phase,
+			s.DB,
+			s.Table,
+			strconv.FormatInt(s.PhysicalID, 10),
+			strconv.Itoa(s.Files),
+			strconv.FormatUint(s.KVs, 10),
+			strconv.FormatUint(s.Bytes, 10),
+			strconv.FormatUint(s.RewrittenKVs, 10),
+			strconv.FormatFloat(s.Duration.Seconds(), 'f', 3, 64),
+		})
+	}
+	if err := w.WriteAll(records); err != nil {
+		return nil, errors.Trace(err)
+	}
+	return buf.Bytes(), nil
+}
+
+// reportTableStats logs the slowest tables restored in the phase into the summary, and appends the
+// statistics of all the tables to the local CSV file if it's set, so the point in time restore reports
+// both the snapshot restore and the log restore. The failure of writing is only logged.
+func reportTableStats(path, phase string, stats []restoreTableStats) {
+	if len(stats) == 0 {
+		return
+	}
+	slowest := slowestTables(stats, slowestTableCount)
+	summary.Log("restore slowest tables", zap.String("phase", phase), zap.Objects("tables", slowest))
+	if path == "" {
+		return
+	}
+	if err := appendTableStatsCSV(path, phase, stats); err != nil {
+		log.Warn("failed to write the restore statistics of the tables", zap.String("path", path), zap.Error(err))
+		return
+	}
+	log.Info("the restore statistics of the tables are written", zap.String("path", path),
+		zap.String("phase", phase), zap.Int("tables", len(stats)))
+}
+
+func appendTableStatsCSV(path, phase string, stats []restoreTableStats) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	data, err := encodeTableStatsCSV(phase, stats, info.Size() == 0)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	_, err = f.Write(data)
+	return errors.Trace(err)
+}
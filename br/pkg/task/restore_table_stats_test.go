@@ -0,0 +1,77 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package task
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pingcap/tidb/br/pkg/metautil"
+	snapclient "github.com/pingcap/tidb/br/pkg/restore/snap_client"
+	"github.com/pingcap/tidb/pkg/meta/model"
+	"github.com/pingcap/tidb/pkg/parser/ast"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRestoreTableStats(t *testing.T) {
+	db := &model.DBInfo{Name: ast.NewCIStr("test")}
+	tables := []*snapclient.CreatedTable{
+		{
+			Table:    &model.TableInfo{ID: 10, Name: ast.NewCIStr("t1")},
+			OldTable: &metautil.Table{DB: db},
+		},
+		{
+			Table: &model.TableInfo{ID: 20, Name: ast.NewCIStr("t2"), Partition: &model.PartitionInfo{
+				Enable:      true,
+				Definitions: []model.PartitionDefinition{{ID: 21}, {ID: 22}},
+			}},
+			OldTable: &metautil.Table{DB: db},
+		},
+		{
+			Table:    &model.TableInfo{ID: 30, Name: ast.NewCIStr("empty")},
+			OldTable: &metautil.Table{DB: db},
+		},
+	}
+	stats := aggregateTableStats([]snapclient.TableStats{
+		{PhysicalID: 10, Files: 2, KVs: 10, Bytes: 100, RewrittenKVs: 10, Duration: time.Second},
+		// the partitions are counted into the partitioned table, and their import time is summed.
+		{PhysicalID: 21, Files: 1, KVs: 5, Bytes: 50, Duration: 3 * time.Second},
+		{PhysicalID: 22, Files: 1, KVs: 6, Bytes: 60, Duration: 2 * time.Second},
+		// the table isn't created by the restore.
+		{PhysicalID: 40, Files: 1},
+	}, tables)
+	require.Equal(t, []restoreTableStats{
+		{DB: "test", Table: "t1", TableStats: snapclient.TableStats{
+			PhysicalID: 10, Files: 2, KVs: 10, Bytes: 100, RewrittenKVs: 10, Duration: time.Second,
+		}},
+		{DB: "test", Table: "t2", TableStats: snapclient.TableStats{
+			PhysicalID: 20, Files: 2, KVs: 11, Bytes: 110, Duration: 5 * time.Second,
+		}},
+		{DB: "test", Table: "empty", TableStats: snapclient.TableStats{PhysicalID: 30}},
+	}, stats)
+
+	slowest := slowestTables(stats, 2)
+	require.Len(t, slowest, 2)
+	require.Equal(t, "t2", slowest[0].Table)
+	require.Equal(t, "t1", slowest[1].Table)
+	require.Len(t, slowestTables(stats, 10), 3)
+
+	data, err := encodeTableStatsCSV(tableStatsPhaseSnapshot, stats, true)
+	require.NoError(t, err)
+	require.Equal(t, "phase,db,table,table_id,files,kvs,bytes,rewritten_kvs,duration_seconds\n"+
+		"snapshot,test,t1,10,2,10,100,10,1.000\n"+
+		"snapshot,test,t2,20,2,11,110,0,5.000\n"+
+		"snapshot,test,empty,30,0,0,0,0,0.000\n", string(data))
+
+	// the header is only written into the new file, and the log restore appends to it.
+	path := filepath.Join(t.TempDir(), "table-stats.csv")
+	reportTableStats(path, tableStatsPhaseSnapshot, stats[:1])
+	reportTableStats(path, tableStatsPhaseLog, stats[1:2])
+	data, err = os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, "phase,db,table,table_id,files,kvs,bytes,rewritten_kvs,duration_seconds\n"+
+		"snapshot,test,t1,10,2,10,100,10,1.000\n"+
+		"log,test,t2,20,2,11,110,0,5.000\n", string(data))
+}
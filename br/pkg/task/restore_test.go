@@ -523,3 +523,53 @@ func TestCheckTikvSpace(t *testing.T) {
 	store := pdhttp.StoreInfo{Store: pdhttp.MetaStore{ID: 1}, Status: pdhttp.StoreStatus{Available: "500PB"}}
 	require.NoError(t, task.CheckStoreSpace(400*pb, &store))
 }
+
+func TestRetryRestoreWithToken(t *testing.T) {
+	ctx := context.Background()
+	m, err := mock.NewCluster()
+	require.NoError(t, err)
+	require.NoError(t, m.Start())
+	defer m.Stop()
+	g := gluetidb.New()
+
+	cfg := &task.RestoreConfig{}
+	cfg.Storage = "s3://bucket/log"
+	cfg.FullBackupStorage = "s3://bucket/full"
+	cfg.IdempotencyToken = "token"
+	cfg.UseCheckpoint = true
+	cfg.FilterStr = []string{"db.*"}
+	fingerprint := task.RestoreTokenFingerprint(task.PointRestoreCmd, cfg)
+	finished, crashed, err := task.AcquireRestoreToken(ctx, g, m.Storage, cfg, fingerprint)
+	require.NoError(t, err)
+	require.False(t, finished)
+
+	// the unfinished restore is taken over by the retry.
+	finished, owner, err := task.AcquireRestoreToken(ctx, g, m.Storage, cfg, fingerprint)
+	require.NoError(t, err)
+	require.False(t, finished)
+	require.NotEqual(t, crashed, owner)
+	// the token can't be finished by the run taken over.
+	require.Error(t, task.FinishRestoreToken(ctx, g, m.Storage, cfg, crashed))
+	// the restored ts is adjusted by the point restore.
+	restoredCfg := *cfg
+	restoredCfg.RestoreTS = 456
+	require.NoError(t, task.FinishRestoreToken(ctx, g, m.Storage, &restoredCfg, owner))
+
+	// the retry with the same flags is finished.
+	retryCfg := *cfg
+	fingerprint = task.RestoreTokenFingerprint(task.PointRestoreCmd, &retryCfg)
+	finished, _, err = task.AcquireRestoreToken(ctx, g, m.Storage, &retryCfg, fingerprint)
+	require.NoError(t, err)
+	require.True(t, finished)
+
+	// the token can't be used by another restore, e.g. of other tables or backups.
+	retryCfg.FilterStr = []string{"db.t"}
+	fingerprint = task.RestoreTokenFingerprint(task.PointRestoreCmd, &retryCfg)
+	_, _, err = task.AcquireRestoreToken(ctx, g, m.Storage, &retryCfg, fingerprint)
+	require.Error(t, err)
+	retryCfg = *cfg
+	retryCfg.Storage = "s3://bucket/other"
+	fingerprint = task.RestoreTokenFingerprint(task.PointRestoreCmd, &retryCfg)
+	_, _, err = task.AcquireRestoreToken(ctx, g, m.Storage, &retryCfg, fingerprint)
+	require.Error(t, err)
+}
@@ -0,0 +1,134 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package task
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/log"
+	"github.com/pingcap/tidb/br/pkg/conn"
+	berrors "github.com/pingcap/tidb/br/pkg/errors"
+	"github.com/pingcap/tidb/br/pkg/glue"
+	"github.com/pingcap/tidb/br/pkg/restore/tiflashrec"
+	"github.com/pingcap/tidb/br/pkg/summary"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"go.uber.org/zap"
+)
+
+const (
+	flagTiFlashReplicasFile = "file"
+
+	// RestoreTiFlashReplicasCmd is the name of `br restore tiflash-replicas`.
+	RestoreTiFlashReplicasCmd = "Restore TiFlash Replicas"
+)
+
+// saveDeferredTiFlashReplicas saves the TiFlash replicas recorded by the log restore into the storage of
+// --defer-tiflash-replicas, instead of setting them.
+func saveDeferredTiFlashReplicas(ctx context.Context, mgr *conn.Mgr, cfg *RestoreConfig) error {
+	_, s, err := GetStorage(ctx, cfg.DeferTiFlashReplicas, &cfg.Config)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	file := cfg.tiflashRecorder.ToFile(mgr.GetPDClient().GetClusterID(ctx), cfg.RestoreTS)
+	name := tiflashrec.RecorderFileName(cfg.RestoreTS)
+	if err := tiflashrec.WriteRecorderFile(ctx, s, name, file); err != nil {
+		return errors.Trace(err)
+	}
+	log.Info("saved the TiFlash replicas, they aren't set until `br restore tiflash-replicas` is executed",
+		zap.String("storage", s.URI()), zap.String("file", name), zap.Int("tables", len(file.Items)))
+	summary.Log("the TiFlash replicas are deferred", zap.String("storage", s.URI()), zap.String("file", name))
+	return nil
+}
+
+// RestoreTiFlashReplicasConfig is the config for `br restore tiflash-replicas`.
+type RestoreTiFlashReplicasConfig struct {
+	RestoreConfig
+
+	// File is the file in the storage saved by the log restore with --defer-tiflash-replicas.
+	File string `json:"file" toml:"file"`
+}
+
+// DefineRestoreTiFlashReplicasFlags defines flags for `br restore tiflash-replicas`.
+func DefineRestoreTiFlashReplicasFlags(command *cobra.Command) {
+	command.Flags().String(flagTiFlashReplicasFile, "", fmt.Sprintf("the file in the storage the TiFlash replicas "+
+		"are saved to by the log restore with --%s", FlagStreamDeferTiFlashReplicas))
+	_ = command.MarkFlagRequired(flagTiFlashReplicasFile)
+}
+
+// ParseFromFlags parses the config from the flag set.
+func (cfg *RestoreTiFlashReplicasConfig) ParseFromFlags(flags *pflag.FlagSet) error {
+	if err := cfg.RestoreConfig.ParseFromFlags(flags, false); err != nil {
+		return errors.Trace(err)
+	}
+	var err error
+	cfg.File, err = flags.GetString(flagTiFlashReplicasFile)
+	return errors.Trace(err)
+}
+
+// RunRestoreTiFlashReplicas sets the TiFlash replicas saved by the log restore. The tables dropped or
+// truncated since the restore are skipped.
+func RunRestoreTiFlashReplicas(c context.Context, g glue.Glue, cmdName string, cfg *RestoreTiFlashReplicasConfig) error {
+	g = withResourceGroup(g, cfg.ResourceGroup)
+	cfg.Adjust()
+	defer summary.Summary(cmdName)
+	ctx, cancel := context.WithCancel(c)
+	defer cancel()
+
+	_, s, err := GetStorage(ctx, cfg.Storage, &cfg.Config)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	file, err := tiflashrec.ReadRecorderFile(ctx, s, cfg.File)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	mgr, err := NewMgr(ctx, g, cfg.PD, cfg.TLS, GetKeepalive(&cfg.Config), cfg.CheckRequirements, true, conn.NormalVersionChecker)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer mgr.Close()
+	// the table IDs in the file are only valid in the cluster restored into.
+	if clusterID := mgr.GetPDClient().GetClusterID(ctx); clusterID != file.ClusterID {
+		return errors.Annotatef(berrors.ErrInvalidArgument,
+			"the TiFlash replicas are saved by the restore into the cluster %d, but the current cluster is %d",
+			file.ClusterID, clusterID)
+	}
+
+	sqls := file.Recorder().GenerateAlterTableDDLs(mgr.GetDomain().InfoSchema())
+	console := glue.GetConsole(g)
+	report := console.CreateTable()
+	defer report.Print()
+	var (
+		firstErr error
+		failed   int
+	)
+	err = g.UseOneShotSession(mgr.GetStorage(), false, func(se glue.Session) error {
+		for _, sql := range sqls {
+			if err := se.ExecuteInternal(ctx, sql); err != nil {
+				log.Warn("failed to set the TiFlash replica", zap.String("sql", sql), zap.Error(err))
+				report.Add(sql, "failed: "+err.Error())
+				failed++
+				if firstErr == nil {
+					firstErr = err
+				}
+				continue
+			}
+			report.Add(sql, "done")
+		}
+		return nil
+	})
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if firstErr != nil {
+		return errors.Annotatef(firstErr, "failed to set %d of %d TiFlash replicas", failed, len(sqls))
+	}
+
+	summary.Log(cmdName, zap.Uint64("restore-ts", file.RestoreTS), zap.Int("tables", len(sqls)),
+		zap.Int("skipped", len(file.Items)-len(sqls)))
+	summary.SetSuccessStatus(true)
+	return nil
+}
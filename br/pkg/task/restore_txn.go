@@ -26,6 +26,8 @@ func RunRestoreTxn(c context.Context, g glue.Glue, cmdName string, cfg *Config)
 	}
 
 	defer summary.Summary(cmdName)
+	defer cfg.storageRetryPolicy.Report()
+	defer cfg.collectStorageRequestStats()
 	ctx, cancel := context.WithCancel(c)
 	defer cancel()
 
@@ -54,6 +56,11 @@ func RunRestoreTxn(c context.Context, g glue.Glue, cmdName string, cfg *Config)
 	if err != nil {
 		return errors.Trace(err)
 	}
+	if consistencyPoint := metautil.GetConsistencyPoint(backupMeta); consistencyPoint != nil {
+		// the application resumes from the marker after the restore.
+		summary.Log("restore to the consistency point of the application",
+			zap.String("marker", consistencyPoint.Marker), zap.Uint64("backup-ts", consistencyPoint.BackupTS))
+	}
 	reader := metautil.NewMetaReader(backupMeta, s, &cfg.CipherInfo)
 	if err = client.LoadSchemaIfNeededAndInitClient(c, backupMeta, u, reader, true, nil, nil); err != nil {
 		return errors.Trace(err)
@@ -0,0 +1,348 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package task
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/log"
+	"github.com/pingcap/tidb/br/pkg/checkpoint"
+	"github.com/pingcap/tidb/br/pkg/checksum"
+	"github.com/pingcap/tidb/br/pkg/conn"
+	berrors "github.com/pingcap/tidb/br/pkg/errors"
+	"github.com/pingcap/tidb/br/pkg/glue"
+	"github.com/pingcap/tidb/br/pkg/metautil"
+	"github.com/pingcap/tidb/br/pkg/storage"
+	"github.com/pingcap/tidb/br/pkg/summary"
+	"github.com/pingcap/tidb/br/pkg/utils"
+	"github.com/pingcap/tidb/pkg/infoschema"
+	"github.com/pingcap/tidb/pkg/kv"
+	"github.com/pingcap/tidb/pkg/meta"
+	"github.com/pingcap/tidb/pkg/meta/model"
+	kvutil "github.com/tikv/client-go/v2/util"
+	"go.uber.org/zap"
+)
+
+// VerifyRestoreCmd is the name of `br restore verify`.
+const VerifyRestoreCmd = "Verify Restore"
+
+const (
+	verifyPass = "pass"
+	verifyFail = "fail"
+	// verifyWarn is the status of the check against the inaccurate values, e.g. the row count in statistics.
+	verifyWarn = "warn"
+	verifySkip = "skip"
+)
+
+// restoreVerifyCheck is a check of a restored table.
+type restoreVerifyCheck struct {
+	name   string
+	status string
+	detail string
+}
+
+// restoreVerifyResult is the checks of a restored table.
+type restoreVerifyResult struct {
+	db     string
+	table  string
+	checks []restoreVerifyCheck
+}
+
+func (r *restoreVerifyResult) add(name, status, detail string) {
+	r.checks = append(r.checks, restoreVerifyCheck{name: name, status: status, detail: detail})
+}
+
+// status returns the worst status of the checks.
+func (r *restoreVerifyResult) status() string {
+	status := verifyPass
+	for _, check := range r.checks {
+		switch check.status {
+		case verifyFail:
+			return verifyFail
+		case verifyWarn:
+			status = verifyWarn
+		}
+	}
+	return status
+}
+
+// RunRestoreVerify compares the restored tables in the cluster with the values recorded in the backup,
+// including the checksums, the row counts, the indexes and the auto ID watermarks.
+func RunRestoreVerify(c context.Context, g glue.Glue, cmdName string, cfg *RestoreConfig) error {
+	g = withResourceGroup(g, cfg.ResourceGroup)
+	cfg.Adjust()
+	defer summary.Summary(cmdName)
+	ctx, cancel := context.WithCancel(c)
+	defer cancel()
+
+	_, s, backupMeta, err := ReadBackupMeta(ctx, metautil.MetaFile, &cfg.Config)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	reader := metautil.NewMetaReader(backupMeta, s, &cfg.CipherInfo)
+	dbs, err := metautil.LoadBackupTables(ctx, reader, true)
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	mgr, err := NewMgr(ctx, g, cfg.PD, cfg.TLS, GetKeepalive(&cfg.Config), cfg.CheckRequirements, true, conn.NormalVersionChecker)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer mgr.Close()
+	se, err := g.CreateSession(mgr.GetStorage())
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer se.Close()
+
+	ts, err := mgr.GetCurrentTsFromPD(ctx)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	snapshot := mgr.GetStorage().GetSnapshot(kv.NewVersion(ts))
+	v := &restoreVerifier{
+		mgr:    mgr,
+		se:     se,
+		cfg:    cfg,
+		meta:   meta.NewReader(snapshot),
+		is:     mgr.GetDomain().InfoSchema(),
+		ts:     ts,
+		backup: s,
+	}
+
+	tables := make([]*metautil.Table, 0)
+	for _, db := range dbs {
+		dbName := db.Info.Name.O
+		if name, ok := utils.GetSysDBName(db.Info.Name); ok && utils.IsSysDB(name) {
+			// the system tables are merged into the ones of the cluster, they can't be verified.
+			continue
+		}
+		if checkpoint.IsCheckpointDB(db.Info.Name) {
+			continue
+		}
+		for _, table := range db.Tables {
+			if table.Info == nil || !cfg.TableFilter.MatchTable(dbName, table.Info.Name.O) {
+				continue
+			}
+			tables = append(tables, table)
+		}
+	}
+	if len(tables) == 0 {
+		return errors.Annotate(berrors.ErrInvalidArgument, "no table in the backup matches the filter")
+	}
+	slices.SortFunc(tables, func(a, b *metautil.Table) int {
+		if n := strings.Compare(a.DB.Name.L, b.DB.Name.L); n != 0 {
+			return n
+		}
+		return strings.Compare(a.Info.Name.L, b.Info.Name.L)
+	})
+
+	results := make([]*restoreVerifyResult, 0, len(tables))
+	failed := 0
+	for _, table := range tables {
+		result, err := v.verifyTable(ctx, table)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if result.status() == verifyFail {
+			failed++
+		}
+		results = append(results, result)
+	}
+	printRestoreVerifyReport(glue.GetConsole(g), results)
+
+	summary.Log(cmdName, zap.Int("tables", len(results)), zap.Int("failed", failed))
+	if failed > 0 {
+		return errors.Annotatef(berrors.ErrRestoreVerifyFailed, "%d of %d tables failed", failed, len(results))
+	}
+	summary.SetSuccessStatus(true)
+	return nil
+}
+
+type restoreVerifier struct {
+	mgr    *conn.Mgr
+	se     glue.Session
+	cfg    *RestoreConfig
+	meta   meta.Reader
+	is     infoschema.InfoSchema
+	ts     uint64
+	backup storage.ExternalStorage
+}
+
+func (v *restoreVerifier) verifyTable(ctx context.Context, table *metautil.Table) (*restoreVerifyResult, error) {
+	result := &restoreVerifyResult{db: table.DB.Name.O, table: table.Info.Name.O}
+	dbInfo, ok := v.is.SchemaByName(table.DB.Name)
+	if !ok {
+		result.add("exists", verifyFail, "the database isn't restored")
+		return result, nil
+	}
+	restored, err := v.is.TableByName(ctx, table.DB.Name, table.Info.Name)
+	if err != nil {
+		if infoschema.ErrTableNotExists.Equal(err) {
+			result.add("exists", verifyFail, "the table isn't restored")
+			return result, nil
+		}
+		return nil, errors.Trace(err)
+	}
+	info := restored.Meta()
+	if table.Info.IsView() || table.Info.IsSequence() {
+		result.add("exists", verifyPass, "")
+		return result, nil
+	}
+
+	if err := v.verifyChecksum(ctx, result, table, info); err != nil {
+		return nil, errors.Trace(err)
+	}
+	if err := v.verifyRowCount(ctx, result, table); err != nil {
+		return nil, errors.Trace(err)
+	}
+	verifyIndexes(result, table.Info, info)
+	if err := v.verifyAutoIDs(result, table.Info, dbInfo.ID, info); err != nil {
+		return nil, errors.Trace(err)
+	}
+	return result, nil
+}
+
+func (v *restoreVerifier) verifyChecksum(
+	ctx context.Context,
+	result *restoreVerifyResult,
+	table *metautil.Table,
+	info *model.TableInfo,
+) error {
+	expected := metautil.CalculateChecksumStatsOnFiles(table.Files)
+	if !expected.ChecksumExists() {
+		result.add("checksum", verifySkip, "no checksum in the backup")
+		return nil
+	}
+	exe, err := checksum.NewExecutorBuilder(info, v.ts).
+		SetOldTable(table).
+		SetConcurrency(v.cfg.ChecksumConcurrency).
+		SetExplicitRequestSourceType(kvutil.ExplicitTypeBR).
+		SetResourceGroupName(v.cfg.ResourceGroup).
+		Build()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	resp, err := exe.Execute(ctx, v.mgr.GetStorage().GetClient(), func() {})
+	if err != nil {
+		return errors.Trace(err)
+	}
+	detail := fmt.Sprintf("crc64xor %d, kvs %d, bytes %d", resp.Checksum, resp.TotalKvs, resp.TotalBytes)
+	if resp.Checksum != expected.Crc64Xor || resp.TotalKvs != expected.TotalKvs || resp.TotalBytes != expected.TotalBytes {
+		result.add("checksum", verifyFail, fmt.Sprintf("expected crc64xor %d, kvs %d, bytes %d, but got %s",
+			expected.Crc64Xor, expected.TotalKvs, expected.TotalBytes, detail))
+		return nil
+	}
+	result.add("checksum", verifyPass, detail)
+	return nil
+}
+
+func (v *restoreVerifier) verifyRowCount(ctx context.Context, result *restoreVerifyResult, table *metautil.Table) error {
+	expected, ok, err := metautil.ReadStatsRowCount(ctx, v.backup, &v.cfg.CipherInfo, table)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if !ok {
+		result.add("row count", verifySkip, "no statistics in the backup")
+		return nil
+	}
+	rows, _, err := v.se.GetSessionCtx().GetRestrictedSQLExecutor().ExecRestrictedSQL(
+		kv.WithInternalSourceType(ctx, kv.InternalTxnBR),
+		nil,
+		"SELECT COUNT(*) FROM %n.%n",
+		table.DB.Name.O, table.Info.Name.O,
+	)
+	if err != nil {
+		return errors.Annotatef(err, "failed to count the rows of %s.%s", table.DB.Name, table.Info.Name)
+	}
+	count := rows[0].GetInt64(0)
+	if count != expected {
+		// the row count in the statistics may be stale, so the mismatch isn't a failure.
+		result.add("row count", verifyWarn, fmt.Sprintf("%d rows in the statistics of the backup, but got %d", expected, count))
+		return nil
+	}
+	result.add("row count", verifyPass, fmt.Sprint(count))
+	return nil
+}
+
+func verifyIndexes(result *restoreVerifyResult, backup, restored *model.TableInfo) {
+	publicIndexes := func(info *model.TableInfo) []string {
+		names := make([]string, 0, len(info.Indices))
+		for _, index := range info.Indices {
+			if index.State == model.StatePublic {
+				names = append(names, index.Name.L)
+			}
+		}
+		slices.Sort(names)
+		return names
+	}
+	expected, got := publicIndexes(backup), publicIndexes(restored)
+	if !slices.Equal(expected, got) {
+		result.add("indexes", verifyFail, fmt.Sprintf("expected %v, but got %v", expected, got))
+		return
+	}
+	result.add("indexes", verifyPass, fmt.Sprint(len(got)))
+}
+
+// verifyAutoIDs checks the auto ID watermarks of the restored table aren't less than the backup's, otherwise
+// the inserted rows may conflict with the restored ones.
+func (v *restoreVerifier) verifyAutoIDs(result *restoreVerifyResult, backup *model.TableInfo, dbID int64, restored *model.TableInfo) error {
+	if !utils.NeedAutoID(restored) {
+		result.add("auto id", verifySkip, "no auto id")
+		return nil
+	}
+	accessors := v.meta.GetAutoIDAccessors(dbID, restored.ID)
+	var (
+		autoID int64
+		err    error
+	)
+	if restored.SepAutoInc() {
+		autoID, err = accessors.IncrementID(restored.Version).Get()
+	} else {
+		autoID, err = accessors.RowID().Get()
+	}
+	if err != nil {
+		return errors.Trace(err)
+	}
+	// the auto ID in the backup is the next ID to allocate.
+	if autoID+1 < backup.AutoIncID {
+		result.add("auto id", verifyFail, fmt.Sprintf("the next auto id %d is less than %d in the backup", autoID+1, backup.AutoIncID))
+		return nil
+	}
+	if restored.ContainsAutoRandomBits() {
+		autoRandID, err := accessors.RandomID().Get()
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if autoRandID+1 < backup.AutoRandID {
+			result.add("auto id", verifyFail, fmt.Sprintf("the next auto random id %d is less than %d in the backup",
+				autoRandID+1, backup.AutoRandID))
+			return nil
+		}
+	}
+	result.add("auto id", verifyPass, fmt.Sprint(autoID+1))
+	return nil
+}
+
+// printRestoreVerifyReport prints the status of each table, with the details of the checks not passed.
+func printRestoreVerifyReport(console glue.ConsoleOperations, results []*restoreVerifyResult) {
+	for _, result := range results {
+		status := result.status()
+		console.Printf("[%s] %s\n", strings.ToUpper(status), utils.EncloseDBAndTable(result.db, result.table))
+		if status == verifyPass {
+			continue
+		}
+		for _, check := range result.checks {
+			if check.status == verifyPass {
+				continue
+			}
+			console.Printf("    %s: %s, %s\n", check.name, check.status, check.detail)
+		}
+	}
+	log.Info("restore verified", zap.Int("tables", len(results)))
+}
@@ -0,0 +1,44 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package task
+
+import (
+	"testing"
+
+	"github.com/pingcap/tidb/pkg/meta/model"
+	"github.com/pingcap/tidb/pkg/parser/ast"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRestoreVerifyResult(t *testing.T) {
+	result := &restoreVerifyResult{db: "test", table: "t"}
+	result.add("checksum", verifyPass, "")
+	result.add("row count", verifySkip, "")
+	require.Equal(t, verifyPass, result.status())
+	result.add("row count", verifyWarn, "")
+	require.Equal(t, verifyWarn, result.status())
+	result.add("indexes", verifyFail, "")
+	require.Equal(t, verifyFail, result.status())
+}
+
+func TestVerifyIndexes(t *testing.T) {
+	newTableInfo := func(indexes ...string) *model.TableInfo {
+		info := &model.TableInfo{}
+		for _, index := range indexes {
+			info.Indices = append(info.Indices, &model.IndexInfo{Name: ast.NewCIStr(index), State: model.StatePublic})
+		}
+		return info
+	}
+
+	result := &restoreVerifyResult{}
+	verifyIndexes(result, newTableInfo("a", "B"), newTableInfo("b", "a"))
+	require.Equal(t, []restoreVerifyCheck{{name: "indexes", status: verifyPass, detail: "2"}}, result.checks)
+
+	// the non-public index in the restored table isn't counted.
+	restored := newTableInfo("a", "b")
+	restored.Indices[1].State = model.StateWriteOnly
+	result = &restoreVerifyResult{}
+	verifyIndexes(result, newTableInfo("a", "b"), restored)
+	require.Equal(t, verifyFail, result.status())
+	require.Equal(t, "expected [a b], but got [a]", result.checks[0].detail)
+}
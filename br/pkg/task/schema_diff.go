@@ -0,0 +1,387 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package task
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/log"
+	"github.com/pingcap/tidb/br/pkg/encryption"
+	berrors "github.com/pingcap/tidb/br/pkg/errors"
+	"github.com/pingcap/tidb/br/pkg/glue"
+	"github.com/pingcap/tidb/br/pkg/metautil"
+	"github.com/pingcap/tidb/br/pkg/stream"
+	"github.com/pingcap/tidb/br/pkg/utils"
+	"github.com/pingcap/tidb/pkg/meta/model"
+	"github.com/pingcap/tidb/pkg/parser/mysql"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/tikv/client-go/v2/oracle"
+	"go.uber.org/zap"
+)
+
+const (
+	flagSchemaDiffFromTS = "from-ts"
+	flagSchemaDiffToTS   = "to-ts"
+
+	// SchemaDiffCmd is the name of `br debug schema-diff`.
+	SchemaDiffCmd = "Schema Diff"
+)
+
+// SchemaDiffConfig is the config for `br debug schema-diff`.
+type SchemaDiffConfig struct {
+	Config
+
+	// FullBackupStorage is the snapshot backup taken as the base of the schemas, `--storage` is the log
+	// backup.
+	FullBackupStorage string `json:"full-backup-storage" toml:"full-backup-storage"`
+	FromTS            uint64 `json:"from-ts" toml:"from-ts"`
+	ToTS              uint64 `json:"to-ts" toml:"to-ts"`
+}
+
+// DefineSchemaDiffFlags defines flags for `br debug schema-diff`.
+func DefineSchemaDiffFlags(command *cobra.Command) {
+	command.Flags().String(flagSchemaDiffFromTS, "", "The ts the diff starts from, "+
+		"support TSO or datetime, e.g. '400036290571534337' or '2018-05-11 01:42:23+0800'")
+	command.Flags().String(flagSchemaDiffToTS, "", "The ts the diff ends at, "+
+		"support TSO or datetime, e.g. '400036290571534337' or '2018-05-11 01:42:23+0800'")
+	command.Flags().String(FlagStreamFullBackupStorage, "", "The snapshot backup taken as the base of the schemas, "+
+		"without it the tables unchanged in the log backup before --"+flagSchemaDiffFromTS+" are unknown")
+	_ = command.MarkFlagRequired(flagSchemaDiffFromTS)
+	_ = command.MarkFlagRequired(flagSchemaDiffToTS)
+}
+
+// ParseFromFlags parses the config from the flag set.
+func (cfg *SchemaDiffConfig) ParseFromFlags(flags *pflag.FlagSet) error {
+	if err := cfg.Config.ParseFromFlags(flags); err != nil {
+		return errors.Trace(err)
+	}
+	if cfg.Storage == "" {
+		return errors.Annotatef(berrors.ErrInvalidArgument, "--%s of the log backup is required", flagStorage)
+	}
+	var err error
+	if cfg.FullBackupStorage, err = flags.GetString(FlagStreamFullBackupStorage); err != nil {
+		return errors.Trace(err)
+	}
+	tsString, err := flags.GetString(flagSchemaDiffFromTS)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if cfg.FromTS, err = ParseTSString(tsString, true); err != nil {
+		return errors.Trace(err)
+	}
+	if tsString, err = flags.GetString(flagSchemaDiffToTS); err != nil {
+		return errors.Trace(err)
+	}
+	if cfg.ToTS, err = ParseTSString(tsString, true); err != nil {
+		return errors.Trace(err)
+	}
+	if cfg.FromTS == 0 || cfg.ToTS <= cfg.FromTS {
+		return errors.Annotatef(berrors.ErrInvalidArgument,
+			"--%s(%d) must be greater than --%s(%d)", flagSchemaDiffToTS, cfg.ToTS, flagSchemaDiffFromTS, cfg.FromTS)
+	}
+	return nil
+}
+
+// tableSchema is a table replayed at a ts.
+type tableSchema struct {
+	db   string
+	info *model.TableInfo
+}
+
+func (t tableSchema) name() string {
+	return utils.EncloseDBAndTable(t.db, t.info.Name.O)
+}
+
+// replaySchemas replays the tables in the snapshot backup and the changes in the log backup in memory,
+// and returns the tables existing at ts keyed by the table ID. Both of the events are sorted by the ts.
+func replaySchemas(
+	snapshotEvents, logEvents []stream.SchemaEvent,
+	dbNames map[int64]string,
+	ts uint64,
+) map[int64]tableSchema {
+	tables := make(map[int64]tableSchema)
+	apply := func(event stream.SchemaEvent) {
+		if event.Info == nil {
+			delete(tables, event.TableID)
+			return
+		}
+		db, ok := dbNames[event.DBID]
+		if !ok {
+			db = fmt.Sprintf("<db %d>", event.DBID)
+		}
+		tables[event.TableID] = tableSchema{db: db, info: event.Info}
+	}
+	i, j := 0, 0
+	for i < len(snapshotEvents) || j < len(logEvents) {
+		if j >= len(logEvents) || (i < len(snapshotEvents) && snapshotEvents[i].TS <= logEvents[j].TS) {
+			if snapshotEvents[i].TS > ts {
+				break
+			}
+			apply(snapshotEvents[i])
+			i++
+			continue
+		}
+		if logEvents[j].TS > ts {
+			break
+		}
+		apply(logEvents[j])
+		j++
+	}
+	return tables
+}
+
+// schemaDiff is the change of a table between two ts.
+type schemaDiff struct {
+	table   string
+	action  string
+	details []string
+}
+
+func columnDesc(col *model.ColumnInfo) string {
+	desc := col.FieldType.String()
+	if mysql.HasNotNullFlag(col.GetFlag()) {
+		desc += " NOT NULL"
+	}
+	if def := col.GetDefaultValue(); def != nil {
+		desc += fmt.Sprintf(" DEFAULT %v", def)
+	}
+	return desc
+}
+
+func indexDesc(idx *model.IndexInfo) string {
+	cols := make([]string, 0, len(idx.Columns))
+	for _, col := range idx.Columns {
+		cols = append(cols, col.Name.O)
+	}
+	desc := fmt.Sprintf("(%s)", strings.Join(cols, ", "))
+	if idx.Primary {
+		return "PRIMARY KEY " + desc
+	}
+	if idx.Unique {
+		return "UNIQUE " + desc
+	}
+	return desc
+}
+
+func partitionNames(info *model.TableInfo) []string {
+	pi := info.GetPartitionInfo()
+	if pi == nil {
+		return nil
+	}
+	names := make([]string, 0, len(pi.Definitions))
+	for _, def := range pi.Definitions {
+		names = append(names, def.Name.O)
+	}
+	return names
+}
+
+// diffTableInfo returns the changes of the columns, indexes and partitions of a table. The columns are
+// matched by the IDs if it's the same table, so that the renamed columns are found, otherwise by the names.
+func diffTableInfo(before, after *model.TableInfo, sameTable bool) []string {
+	details := make([]string, 0)
+	columnKey := func(col *model.ColumnInfo) string {
+		if sameTable {
+			return fmt.Sprintf("%d", col.ID)
+		}
+		return col.Name.L
+	}
+	beforeCols := make(map[string]*model.ColumnInfo, len(before.Columns))
+	for _, col := range before.Columns {
+		beforeCols[columnKey(col)] = col
+	}
+	afterKeys := make(map[string]struct{}, len(after.Columns))
+	for _, col := range after.Columns {
+		key := columnKey(col)
+		afterKeys[key] = struct{}{}
+		old, ok := beforeCols[key]
+		if !ok {
+			details = append(details, fmt.Sprintf("column %s added: %s", utils.EncloseName(col.Name.O), columnDesc(col)))
+			continue
+		}
+		if old.Name.L != col.Name.L {
+			details = append(details, fmt.Sprintf("column %s renamed to %s",
+				utils.EncloseName(old.Name.O), utils.EncloseName(col.Name.O)))
+		}
+		if oldDesc, desc := columnDesc(old), columnDesc(col); oldDesc != desc {
+			details = append(details, fmt.Sprintf("column %s changed: %s -> %s", utils.EncloseName(col.Name.O), oldDesc, desc))
+		}
+	}
+	for _, col := range before.Columns {
+		if _, ok := afterKeys[columnKey(col)]; !ok {
+			details = append(details, fmt.Sprintf("column %s dropped", utils.EncloseName(col.Name.O)))
+		}
+	}
+
+	beforeIndexes := make(map[string]*model.IndexInfo, len(before.Indices))
+	for _, idx := range before.Indices {
+		beforeIndexes[idx.Name.L] = idx
+	}
+	afterIndexes := make(map[string]struct{}, len(after.Indices))
+	for _, idx := range after.Indices {
+		afterIndexes[idx.Name.L] = struct{}{}
+		old, ok := beforeIndexes[idx.Name.L]
+		switch {
+		case !ok:
+			details = append(details, fmt.Sprintf("index %s added: %s", utils.EncloseName(idx.Name.O), indexDesc(idx)))
+		case indexDesc(old) != indexDesc(idx):
+			details = append(details, fmt.Sprintf("index %s changed: %s -> %s",
+				utils.EncloseName(idx.Name.O), indexDesc(old), indexDesc(idx)))
+		}
+	}
+	for _, idx := range before.Indices {
+		if _, ok := afterIndexes[idx.Name.L]; !ok {
+			details = append(details, fmt.Sprintf("index %s dropped", utils.EncloseName(idx.Name.O)))
+		}
+	}
+
+	if oldParts, parts := partitionNames(before), partitionNames(after); !slices.Equal(oldParts, parts) {
+		details = append(details, fmt.Sprintf("partitions changed: [%s] -> [%s]",
+			strings.Join(oldParts, ", "), strings.Join(parts, ", ")))
+	}
+	return details
+}
+
+// diffSchemaTables compares the tables at two ts. complete tells whether all the tables existing at the
+// first ts are known, otherwise a table first changed in the window can't be told from a created one.
+func diffSchemaTables(before, after map[int64]tableSchema, complete bool) []schemaDiff {
+	diffs := make([]schemaDiff, 0)
+	// the tables dropped and the ones created with the same name, e.g. truncated.
+	droppedByName := make(map[string]int64)
+	for id, table := range before {
+		if _, ok := after[id]; !ok {
+			droppedByName[table.name()] = id
+		}
+	}
+	recreated := make(map[int64]struct{})
+	createdAction := "created"
+	if !complete {
+		createdAction = "created or first changed"
+	}
+	for id, table := range after {
+		old, ok := before[id]
+		if !ok {
+			if oldID, ok := droppedByName[table.name()]; ok {
+				recreated[oldID] = struct{}{}
+				diffs = append(diffs, schemaDiff{
+					table:   table.name(),
+					action:  fmt.Sprintf("recreated, id %d -> %d", oldID, id),
+					details: diffTableInfo(before[oldID].info, table.info, false),
+				})
+				continue
+			}
+			diffs = append(diffs, schemaDiff{table: table.name(), action: fmt.Sprintf("%s, id %d", createdAction, id)})
+			continue
+		}
+		details := make([]string, 0)
+		if old.name() != table.name() {
+			details = append(details, fmt.Sprintf("renamed from %s", old.name()))
+		}
+		details = append(details, diffTableInfo(old.info, table.info, true)...)
+		if len(details) == 0 && old.info.UpdateTS != table.info.UpdateTS {
+			details = append(details, "other options changed")
+		}
+		if len(details) > 0 {
+			diffs = append(diffs, schemaDiff{table: table.name(), action: fmt.Sprintf("altered, id %d", id), details: details})
+		}
+	}
+	for id, table := range before {
+		if _, ok := after[id]; ok {
+			continue
+		}
+		if _, ok := recreated[id]; ok {
+			continue
+		}
+		diffs = append(diffs, schemaDiff{table: table.name(), action: fmt.Sprintf("dropped, id %d", id)})
+	}
+	sort.Slice(diffs, func(i, j int) bool {
+		if diffs[i].table != diffs[j].table {
+			return diffs[i].table < diffs[j].table
+		}
+		return diffs[i].action < diffs[j].action
+	})
+	return diffs
+}
+
+// RunSchemaDiff replays the meta kv entries of the log backup in memory, and prints the changes of the
+// tables between two ts, so that the users can pick the ts to restore to before an accidental DDL.
+func RunSchemaDiff(c context.Context, g glue.Glue, cmdName string, cfg *SchemaDiffConfig) error {
+	ctx, cancel := context.WithCancel(c)
+	defer cancel()
+
+	dbNames := make(map[int64]string)
+	snapshotEvents := make([]stream.SchemaEvent, 0)
+	if cfg.FullBackupStorage != "" {
+		snapshotCfg := cfg.Config
+		snapshotCfg.Storage = cfg.FullBackupStorage
+		_, s, backupMeta, err := ReadBackupMeta(ctx, metautil.MetaFile, &snapshotCfg)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if backupMeta.GetEndVersion() > cfg.FromTS {
+			return errors.Annotatef(berrors.ErrInvalidArgument, "the snapshot backup at %d is after --%s(%d)",
+				backupMeta.GetEndVersion(), flagSchemaDiffFromTS, cfg.FromTS)
+		}
+		reader := metautil.NewMetaReader(backupMeta, s, &cfg.CipherInfo)
+		dbs, err := metautil.LoadBackupTables(ctx, reader, false)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		for _, db := range dbs {
+			dbNames[db.Info.ID] = db.Info.Name.O
+			for _, table := range db.Tables {
+				if table.Info == nil {
+					continue
+				}
+				snapshotEvents = append(snapshotEvents, stream.SchemaEvent{
+					TS: backupMeta.GetEndVersion(), DBID: db.Info.ID, TableID: table.Info.ID, Info: table.Info})
+			}
+		}
+	}
+
+	_, s, err := GetStorage(ctx, cfg.Storage, &cfg.Config)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	encryptionManager, err := encryption.NewManager(&cfg.LogBackupCipherInfo, &cfg.MasterKeyConfig)
+	if err != nil {
+		return errors.Annotate(err, "failed to create encryption manager for log backup")
+	}
+	defer encryptionManager.Close()
+	search := stream.NewSchemaSearch(s, stream.NewMetadataHelper(stream.WithEncryptionManager(encryptionManager)), "")
+	search.SetEndTs(cfg.ToTS)
+	result, err := search.SearchEvents(ctx)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	for id, name := range result.DBNames {
+		dbNames[id] = name
+	}
+
+	before := replaySchemas(snapshotEvents, result.Events, dbNames, cfg.FromTS)
+	after := replaySchemas(snapshotEvents, result.Events, dbNames, cfg.ToTS)
+	diffs := diffSchemaTables(before, after, cfg.FullBackupStorage != "")
+
+	console := glue.GetConsole(g)
+	formatTS := func(ts uint64) string {
+		return fmt.Sprintf("%d (%s)", ts, stream.FormatDate(oracle.GetTimeFromTS(ts)))
+	}
+	console.Printf("schema diff from %s to %s:\n", formatTS(cfg.FromTS), formatTS(cfg.ToTS))
+	if len(diffs) == 0 {
+		console.Println("  no table is changed")
+	}
+	for _, diff := range diffs {
+		console.Printf("  %s %s\n", diff.table, diff.action)
+		for _, detail := range diff.details {
+			console.Printf("    %s\n", detail)
+		}
+	}
+	log.Info("diffed schema", zap.String("cmd", cmdName), zap.Uint64("from-ts", cfg.FromTS),
+		zap.Uint64("to-ts", cfg.ToTS), zap.Int("changes", len(diffs)))
+	return nil
+}
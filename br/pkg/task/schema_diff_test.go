@@ -0,0 +1,67 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package task
+
+import (
+	"testing"
+
+	"github.com/pingcap/tidb/br/pkg/stream"
+	"github.com/pingcap/tidb/pkg/meta/model"
+	"github.com/pingcap/tidb/pkg/parser/ast"
+	"github.com/pingcap/tidb/pkg/parser/mysql"
+	"github.com/pingcap/tidb/pkg/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchemaDiff(t *testing.T) {
+	newColumn := func(id int64, name string, tp byte) *model.ColumnInfo {
+		return &model.ColumnInfo{ID: id, Name: ast.NewCIStr(name), FieldType: *types.NewFieldType(tp)}
+	}
+	newInfo := func(name string, updateTS uint64, columns ...*model.ColumnInfo) *model.TableInfo {
+		return &model.TableInfo{Name: ast.NewCIStr(name), UpdateTS: updateTS, Columns: columns}
+	}
+	dbNames := map[int64]string{1: "test"}
+	snapshot := []stream.SchemaEvent{
+		{TS: 10, DBID: 1, TableID: 100, Info: newInfo("t1", 10, newColumn(1, "id", mysql.TypeLong))},
+		{TS: 10, DBID: 1, TableID: 101, Info: newInfo("t2", 10, newColumn(1, "id", mysql.TypeLong))},
+		{TS: 10, DBID: 1, TableID: 102, Info: newInfo("t3", 10, newColumn(1, "id", mysql.TypeLong))},
+	}
+	withIndex := newInfo("t2", 25, newColumn(1, "id", mysql.TypeLonglong), newColumn(2, "name", mysql.TypeVarchar))
+	withIndex.Indices = []*model.IndexInfo{{Name: ast.NewCIStr("idx"), Columns: []*model.IndexColumn{{Name: ast.NewCIStr("name")}}}}
+	events := []stream.SchemaEvent{
+		// the change before the window is applied to both sides.
+		{TS: 15, DBID: 1, TableID: 100, Info: newInfo("t1", 15, newColumn(1, "uid", mysql.TypeLong))},
+		{TS: 16, DBID: 1, TableID: 107, Info: newInfo("t5", 16)},
+		// t1 is renamed, t2 is altered, t3 is truncated, t4 is created and t5 is dropped.
+		{TS: 21, DBID: 1, TableID: 100, Info: newInfo("t1_old", 21, newColumn(1, "uid", mysql.TypeLong))},
+		{TS: 22, DBID: 1, TableID: 101, Info: withIndex},
+		{TS: 23, DBID: 1, TableID: 102},
+		{TS: 23, DBID: 1, TableID: 105, Info: newInfo("t3", 23, newColumn(1, "id", mysql.TypeLong))},
+		{TS: 24, DBID: 1, TableID: 106, Info: newInfo("t4", 24)},
+		{TS: 26, DBID: 1, TableID: 107},
+		// the change after the window is ignored.
+		{TS: 40, DBID: 1, TableID: 106},
+	}
+
+	before := replaySchemas(snapshot, events, dbNames, 20)
+	require.Len(t, before, 4)
+	require.Equal(t, "uid", before[100].info.Columns[0].Name.O)
+	after := replaySchemas(snapshot, events, dbNames, 30)
+
+	diffs := diffSchemaTables(before, after, true)
+	require.Equal(t, []schemaDiff{
+		{table: "`test`.`t1_old`", action: "altered, id 100", details: []string{"renamed from `test`.`t1`"}},
+		{table: "`test`.`t2`", action: "altered, id 101", details: []string{
+			"column `id` changed: int(11) -> bigint(20)",
+			"column `name` added: varchar(5) CHARACTER SET utf8mb4 COLLATE utf8mb4_bin",
+			"index `idx` added: (name)",
+		}},
+		{table: "`test`.`t3`", action: "recreated, id 102 -> 105", details: []string{}},
+		{table: "`test`.`t4`", action: "created, id 106"},
+		{table: "`test`.`t5`", action: "dropped, id 107"},
+	}, diffs)
+
+	// without the snapshot backup, the tables first changed in the window may exist before it.
+	diffs = diffSchemaTables(replaySchemas(nil, events, dbNames, 20), replaySchemas(nil, events, dbNames, 30), false)
+	require.Equal(t, "created or first changed, id 101", diffs[1].action)
+}
@@ -0,0 +1,362 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package task
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/log"
+	"github.com/pingcap/tidb/br/pkg/encryption"
+	berrors "github.com/pingcap/tidb/br/pkg/errors"
+	"github.com/pingcap/tidb/br/pkg/glue"
+	"github.com/pingcap/tidb/br/pkg/metautil"
+	"github.com/pingcap/tidb/br/pkg/stream"
+	"github.com/pingcap/tidb/br/pkg/utils"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/tikv/client-go/v2/oracle"
+	"go.uber.org/zap"
+)
+
+const (
+	flagSearchSchemaName = "name"
+
+	// SearchSchemaCmd is the name of `br debug search-schema`.
+	SearchSchemaCmd = "Search Schema"
+)
+
+// SearchSchemaConfig is the config for `br debug search-schema`.
+type SearchSchemaConfig struct {
+	Config
+
+	// Name is the table or column name to search, it is case-insensitive.
+	Name string `json:"name" toml:"name"`
+	// FullBackupStorage is the snapshot backup to search, `--storage` is the log backup.
+	FullBackupStorage string `json:"full-backup-storage" toml:"full-backup-storage"`
+	// StartTS and EndTS limit the log backup to search, zero means unlimited.
+	StartTS uint64 `json:"start-ts" toml:"start-ts"`
+	EndTS   uint64 `json:"end-ts" toml:"end-ts"`
+}
+
+// DefineSearchSchemaFlags defines flags for `br debug search-schema`.
+func DefineSearchSchemaFlags(command *cobra.Command) {
+	command.Flags().String(flagSearchSchemaName, "", "The table or column name to search, case-insensitive")
+	command.Flags().String(FlagStreamFullBackupStorage, "", "The snapshot backup to search, "+
+		"--storage is taken as the log backup if it is given")
+	command.Flags().String(FlagStreamStartTS, "", "Only search the log backup after this ts, "+
+		"support TSO or datetime, e.g. '400036290571534337' or '2018-05-11 01:42:23+0800'")
+	command.Flags().String(flagStreamEndTS, "", "Only search the log backup before this ts, "+
+		"support TSO or datetime, e.g. '400036290571534337' or '2018-05-11 01:42:23+0800'")
+	_ = command.MarkFlagRequired(flagSearchSchemaName)
+}
+
+// ParseFromFlags parses the config from the flag set.
+func (cfg *SearchSchemaConfig) ParseFromFlags(flags *pflag.FlagSet) error {
+	if err := cfg.Config.ParseFromFlags(flags); err != nil {
+		return errors.Trace(err)
+	}
+	var err error
+	if cfg.Name, err = flags.GetString(flagSearchSchemaName); err != nil {
+		return errors.Trace(err)
+	}
+	if cfg.Name == "" {
+		return errors.Annotatef(berrors.ErrInvalidArgument, "--%s is required", flagSearchSchemaName)
+	}
+	if cfg.FullBackupStorage, err = flags.GetString(FlagStreamFullBackupStorage); err != nil {
+		return errors.Trace(err)
+	}
+	if cfg.Storage == "" && cfg.FullBackupStorage == "" {
+		return errors.Annotatef(berrors.ErrInvalidArgument, "at least one of --%s and --%s is required",
+			flagStorage, FlagStreamFullBackupStorage)
+	}
+	tsString, err := flags.GetString(FlagStreamStartTS)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if cfg.StartTS, err = ParseTSString(tsString, true); err != nil {
+		return errors.Trace(err)
+	}
+	if tsString, err = flags.GetString(flagStreamEndTS); err != nil {
+		return errors.Trace(err)
+	}
+	if cfg.EndTS, err = ParseTSString(tsString, true); err != nil {
+		return errors.Trace(err)
+	}
+	if cfg.EndTS > 0 && cfg.EndTS < cfg.StartTS {
+		return errors.Annotatef(berrors.ErrInvalidArgument,
+			"--%s(%d) is less than --%s(%d)", flagStreamEndTS, cfg.EndTS, FlagStreamStartTS, cfg.StartTS)
+	}
+	return nil
+}
+
+// schemaChange is a change of a matched table shown in the history.
+type schemaChange struct {
+	ts      uint64
+	action  string
+	tableID int64
+	db      string
+	table   string
+	matched string
+}
+
+// schemaLifetime is a period in which a matched table exists in the backup.
+type schemaLifetime struct {
+	tableID int64
+	db      string
+	table   string
+	// since is the ts the table is first seen, or the ts of the snapshot backup if the table exists in it.
+	since      uint64
+	inSnapshot bool
+	// until is the ts the table is dropped, zero if the table isn't dropped by the end of the backup.
+	until uint64
+}
+
+// buildSchemaHistory merges the tables in the snapshot backup and the changes in the log backup, and
+// returns the changes and the lifetimes of each table ID. The snapshot events are the tables existing at
+// the ts of the snapshot backup, both of the events are sorted by the ts.
+func buildSchemaHistory(
+	name string,
+	snapshotEvents []stream.SchemaEvent,
+	logEvents []stream.SchemaEvent,
+	dbNames map[int64]string,
+) ([]schemaChange, []*schemaLifetime) {
+	type state struct {
+		alive    bool
+		db       string
+		table    string
+		lifetime *schemaLifetime
+	}
+	states := make(map[int64]*state)
+	changes := make([]schemaChange, 0, len(logEvents))
+	lifetimes := make([]*schemaLifetime, 0)
+
+	dbName := func(id int64) string {
+		if name, ok := dbNames[id]; ok {
+			return name
+		}
+		return fmt.Sprintf("<db %d>", id)
+	}
+	apply := func(event stream.SchemaEvent, inSnapshot bool) {
+		st, ok := states[event.TableID]
+		if !ok {
+			st = &state{}
+			states[event.TableID] = st
+		}
+		if event.Info == nil {
+			if st.alive {
+				st.alive = false
+				st.lifetime.until = event.TS
+			}
+			changes = append(changes, schemaChange{ts: event.TS, action: "dropped", tableID: event.TableID,
+				db: st.db, table: st.table})
+			return
+		}
+
+		db, table := dbName(event.DBID), event.Info.Name.O
+		matched, _ := stream.MatchSchemaName(event.Info, name)
+		action := "changed"
+		switch {
+		case inSnapshot && st.alive:
+			// the table created in the log backup before the snapshot backup.
+			return
+		case inSnapshot:
+			action = "in snapshot"
+			st.lifetime = &schemaLifetime{tableID: event.TableID, since: event.TS, inSnapshot: true}
+			lifetimes = append(lifetimes, st.lifetime)
+		case !st.alive && st.lifetime != nil && st.lifetime.until == event.TS:
+			// the table moved to another database is dropped and put at the same ts.
+			action = fmt.Sprintf("moved from %s", utils.EncloseDBAndTable(st.db, st.table))
+			st.lifetime.until = 0
+			for k := len(changes) - 1; k >= 0; k-- {
+				if changes[k].tableID == event.TableID && changes[k].ts == event.TS {
+					changes = append(changes[:k], changes[k+1:]...)
+					break
+				}
+			}
+		case !st.alive:
+			// the table info is written by the most of the DDLs, so the table may be created before the
+			// first write in the log backup.
+			action = "first seen"
+			if st.lifetime != nil {
+				action = "recovered"
+			}
+			st.lifetime = &schemaLifetime{tableID: event.TableID, since: event.TS}
+			lifetimes = append(lifetimes, st.lifetime)
+		case st.db != db || st.table != table:
+			action = fmt.Sprintf("renamed from %s", utils.EncloseDBAndTable(st.db, st.table))
+		}
+		st.alive, st.db, st.table = true, db, table
+		st.lifetime.db, st.lifetime.table = db, table
+		changes = append(changes, schemaChange{ts: event.TS, action: action, tableID: event.TableID,
+			db: db, table: table, matched: matched})
+	}
+
+	i, j := 0, 0
+	for i < len(snapshotEvents) || j < len(logEvents) {
+		if j >= len(logEvents) || (i < len(snapshotEvents) && snapshotEvents[i].TS <= logEvents[j].TS) {
+			apply(snapshotEvents[i], true)
+			i++
+			continue
+		}
+		apply(logEvents[j], false)
+		j++
+	}
+	return changes, lifetimes
+}
+
+// schemaSearchResult is the tables matching a name found in the snapshot backup and the log backup.
+type schemaSearchResult struct {
+	snapshotTS uint64
+	// snapshotEvents are the matched tables existing in the snapshot backup.
+	snapshotEvents []stream.SchemaEvent
+	snapshotFiles  map[int64][]string
+	dbNames        map[int64]string
+	log            *stream.SchemaSearchResult
+}
+
+// searchSchema searches the snapshot backup in fullBackupStorage and the log backup in cfg.Storage for the
+// tables matching a table or column name, either of the storages can be empty.
+func searchSchema(
+	ctx context.Context,
+	cfg *Config,
+	fullBackupStorage string,
+	name string,
+	startTS, endTS uint64,
+) (*schemaSearchResult, error) {
+	result := &schemaSearchResult{
+		snapshotEvents: make([]stream.SchemaEvent, 0),
+		snapshotFiles:  make(map[int64][]string),
+		dbNames:        make(map[int64]string),
+		log:            &stream.SchemaSearchResult{},
+	}
+	knownIDs := make(map[int64]struct{})
+	if fullBackupStorage != "" {
+		snapshotCfg := *cfg
+		snapshotCfg.Storage = fullBackupStorage
+		_, s, backupMeta, err := ReadBackupMeta(ctx, metautil.MetaFile, &snapshotCfg)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		result.snapshotTS = backupMeta.GetEndVersion()
+		reader := metautil.NewMetaReader(backupMeta, s, &cfg.CipherInfo)
+		dbs, err := metautil.LoadBackupTables(ctx, reader, true)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		for _, db := range dbs {
+			result.dbNames[db.Info.ID] = db.Info.Name.O
+			for _, table := range db.Tables {
+				if table.Info == nil {
+					continue
+				}
+				if _, ok := stream.MatchSchemaName(table.Info, name); !ok {
+					continue
+				}
+				knownIDs[table.Info.ID] = struct{}{}
+				result.snapshotEvents = append(result.snapshotEvents, stream.SchemaEvent{
+					TS: result.snapshotTS, DBID: db.Info.ID, TableID: table.Info.ID, Info: table.Info})
+				for _, file := range table.Files {
+					result.snapshotFiles[table.Info.ID] = append(result.snapshotFiles[table.Info.ID], file.Name)
+				}
+			}
+		}
+	}
+
+	if cfg.Storage != "" {
+		_, s, err := GetStorage(ctx, cfg.Storage, cfg)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		encryptionManager, err := encryption.NewManager(&cfg.LogBackupCipherInfo, &cfg.MasterKeyConfig)
+		if err != nil {
+			return nil, errors.Annotate(err, "failed to create encryption manager for log backup")
+		}
+		defer encryptionManager.Close()
+		search := stream.NewSchemaSearch(s, stream.NewMetadataHelper(stream.WithEncryptionManager(encryptionManager)), name)
+		search.SetStartTS(startTS)
+		search.SetEndTs(endTS)
+		if result.log, err = search.Search(ctx, knownIDs); err != nil {
+			return nil, errors.Trace(err)
+		}
+		for id, dbName := range result.log.DBNames {
+			result.dbNames[id] = dbName
+		}
+	}
+	return result, nil
+}
+
+// RunSearchSchema searches the snapshot backup and the log backup for the tables matching a table or
+// column name, and reports when they existed, their IDs over time and the data files containing them.
+func RunSearchSchema(c context.Context, g glue.Glue, cmdName string, cfg *SearchSchemaConfig) error {
+	ctx, cancel := context.WithCancel(c)
+	defer cancel()
+
+	console := glue.GetConsole(g)
+	searched, err := searchSchema(ctx, &cfg.Config, cfg.FullBackupStorage, cfg.Name, cfg.StartTS, cfg.EndTS)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	snapshotFiles, result := searched.snapshotFiles, searched.log
+
+	changes, lifetimes := buildSchemaHistory(cfg.Name, searched.snapshotEvents, result.Events, searched.dbNames)
+	if len(changes) == 0 {
+		console.Printf("no table or column named %q is found in the backup\n", cfg.Name)
+		return nil
+	}
+
+	formatTS := func(ts uint64) string {
+		return fmt.Sprintf("%d (%s)", ts, stream.FormatDate(oracle.GetTimeFromTS(ts)))
+	}
+	console.Println("history:")
+	for _, change := range changes {
+		line := fmt.Sprintf("  %s %s id=%d %s", formatTS(change.ts),
+			utils.EncloseDBAndTable(change.db, change.table), change.tableID, change.action)
+		if change.matched != "" {
+			line += fmt.Sprintf(", matched %s", change.matched)
+		}
+		console.Println(line)
+	}
+
+	console.Println("lifetimes:")
+	sort.SliceStable(lifetimes, func(i, j int) bool { return lifetimes[i].since < lifetimes[j].since })
+	for _, lifetime := range lifetimes {
+		since := "first seen at " + formatTS(lifetime.since)
+		if lifetime.inSnapshot {
+			since = "exists in the snapshot backup at " + formatTS(lifetime.since)
+		}
+		until := "not dropped by the end of the backup"
+		if lifetime.until > 0 {
+			until = "dropped at " + formatTS(lifetime.until)
+		}
+		console.Printf("  %s id=%d: %s, %s\n",
+			utils.EncloseDBAndTable(lifetime.db, lifetime.table), lifetime.tableID, since, until)
+	}
+
+	console.Println("data files:")
+	ids := make([]int64, 0, len(snapshotFiles)+len(result.Files))
+	for id := range snapshotFiles {
+		ids = append(ids, id)
+	}
+	for id := range result.Files {
+		if _, ok := snapshotFiles[id]; !ok {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	for _, id := range ids {
+		console.Printf("  id=%d: %d snapshot files, %d log files\n", id, len(snapshotFiles[id]), len(result.Files[id]))
+		for _, name := range snapshotFiles[id] {
+			console.Printf("    %s\n", name)
+		}
+		for _, file := range result.Files[id] {
+			console.Printf("    %s [%d, %d] cf=%s entries=%d\n",
+				file.Path, file.MinTs, file.MaxTs, file.Cf, file.NumberOfEntries)
+		}
+	}
+	log.Info("searched schema", zap.String("cmd", cmdName), zap.String("name", cfg.Name),
+		zap.Uint64("snapshot-ts", searched.snapshotTS), zap.Int("changes", len(changes)), zap.Int("tables", len(lifetimes)))
+	return nil
+}
@@ -0,0 +1,57 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package task
+
+import (
+	"testing"
+
+	"github.com/pingcap/tidb/br/pkg/stream"
+	"github.com/pingcap/tidb/pkg/meta/model"
+	"github.com/pingcap/tidb/pkg/parser/ast"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildSchemaHistory(t *testing.T) {
+	newInfo := func(name string, columns ...string) *model.TableInfo {
+		info := &model.TableInfo{Name: ast.NewCIStr(name)}
+		for _, col := range columns {
+			info.Columns = append(info.Columns, &model.ColumnInfo{Name: ast.NewCIStr(col)})
+		}
+		return info
+	}
+	dbNames := map[int64]string{1: "test", 2: "archive"}
+	snapshot := []stream.SchemaEvent{{TS: 10, DBID: 1, TableID: 100, Info: newInfo("t1", "id")}}
+	events := []stream.SchemaEvent{
+		// the table is renamed, and then truncated, i.e. dropped and created with the new ID.
+		{TS: 12, DBID: 1, TableID: 100, Info: newInfo("t2", "id")},
+		{TS: 20, DBID: 1, TableID: 100},
+		{TS: 20, DBID: 1, TableID: 104, Info: newInfo("t2", "id")},
+		// the table is moved to another database, and then dropped.
+		{TS: 30, DBID: 1, TableID: 104},
+		{TS: 30, DBID: 2, TableID: 104, Info: newInfo("t2", "id")},
+		{TS: 40, DBID: 2, TableID: 104},
+	}
+
+	changes, lifetimes := buildSchemaHistory("id", snapshot, events, dbNames)
+	require.Equal(t, []schemaChange{
+		{ts: 10, action: "in snapshot", tableID: 100, db: "test", table: "t1", matched: "column id"},
+		{ts: 12, action: "renamed from `test`.`t1`", tableID: 100, db: "test", table: "t2", matched: "column id"},
+		{ts: 20, action: "dropped", tableID: 100, db: "test", table: "t2"},
+		{ts: 20, action: "first seen", tableID: 104, db: "test", table: "t2", matched: "column id"},
+		{ts: 30, action: "moved from `test`.`t2`", tableID: 104, db: "archive", table: "t2", matched: "column id"},
+		{ts: 40, action: "dropped", tableID: 104, db: "archive", table: "t2"},
+	}, changes)
+	require.Equal(t, []*schemaLifetime{
+		{tableID: 100, db: "test", table: "t2", since: 10, inSnapshot: true, until: 20},
+		{tableID: 104, db: "archive", table: "t2", since: 20, until: 40},
+	}, lifetimes)
+
+	// the table seen in the log backup before the snapshot backup isn't reported again.
+	changes, lifetimes = buildSchemaHistory("t1", snapshot, []stream.SchemaEvent{
+		{TS: 5, DBID: 1, TableID: 100, Info: newInfo("t1")},
+	}, dbNames)
+	require.Len(t, changes, 1)
+	require.Equal(t, "first seen", changes[0].action)
+	require.Len(t, lifetimes, 1)
+	require.Equal(t, uint64(5), lifetimes[0].since)
+}
@@ -67,6 +67,8 @@ type ShowResult struct {
 	IsRawKV   bool       `json:"is-raw-kv"`
 	RawRanges []RawRange `json:"raw-ranges"`
 	Tables    []Table    `json:"tables"`
+
+	ConsistencyPoint *metautil.ConsistencyPoint `json:"consistency-point,omitempty"`
 }
 
 type CmdExecutor struct {
@@ -83,12 +85,14 @@ func CreateExec(ctx context.Context, cfg Config) (*CmdExecutor, error) {
 }
 
 func (exec *CmdExecutor) Read(ctx context.Context) (ShowResult, error) {
-	res := convertBasic(exec.meta.GetBasic())
+	basic := exec.meta.GetBasic()
+	res := convertBasic(basic)
 	if res.EndVersion < res.StartVersion {
 		return ShowResult{}, berrors.ErrInvalidMetaFile.GenWithStackByArgs(fmt.Sprintf(
 			"the start version(%s) is greater than the end version(%s), perhaps reading a backup meta from log backup",
 			res.StartVersion, res.EndVersion))
 	}
+	res.ConsistencyPoint = metautil.GetConsistencyPoint(&basic)
 	if !res.IsRawKV {
 		out := make(chan *metautil.Table, 16)
 		errc := make(chan error, 1)
@@ -17,7 +17,6 @@ package task
 import (
 	"bytes"
 	"context"
-	"encoding/binary"
 	"fmt"
 	"math"
 	"net/http"
@@ -28,6 +27,7 @@ import (
 
 	"github.com/docker/go-units"
 	"github.com/fatih/color"
+	"github.com/google/uuid"
 	"github.com/opentracing/opentracing-go"
 	"github.com/pingcap/errors"
 	"github.com/pingcap/failpoint"
@@ -45,6 +45,8 @@ import (
 	"github.com/pingcap/tidb/br/pkg/restore"
 	"github.com/pingcap/tidb/br/pkg/restore/ingestrec"
 	logclient "github.com/pingcap/tidb/br/pkg/restore/log_client"
+	"github.com/pingcap/tidb/br/pkg/restore/membudget"
+	snapclient "github.com/pingcap/tidb/br/pkg/restore/snap_client"
 	"github.com/pingcap/tidb/br/pkg/restore/tiflashrec"
 	restoreutils "github.com/pingcap/tidb/br/pkg/restore/utils"
 	"github.com/pingcap/tidb/br/pkg/storage"
@@ -52,26 +54,37 @@ import (
 	"github.com/pingcap/tidb/br/pkg/streamhelper"
 	advancercfg "github.com/pingcap/tidb/br/pkg/streamhelper/config"
 	"github.com/pingcap/tidb/br/pkg/streamhelper/daemon"
+	"github.com/pingcap/tidb/br/pkg/streamhelper/spans"
 	"github.com/pingcap/tidb/br/pkg/summary"
 	"github.com/pingcap/tidb/br/pkg/utils"
+	"github.com/pingcap/tidb/pkg/infoschema"
 	"github.com/pingcap/tidb/pkg/kv"
+	"github.com/pingcap/tidb/pkg/meta"
 	"github.com/pingcap/tidb/pkg/meta/model"
+	"github.com/pingcap/tidb/pkg/parser/ast"
+	"github.com/pingcap/tidb/pkg/tablecodec"
+	"github.com/pingcap/tidb/pkg/util"
 	"github.com/pingcap/tidb/pkg/util/cdcutil"
+	filter "github.com/pingcap/tidb/pkg/util/table-filter"
 	"github.com/spf13/pflag"
 	"github.com/tikv/client-go/v2/oracle"
 	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.uber.org/multierr"
 	"go.uber.org/zap"
 )
 
 const (
-	flagYes                = "yes"
-	flagCleanUpCompactions = "clean-up-compactions"
-	flagUntil              = "until"
-	flagStreamJSONOutput   = "json"
-	flagStreamTaskName     = "task-name"
-	flagStreamStartTS      = "start-ts"
-	flagStreamEndTS        = "end-ts"
-	flagGCSafePointTTS     = "gc-ttl"
+	flagYes                 = "yes"
+	flagCleanUpCompactions  = "clean-up-compactions"
+	flagUntil               = "until"
+	flagStreamJSONOutput    = "json"
+	flagStreamWatch         = "watch"
+	flagStreamWatchInterval = "watch-interval"
+	flagStreamTaskName      = "task-name"
+	flagStreamStartTS       = "start-ts"
+	flagStreamEndTS         = "end-ts"
+	flagStreamIncludeSys    = "include-sys-tables"
+	flagGCSafePointTTS      = "gc-ttl"
 
 	truncateLockPath   = "truncating.lock"
 	hintOnTruncateLock = "There might be another truncate task running, or a truncate task that didn't exit properly. " +
@@ -89,17 +102,19 @@ var (
 	StreamStop     = "log stop"
 	StreamPause    = "log pause"
 	StreamResume   = "log resume"
+	StreamUpdate   = "log update"
 	StreamStatus   = "log status"
 	StreamTruncate = "log truncate"
 	StreamMetadata = "log metadata"
+	StreamIndex    = "log index"
+	StreamDoctor   = "log doctor"
 	StreamCtl      = "log advancer"
 
 	skipSummaryCommandList = map[string]struct{}{
 		StreamStatus:   {},
 		StreamTruncate: {},
+		StreamDoctor:   {},
 	}
-
-	streamShiftDuration = time.Hour
 )
 
 var StreamCommandMap = map[string]func(c context.Context, g glue.Glue, cmdName string, cfg *StreamConfig) error{
@@ -107,9 +122,12 @@ var StreamCommandMap = map[string]func(c context.Context, g glue.Glue, cmdName s
 	StreamStop:     RunStreamStop,
 	StreamPause:    RunStreamPause,
 	StreamResume:   RunStreamResume,
+	StreamUpdate:   RunStreamUpdate,
 	StreamStatus:   RunStreamStatus,
 	StreamTruncate: RunStreamTruncate,
 	StreamMetadata: RunStreamMetadata,
+	StreamIndex:    RunStreamIndex,
+	StreamDoctor:   RunStreamDoctor,
 	StreamCtl:      RunStreamAdvancer,
 }
 
@@ -124,22 +142,37 @@ type StreamConfig struct {
 	EndTS   uint64 `json:"end-ts" toml:"end-ts"`
 	// SafePointTTL ensures TiKV can scan entries not being GC at [startTS, currentTS]
 	SafePointTTL int64 `json:"safe-point-ttl" toml:"safe-point-ttl"`
+	// IncludeSysTables observes the data of the system tables, which is skipped by the log restore.
+	IncludeSysTables bool `json:"include-sys-tables" toml:"include-sys-tables"`
+	// ExplicitIncludeSysTables is whether IncludeSysTables is given by the flag, `stream update` keeps
+	// the setting of the task otherwise.
+	ExplicitIncludeSysTables bool `json:"-" toml:"-"`
+	// Labels are the user-defined labels recorded in the backupmeta of the log backup.
+	Labels map[string]string `json:"labels" toml:"labels"`
 
 	// Spec for the command `truncate`, we should truncate the until when?
 	Until              uint64 `json:"until" toml:"until"`
 	DryRun             bool   `json:"dry-run" toml:"dry-run"`
 	SkipPrompt         bool   `json:"skip-prompt" toml:"skip-prompt"`
 	CleanUpCompactions bool   `json:"clean-up-compactions" toml:"clean-up-compactions"`
+	// FullBackupStorages are the snapshot backups checked by the dry run of `truncate`.
+	FullBackupStorages []string `json:"full-backup-storages" toml:"full-backup-storages"`
 
 	// Spec for the command `status`.
-	JSONOutput bool `json:"json-output" toml:"json-output"`
+	JSONOutput    bool          `json:"json-output" toml:"json-output"`
+	Watch         bool          `json:"watch" toml:"watch"`
+	WatchInterval time.Duration `json:"watch-interval" toml:"watch-interval"`
+
+	// Spec for the command `doctor`.
+	DoctorObserveDuration  time.Duration `json:"doctor-observe-duration" toml:"doctor-observe-duration"`
+	DoctorMaxCheckpointLag time.Duration `json:"doctor-max-checkpoint-lag" toml:"doctor-max-checkpoint-lag"`
 
 	// Spec for the command `advancer`.
 	AdvancerCfg advancercfg.Config `json:"advancer-config" toml:"advancer-config"`
 }
 
 func (cfg *StreamConfig) makeStorage(ctx context.Context) (storage.ExternalStorage, error) {
-	u, err := storage.ParseBackend(cfg.Storage, &cfg.BackendOptions)
+	u, err := storage.ParseBackendWithContext(ctx, cfg.Storage, &cfg.BackendOptions)
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
@@ -165,6 +198,21 @@ func DefineStreamStartFlags(flags *pflag.FlagSet) {
 	flags.Int64(flagGCSafePointTTS, utils.DefaultStreamStartSafePointTTL,
 		"the TTL (in seconds) that PD holds for BR's GC safepoint")
 	_ = flags.MarkHidden(flagGCSafePointTTS)
+	defineStreamIncludeSysTablesFlag(flags)
+	defineBackupLabelFlag(flags)
+}
+
+// defineStreamIncludeSysTablesFlag defines the flag overriding the default exclusion of the system tables.
+func defineStreamIncludeSysTablesFlag(flags *pflag.FlagSet) {
+	flags.Bool(flagStreamIncludeSys, false,
+		"observe the data of the system tables in the `mysql` and `sys` schemas, which isn't restored by the log restore, "+
+			"except `mysql.tidb_ddl_history`.")
+}
+
+// DefineStreamUpdateFlags defines flags used for `stream update`
+func DefineStreamUpdateFlags(flags *pflag.FlagSet) {
+	DefineStreamCommonFlags(flags)
+	defineStreamIncludeSysTablesFlag(flags)
 }
 
 func DefineStreamPauseFlags(flags *pflag.FlagSet) {
@@ -185,6 +233,12 @@ func DefineStreamStatusCommonFlags(flags *pflag.FlagSet) {
 	flags.Bool(flagStreamJSONOutput, false,
 		"Print JSON as the output.",
 	)
+	flags.Bool(flagStreamWatch, false,
+		"Keep polling the status and print a line of JSON for each task every --watch-interval, requires --json.",
+	)
+	flags.Duration(flagStreamWatchInterval, 10*time.Second,
+		"The interval to poll the status in the watch mode.",
+	)
 }
 
 func DefineStreamTruncateLogFlags(flags *pflag.FlagSet) {
@@ -193,6 +247,9 @@ func DefineStreamTruncateLogFlags(flags *pflag.FlagSet) {
 	flags.Bool(flagDryRun, false, "Run the command but don't really delete the files.")
 	flags.BoolP(flagYes, "y", false, "Skip all prompts and always execute the command.")
 	flags.Bool(flagCleanUpCompactions, false, "Clean up compaction files. Including the compacted log files and expired SST files.")
+	flags.StringArray(FlagStreamFullBackupStorage, nil, "The snapshot backups taken for the log backup, "+
+		"--dry-run reports the ones that can't be the base of the point in time restore after truncating. "+
+		"It can be specified multiple times.")
 }
 
 func (cfg *StreamConfig) ParseStreamStatusFromFlags(flags *pflag.FlagSet) error {
@@ -201,6 +258,18 @@ func (cfg *StreamConfig) ParseStreamStatusFromFlags(flags *pflag.FlagSet) error
 	if err != nil {
 		return errors.Trace(err)
 	}
+	if cfg.Watch, err = flags.GetBool(flagStreamWatch); err != nil {
+		return errors.Trace(err)
+	}
+	if cfg.WatchInterval, err = flags.GetDuration(flagStreamWatchInterval); err != nil {
+		return errors.Trace(err)
+	}
+	if cfg.Watch && !cfg.JSONOutput {
+		return errors.Annotatef(berrors.ErrInvalidArgument, "--%s requires --%s", flagStreamWatch, flagStreamJSONOutput)
+	}
+	if cfg.Watch && cfg.WatchInterval <= 0 {
+		return errors.Annotatef(berrors.ErrInvalidArgument, "--%s must be positive", flagStreamWatchInterval)
+	}
 
 	if err = cfg.ParseStreamCommonFromFlags(flags); err != nil {
 		return errors.Trace(err)
@@ -226,6 +295,12 @@ func (cfg *StreamConfig) ParseStreamTruncateFromFlags(flags *pflag.FlagSet) erro
 	if cfg.CleanUpCompactions, err = flags.GetBool(flagCleanUpCompactions); err != nil {
 		return errors.Trace(err)
 	}
+	if cfg.FullBackupStorages, err = flags.GetStringArray(FlagStreamFullBackupStorage); err != nil {
+		return errors.Trace(err)
+	}
+	if len(cfg.FullBackupStorages) > 0 && !cfg.DryRun {
+		return errors.Annotatef(berrors.ErrInvalidArgument, "--%s requires --%s", FlagStreamFullBackupStorage, flagDryRun)
+	}
 	return nil
 }
 
@@ -262,6 +337,29 @@ func (cfg *StreamConfig) ParseStreamStartFromFlags(flags *pflag.FlagSet) error {
 		cfg.SafePointTTL = utils.DefaultStreamStartSafePointTTL
 	}
 
+	if cfg.IncludeSysTables, err = flags.GetBool(flagStreamIncludeSys); err != nil {
+		return errors.Trace(err)
+	}
+	if cfg.Labels, err = parseBackupLabelFlag(flags); err != nil {
+		return errors.Trace(err)
+	}
+
+	return nil
+}
+
+// ParseStreamUpdateFromFlags parse parameters for `stream update`
+func (cfg *StreamConfig) ParseStreamUpdateFromFlags(flags *pflag.FlagSet) error {
+	if err := cfg.ParseStreamCommonFromFlags(flags); err != nil {
+		return errors.Trace(err)
+	}
+	if !cfg.ExplicitFilter {
+		return errors.Annotate(berrors.ErrInvalidArgument, "the new table filter must be specified by --filter")
+	}
+	var err error
+	if cfg.IncludeSysTables, err = flags.GetBool(flagStreamIncludeSys); err != nil {
+		return errors.Trace(err)
+	}
+	cfg.ExplicitIncludeSysTables = flags.Changed(flagStreamIncludeSys)
 	return nil
 }
 
@@ -323,7 +421,7 @@ func NewStreamMgr(ctx context.Context, cfg *StreamConfig, g glue.Glue, isStreamS
 	if isStreamStart {
 		client := backup.NewBackupClient(ctx, mgr)
 
-		backend, err := storage.ParseBackend(cfg.Storage, &cfg.BackendOptions)
+		backend, err := storage.ParseBackendWithContext(ctx, cfg.Storage, &cfg.BackendOptions)
 		if err != nil {
 			return nil, errors.Trace(err)
 		}
@@ -332,6 +430,8 @@ func NewStreamMgr(ctx context.Context, cfg *StreamConfig, g glue.Glue, isStreamS
 			NoCredentials:            cfg.NoCreds,
 			SendCredentials:          cfg.SendCreds,
 			CheckS3ObjectLockOptions: true,
+			RetryPolicy:              cfg.storageRetryPolicy,
+			RequestStats:             cfg.storageRequestStats,
 		}
 		if err = client.SetStorage(ctx, backend, &opts); err != nil {
 			return nil, errors.Trace(err)
@@ -420,6 +520,7 @@ func (s *streamMgr) buildObserveRanges() ([]kv.KeyRange, error) {
 		s.cfg.FilterStr,
 		s.cfg.TableFilter,
 		s.cfg.StartTS,
+		s.cfg.IncludeSysTables,
 	)
 	if err != nil {
 		return nil, errors.Trace(err)
@@ -446,7 +547,13 @@ func (s *streamMgr) backupFullSchemas(ctx context.Context) error {
 		m.StartVersion = s.cfg.StartTS
 		m.ClusterId = s.bc.GetClusterID()
 		m.ClusterVersion = clusterVersion
+		if len(s.cfg.Labels) > 0 {
+			err = metautil.SetBackupLabels(m, s.cfg.Labels)
+		}
 	})
+	if err != nil {
+		return errors.Trace(err)
+	}
 
 	if err = metaWriter.FlushBackupMeta(ctx); err != nil {
 		return errors.Trace(err)
@@ -506,6 +613,8 @@ func RunStreamCommand(
 			summary.Summary(cmdName)
 		}
 	}()
+	defer cfg.storageRetryPolicy.Report()
+	defer cfg.collectStorageRequestStats()
 	commandFn, exist := StreamCommandMap[cmdName]
 	if !exist {
 		return errors.Annotatef(berrors.ErrInvalidArgument, "invalid command %s", cmdName)
@@ -537,6 +646,9 @@ func RunStreamStart(
 		ctx = opentracing.ContextWithSpan(ctx, span1)
 	}
 
+	if err := checkStreamCredentialRef(cfg); err != nil {
+		return errors.Trace(err)
+	}
 	streamMgr, err := NewStreamMgr(ctx, cfg, g, true)
 	if err != nil {
 		return errors.Trace(err)
@@ -567,11 +679,17 @@ func RunStreamStart(
 		return errors.Trace(err)
 	}
 
-	// It supports single stream log task currently.
-	if count, err := cli.GetTaskCount(ctx); err != nil {
+	// The tasks must have different names and observe disjoint tables, which is checked
+	// after the ranges to observe are built.
+	tasks, err := cli.GetAllTasks(ctx)
+	if err != nil {
 		return errors.Trace(err)
-	} else if count > 0 {
-		return errors.Annotate(berrors.ErrStreamLogTaskExist, "failed to start the log backup, allow only one running task")
+	}
+	for _, t := range tasks {
+		if t.Info.Name == cfg.TaskName {
+			return errors.Annotatef(berrors.ErrStreamLogTaskExist,
+				"failed to start the log backup, the task %s already exists", cfg.TaskName)
+		}
 	}
 
 	// make sure external file lock is available
@@ -632,6 +750,9 @@ func RunStreamStart(
 			zap.String("PD address", pdAddress))
 		return errors.Annotate(berrors.ErrInvalidArgument, "nothing need to observe")
 	}
+	if err = checkObserveRangesDisjoint(ctx, tasks, cfg.TaskName, ranges); err != nil {
+		return errors.Trace(err)
+	}
 
 	securityConfig := generateSecurityConfig(cfg)
 	ti := streamhelper.TaskInfo{
@@ -646,14 +767,40 @@ func RunStreamStart(
 		},
 		Ranges:  ranges,
 		Pausing: false,
+		Labels:  cfg.Labels,
+	}
+	if err = stream.SaveTableFilterChange(ctx, streamMgr.bc.GetStorage(), stream.TableFilterChange{
+		ChangeTS:         cfg.StartTS,
+		TableFilter:      cfg.FilterStr,
+		IncludeSysTables: cfg.IncludeSysTables,
+	}); err != nil {
+		return errors.Trace(err)
 	}
 	if err = cli.PutTask(ctx, ti); err != nil {
 		return errors.Trace(err)
 	}
+	recordToCatalog(ctx, &cfg.Config, cfg.Storage, &metautil.CatalogRecord{
+		Operation: metautil.CatalogBackup,
+		Kind:      metautil.CatalogKindLog,
+		StartTS:   cfg.StartTS,
+		ClusterID: streamMgr.bc.GetClusterID(),
+		Labels:    cfg.Labels,
+	}, nil)
 	summary.Log(cmdName, ti.ZapTaskInfo()...)
 	return nil
 }
 
+// checkStreamCredentialRef rejects the credential reference if the credentials are sent to TiKV, because
+// the credentials resolved from it would be persisted in the log backup task.
+func checkStreamCredentialRef(cfg *StreamConfig) error {
+	if cfg.SendCreds && storage.HasCredentialRef(cfg.Storage) {
+		return errors.Annotatef(berrors.ErrInvalidArgument,
+			"the credentials referenced by credential-ref would be saved in the log backup task if they're "+
+				"sent to TiKV, please set --%s=false and provide the credentials to TiKV instead", flagSendCreds)
+	}
+	return nil
+}
+
 func generateSecurityConfig(cfg *StreamConfig) backuppb.StreamBackupTaskSecurityConfig {
 	if len(cfg.LogBackupCipherInfo.CipherKey) > 0 && utils.IsEffectiveEncryptionMethod(cfg.LogBackupCipherInfo.CipherType) {
 		return backuppb.StreamBackupTaskSecurityConfig{
@@ -711,6 +858,30 @@ func RunStreamMetadata(
 	return nil
 }
 
+// RunStreamIndex builds the table ID indexes of the metadata files without one, so the point in time
+// restore can skip the files of the tables not to restore before downloading them. The indexes of the
+// deleted metadata files are deleted.
+func RunStreamIndex(
+	c context.Context,
+	g glue.Glue,
+	cmdName string,
+	cfg *StreamConfig,
+) error {
+	ctx, cancelFn := context.WithCancel(c)
+	defer cancelFn()
+
+	_, s, err := GetStorage(ctx, cfg.Storage, &cfg.Config)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	built, deleted, err := stream.BuildTableIDIndexes(ctx, s)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	summary.Log(cmdName, zap.Int("built-indexes", built), zap.Int("deleted-indexes", deleted))
+	return nil
+}
+
 // RunStreamStop specifies stoping a stream task
 func RunStreamStop(
 	c context.Context,
@@ -751,10 +922,17 @@ func RunStreamStop(
 	if err != nil {
 		return errors.Trace(err)
 	}
+	// the checkpoint and the labels are gone with the task, so read them for the catalog first.
+	catalogRecord := stoppedLogBackupRecord(ctx, &cfg.Config, ti)
 
 	if err = cli.DeleteTask(ctx, cfg.TaskName); err != nil {
 		return errors.Trace(err)
 	}
+	if catalogRecord != nil {
+		catalogRecord.ClusterID = streamMgr.mgr.GetPDClient().GetClusterID(ctx)
+		storageURL := storage.FormatBackendURL(ti.Info.GetStorage())
+		recordToCatalog(ctx, &cfg.Config, storageURL.String(), catalogRecord, nil)
+	}
 
 	if err := streamMgr.setGCSafePoint(ctx,
 		utils.BRServiceSafePoint{
@@ -770,6 +948,33 @@ func RunStreamStop(
 	return nil
 }
 
+// stoppedLogBackupRecord returns the catalog record of the stopped log backup, whose end ts is the
+// checkpoint of the task. It returns nil if there isn't a catalog or the task can't be read.
+func stoppedLogBackupRecord(ctx context.Context, cfg *Config, ti *streamhelper.Task) *metautil.CatalogRecord {
+	if cfg.Catalog == "" {
+		return nil
+	}
+	checkpoint, err := ti.GetGlobalCheckPointTS(ctx)
+	if err != nil {
+		log.Warn("failed to get the checkpoint of the task for the catalog", zap.String("task", ti.Info.Name),
+			zap.Error(err))
+		return nil
+	}
+	labels, err := ti.Labels(ctx)
+	if err != nil {
+		log.Warn("failed to get the labels of the task for the catalog", zap.String("task", ti.Info.Name),
+			zap.Error(err))
+		return nil
+	}
+	return &metautil.CatalogRecord{
+		Operation: metautil.CatalogBackup,
+		Kind:      metautil.CatalogKindLog,
+		StartTS:   ti.Info.StartTs,
+		EndTS:     checkpoint,
+		Labels:    labels,
+	}
+}
+
 // RunStreamPause specifies pausing a stream task.
 func RunStreamPause(
 	c context.Context,
@@ -916,6 +1121,137 @@ func RunStreamResume(
 	return nil
 }
 
+// checkObserveRangesDisjoint checks the ranges to observe by the task don't overlap with the other
+// tasks, so the tables are backed up by at most one task. The meta range is observed by all the tasks.
+func checkObserveRangesDisjoint(ctx context.Context, tasks []streamhelper.Task, taskName string, ranges []kv.KeyRange) error {
+	metaRange := stream.BuildObserveMetaRange()
+	for i := range tasks {
+		t := &tasks[i]
+		if t.Info.Name == taskName {
+			continue
+		}
+		others, err := t.Ranges(ctx)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		for _, r := range ranges {
+			if bytes.Equal(r.StartKey, metaRange.StartKey) && bytes.Equal(r.EndKey, metaRange.EndKey) {
+				continue
+			}
+			for _, o := range others {
+				if bytes.Equal(o.StartKey, metaRange.StartKey) && bytes.Equal(o.EndKey, metaRange.EndKey) {
+					continue
+				}
+				if spans.Overlaps(r, o) {
+					return errors.Annotatef(berrors.ErrStreamLogTaskExist,
+						"the tables to observe overlap with the task %s, the tasks must observe disjoint tables",
+						t.Info.Name)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// RunStreamUpdate updates the table filter of a stream task in place. The change is recorded in the
+// storage of the task, so the restore knows the window in which each table is covered.
+func RunStreamUpdate(
+	c context.Context,
+	g glue.Glue,
+	cmdName string,
+	cfg *StreamConfig,
+) error {
+	ctx, cancelFn := context.WithCancel(c)
+	defer cancelFn()
+
+	if span := opentracing.SpanFromContext(ctx); span != nil && span.Tracer() != nil {
+		span1 := span.Tracer().StartSpan(
+			"task.RunStreamUpdate",
+			opentracing.ChildOf(span.Context()),
+		)
+		defer span1.Finish()
+		ctx = opentracing.ContextWithSpan(ctx, span1)
+	}
+
+	streamMgr, err := NewStreamMgr(ctx, cfg, g, false)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer streamMgr.close()
+
+	etcdCLI, err := dialEtcdWithCfg(ctx, cfg.Config)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	cli := streamhelper.NewMetaDataClient(etcdCLI)
+	defer func() {
+		if closeErr := cli.Close(); closeErr != nil {
+			log.Warn("failed to close etcd client", zap.Error(closeErr))
+		}
+	}()
+	ti, rev, err := cli.GetTaskWithRevision(ctx, cfg.TaskName)
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	opts := getExternalStorageOptions(&cfg.Config, ti.Info.Storage)
+	extStorage, err := storage.New(ctx, ti.Info.Storage, &opts)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if err = stream.SaveInitialTableFilter(ctx, extStorage, ti.Info.StartTs, ti.Info.TableFilter); err != nil {
+		return errors.Trace(err)
+	}
+	if !cfg.ExplicitIncludeSysTables {
+		changes, err := stream.LoadTableFilterChanges(ctx, extStorage)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		// keep observing the system tables as the last change does.
+		cfg.IncludeSysTables = changes[len(changes)-1].IncludeSysTables
+	}
+
+	// the ranges to observe are built by the schemas at the change ts.
+	changeTS, err := streamMgr.mgr.GetCurrentTsFromPD(ctx)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	cfg.StartTS = changeTS
+	ranges, err := streamMgr.buildObserveRanges()
+	if err != nil {
+		return errors.Trace(err)
+	} else if len(ranges) == 0 {
+		return errors.Annotate(berrors.ErrInvalidArgument, "nothing need to observe")
+	}
+	tasks, err := cli.GetAllTasks(ctx)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if err = checkObserveRangesDisjoint(ctx, tasks, cfg.TaskName, ranges); err != nil {
+		return errors.Trace(err)
+	}
+
+	// record the change before applying it, so a recorded change may not take effect, which only makes
+	// the restore more conservative, but an applied change is always recorded.
+	if err = stream.SaveTableFilterChange(ctx, extStorage, stream.TableFilterChange{
+		ChangeTS:         changeTS,
+		TableFilter:      cfg.FilterStr,
+		IncludeSysTables: cfg.IncludeSysTables,
+	}); err != nil {
+		return errors.Trace(err)
+	}
+
+	info := ti.Info
+	info.TableFilter = cfg.FilterStr
+	if err = cli.UpdateTaskFilter(ctx, info, rev, ranges); err != nil {
+		return errors.Trace(err)
+	}
+	summary.Log(cmdName, logutil.StreamBackupTaskInfo(&info),
+		zap.Strings("old-filter", ti.Info.TableFilter), zap.Bool("include-sys-tables", cfg.IncludeSysTables),
+		zap.Uint64("change-ts", changeTS))
+	return nil
+}
+
 func RunStreamAdvancer(c context.Context, g glue.Glue, cmdName string, cfg *StreamConfig) error {
 	ctx, cancel := context.WithCancel(c)
 	defer cancel()
@@ -934,6 +1270,11 @@ func RunStreamAdvancer(c context.Context, g glue.Glue, cmdName string, cfg *Stre
 	env := streamhelper.CliEnv(mgr.StoreManager, mgr.GetStore(), etcdCLI)
 	advancer := streamhelper.NewCheckpointAdvancer(env)
 	advancer.UpdateConfig(cfg.AdvancerCfg)
+	advancer.SetLogArchive(&stream.LogArchiveVerifier{
+		StorageOptions: func(u *backuppb.StorageBackend) storage.ExternalStorageOptions {
+			return getExternalStorageOptions(&cfg.Config, u)
+		},
+	})
 	ownerMgr := streamhelper.OwnerManagerForLogBackup(ctx, etcdCLI)
 	defer func() {
 		ownerMgr.Close()
@@ -1002,10 +1343,13 @@ func makeStatusController(ctx context.Context, cfg *StreamConfig, g glue.Glue) (
 	}
 	cli := streamhelper.NewMetaDataClient(etcdCLI)
 	var printer stream.TaskPrinter
-	if !cfg.JSONOutput {
-		printer = stream.PrintTaskByTable(console)
-	} else {
+	switch {
+	case cfg.Watch:
+		printer = stream.PrintTaskWithJSONLines(console)
+	case cfg.JSONOutput:
 		printer = stream.PrintTaskWithJSON(console)
+	default:
+		printer = stream.PrintTaskByTable(console)
 	}
 	mgr, err := NewMgr(ctx, g, cfg.PD, cfg.TLS, GetKeepalive(&cfg.Config),
 		cfg.CheckRequirements, false, conn.StreamVersionChecker)
@@ -1047,6 +1391,9 @@ func RunStreamStatus(
 			log.Warn("failed to close etcd client", zap.Error(closeErr))
 		}
 	}()
+	if cfg.Watch {
+		return ctl.WatchStatusOfTask(ctx, cfg.TaskName, cfg.WatchInterval)
+	}
 	return ctl.PrintStatusOfTask(ctx, cfg.TaskName)
 }
 
@@ -1144,6 +1491,11 @@ func RunStreamTruncate(c context.Context, g glue.Glue, cmdName string, cfg *Stre
 		em(fileCount),
 		em(formatTS(cfg.Until)),
 	)
+	if cfg.DryRun {
+		if err := printTruncateReport(ctx, console, cfg, metas.TruncateReport(shiftUntilTS, cfg.Until)); err != nil {
+			return err
+		}
+	}
 	if !cfg.SkipPrompt && !console.PromptBool(warn("Are you sure?")) {
 		return nil
 	}
@@ -1204,9 +1556,13 @@ func checkTaskExists(ctx context.Context, cfg *RestoreConfig, etcdCLI *clientv3.
 		return err
 	}
 	if len(tasks) > 0 {
+		names := make([]string, 0, len(tasks))
+		for _, t := range tasks {
+			names = append(names, t.Info.Name)
+		}
 		return errors.Errorf("log backup task is running: %s, "+
 			"please stop the task before restore, and after PITR operation finished, "+
-			"create log-backup task again and create a full backup on this cluster", tasks[0].Info.Name)
+			"create log-backup task again and create a full backup on this cluster", strings.Join(names, ", "))
 	}
 
 	return nil
@@ -1238,6 +1594,21 @@ func RunStreamRestore(
 		defer span1.Finish()
 		ctx = opentracing.ContextWithSpan(ctx, span1)
 	}
+	if cfg.ColumnMapping != "" {
+		// the DDLs in the log backup would overwrite the mapped columns.
+		return errors.Annotatef(berrors.ErrInvalidArgument, "--%s isn't supported by the point in time restore", flagColumnMapping)
+	}
+	if cfg.ImportFormat != "" {
+		return errors.Annotatef(berrors.ErrInvalidArgument, "--%s isn't supported by the point in time restore", flagImportFormat)
+	}
+	if len(cfg.RowFilters) > 0 {
+		// the rows written by the log backup can't be filtered.
+		return errors.Annotatef(berrors.ErrInvalidArgument, "--%s isn't supported by the point in time restore", flagRowFilter)
+	}
+	if len(cfg.Sanitizers) > 0 {
+		// the rows written by the log backup would be restored unsanitized.
+		return errors.Annotatef(berrors.ErrInvalidArgument, "--%s isn't supported by the point in time restore", flagSanitize)
+	}
 	_, s, err := GetStorage(ctx, cfg.Config.Storage, &cfg.Config)
 	if err != nil {
 		return errors.Trace(err)
@@ -1252,16 +1623,21 @@ func RunStreamRestore(
 		cfg.RestoreTS = logInfo.logMaxTS
 	}
 	cfg.upstreamClusterID = logInfo.clusterID
+	if err := checkRestoreCluster(restoreClusterCheck{
+		Backup:     "log backup",
+		Upstream:   logInfo.clusterID,
+		Downstream: mgr.GetPDClient().GetClusterID(ctx),
+	}, cfg.AllowCrossCluster); err != nil {
+		return errors.Trace(err)
+	}
 
 	if len(cfg.FullBackupStorage) > 0 {
 		startTS, fullClusterID, err := getFullBackupTS(ctx, cfg)
 		if err != nil {
 			return errors.Trace(err)
 		}
-		if logInfo.clusterID > 0 && fullClusterID > 0 && logInfo.clusterID != fullClusterID {
-			return errors.Annotatef(berrors.ErrInvalidArgument,
-				"the full snapshot(from cluster ID:%v) and log(from cluster ID:%v) come from different cluster.",
-				fullClusterID, logInfo.clusterID)
+		if err := checkBackupsFromSameCluster(fullClusterID, logInfo.clusterID); err != nil {
+			return errors.Trace(err)
 		}
 
 		cfg.StartTS = startTS
@@ -1285,6 +1661,11 @@ func RunStreamRestore(
 		return errors.Trace(err)
 	}
 
+	// the existing data is checked before the snapshot and the meta are restored.
+	if err := checkRewriteTSRegression(ctx, mgr, cfg, checkInfo.CheckpointInfo); err != nil {
+		return errors.Trace(err)
+	}
+
 	failpoint.Inject("failed-before-full-restore", func(_ failpoint.Value) {
 		failpoint.Return(errors.New("failpoint: failed before full restore"))
 	})
@@ -1300,6 +1681,10 @@ func RunStreamRestore(
 			return errors.Trace(err)
 		}
 		cfg.Config.Storage = logStorage
+		// the rewrite ts provided by the other sources must be after the restored snapshot.
+		if cfg.snapshotRestoredTS, err = restore.GetTSWithRetry(ctx, mgr.GetPDClient()); err != nil {
+			return errors.Trace(err)
+		}
 	} else if len(cfg.FullBackupStorage) > 0 {
 		skipMsg := []byte(fmt.Sprintf("%s command is skipped due to checkpoint mode for restore\n", FullRestoreCmd))
 		if _, err := glue.GetConsole(g).Out().Write(skipMsg); err != nil {
@@ -1337,10 +1722,10 @@ func restoreStream(
 	)
 	defer func() {
 		if err != nil {
-			summary.Log("restore log failed summary", zap.Error(err))
+			summary.Log("restore log failed summary", append(cfg.storageRequestFields(), zap.Error(err))...)
 		} else {
 			totalDureTime := time.Since(startTime)
-			summary.Log("restore log success summary", zap.Duration("total-take", totalDureTime),
+			summary.Log("restore log success summary", append([]zap.Field{zap.Duration("total-take", totalDureTime),
 				zap.Uint64("source-start-point", cfg.StartTS),
 				zap.Uint64("source-end-point", cfg.RestoreTS),
 				zap.Uint64("target-end-point", currentTS),
@@ -1352,7 +1737,15 @@ func restoreStream(
 				zap.String("total-size", units.HumanSize(float64(totalSize))),
 				zap.String("skipped-size-by-checkpoint", units.HumanSize(float64(checkpointTotalSize))),
 				zap.String("average-speed", units.HumanSize(float64(totalSize)/totalDureTime.Seconds())+"/s"),
-			)
+			}, cfg.storageRequestFields()...)...)
+		}
+	}()
+	// the cleanup runs after the other deferred functions, such as flushing the checkpoint.
+	cleaner := &restoreCleaner{}
+	canceled := func() bool { return err != nil && c.Err() != nil }
+	defer func() {
+		if canceled() {
+			cleaner.cleanupAndReport(g)
 		}
 	}()
 
@@ -1382,7 +1775,11 @@ func restoreStream(
 		log.Info("reuse the task's rewrite ts", zap.Uint64("rewrite-ts", taskInfo.Metadata.RewriteTS))
 		currentTS = taskInfo.Metadata.RewriteTS
 	} else {
-		currentTS, err = restore.GetTSWithRetry(ctx, mgr.GetPDClient())
+		provider, err := newRewriteTSProvider(cfg.RewriteTSSource, mgr.GetPDClient(), &cfg.TLS)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		currentTS, err = restore.GetRewriteTS(ctx, provider, mgr.GetPDClient(), cfg.snapshotRestoredTS)
 		if err != nil {
 			return errors.Trace(err)
 		}
@@ -1392,13 +1789,38 @@ func restoreStream(
 	}
 
 	importModeSwitcher := restore.NewImportModeSwitcher(mgr.GetPDClient(), cfg.Config.SwitchModeInterval, mgr.GetTLSConfig())
-	restoreSchedulers, _, err := restore.RestorePreWork(ctx, mgr, importModeSwitcher, cfg.Online, false)
+	// no data is ingested if the meta entries are exported.
+	skipPreWork := cfg.Online || cfg.ExportMetaEntries != ""
+	restoreSchedulers, _, err := restore.RestorePreWork(ctx, mgr, importModeSwitcher, skipPreWork, false)
 	if err != nil {
 		return errors.Trace(err)
 	}
+	// the post-work runs once the kvs are restored, so the paced delete ranges are inserted with the
+	// schedulers resumed.
+	postWorkDone := false
+	runPostWork := func() {
+		if !postWorkDone {
+			postWorkDone = true
+			restore.RestorePostWork(ctx, importModeSwitcher, restoreSchedulers, skipPreWork)
+		}
+	}
+	if !skipPreWork {
+		cleaner.register("resume the pd schedulers", func(ctx context.Context) error {
+			if postWorkDone {
+				return nil
+			}
+			return multierr.Combine(importModeSwitcher.SwitchToNormalMode(ctx), restoreSchedulers(ctx))
+		})
+	}
 	// Always run the post-work even on error, so we don't stuck in the import
 	// mode or emptied schedulers
-	defer restore.RestorePostWork(ctx, importModeSwitcher, restoreSchedulers, cfg.Online)
+	defer func() {
+		if canceled() {
+			log.Info("the log restore is canceled, the pd schedulers are resumed by the cleanup")
+			return
+		}
+		runPostWork()
+	}()
 
 	// It need disable GC in TiKV when PiTR.
 	// because the process of PITR is concurrent and kv events isn't sorted by tso.
@@ -1472,19 +1894,56 @@ func restoreStream(
 
 	// get the schemas ID replace information.
 	// since targeted full backup storage, need to use the full backup cipher
+	var mergePartitionsFilter filter.Filter
+	if len(cfg.MergePartitions) > 0 {
+		if mergePartitionsFilter, err = filter.Parse(cfg.MergePartitions); err != nil {
+			return errors.Annotatef(berrors.ErrInvalidArgument, "invalid --%s: %v", FlagStreamMergePartitions, err)
+		}
+	}
 	tableMappingManager, err := client.BuildTableMappingManager(ctx, &logclient.BuildTableMappingManagerConfig{
 		CurrentIdMapSaved: currentIdMapSaved,
 		TableFilter:       cfg.TableFilter,
 		FullBackupStorage: fullBackupStorage,
 		CipherInfo:        &cfg.Config.CipherInfo,
 		Files:             ddlFiles,
+		MergePartitions:   mergePartitionsFilter,
 	})
 	if err != nil {
 		return errors.Trace(err)
 	}
 
+	filterHistory, err := client.LoadTableFilterHistory(ctx)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if err = checkTableFilterCoverage(filterHistory, cfg, tableMappingManager.DbReplaceMap); err != nil {
+		return errors.Trace(err)
+	}
+
 	schemasReplace := stream.NewSchemasReplace(tableMappingManager.DbReplaceMap, cfg.tiflashRecorder,
 		client.CurrentTS(), cfg.TableFilter, client.RecordDeleteRange)
+	schemasReplace.PreserveClusterMeta = cfg.PreserveClusterMeta
+	schemasReplace.ValidateRoundTrip = cfg.ValidateMetaRoundTrip
+	schemasReplace.MissingPartitionPolicy = cfg.MissingPartitionPolicy
+	schemasReplace.GenGlobalID = client.IDReservation().GenGlobalID
+	schemasReplace.PersistIDMap = func(ctx context.Context) error {
+		return client.SaveIDMap(ctx, tableMappingManager)
+	}
+	schemasReplace.DebugKeyPrefix = cfg.DebugRewriteKeyPrefix
+	schemasReplace.RollbackRecordPolicy = cfg.RollbackRecordPolicy
+	schemasReplace.DelRangeConflictPolicy = cfg.DeleteRangeConflictPolicy
+	if mergePartitionsFilter != nil {
+		if err := mergePartitions(schemasReplace, mgr.GetDomain().InfoSchema(), mergePartitionsFilter); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	if cfg.RollbackRecordPolicy == stream.RollbackRecordCollect {
+		// the records collected before the restore resumed from the checkpoint are kept.
+		resume := taskInfo != nil && taskInfo.Metadata != nil
+		if schemasReplace.RollbackRecordCollector, err = stream.NewRollbackRecordCollector(cfg.RollbackRecordFile, resume); err != nil {
+			return errors.Trace(err)
+		}
+	}
 	schemasReplace.AfterTableRewritten = func(deleted bool, tableInfo *model.TableInfo) {
 		// When the table replica changed to 0, the tiflash replica might be set to `nil`.
 		// We should remove the table if we meet.
@@ -1496,6 +1955,46 @@ func restoreStream(
 		// Remove the replica firstly. Let's restore them at the end.
 		tableInfo.TiFlashReplica = nil
 	}
+	if strings.EqualFold(cfg.WithPlacementPolicy, "IGNORE") {
+		// the placement policies aren't restored, so the references to them are stripped.
+		stripPlacement := stream.NewStripAttributesRule(stream.MetaAttributePlacement)
+		schemasReplace.RegisterRule(stream.MetaKeyDB, stripPlacement)
+		schemasReplace.RegisterRule(stream.MetaKeyTable, stripPlacement)
+	}
+	if cfg.ConvertCharset != "" {
+		conversion, err := utils.ParseCharsetConversion(cfg.ConvertCharset)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		convertCharset := stream.NewConvertCharsetRule(conversion)
+		schemasReplace.RegisterRule(stream.MetaKeyDB, convertCharset)
+		schemasReplace.RegisterRule(stream.MetaKeyTable, convertCharset)
+	}
+	if cfg.PlacementTemplate != "" {
+		template, err := utils.LoadPlacementTemplate(cfg.PlacementTemplate)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		// the meta kv entries are written directly, so the IDs of the downstream policies are required.
+		is := mgr.GetDomain().InfoSchema()
+		if err := template.ResolvePolicyIDs(func(name ast.CIStr) (int64, bool) {
+			policy, ok := is.PolicyByName(name)
+			if !ok {
+				return 0, false
+			}
+			return policy.ID, true
+		}); err != nil {
+			return errors.Trace(err)
+		}
+		placementTemplate := stream.NewPlacementTemplateRule(template)
+		schemasReplace.RegisterRule(stream.MetaKeyDB, placementTemplate)
+		schemasReplace.RegisterRule(stream.MetaKeyTable, placementTemplate)
+	}
+	for keyType, rules := range cfg.MetaRewriteRules {
+		for _, rule := range rules {
+			schemasReplace.RegisterRule(keyType, rule)
+		}
+	}
 
 	updateStats := func(kvCount uint64, size uint64) {
 		mu.Lock()
@@ -1504,80 +2003,174 @@ func restoreStream(
 		totalSize += size
 	}
 
+	if cfg.ExportMetaEntries != "" {
+		if err := client.EnableMetaKVExport(ctx, cfg.ExportMetaEntries); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	// the meta kv entries overwrite the existing schemas in place, so they can't be rolled back.
+	if cfg.UseCheckpoint {
+		cleaner.note("roll back the restored logs", "skipped, kept for resuming from the checkpoint")
+	} else {
+		cleaner.note("roll back the restored logs", "skipped, the restored schemas and data are left as is, "+
+			"drop the restored tables before restoring again")
+	}
+	log.Info("restore the meta kv files", zap.Int("files", len(ddlFiles)),
+		zap.String("json-codec", utils.MetaJSON().Name()))
 	pm := g.StartProgress(ctx, "Restore Meta Files", int64(len(ddlFiles)), !cfg.LogProgress)
-	if err = withProgress(pm, func(p glue.Progress) error {
+	err = withProgress(pm, func(p glue.Progress) error {
 		client.RunGCRowsLoader(ctx)
+		releaseDDLGate, err := acquireDDLGate(ctx, g, mgr, cfg)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		defer releaseDDLGate()
 		return client.RestoreAndRewriteMetaKVFiles(ctx, ddlFiles, schemasReplace, updateStats, p.Inc)
-	}); err != nil {
+	})
+	if collector := schemasReplace.RollbackRecordCollector; collector != nil {
+		if closeErr := collector.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}
+	if err != nil {
 		return errors.Annotate(err, "failed to restore meta files")
 	}
-
-	rewriteRules := initRewriteRules(schemasReplace)
-
-	ingestRecorder := schemasReplace.GetIngestRecorder()
-	if err := rangeFilterFromIngestRecorder(ingestRecorder, rewriteRules); err != nil {
-		return errors.Trace(err)
+	if cfg.RollbackRecordPolicy == stream.RollbackRecordDrop || cfg.RollbackRecordPolicy == stream.RollbackRecordCollect {
+		log.Info("skipped the rollback and lock records of the meta kv files",
+			zap.String("policy", string(cfg.RollbackRecordPolicy)), zap.String("file", cfg.RollbackRecordFile),
+			zap.Uint64("count", schemasReplace.SkippedRollbackRecords()))
 	}
-
-	logFilesIter, err := client.LoadDMLFiles(ctx)
-	if err != nil {
-		return errors.Trace(err)
+	if tsRange, ok := client.AllocatedRewriteTSRange(); ok && tsRange.Batches > 0 {
+		log.Info("allocated the commit ts of the meta kv entries in batches", zap.Uint64("rewrite-ts", currentTS),
+			zap.Uint64("first-ts", tsRange.First), zap.Uint64("last-ts", tsRange.Last), zap.Int("batches", tsRange.Batches))
+		summary.CollectUint("meta kv first commit ts", tsRange.First)
+		summary.CollectUint("meta kv last commit ts", tsRange.Last)
+		summary.CollectInt("meta kv commit ts batches", tsRange.Batches)
+	}
+	if stats := schemasReplace.DelRangeDedupStats(); stats.Duplicated > 0 || stats.Conflicted > 0 {
+		log.Info("skipped the delete ranges recorded before", zap.Uint64("duplicated", stats.Duplicated),
+			zap.Uint64("conflicted", stats.Conflicted))
+	}
+	if cfg.ExportMetaEntries != "" {
+		count, err := client.FinishMetaKVExport()
+		if err != nil {
+			return errors.Trace(err)
+		}
+		// the meta entries aren't applied, so the data can't be restored.
+		log.Info("the meta entries are exported, skip restoring the data files",
+			zap.String("addr", cfg.ExportMetaEntries), zap.Uint64("count", count))
+		gcDisabledRestorable = true
+		return nil
 	}
 
-	compactionIter := client.LogFileManager.GetCompactionIter(ctx)
-
-	se, err := g.CreateSession(mgr.GetStorage())
+	rewriteRules, err := schemasReplace.DataRewriteRules()
 	if err != nil {
 		return errors.Trace(err)
 	}
-	execCtx := se.GetSessionCtx().GetRestrictedSQLExecutor()
-	splitSize, splitKeys := utils.GetRegionSplitInfo(execCtx)
-	log.Info("[Log Restore] get split threshold from tikv config", zap.Uint64("split-size", splitSize), zap.Int64("split-keys", splitKeys))
-
-	pd := g.StartProgress(ctx, "Restore Files(SST + KV)", logclient.TotalEntryCount, !cfg.LogProgress)
-	err = withProgress(pd, func(p glue.Progress) (pErr error) {
-		updateStatsWithCheckpoint := func(kvCount, size uint64) {
-			mu.Lock()
-			defer mu.Unlock()
-			totalKVCount += kvCount
-			totalSize += size
-			checkpointTotalKVCount += kvCount
-			checkpointTotalSize += size
-			// increase the progress
-			p.IncBy(int64(kvCount))
-		}
-		compactedSplitIter, err := client.WrapCompactedFilesIterWithSplitHelper(
-			ctx, compactionIter, rewriteRules, sstCheckpointSets,
-			updateStatsWithCheckpoint, splitSize, splitKeys,
-		)
+	if len(cfg.TenantFilters) > 0 {
+		tenants, err := snapclient.ParseTenantFilters(cfg.TenantFilters)
 		if err != nil {
 			return errors.Trace(err)
 		}
-
-		err = client.RestoreCompactedSstFiles(ctx, compactedSplitIter, rewriteRules, importModeSwitcher, p.IncBy)
-		if err != nil {
+		if err := filterTenantRewriteRules(mgr.GetStorage(), schemasReplace, rewriteRules, tenants); err != nil {
 			return errors.Trace(err)
 		}
+	}
 
-		logFilesIterWithSplit, err := client.WrapLogFilesIterWithSplitHelper(ctx, logFilesIter, execCtx, rewriteRules, updateStatsWithCheckpoint, splitSize, splitKeys)
+	ingestRecorder := schemasReplace.GetIngestRecorder()
+	if err := rangeFilterFromIngestRecorder(ingestRecorder, rewriteRules); err != nil {
+		return errors.Trace(err)
+	}
+
+	if cfg.SchemaOnly {
+		// the DDLs have been replayed by the meta files, skip the data.
+		log.Info("schema-only restore, skip restoring the data files")
+	} else {
+		// the files of the tables without rewrite rules are pruned before downloading.
+		tableIDs := make(map[int64]struct{}, len(rewriteRules))
+		for tableID := range rewriteRules {
+			tableIDs[tableID] = struct{}{}
+		}
+		compactionIter := client.LogFileManager.GetCompactionIterOfTables(ctx, tableIDs)
+		if cfg.ZoneLeader.Zone != "" {
+			zoneLeaders, err := preferZoneLeaders(ctx, g, client, cfg, tableIDs, rewriteRules)
+			if err != nil {
+				return errors.Trace(err)
+			}
+			defer resetZoneLeaders(g, zoneLeaders)
+		}
+
+		se, err := g.CreateSession(mgr.GetStorage())
 		if err != nil {
 			return errors.Trace(err)
 		}
+		execCtx := se.GetSessionCtx().GetRestrictedSQLExecutor()
+		splitSize, splitKeys := utils.GetRegionSplitInfo(execCtx)
+		log.Info("[Log Restore] get split threshold from tikv config", zap.Uint64("split-size", splitSize), zap.Int64("split-keys", splitKeys))
+
+		pd := g.StartProgress(ctx, "Restore Files(SST + KV)", logclient.TotalEntryCount, !cfg.LogProgress)
+		err = withProgress(pd, func(p glue.Progress) (pErr error) {
+			updateStatsWithCheckpoint := func(kvCount, size uint64) {
+				mu.Lock()
+				defer mu.Unlock()
+				totalKVCount += kvCount
+				totalSize += size
+				checkpointTotalKVCount += kvCount
+				checkpointTotalSize += size
+				// increase the progress
+				p.IncBy(int64(kvCount))
+			}
+			compactedSplitIter, err := client.WrapCompactedFilesIterWithSplitHelper(
+				ctx, compactionIter, rewriteRules, sstCheckpointSets,
+				updateStatsWithCheckpoint, splitSize, splitKeys,
+			)
+			if err != nil {
+				return errors.Trace(err)
+			}
 
-		if cfg.UseCheckpoint {
-			// TODO make a failpoint iter inside the logclient.
-			failpoint.Inject("corrupt-files", func(v failpoint.Value) {
-				var retErr error
-				logFilesIterWithSplit, retErr = logclient.WrapLogFilesIterWithCheckpointFailpoint(v, logFilesIterWithSplit, rewriteRules)
-				defer func() { pErr = retErr }()
-			})
-		}
+			err = client.RestoreCompactedSstFiles(ctx, compactedSplitIter, rewriteRules, importModeSwitcher, p.IncBy)
+			if err != nil {
+				return errors.Trace(err)
+			}
 
-		return client.RestoreKVFiles(ctx, rewriteRules, logFilesIterWithSplit,
-			cfg.PitrBatchCount, cfg.PitrBatchSize, updateStats, p.IncBy, &cfg.LogBackupCipherInfo, cfg.MasterKeyConfig.MasterKeys)
-	})
-	if err != nil {
-		return errors.Annotate(err, "failed to restore kv files")
+			logFilesIter, err := client.LoadDMLFilesOfTables(ctx, tableIDs, p.IncBy)
+			if err != nil {
+				return errors.Trace(err)
+			}
+			logFilesIterWithSplit, err := client.WrapLogFilesIterWithSplitHelper(ctx, logFilesIter, execCtx, rewriteRules, updateStatsWithCheckpoint, splitSize, splitKeys)
+			if err != nil {
+				return errors.Trace(err)
+			}
+
+			if cfg.UseCheckpoint {
+				// TODO make a failpoint iter inside the logclient.
+				failpoint.Inject("corrupt-files", func(v failpoint.Value) {
+					var retErr error
+					logFilesIterWithSplit, retErr = logclient.WrapLogFilesIterWithCheckpointFailpoint(v, logFilesIterWithSplit, rewriteRules)
+					defer func() { pErr = retErr }()
+				})
+			}
+
+			return client.RestoreKVFiles(ctx, rewriteRules, logFilesIterWithSplit,
+				cfg.PitrBatchCount, cfg.PitrBatchSize, updateStats, p.IncBy, &cfg.LogBackupCipherInfo, cfg.MasterKeyConfig.MasterKeys)
+		})
+		if err != nil {
+			return errors.Annotate(err, "failed to restore kv files")
+		}
+		if files, size := client.LogFileManager.DuplicateDataFiles(); files > 0 {
+			log.Warn("skipped the duplicate log files flushed more than once by the log backup task",
+				zap.Int64("files", files), zap.Uint64("size", size))
+			summary.CollectInt("duplicate log files skipped", int(files))
+			summary.CollectUint("duplicate log bytes skipped", size)
+		}
+		// the old versions are read back, so it's verified before the GC is resumed.
+		verified, err := client.VerifySampledKVs(ctx, mgr.GetStorage())
+		if err != nil {
+			return errors.Annotate(err, "failed to verify the sampled keys")
+		}
+		if verified.Keys > 0 {
+			summary.CollectInt("sampled keys verified", verified.Keys)
+		}
 	}
 
 	// failpoint to stop for a while after restoring kvs
@@ -1599,15 +2192,34 @@ func restoreStream(
 		return errors.Annotate(err, "failed to clean up")
 	}
 
-	if err = client.InsertGCRows(ctx); err != nil {
+	// nothing is ingested since then, and inserting the delete ranges may be spread over a long time.
+	runPostWork()
+	if err = client.InsertGCRows(ctx, g); err != nil {
 		return errors.Annotate(err, "failed to insert rows into gc_delete_range")
 	}
 
 	if err = client.RepairIngestIndex(ctx, ingestRecorder, g); err != nil {
+		for _, record := range ingestRecorder.Records() {
+			if record.State == ingestrec.IngestIndexFailed {
+				log.Warn("failed to repair the ingest index", zap.Any("record", record))
+			}
+		}
+		if cfg.UseCheckpoint {
+			log.Warn("the failed ingest indexes can be repaired again by `br restore repair-indexes --from-checkpoint`")
+		}
 		return errors.Annotate(err, "failed to repair ingest index")
 	}
 
-	if cfg.tiflashRecorder != nil {
+	if cfg.tiflashRecorder != nil && cfg.Follow {
+		// the next round of following can't start if there are TiFlash replicas.
+		sqls := cfg.tiflashRecorder.GenerateAlterTableDDLs(mgr.GetDomain().InfoSchema())
+		log.Warn("the TiFlash replicas aren't restored in the follow mode, "+
+			"please execute the SQLs to restore them after the following stops", zap.Strings("sqls", sqls))
+	} else if cfg.tiflashRecorder != nil && cfg.DeferTiFlashReplicas != "" {
+		if err := saveDeferredTiFlashReplicas(ctx, mgr, cfg); err != nil {
+			return errors.Annotate(err, "failed to save the TiFlash replicas")
+		}
+	} else if cfg.tiflashRecorder != nil {
 		sqls := cfg.tiflashRecorder.GenerateAlterTableDDLs(mgr.GetDomain().InfoSchema())
 		log.Info("Generating SQLs for restoring TiFlash Replica",
 			zap.Strings("sqls", sqls))
@@ -1633,11 +2245,152 @@ func restoreStream(
 		}
 	})
 
+	reportTableStats(cfg.TableStatsReport, tableStatsPhaseLog,
+		resolveTableStats(client.TableStats(), mgr.GetDomain().InfoSchema()))
+
 	gcDisabledRestorable = true
 
 	return nil
 }
 
+// followLogBackup keeps restoring the new entries of the log backup every interval, until the context
+// is done. Each round restores the entries in (restoredTS, the checkpoint of the log backup] without
+// the full backup, so the ID maps saved at restoredTS by the last round are used to rewrite them.
+func followLogBackup(
+	c context.Context,
+	g glue.Glue,
+	mgr *conn.Mgr,
+	cmdName string,
+	cfg *RestoreConfig,
+	restoredTS uint64,
+) error {
+	ticker := time.NewTicker(cfg.FollowInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.Done():
+			log.Info("stop following the log backup", zap.Uint64("restored-ts", restoredTS))
+			return nil
+		case <-ticker.C:
+		}
+
+		_, s, err := GetStorage(c, cfg.Config.Storage, &cfg.Config)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		logInfo, err := getLogRangeWithStorage(c, s)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if logInfo.logMaxTS <= restoredTS {
+			log.Info("no new entries of the log backup", zap.Uint64("restored-ts", restoredTS))
+			continue
+		}
+
+		roundCfg := *cfg
+		roundCfg.FullBackupStorage = ""
+		roundCfg.StartTS = restoredTS
+		roundCfg.RestoreTS = logInfo.logMaxTS
+		if err := RunStreamRestore(c, mgr, g, &roundCfg); err != nil {
+			return errors.Annotatef(err, "failed to restore the log backup from %d to %d, "+
+				"please restart the follow mode with --%s %d", restoredTS, roundCfg.RestoreTS, FlagStreamStartTS, restoredTS)
+		}
+		removeCheckpointData(c, g, mgr, cmdName, &roundCfg)
+		restoredTS = roundCfg.RestoreTS
+		log.Info("restored the new entries of the log backup", zap.Uint64("restored-ts", restoredTS),
+			zap.Duration("lag", time.Since(oracle.GetTimeFromTS(restoredTS))))
+	}
+}
+
+// checkTableFilterCoverage checks the tables to restore are covered by the log backup in the whole
+// restore window, if the table filter of the log backup task is updated in the window. The tables
+// not covered in the whole window are skipped as before, they're restored by the full backup only.
+func checkTableFilterCoverage(
+	history *stream.TableFilterHistory,
+	cfg *RestoreConfig,
+	dbMap map[stream.UpstreamID]*stream.DBReplace,
+) error {
+	uncovered := make([]string, 0)
+	for _, db := range dbMap {
+		for _, table := range db.TableMap {
+			if len(table.Name) == 0 || !cfg.TableFilter.MatchTable(db.Name, table.Name) {
+				continue
+			}
+			windows := history.UncoveredWindows(db.Name, table.Name, cfg.StartTS, cfg.RestoreTS)
+			if len(windows) == 0 || (len(windows) == 1 && windows[0].From == cfg.StartTS && windows[0].To > cfg.RestoreTS) {
+				continue
+			}
+			uncovered = append(uncovered, fmt.Sprintf("%s (not covered in [%d, %d))",
+				utils.EncloseDBAndTable(db.Name, table.Name), windows[0].From, windows[0].To))
+		}
+	}
+	if len(uncovered) == 0 {
+		return nil
+	}
+	slices.Sort(uncovered)
+	const maxDisplay = 10
+	if len(uncovered) > maxDisplay {
+		uncovered = append(uncovered[:maxDisplay], fmt.Sprintf("and %d more", len(uncovered)-maxDisplay))
+	}
+	return errors.Annotatef(berrors.ErrInvalidArgument,
+		"the table filter of the log backup task is updated during the restore window, "+
+			"the tables aren't covered by the log backup in the whole window, please exclude them by --filter: %s",
+		strings.Join(uncovered, ", "))
+}
+
+// mergePartitions restores the partitioned tables matched by the rules as non-partitioned tables. The
+// tables restored from the full backup are merged by the snapshot restore, but the ones existing in the
+// downstream as partitioned tables, e.g. restored by another restore, can't be merged, since the meta of
+// them isn't rewritten by the log restore.
+func mergePartitions(sr *stream.SchemasReplace, is infoschema.InfoSchema, f filter.Filter) error {
+	merged := sr.MergePartitions(f)
+	partitioned := make([]string, 0)
+	for tableID, name := range merged {
+		if tableInfo, ok := is.TableInfoByID(tableID); ok && tableInfo.GetPartitionInfo() != nil {
+			partitioned = append(partitioned, name)
+		}
+	}
+	if len(partitioned) > 0 {
+		slices.Sort(partitioned)
+		return errors.Annotatef(berrors.ErrInvalidArgument, "the tables exist as partitioned tables in the "+
+			"cluster, their partitions can't be merged, please exclude them by --%s: %s",
+			FlagStreamMergePartitions, strings.Join(partitioned, ", "))
+	}
+	log.Info("merge the partitions of the tables", zap.Int("tables", len(merged)))
+	return nil
+}
+
+// acquireDDLGate pauses the DDL jobs of the target cluster involving the restored tables while the meta kv
+// entries are applied, so they don't interleave with the restored meta.
+func acquireDDLGate(ctx context.Context, g glue.Glue, mgr *conn.Mgr, cfg *RestoreConfig) (release func(), err error) {
+	// the meta of the target cluster isn't changed if the meta entries are exported.
+	if !cfg.PauseDDL || cfg.ExportMetaEntries != "" {
+		return func() {}, nil
+	}
+	filters := cfg.FilterStr
+	if len(filters) == 0 {
+		filters = []string{"*.*"}
+	}
+	se, err := g.CreateSession(mgr.GetStorage())
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	gate, err := logclient.NewDDLGate(mgr.GetStorage(), logclient.NewSessionDDLJobPauser(se.GetSessionCtx()),
+		fmt.Sprintf("restore-%s", uuid.New()), filters)
+	if err != nil {
+		se.Close()
+		return nil, errors.Trace(err)
+	}
+	if err := gate.Acquire(ctx); err != nil {
+		se.Close()
+		return nil, errors.Trace(err)
+	}
+	return func() {
+		gate.Release(ctx)
+		se.Close()
+	}, nil
+}
+
 func createRestoreClient(ctx context.Context, g glue.Glue, cfg *RestoreConfig, mgr *conn.Mgr) (*logclient.LogClient, error) {
 	var err error
 	keepaliveCfg := GetKeepalive(&cfg.Config)
@@ -1654,7 +2407,7 @@ func createRestoreClient(ctx context.Context, g glue.Glue, cfg *RestoreConfig, m
 		}
 	}()
 
-	u, err := storage.ParseBackend(cfg.Storage, &cfg.BackendOptions)
+	u, err := storage.ParseBackendWithContext(ctx, cfg.Storage, &cfg.BackendOptions)
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
@@ -1665,6 +2418,11 @@ func createRestoreClient(ctx context.Context, g glue.Glue, cfg *RestoreConfig, m
 	}
 	client.SetCrypter(&cfg.CipherInfo)
 	client.SetUpstreamClusterID(cfg.upstreamClusterID)
+	client.SetMemoryBudget(membudget.New(int64(cfg.MemoryLimit)))
+	client.SetDeleteRangeBatchLimit(cfg.DeleteRangeBatch)
+	client.SetDeleteRangePace(cfg.DeleteRangePace)
+	client.SetVerifySampleRate(cfg.VerifySampleRate)
+	client.SetRewriteTSBatchKeys(cfg.RewriteTSBatchKeys)
 
 	createCheckpointSessionFn := func() (glue.Session, error) {
 		// always create a new session for checkpoint runner
@@ -1679,7 +2437,7 @@ func createRestoreClient(ctx context.Context, g glue.Glue, cfg *RestoreConfig, m
 		return nil, errors.Trace(err)
 	}
 
-	err = client.SetRawKVBatchClient(ctx, cfg.PD, cfg.TLS.ToKVSecurity())
+	err = client.SetRawKVBatchClient(ctx, cfg.PD, mgr.GetPDTLSConfig(), mgr.GetTLSConfig())
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
@@ -1705,6 +2463,139 @@ func rangeFilterFromIngestRecorder(recorder *ingestrec.IngestRecorder, rewriteRu
 	return errors.Trace(err)
 }
 
+// tableRanges returns the key ranges of the tables, or the partitions of the partitioned ones, including
+// both the records and the indexes.
+func tableRanges(tables []*model.TableInfo) []kv.KeyRange {
+	ids := make([]int64, 0, len(tables))
+	for _, table := range tables {
+		if table.Partition != nil {
+			for _, def := range table.Partition.Definitions {
+				ids = append(ids, def.ID)
+			}
+			continue
+		}
+		ids = append(ids, table.ID)
+	}
+	slices.Sort(ids)
+	ids = slices.Compact(ids)
+	ranges := make([]kv.KeyRange, 0, len(ids))
+	for _, id := range ids {
+		ranges = append(ranges, kv.KeyRange{
+			StartKey: tablecodec.EncodeTablePrefix(id),
+			EndKey:   tablecodec.EncodeTablePrefix(id + 1),
+		})
+	}
+	return ranges
+}
+
+// preferZoneLeaders moves the leaders of the restored tables into the zone of the backup storage if the
+// cross-zone transfer saved is worth the cost, the returned manager must be reset after the restore.
+func preferZoneLeaders(
+	ctx context.Context,
+	g glue.Glue,
+	client *logclient.LogClient,
+	cfg *RestoreConfig,
+	tableIDs map[int64]struct{},
+	rewriteRules map[int64]*restoreutils.RewriteRules,
+) (*logclient.ZoneLeaderManager, error) {
+	upstreamBytes, err := client.DMLBytesOfTables(ctx, tableIDs)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	// the merged partitions are counted into their tables.
+	tableBytes := make(map[int64]uint64, len(upstreamBytes))
+	for tableID, bytes := range upstreamBytes {
+		if rule := rewriteRules[tableID]; rule != nil && rule.NewTableID != 0 {
+			tableBytes[rule.NewTableID] += bytes
+		}
+	}
+	zoneLeaders, err := client.NewZoneLeaderManager(ctx, cfg.ZoneLeader)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if _, err := zoneLeaders.PreferZone(ctx, tableBytes); err != nil {
+		resetZoneLeaders(g, zoneLeaders)
+		return nil, errors.Trace(err)
+	}
+	return zoneLeaders, nil
+}
+
+// resetZoneLeaders removes the placement rules moving the leaders into the zone of the backup storage. It
+// runs in a new context, because the context of the restore may be canceled, and the rules left keep the
+// leaders of the restored tables in the zone until the next restore removes them.
+func resetZoneLeaders(g glue.Glue, zoneLeaders *logclient.ZoneLeaderManager) {
+	ctx, cancel := context.WithTimeout(context.Background(), restoreCleanupTimeout)
+	defer cancel()
+	if err := zoneLeaders.Reset(ctx); err != nil {
+		glue.GetConsole(g).Println(fmt.Sprintf("the placement rule group '%s' failed to be removed, it keeps the "+
+			"leaders of the restored tables in the zone of the backup storage, remove it by `pd-ctl config "+
+			"placement-rules rule-bundle delete %s` or retry the restore", logclient.ZoneLeaderRuleGroup,
+			logclient.ZoneLeaderRuleGroup))
+	}
+}
+
+// checkRewriteTSRegression refuses the log restore if the existing data in the tables to restore is written
+// after the RewriteTS, e.g. the tables are written by the applications before the restore starts, unless
+// --force is set. It runs before anything is restored. The RewriteTS allocated from the PD later is always
+// after the existing data, so only the RewriteTS reused from the checkpoint or provided by the other
+// sources is checked.
+func checkRewriteTSRegression(
+	ctx context.Context,
+	mgr *conn.Mgr,
+	cfg *RestoreConfig,
+	taskInfo *checkpoint.CheckpointTaskInfoForLogRestore,
+) error {
+	if cfg.Force {
+		log.Warn("skip checking the existing data of the tables to restore against the rewrite ts")
+		return nil
+	}
+	var rewriteTS uint64
+	switch {
+	case taskInfo != nil && taskInfo.Metadata != nil:
+		rewriteTS = taskInfo.Metadata.RewriteTS
+	case cfg.RewriteTSSource == "" || strings.EqualFold(cfg.RewriteTSSource, rewriteTSSourcePD):
+		return nil
+	default:
+		provider, err := newRewriteTSProvider(cfg.RewriteTSSource, mgr.GetPDClient(), &cfg.TLS)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		// the ts provided later isn't before this one.
+		if rewriteTS, err = restore.GetRewriteTS(ctx, provider, mgr.GetPDClient(), cfg.snapshotRestoredTS); err != nil {
+			return errors.Trace(err)
+		}
+	}
+
+	is := mgr.GetDomain().InfoSchema()
+	var tables []*model.TableInfo
+	for _, dbName := range is.AllSchemaNames() {
+		if util.IsMemOrSysDB(dbName.L) || checkpoint.IsCheckpointDB(dbName) {
+			continue
+		}
+		tableInfos, err := is.SchemaTableInfos(ctx, dbName)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		for _, tableInfo := range tableInfos {
+			if cfg.TableFilter.MatchTable(dbName.O, tableInfo.Name.O) {
+				tables = append(tables, tableInfo)
+			}
+		}
+	}
+	if len(tables) == 0 {
+		return nil
+	}
+	prober, err := restore.NewStoreKeyProber(mgr.GetStorage())
+	if err != nil {
+		return errors.Trace(err)
+	}
+	err = restore.NewTSRegressionGuard(mgr.GetPDClient(), prober).Check(ctx, rewriteTS, tableRanges(tables))
+	if berrors.ErrRestoreTSRegression.Equal(err) {
+		return errors.Annotatef(err, "set --%s to restore anyway", FlagStreamForce)
+	}
+	return errors.Trace(err)
+}
+
 func getExternalStorageOptions(cfg *Config, u *backuppb.StorageBackend) storage.ExternalStorageOptions {
 	var httpClient *http.Client
 	if u.GetGcs() == nil {
@@ -1714,6 +2605,8 @@ func getExternalStorageOptions(cfg *Config, u *backuppb.StorageBackend) storage.
 		NoCredentials:   cfg.NoCreds,
 		SendCredentials: cfg.SendCreds,
 		HTTPClient:      httpClient,
+		RetryPolicy:     cfg.storageRetryPolicy,
+		RequestStats:    cfg.storageRequestStats,
 	}
 }
 
@@ -1761,69 +2654,28 @@ func getLogRangeWithStorage(
 	ctx context.Context,
 	s storage.ExternalStorage,
 ) (backupLogInfo, error) {
-	// logStartTS: Get log start ts from backupmeta file.
-	metaData, err := s.ReadFile(ctx, metautil.MetaFile)
-	if err != nil {
-		return backupLogInfo{}, errors.Trace(err)
-	}
-	backupMeta := &backuppb.BackupMeta{}
-	if err = backupMeta.Unmarshal(metaData); err != nil {
-		return backupLogInfo{}, errors.Trace(err)
-	}
-	// endVersion > 0 represents that the storage has been used for `br backup`
-	if backupMeta.GetEndVersion() > 0 {
-		return backupLogInfo{}, errors.Annotate(berrors.ErrStorageUnknown,
-			"the storage has been used for full backup")
-	}
-	logStartTS := backupMeta.GetStartVersion()
-
-	// truncateTS: get log truncate ts from TruncateSafePointFileName.
-	// If truncateTS equals 0, which represents the stream log has never been truncated.
-	truncateTS, err := stream.GetTSFromFile(ctx, s, stream.TruncateSafePointFileName)
-	if err != nil {
-		return backupLogInfo{}, errors.Trace(err)
-	}
-	logMinTS := max(logStartTS, truncateTS)
-
-	// get max global resolved ts from metas.
-	logMaxTS, err := getGlobalCheckpointFromStorage(ctx, s)
+	logRange, err := stream.GetLogRange(ctx, s)
 	if err != nil {
 		return backupLogInfo{}, errors.Trace(err)
 	}
-	logMaxTS = max(logMinTS, logMaxTS)
-
 	return backupLogInfo{
-		logMaxTS:  logMaxTS,
-		logMinTS:  logMinTS,
-		clusterID: backupMeta.ClusterId,
+		logMaxTS:  logRange.MaxTS,
+		logMinTS:  logRange.MinTS,
+		clusterID: logRange.ClusterID,
 	}, nil
 }
 
-func getGlobalCheckpointFromStorage(ctx context.Context, s storage.ExternalStorage) (uint64, error) {
-	var globalCheckPointTS uint64 = 0
-	opt := storage.WalkOption{SubDir: stream.GetStreamBackupGlobalCheckpointPrefix()}
-	err := s.WalkDir(ctx, &opt, func(path string, size int64) error {
-		if !strings.HasSuffix(path, ".ts") {
-			return nil
-		}
-
-		buff, err := s.ReadFile(ctx, path)
-		if err != nil {
-			return errors.Trace(err)
-		}
-		ts := binary.LittleEndian.Uint64(buff)
-		globalCheckPointTS = max(ts, globalCheckPointTS)
-		return nil
-	})
-	return globalCheckPointTS, errors.Trace(err)
-}
-
 // getFullBackupTS gets the snapshot-ts of full backup
 func getFullBackupTS(
 	ctx context.Context,
 	cfg *RestoreConfig,
 ) (uint64, uint64, error) {
-	_, s, err := GetStorage(ctx, cfg.FullBackupStorage, &cfg.Config)
+	return readFullBackupTS(ctx, cfg.FullBackupStorage, &cfg.Config)
+}
+
+// readFullBackupTS reads the snapshot-ts and the cluster id of the full backup at the storage.
+func readFullBackupTS(ctx context.Context, fullBackupStorage string, cfg *Config) (uint64, uint64, error) {
+	_, s, err := GetStorage(ctx, fullBackupStorage, cfg)
 	if err != nil {
 		return 0, 0, errors.Trace(err)
 	}
@@ -1864,52 +2716,50 @@ func parseFullBackupTablesStorage(
 	}, nil
 }
 
-func initRewriteRules(schemasReplace *stream.SchemasReplace) map[int64]*restoreutils.RewriteRules {
-	rules := make(map[int64]*restoreutils.RewriteRules)
-	filter := schemasReplace.TableFilter
-
+// filterTenantRewriteRules removes the rewrite rules of the partitions not holding the tenants, so the log
+// backup entries of them are skipped. The partitions are located by the table infos restored from the meta
+// files, which include the partitions added by the DDLs in the log backup.
+func filterTenantRewriteRules(
+	store kv.Storage,
+	schemasReplace *stream.SchemasReplace,
+	rules map[int64]*restoreutils.RewriteRules,
+	filters map[string][]int64,
+) error {
+	m := meta.NewReader(store.GetSnapshot(kv.MaxVersion))
 	for _, dbReplace := range schemasReplace.DbMap {
-		if utils.IsSysDB(dbReplace.Name) || !filter.MatchSchema(dbReplace.Name) {
-			continue
-		}
-
-		for oldTableID, tableReplace := range dbReplace.TableMap {
-			if !filter.MatchTable(dbReplace.Name, tableReplace.Name) {
+		for _, tableReplace := range dbReplace.TableMap {
+			tenants, ok := filters[snapclient.TableKey(dbReplace.Name, tableReplace.Name)]
+			if !ok {
 				continue
 			}
-
-			if _, exist := rules[oldTableID]; !exist {
-				log.Info("add rewrite rule",
-					zap.String("tableName", dbReplace.Name+"."+tableReplace.Name),
-					zap.Int64("oldID", oldTableID), zap.Int64("newID", tableReplace.TableID))
-				rules[oldTableID] = restoreutils.GetRewriteRuleOfTable(
-					oldTableID, tableReplace.TableID, 0, tableReplace.IndexMap, false)
+			info, err := m.GetTable(dbReplace.DbID, tableReplace.TableID)
+			if meta.ErrDBNotExists.Equal(err) || (err == nil && info == nil) {
+				// the table is dropped by the log backup.
+				continue
+			}
+			if err != nil {
+				return errors.Trace(err)
+			}
+			selected, err := snapclient.SelectPartitions(info, tenants)
+			if err != nil {
+				return errors.Trace(err)
 			}
-
 			for oldID, newID := range tableReplace.PartitionMap {
-				if _, exist := rules[oldID]; !exist {
-					log.Info("add rewrite rule",
-						zap.String("tableName", dbReplace.Name+"."+tableReplace.Name),
-						zap.Int64("oldID", oldID), zap.Int64("newID", newID))
-					rules[oldID] = restoreutils.GetRewriteRuleOfTable(oldID, newID, 0, tableReplace.IndexMap, false)
+				if _, ok := selected[newID]; !ok {
+					delete(rules, oldID)
 				}
 			}
+			log.Info("filter log backup entries by tenants", zap.String("db", dbReplace.Name),
+				zap.String("table", tableReplace.Name), zap.Int64s("tenants", tenants), zap.Int("partitions", len(selected)))
 		}
 	}
-	return rules
+	return nil
 }
 
 // ShiftTS gets a smaller shiftTS than startTS.
 // It has a safe duration between shiftTS and startTS for trasaction.
 func ShiftTS(startTS uint64) uint64 {
-	physical := oracle.ExtractPhysical(startTS)
-	logical := oracle.ExtractLogical(startTS)
-
-	shiftPhysical := physical - streamShiftDuration.Milliseconds()
-	if shiftPhysical < 0 {
-		return 0
-	}
-	return oracle.ComposeTS(shiftPhysical, logical)
+	return stream.ShiftTS(startTS)
 }
 
 func buildPauseSafePointName(taskName string) string {
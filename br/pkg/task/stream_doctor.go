@@ -0,0 +1,187 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package task
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/log"
+	"github.com/pingcap/tidb/br/pkg/conn"
+	berrors "github.com/pingcap/tidb/br/pkg/errors"
+	"github.com/pingcap/tidb/br/pkg/glue"
+	"github.com/pingcap/tidb/br/pkg/httputil"
+	"github.com/pingcap/tidb/br/pkg/storage"
+	"github.com/pingcap/tidb/br/pkg/stream"
+	"github.com/pingcap/tidb/br/pkg/streamhelper"
+	"github.com/spf13/pflag"
+	"github.com/tikv/client-go/v2/oracle"
+	"go.uber.org/zap"
+)
+
+const (
+	flagStreamDoctorObserve = "observe-duration"
+	flagStreamDoctorMaxLag  = "max-checkpoint-lag"
+
+	doctorSampleInterval = time.Second
+)
+
+// DefineStreamDoctorFlags defines the flags of `log doctor`.
+func DefineStreamDoctorFlags(flags *pflag.FlagSet) {
+	flags.String(flagStreamTaskName, stream.WildCard,
+		"The task name for backup stream log. If default, check all of tasks",
+	)
+	flags.Bool(flagStreamJSONOutput, false, "Print JSON as the output.")
+	flags.Duration(flagStreamDoctorObserve, 10*time.Second,
+		"The duration to observe the owner of the advancer, the owner changing in it is reported.")
+	flags.Duration(flagStreamDoctorMaxLag, 10*time.Minute,
+		"The checkpoint of a running task lagging more than it is reported.")
+}
+
+// ParseStreamDoctorFromFlags parses the flags of `log doctor`.
+func (cfg *StreamConfig) ParseStreamDoctorFromFlags(flags *pflag.FlagSet) error {
+	var err error
+	if cfg.JSONOutput, err = flags.GetBool(flagStreamJSONOutput); err != nil {
+		return errors.Trace(err)
+	}
+	if cfg.DoctorObserveDuration, err = flags.GetDuration(flagStreamDoctorObserve); err != nil {
+		return errors.Trace(err)
+	}
+	if cfg.DoctorMaxCheckpointLag, err = flags.GetDuration(flagStreamDoctorMaxLag); err != nil {
+		return errors.Trace(err)
+	}
+	if cfg.DoctorObserveDuration < 0 || cfg.DoctorMaxCheckpointLag <= 0 {
+		return errors.Annotatef(berrors.ErrInvalidArgument, "--%s can't be negative and --%s must be positive",
+			flagStreamDoctorObserve, flagStreamDoctorMaxLag)
+	}
+	return errors.Trace(cfg.ParseStreamCommonFromFlags(flags))
+}
+
+type doctorResult struct {
+	Task     string                 `json:"task"`
+	Findings []stream.DoctorFinding `json:"findings"`
+}
+
+// RunStreamDoctor checks the log backup tasks for the common failure modes, and prints the probable
+// causes and the remediation steps of the problems found.
+func RunStreamDoctor(c context.Context, g glue.Glue, cmdName string, cfg *StreamConfig) error {
+	ctx, cancel := context.WithCancel(c)
+	defer cancel()
+
+	if err := checkConfigForStatus(cfg.PD); err != nil {
+		return err
+	}
+	etcdCLI, err := dialEtcdWithCfg(ctx, cfg.Config)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	cli := streamhelper.NewMetaDataClient(etcdCLI)
+	defer func() {
+		if closeErr := cli.Close(); closeErr != nil {
+			log.Warn("failed to close etcd client", zap.Error(closeErr))
+		}
+	}()
+	mgr, err := NewMgr(ctx, g, cfg.PD, cfg.TLS, GetKeepalive(&cfg.Config),
+		cfg.CheckRequirements, false, conn.StreamVersionChecker)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer mgr.Close()
+
+	var tasks []streamhelper.Task
+	if cfg.TaskName == stream.WildCard {
+		if tasks, err = cli.GetAllTasks(ctx); err != nil {
+			return errors.Trace(err)
+		}
+	} else {
+		t, err := cli.GetTask(ctx, cfg.TaskName)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		tasks = append(tasks, *t)
+	}
+	console := glue.GetConsole(g)
+	if len(tasks) == 0 {
+		console.Println("No Task Yet.")
+		return nil
+	}
+
+	// the states of the cluster are shared by the tasks.
+	stores, err := stream.CollectDoctorStores(ctx, mgr.GetPDHTTPClient(), httputil.NewClient(mgr.GetTLSConfig()),
+		mgr.GetTLSConfig() != nil)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	owners, err := stream.SampleAdvancerOwners(ctx, cli, cfg.DoctorObserveDuration, doctorSampleInterval)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	gcSafePoints, err := mgr.GetPDHTTPClient().GetGCSafePoint(ctx)
+	if err != nil {
+		return errors.Annotate(err, "failed to get the service GC safe points from PD")
+	}
+	safePoints := make(map[string]stream.DoctorSafePoint, len(gcSafePoints.ServiceGCSafepoints))
+	for _, sp := range gcSafePoints.ServiceGCSafepoints {
+		safePoints[sp.ServiceID] = stream.DoctorSafePoint{SafePoint: sp.SafePoint, ExpiredAt: time.Unix(sp.ExpiredAt, 0)}
+	}
+	currentTS, err := mgr.GetCurrentTsFromPD(ctx)
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	results := make([]doctorResult, 0, len(tasks))
+	for i := range tasks {
+		t := &tasks[i]
+		snapshot := &stream.DoctorSnapshot{
+			Now:              oracle.GetTimeFromTS(currentTS),
+			TaskName:         t.Info.Name,
+			Stores:           stores,
+			SafePoints:       safePoints,
+			PauseSafePointID: buildPauseSafePointName(t.Info.Name),
+			GCSafePoint:      gcSafePoints.GCSafePoint,
+			AdvancerOwners:   owners,
+			ObserveWindow:    cfg.DoctorObserveDuration,
+		}
+		if snapshot.Paused, err = t.IsPaused(ctx); err != nil {
+			return errors.Trace(err)
+		}
+		if snapshot.CheckpointTS, err = t.GetGlobalCheckPointTS(ctx); err != nil {
+			return errors.Trace(err)
+		}
+		if snapshot.LastErrors, err = t.LastError(ctx); err != nil {
+			return errors.Trace(err)
+		}
+		snapshot.StorageErr = probeTaskStorage(ctx, cfg, t)
+		results = append(results, doctorResult{
+			Task:     t.Info.Name,
+			Findings: stream.Diagnose(snapshot, cfg.DoctorMaxCheckpointLag),
+		})
+	}
+
+	if cfg.JSONOutput {
+		data, err := json.Marshal(results)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		console.Println(string(data))
+		return nil
+	}
+	for _, r := range results {
+		stream.PrintDoctorFindings(console, r.Task, r.Findings)
+	}
+	return nil
+}
+
+// probeTaskStorage writes a probe file into the storage of the task.
+func probeTaskStorage(ctx context.Context, cfg *StreamConfig, t *streamhelper.Task) error {
+	backend := t.Info.GetStorage()
+	opts := getExternalStorageOptions(&cfg.Config, backend)
+	s, err := storage.New(ctx, backend, &opts)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer s.Close()
+	return errors.Trace(stream.ProbeStorage(ctx, s, time.Now()))
+}
@@ -28,6 +28,9 @@ import (
 	"github.com/pingcap/tidb/br/pkg/metautil"
 	"github.com/pingcap/tidb/br/pkg/storage"
 	"github.com/pingcap/tidb/br/pkg/stream"
+	"github.com/pingcap/tidb/pkg/kv"
+	"github.com/pingcap/tidb/pkg/meta/model"
+	"github.com/pingcap/tidb/pkg/tablecodec"
 	"github.com/stretchr/testify/require"
 	"github.com/tikv/client-go/v2/oracle"
 )
@@ -38,7 +41,7 @@ func TestShiftTS(t *testing.T) {
 	require.Equal(t, true, shiftTS < startTS)
 
 	delta := oracle.GetTimeFromTS(startTS).Sub(oracle.GetTimeFromTS(shiftTS))
-	require.Equal(t, delta, streamShiftDuration)
+	require.Equal(t, delta, stream.ShiftDuration)
 }
 
 func TestCheckLogRange(t *testing.T) {
@@ -169,9 +172,12 @@ func TestGetGlobalCheckpointFromStorage(t *testing.T) {
 	err = fakeCheckpointFiles(ctx, tmpdir, infos)
 	require.Nil(t, err)
 
-	ts, err := getGlobalCheckpointFromStorage(ctx, s)
+	data, err := proto.Marshal(&backuppb.BackupMeta{StartVersion: 10})
 	require.Nil(t, err)
-	require.Equal(t, ts, uint64(99))
+	require.Nil(t, s.WriteFile(ctx, metautil.MetaFile, data))
+	logInfo, err := getLogRangeWithStorage(ctx, s)
+	require.Nil(t, err)
+	require.Equal(t, logInfo.logMaxTS, uint64(99))
 }
 
 func TestGetLogRangeWithFullBackupDir(t *testing.T) {
@@ -232,3 +238,30 @@ func TestGetExternalStorageOptions(t *testing.T) {
 	options = getExternalStorageOptions(&cfg, u)
 	require.Nil(t, options.HTTPClient)
 }
+
+func TestCheckStreamCredentialRef(t *testing.T) {
+	cfg := &StreamConfig{}
+	cfg.Storage = "s3://bucket/path?credential-ref=vault:secret/data/br"
+	cfg.SendCreds = true
+	require.ErrorIs(t, checkStreamCredentialRef(cfg), berrors.ErrInvalidArgument)
+	cfg.SendCreds = false
+	require.NoError(t, checkStreamCredentialRef(cfg))
+	cfg.Storage = "s3://bucket/path?access-key=ak"
+	cfg.SendCreds = true
+	require.NoError(t, checkStreamCredentialRef(cfg))
+}
+
+func TestTableRanges(t *testing.T) {
+	tables := []*model.TableInfo{
+		{ID: 102},
+		{ID: 101},
+		// the partitions of a partitioned table.
+		{ID: 103, Partition: &model.PartitionInfo{Definitions: []model.PartitionDefinition{{ID: 105}, {ID: 104}}}},
+	}
+	require.Equal(t, []kv.KeyRange{
+		{StartKey: tablecodec.EncodeTablePrefix(101), EndKey: tablecodec.EncodeTablePrefix(102)},
+		{StartKey: tablecodec.EncodeTablePrefix(102), EndKey: tablecodec.EncodeTablePrefix(103)},
+		{StartKey: tablecodec.EncodeTablePrefix(104), EndKey: tablecodec.EncodeTablePrefix(105)},
+		{StartKey: tablecodec.EncodeTablePrefix(105), EndKey: tablecodec.EncodeTablePrefix(106)},
+	}, tableRanges(tables))
+}
@@ -0,0 +1,97 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package task
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/docker/go-units"
+	"github.com/pingcap/errors"
+	"github.com/pingcap/tidb/br/pkg/glue"
+	"github.com/pingcap/tidb/br/pkg/stream"
+	"github.com/tikv/client-go/v2/oracle"
+)
+
+// truncateReportMaxItems is the max count of the items of each list printed by the truncate report,
+// all of them are logged.
+const truncateReportMaxItems = 20
+
+// truncateSnapshot is a snapshot backup taken for the log backup.
+type truncateSnapshot struct {
+	Storage  string
+	BackupTS uint64
+}
+
+// orphanedSnapshots returns the snapshots that can't be the base of the point in time restore after
+// truncating the log backup until the ts, since the log after them is removed.
+func orphanedSnapshots(snapshots []truncateSnapshot, until uint64) []truncateSnapshot {
+	orphaned := make([]truncateSnapshot, 0, len(snapshots))
+	for _, snapshot := range snapshots {
+		if snapshot.BackupTS < until {
+			orphaned = append(orphaned, snapshot)
+		}
+	}
+	return orphaned
+}
+
+func formatTruncateTS(ts uint64) string {
+	return fmt.Sprintf("%s(%d)", oracle.GetTimeFromTS(ts).Format("2006-01-02 15:04:05.0000"), ts)
+}
+
+// truncateReportItems formats the files, the earliest restorable ts of the tables and the orphaned
+// snapshots of the report.
+func truncateReportItems(report *stream.TruncateReport, orphaned []truncateSnapshot) (files, tables, snapshots []string) {
+	files = make([]string, 0, len(report.Files))
+	for _, f := range report.Files {
+		files = append(files, fmt.Sprintf("%s (%s, max-ts=%d)", f.Path, units.HumanSize(float64(f.Length)), f.MaxTS))
+	}
+	tableIDs := slices.Sorted(maps.Keys(report.TableEarliestTS))
+	tables = make([]string, 0, len(tableIDs))
+	for _, tableID := range tableIDs {
+		tables = append(tables, fmt.Sprintf("table %d: %s", tableID, formatTruncateTS(report.TableEarliestTS[tableID])))
+	}
+	snapshots = make([]string, 0, len(orphaned))
+	for _, snapshot := range orphaned {
+		snapshots = append(snapshots, fmt.Sprintf("%s (backup-ts=%s)", snapshot.Storage, formatTruncateTS(snapshot.BackupTS)))
+	}
+	return files, tables, snapshots
+}
+
+// printTruncateReport prints the preview of truncating the log backup for the dry run, including the
+// files to be deleted, the new earliest restorable ts of the tables and the snapshot backups orphaned.
+func printTruncateReport(
+	ctx context.Context,
+	console glue.ConsoleOperations,
+	cfg *StreamConfig,
+	report *stream.TruncateReport,
+) error {
+	snapshots := make([]truncateSnapshot, 0, len(cfg.FullBackupStorages))
+	for _, s := range cfg.FullBackupStorages {
+		backupTS, _, err := readFullBackupTS(ctx, s, &cfg.Config)
+		if err != nil {
+			return errors.Annotatef(err, "failed to read the snapshot backup %s", s)
+		}
+		snapshots = append(snapshots, truncateSnapshot{Storage: s, BackupTS: backupTS})
+	}
+	files, tables, orphaned := truncateReportItems(report, orphanedSnapshots(snapshots, cfg.Until))
+
+	console.Printf("The dry run would reclaim %s in %d files.\n",
+		units.HumanSize(float64(report.TotalSize)), len(report.Files))
+	glue.PrintList(console, "the files would be deleted:", files, truncateReportMaxItems)
+	if len(tables) > 0 {
+		glue.PrintList(console, "the earliest restorable ts of the tables whose data would be truncated:",
+			tables, truncateReportMaxItems)
+	}
+	if len(cfg.FullBackupStorages) > 0 {
+		if len(orphaned) == 0 {
+			console.Println("No snapshot backup would be orphaned.")
+		} else {
+			glue.PrintList(console, "the snapshot backups would be orphaned, they can't be the base of the point in time restore:",
+				orphaned, truncateReportMaxItems)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package task
+
+import (
+	"testing"
+
+	"github.com/pingcap/tidb/br/pkg/stream"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTruncateReportItems(t *testing.T) {
+	snapshots := []truncateSnapshot{
+		{Storage: "s3://bucket/full-1", BackupTS: 100},
+		{Storage: "s3://bucket/full-2", BackupTS: 200},
+		{Storage: "s3://bucket/full-3", BackupTS: 300},
+	}
+	orphaned := orphanedSnapshots(snapshots, 200)
+	require.Equal(t, snapshots[:1], orphaned)
+	require.Empty(t, orphanedSnapshots(snapshots, 100))
+
+	report := &stream.TruncateReport{
+		Files: []stream.TruncatedFile{
+			{Path: "v1/20260101/a.log", Length: 2048, MaxTS: 150},
+		},
+		TotalSize:       2048,
+		TableEarliestTS: map[int64]uint64{42: 200, 7: 250},
+	}
+	files, tables, orphanedItems := truncateReportItems(report, orphaned)
+	require.Equal(t, []string{"v1/20260101/a.log (2.048kB, max-ts=150)"}, files)
+	require.Len(t, tables, 2)
+	require.Contains(t, tables[0], "table 7: ")
+	require.Contains(t, tables[0], "(250)")
+	require.Contains(t, tables[1], "table 42: ")
+	require.Len(t, orphanedItems, 1)
+	require.Contains(t, orphanedItems[0], "s3://bucket/full-1 (backup-ts=")
+}
@@ -0,0 +1,108 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package utils
+
+import (
+	"strings"
+
+	"github.com/pingcap/errors"
+	berrors "github.com/pingcap/tidb/br/pkg/errors"
+	"github.com/pingcap/tidb/pkg/meta/model"
+	"github.com/pingcap/tidb/pkg/parser/charset"
+	"github.com/pingcap/tidb/pkg/util/collate"
+)
+
+// CharsetConversion converts the charset of the restored databases, tables and columns, e.g. from utf8 to
+// utf8mb4 for the clusters only allowing utf8mb4. The collations are converted to the ones of the new
+// charset with the same suffix, e.g. utf8_general_ci to utf8mb4_general_ci.
+//
+// The data is restored as it is, so only the conversions keeping the encoding of the values and the index
+// keys are supported: the values of the old charset must be valid in the new one, and the collations must
+// be compatible, see collate.CompatibleCollate.
+type CharsetConversion struct {
+	From string
+	To   string
+}
+
+// ParseCharsetConversion parses the conversion like "utf8:utf8mb4".
+func ParseCharsetConversion(s string) (*CharsetConversion, error) {
+	from, to, ok := strings.Cut(s, ":")
+	if !ok {
+		return nil, errors.Annotatef(berrors.ErrInvalidArgument,
+			"invalid charset conversion %q, it should be like \"utf8:utf8mb4\"", s)
+	}
+	c := &CharsetConversion{From: strings.ToLower(strings.TrimSpace(from)), To: strings.ToLower(strings.TrimSpace(to))}
+	for _, cs := range []string{c.From, c.To} {
+		if _, err := charset.GetCharsetInfo(cs); err != nil {
+			return nil, errors.Annotatef(berrors.ErrInvalidArgument, "invalid charset conversion %q: %v", s, err)
+		}
+	}
+	if c.From == c.To {
+		return nil, errors.Annotatef(berrors.ErrInvalidArgument, "invalid charset conversion %q, the charsets are the same", s)
+	}
+	if !charsetValuesCompatible(c.From, c.To) {
+		return nil, errors.Annotatef(berrors.ErrInvalidArgument,
+			"can't convert the charset %s to %s, the values need to be re-encoded", c.From, c.To)
+	}
+	return c, nil
+}
+
+// charsetValuesCompatible checks whether the values of the charset from are valid values of the charset to
+// in the same encoding.
+func charsetValuesCompatible(from, to string) bool {
+	return from == charset.CharsetUTF8 && to == charset.CharsetUTF8MB4
+}
+
+// convertCollation returns the collation of the new charset. An error is returned if the collation
+// isn't compatible with the converted one, so the index keys would be encoded differently.
+func (c *CharsetConversion) convertCollation(collation string) (string, error) {
+	if collation == "" {
+		return "", nil
+	}
+	converted := c.To + strings.TrimPrefix(strings.ToLower(collation), c.From)
+	if _, err := charset.GetCollationByName(converted); err != nil || !collate.CompatibleCollate(collation, converted) {
+		return "", errors.Annotatef(berrors.ErrInvalidArgument,
+			"can't convert the collation %s to the charset %s, the index keys need to be re-encoded", collation, c.To)
+	}
+	return converted, nil
+}
+
+// ConvertDB converts the default charset of the database.
+func (c *CharsetConversion) ConvertDB(info *model.DBInfo) error {
+	if !strings.EqualFold(info.Charset, c.From) {
+		return nil
+	}
+	collation, err := c.convertCollation(info.Collate)
+	if err != nil {
+		return errors.Annotatef(err, "failed to convert the charset of database %s", info.Name)
+	}
+	info.Charset, info.Collate = c.To, collation
+	return nil
+}
+
+// ConvertTable converts the default charset of the table and the charset of the columns, and returns
+// whether the table info is changed.
+func (c *CharsetConversion) ConvertTable(info *model.TableInfo) (bool, error) {
+	changed := false
+	if strings.EqualFold(info.Charset, c.From) {
+		collation, err := c.convertCollation(info.Collate)
+		if err != nil {
+			return false, errors.Annotatef(err, "failed to convert the charset of table %s", info.Name)
+		}
+		info.Charset, info.Collate = c.To, collation
+		changed = true
+	}
+	for _, col := range info.Columns {
+		if !strings.EqualFold(col.GetCharset(), c.From) {
+			continue
+		}
+		collation, err := c.convertCollation(col.GetCollate())
+		if err != nil {
+			return false, errors.Annotatef(err, "failed to convert the charset of column %s.%s", info.Name, col.Name)
+		}
+		col.SetCharset(c.To)
+		col.SetCollate(collation)
+		changed = true
+	}
+	return changed, nil
+}
@@ -0,0 +1,65 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package utils
+
+import (
+	"testing"
+
+	"github.com/pingcap/tidb/pkg/meta/model"
+	"github.com/pingcap/tidb/pkg/parser/ast"
+	"github.com/pingcap/tidb/pkg/parser/mysql"
+	"github.com/pingcap/tidb/pkg/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCharsetConversion(t *testing.T) {
+	for _, s := range []string{"utf8", "utf8:utf8", "utf8:nope", "utf8mb4:utf8", "latin1:utf8mb4"} {
+		_, err := ParseCharsetConversion(s)
+		require.Error(t, err, s)
+	}
+	c, err := ParseCharsetConversion(" UTF8 : utf8mb4 ")
+	require.NoError(t, err)
+	require.Equal(t, &CharsetConversion{From: "utf8", To: "utf8mb4"}, c)
+
+	db := &model.DBInfo{Name: ast.NewCIStr("db"), Charset: "utf8", Collate: "utf8_general_ci"}
+	require.NoError(t, c.ConvertDB(db))
+	require.Equal(t, "utf8mb4", db.Charset)
+	require.Equal(t, "utf8mb4_general_ci", db.Collate)
+
+	newColumn := func(name, cs, collation string) *model.ColumnInfo {
+		ft := types.NewFieldType(mysql.TypeVarchar)
+		ft.SetCharset(cs)
+		ft.SetCollate(collation)
+		return &model.ColumnInfo{Name: ast.NewCIStr(name), FieldType: *ft}
+	}
+	table := &model.TableInfo{
+		Name:    ast.NewCIStr("t"),
+		Charset: "utf8",
+		Collate: "utf8_bin",
+		Columns: []*model.ColumnInfo{
+			newColumn("a", "utf8", "utf8_unicode_ci"),
+			newColumn("b", "utf8mb4", "utf8mb4_0900_ai_ci"),
+			newColumn("c", "binary", "binary"),
+		},
+	}
+	changed, err := c.ConvertTable(table)
+	require.NoError(t, err)
+	require.True(t, changed)
+	require.Equal(t, "utf8mb4", table.Charset)
+	require.Equal(t, "utf8mb4_bin", table.Collate)
+	require.Equal(t, "utf8mb4", table.Columns[0].GetCharset())
+	require.Equal(t, "utf8mb4_unicode_ci", table.Columns[0].GetCollate())
+	require.Equal(t, "utf8mb4_0900_ai_ci", table.Columns[1].GetCollate())
+	require.Equal(t, "binary", table.Columns[2].GetCharset())
+	changed, err = c.ConvertTable(table)
+	require.NoError(t, err)
+	require.False(t, changed)
+
+	// utf8_tolower_ci has no compatible collation of utf8mb4.
+	table = &model.TableInfo{
+		Name:    ast.NewCIStr("t"),
+		Columns: []*model.ColumnInfo{newColumn("a", "utf8", "utf8_tolower_ci")},
+	}
+	_, err = c.ConvertTable(table)
+	require.ErrorContains(t, err, "the index keys need to be re-encoded")
+}
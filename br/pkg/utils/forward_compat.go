@@ -0,0 +1,277 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/pingcap/errors"
+)
+
+// UnknownJSONFields are the JSON fields unknown to a go structure, e.g. the fields of TableInfo
+// added by a newer version of TiDB. They can be added back when the structure is marshaled again,
+// so that rewriting the JSON doesn't drop them.
+type UnknownJSONFields struct {
+	diff  *jsonDiff
+	paths []string
+}
+
+// jsonDiff is the fields only in the original JSON, organized as the tree of the JSON.
+type jsonDiff struct {
+	fields   map[string]any
+	children map[string]*jsonDiff
+	elems    map[int]*jsonDiff
+}
+
+// UnmarshalWithUnknownFields unmarshals the JSON into v by the MetaJSONCodec, and returns
+// the fields unknown to v. nil is returned if there is no unknown field.
+func UnmarshalWithUnknownFields(data []byte, v any) (*UnknownJSONFields, error) {
+	codec := MetaJSON()
+	// the fast path, nearly every JSON is written by a version knowing all the fields.
+	if err := codec.UnmarshalStrict(data, v); err == nil {
+		return nil, nil
+	}
+	if err := codec.Unmarshal(data, v); err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	// the unknown fields are those lost after a round trip.
+	roundTrip, err := codec.Marshal(v)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	orig, err := decodeJSONValue(data)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	known, err := decodeJSONValue(roundTrip)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	u := &UnknownJSONFields{}
+	u.diff = diffJSON(orig, known, "", &u.paths)
+	if u.diff == nil {
+		return nil, nil
+	}
+	sort.Strings(u.paths)
+	return u, nil
+}
+
+// Paths returns the paths of the unknown fields with non-zero values, e.g. `partition.definitions[0].foo`.
+// The fields with zero values are usually the fields omitted if empty, they are preserved but not reported.
+func (u *UnknownJSONFields) Paths() []string {
+	if u == nil {
+		return nil
+	}
+	return u.paths
+}
+
+// MarshalWithUnknownFields marshals v by the MetaJSONCodec, and adds the unknown fields back.
+func MarshalWithUnknownFields(v any, unknown *UnknownJSONFields) ([]byte, error) {
+	data, err := MetaJSON().Marshal(v)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if unknown == nil {
+		return data, nil
+	}
+	value, err := decodeJSONValue(data)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	data, err = json.Marshal(applyJSONDiff(unknown.diff, value))
+	return data, errors.Trace(err)
+}
+
+// LossyJSONPaths returns the paths of the values in the original JSON which are lost or changed in the
+// JSON after a round trip, e.g. the unknown fields dropped or the numbers losing precision. The fields
+// only in the round trip JSON are ignored, they're usually the fields added with the default values, and
+// so are the fields with zero values omitted by the round trip.
+func LossyJSONPaths(orig, roundTrip []byte) ([]string, error) {
+	o, err := decodeJSONValue(orig)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	r, err := decodeJSONValue(roundTrip)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	paths := make([]string, 0)
+	lossyJSON(o, r, "", &paths)
+	sort.Strings(paths)
+	return paths, nil
+}
+
+func lossyJSON(orig, roundTrip any, path string, paths *[]string) {
+	root := func(p string) string {
+		if p == "" {
+			return "$"
+		}
+		return p
+	}
+	switch o := orig.(type) {
+	case map[string]any:
+		r, ok := roundTrip.(map[string]any)
+		if !ok {
+			*paths = append(*paths, root(path))
+			return
+		}
+		for key, ov := range o {
+			fieldPath := key
+			if path != "" {
+				fieldPath = path + "." + key
+			}
+			rv, ok := r[key]
+			if !ok {
+				if !isZeroJSONValue(ov) {
+					*paths = append(*paths, fieldPath)
+				}
+				continue
+			}
+			lossyJSON(ov, rv, fieldPath, paths)
+		}
+	case []any:
+		r, ok := roundTrip.([]any)
+		if !ok && len(o) == 0 && roundTrip == nil {
+			return
+		}
+		if !ok || len(r) != len(o) {
+			*paths = append(*paths, root(path))
+			return
+		}
+		for i := range o {
+			lossyJSON(o[i], r[i], fmt.Sprintf("%s[%d]", path, i), paths)
+		}
+	case json.Number:
+		r, ok := roundTrip.(json.Number)
+		if !ok || (o != r && !equalJSONNumber(o, r)) {
+			*paths = append(*paths, root(path))
+		}
+	default:
+		// the null is equal to the zero value of the field.
+		if orig == nil && isZeroJSONValue(roundTrip) {
+			return
+		}
+		if orig != roundTrip {
+			*paths = append(*paths, root(path))
+		}
+	}
+}
+
+// equalJSONNumber returns whether the numbers are equal exactly, e.g. 1e2 and 100.
+func equalJSONNumber(a, b json.Number) bool {
+	x, ok := new(big.Rat).SetString(a.String())
+	if !ok {
+		return false
+	}
+	y, ok := new(big.Rat).SetString(b.String())
+	return ok && x.Cmp(y) == 0
+}
+
+func decodeJSONValue(data []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	// keep the IDs exactly.
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, errors.Trace(err)
+	}
+	return v, nil
+}
+
+func diffJSON(orig, known any, path string, paths *[]string) *jsonDiff {
+	d := &jsonDiff{}
+	switch o := orig.(type) {
+	case map[string]any:
+		k, ok := known.(map[string]any)
+		if !ok {
+			return nil
+		}
+		for key, ov := range o {
+			fieldPath := key
+			if path != "" {
+				fieldPath = path + "." + key
+			}
+			kv, ok := k[key]
+			if !ok {
+				if d.fields == nil {
+					d.fields = make(map[string]any)
+				}
+				d.fields[key] = ov
+				if !isZeroJSONValue(ov) {
+					*paths = append(*paths, fieldPath)
+				}
+				continue
+			}
+			if child := diffJSON(ov, kv, fieldPath, paths); child != nil {
+				if d.children == nil {
+					d.children = make(map[string]*jsonDiff)
+				}
+				d.children[key] = child
+			}
+		}
+	case []any:
+		k, ok := known.([]any)
+		if !ok || len(k) != len(o) {
+			return nil
+		}
+		for i := range o {
+			if child := diffJSON(o[i], k[i], fmt.Sprintf("%s[%d]", path, i), paths); child != nil {
+				if d.elems == nil {
+					d.elems = make(map[int]*jsonDiff)
+				}
+				d.elems[i] = child
+			}
+		}
+	}
+	if d.fields == nil && d.children == nil && d.elems == nil {
+		return nil
+	}
+	return d
+}
+
+func applyJSONDiff(d *jsonDiff, value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, fv := range d.fields {
+			if _, ok := v[key]; !ok {
+				v[key] = fv
+			}
+		}
+		for key, child := range d.children {
+			if cv, ok := v[key]; ok {
+				v[key] = applyJSONDiff(child, cv)
+			}
+		}
+	case []any:
+		for i, child := range d.elems {
+			if i < len(v) {
+				v[i] = applyJSONDiff(child, v[i])
+			}
+		}
+	}
+	return value
+}
+
+func isZeroJSONValue(v any) bool {
+	switch x := v.(type) {
+	case nil:
+		return true
+	case bool:
+		return !x
+	case string:
+		return x == ""
+	case json.Number:
+		f, err := x.Float64()
+		return err == nil && f == 0
+	case []any:
+		return len(x) == 0
+	case map[string]any:
+		return len(x) == 0
+	}
+	return false
+}
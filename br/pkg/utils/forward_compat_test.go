@@ -0,0 +1,83 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type compatInner struct {
+	ID int64 `json:"id"`
+}
+
+type compatOuter struct {
+	ID      int64          `json:"id"`
+	Name    string         `json:"name"`
+	Comment string         `json:"comment,omitempty"`
+	Inners  []*compatInner `json:"inners"`
+}
+
+func TestUnknownJSONFields(t *testing.T) {
+	// all the fields are known.
+	var v compatOuter
+	unknown, err := UnmarshalWithUnknownFields([]byte(`{"id":1,"name":"a","inners":[{"id":2}]}`), &v)
+	require.NoError(t, err)
+	require.Nil(t, unknown)
+	require.Empty(t, unknown.Paths())
+	data, err := MarshalWithUnknownFields(&v, unknown)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"id":1,"name":"a","inners":[{"id":2}]}`, string(data))
+
+	// the unknown fields are kept after the known fields are rewritten.
+	orig := `{"id":1,"name":"a","new":{"n":9007199254740993},"zero":false,` +
+		`"inners":[{"id":2},{"id":3,"new":[1,2]}]}`
+	v = compatOuter{}
+	unknown, err = UnmarshalWithUnknownFields([]byte(orig), &v)
+	require.NoError(t, err)
+	require.Equal(t, compatOuter{ID: 1, Name: "a", Inners: []*compatInner{{ID: 2}, {ID: 3}}}, v)
+	require.Equal(t, []string{"inners[1].new", "new"}, unknown.Paths())
+
+	v.ID = 100
+	v.Inners[1].ID = 300
+	v.Comment = "c"
+	data, err = MarshalWithUnknownFields(&v, unknown)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"id":100,"name":"a","comment":"c","new":{"n":9007199254740993},"zero":false,`+
+		`"inners":[{"id":2},{"id":300,"new":[1,2]}]}`, string(data))
+
+	// the rewritten known fields aren't overwritten by the original values.
+	v = compatOuter{}
+	unknown, err = UnmarshalWithUnknownFields([]byte(`{"id":1,"comment":"c","new":1}`), &v)
+	require.NoError(t, err)
+	v.Comment = ""
+	data, err = MarshalWithUnknownFields(&v, unknown)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"id":1,"name":"","inners":null,"new":1}`, string(data))
+
+	_, err = UnmarshalWithUnknownFields([]byte(`{"id":"x"}`), &v)
+	require.Error(t, err)
+}
+
+func TestLossyJSONPaths(t *testing.T) {
+	paths, err := LossyJSONPaths(
+		[]byte(`{"id":1,"ratio":0.5,"big":1e2,"empty":[],"zero":0,"null":null,"cols":[{"id":1,"x":"a"}],"lost":{"a":1}}`),
+		[]byte(`{"id":1,"ratio":5e-1,"big":100,"null":false,"cols":[{"id":1,"x":"a"}],"added":true}`),
+	)
+	require.NoError(t, err)
+	require.Equal(t, []string{"lost"}, paths)
+
+	paths, err = LossyJSONPaths(
+		[]byte(`{"id":9007199254740993,"f":0.1,"cols":[{"x":"a"},{"x":"b"}],"s":"a","m":{"a":1}}`),
+		[]byte(`{"id":9007199254740992,"f":0.10000000000000001,"cols":[{"x":"a"}],"s":"b","m":[1]}`),
+	)
+	require.NoError(t, err)
+	require.Equal(t, []string{"cols", "f", "id", "m", "s"}, paths)
+
+	paths, err = LossyJSONPaths([]byte(`1`), []byte(`"1"`))
+	require.NoError(t, err)
+	require.Equal(t, []string{"$"}, paths)
+	_, err = LossyJSONPaths([]byte(`{`), []byte(`{}`))
+	require.Error(t, err)
+}
@@ -0,0 +1,92 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"sync/atomic"
+
+	gojson "github.com/goccy/go-json"
+	"github.com/pingcap/errors"
+)
+
+// MetaJSONCodec encodes and decodes the DBInfo and TableInfo JSON rewritten by the log restore. The meta
+// phase of a log restore may decode and encode millions of them, so a faster codec can be plugged in, as
+// long as it produces the same bytes as encoding/json for the model structures.
+type MetaJSONCodec interface {
+	// Name is the name of the codec, it's logged by the restore.
+	Name() string
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+	// UnmarshalStrict is Unmarshal returning an error if there is any field unknown to v.
+	UnmarshalStrict(data []byte, v any) error
+}
+
+// StdMetaJSONCodec is the MetaJSONCodec by encoding/json, it's the default one.
+var StdMetaJSONCodec MetaJSONCodec = stdMetaJSONCodec{}
+
+// GoccyMetaJSONCodec is the MetaJSONCodec by github.com/goccy/go-json, it's the default one if built with
+// the tag `metajson_goccy`.
+var GoccyMetaJSONCodec MetaJSONCodec = goccyMetaJSONCodec{}
+
+var metaJSONCodec = func() *atomic.Pointer[MetaJSONCodec] {
+	p := new(atomic.Pointer[MetaJSONCodec])
+	p.Store(&defaultMetaJSONCodec)
+	return p
+}()
+
+// MetaJSON returns the MetaJSONCodec in use.
+func MetaJSON() MetaJSONCodec {
+	return *metaJSONCodec.Load()
+}
+
+// SetMetaJSONCodec replaces the MetaJSONCodec in use, and returns the function restoring the previous one.
+func SetMetaJSONCodec(codec MetaJSONCodec) (restore func()) {
+	prev := metaJSONCodec.Swap(&codec)
+	return func() {
+		metaJSONCodec.Store(prev)
+	}
+}
+
+type stdMetaJSONCodec struct{}
+
+func (stdMetaJSONCodec) Name() string {
+	return "encoding/json"
+}
+
+func (stdMetaJSONCodec) Marshal(v any) ([]byte, error) {
+	data, err := json.Marshal(v)
+	return data, errors.Trace(err)
+}
+
+func (stdMetaJSONCodec) Unmarshal(data []byte, v any) error {
+	return errors.Trace(json.Unmarshal(data, v))
+}
+
+func (stdMetaJSONCodec) UnmarshalStrict(data []byte, v any) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	return errors.Trace(dec.Decode(v))
+}
+
+type goccyMetaJSONCodec struct{}
+
+func (goccyMetaJSONCodec) Name() string {
+	return "goccy/go-json"
+}
+
+func (goccyMetaJSONCodec) Marshal(v any) ([]byte, error) {
+	data, err := gojson.Marshal(v)
+	return data, errors.Trace(err)
+}
+
+func (goccyMetaJSONCodec) Unmarshal(data []byte, v any) error {
+	return errors.Trace(gojson.Unmarshal(data, v))
+}
+
+func (goccyMetaJSONCodec) UnmarshalStrict(data []byte, v any) error {
+	dec := gojson.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	return errors.Trace(dec.Decode(v))
+}
@@ -0,0 +1,7 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+//go:build metajson_goccy
+
+package utils
+
+var defaultMetaJSONCodec = GoccyMetaJSONCodec
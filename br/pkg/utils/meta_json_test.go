@@ -0,0 +1,138 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package utils
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/pingcap/tidb/pkg/meta/model"
+	"github.com/stretchr/testify/require"
+)
+
+// fillValue sets every field reachable from v to a non-zero value, so that no field is omitted when it's
+// encoded. The recursion stops at depth, the recursive structures are left nil below it.
+func fillValue(v reflect.Value, seed *int, depth int) {
+	*seed++
+	n := *seed
+	switch v.Kind() {
+	case reflect.Bool:
+		v.SetBool(true)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		// the values overflowing the small types are wrapped, they're non-zero anyway.
+		v.SetInt(int64(n%100+1) * 1_000_003)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		v.SetUint(uint64(n%100 + 1))
+	case reflect.Float32, reflect.Float64:
+		v.SetFloat(float64(n) + 0.1)
+	case reflect.String:
+		// the characters escaped by encoding/json.
+		v.SetString(fmt.Sprintf("s%d <&> \"\\   中文", n))
+	case reflect.Pointer:
+		if depth == 0 {
+			return
+		}
+		p := reflect.New(v.Type().Elem())
+		fillValue(p.Elem(), seed, depth-1)
+		v.Set(p)
+	case reflect.Slice:
+		if depth == 0 {
+			return
+		}
+		s := reflect.MakeSlice(v.Type(), 2, 2)
+		for i := range s.Len() {
+			fillValue(s.Index(i), seed, depth-1)
+		}
+		v.Set(s)
+	case reflect.Array:
+		for i := range v.Len() {
+			fillValue(v.Index(i), seed, depth)
+		}
+	case reflect.Map:
+		if depth == 0 {
+			return
+		}
+		m := reflect.MakeMap(v.Type())
+		for range 2 {
+			key := reflect.New(v.Type().Key()).Elem()
+			fillValue(key, seed, depth-1)
+			value := reflect.New(v.Type().Elem()).Elem()
+			fillValue(value, seed, depth-1)
+			m.SetMapIndex(key, value)
+		}
+		v.Set(m)
+	case reflect.Struct:
+		if v.Type() == reflect.TypeOf(time.Time{}) {
+			v.Set(reflect.ValueOf(time.Date(2026, 1, 2, 3, 4, 5, n, time.UTC)))
+			return
+		}
+		for i := range v.NumField() {
+			if v.Type().Field(i).IsExported() {
+				fillValue(v.Field(i), seed, depth)
+			}
+		}
+	}
+}
+
+func TestMetaJSONCodecCompatibility(t *testing.T) {
+	newFilled := func(v any) any {
+		seed := 0
+		fillValue(reflect.ValueOf(v).Elem(), &seed, 4)
+		return v
+	}
+	cases := []any{
+		&model.DBInfo{},
+		&model.TableInfo{},
+		newFilled(&model.DBInfo{}),
+		newFilled(&model.TableInfo{}),
+		newFilled(&model.TableInfo{}).(*model.TableInfo).Partition,
+		newFilled(&model.TableInfo{}).(*model.TableInfo).Columns[0],
+		newFilled(&model.TableInfo{}).(*model.TableInfo).Indices[0],
+	}
+	codecs := []MetaJSONCodec{StdMetaJSONCodec, GoccyMetaJSONCodec}
+	for _, c := range cases {
+		typ := reflect.TypeOf(c).Elem()
+		expected, err := StdMetaJSONCodec.Marshal(c)
+		require.NoError(t, err, typ)
+		for _, codec := range codecs {
+			// the output is byte stable.
+			data, err := codec.Marshal(c)
+			require.NoError(t, err, typ)
+			require.Equal(t, string(expected), string(data), "%s by %s", typ, codec.Name())
+
+			// the decoded structure is encoded to the same bytes again.
+			decoded := reflect.New(typ).Interface()
+			require.NoError(t, codec.UnmarshalStrict(expected, decoded), "%s by %s", typ, codec.Name())
+			again, err := StdMetaJSONCodec.Marshal(decoded)
+			require.NoError(t, err, typ)
+			require.Equal(t, string(expected), string(again), "%s by %s", typ, codec.Name())
+		}
+	}
+
+	for _, codec := range codecs {
+		var info model.DBInfo
+		require.Error(t, codec.UnmarshalStrict([]byte(`{"id":1,"unknown":2}`), &info), codec.Name())
+		require.NoError(t, codec.Unmarshal([]byte(`{"id":1,"unknown":2}`), &info), codec.Name())
+		require.Equal(t, int64(1), info.ID)
+	}
+}
+
+func TestSetMetaJSONCodec(t *testing.T) {
+	prev := MetaJSON()
+	restore := SetMetaJSONCodec(GoccyMetaJSONCodec)
+	require.Equal(t, GoccyMetaJSONCodec, MetaJSON())
+
+	// the unknown fields are kept by the plugged codec too.
+	info := &model.TableInfo{}
+	unknown, err := UnmarshalWithUnknownFields([]byte(`{"id":1,"name":{"O":"t","L":"t"},"future":{"a":1}}`), info)
+	require.NoError(t, err)
+	require.Equal(t, []string{"future"}, unknown.Paths())
+	data, err := MarshalWithUnknownFields(info, unknown)
+	require.NoError(t, err)
+	require.Contains(t, string(data), `"future":{"a":1}`)
+
+	restore()
+	require.Equal(t, prev, MetaJSON())
+}
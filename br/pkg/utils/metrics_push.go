@@ -0,0 +1,85 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package utils
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/pingcap/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/push"
+	"go.uber.org/zap"
+)
+
+// metricsPushJob is the job label of the metrics pushed by BR.
+const metricsPushJob = "br"
+
+// metricsPushTimeout is the timeout of a push, so an unreachable push gateway can't block the pusher forever.
+var metricsPushTimeout = 10 * time.Second
+
+// MetricsPusher pushes the metrics to the Prometheus push gateway periodically, and once more when it's
+// stopped, so the metrics of the short-lived BR runs aren't lost before Prometheus scrapes them.
+type MetricsPusher struct {
+	pusher   *push.Pusher
+	interval time.Duration
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// StartMetricsPusher starts pushing the metrics of the gatherer to the push gateway at addr. The labels
+// identify the run, e.g. the command and the task name, they group the metrics in the push gateway so the
+// pushes of the concurrent runs don't overwrite each other. The metrics are only pushed when stopped if
+// interval isn't positive.
+func StartMetricsPusher(
+	addr string,
+	interval time.Duration,
+	gatherer prometheus.Gatherer,
+	labels map[string]string,
+) *MetricsPusher {
+	pusher := push.New(addr, metricsPushJob).Gatherer(gatherer).Client(&http.Client{Timeout: metricsPushTimeout})
+	for name, value := range labels {
+		pusher = pusher.Grouping(name, value)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	p := &MetricsPusher{pusher: pusher, interval: interval, cancel: cancel}
+	log.Info("start to push metrics", zap.String("addr", addr), zap.Duration("interval", interval),
+		zap.Any("labels", labels))
+	if interval > 0 {
+		p.wg.Add(1)
+		go func() {
+			defer p.wg.Done()
+			p.pushLoop(ctx)
+		}()
+	}
+	return p
+}
+
+func (p *MetricsPusher) pushLoop(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := p.pusher.Push(); err != nil {
+				log.Warn("failed to push metrics", zap.Error(err))
+			}
+		}
+	}
+}
+
+// Stop stops pushing the metrics periodically, and pushes the final metrics.
+func (p *MetricsPusher) Stop() {
+	p.cancel()
+	p.wg.Wait()
+	if err := p.pusher.Push(); err != nil {
+		log.Warn("failed to push the final metrics", zap.Error(err))
+		return
+	}
+	log.Info("push the final metrics")
+}
@@ -0,0 +1,85 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package utils
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetricsPusher(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		pushes []string
+		bodies []string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		mu.Lock()
+		pushes = append(pushes, r.Method+" "+r.URL.Path)
+		bodies = append(bodies, string(body))
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+	takePushes := func() ([]string, []string) {
+		mu.Lock()
+		defer mu.Unlock()
+		p, b := pushes, bodies
+		pushes, bodies = nil, nil
+		return p, b
+	}
+
+	registry := prometheus.NewRegistry()
+	counter := prometheus.NewCounter(prometheus.CounterOpts{Name: "br_test_pushed_total"})
+	registry.MustRegister(counter)
+	counter.Inc()
+
+	// the metrics are only pushed when stopped.
+	p := StartMetricsPusher(server.URL, 0, registry, map[string]string{"command": "restore-full"})
+	p.Stop()
+	got, gotBodies := takePushes()
+	require.Equal(t, []string{"PUT /metrics/job/br/command/restore-full"}, got)
+	require.True(t, strings.Contains(gotBodies[0], "br_test_pushed_total"))
+
+	p = StartMetricsPusher(server.URL, 10*time.Millisecond, registry, map[string]string{"command": "log-start"})
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(pushes) >= 2
+	}, 5*time.Second, 10*time.Millisecond)
+	p.Stop()
+	got, _ = takePushes()
+	for _, push := range got {
+		require.Equal(t, "PUT /metrics/job/br/command/log-start", push)
+	}
+}
+
+func TestMetricsPusherTimeout(t *testing.T) {
+	done := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-done
+	}))
+	defer server.Close()
+	defer close(done)
+	origin := metricsPushTimeout
+	metricsPushTimeout = 100 * time.Millisecond
+	defer func() {
+		metricsPushTimeout = origin
+	}()
+
+	// the push to the hanging push gateway is given up after the timeout.
+	p := StartMetricsPusher(server.URL, 0, prometheus.NewRegistry(), nil)
+	start := time.Now()
+	p.Stop()
+	require.Less(t, time.Since(start), 5*time.Second)
+}
@@ -0,0 +1,135 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package utils
+
+import (
+	"os"
+	"strings"
+
+	"github.com/pingcap/errors"
+	berrors "github.com/pingcap/tidb/br/pkg/errors"
+	"github.com/pingcap/tidb/pkg/meta/model"
+	"github.com/pingcap/tidb/pkg/parser/ast"
+	"gopkg.in/yaml.v2"
+)
+
+// PlacementPolicyNone removes the references to the upstream placement policy.
+const PlacementPolicyNone = "none"
+
+// PlacementTemplate maps the placement policies of the upstream cluster to the ones of the downstream
+// cluster, so the databases and tables can be restored across the topologies, e.g. from 3 data centers
+// to a single one, where the constraints of the upstream policies can't be satisfied. The template is a
+// YAML file like:
+//
+//	policies:
+//	  three_dc: single_dc
+//	  two_region: none
+//
+// The references to the policies mapped to `none` are removed from the databases, tables and partitions,
+// and the policies not in the template are kept.
+type PlacementTemplate struct {
+	// Policies maps the lower-case names of the upstream policies to the references of the downstream
+	// ones, nil means none. The IDs of the references are 0 until ResolvePolicyIDs.
+	Policies map[string]*model.PolicyRefInfo
+}
+
+// ParsePlacementTemplate parses the template in YAML.
+func ParsePlacementTemplate(data []byte) (*PlacementTemplate, error) {
+	var file struct {
+		Policies map[string]string `yaml:"policies"`
+	}
+	if err := yaml.UnmarshalStrict(data, &file); err != nil {
+		return nil, errors.Annotatef(berrors.ErrInvalidArgument, "invalid placement template: %v", err)
+	}
+	t := &PlacementTemplate{Policies: make(map[string]*model.PolicyRefInfo, len(file.Policies))}
+	for upstream, downstream := range file.Policies {
+		upstream, downstream = strings.TrimSpace(upstream), strings.TrimSpace(downstream)
+		if upstream == "" || downstream == "" {
+			return nil, errors.Annotatef(berrors.ErrInvalidArgument,
+				"invalid placement template, the policy names must not be empty")
+		}
+		if _, ok := t.Policies[strings.ToLower(upstream)]; ok {
+			return nil, errors.Annotatef(berrors.ErrInvalidArgument,
+				"invalid placement template, duplicate upstream policy %s", upstream)
+		}
+		var ref *model.PolicyRefInfo
+		if !strings.EqualFold(downstream, PlacementPolicyNone) {
+			ref = &model.PolicyRefInfo{Name: ast.NewCIStr(downstream)}
+		}
+		t.Policies[strings.ToLower(upstream)] = ref
+	}
+	return t, nil
+}
+
+// LoadPlacementTemplate loads the template from the file.
+func LoadPlacementTemplate(path string) (*PlacementTemplate, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Annotatef(err, "failed to read the placement template %s", path)
+	}
+	return ParsePlacementTemplate(data)
+}
+
+// ResolvePolicyIDs sets the IDs of the downstream policies, which are required when the meta kv entries
+// are written directly, e.g. in log restore.
+func (t *PlacementTemplate) ResolvePolicyIDs(policyID func(name ast.CIStr) (int64, bool)) error {
+	for _, ref := range t.Policies {
+		if ref == nil {
+			continue
+		}
+		id, ok := policyID(ref.Name)
+		if !ok {
+			return errors.Annotatef(berrors.ErrInvalidArgument,
+				"the placement policy %s of the placement template doesn't exist in the downstream cluster", ref.Name)
+		}
+		ref.ID = id
+	}
+	return nil
+}
+
+// ReplacesPolicy checks whether the references to the upstream policy are replaced, so the policy
+// doesn't need to be restored.
+func (t *PlacementTemplate) ReplacesPolicy(name ast.CIStr) bool {
+	ref, ok := t.Policies[name.L]
+	return ok && (ref == nil || ref.Name.L != name.L)
+}
+
+// mapRef returns the reference to the downstream policy, and whether the reference is changed.
+func (t *PlacementTemplate) mapRef(ref *model.PolicyRefInfo) (*model.PolicyRefInfo, bool) {
+	if ref == nil {
+		return nil, false
+	}
+	newRef, ok := t.Policies[ref.Name.L]
+	if !ok {
+		return ref, false
+	}
+	if newRef == nil {
+		return nil, true
+	}
+	// the reference may be normalized in place when the table is created, so it isn't shared.
+	return &model.PolicyRefInfo{ID: newRef.ID, Name: newRef.Name}, true
+}
+
+// ApplyDB replaces the placement policy of the database, and returns whether the database info is changed.
+func (t *PlacementTemplate) ApplyDB(info *model.DBInfo) bool {
+	var changed bool
+	info.PlacementPolicyRef, changed = t.mapRef(info.PlacementPolicyRef)
+	return changed
+}
+
+// ApplyTable replaces the placement policies of the table and its partitions, and returns whether the
+// table info is changed.
+func (t *PlacementTemplate) ApplyTable(info *model.TableInfo) bool {
+	var changed bool
+	info.PlacementPolicyRef, changed = t.mapRef(info.PlacementPolicyRef)
+	if info.Partition == nil {
+		return changed
+	}
+	for i := range info.Partition.Definitions {
+		def := &info.Partition.Definitions[i]
+		var defChanged bool
+		def.PlacementPolicyRef, defChanged = t.mapRef(def.PlacementPolicyRef)
+		changed = changed || defChanged
+	}
+	return changed
+}
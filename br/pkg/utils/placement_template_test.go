@@ -0,0 +1,67 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package utils
+
+import (
+	"testing"
+
+	"github.com/pingcap/tidb/pkg/meta/model"
+	"github.com/pingcap/tidb/pkg/parser/ast"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlacementTemplate(t *testing.T) {
+	for _, data := range []string{
+		"policies: [a, b]",
+		"policy:\n  a: b",
+		"policies:\n  a: ''",
+		"policies:\n  a: b\n  A: c",
+	} {
+		_, err := ParsePlacementTemplate([]byte(data))
+		require.Error(t, err, data)
+	}
+
+	tmpl, err := ParsePlacementTemplate([]byte("policies:\n  Three_DC: single_dc\n  two_region: NONE\n  same: Same"))
+	require.NoError(t, err)
+	require.True(t, tmpl.ReplacesPolicy(ast.NewCIStr("three_dc")))
+	require.True(t, tmpl.ReplacesPolicy(ast.NewCIStr("two_region")))
+	require.False(t, tmpl.ReplacesPolicy(ast.NewCIStr("same")))
+	require.False(t, tmpl.ReplacesPolicy(ast.NewCIStr("other")))
+
+	ref := func(id int64, name string) *model.PolicyRefInfo {
+		return &model.PolicyRefInfo{ID: id, Name: ast.NewCIStr(name)}
+	}
+	db := &model.DBInfo{PlacementPolicyRef: ref(1, "two_region")}
+	require.True(t, tmpl.ApplyDB(db))
+	require.Nil(t, db.PlacementPolicyRef)
+	db.PlacementPolicyRef = ref(2, "other")
+	require.False(t, tmpl.ApplyDB(db))
+	require.Equal(t, ref(2, "other"), db.PlacementPolicyRef)
+
+	table := &model.TableInfo{
+		PlacementPolicyRef: ref(3, "three_dc"),
+		Partition: &model.PartitionInfo{Definitions: []model.PartitionDefinition{
+			{PlacementPolicyRef: ref(1, "two_region")},
+			{PlacementPolicyRef: ref(2, "other")},
+			{},
+		}},
+	}
+	require.True(t, tmpl.ApplyTable(table))
+	require.Equal(t, ref(0, "single_dc"), table.PlacementPolicyRef)
+	require.Nil(t, table.Partition.Definitions[0].PlacementPolicyRef)
+	require.Equal(t, ref(2, "other"), table.Partition.Definitions[1].PlacementPolicyRef)
+	require.Nil(t, table.Partition.Definitions[2].PlacementPolicyRef)
+	require.False(t, tmpl.ApplyTable(table))
+
+	policyIDs := map[string]int64{"single_dc": 10, "same": 11}
+	policyID := func(name ast.CIStr) (int64, bool) {
+		id, ok := policyIDs[name.L]
+		return id, ok
+	}
+	require.NoError(t, tmpl.ResolvePolicyIDs(policyID))
+	table.PlacementPolicyRef = ref(3, "three_dc")
+	require.True(t, tmpl.ApplyTable(table))
+	require.Equal(t, ref(10, "single_dc"), table.PlacementPolicyRef)
+	delete(policyIDs, "single_dc")
+	require.ErrorContains(t, tmpl.ResolvePolicyIDs(policyID), "doesn't exist in the downstream cluster")
+}
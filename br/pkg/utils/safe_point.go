@@ -100,23 +100,36 @@ func StartServiceSafePointKeeper(
 	pdClient pd.Client,
 	sp BRServiceSafePoint,
 ) error {
+	_, err := startServiceSafePointKeeper(ctx, pdClient, sp)
+	return errors.Trace(err)
+}
+
+// startServiceSafePointKeeper returns a channel closed once the keeper exits,
+// i.e. the service safe point is no longer updated.
+func startServiceSafePointKeeper(
+	ctx context.Context,
+	pdClient pd.Client,
+	sp BRServiceSafePoint,
+) (<-chan struct{}, error) {
 	if sp.ID == "" || sp.TTL <= 0 {
-		return errors.Annotatef(berrors.ErrInvalidArgument, "invalid service safe point %v", sp)
+		return nil, errors.Annotatef(berrors.ErrInvalidArgument, "invalid service safe point %v", sp)
 	}
 	if err := CheckGCSafePoint(ctx, pdClient, sp.BackupTS); err != nil {
-		return errors.Trace(err)
+		return nil, errors.Trace(err)
 	}
 	// Update service safe point immediately to cover the gap between starting
 	// update goroutine and updating service safe point.
 	if err := UpdateServiceSafePoint(ctx, pdClient, sp); err != nil {
-		return errors.Trace(err)
+		return nil, errors.Trace(err)
 	}
 
 	// It would be OK since TTL won't be zero, so gapTime should > `0.
 	updateGapTime := time.Duration(sp.TTL) * time.Second / preUpdateServiceSafePointFactor
 	updateTick := time.NewTicker(updateGapTime)
 	checkTick := time.NewTicker(checkGCSafePointGapTime)
+	done := make(chan struct{})
 	go func() {
+		defer close(done)
 		defer updateTick.Stop()
 		defer checkTick.Stop()
 		for {
@@ -125,6 +138,10 @@ func StartServiceSafePointKeeper(
 				log.Debug("service safe point keeper exited")
 				return
 			case <-updateTick.C:
+				if ctx.Err() != nil {
+					// the safe point may have been removed.
+					return
+				}
 				if err := UpdateServiceSafePoint(ctx, pdClient, sp); err != nil {
 					log.Warn("failed to update service safe point, backup may fail if gc triggered",
 						zap.Error(err),
@@ -140,5 +157,5 @@ func StartServiceSafePointKeeper(
 			}
 		}
 	}()
-	return nil
+	return done, nil
 }
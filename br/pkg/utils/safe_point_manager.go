@@ -0,0 +1,221 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package utils
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/log"
+	berrors "github.com/pingcap/tidb/br/pkg/errors"
+	pd "github.com/tikv/pd/client"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.uber.org/zap"
+)
+
+// safePointOwnerPrefix is the prefix of the keys recording the owners of the service safe points in the
+// etcd of the cluster, the key format is {safePointOwnerPrefix}/{safePointID}.
+const safePointOwnerPrefix = "/tidb/brie/safepoint"
+
+var globalSafePointManager = NewSafePointManager()
+
+// GlobalSafePointManager returns the SafePointManager shared by all the BR tasks in the process.
+func GlobalSafePointManager() *SafePointManager {
+	return globalSafePointManager
+}
+
+// SafePointManager owns the service safe points registered by the BR tasks, so that the concurrent
+// tasks never update or remove the safe points of each other. The tasks of the same process are
+// coordinated by the manager, and the tasks of the other BR processes or TiDB instances by the owners
+// recorded in the etcd of the cluster.
+type SafePointManager struct {
+	mu     sync.Mutex
+	leases map[string]*SafePointLease
+}
+
+// NewSafePointManager creates a SafePointManager.
+func NewSafePointManager() *SafePointManager {
+	return &SafePointManager{leases: make(map[string]*SafePointLease)}
+}
+
+// SafePointLease is a service safe point owned by a task. It is renewed until released, and
+// PD removes it once the TTL expires if BR exits without releasing it.
+type SafePointLease struct {
+	Task       string
+	SafePoint  BRServiceSafePoint
+	Registered time.Time
+
+	manager  *SafePointManager
+	pdClient pd.Client
+	etcdCli  *clientv3.Client
+	leaseID  clientv3.LeaseID
+	cancel   context.CancelFunc
+	done     <-chan struct{}
+	released bool
+}
+
+// Register registers the service safe point for the task and renews it until the lease is released.
+// It fails if the safe point is owned by another task. If etcdCli isn't nil, the ownership is claimed
+// in the etcd of the cluster by a lease too, so the tasks of the other processes can't take it either.
+func (m *SafePointManager) Register(
+	ctx context.Context,
+	pdClient pd.Client,
+	etcdCli *clientv3.Client,
+	task string,
+	sp BRServiceSafePoint,
+) (*SafePointLease, error) {
+	m.mu.Lock()
+	if owner, ok := m.leases[sp.ID]; ok {
+		m.mu.Unlock()
+		return nil, errors.Annotatef(berrors.ErrInvalidArgument,
+			"service safe point %s is owned by task %s", sp.ID, owner.Task)
+	}
+	lease := &SafePointLease{
+		Task:       task,
+		SafePoint:  sp,
+		Registered: time.Now(),
+		manager:    m,
+		pdClient:   pdClient,
+		etcdCli:    etcdCli,
+		leaseID:    clientv3.NoLease,
+	}
+	// reserve the ID before talking to PD.
+	m.leases[sp.ID] = lease
+	m.mu.Unlock()
+
+	kctx, cancel := context.WithCancel(ctx)
+	if etcdCli != nil {
+		if err := lease.claim(kctx); err != nil {
+			cancel()
+			m.remove(sp.ID)
+			return nil, errors.Trace(err)
+		}
+	}
+	done, err := startServiceSafePointKeeper(kctx, pdClient, sp)
+	if err != nil {
+		cancel()
+		lease.unclaim(ctx)
+		m.remove(sp.ID)
+		return nil, errors.Trace(err)
+	}
+	lease.cancel, lease.done = cancel, done
+	log.Info("registered service safe point", zap.String("task", task), zap.Object("safePoint", sp))
+	return lease, nil
+}
+
+// claim records the task as the owner of the safe point in etcd with a lease kept alive until ctx is
+// done, it fails if the safe point is owned by a task of another process.
+func (l *SafePointLease) claim(ctx context.Context) error {
+	ttl := l.SafePoint.TTL
+	if ttl <= 0 {
+		ttl = DefaultBRGCSafePointTTL
+	}
+	grant, err := l.etcdCli.Grant(ctx, ttl)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	hostname, _ := os.Hostname()
+	owner := fmt.Sprintf("%s (%s, pid %d)", l.Task, hostname, os.Getpid())
+	key := path.Join(safePointOwnerPrefix, l.SafePoint.ID)
+	resp, err := l.etcdCli.Txn(ctx).
+		If(clientv3.Compare(clientv3.CreateRevision(key), "=", 0)).
+		Then(clientv3.OpPut(key, owner, clientv3.WithLease(grant.ID))).
+		Else(clientv3.OpGet(key)).
+		Commit()
+	if err != nil {
+		l.revoke(ctx, grant.ID)
+		return errors.Trace(err)
+	}
+	if !resp.Succeeded {
+		l.revoke(ctx, grant.ID)
+		var current string
+		if kvs := resp.Responses[0].GetResponseRange().GetKvs(); len(kvs) > 0 {
+			current = string(kvs[0].Value)
+		}
+		return errors.Annotatef(berrors.ErrInvalidArgument,
+			"service safe point %s is owned by task %s", l.SafePoint.ID, current)
+	}
+	keepAlive, err := l.etcdCli.KeepAlive(ctx, grant.ID)
+	if err != nil {
+		l.revoke(ctx, grant.ID)
+		return errors.Trace(err)
+	}
+	go func() {
+		// drain the responses, the channel is closed once ctx is done.
+		for range keepAlive {
+		}
+	}()
+	l.leaseID = grant.ID
+	return nil
+}
+
+// unclaim removes the owner of the safe point from etcd by revoking its lease.
+func (l *SafePointLease) unclaim(ctx context.Context) {
+	if l.leaseID == clientv3.NoLease {
+		return
+	}
+	l.revoke(ctx, l.leaseID)
+	l.leaseID = clientv3.NoLease
+}
+
+func (l *SafePointLease) revoke(ctx context.Context, id clientv3.LeaseID) {
+	if _, err := l.etcdCli.Revoke(ctx, id); err != nil {
+		log.Warn("failed to revoke the lease of the service safe point owner, it's removed once expired",
+			zap.String("id", l.SafePoint.ID), zap.Int64("lease-id", int64(id)), zap.Error(err))
+	}
+}
+
+// Leases returns the registered leases ordered by the registration time.
+func (m *SafePointManager) Leases() []*SafePointLease {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	leases := make([]*SafePointLease, 0, len(m.leases))
+	for _, l := range m.leases {
+		leases = append(leases, l)
+	}
+	sort.Slice(leases, func(i, j int) bool { return leases[i].Registered.Before(leases[j].Registered) })
+	return leases
+}
+
+func (m *SafePointManager) remove(id string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.leases, id)
+}
+
+// Release stops renewing the service safe point and removes it from PD. If keep is true, the safe point
+// is left to expire by its TTL instead, e.g. the checkpoint of an unfinished task needs it to retry.
+func (l *SafePointLease) Release(ctx context.Context, keep bool) error {
+	l.manager.mu.Lock()
+	if l.released {
+		l.manager.mu.Unlock()
+		return nil
+	}
+	l.released = true
+	l.manager.mu.Unlock()
+
+	// wait for the keeper to exit, otherwise it may add the safe point back after it's removed.
+	l.cancel()
+	<-l.done
+	defer l.manager.remove(l.SafePoint.ID)
+	// the ownership is released even if the safe point is kept, so the retry of the task can take it.
+	defer l.unclaim(ctx)
+	if keep {
+		log.Info("keep service safe point until it expires", zap.String("task", l.Task), zap.Object("safePoint", l.SafePoint))
+		return nil
+	}
+	sp := l.SafePoint
+	// set the ttl to 0 to remove the safe point.
+	sp.TTL = 0
+	if err := UpdateServiceSafePoint(ctx, l.pdClient, sp); err != nil {
+		return errors.Trace(err)
+	}
+	log.Info("removed service safe point", zap.String("task", l.Task), zap.String("id", sp.ID))
+	return nil
+}
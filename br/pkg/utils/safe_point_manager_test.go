@@ -0,0 +1,85 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package utils_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pingcap/tidb/br/pkg/utils"
+	"github.com/stretchr/testify/require"
+	"go.etcd.io/etcd/tests/v3/integration"
+)
+
+func TestSafePointManager(t *testing.T) {
+	ctx := context.Background()
+	pdClient := &mockSafePoint{safepoint: 2333, services: make(map[string]uint64)}
+	m := utils.NewSafePointManager()
+
+	backupSP := utils.BRServiceSafePoint{ID: "br-backup", TTL: 10, BackupTS: 3000}
+	backup, err := m.Register(ctx, pdClient, nil, "backup", backupSP)
+	require.NoError(t, err)
+	restore, err := m.Register(ctx, pdClient, nil, "restore", utils.BRServiceSafePoint{ID: "br-restore", TTL: 10, BackupTS: 4000})
+	require.NoError(t, err)
+
+	// the safe point owned by another task can't be registered.
+	_, err = m.Register(ctx, pdClient, nil, "log restore", backupSP)
+	require.ErrorContains(t, err, "owned by task backup")
+	// the safe point behind the GC safe point is rejected and not held.
+	_, err = m.Register(ctx, pdClient, nil, "stale", utils.BRServiceSafePoint{ID: "br-stale", TTL: 10, BackupTS: 2000})
+	require.Error(t, err)
+	leases := m.Leases()
+	require.Len(t, leases, 2)
+	require.Equal(t, "backup", leases[0].Task)
+	require.Equal(t, "restore", leases[1].Task)
+
+	sp, ok := pdClient.GetServiceSafePoint("br-backup")
+	require.True(t, ok)
+	require.Equal(t, uint64(2999), sp)
+
+	// releasing a lease doesn't affect the others.
+	require.NoError(t, backup.Release(ctx, false))
+	require.NoError(t, backup.Release(ctx, false))
+	_, ok = pdClient.GetServiceSafePoint("br-backup")
+	require.False(t, ok)
+	_, ok = pdClient.GetServiceSafePoint("br-restore")
+	require.True(t, ok)
+
+	// the kept safe point expires by TTL.
+	require.NoError(t, restore.Release(ctx, true))
+	_, ok = pdClient.GetServiceSafePoint("br-restore")
+	require.True(t, ok)
+	require.Empty(t, m.Leases())
+
+	// the released ID can be registered again.
+	backup, err = m.Register(ctx, pdClient, nil, "backup retry", backupSP)
+	require.NoError(t, err)
+	require.NoError(t, backup.Release(ctx, false))
+}
+
+func TestSafePointManagerAcrossProcesses(t *testing.T) {
+	integration.BeforeTestExternal(t)
+	testEtcdCluster := integration.NewClusterV3(t, &integration.ClusterConfig{Size: 1})
+	defer testEtcdCluster.Terminate(t)
+	etcdCli := testEtcdCluster.RandClient()
+
+	ctx := context.Background()
+	pdClient := &mockSafePoint{safepoint: 2333, services: make(map[string]uint64)}
+	// the managers of two processes sharing the cluster.
+	m1, m2 := utils.NewSafePointManager(), utils.NewSafePointManager()
+
+	sp := utils.BRServiceSafePoint{ID: "br-backup", TTL: 10, BackupTS: 3000}
+	backup, err := m1.Register(ctx, pdClient, etcdCli, "backup", sp)
+	require.NoError(t, err)
+	_, err = m2.Register(ctx, pdClient, etcdCli, "backup", sp)
+	require.ErrorContains(t, err, "service safe point br-backup is owned by task backup (")
+	require.Empty(t, m2.Leases())
+
+	// the ownership is released with the kept safe point, so the retry can take it.
+	require.NoError(t, backup.Release(ctx, true))
+	backup, err = m2.Register(ctx, pdClient, etcdCli, "backup retry", sp)
+	require.NoError(t, err)
+	require.NoError(t, backup.Release(ctx, false))
+	_, ok := pdClient.GetServiceSafePoint("br-backup")
+	require.False(t, ok)
+}
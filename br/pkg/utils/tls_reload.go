@@ -0,0 +1,168 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package utils
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/log"
+	"go.uber.org/zap"
+)
+
+// tlsFileReloader reloads the CA and the client certificate when the files change, so the long-running
+// tasks, e.g. the log backup advancer and the following log restore, keep working after the certificates
+// are rotated. The files are checked on each handshake, and the connections established before aren't
+// affected.
+type tlsFileReloader struct {
+	caPath   string
+	certPath string
+	keyPath  string
+
+	mu          sync.Mutex
+	caModTime   time.Time
+	pool        *x509.CertPool
+	certModTime time.Time
+	keyModTime  time.Time
+	cert        *tls.Certificate
+}
+
+func fileModTime(path string) (time.Time, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}, errors.Trace(err)
+	}
+	return info.ModTime(), nil
+}
+
+// certPool returns the pool of the CA, reloaded if the file changes.
+func (r *tlsFileReloader) certPool() (*x509.CertPool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	modTime, err := fileModTime(r.caPath)
+	if err != nil {
+		return nil, errors.Annotate(err, "could not read ca certificate")
+	}
+	if r.pool != nil && modTime.Equal(r.caModTime) {
+		return r.pool, nil
+	}
+	content, err := os.ReadFile(r.caPath)
+	if err != nil {
+		return nil, errors.Annotate(err, "could not read ca certificate")
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(content) {
+		return nil, errors.Errorf("failed to append the ca certificates of %s", r.caPath)
+	}
+	if r.pool != nil {
+		log.Info("reloaded the ca certificate", zap.String("path", r.caPath))
+	}
+	r.pool, r.caModTime = pool, modTime
+	return pool, nil
+}
+
+// certificate returns the client certificate, reloaded if the certificate or the key file changes.
+func (r *tlsFileReloader) certificate() (*tls.Certificate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	certModTime, err := fileModTime(r.certPath)
+	if err != nil {
+		return nil, errors.Annotate(err, "could not load client key pair")
+	}
+	keyModTime, err := fileModTime(r.keyPath)
+	if err != nil {
+		return nil, errors.Annotate(err, "could not load client key pair")
+	}
+	if r.cert != nil && certModTime.Equal(r.certModTime) && keyModTime.Equal(r.keyModTime) {
+		return r.cert, nil
+	}
+	cert, err := tls.LoadX509KeyPair(r.certPath, r.keyPath)
+	if err != nil {
+		// the certificate and the key may be replaced one by one, keep using the old pair in the meantime.
+		if r.cert != nil {
+			log.Warn("failed to reload the client key pair, use the old one", zap.Error(err))
+			return r.cert, nil
+		}
+		return nil, errors.Annotate(err, "could not load client key pair")
+	}
+	if r.cert != nil {
+		log.Info("reloaded the client certificate", zap.String("path", r.certPath))
+	}
+	r.cert, r.certModTime, r.keyModTime = &cert, certModTime, keyModTime
+	return r.cert, nil
+}
+
+// verifyConnection verifies the certificates of the server by the current CA, like the default
+// verification of the client.
+func (r *tlsFileReloader) verifyConnection(cs tls.ConnectionState) error {
+	if len(cs.PeerCertificates) == 0 {
+		return errors.New("no certificates available to verify")
+	}
+	pool, err := r.certPool()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	intermediates := x509.NewCertPool()
+	for _, cert := range cs.PeerCertificates[1:] {
+		intermediates.AddCert(cert)
+	}
+	_, err = cs.PeerCertificates[0].Verify(x509.VerifyOptions{
+		Roots:         pool,
+		Intermediates: intermediates,
+		DNSName:       cs.ServerName,
+	})
+	return errors.Annotate(err, "can't verify certificate, maybe different CA is used")
+}
+
+// NewReloadingTLSConfig creates the client TLS config reloading the CA and the client certificate when
+// the files change. The cert and key are optional.
+func NewReloadingTLSConfig(caPath, certPath, keyPath string) (*tls.Config, error) {
+	r := &tlsFileReloader{caPath: caPath, certPath: certPath, keyPath: keyPath}
+	// check the files ahead, so the misconfiguration is reported before connecting.
+	if _, err := r.certPool(); err != nil {
+		return nil, errors.Trace(err)
+	}
+	/* #nosec G402 */
+	tlsCfg := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		// the server is verified by verifyConnection with the reloaded CA instead.
+		InsecureSkipVerify: true,
+		VerifyConnection:   r.verifyConnection,
+	}
+	if certPath != "" || keyPath != "" {
+		if _, err := r.certificate(); err != nil {
+			return nil, errors.Trace(err)
+		}
+		tlsCfg.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			return r.certificate()
+		}
+	}
+	return tlsCfg, nil
+}
+
+// TLSDialer returns the gRPC dialer securing the connections by the TLS config, for the gRPC clients
+// building their TLS config only once, e.g. the PD client. The gRPC client should use the insecure
+// transport credentials then, since the connections are already secured.
+func TLSDialer(tlsCfg *tls.Config) func(ctx context.Context, addr string) (net.Conn, error) {
+	return func(ctx context.Context, addr string) (net.Conn, error) {
+		cfg := tlsCfg.Clone()
+		if cfg.ServerName == "" {
+			if host, _, err := net.SplitHostPort(addr); err == nil {
+				cfg.ServerName = host
+			}
+		}
+		// gRPC requires HTTP/2 to be negotiated by ALPN.
+		if !slices.Contains(cfg.NextProtos, "h2") {
+			cfg.NextProtos = append(cfg.NextProtos, "h2")
+		}
+		conn, err := (&tls.Dialer{Config: cfg}).DialContext(ctx, "tcp", addr)
+		return conn, errors.Trace(err)
+	}
+}
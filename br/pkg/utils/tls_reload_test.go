@@ -0,0 +1,232 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package utils
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newTestCA(t *testing.T) *testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return &testCA{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// issue returns the PEM of the certificate and the key signed by the CA.
+func (ca *testCA) issue(t *testing.T, cn string) (certPEM, keyPEM []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: cn},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth, x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+// writeTLSFile writes the file and moves the mtime forward, so the change is detected even if the
+// mtime granularity of the file system is coarse.
+func writeTLSFile(t *testing.T, path string, content []byte, modTime time.Time) {
+	require.NoError(t, os.WriteFile(path, content, 0o600))
+	require.NoError(t, os.Chtimes(path, modTime, modTime))
+}
+
+func TestReloadingTLSConfig(t *testing.T) {
+	oldCA, newCA := newTestCA(t), newTestCA(t)
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(oldCA.cert)
+	clientCAs.AddCert(newCA.cert)
+
+	var serverCert atomic.Pointer[tls.Certificate]
+	setServerCert := func(ca *testCA) {
+		certPEM, keyPEM := ca.issue(t, "server")
+		cert, err := tls.X509KeyPair(certPEM, keyPEM)
+		require.NoError(t, err)
+		serverCert.Store(&cert)
+	}
+	setServerCert(oldCA)
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, r.TLS.PeerCertificates[0].Subject.CommonName)
+	}))
+	server.TLS = &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		// the certificate of httptest is used without the SNI, so GetCertificate doesn't work.
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			return &tls.Config{
+				MinVersion:   tls.VersionTLS12,
+				ClientAuth:   tls.RequireAndVerifyClientCert,
+				ClientCAs:    clientCAs,
+				Certificates: []tls.Certificate{*serverCert.Load()},
+			}, nil
+		},
+	}
+	server.StartTLS()
+	defer server.Close()
+
+	dir := t.TempDir()
+	caPath := filepath.Join(dir, "ca.pem")
+	certPath := filepath.Join(dir, "client.pem")
+	keyPath := filepath.Join(dir, "client.key")
+	modTime := time.Now().Add(-time.Minute)
+	writeTLSFile(t, caPath, oldCA.pem, modTime)
+	certPEM, keyPEM := oldCA.issue(t, "client-1")
+	writeTLSFile(t, certPath, certPEM, modTime)
+	writeTLSFile(t, keyPath, keyPEM, modTime)
+
+	tlsCfg, err := NewReloadingTLSConfig(caPath, certPath, keyPath)
+	require.NoError(t, err)
+	// every request does a new handshake.
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: tlsCfg, DisableKeepAlives: true}}
+	get := func() (string, error) {
+		resp, err := client.Get(server.URL)
+		if err != nil {
+			return "", err
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		return string(body), err
+	}
+	cn, err := get()
+	require.NoError(t, err)
+	require.Equal(t, "client-1", cn)
+
+	// the client certificate is rotated.
+	modTime = modTime.Add(time.Second)
+	certPEM, keyPEM = oldCA.issue(t, "client-2")
+	writeTLSFile(t, certPath, certPEM, modTime)
+	writeTLSFile(t, keyPath, keyPEM, modTime)
+	cn, err = get()
+	require.NoError(t, err)
+	require.Equal(t, "client-2", cn)
+
+	// the key is replaced before the certificate, the old pair is used in the meantime.
+	modTime = modTime.Add(time.Second)
+	certPEM, keyPEM = oldCA.issue(t, "client-3")
+	writeTLSFile(t, keyPath, keyPEM, modTime)
+	cn, err = get()
+	require.NoError(t, err)
+	require.Equal(t, "client-2", cn)
+	writeTLSFile(t, certPath, certPEM, modTime)
+	cn, err = get()
+	require.NoError(t, err)
+	require.Equal(t, "client-3", cn)
+
+	// the server is issued by the new CA, which isn't trusted until the CA file is rotated.
+	setServerCert(newCA)
+	_, err = get()
+	require.ErrorContains(t, err, "maybe different CA is used")
+	modTime = modTime.Add(time.Second)
+	writeTLSFile(t, caPath, newCA.pem, modTime)
+	cn, err = get()
+	require.NoError(t, err)
+	require.Equal(t, "client-3", cn)
+}
+
+func TestReloadingTLSConfigInvalidFiles(t *testing.T) {
+	dir := t.TempDir()
+	_, err := NewReloadingTLSConfig(filepath.Join(dir, "ca.pem"), "", "")
+	require.ErrorContains(t, err, "could not read ca certificate")
+
+	caPath := filepath.Join(dir, "ca.pem")
+	require.NoError(t, os.WriteFile(caPath, []byte("not a certificate"), 0o600))
+	_, err = NewReloadingTLSConfig(caPath, "", "")
+	require.ErrorContains(t, err, "failed to append the ca certificates")
+
+	ca := newTestCA(t)
+	require.NoError(t, os.WriteFile(caPath, ca.pem, 0o600))
+	tlsCfg, err := NewReloadingTLSConfig(caPath, "", "")
+	require.NoError(t, err)
+	require.Nil(t, tlsCfg.GetClientCertificate)
+	_, err = NewReloadingTLSConfig(caPath, filepath.Join(dir, "client.pem"), filepath.Join(dir, "client.key"))
+	require.ErrorContains(t, err, "could not load client key pair")
+}
+
+func TestTLSDialer(t *testing.T) {
+	ca := newTestCA(t)
+	certPEM, keyPEM := ca.issue(t, "server")
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	require.NoError(t, err)
+	lis, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{cert},
+		NextProtos:   []string{"h2"},
+	})
+	require.NoError(t, err)
+	defer lis.Close()
+	go func() {
+		for {
+			conn, err := lis.Accept()
+			if err != nil {
+				return
+			}
+			_ = conn.(*tls.Conn).Handshake()
+			_ = conn.Close()
+		}
+	}()
+
+	caPath := filepath.Join(t.TempDir(), "ca.pem")
+	writeTLSFile(t, caPath, ca.pem, time.Now())
+	tlsCfg, err := NewReloadingTLSConfig(caPath, "", "")
+	require.NoError(t, err)
+	conn, err := TLSDialer(tlsCfg)(context.Background(), lis.Addr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+	state := conn.(*tls.Conn).ConnectionState()
+	require.Equal(t, "h2", state.NegotiatedProtocol)
+	// the config is cloned for each connection.
+	require.Empty(t, tlsCfg.NextProtos)
+
+	// the server issued by another CA is refused.
+	tlsCfg, err = NewReloadingTLSConfig(caPath, "", "")
+	require.NoError(t, err)
+	writeTLSFile(t, caPath, newTestCA(t).pem, time.Now().Add(time.Second))
+	_, err = TLSDialer(tlsCfg)(context.Background(), lis.Addr().String())
+	require.ErrorContains(t, err, "maybe different CA is used")
+}
@@ -0,0 +1,43 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package utils
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/tidb/pkg/parser/mysql"
+	"github.com/pingcap/tidb/pkg/sessionctx/stmtctx"
+	"github.com/pingcap/tidb/pkg/types"
+	"github.com/tikv/client-go/v2/oracle"
+)
+
+// ParseTSString parses the ts given as a TSO or a datetime, port from tidb setSnapshotTS. The datetime must
+// have the timezone if tzCheck is set.
+func ParseTSString(ts string, tzCheck bool) (uint64, error) {
+	if len(ts) == 0 {
+		return 0, nil
+	}
+	if tso, err := strconv.ParseUint(ts, 10, 64); err == nil {
+		return tso, nil
+	}
+
+	loc := time.Local
+	sc := stmtctx.NewStmtCtxWithTimeZone(loc)
+	if tzCheck {
+		tzIdx, _, _, _, _ := types.GetTimezone(ts)
+		if tzIdx < 0 {
+			return 0, errors.Errorf("must set timezone when using datetime format ts, e.g. '2018-05-11 01:42:23+0800'")
+		}
+	}
+	t, err := types.ParseTime(sc.TypeCtx(), ts, mysql.TypeTimestamp, types.MaxFsp)
+	if err != nil {
+		return 0, errors.Trace(err)
+	}
+	t1, err := t.GoTime(loc)
+	if err != nil {
+		return 0, errors.Trace(err)
+	}
+	return oracle.GoTimeToTS(t1), nil
+}
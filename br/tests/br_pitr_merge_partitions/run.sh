@@ -0,0 +1,88 @@
+#!/bin/bash
+#
+# Copyright 2026 PingCAP, Inc.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+
+set -eu
+. run_services
+CUR=$(cd `dirname $0`; pwd)
+
+# const value
+PREFIX="pitr_merge_backup" # NOTICE: don't start with 'br' because `restart services` would remove file/directory br*.
+res_file="$TEST_DIR/sql_res.$TEST_NAME.txt"
+TASK_NAME="br_pitr_merge_partitions"
+DB="$TEST_NAME"
+
+restart_services
+
+# the partitioned tables restored from the full backup
+run_sql "create database $DB;"
+run_sql "create table $DB.t(id int primary key, v int) partition by range (id) (partition p0 values less than (100), partition p1 values less than (200), partition p2 values less than (maxvalue));"
+run_sql "insert into $DB.t values (1, 1), (101, 101), (201, 201);"
+run_sql "create table $DB.t2(id int primary key, v int) partition by hash (id) partitions 4;"
+run_sql "insert into $DB.t2 values (1, 1), (2, 2), (3, 3), (4, 4);"
+
+echo "start log task"
+run_br --pd $PD_ADDR log start --task-name $TASK_NAME -s "local://$TEST_DIR/$PREFIX/log"
+
+echo "run snapshot backup"
+run_br --pd $PD_ADDR backup full -s "local://$TEST_DIR/$PREFIX/full"
+
+# the rows written in the log backup, including the ones of the partitions added and reorganized later
+run_sql "insert into $DB.t values (2, 2), (102, 102), (202, 202);"
+run_sql "update $DB.t set v = v + 1000 where id = 1;"
+run_sql "alter table $DB.t reorganize partition p2 into (partition p2 values less than (300), partition p3 values less than (maxvalue));"
+run_sql "insert into $DB.t values (301, 301);"
+run_sql "insert into $DB.t2 values (5, 5);"
+run_sql "delete from $DB.t2 where id = 1;"
+
+. "$CUR/../br_test_utils.sh" && wait_log_checkpoint_advance $TASK_NAME
+
+# the partitions truncated in the log backup can't be removed from the merged table
+before_truncate_ts=$(python3 -c "import time; print(int(time.time() * 1000) << 18)")
+run_sql "alter table $DB.t2 truncate partition p0;"
+wait_log_checkpoint_advance $TASK_NAME
+
+restart_services
+
+echo "run pitr with the partitions merged"
+run_br --pd $PD_ADDR restore point -s "local://$TEST_DIR/$PREFIX/log" --full-backup-storage "local://$TEST_DIR/$PREFIX/full" \
+    --restored-ts $before_truncate_ts --merge-partitions "$DB.*" > $res_file 2>&1 || ( cat $res_file && exit 1 )
+
+for table in t t2; do
+    run_sql "select count(*) cnt from information_schema.partitions where table_schema = '$DB' and table_name = '$table' and partition_name is not null;"
+    check_contains "cnt: 0"
+done
+run_sql "select count(*) cnt, sum(v) s from $DB.t;"
+check_contains "cnt: 7"
+check_contains "s: 1910"
+run_sql "select count(*) cnt, sum(v) s from $DB.t2;"
+check_contains "cnt: 4"
+check_contains "s: 14"
+run_sql "admin check table $DB.t;"
+run_sql "admin check table $DB.t2;"
+
+restart_services
+
+echo "run pitr over the truncated partition"
+restore_fail=0
+run_br --pd $PD_ADDR restore point -s "local://$TEST_DIR/$PREFIX/log" --full-backup-storage "local://$TEST_DIR/$PREFIX/full" \
+    --merge-partitions "$DB.t2" > $res_file 2>&1 || restore_fail=1
+if [ $restore_fail -ne 1 ]; then
+    echo 'pitr success over the truncated partition of the merged table'
+    exit 1
+fi
+check_contains "are changed by the ddl job"
+
+run_br --pd $PD_ADDR log stop --task-name $TASK_NAME
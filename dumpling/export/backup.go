@@ -0,0 +1,414 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package export
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/pingcap/errors"
+	brexport "github.com/pingcap/tidb/br/pkg/export"
+	"github.com/pingcap/tidb/br/pkg/storage"
+	"github.com/pingcap/tidb/br/pkg/summary"
+	"github.com/pingcap/tidb/br/pkg/utils"
+	"github.com/pingcap/tidb/br/pkg/version"
+	tcontext "github.com/pingcap/tidb/dumpling/context"
+	"github.com/pingcap/tidb/pkg/executor/showcreate"
+	"github.com/pingcap/tidb/pkg/meta/autoid"
+	"github.com/pingcap/tidb/pkg/meta/model"
+	"github.com/pingcap/tidb/pkg/parser/ast"
+	"github.com/pingcap/tidb/pkg/types"
+	"github.com/pingcap/tidb/pkg/util/mock"
+	"go.uber.org/zap"
+	"golang.org/x/sync/errgroup"
+)
+
+// backupRowChannelSize is the number of the rows read ahead from the backup.
+const backupRowChannelSize = 1024
+
+// openBackupSource resolves the tables to dump as of the snapshot from the backups instead of a cluster.
+func openBackupSource(d *Dumper) error {
+	tctx, conf := d.tctx, d.conf
+	if conf.SQL != "" || conf.Where != "" {
+		return errors.Errorf("--%s and --%s are not supported with --%s", flagSQL, flagWhere, flagBackupStorage)
+	}
+	asOfTS, err := utils.ParseTSString(conf.Snapshot, false)
+	if err != nil {
+		return errors.Annotatef(err, "failed to parse --%s %q", flagSnapshot, conf.Snapshot)
+	}
+	if asOfTS > 0 && conf.LogBackupStorage == "" {
+		return errors.Errorf("--%s requires the log backup given by --%s with --%s",
+			flagSnapshot, flagLogBackupStorage, flagBackupStorage)
+	}
+	cfg := &brexport.SourceConfig{
+		Cipher:          conf.BackupCipher,
+		LogCipher:       conf.LogBackupCipher,
+		MasterKeyConfig: conf.LogBackupMasterKeyConfig,
+		AsOfTS:          asOfTS,
+		TableFilter:     conf.TableFilter,
+	}
+	if cfg.SnapshotStorage, err = openBackupStorage(tctx, conf.BackupStorage, &conf.BackendOptions); err != nil {
+		return errors.Trace(err)
+	}
+	if conf.LogBackupStorage != "" {
+		if cfg.LogStorage, err = openBackupStorage(tctx, conf.LogBackupStorage, &conf.BackendOptions); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	source, err := brexport.OpenSource(tctx, cfg)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if len(conf.Databases) > 0 {
+		source.Tables = slices.DeleteFunc(source.Tables, func(table *brexport.Table) bool {
+			return !slices.Contains(conf.Databases, table.DB)
+		})
+	}
+	d.backupSource = source
+	conf.ServerInfo = version.ServerInfo{ServerType: version.ServerTypeTiDB}
+	conf.Snapshot = strconv.FormatUint(source.AsOfTS, 10)
+	tctx.L().Info("dump from the backups",
+		zap.Uint64("snapshot-backup-ts", source.SnapshotTS),
+		zap.Uint64("as-of-ts", source.AsOfTS),
+		zap.Int("tables", len(source.Tables)))
+	return nil
+}
+
+func openBackupStorage(ctx context.Context, url string, opts *storage.BackendOptions) (storage.ExternalStorage, error) {
+	b, err := storage.ParseBackend(url, opts)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	s, err := storage.New(ctx, b, &storage.ExternalStorageOptions{})
+	return s, errors.Trace(err)
+}
+
+// dumpBackup dumps the tables reconstructed from the backups, the rows are written by the writers the
+// same as the ones read from a cluster.
+func (d *Dumper) dumpBackup() (dumpErr error) {
+	tctx, conf, source := d.tctx, d.conf, d.backupSource
+	tctx.L().Info("begin to run Dump from the backups", zap.Stringer("conf", conf))
+	m := newGlobalMetadata(tctx, d.extStore, conf.Snapshot)
+	defer func() {
+		if dumpErr == nil {
+			_ = m.writeGlobalMetaData()
+		}
+	}()
+	m.recordStartTime(time.Now())
+	m.recordBackupMetaData(source.AsOfTS)
+	atomic.StoreInt64(&d.totalTables, int64(len(source.Tables)))
+
+	summary.SetLogCollector(summary.NewLogCollector(tctx.L().Info))
+	summary.SetUnit(summary.BackupUnit)
+	defer summary.Summary(summary.BackupUnit)
+
+	taskIn, taskOut := infiniteChan[Task]()
+	wg, writingCtx := errgroup.WithContext(tctx)
+	writerCtx := tctx.WithContext(writingCtx)
+	writers := make([]*Writer, conf.Threads)
+	for i := range writers {
+		writer := NewWriter(writerCtx, int64(i), conf, nil, d.extStore, d.metrics)
+		// the rows are read from the backups again when the table is retried.
+		writer.rebuildConnFn = func(conn *sql.Conn, _ bool) (*sql.Conn, error) {
+			return conn, nil
+		}
+		writer.setFinishTableCallBack(func(task Task) {
+			if _, ok := task.(*TaskTableData); ok {
+				IncCounter(d.metrics.finishedTablesCounter)
+			}
+		})
+		writer.setFinishTaskCallBack(func(task Task) {
+			if _, ok := task.(*TaskTableData); ok {
+				d.metrics.completedChunks.Add(1)
+			}
+		})
+		wg.Go(func() error {
+			return writer.run(taskOut)
+		})
+		writers[i] = writer
+	}
+
+	logProgressCtx, logProgressCancel := tctx.WithCancel()
+	go d.runLogProgress(logProgressCtx)
+	defer logProgressCancel()
+
+	tableDataStartTime := time.Now()
+	err := d.dumpBackupTables(writerCtx, taskIn)
+	d.metrics.progressReady.Store(true)
+	close(taskIn)
+	if err := wg.Wait(); err != nil {
+		summary.CollectFailureUnit("dump table data", err)
+		return errors.Trace(err)
+	}
+	if err != nil {
+		return errors.Trace(err)
+	}
+	summary.CollectSuccessUnit("dump cost", countTotalTask(writers), time.Since(tableDataStartTime))
+
+	summary.SetSuccessStatus(true)
+	m.recordFinishTime(time.Now())
+	return nil
+}
+
+func (d *Dumper) dumpBackupTables(tctx *tcontext.Context, taskChan chan<- Task) error {
+	conf, source := d.conf, d.backupSource
+	exporter := source.NewExporter(nil, "")
+	sctx := mock.NewContext()
+	specCmts := getSpecialComments(conf.ServerInfo.ServerType)
+	dumpedDBs := make(map[string]struct{})
+	for _, table := range source.Tables {
+		if _, ok := dumpedDBs[table.DB]; !ok && !conf.NoSchemas {
+			dumpedDBs[table.DB] = struct{}{}
+			dbInfo, ok := source.DBInfos[table.DB]
+			if !ok {
+				dbInfo = &model.DBInfo{Name: ast.NewCIStr(table.DB)}
+			}
+			buf := new(bytes.Buffer)
+			if err := showcreate.Database(sctx, dbInfo, false, buf); err != nil {
+				return errors.Annotatef(err, "failed to show create database %s", table.DB)
+			}
+			if d.sendTaskToChan(tctx, NewTaskDatabaseMeta(table.DB, buf.String()), taskChan) {
+				return tctx.Err()
+			}
+		}
+
+		meta, err := newBackupTableMeta(sctx, table, specCmts, conf.CompleteInsert)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if !conf.NoSchemas {
+			if d.sendTaskToChan(tctx, NewTaskTableMeta(table.DB, table.Info.Name.O, meta.ShowCreateTable()), taskChan) {
+				return tctx.Err()
+			}
+		}
+		if conf.NoData {
+			continue
+		}
+		data := &backupTableData{
+			name:       table.Info.Name.O,
+			colOffsets: meta.colOffsets,
+			read: func(ctx context.Context, fn func(int64, []types.Datum) error) (uint64, error) {
+				return exporter.ReadTable(ctx, table, fn)
+			},
+		}
+		if d.sendTaskToChan(tctx, NewTaskTableData(meta, data, 0, 1), taskChan) {
+			return tctx.Err()
+		}
+	}
+	return nil
+}
+
+// backupTableMeta is the meta of a table reconstructed from the backups.
+type backupTableMeta struct {
+	database        string
+	table           string
+	colTypes        []string
+	colNames        []string
+	selectedField   string
+	specCmts        []string
+	showCreateTable string
+	// colOffsets are the offsets of the dumped columns in the exported columns of the table.
+	colOffsets []int
+}
+
+// newBackupTableMeta creates the meta of the table, the generated columns aren't dumped the same as the
+// tables read from a cluster.
+func newBackupTableMeta(
+	sctx *mock.Context,
+	table *brexport.Table,
+	specCmts []string,
+	completeInsert bool,
+) (*backupTableMeta, error) {
+	meta := &backupTableMeta{
+		database: table.DB,
+		table:    table.Info.Name.O,
+		specCmts: specCmts,
+	}
+	// the virtual generated columns aren't in the exported columns.
+	hasGeneratedColumn := slices.ContainsFunc(table.Info.Cols(), (*model.ColumnInfo).IsGenerated)
+	fields := make([]string, 0, len(table.Info.Columns))
+	for i, col := range brexport.ExportedColumns(table.Info) {
+		if col.IsGenerated() {
+			continue
+		}
+		meta.colOffsets = append(meta.colOffsets, i)
+		meta.colTypes = append(meta.colTypes, strings.ToUpper(types.TypeToStr(col.GetType(), col.GetCharset())))
+		meta.colNames = append(meta.colNames, col.Name.O)
+		fields = append(fields, wrapBackTicks(escapeString(col.Name.O)))
+	}
+	meta.selectedField = "*"
+	if completeInsert || hasGeneratedColumn {
+		meta.selectedField = strings.Join(fields, ",")
+	}
+	buf := new(bytes.Buffer)
+	if err := showcreate.Table(sctx, nil, table.Info, autoid.Allocators{}, buf); err != nil {
+		return nil, errors.Annotatef(err, "failed to show create table %s.%s", table.DB, table.Info.Name.O)
+	}
+	meta.showCreateTable = buf.String()
+	return meta, nil
+}
+
+func (tm *backupTableMeta) DatabaseName() string {
+	return tm.database
+}
+
+func (tm *backupTableMeta) TableName() string {
+	return tm.table
+}
+
+func (tm *backupTableMeta) ColumnCount() uint {
+	return uint(len(tm.colTypes))
+}
+
+func (tm *backupTableMeta) ColumnTypes() []string {
+	return tm.colTypes
+}
+
+func (tm *backupTableMeta) ColumnNames() []string {
+	return tm.colNames
+}
+
+func (tm *backupTableMeta) SelectedField() string {
+	return tm.selectedField
+}
+
+func (tm *backupTableMeta) SelectedLen() int {
+	return len(tm.colTypes)
+}
+
+func (tm *backupTableMeta) SpecialComments() StringIter {
+	return newStringIter(tm.specCmts...)
+}
+
+func (tm *backupTableMeta) ShowCreateTable() string {
+	return tm.showCreateTable
+}
+
+func (*backupTableMeta) ShowCreateView() string {
+	return ""
+}
+
+func (*backupTableMeta) AvgRowLength() uint64 {
+	return 0
+}
+
+func (*backupTableMeta) HasImplicitRowID() bool {
+	return false
+}
+
+// backupTableData implements TableDataIR, the rows are read from the backups in the background.
+type backupTableData struct {
+	name       string
+	colOffsets []int
+	// read calls fn with the rows of the table, it's the ReadTable of the exporter.
+	read func(ctx context.Context, fn func(physicalID int64, row []types.Datum) error) (uint64, error)
+
+	cancel context.CancelFunc
+	iter   *backupRowIter
+}
+
+func (td *backupTableData) Start(tctx *tcontext.Context, _ *sql.Conn) error {
+	ctx, cancel := context.WithCancel(tctx)
+	rows := make(chan []sql.RawBytes, backupRowChannelSize)
+	iter := &backupRowIter{rows: rows, args: make([]any, len(td.colOffsets))}
+	go func() {
+		_, err := td.read(ctx, func(_ int64, row []types.Datum) error {
+			values := make([]sql.RawBytes, len(td.colOffsets))
+			for i, offset := range td.colOffsets {
+				value, err := backupValue(&row[offset])
+				if err != nil {
+					return errors.Annotatef(err, "failed to format the row of %s", td.name)
+				}
+				values[i] = value
+			}
+			select {
+			case rows <- values:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+		// the error is read after the channel is closed.
+		iter.err = err
+		close(rows)
+	}()
+	iter.Next()
+	td.cancel, td.iter = cancel, iter
+	return nil
+}
+
+func (td *backupTableData) Rows() SQLRowIter {
+	return td.iter
+}
+
+func (*backupTableData) RawRows() *sql.Rows {
+	return nil
+}
+
+func (td *backupTableData) Close() error {
+	if td.cancel != nil {
+		td.cancel()
+		// wait for the reading goroutine to exit.
+		for range td.iter.rows {
+		}
+	}
+	return nil
+}
+
+// backupValue formats the datum as the text returned by MySQL protocol, nil is NULL.
+func backupValue(d *types.Datum) (sql.RawBytes, error) {
+	switch d.Kind() {
+	case types.KindNull:
+		return nil, nil
+	case types.KindBytes, types.KindMysqlBit, types.KindBinaryLiteral:
+		return append(sql.RawBytes{}, d.GetBytes()...), nil
+	}
+	s, err := d.ToString()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return sql.RawBytes(s), nil
+}
+
+// backupRowIter implements SQLRowIter over the rows read from the backups.
+type backupRowIter struct {
+	rows    <-chan []sql.RawBytes
+	row     []sql.RawBytes
+	hasNext bool
+	args    []any
+	// err is the error of reading the rows, it's set before rows is closed.
+	err error
+}
+
+func (iter *backupRowIter) Decode(row RowReceiver) error {
+	row.BindAddress(iter.args)
+	for i, arg := range iter.args {
+		*(arg.(*sql.RawBytes)) = iter.row[i]
+	}
+	return nil
+}
+
+func (iter *backupRowIter) Next() {
+	iter.row, iter.hasNext = <-iter.rows
+}
+
+func (iter *backupRowIter) Error() error {
+	if iter.hasNext {
+		return nil
+	}
+	return errors.Trace(iter.err)
+}
+
+func (iter *backupRowIter) HasNext() bool {
+	return iter.hasNext
+}
+
+func (*backupRowIter) Close() error {
+	return nil
+}
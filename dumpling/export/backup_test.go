@@ -0,0 +1,126 @@
+// Copyright 2026 PingCAP, Inc. Licensed under Apache-2.0.
+
+package export
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pingcap/errors"
+	brexport "github.com/pingcap/tidb/br/pkg/export"
+	"github.com/pingcap/tidb/br/pkg/storage"
+	"github.com/pingcap/tidb/br/pkg/version"
+	tcontext "github.com/pingcap/tidb/dumpling/context"
+	"github.com/pingcap/tidb/pkg/meta/model"
+	"github.com/pingcap/tidb/pkg/parser/ast"
+	"github.com/pingcap/tidb/pkg/parser/charset"
+	"github.com/pingcap/tidb/pkg/parser/mysql"
+	"github.com/pingcap/tidb/pkg/types"
+	"github.com/pingcap/tidb/pkg/util/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// backupTestTable returns `t(id bigint primary key, name varchar(255), data varbinary(255),
+// double_id bigint as (id * 2) virtual)`.
+func backupTestTable() *brexport.Table {
+	newCol := func(id int64, name string, tp byte, cs string) *model.ColumnInfo {
+		col := &model.ColumnInfo{ID: id, Name: ast.NewCIStr(name), Offset: int(id - 1), State: model.StatePublic}
+		col.FieldType = *types.NewFieldType(tp)
+		col.SetCharset(cs)
+		col.SetFlen(255)
+		return col
+	}
+	id := newCol(1, "id", mysql.TypeLonglong, charset.CharsetBin)
+	id.SetFlen(20)
+	id.AddFlag(mysql.PriKeyFlag | mysql.NotNullFlag)
+	name := newCol(2, "name", mysql.TypeVarchar, charset.CharsetUTF8MB4)
+	name.SetCollate(charset.CollationUTF8MB4)
+	data := newCol(3, "data", mysql.TypeVarchar, charset.CharsetBin)
+	data.SetCollate(charset.CollationBin)
+	doubleID := newCol(4, "double_id", mysql.TypeLonglong, charset.CharsetBin)
+	doubleID.SetFlen(20)
+	doubleID.GeneratedExprString = "`id` * 2"
+	return &brexport.Table{
+		DB: "test",
+		Info: &model.TableInfo{
+			ID:         100,
+			Name:       ast.NewCIStr("t"),
+			Columns:    []*model.ColumnInfo{id, name, data, doubleID},
+			PKIsHandle: true,
+			Charset:    charset.CharsetUTF8MB4,
+			Collate:    charset.CollationUTF8MB4,
+			State:      model.StatePublic,
+		},
+	}
+}
+
+func TestBackupTableMeta(t *testing.T) {
+	specCmts := getSpecialComments(version.ServerTypeTiDB)
+	meta, err := newBackupTableMeta(mock.NewContext(), backupTestTable(), specCmts, false)
+	require.NoError(t, err)
+	require.Equal(t, "test", meta.DatabaseName())
+	require.Equal(t, "t", meta.TableName())
+	require.Equal(t, []string{"BIGINT", "VARCHAR", "VARBINARY"}, meta.ColumnTypes())
+	require.Equal(t, []string{"id", "name", "data"}, meta.ColumnNames())
+	require.Equal(t, []int{0, 1, 2}, meta.colOffsets)
+	// the generated columns are listed explicitly.
+	require.Equal(t, "`id`,`name`,`data`", meta.SelectedField())
+	require.Contains(t, meta.ShowCreateTable(), "CREATE TABLE `t` (")
+	require.Contains(t, meta.ShowCreateTable(), "`double_id` bigint(20) GENERATED ALWAYS AS (`id` * 2) VIRTUAL")
+
+	table := backupTestTable()
+	table.Info.Columns = table.Info.Columns[:3]
+	meta, err = newBackupTableMeta(mock.NewContext(), table, specCmts, false)
+	require.NoError(t, err)
+	require.Equal(t, "*", meta.SelectedField())
+	meta, err = newBackupTableMeta(mock.NewContext(), table, specCmts, true)
+	require.NoError(t, err)
+	require.Equal(t, "`id`,`name`,`data`", meta.SelectedField())
+}
+
+func TestBackupTableData(t *testing.T) {
+	meta, err := newBackupTableMeta(mock.NewContext(), backupTestTable(), nil, false)
+	require.NoError(t, err)
+	rows := [][]types.Datum{
+		{types.NewIntDatum(1), types.NewStringDatum("a'b"), types.NewBytesDatum([]byte{0, 1})},
+		{types.NewIntDatum(2), types.NewDatum(nil), types.NewBytesDatum([]byte{})},
+	}
+	var readErr error
+	data := &backupTableData{
+		name:       "t",
+		colOffsets: meta.colOffsets,
+		read: func(_ context.Context, fn func(int64, []types.Datum) error) (uint64, error) {
+			for _, row := range rows {
+				if err := fn(100, row); err != nil {
+					return 0, err
+				}
+			}
+			return uint64(len(rows)), readErr
+		},
+	}
+
+	conf := configForWriteSQL(createMockConfig(), UnspecifiedSize, UnspecifiedSize)
+	m := newMetrics(conf.PromFactory, conf.Labels)
+	require.NoError(t, data.Start(tcontext.Background(), nil))
+	bf := storage.NewBufferWriter()
+	n, err := WriteInsert(tcontext.Background(), conf, meta, data, bf, m)
+	require.NoError(t, data.Close())
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), n)
+	require.Equal(t, "INSERT INTO `t` (`id`,`name`,`data`) VALUES\n"+
+		"(1,'a''b',x'0001'),\n"+
+		"(2,NULL,x'');\n", bf.String())
+
+	readErr = errors.New("read failed")
+	require.NoError(t, data.Start(tcontext.Background(), nil))
+	_, err = WriteInsert(tcontext.Background(), conf, meta, data, storage.NewBufferWriter(), m)
+	require.NoError(t, data.Close())
+	require.ErrorContains(t, err, "read failed")
+
+	// the reading is stopped when the table data is closed before all the rows are written.
+	readErr = nil
+	rows = append(rows, rows...)
+	require.NoError(t, data.Start(tcontext.Background(), nil))
+	require.True(t, data.Rows().HasNext())
+	require.NoError(t, data.Close())
+}
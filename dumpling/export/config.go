@@ -18,6 +18,9 @@ import (
 	"github.com/go-sql-driver/mysql"
 	"github.com/pingcap/errors"
 	"github.com/pingcap/failpoint"
+	backuppb "github.com/pingcap/kvproto/pkg/brpb"
+	"github.com/pingcap/kvproto/pkg/encryptionpb"
+	"github.com/pingcap/tidb/br/pkg/encryption"
 	"github.com/pingcap/tidb/br/pkg/storage"
 	"github.com/pingcap/tidb/br/pkg/version"
 	"github.com/pingcap/tidb/pkg/util"
@@ -76,6 +79,16 @@ const (
 	flagTransactionalConsistency = "transactional-consistency"
 	flagCompress                 = "compress"
 	flagCsvOutputDialect         = "csv-output-dialect"
+	flagBackupStorage            = "backup-storage"
+	flagLogBackupStorage         = "log-backup-storage"
+	flagBackupCipherType         = "backup-crypter.method"
+	flagBackupCipherKey          = "backup-crypter.key"
+	flagBackupCipherKeyFile      = "backup-crypter.key-file"
+	flagLogBackupCipherType      = "log-backup-crypter.method"
+	flagLogBackupCipherKey       = "log-backup-crypter.key"
+	flagLogBackupCipherKeyFile   = "log-backup-crypter.key-file"
+	flagLogBackupMasterKey       = "log-backup-master-key"
+	flagLogBackupMasterKeyMethod = "log-backup-master-key-crypter-method"
 
 	// FlagHelp represents the help flag
 	FlagHelp = "help"
@@ -163,6 +176,16 @@ type Config struct {
 	CsvDelimiter      string
 	CsvLineTerminator string
 	Databases         []string
+	// BackupStorage is the snapshot backup of BR the data is dumped from instead of a cluster.
+	BackupStorage string
+	// LogBackupStorage is the log backup after BackupStorage, which is needed to dump the data as of the
+	// snapshot later than the snapshot backup.
+	LogBackupStorage string
+	// BackupCipher decrypts the snapshot backup.
+	BackupCipher *backuppb.CipherInfo `json:"-"`
+	// LogBackupCipher and LogBackupMasterKeyConfig decrypt the log backup, at most one of them is effective.
+	LogBackupCipher          *backuppb.CipherInfo      `json:"-"`
+	LogBackupMasterKeyConfig *backuppb.MasterKeyConfig `json:"-"`
 
 	TableFilter         filter.Filter `json:"-"`
 	Where               string
@@ -241,6 +264,9 @@ func DefaultConfig() *Config {
 		PromFactory:              promutil.NewDefaultFactory(),
 		PromRegistry:             promutil.NewDefaultRegistry(),
 		TransactionalConsistency: true,
+		BackupCipher:             &backuppb.CipherInfo{CipherType: encryptionpb.EncryptionMethod_PLAINTEXT},
+		LogBackupCipher:          &backuppb.CipherInfo{CipherType: encryptionpb.EncryptionMethod_PLAINTEXT},
+		LogBackupMasterKeyConfig: &backuppb.MasterKeyConfig{},
 	}
 }
 
@@ -321,7 +347,7 @@ func (*Config) DefineFlags(flags *pflag.FlagSet) {
 	flags.StringP(flagLogfile, "L", "", "Log file `path`, leave empty to write to console")
 	flags.String(flagLogfmt, "text", "Log `format`: {text|json}")
 	flags.String(flagConsistency, ConsistencyTypeAuto, "Consistency level during dumping: {auto|none|flush|lock|snapshot}")
-	flags.String(flagSnapshot, "", "Snapshot position (uint64 or MySQL style string timestamp). Valid only when consistency=snapshot")
+	flags.String(flagSnapshot, "", "Snapshot position (uint64 or MySQL style string timestamp). Valid only when consistency=snapshot, or the position the data is dumped as of with --backup-storage")
 	flags.BoolP(flagNoViews, "W", true, "Do not dump views")
 	flags.Bool(flagNoSequences, true, "Do not dump sequences")
 	flags.Bool(flagSortByPk, true, "Sort dump results by primary key through order by sql")
@@ -357,6 +383,16 @@ func (*Config) DefineFlags(flags *pflag.FlagSet) {
 	_ = flags.MarkHidden(flagTransactionalConsistency)
 	flags.StringP(flagCompress, "c", "", "Compress output file type, support 'gzip', 'snappy', 'zstd', 'no-compression' now")
 	flags.String(flagCsvOutputDialect, "", "The dialect of output CSV file, support 'snowflake', 'redshift', 'bigquery' now")
+	flags.String(flagBackupStorage, "", "Dump the data from the snapshot backup of BR in this storage instead of a cluster, e.g. 's3://bucket/full'. Views and sequences are not dumped")
+	flags.String(flagLogBackupStorage, "", "The log backup of BR after the snapshot backup, which is needed if --snapshot is later than the snapshot backup")
+	flags.String(flagBackupCipherType, "plaintext", "The crypter method of the snapshot backup, support 'plaintext', 'aes128-ctr', 'aes192-ctr' and 'aes256-ctr'")
+	flags.String(flagBackupCipherKey, "", "The hex key of the snapshot backup")
+	flags.String(flagBackupCipherKeyFile, "", "The file of the hex key of the snapshot backup")
+	flags.String(flagLogBackupCipherType, "plaintext", "The crypter method of the log backup, support 'plaintext', 'aes128-ctr', 'aes192-ctr' and 'aes256-ctr'")
+	flags.String(flagLogBackupCipherKey, "", "The hex key of the log backup")
+	flags.String(flagLogBackupCipherKeyFile, "", "The file of the hex key of the log backup")
+	flags.String(flagLogBackupMasterKey, "", "The comma separated master keys of the log backup, e.g. 'local:///path/to/key,aws-kms:///key-id?REGION=us-east-1'")
+	flags.String(flagLogBackupMasterKeyMethod, "aes256-ctr", "The crypter method of the log backup encrypted by the master keys")
 }
 
 // ParseFromFlags parses dumpling's export.Config from flags
@@ -515,6 +551,37 @@ func (conf *Config) ParseFromFlags(flags *pflag.FlagSet) error {
 	if err != nil {
 		return errors.Trace(err)
 	}
+	conf.BackupStorage, err = flags.GetString(flagBackupStorage)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	conf.LogBackupStorage, err = flags.GetString(flagLogBackupStorage)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if conf.BackupCipher, err = parseCipherInfo(flags, flagBackupCipherType, flagBackupCipherKey, flagBackupCipherKeyFile); err != nil {
+		return errors.Trace(err)
+	}
+	if conf.LogBackupCipher, err = parseCipherInfo(flags, flagLogBackupCipherType, flagLogBackupCipherKey, flagLogBackupCipherKeyFile); err != nil {
+		return errors.Trace(err)
+	}
+	masterKeys, err := flags.GetString(flagLogBackupMasterKey)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	conf.LogBackupMasterKeyConfig = &backuppb.MasterKeyConfig{}
+	if masterKeys != "" {
+		if conf.LogBackupCipher.CipherType != encryptionpb.EncryptionMethod_PLAINTEXT {
+			return errors.Errorf("--%s and --%s can't be set at the same time", flagLogBackupCipherType, flagLogBackupMasterKey)
+		}
+		method, err := flags.GetString(flagLogBackupMasterKeyMethod)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if conf.LogBackupMasterKeyConfig, err = encryption.ParseMasterKeyConfig(method, masterKeys); err != nil {
+			return errors.Trace(err)
+		}
+	}
 
 	if conf.Threads <= 0 {
 		return errors.Errorf("--threads is set to %d. It should be greater than 0", conf.Threads)
@@ -522,6 +589,9 @@ func (conf *Config) ParseFromFlags(flags *pflag.FlagSet) error {
 	if len(conf.CsvSeparator) == 0 {
 		return errors.New("--csv-separator is set to \"\". It must not be an empty string")
 	}
+	if conf.LogBackupStorage != "" && conf.BackupStorage == "" {
+		return errors.Errorf("--%s requires the snapshot backup given by --%s", flagLogBackupStorage, flagBackupStorage)
+	}
 
 	if conf.SessionParams == nil {
 		conf.SessionParams = make(map[string]any)
@@ -702,6 +772,24 @@ func ParseOutputDialect(outputDialect string) (CSVDialect, error) {
 	}
 }
 
+// parseCipherInfo parses the crypter method and the key of a backup from the flags.
+func parseCipherInfo(flags *pflag.FlagSet, methodFlag, keyFlag, keyFileFlag string) (*backuppb.CipherInfo, error) {
+	method, err := flags.GetString(methodFlag)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	key, err := flags.GetString(keyFlag)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	keyFile, err := flags.GetString(keyFileFlag)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	cipher, err := encryption.ParseCipherInfo(method, key, keyFile)
+	return cipher, errors.Annotatef(err, "invalid --%s", methodFlag)
+}
+
 func (conf *Config) createExternalStorage(ctx context.Context) (storage.ExternalStorage, error) {
 	if conf.ExtStorage != nil {
 		return conf.ExtStorage, nil
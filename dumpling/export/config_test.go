@@ -6,8 +6,10 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/pingcap/kvproto/pkg/encryptionpb"
 	"github.com/pingcap/tidb/br/pkg/version"
 	tcontext "github.com/pingcap/tidb/dumpling/context"
+	"github.com/spf13/pflag"
 	"github.com/stretchr/testify/require"
 )
 
@@ -51,3 +53,24 @@ func TestGetConfTables(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, expectedDBTables, actualDBTables)
 }
+
+func TestParseBackupCipherInfo(t *testing.T) {
+	conf := DefaultConfig()
+	flags := pflag.NewFlagSet("dumpling", pflag.ContinueOnError)
+	conf.DefineFlags(flags)
+	require.NoError(t, flags.Parse([]string{
+		"--" + flagBackupCipherType, "aes128-ctr", "--" + flagBackupCipherKey, "0123456789abcdef0123456789abcdef",
+	}))
+	cipher, err := parseCipherInfo(flags, flagBackupCipherType, flagBackupCipherKey, flagBackupCipherKeyFile)
+	require.NoError(t, err)
+	require.Equal(t, encryptionpb.EncryptionMethod_AES128_CTR, cipher.CipherType)
+	require.Len(t, cipher.CipherKey, 16)
+
+	cipher, err = parseCipherInfo(flags, flagLogBackupCipherType, flagLogBackupCipherKey, flagLogBackupCipherKeyFile)
+	require.NoError(t, err)
+	require.Equal(t, encryptionpb.EncryptionMethod_PLAINTEXT, cipher.CipherType)
+
+	require.NoError(t, flags.Set(flagLogBackupCipherType, "aes256-ctr"))
+	_, err = parseCipherInfo(flags, flagLogBackupCipherType, flagLogBackupCipherKey, flagLogBackupCipherKeyFile)
+	require.ErrorContains(t, err, "invalid --"+flagLogBackupCipherType)
+}
@@ -24,6 +24,7 @@ import (
 	"github.com/pingcap/errors"
 	"github.com/pingcap/failpoint"
 	pclog "github.com/pingcap/log"
+	brexport "github.com/pingcap/tidb/br/pkg/export"
 	"github.com/pingcap/tidb/br/pkg/storage"
 	"github.com/pingcap/tidb/br/pkg/summary"
 	"github.com/pingcap/tidb/br/pkg/version"
@@ -69,6 +70,9 @@ type Dumper struct {
 	charsetAndDefaultCollationMap map[string]string
 
 	speedRecorder *SpeedRecorder
+
+	// backupSource is the backups the tables are dumped from, it's nil if they're dumped from a cluster.
+	backupSource *brexport.Source
 }
 
 // NewDumper returns a new Dumper
@@ -123,6 +127,15 @@ func NewDumper(ctx context.Context, conf *Config) (*Dumper, error) {
 		}()
 	})
 
+	if conf.BackupStorage != "" {
+		err = runSteps(d,
+			initLogger,
+			createExternalStore,
+			startHTTPService,
+			openBackupSource)
+		return d, err
+	}
+
 	err = runSteps(d,
 		initLogger,
 		createExternalStore,
@@ -144,6 +157,9 @@ func NewDumper(ctx context.Context, conf *Config) (*Dumper, error) {
 // nolint: gocyclo
 func (d *Dumper) Dump() (dumpErr error) {
 	initColTypeRowReceiverMap()
+	if d.backupSource != nil {
+		return d.dumpBackup()
+	}
 	var (
 		conn    *sql.Conn
 		err     error
@@ -1290,6 +1306,9 @@ func canRebuildConn(consistency string, trxConsistencyOnly bool) bool {
 func (d *Dumper) Close() error {
 	d.cancelCtx()
 	d.metrics.unregisterFrom(d.conf.PromRegistry)
+	if d.backupSource != nil {
+		d.backupSource.Close()
+	}
 	if d.dbHandle != nil {
 		return d.dbHandle.Close()
 	}
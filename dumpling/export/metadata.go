@@ -65,6 +65,12 @@ func (m *globalMetadata) recordGlobalMetaData(db *sql.Conn, serverInfo version.S
 	return recordGlobalMetaData(m.tctx, db, &m.buffer, serverInfo, afterConn, m.snapshot)
 }
 
+// recordBackupMetaData records the ts the data is dumped as of from the backups, in the same format as
+// the one dumped from TiDB.
+func (m *globalMetadata) recordBackupMetaData(asOfTS uint64) {
+	fmt.Fprintf(&m.buffer, "SHOW MASTER STATUS:\n\tLog: tidb-binlog\n\tPos: %d\n\tGTID:\n", asOfTS)
+}
+
 func recordGlobalMetaData(tctx *tcontext.Context, db *sql.Conn, buffer *bytes.Buffer, serverInfo version.ServerInfo, afterConn bool, snapshot string) error { // revive:disable-line:flag-parameter
 	serverType := serverInfo.ServerType
 	writeMasterStatusHeader := func() {
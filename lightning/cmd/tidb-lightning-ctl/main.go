@@ -18,12 +18,15 @@ import (
 	"context"
 	"flag"
 	"fmt"
+	"net/url"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
 
 	"github.com/pingcap/errors"
 	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/pingcap/tidb/br/pkg/storage"
 	"github.com/pingcap/tidb/lightning/pkg/importer"
 	"github.com/pingcap/tidb/lightning/pkg/server"
 	"github.com/pingcap/tidb/pkg/lightning/backend"
@@ -31,6 +34,8 @@ import (
 	"github.com/pingcap/tidb/pkg/lightning/checkpoints"
 	"github.com/pingcap/tidb/pkg/lightning/common"
 	"github.com/pingcap/tidb/pkg/lightning/config"
+	"github.com/pingcap/tidb/pkg/lightning/errormanager"
+	"github.com/pingcap/tidb/pkg/lightning/log"
 	"github.com/pingcap/tidb/pkg/lightning/tikv"
 	pdhttp "github.com/tikv/pd/client/http"
 )
@@ -51,6 +56,7 @@ func run() error {
 		mode                                        *string
 		cpRemove, cpErrIgnore, cpErrDestroy, cpDump *string
 		localStoringTables                          *bool
+		conflictReportApply                         *string
 
 		fsUsage func()
 	)
@@ -74,6 +80,8 @@ func run() error {
 
 		localStoringTables = fs.Bool("check-local-storage", false, "show tables that are missing local intermediate files (value can be 'all' or '`db`.`table`')")
 
+		conflictReportApply = fs.String("conflict-report-apply", "", "write the resolved rows in the given conflict report file into the target tables")
+
 		fsUsage = fs.Usage
 	}))
 
@@ -130,6 +138,9 @@ func run() error {
 	if *localStoringTables {
 		return errors.Trace(getLocalStoringTables(ctx, cfg))
 	}
+	if len(*conflictReportApply) != 0 {
+		return errors.Trace(applyConflictReport(ctx, cfg, *conflictReportApply))
+	}
 
 	fsUsage()
 	return nil
@@ -318,3 +329,32 @@ func getLocalStoringTables(ctx context.Context, cfg *config.Config) (err2 error)
 
 	return nil
 }
+
+func applyConflictReport(ctx context.Context, cfg *config.Config, reportURL string) error {
+	u, err := url.Parse(reportURL)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	name := path.Base(u.Path)
+	u.Path = path.Dir(u.Path)
+	b, err := storage.ParseBackend(u.String(), nil)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	store, err := storage.New(ctx, b, &storage.ExternalStorageOptions{})
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer store.Close()
+
+	db, err := importer.DBFromConfig(ctx, cfg.TiDB)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	//nolint: errcheck
+	defer db.Close()
+
+	applied, err := errormanager.ApplyConflictReport(ctx, db, store, name, log.FromContext(ctx))
+	fmt.Fprintln(os.Stderr, "Applied resolved conflicts:", applied)
+	return errors.Trace(err)
+}
@@ -111,6 +111,16 @@ func openParser(
 		if err != nil {
 			return nil, err
 		}
+	case mydump.SourceTypeAvro:
+		parser, err = mydump.NewAvroParser(ctx, &cfg.Mydumper.Avro, reader, chunk.FileMeta.Path)
+		if err != nil {
+			return nil, err
+		}
+	case mydump.SourceTypeORC:
+		parser, err = mydump.NewORCParser(ctx, reader, chunk.FileMeta.Path)
+		if err != nil {
+			return nil, err
+		}
 	default:
 		return nil, errors.Errorf("file '%s' with unknown source type '%s'", chunk.Key.Path, chunk.FileMeta.Type.String())
 	}
@@ -348,7 +358,7 @@ func (cr *chunkProcessor) encodeLoop(
 			err = cr.parser.ReadRow()
 			columnNames := cr.parser.Columns()
 			newOffset, rowID = cr.parser.Pos()
-			if cr.chunk.FileMeta.Compression != mydump.CompressionNone || cr.chunk.FileMeta.Type == mydump.SourceTypeParquet {
+			if cr.chunk.FileMeta.Compression != mydump.CompressionNone || cr.chunk.FileMeta.Type.IsRowOffset() {
 				newScannedOffset, scannedOffsetErr = cr.parser.ScannedPos()
 				if scannedOffsetErr != nil {
 					logger.Warn("fail to get data engine ScannedPos, progress may not be accurate",
@@ -498,7 +508,7 @@ func (cr *chunkProcessor) encodeLoop(
 		if m, ok := metric.FromContext(ctx); ok {
 			m.RowEncodeSecondsHistogram.Observe(encodeDur.Seconds())
 			m.RowReadSecondsHistogram.Observe(readDur.Seconds())
-			if cr.chunk.FileMeta.Type == mydump.SourceTypeParquet {
+			if cr.chunk.FileMeta.Type.IsRowOffset() {
 				m.RowReadBytesHistogram.Observe(float64(newScannedOffset - scannedOffset))
 			} else {
 				m.RowReadBytesHistogram.Observe(float64(newOffset - offset))
@@ -715,7 +725,7 @@ func (cr *chunkProcessor) deliverLoop(
 			}
 			delta := highOffset - lowOffset
 			if delta >= 0 {
-				if cr.chunk.FileMeta.Type == mydump.SourceTypeParquet {
+				if cr.chunk.FileMeta.Type.IsRowOffset() {
 					if currRealOffset > startRealOffset {
 						m.BytesCounter.WithLabelValues(metric.StateRestored).Add(float64(currRealOffset - startRealOffset))
 					}
@@ -493,6 +493,16 @@ func (p *PreImportInfoGetterImpl) ReadFirstNRowsByFileMeta(ctx context.Context,
 		if err != nil {
 			return nil, nil, errors.Trace(err)
 		}
+	case mydump.SourceTypeAvro:
+		parser, err = mydump.NewAvroParser(ctx, &p.cfg.Mydumper.Avro, reader, dataFileMeta.Path)
+		if err != nil {
+			return nil, nil, errors.Trace(err)
+		}
+	case mydump.SourceTypeORC:
+		parser, err = mydump.NewORCParser(ctx, reader, dataFileMeta.Path)
+		if err != nil {
+			return nil, nil, errors.Trace(err)
+		}
 	default:
 		panic(fmt.Sprintf("unknown file type '%s'", dataFileMeta.Type))
 	}
@@ -663,6 +673,16 @@ func (p *PreImportInfoGetterImpl) sampleDataFromTable(
 		if err != nil {
 			return 0.0, false, errors.Trace(err)
 		}
+	case mydump.SourceTypeAvro:
+		parser, err = mydump.NewAvroParser(ctx, &p.cfg.Mydumper.Avro, reader, sampleFile.Path)
+		if err != nil {
+			return 0.0, false, errors.Trace(err)
+		}
+	case mydump.SourceTypeORC:
+		parser, err = mydump.NewORCParser(ctx, reader, sampleFile.Path)
+		if err != nil {
+			return 0.0, false, errors.Trace(err)
+		}
 	default:
 		panic(fmt.Sprintf("file '%s' with unknown source type '%s'", sampleFile.Path, sampleFile.Type.String()))
 	}
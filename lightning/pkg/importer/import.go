@@ -586,6 +586,7 @@ outside:
 
 	task.End(zap.ErrorLevel, err)
 	rc.errorMgr.LogErrorDetails()
+	rc.exportConflictReport(ctx)
 	rc.errorSummaries.emitLog()
 
 	return errors.Trace(err)
@@ -682,7 +683,7 @@ func verifyCheckpoint(cfg *config.Config, taskCp *checkpoints.TaskCheckpoint) er
 	}
 	// always check the backend value even with 'check-requirements = false'
 	retryUsage := "destroy all checkpoints"
-	if cfg.Checkpoint.Driver == config.CheckpointDriverFile {
+	if cfg.Checkpoint.Driver == config.CheckpointDriverFile || cfg.Checkpoint.Driver == config.CheckpointDriverStorage {
 		retryUsage = fmt.Sprintf("delete the file '%s'", cfg.Checkpoint.DSN)
 	}
 	retryUsage += " and remove all restored tables and try again"
@@ -1499,9 +1500,9 @@ func (rc *Controller) importTables(ctx context.Context) (finalErr error) {
 			} else {
 				for _, eng := range cp.Engines {
 					for _, chunk := range eng.Chunks {
-						// for parquet files filesize is more accurate, we can calculate correct unfinished bytes unless
+						// for parquet and orc files filesize is more accurate, we can calculate correct unfinished bytes unless
 						//  we set up the reader, so we directly use filesize here
-						if chunk.FileMeta.Type == mydump.SourceTypeParquet {
+						if chunk.FileMeta.Type.IsRowOffset() {
 							totalDataSizeToRestore += chunk.FileMeta.FileSize
 							if m, ok := metric.FromContext(ctx); ok {
 								m.RowsCounter.WithLabelValues(metric.StateTotalRestore, tableName).Add(float64(chunk.UnfinishedSize()))
@@ -1636,6 +1637,34 @@ func addExtendDataForCheckpoint(
 	return nil
 }
 
+// exportConflictReport exports the conflicts to conflict.report-dir. the failure
+// is only logged since the conflicts are still kept in the task info schema.
+func (rc *Controller) exportConflictReport(ctx context.Context) {
+	if len(rc.cfg.Conflict.ReportDir) == 0 {
+		return
+	}
+	logger := log.FromContext(ctx).With(zap.String("report-dir", rc.cfg.Conflict.ReportDir))
+	u, err := storage.ParseBackend(rc.cfg.Conflict.ReportDir, nil)
+	if err != nil {
+		logger.Warn("failed to export conflict report", zap.Error(err))
+		return
+	}
+	store, err := storage.New(ctx, u, &storage.ExternalStorageOptions{})
+	if err != nil {
+		logger.Warn("failed to export conflict report", zap.Error(err))
+		return
+	}
+	defer store.Close()
+	files, err := rc.errorMgr.ExportConflictReport(ctx, store, rc.cfg.Conflict.ReportFormat)
+	if err != nil {
+		logger.Warn("failed to export conflict report", zap.Error(err))
+		return
+	}
+	if len(files) > 0 {
+		logger.Info("conflict report exported", zap.Strings("files", files))
+	}
+}
+
 func (rc *Controller) outputErrorSummary() {
 	if rc.errorMgr.HasError() {
 		fmt.Println(rc.errorMgr.Output())
@@ -0,0 +1,427 @@
+// Copyright 2026 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/aws/aws-sdk-go/service/sqs/sqsiface"
+	"github.com/pingcap/errors"
+	"github.com/pingcap/tidb/br/pkg/storage"
+	"github.com/pingcap/tidb/pkg/lightning/checkpoints"
+	"github.com/pingcap/tidb/pkg/lightning/common"
+	"github.com/pingcap/tidb/pkg/lightning/config"
+	"github.com/pingcap/tidb/pkg/lightning/log"
+	"github.com/pingcap/tidb/pkg/lightning/mydump"
+	"go.uber.org/zap"
+)
+
+const (
+	// the longest time to wait for the messages of SQS in a request.
+	sqsMaxWaitTime = 20 * time.Second
+	// SQS returns at most 10 messages in a request, and deletes at most 10
+	// messages in a request.
+	sqsMaxMessages = 10
+	// the interval to list all the files of the data source, to discover the
+	// files arrived with the paths before the last one discovered.
+	fullListingInterval = time.Hour
+)
+
+// continuousSource discovers the files arrived in the data source.
+type continuousSource interface {
+	// discover returns the arrived files which aren't recorded in filesDB. the
+	// files more than `limit` are returned if they can't be discovered again.
+	discover(ctx context.Context, filesDB *checkpoints.ContinuousFilesDB, limit int) ([]checkpoints.ContinuousFile, error)
+	// ack is called after the discovered files are recorded in a batch.
+	ack(ctx context.Context) error
+}
+
+// listingSource lists the data source to discover the files. it only lists the
+// files after the last one discovered, which are the newly-arrived ones if the
+// files are named in the order they arrive, and lists all the files at the start
+// and every fullListingInterval.
+type listingSource struct {
+	store storage.ExternalStorage
+	// lastPath is the last path discovered, the next listing starts after it.
+	lastPath        string
+	lastFullListing time.Time
+}
+
+func (s *listingSource) discover(
+	ctx context.Context,
+	filesDB *checkpoints.ContinuousFilesDB,
+	limit int,
+) ([]checkpoints.ContinuousFile, error) {
+	now := time.Now()
+	startAfter := s.lastPath
+	if now.Sub(s.lastFullListing) >= fullListingInterval {
+		startAfter = ""
+	}
+	var files []checkpoints.ContinuousFile
+	err := s.store.WalkDir(ctx, &storage.WalkOption{StartAfter: startAfter}, func(path string, size int64) error {
+		// not all the storages support StartAfter.
+		if len(startAfter) == 0 || path > startAfter {
+			files = append(files, checkpoints.ContinuousFile{Path: path, Size: size})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if len(startAfter) == 0 {
+		s.lastFullListing = now
+	}
+	files = sortContinuousFiles(files)
+	if len(files) > 0 {
+		s.lastPath = max(s.lastPath, files[len(files)-1].Path)
+	}
+	files, err = filesDB.NewFiles(ctx, files)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if len(files) > limit {
+		// the rest files are discovered by the next listing.
+		files = files[:limit]
+		s.lastPath = files[limit-1].Path
+	}
+	return files, nil
+}
+
+func (*listingSource) ack(context.Context) error {
+	return nil
+}
+
+// s3EventNotification is the message of an S3 event notification.
+type s3EventNotification struct {
+	Records []struct {
+		EventName string `json:"eventName"`
+		S3        struct {
+			Bucket struct {
+				Name string `json:"name"`
+			} `json:"bucket"`
+			Object struct {
+				Key  string `json:"key"`
+				Size int64  `json:"size"`
+			} `json:"object"`
+		} `json:"s3"`
+	} `json:"Records"`
+}
+
+// sqsSource receives the S3 event notifications from an SQS queue to discover
+// the files. the messages are deleted after the files are recorded, so the
+// files of the messages redelivered after a crash are filtered out by the
+// record.
+type sqsSource struct {
+	svc      sqsiface.SQSAPI
+	queueURL string
+	bucket   string
+	// prefix is the prefix of the keys of the data source, it ends with '/' if
+	// it isn't empty.
+	prefix   string
+	waitTime time.Duration
+	received []*sqs.Message
+}
+
+func newSQSSource(sourceDir, queueURL string, pollInterval time.Duration) (*sqsSource, error) {
+	backend, err := storage.ParseBackend(sourceDir, nil)
+	if err != nil {
+		return nil, common.NormalizeError(err)
+	}
+	s3 := backend.GetS3()
+	if s3 == nil {
+		return nil, common.ErrInvalidConfig.GenWithStack(
+			"`continuous.sqs-queue-url` requires the data source on s3, but it's %s", sourceDir)
+	}
+
+	awsConfig := aws.NewConfig().WithRegion(sqsQueueRegion(queueURL, s3.Region))
+	if s3.AccessKey != "" && s3.SecretAccessKey != "" {
+		awsConfig.WithCredentials(credentials.NewStaticCredentials(s3.AccessKey, s3.SecretAccessKey, s3.SessionToken))
+	}
+	ses, err := session.NewSessionWithOptions(session.Options{Config: *awsConfig})
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	prefix := strings.Trim(s3.Prefix, "/")
+	if len(prefix) > 0 {
+		prefix += "/"
+	}
+	return &sqsSource{
+		svc:      sqs.New(ses),
+		queueURL: queueURL,
+		bucket:   s3.Bucket,
+		prefix:   prefix,
+		waitTime: min(pollInterval, sqsMaxWaitTime),
+	}, nil
+}
+
+// sqsQueueRegion returns the region in the URL of the queue like
+// https://sqs.us-west-2.amazonaws.com/123456789012/queue, or the fallback one
+// if there isn't.
+func sqsQueueRegion(queueURL, fallback string) string {
+	if u, err := url.Parse(queueURL); err == nil {
+		parts := strings.Split(u.Hostname(), ".")
+		if len(parts) >= 3 && parts[0] == "sqs" {
+			return parts[1]
+		}
+	}
+	if fallback == "" {
+		return "us-east-1"
+	}
+	return fallback
+}
+
+func (s *sqsSource) discover(
+	ctx context.Context,
+	filesDB *checkpoints.ContinuousFilesDB,
+	limit int,
+) ([]checkpoints.ContinuousFile, error) {
+	var files []checkpoints.ContinuousFile
+	waitTime := s.waitTime
+	for len(files) < limit {
+		out, err := s.svc.ReceiveMessageWithContext(ctx, &sqs.ReceiveMessageInput{
+			QueueUrl:            aws.String(s.queueURL),
+			MaxNumberOfMessages: aws.Int64(sqsMaxMessages),
+			WaitTimeSeconds:     aws.Int64(int64(waitTime.Seconds())),
+		})
+		if err != nil {
+			return nil, errors.Annotatef(err, "failed to receive the messages from %s", s.queueURL)
+		}
+		if len(out.Messages) == 0 {
+			break
+		}
+		for _, msg := range out.Messages {
+			s.received = append(s.received, msg)
+			msgFiles, err := s.parseMessage(aws.StringValue(msg.Body))
+			if err != nil {
+				log.FromContext(ctx).Warn("ignore the invalid message of the s3 event notification",
+					zap.String("messageID", aws.StringValue(msg.MessageId)), zap.Error(err))
+				continue
+			}
+			files = append(files, msgFiles...)
+		}
+		// the messages which are already available are received without waiting.
+		waitTime = time.Second
+	}
+	// all the files are returned since the messages are deleted after the
+	// files are recorded.
+	files, err := filesDB.NewFiles(ctx, files)
+	return files, errors.Trace(err)
+}
+
+func (s *sqsSource) parseMessage(body string) ([]checkpoints.ContinuousFile, error) {
+	var notification s3EventNotification
+	if err := json.Unmarshal([]byte(body), &notification); err != nil {
+		return nil, errors.Trace(err)
+	}
+	files := make([]checkpoints.ContinuousFile, 0, len(notification.Records))
+	for _, r := range notification.Records {
+		if !strings.HasPrefix(r.EventName, "ObjectCreated:") || r.S3.Bucket.Name != s.bucket {
+			continue
+		}
+		key, err := url.QueryUnescape(r.S3.Object.Key)
+		if err != nil {
+			return nil, errors.Annotatef(err, "invalid key %s", r.S3.Object.Key)
+		}
+		if !strings.HasPrefix(key, s.prefix) || strings.HasSuffix(key, "/") {
+			continue
+		}
+		files = append(files, checkpoints.ContinuousFile{Path: strings.TrimPrefix(key, s.prefix), Size: r.S3.Object.Size})
+	}
+	return files, nil
+}
+
+func (s *sqsSource) ack(ctx context.Context) error {
+	for len(s.received) > 0 {
+		n := min(len(s.received), sqsMaxMessages)
+		entries := make([]*sqs.DeleteMessageBatchRequestEntry, 0, n)
+		for _, msg := range s.received[:n] {
+			entries = append(entries, &sqs.DeleteMessageBatchRequestEntry{
+				Id:            msg.MessageId,
+				ReceiptHandle: msg.ReceiptHandle,
+			})
+		}
+		out, err := s.svc.DeleteMessageBatchWithContext(ctx, &sqs.DeleteMessageBatchInput{
+			QueueUrl: aws.String(s.queueURL),
+			Entries:  entries,
+		})
+		if err != nil {
+			return errors.Annotatef(err, "failed to delete the messages from %s", s.queueURL)
+		}
+		// the messages failed to delete are redelivered, and filtered out by
+		// the record of the files.
+		for _, failed := range out.Failed {
+			log.FromContext(ctx).Warn("failed to delete the message of the s3 event notification",
+				zap.String("messageID", aws.StringValue(failed.Id)), zap.String("reason", aws.StringValue(failed.Message)))
+		}
+		s.received = s.received[n:]
+	}
+	return nil
+}
+
+// batchFileIterator iterates the files of a batch.
+type batchFileIterator struct {
+	files []checkpoints.ContinuousFile
+}
+
+// IterateFiles implements mydump.FileIterator.
+func (iter *batchFileIterator) IterateFiles(ctx context.Context, hdl mydump.FileHandler) error {
+	for _, f := range iter.files {
+		if err := hdl(ctx, f.Path, f.Size); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return nil
+}
+
+// sortContinuousFiles sorts the files by the paths and removes the duplicated
+// ones, the files are imported in the order of the paths, which is usually the
+// order they arrive in.
+func sortContinuousFiles(files []checkpoints.ContinuousFile) []checkpoints.ContinuousFile {
+	slices.SortFunc(files, func(a, b checkpoints.ContinuousFile) int {
+		return strings.Compare(a.Path, b.Path)
+	})
+	return slices.CompactFunc(files, func(a, b checkpoints.ContinuousFile) bool {
+		return a.Path == b.Path
+	})
+}
+
+// nextBatch returns the batch to import, it's the pending batch if there is one,
+// or a new batch of the newly-arrived files. it returns nil if there is no file
+// to import.
+func nextBatch(
+	ctx context.Context,
+	filesDB *checkpoints.ContinuousFilesDB,
+	source continuousSource,
+	limit int,
+) (*checkpoints.ContinuousBatch, error) {
+	batch, err := filesDB.PendingBatch(ctx)
+	if err != nil || batch != nil {
+		return batch, errors.Trace(err)
+	}
+	files, err := source.discover(ctx, filesDB, limit)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	files = sortContinuousFiles(files)
+	if len(files) > 0 {
+		batch, err = filesDB.CreateBatch(ctx, files)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	return batch, errors.Trace(source.ack(ctx))
+}
+
+// runContinuous runs the task in the continuous mode, it imports the
+// newly-arrived files batch by batch until lightning is stopped.
+func (l *Lightning) runContinuous(taskCtx context.Context, taskCfg *config.Config, o *options) error {
+	logger := o.logger
+	ctx, cancel := context.WithCancel(taskCtx)
+	defer cancel()
+	go func() {
+		select {
+		case <-l.ctx.Done():
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	s := o.dumpFileStorage
+	if s == nil {
+		u, err := storage.ParseBackend(taskCfg.Mydumper.SourceDir, nil)
+		if err != nil {
+			return common.NormalizeError(err)
+		}
+		s, err = storage.New(ctx, u, &storage.ExternalStorageOptions{})
+		if err != nil {
+			return common.NormalizeError(err)
+		}
+		defer s.Close()
+	}
+
+	var source continuousSource = &listingSource{store: s}
+	if taskCfg.Continuous.SQSQueueURL != "" {
+		sqsSrc, err := newSQSSource(taskCfg.Mydumper.SourceDir, taskCfg.Continuous.SQSQueueURL,
+			taskCfg.Continuous.PollInterval.Duration)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		source = sqsSrc
+	}
+
+	var (
+		db  *sql.DB
+		err error
+	)
+	if taskCfg.Checkpoint.MySQLParam != nil {
+		db, err = taskCfg.Checkpoint.MySQLParam.Connect()
+	} else {
+		db, err = sql.Open("mysql", taskCfg.Checkpoint.DSN)
+	}
+	if err != nil {
+		return common.ErrOpenCheckpoint.Wrap(err).GenWithStackByArgs()
+	}
+	//nolint: errcheck
+	defer db.Close()
+	filesDB, err := checkpoints.NewContinuousFilesDB(ctx, db, taskCfg.Continuous.Schema, taskCfg.Checkpoint.Schema)
+	if err != nil {
+		return common.ErrOpenCheckpoint.Wrap(err).GenWithStackByArgs()
+	}
+
+	logger.Info("lightning is running in the continuous mode",
+		zap.String("source", taskCfg.Mydumper.SourceDir),
+		zap.String("sqsQueueURL", taskCfg.Continuous.SQSQueueURL),
+		zap.Duration("pollInterval", taskCfg.Continuous.PollInterval.Duration))
+	for {
+		batch, err := nextBatch(ctx, filesDB, source, taskCfg.Continuous.BatchMaxFiles)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return errors.Trace(err)
+		}
+		if batch == nil {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(taskCfg.Continuous.PollInterval.Duration):
+			}
+			continue
+		}
+
+		task := logger.Begin(zap.InfoLevel, "import the continuous batch")
+		batchOpts := *o
+		batchOpts.dumpFileStorage = s
+		batchOpts.fileIter = &batchFileIterator{files: batch.Files}
+		err = l.run(ctx, taskCfg, &batchOpts)
+		if err == nil {
+			err = filesDB.FinishBatch(ctx, batch.ID)
+		}
+		task.End(zap.ErrorLevel, err, zap.Int64("batchID", batch.ID), zap.Int("files", len(batch.Files)))
+		if err != nil {
+			return errors.Trace(err)
+		}
+	}
+}
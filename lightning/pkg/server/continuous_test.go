@@ -0,0 +1,201 @@
+// Copyright 2026 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/aws/aws-sdk-go/service/sqs/sqsiface"
+	"github.com/pingcap/tidb/br/pkg/storage"
+	"github.com/pingcap/tidb/pkg/lightning/checkpoints"
+	"github.com/stretchr/testify/require"
+)
+
+type mockSQS struct {
+	sqsiface.SQSAPI
+	messages [][]*sqs.Message
+	deleted  []string
+}
+
+func (m *mockSQS) ReceiveMessageWithContext(
+	_ aws.Context, _ *sqs.ReceiveMessageInput, _ ...request.Option,
+) (*sqs.ReceiveMessageOutput, error) {
+	if len(m.messages) == 0 {
+		return &sqs.ReceiveMessageOutput{}, nil
+	}
+	out := &sqs.ReceiveMessageOutput{Messages: m.messages[0]}
+	m.messages = m.messages[1:]
+	return out, nil
+}
+
+func (m *mockSQS) DeleteMessageBatchWithContext(
+	_ aws.Context, input *sqs.DeleteMessageBatchInput, _ ...request.Option,
+) (*sqs.DeleteMessageBatchOutput, error) {
+	for _, e := range input.Entries {
+		m.deleted = append(m.deleted, aws.StringValue(e.ReceiptHandle))
+	}
+	return &sqs.DeleteMessageBatchOutput{}, nil
+}
+
+func newTestContinuousFilesDB(t *testing.T) (*checkpoints.ContinuousFilesDB, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, mock.ExpectationsWereMet())
+		_ = db.Close()
+	})
+	mock.ExpectExec("CREATE DATABASE").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("CREATE TABLE").WillReturnResult(sqlmock.NewResult(0, 0))
+	filesDB, err := checkpoints.NewContinuousFilesDB(context.Background(), db, "files", "cp")
+	require.NoError(t, err)
+	return filesDB, mock
+}
+
+func expectNoPendingBatch(mock sqlmock.Sqlmock) {
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT batch_id, path, file_size").
+		WillReturnRows(sqlmock.NewRows([]string{"batch_id", "path", "file_size"}))
+	mock.ExpectCommit()
+}
+
+func expectRecordedFiles(mock sqlmock.Sqlmock, paths ...string) {
+	rows := sqlmock.NewRows([]string{"path"})
+	for _, p := range paths {
+		rows.AddRow(p)
+	}
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT path FROM").WillReturnRows(rows)
+	mock.ExpectCommit()
+}
+
+func expectCreateBatch(mock sqlmock.Sqlmock, paths ...string) {
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT COALESCE").WillReturnRows(sqlmock.NewRows([]string{"max"}).AddRow(0))
+	insert := mock.ExpectPrepare("INSERT IGNORE INTO")
+	for _, p := range paths {
+		insert.ExpectExec().WithArgs(sqlmock.AnyArg(), p, sqlmock.AnyArg(), 1, 0).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+	mock.ExpectCommit()
+}
+
+func TestSQSSource(t *testing.T) {
+	ctx := context.Background()
+	filesDB, mock := newTestContinuousFilesDB(t)
+	svc := &mockSQS{messages: [][]*sqs.Message{
+		{
+			{MessageId: aws.String("1"), ReceiptHandle: aws.String("h1"), Body: aws.String(`{"Records": [
+				{"eventName": "ObjectCreated:Put", "s3": {"bucket": {"name": "bucket"}, "object": {"key": "data/db.t.2.csv", "size": 20}}},
+				{"eventName": "ObjectRemoved:Delete", "s3": {"bucket": {"name": "bucket"}, "object": {"key": "data/db.t.3.csv"}}},
+				{"eventName": "ObjectCreated:Put", "s3": {"bucket": {"name": "other"}, "object": {"key": "data/db.t.4.csv"}}},
+				{"eventName": "ObjectCreated:Put", "s3": {"bucket": {"name": "bucket"}, "object": {"key": "other/db.t.5.csv"}}}
+			]}`)},
+			{MessageId: aws.String("2"), ReceiptHandle: aws.String("h2"), Body: aws.String(`{"Event": "s3:TestEvent"}`)},
+		},
+		{
+			{MessageId: aws.String("3"), ReceiptHandle: aws.String("h3"), Body: aws.String(`{"Records": [
+				{"eventName": "ObjectCreated:Put", "s3": {"bucket": {"name": "bucket"}, "object": {"key": "data/db.t-schema.sql", "size": 30}}},
+				{"eventName": "ObjectCreated:Copy", "s3": {"bucket": {"name": "bucket"}, "object": {"key": "data/db.t.1+%281%29.csv", "size": 10}}}
+			]}`)},
+			{MessageId: aws.String("4"), ReceiptHandle: aws.String("h4"), Body: aws.String(`not json`)},
+		},
+	}}
+	source := &sqsSource{svc: svc, queueURL: "https://sqs.us-west-2.amazonaws.com/1/q", bucket: "bucket", prefix: "data/"}
+
+	expectNoPendingBatch(mock)
+	expectRecordedFiles(mock, "db.t-schema.sql")
+	expectCreateBatch(mock, "db.t.1 (1).csv", "db.t.2.csv")
+	batch, err := nextBatch(ctx, filesDB, source, 2)
+	require.NoError(t, err)
+	require.Equal(t, &checkpoints.ContinuousBatch{ID: 1, Files: []checkpoints.ContinuousFile{
+		{Path: "db.t.1 (1).csv", Size: 10},
+		{Path: "db.t.2.csv", Size: 20},
+	}}, batch)
+	// all the received messages are deleted, including the invalid ones.
+	require.Equal(t, []string{"h1", "h2", "h3", "h4"}, svc.deleted)
+
+	expectNoPendingBatch(mock)
+	batch, err = nextBatch(ctx, filesDB, source, 2)
+	require.NoError(t, err)
+	require.Nil(t, batch)
+
+	require.Equal(t, "us-west-2", sqsQueueRegion("https://sqs.us-west-2.amazonaws.com/1/q", "eu-west-1"))
+	require.Equal(t, "eu-west-1", sqsQueueRegion("http://localhost:4566/1/q", "eu-west-1"))
+	require.Equal(t, "us-east-1", sqsQueueRegion("http://localhost:4566/1/q", ""))
+}
+
+func TestListingSource(t *testing.T) {
+	ctx := context.Background()
+	store, err := storage.NewLocalStorage(t.TempDir())
+	require.NoError(t, err)
+	for _, name := range []string{"db.t.3.csv", "db.t.1.csv", "db.t.2.csv", "db.t-schema.sql"} {
+		require.NoError(t, store.WriteFile(ctx, name, []byte("1")))
+	}
+	filesDB, mock := newTestContinuousFilesDB(t)
+	source := &listingSource{store: store}
+
+	expectNoPendingBatch(mock)
+	expectRecordedFiles(mock, "db.t-schema.sql")
+	expectCreateBatch(mock, "db.t.1.csv", "db.t.2.csv")
+	batch, err := nextBatch(ctx, filesDB, source, 2)
+	require.NoError(t, err)
+	require.Equal(t, &checkpoints.ContinuousBatch{ID: 1, Files: []checkpoints.ContinuousFile{
+		{Path: "db.t.1.csv", Size: 1},
+		{Path: "db.t.2.csv", Size: 1},
+	}}, batch)
+
+	var iterated []string
+	err = (&batchFileIterator{files: batch.Files}).IterateFiles(ctx, func(_ context.Context, path string, _ int64) error {
+		iterated = append(iterated, path)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"db.t.1.csv", "db.t.2.csv"}, iterated)
+
+	// the pending batch is imported again before discovering the new files.
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT batch_id, path, file_size").
+		WillReturnRows(sqlmock.NewRows([]string{"batch_id", "path", "file_size"}).AddRow(1, "db.t.1.csv", 1))
+	mock.ExpectCommit()
+	batch, err = nextBatch(ctx, filesDB, source, 2)
+	require.NoError(t, err)
+	require.Equal(t, &checkpoints.ContinuousBatch{ID: 1, Files: []checkpoints.ContinuousFile{
+		{Path: "db.t.1.csv", Size: 1},
+	}}, batch)
+
+	// only the files after the last one discovered are listed.
+	for _, name := range []string{"db.t.0.csv", "db.t.4.csv"} {
+		require.NoError(t, store.WriteFile(ctx, name, []byte("1")))
+	}
+	expectRecordedFiles(mock)
+	files, err := source.discover(ctx, filesDB, 10)
+	require.NoError(t, err)
+	require.Equal(t, []checkpoints.ContinuousFile{{Path: "db.t.3.csv", Size: 1}, {Path: "db.t.4.csv", Size: 1}}, files)
+	require.Equal(t, "db.t.4.csv", source.lastPath)
+
+	// the files arrived out of order are discovered by the full listing.
+	source.lastFullListing = time.Time{}
+	expectRecordedFiles(mock, "db.t-schema.sql", "db.t.1.csv", "db.t.2.csv", "db.t.3.csv", "db.t.4.csv")
+	files, err = source.discover(ctx, filesDB, 10)
+	require.NoError(t, err)
+	require.Equal(t, []checkpoints.ContinuousFile{{Path: "db.t.0.csv", Size: 1}}, files)
+	require.Equal(t, "db.t.4.csv", source.lastPath)
+}
@@ -302,7 +302,11 @@ func (l *Lightning) RunServer() error {
 			promRegistry: l.promRegistry,
 			logger:       log.L(),
 		}
-		err = l.run(context.Background(), task, o)
+		if task.Continuous.Enable {
+			err = l.runContinuous(context.Background(), task, o)
+		} else {
+			err = l.run(context.Background(), task, o)
+		}
 		if err != nil && !common.IsContextCanceledError(err) {
 			importer.DeliverPauser.Pause() // force pause the progress on error
 			log.L().Error("tidb lightning encountered error", zap.Error(err))
@@ -396,6 +400,9 @@ func (l *Lightning) RunOnceWithOptions(taskCtx context.Context, taskCfg *config.
 		})
 	}
 
+	if taskCfg.Continuous.Enable {
+		return l.runContinuous(taskCtx, taskCfg, o)
+	}
 	return l.run(taskCtx, taskCfg, o)
 }
 
@@ -536,7 +543,7 @@ func (l *Lightning) run(taskCtx context.Context, taskCfg *config.Config, o *opti
 
 	loadTask := o.logger.Begin(zap.InfoLevel, "load data source")
 	var mdl *mydump.MDLoader
-	mdl, err = mydump.NewLoaderWithStore(ctx, mydump.NewLoaderCfg(taskCfg), s)
+	mdl, err = mydump.NewLoaderWithStore(ctx, mydump.NewLoaderCfg(taskCfg), s, mydump.WithFileIterator(o.fileIter))
 	loadTask.End(zap.ErrorLevel, err)
 	if err != nil {
 		return errors.Trace(err)
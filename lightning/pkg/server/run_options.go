@@ -19,6 +19,7 @@ import (
 
 	"github.com/pingcap/tidb/br/pkg/storage"
 	"github.com/pingcap/tidb/pkg/lightning/log"
+	"github.com/pingcap/tidb/pkg/lightning/mydump"
 	"github.com/pingcap/tidb/pkg/util/promutil"
 	"go.uber.org/atomic"
 	"go.uber.org/zap"
@@ -32,6 +33,8 @@ type options struct {
 	promRegistry      promutil.Registry
 	logger            log.Logger
 	dupIndicator      *atomic.Bool
+	// fileIter is the files imported in a batch of the continuous mode.
+	fileIter mydump.FileIterator
 	// only used in tests
 	db *sql.DB
 }
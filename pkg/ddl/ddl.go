@@ -1505,6 +1505,18 @@ func ResumeJobsBySystem(se sessionctx.Context, ids []int64) (errs []error, err e
 	return processJobs(ctx, resumePausedJob, se, ids, model.AdminCommandBySystem)
 }
 
+// PauseJobsByRestore pauses Jobs for the DDL gate of a restore.
+func PauseJobsByRestore(se sessionctx.Context, ids []int64) (errs []error, err error) {
+	ctx := context.Background()
+	return processJobs(ctx, pauseRunningJob, se, ids, model.AdminCommandByRestore)
+}
+
+// ResumeJobsByRestore resumes Jobs that are paused by the DDL gate of a restore.
+func ResumeJobsByRestore(se sessionctx.Context, ids []int64) (errs []error, err error) {
+	ctx := context.Background()
+	return processJobs(ctx, resumePausedJob, se, ids, model.AdminCommandByRestore)
+}
+
 // pprocessAllJobs processes all the jobs in the job table, 100 jobs at a time in case of high memory usage.
 func processAllJobs(
 	ctx context.Context,
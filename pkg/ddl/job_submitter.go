@@ -22,6 +22,7 @@ import (
 	"slices"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/pingcap/errors"
@@ -46,6 +47,7 @@ import (
 	"github.com/pingcap/tidb/pkg/util/generic"
 	"github.com/pingcap/tidb/pkg/util/intest"
 	"github.com/pingcap/tidb/pkg/util/mathutil"
+	filter "github.com/pingcap/tidb/pkg/util/table-filter"
 	tikv "github.com/tikv/client-go/v2/kv"
 	"github.com/tikv/client-go/v2/oracle"
 	clientv3 "go.etcd.io/etcd/client/v3"
@@ -72,6 +74,8 @@ type JobSubmitter struct {
 	limitJobCh chan *JobWrapper
 	// get notification if any DDL job submitted or finished.
 	ddlJobNotifyCh chan struct{}
+
+	restoreDDLGates restoreDDLGateCache
 }
 
 func (s *JobSubmitter) submitLoop() {
@@ -349,6 +353,7 @@ func (s *JobSubmitter) addBatchDDLJobs2Table(jobWs []*JobWrapper) error {
 // so this function has side effect, it will set table/db/job id of 'jobs'.
 func (s *JobSubmitter) GenGIDAndInsertJobsWithRetry(ctx context.Context, ddlSe *sess.Session, jobWs []*JobWrapper) error {
 	savedJobIDs := make([]int64, len(jobWs))
+	gated := make(map[int]gatedJobState)
 	count := getRequiredGIDCount(jobWs)
 	return genGIDAndCallWithRetry(ctx, ddlSe, count, func(ids []int64) error {
 		failpoint.Inject("mockGenGlobalIDFail", func(val failpoint.Value) {
@@ -357,6 +362,13 @@ func (s *JobSubmitter) GenGIDAndInsertJobsWithRetry(ctx context.Context, ddlSe *
 			}
 		})
 		assignGIDsForJobs(jobWs, ids)
+		txn, err := ddlSe.Txn()
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if err = s.pauseJobsGatedByRestore(txn, jobWs, gated); err != nil {
+			return errors.Trace(err)
+		}
 		// job scheduler will start run them after txn commit, we want to make sure
 		// the channel exists before the jobs are submitted.
 		for i, jobW := range jobWs {
@@ -374,6 +386,119 @@ func (s *JobSubmitter) GenGIDAndInsertJobsWithRetry(ctx context.Context, ddlSe *
 	})
 }
 
+// RestoreDDLGateCacheTTL is how long the DDL gates of the restore tasks read by a job submitter are reused,
+// measured by the start ts of the txns inserting the jobs. The restore waits for it after setting or expiring
+// a gate, so the job submitters of all the TiDB nodes see the change.
+const RestoreDDLGateCacheTTL = 2 * time.Second
+
+// restoreDDLGateCache caches the DDL gates of the restore tasks, so they aren't read by every submission.
+type restoreDDLGateCache struct {
+	mu     sync.Mutex
+	gates  []*meta.RestoreDDLGate
+	loadTS uint64
+}
+
+// get returns the gates read by a txn started within RestoreDDLGateCacheTTL before the txn, or reads them
+// by the txn.
+func (c *restoreDDLGateCache) get(txn kv.Transaction) ([]*meta.RestoreDDLGate, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	startTS := txn.StartTS()
+	if c.loadTS > 0 && oracle.GetTimeFromTS(startTS).Before(oracle.GetTimeFromTS(c.loadTS).Add(RestoreDDLGateCacheTTL)) {
+		return c.gates, nil
+	}
+	gates, err := meta.NewMutator(txn).ListRestoreDDLGates()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	c.gates, c.loadTS = gates, startTS
+	return gates, nil
+}
+
+// gatedJobState is the state of a job before it's paused by a restore DDL gate.
+type gatedJobState struct {
+	state    model.JobState
+	operator model.AdminCommandOperator
+}
+
+// pauseJobsGatedByRestore pauses the jobs covered by the unexpired DDL gates of the restore tasks, they're
+// paused by restore, so they're neither resumed by the DDL scheduler nor by the system after an upgrade.
+// The gates are cached for RestoreDDLGateCacheTTL, and the txn inserting the jobs locks the global ID key.
+// The restore waits for the cache TTL after setting a gate and then updates the global ID, so a job is either
+// paused here, or inserted before the global ID is updated and paused by the restore then.
+// gated keeps the states of the jobs paused by the previous attempt by their indexes, they're reset first
+// in case of retry.
+func (s *JobSubmitter) pauseJobsGatedByRestore(txn kv.Transaction, jobWs []*JobWrapper, gated map[int]gatedJobState) error {
+	for i, st := range gated {
+		jobWs[i].State = st.state
+		jobWs[i].AdminOperator = st.operator
+		delete(gated, i)
+	}
+	gates, err := s.restoreDDLGates.get(txn)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	now := oracle.GetTimeFromTS(txn.StartTS())
+	for _, gate := range gates {
+		if now.After(gate.ExpireTime) {
+			continue
+		}
+		f, err := ParseRestoreDDLGateFilter(gate)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		for i, jobW := range jobWs {
+			if jobW.AdminOperator == model.AdminCommandByRestore || !RestoreDDLGateCovers(f, jobW.Job) {
+				continue
+			}
+			st := gatedJobState{state: jobW.State, operator: jobW.AdminOperator}
+			if jobW.IsPausing() {
+				// paused by system during the upgrade, keep it paused by the gate after the upgrade.
+				jobW.AdminOperator = model.AdminCommandByRestore
+			} else if !jobW.IsPausable() {
+				continue
+			} else if err := pauseRunningJob(jobW.Job, model.AdminCommandByRestore); err != nil {
+				return errors.Trace(err)
+			}
+			gated[i] = st
+			logutil.DDLLogger().Info("pause the DDL job gated by restore",
+				zap.Stringer("job", jobW.Job), zap.String("task", gate.Task))
+		}
+	}
+	return nil
+}
+
+// ParseRestoreDDLGateFilter parses the table filter of the DDL gate of a restore task.
+func ParseRestoreDDLGateFilter(gate *meta.RestoreDDLGate) (filter.Filter, error) {
+	f, err := filter.Parse(gate.Filters)
+	if err != nil {
+		return nil, errors.Annotatef(err, "failed to parse the filter of the restore DDL gate %s", gate.Task)
+	}
+	return filter.CaseInsensitive(f), nil
+}
+
+// RestoreDDLGateCovers returns whether the job involves the tables matched by the filter of a restore DDL
+// gate. The jobs involving all the schemas are always covered, and those involving the system schemas never.
+func RestoreDDLGateCovers(f filter.Filter, job *model.Job) bool {
+	if hasSysDB(job) {
+		return false
+	}
+	for _, info := range job.GetInvolvingSchemaInfo() {
+		switch {
+		case info.Database == model.InvolvingAll:
+			return true
+		case info.Database == model.InvolvingNone:
+		case info.Table == model.InvolvingAll || info.Table == model.InvolvingNone:
+			if f.MatchSchema(info.Database) {
+				return true
+			}
+		case f.MatchTable(info.Database, info.Table):
+			return true
+		}
+	}
+	return false
+}
+
 type gidAllocator struct {
 	idx int
 	ids []int64
@@ -595,3 +595,54 @@ func TestSubmitJobAfterDDLIsClosed(t *testing.T) {
 	require.Error(t, ddlErr)
 	require.Equal(t, "context canceled", ddlErr.Error())
 }
+
+func TestGenGIDAndInsertJobsWithRestoreDDLGate(t *testing.T) {
+	store := testkit.CreateMockStore(t, mockstore.WithStoreType(mockstore.EmbedUnistore))
+	// disable DDL to avoid it interfere the test
+	tk := testkit.NewTestKit(t, store)
+	dom := domain.GetDomain(tk.Session())
+	dom.DDL().OwnerManager().CampaignCancel()
+	ctx := kv.WithInternalSourceType(context.Background(), kv.InternalTxnDDL)
+
+	require.NoError(t, kv.RunInNewTxn(ctx, store, true, func(_ context.Context, txn kv.Transaction) error {
+		m := meta.NewMutator(txn)
+		require.NoError(t, m.SetRestoreDDLGate(&meta.RestoreDDLGate{
+			Task: "restore-1", Filters: []string{"test.*"}, ExpireTime: time.Now().Add(time.Hour)}))
+		// the expired gate is ignored.
+		return m.SetRestoreDDLGate(&meta.RestoreDDLGate{
+			Task: "restore-2", Filters: []string{"*.*"}, ExpireTime: time.Now().Add(-time.Hour)})
+	}))
+	newJob := func(schema, table string) *ddl.JobWrapper {
+		return &ddl.JobWrapper{
+			Job: &model.Job{
+				Version:    model.GetJobVerInUse(),
+				Type:       model.ActionCreateTable,
+				SchemaName: schema,
+				TableName:  table,
+			},
+			JobArgs: &model.CreateTableArgs{TableInfo: &model.TableInfo{}},
+		}
+	}
+	jobs := []*ddl.JobWrapper{newJob("test", "t1"), newJob("other", "t1"), newJob("mysql", "t1")}
+	// the jobs paused by the failed attempt are reset.
+	testfailpoint.Enable(t, "github.com/pingcap/tidb/pkg/ddl/mockGenGIDRetryableError", `1*return(true)`)
+	submitter := ddl.NewJobSubmitterForTest()
+	require.NoError(t, submitter.GenGIDAndInsertJobsWithRetry(ctx, sess.NewSession(tk.Session()), jobs))
+
+	inserted, err := ddl.GetAllDDLJobs(ctx, tk.Session())
+	require.NoError(t, err)
+	require.Len(t, inserted, 3)
+	require.True(t, inserted[0].IsPausing())
+	require.Equal(t, model.AdminCommandByRestore, inserted[0].AdminOperator)
+	require.False(t, inserted[1].IsPausing())
+	require.False(t, inserted[2].IsPausing())
+
+	// the gates are cached by the submitter for a while.
+	require.NoError(t, kv.RunInNewTxn(ctx, store, true, func(_ context.Context, txn kv.Transaction) error {
+		return meta.NewMutator(txn).DelRestoreDDLGate("restore-1")
+	}))
+	jobs = []*ddl.JobWrapper{newJob("test", "t2")}
+	require.NoError(t, submitter.GenGIDAndInsertJobsWithRetry(ctx, sess.NewSession(tk.Session()), jobs))
+	require.True(t, jobs[0].IsPausing())
+	require.Equal(t, model.AdminCommandByRestore, jobs[0].AdminOperator)
+}
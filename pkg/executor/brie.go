@@ -28,6 +28,7 @@ import (
 	backuppb "github.com/pingcap/kvproto/pkg/brpb"
 	"github.com/pingcap/kvproto/pkg/encryptionpb"
 	"github.com/pingcap/log"
+	"github.com/pingcap/tidb/br/pkg/encryption"
 	"github.com/pingcap/tidb/br/pkg/glue"
 	"github.com/pingcap/tidb/br/pkg/storage"
 	"github.com/pingcap/tidb/br/pkg/task"
@@ -53,6 +54,7 @@ import (
 	"github.com/pingcap/tidb/pkg/util/sem"
 	"github.com/pingcap/tidb/pkg/util/syncutil"
 	filter "github.com/pingcap/tidb/pkg/util/table-filter"
+	tikvconfig "github.com/tikv/client-go/v2/config"
 	"github.com/tikv/client-go/v2/oracle"
 	pd "github.com/tikv/pd/client"
 	"go.uber.org/zap"
@@ -345,7 +347,7 @@ func (b *executorBuilder) buildBRIE(s *ast.BRIEStmt, schema *expression.Schema)
 		case ast.BRIEOptionChecksumConcurrency:
 			cfg.ChecksumConcurrency = uint(opt.UintValue)
 		case ast.BRIEOptionEncryptionKeyFile:
-			cfg.CipherInfo.CipherKey, err = task.GetCipherKeyContent("", opt.StrValue)
+			cfg.CipherInfo.CipherKey, err = encryption.GetCipherKeyContent("", opt.StrValue)
 			if err != nil {
 				b.err = err
 				return nil
@@ -448,6 +450,8 @@ func (b *executorBuilder) buildBRIE(s *ast.BRIEStmt, schema *expression.Schema)
 				e.restoreCfg.WithSysTable = opt.UintValue != 0
 			case ast.BRIEOptionLoadStats:
 				e.restoreCfg.LoadStats = opt.UintValue != 0
+			case ast.BRIEOptionAllowCrossCluster:
+				e.restoreCfg.AllowCrossCluster = opt.UintValue != 0
 			}
 		}
 
@@ -708,7 +712,7 @@ func (gs *tidbGlue) CreateSession(_ kv.Storage) (glue.Session, error) {
 }
 
 // Open implements glue.Glue
-func (gs *tidbGlue) Open(string, pd.SecurityOption) (kv.Storage, error) {
+func (gs *tidbGlue) Open(string, pd.SecurityOption, tikvconfig.Security) (kv.Storage, error) {
 	return gs.se.GetStore(), nil
 }
 
@@ -199,7 +199,7 @@ func TestBRIEBuilderOptions(t *testing.T) {
 	require.Equal(t, encryptionpb.EncryptionMethod_AES256_CTR, e.backupCfg.CipherInfo.CipherType)
 	require.Greater(t, len(e.backupCfg.CipherInfo.CipherKey), 0)
 
-	stmt, err = p.ParseOneStmt("RESTORE TABLE `a` FROM 'noop://' CHECKSUM_CONCURRENCY = 4 WAIT_TIFLASH_READY = 1 WITH_SYS_TABLE = 1 LOAD_STATS = 1", "", "")
+	stmt, err = p.ParseOneStmt("RESTORE TABLE `a` FROM 'noop://' CHECKSUM_CONCURRENCY = 4 WAIT_TIFLASH_READY = 1 WITH_SYS_TABLE = 1 LOAD_STATS = 1 ALLOW_CROSS_CLUSTER = 1", "", "")
 	require.NoError(t, err)
 	nodeW = resolve.NewNodeW(stmt)
 	plan, err = core.BuildLogicalPlanForTest(ctx, sctx, nodeW, infoschema.MockInfoSchema([]*model.TableInfo{core.MockSignedTable(), core.MockUnsignedTable(), core.MockView()}))
@@ -217,6 +217,8 @@ func TestBRIEBuilderOptions(t *testing.T) {
 			require.Equal(t, uint64(1), opt.UintValue)
 		case ast.BRIEOptionLoadStats:
 			require.Equal(t, uint64(1), opt.UintValue)
+		case ast.BRIEOptionAllowCrossCluster:
+			require.Equal(t, uint64(1), opt.UintValue)
 		}
 	}
 	schema = plan.Schema()
@@ -229,4 +231,5 @@ func TestBRIEBuilderOptions(t *testing.T) {
 	require.True(t, e.restoreCfg.WaitTiflashReady)
 	require.True(t, e.restoreCfg.WithSysTable)
 	require.True(t, e.restoreCfg.LoadStats)
+	require.True(t, e.restoreCfg.AllowCrossCluster)
 }
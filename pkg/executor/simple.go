@@ -36,6 +36,7 @@ import (
 	"github.com/pingcap/tidb/pkg/executor/internal/exec"
 	"github.com/pingcap/tidb/pkg/executor/internal/querywatch"
 	executor_metrics "github.com/pingcap/tidb/pkg/executor/metrics"
+	"github.com/pingcap/tidb/pkg/executor/showcreate"
 	"github.com/pingcap/tidb/pkg/expression"
 	"github.com/pingcap/tidb/pkg/extension"
 	"github.com/pingcap/tidb/pkg/infoschema"
@@ -602,7 +603,7 @@ func (e *SimpleExec) executeUse(s *ast.UseStmt) error {
 	sessionVars := e.Ctx().GetSessionVars()
 	dbCollate := dbinfo.Collate
 	if dbCollate == "" {
-		dbCollate = getDefaultCollate(dbinfo.Charset)
+		dbCollate = showcreate.DefaultCollate(dbinfo.Charset)
 	}
 	// If new collations are enabled, switch to the default
 	// collation if this one is not supported.
@@ -649,6 +649,13 @@ func OpenCheckpointsDB(ctx context.Context, cfg *config.Config) (DB, error) {
 		}
 		return cpdb, nil
 
+	case config.CheckpointDriverStorage:
+		cpdb, err := NewStorageCheckpointsDB(ctx, cfg.Checkpoint.DSN)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		return cpdb, nil
+
 	default:
 		return nil, common.ErrUnknownCheckpointDriver.GenWithStackByArgs(cfg.Checkpoint.Driver)
 	}
@@ -688,7 +695,7 @@ func IsCheckpointsDBExists(ctx context.Context, cfg *config.Config) (bool, error
 		}
 		return result, nil
 
-	case config.CheckpointDriverFile:
+	case config.CheckpointDriverFile, config.CheckpointDriverStorage:
 		s, fileName, err := createExstorageByCompletePath(ctx, cfg.Checkpoint.DSN)
 		if err != nil {
 			return false, errors.Trace(err)
@@ -1123,6 +1130,10 @@ type FileCheckpointsDB struct {
 	path        string
 	fileName    string
 	exStorage   storage.ExternalStorage
+	// conditional is set for the "storage" driver, the file is written only if
+	// its version is still the one read or written by this instance.
+	conditional storage.ConditionalWriter
+	version     string
 }
 
 func newFileCheckpointsDB(
@@ -1130,38 +1141,54 @@ func newFileCheckpointsDB(
 	path string,
 	exStorage storage.ExternalStorage,
 	fileName string,
+	conditional storage.ConditionalWriter,
 ) (*FileCheckpointsDB, error) {
 	cpdb := &FileCheckpointsDB{
 		checkpoints: checkpointspb.CheckpointsModel{
 			TaskCheckpoint: &checkpointspb.TaskCheckpointModel{},
 			Checkpoints:    map[string]*checkpointspb.TableCheckpointModel{},
 		},
-		ctx:       ctx,
-		path:      path,
-		fileName:  fileName,
-		exStorage: exStorage,
+		ctx:         ctx,
+		path:        path,
+		fileName:    fileName,
+		exStorage:   exStorage,
+		conditional: conditional,
 	}
 
 	if cpdb.fileName == "" {
 		return nil, errors.Errorf("the checkpoint DSN '%s' must not be a directory", path)
 	}
 
-	exist, err := cpdb.exStorage.FileExists(ctx, cpdb.fileName)
-	if err != nil {
-		return nil, errors.Trace(err)
-	}
-	if !exist {
-		log.FromContext(ctx).Info("open checkpoint file failed, going to create a new one",
-			zap.String("path", path),
-			log.ShortError(err),
-		)
-		return cpdb, nil
-	}
-	content, err := cpdb.exStorage.ReadFile(ctx, cpdb.fileName)
-	if err != nil {
-		return nil, errors.Trace(err)
+	var content []byte
+	if cpdb.conditional != nil {
+		var err error
+		content, cpdb.version, err = cpdb.conditional.ReadFileWithVersion(ctx, cpdb.fileName)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if len(cpdb.version) == 0 {
+			log.FromContext(ctx).Info("checkpoint file not found, going to create a new one",
+				zap.String("path", path))
+			return cpdb, nil
+		}
+	} else {
+		exist, err := cpdb.exStorage.FileExists(ctx, cpdb.fileName)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if !exist {
+			log.FromContext(ctx).Info("open checkpoint file failed, going to create a new one",
+				zap.String("path", path),
+				log.ShortError(err),
+			)
+			return cpdb, nil
+		}
+		content, err = cpdb.exStorage.ReadFile(ctx, cpdb.fileName)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
 	}
-	err = cpdb.checkpoints.Unmarshal(content)
+	err := cpdb.checkpoints.Unmarshal(content)
 	if err != nil {
 		log.FromContext(ctx).Error("checkpoint file is broken", zap.String("path", path), zap.Error(err))
 	}
@@ -1190,7 +1217,29 @@ func NewFileCheckpointsDB(ctx context.Context, path string) (*FileCheckpointsDB,
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
-	return newFileCheckpointsDB(ctx, path, s, fileName)
+	return newFileCheckpointsDB(ctx, path, s, fileName, nil)
+}
+
+// NewStorageCheckpointsDB creates a new FileCheckpointsDB on the external
+// storage supporting conditional writes. the checkpoints are saved only if the
+// file isn't changed by others since it's read, so an instance preempted and
+// replaced by another one fails instead of overwriting the newer checkpoints.
+func NewStorageCheckpointsDB(ctx context.Context, path string) (*FileCheckpointsDB, error) {
+	s, fileName, err := createExstorageByCompletePath(ctx, path)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if s == nil {
+		return nil, common.ErrInvalidConfig.GenWithStack(
+			"`checkpoint.dsn` must be set when `checkpoint.driver` is \"storage\"")
+	}
+	conditional, ok := s.(storage.ConditionalWriter)
+	if !ok {
+		s.Close()
+		return nil, common.ErrInvalidConfig.GenWithStack(
+			"the storage of the checkpoint DSN '%s' doesn't support conditional writes", path)
+	}
+	return newFileCheckpointsDB(ctx, path, s, fileName, conditional)
 }
 
 // NewFileCheckpointsDBWithExstorageFileName creates a new FileCheckpointsDB with external storage and file name
@@ -1200,7 +1249,7 @@ func NewFileCheckpointsDBWithExstorageFileName(
 	s storage.ExternalStorage,
 	fileName string,
 ) (*FileCheckpointsDB, error) {
-	return newFileCheckpointsDB(ctx, path, s, fileName)
+	return newFileCheckpointsDB(ctx, path, s, fileName, nil)
 }
 
 // createExstorageByCompletePath create ExternalStorage by completePath and return fileName.
@@ -1256,6 +1305,14 @@ func (cpdb *FileCheckpointsDB) save() error {
 	if err != nil {
 		return errors.Trace(err)
 	}
+	if cpdb.conditional != nil {
+		version, err := cpdb.conditional.WriteFileIfMatch(cpdb.ctx, cpdb.fileName, serialized, cpdb.version)
+		if err != nil {
+			return errors.Annotatef(err, "failed to save checkpoint '%s', it may be taken over by another instance", cpdb.path)
+		}
+		cpdb.version = version
+		return nil
+	}
 	return cpdb.exStorage.WriteFile(cpdb.ctx, cpdb.fileName, serialized)
 }
 
@@ -1862,6 +1919,7 @@ func (cpdb *FileCheckpointsDB) RemoveCheckpoint(_ context.Context, tableName str
 
 	if tableName == allTables {
 		cpdb.checkpoints.Reset()
+		cpdb.version = ""
 		return errors.Trace(cpdb.exStorage.DeleteFile(cpdb.ctx, cpdb.fileName))
 	}
 
@@ -1875,6 +1933,7 @@ func (cpdb *FileCheckpointsDB) MoveCheckpoints(_ context.Context, taskID int64)
 	defer cpdb.lock.Unlock()
 
 	newFileName := fmt.Sprintf("%s.%d.bak", cpdb.fileName, taskID)
+	cpdb.version = ""
 	return cpdb.exStorage.Rename(cpdb.ctx, cpdb.fileName, newFileName)
 }
 
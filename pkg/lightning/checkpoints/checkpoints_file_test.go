@@ -383,3 +383,45 @@ func TestDestroyOneErrorCheckpoint(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, checkpoints.CheckpointStatusAllWritten/10, cp.Status)
 }
+
+func TestStorageCheckpointsDB(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "cp.pb")
+	cfg := newTestConfig()
+	dbInfo := map[string]*checkpoints.TidbDBInfo{
+		"db1": {
+			Name:   "db1",
+			Tables: map[string]*checkpoints.TidbTableInfo{"t1": {Name: "t1"}},
+		},
+	}
+
+	cpdb1, err := checkpoints.NewStorageCheckpointsDB(ctx, path)
+	require.NoError(t, err)
+	cpdb2, err := checkpoints.NewStorageCheckpointsDB(ctx, path)
+	require.NoError(t, err)
+	require.NoError(t, cpdb1.Initialize(ctx, cfg, dbInfo))
+	// the file is created by cpdb1 after cpdb2 read it.
+	require.ErrorContains(t, cpdb2.Initialize(ctx, cfg, dbInfo), "it may be taken over by another instance")
+
+	// cpdb3 takes over the task after cpdb1 is preempted.
+	cpdb3, err := checkpoints.NewStorageCheckpointsDB(ctx, path)
+	require.NoError(t, err)
+	cp, err := cpdb3.Get(ctx, "`db1`.`t1`")
+	require.NoError(t, err)
+	require.Equal(t, checkpoints.CheckpointStatusLoaded, cp.Status)
+	cpd := checkpoints.NewTableCheckpointDiff()
+	scm := checkpoints.StatusCheckpointMerger{EngineID: checkpoints.WholeTableEngineID, Status: checkpoints.CheckpointStatusAllWritten}
+	scm.MergeInto(cpd)
+	require.NoError(t, cpdb3.Update(ctx, map[string]*checkpoints.TableCheckpointDiff{"`db1`.`t1`": cpd}))
+	require.ErrorContains(t, cpdb1.Update(ctx, map[string]*checkpoints.TableCheckpointDiff{"`db1`.`t1`": cpd}),
+		"it may be taken over by another instance")
+
+	cp, err = cpdb3.Get(ctx, "`db1`.`t1`")
+	require.NoError(t, err)
+	require.Equal(t, checkpoints.CheckpointStatusAllWritten, cp.Status)
+	require.NoError(t, cpdb3.RemoveCheckpoint(ctx, "all"))
+	require.NoError(t, cpdb3.Close())
+
+	_, err = checkpoints.NewStorageCheckpointsDB(ctx, "")
+	require.ErrorContains(t, err, "`checkpoint.dsn` must be set")
+}
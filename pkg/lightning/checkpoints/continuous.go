@@ -0,0 +1,262 @@
+// Copyright 2026 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package checkpoints
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"strings"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/tidb/pkg/lightning/common"
+	"github.com/pingcap/tidb/pkg/lightning/log"
+	"go.uber.org/zap"
+)
+
+// CheckpointTableNameContinuousFile is the name of the table recording the files
+// imported in the continuous mode.
+const CheckpointTableNameContinuousFile = "continuous_file_v1"
+
+// the status of the files recorded in the continuous mode.
+const (
+	continuousFilePending  = 0
+	continuousFileImported = 1
+)
+
+// continuousFileQueryBatchSize is the number of the files looked up in a query.
+const continuousFileQueryBatchSize = 256
+
+// some SQL statement templates used by ContinuousFilesDB.
+const (
+	CreateContinuousFileTableTemplate = `
+		CREATE TABLE IF NOT EXISTS %s.%s (
+			path_hash binary(32) NOT NULL PRIMARY KEY,
+			path varchar(2048) NOT NULL,
+			file_size bigint NOT NULL,
+			batch_id bigint NOT NULL,
+			status tinyint unsigned NOT NULL,
+			create_time timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			update_time timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+			INDEX(batch_id),
+			INDEX(status)
+		);`
+	ReadPendingContinuousFilesTemplate = `
+		SELECT batch_id, path, file_size FROM %s.%s WHERE status = ? ORDER BY batch_id, path;`
+	ReadMaxContinuousBatchTemplate = `
+		SELECT COALESCE(MAX(batch_id), 0) FROM %s.%s;`
+	InsertContinuousFileTemplate = `
+		INSERT IGNORE INTO %s.%s (path_hash, path, file_size, batch_id, status) VALUES (?, ?, ?, ?, ?);`
+	UpdateContinuousBatchTemplate = `
+		UPDATE %s.%s SET status = ? WHERE batch_id = ?;`
+	DeleteAllCheckpointRecordsTemplate = "DELETE FROM %s.%s;"
+)
+
+// ContinuousFile is a file of the data source discovered in the continuous mode.
+// the files are identified by the paths, so a file rewritten after it's
+// imported isn't imported again.
+type ContinuousFile struct {
+	Path string
+	Size int64
+}
+
+// ContinuousBatch is a batch of the files imported by one run of lightning.
+type ContinuousBatch struct {
+	ID    int64
+	Files []ContinuousFile
+}
+
+// ContinuousFilesDB records the files imported in the continuous mode, it's in
+// the checkpoint database so that the record of a batch is finished with the
+// checkpoints of the batch cleared in one transaction, which makes sure every
+// file is imported exactly once.
+type ContinuousFilesDB struct {
+	db               *sql.DB
+	schema           string
+	checkpointSchema string
+}
+
+// NewContinuousFilesDB creates a new ContinuousFilesDB. the checkpoints in the
+// checkpointSchema are cleared when a batch is finished.
+func NewContinuousFilesDB(ctx context.Context, db *sql.DB, schema, checkpointSchema string) (*ContinuousFilesDB, error) {
+	s := common.SQLWithRetry{
+		DB:           db,
+		Logger:       log.FromContext(ctx).With(zap.String("schema", schema)),
+		HideQueryLog: true,
+	}
+	err := s.Exec(ctx, "create continuous files database", common.SprintfWithIdentifiers(CreateDBTemplate, schema))
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	err = s.Exec(ctx, "create continuous files table",
+		common.SprintfWithIdentifiers(CreateContinuousFileTableTemplate, schema, CheckpointTableNameContinuousFile))
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return &ContinuousFilesDB{
+		db:               db,
+		schema:           schema,
+		checkpointSchema: checkpointSchema,
+	}, nil
+}
+
+func continuousFileHash(path string) []byte {
+	hash := sha256.Sum256([]byte(path))
+	return hash[:]
+}
+
+// PendingBatch returns the batch which is created but not finished, it's nil if
+// there is no such batch.
+func (cfdb *ContinuousFilesDB) PendingBatch(ctx context.Context) (*ContinuousBatch, error) {
+	var batch *ContinuousBatch
+	s := common.SQLWithRetry{DB: cfdb.db, Logger: log.FromContext(ctx)}
+	err := s.Transact(ctx, "read pending continuous batch", func(c context.Context, tx *sql.Tx) error {
+		batch = nil
+		query := common.SprintfWithIdentifiers(ReadPendingContinuousFilesTemplate, cfdb.schema, CheckpointTableNameContinuousFile)
+		rows, err := tx.QueryContext(c, query, continuousFilePending)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		//nolint: errcheck
+		defer rows.Close()
+		for rows.Next() {
+			var (
+				batchID int64
+				file    ContinuousFile
+			)
+			if err := rows.Scan(&batchID, &file.Path, &file.Size); err != nil {
+				return errors.Trace(err)
+			}
+			if batch == nil {
+				batch = &ContinuousBatch{ID: batchID}
+			}
+			// there is at most one pending batch, the later ones are picked up
+			// after the first one is finished anyway.
+			if batchID == batch.ID {
+				batch.Files = append(batch.Files, file)
+			}
+		}
+		return errors.Trace(rows.Err())
+	})
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return batch, nil
+}
+
+// NewFiles returns the files which aren't recorded, the order is kept.
+func (cfdb *ContinuousFilesDB) NewFiles(ctx context.Context, files []ContinuousFile) ([]ContinuousFile, error) {
+	recorded := make(map[string]struct{})
+	s := common.SQLWithRetry{DB: cfdb.db, Logger: log.FromContext(ctx), HideQueryLog: true}
+	for start := 0; start < len(files); start += continuousFileQueryBatchSize {
+		batch := files[start:min(start+continuousFileQueryBatchSize, len(files))]
+		query := common.SprintfWithIdentifiers("SELECT path FROM %s.%s WHERE path_hash IN (",
+			cfdb.schema, CheckpointTableNameContinuousFile) +
+			strings.Repeat("?, ", len(batch)-1) + "?);"
+		args := make([]any, 0, len(batch))
+		for _, f := range batch {
+			args = append(args, continuousFileHash(f.Path))
+		}
+		err := s.Transact(ctx, "read recorded continuous files", func(c context.Context, tx *sql.Tx) error {
+			rows, err := tx.QueryContext(c, query, args...)
+			if err != nil {
+				return errors.Trace(err)
+			}
+			//nolint: errcheck
+			defer rows.Close()
+			for rows.Next() {
+				var path string
+				if err := rows.Scan(&path); err != nil {
+					return errors.Trace(err)
+				}
+				recorded[path] = struct{}{}
+			}
+			return errors.Trace(rows.Err())
+		})
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+
+	newFiles := make([]ContinuousFile, 0, len(files)-len(recorded))
+	for _, f := range files {
+		if _, ok := recorded[f.Path]; !ok {
+			newFiles = append(newFiles, f)
+		}
+	}
+	return newFiles, nil
+}
+
+// CreateBatch records the files as a new pending batch. the files recorded
+// before are ignored, and it returns nil if all the files are recorded.
+func (cfdb *ContinuousFilesDB) CreateBatch(ctx context.Context, files []ContinuousFile) (*ContinuousBatch, error) {
+	var batch *ContinuousBatch
+	s := common.SQLWithRetry{DB: cfdb.db, Logger: log.FromContext(ctx).With(zap.Int("files", len(files)))}
+	err := s.Transact(ctx, "create continuous batch", func(c context.Context, tx *sql.Tx) error {
+		batch = nil
+		var maxBatchID int64
+		query := common.SprintfWithIdentifiers(ReadMaxContinuousBatchTemplate, cfdb.schema, CheckpointTableNameContinuousFile)
+		if err := tx.QueryRowContext(c, query).Scan(&maxBatchID); err != nil {
+			return errors.Trace(err)
+		}
+		stmt, err := tx.PrepareContext(c,
+			common.SprintfWithIdentifiers(InsertContinuousFileTemplate, cfdb.schema, CheckpointTableNameContinuousFile))
+		if err != nil {
+			return errors.Trace(err)
+		}
+		//nolint: errcheck
+		defer stmt.Close()
+
+		newBatch := &ContinuousBatch{ID: maxBatchID + 1}
+		for _, f := range files {
+			res, err := stmt.ExecContext(c, continuousFileHash(f.Path), f.Path, f.Size, newBatch.ID, continuousFilePending)
+			if err != nil {
+				return errors.Trace(err)
+			}
+			if n, err := res.RowsAffected(); err != nil {
+				return errors.Trace(err)
+			} else if n > 0 {
+				newBatch.Files = append(newBatch.Files, f)
+			}
+		}
+		if len(newBatch.Files) > 0 {
+			batch = newBatch
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return batch, nil
+}
+
+// FinishBatch marks the files of the batch imported, and clears the checkpoints
+// of the batch in the same transaction.
+func (cfdb *ContinuousFilesDB) FinishBatch(ctx context.Context, batchID int64) error {
+	s := common.SQLWithRetry{DB: cfdb.db, Logger: log.FromContext(ctx).With(zap.Int64("batchID", batchID))}
+	return s.Transact(ctx, "finish continuous batch", func(c context.Context, tx *sql.Tx) error {
+		query := common.SprintfWithIdentifiers(UpdateContinuousBatchTemplate, cfdb.schema, CheckpointTableNameContinuousFile)
+		if _, err := tx.ExecContext(c, query, continuousFileImported, batchID); err != nil {
+			return errors.Trace(err)
+		}
+		for _, tbl := range []string{CheckpointTableNameChunk, CheckpointTableNameEngine, CheckpointTableNameTable} {
+			query := common.SprintfWithIdentifiers(DeleteAllCheckpointRecordsTemplate, cfdb.checkpointSchema, tbl)
+			if _, err := tx.ExecContext(c, query); err != nil {
+				return errors.Trace(err)
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,107 @@
+// Copyright 2026 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package checkpoints_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/pingcap/tidb/pkg/lightning/checkpoints"
+	"github.com/stretchr/testify/require"
+)
+
+func newContinuousFilesDB(t *testing.T) (*checkpoints.ContinuousFilesDB, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		mock.ExpectClose()
+		require.NoError(t, db.Close())
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+	mock.ExpectExec("CREATE DATABASE IF NOT EXISTS `mock-files`").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS `mock-files`\\.`continuous_file_v\\d+` .+").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	cfdb, err := checkpoints.NewContinuousFilesDB(context.Background(), db, "mock-files", "mock-schema")
+	require.NoError(t, err)
+	return cfdb, mock
+}
+
+func pathHash(path string) []byte {
+	hash := sha256.Sum256([]byte(path))
+	return hash[:]
+}
+
+func TestContinuousFilesDB(t *testing.T) {
+	ctx := context.Background()
+	cfdb, mock := newContinuousFilesDB(t)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT batch_id, path, file_size FROM `mock-files`\\.`continuous_file_v1` WHERE status = \\?").
+		WithArgs(0).
+		WillReturnRows(sqlmock.NewRows([]string{"batch_id", "path", "file_size"}))
+	mock.ExpectCommit()
+	batch, err := cfdb.PendingBatch(ctx)
+	require.NoError(t, err)
+	require.Nil(t, batch)
+
+	files := []checkpoints.ContinuousFile{{Path: "db.t.1.csv", Size: 10}, {Path: "db.t.2.csv", Size: 20}}
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT path FROM `mock-files`\\.`continuous_file_v1` WHERE path_hash IN \\(\\?, \\?\\)").
+		WithArgs(pathHash("db.t.1.csv"), pathHash("db.t.2.csv")).
+		WillReturnRows(sqlmock.NewRows([]string{"path"}).AddRow("db.t.1.csv"))
+	mock.ExpectCommit()
+	newFiles, err := cfdb.NewFiles(ctx, files)
+	require.NoError(t, err)
+	require.Equal(t, files[1:], newFiles)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT COALESCE\\(MAX\\(batch_id\\), 0\\) FROM `mock-files`\\.`continuous_file_v1`").
+		WillReturnRows(sqlmock.NewRows([]string{"max"}).AddRow(3))
+	insert := mock.ExpectPrepare("INSERT IGNORE INTO `mock-files`\\.`continuous_file_v1`")
+	insert.ExpectExec().
+		WithArgs(pathHash("db.t.1.csv"), "db.t.1.csv", 10, 4, 0).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	insert.ExpectExec().
+		WithArgs(pathHash("db.t.2.csv"), "db.t.2.csv", 20, 4, 0).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	batch, err = cfdb.CreateBatch(ctx, files)
+	require.NoError(t, err)
+	require.Equal(t, &checkpoints.ContinuousBatch{ID: 4, Files: files[1:]}, batch)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT batch_id, path, file_size FROM `mock-files`\\.`continuous_file_v1` WHERE status = \\?").
+		WithArgs(0).
+		WillReturnRows(sqlmock.NewRows([]string{"batch_id", "path", "file_size"}).
+			AddRow(4, "db.t.2.csv", 20).
+			AddRow(5, "db.t.3.csv", 30))
+	mock.ExpectCommit()
+	batch, err = cfdb.PendingBatch(ctx)
+	require.NoError(t, err)
+	require.Equal(t, &checkpoints.ContinuousBatch{ID: 4, Files: files[1:]}, batch)
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE `mock-files`\\.`continuous_file_v1` SET status = \\? WHERE batch_id = \\?").
+		WithArgs(1, 4).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("DELETE FROM `mock-schema`\\.`chunk_v\\d+`").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("DELETE FROM `mock-schema`\\.`engine_v\\d+`").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("DELETE FROM `mock-schema`\\.`table_v\\d+`").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	require.NoError(t, cfdb.FinishBatch(ctx, 4))
+}
@@ -62,6 +62,9 @@ const (
 	CheckpointDriverMySQL = "mysql"
 	// CheckpointDriverFile is a constant for choosing the "File" checkpoint driver in the configuration.
 	CheckpointDriverFile = "file"
+	// CheckpointDriverStorage is a constant for choosing the "Storage" checkpoint driver in the configuration.
+	// the checkpoints are stored as a file on the external storage supporting conditional writes, such as S3 or GCS.
+	CheckpointDriverStorage = "storage"
 
 	// KVWriteBatchSize batch size when write to TiKV.
 	// this is the default value of linux send buffer size(net.ipv4.tcp_wmem) too.
@@ -101,6 +104,13 @@ const (
 	defaultCSVDataCharacterSet       = "binary"
 	defaultCSVDataInvalidCharReplace = utf8.RuneError
 
+	// DefaultAvroDecodeConcurrency is the default number of the blocks of an Avro file decoded at the same time.
+	DefaultAvroDecodeConcurrency = 4
+
+	defaultContinuousSchemaName    = "tidb_lightning_continuous"
+	defaultContinuousPollInterval  = time.Minute
+	defaultContinuousBatchMaxFiles = 1000
+
 	DefaultSwitchTiKVModeInterval = 5 * time.Minute
 )
 
@@ -288,6 +298,7 @@ type Config struct {
 	Routes       Routes          `toml:"routes" json:"routes"`
 	Security     Security        `toml:"security" json:"security"`
 	Conflict     Conflict        `toml:"conflict" json:"conflict"`
+	Continuous   Continuous      `toml:"continuous" json:"continuous"`
 }
 
 // String implements fmt.Stringer interface.
@@ -860,6 +871,42 @@ func (csv *CSVConfig) adjust() error {
 	return nil
 }
 
+// AvroFieldMapping maps a field of the Avro records to a column of the table.
+type AvroFieldMapping struct {
+	// Field is the dot separated path of the field, e.g. `after.id`. The last element can be `*` to map
+	// all the fields of the record to the columns of the same names, e.g. `after.*`.
+	Field string `toml:"field" json:"field"`
+	// Column is the name of the column, it's the name of the field if empty. It must be empty if the
+	// field ends with `*`.
+	Column string `toml:"column" json:"column"`
+}
+
+// AvroConfig is the config for Avro files.
+type AvroConfig struct {
+	// FieldMappings selects the fields of the Avro records imported as the columns. The top level fields
+	// are imported as the columns of the same names if it's empty. The nested records, arrays and maps
+	// not mapped are imported as JSON text.
+	FieldMappings []*AvroFieldMapping `toml:"field-mapping" json:"field-mapping"`
+	// DecodeConcurrency is the number of the blocks of a file decoded at the same time.
+	DecodeConcurrency int `toml:"decode-concurrency" json:"decode-concurrency"`
+}
+
+func (avro *AvroConfig) adjust() error {
+	if avro.DecodeConcurrency <= 0 {
+		avro.DecodeConcurrency = DefaultAvroDecodeConcurrency
+	}
+	for _, m := range avro.FieldMappings {
+		if m.Field == "" || strings.HasPrefix(m.Field, ".") || strings.HasSuffix(m.Field, ".") {
+			return common.ErrInvalidConfig.GenWithStack("invalid field %q of `mydumper.avro.field-mapping`", m.Field)
+		}
+		if (m.Field == "*" || strings.HasSuffix(m.Field, ".*")) && m.Column != "" {
+			return common.ErrInvalidConfig.GenWithStack(
+				"the column of the field %q of `mydumper.avro.field-mapping` must be empty", m.Field)
+		}
+	}
+	return nil
+}
+
 // MydumperRuntime is the runtime config for mydumper.
 type MydumperRuntime struct {
 	ReadBlockSize    ByteSize         `toml:"read-block-size" json:"read-block-size"`
@@ -869,6 +916,7 @@ type MydumperRuntime struct {
 	SourceDir        string           `toml:"data-source-dir" json:"data-source-dir"`
 	CharacterSet     string           `toml:"character-set" json:"character-set"`
 	CSV              CSVConfig        `toml:"csv" json:"csv"`
+	Avro             AvroConfig       `toml:"avro" json:"avro"`
 	MaxRegionSize    ByteSize         `toml:"max-region-size" json:"max-region-size"`
 	Filter           []string         `toml:"filter" json:"filter"`
 	FileRouters      []*FileRouteRule `toml:"files" json:"files"`
@@ -895,6 +943,9 @@ func (m *MydumperRuntime) adjust() error {
 	if err := m.CSV.adjust(); err != nil {
 		return err
 	}
+	if err := m.Avro.adjust(); err != nil {
+		return err
+	}
 	if m.StrictFormat && len(m.CSV.Terminator) == 0 {
 		return common.ErrInvalidConfig.GenWithStack(
 			`mydumper.strict-format can not be used with empty mydumper.csv.terminator. Please set mydumper.csv.terminator to a non-empty value like "\r\n"`)
@@ -1357,14 +1408,76 @@ func ParseCharset(dataCharacterSet string) (Charset, error) {
 	}
 }
 
+// Continuous is the config of the continuous mode, in which lightning watches the
+// data source and imports the newly-arrived files in micro batches.
+type Continuous struct {
+	Enable bool `toml:"enable" json:"enable"`
+	// Schema is the schema of the table recording the imported files, it's in
+	// the checkpoint database.
+	Schema string `toml:"schema" json:"schema"`
+	// PollInterval is the interval to discover the newly-arrived files.
+	PollInterval Duration `toml:"poll-interval" json:"poll-interval"`
+	// BatchMaxFiles is the maximum number of the files imported in a batch.
+	BatchMaxFiles int `toml:"batch-max-files" json:"batch-max-files"`
+	// SQSQueueURL is the URL of the SQS queue receiving the S3 event notifications
+	// of the data source. the data source is listed to discover the files if
+	// it's empty.
+	SQSQueueURL string `toml:"sqs-queue-url" json:"sqs-queue-url"`
+}
+
+// adjust assigns default values and check illegal values. The arguments must be
+// adjusted before calling this function.
+func (c *Continuous) adjust(cp *Checkpoint, i *TikvImporter) error {
+	if !c.Enable {
+		return nil
+	}
+	if !cp.Enable || cp.Driver != CheckpointDriverMySQL {
+		return common.ErrInvalidConfig.GenWithStack(
+			"continuous mode records the imported files in the checkpoint database, " +
+				"`checkpoint.enable` must be true and `checkpoint.driver` must be \"mysql\"")
+	}
+	if i.Backend == BackendLocal && !i.ParallelImport {
+		return common.ErrInvalidConfig.GenWithStack(
+			"continuous mode imports into non-empty tables, `tikv-importer.parallel-import` must be true " +
+				"when use tikv-importer.backend = \"local\"")
+	}
+	if len(c.Schema) == 0 {
+		c.Schema = defaultContinuousSchemaName
+	}
+	if c.Schema == cp.Schema {
+		return common.ErrInvalidConfig.GenWithStack(
+			"`continuous.schema` must be different from `checkpoint.schema` (%s)", cp.Schema)
+	}
+	if c.PollInterval.Duration <= 0 {
+		return common.ErrInvalidConfig.GenWithStack("`continuous.poll-interval` must be positive")
+	}
+	if c.BatchMaxFiles <= 0 {
+		return common.ErrInvalidConfig.GenWithStack("`continuous.batch-max-files` must be positive")
+	}
+	// the checkpoints of a batch are cleared together with the record of its
+	// files in one transaction, so they must be kept after success.
+	cp.KeepAfterSuccess = CheckpointOrigin
+	return nil
+}
+
 // Conflict is the config section for PK/UK conflict related configurations.
 type Conflict struct {
 	Strategy                     DuplicateResolutionAlgorithm `toml:"strategy" json:"strategy"`
 	PrecheckConflictBeforeImport bool                         `toml:"precheck-conflict-before-import" json:"precheck-conflict-before-import"`
 	Threshold                    int64                        `toml:"threshold" json:"threshold"`
 	MaxRecordRows                int64                        `toml:"max-record-rows" json:"max-record-rows"`
+	// ReportDir is the URL of the external storage where the conflict report is
+	// exported to after the import, the report isn't exported if it's empty.
+	ReportDir    string `toml:"report-dir" json:"report-dir"`
+	ReportFormat string `toml:"report-format" json:"report-format"`
 }
 
+// the formats of the conflict report.
+const (
+	ConflictReportFormatJSON = "json"
+	ConflictReportFormatCSV  = "csv"
+)
+
 // adjust assigns default values and check illegal values. The arguments must be
 // adjusted before calling this function.
 func (c *Conflict) adjust(i *TikvImporter) error {
@@ -1432,6 +1545,21 @@ func (c *Conflict) adjust(i *TikvImporter) error {
 		}
 		c.MaxRecordRows = c.Threshold
 	}
+
+	if len(c.ReportDir) > 0 {
+		if c.Strategy == NoneOnDup {
+			return common.ErrInvalidConfig.GenWithStack(
+				`conflict.report-dir cannot be set when conflict.strategy is ""`)
+		}
+		switch c.ReportFormat {
+		case "":
+			c.ReportFormat = ConflictReportFormatJSON
+		case ConflictReportFormatJSON, ConflictReportFormatCSV:
+		default:
+			return common.ErrInvalidConfig.GenWithStack(
+				"unsupported `conflict.report-format` (%s)", c.ReportFormat)
+		}
+	}
 	return nil
 }
 
@@ -1478,6 +1606,9 @@ func NewConfig() *Config {
 				EscapedBy:         `\`,
 				TrimLastSep:       false,
 			},
+			Avro: AvroConfig{
+				DecodeConcurrency: DefaultAvroDecodeConcurrency,
+			},
 			StrictFormat:           false,
 			MaxRegionSize:          MaxRegionSize,
 			Filter:                 GetDefaultFilter(),
@@ -1513,6 +1644,11 @@ func NewConfig() *Config {
 			Threshold:                    -1,
 			MaxRecordRows:                -1,
 		},
+		Continuous: Continuous{
+			Schema:        defaultContinuousSchemaName,
+			PollInterval:  Duration{Duration: defaultContinuousPollInterval},
+			BatchMaxFiles: defaultContinuousBatchMaxFiles,
+		},
 	}
 }
 
@@ -1634,5 +1770,8 @@ func (cfg *Config) Adjust(ctx context.Context) error {
 	if err = cfg.Routes.adjust(&cfg.Mydumper); err != nil {
 		return err
 	}
-	return cfg.Conflict.adjust(&cfg.TikvImporter)
+	if err = cfg.Conflict.adjust(&cfg.TikvImporter); err != nil {
+		return err
+	}
+	return cfg.Continuous.adjust(&cfg.Checkpoint, &cfg.TikvImporter)
 }
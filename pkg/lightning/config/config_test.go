@@ -339,6 +339,45 @@ func TestAdjustWillBatchImportRatioInvalid(t *testing.T) {
 	require.Equal(t, 0.75, cfg.Mydumper.BatchImportRatio)
 }
 
+func TestAdjustAvro(t *testing.T) {
+	cfg := NewConfig()
+	assignMinimalLegalValue(cfg)
+	cfg.TiDB.DistSQLScanConcurrency = 1
+	cfg.Mydumper.Avro.DecodeConcurrency = 0
+	cfg.Mydumper.Avro.FieldMappings = []*AvroFieldMapping{{Field: "after.id", Column: "id"}, {Field: "after.*"}}
+	require.NoError(t, cfg.Adjust(context.Background()))
+	require.Equal(t, DefaultAvroDecodeConcurrency, cfg.Mydumper.Avro.DecodeConcurrency)
+
+	for _, m := range []*AvroFieldMapping{{Field: ""}, {Field: "after."}, {Field: "*", Column: "c"}} {
+		cfg.Mydumper.Avro.FieldMappings = []*AvroFieldMapping{m}
+		require.ErrorContains(t, cfg.Adjust(context.Background()), "`mydumper.avro.field-mapping`")
+	}
+}
+
+func TestAdjustContinuous(t *testing.T) {
+	cfg := NewConfig()
+	assignMinimalLegalValue(cfg)
+	cfg.TiDB.DistSQLScanConcurrency = 1
+	cfg.Continuous.Enable = true
+	require.ErrorContains(t, cfg.Adjust(context.Background()), "`checkpoint.driver` must be \"mysql\"")
+
+	cfg.Checkpoint.Driver = CheckpointDriverMySQL
+	require.ErrorContains(t, cfg.Adjust(context.Background()), "`tikv-importer.parallel-import` must be true")
+
+	cfg.TikvImporter.ParallelImport = true
+	require.NoError(t, cfg.Adjust(context.Background()))
+	require.Equal(t, CheckpointOrigin, cfg.Checkpoint.KeepAfterSuccess)
+	require.Equal(t, "tidb_lightning_continuous", cfg.Continuous.Schema)
+	require.Equal(t, time.Minute, cfg.Continuous.PollInterval.Duration)
+	require.Equal(t, 1000, cfg.Continuous.BatchMaxFiles)
+
+	cfg.Continuous.Schema = cfg.Checkpoint.Schema
+	require.ErrorContains(t, cfg.Adjust(context.Background()), "`continuous.schema` must be different")
+	cfg.Continuous.Schema = ""
+	cfg.Continuous.BatchMaxFiles = 0
+	require.ErrorContains(t, cfg.Adjust(context.Background()), "`continuous.batch-max-files` must be positive")
+}
+
 func TestAdjustSecuritySection(t *testing.T) {
 	testCases := []struct {
 		input          string
@@ -1410,6 +1449,18 @@ func TestAdjustConflict(t *testing.T) {
 	cfg.Conflict.MaxRecordRows = 1
 	require.NoError(t, cfg.Conflict.adjust(&cfg.TikvImporter))
 	require.EqualValues(t, 0, cfg.Conflict.MaxRecordRows)
+
+	cfg.Conflict.ReportDir = "s3://bucket/conflicts"
+	require.NoError(t, cfg.Conflict.adjust(&cfg.TikvImporter))
+	require.Equal(t, ConflictReportFormatJSON, cfg.Conflict.ReportFormat)
+	cfg.Conflict.ReportFormat = "CSV"
+	require.ErrorContains(t, cfg.Conflict.adjust(&cfg.TikvImporter), "unsupported `conflict.report-format` (CSV)")
+	cfg.Conflict.ReportFormat = ConflictReportFormatCSV
+	require.NoError(t, cfg.Conflict.adjust(&cfg.TikvImporter))
+
+	cfg.TikvImporter.Backend = BackendLocal
+	cfg.Conflict.Strategy = NoneOnDup
+	require.ErrorContains(t, cfg.Conflict.adjust(&cfg.TikvImporter), `conflict.report-dir cannot be set when conflict.strategy is ""`)
 }
 
 func TestAdjustBlockSize(t *testing.T) {
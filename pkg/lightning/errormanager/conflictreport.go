@@ -0,0 +1,510 @@
+// Copyright 2026 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errormanager
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/tidb/br/pkg/storage"
+	"github.com/pingcap/tidb/pkg/lightning/common"
+	"github.com/pingcap/tidb/pkg/lightning/config"
+	"github.com/pingcap/tidb/pkg/lightning/log"
+	"github.com/pingcap/tidb/pkg/util/redact"
+	"go.uber.org/zap"
+	"golang.org/x/exp/slices"
+)
+
+const (
+	selectConflictTables = `
+		SELECT DISTINCT table_name FROM %s.%s WHERE task_id = ?;
+	`
+
+	selectConflictErrorsForReport = `
+		SELECT id, index_name, raw_key, key_data, row_data
+		FROM %s.` + ConflictErrorTableName + `
+		WHERE task_id = ? AND table_name = ? AND kv_type <> 2
+		ORDER BY index_name, raw_key, id;
+	`
+
+	selectDupRecordsForReport = `
+		SELECT id, path, offset, error, row_id, row_data
+		FROM %s.` + DupRecordTableName + `
+		WHERE task_id = ? AND table_name = ?
+		ORDER BY id;
+	`
+)
+
+// the columns of the conflict report in the CSV format, every row of a conflict
+// is a line, and the lines of a conflict share the same id.
+var conflictReportCSVHeader = []string{
+	"id", "table", "index", "key", "error", "path", "offset", "row_id", "handle", "row_data", "resolved",
+}
+
+// ConflictRow is a row involved in a conflict.
+type ConflictRow struct {
+	// Path and Offset locate the row in the source files, they are unknown for
+	// the conflicts detected after the import by the local backend.
+	Path   string `json:"path,omitempty"`
+	Offset int64  `json:"offset,omitempty"`
+	RowID  int64  `json:"row_id,omitempty"`
+	// Handle is the handle of the row in the target table, it's only known for
+	// the conflicts detected after the import by the local backend.
+	Handle string `json:"handle,omitempty"`
+	Data   string `json:"data"`
+}
+
+// ConflictReportEntry is a conflict in the conflict report.
+//
+// the conflicts detected after the import by the local backend contain all the
+// rows sharing the conflicted key. the conflicts recorded by the tidb backend or
+// the conflict detection before the import contain the row which isn't imported,
+// while the one kept in the target table is described by the error.
+type ConflictReportEntry struct {
+	ID    string `json:"id"`
+	Table string `json:"table"`
+	Index string `json:"index,omitempty"`
+	// Key is the hex encoded conflicted key.
+	Key   string        `json:"key,omitempty"`
+	Error string        `json:"error,omitempty"`
+	Rows  []ConflictRow `json:"rows"`
+	// Resolved is filled by the user with the row to keep, in the form of a JSON
+	// array of the values in the column order of the table, such as [1,"a",null].
+	// the conflicts with an empty Resolved are left as they are.
+	Resolved string `json:"resolved,omitempty"`
+}
+
+// conflictReportFileName returns the name of the report file of the table.
+func conflictReportFileName(taskID int64, tableName, format string) string {
+	return path.Join(strconv.FormatInt(taskID, 10), url.PathEscape(tableName)+"."+format)
+}
+
+// ExportConflictReport exports the conflicts of the task to the external
+// storage, a file per table. the file of a table is renamed from a temporary
+// file after it's written completely, so an interrupted export is resumed by
+// exporting the tables without a report file. it returns the names of the files
+// exported.
+func (em *ErrorManager) ExportConflictReport(
+	ctx context.Context,
+	store storage.ExternalStorage,
+	format string,
+) ([]string, error) {
+	if em.db == nil {
+		return nil, nil
+	}
+	var sources []string
+	if em.conflictV1Enabled {
+		sources = append(sources, ConflictErrorTableName)
+	}
+	if em.conflictV2Enabled {
+		sources = append(sources, DupRecordTableName)
+	}
+	exec := common.SQLWithRetry{
+		DB:     em.db,
+		Logger: em.logger,
+	}
+
+	var tables []string
+	for _, source := range sources {
+		err := exec.Transact(ctx, "read conflict tables", func(c context.Context, tx *sql.Tx) error {
+			rows, err := tx.QueryContext(c, common.SprintfWithIdentifiers(selectConflictTables, em.schema, source), em.taskID)
+			if err != nil {
+				return errors.Trace(err)
+			}
+			//nolint: errcheck
+			defer rows.Close()
+			for rows.Next() {
+				var tableName string
+				if err := rows.Scan(&tableName); err != nil {
+					return errors.Trace(err)
+				}
+				if !slices.Contains(tables, tableName) {
+					tables = append(tables, tableName)
+				}
+			}
+			return errors.Trace(rows.Err())
+		})
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	slices.Sort(tables)
+
+	files := make([]string, 0, len(tables))
+	for _, tableName := range tables {
+		name := conflictReportFileName(em.taskID, tableName, format)
+		exists, err := store.FileExists(ctx, name)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if !exists {
+			entries, err := em.readConflictReport(ctx, tableName)
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			if err := writeConflictReport(ctx, store, name, format, entries); err != nil {
+				return nil, errors.Trace(err)
+			}
+			em.logger.Info("exported conflict report",
+				zap.String("table", tableName), zap.String("file", name), zap.Int("conflicts", len(entries)))
+		}
+		files = append(files, name)
+	}
+	return files, nil
+}
+
+func (em *ErrorManager) readConflictReport(ctx context.Context, tableName string) ([]*ConflictReportEntry, error) {
+	var entries []*ConflictReportEntry
+	exec := common.SQLWithRetry{
+		DB:           em.db,
+		Logger:       em.logger.With(zap.String("table", tableName)),
+		HideQueryLog: redact.NeedRedact(),
+	}
+	if em.conflictV1Enabled {
+		err := exec.Transact(ctx, "read conflict errors for report", func(c context.Context, tx *sql.Tx) error {
+			entries = entries[:0]
+			rows, err := tx.QueryContext(c, common.SprintfWithIdentifiers(selectConflictErrorsForReport, em.schema),
+				em.taskID, tableName)
+			if err != nil {
+				return errors.Trace(err)
+			}
+			//nolint: errcheck
+			defer rows.Close()
+			var last *ConflictReportEntry
+			for rows.Next() {
+				var (
+					id               int64
+					indexName        string
+					rawKey           []byte
+					keyData, rowData sql.NullString
+				)
+				if err := rows.Scan(&id, &indexName, &rawKey, &keyData, &rowData); err != nil {
+					return errors.Trace(err)
+				}
+				// the rows are ordered by the conflicted key, so the rows sharing
+				// a key are merged into one conflict.
+				key := hex.EncodeToString(rawKey)
+				if last == nil || last.Index != indexName || last.Key != key {
+					last = &ConflictReportEntry{
+						ID:    fmt.Sprintf("%s:%d", ConflictErrorTableName, id),
+						Table: tableName,
+						Index: indexName,
+						Key:   key,
+					}
+					entries = append(entries, last)
+				}
+				last.Rows = append(last.Rows, ConflictRow{Handle: keyData.String, Data: rowData.String})
+			}
+			return errors.Trace(rows.Err())
+		})
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	if em.conflictV2Enabled {
+		numV1 := len(entries)
+		err := exec.Transact(ctx, "read duplicate records for report", func(c context.Context, tx *sql.Tx) error {
+			entries = entries[:numV1]
+			rows, err := tx.QueryContext(c, common.SprintfWithIdentifiers(selectDupRecordsForReport, em.schema),
+				em.taskID, tableName)
+			if err != nil {
+				return errors.Trace(err)
+			}
+			//nolint: errcheck
+			defer rows.Close()
+			for rows.Next() {
+				var (
+					id     int64
+					errMsg string
+					row    ConflictRow
+				)
+				if err := rows.Scan(&id, &row.Path, &row.Offset, &errMsg, &row.RowID, &row.Data); err != nil {
+					return errors.Trace(err)
+				}
+				entries = append(entries, &ConflictReportEntry{
+					ID:    fmt.Sprintf("%s:%d", DupRecordTableName, id),
+					Table: tableName,
+					Error: errMsg,
+					Rows:  []ConflictRow{row},
+				})
+			}
+			return errors.Trace(rows.Err())
+		})
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	return entries, nil
+}
+
+// writerAdapter adapts an ExternalFileWriter to an io.Writer.
+type writerAdapter struct {
+	ctx context.Context
+	w   storage.ExternalFileWriter
+}
+
+func (w writerAdapter) Write(p []byte) (int, error) {
+	return w.w.Write(w.ctx, p)
+}
+
+func writeConflictReport(
+	ctx context.Context,
+	store storage.ExternalStorage,
+	name string,
+	format string,
+	entries []*ConflictReportEntry,
+) (err error) {
+	tmpName := name + ".tmp"
+	fileWriter, err := store.Create(ctx, tmpName, nil)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer func() {
+		if closeErr := fileWriter.Close(ctx); err == nil {
+			err = errors.Trace(closeErr)
+		}
+		if err == nil {
+			err = errors.Trace(store.Rename(ctx, tmpName, name))
+		}
+	}()
+
+	bw := bufio.NewWriter(writerAdapter{ctx: ctx, w: fileWriter})
+	switch format {
+	case config.ConflictReportFormatJSON:
+		// a conflict per line, so that the report can be processed in stream.
+		enc := json.NewEncoder(bw)
+		for _, entry := range entries {
+			if err := enc.Encode(entry); err != nil {
+				return errors.Trace(err)
+			}
+		}
+	case config.ConflictReportFormatCSV:
+		w := csv.NewWriter(bw)
+		if err := w.Write(conflictReportCSVHeader); err != nil {
+			return errors.Trace(err)
+		}
+		for _, entry := range entries {
+			for _, row := range entry.Rows {
+				err := w.Write([]string{
+					entry.ID, entry.Table, entry.Index, entry.Key, entry.Error, row.Path,
+					strconv.FormatInt(row.Offset, 10), strconv.FormatInt(row.RowID, 10), row.Handle, row.Data, entry.Resolved,
+				})
+				if err != nil {
+					return errors.Trace(err)
+				}
+			}
+		}
+		w.Flush()
+		if err := w.Error(); err != nil {
+			return errors.Trace(err)
+		}
+	default:
+		return errors.Errorf("unsupported conflict report format %s", format)
+	}
+	return errors.Trace(bw.Flush())
+}
+
+// ReadConflictReport reads the conflict report file exported by
+// ExportConflictReport, the format is decided by the file extension.
+func ReadConflictReport(ctx context.Context, store storage.ExternalStorage, name string) ([]*ConflictReportEntry, error) {
+	content, err := store.ReadFile(ctx, name)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	var entries []*ConflictReportEntry
+	switch strings.TrimPrefix(path.Ext(name), ".") {
+	case config.ConflictReportFormatJSON:
+		dec := json.NewDecoder(bytes.NewReader(content))
+		for {
+			entry := &ConflictReportEntry{}
+			if err := dec.Decode(entry); err == io.EOF {
+				break
+			} else if err != nil {
+				return nil, errors.Annotatef(err, "invalid conflict report %s", name)
+			}
+			entries = append(entries, entry)
+		}
+	case config.ConflictReportFormatCSV:
+		r := csv.NewReader(bytes.NewReader(content))
+		r.FieldsPerRecord = len(conflictReportCSVHeader)
+		records, err := r.ReadAll()
+		if err != nil {
+			return nil, errors.Annotatef(err, "invalid conflict report %s", name)
+		}
+		if len(records) == 0 || !slices.Equal(records[0], conflictReportCSVHeader) {
+			return nil, errors.Errorf("invalid conflict report %s, the header is missing", name)
+		}
+		var last *ConflictReportEntry
+		for _, record := range records[1:] {
+			offset, err := strconv.ParseInt(record[6], 10, 64)
+			if err != nil {
+				return nil, errors.Annotatef(err, "invalid offset of conflict %s", record[0])
+			}
+			rowID, err := strconv.ParseInt(record[7], 10, 64)
+			if err != nil {
+				return nil, errors.Annotatef(err, "invalid row_id of conflict %s", record[0])
+			}
+			if last == nil || last.ID != record[0] {
+				last = &ConflictReportEntry{
+					ID:    record[0],
+					Table: record[1],
+					Index: record[2],
+					Key:   record[3],
+					Error: record[4],
+				}
+				entries = append(entries, last)
+			}
+			last.Rows = append(last.Rows, ConflictRow{
+				Path:   record[5],
+				Offset: offset,
+				RowID:  rowID,
+				Handle: record[8],
+				Data:   record[9],
+			})
+			// the resolved row can be filled in any line of the conflict.
+			if len(record[10]) > 0 {
+				last.Resolved = record[10]
+			}
+		}
+	default:
+		return nil, errors.Errorf("unsupported conflict report %s, the extension must be .json or .csv", name)
+	}
+	return entries, nil
+}
+
+// ApplyConflictReport writes the resolved rows in the conflict report file into
+// the target tables with REPLACE, which removes the rows conflicting with them.
+// the values of the rows are passed as the arguments of the statement. it
+// returns the number of the conflicts applied. applying a report again is
+// harmless, so it can be retried after a failure.
+func ApplyConflictReport(
+	ctx context.Context,
+	db *sql.DB,
+	store storage.ExternalStorage,
+	name string,
+	logger log.Logger,
+) (int, error) {
+	entries, err := ReadConflictReport(ctx, store, name)
+	if err != nil {
+		return 0, errors.Trace(err)
+	}
+	exec := common.SQLWithRetry{
+		DB:           db,
+		Logger:       logger,
+		HideQueryLog: redact.NeedRedact(),
+	}
+	applied := 0
+	for _, entry := range entries {
+		resolved := strings.TrimSpace(entry.Resolved)
+		if len(resolved) == 0 {
+			continue
+		}
+		schema, table, err := splitUniqueTable(entry.Table)
+		if err != nil {
+			return applied, errors.Annotatef(err, "invalid table of conflict %s", entry.ID)
+		}
+		values, err := parseResolvedRow(resolved)
+		if err != nil {
+			return applied, errors.Annotatef(err, "the resolved row of conflict %s must be a JSON array "+
+				"like [1,\"a\",null], got %s", entry.ID, redact.Value(resolved))
+		}
+		query := fmt.Sprintf("REPLACE INTO %s VALUES (%s);", common.UniqueTable(schema, table),
+			strings.TrimSuffix(strings.Repeat("?,", len(values)), ","))
+		if err := exec.Exec(ctx, "apply resolved conflict", query, values...); err != nil {
+			return applied, errors.Annotatef(err, "failed to apply conflict %s", entry.ID)
+		}
+		applied++
+	}
+	return applied, nil
+}
+
+// splitUniqueTable splits the table name made by common.UniqueTable into the
+// schema and the table.
+func splitUniqueTable(name string) (schema, table string, err error) {
+	schema, rest, ok := cutQuotedIdentifier(name)
+	if ok && strings.HasPrefix(rest, ".") {
+		table, rest, ok = cutQuotedIdentifier(rest[1:])
+		if ok && len(rest) == 0 {
+			return schema, table, nil
+		}
+	}
+	return "", "", errors.Errorf("%s isn't a quoted table name like `db`.`tbl`", name)
+}
+
+// cutQuotedIdentifier cuts the leading identifier quoted by backquotes from s,
+// and unescapes it.
+func cutQuotedIdentifier(s string) (identifier, rest string, ok bool) {
+	if !strings.HasPrefix(s, "`") {
+		return "", "", false
+	}
+	var builder strings.Builder
+	for i := 1; i < len(s); i++ {
+		if s[i] != '`' {
+			builder.WriteByte(s[i])
+			continue
+		}
+		if i+1 < len(s) && s[i+1] == '`' {
+			builder.WriteByte('`')
+			i++
+			continue
+		}
+		return builder.String(), s[i+1:], true
+	}
+	return "", "", false
+}
+
+// parseResolvedRow parses the resolved row into the arguments of the statement.
+// the numbers are kept as the text to not lose the precision, and the arrays and
+// the objects are passed as the JSON text.
+func parseResolvedRow(resolved string) ([]any, error) {
+	dec := json.NewDecoder(strings.NewReader(resolved))
+	dec.UseNumber()
+	var values []any
+	if err := dec.Decode(&values); err != nil {
+		return nil, errors.Trace(err)
+	}
+	if dec.More() {
+		return nil, errors.New("unexpected data after the array")
+	}
+	if len(values) == 0 {
+		return nil, errors.New("the row is empty")
+	}
+	for i, v := range values {
+		switch v := v.(type) {
+		case json.Number:
+			values[i] = v.String()
+		case []any, map[string]any:
+			data, err := json.Marshal(v)
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			values[i] = string(data)
+		}
+	}
+	return values, nil
+}
@@ -0,0 +1,179 @@
+// Copyright 2026 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errormanager
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/pingcap/tidb/br/pkg/storage"
+	"github.com/pingcap/tidb/pkg/lightning/config"
+	"github.com/pingcap/tidb/pkg/lightning/log"
+	"github.com/stretchr/testify/require"
+)
+
+func expectConflictReportQueries(mock sqlmock.Sqlmock) {
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT DISTINCT table_name FROM `lightning_errors`\\.`conflict_error_v4` WHERE task_id = \\?").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"table_name"}).AddRow("`test`.`t`"))
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT DISTINCT table_name FROM `lightning_errors`\\.`conflict_records_v2` WHERE task_id = \\?").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"table_name"}).AddRow("`test`.`t`"))
+	mock.ExpectCommit()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id, index_name, raw_key, key_data, row_data\\s+FROM `lightning_errors`\\.conflict_error_v4").
+		WithArgs(1, "`test`.`t`").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "index_name", "raw_key", "key_data", "row_data"}).
+			AddRow(3, "PRIMARY", []byte{1}, "1", "(1, a)").
+			AddRow(5, "PRIMARY", []byte{1}, "1", "(1, b)").
+			AddRow(4, "uk", []byte{2}, "2", "(2, c)").
+			AddRow(6, "uk", []byte{2}, "3", "(3, c)"))
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id, path, offset, error, row_id, row_data\\s+FROM `lightning_errors`\\.conflict_records_v2").
+		WithArgs(1, "`test`.`t`").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "path", "offset", "error", "row_id", "row_data"}).
+			AddRow(1, "test.t.1.csv", 10, "Duplicate entry '4' for key 't.PRIMARY'", 7, "(4,'d')"))
+	mock.ExpectCommit()
+}
+
+func TestExportConflictReport(t *testing.T) {
+	ctx := context.Background()
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		mock.ExpectClose()
+		require.NoError(t, db.Close())
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	cfg := config.NewConfig()
+	cfg.TaskID = 1
+	cfg.TikvImporter.Backend = config.BackendLocal
+	cfg.Conflict.Strategy = config.ReplaceOnDup
+	cfg.Conflict.PrecheckConflictBeforeImport = true
+	cfg.App.TaskInfoSchemaName = "lightning_errors"
+	em := New(db, cfg, log.L())
+
+	expected := []*ConflictReportEntry{
+		{
+			ID: "conflict_error_v4:3", Table: "`test`.`t`", Index: "PRIMARY", Key: "01",
+			Rows: []ConflictRow{{Handle: "1", Data: "(1, a)"}, {Handle: "1", Data: "(1, b)"}},
+		},
+		{
+			ID: "conflict_error_v4:4", Table: "`test`.`t`", Index: "uk", Key: "02",
+			Rows: []ConflictRow{{Handle: "2", Data: "(2, c)"}, {Handle: "3", Data: "(3, c)"}},
+		},
+		{
+			ID: "conflict_records_v2:1", Table: "`test`.`t`", Error: "Duplicate entry '4' for key 't.PRIMARY'",
+			Rows: []ConflictRow{{Path: "test.t.1.csv", Offset: 10, RowID: 7, Data: "(4,'d')"}},
+		},
+	}
+	for _, format := range []string{config.ConflictReportFormatJSON, config.ConflictReportFormatCSV} {
+		store, err := storage.NewLocalStorage(t.TempDir())
+		require.NoError(t, err)
+
+		expectConflictReportQueries(mock)
+		files, err := em.ExportConflictReport(ctx, store, format)
+		require.NoError(t, err)
+		require.Equal(t, []string{"1/%60test%60.%60t%60." + format}, files)
+		exists, err := store.FileExists(ctx, files[0]+".tmp")
+		require.NoError(t, err)
+		require.False(t, exists)
+
+		entries, err := ReadConflictReport(ctx, store, files[0])
+		require.NoError(t, err)
+		require.Equal(t, expected, entries)
+
+		// the exported tables are skipped when the export is resumed.
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT DISTINCT table_name FROM `lightning_errors`\\.`conflict_error_v4`").
+			WillReturnRows(sqlmock.NewRows([]string{"table_name"}).AddRow("`test`.`t`"))
+		mock.ExpectCommit()
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT DISTINCT table_name FROM `lightning_errors`\\.`conflict_records_v2`").
+			WillReturnRows(sqlmock.NewRows([]string{"table_name"}))
+		mock.ExpectCommit()
+		resumed, err := em.ExportConflictReport(ctx, store, format)
+		require.NoError(t, err)
+		require.Equal(t, files, resumed)
+		require.NoError(t, mock.ExpectationsWereMet())
+	}
+}
+
+func TestApplyConflictReport(t *testing.T) {
+	ctx := context.Background()
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		mock.ExpectClose()
+		require.NoError(t, db.Close())
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+	store, err := storage.NewLocalStorage(t.TempDir())
+	require.NoError(t, err)
+
+	require.NoError(t, store.WriteFile(ctx, "t.json", []byte(
+		`{"id":"conflict_error_v4:3","table":"`+"`test`.`t`"+`","rows":[{"data":"(1, a)"},{"data":"(1, b)"}],"resolved":"[1,\"b\"]"}
+{"id":"conflict_error_v4:4","table":"`+"`test`.`t`"+`","rows":[{"data":"(2, c)"},{"data":"(3, c)"}]}
+`)))
+	mock.ExpectExec("REPLACE INTO `test`\\.`t` VALUES \\(\\?,\\?\\);").WithArgs("1", "b").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	applied, err := ApplyConflictReport(ctx, db, store, "t.json", log.L())
+	require.NoError(t, err)
+	require.Equal(t, 1, applied)
+
+	require.NoError(t, store.WriteFile(ctx, "t.csv", []byte(
+		"id,table,index,key,error,path,offset,row_id,handle,row_data,resolved\n"+
+			"conflict_error_v4:4,`test`.`t`,uk,02,,,0,0,2,\"(2, c)\",\n"+
+			"conflict_error_v4:4,`test`.`t`,uk,02,,,0,0,3,\"(3, c)\",\"[3,\"\"c\"\"]\"\n"+
+			"conflict_records_v2:1,`te``st`.`t`,,,dup,test.t.1.csv,10,7,,\"(4,'d')\",\"[4,null,{\"\"a\"\":[1]}]\"\n")))
+	mock.ExpectExec("REPLACE INTO `test`\\.`t` VALUES \\(\\?,\\?\\);").WithArgs("3", "c").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec("REPLACE INTO `te``st`\\.`t` VALUES \\(\\?,\\?,\\?\\);").WithArgs("4", nil, `{"a":[1]}`).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	applied, err = ApplyConflictReport(ctx, db, store, "t.csv", log.L())
+	require.NoError(t, err)
+	require.Equal(t, 2, applied)
+
+	require.NoError(t, store.WriteFile(ctx, "bad.json", []byte(
+		`{"id":"conflict_error_v4:3","table":"`+"`test`.`t`"+`","resolved":"1; DROP TABLE t"}`)))
+	_, err = ApplyConflictReport(ctx, db, store, "bad.json", log.L())
+	require.ErrorContains(t, err, "the resolved row of conflict conflict_error_v4:3 must be a JSON array")
+	for _, resolved := range []string{`(1,'b')`, `[1] x`, `[]`} {
+		_, err = parseResolvedRow(resolved)
+		require.Error(t, err, resolved)
+	}
+
+	require.NoError(t, store.WriteFile(ctx, "bad.json", []byte(
+		`{"id":"conflict_error_v4:3","table":"t; DROP TABLE t","resolved":"[1]"}`)))
+	_, err = ApplyConflictReport(ctx, db, store, "bad.json", log.L())
+	require.ErrorContains(t, err, "t; DROP TABLE t isn't a quoted table name")
+	for _, name := range []string{"`a`", "`a`.`b`c", "`a`.`b", "`a`.`b`.`c`"} {
+		_, _, err = splitUniqueTable(name)
+		require.Error(t, err, name)
+	}
+	schema, table, err := splitUniqueTable("`a``b`.`c.d`")
+	require.NoError(t, err)
+	require.Equal(t, []string{"a`b", "c.d"}, []string{schema, table})
+
+	_, err = ApplyConflictReport(ctx, db, store, "t.txt", log.L())
+	require.Error(t, err)
+}
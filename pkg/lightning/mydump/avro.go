@@ -0,0 +1,629 @@
+// Copyright 2026 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mydump
+
+import (
+	"bytes"
+	"compress/bzip2"
+	"compress/flate"
+	"encoding/binary"
+	"encoding/json"
+	"hash/crc32"
+	"io"
+	"math"
+	"strings"
+
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
+	"github.com/pingcap/errors"
+)
+
+// the Avro object container file, see https://avro.apache.org/docs/1.11.1/specification/#object-container-files
+const (
+	avroSyncSize = 16
+
+	avroSchemaKey = "avro.schema"
+	avroCodecKey  = "avro.codec"
+
+	avroCodecNull      = "null"
+	avroCodecDeflate   = "deflate"
+	avroCodecSnappy    = "snappy"
+	avroCodecZstandard = "zstandard"
+	avroCodecBzip2     = "bzip2"
+)
+
+var avroMagic = []byte{'O', 'b', 'j', 1}
+
+// avroType is the type of an Avro schema.
+type avroType int
+
+const (
+	avroNull avroType = iota
+	avroBoolean
+	avroInt
+	avroLong
+	avroFloat
+	avroDouble
+	avroBytes
+	avroString
+	avroRecord
+	avroEnum
+	avroArray
+	avroMap
+	avroUnion
+	avroFixed
+)
+
+var avroPrimitiveTypes = map[string]avroType{
+	"null":    avroNull,
+	"boolean": avroBoolean,
+	"int":     avroInt,
+	"long":    avroLong,
+	"float":   avroFloat,
+	"double":  avroDouble,
+	"bytes":   avroBytes,
+	"string":  avroString,
+}
+
+func (t avroType) String() string {
+	switch t {
+	case avroRecord:
+		return "record"
+	case avroEnum:
+		return "enum"
+	case avroArray:
+		return "array"
+	case avroMap:
+		return "map"
+	case avroUnion:
+		return "union"
+	case avroFixed:
+		return "fixed"
+	}
+	for name, tp := range avroPrimitiveTypes {
+		if tp == t {
+			return name
+		}
+	}
+	return "unknown"
+}
+
+// avroField is a field of an Avro record.
+type avroField struct {
+	name   string
+	schema *avroSchema
+}
+
+// avroSchema is a parsed Avro schema.
+type avroSchema struct {
+	tp   avroType
+	name string
+	// logicalType annotates the underlying type, e.g. `date` of int and `decimal` of bytes.
+	logicalType string
+	precision   int
+	scale       int
+	// size is the size of the fixed type.
+	size int
+
+	fields   []*avroField
+	symbols  []string
+	items    *avroSchema
+	values   *avroSchema
+	branches []*avroSchema
+}
+
+// String returns the type of the schema used in the diagnostics, e.g. `long(timestamp-millis)`.
+func (s *avroSchema) String() string {
+	var name string
+	switch s.tp {
+	case avroUnion:
+		names := make([]string, 0, len(s.branches))
+		for _, branch := range s.branches {
+			names = append(names, branch.String())
+		}
+		return "[" + strings.Join(names, ",") + "]"
+	case avroRecord, avroEnum, avroFixed:
+		name = s.tp.String() + " " + s.name
+	default:
+		name = s.tp.String()
+	}
+	if s.logicalType != "" {
+		name += "(" + s.logicalType + ")"
+	}
+	return name
+}
+
+// field returns the field of the record by the case-insensitive name.
+func (s *avroSchema) field(name string) (int, *avroField) {
+	for i, f := range s.fields {
+		if strings.EqualFold(f.name, name) {
+			return i, f
+		}
+	}
+	return -1, nil
+}
+
+// avroSchemaParser parses the JSON schema, the named types are recorded to resolve the references.
+type avroSchemaParser struct {
+	named map[string]*avroSchema
+}
+
+func parseAvroSchema(data []byte) (*avroSchema, error) {
+	var raw any
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, errors.Annotate(err, "invalid avro schema")
+	}
+	p := &avroSchemaParser{named: make(map[string]*avroSchema)}
+	return p.parse(raw, "")
+}
+
+func (p *avroSchemaParser) parse(raw any, namespace string) (*avroSchema, error) {
+	switch v := raw.(type) {
+	case string:
+		if tp, ok := avroPrimitiveTypes[v]; ok {
+			return &avroSchema{tp: tp}, nil
+		}
+		if s, ok := p.named[fullAvroName(v, namespace)]; ok {
+			return s, nil
+		}
+		if s, ok := p.named[v]; ok {
+			return s, nil
+		}
+		return nil, errors.Errorf("unknown avro type %q", v)
+	case []any:
+		s := &avroSchema{tp: avroUnion, branches: make([]*avroSchema, 0, len(v))}
+		for _, item := range v {
+			branch, err := p.parse(item, namespace)
+			if err != nil {
+				return nil, err
+			}
+			s.branches = append(s.branches, branch)
+		}
+		return s, nil
+	case map[string]any:
+		return p.parseComplex(v, namespace)
+	default:
+		return nil, errors.Errorf("invalid avro schema %v", raw)
+	}
+}
+
+func (p *avroSchemaParser) parseComplex(v map[string]any, namespace string) (*avroSchema, error) {
+	typeName, ok := v["type"].(string)
+	if !ok {
+		// the type can be a schema itself, e.g. {"type": {"type": "array", "items": "int"}}.
+		if inner, ok := v["type"]; ok {
+			return p.parse(inner, namespace)
+		}
+		return nil, errors.Errorf("avro schema %v has no type", v)
+	}
+	logicalType, _ := v["logicalType"].(string)
+	if tp, ok := avroPrimitiveTypes[typeName]; ok {
+		s := &avroSchema{tp: tp, logicalType: logicalType}
+		s.precision, s.scale = jsonInt(v["precision"]), jsonInt(v["scale"])
+		return s, nil
+	}
+	name, _ := v["name"].(string)
+	if ns, ok := v["namespace"].(string); ok {
+		namespace = ns
+	}
+	register := func(s *avroSchema) {
+		s.name = fullAvroName(name, namespace)
+		p.named[s.name] = s
+		if idx := strings.LastIndexByte(s.name, '.'); idx >= 0 {
+			namespace = s.name[:idx]
+		}
+	}
+	switch typeName {
+	case "record", "error":
+		s := &avroSchema{tp: avroRecord}
+		// registered before the fields for the recursive types.
+		register(s)
+		fields, _ := v["fields"].([]any)
+		for _, raw := range fields {
+			f, ok := raw.(map[string]any)
+			if !ok {
+				return nil, errors.Errorf("invalid field %v of avro record %s", raw, s.name)
+			}
+			fieldName, _ := f["name"].(string)
+			fieldSchema, err := p.parse(f["type"], namespace)
+			if err != nil {
+				return nil, errors.Annotatef(err, "field %s of avro record %s", fieldName, s.name)
+			}
+			s.fields = append(s.fields, &avroField{name: fieldName, schema: fieldSchema})
+		}
+		return s, nil
+	case "enum":
+		s := &avroSchema{tp: avroEnum}
+		register(s)
+		symbols, _ := v["symbols"].([]any)
+		for _, symbol := range symbols {
+			str, _ := symbol.(string)
+			s.symbols = append(s.symbols, str)
+		}
+		return s, nil
+	case "fixed":
+		s := &avroSchema{tp: avroFixed, size: jsonInt(v["size"]), logicalType: logicalType}
+		s.precision, s.scale = jsonInt(v["precision"]), jsonInt(v["scale"])
+		register(s)
+		return s, nil
+	case "array":
+		items, err := p.parse(v["items"], namespace)
+		if err != nil {
+			return nil, err
+		}
+		return &avroSchema{tp: avroArray, items: items}, nil
+	case "map":
+		values, err := p.parse(v["values"], namespace)
+		if err != nil {
+			return nil, err
+		}
+		return &avroSchema{tp: avroMap, values: values}, nil
+	default:
+		return p.parse(typeName, namespace)
+	}
+}
+
+func fullAvroName(name, namespace string) string {
+	if strings.Contains(name, ".") || namespace == "" {
+		return name
+	}
+	return namespace + "." + name
+}
+
+func jsonInt(v any) int {
+	f, _ := v.(float64)
+	return int(f)
+}
+
+// avroUnionValue is a decoded value of a union, the schema of the branch is kept to convert the value.
+type avroUnionValue struct {
+	schema *avroSchema
+	value  any
+}
+
+// avroDecoder decodes the Avro binary encoding, see https://avro.apache.org/docs/1.11.1/specification/#binary-encoding.
+type avroDecoder struct {
+	data []byte
+	pos  int
+}
+
+var errAvroShortBuffer = errors.New("unexpected end of the avro data")
+
+func (d *avroDecoder) readLong() (int64, error) {
+	v, n := binary.Varint(d.data[d.pos:])
+	if n <= 0 {
+		return 0, errAvroShortBuffer
+	}
+	d.pos += n
+	return v, nil
+}
+
+func (d *avroDecoder) readBytes() ([]byte, error) {
+	l, err := d.readLong()
+	if err != nil {
+		return nil, err
+	}
+	return d.readFixed(int(l))
+}
+
+func (d *avroDecoder) readFixed(n int) ([]byte, error) {
+	if n < 0 || d.pos+n > len(d.data) {
+		return nil, errAvroShortBuffer
+	}
+	b := d.data[d.pos : d.pos+n]
+	d.pos += n
+	return b, nil
+}
+
+// decode decodes a value of the schema. The bytes and strings point to the decoded data, the records are
+// decoded to []any, the arrays to []any and the maps to map[string]any.
+func (d *avroDecoder) decode(s *avroSchema) (any, error) {
+	switch s.tp {
+	case avroNull:
+		return nil, nil
+	case avroBoolean:
+		b, err := d.readFixed(1)
+		if err != nil {
+			return nil, err
+		}
+		return b[0] != 0, nil
+	case avroInt:
+		v, err := d.readLong()
+		if err != nil {
+			return nil, err
+		}
+		if v < math.MinInt32 || v > math.MaxInt32 {
+			return nil, errors.Errorf("avro int %d overflows", v)
+		}
+		return int32(v), nil
+	case avroLong:
+		return d.readLong()
+	case avroFloat:
+		b, err := d.readFixed(4)
+		if err != nil {
+			return nil, err
+		}
+		return math.Float32frombits(binary.LittleEndian.Uint32(b)), nil
+	case avroDouble:
+		b, err := d.readFixed(8)
+		if err != nil {
+			return nil, err
+		}
+		return math.Float64frombits(binary.LittleEndian.Uint64(b)), nil
+	case avroBytes:
+		return d.readBytes()
+	case avroString:
+		b, err := d.readBytes()
+		if err != nil {
+			return nil, err
+		}
+		return string(b), nil
+	case avroFixed:
+		return d.readFixed(s.size)
+	case avroEnum:
+		idx, err := d.readLong()
+		if err != nil {
+			return nil, err
+		}
+		if idx < 0 || int(idx) >= len(s.symbols) {
+			return nil, errors.Errorf("avro enum index %d out of range of %s", idx, s)
+		}
+		return s.symbols[idx], nil
+	case avroUnion:
+		idx, err := d.readLong()
+		if err != nil {
+			return nil, err
+		}
+		if idx < 0 || int(idx) >= len(s.branches) {
+			return nil, errors.Errorf("avro union index %d out of range of %s", idx, s)
+		}
+		branch := s.branches[idx]
+		v, err := d.decode(branch)
+		if err != nil || v == nil {
+			return v, err
+		}
+		return avroUnionValue{schema: branch, value: v}, nil
+	case avroRecord:
+		values := make([]any, len(s.fields))
+		for i, f := range s.fields {
+			v, err := d.decode(f.schema)
+			if err != nil {
+				return nil, errors.Annotatef(err, "field %s", f.name)
+			}
+			values[i] = v
+		}
+		return values, nil
+	case avroArray:
+		var items []any
+		err := d.decodeBlocks(func() error {
+			v, err := d.decode(s.items)
+			items = append(items, v)
+			return err
+		})
+		return items, err
+	case avroMap:
+		m := make(map[string]any)
+		err := d.decodeBlocks(func() error {
+			key, err := d.readBytes()
+			if err != nil {
+				return err
+			}
+			v, err := d.decode(s.values)
+			m[string(key)] = v
+			return err
+		})
+		return m, err
+	default:
+		return nil, errors.Errorf("unsupported avro type %s", s)
+	}
+}
+
+// decodeBlocks decodes the blocks of an array or a map.
+func (d *avroDecoder) decodeBlocks(fn func() error) error {
+	for {
+		count, err := d.readLong()
+		if err != nil {
+			return err
+		}
+		if count == 0 {
+			return nil
+		}
+		if count < 0 {
+			// the block size follows the negative count.
+			count = -count
+			if _, err := d.readLong(); err != nil {
+				return err
+			}
+		}
+		for range count {
+			if err := fn(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// avroHeader is the header of an object container file.
+type avroHeader struct {
+	schema *avroSchema
+	codec  string
+	sync   []byte
+	// size is the size of the header, the first block starts here.
+	size int64
+}
+
+// readAvroHeader reads the header of the file from the beginning of the reader.
+func readAvroHeader(r io.Reader) (*avroHeader, error) {
+	br := &avroByteReader{r: r}
+	magic := make([]byte, len(avroMagic))
+	if _, err := io.ReadFull(br, magic); err != nil {
+		return nil, errors.Annotate(err, "failed to read the avro magic")
+	}
+	if !bytes.Equal(magic, avroMagic) {
+		return nil, errors.New("not an avro object container file")
+	}
+	meta := make(map[string][]byte)
+	for {
+		count, err := binary.ReadVarint(br)
+		if err != nil {
+			return nil, errors.Annotate(err, "failed to read the avro metadata")
+		}
+		if count == 0 {
+			break
+		}
+		if count < 0 {
+			count = -count
+			if _, err := binary.ReadVarint(br); err != nil {
+				return nil, errors.Annotate(err, "failed to read the avro metadata")
+			}
+		}
+		for range count {
+			key, err := readAvroStreamBytes(br)
+			if err != nil {
+				return nil, errors.Annotate(err, "failed to read the avro metadata")
+			}
+			value, err := readAvroStreamBytes(br)
+			if err != nil {
+				return nil, errors.Annotate(err, "failed to read the avro metadata")
+			}
+			meta[string(key)] = value
+		}
+	}
+	sync := make([]byte, avroSyncSize)
+	if _, err := io.ReadFull(br, sync); err != nil {
+		return nil, errors.Annotate(err, "failed to read the avro sync marker")
+	}
+	schema, err := parseAvroSchema(meta[avroSchemaKey])
+	if err != nil {
+		return nil, err
+	}
+	if schema.tp != avroRecord {
+		return nil, errors.Errorf("the avro schema should be a record, but got %s", schema)
+	}
+	codec := string(meta[avroCodecKey])
+	if codec == "" {
+		codec = avroCodecNull
+	}
+	switch codec {
+	case avroCodecNull, avroCodecDeflate, avroCodecSnappy, avroCodecZstandard, avroCodecBzip2:
+	default:
+		return nil, errors.Errorf("unsupported avro codec %q", codec)
+	}
+	return &avroHeader{schema: schema, codec: codec, sync: sync, size: br.n}, nil
+}
+
+// avroBlockHeader is the header of a block in the file.
+type avroBlockHeader struct {
+	// offset is the offset of the block in the file.
+	offset int64
+	count  int64
+	// dataSize is the size of the serialized objects in the block.
+	dataSize int64
+	// size is the size of the whole block including the header and the sync marker.
+	size int64
+}
+
+// readAvroBlockHeader reads the header of the block at offset from the reader, it returns io.EOF if there is
+// no more block.
+func readAvroBlockHeader(r io.Reader, offset int64) (avroBlockHeader, error) {
+	br := &avroByteReader{r: r}
+	count, err := binary.ReadVarint(br)
+	if err != nil {
+		if err == io.EOF {
+			return avroBlockHeader{}, io.EOF
+		}
+		return avroBlockHeader{}, errors.Annotatef(err, "failed to read the avro block at offset %d", offset)
+	}
+	dataSize, err := binary.ReadVarint(br)
+	if err != nil {
+		return avroBlockHeader{}, errors.Annotatef(err, "failed to read the avro block at offset %d", offset)
+	}
+	if count < 0 || dataSize < 0 {
+		return avroBlockHeader{}, errors.Errorf("invalid avro block at offset %d", offset)
+	}
+	return avroBlockHeader{
+		offset:   offset,
+		count:    count,
+		dataSize: dataSize,
+		size:     br.n + dataSize + avroSyncSize,
+	}, nil
+}
+
+// decompressAvroBlock decompresses the serialized objects of a block.
+func decompressAvroBlock(codec string, data []byte) ([]byte, error) {
+	switch codec {
+	case avroCodecNull:
+		return data, nil
+	case avroCodecDeflate:
+		return io.ReadAll(flate.NewReader(bytes.NewReader(data)))
+	case avroCodecSnappy:
+		// the compressed data is followed by the CRC32 checksum of the uncompressed data.
+		if len(data) < 4 {
+			return nil, errAvroShortBuffer
+		}
+		decoded, err := snappy.Decode(nil, data[:len(data)-4])
+		if err != nil {
+			return nil, err
+		}
+		if crc32.ChecksumIEEE(decoded) != binary.BigEndian.Uint32(data[len(data)-4:]) {
+			return nil, errors.New("avro snappy block checksum mismatch")
+		}
+		return decoded, nil
+	case avroCodecZstandard:
+		decoder, err := zstd.NewReader(nil)
+		if err != nil {
+			return nil, err
+		}
+		defer decoder.Close()
+		return decoder.DecodeAll(data, nil)
+	case avroCodecBzip2:
+		return io.ReadAll(bzip2.NewReader(bytes.NewReader(data)))
+	default:
+		return nil, errors.Errorf("unsupported avro codec %q", codec)
+	}
+}
+
+// avroByteReader reads the bytes one by one for binary.ReadVarint, and counts the read bytes.
+type avroByteReader struct {
+	r   io.Reader
+	buf [1]byte
+	n   int64
+}
+
+func (r *avroByteReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.n += int64(n)
+	return n, err
+}
+
+func (r *avroByteReader) ReadByte() (byte, error) {
+	if _, err := io.ReadFull(r, r.buf[:]); err != nil {
+		return 0, err
+	}
+	return r.buf[0], nil
+}
+
+func readAvroStreamBytes(r *avroByteReader) ([]byte, error) {
+	l, err := binary.ReadVarint(r)
+	if err != nil {
+		return nil, err
+	}
+	if l < 0 {
+		return nil, errors.Errorf("invalid avro bytes length %d", l)
+	}
+	b := make([]byte, l)
+	_, err = io.ReadFull(r, b)
+	return b, err
+}
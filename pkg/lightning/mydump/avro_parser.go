@@ -0,0 +1,692 @@
+// Copyright 2026 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mydump
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/tidb/pkg/lightning/config"
+	"github.com/pingcap/tidb/pkg/lightning/log"
+	"github.com/pingcap/tidb/pkg/types"
+	"go.uber.org/zap"
+	"golang.org/x/sync/errgroup"
+)
+
+// avroColumn is a column read from the fields of the Avro records.
+type avroColumn struct {
+	name string
+	// path is the indexes of the fields from the top level record.
+	path []int
+	// field is the dot separated path of the field used in the diagnostics.
+	field  string
+	schema *avroSchema
+}
+
+// avroBlock is a decoded block of the file.
+type avroBlock struct {
+	header avroBlockHeader
+	rows   [][]any
+}
+
+// AvroParser parses an Avro object container file for import.
+// It implements the Parser interface.
+//
+// Like the orc files, the position of the parser is the index of the next row in the file, and the next
+// blocks are read and decoded in parallel.
+type AvroParser struct {
+	reader            ReadSeekCloser
+	bufReader         *bufio.Reader
+	path              string
+	header            *avroHeader
+	columns           []avroColumn
+	columnNames       []string
+	decodeConcurrency int
+	logger            log.Logger
+
+	// nextOffset is the offset of the next block to read from the reader.
+	nextOffset int64
+	pending    []*avroBlock
+	block      *avroBlock
+	rowIdx     int
+	// curRow is the index of the next row in the file.
+	curRow  int64
+	lastRow Row
+}
+
+// NewAvroParser creates an Avro parser, the reader should be at the beginning of the file.
+func NewAvroParser(
+	ctx context.Context,
+	cfg *config.AvroConfig,
+	reader ReadSeekCloser,
+	path string,
+) (*AvroParser, error) {
+	bufReader := bufio.NewReader(reader)
+	header, err := readAvroHeader(bufReader)
+	if err != nil {
+		return nil, errors.Annotatef(err, "failed to read the avro file %s", path)
+	}
+	columns, err := resolveAvroColumns(header.schema, cfg.FieldMappings)
+	if err != nil {
+		return nil, errors.Annotatef(err, "failed to map the fields of the avro file %s", path)
+	}
+	logger := log.FromContext(ctx)
+	names := make([]string, 0, len(columns))
+	for _, col := range columns {
+		names = append(names, col.name)
+		logger.Debug("map the avro field to the column", zap.String("file", path), zap.String("field", col.field),
+			zap.Stringer("avro-type", col.schema), zap.String("column", col.name),
+			zap.String("conversion", avroConversion(col.schema)))
+	}
+	p := &AvroParser{
+		reader:            reader,
+		bufReader:         bufReader,
+		path:              path,
+		header:            header,
+		columns:           columns,
+		columnNames:       names,
+		decodeConcurrency: max(cfg.DecodeConcurrency, 1),
+		logger:            logger,
+		nextOffset:        header.size,
+	}
+	return p, nil
+}
+
+// resolveAvroColumns resolves the columns by the mapping rules, the top level fields are the columns if
+// there is no rule.
+func resolveAvroColumns(schema *avroSchema, mappings []*config.AvroFieldMapping) ([]avroColumn, error) {
+	if len(mappings) == 0 {
+		mappings = []*config.AvroFieldMapping{{Field: "*"}}
+	}
+	var columns []avroColumn
+	seen := make(map[string]string)
+	add := func(col avroColumn) error {
+		if field, ok := seen[col.name]; ok {
+			return errors.Errorf("the fields %s and %s are mapped to the same column %s", field, col.field, col.name)
+		}
+		seen[col.name] = col.field
+		columns = append(columns, col)
+		return nil
+	}
+	for _, m := range mappings {
+		elems := strings.Split(m.Field, ".")
+		record, path := schema, make([]int, 0, len(elems))
+		for i, elem := range elems {
+			record = nullableAvroRecord(record)
+			if record == nil {
+				return nil, errors.Errorf("the field %s isn't a record", strings.Join(elems[:i], "."))
+			}
+			if elem == "*" && i == len(elems)-1 {
+				break
+			}
+			idx, f := record.field(elem)
+			if f == nil {
+				return nil, errors.Errorf("the field %s isn't found in the avro schema", strings.Join(elems[:i+1], "."))
+			}
+			path = append(path, idx)
+			if i == len(elems)-1 {
+				name := m.Column
+				if name == "" {
+					name = f.name
+				}
+				if err := add(avroColumn{
+					name: strings.ToLower(name), path: path, field: m.Field, schema: f.schema,
+				}); err != nil {
+					return nil, err
+				}
+			}
+			record = f.schema
+		}
+		if elems[len(elems)-1] != "*" {
+			continue
+		}
+		prefix := strings.Join(elems[:len(elems)-1], ".")
+		for idx, f := range record.fields {
+			field := f.name
+			if prefix != "" {
+				field = prefix + "." + f.name
+			}
+			if err := add(avroColumn{
+				name: strings.ToLower(f.name), path: append(slices.Clone(path), idx), field: field, schema: f.schema,
+			}); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return columns, nil
+}
+
+// nullableAvroRecord returns the record of the schema, the record can be a branch of a union with null, e.g.
+// the `after` of the change events.
+func nullableAvroRecord(s *avroSchema) *avroSchema {
+	switch s.tp {
+	case avroRecord:
+		return s
+	case avroUnion:
+		var record *avroSchema
+		for _, branch := range s.branches {
+			switch {
+			case branch.tp == avroNull:
+			case branch.tp == avroRecord && record == nil:
+				record = branch
+			default:
+				return nil
+			}
+		}
+		return record
+	}
+	return nil
+}
+
+// avroConversion describes how the values of the schema are converted, it's used in the diagnostics.
+func avroConversion(s *avroSchema) string {
+	switch s.tp {
+	case avroUnion:
+		conversions := make([]string, 0, len(s.branches))
+		for _, branch := range s.branches {
+			if branch.tp != avroNull {
+				conversions = append(conversions, avroConversion(branch))
+			}
+		}
+		return strings.Join(conversions, "|")
+	case avroRecord, avroArray, avroMap:
+		return "json text"
+	case avroEnum:
+		return "enum symbol"
+	case avroBoolean:
+		return "0/1"
+	}
+	switch s.logicalType {
+	case "decimal":
+		return "decimal text"
+	case "date":
+		return "date text"
+	case "time-millis", "time-micros":
+		return "time text"
+	case "timestamp-millis", "timestamp-micros", "timestamp-nanos":
+		return "utc datetime text"
+	case "local-timestamp-millis", "local-timestamp-micros", "local-timestamp-nanos":
+		return "datetime text"
+	}
+	return s.tp.String()
+}
+
+// Pos returns the index of the next row.
+// It implements the Parser interface.
+func (p *AvroParser) Pos() (pos int64, rowID int64) {
+	return p.curRow, p.lastRow.RowID
+}
+
+// SetPos sets the index of the next row, the blocks before it are skipped by the block headers.
+// It implements the Parser interface.
+func (p *AvroParser) SetPos(pos int64, rowID int64) error {
+	p.lastRow.RowID = rowID
+	p.pending, p.block, p.rowIdx = nil, nil, 0
+	p.curRow, p.nextOffset = pos, p.header.size
+	if err := p.seek(p.nextOffset); err != nil {
+		return err
+	}
+	for row := int64(0); row < pos; {
+		header, err := readAvroBlockHeader(p.bufReader, p.nextOffset)
+		if err == io.EOF {
+			return p.seek(p.nextOffset)
+		}
+		if err != nil {
+			return errors.Annotatef(err, "in %s", p.path)
+		}
+		if pos < row+header.count {
+			if err := p.seek(p.nextOffset); err != nil {
+				return err
+			}
+			blocks, err := p.decodeBlocks(1)
+			if err != nil {
+				return err
+			}
+			p.block, p.rowIdx = blocks[0], int(pos-row)
+			return nil
+		}
+		row += header.count
+		p.nextOffset += header.size
+		if err := skipAvroBlockData(p.reader, p.bufReader, header); err != nil {
+			return err
+		}
+	}
+	return p.seek(p.nextOffset)
+}
+
+func (p *AvroParser) seek(offset int64) error {
+	if _, err := p.reader.Seek(offset, io.SeekStart); err != nil {
+		return errors.Trace(err)
+	}
+	p.bufReader.Reset(p.reader)
+	return nil
+}
+
+// rawAvroBlock is a block read from the file but not decoded.
+type rawAvroBlock struct {
+	header avroBlockHeader
+	data   []byte
+}
+
+// decodeBlocks reads at most n blocks from nextOffset and decodes them in parallel, it returns no block at
+// the end of the file.
+func (p *AvroParser) decodeBlocks(n int) ([]*avroBlock, error) {
+	raws := make([]rawAvroBlock, 0, n)
+	for range n {
+		header, err := readAvroBlockHeader(p.bufReader, p.nextOffset)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.Annotatef(err, "in %s", p.path)
+		}
+		data := make([]byte, header.dataSize+avroSyncSize)
+		if _, err := io.ReadFull(p.bufReader, data); err != nil {
+			return nil, errors.Annotatef(err, "failed to read the avro block at offset %d in %s", header.offset, p.path)
+		}
+		if !bytes.Equal(data[header.dataSize:], p.header.sync) {
+			return nil, errors.Errorf("the sync marker of the avro block at offset %d in %s mismatches, "+
+				"the file may be corrupted", header.offset, p.path)
+		}
+		raws = append(raws, rawAvroBlock{header: header, data: data[:header.dataSize]})
+		p.nextOffset += header.size
+	}
+
+	blocks := make([]*avroBlock, len(raws))
+	var eg errgroup.Group
+	for i, raw := range raws {
+		eg.Go(func() error {
+			block, err := p.decodeBlock(raw)
+			blocks[i] = block
+			return err
+		})
+	}
+	return blocks, eg.Wait()
+}
+
+func (p *AvroParser) decodeBlock(raw rawAvroBlock) (*avroBlock, error) {
+	data, err := decompressAvroBlock(p.header.codec, raw.data)
+	if err != nil {
+		return nil, errors.Annotatef(err, "failed to decompress the avro block at offset %d in %s by %s",
+			raw.header.offset, p.path, p.header.codec)
+	}
+	d := &avroDecoder{data: data}
+	block := &avroBlock{header: raw.header, rows: make([][]any, 0, raw.header.count)}
+	for i := range raw.header.count {
+		v, err := d.decode(p.header.schema)
+		if err != nil {
+			return nil, errors.Annotatef(err, "failed to decode the row %d of the avro block at offset %d in %s",
+				i, raw.header.offset, p.path)
+		}
+		//nolint: forcetypeassert
+		block.rows = append(block.rows, v.([]any))
+	}
+	if d.pos != len(d.data) {
+		return nil, errors.Errorf("%d bytes are left after decoding the avro block at offset %d in %s, "+
+			"the schema may mismatch the data", len(d.data)-d.pos, raw.header.offset, p.path)
+	}
+	return block, nil
+}
+
+// ScannedPos implements the Parser interface.
+// For avro it's the offset of the blocks read from the file.
+func (p *AvroParser) ScannedPos() (int64, error) {
+	return p.nextOffset, nil
+}
+
+// Close closes the file of the parser.
+// It implements the Parser interface.
+func (p *AvroParser) Close() error {
+	return p.reader.Close()
+}
+
+// ReadRow reads a row in the avro file by the parser.
+// It implements the Parser interface.
+func (p *AvroParser) ReadRow() error {
+	p.lastRow.RowID++
+	p.lastRow.Length = 0
+	for p.block == nil || p.rowIdx >= len(p.block.rows) {
+		if len(p.pending) == 0 {
+			blocks, err := p.decodeBlocks(p.decodeConcurrency)
+			if err != nil {
+				return err
+			}
+			if len(blocks) == 0 {
+				return io.EOF
+			}
+			p.pending = blocks
+		}
+		p.block, p.pending, p.rowIdx = p.pending[0], p.pending[1:], 0
+	}
+
+	record := p.block.rows[p.rowIdx]
+	if cap(p.lastRow.Row) < len(p.columns) {
+		p.lastRow.Row = make([]types.Datum, len(p.columns))
+	} else {
+		p.lastRow.Row = p.lastRow.Row[:len(p.columns)]
+	}
+	for i := range p.columns {
+		col := &p.columns[i]
+		v, schema := avroColumnValue(record, col)
+		length, err := setAvroDatum(&p.lastRow.Row[i], v, schema)
+		if err != nil {
+			return errors.Annotatef(err, "failed to convert the avro field %s (%s) to the column %s at row %d "+
+				"of the block at offset %d in %s", col.field, col.schema, col.name, p.rowIdx,
+				p.block.header.offset, p.path)
+		}
+		p.lastRow.Length += length
+	}
+	p.rowIdx++
+	p.curRow++
+	return nil
+}
+
+// avroColumnValue returns the value of the column in the record and the schema of it.
+func avroColumnValue(record []any, col *avroColumn) (any, *avroSchema) {
+	var v any = record
+	for _, idx := range col.path {
+		if u, ok := v.(avroUnionValue); ok {
+			v = u.value
+		}
+		values, ok := v.([]any)
+		if !ok {
+			// a nullable record is null.
+			return nil, col.schema
+		}
+		v = values[idx]
+	}
+	return v, col.schema
+}
+
+// setAvroDatum converts the value of the avro schema to the datum, and returns the length of the value.
+func setAvroDatum(d *types.Datum, v any, s *avroSchema) (int, error) {
+	if u, ok := v.(avroUnionValue); ok {
+		return setAvroDatum(d, u.value, u.schema)
+	}
+	switch x := v.(type) {
+	case nil:
+		d.SetNull()
+		return 0, nil
+	case bool:
+		if x {
+			d.SetUint64(1)
+		} else {
+			d.SetUint64(0)
+		}
+		return 1, nil
+	case int32:
+		return 4, setAvroDatumByInt(d, int64(x), s)
+	case int64:
+		return 8, setAvroDatumByInt(d, x, s)
+	case float32:
+		d.SetFloat32(x)
+		return 4, nil
+	case float64:
+		d.SetFloat64(x)
+		return 8, nil
+	case string:
+		d.SetString(x, "utf8mb4_bin")
+		return len(x), nil
+	case []byte:
+		if s.logicalType == "decimal" {
+			if len(x) == 0 {
+				return 0, errors.New("empty bytes of the decimal")
+			}
+			// binaryToDecimalStr changes the bytes.
+			d.SetString(binaryToDecimalStr(slices.Clone(x), s.scale), "utf8mb4_bin")
+			return len(x), nil
+		}
+		d.SetBytes(x)
+		return len(x), nil
+	case []any, map[string]any:
+		text, err := avroToJSON(v, s)
+		if err != nil {
+			return 0, err
+		}
+		d.SetString(string(text), "utf8mb4_bin")
+		return len(text), nil
+	default:
+		return 0, errors.Errorf("unexpected value %T of the avro type %s", v, s)
+	}
+}
+
+func setAvroDatumByInt(d *types.Datum, v int64, s *avroSchema) error {
+	switch s.logicalType {
+	case "date":
+		d.SetString(time.Unix(v*secPerDay, 0).UTC().Format(time.DateOnly), "utf8mb4_bin")
+	case "time-millis":
+		d.SetString(time.UnixMilli(v).UTC().Format("15:04:05.999999"), "utf8mb4_bin")
+	case "time-micros":
+		d.SetString(time.UnixMicro(v).UTC().Format("15:04:05.999999"), "utf8mb4_bin")
+	case "timestamp-millis":
+		d.SetString(time.UnixMilli(v).UTC().Format(utcTimeLayout), "utf8mb4_bin")
+	case "timestamp-micros":
+		d.SetString(time.UnixMicro(v).UTC().Format(utcTimeLayout), "utf8mb4_bin")
+	case "timestamp-nanos":
+		d.SetString(time.Unix(0, v).UTC().Format(utcTimeLayout), "utf8mb4_bin")
+	case "local-timestamp-millis":
+		d.SetString(time.UnixMilli(v).UTC().Format(timeLayout), "utf8mb4_bin")
+	case "local-timestamp-micros":
+		d.SetString(time.UnixMicro(v).UTC().Format(timeLayout), "utf8mb4_bin")
+	case "local-timestamp-nanos":
+		d.SetString(time.Unix(0, v).UTC().Format(timeLayout), "utf8mb4_bin")
+	default:
+		d.SetInt64(v)
+	}
+	return nil
+}
+
+// avroToJSON encodes the record, array or map as JSON, the fields of the records are kept in order.
+func avroToJSON(v any, s *avroSchema) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := writeAvroJSON(&buf, v, s); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func writeAvroJSON(buf *bytes.Buffer, v any, s *avroSchema) error {
+	if u, ok := v.(avroUnionValue); ok {
+		return writeAvroJSON(buf, u.value, u.schema)
+	}
+	switch x := v.(type) {
+	case []any:
+		if s.tp == avroRecord {
+			buf.WriteByte('{')
+			for i, f := range s.fields {
+				if i > 0 {
+					buf.WriteByte(',')
+				}
+				key, _ := json.Marshal(f.name)
+				buf.Write(key)
+				buf.WriteByte(':')
+				if err := writeAvroJSON(buf, x[i], f.schema); err != nil {
+					return err
+				}
+			}
+			buf.WriteByte('}')
+			return nil
+		}
+		buf.WriteByte('[')
+		for i, item := range x {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeAvroJSON(buf, item, s.items); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+		return nil
+	case map[string]any:
+		keys := make([]string, 0, len(x))
+		for key := range x {
+			keys = append(keys, key)
+		}
+		slices.Sort(keys)
+		buf.WriteByte('{')
+		for i, key := range keys {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			encoded, _ := json.Marshal(key)
+			buf.Write(encoded)
+			buf.WriteByte(':')
+			if err := writeAvroJSON(buf, x[key], s.values); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+		return nil
+	case []byte:
+		if s.logicalType == "decimal" && len(x) > 0 {
+			buf.WriteString(binaryToDecimalStr(slices.Clone(x), s.scale))
+			return nil
+		}
+	}
+	encoded, err := json.Marshal(v)
+	if err != nil {
+		return errors.Annotatef(err, "failed to encode the avro value %v as json", v)
+	}
+	buf.Write(encoded)
+	return nil
+}
+
+// LastRow gets the last row parsed by the parser.
+// It implements the Parser interface.
+func (p *AvroParser) LastRow() Row {
+	return p.lastRow
+}
+
+// RecycleRow implements the Parser interface.
+func (*AvroParser) RecycleRow(_ Row) {
+}
+
+// Columns returns the _lower-case_ column names corresponding to values in
+// the LastRow.
+func (p *AvroParser) Columns() []string {
+	return p.columnNames
+}
+
+// SetColumns set restored column names to parser
+func (*AvroParser) SetColumns(_ []string) {
+	// just do nothing
+}
+
+// SetLogger sets the logger used in the parser.
+// It implements the Parser interface.
+func (p *AvroParser) SetLogger(l log.Logger) {
+	p.logger = l
+}
+
+// SetRowID sets the rowID in an avro file.
+// It implements the Parser interface.
+func (p *AvroParser) SetRowID(rowID int64) {
+	p.lastRow.RowID = rowID
+}
+
+// makeAvroFileRegion splits the avro file into the regions of about cfg.MaxChunkSize by the block headers.
+// The regions start at the blocks, and like the orc files, the offsets of the regions are the indexes of the
+// rows.
+func makeAvroFileRegion(
+	ctx context.Context,
+	cfg *DataDivideConfig,
+	dataFile FileInfo,
+) ([]*TableRegion, []float64, error) {
+	r, err := cfg.Store.Open(ctx, dataFile.FileMeta.Path, nil)
+	if err != nil {
+		return nil, nil, errors.Trace(err)
+	}
+	defer r.Close()
+	bufReader := bufio.NewReader(r)
+	header, err := readAvroHeader(bufReader)
+	if err != nil {
+		return nil, nil, errors.Annotatef(err, "failed to read the avro file %s", dataFile.FileMeta.Path)
+	}
+
+	var (
+		regions         []*TableRegion
+		sizes           []float64
+		regionStart     = header.size
+		offset          = header.size
+		rowIDBase, rows int64
+	)
+	addRegion := func(end int64) {
+		regions = append(regions, &TableRegion{
+			DB:       cfg.TableMeta.DB,
+			Table:    cfg.TableMeta.Name,
+			FileMeta: dataFile.FileMeta,
+			Chunk: Chunk{
+				Offset:       rowIDBase,
+				EndOffset:    rowIDBase + rows,
+				PrevRowIDMax: rowIDBase,
+				RowIDMax:     rowIDBase + rows,
+			},
+		})
+		sizes = append(sizes, float64(end-regionStart))
+		regionStart, rowIDBase, rows = end, rowIDBase+rows, 0
+	}
+	for {
+		block, err := readAvroBlockHeader(bufReader, offset)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, errors.Annotatef(err, "in %s", dataFile.FileMeta.Path)
+		}
+		if rows > 0 && offset-regionStart >= cfg.MaxChunkSize {
+			addRegion(offset)
+		}
+		rows += block.count
+		offset += block.size
+		if err := skipAvroBlockData(r, bufReader, block); err != nil {
+			return nil, nil, err
+		}
+	}
+	addRegion(max(offset, dataFile.FileMeta.FileSize))
+	if len(regions) > 1 {
+		log.FromContext(ctx).Info("split the avro file by the blocks", zap.String("file", dataFile.FileMeta.Path),
+			zap.Int("regions", len(regions)), zap.Int64("rows", rowIDBase))
+	}
+	return regions, sizes, nil
+}
+
+// skipAvroBlockData skips the data of the block whose header is just read from bufReader, the small blocks
+// are skipped in the buffer.
+func skipAvroBlockData(r io.ReadSeeker, bufReader *bufio.Reader, block avroBlockHeader) error {
+	skip := block.dataSize + avroSyncSize
+	if skip <= int64(bufReader.Buffered()) {
+		_, _ = bufReader.Discard(int(skip))
+		return nil
+	}
+	if _, err := r.Seek(block.offset+block.size, io.SeekStart); err != nil {
+		return errors.Trace(err)
+	}
+	bufReader.Reset(r)
+	return nil
+}
+
+// String implements fmt.Stringer for the diagnostics.
+func (col avroColumn) String() string {
+	return fmt.Sprintf("%s(%s)->%s", col.field, col.schema, col.name)
+}
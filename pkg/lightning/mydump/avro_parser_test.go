@@ -0,0 +1,354 @@
+// Copyright 2026 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mydump
+
+import (
+	"bytes"
+	"compress/flate"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/pingcap/tidb/br/pkg/storage"
+	"github.com/pingcap/tidb/pkg/lightning/config"
+	"github.com/pingcap/tidb/pkg/types"
+	"github.com/stretchr/testify/require"
+)
+
+// avroTestWriter writes an avro object container file, the rows are encoded by the caller.
+type avroTestWriter struct {
+	buf   bytes.Buffer
+	codec string
+	sync  []byte
+}
+
+func newAvroTestWriter(schema, codec string) *avroTestWriter {
+	w := &avroTestWriter{codec: codec, sync: []byte("0123456789abcdef")}
+	w.buf.Write(avroMagic)
+	w.buf.Write(binary.AppendVarint(nil, 2))
+	for _, kv := range [][2]string{{avroSchemaKey, schema}, {avroCodecKey, codec}} {
+		w.buf.Write(avroTestBytes(kv[0]))
+		w.buf.Write(avroTestBytes(kv[1]))
+	}
+	w.buf.Write(binary.AppendVarint(nil, 0))
+	w.buf.Write(w.sync)
+	return w
+}
+
+// writeBlock writes a block holding the rows, and returns the offset of the block.
+func (w *avroTestWriter) writeBlock(t *testing.T, rows ...[]byte) int64 {
+	offset := int64(w.buf.Len())
+	data := bytes.Join(rows, nil)
+	if w.codec == avroCodecDeflate {
+		var compressed bytes.Buffer
+		fw, err := flate.NewWriter(&compressed, flate.DefaultCompression)
+		require.NoError(t, err)
+		_, err = fw.Write(data)
+		require.NoError(t, err)
+		require.NoError(t, fw.Close())
+		data = compressed.Bytes()
+	}
+	w.buf.Write(binary.AppendVarint(nil, int64(len(rows))))
+	w.buf.Write(binary.AppendVarint(nil, int64(len(data))))
+	w.buf.Write(data)
+	w.buf.Write(w.sync)
+	return offset
+}
+
+func (w *avroTestWriter) store(t *testing.T, name string) storage.ExternalStorage {
+	s, err := storage.NewLocalStorage(t.TempDir())
+	require.NoError(t, err)
+	require.NoError(t, s.WriteFile(context.Background(), name, w.buf.Bytes()))
+	return s
+}
+
+func avroTestLong(v int64) []byte {
+	return binary.AppendVarint(nil, v)
+}
+
+func avroTestBytes(s string) []byte {
+	return append(avroTestLong(int64(len(s))), s...)
+}
+
+func openAvroTestParser(t *testing.T, s storage.ExternalStorage, name string, cfg *config.AvroConfig) *AvroParser {
+	r, err := s.Open(context.Background(), name, nil)
+	require.NoError(t, err)
+	p, err := NewAvroParser(context.Background(), cfg, r, name)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = p.Close() })
+	return p
+}
+
+const avroTestSchema = `{
+	"type": "record", "name": "Row", "namespace": "test",
+	"fields": [
+		{"name": "ID", "type": "long"},
+		{"name": "name", "type": ["null", "string"]},
+		{"name": "ok", "type": "boolean"},
+		{"name": "score", "type": "double"},
+		{"name": "price", "type": {"type": "bytes", "logicalType": "decimal", "precision": 10, "scale": 2}},
+		{"name": "day", "type": {"type": "int", "logicalType": "date"}},
+		{"name": "ts", "type": {"type": "long", "logicalType": "timestamp-millis"}},
+		{"name": "color", "type": {"type": "enum", "name": "Color", "symbols": ["RED", "BLUE"]}},
+		{"name": "tags", "type": {"type": "array", "items": "string"}},
+		{"name": "extra", "type": ["null", {"type": "record", "name": "Extra", "fields": [
+			{"name": "city", "type": "string"},
+			{"name": "zip", "type": "int"}
+		]}]}
+	]
+}`
+
+func avroTestRow(id int64, name string, extra bool) []byte {
+	var row []byte
+	row = append(row, avroTestLong(id)...)
+	if name == "" {
+		row = append(row, avroTestLong(0)...)
+	} else {
+		row = append(row, avroTestLong(1)...)
+		row = append(row, avroTestBytes(name)...)
+	}
+	row = append(row, 1)
+	var score [8]byte
+	binary.LittleEndian.PutUint64(score[:], 0x3ff8000000000000) // 1.5
+	row = append(row, score[:]...)
+	row = append(row, avroTestLong(2)...)
+	row = append(row, 0x30, 0x39) // 12345
+	row = append(row, avroTestLong(19000)...)
+	row = append(row, avroTestLong(1_700_000_000_123)...)
+	row = append(row, avroTestLong(1)...)
+	row = append(row, avroTestLong(2)...)
+	row = append(row, avroTestBytes("a")...)
+	row = append(row, avroTestBytes("b")...)
+	row = append(row, avroTestLong(0)...)
+	if extra {
+		row = append(row, avroTestLong(1)...)
+		row = append(row, avroTestBytes("SF")...)
+		row = append(row, avroTestLong(94000)...)
+	} else {
+		row = append(row, avroTestLong(0)...)
+	}
+	return row
+}
+
+func TestAvroParser(t *testing.T) {
+	for _, codec := range []string{avroCodecNull, avroCodecDeflate} {
+		t.Run(codec, func(t *testing.T) {
+			w := newAvroTestWriter(avroTestSchema, codec)
+			w.writeBlock(t, avroTestRow(1, "alice", true), avroTestRow(2, "", false))
+			w.writeBlock(t, avroTestRow(3, "bob", false))
+			s := w.store(t, "test.avro")
+
+			p := openAvroTestParser(t, s, "test.avro", &config.AvroConfig{DecodeConcurrency: 2})
+			require.Equal(t, []string{"id", "name", "ok", "score", "price", "day", "ts", "color", "tags", "extra"},
+				p.Columns())
+
+			require.NoError(t, p.ReadRow())
+			row := p.LastRow()
+			require.Equal(t, int64(1), row.RowID)
+			require.Equal(t, []types.Datum{
+				types.NewIntDatum(1),
+				types.NewCollationStringDatum("alice", "utf8mb4_bin"),
+				types.NewUintDatum(1),
+				types.NewFloat64Datum(1.5),
+				types.NewCollationStringDatum("123.45", "utf8mb4_bin"),
+				types.NewCollationStringDatum("2022-01-08", "utf8mb4_bin"),
+				types.NewCollationStringDatum("2023-11-14 22:13:20.123Z", "utf8mb4_bin"),
+				types.NewCollationStringDatum("BLUE", "utf8mb4_bin"),
+				types.NewCollationStringDatum(`["a","b"]`, "utf8mb4_bin"),
+				types.NewCollationStringDatum(`{"city":"SF","zip":94000}`, "utf8mb4_bin"),
+			}, row.Row)
+
+			require.NoError(t, p.ReadRow())
+			row = p.LastRow()
+			require.True(t, row.Row[1].IsNull())
+			require.True(t, row.Row[9].IsNull())
+
+			require.NoError(t, p.ReadRow())
+			require.Equal(t, int64(3), p.LastRow().RowID)
+			require.Equal(t, types.NewIntDatum(3), p.LastRow().Row[0])
+			require.ErrorIs(t, p.ReadRow(), io.EOF)
+			pos, rowID := p.Pos()
+			require.Equal(t, int64(3), pos)
+			require.Equal(t, int64(4), rowID)
+		})
+	}
+}
+
+func TestAvroFieldMapping(t *testing.T) {
+	w := newAvroTestWriter(avroTestSchema, avroCodecNull)
+	w.writeBlock(t, avroTestRow(1, "alice", true), avroTestRow(2, "bob", false))
+	s := w.store(t, "test.avro")
+
+	p := openAvroTestParser(t, s, "test.avro", &config.AvroConfig{FieldMappings: []*config.AvroFieldMapping{
+		{Field: "id", Column: "user_id"},
+		{Field: "extra.*"},
+	}})
+	require.Equal(t, []string{"user_id", "city", "zip"}, p.Columns())
+	require.NoError(t, p.ReadRow())
+	require.Equal(t, []types.Datum{
+		types.NewIntDatum(1),
+		types.NewCollationStringDatum("SF", "utf8mb4_bin"),
+		types.NewIntDatum(94000),
+	}, p.LastRow().Row)
+	// the fields of the null record are null.
+	require.NoError(t, p.ReadRow())
+	require.True(t, p.LastRow().Row[1].IsNull())
+	require.True(t, p.LastRow().Row[2].IsNull())
+
+	for _, c := range []struct {
+		mappings []*config.AvroFieldMapping
+		err      string
+	}{
+		{[]*config.AvroFieldMapping{{Field: "missing"}}, "the field missing isn't found"},
+		{[]*config.AvroFieldMapping{{Field: "name.x"}}, "the field name isn't a record"},
+		{[]*config.AvroFieldMapping{{Field: "id"}, {Field: "name", Column: "ID"}}, "mapped to the same column id"},
+	} {
+		r, err := s.Open(context.Background(), "test.avro", nil)
+		require.NoError(t, err)
+		_, err = NewAvroParser(context.Background(), &config.AvroConfig{FieldMappings: c.mappings}, r, "test.avro")
+		require.ErrorContains(t, err, c.err)
+		require.NoError(t, r.Close())
+	}
+}
+
+func TestAvroConversionDiagnostics(t *testing.T) {
+	w := newAvroTestWriter(`{"type": "record", "name": "r", "fields": [
+		{"name": "price", "type": {"type": "bytes", "logicalType": "decimal", "precision": 4, "scale": 2}}
+	]}`, avroCodecNull)
+	offset := w.writeBlock(t, avroTestBytes("\x01"), avroTestBytes(""))
+	s := w.store(t, "bad.avro")
+
+	p := openAvroTestParser(t, s, "bad.avro", &config.AvroConfig{})
+	require.NoError(t, p.ReadRow())
+	require.Equal(t, types.NewCollationStringDatum("0.01", "utf8mb4_bin"), p.LastRow().Row[0])
+	err := p.ReadRow()
+	require.ErrorContains(t, err, fmt.Sprintf("failed to convert the avro field price (bytes(decimal)) to the "+
+		"column price at row 1 of the block at offset %d in bad.avro", offset))
+
+	// the data not matching the schema.
+	w = newAvroTestWriter(`{"type": "record", "name": "r", "fields": [{"name": "a", "type": "long"}]}`,
+		avroCodecNull)
+	offset = w.writeBlock(t, append(avroTestLong(1), 0))
+	s = w.store(t, "bad.avro")
+	p = openAvroTestParser(t, s, "bad.avro", &config.AvroConfig{})
+	require.ErrorContains(t, p.ReadRow(), fmt.Sprintf("1 bytes are left after decoding the avro block at offset %d", offset))
+}
+
+func TestMakeAvroFileRegionAndSetPos(t *testing.T) {
+	w := newAvroTestWriter(`{"type": "record", "name": "r", "fields": [{"name": "a", "type": "long"}]}`,
+		avroCodecNull)
+	var offsets []int64
+	id := int64(0)
+	for range 10 {
+		rows := make([][]byte, 0, 5)
+		for range 5 {
+			rows = append(rows, avroTestLong(id))
+			id++
+		}
+		offsets = append(offsets, w.writeBlock(t, rows...))
+	}
+	s := w.store(t, "test.avro")
+	fileSize := int64(w.buf.Len())
+
+	cfg := &DataDivideConfig{
+		Store:        s,
+		MaxChunkSize: offsets[3] - offsets[0],
+		TableMeta:    &MDTableMeta{DB: "db", Name: "t"},
+	}
+	fileInfo := FileInfo{FileMeta: SourceFileMeta{Path: "test.avro", Type: SourceTypeAvro, FileSize: fileSize}}
+	regions, sizes, err := makeAvroFileRegion(context.Background(), cfg, fileInfo)
+	require.NoError(t, err)
+	require.Len(t, regions, 4)
+	require.Len(t, sizes, 4)
+	for i, region := range regions {
+		require.Equal(t, int64(i*15), region.Chunk.Offset)
+		require.Equal(t, int64(i*15), region.Chunk.PrevRowIDMax)
+		if i < 3 {
+			require.Equal(t, int64(i*15+15), region.Chunk.EndOffset)
+			require.Equal(t, int64(i*15+15), region.Chunk.RowIDMax)
+			require.Equal(t, float64(offsets[i*3+3]-offsets[i*3]), sizes[i])
+		} else {
+			require.Equal(t, int64(50), region.Chunk.EndOffset)
+			require.Equal(t, int64(50), region.Chunk.RowIDMax)
+			require.Equal(t, float64(fileSize-offsets[i*3]), sizes[i])
+		}
+	}
+
+	// read the regions like the chunk processor.
+	var ids []int64
+	for _, region := range regions {
+		p := openAvroTestParser(t, s, "test.avro", &config.AvroConfig{DecodeConcurrency: 3})
+		require.NoError(t, p.SetPos(region.Chunk.Offset, region.Chunk.PrevRowIDMax))
+		for {
+			pos, _ := p.Pos()
+			if pos >= region.Chunk.EndOffset {
+				break
+			}
+			require.NoError(t, p.ReadRow())
+			require.Equal(t, int64(len(ids)+1), p.LastRow().RowID)
+			ids = append(ids, p.LastRow().Row[0].GetInt64())
+		}
+	}
+	require.Len(t, ids, 50)
+	for i, v := range ids {
+		require.Equal(t, int64(i), v)
+	}
+
+	// resume from the checkpoint in the middle of a block.
+	p := openAvroTestParser(t, s, "test.avro", &config.AvroConfig{})
+	require.NoError(t, p.SetPos(20, 20))
+	require.NoError(t, p.ReadRow())
+	require.NoError(t, p.ReadRow())
+	pos, rowID := p.Pos()
+	require.Equal(t, int64(22), pos)
+	require.Equal(t, int64(22), rowID)
+	p = openAvroTestParser(t, s, "test.avro", &config.AvroConfig{})
+	require.NoError(t, p.SetPos(pos, rowID))
+	require.NoError(t, p.ReadRow())
+	require.Equal(t, int64(23), p.LastRow().RowID)
+	require.Equal(t, types.NewIntDatum(22), p.LastRow().Row[0])
+}
+
+func TestAvroSetPosInDenseBlock(t *testing.T) {
+	w := newAvroTestWriter(`{"type": "record", "name": "r", "fields": [{"name": "a", "type": "long"}]}`,
+		avroCodecDeflate)
+	w.writeBlock(t, avroTestLong(-1))
+	rows := make([][]byte, 0, 1000)
+	for i := range 1000 {
+		rows = append(rows, avroTestLong(int64(i%10)))
+	}
+	offset := w.writeBlock(t, rows...)
+	s := w.store(t, "dense.avro")
+	// the block holds more rows than bytes.
+	require.Less(t, int64(w.buf.Len())-offset, int64(1000))
+
+	// every row of the block has its own position, so the rows read aren't read again on resume.
+	p := openAvroTestParser(t, s, "dense.avro", &config.AvroConfig{})
+	require.NoError(t, p.SetPos(601, 601))
+	require.NoError(t, p.ReadRow())
+	require.Equal(t, int64(602), p.LastRow().RowID)
+	require.Equal(t, types.NewIntDatum(0), p.LastRow().Row[0])
+	require.NoError(t, p.ReadRow())
+	require.Equal(t, types.NewIntDatum(1), p.LastRow().Row[0])
+	pos, rowID := p.Pos()
+	require.Equal(t, int64(603), pos)
+	require.Equal(t, int64(603), rowID)
+
+	p = openAvroTestParser(t, s, "dense.avro", &config.AvroConfig{})
+	require.NoError(t, p.SetPos(1001, 1001))
+	require.ErrorIs(t, p.ReadRow(), io.EOF)
+}
@@ -98,10 +98,10 @@ type SourceFileMeta struct {
 	ExtendData ExtendColumnData
 	// RealSize is same as FileSize if the file is not compressed and not parquet.
 	// If the file is compressed, RealSize is the estimated uncompressed size.
-	// If the file is parquet, RealSize is the estimated data size after convert
+	// If the file is parquet or orc, RealSize is the estimated data size after convert
 	// to row oriented storage.
 	RealSize int64
-	Rows     int64 // only for parquet
+	Rows     int64 // only for parquet and orc
 }
 
 // NewMDTableMeta creates an Mydumper table meta with specified character set.
@@ -247,6 +247,7 @@ type mdLoaderSetup struct {
 	setupCfg      *MDLoaderSetupConfig
 
 	sampledParquetRowSizes map[string]float64
+	sampledORCRowSizes     map[string]float64
 }
 
 // NewLoader constructs a MyDumper loader that scanns the data source and constructs a set of metadatas.
@@ -325,6 +326,7 @@ func NewLoaderWithStore(ctx context.Context, cfg LoaderConfig,
 		setupCfg:      mdLoaderSetupCfg,
 
 		sampledParquetRowSizes: make(map[string]float64),
+		sampledORCRowSizes:     make(map[string]float64),
 	}
 
 	if err := setup.setup(ctx); err != nil {
@@ -525,7 +527,7 @@ func (s *mdLoaderSetup) constructFileInfo(ctx context.Context, path string, size
 		s.tableSchemas = append(s.tableSchemas, info)
 	case SourceTypeViewSchema:
 		s.viewSchemas = append(s.viewSchemas, info)
-	case SourceTypeSQL, SourceTypeCSV:
+	case SourceTypeSQL, SourceTypeCSV, SourceTypeAvro:
 		if info.FileMeta.Compression != CompressionNone {
 			compressRatio, err2 := SampleFileCompressRatio(ctx, info.FileMeta, s.loader.GetStore())
 			if err2 != nil {
@@ -563,6 +565,33 @@ func (s *mdLoaderSetup) constructFileInfo(ctx context.Context, path string, size
 			}
 		}
 		s.tableDatas = append(s.tableDatas, info)
+	case SourceTypeORC:
+		tableName := info.TableName.String()
+		if s.sampledORCRowSizes[tableName] == 0 {
+			s.sampledORCRowSizes[tableName], err = SampleORCRowSize(ctx, info.FileMeta, s.loader.GetStore())
+			if err != nil {
+				logger.Error("fail to sample orc row size", zap.String("category", "loader"),
+					zap.String("schema", res.Schema), zap.String("table", res.Name),
+					zap.Stringer("type", res.Type), zap.Error(err))
+				return errors.Trace(err)
+			}
+		}
+		totalRowCount, err := ReadORCFileRowCountByFile(ctx, s.loader.GetStore(), info.FileMeta)
+		if err != nil {
+			logger.Error("fail to get file total row count", zap.String("category", "loader"),
+				zap.String("schema", res.Schema), zap.String("table", res.Name),
+				zap.Stringer("type", res.Type), zap.Error(err))
+			return errors.Trace(err)
+		}
+		// the row count is in the footer, so it's always read for the row based offsets of the regions.
+		info.FileMeta.Rows = totalRowCount
+		if s.sampledORCRowSizes[tableName] != 0 {
+			info.FileMeta.RealSize = int64(float64(totalRowCount) * s.sampledORCRowSizes[tableName])
+		}
+		if m, ok := metric.FromContext(ctx); ok {
+			m.RowsCounter.WithLabelValues(metric.StateTotalRestore, tableName).Add(float64(totalRowCount))
+		}
+		s.tableDatas = append(s.tableDatas, info)
 	}
 
 	logger.Debug("file route result", zap.String("schema", res.Schema),
@@ -888,3 +917,44 @@ func SampleParquetRowSize(ctx context.Context, fileMeta SourceFileMeta, store st
 	}
 	return float64(rowSize) / float64(rowCount), nil
 }
+
+// SampleORCRowSize samples row size of the orc file.
+func SampleORCRowSize(ctx context.Context, fileMeta SourceFileMeta, store storage.ExternalStorage) (float64, error) {
+	reader, err := store.Open(ctx, fileMeta.Path, nil)
+	if err != nil {
+		return 0, err
+	}
+	parser, err := NewORCParser(ctx, reader, fileMeta.Path)
+	if err != nil {
+		//nolint: errcheck
+		reader.Close()
+		return 0, err
+	}
+	//nolint: errcheck
+	defer parser.Close()
+
+	var (
+		rowSize  int64
+		rowCount int64
+	)
+	for {
+		err = parser.ReadRow()
+		if err != nil {
+			if errors.Cause(err) == io.EOF {
+				break
+			}
+			return 0, err
+		}
+		lastRow := parser.LastRow()
+		rowCount++
+		rowSize += int64(lastRow.Length)
+		parser.RecycleRow(lastRow)
+		if rowSize > maxSampleParquetDataSize || rowCount > maxSampleParquetRowCount {
+			break
+		}
+	}
+	if rowCount == 0 {
+		return 0, nil
+	}
+	return float64(rowSize) / float64(rowCount), nil
+}
@@ -377,6 +377,24 @@ func TestTablesWithDots(t *testing.T) {
 	}}, mdl.GetDatabases())
 }
 
+func TestAvroDataFiles(t *testing.T) {
+	s := newTestMydumpLoaderSuite(t)
+
+	s.touch(t, "db-schema-create.sql")
+	s.touch(t, "db.tbl-schema.sql")
+	s.touch(t, "db.tbl.0001.avro")
+
+	mdl, err := md.NewLoader(context.Background(), md.NewLoaderCfg(s.cfg))
+	require.NoError(t, err)
+	dbMetas := mdl.GetDatabases()
+	require.Len(t, dbMetas, 1)
+	require.Len(t, dbMetas[0].Tables, 1)
+	require.Equal(t, []md.FileInfo{{
+		TableName: filter.Table{Schema: "db", Name: "tbl"},
+		FileMeta:  md.SourceFileMeta{Path: "db.tbl.0001.avro", Type: md.SourceTypeAvro, SortKey: "0001"},
+	}}, dbMetas[0].Tables[0].DataFiles)
+}
+
 func TestRouter(t *testing.T) {
 	// route db and table but with some table not hit rules
 	{
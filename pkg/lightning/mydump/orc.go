@@ -0,0 +1,873 @@
+// Copyright 2026 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mydump
+
+import (
+	"bytes"
+	"compress/flate"
+	"fmt"
+	"io"
+	"math/big"
+	"strings"
+
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
+	"github.com/pingcap/errors"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// The decoding of the ORC files follows https://orc.apache.org/specification/ORCv1/.
+
+const (
+	orcMagic = "ORC"
+	// orcTailReadSize is the size read from the end of the file at first, it holds the postscript and the
+	// footer of the most files.
+	orcTailReadSize = 16 * 1024
+	// orcTimestampBase is the unix time of 2015-01-01 00:00:00, the seconds of the timestamps are relative
+	// to it.
+	orcTimestampBase = 1420070400
+)
+
+type orcCompression int
+
+const (
+	orcCompressionNone orcCompression = iota
+	orcCompressionZlib
+	orcCompressionSnappy
+	orcCompressionLzo
+	orcCompressionLz4
+	orcCompressionZstd
+)
+
+func (c orcCompression) String() string {
+	switch c {
+	case orcCompressionNone:
+		return "none"
+	case orcCompressionZlib:
+		return "zlib"
+	case orcCompressionSnappy:
+		return "snappy"
+	case orcCompressionLzo:
+		return "lzo"
+	case orcCompressionLz4:
+		return "lz4"
+	case orcCompressionZstd:
+		return "zstd"
+	default:
+		return fmt.Sprintf("unknown(%d)", int(c))
+	}
+}
+
+type orcKind int
+
+const (
+	orcBoolean orcKind = iota
+	orcByte
+	orcShort
+	orcInt
+	orcLong
+	orcFloat
+	orcDouble
+	orcString
+	orcBinary
+	orcTimestamp
+	orcList
+	orcMap
+	orcStruct
+	orcUnion
+	orcDecimal
+	orcDate
+	orcVarchar
+	orcChar
+	orcTimestampInstant
+)
+
+var orcKindNames = []string{
+	"boolean", "tinyint", "smallint", "int", "bigint", "float", "double", "string", "binary", "timestamp",
+	"array", "map", "struct", "uniontype", "decimal", "date", "varchar", "char", "timestamp with local time zone",
+}
+
+func (k orcKind) String() string {
+	if k >= 0 && int(k) < len(orcKindNames) {
+		return orcKindNames[k]
+	}
+	return fmt.Sprintf("unknown(%d)", int(k))
+}
+
+// orcType is a type in the footer, the types are a flattened tree and the subtypes are the indexes of the
+// children. The index of a type is also the column id of the streams.
+type orcType struct {
+	kind       orcKind
+	subtypes   []uint32
+	fieldNames []string
+	maxLength  uint32
+	precision  uint32
+	scale      uint32
+}
+
+type orcStripeInfo struct {
+	offset       int64
+	indexLength  int64
+	dataLength   int64
+	footerLength int64
+	rows         int64
+}
+
+// size is the size of the whole stripe in the file.
+func (s *orcStripeInfo) size() int64 {
+	return s.indexLength + s.dataLength + s.footerLength
+}
+
+// orcTail is the metadata in the postscript and the footer of a file.
+type orcTail struct {
+	compression orcCompression
+	blockSize   uint64
+	types       []*orcType
+	stripes     []orcStripeInfo
+	rows        int64
+}
+
+// typeString returns the type in the hive syntax for the diagnostics.
+func (t *orcTail) typeString(id uint32) string {
+	tp := t.types[id]
+	switch tp.kind {
+	case orcDecimal:
+		return fmt.Sprintf("decimal(%d,%d)", tp.precision, tp.scale)
+	case orcVarchar, orcChar:
+		return fmt.Sprintf("%s(%d)", tp.kind, tp.maxLength)
+	case orcList:
+		return fmt.Sprintf("array<%s>", t.typeString(tp.subtypes[0]))
+	case orcMap:
+		return fmt.Sprintf("map<%s,%s>", t.typeString(tp.subtypes[0]), t.typeString(tp.subtypes[1]))
+	case orcStruct, orcUnion:
+		var sb strings.Builder
+		sb.WriteString(tp.kind.String())
+		sb.WriteByte('<')
+		for i, sub := range tp.subtypes {
+			if i > 0 {
+				sb.WriteByte(',')
+			}
+			if tp.kind == orcStruct {
+				sb.WriteString(tp.fieldNames[i])
+				sb.WriteByte(':')
+			}
+			sb.WriteString(t.typeString(sub))
+		}
+		sb.WriteByte('>')
+		return sb.String()
+	}
+	return tp.kind.String()
+}
+
+// orcProtoField is a field of a protobuf message, only the varint and bytes fields are used by ORC.
+type orcProtoField struct {
+	num    protowire.Number
+	typ    protowire.Type
+	varint uint64
+	bytes  []byte
+}
+
+// uints returns the values of a repeated integer field, which can be packed or not.
+func (f *orcProtoField) uints(dst []uint32) ([]uint32, error) {
+	if f.typ == protowire.VarintType {
+		return append(dst, uint32(f.varint)), nil
+	}
+	data := f.bytes
+	for len(data) > 0 {
+		v, n := protowire.ConsumeVarint(data)
+		if n < 0 {
+			return nil, errors.Annotate(protowire.ParseError(n), "invalid orc metadata")
+		}
+		dst = append(dst, uint32(v))
+		data = data[n:]
+	}
+	return dst, nil
+}
+
+// walkORCProto calls fn with each field of the protobuf message.
+func walkORCProto(data []byte, fn func(f *orcProtoField) error) error {
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return errors.Annotate(protowire.ParseError(n), "invalid orc metadata")
+		}
+		data = data[n:]
+		f := &orcProtoField{num: num, typ: typ}
+		switch typ {
+		case protowire.VarintType:
+			f.varint, n = protowire.ConsumeVarint(data)
+		case protowire.BytesType:
+			f.bytes, n = protowire.ConsumeBytes(data)
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, data)
+		}
+		if n < 0 {
+			return errors.Annotate(protowire.ParseError(n), "invalid orc metadata")
+		}
+		data = data[n:]
+		if err := fn(f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readORCTail reads the postscript and the footer at the end of the file.
+func readORCTail(r io.ReadSeeker, decompressor *orcDecompressor) (*orcTail, error) {
+	fileSize, err := r.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if fileSize <= int64(len(orcMagic))+1 {
+		return nil, errors.New("not an orc file, the file is too small")
+	}
+	buf, err := readORCAt(r, fileSize-min(fileSize, orcTailReadSize), min(fileSize, orcTailReadSize))
+	if err != nil {
+		return nil, err
+	}
+	psLen := int(buf[len(buf)-1])
+	if psLen+1 > len(buf) {
+		return nil, errors.New("not an orc file, the postscript is out of the file")
+	}
+	tail := &orcTail{}
+	var footerLen uint64
+	magic := ""
+	err = walkORCProto(buf[len(buf)-1-psLen:len(buf)-1], func(f *orcProtoField) error {
+		switch f.num {
+		case 1:
+			footerLen = f.varint
+		case 2:
+			tail.compression = orcCompression(f.varint)
+		case 3:
+			tail.blockSize = f.varint
+		case 8000:
+			magic = string(f.bytes)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if magic != orcMagic {
+		return nil, errors.New("not an orc file, the magic of the postscript mismatches")
+	}
+	switch tail.compression {
+	case orcCompressionNone, orcCompressionZlib, orcCompressionSnappy, orcCompressionZstd:
+	default:
+		return nil, errors.Errorf("unsupported orc compression %s", tail.compression)
+	}
+	decompressor.compression = tail.compression
+
+	need := int64(footerLen) + int64(psLen) + 1
+	if need > fileSize {
+		return nil, errors.New("invalid orc file, the footer is out of the file")
+	}
+	if need > int64(len(buf)) {
+		if buf, err = readORCAt(r, fileSize-need, need); err != nil {
+			return nil, err
+		}
+	}
+	footer, err := decompressor.decompressAll(buf[len(buf)-int(need) : len(buf)-1-psLen])
+	if err != nil {
+		return nil, errors.Annotate(err, "failed to decompress the orc footer")
+	}
+	if err := tail.parseFooter(footer); err != nil {
+		return nil, err
+	}
+	return tail, nil
+}
+
+func readORCAt(r io.ReadSeeker, offset, size int64) ([]byte, error) {
+	if _, err := r.Seek(offset, io.SeekStart); err != nil {
+		return nil, errors.Trace(err)
+	}
+	buf := make([]byte, size)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return nil, errors.Annotatef(err, "failed to read %d bytes at offset %d", size, offset)
+	}
+	return buf, nil
+}
+
+func (t *orcTail) parseFooter(data []byte) error {
+	err := walkORCProto(data, func(f *orcProtoField) error {
+		switch f.num {
+		case 3:
+			stripe := orcStripeInfo{}
+			err := walkORCProto(f.bytes, func(f *orcProtoField) error {
+				switch f.num {
+				case 1:
+					stripe.offset = int64(f.varint)
+				case 2:
+					stripe.indexLength = int64(f.varint)
+				case 3:
+					stripe.dataLength = int64(f.varint)
+				case 4:
+					stripe.footerLength = int64(f.varint)
+				case 5:
+					stripe.rows = int64(f.varint)
+				}
+				return nil
+			})
+			t.stripes = append(t.stripes, stripe)
+			return err
+		case 4:
+			tp := &orcType{}
+			err := walkORCProto(f.bytes, func(f *orcProtoField) error {
+				var err error
+				switch f.num {
+				case 1:
+					tp.kind = orcKind(f.varint)
+				case 2:
+					tp.subtypes, err = f.uints(tp.subtypes)
+				case 3:
+					tp.fieldNames = append(tp.fieldNames, string(f.bytes))
+				case 4:
+					tp.maxLength = uint32(f.varint)
+				case 5:
+					tp.precision = uint32(f.varint)
+				case 6:
+					tp.scale = uint32(f.varint)
+				}
+				return err
+			})
+			t.types = append(t.types, tp)
+			return err
+		case 6:
+			t.rows = int64(f.varint)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if len(t.types) == 0 || t.types[0].kind != orcStruct {
+		return errors.New("the root type of the orc file should be a struct")
+	}
+	for id, tp := range t.types {
+		if tp.kind < orcBoolean || tp.kind > orcTimestampInstant {
+			return errors.Errorf("unknown orc type %s of the column %d", tp.kind, id)
+		}
+		// the children are after the parent, so the types can't be a loop.
+		for _, sub := range tp.subtypes {
+			if int(sub) <= id || int(sub) >= len(t.types) {
+				return errors.Errorf("invalid subtype %d of the orc column %d", sub, id)
+			}
+		}
+		switch {
+		case tp.kind == orcStruct && len(tp.fieldNames) != len(tp.subtypes),
+			tp.kind == orcList && len(tp.subtypes) != 1,
+			tp.kind == orcMap && len(tp.subtypes) != 2:
+			return errors.Errorf("invalid orc type %s of the column %d", tp.kind, id)
+		}
+	}
+	return nil
+}
+
+// orcDecompressor decompresses the chunks of the streams, a zstd decoder is reused by the chunks.
+type orcDecompressor struct {
+	compression orcCompression
+	zstdDecoder *zstd.Decoder
+}
+
+// decompressAll decompresses all the chunks of the data.
+func (d *orcDecompressor) decompressAll(data []byte) ([]byte, error) {
+	if d.compression == orcCompressionNone {
+		return data, nil
+	}
+	s := &orcStream{decompressor: d, data: data}
+	var out []byte
+	for {
+		if err := s.fill(); err != nil {
+			if err == io.EOF {
+				return out, nil
+			}
+			return nil, err
+		}
+		out = append(out, s.buf...)
+	}
+}
+
+func (d *orcDecompressor) decompress(data []byte) ([]byte, error) {
+	switch d.compression {
+	case orcCompressionZlib:
+		return io.ReadAll(flate.NewReader(bytes.NewReader(data)))
+	case orcCompressionSnappy:
+		return snappy.Decode(nil, data)
+	case orcCompressionZstd:
+		if d.zstdDecoder == nil {
+			decoder, err := zstd.NewReader(nil, zstd.WithDecoderConcurrency(1))
+			if err != nil {
+				return nil, err
+			}
+			d.zstdDecoder = decoder
+		}
+		return d.zstdDecoder.DecodeAll(data, nil)
+	default:
+		return nil, errors.Errorf("unsupported orc compression %s", d.compression)
+	}
+}
+
+func (d *orcDecompressor) close() {
+	if d.zstdDecoder != nil {
+		d.zstdDecoder.Close()
+	}
+}
+
+// orcStream reads a stream of a stripe, the chunks are decompressed when they are read.
+type orcStream struct {
+	decompressor *orcDecompressor
+	// data is the compressed chunks not read.
+	data []byte
+	// buf is the current decompressed chunk.
+	buf []byte
+	pos int
+}
+
+var errORCShortStream = errors.New("unexpected end of the orc stream")
+
+func (s *orcStream) fill() error {
+	if len(s.data) == 0 {
+		return io.EOF
+	}
+	s.pos = 0
+	if s.decompressor.compression == orcCompressionNone {
+		s.buf, s.data = s.data, nil
+		return nil
+	}
+	if len(s.data) < 3 {
+		return errORCShortStream
+	}
+	header := uint32(s.data[0]) | uint32(s.data[1])<<8 | uint32(s.data[2])<<16
+	length := int(header >> 1)
+	if 3+length > len(s.data) {
+		return errORCShortStream
+	}
+	chunk := s.data[3 : 3+length]
+	s.data = s.data[3+length:]
+	if header&1 == 1 {
+		// the chunk isn't compressed as it's larger after the compression.
+		s.buf = chunk
+		return nil
+	}
+	var err error
+	s.buf, err = s.decompressor.decompress(chunk)
+	return errors.Annotatef(err, "failed to decompress the orc chunk by %s", s.decompressor.compression)
+}
+
+// ReadByte implements io.ByteReader.
+func (s *orcStream) ReadByte() (byte, error) {
+	for s.pos >= len(s.buf) {
+		if err := s.fill(); err != nil {
+			if err == io.EOF {
+				return 0, errORCShortStream
+			}
+			return 0, err
+		}
+	}
+	b := s.buf[s.pos]
+	s.pos++
+	return b, nil
+}
+
+// readN reads n bytes, the bytes are a slice of the decompressed chunk if they are in one chunk.
+func (s *orcStream) readN(n int) ([]byte, error) {
+	for s.pos >= len(s.buf) && n > 0 {
+		if err := s.fill(); err != nil {
+			if err == io.EOF {
+				return nil, errORCShortStream
+			}
+			return nil, err
+		}
+	}
+	if s.pos+n <= len(s.buf) {
+		b := s.buf[s.pos : s.pos+n]
+		s.pos += n
+		return b, nil
+	}
+	b := make([]byte, 0, n)
+	for len(b) < n {
+		if s.pos >= len(s.buf) {
+			if err := s.fill(); err != nil {
+				if err == io.EOF {
+					return nil, errORCShortStream
+				}
+				return nil, err
+			}
+			continue
+		}
+		m := min(n-len(b), len(s.buf)-s.pos)
+		b = append(b, s.buf[s.pos:s.pos+m]...)
+		s.pos += m
+	}
+	return b, nil
+}
+
+func readORCVarint(s io.ByteReader, signed bool) (int64, error) {
+	var u uint64
+	for shift := uint(0); ; shift += 7 {
+		b, err := s.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		if shift >= 64 {
+			return 0, errors.New("the orc varint overflows 64 bits")
+		}
+		u |= uint64(b&0x7f) << shift
+		if b < 0x80 {
+			break
+		}
+	}
+	if signed {
+		return int64(u>>1) ^ -int64(u&1), nil
+	}
+	return int64(u), nil
+}
+
+// readORCBigVarint reads the unbounded signed varint of the decimals.
+func readORCBigVarint(s io.ByteReader) (*big.Int, error) {
+	u := new(big.Int)
+	group := new(big.Int)
+	for shift := uint(0); ; shift += 7 {
+		b, err := s.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		u.Or(u, group.Lsh(group.SetUint64(uint64(b&0x7f)), shift))
+		if b < 0x80 {
+			break
+		}
+	}
+	negative := u.Bit(0) == 1
+	u.Rsh(u, 1)
+	if negative {
+		u.Neg(u).Sub(u, big.NewInt(1))
+	}
+	return u, nil
+}
+
+// orcByteRLE decodes the byte run length encoding.
+type orcByteRLE struct {
+	s         *orcStream
+	repeat    bool
+	value     byte
+	remaining int
+}
+
+func (d *orcByteRLE) next() (byte, error) {
+	if d.remaining == 0 {
+		control, err := d.s.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		if control < 0x80 {
+			d.repeat, d.remaining = true, int(control)+3
+			if d.value, err = d.s.ReadByte(); err != nil {
+				return 0, err
+			}
+		} else {
+			d.repeat, d.remaining = false, 0x100-int(control)
+		}
+	}
+	d.remaining--
+	if d.repeat {
+		return d.value, nil
+	}
+	return d.s.ReadByte()
+}
+
+// orcBoolRLE decodes the booleans, they are the bits of the bytes from the most significant bit.
+type orcBoolRLE struct {
+	bytes orcByteRLE
+	cur   byte
+	bits  int
+}
+
+func newORCBoolRLE(s *orcStream) *orcBoolRLE {
+	return &orcBoolRLE{bytes: orcByteRLE{s: s}}
+}
+
+func (d *orcBoolRLE) next() (bool, error) {
+	if d.bits == 0 {
+		b, err := d.bytes.next()
+		if err != nil {
+			return false, err
+		}
+		d.cur, d.bits = b, 8
+	}
+	d.bits--
+	return d.cur>>d.bits&1 == 1, nil
+}
+
+// orcIntDecoder decodes the integers of the run length encoding version 1 or 2.
+type orcIntDecoder interface {
+	next() (int64, error)
+}
+
+func newORCIntDecoder(s *orcStream, v2, signed bool) orcIntDecoder {
+	if v2 {
+		return &orcIntRLEv2{s: s, signed: signed}
+	}
+	return &orcIntRLEv1{s: s, signed: signed}
+}
+
+type orcIntRLEv1 struct {
+	s            *orcStream
+	signed       bool
+	literal      bool
+	remaining    int
+	value, delta int64
+}
+
+func (d *orcIntRLEv1) next() (int64, error) {
+	if d.remaining == 0 {
+		control, err := d.s.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		if control < 0x80 {
+			d.literal, d.remaining = false, int(control)+3
+			delta, err := d.s.ReadByte()
+			if err != nil {
+				return 0, err
+			}
+			d.delta = int64(int8(delta))
+			if d.value, err = readORCVarint(d.s, d.signed); err != nil {
+				return 0, err
+			}
+		} else {
+			d.literal, d.remaining = true, 0x100-int(control)
+		}
+	}
+	d.remaining--
+	if d.literal {
+		return readORCVarint(d.s, d.signed)
+	}
+	v := d.value
+	d.value += d.delta
+	return v, nil
+}
+
+type orcIntRLEv2 struct {
+	s      *orcStream
+	signed bool
+	values []int64
+	idx    int
+	packed []uint64
+}
+
+func (d *orcIntRLEv2) next() (int64, error) {
+	if d.idx >= len(d.values) {
+		if err := d.readRun(); err != nil {
+			return 0, err
+		}
+	}
+	v := d.values[d.idx]
+	d.idx++
+	return v, nil
+}
+
+// orcDecodeBitWidth decodes the 5 bits width of the run length encoding version 2.
+func orcDecodeBitWidth(encoded byte) int {
+	switch {
+	case encoded <= 23:
+		return int(encoded) + 1
+	case encoded <= 27:
+		return 26 + int(encoded-24)*2
+	default:
+		return 40 + int(encoded-28)*8
+	}
+}
+
+// orcClosestFixedBits rounds up the bits of the patches to the widths of the bit packing.
+func orcClosestFixedBits(n int) int {
+	switch {
+	case n == 0:
+		return 1
+	case n <= 24:
+		return n
+	case n <= 32:
+		return (n + 1) / 2 * 2
+	default:
+		return (n + 7) / 8 * 8
+	}
+}
+
+func (d *orcIntRLEv2) zigzag(u uint64) int64 {
+	if d.signed {
+		return int64(u>>1) ^ -int64(u&1)
+	}
+	return int64(u)
+}
+
+func (d *orcIntRLEv2) readRun() error {
+	first, err := d.s.ReadByte()
+	if err != nil {
+		return err
+	}
+	d.values, d.idx = d.values[:0], 0
+	if first>>6 == 0 {
+		// short repeat
+		width, count := int(first>>3&7)+1, int(first&7)+3
+		var u uint64
+		for range width {
+			b, err := d.s.ReadByte()
+			if err != nil {
+				return err
+			}
+			u = u<<8 | uint64(b)
+		}
+		v := d.zigzag(u)
+		for range count {
+			d.values = append(d.values, v)
+		}
+		return nil
+	}
+
+	second, err := d.s.ReadByte()
+	if err != nil {
+		return err
+	}
+	length := int(first&1)<<8 | int(second) + 1
+	switch first >> 6 {
+	case 1:
+		// direct
+		if d.packed, err = d.unpack(d.packed[:0], length, orcDecodeBitWidth(first>>1&0x1f)); err != nil {
+			return err
+		}
+		for _, u := range d.packed {
+			d.values = append(d.values, d.zigzag(u))
+		}
+	case 2:
+		return d.readPatchedBase(first, length)
+	case 3:
+		return d.readDelta(first, length)
+	}
+	return nil
+}
+
+func (d *orcIntRLEv2) readPatchedBase(first byte, length int) error {
+	width := orcDecodeBitWidth(first >> 1 & 0x1f)
+	third, err := d.s.ReadByte()
+	if err != nil {
+		return err
+	}
+	fourth, err := d.s.ReadByte()
+	if err != nil {
+		return err
+	}
+	baseWidth, patchWidth := int(third>>5)+1, orcDecodeBitWidth(third&0x1f)
+	gapWidth, patchCount := int(fourth>>5)+1, int(fourth&0x1f)
+	if width+patchWidth > 64 {
+		return errors.New("invalid orc patched base run, the patched values overflow 64 bits")
+	}
+	var u uint64
+	for range baseWidth {
+		b, err := d.s.ReadByte()
+		if err != nil {
+			return err
+		}
+		u = u<<8 | uint64(b)
+	}
+	// the base is sign-magnitude.
+	signBit := uint64(1) << (baseWidth*8 - 1)
+	base := int64(u &^ signBit)
+	if u&signBit != 0 {
+		base = -base
+	}
+	data, err := d.unpack(d.packed[:0], length, width)
+	if err != nil {
+		return err
+	}
+	patches, err := d.unpack(nil, patchCount, orcClosestFixedBits(gapWidth+patchWidth))
+	if err != nil {
+		return err
+	}
+	idx := 0
+	for _, p := range patches {
+		idx += int(p >> patchWidth)
+		if idx >= length {
+			return errors.New("invalid orc patched base run, the patch is out of the run")
+		}
+		data[idx] |= (p & (1<<patchWidth - 1)) << width
+	}
+	for _, v := range data {
+		d.values = append(d.values, base+int64(v))
+	}
+	d.packed = data
+	return nil
+}
+
+func (d *orcIntRLEv2) readDelta(first byte, length int) error {
+	width := 0
+	if encoded := first >> 1 & 0x1f; encoded != 0 {
+		width = orcDecodeBitWidth(encoded)
+	}
+	base, err := readORCVarint(d.s, d.signed)
+	if err != nil {
+		return err
+	}
+	deltaBase, err := readORCVarint(d.s, true)
+	if err != nil {
+		return err
+	}
+	d.values = append(d.values, base)
+	if width == 0 {
+		// fixed delta
+		for i := 1; i < length; i++ {
+			d.values = append(d.values, d.values[i-1]+deltaBase)
+		}
+		return nil
+	}
+	if length == 1 {
+		return nil
+	}
+	d.values = append(d.values, base+deltaBase)
+	if d.packed, err = d.unpack(d.packed[:0], length-2, width); err != nil {
+		return err
+	}
+	for _, delta := range d.packed {
+		prev := d.values[len(d.values)-1]
+		if deltaBase < 0 {
+			d.values = append(d.values, prev-int64(delta))
+		} else {
+			d.values = append(d.values, prev+int64(delta))
+		}
+	}
+	return nil
+}
+
+// unpack reads n values of the width bits packed from the most significant bit.
+func (d *orcIntRLEv2) unpack(dst []uint64, n, width int) ([]uint64, error) {
+	var cur uint64
+	bits := 0
+	for range n {
+		var v uint64
+		for need := width; need > 0; {
+			if bits == 0 {
+				b, err := d.s.ReadByte()
+				if err != nil {
+					return nil, err
+				}
+				cur, bits = uint64(b), 8
+			}
+			take := min(need, bits)
+			v = v<<take | cur>>(bits-take)&(1<<take-1)
+			bits -= take
+			need -= take
+		}
+		dst = append(dst, v)
+	}
+	return dst, nil
+}
@@ -0,0 +1,938 @@
+// Copyright 2026 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mydump
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/tidb/br/pkg/storage"
+	"github.com/pingcap/tidb/pkg/lightning/log"
+	"github.com/pingcap/tidb/pkg/types"
+	"go.uber.org/zap"
+)
+
+// the kinds of the streams used by the column readers.
+const (
+	orcStreamPresent        = 0
+	orcStreamData           = 1
+	orcStreamLength         = 2
+	orcStreamDictionaryData = 3
+	orcStreamSecondary      = 5
+)
+
+// the kinds of the column encodings.
+const (
+	orcEncodingDirect       = 0
+	orcEncodingDictionary   = 1
+	orcEncodingDirectV2     = 2
+	orcEncodingDictionaryV2 = 3
+)
+
+type orcStreamKey struct {
+	column uint32
+	kind   uint64
+}
+
+type orcColumnEncoding struct {
+	kind           uint64
+	dictionarySize uint64
+}
+
+// orcStripe is a stripe read from the file, the streams are decompressed when they are read.
+type orcStripe struct {
+	tail         *orcTail
+	decompressor *orcDecompressor
+	streams      map[orcStreamKey][]byte
+	encodings    []orcColumnEncoding
+	location     *time.Location
+}
+
+// stream returns the stream of the column, a missing stream is empty, e.g. the data of a column whose values
+// are all null.
+func (s *orcStripe) stream(column uint32, kind uint64) *orcStream {
+	return &orcStream{decompressor: s.decompressor, data: s.streams[orcStreamKey{column: column, kind: kind}]}
+}
+
+func (s *orcStripe) intDecoder(column uint32, kind uint64, signed bool) orcIntDecoder {
+	encoding := s.encodings[column].kind
+	return newORCIntDecoder(s.stream(column, kind), encoding == orcEncodingDirectV2 || encoding == orcEncodingDictionaryV2, signed)
+}
+
+// orcColumnReader reads the values of a column row by row.
+type orcColumnReader interface {
+	// next returns the value of the next row, it's nil if the value is null.
+	next() (any, error)
+}
+
+// orcDecimalText is the text of a decimal, it's a number in the JSON text.
+type orcDecimalText string
+
+// orcStructValue is the value of a struct, the fields are kept in order in the JSON text.
+type orcStructValue struct {
+	names  []string
+	values []any
+}
+
+type orcMapValue struct {
+	keys, values []any
+}
+
+// orcPresence reads the present stream of a column, all the values are present if there is no stream.
+type orcPresence struct {
+	present *orcBoolRLE
+}
+
+func (p orcPresence) isNull() (bool, error) {
+	if p.present == nil {
+		return false, nil
+	}
+	present, err := p.present.next()
+	return !present, err
+}
+
+// newORCColumnReader creates the reader of the column in the stripe.
+func (s *orcStripe) newColumnReader(column uint32) (orcColumnReader, error) {
+	if int(column) >= len(s.encodings) {
+		return nil, errors.Errorf("the encoding of the orc column %d is missing", column)
+	}
+	presence := orcPresence{}
+	if _, ok := s.streams[orcStreamKey{column: column, kind: orcStreamPresent}]; ok {
+		presence.present = newORCBoolRLE(s.stream(column, orcStreamPresent))
+	}
+	tp := s.tail.types[column]
+	data := s.stream(column, orcStreamData)
+	var err error
+	switch tp.kind {
+	case orcBoolean:
+		return &orcBoolColumn{presence: presence, data: newORCBoolRLE(data)}, nil
+	case orcByte:
+		return &orcByteColumn{presence: presence, data: orcByteRLE{s: data}}, nil
+	case orcShort, orcInt, orcLong:
+		return &orcIntColumn{presence: presence, data: s.intDecoder(column, orcStreamData, true)}, nil
+	case orcFloat, orcDouble:
+		return &orcFloatColumn{presence: presence, data: data, double: tp.kind == orcDouble}, nil
+	case orcString, orcVarchar, orcChar, orcBinary:
+		return s.newStringColumn(column, presence, tp.kind == orcBinary)
+	case orcDecimal:
+		return &orcDecimalColumn{
+			presence: presence, data: data, scale: s.intDecoder(column, orcStreamSecondary, true),
+		}, nil
+	case orcDate:
+		return &orcDateColumn{presence: presence, days: s.intDecoder(column, orcStreamData, true)}, nil
+	case orcTimestamp, orcTimestampInstant:
+		return &orcTimestampColumn{
+			presence: presence,
+			seconds:  s.intDecoder(column, orcStreamData, true),
+			nanos:    s.intDecoder(column, orcStreamSecondary, false),
+			instant:  tp.kind == orcTimestampInstant,
+			location: s.location,
+		}, nil
+	case orcStruct:
+		c := &orcStructColumn{presence: presence, names: tp.fieldNames}
+		c.children, err = s.newColumnReaders(tp.subtypes)
+		return c, err
+	case orcList, orcMap:
+		c := &orcListColumn{presence: presence, length: s.intDecoder(column, orcStreamLength, false)}
+		c.children, err = s.newColumnReaders(tp.subtypes)
+		return c, err
+	default:
+		return nil, errors.Errorf("unsupported orc type %s", s.tail.typeString(column))
+	}
+}
+
+func (s *orcStripe) newColumnReaders(columns []uint32) ([]orcColumnReader, error) {
+	readers := make([]orcColumnReader, 0, len(columns))
+	for _, column := range columns {
+		reader, err := s.newColumnReader(column)
+		if err != nil {
+			return nil, err
+		}
+		readers = append(readers, reader)
+	}
+	return readers, nil
+}
+
+func (s *orcStripe) newStringColumn(column uint32, presence orcPresence, binary bool) (orcColumnReader, error) {
+	encoding := s.encodings[column]
+	lengths := s.intDecoder(column, orcStreamLength, false)
+	c := &orcStringColumn{presence: presence, binary: binary}
+	if encoding.kind == orcEncodingDirect || encoding.kind == orcEncodingDirectV2 {
+		c.data, c.lengths = s.stream(column, orcStreamData), lengths
+		return c, nil
+	}
+	// the dictionary is loaded at once, the data stream is the indexes of the dictionary.
+	dictData := s.stream(column, orcStreamDictionaryData)
+	c.dictionary = make([][]byte, 0, encoding.dictionarySize)
+	for range encoding.dictionarySize {
+		length, err := lengths.next()
+		if err != nil {
+			return nil, errors.Annotatef(err, "failed to read the dictionary of the orc column %d", column)
+		}
+		if length < 0 {
+			return nil, errors.Errorf("invalid length %d in the dictionary of the orc column %d", length, column)
+		}
+		value, err := dictData.readN(int(length))
+		if err != nil {
+			return nil, errors.Annotatef(err, "failed to read the dictionary of the orc column %d", column)
+		}
+		c.dictionary = append(c.dictionary, value)
+	}
+	c.indexes = s.intDecoder(column, orcStreamData, false)
+	return c, nil
+}
+
+type orcBoolColumn struct {
+	presence orcPresence
+	data     *orcBoolRLE
+}
+
+func (c *orcBoolColumn) next() (any, error) {
+	if null, err := c.presence.isNull(); null || err != nil {
+		return nil, err
+	}
+	return c.data.next()
+}
+
+type orcByteColumn struct {
+	presence orcPresence
+	data     orcByteRLE
+}
+
+func (c *orcByteColumn) next() (any, error) {
+	if null, err := c.presence.isNull(); null || err != nil {
+		return nil, err
+	}
+	b, err := c.data.next()
+	return int64(int8(b)), err
+}
+
+type orcIntColumn struct {
+	presence orcPresence
+	data     orcIntDecoder
+}
+
+func (c *orcIntColumn) next() (any, error) {
+	if null, err := c.presence.isNull(); null || err != nil {
+		return nil, err
+	}
+	return c.data.next()
+}
+
+type orcFloatColumn struct {
+	presence orcPresence
+	data     *orcStream
+	double   bool
+}
+
+func (c *orcFloatColumn) next() (any, error) {
+	if null, err := c.presence.isNull(); null || err != nil {
+		return nil, err
+	}
+	if c.double {
+		b, err := c.data.readN(8)
+		if err != nil {
+			return nil, err
+		}
+		return math.Float64frombits(binary.LittleEndian.Uint64(b)), nil
+	}
+	b, err := c.data.readN(4)
+	if err != nil {
+		return nil, err
+	}
+	return math.Float32frombits(binary.LittleEndian.Uint32(b)), nil
+}
+
+type orcStringColumn struct {
+	presence orcPresence
+	binary   bool
+	// data and lengths are for the direct encoding.
+	data    *orcStream
+	lengths orcIntDecoder
+	// dictionary and indexes are for the dictionary encoding.
+	dictionary [][]byte
+	indexes    orcIntDecoder
+}
+
+func (c *orcStringColumn) next() (any, error) {
+	if null, err := c.presence.isNull(); null || err != nil {
+		return nil, err
+	}
+	var value []byte
+	if c.indexes != nil {
+		idx, err := c.indexes.next()
+		if err != nil {
+			return nil, err
+		}
+		if idx < 0 || idx >= int64(len(c.dictionary)) {
+			return nil, errors.Errorf("the dictionary index %d is out of the dictionary of %d values", idx, len(c.dictionary))
+		}
+		value = c.dictionary[idx]
+	} else {
+		length, err := c.lengths.next()
+		if err != nil {
+			return nil, err
+		}
+		if length < 0 {
+			return nil, errors.Errorf("invalid length %d of the string", length)
+		}
+		if value, err = c.data.readN(int(length)); err != nil {
+			return nil, err
+		}
+	}
+	if c.binary {
+		return value, nil
+	}
+	return string(value), nil
+}
+
+type orcDecimalColumn struct {
+	presence orcPresence
+	data     *orcStream
+	scale    orcIntDecoder
+}
+
+func (c *orcDecimalColumn) next() (any, error) {
+	if null, err := c.presence.isNull(); null || err != nil {
+		return nil, err
+	}
+	unscaled, err := readORCBigVarint(c.data)
+	if err != nil {
+		return nil, err
+	}
+	scale, err := c.scale.next()
+	if err != nil {
+		return nil, err
+	}
+	return orcDecimalText(formatORCDecimal(unscaled, scale)), nil
+}
+
+// formatORCDecimal formats the decimal of unscaled * 10^-scale.
+func formatORCDecimal(unscaled *big.Int, scale int64) string {
+	digits := new(big.Int).Abs(unscaled).String()
+	sign := ""
+	if unscaled.Sign() < 0 {
+		sign = "-"
+	}
+	switch {
+	case scale <= 0:
+		return sign + digits + strings.Repeat("0", int(-scale))
+	case int64(len(digits)) <= scale:
+		return sign + "0." + strings.Repeat("0", int(scale)-len(digits)) + digits
+	default:
+		point := len(digits) - int(scale)
+		return sign + digits[:point] + "." + digits[point:]
+	}
+}
+
+type orcDateColumn struct {
+	presence orcPresence
+	days     orcIntDecoder
+}
+
+func (c *orcDateColumn) next() (any, error) {
+	if null, err := c.presence.isNull(); null || err != nil {
+		return nil, err
+	}
+	days, err := c.days.next()
+	if err != nil {
+		return nil, err
+	}
+	return time.Unix(days*secPerDay, 0).UTC().Format(time.DateOnly), nil
+}
+
+type orcTimestampColumn struct {
+	presence orcPresence
+	seconds  orcIntDecoder
+	nanos    orcIntDecoder
+	// instant is true for the timestamp with local time zone, which is an instant in UTC. Otherwise the
+	// timestamp is the wall clock in the time zone of the writer.
+	instant  bool
+	location *time.Location
+}
+
+func (c *orcTimestampColumn) next() (any, error) {
+	if null, err := c.presence.isNull(); null || err != nil {
+		return nil, err
+	}
+	seconds, err := c.seconds.next()
+	if err != nil {
+		return nil, err
+	}
+	encodedNanos, err := c.nanos.next()
+	if err != nil {
+		return nil, err
+	}
+	// the low 3 bits are the number of the trailing zeros removed minus 1.
+	nanos := encodedNanos >> 3
+	if zeros := encodedNanos & 7; zeros != 0 {
+		for range zeros + 1 {
+			nanos *= 10
+		}
+	}
+	// the writer truncates the negative seconds toward zero.
+	if seconds < 0 && nanos > 999999 {
+		seconds--
+	}
+	if c.instant {
+		return time.Unix(orcTimestampBase+seconds, nanos).UTC().Format(utcTimeLayout), nil
+	}
+	base := time.Date(2015, time.January, 1, 0, 0, 0, 0, c.location).Unix()
+	return time.Unix(base+seconds, nanos).In(c.location).Format(timeLayout), nil
+}
+
+type orcStructColumn struct {
+	presence orcPresence
+	names    []string
+	children []orcColumnReader
+}
+
+func (c *orcStructColumn) next() (any, error) {
+	if null, err := c.presence.isNull(); null || err != nil {
+		return nil, err
+	}
+	v := orcStructValue{names: c.names, values: make([]any, 0, len(c.children))}
+	for _, child := range c.children {
+		value, err := child.next()
+		if err != nil {
+			return nil, err
+		}
+		v.values = append(v.values, value)
+	}
+	return v, nil
+}
+
+// orcListColumn reads the lists or the maps, the children are the items, or the keys and the values.
+type orcListColumn struct {
+	presence orcPresence
+	length   orcIntDecoder
+	children []orcColumnReader
+}
+
+func (c *orcListColumn) next() (any, error) {
+	if null, err := c.presence.isNull(); null || err != nil {
+		return nil, err
+	}
+	length, err := c.length.next()
+	if err != nil {
+		return nil, err
+	}
+	if length < 0 {
+		return nil, errors.Errorf("invalid length %d of the list", length)
+	}
+	items := make([][]any, len(c.children))
+	for range length {
+		for i, child := range c.children {
+			v, err := child.next()
+			if err != nil {
+				return nil, err
+			}
+			items[i] = append(items[i], v)
+		}
+	}
+	if len(c.children) == 2 {
+		return orcMapValue{keys: items[0], values: items[1]}, nil
+	}
+	if items[0] == nil {
+		return []any{}, nil
+	}
+	return items[0], nil
+}
+
+// ORCParser parses an ORC file for import.
+// It implements the Parser interface.
+//
+// The position of the parser is the index of the row like the parquet files, and the stripes are read one
+// by one. All the rows are read without the predicates, so the row indexes and the bloom filters are
+// skipped.
+type ORCParser struct {
+	reader       ReadSeekCloser
+	path         string
+	tail         *orcTail
+	decompressor *orcDecompressor
+	columns      []string
+	logger       log.Logger
+
+	// stripeIdx is the index of the current stripe, and stripeStart is the index of its first row.
+	stripeIdx   int
+	stripeStart int64
+	readers     []orcColumnReader
+	// curRow is the index of the next row in the file.
+	curRow  int64
+	lastRow Row
+}
+
+// NewORCParser creates an ORC parser.
+func NewORCParser(ctx context.Context, reader ReadSeekCloser, path string) (*ORCParser, error) {
+	decompressor := &orcDecompressor{}
+	tail, err := readORCTail(reader, decompressor)
+	if err != nil {
+		decompressor.close()
+		return nil, errors.Annotatef(err, "failed to read the orc file %s", path)
+	}
+	logger := log.FromContext(ctx)
+	root := tail.types[0]
+	columns := make([]string, 0, len(root.fieldNames))
+	for i, name := range root.fieldNames {
+		if err := checkORCTypeSupported(tail, root.subtypes[i]); err != nil {
+			decompressor.close()
+			return nil, errors.Annotatef(err, "the column %s of the orc file %s", name, path)
+		}
+		columns = append(columns, strings.ToLower(name))
+		logger.Debug("read the orc column", zap.String("file", path), zap.String("column", name),
+			zap.String("orc-type", tail.typeString(root.subtypes[i])))
+	}
+	return &ORCParser{
+		reader:       reader,
+		path:         path,
+		tail:         tail,
+		decompressor: decompressor,
+		columns:      columns,
+		logger:       logger,
+		stripeIdx:    -1,
+	}, nil
+}
+
+func checkORCTypeSupported(tail *orcTail, column uint32) error {
+	tp := tail.types[column]
+	if tp.kind == orcUnion {
+		return errors.Errorf("unsupported orc type %s", tail.typeString(column))
+	}
+	for _, sub := range tp.subtypes {
+		if err := checkORCTypeSupported(tail, sub); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// openStripe reads the stripe, and creates the readers of the columns.
+func (p *ORCParser) openStripe(idx int) error {
+	info := p.tail.stripes[idx]
+	// the index streams are at the beginning of the stripe, they are skipped in the full scans.
+	data, err := readORCAt(p.reader, info.offset+info.indexLength, info.dataLength+info.footerLength)
+	if err != nil {
+		return errors.Annotatef(err, "failed to read the stripe %d of the orc file %s", idx, p.path)
+	}
+	stripe, err := p.parseStripe(data, info)
+	if err != nil {
+		return errors.Annotatef(err, "failed to read the stripe %d of the orc file %s", idx, p.path)
+	}
+	root := p.tail.types[0]
+	readers := make([]orcColumnReader, 0, len(root.subtypes))
+	for i, column := range root.subtypes {
+		reader, err := stripe.newColumnReader(column)
+		if err != nil {
+			return errors.Annotatef(err, "failed to read the column %s (%s) of the stripe %d of the orc file %s",
+				root.fieldNames[i], p.tail.typeString(column), idx, p.path)
+		}
+		readers = append(readers, reader)
+	}
+	p.stripeIdx, p.readers = idx, readers
+	return nil
+}
+
+func (p *ORCParser) parseStripe(data []byte, info orcStripeInfo) (*orcStripe, error) {
+	footer, err := p.decompressor.decompressAll(data[info.dataLength:])
+	if err != nil {
+		return nil, errors.Annotate(err, "failed to decompress the stripe footer")
+	}
+	stripe := &orcStripe{
+		tail:         p.tail,
+		decompressor: p.decompressor,
+		streams:      make(map[orcStreamKey][]byte),
+		location:     time.UTC,
+	}
+	offset := -info.indexLength
+	timezone := ""
+	err = walkORCProto(footer, func(f *orcProtoField) error {
+		switch f.num {
+		case 1:
+			var key orcStreamKey
+			var length int64
+			err := walkORCProto(f.bytes, func(f *orcProtoField) error {
+				switch f.num {
+				case 1:
+					key.kind = f.varint
+				case 2:
+					key.column = uint32(f.varint)
+				case 3:
+					length = int64(f.varint)
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			if offset >= 0 {
+				if offset+length > info.dataLength {
+					return errors.Errorf("the stream %d of the column %d is out of the stripe", key.kind, key.column)
+				}
+				stripe.streams[key] = data[offset : offset+length]
+			}
+			offset += length
+		case 2:
+			var encoding orcColumnEncoding
+			err := walkORCProto(f.bytes, func(f *orcProtoField) error {
+				switch f.num {
+				case 1:
+					encoding.kind = f.varint
+				case 2:
+					encoding.dictionarySize = f.varint
+				}
+				return nil
+			})
+			stripe.encodings = append(stripe.encodings, encoding)
+			return err
+		case 3:
+			timezone = string(f.bytes)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if timezone != "" {
+		location, err := time.LoadLocation(timezone)
+		if err != nil {
+			p.logger.Warn("failed to load the time zone of the orc writer, the timestamps are read in UTC",
+				zap.String("file", p.path), zap.String("timezone", timezone), zap.Error(err))
+		} else {
+			stripe.location = location
+		}
+	}
+	return stripe, nil
+}
+
+// Pos returns the index of the next row.
+// It implements the Parser interface.
+func (p *ORCParser) Pos() (pos int64, rowID int64) {
+	return p.curRow, p.lastRow.RowID
+}
+
+// SetPos sets the index of the next row, the rows before it in the stripe are skipped.
+// It implements the Parser interface.
+func (p *ORCParser) SetPos(pos int64, rowID int64) error {
+	p.lastRow.RowID = rowID
+	p.stripeStart, p.stripeIdx, p.readers = 0, -1, nil
+	for idx, stripe := range p.tail.stripes {
+		if pos < p.stripeStart+stripe.rows {
+			if err := p.openStripe(idx); err != nil {
+				return err
+			}
+			p.curRow = p.stripeStart
+			for range pos - p.stripeStart {
+				if err := p.readValues(nil); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		p.stripeStart += stripe.rows
+	}
+	p.curRow, p.stripeIdx = pos, len(p.tail.stripes)
+	return nil
+}
+
+// ScannedPos implements the Parser interface.
+// For orc it's the end offset of the stripe being read.
+func (p *ORCParser) ScannedPos() (int64, error) {
+	if p.stripeIdx < 0 {
+		return 0, nil
+	}
+	if p.stripeIdx >= len(p.tail.stripes) {
+		return p.reader.Seek(0, io.SeekEnd)
+	}
+	stripe := p.tail.stripes[p.stripeIdx]
+	return stripe.offset + stripe.size(), nil
+}
+
+// Close closes the file of the parser.
+// It implements the Parser interface.
+func (p *ORCParser) Close() error {
+	p.decompressor.close()
+	return p.reader.Close()
+}
+
+// ReadRow reads a row in the orc file by the parser.
+// It implements the Parser interface.
+func (p *ORCParser) ReadRow() error {
+	p.lastRow.RowID++
+	p.lastRow.Length = 0
+	for p.stripeIdx < 0 || p.stripeIdx >= len(p.tail.stripes) ||
+		p.curRow >= p.stripeStart+p.tail.stripes[p.stripeIdx].rows {
+		next := p.stripeIdx + 1
+		if p.stripeIdx >= 0 && p.stripeIdx < len(p.tail.stripes) {
+			p.stripeStart += p.tail.stripes[p.stripeIdx].rows
+		}
+		if next >= len(p.tail.stripes) {
+			p.stripeIdx, p.readers = len(p.tail.stripes), nil
+			return io.EOF
+		}
+		if err := p.openStripe(next); err != nil {
+			return err
+		}
+	}
+
+	if cap(p.lastRow.Row) < len(p.readers) {
+		p.lastRow.Row = make([]types.Datum, len(p.readers))
+	} else {
+		p.lastRow.Row = p.lastRow.Row[:len(p.readers)]
+	}
+	return p.readValues(p.lastRow.Row)
+}
+
+// readValues reads the values of the next row into the row, the values are skipped if the row is nil.
+func (p *ORCParser) readValues(row []types.Datum) error {
+	root := p.tail.types[0]
+	for i, reader := range p.readers {
+		v, err := reader.next()
+		if err == nil && row != nil {
+			var length int
+			length, err = setORCDatum(&row[i], v)
+			p.lastRow.Length += length
+		}
+		if err != nil {
+			return errors.Annotatef(err, "failed to read the column %s (%s) at row %d of the stripe %d of the orc file %s",
+				root.fieldNames[i], p.tail.typeString(root.subtypes[i]), p.curRow-p.stripeStart, p.stripeIdx, p.path)
+		}
+	}
+	p.curRow++
+	return nil
+}
+
+// setORCDatum converts the value of the column to the datum, and returns the length of the value.
+func setORCDatum(d *types.Datum, v any) (int, error) {
+	switch x := v.(type) {
+	case nil:
+		d.SetNull()
+		return 0, nil
+	case bool:
+		if x {
+			d.SetUint64(1)
+		} else {
+			d.SetUint64(0)
+		}
+		return 1, nil
+	case int64:
+		d.SetInt64(x)
+		return 8, nil
+	case float32:
+		d.SetFloat32(x)
+		return 4, nil
+	case float64:
+		d.SetFloat64(x)
+		return 8, nil
+	case string:
+		d.SetString(x, "utf8mb4_bin")
+		return len(x), nil
+	case orcDecimalText:
+		d.SetString(string(x), "utf8mb4_bin")
+		return len(x), nil
+	case []byte:
+		d.SetBytes(x)
+		return len(x), nil
+	default:
+		var buf bytes.Buffer
+		if err := writeORCJSON(&buf, v); err != nil {
+			return 0, err
+		}
+		d.SetString(buf.String(), "utf8mb4_bin")
+		return buf.Len(), nil
+	}
+}
+
+// writeORCJSON encodes the struct, list or map as JSON, the fields of the structs are kept in order.
+func writeORCJSON(buf *bytes.Buffer, v any) error {
+	switch x := v.(type) {
+	case orcDecimalText:
+		buf.WriteString(string(x))
+		return nil
+	case orcStructValue:
+		buf.WriteByte('{')
+		for i, name := range x.names {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			key, _ := json.Marshal(name)
+			buf.Write(key)
+			buf.WriteByte(':')
+			if err := writeORCJSON(buf, x.values[i]); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+		return nil
+	case []any:
+		buf.WriteByte('[')
+		for i, item := range x {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeORCJSON(buf, item); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+		return nil
+	case orcMapValue:
+		buf.WriteByte('{')
+		for i, key := range x.keys {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			text, ok := key.(string)
+			if !ok {
+				text = fmt.Sprint(key)
+			}
+			encoded, _ := json.Marshal(text)
+			buf.Write(encoded)
+			buf.WriteByte(':')
+			if err := writeORCJSON(buf, x.values[i]); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+		return nil
+	}
+	encoded, err := json.Marshal(v)
+	if err != nil {
+		return errors.Annotatef(err, "failed to encode the orc value %v as json", v)
+	}
+	buf.Write(encoded)
+	return nil
+}
+
+// LastRow gets the last row parsed by the parser.
+// It implements the Parser interface.
+func (p *ORCParser) LastRow() Row {
+	return p.lastRow
+}
+
+// RecycleRow implements the Parser interface.
+func (*ORCParser) RecycleRow(_ Row) {
+}
+
+// Columns returns the _lower-case_ column names corresponding to values in
+// the LastRow.
+func (p *ORCParser) Columns() []string {
+	return p.columns
+}
+
+// SetColumns set restored column names to parser
+func (*ORCParser) SetColumns(_ []string) {
+	// just do nothing
+}
+
+// SetLogger sets the logger used in the parser.
+// It implements the Parser interface.
+func (p *ORCParser) SetLogger(l log.Logger) {
+	p.logger = l
+}
+
+// SetRowID sets the rowID in an orc file.
+// It implements the Parser interface.
+func (p *ORCParser) SetRowID(rowID int64) {
+	p.lastRow.RowID = rowID
+}
+
+func readORCTailByFile(ctx context.Context, store storage.ExternalStorage, path string) (*orcTail, error) {
+	r, err := store.Open(ctx, path, nil)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	//nolint: errcheck
+	defer r.Close()
+	decompressor := &orcDecompressor{}
+	defer decompressor.close()
+	tail, err := readORCTail(r, decompressor)
+	if err != nil {
+		return nil, errors.Annotatef(err, "failed to read the orc file %s", path)
+	}
+	return tail, nil
+}
+
+// ReadORCFileRowCountByFile reads the row count from the footer of the orc file.
+func ReadORCFileRowCountByFile(
+	ctx context.Context,
+	store storage.ExternalStorage,
+	fileMeta SourceFileMeta,
+) (int64, error) {
+	tail, err := readORCTailByFile(ctx, store, fileMeta.Path)
+	if err != nil {
+		return 0, err
+	}
+	return tail.rows, nil
+}
+
+// makeORCFileRegion splits the orc file into the regions of about cfg.MaxChunkSize by the stripes, so the
+// stripes can be imported in parallel. Like the parquet files, the offsets of the regions are the indexes
+// of the rows.
+func makeORCFileRegion(
+	ctx context.Context,
+	cfg *DataDivideConfig,
+	dataFile FileInfo,
+) ([]*TableRegion, []float64, error) {
+	tail, err := readORCTailByFile(ctx, cfg.Store, dataFile.FileMeta.Path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var (
+		regions               []*TableRegion
+		sizes                 []float64
+		regionStart, rowCount int64
+		regionSize            int64
+	)
+	addRegion := func() {
+		regions = append(regions, &TableRegion{
+			DB:       cfg.TableMeta.DB,
+			Table:    cfg.TableMeta.Name,
+			FileMeta: dataFile.FileMeta,
+			Chunk: Chunk{
+				Offset:       regionStart,
+				EndOffset:    rowCount,
+				PrevRowIDMax: regionStart,
+				RowIDMax:     rowCount,
+			},
+		})
+		sizes = append(sizes, float64(regionSize))
+		regionStart, regionSize = rowCount, 0
+	}
+	for _, stripe := range tail.stripes {
+		if rowCount > regionStart && regionSize >= cfg.MaxChunkSize {
+			addRegion()
+		}
+		rowCount += stripe.rows
+		regionSize += stripe.size()
+	}
+	if rowCount > regionStart || len(regions) == 0 {
+		addRegion()
+	}
+	if len(regions) > 1 {
+		log.FromContext(ctx).Info("split the orc file by the stripes", zap.String("file", dataFile.FileMeta.Path),
+			zap.Int("regions", len(regions)), zap.Int64("rows", rowCount))
+	}
+	return regions, sizes, nil
+}
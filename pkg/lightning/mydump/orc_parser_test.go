@@ -0,0 +1,551 @@
+// Copyright 2026 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mydump
+
+import (
+	"bytes"
+	"compress/flate"
+	"context"
+	"encoding/binary"
+	"io"
+	"math"
+	"slices"
+	"testing"
+
+	"github.com/pingcap/tidb/br/pkg/storage"
+	"github.com/pingcap/tidb/pkg/types"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// orcTestRow is a row of the test schema, the nil pointers are null.
+type orcTestRow struct {
+	id    int64
+	name  *string
+	score float64
+	price int64 // the unscaled value of decimal(10,2)
+	day   int64
+	// seconds since 2015-01-01 and the nanoseconds.
+	seconds, nanos int64
+	ok             bool
+	tags           []string
+	zip            *int64 // the field of the struct info
+	attrs          map[string]int64
+	tiny           int8
+}
+
+const orcTestSchemaColumns = 15
+
+// orcTestTypes is the struct<id:bigint,name:string,score:double,price:decimal(10,2),day:date,ts:timestamp,
+// ok:boolean,tags:array<string>,info:struct<zip:int>,attrs:map<string,int>,tiny:tinyint>.
+func orcTestTypes() [][]byte {
+	tp := func(kind orcKind, subtypes []uint32, names ...string) []byte {
+		b := protowire.AppendTag(nil, 1, protowire.VarintType)
+		b = protowire.AppendVarint(b, uint64(kind))
+		for _, sub := range subtypes {
+			b = protowire.AppendTag(b, 2, protowire.VarintType)
+			b = protowire.AppendVarint(b, uint64(sub))
+		}
+		for _, name := range names {
+			b = protowire.AppendTag(b, 3, protowire.BytesType)
+			b = protowire.AppendString(b, name)
+		}
+		return b
+	}
+	decimal := tp(orcDecimal, nil)
+	decimal = protowire.AppendTag(decimal, 5, protowire.VarintType)
+	decimal = protowire.AppendVarint(decimal, 10)
+	decimal = protowire.AppendTag(decimal, 6, protowire.VarintType)
+	decimal = protowire.AppendVarint(decimal, 2)
+	return [][]byte{
+		tp(orcStruct, []uint32{1, 2, 3, 4, 5, 6, 7, 8, 10, 12, 15},
+			"id", "name", "score", "price", "day", "ts", "ok", "tags", "info", "attrs", "tiny"),
+		tp(orcLong, nil),
+		tp(orcString, nil),
+		tp(orcDouble, nil),
+		decimal,
+		tp(orcDate, nil),
+		tp(orcTimestamp, nil),
+		tp(orcBoolean, nil),
+		tp(orcList, []uint32{9}),
+		tp(orcString, nil),
+		tp(orcStruct, []uint32{11}, "zip"),
+		tp(orcInt, nil),
+		tp(orcMap, []uint32{13, 14}),
+		tp(orcString, nil),
+		tp(orcInt, nil),
+		tp(orcByte, nil),
+	}
+}
+
+func orcTestZigzag(v int64) uint64 {
+	return uint64(v<<1) ^ uint64(v>>63)
+}
+
+// orcTestInts encodes the integers by the literals of the run length encoding version 1, or the direct
+// runs of 64 bits of the version 2.
+func orcTestInts(v2, signed bool, values ...int64) []byte {
+	var b []byte
+	for chunk := range slices.Chunk(values, 128) {
+		if v2 {
+			b = append(b, 1<<6|31<<1|byte((len(chunk)-1)>>8), byte(len(chunk)-1))
+			for _, v := range chunk {
+				u := uint64(v)
+				if signed {
+					u = orcTestZigzag(v)
+				}
+				b = binary.BigEndian.AppendUint64(b, u)
+			}
+			continue
+		}
+		b = append(b, byte(0x100-len(chunk)))
+		for _, v := range chunk {
+			u := uint64(v)
+			if signed {
+				u = orcTestZigzag(v)
+			}
+			b = binary.AppendUvarint(b, u)
+		}
+	}
+	return b
+}
+
+func orcTestByteRLE(values []byte) []byte {
+	var b []byte
+	for chunk := range slices.Chunk(values, 128) {
+		b = append(b, byte(0x100-len(chunk)))
+		b = append(b, chunk...)
+	}
+	return b
+}
+
+func orcTestBools(values ...bool) []byte {
+	packed := make([]byte, (len(values)+7)/8)
+	for i, v := range values {
+		if v {
+			packed[i/8] |= 0x80 >> (i % 8)
+		}
+	}
+	return orcTestByteRLE(packed)
+}
+
+type orcTestWriter struct {
+	buf         bytes.Buffer
+	compression orcCompression
+	v2          bool
+	stripes     [][]byte
+	rows        int64
+	// truncateID truncates the data of the id column to break the file.
+	truncateID bool
+}
+
+func newORCTestWriter(compression orcCompression, v2 bool) *orcTestWriter {
+	w := &orcTestWriter{compression: compression, v2: v2}
+	w.buf.WriteString(orcMagic)
+	return w
+}
+
+// compress compresses the data into the chunks of at most 64 bytes, so a value can cross the chunks.
+func (w *orcTestWriter) compress(t *testing.T, data []byte) []byte {
+	if w.compression == orcCompressionNone {
+		return data
+	}
+	var out []byte
+	for chunk := range slices.Chunk(data, 64) {
+		var compressed bytes.Buffer
+		fw, err := flate.NewWriter(&compressed, flate.BestCompression)
+		require.NoError(t, err)
+		_, err = fw.Write(chunk)
+		require.NoError(t, err)
+		require.NoError(t, fw.Close())
+		header, body := uint32(compressed.Len())<<1, compressed.Bytes()
+		if compressed.Len() >= len(chunk) {
+			header, body = uint32(len(chunk))<<1|1, chunk
+		}
+		out = append(out, byte(header), byte(header>>8), byte(header>>16))
+		out = append(out, body...)
+	}
+	return out
+}
+
+// writeStripe writes the rows as a stripe, and returns the offset of the stripe.
+func (w *orcTestWriter) writeStripe(t *testing.T, rows ...orcTestRow) int64 {
+	type stream struct {
+		column uint32
+		kind   uint64
+		data   []byte
+	}
+	var (
+		streams                               []stream
+		ids, prices, scales, days, secs, nano []int64
+		nameData                              []byte
+		namePresent, infoPresent, attrPresent []bool
+		nameLengths, tagLengths, attrLengths  []int64
+		scores                                []byte
+		oks                                   []bool
+		tags                                  []string
+		zips, attrValues                      []int64
+		keyData                               []byte
+		keyLengths                            []int64
+		tinies                                []byte
+	)
+	for _, r := range rows {
+		ids = append(ids, r.id)
+		namePresent = append(namePresent, r.name != nil)
+		if r.name != nil {
+			nameData = append(nameData, *r.name...)
+			nameLengths = append(nameLengths, int64(len(*r.name)))
+		}
+		scores = binary.LittleEndian.AppendUint64(scores, math.Float64bits(r.score))
+		prices, scales = append(prices, r.price), append(scales, 2)
+		days = append(days, r.day)
+		secs = append(secs, r.seconds)
+		encodedNanos := r.nanos << 3
+		if r.nanos != 0 && r.nanos%100 == 0 {
+			zeros := int64(0)
+			for encodedNanos = r.nanos; encodedNanos%10 == 0 && zeros < 8; zeros++ {
+				encodedNanos /= 10
+			}
+			encodedNanos = encodedNanos<<3 | (zeros - 1)
+		}
+		nano = append(nano, encodedNanos)
+		oks = append(oks, r.ok)
+		tagLengths = append(tagLengths, int64(len(r.tags)))
+		tags = append(tags, r.tags...)
+		infoPresent = append(infoPresent, r.zip != nil)
+		if r.zip != nil {
+			zips = append(zips, *r.zip)
+		}
+		attrPresent = append(attrPresent, r.attrs != nil)
+		if r.attrs != nil {
+			attrLengths = append(attrLengths, int64(len(r.attrs)))
+			for _, key := range slices.Sorted(func(yield func(string) bool) {
+				for key := range r.attrs {
+					if !yield(key) {
+						return
+					}
+				}
+			}) {
+				keyData = append(keyData, key...)
+				keyLengths = append(keyLengths, int64(len(key)))
+				attrValues = append(attrValues, r.attrs[key])
+			}
+		}
+		tinies = append(tinies, byte(r.tiny))
+	}
+	dictionary := slices.Compact(slices.Sorted(slices.Values(tags)))
+	var dictData []byte
+	var dictLengths, tagIndexes []int64
+	for _, tag := range dictionary {
+		dictData = append(dictData, tag...)
+		dictLengths = append(dictLengths, int64(len(tag)))
+	}
+	for _, tag := range tags {
+		idx, _ := slices.BinarySearch(dictionary, tag)
+		tagIndexes = append(tagIndexes, int64(idx))
+	}
+
+	v2 := w.v2
+	idData := orcTestInts(v2, true, ids...)
+	if w.truncateID {
+		idData = idData[:len(idData)/2]
+	}
+	streams = append(streams,
+		stream{1, orcStreamData, idData},
+		stream{2, orcStreamPresent, orcTestBools(namePresent...)},
+		stream{2, orcStreamData, nameData},
+		stream{2, orcStreamLength, orcTestInts(v2, false, nameLengths...)},
+		stream{3, orcStreamData, scores},
+		stream{4, orcStreamData, orcTestDecimals(prices)},
+		stream{4, orcStreamSecondary, orcTestInts(v2, true, scales...)},
+		stream{5, orcStreamData, orcTestInts(v2, true, days...)},
+		stream{6, orcStreamData, orcTestInts(v2, true, secs...)},
+		stream{6, orcStreamSecondary, orcTestInts(v2, false, nano...)},
+		stream{7, orcStreamData, orcTestBools(oks...)},
+		stream{8, orcStreamLength, orcTestInts(v2, false, tagLengths...)},
+		stream{9, orcStreamData, orcTestInts(v2, false, tagIndexes...)},
+		stream{9, orcStreamDictionaryData, dictData},
+		stream{9, orcStreamLength, orcTestInts(v2, false, dictLengths...)},
+		stream{10, orcStreamPresent, orcTestBools(infoPresent...)},
+		stream{11, orcStreamData, orcTestInts(v2, true, zips...)},
+		stream{12, orcStreamPresent, orcTestBools(attrPresent...)},
+		stream{12, orcStreamLength, orcTestInts(v2, false, attrLengths...)},
+		stream{13, orcStreamData, keyData},
+		stream{13, orcStreamLength, orcTestInts(v2, false, keyLengths...)},
+		stream{14, orcStreamData, orcTestInts(v2, true, attrValues...)},
+		stream{15, orcStreamData, orcTestByteRLE(tinies)},
+	)
+
+	offset := int64(w.buf.Len())
+	var footer []byte
+	var dataLength int64
+	for _, s := range streams {
+		data := w.compress(t, s.data)
+		w.buf.Write(data)
+		dataLength += int64(len(data))
+		var msg []byte
+		msg = protowire.AppendTag(msg, 1, protowire.VarintType)
+		msg = protowire.AppendVarint(msg, s.kind)
+		msg = protowire.AppendTag(msg, 2, protowire.VarintType)
+		msg = protowire.AppendVarint(msg, uint64(s.column))
+		msg = protowire.AppendTag(msg, 3, protowire.VarintType)
+		msg = protowire.AppendVarint(msg, uint64(len(data)))
+		footer = protowire.AppendTag(footer, 1, protowire.BytesType)
+		footer = protowire.AppendBytes(footer, msg)
+	}
+	direct, dict := uint64(orcEncodingDirect), uint64(orcEncodingDictionary)
+	if v2 {
+		direct, dict = orcEncodingDirectV2, orcEncodingDictionaryV2
+	}
+	for column := range orcTestSchemaColumns + 1 {
+		var msg []byte
+		msg = protowire.AppendTag(msg, 1, protowire.VarintType)
+		if column == 9 {
+			msg = protowire.AppendVarint(msg, dict)
+			msg = protowire.AppendTag(msg, 2, protowire.VarintType)
+			msg = protowire.AppendVarint(msg, uint64(len(dictionary)))
+		} else {
+			msg = protowire.AppendVarint(msg, direct)
+		}
+		footer = protowire.AppendTag(footer, 2, protowire.BytesType)
+		footer = protowire.AppendBytes(footer, msg)
+	}
+	footer = protowire.AppendTag(footer, 3, protowire.BytesType)
+	footer = protowire.AppendString(footer, "UTC")
+	footer = w.compress(t, footer)
+	w.buf.Write(footer)
+
+	var info []byte
+	for i, v := range []int64{offset, 0, dataLength, int64(len(footer)), int64(len(rows))} {
+		info = protowire.AppendTag(info, protowire.Number(i+1), protowire.VarintType)
+		info = protowire.AppendVarint(info, uint64(v))
+	}
+	w.stripes = append(w.stripes, info)
+	w.rows += int64(len(rows))
+	return offset
+}
+
+func orcTestDecimals(values []int64) []byte {
+	var b []byte
+	for _, v := range values {
+		b = binary.AppendUvarint(b, orcTestZigzag(v))
+	}
+	return b
+}
+
+func (w *orcTestWriter) finish(t *testing.T) []byte {
+	var footer []byte
+	footer = protowire.AppendTag(footer, 1, protowire.VarintType)
+	footer = protowire.AppendVarint(footer, uint64(len(orcMagic)))
+	for _, stripe := range w.stripes {
+		footer = protowire.AppendTag(footer, 3, protowire.BytesType)
+		footer = protowire.AppendBytes(footer, stripe)
+	}
+	for _, tp := range orcTestTypes() {
+		footer = protowire.AppendTag(footer, 4, protowire.BytesType)
+		footer = protowire.AppendBytes(footer, tp)
+	}
+	footer = protowire.AppendTag(footer, 6, protowire.VarintType)
+	footer = protowire.AppendVarint(footer, uint64(w.rows))
+	footer = w.compress(t, footer)
+	w.buf.Write(footer)
+
+	var ps []byte
+	ps = protowire.AppendTag(ps, 1, protowire.VarintType)
+	ps = protowire.AppendVarint(ps, uint64(len(footer)))
+	ps = protowire.AppendTag(ps, 2, protowire.VarintType)
+	ps = protowire.AppendVarint(ps, uint64(w.compression))
+	ps = protowire.AppendTag(ps, 3, protowire.VarintType)
+	ps = protowire.AppendVarint(ps, 64)
+	ps = protowire.AppendTag(ps, 8000, protowire.BytesType)
+	ps = protowire.AppendString(ps, orcMagic)
+	w.buf.Write(ps)
+	w.buf.WriteByte(byte(len(ps)))
+	return w.buf.Bytes()
+}
+
+func orcTestStore(t *testing.T, name string, data []byte) storage.ExternalStorage {
+	s, err := storage.NewLocalStorage(t.TempDir())
+	require.NoError(t, err)
+	require.NoError(t, s.WriteFile(context.Background(), name, data))
+	return s
+}
+
+func openORCTestParser(t *testing.T, s storage.ExternalStorage, name string) *ORCParser {
+	r, err := s.Open(context.Background(), name, nil)
+	require.NoError(t, err)
+	p, err := NewORCParser(context.Background(), r, name)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = p.Close() })
+	return p
+}
+
+func orcTestRows(start, n int) []orcTestRow {
+	rows := make([]orcTestRow, 0, n)
+	for i := start; i < start+n; i++ {
+		rows = append(rows, orcTestRow{id: int64(i), score: float64(i) / 2, price: int64(i) * 101, day: int64(i)})
+	}
+	return rows
+}
+
+func TestORCParser(t *testing.T) {
+	alice := "alice"
+	zip := int64(94000)
+	for _, c := range []struct {
+		compression orcCompression
+		v2          bool
+	}{
+		{orcCompressionNone, false},
+		{orcCompressionZlib, true},
+	} {
+		t.Run(c.compression.String(), func(t *testing.T) {
+			w := newORCTestWriter(c.compression, c.v2)
+			w.writeStripe(t,
+				orcTestRow{
+					id: 1, name: &alice, score: 1.5, price: 12345, day: 19000, nanos: 123000000, ok: true,
+					tags: []string{"b", "a", "b"}, zip: &zip, attrs: map[string]int64{"k": 1, "j": -2}, tiny: -1,
+				},
+				orcTestRow{id: 2, seconds: -1, nanos: 1000, tags: []string{}},
+			)
+			w.writeStripe(t, orcTestRows(3, 200)...)
+			s := orcTestStore(t, "test.orc", w.finish(t))
+
+			p := openORCTestParser(t, s, "test.orc")
+			require.Equal(t, []string{"id", "name", "score", "price", "day", "ts", "ok", "tags", "info", "attrs", "tiny"},
+				p.Columns())
+			require.NoError(t, p.ReadRow())
+			require.Equal(t, int64(1), p.LastRow().RowID)
+			require.Equal(t, []types.Datum{
+				types.NewIntDatum(1),
+				types.NewCollationStringDatum("alice", "utf8mb4_bin"),
+				types.NewFloat64Datum(1.5),
+				types.NewCollationStringDatum("123.45", "utf8mb4_bin"),
+				types.NewCollationStringDatum("2022-01-08", "utf8mb4_bin"),
+				types.NewCollationStringDatum("2015-01-01 00:00:00.123", "utf8mb4_bin"),
+				types.NewUintDatum(1),
+				types.NewCollationStringDatum(`["b","a","b"]`, "utf8mb4_bin"),
+				types.NewCollationStringDatum(`{"zip":94000}`, "utf8mb4_bin"),
+				types.NewCollationStringDatum(`{"j":-2,"k":1}`, "utf8mb4_bin"),
+				types.NewIntDatum(-1),
+			}, p.LastRow().Row)
+
+			require.NoError(t, p.ReadRow())
+			row := p.LastRow().Row
+			require.True(t, row[1].IsNull())
+			require.Equal(t, types.NewCollationStringDatum("2014-12-31 23:59:59.000001", "utf8mb4_bin"), row[5])
+			require.Equal(t, types.NewCollationStringDatum(`[]`, "utf8mb4_bin"), row[7])
+			require.True(t, row[8].IsNull())
+			require.True(t, row[9].IsNull())
+
+			for i := 3; i < 203; i++ {
+				require.NoError(t, p.ReadRow())
+				require.Equal(t, int64(i), p.LastRow().RowID)
+				require.Equal(t, types.NewIntDatum(int64(i)), p.LastRow().Row[0])
+				require.Equal(t, types.NewFloat64Datum(float64(i)/2), p.LastRow().Row[2])
+			}
+			require.ErrorIs(t, p.ReadRow(), io.EOF)
+			pos, rowID := p.Pos()
+			require.Equal(t, int64(202), pos)
+			require.Equal(t, int64(203), rowID)
+		})
+	}
+}
+
+func TestMakeORCFileRegionAndSetPos(t *testing.T) {
+	w := newORCTestWriter(orcCompressionZlib, true)
+	var offsets []int64
+	for i := range 6 {
+		offsets = append(offsets, w.writeStripe(t, orcTestRows(i*10, 10)...))
+	}
+	data := w.finish(t)
+	s := orcTestStore(t, "test.orc", data)
+
+	rows, err := ReadORCFileRowCountByFile(context.Background(), s, SourceFileMeta{Path: "test.orc"})
+	require.NoError(t, err)
+	require.Equal(t, int64(60), rows)
+
+	cfg := &DataDivideConfig{
+		Store:        s,
+		MaxChunkSize: offsets[2] - offsets[0],
+		TableMeta:    &MDTableMeta{DB: "db", Name: "t"},
+	}
+	fileInfo := FileInfo{FileMeta: SourceFileMeta{Path: "test.orc", Type: SourceTypeORC, FileSize: int64(len(data))}}
+	regions, sizes, err := makeORCFileRegion(context.Background(), cfg, fileInfo)
+	require.NoError(t, err)
+	require.Len(t, regions, 3)
+	require.Len(t, sizes, 3)
+	for i, region := range regions {
+		require.Equal(t, Chunk{
+			Offset:       int64(i * 20),
+			EndOffset:    int64(i*20 + 20),
+			PrevRowIDMax: int64(i * 20),
+			RowIDMax:     int64(i*20 + 20),
+		}, region.Chunk)
+		require.Greater(t, sizes[i], float64(0))
+	}
+
+	// read the regions like the chunk processor.
+	var ids []int64
+	for _, region := range regions {
+		p := openORCTestParser(t, s, "test.orc")
+		require.NoError(t, p.SetPos(region.Chunk.Offset, region.Chunk.PrevRowIDMax))
+		for {
+			pos, _ := p.Pos()
+			if pos >= region.Chunk.EndOffset {
+				break
+			}
+			require.NoError(t, p.ReadRow())
+			require.Equal(t, int64(len(ids)+1), p.LastRow().RowID)
+			ids = append(ids, p.LastRow().Row[0].GetInt64())
+		}
+		scanned, err := p.ScannedPos()
+		require.NoError(t, err)
+		require.Greater(t, scanned, int64(0))
+	}
+	require.Len(t, ids, 60)
+	for i, v := range ids {
+		require.Equal(t, int64(i), v)
+	}
+
+	// resume from the checkpoint in the middle of a stripe.
+	p := openORCTestParser(t, s, "test.orc")
+	require.NoError(t, p.SetPos(35, 35))
+	pos, rowID := p.Pos()
+	require.Equal(t, int64(35), pos)
+	require.Equal(t, int64(35), rowID)
+	require.NoError(t, p.ReadRow())
+	require.Equal(t, int64(36), p.LastRow().RowID)
+	require.Equal(t, types.NewIntDatum(35), p.LastRow().Row[0])
+	require.NoError(t, p.SetPos(60, 60))
+	require.ErrorIs(t, p.ReadRow(), io.EOF)
+}
+
+func TestORCParserDiagnostics(t *testing.T) {
+	w := newORCTestWriter(orcCompressionNone, false)
+	w.truncateID = true
+	w.writeStripe(t, orcTestRows(0, 4)...)
+	s := orcTestStore(t, "bad.orc", w.finish(t))
+	p := openORCTestParser(t, s, "bad.orc")
+	require.NoError(t, p.ReadRow())
+	require.ErrorContains(t, p.ReadRow(),
+		"failed to read the column id (bigint) at row 1 of the stripe 0 of the orc file bad.orc: unexpected end of the orc stream")
+
+	s = orcTestStore(t, "bad.orc", []byte("not an orc file"))
+	r, err := s.Open(context.Background(), "bad.orc", nil)
+	require.NoError(t, err)
+	_, err = NewORCParser(context.Background(), r, "bad.orc")
+	require.ErrorContains(t, err, "failed to read the orc file bad.orc: not an orc file")
+	require.NoError(t, r.Close())
+}
@@ -0,0 +1,123 @@
+// Copyright 2026 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mydump
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newORCTestStream(data ...byte) *orcStream {
+	return &orcStream{decompressor: &orcDecompressor{}, data: data}
+}
+
+func readORCTestInts(t *testing.T, d orcIntDecoder, n int) []int64 {
+	values := make([]int64, 0, n)
+	for range n {
+		v, err := d.next()
+		require.NoError(t, err)
+		values = append(values, v)
+	}
+	return values
+}
+
+func TestORCIntRLE(t *testing.T) {
+	// most of the cases are the examples in the specification.
+	cases := []struct {
+		v2       bool
+		signed   bool
+		data     []byte
+		expected []int64
+	}{
+		{false, false, []byte{0x00, 0x00, 0x64}, []int64{100, 100, 100}},
+		{false, false, []byte{0x00, 0xff, 0x64}, []int64{100, 99, 98}},
+		{false, false, []byte{0xfb, 0x02, 0x03, 0x06, 0x07, 0x0b}, []int64{2, 3, 6, 7, 11}},
+		{false, true, []byte{0xfe, 0x03, 0x04}, []int64{-2, 2}},
+		{true, false, []byte{0x0a, 0x27, 0x10}, []int64{10000, 10000, 10000, 10000, 10000}},
+		{true, false, []byte{0x5e, 0x03, 0x5c, 0xa1, 0xab, 0x1e, 0xde, 0xad, 0xbe, 0xef},
+			[]int64{23713, 43806, 57005, 48879}},
+		{true, false, []byte{
+			0x8e, 0x13, 0x2b, 0x21, 0x07, 0xd0, 0x1e, 0x00, 0x14, 0x70, 0x28, 0x32, 0x3c, 0x46, 0x50, 0x5a, 0x64, 0x6e,
+			0x78, 0x82, 0x8c, 0x96, 0xa0, 0xaa, 0xb4, 0xbe, 0xfc, 0xe8,
+		}, []int64{
+			2030, 2000, 2020, 1000000, 2040, 2050, 2060, 2070, 2080, 2090, 2100, 2110, 2120, 2130, 2140, 2150, 2160,
+			2170, 2180, 2190,
+		}},
+		{true, false, []byte{0xc6, 0x09, 0x02, 0x02, 0x22, 0x42, 0x42, 0x46},
+			[]int64{2, 3, 5, 7, 11, 13, 17, 19, 23, 29}},
+		// fixed delta of -1 from 10.
+		{true, true, []byte{0xc0, 0x03, 0x14, 0x01}, []int64{10, 9, 8, 7}},
+	}
+	for i, c := range cases {
+		d := newORCIntDecoder(newORCTestStream(c.data...), c.v2, c.signed)
+		require.Equal(t, c.expected, readORCTestInts(t, d, len(c.expected)), "case %d", i)
+		_, err := d.next()
+		require.ErrorIs(t, err, errORCShortStream, "case %d", i)
+	}
+}
+
+func TestORCByteAndBoolRLE(t *testing.T) {
+	d := &orcByteRLE{s: newORCTestStream(0x61, 0x00, 0xfe, 0x44, 0x45)}
+	for range 100 {
+		b, err := d.next()
+		require.NoError(t, err)
+		require.Equal(t, byte(0), b)
+	}
+	for _, expected := range []byte{0x44, 0x45} {
+		b, err := d.next()
+		require.NoError(t, err)
+		require.Equal(t, expected, b)
+	}
+
+	bools := newORCBoolRLE(newORCTestStream(0xff, 0x80))
+	for _, expected := range []bool{true, false, false, false, false, false, false, false} {
+		b, err := bools.next()
+		require.NoError(t, err)
+		require.Equal(t, expected, b)
+	}
+}
+
+func TestORCDecimal(t *testing.T) {
+	// 12345 and -12345 by the zigzag encoding.
+	v, err := readORCBigVarint(newORCTestStream(0xf2, 0xc0, 0x01))
+	require.NoError(t, err)
+	require.Equal(t, "12345", v.String())
+	v, err = readORCBigVarint(newORCTestStream(0xf1, 0xc0, 0x01))
+	require.NoError(t, err)
+	require.Equal(t, "-12345", v.String())
+
+	require.Equal(t, "123.45", formatORCDecimal(big.NewInt(12345), 2))
+	require.Equal(t, "-0.045", formatORCDecimal(big.NewInt(-45), 3))
+	require.Equal(t, "1200", formatORCDecimal(big.NewInt(12), -2))
+	huge, _ := new(big.Int).SetString("-123456789012345678901234567890", 10)
+	require.Equal(t, "-1234567890123456789012345678.90", formatORCDecimal(huge, 2))
+}
+
+func TestORCBitWidth(t *testing.T) {
+	require.Equal(t, 1, orcDecodeBitWidth(0))
+	require.Equal(t, 24, orcDecodeBitWidth(23))
+	require.Equal(t, 26, orcDecodeBitWidth(24))
+	require.Equal(t, 32, orcDecodeBitWidth(27))
+	require.Equal(t, 40, orcDecodeBitWidth(28))
+	require.Equal(t, 64, orcDecodeBitWidth(31))
+	require.Equal(t, 1, orcClosestFixedBits(0))
+	require.Equal(t, 14, orcClosestFixedBits(14))
+	require.Equal(t, 26, orcClosestFixedBits(25))
+	require.Equal(t, 32, orcClosestFixedBits(31))
+	require.Equal(t, 40, orcClosestFixedBits(33))
+	require.Equal(t, 64, orcClosestFixedBits(57))
+}
@@ -239,6 +239,10 @@ func MakeTableRegions(
 			dataFileSize := info.FileMeta.FileSize
 			if info.FileMeta.Type == SourceTypeParquet {
 				regions, sizes, err = makeParquetFileRegion(egCtx, cfg, info)
+			} else if info.FileMeta.Type == SourceTypeAvro {
+				regions, sizes, err = makeAvroFileRegion(egCtx, cfg, info)
+			} else if info.FileMeta.Type == SourceTypeORC {
+				regions, sizes, err = makeORCFileRegion(egCtx, cfg, info)
 			} else if info.FileMeta.Type == SourceTypeCSV && cfg.StrictFormat &&
 				info.FileMeta.Compression == CompressionNone &&
 				dataFileSize > cfg.MaxChunkSize+cfg.MaxChunkSize/largeCSVLowerThresholdRation {
@@ -48,6 +48,10 @@ const (
 	SourceTypeParquet
 	// SourceTypeViewSchema means this source file is a schema file for the view.
 	SourceTypeViewSchema
+	// SourceTypeAvro means this source file is an Avro object container file.
+	SourceTypeAvro
+	// SourceTypeORC means this source file is an ORC data file.
+	SourceTypeORC
 )
 
 const (
@@ -63,6 +67,10 @@ const (
 	TypeCSV = "csv"
 	// TypeParquet is the source type value for parquet data file.
 	TypeParquet = "parquet"
+	// TypeAvro is the source type value for avro data file.
+	TypeAvro = "avro"
+	// TypeORC is the source type value for orc data file.
+	TypeORC = "orc"
 	// TypeIgnore is the source type value for a ignored data file.
 	TypeIgnore = "ignore"
 )
@@ -116,6 +124,10 @@ func parseSourceType(t string) (SourceType, error) {
 		return SourceTypeCSV, nil
 	case TypeParquet:
 		return SourceTypeParquet, nil
+	case TypeAvro:
+		return SourceTypeAvro, nil
+	case TypeORC:
+		return SourceTypeORC, nil
 	case TypeIgnore:
 		return SourceTypeIgnore, nil
 	case ViewSchema:
@@ -137,6 +149,10 @@ func (s SourceType) String() string {
 		return TypeSQL
 	case SourceTypeParquet:
 		return TypeParquet
+	case SourceTypeAvro:
+		return TypeAvro
+	case SourceTypeORC:
+		return TypeORC
 	case SourceTypeViewSchema:
 		return ViewSchema
 	default:
@@ -144,6 +160,12 @@ func (s SourceType) String() string {
 	}
 }
 
+// IsRowOffset returns whether the offsets of the chunks are the indexes of the rows instead of the
+// bytes, they are for the columnar files and the avro files.
+func (s SourceType) IsRowOffset() bool {
+	return s == SourceTypeParquet || s == SourceTypeORC || s == SourceTypeAvro
+}
+
 // ParseCompressionOnFileExtension parses the compression type from the file extension.
 func ParseCompressionOnFileExtension(filename string) Compression {
 	fileExt := strings.ToLower(filepath.Ext(filename))
@@ -185,7 +207,7 @@ var defaultFileRouteRules = []*config.FileRouteRule{
 	// ignore *-schema-trigger.sql, *-schema-post.sql files
 	{Pattern: `(?i).*(-schema-trigger|-schema-post)\.sql(?:\.(\w*?))?$`, Type: "ignore"},
 	// ignore backup files
-	{Pattern: `(?i).*\.(sql|csv|parquet)(\.(\w+))?\.(bak|BAK)$`, Type: "ignore"},
+	{Pattern: `(?i).*\.(sql|csv|parquet|avro|orc)(\.(\w+))?\.(bak|BAK)$`, Type: "ignore"},
 	// db schema create file pattern, matches files like '{schema}-schema-create.sql[.{compress}]'
 	{Pattern: `(?i)^(?:[^/]*/)*([^/.]+)-schema-create\.sql(?:\.(\w*?))?$`,
 		Schema: "$1", Table: "", Type: SchemaSchema, Compression: "$2", Unescape: true},
@@ -196,7 +218,7 @@ var defaultFileRouteRules = []*config.FileRouteRule{
 	{Pattern: `(?i)^(?:[^/]*/)*([^/.]+)\.(.*?)-schema-view\.sql(?:\.(\w*?))?$`,
 		Schema: "$1", Table: "$2", Type: ViewSchema, Compression: "$3", Unescape: true},
 	// source file pattern, matches files like '{schema}.{table}.0001.{sql|csv}[.{compress}]'
-	{Pattern: `(?i)^(?:[^/]*/)*([^/.]+)\.(.*?)(?:\.([0-9]+))?\.(sql|csv|parquet)(?:\.(\w+))?$`,
+	{Pattern: `(?i)^(?:[^/]*/)*([^/.]+)\.(.*?)(?:\.([0-9]+))?\.(sql|csv|parquet|avro|orc)(?:\.(\w+))?$`,
 		Schema: "$1", Table: "$2", Type: "$4", Key: "$3", Compression: "$5", Unescape: true},
 }
 
@@ -361,6 +383,12 @@ func (p regexRouterParser) Parse(r *config.FileRouteRule, logger log.Logger) (*R
 			if result.Type == SourceTypeParquet && compression != CompressionNone {
 				return errors.Errorf("can't support whole compressed parquet file, should compress parquet files by choosing correct parquet compress writer, path: %s", r.Path)
 			}
+			if result.Type == SourceTypeAvro && compression != CompressionNone {
+				return errors.Errorf("can't support whole compressed avro file, should compress the blocks of avro files by the avro codec, path: %s", r.Path)
+			}
+			if result.Type == SourceTypeORC && compression != CompressionNone {
+				return errors.Errorf("can't support whole compressed orc file, should compress orc files by the compression of the orc writer, path: %s", r.Path)
+			}
 			result.Compression = compression
 			return nil
 		})
@@ -69,6 +69,9 @@ func TestDefaultRouter(t *testing.T) {
 		"/test/123/my_schema.my_table.sql.gz":    {"my_schema", "my_table", "", "gz", "sql"},
 		"my_dir/my_schema.my_table.csv.lzo":      {"my_schema", "my_table", "", "lzo", "csv"},
 		"my_schema.my_table.0001.sql.snappy":     {"my_schema", "my_table", "0001", "snappy", "sql"},
+		"my_schema.my_table.0002.avro":           {"my_schema", "my_table", "0002", "", "avro"},
+		"my_schema.my_table.0002.avro.bak":       nil,
+		"my_schema.my_table.0002.orc":            {"my_schema", "my_table", "0002", "", "orc"},
 	}
 	for path, fields := range inputOutputMap {
 		res, err := r.Route(path)
@@ -324,3 +327,17 @@ func TestRouteWithCompressedParquet(t *testing.T) {
 	_, err = router.Route(fileName)
 	require.Error(t, err)
 }
+
+func TestRouteWithCompressedAvro(t *testing.T) {
+	router, err := NewFileRouter(defaultFileRouteRules, log.L())
+	require.NoError(t, err)
+	_, err = router.Route("myschema.my_table.000.avro.gz")
+	require.ErrorContains(t, err, "can't support whole compressed avro file")
+}
+
+func TestRouteWithCompressedORC(t *testing.T) {
+	router, err := NewFileRouter(defaultFileRouteRules, log.L())
+	require.NoError(t, err)
+	_, err = router.Route("myschema.my_table.000.orc.gz")
+	require.ErrorContains(t, err, "can't support whole compressed orc file")
+}
@@ -96,6 +96,7 @@ var (
 	mMetaDataLock        = []byte("metadataLock")
 	mSchemaCacheSize     = []byte("SchemaCacheSize")
 	mRequestUnitStats    = []byte("RequestUnitStats")
+	mRestoreDDLGates     = []byte("RestoreDDLGates")
 	// the id for 'default' group, the internal ddl can ensure
 	// user created resource group won't duplicate with this id.
 	defaultGroupID = int64(1)
@@ -730,6 +731,45 @@ func (m *Mutator) ClearBDRRole() error {
 	return errors.Trace(m.txn.Clear(mBDRRole))
 }
 
+// RestoreDDLGate keeps the DDL jobs involving the tables matched by Filters paused while a log restore
+// applies the meta kv entries. It's ignored after ExpireTime, so a crashed restore doesn't block DDL forever.
+type RestoreDDLGate struct {
+	Task       string    `json:"task"`
+	Filters    []string  `json:"filters"`
+	ExpireTime time.Time `json:"expire-time"`
+}
+
+// SetRestoreDDLGate write the DDL gate of a restore task into storage.
+func (m *Mutator) SetRestoreDDLGate(gate *RestoreDDLGate) error {
+	data, err := json.Marshal(gate)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(m.txn.HSet(mRestoreDDLGates, []byte(gate.Task), data))
+}
+
+// DelRestoreDDLGate delete the DDL gate of a restore task from storage.
+func (m *Mutator) DelRestoreDDLGate(task string) error {
+	return errors.Trace(m.txn.HDel(mRestoreDDLGates, []byte(task)))
+}
+
+// ListRestoreDDLGates get the DDL gates of all the restore tasks, including the expired ones.
+func (m *Mutator) ListRestoreDDLGates() ([]*RestoreDDLGate, error) {
+	res, err := m.txn.HGetAll(mRestoreDDLGates)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	gates := make([]*RestoreDDLGate, 0, len(res))
+	for _, r := range res {
+		gate := &RestoreDDLGate{}
+		if err := json.Unmarshal(r.Value, gate); err != nil {
+			return nil, errors.Trace(err)
+		}
+		gates = append(gates, gate)
+	}
+	return gates, nil
+}
+
 // SetDDLTables write a key into storage.
 func (m *Mutator) SetDDLTables(ddlTableVersion DDLTableVersion) error {
 	return errors.Trace(m.txn.Set(mDDLTableVersion, ddlTableVersion.Bytes()))
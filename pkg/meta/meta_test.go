@@ -437,6 +437,18 @@ func TestMeta(t *testing.T) {
 	require.NoError(t, err)
 	require.Len(t, role, 0)
 
+	// Test for restore DDL gates
+	gates, err := m.ListRestoreDDLGates()
+	require.NoError(t, err)
+	require.Empty(t, gates)
+	gate := &meta.RestoreDDLGate{Task: "restore-1", Filters: []string{"db.*"}, ExpireTime: time.Unix(100, 0).UTC()}
+	require.NoError(t, m.SetRestoreDDLGate(gate))
+	require.NoError(t, m.SetRestoreDDLGate(&meta.RestoreDDLGate{Task: "restore-2"}))
+	require.NoError(t, m.DelRestoreDDLGate("restore-2"))
+	gates, err = m.ListRestoreDDLGates()
+	require.NoError(t, err)
+	require.Equal(t, []*meta.RestoreDDLGate{gate}, gates)
+
 	err = txn.Commit(context.Background())
 	require.NoError(t, err)
 
@@ -624,6 +624,11 @@ func (job *Job) IsPausedBySystem() bool {
 	return job.IsPaused() && job.AdminOperator == AdminCommandBySystem
 }
 
+// IsPausedByRestore returns whether the job is paused by the DDL gate of a restore.
+func (job *Job) IsPausedByRestore() bool {
+	return job.IsPaused() && job.AdminOperator == AdminCommandByRestore
+}
+
 // IsPausing indicates whether the job is pausing.
 func (job *Job) IsPausing() bool {
 	return job.State == JobStatePausing
@@ -1074,6 +1079,9 @@ const (
 	// AdminCommandBySystem indicates that the Cancel/Pause/Resume command on
 	// DDL job is issued by TiDB itself, such as Upgrade(bootstrap).
 	AdminCommandBySystem
+	// AdminCommandByRestore indicates that the Pause/Resume command on DDL job
+	// is issued by the DDL gate of a log restore.
+	AdminCommandByRestore
 )
 
 // String implements fmt.Stringer interface.
@@ -1083,6 +1091,8 @@ func (a *AdminCommandOperator) String() string {
 		return "EndUser"
 	case AdminCommandBySystem:
 		return "System"
+	case AdminCommandByRestore:
+		return "Restore"
 	default:
 		return "None"
 	}
@@ -3484,6 +3484,7 @@ const (
 	BRIEOptionRestoredTS
 	BRIEOptionWaitTiflashReady
 	BRIEOptionWithSysTable
+	BRIEOptionAllowCrossCluster
 	// import options
 	BRIEOptionAnalyze
 	BRIEOptionBackend
@@ -3611,6 +3612,8 @@ func (kind BRIEOptionType) String() string {
 		return "IGNORE_STATS"
 	case BRIEOptionLoadStats:
 		return "LOAD_STATS"
+	case BRIEOptionAllowCrossCluster:
+		return "ALLOW_CROSS_CLUSTER"
 	case BRIEOptionChecksumConcurrency:
 		return "CHECKSUM_CONCURRENCY"
 	case BRIEOptionCompressionLevel:
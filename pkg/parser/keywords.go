@@ -264,6 +264,7 @@ var Keywords = []KeywordsType{
 	{"AGAINST", false, "unreserved"},
 	{"AGO", false, "unreserved"},
 	{"ALGORITHM", false, "unreserved"},
+	{"ALLOW_CROSS_CLUSTER", false, "unreserved"},
 	{"ALWAYS", false, "unreserved"},
 	{"ANY", false, "unreserved"},
 	{"APPLY", false, "unreserved"},
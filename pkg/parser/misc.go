@@ -292,6 +292,7 @@ var tokenMap = map[string]int{
 	"WITH_SYS_TABLE":           withSysTable,
 	"IGNORE_STATS":             ignoreStats,
 	"LOAD_STATS":               loadStats,
+	"ALLOW_CROSS_CLUSTER":      allowCrossCluster,
 	"CHECKSUM_CONCURRENCY":     checksumConcurrency,
 	"COMPRESSION_LEVEL":        compressionLevel,
 	"COMPRESSION_TYPE":         compressionType,
@@ -317,6 +317,7 @@ import (
 	against               "AGAINST"
 	ago                   "AGO"
 	algorithm             "ALGORITHM"
+	allowCrossCluster     "ALLOW_CROSS_CLUSTER"
 	always                "ALWAYS"
 	any                   "ANY"
 	apply                 "APPLY"
@@ -5787,6 +5788,10 @@ BRIEBooleanOptionName:
 	{
 		$$ = ast.BRIEOptionLoadStats
 	}
+|	"ALLOW_CROSS_CLUSTER"
+	{
+		$$ = ast.BRIEOptionAllowCrossCluster
+	}
 
 BRIEStringOptionName:
 	"TIKV_IMPORTER"
@@ -7134,6 +7139,7 @@ UnReservedKeyword:
 |	"WAIT_TIFLASH_READY"
 |	"IGNORE_STATS"
 |	"LOAD_STATS"
+|	"ALLOW_CROSS_CLUSTER"
 |	"CHECKSUM_CONCURRENCY"
 |	"COMPRESSION_LEVEL"
 |	"COMPRESSION_TYPE"
@@ -74,6 +74,14 @@ func WithSecurity(s config.Security) Option {
 	}
 }
 
+// WithTiKVSecurity changes the config.Security of the connections to TiKV, the one set by WithSecurity is
+// used if it isn't set.
+func WithTiKVSecurity(s config.Security) Option {
+	return func(c *TiKVDriver) {
+		c.tikvSecurity = &s
+	}
+}
+
 // WithTiKVClientConfig changes the config.TiKVClient used by tikv driver.
 func WithTiKVClientConfig(client config.TiKVClient) Option {
 	return func(c *TiKVDriver) {
@@ -103,6 +111,7 @@ func getKVStore(path string, tls config.Security) (kv.Storage, error) {
 type TiKVDriver struct {
 	pdConfig        config.PDClient
 	security        config.Security
+	tikvSecurity    *config.Security
 	tikvConfig      config.TiKVClient
 	txnLocalLatches config.TxnLocalLatches
 }
@@ -212,8 +221,12 @@ func (d TiKVDriver) OpenWithOptions(path string, options ...Option) (resStore kv
 
 	codec := pdClient.GetCodec()
 
+	tikvSecurity := d.security
+	if d.tikvSecurity != nil {
+		tikvSecurity = *d.tikvSecurity
+	}
 	rpcClient := tikv.NewRPCClient(
-		tikv.WithSecurity(d.security),
+		tikv.WithSecurity(tikvSecurity),
 		tikv.WithCodec(codec),
 	)
 